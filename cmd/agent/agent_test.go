@@ -3,6 +3,9 @@ package main
 import (
 	"strings"
 	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
 // TestAddressProtocolStripping tests that protocols are correctly stripped
@@ -187,6 +190,44 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+// closingStream records that the agent closed its side of the stream
+type closingStream struct {
+	pb.Commander_ConnectClient
+	closed bool
+}
+
+func (c *closingStream) CloseSend() error {
+	c.closed = true
+	return nil
+}
+
+// TestStreamSyncDrain tests that a drain closes the stream and delays the reconnect
+func TestStreamSyncDrain(t *testing.T) {
+	ss := &StreamSync{}
+	stream := &closingStream{}
+	ss.SetStream(stream)
+
+	ss.Drain(time.Minute)
+	if !stream.closed || ss.GetStream() != nil {
+		t.Errorf("Drain did not close the stream")
+	}
+	if wait := ss.DrainWait(); wait <= 50*time.Second || wait > time.Minute {
+		t.Errorf("DrainWait() = %v, want about a minute", wait)
+	}
+
+	// The receiver of the drained stream must not clear a newer one
+	newer := &closingStream{}
+	ss.SetStream(newer)
+	ss.ClearStream(stream)
+	if ss.GetStream() != newer {
+		t.Errorf("ClearStream cleared the newer stream")
+	}
+	ss.ClearStream(newer)
+	if ss.GetStream() != nil {
+		t.Errorf("ClearStream did not clear the stream")
+	}
+}
+
 // BenchmarkAddressParsing benchmarks address parsing
 func BenchmarkAddressParsing(b *testing.B) {
 	input := "gw1.example.com:5020, gw2.example.com:5020, gw3.example.com:5020"
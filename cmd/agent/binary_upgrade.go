@@ -0,0 +1,445 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// updateLog shares the level of the updater package
+var updateLog = logging.NewLogger("updater")
+
+const (
+	defaultUpgradeStepTimeout = 30 * time.Second
+	upgradePollInterval       = 250 * time.Millisecond
+	// upgradeBackupSuffix is the copy of the replaced binary kept next to it for rollback
+	upgradeBackupSuffix = ".avika-prev"
+)
+
+var nginxVersionPattern = regexp.MustCompile(`nginx/(\S+)`)
+
+// upgradeMu serializes binary upgrades: two at once would signal each other's masters
+var upgradeMu sync.Mutex
+
+type nginxProc struct {
+	pid     int
+	cmdline string
+}
+
+// binaryUpgrade performs nginx's on-the-fly binary upgrade: USR2 makes the old master start a
+// new master from the binary on disk, WINCH stops the old workers and QUIT the old master. Each
+// step is verified; until the old master quits, a failure puts the old master back in charge.
+// Host access goes through the func fields so tests can fake the process table.
+type binaryUpgrade struct {
+	oldPID          int
+	binary          string // the running binary, which the master re-executes on USR2
+	newBinary       string // installed over binary first; empty when it was replaced already
+	expectedVersion string
+	timeout         time.Duration
+	pollEvery       time.Duration
+
+	signal     func(pid int, sig string) error
+	children   func(pid int) []nginxProc
+	alive      func(pid int) bool
+	version    func(binary string) (string, error)
+	testConfig func(binary string) error
+	install    func(src, dst string) error // keeps dst+upgradeBackupSuffix
+	restore    func(dst string) error
+	health     func() error
+
+	resp      *pb.BinaryUpgradeResponse
+	installed bool
+}
+
+func (u *binaryUpgrade) run() *pb.BinaryUpgradeResponse {
+	u.resp = &pb.BinaryUpgradeResponse{OldPid: int32(u.oldPID)}
+	u.resp.OldVersion, _ = u.version(procExe(u.oldPID))
+
+	candidate := firstOr(u.newBinary, u.binary)
+	if !u.step("preflight", func() (string, error) {
+		if !u.alive(u.oldPID) {
+			return "", fmt.Errorf("master %d is not running", u.oldPID)
+		}
+		if pid := u.newMaster(); pid != 0 {
+			return "", fmt.Errorf("master %d already has a new master %d: an upgrade is in progress", u.oldPID, pid)
+		}
+		if err := u.testConfig(candidate); err != nil {
+			return "", err
+		}
+		v, err := u.version(candidate)
+		if err != nil {
+			return "", err
+		}
+		if u.expectedVersion != "" && v != u.expectedVersion {
+			return "", fmt.Errorf("%s is nginx %s, expected %s", candidate, v, u.expectedVersion)
+		}
+		return fmt.Sprintf("%s: nginx %s, configuration ok", candidate, v), nil
+	}) {
+		return u.resp
+	}
+
+	if u.newBinary != "" && u.newBinary != u.binary {
+		if !u.step("install", func() (string, error) {
+			if err := u.install(u.newBinary, u.binary); err != nil {
+				return "", err
+			}
+			u.installed = true
+			return fmt.Sprintf("%s installed as %s, previous binary kept as %s%s", u.newBinary, u.binary, u.binary, upgradeBackupSuffix), nil
+		}) {
+			return u.resp
+		}
+	}
+
+	var newPID int
+	if !u.step("usr2", func() (string, error) {
+		if err := u.signal(u.oldPID, "USR2"); err != nil {
+			return "", err
+		}
+		if !u.waitFor(func() bool { newPID = u.newMaster(); return newPID != 0 }) {
+			return "", fmt.Errorf("no new master started within %s; see the error log", u.timeout)
+		}
+		u.resp.NewPid = int32(newPID)
+		return fmt.Sprintf("new master %d started", newPID), nil
+	}) {
+		u.rollback(0, false)
+		return u.resp
+	}
+
+	if !u.step("verify", func() (string, error) {
+		if !u.waitFor(func() bool { return countWorkers(u.children(newPID)) > 0 }) {
+			return "", fmt.Errorf("new master %d started no workers within %s", newPID, u.timeout)
+		}
+		v, err := u.version(procExe(newPID))
+		if err != nil {
+			return "", err
+		}
+		u.resp.NewVersion = v
+		if u.expectedVersion != "" && v != u.expectedVersion {
+			return "", fmt.Errorf("new master runs nginx %s, expected %s", v, u.expectedVersion)
+		}
+		if err := u.health(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("nginx %s with %d workers", v, countWorkers(u.children(newPID))), nil
+	}) {
+		u.rollback(newPID, false)
+		return u.resp
+	}
+
+	if !u.step("winch", func() (string, error) {
+		if err := u.signal(u.oldPID, "WINCH"); err != nil {
+			return "", err
+		}
+		if !u.waitFor(func() bool { return countWorkers(u.children(u.oldPID)) == 0 }) {
+			return "", fmt.Errorf("old workers still running after %s", u.timeout)
+		}
+		// The new workers now serve all traffic
+		if err := u.health(); err != nil {
+			return "", err
+		}
+		return "old workers stopped gracefully", nil
+	}) {
+		u.rollback(newPID, true)
+		return u.resp
+	}
+
+	// Past this point the old master is gone and there is nothing to roll back to
+	u.step("quit", func() (string, error) {
+		if err := u.signal(u.oldPID, "QUIT"); err != nil {
+			return "", err
+		}
+		if !u.waitFor(func() bool { return !u.alive(u.oldPID) }) {
+			return "", fmt.Errorf("old master %d still running after %s; it has no workers left", u.oldPID, u.timeout)
+		}
+		return fmt.Sprintf("old master %d exited", u.oldPID), nil
+	})
+	u.resp.Success = true
+	u.resp.Error = ""
+	return u.resp
+}
+
+// rollback returns control to the old master: its workers are restarted if WINCH stopped them,
+// the new master quits and the previous binary is restored
+func (u *binaryUpgrade) rollback(newPID int, winched bool) {
+	u.resp.RolledBack = u.step("rollback", func() (string, error) {
+		var done []string
+		if winched {
+			// HUP starts workers again without re-reading the binary
+			if err := u.signal(u.oldPID, "HUP"); err != nil {
+				return "", err
+			}
+			if !u.waitFor(func() bool { return countWorkers(u.children(u.oldPID)) > 0 }) {
+				return "", fmt.Errorf("old master %d started no workers after HUP", u.oldPID)
+			}
+			done = append(done, "old workers restarted")
+		}
+		if newPID == 0 {
+			newPID = u.newMaster() // started after the timeout
+		}
+		if newPID != 0 {
+			if err := u.signal(newPID, "QUIT"); err != nil {
+				return "", err
+			}
+			if !u.waitFor(func() bool { return !u.alive(newPID) }) {
+				return "", fmt.Errorf("new master %d still running after QUIT", newPID)
+			}
+			done = append(done, fmt.Sprintf("new master %d stopped", newPID))
+		}
+		if u.installed {
+			if err := u.restore(u.binary); err != nil {
+				return "", err
+			}
+			done = append(done, "previous binary restored")
+		}
+		return strings.Join(append(done, fmt.Sprintf("master %d serving", u.oldPID)), ", "), nil
+	})
+}
+
+// step runs fn as a named step and records its outcome; the first failed step is the error
+func (u *binaryUpgrade) step(name string, fn func() (string, error)) bool {
+	start := time.Now()
+	detail, err := fn()
+	s := &pb.BinaryUpgradeStep{Name: name, Ok: err == nil, Detail: detail, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		s.Detail = err.Error()
+		if u.resp.Error == "" {
+			u.resp.Error = fmt.Sprintf("%s: %v", name, err)
+		}
+	}
+	u.resp.Steps = append(u.resp.Steps, s)
+	return err == nil
+}
+
+func (u *binaryUpgrade) waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(u.timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(u.pollEvery)
+	}
+}
+
+// newMaster returns the master the old master started on USR2, 0 when there is none
+func (u *binaryUpgrade) newMaster() int {
+	for _, p := range u.children(u.oldPID) {
+		if strings.Contains(p.cmdline, "master process") {
+			return p.pid
+		}
+	}
+	return 0
+}
+
+func countWorkers(procs []nginxProc) int {
+	n := 0
+	for _, p := range procs {
+		if strings.Contains(p.cmdline, "worker process") {
+			n++
+		}
+	}
+	return n
+}
+
+func procExe(pid int) string {
+	return fmt.Sprintf("/proc/%d/exe", pid)
+}
+
+// procChildren lists the children of pid from /proc
+func procChildren(pid int) []nginxProc {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var out []nginxProc
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", child))
+		if err != nil {
+			continue
+		}
+		// pid (comm) state ppid ...; comm may contain spaces and parentheses
+		i := strings.LastIndexByte(string(stat), ')')
+		fields := strings.Fields(string(stat[i+1:]))
+		if i < 0 || len(fields) < 2 || fields[1] != strconv.Itoa(pid) {
+			continue
+		}
+		cmdline, _ := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", child))
+		out = append(out, nginxProc{pid: child, cmdline: strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))})
+	}
+	return out
+}
+
+// privileged runs a command, through sudo -n when the agent is not root
+func privileged(name string, args ...string) ([]byte, error) {
+	if os.Geteuid() == 0 {
+		return exec.Command(name, args...).CombinedOutput()
+	}
+	return exec.Command("sudo", append([]string{"-n", name}, args...)...).CombinedOutput()
+}
+
+func nginxBinaryVersion(binary string) (string, error) {
+	out, err := privileged(binary, "-v")
+	if err != nil {
+		return "", fmt.Errorf("%s -v: %v: %s", binary, err, strings.TrimSpace(string(out)))
+	}
+	m := nginxVersionPattern.FindStringSubmatch(string(out))
+	if m == nil {
+		return "", fmt.Errorf("%s -v printed no version: %s", binary, strings.TrimSpace(string(out)))
+	}
+	return m[1], nil
+}
+
+// installBinary copies src over dst, keeping dst as dst+upgradeBackupSuffix. The rename keeps
+// the running master's binary intact.
+func installBinary(src, dst string) error {
+	if err := copyFile(dst, dst+upgradeBackupSuffix); err != nil {
+		return fmt.Errorf("backing up %s: %w", dst, err)
+	}
+	tmp := dst + ".avika-new"
+	if err := copyFile(src, tmp); err != nil {
+		return fmt.Errorf("copying %s: %w", src, err)
+	}
+	return os.Rename(tmp, dst)
+}
+
+func restoreBinary(dst string) error {
+	tmp := dst + ".avika-new"
+	if err := copyFile(dst+upgradeBackupSuffix, tmp); err != nil {
+		return fmt.Errorf("restoring %s: %w", dst, err)
+	}
+	return os.Rename(tmp, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// statusCheck returns a health check requesting the instance's status URL; without one only the
+// workers are checked
+func statusCheck(url string) func() error {
+	if url == "" {
+		return func() error { return nil }
+	}
+	return func() error {
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(url)
+		if err != nil {
+			return fmt.Errorf("health check %s: %w", url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("health check %s: HTTP %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// UpgradeNginxBinary replaces the running nginx binary of an instance without dropping
+// connections and rolls back when a step fails
+func (s *mgmtServer) UpgradeNginxBinary(ctx context.Context, req *pb.BinaryUpgradeRequest) (*pb.BinaryUpgradeResponse, error) {
+	if !upgradeMu.TryLock() {
+		return &pb.BinaryUpgradeResponse{Error: "another binary upgrade is in progress"}, nil
+	}
+	defer upgradeMu.Unlock()
+
+	inst, ok := nginxInstances.get(firstOr(req.NginxInstanceId, nginxInstances.primaryID()))
+	if !ok {
+		return &pb.BinaryUpgradeResponse{Error: fmt.Sprintf("unknown NGINX instance %q", req.NginxInstanceId)}, nil
+	}
+	pid, _ := strconv.Atoi(inst.Pid)
+	binary := strings.TrimSuffix(inst.BinaryPath, " (deleted)")
+	if pid <= 0 || !filepath.IsAbs(binary) {
+		return &pb.BinaryUpgradeResponse{Error: fmt.Sprintf("master process or binary of NGINX instance %s is unknown", inst.InstanceId)}, nil
+	}
+	if req.BinaryPath != "" && !filepath.IsAbs(req.BinaryPath) {
+		return &pb.BinaryUpgradeResponse{Error: "binary_path must be absolute"}, nil
+	}
+
+	confPath, url := inst.ConfPath, parseInstanceStatusURLs(*instanceStatusURLs)[inst.InstanceId]
+	if inst.Primary {
+		runtimeMu.RLock()
+		confPath, url = s.configPath, *nginxStatusURL
+		runtimeMu.RUnlock()
+	}
+	timeout := defaultUpgradeStepTimeout
+	if req.TimeoutSec > 0 {
+		timeout = time.Duration(req.TimeoutSec) * time.Second
+	}
+
+	u := &binaryUpgrade{
+		oldPID:          pid,
+		binary:          binary,
+		newBinary:       req.BinaryPath,
+		expectedVersion: req.ExpectedVersion,
+		timeout:         timeout,
+		pollEvery:       upgradePollInterval,
+		signal: func(pid int, sig string) error {
+			if out, err := privileged("kill", "-s", sig, strconv.Itoa(pid)); err != nil {
+				return fmt.Errorf("kill -%s %d: %s", sig, pid, strings.TrimSpace(string(out)))
+			}
+			return nil
+		},
+		children: procChildren,
+		alive: func(pid int) bool {
+			_, err := os.Stat(fmt.Sprintf("/proc/%d", pid))
+			return err == nil
+		},
+		version: nginxBinaryVersion,
+		testConfig: func(binary string) error {
+			args := []string{"-t", "-c", confPath}
+			if inst.Prefix != "" {
+				args = append(args, "-p", inst.Prefix)
+			}
+			if out, err := privileged(binary, args...); err != nil {
+				return fmt.Errorf("config test with %s failed: %s", binary, strings.TrimSpace(string(out)))
+			}
+			return nil
+		},
+		install: installBinary,
+		restore: restoreBinary,
+		health:  statusCheck(url),
+	}
+	updateLog.Info().Msgf("Upgrading the NGINX binary of instance %s (master %d)", inst.InstanceId, pid)
+	resp := u.run()
+	if resp.Success {
+		updateLog.Info().Msgf("NGINX binary upgrade of instance %s done: %s -> %s, master %d", inst.InstanceId, resp.OldVersion, resp.NewVersion, resp.NewPid)
+	} else {
+		updateLog.Warn().Msgf("NGINX binary upgrade of instance %s failed (rolled back: %t): %s", inst.InstanceId, resp.RolledBack, resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNginx is a process table reacting to the upgrade signals like nginx does
+type fakeNginx struct {
+	procs      map[int]nginxProc
+	parents    map[int]int
+	next       int
+	startNew   bool // USR2 starts a new master with workers
+	healthErr  error
+	signals    []string
+	restored   bool
+	newVersion string
+}
+
+func newFakeNginx() *fakeNginx {
+	f := &fakeNginx{procs: map[int]nginxProc{}, parents: map[int]int{}, next: 200, startNew: true, newVersion: "1.27.1"}
+	f.spawn(100, 1, "nginx: master process /usr/sbin/nginx")
+	f.spawn(101, 100, "nginx: worker process")
+	return f
+}
+
+func (f *fakeNginx) spawn(pid, ppid int, cmdline string) {
+	f.procs[pid] = nginxProc{pid: pid, cmdline: cmdline}
+	f.parents[pid] = ppid
+}
+
+func (f *fakeNginx) signal(pid int, sig string) error {
+	f.signals = append(f.signals, sig+" "+map[bool]string{true: "old", false: "new"}[pid == 100])
+	switch sig {
+	case "USR2":
+		if f.startNew {
+			f.spawn(f.next, pid, "nginx: master process /usr/sbin/nginx")
+			f.spawn(f.next+1, f.next, "nginx: worker process")
+		}
+	case "WINCH", "QUIT":
+		for child, parent := range f.parents {
+			if parent == pid && (sig == "QUIT" || strings.Contains(f.procs[child].cmdline, "worker")) {
+				delete(f.procs, child)
+				delete(f.parents, child)
+			}
+		}
+		if sig == "QUIT" {
+			delete(f.procs, pid)
+			delete(f.parents, pid)
+		}
+	case "HUP":
+		f.spawn(102, pid, "nginx: worker process")
+	}
+	return nil
+}
+
+func (f *fakeNginx) upgrade() *binaryUpgrade {
+	return &binaryUpgrade{
+		oldPID:    100,
+		binary:    "/usr/sbin/nginx",
+		newBinary: "/tmp/nginx-1.27.1",
+		timeout:   50 * time.Millisecond,
+		pollEvery: time.Millisecond,
+		signal:    f.signal,
+		children: func(pid int) []nginxProc {
+			var out []nginxProc
+			for child, parent := range f.parents {
+				if parent == pid {
+					out = append(out, f.procs[child])
+				}
+			}
+			return out
+		},
+		alive: func(pid int) bool { _, ok := f.procs[pid]; return ok },
+		version: func(binary string) (string, error) {
+			if binary == "/proc/100/exe" {
+				return "1.26.2", nil
+			}
+			return f.newVersion, nil
+		},
+		testConfig: func(string) error { return nil },
+		install:    func(src, dst string) error { return nil },
+		restore:    func(string) error { f.restored = true; return nil },
+		health:     func() error { return f.healthErr },
+	}
+}
+
+func stepNames(u *binaryUpgrade) string {
+	var names []string
+	for _, s := range u.resp.Steps {
+		names = append(names, s.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestBinaryUpgrade(t *testing.T) {
+	f := newFakeNginx()
+	u := f.upgrade()
+	u.expectedVersion = "1.27.1"
+	resp := u.run()
+	if !resp.Success || resp.Error != "" || resp.NewPid != 200 || resp.OldVersion != "1.26.2" || resp.NewVersion != "1.27.1" {
+		t.Fatalf("response = %+v", resp)
+	}
+	if got := stepNames(u); got != "preflight,install,usr2,verify,winch,quit" {
+		t.Errorf("steps = %s", got)
+	}
+	if got := strings.Join(f.signals, ","); got != "USR2 old,WINCH old,QUIT old" {
+		t.Errorf("signals = %s", got)
+	}
+	if _, ok := f.procs[100]; ok {
+		t.Error("old master still running")
+	}
+}
+
+func TestBinaryUpgradeRollback(t *testing.T) {
+	// The new workers fail the health check once the old ones are gone
+	f := newFakeNginx()
+	u := f.upgrade()
+	u.health = func() error {
+		if len(f.signals) > 1 {
+			return errors.New("HTTP 502")
+		}
+		return nil
+	}
+	resp := u.run()
+	if resp.Success || !resp.RolledBack || !strings.HasPrefix(resp.Error, "winch: ") || !f.restored {
+		t.Fatalf("response = %+v", resp)
+	}
+	if got := strings.Join(f.signals, ","); got != "USR2 old,WINCH old,HUP old,QUIT new" {
+		t.Errorf("signals = %s", got)
+	}
+	if _, ok := f.procs[200]; ok || countWorkers(u.children(100)) != 1 {
+		t.Error("old master should serve again without the new master")
+	}
+
+	// The new binary is another version than expected
+	f = newFakeNginx()
+	u = f.upgrade()
+	u.expectedVersion = "1.27.1"
+	f.newVersion = "1.25.0"
+	if resp := u.run(); resp.Success || len(f.signals) != 0 || stepNames(u) != "preflight" {
+		t.Errorf("response = %+v, signals = %v", resp, f.signals)
+	}
+
+	// No new master starts, e.g. the new binary cannot bind or load a module
+	f = newFakeNginx()
+	f.startNew = false
+	u = f.upgrade()
+	if resp := u.run(); resp.Success || !resp.RolledBack || stepNames(u) != "preflight,install,usr2,rollback" || !f.restored {
+		t.Errorf("response = %+v", resp)
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+var (
+	bufferLog = logging.NewLogger("buffer")
+	// readLog logs every read of the sender loop, sampled
+	readLog = logging.Sampled(bufferLog, 1, 10*time.Second)
 )
 
 const (
@@ -121,9 +128,7 @@ func (b *FileBuffer) ReadNext() ([]byte, int64, error) {
 	if _, err := b.walFile.Seek(b.readOffset, io.SeekStart); err != nil {
 		return nil, 0, err
 	}
-	if b.readOffset%1000 == 0 { // Don't spam too much, but log often enough
-		log.Printf("ReadNext: offset=%d, wal_size=%d", b.readOffset, sz)
-	}
+	readLog.Debug().Int64("offset", b.readOffset).Int64("wal_size", sz).Msg("ReadNext")
 
 	var length uint32
 	if err := binary.Read(b.walFile, binary.LittleEndian, &length); err != nil {
@@ -220,7 +225,7 @@ func (b *FileBuffer) rotationLoop() {
 		select {
 		case <-ticker.C:
 			if err := b.maybeRotate(); err != nil {
-				log.Printf("WAL rotation check error: %v", err)
+				bufferLog.Error().Msgf("WAL rotation check error: %v", err)
 			}
 		case <-b.stopCh:
 			return
@@ -245,7 +250,7 @@ func (b *FileBuffer) maybeRotate() error {
 
 	// Also check if enough data has been read to make compaction worthwhile
 	if b.readOffset == 0 || float64(b.readOffset)/float64(size) < MinCompactionRatio {
-		log.Printf("WAL size %d exceeds max %d but not enough read data to compact (read offset: %d)", size, b.maxWALSize, b.readOffset)
+		bufferLog.Info().Msgf("WAL size %d exceeds max %d but not enough read data to compact (read offset: %d)", size, b.maxWALSize, b.readOffset)
 		return nil
 	}
 
@@ -261,7 +266,7 @@ func (b *FileBuffer) Compact() error {
 }
 
 func (b *FileBuffer) compactLocked() error {
-	log.Printf("Starting WAL compaction (read offset: %d)", b.readOffset)
+	bufferLog.Info().Msgf("Starting WAL compaction (read offset: %d)", b.readOffset)
 
 	// Get current WAL size
 	size, err := b.sizeLocked()
@@ -271,7 +276,7 @@ func (b *FileBuffer) compactLocked() error {
 
 	// If read offset is 0 or near start, nothing to compact
 	if b.readOffset < 1024 {
-		log.Println("Nothing to compact, read offset too small")
+		bufferLog.Info().Msg("Nothing to compact, read offset too small")
 		return nil
 	}
 
@@ -333,7 +338,7 @@ func (b *FileBuffer) compactLocked() error {
 		return fmt.Errorf("failed to sync cursor: %w", err)
 	}
 
-	log.Printf("WAL compaction complete: removed %d bytes, kept %d bytes (was %d)", oldOffset, copied, size)
+	bufferLog.Info().Msgf("WAL compaction complete: removed %d bytes, kept %d bytes (was %d)", oldOffset, copied, size)
 	return nil
 }
 
@@ -0,0 +1,269 @@
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// InstallResult describes where a certificate was written and how to undo it.
+type InstallResult struct {
+	CertPath      string
+	KeyPath       string
+	ChainPath     string
+	FullChainPath string // cert + chain, used for ssl_certificate when a chain is supplied
+
+	// originals holds the previous content of every file touched (nil = file did not exist)
+	originals map[string][]byte
+	modes     map[string]os.FileMode
+}
+
+// ValidateKeyPair checks that the PEM certificate (plus optional chain) matches the private key.
+func ValidateKeyPair(cert, key, chain []byte) error {
+	full := cert
+	if len(chain) > 0 {
+		full = append(append(append([]byte{}, cert...), '\n'), chain...)
+	}
+	if _, err := tls.X509KeyPair(full, key); err != nil {
+		return fmt.Errorf("certificate and key do not match: %w", err)
+	}
+	return nil
+}
+
+// Install writes a certificate, key and optional chain into dir using the <domain>.crt/.key/.chain.crt
+// naming convention. The key is written 0600, certificates 0644; writes are atomic (temp file + rename).
+// When backup is true, existing files are also copied to <file>.bak.<timestamp>.
+func Install(dir, domain string, cert, key, chain []byte, backup bool) (*InstallResult, error) {
+	if strings.ContainsAny(domain, `/\`) || domain == "" || domain == "." || domain == ".." {
+		return nil, fmt.Errorf("invalid domain %q", domain)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	res := &InstallResult{
+		CertPath:  filepath.Join(dir, domain+".crt"),
+		KeyPath:   filepath.Join(dir, domain+".key"),
+		originals: make(map[string][]byte),
+		modes:     make(map[string]os.FileMode),
+	}
+	files := []struct {
+		path string
+		data []byte
+		mode os.FileMode
+	}{
+		{res.CertPath, cert, 0644},
+		{res.KeyPath, key, 0600},
+	}
+	if len(chain) > 0 {
+		res.ChainPath = filepath.Join(dir, domain+".chain.crt")
+		files = append(files, struct {
+			path string
+			data []byte
+			mode os.FileMode
+		}{res.ChainPath, chain, 0644})
+
+		res.FullChainPath = filepath.Join(dir, domain+".fullchain.crt")
+		fullChain := append(append(append([]byte{}, cert...), '\n'), chain...)
+		files = append(files, struct {
+			path string
+			data []byte
+			mode os.FileMode
+		}{res.FullChainPath, fullChain, 0644})
+	}
+
+	stamp := time.Now().Format("20060102-150405")
+	for _, f := range files {
+		if err := res.remember(f.path); err != nil {
+			res.Rollback()
+			return nil, err
+		}
+		if backup && res.originals[f.path] != nil {
+			if err := os.WriteFile(f.path+".bak."+stamp, res.originals[f.path], res.modes[f.path]); err != nil {
+				res.Rollback()
+				return nil, fmt.Errorf("failed to back up %s: %w", f.path, err)
+			}
+		}
+		if err := writeFileAtomic(f.path, f.data, f.mode); err != nil {
+			res.Rollback()
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// UpdateServerBlocks points ssl_certificate/ssl_certificate_key of every server block whose
+// server_name matches domain at the installed files. Returns the config files that changed.
+// Changes are tracked so Rollback also restores the config files.
+func (r *InstallResult) UpdateServerBlocks(configFiles []string, domain string) ([]string, error) {
+	var changed []string
+	for _, path := range configFiles {
+		// sites-enabled entries are usually symlinks; edit the target so the link survives
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		certPath := r.CertPath
+		if r.FullChainPath != "" {
+			certPath = r.FullChainPath
+		}
+		updated, n := rewriteServerCertificates(string(data), domain, certPath, r.KeyPath)
+		if n == 0 {
+			continue
+		}
+		if err := r.remember(path); err != nil {
+			return changed, err
+		}
+		if err := writeFileAtomic(path, []byte(updated), r.modes[path]); err != nil {
+			return changed, err
+		}
+		changed = append(changed, path)
+	}
+	return changed, nil
+}
+
+// Rollback restores every file touched by Install/UpdateServerBlocks to its previous state.
+func (r *InstallResult) Rollback() error {
+	var firstErr error
+	for path, data := range r.originals {
+		var err error
+		if data == nil {
+			err = os.Remove(path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = writeFileAtomic(path, data, r.modes[path])
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *InstallResult) remember(path string) error {
+	if _, seen := r.originals[path]; seen {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.originals[path] = nil
+			r.modes[path] = 0644
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	r.originals[path] = data
+	r.modes[path] = info.Mode().Perm()
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+var (
+	serverNameRe = regexp.MustCompile(`(?m)^\s*server_name\s+([^;]+);`)
+	sslCertRe    = regexp.MustCompile(`(?m)^(\s*ssl_certificate)\s+[^;]+;`)
+	sslKeyRe     = regexp.MustCompile(`(?m)^(\s*ssl_certificate_key)\s+[^;]+;`)
+)
+
+// rewriteServerCertificates rewrites the certificate directives inside matching server blocks.
+// It returns the new content and the number of server blocks updated.
+func rewriteServerCertificates(content, domain, certPath, keyPath string) (string, int) {
+	var out strings.Builder
+	updated := 0
+	rest := content
+	for {
+		start, end := nextServerBlock(rest)
+		if start < 0 {
+			out.WriteString(rest)
+			break
+		}
+		out.WriteString(rest[:start])
+		block := rest[start:end]
+		if serverBlockMatches(block, domain) && sslCertRe.MatchString(block) {
+			block = sslCertRe.ReplaceAllString(block, "${1} "+certPath+";")
+			block = sslKeyRe.ReplaceAllString(block, "${1} "+keyPath+";")
+			updated++
+		}
+		out.WriteString(block)
+		rest = rest[end:]
+	}
+	return out.String(), updated
+}
+
+var serverOpenRe = regexp.MustCompile(`(?m)^\s*server\s*\{`)
+
+// nextServerBlock returns the byte range of the next `server { ... }` block (brace balanced).
+func nextServerBlock(s string) (int, int) {
+	loc := serverOpenRe.FindStringIndex(s)
+	if loc == nil {
+		return -1, -1
+	}
+	depth := 0
+	inComment := false
+	for i := loc[1] - 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case inComment:
+			if c == '\n' {
+				inComment = false
+			}
+		case c == '#':
+			inComment = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return loc[0], i + 1
+			}
+		}
+	}
+	return -1, -1
+}
+
+func serverBlockMatches(block, domain string) bool {
+	for _, m := range serverNameRe.FindAllStringSubmatch(block, -1) {
+		for _, name := range strings.Fields(m[1]) {
+			if name == domain {
+				return true
+			}
+			if strings.HasPrefix(name, "*.") && strings.HasSuffix(domain, name[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package certs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteServerCertificates(t *testing.T) {
+	conf := `http {
+    upstream app {
+        server 10.0.0.1:8080;
+    }
+    server {
+        listen 443 ssl;
+        server_name example.com www.example.com;
+        ssl_certificate /old/example.crt;
+        ssl_certificate_key /old/example.key;
+        location / { proxy_pass http://app; }
+    }
+    server {
+        listen 443 ssl;
+        server_name other.com; # not example.com
+        ssl_certificate /old/other.crt;
+        ssl_certificate_key /old/other.key;
+    }
+}
+`
+	out, n := rewriteServerCertificates(conf, "www.example.com", "/etc/nginx/ssl/www.example.com.crt", "/etc/nginx/ssl/www.example.com.key")
+	if n != 1 {
+		t.Fatalf("expected 1 server block updated, got %d", n)
+	}
+	if !strings.Contains(out, "ssl_certificate /etc/nginx/ssl/www.example.com.crt;") ||
+		!strings.Contains(out, "ssl_certificate_key /etc/nginx/ssl/www.example.com.key;") {
+		t.Errorf("example.com block not rewritten:\n%s", out)
+	}
+	if !strings.Contains(out, "ssl_certificate /old/other.crt;") {
+		t.Errorf("unrelated server block was modified:\n%s", out)
+	}
+	if !strings.Contains(out, "server 10.0.0.1:8080;") {
+		t.Errorf("upstream block was modified:\n%s", out)
+	}
+}
+
+func TestServerBlockMatchesWildcard(t *testing.T) {
+	block := "server {\n    server_name *.example.com;\n}"
+	if !serverBlockMatches(block, "api.example.com") {
+		t.Error("wildcard server_name should match subdomain")
+	}
+	if serverBlockMatches(block, "example.org") {
+		t.Error("wildcard server_name should not match other domain")
+	}
+}
+
+func TestInstallRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "example.com.crt")
+	if err := os.WriteFile(existing, []byte("old-cert"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Install(dir, "example.com", []byte("new-cert"), []byte("new-key"), nil, true)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	info, err := os.Stat(res.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key written with mode %v, want 0600", info.Mode().Perm())
+	}
+	backups, _ := filepath.Glob(existing + ".bak.*")
+	if len(backups) != 1 {
+		t.Errorf("expected one backup of existing cert, got %v", backups)
+	}
+
+	if err := res.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	data, _ := os.ReadFile(existing)
+	if string(data) != "old-cert" {
+		t.Errorf("cert not restored, got %q", data)
+	}
+	if _, err := os.Stat(res.KeyPath); !os.IsNotExist(err) {
+		t.Error("newly created key should be removed on rollback")
+	}
+
+	if _, err := Install(dir, "../etc", []byte("c"), []byte("k"), nil, false); err == nil {
+		t.Error("expected path traversal in domain to be rejected")
+	}
+}
@@ -0,0 +1,158 @@
+// Package collectors runs user-defined metric sources next to the built-in NGINX and system
+// collectors. A collector is either an executable that prints metrics (exec) or an HTTP endpoint
+// serving them (prometheus); both use the Prometheus text format. Exec collectors take the place of
+// Go plugins, which would have to be built with the agent's exact toolchain and dependencies.
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// Collector is a custom metric source
+type Collector interface {
+	Name() string
+	Interval() time.Duration
+	Collect(ctx context.Context) ([]*pb.GenericMetric, error)
+}
+
+const (
+	defaultInterval = 30 * time.Second
+	defaultTimeout  = 10 * time.Second
+	minInterval     = time.Second
+
+	// maxOutputBytes bounds what a single collection may read
+	maxOutputBytes = 4 << 20
+)
+
+// Spec describes a custom collector, read from a collectors.d file
+type Spec struct {
+	Name     string
+	Type     string // exec or prometheus
+	Command  string // exec: run with /bin/sh -c
+	URL      string // prometheus: endpoint to scrape
+	Interval time.Duration
+	Timeout  time.Duration
+	Labels   map[string]string // added to every sample
+}
+
+// New returns the collector a spec describes
+func New(spec Spec) (Collector, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("collector name is required")
+	}
+	if spec.Interval == 0 {
+		spec.Interval = defaultInterval
+	}
+	if spec.Interval < minInterval {
+		return nil, fmt.Errorf("collector %s: interval must be at least %s", spec.Name, minInterval)
+	}
+	if spec.Timeout <= 0 || spec.Timeout > spec.Interval {
+		spec.Timeout = min(defaultTimeout, spec.Interval)
+	}
+	switch spec.Type {
+	case "exec":
+		if spec.Command == "" {
+			return nil, fmt.Errorf("collector %s: COMMAND is required", spec.Name)
+		}
+		return &execCollector{spec: spec}, nil
+	case "prometheus":
+		if !strings.HasPrefix(spec.URL, "http://") && !strings.HasPrefix(spec.URL, "https://") {
+			return nil, fmt.Errorf("collector %s: URL must be http(s)", spec.Name)
+		}
+		return &promCollector{spec: spec, client: &http.Client{Timeout: spec.Timeout}}, nil
+	default:
+		return nil, fmt.Errorf("collector %s: unsupported type %q (exec or prometheus)", spec.Name, spec.Type)
+	}
+}
+
+// execCollector runs a command and parses its standard output
+type execCollector struct {
+	spec Spec
+}
+
+func (c *execCollector) Name() string            { return c.spec.Name }
+func (c *execCollector) Interval() time.Duration { return c.spec.Interval }
+
+func (c *execCollector) Collect(ctx context.Context) ([]*pb.GenericMetric, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.spec.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.spec.Command)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	metrics, parseErr := ParseText(io.LimitReader(stdout, maxOutputBytes), c.spec.Name, c.spec.Labels)
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return metrics, parseErr
+}
+
+// promCollector scrapes an HTTP endpoint serving the Prometheus text format
+type promCollector struct {
+	spec   Spec
+	client *http.Client
+}
+
+func (c *promCollector) Name() string            { return c.spec.Name }
+func (c *promCollector) Interval() time.Duration { return c.spec.Interval }
+
+func (c *promCollector) Collect(ctx context.Context) ([]*pb.GenericMetric, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.spec.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", c.spec.URL, resp.Status)
+	}
+	return ParseText(io.LimitReader(resp.Body, maxOutputBytes), c.spec.Name, c.spec.Labels)
+}
+
+// Run collects from every collector on its own interval until ctx is done, passing each
+// non-empty batch to emit. Failures are reported through onError.
+func Run(ctx context.Context, collectors []Collector, emit func([]*pb.GenericMetric), onError func(name string, err error)) {
+	for _, c := range collectors {
+		go func(c Collector) {
+			ticker := time.NewTicker(c.Interval())
+			defer ticker.Stop()
+			for {
+				metrics, err := c.Collect(ctx)
+				if err != nil && ctx.Err() == nil {
+					onError(c.Name(), err)
+				}
+				if len(metrics) > 0 {
+					emit(metrics)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(c)
+	}
+}
@@ -0,0 +1,119 @@
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleText = `# HELP phpfpm_active_processes Active processes
+# TYPE phpfpm_active_processes gauge
+phpfpm_active_processes{pool="www"} 3
+# TYPE phpfpm_accepted_connections counter
+phpfpm_accepted_connections{pool="www",note="a \"quoted\" \\ value"} 1024 1700000000000
+# TYPE haproxy_latency_seconds histogram
+haproxy_latency_seconds_bucket{le="0.5"} 7
+haproxy_latency_seconds_count 9
+up 1
+broken NaN
+`
+
+func TestParseText(t *testing.T) {
+	metrics, err := ParseText(strings.NewReader(sampleText), "php-fpm", map[string]string{"pool": "default", "host": "web-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 5 {
+		t.Fatalf("got %d samples, want 5 (NaN skipped)", len(metrics))
+	}
+	active := metrics[0]
+	if active.Name != "phpfpm_active_processes" || active.Value != 3 || active.Type != "gauge" || active.Source != "php-fpm" {
+		t.Errorf("active = %+v", active)
+	}
+	if active.Labels["pool"] != "www" || active.Labels["host"] != "web-1" {
+		t.Errorf("sample labels should win over extra labels: %v", active.Labels)
+	}
+	accepted := metrics[1]
+	if accepted.Type != "counter" || accepted.TimestampMs != 1700000000000 || accepted.Labels["note"] != `a "quoted" \ value` {
+		t.Errorf("accepted = %+v", accepted)
+	}
+	if metrics[2].Type != "histogram" || metrics[3].Type != "histogram" || metrics[4].Type != "untyped" {
+		t.Errorf("types = %s %s %s", metrics[2].Type, metrics[3].Type, metrics[4].Type)
+	}
+
+	for _, bad := range []string{"1abc 2", `m{l=unquoted} 1`, `m{l="open} 1`, "m", "m one"} {
+		if _, err := ParseText(strings.NewReader(bad), "x", nil); err == nil {
+			t.Errorf("%q should fail to parse", bad)
+		}
+	}
+}
+
+func TestCollectors(t *testing.T) {
+	exec, err := New(Spec{Name: "script", Type: "exec", Command: "echo 'queue_depth{queue=\"mail\"} 12'", Interval: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := exec.Collect(context.Background())
+	if err != nil || len(metrics) != 1 || metrics[0].Value != 12 || metrics[0].Labels["queue"] != "mail" {
+		t.Errorf("exec = %v, %v", metrics, err)
+	}
+	failing, _ := New(Spec{Name: "fail", Type: "exec", Command: "echo oops >&2; exit 3"})
+	if _, err := failing.Collect(context.Background()); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("failing command: err = %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sampleText))
+	}))
+	defer srv.Close()
+	prom, err := New(Spec{Name: "haproxy", Type: "prometheus", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prom.Interval() != defaultInterval {
+		t.Errorf("interval = %s, want the default", prom.Interval())
+	}
+	if metrics, err := prom.Collect(context.Background()); err != nil || len(metrics) != 5 {
+		t.Errorf("prometheus = %d samples, %v", len(metrics), err)
+	}
+
+	for _, spec := range []Spec{
+		{Type: "exec", Command: "true"},
+		{Name: "x", Type: "plugin"},
+		{Name: "x", Type: "exec"},
+		{Name: "x", Type: "prometheus", URL: "file:///etc/passwd"},
+		{Name: "x", Type: "exec", Command: "true", Interval: time.Millisecond},
+	} {
+		if _, err := New(spec); err == nil {
+			t.Errorf("%+v should be rejected", spec)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "php-fpm.conf"), []byte("# PHP-FPM pools\nTYPE=exec\nCOMMAND=\"/usr/local/bin/fpm-metrics www\"\nINTERVAL=15s\nLABEL_pool=www\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "haproxy.conf"), []byte("NAME=lb\nTYPE=prometheus\nURL=http://127.0.0.1:8405/metrics\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0644)
+
+	specs, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0].Name != "lb" || specs[1].Name != "php-fpm" {
+		t.Fatalf("specs = %+v", specs)
+	}
+	fpm := specs[1]
+	if fpm.Command != "/usr/local/bin/fpm-metrics www" || fpm.Interval != 15*time.Second || fpm.Labels["pool"] != "www" {
+		t.Errorf("php-fpm = %+v", fpm)
+	}
+
+	if specs, err := LoadDir(filepath.Join(dir, "missing")); err != nil || len(specs) != 0 {
+		t.Errorf("missing dir = %v, %v", specs, err)
+	}
+}
@@ -0,0 +1,170 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// maxSamples bounds the samples kept from a single collection
+const maxSamples = 10000
+
+// ParseText parses the Prometheus text exposition format: "# TYPE name kind" comments and
+// "name{label="value",...} value [timestamp_ms]" samples. Non-finite values are skipped and
+// extra labels are added to every sample without overriding its own.
+func ParseText(r io.Reader, source string, extra map[string]string) ([]*pb.GenericMetric, error) {
+	types := map[string]string{}
+	var metrics []*pb.GenericMetric
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if fields := strings.Fields(line); len(fields) == 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		m, err := parseSample(line)
+		if err != nil {
+			return metrics, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+			continue
+		}
+		m.Source = source
+		m.Type = sampleType(types, m.Name)
+		for k, v := range extra {
+			if _, ok := m.Labels[k]; !ok {
+				if m.Labels == nil {
+					m.Labels = map[string]string{}
+				}
+				m.Labels[k] = v
+			}
+		}
+		if len(metrics) >= maxSamples {
+			return metrics, fmt.Errorf("more than %d samples, rest dropped", maxSamples)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, scanner.Err()
+}
+
+// sampleType returns the declared type of a sample, looking through the suffixes summaries and
+// histograms add to their family name
+func sampleType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if t, ok := types[strings.TrimSuffix(name, suffix)]; ok && strings.HasSuffix(name, suffix) {
+			return t
+		}
+	}
+	return "untyped"
+}
+
+func parseSample(line string) (*pb.GenericMetric, error) {
+	m := &pb.GenericMetric{}
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	m.Name = line[:end]
+	if !validMetricName(m.Name) {
+		return nil, fmt.Errorf("invalid metric name %q", m.Name)
+	}
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		labels, n, err := parseLabels(rest)
+		if err != nil {
+			return nil, err
+		}
+		m.Labels = labels
+		rest = rest[n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("expected a value and an optional timestamp")
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[0])
+	}
+	m.Value = v
+	if len(fields) == 2 {
+		if m.TimestampMs, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+	}
+	return m, nil
+}
+
+// parseLabels parses a {name="value",...} block, returning the labels and the bytes consumed
+func parseLabels(s string) (map[string]string, int, error) {
+	labels := map[string]string{}
+	i := 1 // past '{'
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("unterminated labels")
+		}
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return nil, 0, fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, fmt.Errorf("label %s: value must be quoted", name)
+		}
+		i++
+		var value strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("label %s: unterminated value", name)
+		}
+		i++ // past closing quote
+		labels[name] = value.String()
+	}
+}
+
+func validMetricName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
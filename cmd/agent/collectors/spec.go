@@ -0,0 +1,87 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LoadDir reads one collector per *.conf file in dir, in the key=value format of
+// avika-agent.conf:
+//
+//	NAME=php-fpm
+//	TYPE=exec
+//	COMMAND=/usr/local/bin/php-fpm-metrics
+//	INTERVAL=30s
+//	TIMEOUT=10s
+//	LABEL_pool=www
+//
+// TYPE is exec (COMMAND, run with /bin/sh -c) or prometheus (URL). NAME defaults to the file
+// name, LABEL_* keys are added to every sample. A missing dir means no custom collectors.
+func LoadDir(dir string) ([]Spec, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var specs []Spec
+	for _, path := range files {
+		spec, err := loadSpec(path)
+		if err != nil {
+			return specs, fmt.Errorf("%s: %w", path, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func loadSpec(path string) (Spec, error) {
+	spec := Spec{Name: strings.TrimSuffix(filepath.Base(path), ".conf"), Labels: map[string]string{}}
+	f, err := os.Open(path)
+	if err != nil {
+		return spec, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		val := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+
+		switch key {
+		case "NAME":
+			spec.Name = val
+		case "TYPE":
+			spec.Type = strings.ToLower(val)
+		case "COMMAND":
+			spec.Command = val
+		case "URL":
+			spec.URL = val
+		case "INTERVAL":
+			if spec.Interval, err = time.ParseDuration(val); err != nil {
+				return spec, fmt.Errorf("invalid INTERVAL: %w", err)
+			}
+		case "TIMEOUT":
+			if spec.Timeout, err = time.ParseDuration(val); err != nil {
+				return spec, fmt.Errorf("invalid TIMEOUT: %w", err)
+			}
+		default:
+			if name := strings.TrimPrefix(key, "LABEL_"); name != key && name != "" {
+				spec.Labels[name] = val
+			}
+		}
+	}
+	return spec, scanner.Err()
+}
@@ -3,7 +3,6 @@ package config
 import (
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -21,7 +20,7 @@ func BackupNginxConfig(reason string) error {
 	timestamp := time.Now().Format("20060102150405")
 	backupPath := filepath.Join(BackupDir, fmt.Sprintf("%s_%s", timestamp, reason))
 
-	log.Printf("Creating NGINX config backup at %s (reason: %s)", backupPath, reason)
+	configLog.Info().Msgf("Creating NGINX config backup at %s (reason: %s)", backupPath, reason)
 
 	if err := os.MkdirAll(backupPath, 0755); err != nil {
 		return fmt.Errorf("failed to create backup directory: %w", err)
@@ -62,9 +61,9 @@ func EnforceRetention() error {
 	toRemove := len(backups) - MaxBackups
 	for i := 0; i < toRemove; i++ {
 		path := filepath.Join(BackupDir, backups[i].Name())
-		log.Printf("Removing old backup: %s", path)
+		configLog.Info().Msgf("Removing old backup: %s", path)
 		if err := os.RemoveAll(path); err != nil {
-			log.Printf("Warning: failed to remove old backup %s: %v", path, err)
+			configLog.Warn().Msgf("Failed to remove old backup %s: %v", path, err)
 		}
 	}
 
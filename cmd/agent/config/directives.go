@@ -0,0 +1,406 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// token is a lexical unit of an NGINX config file.
+type token struct {
+	value  string
+	line   int
+	quoted bool
+}
+
+func (t token) is(s string) bool {
+	return !t.quoted && t.value == s
+}
+
+// lex splits NGINX config text into words, quoted strings, comments and the ; { } punctuation.
+// Comments are returned as a single token starting with '#'.
+func lex(data string) []token {
+	var tokens []token
+	line := 1
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			end := strings.IndexByte(data[i:], '\n')
+			if end < 0 {
+				end = len(data) - i
+			}
+			tokens = append(tokens, token{value: strings.TrimRight(data[i:i+end], "\r"), line: line})
+			i += end
+		case c == ';' || c == '{' || c == '}':
+			tokens = append(tokens, token{value: string(c), line: line})
+			i++
+		case c == '"' || c == '\'':
+			start := line
+			var b strings.Builder
+			i++
+			for i < len(data) && data[i] != c {
+				if data[i] == '\\' && i+1 < len(data) && data[i+1] == c {
+					i++
+				} else if data[i] == '\n' {
+					line++
+				}
+				b.WriteByte(data[i])
+				i++
+			}
+			i++ // closing quote
+			tokens = append(tokens, token{value: b.String(), line: start, quoted: true})
+		default:
+			var b strings.Builder
+			for i < len(data) {
+				c := data[i]
+				if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == '}' {
+					break
+				}
+				if c == '{' {
+					// ${var} is part of the word, a bare { opens a block
+					if b.Len() == 0 || data[i-1] != '$' {
+						break
+					}
+					end := strings.IndexByte(data[i:], '}')
+					if end < 0 {
+						end = len(data) - i - 1
+					}
+					b.WriteString(data[i : i+end+1])
+					i += end + 1
+					continue
+				}
+				if c == '\\' && i+1 < len(data) {
+					b.WriteByte(c)
+					i++
+					c = data[i]
+				}
+				b.WriteByte(c)
+				i++
+			}
+			tokens = append(tokens, token{value: b.String(), line: line})
+		}
+	}
+	return tokens
+}
+
+// treeParser parses a config file and every file it includes into a flat file list.
+type treeParser struct {
+	baseDir string
+	files   []*pb.ConfigFile
+	index   map[string]int
+}
+
+// ParseTree parses the config at path, following include directives, into a directive tree.
+// The main file is always files[0]. Syntax errors are reported per file in ConfigFile.Errors;
+// an error is only returned when the main file cannot be read.
+func ParseTree(path string) ([]*pb.ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	p := &treeParser{baseDir: filepath.Dir(path), index: make(map[string]int)}
+	p.parseFile(path, string(data))
+	return p.files, nil
+}
+
+// ParseString parses config text without following includes.
+func ParseString(name, content string) *pb.ConfigFile {
+	p := &treeParser{baseDir: filepath.Dir(name), index: map[string]int{name: 0}}
+	file := &pb.ConfigFile{File: name}
+	p.files = []*pb.ConfigFile{file}
+	tokens := lex(content)
+	file.Parsed, _ = p.parseBlock(file, tokens, 0, 0, false)
+	return file
+}
+
+func (p *treeParser) parseFile(path, content string) int {
+	idx := len(p.files)
+	file := &pb.ConfigFile{File: path}
+	p.files = append(p.files, file)
+	p.index[path] = idx
+
+	tokens := lex(content)
+	file.Parsed, _ = p.parseBlock(file, tokens, 0, 0, true)
+	return idx
+}
+
+// parseBlock parses directives until the matching } (or EOF at depth 0) and returns the next position.
+func (p *treeParser) parseBlock(file *pb.ConfigFile, tokens []token, pos, depth int, followIncludes bool) ([]*pb.ConfigDirective, int) {
+	var dirs []*pb.ConfigDirective
+	for pos < len(tokens) {
+		tok := tokens[pos]
+		pos++
+
+		if tok.is("}") {
+			if depth == 0 {
+				file.Errors = append(file.Errors, fmt.Sprintf("%s:%d: unexpected \"}\"", file.File, tok.line))
+				continue
+			}
+			return dirs, pos
+		}
+		if !tok.quoted && strings.HasPrefix(tok.value, "#") {
+			dirs = append(dirs, &pb.ConfigDirective{Directive: "#", Line: int32(tok.line), Comment: tok.value[1:]})
+			continue
+		}
+		if tok.is(";") || tok.is("{") {
+			file.Errors = append(file.Errors, fmt.Sprintf("%s:%d: unexpected %q", file.File, tok.line, tok.value))
+			continue
+		}
+
+		d := &pb.ConfigDirective{Directive: tok.value, Line: int32(tok.line)}
+		var trailing []*pb.ConfigDirective
+		terminated := false
+		for pos < len(tokens) {
+			t := tokens[pos]
+			pos++
+			if t.is(";") {
+				terminated = true
+				break
+			}
+			if t.is("{") {
+				d.IsBlock = true
+				d.Block, pos = p.parseBlock(file, tokens, pos, depth+1, followIncludes)
+				terminated = true
+				break
+			}
+			if t.is("}") {
+				pos--
+				break
+			}
+			if !t.quoted && strings.HasPrefix(t.value, "#") {
+				trailing = append(trailing, &pb.ConfigDirective{Directive: "#", Line: int32(t.line), Comment: t.value[1:]})
+				continue
+			}
+			d.Args = append(d.Args, t.value)
+		}
+		if !terminated {
+			file.Errors = append(file.Errors, fmt.Sprintf("%s:%d: directive %q is not terminated by \";\"", file.File, d.Line, d.Directive))
+		}
+
+		if followIncludes && d.Directive == "include" && len(d.Args) == 1 {
+			p.resolveInclude(file, d)
+		}
+		dirs = append(dirs, d)
+		dirs = append(dirs, trailing...)
+	}
+	if depth > 0 {
+		file.Errors = append(file.Errors, fmt.Sprintf("%s: unexpected end of file, expecting \"}\"", file.File))
+	}
+	return dirs, pos
+}
+
+// resolveInclude parses the files matched by an include directive. Relative patterns are
+// resolved against the main config's directory, as NGINX does with its conf prefix.
+func (p *treeParser) resolveInclude(file *pb.ConfigFile, d *pb.ConfigDirective) {
+	pattern := d.Args[0]
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(p.baseDir, pattern)
+	}
+
+	var matches []string
+	if strings.ContainsAny(pattern, "*?[") {
+		var err error
+		matches, err = filepath.Glob(pattern)
+		if err != nil {
+			file.Errors = append(file.Errors, fmt.Sprintf("%s:%d: bad include pattern %q", file.File, d.Line, d.Args[0]))
+			return
+		}
+	} else {
+		matches = []string{pattern}
+	}
+
+	for _, m := range matches {
+		if idx, ok := p.index[m]; ok {
+			d.Includes = append(d.Includes, int32(idx))
+			continue
+		}
+		data, err := os.ReadFile(m)
+		if err != nil {
+			file.Errors = append(file.Errors, fmt.Sprintf("%s:%d: include %q: %v", file.File, d.Line, m, err))
+			continue
+		}
+		d.Includes = append(d.Includes, int32(p.parseFile(m, string(data))))
+	}
+}
+
+// BuildConfig renders a directive tree back into NGINX config text.
+func BuildConfig(dirs []*pb.ConfigDirective) string {
+	var b strings.Builder
+	buildBlock(&b, dirs, 0)
+	return b.String()
+}
+
+func buildBlock(b *strings.Builder, dirs []*pb.ConfigDirective, depth int) {
+	indent := strings.Repeat("    ", depth)
+	var prev *pb.ConfigDirective
+	for _, d := range dirs {
+		if d.Directive == "#" {
+			// Keep end-of-line comments on the directive they annotate
+			if prev != nil && !prev.IsBlock && prev.Line > 0 && prev.Line == d.Line {
+				b.WriteString(" #" + d.Comment)
+			} else {
+				if b.Len() > 0 {
+					b.WriteByte('\n')
+				}
+				b.WriteString(indent + "#" + d.Comment)
+			}
+			prev = d
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + d.Directive)
+		for _, arg := range d.Args {
+			b.WriteByte(' ')
+			b.WriteString(quoteArg(arg))
+		}
+		if d.IsBlock {
+			b.WriteString(" {")
+			buildBlock(b, d.Block, depth+1)
+			b.WriteString("\n" + indent + "}")
+		} else {
+			b.WriteByte(';')
+		}
+		prev = d
+	}
+	if depth == 0 && b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+}
+
+// quoteArg double-quotes an argument when it would otherwise be split or misread by NGINX.
+func quoteArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	needsQuote := false
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case ' ', '\t', '\n', '\r', ';', '#', '"', '\'', '}':
+			needsQuote = true
+		case '{':
+			if i == 0 || arg[i-1] != '$' {
+				needsQuote = true
+			}
+		}
+	}
+	if !needsQuote {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// Summarize extracts the server and upstream blocks from a parsed tree, following includes.
+func Summarize(files []*pb.ConfigFile) ([]*pb.ServerBlock, []*pb.UpstreamBlock) {
+	servers := []*pb.ServerBlock{}
+	upstreams := []*pb.UpstreamBlock{}
+	if len(files) == 0 {
+		return servers, upstreams
+	}
+
+	visited := make(map[int32]bool)
+	var walk func(dirs []*pb.ConfigDirective)
+	walk = func(dirs []*pb.ConfigDirective) {
+		for _, d := range dirs {
+			switch {
+			case d.Directive == "include":
+				for _, idx := range d.Includes {
+					if visited[idx] || int(idx) >= len(files) {
+						continue
+					}
+					visited[idx] = true
+					walk(files[idx].Parsed)
+				}
+			case d.Directive == "server" && d.IsBlock:
+				servers = append(servers, summarizeServer(files, d))
+			case d.Directive == "upstream" && d.IsBlock && len(d.Args) > 0:
+				upstreams = append(upstreams, summarizeUpstream(d))
+			case d.IsBlock:
+				walk(d.Block)
+			}
+		}
+	}
+	visited[0] = true
+	walk(files[0].Parsed)
+	return servers, upstreams
+}
+
+func summarizeServer(files []*pb.ConfigFile, d *pb.ConfigDirective) *pb.ServerBlock {
+	s := &pb.ServerBlock{SslConfig: map[string]string{}, Directives: map[string]string{}}
+	var walk func(dirs []*pb.ConfigDirective)
+	walk = func(dirs []*pb.ConfigDirective) {
+		for _, c := range dirs {
+			switch {
+			case c.Directive == "#":
+			case c.Directive == "listen":
+				s.Listen = append(s.Listen, strings.Join(c.Args, " "))
+			case c.Directive == "server_name":
+				s.ServerName = append(s.ServerName, c.Args...)
+			case c.Directive == "root" && len(c.Args) > 0:
+				s.Root = c.Args[0]
+			case c.Directive == "location" && c.IsBlock:
+				s.Locations = append(s.Locations, summarizeLocation(c))
+			case c.Directive == "include" && !c.IsBlock:
+				// snippets included inside a server block contribute to it
+				for _, idx := range c.Includes {
+					if int(idx) < len(files) {
+						walk(files[idx].Parsed)
+					}
+				}
+			case strings.HasPrefix(c.Directive, "ssl_") && !c.IsBlock:
+				s.SslConfig[c.Directive] = strings.Join(c.Args, " ")
+			case !c.IsBlock:
+				s.Directives[c.Directive] = strings.Join(c.Args, " ")
+			}
+		}
+	}
+	walk(d.Block)
+	return s
+}
+
+func summarizeLocation(d *pb.ConfigDirective) *pb.LocationBlock {
+	l := &pb.LocationBlock{Directives: map[string]string{}}
+	switch len(d.Args) {
+	case 1:
+		l.Path = d.Args[0]
+	case 2:
+		l.MatchType = d.Args[0]
+		l.Path = d.Args[1]
+	}
+	for _, c := range d.Block {
+		if c.Directive == "#" || c.IsBlock {
+			continue
+		}
+		if c.Directive == "proxy_pass" && len(c.Args) > 0 {
+			l.ProxyPass = c.Args[0]
+		}
+		l.Directives[c.Directive] = strings.Join(c.Args, " ")
+	}
+	return l
+}
+
+func summarizeUpstream(d *pb.ConfigDirective) *pb.UpstreamBlock {
+	u := &pb.UpstreamBlock{Name: d.Args[0], Directives: map[string]string{}}
+	for _, c := range d.Block {
+		switch {
+		case c.Directive == "#" || c.IsBlock:
+		case c.Directive == "server":
+			u.Servers = append(u.Servers, strings.Join(c.Args, " "))
+		default:
+			u.Directives[c.Directive] = strings.Join(c.Args, " ")
+		}
+	}
+	return u
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMainConf = `user nginx;
+worker_processes auto; # one per core
+
+events {
+    worker_connections 1024;
+}
+
+http {
+    log_format main '$remote_addr - "$request"';
+    include conf.d/*.conf;
+}
+`
+
+const testSiteConf = `upstream backend {
+    server 10.0.0.1:8080 weight=2;
+    server 10.0.0.2:8080;
+    keepalive 16;
+}
+
+server {
+    listen 443 ssl;
+    server_name example.com www.example.com;
+    ssl_certificate /etc/nginx/ssl/example.com.crt;
+
+    location = /health {
+        return 200 "ok";
+    }
+
+    location /api/ {
+        proxy_pass http://backend;
+        proxy_set_header Host ${host};
+    }
+}
+`
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(testMainConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "conf.d", "site.conf"), []byte(testSiteConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "nginx.conf")
+}
+
+func TestParseTree_FollowsIncludes(t *testing.T) {
+	files, err := ParseTree(writeTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected main file and one include, got %d", len(files))
+	}
+	for _, f := range files {
+		if len(f.Errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", f.File, f.Errors)
+		}
+	}
+
+	http := files[0].Parsed[len(files[0].Parsed)-1]
+	if http.Directive != "http" || !http.IsBlock {
+		t.Fatalf("expected http block, got %+v", http)
+	}
+	include := http.Block[1]
+	if include.Directive != "include" || len(include.Includes) != 1 || include.Includes[0] != 1 {
+		t.Errorf("include not resolved: %+v", include)
+	}
+	if got := http.Block[0].Args[1]; got != `$remote_addr - "$request"` {
+		t.Errorf("quoted arg = %q", got)
+	}
+
+	servers, upstreams := Summarize(files)
+	if len(servers) != 1 || len(upstreams) != 1 {
+		t.Fatalf("expected 1 server and 1 upstream, got %d/%d", len(servers), len(upstreams))
+	}
+	s := servers[0]
+	if len(s.ServerName) != 2 || s.Listen[0] != "443 ssl" || s.SslConfig["ssl_certificate"] == "" {
+		t.Errorf("unexpected server summary: %+v", s)
+	}
+	if len(s.Locations) != 2 || s.Locations[0].MatchType != "=" || s.Locations[1].ProxyPass != "http://backend" {
+		t.Errorf("unexpected locations: %+v", s.Locations)
+	}
+	if upstreams[0].Name != "backend" || len(upstreams[0].Servers) != 2 || upstreams[0].Directives["keepalive"] != "16" {
+		t.Errorf("unexpected upstream summary: %+v", upstreams[0])
+	}
+}
+
+func TestBuildConfig_RoundTrip(t *testing.T) {
+	for _, src := range []string{testMainConf, testSiteConf} {
+		first := ParseString("/etc/nginx/test.conf", src)
+		if len(first.Errors) > 0 {
+			t.Fatalf("parse errors: %v", first.Errors)
+		}
+		built := BuildConfig(first.Parsed)
+		second := ParseString("/etc/nginx/test.conf", built)
+		if rebuilt := BuildConfig(second.Parsed); rebuilt != built {
+			t.Errorf("build is not stable:\n%s\n---\n%s", built, rebuilt)
+		}
+	}
+
+	// The trailing comment stays on its directive
+	built := BuildConfig(ParseString("/etc/nginx/test.conf", testMainConf).Parsed)
+	if want := "worker_processes auto; # one per core\n"; !strings.Contains(built, want) {
+		t.Errorf("expected %q in:\n%s", want, built)
+	}
+}
+
+func TestParseString_Errors(t *testing.T) {
+	f := ParseString("/etc/nginx/bad.conf", "http {\n    server {\n        listen 80\n    }\n")
+	if len(f.Errors) == 0 {
+		t.Error("expected errors for unterminated directive and block")
+	}
+}
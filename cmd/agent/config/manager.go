@@ -2,17 +2,32 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
+var configLog = logging.NewLogger("config")
+
 type Manager struct {
 	configPath string
 	backupDir  string
+	instance   *Instance
+}
+
+// Instance is an NGINX instance other than the host's default one: its own configuration,
+// prefix and master process, and possibly its own systemd unit
+type Instance struct {
+	ConfPath    string
+	Prefix      string
+	Binary      string // empty for "nginx" in PATH
+	MasterPID   int
+	SystemdUnit string // empty when the master is not run by systemd
 }
 
 func NewManager(configPath string) *Manager {
@@ -25,6 +40,43 @@ func NewManager(configPath string) *Manager {
 	}
 }
 
+// NewInstanceManager returns a Manager whose test, reload, restart and stop target inst rather
+// than the default nginx service
+func NewInstanceManager(inst Instance) *Manager {
+	m := NewManager(inst.ConfPath)
+	m.instance = &inst
+	return m
+}
+
+// nginx returns the binary and arguments to run nginx with args against the managed instance
+func (m *Manager) nginx(args ...string) (string, []string) {
+	if m.instance == nil {
+		return "nginx", args
+	}
+	binary := m.instance.Binary
+	if binary == "" {
+		binary = "nginx"
+	}
+	if m.instance.Prefix != "" {
+		args = append(args, "-p", m.instance.Prefix)
+	}
+	return binary, append(args, "-c", m.instance.ConfPath)
+}
+
+// runNginx runs nginx with args against the managed instance
+func (m *Manager) runNginx(args ...string) ([]byte, error) {
+	binary, args := m.nginx(args...)
+	return m.runCommand(binary, args...)
+}
+
+// unit returns the systemd unit of the managed instance, empty when it has none
+func (m *Manager) unit() string {
+	if m.instance == nil {
+		return "nginx"
+	}
+	return m.instance.SystemdUnit
+}
+
 // Backup creates a timestamped backup of the current config
 func (m *Manager) Backup() (string, error) {
 	content, err := os.ReadFile(m.configPath)
@@ -61,6 +113,41 @@ func (m *Manager) Update(content string, createBackup bool) (string, error) {
 	return backupPath, nil
 }
 
+// UpdateFiles writes several config files at once (e.g. nginx.conf and its includes).
+// The returned restore func puts every file back as it was before the call.
+func (m *Manager) UpdateFiles(files map[string]string) (func() error, error) {
+	originals := make(map[string][]byte)
+	restore := func() error {
+		var firstErr error
+		for path, content := range originals {
+			var err error
+			if content == nil {
+				err = os.Remove(path)
+			} else {
+				err = os.WriteFile(path, content, 0644)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	for path, content := range files {
+		prev, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			restore()
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		originals[path] = prev
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return restore, nil
+}
+
 // runCommand executes a command with sudo if not already root
 func (m *Manager) runCommand(name string, arg ...string) ([]byte, error) {
 	if os.Geteuid() == 0 {
@@ -81,19 +168,23 @@ func (m *Manager) hasSystemd() bool {
 // Reload reloads the NGINX configuration
 func (m *Manager) Reload() error {
 	// First test the config
-	output, err := m.runCommand("nginx", "-t")
+	output, err := m.runNginx("-t")
 	if err != nil {
-		log.Printf("NGINX config test failed: %s", string(output))
+		configLog.Error().Msgf("NGINX config test failed: %s", string(output))
 		return fmt.Errorf("config test failed: %s", string(output))
 	}
-	log.Printf("NGINX config test successful: %s", string(output))
+	configLog.Info().Msgf("NGINX config test successful: %s", string(output))
 
 	// Prefer systemctl reload if available
-	if m.hasSystemd() {
-		output, err = m.runCommand("systemctl", "reload", "nginx")
-	} else {
+	switch {
+	case m.hasSystemd() && m.unit() != "":
+		output, err = m.runCommand("systemctl", "reload", m.unit())
+	case m.instance != nil && m.instance.MasterPID > 0:
+		// Signal the master directly: nginx -s reads the pid file, which instances may share
+		output, err = m.runCommand("kill", "-HUP", strconv.Itoa(m.instance.MasterPID))
+	default:
 		// Fallback for containers/non-systemd environments
-		output, err = m.runCommand("nginx", "-s", "reload")
+		output, err = m.runNginx("-s", "reload")
 	}
 
 	if err != nil {
@@ -105,12 +196,12 @@ func (m *Manager) Reload() error {
 
 // TestConfig runs nginx -t to validate the current config without applying changes.
 func (m *Manager) TestConfig() error {
-	output, err := m.runCommand("nginx", "-t")
+	output, err := m.runNginx("-t")
 	if err != nil {
-		log.Printf("NGINX config test (explicit) failed: %s", string(output))
+		configLog.Error().Msgf("NGINX config test (explicit) failed: %s", string(output))
 		return fmt.Errorf("config test failed: %s", string(output))
 	}
-	log.Printf("NGINX config test (explicit) successful: %s", string(output))
+	configLog.Info().Msgf("NGINX config test (explicit) successful: %s", string(output))
 	return nil
 }
 
@@ -119,25 +210,31 @@ func (m *Manager) Restart() error {
 	if err := m.TestConfig(); err != nil {
 		return err
 	}
-	if m.hasSystemd() {
-		output, err := m.runCommand("systemctl", "restart", "nginx")
+	if m.hasSystemd() && m.unit() != "" {
+		output, err := m.runCommand("systemctl", "restart", m.unit())
 		if err != nil {
 			return fmt.Errorf("restart failed: %s", string(output))
 		}
 		return nil
 	}
+	if m.instance != nil && m.hasSystemd() {
+		return fmt.Errorf("restart failed: instance %s is not run by a systemd unit", m.instance.ConfPath)
+	}
 	return fmt.Errorf("restart failed: systemctl not found")
 }
 
 // Stop stops the NGINX service
 func (m *Manager) Stop() error {
-	if m.hasSystemd() {
-		output, err := m.runCommand("systemctl", "stop", "nginx")
+	if m.hasSystemd() && m.unit() != "" {
+		output, err := m.runCommand("systemctl", "stop", m.unit())
 		if err != nil {
 			return fmt.Errorf("stop failed: %s", string(output))
 		}
 		return nil
 	}
+	if m.instance != nil && m.hasSystemd() {
+		return fmt.Errorf("stop failed: instance %s is not run by a systemd unit", m.instance.ConfPath)
+	}
 	// Note: We don't want to stop the containerized NGINX process directly via -s quit
 	// as that's usually handled by the orchestrator/container manager.
 	return fmt.Errorf("stop failed: systemctl not found")
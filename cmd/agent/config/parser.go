@@ -31,13 +31,31 @@ func (p *Parser) Parse() (*pb.NginxConfig, error) {
 		lastModified = info.ModTime().Unix()
 	}
 
-	return &pb.NginxConfig{
+	cfg := &pb.NginxConfig{
 		ConfigPath:   p.configPath,
 		Content:      string(content),
 		LastModified: lastModified,
 		Servers:      []*pb.ServerBlock{},
 		Upstreams:    []*pb.UpstreamBlock{},
-	}, nil
+	}
+	if files, err := ParseTree(p.configPath); err == nil {
+		cfg.Servers, cfg.Upstreams = Summarize(files)
+	}
+	return cfg, nil
+}
+
+// ParseStructured is Parse plus the full directive tree of the config and its includes.
+func (p *Parser) ParseStructured() (*pb.NginxConfig, error) {
+	cfg, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ParseTree(p.configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Files = files
+	return cfg, nil
 }
 
 // Validate checks if the config syntax is valid by writing to a temporary file and running nginx -t.
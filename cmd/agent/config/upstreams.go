@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// UpstreamRef locates an upstream block inside a parsed config.
+type UpstreamRef struct {
+	File  *pb.ConfigFile
+	Block *pb.ConfigDirective
+}
+
+// FindUpstreams returns every upstream block in the parsed files, in file order.
+func FindUpstreams(files []*pb.ConfigFile) []UpstreamRef {
+	var refs []UpstreamRef
+	var walk func(f *pb.ConfigFile, dirs []*pb.ConfigDirective)
+	walk = func(f *pb.ConfigFile, dirs []*pb.ConfigDirective) {
+		for _, d := range dirs {
+			if !d.IsBlock {
+				continue
+			}
+			if d.Directive == "upstream" && len(d.Args) > 0 {
+				refs = append(refs, UpstreamRef{File: f, Block: d})
+				continue
+			}
+			walk(f, d.Block)
+		}
+	}
+	for _, f := range files {
+		walk(f, f.Parsed)
+	}
+	return refs
+}
+
+// UpstreamPool converts an upstream block to its API representation.
+func (u UpstreamRef) UpstreamPool() *pb.UpstreamPool {
+	pool := &pb.UpstreamPool{
+		Name:       u.Block.Args[0],
+		File:       u.File.File,
+		Directives: map[string]string{},
+	}
+	for _, d := range u.Block.Block {
+		switch {
+		case d.Directive == "#" || d.IsBlock:
+		case d.Directive == "server" && len(d.Args) > 0:
+			pool.Servers = append(pool.Servers, upstreamServer(d))
+		default:
+			pool.Directives[d.Directive] = strings.Join(d.Args, " ")
+		}
+		if d.Directive == "zone" {
+			pool.Dynamic = true
+		}
+	}
+	return pool
+}
+
+func upstreamServer(d *pb.ConfigDirective) *pb.UpstreamServer {
+	s := &pb.UpstreamServer{Address: d.Args[0], Weight: 1}
+	for _, p := range d.Args[1:] {
+		switch {
+		case p == "backup":
+			s.Backup = true
+		case p == "down":
+			s.Down = true
+		case p == "drain":
+			s.Drain = true
+		case strings.HasPrefix(p, "weight="):
+			if w, err := strconv.Atoi(strings.TrimPrefix(p, "weight=")); err == nil {
+				s.Weight = int32(w)
+			}
+			s.Params = append(s.Params, p)
+		default:
+			s.Params = append(s.Params, p)
+		}
+	}
+	return s
+}
+
+// SetUpstreamServer applies an add/remove/drain/disable/enable action to an upstream block.
+// Open-source NGINX has no drain parameter, so drain marks the server down: after the reload
+// new requests skip it while the old workers finish their in-flight connections.
+func SetUpstreamServer(block *pb.ConfigDirective, action, address string, params []string) error {
+	idx := -1
+	for i, d := range block.Block {
+		if d.Directive == "server" && !d.IsBlock && len(d.Args) > 0 && d.Args[0] == address {
+			idx = i
+			break
+		}
+	}
+
+	switch action {
+	case "add":
+		if idx >= 0 {
+			return fmt.Errorf("server %s already exists in upstream %s", address, block.Args[0])
+		}
+		block.Block = append(block.Block, &pb.ConfigDirective{
+			Directive: "server",
+			Args:      append([]string{address}, params...),
+		})
+		return nil
+	case "remove", "drain", "disable", "enable":
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("server %s not found in upstream %s", address, block.Args[0])
+	}
+	server := block.Block[idx]
+
+	switch action {
+	case "remove":
+		remaining := 0
+		for _, d := range block.Block {
+			if d.Directive == "server" && d != server {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return fmt.Errorf("cannot remove the last server of upstream %s", block.Args[0])
+		}
+		block.Block = append(block.Block[:idx], block.Block[idx+1:]...)
+	case "drain", "disable":
+		server.Args = append(withoutArgs(server.Args, "down", "drain"), "down")
+	case "enable":
+		server.Args = withoutArgs(server.Args, "down", "drain")
+	}
+	return nil
+}
+
+func withoutArgs(args []string, drop ...string) []string {
+	out := make([]string, 0, len(args))
+	for i, a := range args {
+		keep := true
+		if i > 0 {
+			for _, d := range drop {
+				if a == d {
+					keep = false
+				}
+			}
+		}
+		if keep {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestSetUpstreamServer(t *testing.T) {
+	f := ParseString("/etc/nginx/conf.d/site.conf", testSiteConf)
+	refs := FindUpstreams([]*pb.ConfigFile{f})
+	if len(refs) != 1 {
+		t.Fatalf("expected one upstream, got %d", len(refs))
+	}
+	block := refs[0].Block
+
+	if err := SetUpstreamServer(block, "drain", "10.0.0.1:8080", nil); err != nil {
+		t.Fatal(err)
+	}
+	pool := refs[0].UpstreamPool()
+	if !pool.Servers[0].Down || pool.Servers[0].Weight != 2 {
+		t.Errorf("drained server = %+v", pool.Servers[0])
+	}
+
+	if err := SetUpstreamServer(block, "enable", "10.0.0.1:8080", nil); err != nil {
+		t.Fatal(err)
+	}
+	if refs[0].UpstreamPool().Servers[0].Down {
+		t.Error("server still down after enable")
+	}
+
+	if err := SetUpstreamServer(block, "add", "10.0.0.3:8080", []string{"backup"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUpstreamServer(block, "add", "10.0.0.3:8080", nil); err == nil {
+		t.Error("expected duplicate add to fail")
+	}
+	if err := SetUpstreamServer(block, "remove", "10.0.0.2:8080", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUpstreamServer(block, "drain", "10.9.9.9:80", nil); err == nil {
+		t.Error("expected unknown server to fail")
+	}
+
+	built := BuildConfig(f.Parsed)
+	for _, want := range []string{"server 10.0.0.1:8080 weight=2;", "server 10.0.0.3:8080 backup;"} {
+		if !strings.Contains(built, want) {
+			t.Errorf("expected %q in:\n%s", want, built)
+		}
+	}
+	if strings.Contains(built, "10.0.0.2") {
+		t.Errorf("removed server still present:\n%s", built)
+	}
+}
+
+func TestSetUpstreamServer_KeepsLastServer(t *testing.T) {
+	f := ParseString("/etc/nginx/conf.d/one.conf", "upstream one {\n    server 127.0.0.1:9000;\n}\n")
+	block := FindUpstreams([]*pb.ConfigFile{f})[0].Block
+	if err := SetUpstreamServer(block, "remove", "127.0.0.1:9000", nil); err == nil {
+		t.Error("expected removing the last server to fail")
+	}
+}
@@ -15,8 +15,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/avika-ai/avika/cmd/agent/logs"
 	"github.com/avika-ai/avika/cmd/agent/updater"
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/redact"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -48,7 +51,6 @@ func (s *agentConfigServer) UpdateAgentConfig(ctx context.Context, req *pb.Agent
 	if len(req.Updates) == 0 {
 		return &pb.AgentConfigUpdateResponse{Success: false, Error: "updates are required"}, nil
 	}
-
 	changed, requiresRestart, err := applyAgentUpdates(req.Updates, req.HotReload)
 	if err != nil {
 		return &pb.AgentConfigUpdateResponse{Success: false, Error: err.Error()}, nil
@@ -192,18 +194,27 @@ func currentAgentConfigResponse() *pb.GetAgentConfigResponse {
 	}
 }
 
+// applyAgentUpdates validates every update before applying any, so a bad value leaves the agent as
+// it was. Log paths, formats and redaction take effect at once; the collector is reconfigured here.
 func applyAgentUpdates(updates map[string]string, hotReload bool) (changed []string, requiresRestart bool, err error) {
 	changedSet := map[string]struct{}{}
-	addChanged := func(name string) {
+	var steps []func()
+	set := func(name string, restart bool, apply func()) {
 		changedSet[name] = struct{}{}
+		steps = append(steps, apply)
+		requiresRestart = requiresRestart || restart
 	}
+	var reconfigureLogs, redactionChanged, restartUpdater bool
 
 	for rawKey, rawVal := range updates {
 		key := strings.TrimSpace(rawKey)
-		val := strings.TrimSpace(rawVal)
 		if key == "" {
 			continue
 		}
+		if hasControlChars(key) || hasControlChars(rawVal) {
+			return nil, false, fmt.Errorf("%s: control characters are not allowed", key)
+		}
+		val := strings.TrimSpace(rawVal)
 
 		// Labels: LABEL_* keys
 		if strings.HasPrefix(key, "LABEL_") {
@@ -211,126 +222,224 @@ func applyAgentUpdates(updates map[string]string, hotReload bool) (changed []str
 			if labelKey == "" {
 				continue
 			}
-			agentLabelsMu.Lock()
-			if val == "" {
-				delete(agentLabels, labelKey)
-			} else {
-				agentLabels[labelKey] = val
-			}
-			agentLabelsMu.Unlock()
-			addChanged(key)
+			set(key, false, func() {
+				agentLabelsMu.Lock()
+				defer agentLabelsMu.Unlock()
+				if val == "" {
+					delete(agentLabels, labelKey)
+				} else {
+					agentLabels[labelKey] = val
+				}
+			})
 			continue
 		}
 
-		switch strings.ToUpper(key) {
+		name := strings.ToUpper(key)
+		invalid := func(err error) error {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		switch name {
 		case "GATEWAYS", "GATEWAY_SERVER":
-			*gatewayAddr = val
-			addChanged("GATEWAYS")
-			requiresRestart = true
+			set("GATEWAYS", true, func() { *gatewayAddr = val })
 		case "AGENT_ID":
-			*agentID = val
-			addChanged("AGENT_ID")
-			requiresRestart = true
-		case "HEALTH_PORT":
+			set(name, true, func() { *agentID = val })
+		case "HEALTH_PORT", "MGMT_PORT":
 			i, convErr := strconv.Atoi(val)
 			if convErr != nil {
-				return nil, false, fmt.Errorf("invalid HEALTH_PORT: %w", convErr)
+				return nil, false, invalid(convErr)
 			}
-			*healthPort = i
-			addChanged("HEALTH_PORT")
-			requiresRestart = true
-		case "MGMT_PORT":
-			i, convErr := strconv.Atoi(val)
-			if convErr != nil {
-				return nil, false, fmt.Errorf("invalid MGMT_PORT: %w", convErr)
+			port := healthPort
+			if name == "MGMT_PORT" {
+				port = mgmtPort
 			}
-			*mgmtPort = i
-			addChanged("MGMT_PORT")
-			requiresRestart = true
+			set(name, true, func() { *port = i })
 		case "NGINX_CONFIG_PATH":
-			*nginxConfigPath = val
-			addChanged("NGINX_CONFIG_PATH")
-			requiresRestart = true
+			set(name, true, func() { *nginxConfigPath = val })
 		case "NGINX_STATUS_URL":
-			*nginxStatusURL = val
-			addChanged("NGINX_STATUS_URL")
-			requiresRestart = true
-		case "ACCESS_LOG_PATH":
-			*accessLogPath = val
-			addChanged("ACCESS_LOG_PATH")
-			requiresRestart = true
-		case "ERROR_LOG_PATH":
-			*errorLogPath = val
-			addChanged("ERROR_LOG_PATH")
-			requiresRestart = true
+			set(name, true, func() { *nginxStatusURL = val })
+		case "ACCESS_LOG_PATH", "ERROR_LOG_PATH":
+			path, pathErr := parsePathSetting(val)
+			if pathErr != nil {
+				return nil, false, invalid(pathErr)
+			}
+			flagValue := accessLogPath
+			if name == "ERROR_LOG_PATH" {
+				flagValue = errorLogPath
+			}
+			set(name, false, func() { *flagValue = path })
+			reconfigureLogs = true
 		case "LOG_FORMAT":
-			*logFormat = val
-			addChanged("LOG_FORMAT")
-			requiresRestart = true
+			format := strings.ToLower(val)
+			if !logs.IsSupportedFormat(format) {
+				return nil, false, invalid(fmt.Errorf("expected one of %s", strings.Join(logs.SupportedFormats(), ", ")))
+			}
+			set(name, false, func() { *logFormat = format })
+			reconfigureLogs = true
+		case "LOG_FORMAT_STRING":
+			if val != "" {
+				if _, parseErr := logs.NewFormatParser(val); parseErr != nil {
+					return nil, false, invalid(parseErr)
+				}
+			}
+			set(name, false, func() { *logFormatString = val })
+			reconfigureLogs = true
 		case "BUFFER_DIR":
-			*bufferDir = val
-			addChanged("BUFFER_DIR")
-			requiresRestart = true
+			set(name, true, func() { *bufferDir = val })
 		case "UPDATE_SERVER":
-			*updateServer = val
-			addChanged("UPDATE_SERVER")
-			if hotReload {
-				restartUpdaterLoopIfRunning()
-			} else {
-				requiresRestart = true
-			}
+			set(name, !hotReload, func() { *updateServer = val })
+			restartUpdater = hotReload
 		case "UPDATE_INTERVAL":
 			d, convErr := time.ParseDuration(val)
 			if convErr != nil {
-				return nil, false, fmt.Errorf("invalid UPDATE_INTERVAL: %w", convErr)
+				return nil, false, invalid(convErr)
+			}
+			set(name, !hotReload, func() { *updateInterval = d })
+			restartUpdater = hotReload
+		case "UPDATE_PUBLIC_KEY":
+			if val != "" && !strings.HasPrefix(val, "/") {
+				if _, keyErr := updater.ParsePublicKey(val); keyErr != nil {
+					return nil, false, invalid(keyErr)
+				}
 			}
-			*updateInterval = d
-			addChanged("UPDATE_INTERVAL")
-			if hotReload {
-				restartUpdaterLoopIfRunning()
-			} else {
-				requiresRestart = true
+			set(name, true, func() { *updatePublicKey = val })
+		case "UPDATE_REQUIRE_HTTPS":
+			set(name, true, func() { *updateRequireHTTPS = val == "true" || val == "1" })
+		case "UPDATE_HEALTH_TIMEOUT":
+			d, convErr := time.ParseDuration(val)
+			if convErr != nil {
+				return nil, false, invalid(convErr)
 			}
+			set(name, false, func() { *updateHealthTimeout = d })
 		case "LOG_LEVEL":
-			*logLevel = val
-			addChanged("LOG_LEVEL")
-			requiresRestart = true
+			level := strings.ToLower(val)
+			switch level {
+			case "debug", "info", "warn", "error":
+			default:
+				return nil, false, invalid(fmt.Errorf("expected debug, info, warn or error"))
+			}
+			set(name, false, func() {
+				*logLevel = level
+				_ = logging.SetLevel("", level)
+			})
 		case "LOG_FILE":
-			*logFile = val
-			addChanged("LOG_FILE")
-			requiresRestart = true
+			set(name, true, func() { *logFile = val })
 		case "AVIKA_MGMT_ADVERTISE", "MGMT_ADVERTISE":
-			*mgmtAdvertise = val
-			addChanged("AVIKA_MGMT_ADVERTISE")
-			requiresRestart = true
+			set("AVIKA_MGMT_ADVERTISE", true, func() { *mgmtAdvertise = val })
 		case "AVIKA_MGMT_NAT_CIDR", "MGMT_NAT_CIDR":
-			*mgmtNatCIDR = val
-			addChanged("AVIKA_MGMT_NAT_CIDR")
-			requiresRestart = true
+			set("AVIKA_MGMT_NAT_CIDR", true, func() { *mgmtNatCIDR = val })
+		case "METRICS_INTERVAL", "DISCOVERY_INTERVAL", "HEARTBEAT_INTERVAL":
+			maxInterval := time.Duration(0)
+			if name == "HEARTBEAT_INTERVAL" {
+				maxInterval = maxHeartbeatInterval
+			}
+			d, durErr := parseIntervalSetting(val, maxInterval)
+			if durErr != nil {
+				return nil, false, invalid(durErr)
+			}
+			interval := map[string]*time.Duration{
+				"METRICS_INTERVAL":   metricsInterval,
+				"DISCOVERY_INTERVAL": discoveryInterval,
+				"HEARTBEAT_INTERVAL": heartbeatInterval,
+			}[name]
+			set(name, false, func() { *interval = d })
+		case "DISABLED_COLLECTORS":
+			names, listErr := parseCollectorList(val)
+			if listErr != nil {
+				return nil, false, invalid(listErr)
+			}
+			set(name, false, func() { *disabledCollectors = names })
+		case "LOG_REDACT":
+			rules, ruleErr := redact.ParseRules(val)
+			if ruleErr != nil {
+				return nil, false, invalid(ruleErr)
+			}
+			set(name, false, func() { *logRedact = strings.Join(rules, ",") })
+			redactionChanged = true
+		case "LOG_REDACT_PARAMS":
+			params, paramErr := redact.ParseParams(val)
+			if paramErr != nil {
+				return nil, false, invalid(paramErr)
+			}
+			set(name, false, func() { *logRedactParams = strings.Join(params, ",") })
+			redactionChanged = true
+		case "LOG_REDACT_IPV4_PREFIX", "LOG_REDACT_IPV6_PREFIX":
+			prefix, bits, def := logRedactIPv4Prefix, 32, redact.DefaultIPv4Prefix
+			if name == "LOG_REDACT_IPV6_PREFIX" {
+				prefix, bits, def = logRedactIPv6Prefix, 128, redact.DefaultIPv6Prefix
+			}
+			n, prefixErr := redact.ParsePrefix(val, bits, def)
+			if prefixErr != nil {
+				return nil, false, invalid(prefixErr)
+			}
+			set(name, false, func() { *prefix = n })
+			redactionChanged = true
+		case "MAX_CPU_PERCENT":
+			f, convErr := strconv.ParseFloat(val, 64)
+			if convErr != nil || f < 0 {
+				return nil, false, invalid(fmt.Errorf("expected a percentage of one core, 0 for unlimited"))
+			}
+			set(name, false, func() { *maxCPUPercent = f })
+		case "MAX_MEMORY_MB":
+			i, convErr := strconv.Atoi(val)
+			if convErr != nil || i < 0 {
+				return nil, false, invalid(fmt.Errorf("expected a size in MB, 0 for unlimited"))
+			}
+			set(name, false, func() { *maxMemoryMB = i })
+		case "NGINX_METRICS_ENABLED", "SYSTEM_METRICS_ENABLED":
+			b, convErr := strconv.ParseBool(val)
+			if convErr != nil {
+				return nil, false, invalid(fmt.Errorf("expected true or false"))
+			}
+			enabled := nginxMetricsEnabled
+			if name == "SYSTEM_METRICS_ENABLED" {
+				enabled = systemMetricsEnabled
+			}
+			set(name, false, func() { *enabled = b })
 		case "LOG_ROTATION":
 			var lr pb.LogRotateConfig
 			if jsonErr := json.Unmarshal([]byte(val), &lr); jsonErr != nil {
 				return nil, false, fmt.Errorf("invalid LOG_ROTATION JSON: %w", jsonErr)
 			}
-			logConfigMu.Lock()
-			currentLogRotation = &lr
-			logConfigMu.Unlock()
-			addChanged("LOG_ROTATION")
+			set(name, false, func() {
+				logConfigMu.Lock()
+				currentLogRotation = &lr
+				logConfigMu.Unlock()
+			})
 		case "SYSLOG":
 			var sc pb.SyslogConfig
 			if jsonErr := json.Unmarshal([]byte(val), &sc); jsonErr != nil {
 				return nil, false, fmt.Errorf("invalid SYSLOG JSON: %w", jsonErr)
 			}
-			logConfigMu.Lock()
-			currentSyslog = &sc
-			logConfigMu.Unlock()
-			addChanged("SYSLOG")
-			requiresRestart = true // Syslog forwarder is created at startup
+			// Syslog forwarder is created at startup
+			set(name, true, func() {
+				logConfigMu.Lock()
+				currentSyslog = &sc
+				logConfigMu.Unlock()
+			})
 		default:
 			return nil, false, fmt.Errorf("unsupported config key: %s", key)
 		}
 	}
 
+	runtimeMu.Lock()
+	for _, apply := range steps {
+		apply()
+	}
+	collector, access, errorLog, format := runtimeLogCollector, *accessLogPath, *errorLogPath, accessLogFormat()
+	redactor := currentLogRedactor()
+	if redactionChanged {
+		redactor = updateLogRedactor()
+	}
+	runtimeMu.Unlock()
+
+	if collector != nil && (reconfigureLogs || redactionChanged) {
+		collector.SetRedactor(redactor)
+		collector.Reconfigure(access, errorLog, format)
+	}
+	if restartUpdater {
+		restartUpdaterLoopIfRunning()
+	}
+
 	if len(changedSet) > 0 {
 		changed = make([]string, 0, len(changedSet))
 		for k := range changedSet {
@@ -349,6 +458,14 @@ func persistAgentConfigUpdates(path string, updates map[string]string) error {
 	if strings.TrimSpace(path) == "" {
 		return fmt.Errorf("config file path is empty")
 	}
+	formatted := make(map[string]string, len(updates))
+	for k, v := range updates {
+		val, err := formatConfigValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		formatted[k] = val
+	}
 
 	dir := filepath.Dir(path)
 	if dir != "" && dir != "." {
@@ -395,8 +512,8 @@ func persistAgentConfigUpdates(path string, updates map[string]string) error {
 		}
 
 		key := strings.TrimSpace(parts[0])
-		if val, ok := updates[key]; ok {
-			out = append(out, fmt.Sprintf("%s=%s", key, formatConfigValue(val)))
+		if val, ok := formatted[key]; ok {
+			out = append(out, fmt.Sprintf("%s=%s", key, val))
 			seen[key] = true
 			continue
 		}
@@ -409,11 +526,16 @@ func persistAgentConfigUpdates(path string, updates map[string]string) error {
 		out = append(out, "")
 	}
 
-	for k, v := range updates {
+	keys := make([]string, 0, len(formatted))
+	for k := range formatted {
 		if !seen[k] {
-			out = append(out, fmt.Sprintf("%s=%s", k, formatConfigValue(v)))
+			keys = append(keys, k)
 		}
 	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, fmt.Sprintf("%s=%s", k, formatted[k]))
+	}
 
 	content := strings.Join(out, "\n")
 	if !strings.HasSuffix(content, "\n") {
@@ -423,17 +545,29 @@ func persistAgentConfigUpdates(path string, updates map[string]string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
-func formatConfigValue(val string) string {
+// formatConfigValue quotes a value for a key=value line. Values with control characters are
+// refused: a newline would end the line and let the value add keys of its own.
+func formatConfigValue(val string) (string, error) {
+	if hasControlChars(val) {
+		return "", fmt.Errorf("value contains control characters")
+	}
 	v := strings.TrimSpace(val)
 	if v == "" {
-		return "\"\""
+		return "\"\"", nil
 	}
 	// Quote if contains spaces or comment markers
 	if strings.ContainsAny(v, " \t#") || strings.Contains(v, "\"") {
 		v = strings.ReplaceAll(v, "\"", "\\\"")
-		return "\"" + v + "\""
+		return "\"" + v + "\"", nil
 	}
-	return v
+	return v, nil
+}
+
+// hasControlChars reports whether a value holds control characters other than tab
+func hasControlChars(val string) bool {
+	return strings.ContainsFunc(val, func(r rune) bool {
+		return r != '\t' && (r < 0x20 || r == 0x7f)
+	})
 }
 
 func copyFileContents(src, dst string) error {
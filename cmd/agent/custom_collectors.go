@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/agent/buffer"
+	"github.com/avika-ai/avika/cmd/agent/collectors"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// genericMetricsPerMessage bounds the samples buffered in one message
+const genericMetricsPerMessage = 1000
+
+// startCustomCollectors runs the collectors defined in the collectors directory, buffering their
+// samples as GenericMetrics for the gateways
+func startCustomCollectors(ctx context.Context, wal *buffer.FileBuffer) {
+	specs, err := collectors.LoadDir(*collectorsDir)
+	if err != nil {
+		agentLog.Warn().Msgf("Custom collectors: %v", err)
+	}
+	var active []collectors.Collector
+	for _, spec := range specs {
+		c, err := collectors.New(spec)
+		if err != nil {
+			agentLog.Warn().Msgf("Custom collectors: %v", err)
+			continue
+		}
+		agentLog.Info().Msgf("Custom collector %s (%s) every %s", spec.Name, spec.Type, c.Interval())
+		active = append(active, c)
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	collectors.Run(ctx, active, func(metrics []*pb.GenericMetric) {
+		// Chunked to stay well under the buffer's 1MB message limit
+		for start := 0; start < len(metrics); start += genericMetricsPerMessage {
+			end := min(start+genericMetricsPerMessage, len(metrics))
+			writeToBuffer(wal, &pb.AgentMessage{
+				AgentId:   *agentID,
+				Timestamp: time.Now().Unix(),
+				Payload: &pb.AgentMessage_GenericMetrics{
+					GenericMetrics: &pb.GenericMetrics{Metrics: metrics[start:end]},
+				},
+			})
+		}
+	}, func(name string, err error) {
+		agentLog.Warn().Msgf("Custom collector %s failed: %v", name, err)
+	})
+}
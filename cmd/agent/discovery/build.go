@@ -0,0 +1,201 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	// maxIncludedConfigs bounds how many files are read while looking for load_module directives
+	maxIncludedConfigs = 64
+	// loadedModulesTTL is how long the load_module directives of a configuration are cached
+	loadedModulesTTL = time.Minute
+)
+
+var (
+	loadModulePattern = regexp.MustCompile(`(?m)^\s*load_module\s+([^;]+);`)
+	includePattern    = regexp.MustCompile(`(?m)^\s*include\s+([^;]+);`)
+)
+
+// buildKey identifies a binary on disk; a changed mtime or size means it was replaced
+type buildKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// getNginxBuild returns how the binary at exePath was built, cached until the binary changes
+func (d *Discoverer) getNginxBuild(exePath string) *pb.NginxBuild {
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return nil
+	}
+	key := buildKey{exePath, info.Size(), info.ModTime()}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if b, ok := d.builds[key]; ok {
+		return b
+	}
+	out, err := exec.Command(exePath, "-V").CombinedOutput()
+	if err != nil {
+		return nil
+	}
+	b := ParseNginxBuild(string(out))
+	b.BinaryPath = exePath
+	if d.builds == nil {
+		d.builds = make(map[buildKey]*pb.NginxBuild)
+	}
+	d.builds[key] = b
+	return b
+}
+
+// ParseNginxBuild parses the output of nginx -V: the compiler, the OpenSSL it runs with,
+// the configure arguments and the modules compiled in (--with-*_module, --with-stream/mail,
+// --add-module and --add-dynamic-module; modules nginx builds by default are not listed)
+func ParseNginxBuild(output string) *pb.NginxBuild {
+	b := &pb.NginxBuild{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "built by "):
+			b.Compiler = strings.TrimPrefix(line, "built by ")
+		case strings.HasPrefix(line, "built with "):
+			// "built with OpenSSL 3.0.9 30 May 2023 (running with OpenSSL 3.0.11 19 Sep 2023)"
+			ssl := strings.TrimPrefix(line, "built with ")
+			if i := strings.Index(ssl, "(running with "); i >= 0 {
+				ssl = strings.TrimSuffix(ssl[i+len("(running with "):], ")")
+			}
+			b.OpensslVersion = strings.TrimSpace(ssl)
+		case strings.HasPrefix(line, "configure arguments:"):
+			b.ConfigureArgs = splitConfigureArgs(strings.TrimPrefix(line, "configure arguments:"))
+		}
+	}
+	for _, arg := range b.ConfigureArgs {
+		name, value, _ := strings.Cut(arg, "=")
+		switch {
+		case strings.HasPrefix(name, "--with-") && strings.HasSuffix(name, "_module"),
+			name == "--with-stream", name == "--with-mail":
+			b.Modules = append(b.Modules, strings.TrimPrefix(name, "--with-"))
+		case name == "--add-module" || name == "--add-dynamic-module":
+			b.Modules = append(b.Modules, filepath.Base(strings.TrimRight(value, "/")))
+		}
+	}
+	return b
+}
+
+// splitConfigureArgs splits configure arguments on spaces outside of quotes, e.g.
+// --with-cc-opt='-g -O2'
+func splitConfigureArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ' || r == '\t':
+			if cur.Len() > 0 {
+				args = append(args, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// instanceBuild returns the build of the binary at exePath with the dynamic modules its
+// configuration loads
+func (d *Discoverer) instanceBuild(exePath, confPath string) *pb.NginxBuild {
+	b := d.getNginxBuild(exePath)
+	if b == nil {
+		return nil
+	}
+	d.mu.Lock()
+	cached, ok := d.loaded[confPath]
+	d.mu.Unlock()
+	if !ok || time.Since(cached.at) > loadedModulesTTL {
+		cached = loadedModulesEntry{at: time.Now(), modules: loadedModules(confPath)}
+		d.mu.Lock()
+		if d.loaded == nil {
+			d.loaded = make(map[string]loadedModulesEntry)
+		}
+		d.loaded[confPath] = cached
+		d.mu.Unlock()
+	}
+	return &pb.NginxBuild{
+		BinaryPath:     b.BinaryPath,
+		Compiler:       b.Compiler,
+		OpensslVersion: b.OpensslVersion,
+		ConfigureArgs:  b.ConfigureArgs,
+		Modules:        b.Modules,
+		LoadedModules:  cached.modules,
+	}
+}
+
+type loadedModulesEntry struct {
+	at      time.Time
+	modules []string
+}
+
+// loadedModules returns the dynamic modules the configuration loads with load_module, following
+// includes relative to the configuration's directory
+func loadedModules(confPath string) []string {
+	var modules []string
+	readConfigTree(confPath, func(data string) {
+		for _, m := range loadModulePattern.FindAllStringSubmatch(data, -1) {
+			name := filepath.Base(strings.Trim(strings.TrimSpace(m[1]), `"'`))
+			modules = append(modules, strings.TrimSuffix(name, ".so"))
+		}
+	})
+	return modules
+}
+
+// readConfigTree calls visit with the configuration at confPath and every file it includes,
+// includes resolved relative to the configuration's directory
+func readConfigTree(confPath string, visit func(data string)) {
+	seen := make(map[string]bool)
+	prefix := filepath.Dir(confPath)
+	var read func(path string)
+	read = func(path string) {
+		if seen[path] || len(seen) >= maxIncludedConfigs {
+			return
+		}
+		seen[path] = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		visit(string(data))
+		for _, m := range includePattern.FindAllStringSubmatch(string(data), -1) {
+			pattern := strings.Trim(strings.TrimSpace(m[1]), `"'`)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(prefix, pattern)
+			}
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				read(match)
+			}
+		}
+	}
+	read(confPath)
+}
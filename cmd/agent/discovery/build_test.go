@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const nginxV = `nginx version: nginx/1.24.0
+built by gcc 12.2.0 (Debian 12.2.0-14)
+built with OpenSSL 3.0.9 30 May 2023 (running with OpenSSL 3.0.11 19 Sep 2023)
+TLS SNI support enabled
+configure arguments: --prefix=/etc/nginx --with-cc-opt='-g -O2 -fstack-protector' --with-http_ssl_module --with-http_v2_module --with-stream=dynamic --with-stream_ssl_module --without-http_gzip_module --add-dynamic-module=/build/ngx_brotli/ --add-module=../headers-more-nginx-module
+`
+
+func TestParseNginxBuild(t *testing.T) {
+	b := ParseNginxBuild(nginxV)
+	if b.Compiler != "gcc 12.2.0 (Debian 12.2.0-14)" {
+		t.Errorf("compiler = %q", b.Compiler)
+	}
+	if b.OpensslVersion != "OpenSSL 3.0.11 19 Sep 2023" {
+		t.Errorf("openssl = %q", b.OpensslVersion)
+	}
+	if len(b.ConfigureArgs) != 9 || b.ConfigureArgs[1] != "--with-cc-opt=-g -O2 -fstack-protector" {
+		t.Errorf("configure args = %q", b.ConfigureArgs)
+	}
+	want := "http_ssl_module,http_v2_module,stream,stream_ssl_module,ngx_brotli,headers-more-nginx-module"
+	if got := strings.Join(b.Modules, ","); got != want {
+		t.Errorf("modules = %s, want %s", got, want)
+	}
+
+	if b := ParseNginxBuild("nginx version: nginx/1.25.3\nbuilt with OpenSSL 1.1.1w  11 Sep 2023\n"); b.OpensslVersion != "OpenSSL 1.1.1w  11 Sep 2023" {
+		t.Errorf("openssl without runtime version = %q", b.OpensslVersion)
+	}
+}
+
+func TestLoadedModules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("nginx.conf", "load_module modules/ngx_stream_module.so;\ninclude modules-enabled/*.conf;\nevents {}\nhttp { include nginx.conf; }\n")
+	write("modules-enabled/50-brotli.conf", "load_module \"/usr/lib/nginx/modules/ngx_http_brotli_filter_module.so\";\n")
+
+	got := strings.Join(loadedModules(filepath.Join(dir, "nginx.conf")), ",")
+	if got != "ngx_stream_module,ngx_http_brotli_filter_module" {
+		t.Errorf("loaded modules = %s", got)
+	}
+}
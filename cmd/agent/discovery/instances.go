@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	accessLogPattern = regexp.MustCompile(`(?m)^\s*access_log\s+([^;\s]+)`)
+	errorLogPattern  = regexp.MustCompile(`(?m)^\s*error_log\s+([^;\s]+)`)
+)
+
+// nginxProcess is a running process whose name contains "nginx"
+type nginxProcess struct {
+	pid     int32
+	ppid    int32
+	cmdline string
+	exe     string
+}
+
+// nginxMaster is the process an instance is identified by, with its worker processes
+type nginxMaster struct {
+	nginxProcess
+	workers int
+}
+
+// groupInstances returns one entry per nginx instance, ordered by pid. An instance is an nginx
+// process whose parent is not nginx: the master, or the only process with master_process off.
+// Workers, cache managers and old masters during a binary upgrade belong to their parent.
+func groupInstances(procs []nginxProcess) []nginxMaster {
+	isNginx := make(map[int32]bool, len(procs))
+	for _, p := range procs {
+		isNginx[p.pid] = true
+	}
+	workers := make(map[int32]int)
+	var masters []nginxMaster
+	for _, p := range procs {
+		if isNginx[p.ppid] {
+			if strings.Contains(p.cmdline, "worker process") {
+				workers[p.ppid]++
+			}
+			continue
+		}
+		masters = append(masters, nginxMaster{nginxProcess: p})
+	}
+	for i := range masters {
+		masters[i].workers = workers[masters[i].pid]
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i].pid < masters[j].pid })
+	return masters
+}
+
+// parsePrefix returns the -p prefix of an nginx command line
+func parsePrefix(cmdline string) string {
+	parts := strings.Fields(cmdline)
+	for i, part := range parts {
+		if part == "-p" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// instanceIDs names each instance by its configuration file, which survives restarts unlike the
+// master pid. Masters sharing a configuration (different -p or -g pid) get their pid appended.
+func instanceIDs(confPaths []string, pids []int32) []string {
+	count := make(map[string]int, len(confPaths))
+	for _, c := range confPaths {
+		count[c]++
+	}
+	ids := make([]string, len(confPaths))
+	for i, c := range confPaths {
+		ids[i] = c
+		if count[c] > 1 {
+			ids[i] = fmt.Sprintf("%s@%d", c, pids[i])
+		}
+	}
+	return ids
+}
+
+// unitFromCgroup returns the systemd service a process runs under from /proc/<pid>/cgroup,
+// e.g. "0::/system.slice/nginx.service", empty outside systemd
+func unitFromCgroup(data string) string {
+	for _, line := range strings.Split(data, "\n") {
+		i := strings.LastIndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		for _, seg := range strings.Split(line[i+1:], "/") {
+			if strings.HasSuffix(seg, ".service") {
+				return seg
+			}
+		}
+	}
+	return ""
+}
+
+func systemdUnit(pid int32) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	return unitFromCgroup(string(data))
+}
+
+type logPathsEntry struct {
+	at                  time.Time
+	accessLog, errorLog string
+}
+
+// logPaths returns the first file access_log and error_log of the configuration, cached like
+// the load_module directives
+func (d *Discoverer) logPaths(confPath, prefix string) (string, string) {
+	d.mu.Lock()
+	cached, ok := d.logs[confPath]
+	d.mu.Unlock()
+	if ok && time.Since(cached.at) <= loadedModulesTTL {
+		return cached.accessLog, cached.errorLog
+	}
+	access, errorLog := configLogPaths(confPath, prefix)
+	d.mu.Lock()
+	if d.logs == nil {
+		d.logs = make(map[string]logPathsEntry)
+	}
+	d.logs[confPath] = logPathsEntry{at: time.Now(), accessLog: access, errorLog: errorLog}
+	d.mu.Unlock()
+	return access, errorLog
+}
+
+// configLogPaths finds the first access_log and error_log writing to a file. Relative paths are
+// resolved against the prefix, or the configuration's directory without one.
+func configLogPaths(confPath, prefix string) (access, errorLog string) {
+	if prefix == "" {
+		prefix = filepath.Dir(confPath)
+	}
+	first := func(pattern *regexp.Regexp, data string) string {
+		for _, m := range pattern.FindAllStringSubmatch(data, -1) {
+			path := strings.Trim(m[1], `"'`)
+			if path == "off" || path == "stderr" || strings.HasPrefix(path, "syslog:") ||
+				strings.HasPrefix(path, "memory:") || strings.Contains(path, "$") {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(prefix, path)
+			}
+			return path
+		}
+		return ""
+	}
+	readConfigTree(confPath, func(data string) {
+		if access == "" {
+			access = first(accessLogPattern, data)
+		}
+		if errorLog == "" {
+			errorLog = first(errorLogPattern, data)
+		}
+	})
+	return access, errorLog
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroupInstances(t *testing.T) {
+	procs := []nginxProcess{
+		{pid: 210, ppid: 200, cmdline: "nginx: worker process"},
+		{pid: 200, ppid: 1, cmdline: "nginx: master process /usr/sbin/nginx -c /etc/nginx-api/nginx.conf"},
+		{pid: 100, ppid: 1, cmdline: "nginx: master process /usr/sbin/nginx"},
+		{pid: 101, ppid: 100, cmdline: "nginx: worker process"},
+		{pid: 102, ppid: 100, cmdline: "nginx: worker process"},
+		{pid: 103, ppid: 100, cmdline: "nginx: cache manager process"},
+		{pid: 300, ppid: 42, cmdline: "nginx -g daemon off; master_process off;"},
+	}
+	masters := groupInstances(procs)
+	if len(masters) != 3 {
+		t.Fatalf("got %d instances, want 3: %+v", len(masters), masters)
+	}
+	want := []struct {
+		pid     int32
+		workers int
+	}{{100, 2}, {200, 1}, {300, 0}}
+	for i, w := range want {
+		if masters[i].pid != w.pid || masters[i].workers != w.workers {
+			t.Errorf("instance %d = pid %d with %d workers, want pid %d with %d", i, masters[i].pid, masters[i].workers, w.pid, w.workers)
+		}
+	}
+}
+
+func TestInstanceIDs(t *testing.T) {
+	ids := instanceIDs(
+		[]string{"/etc/nginx/nginx.conf", "/etc/nginx-api/nginx.conf", "/etc/nginx/nginx.conf"},
+		[]int32{100, 200, 300},
+	)
+	want := []string{"/etc/nginx/nginx.conf@100", "/etc/nginx-api/nginx.conf", "/etc/nginx/nginx.conf@300"}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("id %d = %q, want %q", i, ids[i], want[i])
+		}
+	}
+}
+
+func TestUnitFromCgroup(t *testing.T) {
+	for data, want := range map[string]string{
+		"0::/system.slice/nginx-api.service\n":                                              "nginx-api.service",
+		"12:pids:/system.slice/nginx.service\n1:name=systemd:/system.slice/nginx.service\n": "nginx.service",
+		"0::/\n": "",
+		"0::/kubepods/besteffort/pod1234/abcdef\n": "",
+	} {
+		if got := unitFromCgroup(data); got != want {
+			t.Errorf("unitFromCgroup(%q) = %q, want %q", data, got, want)
+		}
+	}
+}
+
+func TestConfigLogPaths(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("nginx.conf", "error_log stderr;\nerror_log logs/error.log warn;\nhttp {\n  include conf.d/*.conf;\n}\n")
+	write("conf.d/default.conf", "access_log syslog:server=127.0.0.1 combined;\naccess_log /var/log/nginx-api/access.log main;\n")
+
+	access, errorLog := configLogPaths(filepath.Join(dir, "nginx.conf"), "/srv/nginx-api")
+	if access != "/var/log/nginx-api/access.log" {
+		t.Errorf("access log = %q", access)
+	}
+	if errorLog != "/srv/nginx-api/logs/error.log" {
+		t.Errorf("error log = %q", errorLog)
+	}
+}
@@ -5,68 +5,91 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/shirou/gopsutil/v3/process"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
-type Discoverer struct{}
+type Discoverer struct {
+	mu     sync.Mutex
+	builds map[buildKey]*pb.NginxBuild
+	loaded map[string]loadedModulesEntry // conf path -> load_module directives
+	logs   map[string]logPathsEntry      // conf path -> access_log and error_log
+}
 
 func NewDiscoverer() *Discoverer {
 	return &Discoverer{}
 }
 
-// Scan finds all running NGINX processes
+// Scan finds the running NGINX instances: one per master process, with its workers counted
 func (d *Discoverer) Scan(ctx context.Context) ([]*pb.NginxInstance, error) {
 	procs, err := process.Processes()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list processes: %w", err)
 	}
 
-	var instances []*pb.NginxInstance
-	seenPids := make(map[int32]bool)
-
+	var found []nginxProcess
 	for _, p := range procs {
 		name, err := p.Name()
-		if err != nil {
+		if err != nil || !strings.Contains(strings.ToLower(name), "nginx") {
 			continue
 		}
+		ppid, _ := p.Ppid()
+		cmdline, _ := p.Cmdline()
+		exe, _ := p.Exe()
+		found = append(found, nginxProcess{pid: p.Pid, ppid: ppid, cmdline: cmdline, exe: exe})
+	}
 
-		if strings.Contains(strings.ToLower(name), "nginx") {
-			// Get Detailed info
-			pid := p.Pid
-			if seenPids[pid] {
-				continue
-			}
-			seenPids[pid] = true
-
-			cmdline, _ := p.Cmdline()
-
-			// Simple heuristic: If it has kids or specific cmdline flags, might be master.
-			// Ideally we group workers under master. For MVP, just list all.
-
-			// Attempt to find version from binary
-			version := "unknown"
-			exe, err := p.Exe()
-			if err == nil {
-				version = getNginxVersion(exe)
-			} else {
-				// Fallback: try "nginx" in PATH
-				v := getNginxVersion("nginx")
-				if v != "unknown" {
-					version = v
-				}
+	masters := groupInstances(found)
+	instances := make([]*pb.NginxInstance, 0, len(masters))
+	confPaths := make([]string, len(masters))
+	pids := make([]int32, len(masters))
+	seenBinaries := make(map[string]bool)
+	for i, m := range masters {
+		// Attempt to find version from binary
+		version := "unknown"
+		if m.exe != "" {
+			version = getNginxVersion(m.exe)
+		} else {
+			// Fallback: try "nginx" in PATH
+			v := getNginxVersion("nginx")
+			if v != "unknown" {
+				version = v
 			}
+		}
 
-			instances = append(instances, &pb.NginxInstance{
-				Pid:      fmt.Sprintf("%d", pid),
-				Version:  version,
-				ConfPath: parseConfPath(cmdline),
-				Status:   "RUNNING",
-			})
+		prefix := parsePrefix(m.cmdline)
+		confPath := parseConfPath(m.cmdline)
+		if !filepath.IsAbs(confPath) && prefix != "" {
+			confPath = filepath.Join(prefix, confPath)
+		}
+		accessLog, errorLog := d.logPaths(confPath, prefix)
+		inst := &pb.NginxInstance{
+			Pid:         fmt.Sprintf("%d", m.pid),
+			Version:     version,
+			ConfPath:    confPath,
+			Status:      "RUNNING",
+			Prefix:      prefix,
+			WorkerCount: int32(m.workers),
+			BinaryPath:  m.exe,
+			SystemdUnit: systemdUnit(m.pid),
+			AccessLog:   accessLog,
+			ErrorLog:    errorLog,
 		}
+		// The build is reported once per binary rather than for every instance
+		if m.exe != "" && !seenBinaries[m.exe] {
+			seenBinaries[m.exe] = true
+			inst.Build = d.instanceBuild(m.exe, inst.ConfPath)
+		}
+		instances = append(instances, inst)
+		confPaths[i], pids[i] = confPath, m.pid
+	}
+	for i, id := range instanceIDs(confPaths, pids) {
+		instances[i].InstanceId = id
 	}
 	return instances, nil
 }
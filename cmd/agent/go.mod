@@ -1,11 +1,11 @@
 module github.com/avika-ai/avika/cmd/agent
 
-go 1.24
+go 1.24.0
 
 require (
+	github.com/avika-ai/avika/internal/common v0.0.0-00010101000000-000000000000
 	github.com/hpcloud/tail v1.0.0
 	github.com/shirou/gopsutil/v3 v3.24.5
-	github.com/avika-ai/avika/internal/common v0.0.0-00010101000000-000000000000
 	go.opentelemetry.io/proto/otlp v1.9.0
 	google.golang.org/grpc v1.78.0
 	google.golang.org/protobuf v1.36.11
@@ -16,7 +16,10 @@ require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.2 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
+	github.com/rs/zerolog v1.34.0 // indirect
 	github.com/shoenig/go-m1cpu v0.1.6 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/tklauser/go-sysconf v0.3.12 // indirect
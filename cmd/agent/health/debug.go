@@ -0,0 +1,70 @@
+package health
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strings"
+)
+
+// debugMux serves the /debug endpoints: pprof profiles, a goroutine dump, expvar and the effective
+// configuration
+func (s *Server) debugMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", goroutinesHandler)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/config", s.configHandler)
+	return mux
+}
+
+// requireDebugAccess serves next to clients with the debug token, or to loopback clients when
+// there is no token
+func (s *Server) requireDebugAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.debug.Token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.debug.Token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		} else if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "forbidden: set DEBUG_TOKEN to serve /debug to other hosts", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// goroutinesHandler writes the stack of every goroutine, as a panic prints them
+func goroutinesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// configHandler writes the effective configuration, without its secrets
+func (s *Server) configHandler(w http.ResponseWriter, r *http.Request) {
+	if s.debug.Config == nil {
+		http.Error(w, "not available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.debug.Config())
+}
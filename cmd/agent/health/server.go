@@ -4,20 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	_ "net/http/pprof" // Register pprof handlers
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
+var healthLog = logging.NewLogger("health")
+
 // Server provides HTTP health check endpoints
 type Server struct {
 	server    *http.Server
 	ready     bool
 	mu        sync.RWMutex
 	startTime time.Time
+	debug     DebugOptions
 }
 
 // HealthResponse represents the health check response
@@ -70,11 +73,21 @@ type GCStats struct {
 	GCCPUFraction   float64 `json:"gc_cpu_fraction"`
 }
 
+// DebugOptions configures the /debug endpoints
+type DebugOptions struct {
+	// Token is the bearer token /debug requires. Without one, only clients on the loopback
+	// interface are served, e.g. through kubectl port-forward.
+	Token string
+	// Config returns the effective configuration for /debug/config, without its secrets
+	Config func() any
+}
+
 // NewServer creates a new health check server
-func NewServer(port int) *Server {
+func NewServer(port int, debug DebugOptions) *Server {
 	s := &Server{
 		ready:     false,
 		startTime: time.Now(),
+		debug:     debug,
 	}
 
 	mux := http.NewServeMux()
@@ -86,20 +99,13 @@ func NewServer(port int) *Server {
 	// Runtime stats endpoint for profiling
 	mux.HandleFunc("/stats", s.statsHandler)
 	mux.HandleFunc("/stats/runtime", s.statsHandler)
+
+	// Log levels per component: GET lists them, PUT {"component": "buffer", "level": "debug"} sets one.
+	// Guarded like /debug, since the health port is reachable by probes.
+	mux.Handle("/log-levels", s.requireDebugAccess(http.HandlerFunc(s.logLevelsHandler)))
 	
-	// pprof endpoints for detailed profiling
-	// Access via: /debug/pprof/
-	mux.HandleFunc("/debug/pprof/", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/cmdline", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/profile", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/symbol", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/trace", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/heap", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/goroutine", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/block", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/mutex", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/allocs", http.DefaultServeMux.ServeHTTP)
-	mux.HandleFunc("/debug/pprof/threadcreate", http.DefaultServeMux.ServeHTTP)
+	// Diagnostics: pprof, goroutines, expvar and the effective config, behind requireDebugAccess
+	mux.Handle("/debug/", s.requireDebugAccess(s.debugMux()))
 
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
@@ -114,7 +120,7 @@ func NewServer(port int) *Server {
 
 // Start starts the health check server
 func (s *Server) Start() error {
-	log.Printf("Starting health check server on %s", s.server.Addr)
+	healthLog.Info().Msgf("Starting health check server on %s", s.server.Addr)
 	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("health server error: %w", err)
 	}
@@ -123,7 +129,7 @@ func (s *Server) Start() error {
 
 // Shutdown gracefully shuts down the health check server
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down health check server...")
+	healthLog.Info().Msg("Shutting down health check server...")
 	return s.server.Shutdown(ctx)
 }
 
@@ -172,6 +178,47 @@ func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// LogLevels is the default log level and the level of every component
+type LogLevels struct {
+	Default    string                   `json:"default"`
+	Components []logging.ComponentLevel `json:"components"`
+}
+
+// logLevelsHandler handles /log-levels: GET returns the log levels; PUT changes the level of a
+// component, or the default level when component is empty, until the agent restarts
+func (s *Server) logLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req struct {
+			Component string `json:"component"`
+			Level     string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if req.Component == "" && req.Level == "" {
+			http.Error(w, `{"error":"level is required to change the default level"}`, http.StatusBadRequest)
+			return
+		}
+		if err := logging.SetLevel(req.Component, req.Level); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		healthLog.Info().Str("log_component", req.Component).Str("level", req.Level).Msg("Log level changed")
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	def, components := logging.Levels()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LogLevels{Default: def, Components: components})
+}
+
 // statsHandler returns runtime statistics for profiling
 func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
 	var memStats runtime.MemStats
@@ -0,0 +1,118 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+func TestLogLevelsHandler(t *testing.T) {
+	s := NewServer(0, DebugOptions{})
+	defer logging.SetLevel("health", "")
+
+	do := func(method, body string) (int, LogLevels) {
+		req := httptest.NewRequest(method, "/log-levels", strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:5000"
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, req)
+		var levels LogLevels
+		json.NewDecoder(rec.Body).Decode(&levels)
+		return rec.Code, levels
+	}
+
+	code, levels := do(http.MethodPut, `{"component":"health","level":"debug"}`)
+	if code != http.StatusOK {
+		t.Fatalf("PUT = %d", code)
+	}
+	found := false
+	for _, c := range levels.Components {
+		if c.Component == "health" {
+			found = c.Level == "debug" && c.Overridden
+		}
+	}
+	if !found {
+		t.Errorf("levels = %+v", levels)
+	}
+
+	if code, _ := do(http.MethodPut, `{"component":"nope","level":"debug"}`); code != http.StatusBadRequest {
+		t.Errorf("unknown component = %d", code)
+	}
+	if code, _ := do(http.MethodPut, `{"component":"health","level":"loud"}`); code != http.StatusBadRequest {
+		t.Errorf("unknown level = %d", code)
+	}
+	if code, _ := do(http.MethodDelete, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE = %d", code)
+	}
+}
+
+func TestLogLevelsRequireDebugAccess(t *testing.T) {
+	defer logging.SetLevel("health", "")
+	put := func(s *Server, remoteAddr, auth string) int {
+		req := httptest.NewRequest(http.MethodPut, "/log-levels", strings.NewReader(`{"component":"health","level":"debug"}`))
+		req.RemoteAddr = remoteAddr
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := put(NewServer(0, DebugOptions{}), "10.0.0.5:5000", ""); code != http.StatusForbidden {
+		t.Errorf("remote PUT without a token = %d, want 403", code)
+	}
+	withToken := NewServer(0, DebugOptions{Token: "secret"})
+	if code := put(withToken, "10.0.0.5:5000", ""); code != http.StatusUnauthorized {
+		t.Errorf("PUT without the token = %d, want 401", code)
+	}
+	if code := put(withToken, "10.0.0.5:5000", "Bearer secret"); code != http.StatusOK {
+		t.Errorf("PUT with the token = %d, want 200", code)
+	}
+}
+
+func TestRequireDebugAccess(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name       string
+		token      string
+		remoteAddr string
+		auth       string
+		want       int
+	}{
+		{"loopback without token", "", "127.0.0.1:5000", "", http.StatusOK},
+		{"ipv6 loopback without token", "", "[::1]:5000", "", http.StatusOK},
+		{"remote without token", "", "10.0.0.5:5000", "", http.StatusForbidden},
+		{"remote with token", "secret", "10.0.0.5:5000", "Bearer secret", http.StatusOK},
+		{"wrong token", "secret", "127.0.0.1:5000", "Bearer nope", http.StatusUnauthorized},
+		{"missing token", "secret", "127.0.0.1:5000", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(0, DebugOptions{Token: tt.token})
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			s.requireDebugAccess(ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugConfig(t *testing.T) {
+	s := NewServer(0, DebugOptions{Config: func() any { return map[string]string{"psk": "***"} }})
+	req := httptest.NewRequest(http.MethodGet, "/debug/config", nil)
+	rec := httptest.NewRecorder()
+	s.debugMux().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"psk":"***"`) {
+		t.Errorf("got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// hostInventoryRefresh is how often the OS and package versions are collected again
+const hostInventoryRefresh = 15 * time.Minute
+
+// inventoryPackages are the packages whose versions the gateway matches against its CVE feed
+var inventoryPackages = []string{
+	"nginx", "nginx-core", "nginx-full", "nginx-light", "nginx-extras", "nginx-plus",
+	"openssl", "openssl-libs", "libssl3", "libssl3t64", "libssl1.1",
+}
+
+var (
+	hostInvMu sync.Mutex
+	hostInv   *pb.HostInventory
+	hostInvAt time.Time
+)
+
+// hostInventory returns the host inventory reported in heartbeats. The result is cached and
+// must not be modified.
+func hostInventory() *pb.HostInventory {
+	hostInvMu.Lock()
+	defer hostInvMu.Unlock()
+	if hostInv == nil || time.Since(hostInvAt) >= hostInventoryRefresh {
+		hostInv = collectHostInventory()
+		hostInvAt = time.Now()
+	}
+	return hostInv
+}
+
+func collectHostInventory() *pb.HostInventory {
+	inv := &pb.HostInventory{Arch: runtime.GOARCH}
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		release := parseOSRelease(string(data))
+		inv.OsId, inv.OsVersion, inv.OsName = release["ID"], release["VERSION_ID"], release["PRETTY_NAME"]
+	}
+	if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		inv.Kernel = strings.TrimSpace(string(data))
+	}
+	inv.Packages = installedPackages()
+	return inv
+}
+
+// parseOSRelease parses os-release KEY=value lines, unquoting values
+func parseOSRelease(data string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		fields[key] = strings.Trim(value, `"'`)
+	}
+	return fields
+}
+
+// installedPackages asks the host's package manager for the versions of inventoryPackages
+func installedPackages() []*pb.PackageVersion {
+	if _, err := exec.LookPath("dpkg-query"); err == nil {
+		// Exits non-zero when one of the packages is unknown but still lists the others
+		out, _ := exec.Command("dpkg-query", append([]string{"-W", "-f=${Package}\t${Version}\t${db:Status-Abbrev}\n"}, inventoryPackages...)...).Output()
+		return parseDpkgPackages(string(out))
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		out, _ := exec.Command("rpm", append([]string{"-q", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"}, inventoryPackages...)...).Output()
+		return parseRpmPackages(string(out))
+	}
+	if _, err := exec.LookPath("apk"); err == nil {
+		out, _ := exec.Command("apk", "info", "-v").Output()
+		return parseApkPackages(string(out))
+	}
+	return nil
+}
+
+// parseDpkgPackages parses "name\tversion\tstatus" lines, keeping installed packages ("ii")
+func parseDpkgPackages(out string) []*pb.PackageVersion {
+	var pkgs []*pb.PackageVersion
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) < 3 || f[1] == "" || !strings.HasPrefix(f[2], "ii") {
+			continue
+		}
+		pkgs = append(pkgs, &pb.PackageVersion{Name: f[0], Version: f[1], Manager: "dpkg"})
+	}
+	return pkgs
+}
+
+// parseRpmPackages parses "name\tversion-release" lines; rpm prints "package X is not installed"
+// without a tab for the others
+func parseRpmPackages(out string) []*pb.PackageVersion {
+	var pkgs []*pb.PackageVersion
+	for _, line := range strings.Split(out, "\n") {
+		name, version, ok := strings.Cut(line, "\t")
+		if !ok || version == "" {
+			continue
+		}
+		pkgs = append(pkgs, &pb.PackageVersion{Name: name, Version: version, Manager: "rpm"})
+	}
+	return pkgs
+}
+
+// parseApkPackages picks inventoryPackages out of "apk info -v" lines such as nginx-1.24.0-r7
+func parseApkPackages(out string) []*pb.PackageVersion {
+	var pkgs []*pb.PackageVersion
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		for _, name := range inventoryPackages {
+			version, ok := strings.CutPrefix(line, name+"-")
+			if ok && version != "" && version[0] >= '0' && version[0] <= '9' {
+				pkgs = append(pkgs, &pb.PackageVersion{Name: name, Version: version, Manager: "apk"})
+				break
+			}
+		}
+	}
+	return pkgs
+}
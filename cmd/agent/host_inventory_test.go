@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestParseOSRelease(t *testing.T) {
+	release := parseOSRelease("NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\n# comment\nID=ubuntu\nPRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n")
+	if release["ID"] != "ubuntu" || release["VERSION_ID"] != "22.04" || release["PRETTY_NAME"] != "Ubuntu 22.04.4 LTS" {
+		t.Errorf("os-release = %v", release)
+	}
+}
+
+func TestParsePackages(t *testing.T) {
+	dpkg := parseDpkgPackages("nginx\t1.18.0-6ubuntu14.4\tii \nnginx-core\t1.18.0-6ubuntu14.4\tii \nlibssl1.1\t\tun \nopenssl\t3.0.2-0ubuntu1.15\trc \n")
+	if len(dpkg) != 2 || dpkg[0].Name != "nginx" || dpkg[0].Version != "1.18.0-6ubuntu14.4" || dpkg[0].Manager != "dpkg" {
+		t.Errorf("dpkg packages = %v", dpkg)
+	}
+
+	rpm := parseRpmPackages("nginx\t1.20.1-14.el9\npackage nginx-core is not installed\nopenssl-libs\t3.0.7-27.el9\n")
+	if len(rpm) != 2 || rpm[1].Name != "openssl-libs" || rpm[1].Version != "3.0.7-27.el9" {
+		t.Errorf("rpm packages = %v", rpm)
+	}
+
+	apk := parseApkPackages("musl-1.2.4-r2\nnginx-1.24.0-r7\nnginx-mod-http-brotli-1.0.0-r0\nopenssl-3.1.4-r5\n")
+	if len(apk) != 2 || apk[0].Version != "1.24.0-r7" || apk[1].Name != "openssl" {
+		t.Errorf("apk packages = %v", apk)
+	}
+}
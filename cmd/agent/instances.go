@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/avika-ai/avika/cmd/agent/config"
+	"github.com/avika-ai/avika/cmd/agent/logs"
+	"github.com/avika-ai/avika/cmd/agent/metrics"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// nginxInstances holds the instances of the last discovery scan, so commands, metrics and log
+// collection can target one of several nginx instances on the host by its instance ID
+var nginxInstances instanceRegistry
+
+type instanceRegistry struct {
+	mu        sync.RWMutex
+	instances []*pb.NginxInstance
+}
+
+// set records a scan. The primary instance is the one the agent's NGINX settings (config path,
+// status URL, log files) describe: the instance using the configured config path, or else the
+// first. It is moved to the front.
+func (r *instanceRegistry) set(instances []*pb.NginxInstance, configPath string) {
+	primary := 0
+	for i, inst := range instances {
+		inst.Primary = false
+		if inst.ConfPath == configPath && primary == 0 {
+			primary = i
+		}
+	}
+	if len(instances) > 0 {
+		instances[primary].Primary = true
+		instances[0], instances[primary] = instances[primary], instances[0]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances = instances
+}
+
+func (r *instanceRegistry) get(id string) (*pb.NginxInstance, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, inst := range r.instances {
+		if inst.InstanceId == id {
+			return inst, true
+		}
+	}
+	return nil, false
+}
+
+// secondary returns every instance but the primary one
+func (r *instanceRegistry) secondary() []*pb.NginxInstance {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*pb.NginxInstance
+	for _, inst := range r.instances {
+		if !inst.Primary {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
+
+// primaryID returns the instance ID of the primary instance, empty before the first scan
+func (r *instanceRegistry) primaryID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.instances) == 0 {
+		return ""
+	}
+	return r.instances[0].InstanceId
+}
+
+// configDirs returns the directories of the discovered configurations
+func (r *instanceRegistry) configDirs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dirs := make([]string, 0, len(r.instances))
+	for _, inst := range r.instances {
+		if filepath.IsAbs(inst.ConfPath) {
+			dirs = append(dirs, filepath.Dir(inst.ConfPath))
+		}
+	}
+	return dirs
+}
+
+// instanceTarget returns the config manager and main config file of the instance a command
+// targets; an empty ID is the primary instance
+func (s *mgmtServer) instanceTarget(id string) (*config.Manager, string, error) {
+	if id == "" {
+		return s.configManager, s.configPath, nil
+	}
+	inst, ok := nginxInstances.get(id)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown NGINX instance %q", id)
+	}
+	if inst.Primary {
+		return s.configManager, s.configPath, nil
+	}
+	pid, _ := strconv.Atoi(inst.Pid)
+	return config.NewInstanceManager(config.Instance{
+		ConfPath:    inst.ConfPath,
+		Prefix:      inst.Prefix,
+		Binary:      inst.BinaryPath,
+		MasterPID:   pid,
+		SystemdUnit: inst.SystemdUnit,
+	}), inst.ConfPath, nil
+}
+
+// instanceLogPath returns the log file a log request reads and its format: the configured log
+// files for the primary instance, those of the instance's configuration for the others
+func instanceLogPath(req *pb.LogRequest) (string, string, error) {
+	if req.NginxInstanceId != "" {
+		inst, ok := nginxInstances.get(req.NginxInstanceId)
+		if !ok {
+			return "", "", fmt.Errorf("unknown NGINX instance %q", req.NginxInstanceId)
+		}
+		if !inst.Primary {
+			path := inst.AccessLog
+			if req.LogType == "error" {
+				path = inst.ErrorLog
+			}
+			if path == "" {
+				return "", "", fmt.Errorf("NGINX instance %s logs no %s log to a file", inst.InstanceId, firstOr(req.LogType, "access"))
+			}
+			return path, "combined", nil
+		}
+	}
+
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	if req.LogType == "error" {
+		return *errorLogPath, "combined", nil
+	}
+	return *accessLogPath, accessLogFormat(), nil
+}
+
+// parseInstanceStatusURLs parses "ID=URL,ID=URL" into URLs by instance ID
+func parseInstanceStatusURLs(s string) map[string]string {
+	urls := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		id, url, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && id != "" && url != "" {
+			urls[id] = url
+		}
+	}
+	return urls
+}
+
+// instanceCollectors scrapes the additional instances that have a stub_status URL configured.
+// Collect runs on the metrics goroutine; ObserveErrorLogLine may run on any.
+type instanceCollectors struct {
+	mu   sync.Mutex
+	byID map[string]*instanceCollector
+}
+
+type instanceCollector struct {
+	url       string
+	masterPID int
+	collector *metrics.NginxCollector
+}
+
+// Collect returns the metrics of every secondary instance with a status URL, each with its
+// instance ID
+func (c *instanceCollectors) Collect(instances []*pb.NginxInstance, urls map[string]string, disabled []string) []*pb.NginxMetrics {
+	c.mu.Lock()
+	current := make(map[string]*instanceCollector, len(instances))
+	for _, inst := range instances {
+		url, ok := urls[inst.InstanceId]
+		if !ok {
+			continue
+		}
+		pid, _ := strconv.Atoi(inst.Pid)
+		ic, ok := c.byID[inst.InstanceId]
+		if !ok || ic.url != url || ic.masterPID != pid {
+			ic = &instanceCollector{url: url, masterPID: pid, collector: metrics.NewInstanceCollector(url, pid)}
+		}
+		current[inst.InstanceId] = ic
+	}
+	c.byID = current
+	c.mu.Unlock()
+
+	var out []*pb.NginxMetrics
+	for id, ic := range current {
+		ic.collector.SetDisabledCollectors(disabled...)
+		m, err := ic.collector.Collect()
+		if err != nil {
+			agentLog.Warn().Msgf("NGINX metrics collection failed for instance %s: %v", id, err)
+			continue
+		}
+		m.InstanceId = id
+		out = append(out, m)
+	}
+	return out
+}
+
+// ObserveErrorLogLine passes an error log line of an instance to its collector
+func (c *instanceCollectors) ObserveErrorLogLine(id, line string) {
+	c.mu.Lock()
+	ic, ok := c.byID[id]
+	c.mu.Unlock()
+	if ok {
+		ic.collector.ObserveErrorLogLine(line)
+	}
+}
+
+// instanceLogs returns the log files of instances for the log collector
+func instanceLogs(instances []*pb.NginxInstance) []logs.InstanceLogs {
+	out := make([]logs.InstanceLogs, 0, len(instances))
+	for _, inst := range instances {
+		if inst.AccessLog != "" || inst.ErrorLog != "" {
+			out = append(out, logs.InstanceLogs{InstanceID: inst.InstanceId, AccessLog: inst.AccessLog, ErrorLog: inst.ErrorLog})
+		}
+	}
+	return out
+}
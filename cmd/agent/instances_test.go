@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestInstanceRegistryPrimary(t *testing.T) {
+	var r instanceRegistry
+	instances := []*pb.NginxInstance{
+		{InstanceId: "/etc/nginx-api/nginx.conf", ConfPath: "/etc/nginx-api/nginx.conf", Pid: "200"},
+		{InstanceId: "/etc/nginx/nginx.conf", ConfPath: "/etc/nginx/nginx.conf", Pid: "100"},
+	}
+	r.set(instances, "/etc/nginx/nginx.conf")
+	if r.primaryID() != "/etc/nginx/nginx.conf" || !instances[0].Primary || instances[1].Primary {
+		t.Fatalf("primary = %q, instances = %+v", r.primaryID(), instances)
+	}
+	if sec := r.secondary(); len(sec) != 1 || sec[0].InstanceId != "/etc/nginx-api/nginx.conf" {
+		t.Errorf("secondary = %+v", sec)
+	}
+
+	// Without an instance on the configured path the first one is primary
+	r.set([]*pb.NginxInstance{{InstanceId: "/opt/nginx/conf/nginx.conf", ConfPath: "/opt/nginx/conf/nginx.conf"}}, "/etc/nginx/nginx.conf")
+	if r.primaryID() != "/opt/nginx/conf/nginx.conf" {
+		t.Errorf("primary = %q", r.primaryID())
+	}
+	if dirs := r.configDirs(); len(dirs) != 1 || dirs[0] != "/opt/nginx/conf" {
+		t.Errorf("config dirs = %v", dirs)
+	}
+}
+
+func TestParseInstanceStatusURLs(t *testing.T) {
+	urls := parseInstanceStatusURLs(" /etc/nginx-api/nginx.conf=http://127.0.0.1:8081/nginx_status?x=1 , bad, =http://x")
+	if len(urls) != 1 || urls["/etc/nginx-api/nginx.conf"] != "http://127.0.0.1:8081/nginx_status?x=1" {
+		t.Errorf("urls = %v", urls)
+	}
+}
+
+func TestInstanceTargets(t *testing.T) {
+	defer nginxInstances.set(nil, "")
+	nginxInstances.set([]*pb.NginxInstance{
+		{InstanceId: "/etc/nginx/nginx.conf", ConfPath: "/etc/nginx/nginx.conf", Pid: "100"},
+		{InstanceId: "/etc/nginx-api/nginx.conf", ConfPath: "/etc/nginx-api/nginx.conf", Pid: "200",
+			AccessLog: "/var/log/nginx-api/access.log"},
+	}, "/etc/nginx/nginx.conf")
+
+	s := &mgmtServer{configPath: "/etc/nginx/nginx.conf"}
+	if _, path, err := s.instanceTarget("/etc/nginx-api/nginx.conf"); err != nil || path != "/etc/nginx-api/nginx.conf" {
+		t.Errorf("target = %q, %v", path, err)
+	}
+	if _, path, err := s.instanceTarget(""); err != nil || path != "/etc/nginx/nginx.conf" {
+		t.Errorf("default target = %q, %v", path, err)
+	}
+	resp, err := s.ReloadNginx(context.Background(), &pb.ReloadRequest{NginxInstanceId: "/etc/nginx-web/nginx.conf"})
+	if err != nil || resp.Success || !strings.Contains(resp.Error, "unknown NGINX instance") {
+		t.Errorf("reload of unknown instance = %+v, %v", resp, err)
+	}
+	if !isAllowedConfigPath("/etc/nginx-api/conf.d/app.conf") {
+		t.Error("config of a discovered instance is not allowed")
+	}
+
+	if path, format, err := instanceLogPath(&pb.LogRequest{NginxInstanceId: "/etc/nginx-api/nginx.conf"}); err != nil || path != "/var/log/nginx-api/access.log" || format != "combined" {
+		t.Errorf("access log = %q %q %v", path, format, err)
+	}
+	if _, _, err := instanceLogPath(&pb.LogRequest{NginxInstanceId: "/etc/nginx-api/nginx.conf", LogType: "error"}); err == nil {
+		t.Error("expected an error for an instance without an error log file")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/kube"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// defaultPodInfoLabelsFile is where the Downward API labels volume is expected; override with PODINFO_LABELS_FILE
+const defaultPodInfoLabelsFile = "/etc/podinfo/labels"
+
+// kubernetesMetadataRefresh bounds how stale the reported pod labels can be
+const kubernetesMetadataRefresh = 5 * time.Minute
+
+var (
+	kubeMetaMu sync.Mutex
+	kubeMeta   *pb.KubernetesMetadata
+	kubeMetaAt time.Time
+)
+
+// kubernetesMetadata returns the pod metadata reported in heartbeats, or nil outside Kubernetes.
+// The result is cached and must not be modified.
+func kubernetesMetadata(isPod bool) *pb.KubernetesMetadata {
+	if !isPod {
+		return nil
+	}
+	kubeMetaMu.Lock()
+	defer kubeMetaMu.Unlock()
+	if kubeMeta == nil || time.Since(kubeMetaAt) >= kubernetesMetadataRefresh {
+		kubeMeta = collectKubernetesMetadata(newKubeClient())
+		kubeMetaAt = time.Now()
+	}
+	return kubeMeta
+}
+
+// newKubeClient returns an in-cluster API client, or nil when the service account is not mounted
+func newKubeClient() *kube.Client {
+	client, err := kube.NewInClusterClient()
+	if err != nil {
+		agentLog.Debug().Msgf("Kubernetes API unavailable, using Downward API only: %v", err)
+		return nil
+	}
+	return client
+}
+
+// collectKubernetesMetadata reads the Downward API environment (POD_NAME, POD_NAMESPACE, POD_UID,
+// NODE_NAME) and labels file, then fills the gaps and the owner from the kube API when allowed
+func collectKubernetesMetadata(client *kube.Client) *pb.KubernetesMetadata {
+	meta := &pb.KubernetesMetadata{
+		Namespace: os.Getenv("POD_NAMESPACE"),
+		PodName:   os.Getenv("POD_NAME"),
+		PodUid:    os.Getenv("POD_UID"),
+		NodeName:  os.Getenv("NODE_NAME"),
+	}
+	if meta.Namespace == "" {
+		if data, err := os.ReadFile(kube.ServiceAccountDir + "/namespace"); err == nil {
+			meta.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+	if meta.PodName == "" {
+		// The pod hostname is the pod name unless spec.hostname overrides it
+		meta.PodName, _ = os.Hostname()
+	}
+
+	labelsFile := os.Getenv("PODINFO_LABELS_FILE")
+	if labelsFile == "" {
+		labelsFile = defaultPodInfoLabelsFile
+	}
+	if f, err := os.Open(labelsFile); err == nil {
+		if labels, err := kube.ParseDownwardLabels(f); err == nil {
+			meta.PodLabels = labels
+		} else {
+			agentLog.Warn().Msgf("Failed to parse pod labels from %s: %v", labelsFile, err)
+		}
+		f.Close()
+	}
+
+	if client != nil && meta.Namespace != "" && meta.PodName != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := fillFromKubeAPI(ctx, client, meta); err != nil {
+			agentLog.Debug().Msgf("Kubernetes API lookup of pod %s/%s failed: %v", meta.Namespace, meta.PodName, err)
+		}
+	}
+	return meta
+}
+
+// fillFromKubeAPI completes meta from the pod object and resolves its controlling owner
+func fillFromKubeAPI(ctx context.Context, client *kube.Client, meta *pb.KubernetesMetadata) error {
+	pod, err := client.GetPod(ctx, meta.Namespace, meta.PodName)
+	if err != nil {
+		return err
+	}
+	if meta.PodUid == "" {
+		meta.PodUid = pod.Metadata.UID
+	}
+	if meta.NodeName == "" {
+		meta.NodeName = pod.Spec.NodeName
+	}
+	if len(meta.PodLabels) == 0 {
+		meta.PodLabels = pod.Metadata.Labels
+	}
+
+	owner := kube.ControllerOf(pod.Metadata)
+	if owner == nil {
+		return nil
+	}
+	meta.OwnerKind, meta.OwnerName = owner.Kind, owner.Name
+	if owner.Kind != "ReplicaSet" {
+		return nil
+	}
+	// Report the Deployment rather than the ReplicaSet of the current rollout
+	if rs, err := client.GetReplicaSet(ctx, meta.Namespace, owner.Name); err == nil {
+		if dep := kube.ControllerOf(*rs); dep != nil {
+			meta.OwnerKind, meta.OwnerName = dep.Kind, dep.Name
+		}
+	} else if hash := pod.Metadata.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+		// No access to ReplicaSets: Deployment ReplicaSets are named <deployment>-<pod-template-hash>
+		meta.OwnerKind, meta.OwnerName = "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avika-ai/avika/internal/common/kube"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const kubePodFixture = `{"metadata":{"name":"web-6b7c9-x2x4z","namespace":"shop","uid":"pod-uid",
+	"labels":{"app":"web","pod-template-hash":"6b7c9"},
+	"ownerReferences":[{"kind":"ReplicaSet","name":"web-6b7c9","controller":true}]},
+	"spec":{"nodeName":"node-a"}}`
+
+func TestFillFromKubeAPI(t *testing.T) {
+	rsAllowed := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/shop/pods/web-6b7c9-x2x4z":
+			w.Write([]byte(kubePodFixture))
+		case "/apis/apps/v1/namespaces/shop/replicasets/web-6b7c9":
+			if !rsAllowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"metadata":{"name":"web-6b7c9","ownerReferences":[{"kind":"Deployment","name":"web-frontend","controller":true}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client, err := kube.NewClient(kube.Config{Host: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &pb.KubernetesMetadata{Namespace: "shop", PodName: "web-6b7c9-x2x4z", NodeName: "from-env"}
+	if err := fillFromKubeAPI(context.Background(), client, meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.PodUid != "pod-uid" || meta.NodeName != "from-env" || meta.PodLabels["app"] != "web" {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if meta.OwnerKind != "Deployment" || meta.OwnerName != "web-frontend" {
+		t.Errorf("owner = %s/%s, want Deployment/web-frontend", meta.OwnerKind, meta.OwnerName)
+	}
+
+	// Without access to ReplicaSets the Deployment name comes from the pod-template-hash suffix
+	rsAllowed = false
+	meta = &pb.KubernetesMetadata{Namespace: "shop", PodName: "web-6b7c9-x2x4z"}
+	if err := fillFromKubeAPI(context.Background(), client, meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.OwnerKind != "Deployment" || meta.OwnerName != "web" {
+		t.Errorf("owner = %s/%s, want Deployment/web", meta.OwnerKind, meta.OwnerName)
+	}
+
+	if err := fillFromKubeAPI(context.Background(), client, &pb.KubernetesMetadata{Namespace: "shop", PodName: "gone"}); err != kube.ErrNotFound {
+		t.Errorf("missing pod error = %v", err)
+	}
+}
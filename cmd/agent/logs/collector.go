@@ -3,24 +3,35 @@ package logs
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/redact"
 )
 
+var logsLog = logging.NewLogger("logs")
+
 type LogCollector struct {
+	mu            sync.Mutex // guards the paths and sources across Reconfigure
 	accessLogPath string
 	errorLogPath  string
 	logFormat     string
-	accessTailer  *Tailer
-	errorTailer   *Tailer
+	source        SourceConfig
+	sources       []logSource
+	paused        bool
+	offsets       *OffsetStore
+	primary       string         // instance ID of the nginx instance the log paths belong to
+	instances     []InstanceLogs // log files of the other nginx instances on the host
 
 	exporter         *OTLPExporter
 	syslogForwarder  *LogSyslogForwarder
+	redactor         atomic.Pointer[redact.Redactor] // scrubs entries before they leave the agent
 
 	// Channels for distribution
 	gatewayChan chan *pb.LogEntry
@@ -37,7 +48,7 @@ func NewLogCollector(accessLog, errorLog, logFormat, otlpEndpoint, agentID, host
 	if otlpEndpoint != "" {
 		exp, err := NewOTLPExporter(otlpEndpoint, agentID, hostname)
 		if err != nil {
-			log.Printf("[ERROR] Failed to create OTLP exporter: %v", err)
+			logsLog.Error().Msgf("Failed to create OTLP exporter: %v", err)
 		} else {
 			exporter = exp
 		}
@@ -47,7 +58,7 @@ func NewLogCollector(accessLog, errorLog, logFormat, otlpEndpoint, agentID, host
 	if len(syslogCfg) > 0 && syslogCfg[0].Enabled {
 		syslog = NewLogSyslogForwarder(syslogCfg[0])
 		if syslog != nil {
-			log.Printf("[INFO] Syslog forwarder enabled: %s", syslogCfg[0].TargetAddress)
+			logsLog.Info().Msgf("Syslog forwarder enabled: %s", syslogCfg[0].TargetAddress)
 		}
 	}
 
@@ -63,29 +74,107 @@ func NewLogCollector(accessLog, errorLog, logFormat, otlpEndpoint, agentID, host
 	}
 }
 
+// SetSource makes the collector receive logs over syslog or from journald instead of tailing the
+// log files. It takes effect on Start or the next Reconfigure.
+func (c *LogCollector) SetSource(source SourceConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source = source
+}
+
+// SetRedactor scrubs entries with r before they are sent anywhere; nil stops scrubbing. It takes
+// effect on the next entry.
+func (c *LogCollector) SetRedactor(r *redact.Redactor) {
+	c.redactor.Store(r)
+}
+
+// SetOffsetsFile makes the log file tailers save how far they read to path and resume from there
+// after a restart. It takes effect on Start.
+func (c *LogCollector) SetOffsetsFile(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offsets = NewOffsetStore(path)
+}
+
+// InstanceLogs is the log files of one of several nginx instances on the host
+type InstanceLogs struct {
+	InstanceID string
+	AccessLog  string
+	ErrorLog   string
+}
+
+// SetInstances labels entries from the configured log files with the primary instance's ID and
+// also tails the log files of the other instances, each entry labelled with its instance.
+// Collection restarts when they change; tailers resume from their offsets.
+func (c *LogCollector) SetInstances(primary string, others []InstanceLogs) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx.Err() != nil || (primary == c.primary && slices.Equal(others, c.instances)) {
+		return
+	}
+	c.stopSources()
+	c.wg.Wait()
+	c.primary, c.instances = primary, others
+	if !c.paused {
+		c.start()
+	}
+	if len(others) > 0 {
+		logsLog.Info().Msgf("Collecting the logs of %d NGINX instances", len(others)+1)
+	}
+}
+
 func (c *LogCollector) Start() {
-	// Start Access Log Tailer
-	c.accessTailer = NewTailer(c.accessLogPath, c.logFormat)
-	accChan, err := c.accessTailer.Start()
-	if err != nil {
-		log.Printf("[ERROR] Failed to start access log tailer: %v", err)
-	} else {
-		c.wg.Add(1)
-		go c.consume(accChan)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.start()
+}
+
+func (c *LogCollector) start() {
+	c.sources = nil
+	switch c.source.Mode {
+	case SourceSyslog:
+		receiver, err := NewSyslogReceiver(c.source.SyslogListen, c.logFormat)
+		if err != nil {
+			logsLog.Error().Msgf("Failed to start syslog receiver: %v", err)
+			return
+		}
+		c.startSource("syslog receiver", receiver, "")
+	case SourceJournald:
+		c.startSource("journald reader", NewJournaldReader(c.source.JournaldMatch, c.logFormat), "")
+	default:
+		access, errorLog := NewTailer(c.accessLogPath, c.logFormat), NewTailer(c.errorLogPath, "combined") // Error logs are usually not the same JSON format
+		access.Offsets, errorLog.Offsets = c.offsets, c.offsets
+		c.startSource("access log tailer", access, c.primary)
+		c.startSource("error log tailer", errorLog, c.primary)
+		tailed := map[string]bool{c.accessLogPath: true, c.errorLogPath: true}
+		for _, inst := range c.instances {
+			// Other instances are read as combined; files they share with another instance are tailed once
+			for _, path := range []string{inst.AccessLog, inst.ErrorLog} {
+				if path == "" || tailed[path] {
+					continue
+				}
+				tailed[path] = true
+				t := NewTailer(path, "combined")
+				t.Offsets = c.offsets
+				c.startSource("log tailer for "+inst.InstanceID, t, inst.InstanceID)
+			}
+		}
 	}
+}
 
-	// Start Error Log Tailer
-	c.errorTailer = NewTailer(c.errorLogPath, "combined") // Error logs are usually not the same JSON format
-	errChan, err := c.errorTailer.Start()
+// startSource starts a source; instance, when set, labels its entries with an nginx instance ID
+func (c *LogCollector) startSource(name string, source logSource, instance string) {
+	entries, err := source.Start()
 	if err != nil {
-		log.Printf("[ERROR] Failed to start error log tailer: %v", err)
-	} else {
-		c.wg.Add(1)
-		go c.consume(errChan)
+		logsLog.Error().Msgf("Failed to start %s: %v", name, err)
+		return
 	}
+	c.sources = append(c.sources, source)
+	c.wg.Add(1)
+	go c.consume(entries, instance)
 }
 
-func (c *LogCollector) consume(input <-chan *pb.LogEntry) {
+func (c *LogCollector) consume(input <-chan *pb.LogEntry, instance string) {
 	defer c.wg.Done()
 	for {
 		select {
@@ -93,6 +182,13 @@ func (c *LogCollector) consume(input <-chan *pb.LogEntry) {
 			if !ok {
 				return
 			}
+			if instance != "" {
+				if entry.Labels == nil {
+					entry.Labels = make(map[string]string, 1)
+				}
+				entry.Labels[InstanceLabel] = instance
+			}
+			c.redactor.Load().Apply(entry)
 			// Forward to Gateway
 			select {
 			case c.gatewayChan <- entry:
@@ -124,14 +220,64 @@ func (c *LogCollector) consume(input <-chan *pb.LogEntry) {
 	}
 }
 
-func (c *LogCollector) Stop() {
-	c.cancel()
-	if c.accessTailer != nil {
-		c.accessTailer.Stop()
+// Reconfigure restarts collection on new log paths or format; entries keep flowing to the same
+// gateway channel
+func (c *LogCollector) Reconfigure(accessLog, errorLog, logFormat string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx.Err() != nil || (accessLog == c.accessLogPath && errorLog == c.errorLogPath && logFormat == c.logFormat) {
+		return
 	}
-	if c.errorTailer != nil {
-		c.errorTailer.Stop()
+	c.stopSources()
+	c.wg.Wait() // consumers return once their source's channel closes
+	c.accessLogPath, c.errorLogPath, c.logFormat = accessLog, errorLog, logFormat
+	if !c.paused {
+		c.start()
 	}
+	logsLog.Info().Msgf("Log collection reconfigured (access: %s, error: %s, format: %s)", accessLog, errorLog, logFormat)
+}
+
+// Pause stops collection until Resume. Tailers restart at the end of their files, so lines
+// logged in between are skipped; the agent pauses to shed load.
+func (c *LogCollector) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused || c.ctx.Err() != nil {
+		return
+	}
+	c.paused = true
+	c.stopSources()
+	c.wg.Wait()
+	logsLog.Warn().Msg("Log collection paused")
+}
+
+// Resume restarts collection after Pause
+func (c *LogCollector) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused || c.ctx.Err() != nil {
+		return
+	}
+	c.paused = false
+	if c.offsets != nil {
+		c.offsets.Reset() // skip what was logged while paused
+	}
+	c.start()
+	logsLog.Info().Msg("Log collection resumed")
+}
+
+func (c *LogCollector) stopSources() {
+	for _, source := range c.sources {
+		source.Stop()
+	}
+	c.sources = nil
+}
+
+func (c *LogCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancel()
+	c.stopSources()
 	c.wg.Wait()
 	close(c.gatewayChan)
 
@@ -248,7 +394,7 @@ func (s *LogSyslogForwarder) connect() error {
 		if err == nil {
 			s.conn = conn
 			if attempts > 0 {
-				log.Printf("[SYSLOG] Reconnected to %s://%s after %d attempts", s.network, s.address, attempts+1)
+				logsLog.Info().Msgf("[SYSLOG] Reconnected to %s://%s after %d attempts", s.network, s.address, attempts+1)
 			}
 			return nil
 		}
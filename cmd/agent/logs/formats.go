@@ -0,0 +1,248 @@
+package logs
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// LineParser turns one access log line into a LogEntry. Lines it cannot parse are returned as
+// an entry carrying only the raw content, never as an error that drops them.
+type LineParser interface {
+	ParseLine(line string) (*pb.LogEntry, error)
+}
+
+// accessLogFormats are the access log formats selectable with LOG_FORMAT
+var accessLogFormats = map[string]func() LineParser{
+	"combined": func() LineParser { return NewParser("combined") }, // NGINX combined
+	"json":     func() LineParser { return NewParser("json") },     // NGINX JSON (see jsonLog)
+	"apache":   func() LineParser { return newApacheParser() },
+	"caddy":    func() LineParser { return caddyParser{} },
+	"haproxy":  func() LineParser { return newHAProxyParser() },
+}
+
+// NewLineParser returns the parser of an access log format, NGINX combined for unknown formats.
+// A format containing $variables is taken as an NGINX log_format string.
+func NewLineParser(format string) LineParser {
+	if strings.Contains(format, "$") {
+		if p, err := NewFormatParser(format); err == nil {
+			return p
+		}
+	}
+	if newParser, ok := accessLogFormats[normalizeFormat(format)]; ok {
+		return newParser()
+	}
+	return NewParser("combined")
+}
+
+// IsSupportedFormat reports whether format names an access log format
+func IsSupportedFormat(format string) bool {
+	_, ok := accessLogFormats[normalizeFormat(format)]
+	return ok
+}
+
+// SupportedFormats lists the access log formats, sorted
+func SupportedFormats() []string {
+	formats := make([]string, 0, len(accessLogFormats))
+	for f := range accessLogFormats {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func rawEntry(line string) *pb.LogEntry {
+	return &pb.LogEntry{Timestamp: time.Now().Unix(), LogType: "access", Content: line}
+}
+
+// apacheParser parses Apache's common and combined formats, optionally followed by %D (request
+// time in microseconds): %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i" %D
+type apacheParser struct {
+	regex *regexp.Regexp
+}
+
+func newApacheParser() *apacheParser {
+	return &apacheParser{regex: regexp.MustCompile(
+		`^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) (\S+)[^"]*" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?(?: (\d+))?`)}
+}
+
+func (p *apacheParser) ParseLine(line string) (*pb.LogEntry, error) {
+	m := p.regex.FindStringSubmatch(line)
+	if m == nil {
+		return rawEntry(line), nil
+	}
+	ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[2])
+	if err != nil {
+		ts = time.Now()
+	}
+	status, _ := strconv.Atoi(m[5])
+	bytesSent, _ := strconv.ParseInt(m[6], 10, 64) // "-" is zero bytes
+	entry := &pb.LogEntry{
+		Timestamp:     ts.Unix(),
+		LogType:       "access",
+		Content:       line,
+		RemoteAddr:    m[1],
+		RequestMethod: m[3],
+		RequestUri:    m[4],
+		Status:        int32(status),
+		BodyBytesSent: bytesSent,
+		Referer:       dashEmpty(m[7]),
+		UserAgent:     dashEmpty(m[8]),
+	}
+	if us, err := strconv.ParseInt(m[9], 10, 64); err == nil {
+		entry.RequestTime = float32(us) / 1e6
+	}
+	return entry, nil
+}
+
+// caddyLog is the part of Caddy's JSON access log (logger http.log.access) mapped to LogEntry
+type caddyLog struct {
+	Ts      json.RawMessage `json:"ts"` // unix seconds, or a string with a custom time_format
+	Request struct {
+		RemoteIP string              `json:"remote_ip"`
+		ClientIP string              `json:"client_ip"`
+		Method   string              `json:"method"`
+		URI      string              `json:"uri"`
+		Headers  map[string][]string `json:"headers"`
+	} `json:"request"`
+	Duration  json.RawMessage `json:"duration"` // seconds, or a Go duration string with duration_format
+	Size      int64           `json:"size"`
+	BytesRead int64           `json:"bytes_read"` // request body only; Caddy doesn't log header sizes
+	Status    int32           `json:"status"`
+}
+
+type caddyParser struct{}
+
+func (caddyParser) ParseLine(line string) (*pb.LogEntry, error) {
+	var cl caddyLog
+	if err := json.Unmarshal([]byte(line), &cl); err != nil || cl.Request.Method == "" {
+		return rawEntry(line), nil
+	}
+	header := func(name string) string {
+		if v := cl.Request.Headers[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	remote := cl.Request.ClientIP
+	if remote == "" {
+		remote = cl.Request.RemoteIP
+	}
+	return &pb.LogEntry{
+		Timestamp:     caddyTime(cl.Ts).Unix(),
+		LogType:       "access",
+		Content:       line,
+		RemoteAddr:    remote,
+		RequestMethod: cl.Request.Method,
+		RequestUri:    cl.Request.URI,
+		Status:        cl.Status,
+		BodyBytesSent: cl.Size,
+		RequestLength: cl.BytesRead,
+		RequestTime:   caddySeconds(cl.Duration),
+		RequestId:     header("X-Request-Id"),
+		XRequestId:    header("X-Request-Id"),
+		Traceparent:   header("Traceparent"),
+		Referer:       header("Referer"),
+		UserAgent:     header("User-Agent"),
+		XForwardedFor: header("X-Forwarded-For"),
+	}, nil
+}
+
+func caddyTime(raw json.RawMessage) time.Time {
+	var secs float64
+	if json.Unmarshal(raw, &secs) == nil && secs > 0 {
+		return time.Unix(0, int64(secs*1e9))
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		for _, layout := range []string{time.RFC3339Nano, "2006/01/02 15:04:05.000"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now()
+}
+
+func caddySeconds(raw json.RawMessage) float32 {
+	var secs float64
+	if json.Unmarshal(raw, &secs) == nil {
+		return float32(secs)
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if d, err := time.ParseDuration(s); err == nil {
+			return float32(d.Seconds())
+		}
+	}
+	return 0
+}
+
+// haproxyParser parses HAProxy's HTTP log format (option httplog), with or without the syslog
+// prefix: client:port [accept_date] frontend backend/server TR/Tw/Tc/Tr/Ta status bytes ...
+// "method uri proto". Timers are in milliseconds, -1 when the phase was not reached.
+type haproxyParser struct {
+	regex *regexp.Regexp
+}
+
+func newHAProxyParser() *haproxyParser {
+	return &haproxyParser{regex: regexp.MustCompile(
+		`(\S+):\d+ \[([^\]]+)\] \S+ (\S+)/(\S+) (-?\d+)/(-?\d+)/(-?\d+)/(-?\d+)/\+?(-?\d+) (-?\d+) \+?(\d+) .*?"(\S+) (\S+)`)}
+}
+
+func (p *haproxyParser) ParseLine(line string) (*pb.LogEntry, error) {
+	m := p.regex.FindStringSubmatch(line)
+	if m == nil {
+		return rawEntry(line), nil
+	}
+	// accept_date has no zone; HAProxy logs local time
+	ts, err := time.ParseInLocation("02/Jan/2006:15:04:05.000", m[2], time.Local)
+	if err != nil {
+		ts = time.Now()
+	}
+	ms := func(s string) float32 {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return 0
+		}
+		return float32(v) / 1000
+	}
+	status, _ := strconv.Atoi(m[10])
+	bytesRead, _ := strconv.ParseInt(m[11], 10, 64)
+	entry := &pb.LogEntry{
+		Timestamp:           ts.Unix(),
+		LogType:             "access",
+		Content:             line,
+		RemoteAddr:          m[1],
+		RequestMethod:       m[12],
+		RequestUri:          m[13],
+		Status:              int32(status),
+		BodyBytesSent:       bytesRead,
+		RequestTime:         ms(m[9]),
+		UpstreamConnectTime: ms(m[7]),
+		UpstreamHeaderTime:  ms(m[8]),
+	}
+	if m[4] != "<NOSRV>" {
+		entry.UpstreamAddr = m[3] + "/" + m[4]
+		entry.UpstreamStatus = m[10]
+		entry.UpstreamResponseTime = ms(m[8])
+	}
+	return entry, nil
+}
+
+func dashEmpty(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// normalizeFormat makes LOG_FORMAT values case-insensitive
+func normalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimSpace(format))
+}
@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApacheParser(t *testing.T) {
+	p := NewLineParser("apache")
+	line := `203.0.113.7 - frank [10/Oct/2026:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08" 1532`
+	e, _ := p.ParseLine(line)
+	if e.RemoteAddr != "203.0.113.7" || e.RequestMethod != "GET" || e.RequestUri != "/apache_pb.gif" || e.Status != 200 || e.BodyBytesSent != 2326 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Referer != "http://www.example.com/start.html" || e.UserAgent != "Mozilla/4.08" || e.RequestTime != 0.001532 {
+		t.Errorf("entry = %+v", e)
+	}
+	if want := time.Date(2026, 10, 10, 20, 55, 36, 0, time.UTC).Unix(); e.Timestamp != want {
+		t.Errorf("timestamp = %d, want %d", e.Timestamp, want)
+	}
+
+	common, _ := p.ParseLine(`10.0.0.1 - - [10/Oct/2026:13:55:36 +0000] "POST /login HTTP/1.1" 302 -`)
+	if common.Status != 302 || common.BodyBytesSent != 0 || common.RequestMethod != "POST" {
+		t.Errorf("common = %+v", common)
+	}
+}
+
+func TestCaddyParser(t *testing.T) {
+	p := NewLineParser("Caddy")
+	line := `{"level":"info","ts":1791640536.5,"logger":"http.log.access","msg":"handled request","request":{"remote_ip":"10.0.0.5","client_ip":"198.51.100.4","proto":"HTTP/2.0","method":"GET","host":"example.com","uri":"/api/v1?x=1","headers":{"User-Agent":["curl/8.4.0"],"X-Request-Id":["abc123"]}},"duration":0.0421,"bytes_read":96,"size":512,"status":404}`
+	e, _ := p.ParseLine(line)
+	if e.RemoteAddr != "198.51.100.4" || e.RequestMethod != "GET" || e.RequestUri != "/api/v1?x=1" || e.Status != 404 || e.BodyBytesSent != 512 || e.RequestLength != 96 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.UserAgent != "curl/8.4.0" || e.RequestId != "abc123" || e.RequestTime != float32(0.0421) || e.Timestamp != 1791640536 {
+		t.Errorf("entry = %+v", e)
+	}
+
+	withFormats, _ := p.ParseLine(`{"ts":"2026-10-10T13:55:36Z","request":{"remote_ip":"10.0.0.5","method":"HEAD","uri":"/"},"duration":"250ms","status":200}`)
+	if withFormats.RequestTime != 0.25 || withFormats.Timestamp != time.Date(2026, 10, 10, 13, 55, 36, 0, time.UTC).Unix() || withFormats.RemoteAddr != "10.0.0.5" {
+		t.Errorf("custom formats = %+v", withFormats)
+	}
+}
+
+func TestHAProxyParser(t *testing.T) {
+	p := NewLineParser("haproxy")
+	line := `Feb  6 12:14:14 localhost haproxy[14389]: 10.0.1.2:33317 [06/Feb/2026:12:14:14.655] http-in static/srv1 10/0/30/69/109 200 2750 - - ---- 1/1/1/1/0 0/0 {1wt.eu} {} "GET /index.html HTTP/1.1"`
+	e, _ := p.ParseLine(line)
+	if e.RemoteAddr != "10.0.1.2" || e.RequestMethod != "GET" || e.RequestUri != "/index.html" || e.Status != 200 || e.BodyBytesSent != 2750 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.UpstreamAddr != "static/srv1" || e.UpstreamStatus != "200" || e.RequestTime != float32(0.109) || e.UpstreamConnectTime != float32(0.03) || e.UpstreamHeaderTime != float32(0.069) {
+		t.Errorf("entry = %+v", e)
+	}
+
+	noServer, _ := p.ParseLine(`10.0.1.3:40000 [06/Feb/2026:12:14:15.001] http-in http-in/<NOSRV> 0/-1/-1/-1/0 403 192 - - PR-- 0/0/0/0/0 0/0 "GET /admin HTTP/1.1"`)
+	if noServer.Status != 403 || noServer.UpstreamAddr != "" || noServer.UpstreamConnectTime != 0 {
+		t.Errorf("no server = %+v", noServer)
+	}
+}
+
+func TestNewLineParser(t *testing.T) {
+	for _, format := range []string{"apache", "caddy", "haproxy"} {
+		e, err := NewLineParser(format).ParseLine("not an access log line")
+		if err != nil || e.Content != "not an access log line" || e.Status != 0 {
+			t.Errorf("%s: unparsable line = %+v, %v", format, e, err)
+		}
+	}
+	if _, ok := NewLineParser("iis").(*Parser); !ok {
+		t.Error("unknown formats should fall back to the NGINX parser")
+	}
+	if !IsSupportedFormat(" HAProxy ") || IsSupportedFormat("iis") {
+		t.Error("IsSupportedFormat")
+	}
+	if got := len(SupportedFormats()); got != 5 {
+		t.Errorf("SupportedFormats = %d formats", got)
+	}
+}
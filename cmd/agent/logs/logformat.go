@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// logFormatVar matches the variables of an NGINX log_format: $name or ${name}
+var logFormatVar = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// FormatParser parses access logs written with a custom NGINX log_format. The format string is
+// turned into a regex capturing each variable; variables with a LogEntry field of their own are
+// mapped to it and the others end up in Labels.
+type FormatParser struct {
+	regex *regexp.Regexp
+	vars  []string // variable of each capture group
+}
+
+// NewFormatParser compiles the body of an NGINX log_format directive, e.g.
+// $remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer"
+func NewFormatParser(format string) (*FormatParser, error) {
+	format = strings.TrimSpace(format)
+	locs := logFormatVar.FindAllStringSubmatchIndex(format, -1)
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("log format has no $variables")
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	vars := make([]string, 0, len(locs))
+	last := 0
+	for i, loc := range locs {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		name := varName(format, loc)
+		vars = append(vars, name)
+		// A variable runs up to the next literal; two adjacent variables cannot be told apart
+		if i+1 < len(locs) && locs[i+1][0] == loc[1] {
+			return nil, fmt.Errorf("$%s and $%s need a separator between them", name, varName(format, locs[i+1]))
+		}
+		if loc[1] == len(format) {
+			pattern.WriteString("(.*)")
+		} else {
+			pattern.WriteString("(.*?)")
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	regex, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, err
+	}
+	return &FormatParser{regex: regex, vars: vars}, nil
+}
+
+// varName returns the name of the variable at loc, in either of its spellings
+func varName(format string, loc []int) string {
+	if loc[2] >= 0 {
+		return format[loc[2]:loc[3]]
+	}
+	return format[loc[4]:loc[5]]
+}
+
+// ParseLine parses a single access log line
+func (p *FormatParser) ParseLine(line string) (*pb.LogEntry, error) {
+	m := p.regex.FindStringSubmatch(line)
+	if m == nil {
+		return rawEntry(line), nil
+	}
+	entry := &pb.LogEntry{LogType: "access", Content: line}
+	var ts time.Time
+	uri := ""
+	for i, name := range p.vars {
+		val := m[i+1]
+		if val == "" || val == "-" {
+			continue
+		}
+		switch name {
+		case "remote_addr":
+			entry.RemoteAddr = val
+		case "time_local":
+			ts, _ = time.Parse("02/Jan/2006:15:04:05 -0700", val)
+		case "time_iso8601":
+			ts, _ = time.Parse(time.RFC3339, val)
+		case "msec":
+			if secs, err := strconv.ParseFloat(val, 64); err == nil {
+				ts = time.Unix(0, int64(secs*1e9))
+			}
+		case "request":
+			if parts := strings.Fields(val); len(parts) >= 2 {
+				entry.RequestMethod, entry.RequestUri = parts[0], parts[1]
+			}
+		case "request_method":
+			entry.RequestMethod = val
+		case "request_uri":
+			entry.RequestUri = val
+		case "uri":
+			uri = val
+		case "status":
+			status, _ := strconv.Atoi(val)
+			entry.Status = int32(status)
+		case "body_bytes_sent":
+			entry.BodyBytesSent, _ = strconv.ParseInt(val, 10, 64)
+		case "request_length":
+			entry.RequestLength, _ = strconv.ParseInt(val, 10, 64)
+		case "request_time":
+			entry.RequestTime = timingSeconds(val)
+		case "request_id":
+			entry.RequestId = val
+		case "upstream_addr":
+			entry.UpstreamAddr = val
+		case "upstream_status":
+			entry.UpstreamStatus = val
+		case "upstream_connect_time":
+			entry.UpstreamConnectTime = timingSeconds(val)
+		case "upstream_header_time":
+			entry.UpstreamHeaderTime = timingSeconds(val)
+		case "upstream_response_time":
+			entry.UpstreamResponseTime = timingSeconds(val)
+		case "upstream_cache_status":
+			entry.UpstreamCacheStatus = val
+		case "http_referer":
+			entry.Referer = val
+		case "http_user_agent":
+			entry.UserAgent = val
+		case "http_x_forwarded_for":
+			entry.XForwardedFor = val
+		case "http_traceparent":
+			entry.Traceparent = val
+		case "http_x_request_id":
+			entry.XRequestId = val
+		default:
+			if entry.Labels == nil {
+				entry.Labels = make(map[string]string)
+			}
+			entry.Labels[name] = val
+		}
+	}
+	if entry.RequestUri == "" {
+		entry.RequestUri = uri
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	entry.Timestamp = ts.Unix()
+	return entry, nil
+}
+
+// timingSeconds adds up an NGINX timing variable; the upstream ones list one value per upstream tried
+// ("0.012, 0.034" or "0.012 : 0.034" across internal redirects)
+func timingSeconds(val string) float32 {
+	var total float64
+	for _, part := range strings.FieldsFunc(val, func(r rune) bool { return r == ',' || r == ':' || r == ' ' }) {
+		if f, err := strconv.ParseFloat(part, 64); err == nil {
+			total += f
+		}
+	}
+	return float32(total)
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+const customFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" rt=$request_time len=$request_length uct="$upstream_connect_time" urt="$upstream_response_time" host=$host cache=${upstream_cache_status} tp="$http_traceparent"`
+
+func TestFormatParser(t *testing.T) {
+	p, err := NewFormatParser(customFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := `192.0.2.10 - - [16/Oct/2026:09:30:00 +0000] "GET /shop/cart?id=7 HTTP/2.0" 200 5120 "-" "Mozilla/5.0 (X11; Linux x86_64)" rt=0.250 len=734 uct="0.001" urt="0.100, 0.140" host=shop.example.com cache=HIT tp="00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"`
+	e, _ := p.ParseLine(line)
+	if e.RemoteAddr != "192.0.2.10" || e.RequestMethod != "GET" || e.RequestUri != "/shop/cart?id=7" || e.Status != 200 || e.BodyBytesSent != 5120 || e.RequestLength != 734 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Referer != "" || e.UserAgent != "Mozilla/5.0 (X11; Linux x86_64)" || e.RequestTime != 0.25 || e.UpstreamConnectTime != float32(0.001) || e.UpstreamResponseTime != float32(0.24) {
+		t.Errorf("entry = %+v", e)
+	}
+	if want := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC).Unix(); e.Timestamp != want {
+		t.Errorf("timestamp = %d, want %d", e.Timestamp, want)
+	}
+	if e.UpstreamCacheStatus != "HIT" || e.Traceparent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("cache status = %q, traceparent = %q", e.UpstreamCacheStatus, e.Traceparent)
+	}
+	if len(e.Labels) != 1 || e.Labels["host"] != "shop.example.com" {
+		t.Errorf("labels = %v (remote_user is empty and should be left out)", e.Labels)
+	}
+
+	if e, _ := p.ParseLine("some other line"); e.Content != "some other line" || e.Status != 0 {
+		t.Errorf("unmatched line = %+v", e)
+	}
+
+	if _, ok := NewLineParser(customFormat).(*FormatParser); !ok {
+		t.Error("NewLineParser should compile formats with $variables")
+	}
+	for _, bad := range []string{"combined", "$status$body_bytes_sent"} {
+		if _, err := NewFormatParser(bad); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
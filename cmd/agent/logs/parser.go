@@ -2,7 +2,6 @@ package logs
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -26,12 +25,16 @@ type jsonLog struct {
 	Path     string  `json:"path"`
 	Status   int32   `json:"status"`
 	Bytes    int64   `json:"bytes"`
+	ReqLen   int64   `json:"req_len"`
 	Rt       float32 `json:"rt"`
 	Uct      string  `json:"uct"`
 	Uht      string  `json:"uht"`
 	Urt      string  `json:"urt"`
 	Upstream string  `json:"upstream"`
 	Ustatus  string  `json:"ustatus"`
+	Cache    string  `json:"cache"`
+	TraceCtx string  `json:"traceparent"`
+	XReqID   string  `json:"x_request_id"`
 	Referer  string  `json:"referer"`
 	UA       string  `json:"ua"`
 }
@@ -91,10 +94,14 @@ func (p *Parser) parseJSON(line string) (*pb.LogEntry, error) {
 		RequestUri:           jl.Path,
 		Status:               jl.Status,
 		BodyBytesSent:        jl.Bytes,
+		RequestLength:        jl.ReqLen,
 		RequestTime:          jl.Rt,
 		RequestId:            jl.ReqID,
 		UpstreamAddr:         jl.Upstream,
 		UpstreamStatus:       jl.Ustatus,
+		UpstreamCacheStatus:  dashEmpty(jl.Cache),
+		Traceparent:          dashEmpty(jl.TraceCtx),
+		XRequestId:           dashEmpty(jl.XReqID),
 		UpstreamConnectTime:  parseFloat(jl.Uct),
 		UpstreamHeaderTime:   parseFloat(jl.Uht),
 		UpstreamResponseTime: parseFloat(jl.Urt),
@@ -130,69 +137,6 @@ func (p *Parser) parseCombined(line string) (*pb.LogEntry, error) {
 	}, nil
 }
 
-type Tailer struct {
-	logPath   string
-	logFormat string
-	tail      *tail.Tail
-}
-
-func NewTailer(logPath, format string) *Tailer {
-	return &Tailer{logPath: logPath, logFormat: format}
-}
-
-// Start begins tailing the log file
-func (t *Tailer) Start() (<-chan *pb.LogEntry, error) {
-	config := tail.Config{
-		Follow: true,
-		ReOpen: true,
-		Location: &tail.SeekInfo{
-			Offset: 0,
-			Whence: 2, // Seek from end
-		},
-		Poll: true, // Force polling to avoid fsnotify issues
-	}
-
-	tailFile, err := tail.TailFile(t.logPath, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to tail file: %w", err)
-	}
-
-	t.tail = tailFile
-
-	parser := NewParser(t.logFormat)
-	entryChan := make(chan *pb.LogEntry, 100)
-
-	go func() {
-		defer close(entryChan)
-		for line := range tailFile.Lines {
-			if line.Err != nil {
-				// log.Printf("Tail error: %v", line.Err)
-				continue
-			}
-
-			// DEBUG:
-			// fmt.Printf("Read line: %s\n", line.Text)
-
-			entry, err := parser.ParseLine(line.Text)
-			if err != nil {
-				continue
-			}
-
-			entryChan <- entry
-		}
-	}()
-
-	return entryChan, nil
-}
-
-// Stop stops tailing the log file
-func (t *Tailer) Stop() error {
-	if t.tail != nil {
-		return t.tail.Stop()
-	}
-	return nil
-}
-
 // FollowFromEnd starts tailing the file from the end and sends new lines as LogEntry on the returned channel.
 // logType is "access" or "error"; for error, lines are parsed with ParseErrorLog.
 // The caller must call the returned stop func when done.
@@ -211,7 +155,7 @@ func FollowFromEnd(logPath, logType, format string) (<-chan *pb.LogEntry, func()
 	stop := func() error {
 		return tailFile.Stop()
 	}
-	parser := NewParser(format)
+	parser := NewLineParser(format)
 	go func() {
 		defer close(ch)
 		for line := range tailFile.Lines {
@@ -0,0 +1,395 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// logSource produces the log entries the collector forwards; Tailer is the file source
+type logSource interface {
+	Start() (<-chan *pb.LogEntry, error)
+	Stop() error
+}
+
+// SourceConfig selects where the collector reads NGINX logs from
+type SourceConfig struct {
+	Mode          string // "file" (default), "syslog" or "journald"
+	SyslogListen  string // "udp://127.0.0.1:5140", "tcp://host:port" or "unix:///path/to/socket"
+	JournaldMatch string // journalctl matches, e.g. "SYSLOG_IDENTIFIER=nginx"
+}
+
+// Source modes
+const (
+	SourceFile     = "file"
+	SourceSyslog   = "syslog"
+	SourceJournald = "journald"
+)
+
+// errorLogLine recognizes NGINX error log messages, which syslog and journald carry alongside the
+// access log: "2026/10/16 09:30:00 [error] 12#12: ..." or, without the time, "[error] 12#12: ..."
+var errorLogLine = regexp.MustCompile(`^(?:\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} )?\[(?:emerg|alert|crit|error|warn|notice|info|debug)\] `)
+
+// messageEntry parses one NGINX log message received without a file: error log messages with
+// ParseErrorLog, the rest with the access log parser. received stands in for a missing timestamp.
+func messageEntry(parser LineParser, msg string, received time.Time) *pb.LogEntry {
+	var entry *pb.LogEntry
+	if errorLogLine.MatchString(msg) {
+		entry = ParseErrorLog(msg)
+	} else {
+		var err error
+		if entry, err = parser.ParseLine(msg); err != nil {
+			entry = rawEntry(msg)
+		}
+	}
+	if entry.Timestamp <= 0 {
+		entry.Timestamp = received.Unix()
+	}
+	return entry
+}
+
+// rfc5424Header matches "1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID " after the priority; the
+// structured data that follows is skipped separately
+var rfc5424Header = regexp.MustCompile(`^1 (\S+) \S+ (\S+) \S+ \S+ `)
+
+// rfc3164Header matches "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: " after the priority; NGINX omits the
+// hostname when none is configured
+var rfc3164Header = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (?:(\S+) )?([^\s:\[]+)(?:\[\d+\])?: `)
+
+// parseSyslogMessage splits a syslog message (RFC 3164 as NGINX sends it, or RFC 5424) into its
+// time, tag and message. Messages without a recognizable header are returned whole.
+func parseSyslogMessage(raw string, received time.Time) (ts time.Time, tag, msg string) {
+	raw = strings.TrimRight(raw, "\r\n\x00")
+	ts, msg = received, raw
+	if !strings.HasPrefix(raw, "<") {
+		return ts, "", msg
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return ts, "", msg
+	}
+	if _, err := strconv.Atoi(raw[1:end]); err != nil {
+		return ts, "", msg
+	}
+	rest := raw[end+1:]
+
+	if m := rfc5424Header.FindStringSubmatch(rest); m != nil {
+		if t, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			ts = t
+		}
+		return ts, m[2], skipStructuredData(rest[len(m[0]):])
+	}
+	if m := rfc3164Header.FindStringSubmatch(rest); m != nil {
+		if t, err := time.ParseInLocation(time.Stamp, m[1], time.Local); err == nil {
+			// RFC 3164 has no year; take the one that puts the message closest to now
+			t = t.AddDate(received.Year(), 0, 0)
+			if t.Sub(received) > 24*time.Hour {
+				t = t.AddDate(-1, 0, 0)
+			}
+			ts = t
+		}
+		return ts, m[3], rest[len(m[0]):]
+	}
+	return ts, "", rest
+}
+
+// skipStructuredData drops an RFC 5424 STRUCTURED-DATA part ("-" or "[id k="v"]...") and the BOM
+func skipStructuredData(s string) string {
+	if strings.HasPrefix(s, "- ") {
+		return strings.TrimPrefix(s[2:], "\ufeff")
+	}
+	for strings.HasPrefix(s, "[") {
+		escaped, i := false, 1
+		for ; i < len(s); i++ {
+			if escaped {
+				escaped = false
+			} else if s[i] == '\\' {
+				escaped = true
+			} else if s[i] == ']' {
+				break
+			}
+		}
+		if i >= len(s) {
+			return s
+		}
+		s = s[i+1:]
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(s, " "), "\ufeff")
+}
+
+// SyslogReceiver listens for NGINX logs sent with access_log/error_log syslog:server=... on UDP,
+// TCP (newline-framed) or a unix datagram socket
+type SyslogReceiver struct {
+	network string
+	address string
+	parser  LineParser
+
+	mu       sync.Mutex
+	packet   net.PacketConn
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// NewSyslogReceiver creates a receiver for a listen address such as "udp://127.0.0.1:5140"
+func NewSyslogReceiver(listen, format string) (*SyslogReceiver, error) {
+	network, address, err := parseListenAddress(listen)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogReceiver{network: network, address: address, parser: NewLineParser(format), conns: map[net.Conn]bool{}}, nil
+}
+
+func parseListenAddress(listen string) (network, address string, err error) {
+	listen = strings.TrimSpace(listen)
+	switch {
+	case strings.HasPrefix(listen, "udp://"):
+		network, address = "udp", strings.TrimPrefix(listen, "udp://")
+	case strings.HasPrefix(listen, "tcp://"):
+		network, address = "tcp", strings.TrimPrefix(listen, "tcp://")
+	case strings.HasPrefix(listen, "unix://"):
+		network, address = "unixgram", strings.TrimPrefix(listen, "unix://")
+	default:
+		return "", "", fmt.Errorf("syslog listen address %q must start with udp://, tcp:// or unix://", listen)
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("syslog listen address %q has no address", listen)
+	}
+	return network, address, nil
+}
+
+// Start binds the listen address and returns the entries received
+func (r *SyslogReceiver) Start() (<-chan *pb.LogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(chan *pb.LogEntry, 100)
+
+	if r.network == "tcp" {
+		ln, err := net.Listen("tcp", r.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for syslog: %w", err)
+		}
+		r.listener = ln
+		go r.acceptLoop(ln, out)
+		return out, nil
+	}
+
+	if r.network == "unixgram" {
+		os.Remove(r.address) // a socket left behind by a previous run
+	}
+	pc, err := net.ListenPacket(r.network, r.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for syslog: %w", err)
+	}
+	if r.network == "unixgram" {
+		os.Chmod(r.address, 0666) // NGINX workers do not run as the agent's user
+	}
+	r.packet = pc
+	go r.packetLoop(pc, out)
+	return out, nil
+}
+
+// Addr returns the bound address, or nil before Start
+func (r *SyslogReceiver) Addr() net.Addr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listener != nil {
+		return r.listener.Addr()
+	}
+	if r.packet != nil {
+		return r.packet.LocalAddr()
+	}
+	return nil
+}
+
+func (r *SyslogReceiver) packetLoop(pc net.PacketConn, out chan<- *pb.LogEntry) {
+	defer close(out)
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if !r.isClosed() {
+				logsLog.Error().Msgf("Syslog receiver stopped: %v", err)
+			}
+			return
+		}
+		r.emit(string(buf[:n]), out)
+	}
+}
+
+func (r *SyslogReceiver) acceptLoop(ln net.Listener, out chan<- *pb.LogEntry) {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(out)
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !r.isClosed() {
+				logsLog.Error().Msgf("Syslog receiver stopped: %v", err)
+			}
+			return
+		}
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			conn.Close()
+			return
+		}
+		r.conns[conn] = true
+		r.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				r.mu.Lock()
+				delete(r.conns, conn)
+				r.mu.Unlock()
+				conn.Close()
+			}()
+			scanner := bufio.NewScanner(conn)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				r.emit(scanner.Text(), out)
+			}
+		}()
+	}
+}
+
+func (r *SyslogReceiver) emit(raw string, out chan<- *pb.LogEntry) {
+	now := time.Now()
+	ts, _, msg := parseSyslogMessage(raw, now)
+	if strings.TrimSpace(msg) == "" {
+		return
+	}
+	out <- messageEntry(r.parser, msg, ts)
+}
+
+func (r *SyslogReceiver) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+// Stop closes the listener and any open connections
+func (r *SyslogReceiver) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for conn := range r.conns {
+		conn.Close()
+	}
+	var err error
+	if r.listener != nil {
+		err = r.listener.Close()
+	}
+	if r.packet != nil {
+		err = r.packet.Close()
+		if r.network == "unixgram" {
+			os.Remove(r.address)
+		}
+	}
+	return err
+}
+
+// journalctlPath is the journalctl binary the journald source runs
+var journalctlPath = "journalctl"
+
+// JournaldReader follows the systemd journal with journalctl, for hosts where NGINX logs to
+// syslog and journald collects it
+type JournaldReader struct {
+	matches []string
+	parser  LineParser
+	cancel  context.CancelFunc
+}
+
+// NewJournaldReader follows the journal entries selected by matches, e.g. "SYSLOG_IDENTIFIER=nginx"
+func NewJournaldReader(matches, format string) *JournaldReader {
+	return &JournaldReader{matches: strings.Fields(matches), parser: NewLineParser(format)}
+}
+
+// journalEntry is the part of journalctl's JSON output the reader uses
+type journalEntry struct {
+	Message           json.RawMessage `json:"MESSAGE"` // a string, or an array of bytes when not UTF-8
+	RealtimeTimestamp string          `json:"__REALTIME_TIMESTAMP"`
+}
+
+// parseJournalEntry returns the message and time of one line of journalctl -o json output
+func parseJournalEntry(line []byte) (msg string, ts time.Time, ok bool) {
+	var je journalEntry
+	if err := json.Unmarshal(line, &je); err != nil {
+		return "", time.Time{}, false
+	}
+	if err := json.Unmarshal(je.Message, &msg); err != nil {
+		var raw []byte
+		var ints []int
+		if json.Unmarshal(je.Message, &ints) != nil {
+			return "", time.Time{}, false
+		}
+		for _, b := range ints {
+			raw = append(raw, byte(b))
+		}
+		msg = string(raw)
+	}
+	ts = time.Now()
+	if us, err := strconv.ParseInt(je.RealtimeTimestamp, 10, 64); err == nil {
+		ts = time.UnixMicro(us)
+	}
+	return msg, ts, true
+}
+
+// Start runs journalctl from the current end of the journal and returns the entries it prints
+func (j *JournaldReader) Start() (<-chan *pb.LogEntry, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	args := append([]string{"--follow", "--lines=0", "--output=json"}, j.matches...)
+	cmd := exec.CommandContext(ctx, journalctlPath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to run journalctl: %w", err)
+	}
+	j.cancel = cancel
+
+	out := make(chan *pb.LogEntry, 100)
+	go func() {
+		defer close(out)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			msg, ts, ok := parseJournalEntry(scanner.Bytes())
+			if !ok || strings.TrimSpace(msg) == "" {
+				continue
+			}
+			out <- messageEntry(j.parser, msg, ts)
+		}
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			logsLog.Error().Msgf("Journalctl exited: %v", err)
+		}
+	}()
+	return out, nil
+}
+
+// Stop terminates journalctl
+func (j *JournaldReader) Stop() error {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package logs
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const accessLine = `203.0.113.9 - - [16/Oct/2026:09:30:00 +0000] "GET /health HTTP/1.1" 200 2 "-" "kube-probe/1.30"`
+
+func TestParseSyslogMessage(t *testing.T) {
+	received := time.Date(2026, 10, 16, 9, 30, 5, 0, time.Local)
+	ts, tag, msg := parseSyslogMessage("<190>Oct 16 09:30:00 web-1 nginx: "+accessLine+"\n", received)
+	if tag != "nginx" || msg != accessLine || !ts.Equal(time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("rfc3164 = %s, %q, %q", ts, tag, msg)
+	}
+	// No hostname, as NGINX sends it by default, and a December message received in January
+	ts, tag, msg = parseSyslogMessage("<187>Dec 31 23:59:59 nginx_error: [error] 7#7: *1 upstream timed out", time.Date(2027, 1, 1, 0, 0, 1, 0, time.Local))
+	if tag != "nginx_error" || msg != "[error] 7#7: *1 upstream timed out" || ts.Year() != 2026 {
+		t.Errorf("rfc3164 without hostname = %s, %q, %q", ts, tag, msg)
+	}
+	ts, tag, msg = parseSyslogMessage(`<190>1 2026-10-16T09:30:00.5Z web-1 nginx 12 access [meta x="a\]b"] `+accessLine, received)
+	if tag != "nginx" || msg != accessLine || ts.UnixMilli() != time.Date(2026, 10, 16, 9, 30, 0, 5e8, time.UTC).UnixMilli() {
+		t.Errorf("rfc5424 = %s, %q, %q", ts, tag, msg)
+	}
+	if _, _, msg := parseSyslogMessage(accessLine, received); msg != accessLine {
+		t.Errorf("headerless = %q", msg)
+	}
+}
+
+func TestMessageEntry(t *testing.T) {
+	received := time.Unix(1791000000, 0)
+	parser := NewLineParser("combined")
+	access := messageEntry(parser, accessLine, received)
+	if access.LogType != "access" || access.Status != 200 || access.RequestUri != "/health" {
+		t.Errorf("access = %+v", access)
+	}
+	errEntry := messageEntry(parser, "[warn] 7#7: *3 an upstream response is buffered to a temporary file", received)
+	if errEntry.LogType != "error" || errEntry.Timestamp != received.Unix() {
+		t.Errorf("error = %+v", errEntry)
+	}
+}
+
+func receive(t *testing.T, entries <-chan *pb.LogEntry) *pb.LogEntry {
+	t.Helper()
+	select {
+	case e := <-entries:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry received")
+		return nil
+	}
+}
+
+func TestSyslogReceiver(t *testing.T) {
+	for _, listen := range []string{"udp://127.0.0.1:0", "tcp://127.0.0.1:0", "unix://" + filepath.Join(t.TempDir(), "nginx.sock")} {
+		r, err := NewSyslogReceiver(listen, "combined")
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := r.Start()
+		if err != nil {
+			t.Fatalf("%s: %v", listen, err)
+		}
+		addr := r.Addr()
+		conn, err := net.Dial(addr.Network(), addr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "<190>Oct 16 09:30:00 web-1 nginx: %s\n", accessLine)
+		if e := receive(t, entries); e.Status != 200 || e.RemoteAddr != "203.0.113.9" {
+			t.Errorf("%s: entry = %+v", listen, e)
+		}
+		conn.Close()
+		r.Stop()
+		for range entries {
+		}
+	}
+
+	if _, err := NewSyslogReceiver("127.0.0.1:514", "combined"); err == nil {
+		t.Error("a listen address without a scheme should be rejected")
+	}
+}
+
+func TestJournaldReader(t *testing.T) {
+	if line, ts, ok := parseJournalEntry([]byte(`{"MESSAGE":[104,105],"__REALTIME_TIMESTAMP":"1791000000000000"}`)); !ok || line != "hi" || ts.Unix() != 1791000000 {
+		t.Errorf("byte array message = %q, %s, %v", line, ts, ok)
+	}
+
+	script := filepath.Join(t.TempDir(), "journalctl")
+	os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho '{\"MESSAGE\":%q,\"__REALTIME_TIMESTAMP\":\"1791000000000000\"}'\nexec sleep 60\n", accessLine)), 0755)
+	old := journalctlPath
+	journalctlPath = script
+	defer func() { journalctlPath = old }()
+
+	j := NewJournaldReader("SYSLOG_IDENTIFIER=nginx", "combined")
+	entries, err := j.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := receive(t, entries); e.Status != 200 || e.RemoteAddr != "203.0.113.9" {
+		t.Errorf("entry = %+v", e)
+	}
+	j.Stop()
+	for range entries {
+	}
+}
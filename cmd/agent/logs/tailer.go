@@ -0,0 +1,433 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	tailPollInterval   = 250 * time.Millisecond
+	globRescanInterval = 5 * time.Second
+	offsetSaveInterval = 5 * time.Second
+	// rotatedGrace is how long a rotated file is still read: nginx writes to it until it reopens
+	// its logs (logrotate's postrotate USR1)
+	rotatedGrace = 30 * time.Second
+	// maxLineLength bounds a line without a newline; longer ones are split
+	maxLineLength = 1 << 20
+	tailReadSize  = 64 << 10
+)
+
+// SourceFileLabel is the LogEntry label holding the file an entry was read from
+const SourceFileLabel = "log_file"
+
+// InstanceLabel is the LogEntry label holding the ID of the nginx instance that logged an entry
+const InstanceLabel = "nginx_instance"
+
+// compressedSuffixes are rotated logs a broad glob may match; they are never tailed
+var compressedSuffixes = []string{".gz", ".bz2", ".xz", ".zst", ".zip"}
+
+// fileID identifies a file across renames
+type fileID struct {
+	Dev uint64 `json:"dev"`
+	Ino uint64 `json:"ino"`
+}
+
+func fileIDOf(info os.FileInfo) fileID {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileID{Dev: uint64(st.Dev), Ino: uint64(st.Ino)}
+	}
+	return fileID{}
+}
+
+type followedFile struct {
+	path    string
+	file    *os.File
+	id      fileID
+	offset  int64  // bytes read
+	partial []byte // read after the last newline
+	expires time.Time
+}
+
+// committed is the offset of the end of the last complete line
+func (f *followedFile) committed() int64 {
+	return f.offset - int64(len(f.partial))
+}
+
+// Tailer follows one log file or every file matching a glob (/var/log/nginx/*access*.log). It
+// survives logrotate: renamed files are read to their end for a while after the new file appears,
+// and files truncated in place (copytruncate) are read again from the start. With Offsets set,
+// reading resumes where it stopped across restarts.
+type Tailer struct {
+	pattern   string
+	logFormat string
+	// Offsets, when set, holds the read offset of each file. Without a saved offset, files present
+	// at Start are read from their end and files that appear later from their start.
+	Offsets *OffsetStore
+
+	scanEvery time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+	files     map[string]*followedFile
+	rotated   []*followedFile
+}
+
+func NewTailer(logPath, format string) *Tailer {
+	return &Tailer{pattern: logPath, logFormat: format, scanEvery: globRescanInterval}
+}
+
+// Start begins tailing the matching files
+func (t *Tailer) Start() (<-chan *pb.LogEntry, error) {
+	if _, err := filepath.Match(t.pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid log path %q: %w", t.pattern, err)
+	}
+	if t.Offsets == nil {
+		t.Offsets = NewOffsetStore("")
+	}
+	t.stop, t.done = make(chan struct{}), make(chan struct{})
+	t.files = make(map[string]*followedFile)
+	t.scan(true)
+
+	entryChan := make(chan *pb.LogEntry, 100)
+	go t.run(entryChan, NewLineParser(t.logFormat))
+	return entryChan, nil
+}
+
+// Stop stops tailing and saves the offsets
+func (t *Tailer) Stop() error {
+	if t.stop == nil {
+		return nil
+	}
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	<-t.done
+	return nil
+}
+
+func (t *Tailer) run(out chan<- *pb.LogEntry, parser LineParser) {
+	defer close(t.done)
+	defer close(out)
+	defer func() {
+		for _, f := range t.files {
+			f.file.Close()
+		}
+		for _, f := range t.rotated {
+			f.file.Close()
+		}
+		t.saveOffsets()
+	}()
+
+	emit := func(path, line string) bool {
+		entry, err := parser.ParseLine(line)
+		if err != nil || entry == nil {
+			return true
+		}
+		if entry.Labels == nil {
+			entry.Labels = make(map[string]string, 1)
+		}
+		entry.Labels[SourceFileLabel] = path
+		select {
+		case out <- entry:
+			return true
+		case <-t.stop:
+			return false
+		}
+	}
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	lastScan, lastSave := time.Now(), time.Now()
+	for {
+		if !t.poll(emit) {
+			return
+		}
+		now := time.Now()
+		if now.Sub(lastScan) >= t.scanEvery {
+			t.scan(false)
+			lastScan = now
+		}
+		if now.Sub(lastSave) >= offsetSaveInterval {
+			t.saveOffsets()
+			lastSave = now
+		}
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan opens files newly matching the pattern
+func (t *Tailer) scan(initial bool) {
+	paths := []string{t.pattern}
+	if strings.ContainsAny(t.pattern, "*?[") {
+		paths, _ = filepath.Glob(t.pattern)
+	}
+	for _, path := range paths {
+		if _, ok := t.files[path]; ok || isCompressed(path) {
+			continue
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			continue // not created yet
+		}
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			file.Close()
+			continue
+		}
+		f := &followedFile{path: path, file: file, id: fileIDOf(info)}
+		switch saved, ok := t.Offsets.get(path); {
+		case t.adopt(f):
+			// A followed file renamed onto a matching path keeps its offset
+		case ok && saved.ID == f.id && saved.Offset <= info.Size():
+			f.offset = saved.Offset
+		case ok || !initial:
+			// Rotated while the agent was down, or created since Start: read it all
+		default:
+			f.offset = info.Size()
+		}
+		t.files[path] = f
+	}
+}
+
+// adopt takes over the offset of a rotated file that is f under a new name
+func (t *Tailer) adopt(f *followedFile) bool {
+	for i, r := range t.rotated {
+		if r.id == f.id {
+			f.offset, f.partial = r.offset, r.partial
+			r.file.Close()
+			t.rotated = append(t.rotated[:i], t.rotated[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// poll reads new lines from every file and handles rotation. It returns false once stopped.
+func (t *Tailer) poll(emit func(path, line string) bool) bool {
+	now := time.Now()
+	kept := t.rotated[:0]
+	for _, r := range t.rotated {
+		if !t.read(r, emit) {
+			return false
+		}
+		if now.Before(r.expires) {
+			kept = append(kept, r)
+			continue
+		}
+		t.flushPartial(r, emit)
+		r.file.Close()
+	}
+	t.rotated = kept
+
+	for path, f := range t.files {
+		// Check the path before reading so lines written to the old file are not missed
+		info, statErr := os.Stat(path)
+		if !t.read(f, emit) {
+			return false
+		}
+		switch {
+		case statErr != nil && errors.Is(statErr, os.ErrNotExist):
+			// Renamed or removed and not recreated yet: keep reading until it is, then let go
+			if f.expires.IsZero() {
+				f.expires = now.Add(rotatedGrace)
+			} else if now.After(f.expires) {
+				t.flushPartial(f, emit)
+				f.file.Close()
+				delete(t.files, path)
+				t.Offsets.forget(path)
+			}
+		case statErr == nil && fileIDOf(info) != f.id:
+			// Rename and create: finish the old file in the background, read the new one from the start
+			f.expires = now.Add(rotatedGrace)
+			t.rotated = append(t.rotated, f)
+			delete(t.files, path)
+			if file, err := os.Open(path); err == nil {
+				nf := &followedFile{path: path, file: file, id: fileIDOf(info)}
+				t.files[path] = nf
+				if !t.read(nf, emit) {
+					return false
+				}
+			}
+		default:
+			f.expires = time.Time{}
+		}
+	}
+	return true
+}
+
+// read sends the complete lines appended to f since the last read
+func (t *Tailer) read(f *followedFile, emit func(path, line string) bool) bool {
+	if info, err := f.file.Stat(); err == nil && info.Size() < f.offset {
+		// Truncated in place (copytruncate): start over
+		f.offset, f.partial = 0, nil
+	}
+	buf := make([]byte, tailReadSize)
+	for {
+		n, err := f.file.ReadAt(buf, f.offset)
+		if n > 0 {
+			f.offset += int64(n)
+			data := append(f.partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				if !emit(f.path, string(bytes.TrimSuffix(data[:i], []byte("\r")))) {
+					return false
+				}
+				data = data[i+1:]
+			}
+			if len(data) > maxLineLength {
+				if !emit(f.path, string(data)) {
+					return false
+				}
+				data = nil
+			}
+			f.partial = append([]byte(nil), data...)
+		}
+		if err != nil || n < len(buf) {
+			if err != nil && err != io.EOF {
+				logsLog.Warn().Msgf("Failed to read %s: %v", f.path, err)
+			}
+			break
+		}
+	}
+	if t.files[f.path] == f {
+		// A rotated file shares its path with the new one, whose offset is the one saved
+		t.Offsets.set(f.path, f.id, f.committed())
+	}
+	return true
+}
+
+// flushPartial sends a last line that has no newline
+func (t *Tailer) flushPartial(f *followedFile, emit func(path, line string) bool) {
+	if len(f.partial) > 0 {
+		emit(f.path, string(f.partial))
+		f.partial = nil
+	}
+}
+
+func (t *Tailer) saveOffsets() {
+	if err := t.Offsets.Save(); err != nil {
+		logsLog.Warn().Msgf("Failed to save log offsets: %v", err)
+	}
+}
+
+func isCompressed(path string) bool {
+	for _, suffix := range compressedSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileOffset is how far a file was read
+type fileOffset struct {
+	ID     fileID `json:"id"`
+	Offset int64  `json:"offset"`
+}
+
+// OffsetStore persists how far each log file has been read so collection resumes where it
+// stopped after an agent restart. Tailers sharing a store save it together.
+type OffsetStore struct {
+	path    string
+	mu      sync.Mutex
+	offsets map[string]fileOffset
+	dirty   bool
+}
+
+// NewOffsetStore loads the offsets saved at path; with an empty path nothing is persisted
+func NewOffsetStore(path string) *OffsetStore {
+	s := &OffsetStore{path: path, offsets: make(map[string]fileOffset)}
+	if path == "" {
+		return s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logsLog.Warn().Msgf("Failed to read log offsets %s: %v", path, err)
+		}
+		return s
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		logsLog.Warn().Msgf("Ignoring corrupt log offsets %s: %v", path, err)
+		s.offsets = make(map[string]fileOffset)
+	}
+	return s
+}
+
+func (s *OffsetStore) get(path string) (fileOffset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.offsets[path]
+	return o, ok
+}
+
+func (s *OffsetStore) set(path string, id fileID, offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.offsets[path]; ok && o.ID == id && o.Offset == offset {
+		return
+	}
+	s.offsets[path] = fileOffset{ID: id, Offset: offset}
+	s.dirty = true
+}
+
+func (s *OffsetStore) forget(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.offsets[path]; ok {
+		delete(s.offsets, path)
+		s.dirty = true
+	}
+}
+
+// Reset forgets every offset, so files are read from their end again
+func (s *OffsetStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets = make(map[string]fileOffset)
+	s.dirty = true
+}
+
+// Save writes the offsets if they changed
+func (s *OffsetStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+	data, err := json.Marshal(s.offsets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// expectLines reads entries until the wanted contents arrived, in order
+func expectLines(t *testing.T, ch <-chan *pb.LogEntry, want ...string) []*pb.LogEntry {
+	t.Helper()
+	var got []*pb.LogEntry
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case e := <-ch:
+			got = append(got, e)
+		case <-timeout:
+			t.Fatalf("got %d of %d lines", len(got), len(want))
+		}
+	}
+	for i, e := range got {
+		if e.Content != want[i] {
+			t.Errorf("line %d = %q, want %q", i, e.Content, want[i])
+		}
+	}
+	return got
+}
+
+func expectNothing(t *testing.T, ch <-chan *pb.LogEntry) {
+	t.Helper()
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected line %q", e.Content)
+	case <-time.After(3 * tailPollInterval):
+	}
+}
+
+func TestTailerGlobAndLabels(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "shop-access.log"), filepath.Join(dir, "api-access.log")
+	appendLines(t, a, "old line")
+	appendLines(t, filepath.Join(dir, "shop-access.log.1.gz"), "compressed")
+
+	tailer := NewTailer(filepath.Join(dir, "*access*.log*"), "combined")
+	tailer.scanEvery = tailPollInterval
+	ch, err := tailer.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tailer.Stop()
+
+	// Existing files start at their end
+	appendLines(t, a, "a1")
+	got := expectLines(t, ch, "a1")
+	if got[0].Labels[SourceFileLabel] != a {
+		t.Errorf("labels = %v", got[0].Labels)
+	}
+
+	// Files created later are read from the start once the glob is rescanned
+	appendLines(t, b, "b1", "b2")
+	got = expectLines(t, ch, "b1", "b2")
+	if got[1].Labels[SourceFileLabel] != b {
+		t.Errorf("labels = %v", got[1].Labels)
+	}
+	expectNothing(t, ch)
+}
+
+func TestTailerRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	appendLines(t, path, "")
+
+	tailer := NewTailer(path, "combined")
+	ch, err := tailer.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tailer.Stop()
+
+	appendLines(t, path, "before rotation")
+	expectLines(t, ch, "before rotation")
+
+	// logrotate create: rename, create, nginx keeps writing to the old file until it reopens
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "new file")
+	appendLines(t, path+".1", "late write")
+	// The old file is finished first
+	got := expectLines(t, ch, "late write", "new file")
+	if got[0].Labels[SourceFileLabel] != path {
+		t.Errorf("rotated file label = %q", got[1].Labels[SourceFileLabel])
+	}
+
+	// copytruncate: copied away, truncated in place
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * tailPollInterval)
+	appendLines(t, path, "after truncate")
+	expectLines(t, ch, "after truncate")
+	expectNothing(t, ch)
+}
+
+func TestTailerResumesFromSavedOffsets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	state := filepath.Join(dir, "state", "log-offsets.json")
+	appendLines(t, path, "history")
+
+	first := NewTailer(path, "combined")
+	first.Offsets = NewOffsetStore(state)
+	ch, err := first.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "one")
+	expectLines(t, ch, "one")
+	first.Stop()
+
+	// Lines logged while the agent was down are read after the restart, partial lines are not
+	appendLines(t, path, "two")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("thr")
+	f.Close()
+
+	second := NewTailer(path, "combined")
+	second.Offsets = NewOffsetStore(state)
+	ch, err = second.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Stop()
+	expectLines(t, ch, "two")
+	appendLines(t, path, "ee")
+	expectLines(t, ch, "three")
+}
+
+func TestOffsetStoreIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log-offsets.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewOffsetStore(path)
+	s.set("/var/log/nginx/access.log", fileID{Dev: 1, Ino: 2}, 42)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if o, ok := NewOffsetStore(path).get("/var/log/nginx/access.log"); !ok || o.Offset != 42 || o.ID.Ino != 2 {
+		t.Errorf("reloaded offset = %+v, %v", o, ok)
+	}
+}
@@ -9,9 +9,10 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"expvar"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -33,10 +35,13 @@ import (
 	"github.com/avika-ai/avika/cmd/agent/logs"
 	"github.com/avika-ai/avika/cmd/agent/metrics"
 	"github.com/avika-ai/avika/cmd/agent/updater"
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/redact"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -52,9 +57,12 @@ var (
 	agentID       = flag.String("id", "", "The agent ID (default: hostname)")
 	logLevel      = flag.String("log-level", "info", "Log level (debug, info, warn, error). Set via LOG_LEVEL env for dynamic override.")
 	logFile       = flag.String("log-file", "/var/log/avika-agent/agent.log", "Path to log file. If empty, logs to stdout")
+	logEncoding   = flag.String("log-encoding", "text", "Encoding of the agent's own log: text or json")
+	logComponents = flag.String("log-components", "", "Log levels of single components, e.g. 'buffer=debug,logs=warn'; the others log at -log-level")
 	bufferDir     = flag.String("buffer-dir", "/var/lib/avika-agent/data", "Directory to store the persistent buffer")
 	version       = flag.Bool("version", false, "Display version and exit")
 	healthPort    = flag.Int("health-port", DefaultHealthPort, "Port for health check endpoints")
+	debugToken    = flag.String("debug-token", "", "Bearer token for the health server's /debug endpoints. If empty, /debug serves loopback clients only")
 	mgmtPort      = flag.Int("mgmt-port", DefaultMgmtPort, "Port for management gRPC server")
 	pskKey        = flag.String("psk", "", "Pre-Shared Key for gateway authentication")
 	tlsCertFile   = flag.String("tls-cert", "", "Path to TLS client certificate file")
@@ -65,18 +73,40 @@ var (
 
 	// NGINX configuration
 	nginxStatusURL  = flag.String("nginx-status-url", "http://127.0.0.1/nginx_status", "URL for NGINX stub_status")
+	nginxPlusAPIURL = flag.String("nginx-plus-api-url", "", "NGINX Plus API base URL (e.g. http://127.0.0.1:8080/api/9). Enables runtime upstream changes")
 	accessLogPath   = flag.String("access-log-path", "/var/log/nginx/access.log", "Path to NGINX access log")
 	errorLogPath    = flag.String("error-log-path", "/var/log/nginx/error.log", "Path to NGINX error log")
-	logFormat       = flag.String("log-format", "combined", "Log format (combined or json)")
+	logFormat       = flag.String("log-format", "combined", "Access log format (combined, json, apache, caddy or haproxy)")
+	logFormatString = flag.String("log-format-string", "", "NGINX log_format string of the access log (e.g. '$remote_addr [$time_local] \"$request\" $status'). Overrides -log-format")
+	logSource       = flag.String("log-source", "file", "Where NGINX logs are read from: file (tail the log paths), syslog or journald")
+	syslogListen    = flag.String("log-syslog-listen", "udp://127.0.0.1:5140", "Address the syslog log source listens on (udp://, tcp:// or unix://)")
+	journaldMatch   = flag.String("log-journald-match", "SYSLOG_IDENTIFIER=nginx", "journalctl matches selecting NGINX's entries for the journald log source")
 	nginxConfigPath = flag.String("nginx-config-path", "/etc/nginx/nginx.conf", "Path to NGINX configuration file")
 
+	// Scrubbing of access log entries before they leave the agent, changeable at runtime
+	logRedact           = flag.String("log-redact", "", "Comma-separated redaction rules for access logs: query, tokens, emails, ips, or all")
+	logRedactParams     = flag.String("log-redact-params", "", "Query parameters masked by the query rule besides the built-in secret names")
+	logRedactIPv4Prefix = flag.Int("log-redact-ipv4-prefix", redact.DefaultIPv4Prefix, "Bits of IPv4 client addresses the ips rule keeps")
+	logRedactIPv6Prefix = flag.Int("log-redact-ipv6-prefix", redact.DefaultIPv6Prefix, "Bits of IPv6 client addresses the ips rule keeps")
+
+	// stub_status of additional NGINX instances on the host as comma-separated ID=URL pairs, an
+	// instance's ID being its config path
+	instanceStatusURLs = flag.String("nginx-instance-status-urls", "", "stub_status URLs of additional NGINX instances, e.g. /etc/nginx-api/nginx.conf=http://127.0.0.1:8081/nginx_status")
+
 	// Self-Update
-	updateServer   = flag.String("update-server", "", "URL of the update server (e.g., http://gateway:5021). If empty, auto-derived from gateway address. Set to 'disabled' to turn off")
-	updateInterval = flag.Duration("update-interval", 168*time.Hour, "Interval between update checks (default: 1 week)")
+	updateServer        = flag.String("update-server", "", "URL of the update server (e.g., http://gateway:5021). If empty, auto-derived from gateway address. Set to 'disabled' to turn off")
+	updateInterval      = flag.Duration("update-interval", 168*time.Hour, "Interval between update checks (default: 1 week)")
+	updatePublicKey     = flag.String("update-public-key", "", "ed25519 public key (base64 or hex) or path to a PEM public key. When set, only signed update packages are installed")
+	updateRequireHTTPS  = flag.Bool("update-require-https", false, "Refuse update manifests and binaries not served over HTTPS")
+	updateHealthTimeout = flag.Duration("update-health-timeout", 2*time.Minute, "How long an updated agent has to reach a gateway before the previous binary is restored")
 
 	// Config File
 	configFile = flag.String("config", "/etc/avika/avika-agent.conf", "Path to configuration file")
 
+	// Gateway-served configuration bundle, applied below the config file
+	agentConfigToken = flag.String("config-token", "", "Enrollment token used to fetch this environment's configuration bundle from the gateway")
+	agentConfigURL   = flag.String("config-url", "", "URL of the gateway's agent configuration endpoint. If empty, auto-derived from gateway address")
+
 	// Management address advertisement: host or host:port the gateway should use to dial this agent (Option A - correct IP)
 	mgmtAdvertise = flag.String("mgmt-advertise", "", "Address to advertise for gateway dial-back (e.g. 10.0.2.15 or 10.0.2.15:5025). Also set via AVIKA_MGMT_ADVERTISE.")
 
@@ -88,6 +118,21 @@ var (
 	syslogTarget   = flag.String("syslog-target", "", "Syslog server target (e.g., 'udp://10.0.0.1:514')")
 	syslogFacility = flag.String("syslog-facility", "local7", "Syslog facility")
 	syslogSeverity = flag.String("syslog-severity", "info", "Syslog severity")
+
+	// Metrics collection, changeable at runtime through ConfigureAgent
+	metricsInterval      = flag.Duration("metrics-interval", time.Second, "Interval between NGINX and system metrics samples")
+	nginxMetricsEnabled  = flag.Bool("nginx-metrics", true, "Collect NGINX metrics")
+	systemMetricsEnabled = flag.Bool("system-metrics", true, "Collect system (CPU, memory, network) metrics")
+	disabledCollectors   = flag.String("disabled-collectors", "", "Comma-separated metric collectors to skip: "+strings.Join(metrics.OptionalCollectors, ", "))
+	heartbeatInterval    = flag.Duration("heartbeat-interval", time.Second, "Interval between heartbeats (1s to 1m)")
+	discoveryInterval    = flag.Duration("discovery-interval", time.Second, "Interval between scans for NGINX instances; heartbeats in between report the last scan")
+
+	// Self-limits; while the agent stays over one it pauses log collection and slows down
+	maxCPUPercent = flag.Float64("max-cpu-percent", 0, "CPU the agent may use, in percent of one core (0 = unlimited)")
+	maxMemoryMB   = flag.Int("max-memory-mb", 0, "Resident memory the agent may use, in MB (0 = unlimited)")
+
+	// Custom collectors (exec scripts and Prometheus endpoints), one *.conf file each
+	collectorsDir = flag.String("collectors-dir", "/etc/avika/collectors.d", "Directory of custom metric collector definitions")
 )
 
 // Version information - set at build time via -ldflags
@@ -132,132 +177,273 @@ func loadConfig(path string) error {
 			continue
 		}
 		key := strings.TrimSpace(parts[0])
-		val := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
-
-		// Mapping config keys to flags
-		switch key {
-		case "GATEWAYS":
-			// Gateway address(es) - single or comma-separated for multi-gateway
-			if !setFlags["gateway"] {
-				*gatewayAddr = val
-			}
-		case "AGENT_ID":
-			if !setFlags["id"] {
-				*agentID = val
-			}
-		case "HEALTH_PORT":
-			if !setFlags["health-port"] {
-				if i, err := strconv.Atoi(val); err == nil {
-					*healthPort = i
-				}
-			}
-		case "UPDATE_SERVER":
-			if !setFlags["update-server"] {
-				*updateServer = val
-			}
-		case "UPDATE_INTERVAL":
-			if !setFlags["update-interval"] {
-				if d, err := time.ParseDuration(val); err == nil {
-					*updateInterval = d
-				}
-			}
-		case "NGINX_STATUS_URL":
-			if !setFlags["nginx-status-url"] {
-				*nginxStatusURL = val
-			}
-		case "TLS":
-			if !setFlags["tls"] {
-				*enableTLS = val == "true" || val == "1"
-			}
-		case "TLS_CERT":
-			if !setFlags["tls-cert"] {
-				*tlsCertFile = val
-			}
-		case "TLS_KEY":
-			if !setFlags["tls-key"] {
-				*tlsKeyFile = val
-			}
-		case "TLS_CA":
-			if !setFlags["tls-ca"] {
-				*tlsCACertFile = val
-			}
-		case "TLS_INSECURE":
-			if !setFlags["tls-insecure"] {
-				*tlsInsecure = val == "true" || val == "1"
-			}
-		case "ACCESS_LOG_PATH":
-			if !setFlags["access-log-path"] {
-				*accessLogPath = val
-			}
-		case "ERROR_LOG_PATH":
-			if !setFlags["error-log-path"] {
-				*errorLogPath = val
-			}
-		case "LOG_FORMAT":
-			if !setFlags["log-format"] {
-				*logFormat = val
-			}
-		case "NGINX_CONFIG_PATH":
-			if !setFlags["nginx-config-path"] {
-				*nginxConfigPath = val
-			}
-		case "BUFFER_DIR":
-			if !setFlags["buffer-dir"] {
-				*bufferDir = val
+		val := unquoteConfigValue(strings.TrimSpace(parts[1]))
+
+		applyConfigValue(key, val, setFlags)
+	}
+	return scanner.Err()
+}
+
+// unquoteConfigValue strips one pair of matching quotes, keeping quotes inside the value (as in
+// LOG_FORMAT_STRING='"$request" $status') and undoing formatConfigValue's \" escapes; unbalanced
+// quotes are trimmed as before
+func unquoteConfigValue(val string) string {
+	if len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'' {
+		return val[1 : len(val)-1]
+	}
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		return strings.ReplaceAll(val[1:len(val)-1], `\"`, `"`)
+	}
+	return strings.Trim(val, "\"'")
+}
+
+// applyConfigValue sets the flag a config key maps to unless it was set on the command line
+func applyConfigValue(key, val string, setFlags map[string]bool) {
+	switch key {
+	case "GATEWAYS":
+		// Gateway address(es) - single or comma-separated for multi-gateway
+		if !setFlags["gateway"] {
+			*gatewayAddr = val
+		}
+	case "AGENT_ID":
+		if !setFlags["id"] {
+			*agentID = val
+		}
+	case "DEBUG_TOKEN":
+		if !setFlags["debug-token"] {
+			*debugToken = val
+		}
+	case "HEALTH_PORT":
+		if !setFlags["health-port"] {
+			if i, err := strconv.Atoi(val); err == nil {
+				*healthPort = i
 			}
-		case "LOG_LEVEL":
-			if !setFlags["log-level"] {
-				*logLevel = val
+		}
+	case "UPDATE_SERVER":
+		if !setFlags["update-server"] {
+			*updateServer = val
+		}
+	case "UPDATE_INTERVAL":
+		if !setFlags["update-interval"] {
+			if d, err := time.ParseDuration(val); err == nil {
+				*updateInterval = d
 			}
-		case "LOG_FILE":
-			if !setFlags["log-file"] {
-				*logFile = val
+		}
+	case "UPDATE_PUBLIC_KEY":
+		if !setFlags["update-public-key"] {
+			*updatePublicKey = val
+		}
+	case "UPDATE_REQUIRE_HTTPS":
+		if !setFlags["update-require-https"] {
+			*updateRequireHTTPS = val == "true" || val == "1"
+		}
+	case "UPDATE_HEALTH_TIMEOUT":
+		if !setFlags["update-health-timeout"] {
+			if d, err := time.ParseDuration(val); err == nil {
+				*updateHealthTimeout = d
 			}
-		case "MGMT_PORT":
-			if !setFlags["mgmt-port"] {
-				if i, err := strconv.Atoi(val); err == nil {
-					*mgmtPort = i
-				}
+		}
+	case "NGINX_STATUS_URL":
+		if !setFlags["nginx-status-url"] {
+			*nginxStatusURL = val
+		}
+	case "NGINX_INSTANCE_STATUS_URLS":
+		if !setFlags["nginx-instance-status-urls"] {
+			*instanceStatusURLs = val
+		}
+	case "NGINX_PLUS_API_URL":
+		if !setFlags["nginx-plus-api-url"] {
+			*nginxPlusAPIURL = val
+		}
+	case "TLS":
+		if !setFlags["tls"] {
+			*enableTLS = val == "true" || val == "1"
+		}
+	case "TLS_CERT":
+		if !setFlags["tls-cert"] {
+			*tlsCertFile = val
+		}
+	case "TLS_KEY":
+		if !setFlags["tls-key"] {
+			*tlsKeyFile = val
+		}
+	case "TLS_CA":
+		if !setFlags["tls-ca"] {
+			*tlsCACertFile = val
+		}
+	case "TLS_INSECURE":
+		if !setFlags["tls-insecure"] {
+			*tlsInsecure = val == "true" || val == "1"
+		}
+	case "ACCESS_LOG_PATH":
+		if !setFlags["access-log-path"] {
+			*accessLogPath = val
+		}
+	case "ERROR_LOG_PATH":
+		if !setFlags["error-log-path"] {
+			*errorLogPath = val
+		}
+	case "LOG_FORMAT":
+		if !setFlags["log-format"] {
+			*logFormat = val
+		}
+	case "LOG_FORMAT_STRING":
+		if !setFlags["log-format-string"] {
+			*logFormatString = val
+		}
+	case "LOG_SOURCE":
+		if !setFlags["log-source"] {
+			*logSource = val
+		}
+	case "LOG_SYSLOG_LISTEN":
+		if !setFlags["log-syslog-listen"] {
+			*syslogListen = val
+		}
+	case "LOG_JOURNALD_MATCH":
+		if !setFlags["log-journald-match"] {
+			*journaldMatch = val
+		}
+	case "LOG_REDACT":
+		if !setFlags["log-redact"] {
+			*logRedact = val
+		}
+	case "LOG_REDACT_PARAMS":
+		if !setFlags["log-redact-params"] {
+			*logRedactParams = val
+		}
+	case "LOG_REDACT_IPV4_PREFIX":
+		if !setFlags["log-redact-ipv4-prefix"] {
+			if i, err := strconv.Atoi(val); err == nil {
+				*logRedactIPv4Prefix = i
 			}
-		case "PSK_KEY":
-			if !setFlags["psk"] {
-				*pskKey = val
+		}
+	case "LOG_REDACT_IPV6_PREFIX":
+		if !setFlags["log-redact-ipv6-prefix"] {
+			if i, err := strconv.Atoi(val); err == nil {
+				*logRedactIPv6Prefix = i
 			}
-		case "AVIKA_MGMT_ADVERTISE", "MGMT_ADVERTISE":
-			if *mgmtAdvertise == "" {
-				*mgmtAdvertise = val
+		}
+	case "NGINX_CONFIG_PATH":
+		if !setFlags["nginx-config-path"] {
+			*nginxConfigPath = val
+		}
+	case "BUFFER_DIR":
+		if !setFlags["buffer-dir"] {
+			*bufferDir = val
+		}
+	case "LOG_LEVEL":
+		if !setFlags["log-level"] {
+			*logLevel = val
+		}
+	case "LOG_FILE":
+		if !setFlags["log-file"] {
+			*logFile = val
+		}
+	case "LOG_ENCODING":
+		if !setFlags["log-encoding"] {
+			*logEncoding = val
+		}
+	case "LOG_COMPONENTS":
+		if !setFlags["log-components"] {
+			*logComponents = val
+		}
+	case "MGMT_PORT":
+		if !setFlags["mgmt-port"] {
+			if i, err := strconv.Atoi(val); err == nil {
+				*mgmtPort = i
 			}
-		case "AVIKA_MGMT_NAT_CIDR", "MGMT_NAT_CIDR":
-			if !setFlags["mgmt-nat-cidr"] {
-				*mgmtNatCIDR = val
+		}
+	case "PSK_KEY":
+		if !setFlags["psk"] {
+			*pskKey = val
+		}
+	case "AVIKA_MGMT_ADVERTISE", "MGMT_ADVERTISE":
+		if *mgmtAdvertise == "" {
+			*mgmtAdvertise = val
+		}
+	case "AVIKA_MGMT_NAT_CIDR", "MGMT_NAT_CIDR":
+		if !setFlags["mgmt-nat-cidr"] {
+			*mgmtNatCIDR = val
+		}
+	case "SYSLOG_ENABLED":
+		if !setFlags["syslog-enabled"] {
+			*syslogEnabled = val == "true" || val == "1"
+		}
+	case "SYSLOG_TARGET":
+		if !setFlags["syslog-target"] {
+			*syslogTarget = val
+		}
+	case "SYSLOG_FACILITY":
+		if !setFlags["syslog-facility"] {
+			*syslogFacility = val
+		}
+	case "SYSLOG_SEVERITY":
+		if !setFlags["syslog-severity"] {
+			*syslogSeverity = val
+		}
+	case "METRICS_INTERVAL":
+		if !setFlags["metrics-interval"] {
+			if d, err := time.ParseDuration(val); err == nil {
+				*metricsInterval = d
 			}
-		case "SYSLOG_ENABLED":
-			if !setFlags["syslog-enabled"] {
-				*syslogEnabled = val == "true" || val == "1"
+		}
+	case "NGINX_METRICS_ENABLED":
+		if !setFlags["nginx-metrics"] {
+			*nginxMetricsEnabled = val == "true" || val == "1"
+		}
+	case "SYSTEM_METRICS_ENABLED":
+		if !setFlags["system-metrics"] {
+			*systemMetricsEnabled = val == "true" || val == "1"
+		}
+	case "DISABLED_COLLECTORS":
+		if !setFlags["disabled-collectors"] {
+			*disabledCollectors = val
+		}
+	case "HEARTBEAT_INTERVAL":
+		if !setFlags["heartbeat-interval"] {
+			if d, err := time.ParseDuration(val); err == nil {
+				*heartbeatInterval = d
 			}
-		case "SYSLOG_TARGET":
-			if !setFlags["syslog-target"] {
-				*syslogTarget = val
+		}
+	case "DISCOVERY_INTERVAL":
+		if !setFlags["discovery-interval"] {
+			if d, err := time.ParseDuration(val); err == nil {
+				*discoveryInterval = d
 			}
-		case "SYSLOG_FACILITY":
-			if !setFlags["syslog-facility"] {
-				*syslogFacility = val
+		}
+	case "MAX_CPU_PERCENT":
+		if !setFlags["max-cpu-percent"] {
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				*maxCPUPercent = f
 			}
-		case "SYSLOG_SEVERITY":
-			if !setFlags["syslog-severity"] {
-				*syslogSeverity = val
+		}
+	case "MAX_MEMORY_MB":
+		if !setFlags["max-memory-mb"] {
+			if i, err := strconv.Atoi(val); err == nil {
+				*maxMemoryMB = i
 			}
-		default:
-			// Parse labels with LABEL_ prefix: LABEL_project=myproject
-			if strings.HasPrefix(key, "LABEL_") {
-				labelKey := strings.TrimPrefix(key, "LABEL_")
-				if labelKey != "" {
-					agentLabels[labelKey] = val
-				}
+		}
+	case "COLLECTORS_DIR":
+		if !setFlags["collectors-dir"] {
+			*collectorsDir = val
+		}
+	case "AGENT_CONFIG_TOKEN":
+		if !setFlags["config-token"] {
+			*agentConfigToken = val
+		}
+	case "AGENT_CONFIG_URL":
+		if !setFlags["config-url"] {
+			*agentConfigURL = val
+		}
+	default:
+		// Parse labels with LABEL_ prefix: LABEL_project=myproject
+		if strings.HasPrefix(key, "LABEL_") {
+			labelKey := strings.TrimPrefix(key, "LABEL_")
+			if labelKey != "" {
+				agentLabels[labelKey] = val
 			}
 		}
 	}
-	return scanner.Err()
 }
 
 // loadEnv reads configuration from environment variables.
@@ -284,6 +470,14 @@ func loadEnv() {
 				*updateInterval = d
 			}
 		}},
+		{"UPDATE_PUBLIC_KEY", "update-public-key", func(val string) { *updatePublicKey = val }},
+		{"UPDATE_REQUIRE_HTTPS", "update-require-https", func(val string) { *updateRequireHTTPS = val == "true" || val == "1" }},
+		{"UPDATE_HEALTH_TIMEOUT", "update-health-timeout", func(val string) {
+			if d, err := time.ParseDuration(val); err == nil {
+				*updateHealthTimeout = d
+			}
+		}},
+		{"DEBUG_TOKEN", "debug-token", func(val string) { *debugToken = val }},
 		{"HEALTH_PORT", "health-port", func(val string) {
 			if i, err := strconv.Atoi(val); err == nil {
 				*healthPort = i
@@ -295,13 +489,32 @@ func loadEnv() {
 			}
 		}},
 		{"NGINX_STATUS_URL", "nginx-status-url", func(val string) { *nginxStatusURL = val }},
+		{"NGINX_INSTANCE_STATUS_URLS", "nginx-instance-status-urls", func(val string) { *instanceStatusURLs = val }},
 		{"ACCESS_LOG_PATH", "access-log-path", func(val string) { *accessLogPath = val }},
 		{"ERROR_LOG_PATH", "error-log-path", func(val string) { *errorLogPath = val }},
 		{"LOG_FORMAT", "log-format", func(val string) { *logFormat = val }},
+		{"LOG_FORMAT_STRING", "log-format-string", func(val string) { *logFormatString = val }},
+		{"LOG_SOURCE", "log-source", func(val string) { *logSource = val }},
+		{"LOG_SYSLOG_LISTEN", "log-syslog-listen", func(val string) { *syslogListen = val }},
+		{"LOG_JOURNALD_MATCH", "log-journald-match", func(val string) { *journaldMatch = val }},
+		{"LOG_REDACT", "log-redact", func(val string) { *logRedact = val }},
+		{"LOG_REDACT_PARAMS", "log-redact-params", func(val string) { *logRedactParams = val }},
+		{"LOG_REDACT_IPV4_PREFIX", "log-redact-ipv4-prefix", func(val string) {
+			if i, err := strconv.Atoi(val); err == nil {
+				*logRedactIPv4Prefix = i
+			}
+		}},
+		{"LOG_REDACT_IPV6_PREFIX", "log-redact-ipv6-prefix", func(val string) {
+			if i, err := strconv.Atoi(val); err == nil {
+				*logRedactIPv6Prefix = i
+			}
+		}},
 		{"NGINX_CONFIG_PATH", "nginx-config-path", func(val string) { *nginxConfigPath = val }},
 		{"BUFFER_DIR", "buffer-dir", func(val string) { *bufferDir = val }},
 		{"LOG_LEVEL", "log-level", func(val string) { *logLevel = val }},
 		{"LOG_FILE", "log-file", func(val string) { *logFile = val }},
+		{"LOG_ENCODING", "log-encoding", func(val string) { *logEncoding = val }},
+		{"LOG_COMPONENTS", "log-components", func(val string) { *logComponents = val }},
 		{"PSK_KEY", "psk", func(val string) { *pskKey = val }},
 		{"AVIKA_MGMT_ADVERTISE", "mgmt-advertise", func(val string) { *mgmtAdvertise = val }},
 		{"MGMT_ADVERTISE", "mgmt-advertise", func(val string) { *mgmtAdvertise = val }},
@@ -315,6 +528,37 @@ func loadEnv() {
 		{"SYSLOG_TARGET", "syslog-target", func(val string) { *syslogTarget = val }},
 		{"SYSLOG_FACILITY", "syslog-facility", func(val string) { *syslogFacility = val }},
 		{"SYSLOG_SEVERITY", "syslog-severity", func(val string) { *syslogSeverity = val }},
+		{"METRICS_INTERVAL", "metrics-interval", func(val string) {
+			if d, err := time.ParseDuration(val); err == nil {
+				*metricsInterval = d
+			}
+		}},
+		{"NGINX_METRICS_ENABLED", "nginx-metrics", func(val string) { *nginxMetricsEnabled = val == "true" || val == "1" }},
+		{"SYSTEM_METRICS_ENABLED", "system-metrics", func(val string) { *systemMetricsEnabled = val == "true" || val == "1" }},
+		{"DISABLED_COLLECTORS", "disabled-collectors", func(val string) { *disabledCollectors = val }},
+		{"HEARTBEAT_INTERVAL", "heartbeat-interval", func(val string) {
+			if d, err := time.ParseDuration(val); err == nil {
+				*heartbeatInterval = d
+			}
+		}},
+		{"DISCOVERY_INTERVAL", "discovery-interval", func(val string) {
+			if d, err := time.ParseDuration(val); err == nil {
+				*discoveryInterval = d
+			}
+		}},
+		{"MAX_CPU_PERCENT", "max-cpu-percent", func(val string) {
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				*maxCPUPercent = f
+			}
+		}},
+		{"MAX_MEMORY_MB", "max-memory-mb", func(val string) {
+			if i, err := strconv.Atoi(val); err == nil {
+				*maxMemoryMB = i
+			}
+		}},
+		{"COLLECTORS_DIR", "collectors-dir", func(val string) { *collectorsDir = val }},
+		{"AGENT_CONFIG_TOKEN", "config-token", func(val string) { *agentConfigToken = val }},
+		{"AGENT_CONFIG_URL", "config-url", func(val string) { *agentConfigURL = val }},
 	}
 
 	for _, m := range envMappings {
@@ -368,6 +612,80 @@ func getGatewayAddresses() []string {
 	return addresses
 }
 
+// newUpdater creates the self-updater with the configured package verification. Rejected
+// packages are reported to the gateway so it can alert on them.
+func newUpdater(server string, wal *buffer.FileBuffer) (*updater.Updater, error) {
+	u := updater.New(server, Version)
+	u.AgentID = *agentID
+	u.RequireHTTPS = *updateRequireHTTPS
+	u.StateFile = updateStatePath()
+	if key := *updatePublicKey; key != "" {
+		// A path to a PEM file is read, anything else is the key itself
+		if strings.HasPrefix(key, "/") {
+			data, err := os.ReadFile(key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read update public key: %w", err)
+			}
+			key = string(data)
+		}
+		pub, err := updater.ParsePublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPDATE_PUBLIC_KEY: %w", err)
+		}
+		u.PublicKey = pub
+		agentLog.Info().Msg("Self-update requires packages signed by the release key")
+	}
+	u.OnRejected = func(r *updater.RejectedError) {
+		writeToBuffer(wal, &pb.AgentMessage{
+			AgentId:   *agentID,
+			Timestamp: time.Now().Unix(),
+			Payload: &pb.AgentMessage_UpdateRejected{
+				UpdateRejected: &pb.UpdateRejected{
+					Version:  r.Version,
+					Platform: r.Platform,
+					Url:      r.URL,
+					Reason:   r.Reason,
+				},
+			},
+		})
+	}
+	return u, nil
+}
+
+// updateStatePath is where the status of a self-update is kept across the restart
+func updateStatePath() string {
+	return filepath.Join(*bufferDir, "update-state.json")
+}
+
+// gatewayConnected is closed once the agent holds a stream to a gateway, confirming a self-update
+var (
+	gatewayConnected     = make(chan struct{})
+	gatewayConnectedOnce sync.Once
+)
+
+// updateStatusSettle is how long a new stream must stay up before it counts as connected, so a
+// gateway that rejects the agent (e.g. PSK mismatch) does not confirm an update
+const updateStatusSettle = 5 * time.Second
+
+// updateStatusProto is the outcome of the last self-update for heartbeats
+func updateStatusProto() *pb.UpdateStatus {
+	s := updater.LastStatus()
+	if s == nil {
+		return nil
+	}
+	out := &pb.UpdateStatus{
+		State:       s.State,
+		FromVersion: s.FromVersion,
+		ToVersion:   s.ToVersion,
+		Error:       s.Error,
+		StartedAt:   s.StartedAt.Unix(),
+	}
+	if !s.FinishedAt.IsZero() {
+		out.FinishedAt = s.FinishedAt.Unix()
+	}
+	return out
+}
+
 // deriveUpdateServerFromGateway constructs the update server URL from the gateway address.
 // Gateway gRPC runs on port 5020, HTTP (with updates) runs on port 5021.
 func deriveUpdateServerFromGateway(gatewayAddr string) string {
@@ -415,6 +733,15 @@ func main() {
 	// Load configuration from environment variables (overrides config file, but not CLI flags)
 	loadEnv()
 
+	// Apply the gateway's configuration bundle, then reload the file and environment so they
+	// keep precedence over it
+	if *agentConfigToken != "" && loadRemoteConfig() {
+		if err := loadConfig(*configFile); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load config file: %v\n", err)
+		}
+		loadEnv()
+	}
+
 	// Load version from file if not set via ldflags (e.g. local dev)
 	if strings.Contains(Version, "dev") || Version == "0.1.0" {
 		if data, err := os.ReadFile("VERSION"); err == nil {
@@ -465,33 +792,33 @@ func main() {
 		*agentID = getOrGenerateAgentID()
 	}
 
-	agentInfo("=== Avika Agent Starting ===")
-	agentInfo("Agent ID:  %s", *agentID)
-	agentInfo("Agent IP:  %s", currentIP)
-	agentInfo("Version:   %s", Version)
+	agentLog.Info().Msg("=== Avika Agent Starting ===")
+	agentLog.Info().Msgf("Agent ID:  %s", *agentID)
+	agentLog.Info().Msgf("Agent IP:  %s", currentIP)
+	agentLog.Info().Msgf("Version:   %s", Version)
 	if Version == "0.1.0-dev" {
-		agentWarn("Binary reports 0.1.0-dev; it was likely built without the repo VERSION. Rebuild the gateway image and reinstall or self-update the agent to get the correct version.")
+		agentLog.Warn().Msg("Binary reports 0.1.0-dev; it was likely built without the repo VERSION. Rebuild the gateway image and reinstall or self-update the agent to get the correct version.")
 	}
-	agentInfo("Buffer:    %s", *bufferDir)
-	agentInfo("Gateways:  %s", *gatewayAddr)
-	agentInfo("============================")
+	agentLog.Info().Msgf("Buffer:    %s", *bufferDir)
+	agentLog.Info().Msgf("Gateways:  %s", *gatewayAddr)
+	agentLog.Info().Msg("============================")
 	agentLabelsMu.RLock()
 	if len(agentLabels) > 0 {
 		labelsCopy := make(map[string]string, len(agentLabels))
 		for k, v := range agentLabels {
 			labelsCopy[k] = v
 		}
-		agentInfo("Agent labels: %v", labelsCopy)
+		agentLog.Info().Msgf("Agent labels: %v", labelsCopy)
 	}
 	agentLabelsMu.RUnlock()
 
 	// 2. Start Health Check Server
-	healthServer := health.NewServer(*healthPort)
+	healthServer := health.NewServer(*healthPort, health.DebugOptions{Token: *debugToken, Config: effectiveConfig})
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		if err := healthServer.Start(); err != nil {
-			agentWarn("Health server error: %v", err)
+			agentLog.Warn().Msgf("Health server error: %v", err)
 		}
 	}()
 
@@ -502,33 +829,54 @@ func main() {
 		// Gateway gRPC is on port 5020, HTTP (with updates) is on port 5021
 		effectiveUpdateServer = deriveUpdateServerFromGateway(*gatewayAddr)
 		if effectiveUpdateServer != "" {
-			agentInfo("Auto-derived update server from gateway: %s", effectiveUpdateServer)
+			agentLog.Info().Msgf("Auto-derived update server from gateway: %s", effectiveUpdateServer)
 		}
 	} else if strings.ToLower(effectiveUpdateServer) == "disabled" {
 		effectiveUpdateServer = ""
-		agentInfo("Self-update disabled via configuration")
-	}
-
-	if effectiveUpdateServer != "" {
-		globalUpdater = updater.New(effectiveUpdateServer, Version)
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			startUpdaterLoop(ctx, globalUpdater, *updateInterval)
-			<-ctx.Done()
-		}()
+		agentLog.Info().Msg("Self-update disabled via configuration")
 	}
 
 	// 3. Initialize Persistent Buffer
 	wal, err := buffer.NewFileBuffer(*bufferDir + "agent")
 	if err != nil {
-			agentError("Failed to initialize buffer: %v", err)
+			agentLog.Error().Msgf("Failed to initialize buffer: %v", err)
 		os.Exit(1)
 	}
+	// Backlog of messages not yet sent to the gateway, in /debug/vars
+	expvar.Publish("buffer", expvar.Func(func() any {
+		stats, err := wal.GetStats()
+		if err != nil {
+			return err.Error()
+		}
+		return stats
+	}))
+
+	// The updater reports rejected packages through the buffer, so it starts once the buffer is up
+	if effectiveUpdateServer != "" {
+		if u, err := newUpdater(effectiveUpdateServer, wal); err != nil {
+			agentLog.Error().Msgf("Self-update disabled: %v", err)
+		} else {
+			globalUpdater = u
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				startUpdaterLoop(ctx, globalUpdater, *updateInterval)
+				<-ctx.Done()
+			}()
+		}
+	}
+
+	// Confirm a self-update once this binary reaches a gateway, or roll it back
+	confirmer := globalUpdater
+	if confirmer == nil {
+		confirmer = updater.New("", Version)
+		confirmer.StateFile = updateStatePath()
+	}
+	go confirmer.ConfirmUpdate(ctx, gatewayConnected, *updateHealthTimeout)
 
 	// Initial backup on node add/start
 	if err := config.BackupNginxConfig("startup"); err != nil {
-		agentWarn("Startup backup failed: %v", err)
+		agentLog.Warn().Msgf("Startup backup failed: %v", err)
 	}
 
 	// -------------------------------------------------------------------------
@@ -545,7 +893,7 @@ func main() {
 	collector := logs.NewLogCollector(
 		*accessLogPath,
 		*errorLogPath,
-		*logFormat,
+		accessLogFormat(),
 		"localhost:4317", // OTel OTLP gRPC endpoint
 		*agentID,
 		currentHostname,
@@ -556,11 +904,25 @@ func main() {
 			Severity:      *syslogSeverity,
 		},
 	)
+	switch source := strings.ToLower(*logSource); source {
+	case logs.SourceSyslog, logs.SourceJournald:
+		agentLog.Info().Msgf("Reading NGINX logs from %s instead of the log files", source)
+		collector.SetSource(logs.SourceConfig{Mode: source, SyslogListen: *syslogListen, JournaldMatch: *journaldMatch})
+	case logs.SourceFile, "":
+	default:
+		agentLog.Warn().Msgf("Unknown LOG_SOURCE %q, tailing the log files", *logSource)
+	}
+	collector.SetOffsetsFile(filepath.Join(*bufferDir, "log-offsets.json"))
+	collector.SetRedactor(updateLogRedactor())
 	collector.Start()
 	defer collector.Stop()
+	setRuntimeLogCollector(collector)
+	watchdog.Start(ctx, collector)
 
-	// Metrics Collector
+	// Metrics Collector, plus one per additional NGINX instance with a status URL
 	metricsCollector := metrics.NewNginxCollector(*nginxStatusURL)
+	secondaryCollectors := &instanceCollectors{}
+	requestRates := metrics.NewRequestRates()
 
 	// Goroutine: Collect Logs -> Buffer
 	wg.Add(1)
@@ -570,12 +932,17 @@ func main() {
 		for {
 			select {
 			case <-ctx.Done():
-				agentInfo("Log collection goroutine shutting down...")
+				agentLog.Info().Msg("Log collection goroutine shutting down...")
 				return
 			case entry, ok := <-logChan:
 				if !ok {
 					return
 				}
+				if id := entry.Labels[logs.InstanceLabel]; id == "" || id == nginxInstances.primaryID() {
+					metricsCollector.ObserveErrorLogLine(entry.Content)
+				} else {
+					secondaryCollectors.ObserveErrorLogLine(id, entry.Content)
+				}
 				msg := &pb.AgentMessage{
 					AgentId:   *agentID,
 					Timestamp: time.Now().Unix(),
@@ -594,107 +961,140 @@ func main() {
 		defer wg.Done()
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
+		var lastMetricsAt, lastHeartbeatAt, lastDiscoveryAt time.Time
+		var instances []*pb.NginxInstance
 
 		for {
 			select {
 			case <-ctx.Done():
-				agentInfo("Metrics collection goroutine shutting down...")
+				agentLog.Info().Msg("Metrics collection goroutine shutting down...")
 				return
 			case <-ticker.C:
-				// Dynamic Hostname Detection
-				h, err := os.Hostname()
-				if err == nil && h != "" {
-					currentHostname = h
-				}
+				// Heartbeats and discovery scans on their own intervals, changeable at runtime
+				heartbeatEvery, discoveryEvery := heartbeatSettings()
+				if time.Since(lastHeartbeatAt) >= heartbeatEvery {
+					lastHeartbeatAt = time.Now()
+					// Dynamic Hostname Detection
+					h, err := os.Hostname()
+					if err == nil && h != "" {
+						currentHostname = h
+					}
 
-				// Heartbeat
-				instances, _ := discoverer.Scan(context.Background())
-				isPod, podIP := detectK8s()
+					// Heartbeat, with the instances of the last discovery scan
+					if time.Since(lastDiscoveryAt) >= discoveryEvery {
+						lastDiscoveryAt = time.Now()
+						instances, _ = discoverer.Scan(context.Background())
+						nginxInstances.set(instances, *nginxConfigPath)
+						collector.SetInstances(nginxInstances.primaryID(), instanceLogs(nginxInstances.secondary()))
+					}
+					isPod, podIP := detectK8s()
 
-				// Determine primary NGINX version
-				primaryNginxVersion := "unknown"
-				lastMetricsVersion := metricsCollector.GetLastDetectedVersion()
+					// Determine primary NGINX version
+					primaryNginxVersion := "unknown"
+					lastMetricsVersion := metricsCollector.GetLastDetectedVersion()
 
-				if len(instances) > 0 {
-					for _, inst := range instances {
-						if inst.Version == "unknown" && lastMetricsVersion != "" {
-							inst.Version = lastMetricsVersion
+					if len(instances) > 0 {
+						for _, inst := range instances {
+							if inst.Version == "unknown" && lastMetricsVersion != "" {
+								inst.Version = lastMetricsVersion
+							}
 						}
+						primaryNginxVersion = instances[0].Version
+					} else if lastMetricsVersion != "" {
+						// Even if no process found via discovery (unlikely if metrics work),
+						// we can report the version from metrics API
+						primaryNginxVersion = lastMetricsVersion
 					}
-					primaryNginxVersion = instances[0].Version
-				} else if lastMetricsVersion != "" {
-					// Even if no process found via discovery (unlikely if metrics work),
-					// we can report the version from metrics API
-					primaryNginxVersion = lastMetricsVersion
-				}
 
-				// Fallback for K8s sidecar mode: try to extract from HTTP Server header if native discovery fails
-				if primaryNginxVersion == "unknown" && *nginxStatusURL != "" {
-					client := &http.Client{Timeout: 1 * time.Second}
-					if resp, err := client.Get(*nginxStatusURL); err == nil {
-						serverHeader := resp.Header.Get("Server") // e.g. "nginx/1.25.3"
-						if strings.HasPrefix(strings.ToLower(serverHeader), "nginx/") {
-							primaryNginxVersion = serverHeader[6:]
+					// Fallback for K8s sidecar mode: try to extract from HTTP Server header if native discovery fails
+					if primaryNginxVersion == "unknown" && *nginxStatusURL != "" {
+						client := &http.Client{Timeout: 1 * time.Second}
+						if resp, err := client.Get(*nginxStatusURL); err == nil {
+							serverHeader := resp.Header.Get("Server") // e.g. "nginx/1.25.3"
+							if strings.HasPrefix(strings.ToLower(serverHeader), "nginx/") {
+								primaryNginxVersion = serverHeader[6:]
+							}
+							resp.Body.Close()
 						}
-						resp.Body.Close()
 					}
-				}
 
-				hbMsg := &pb.AgentMessage{
-					AgentId:   *agentID,
-					Timestamp: time.Now().Unix(),
-					Payload: &pb.AgentMessage_Heartbeat{
-						Heartbeat: &pb.Heartbeat{
-							Hostname:     currentHostname,
-							Version:      primaryNginxVersion, // NGINX Version
-							AgentVersion: Version,             // Agent Version
-							Uptime:       time.Since(startTime).Seconds(),
-							Instances:    instances,
-							IsPod:        isPod,
-							PodIp:        podIP,
-							BuildDate:    BuildDate,
-							GitCommit:    GitCommit,
-							GitBranch:    GitBranch,
-							Labels: func() map[string]string {
-								agentLabelsMu.RLock()
-								defer agentLabelsMu.RUnlock()
-								if len(agentLabels) == 0 {
-									return map[string]string{}
-								}
-								m := make(map[string]string, len(agentLabels))
-								for k, v := range agentLabels {
-									m[k] = v
-								}
-								return m
-							}(), // Labels for auto-assignment
-							MgmtAddress:           getChosenMgmtAddress(),   // host:port for gateway dial-back (backward compat)
-							MgmtAddressCandidates: getAllCandidateMgmtAddresses(), // all candidate host:port for gateway to probe
+					hbMsg := &pb.AgentMessage{
+						AgentId:   *agentID,
+						Timestamp: time.Now().Unix(),
+						Payload: &pb.AgentMessage_Heartbeat{
+							Heartbeat: &pb.Heartbeat{
+								Hostname:     currentHostname,
+								Version:      primaryNginxVersion, // NGINX Version
+								AgentVersion: Version,             // Agent Version
+								Uptime:       time.Since(startTime).Seconds(),
+								Instances:    instances,
+								IsPod:        isPod,
+								PodIp:        podIP,
+								BuildDate:    BuildDate,
+								GitCommit:    GitCommit,
+								GitBranch:    GitBranch,
+								Labels: func() map[string]string {
+									agentLabelsMu.RLock()
+									defer agentLabelsMu.RUnlock()
+									if len(agentLabels) == 0 {
+										return map[string]string{}
+									}
+									m := make(map[string]string, len(agentLabels))
+									for k, v := range agentLabels {
+										m[k] = v
+									}
+									return m
+								}(), // Labels for auto-assignment
+								MgmtAddress:           getChosenMgmtAddress(),   // host:port for gateway dial-back (backward compat)
+								MgmtAddressCandidates: getAllCandidateMgmtAddresses(), // all candidate host:port for gateway to probe
+								Kubernetes:            kubernetesMetadata(isPod),      // pod namespace, owner and labels
+								UpdateStatus:          updateStatusProto(),            // outcome of the last self-update
+								Host:                  hostInventory(),                // OS, kernel and nginx/openssl packages
+							ResourceStatus:        watchdog.Status(),              // CPU and memory against the self-limits
+							},
 						},
-					},
+					}
+					writeToBuffer(wal, hbMsg)
 				}
-				writeToBuffer(wal, hbMsg)
 
-				// Metrics - always try to send even if NGINX metrics fail
-				nginxMetrics, err := metricsCollector.Collect()
-				if err != nil {
-					agentWarn("NGINX metrics collection failed: %v", err)
-					// Still send system metrics even if NGINX metrics fail
-					systemMetrics, sysErr := metricsCollector.CollectSystemOnly()
-					if sysErr == nil && systemMetrics != nil {
-						// Create a minimal NginxMetrics with just system data
-						fallbackMetrics := &pb.NginxMetrics{
-							System: systemMetrics,
-						}
-						metricMsg := &pb.AgentMessage{
-							AgentId:   *agentID,
-							Timestamp: time.Now().Unix(),
-							Payload: &pb.AgentMessage_Metrics{
-								Metrics: fallbackMetrics,
-							},
+				// Metrics on their own interval and toggles, both changeable at runtime. System metrics
+				// are still sent when NGINX metrics fail.
+				collectNginx, collectSystem, interval := metricsSettings()
+				if time.Since(lastMetricsAt) < interval {
+					continue
+				}
+				lastMetricsAt = time.Now()
+				metricsCollector.SetLogFiles(logFilePaths())
+				metricsCollector.SetDisabledCollectors(collectorSettings()...)
+				// With several instances the primary collector reports only its own workers
+				masterPID := 0
+				if len(instances) > 1 {
+					masterPID, _ = strconv.Atoi(instances[0].Pid)
+				}
+				metricsCollector.SetMasterPID(masterPID)
+				var nginxMetrics *pb.NginxMetrics
+				if collectNginx {
+					if nginxMetrics, err = metricsCollector.Collect(); err != nil {
+						agentLog.Warn().Msgf("NGINX metrics collection failed: %v", err)
+						nginxMetrics = nil
+					} else {
+						nginxMetrics.InstanceId = nginxInstances.primaryID()
+						if !slices.Contains(collectorSettings(), metrics.CollectorService) {
+							primary, _ := nginxInstances.get(nginxMetrics.InstanceId)
+							nginxMetrics.Service = nginxServices.sample(serviceTarget(primary))
 						}
-						writeToBuffer(wal, metricMsg)
 					}
-				} else {
+				}
+				if nginxMetrics == nil && collectSystem {
+					// Create a minimal NginxMetrics with just system data
+					if systemMetrics, sysErr := metricsCollector.CollectSystemOnly(); sysErr == nil && systemMetrics != nil {
+						nginxMetrics = &pb.NginxMetrics{System: systemMetrics}
+					}
+				} else if nginxMetrics != nil && !collectSystem {
+					nginxMetrics.System = nil
+				}
+				if nginxMetrics != nil {
+					requestRates.Set(nginxMetrics, time.Now())
 					metricMsg := &pb.AgentMessage{
 						AgentId:   *agentID,
 						Timestamp: time.Now().Unix(),
@@ -704,10 +1104,23 @@ func main() {
 					}
 					writeToBuffer(wal, metricMsg)
 				}
+				if collectNginx {
+					for _, m := range secondaryCollectors.Collect(nginxInstances.secondary(), parseInstanceStatusURLs(*instanceStatusURLs), collectorSettings()) {
+						requestRates.Set(m, time.Now())
+						writeToBuffer(wal, &pb.AgentMessage{
+							AgentId:   *agentID,
+							Timestamp: time.Now().Unix(),
+							Payload:   &pb.AgentMessage_Metrics{Metrics: m},
+						})
+					}
+				}
 			}
 		}
 	}()
 
+	// Custom collectors -> Buffer
+	startCustomCollectors(ctx, wal)
+
 	// Start Management Service (gRPC) in background
 	wg.Add(1)
 	go func() {
@@ -718,23 +1131,23 @@ func main() {
 			if err == nil {
 				serverTLSCreds = creds
 			} else {
-				agentWarn("Failed to load TLS credentials for management service: %v", err)
+				agentLog.Warn().Msgf("Failed to load TLS credentials for management service: %v", err)
 			}
 		}
 
-		agentInfo("Starting Management Service with NGINX config: %s", *nginxConfigPath)
+		agentLog.Info().Msgf("Starting Management Service with NGINX config: %s", *nginxConfigPath)
 		startMgmtService(ctx, *nginxConfigPath, *mgmtPort, serverTLSCreds)
 	}()
 
 	// Mark service as ready
 	healthServer.SetReady(true)
-	agentInfo("Agent is ready")
+	agentLog.Info().Msg("Agent is ready")
 
 	// -------------------------------------------------------------------------
 	// Sender (Consumer) -> Gateway(s)
 	// -------------------------------------------------------------------------
 	gateways := getGatewayAddresses()
-	agentInfo("Connecting to %d gateway(s): %v", len(gateways), gateways)
+	agentLog.Info().Msgf("Connecting to %d gateway(s): %v", len(gateways), gateways)
 
 	for _, gwAddr := range gateways {
 		wg.Add(1)
@@ -746,7 +1159,7 @@ func main() {
 
 	// Wait for shutdown signal
 	sig := <-sigChan
-		agentInfo("Received signal %v, initiating graceful shutdown...", sig)
+		agentLog.Info().Msgf("Received signal %v, initiating graceful shutdown...", sig)
 
 	// Mark as not ready
 	healthServer.SetReady(false)
@@ -763,25 +1176,25 @@ func main() {
 
 	select {
 	case <-done:
-		agentInfo("All goroutines stopped gracefully")
+		agentLog.Info().Msg("All goroutines stopped gracefully")
 	case <-time.After(10 * time.Second):
-		agentWarn("Shutdown timeout exceeded (10s), forcing exit")
+		agentLog.Warn().Msg("Shutdown timeout exceeded (10s), forcing exit")
 	}
 
 	// Cleanup buffer before final exit
-	agentInfo("Closing buffer...")
+	agentLog.Info().Msg("Closing buffer...")
 	if err := wal.Close(); err != nil {
-		agentWarn("Error closing buffer: %v", err)
+		agentLog.Warn().Msgf("Error closing buffer: %v", err)
 	}
 
 	// Shutdown health server
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 3*time.Second)
 	if err := healthServer.Shutdown(shutdownCtx); err != nil {
-		agentWarn("Health server shutdown error: %v", err)
+		agentLog.Warn().Msgf("Health server shutdown error: %v", err)
 	}
 	shutdownCancel()
 
-	agentInfo("Agent shutdown complete")
+	agentLog.Info().Msg("Agent shutdown complete")
 }
 
 // getPreferredIPv4 returns the first non-loopback IPv4 address from system interfaces.
@@ -1017,7 +1430,7 @@ func getOrGenerateAgentID() string {
 			// Migrate old-format ID (hostname+10.0.2.15) to new format (hostname-10-0-2-15) so UI and URLs are consistent
 			if migrated := migrateAgentIDToNewFormat(id); migrated != "" {
 				if writeErr := os.WriteFile(idFile, []byte(migrated), 0644); writeErr != nil {
-					agentWarn("Failed to persist migrated agent ID: %v", writeErr)
+					agentLog.Warn().Msgf("Failed to persist migrated agent ID: %v", writeErr)
 				}
 				return migrated
 			}
@@ -1042,7 +1455,7 @@ func getOrGenerateAgentID() string {
 
 	// 3. Persist for next restart
 	if err := os.WriteFile(idFile, []byte(agentID), 0644); err != nil {
-		agentWarn("Failed to persist agent ID: %v", err)
+		agentLog.Warn().Msgf("Failed to persist agent ID: %v", err)
 	}
 
 	return agentID
@@ -1069,15 +1482,19 @@ func migrateAgentIDToNewFormat(id string) string {
 	return hostname + "-" + sanitizeAgentIDSuffix(ip)
 }
 
+// bufferWriteLog logs the buffer writes of every message, sampled to keep a busy NGINX from
+// flooding the log at debug level or while the disk is full
+var bufferWriteLog = logging.Sampled(logging.NewLogger("buffer"), 10, time.Second)
+
 func writeToBuffer(wal *buffer.FileBuffer, msg *pb.AgentMessage) {
-	agentDebug("Writing message to buffer: type %T", msg.Payload)
+	bufferWriteLog.Debug().Msgf("Writing message to buffer: type %T", msg.Payload)
 	data, err := proto.Marshal(msg)
 	if err != nil {
-		agentWarn("Failed to marshal message: %v", err)
+		bufferWriteLog.Warn().Msgf("Failed to marshal message: %v", err)
 		return
 	}
 	if err := wal.Write(data); err != nil {
-		agentWarn("Failed to write to buffer: %v", err)
+		bufferWriteLog.Warn().Msgf("Failed to write to buffer: %v", err)
 	}
 }
 
@@ -1125,6 +1542,8 @@ func detectK8s() (bool, string) {
 type StreamSync struct {
 	mu     sync.Mutex
 	stream pb.Commander_ConnectClient
+	// drainedUntil is when the agent may reconnect to a gateway that drained it
+	drainedUntil time.Time
 }
 
 func (s *StreamSync) Send(msg *pb.AgentMessage) error {
@@ -1148,33 +1567,73 @@ func (s *StreamSync) GetStream() pb.Commander_ConnectClient {
 	return s.stream
 }
 
+// ClearStream forgets stream after it failed, unless the agent already reconnected
+func (s *StreamSync) ClearStream(stream pb.Commander_ConnectClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == stream {
+		s.stream = nil
+	}
+}
+
+// Drain closes the stream of a gateway shutting down so it can stop without waiting for this
+// agent, and keeps the agent away from it for retryAfter
+func (s *StreamSync) Drain(retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream != nil {
+		_ = s.stream.CloseSend()
+		s.stream = nil
+	}
+	s.drainedUntil = time.Now().Add(retryAfter)
+}
+
+// DrainWait is how long the agent must wait before reconnecting to a gateway that drained it
+func (s *StreamSync) DrainWait() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Until(s.drainedUntil)
+}
+
 func handleCommand(cmd *pb.ServerCommand, ss *StreamSync, agentID string) {
-	log.Printf("Processing command %s", cmd.CommandId)
+	agentLog.Info().Msgf("Processing command %s", cmd.CommandId)
 
 	switch payload := cmd.Payload.(type) {
 	case *pb.ServerCommand_LogRequest:
 		go handleLogRequest(cmd.CommandId, payload.LogRequest, ss, agentID)
 	case *pb.ServerCommand_Action:
-		log.Printf("Action command received: %s", payload.Action.Type)
+		agentLog.Info().Msgf("Action command received: %s", payload.Action.Type)
 		// For now just log, could trigger reload etc.
+	case *pb.ServerCommand_ConfigureAgent:
+		go handleConfigureAgent(cmd.CommandId, payload.ConfigureAgent, ss, agentID)
+	case *pb.ServerCommand_Drain:
+		// With other gateways the backlog goes through them meanwhile; with a single address
+		// reconnect at once, which lands on another replica behind it
+		var retryAfter time.Duration
+		if len(getGatewayAddresses()) > 1 {
+			retryAfter = time.Duration(payload.Drain.RetryAfterSeconds) * time.Second
+		}
+		agentLog.Info().Msgf("Gateway is draining (%s), closing stream", payload.Drain.Reason)
+		ss.Drain(retryAfter)
 	case *pb.ServerCommand_Update:
-		log.Printf("🚀 Remote update command received (target: %s, URL: %s)", payload.Update.Version, payload.Update.UpdateUrl)
+		agentLog.Info().Msgf("🚀 Remote update command received (target: %s, URL: %s)", payload.Update.Version, payload.Update.UpdateUrl)
 		if globalUpdater != nil {
 			// If a specific URL is provided in the command, override the default
 			targetURL := payload.Update.UpdateUrl
-			go globalUpdater.CheckAndApply(targetURL)
+			go globalUpdater.CheckAndApplyVersion(targetURL, payload.Update.Version)
 		} else {
-			log.Printf("⚠️  Update command ignored: Self-update is not configured on this agent")
+			agentLog.Warn().Msg("Update command ignored: Self-update is not configured on this agent")
 		}
 	}
 }
 
 func handleLogRequest(cmdID string, req *pb.LogRequest, ss *StreamSync, agentID string) {
-	log.Printf("Handling LogRequest: %s (tail: %d, follow: %v)", req.LogType, req.TailLines, req.Follow)
+	agentLog.Info().Msgf("Handling LogRequest: %s (tail: %d, follow: %v)", req.LogType, req.TailLines, req.Follow)
 
-	logPath := *accessLogPath
-	if req.LogType == "error" {
-		logPath = *errorLogPath
+	logPath, format, err := instanceLogPath(req)
+	if err != nil {
+		agentLog.Info().Msgf("Rejecting LogRequest: %v", err)
+		return
 	}
 
 	// 1. Send tail (last N lines)
@@ -1184,10 +1643,12 @@ func handleLogRequest(cmdID string, req *pb.LogRequest, ss *StreamSync, agentID
 	}
 	logEntries, err := logs.GetLastN(logPath, tailN)
 	if err != nil {
-		log.Printf("Failed to get last N logs: %v", err)
+		agentLog.Error().Msgf("Failed to get last N logs: %v", err)
 		return
 	}
+	redactor := currentLogRedactor()
 	for _, entry := range logEntries {
+		redactor.Apply(entry)
 		msg := &pb.AgentMessage{
 			AgentId:   agentID,
 			Timestamp: time.Now().Unix(),
@@ -1196,7 +1657,7 @@ func handleLogRequest(cmdID string, req *pb.LogRequest, ss *StreamSync, agentID
 			},
 		}
 		if err := ss.Send(msg); err != nil {
-			log.Printf("Failed to send log entry: %v", err)
+			agentLog.Error().Msgf("Failed to send log entry: %v", err)
 			return
 		}
 	}
@@ -1205,18 +1666,15 @@ func handleLogRequest(cmdID string, req *pb.LogRequest, ss *StreamSync, agentID
 	if !req.Follow {
 		return
 	}
-	format := *logFormat
-	if req.LogType == "error" {
-		format = "combined"
-	}
 	followChan, stop, err := logs.FollowFromEnd(logPath, req.LogType, format)
 	if err != nil {
-		log.Printf("Failed to start log follow: %v", err)
+		agentLog.Error().Msgf("Failed to start log follow: %v", err)
 		return
 	}
 	defer stop()
 
 	for entry := range followChan {
+		currentLogRedactor().Apply(entry)
 		msg := &pb.AgentMessage{
 			AgentId:   agentID,
 			Timestamp: time.Now().Unix(),
@@ -1225,7 +1683,7 @@ func handleLogRequest(cmdID string, req *pb.LogRequest, ss *StreamSync, agentID
 			},
 		}
 		if err := ss.Send(msg); err != nil {
-			log.Printf("Log follow send failed (client likely disconnected): %v", err)
+			agentLog.Error().Msgf("Log follow send failed (client likely disconnected): %v", err)
 			return
 		}
 	}
@@ -1256,13 +1714,15 @@ func buildBootstrapHeartbeat(agentID string) *pb.AgentMessage {
 				GitBranch:             GitBranch,
 				MgmtAddress:           getChosenMgmtAddress(),
 				MgmtAddressCandidates: getAllCandidateMgmtAddresses(),
+				Kubernetes:            kubernetesMetadata(isPod),
+				UpdateStatus:          updateStatusProto(),
 			},
 		},
 	}
 }
 
 func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gatewayAddr string) {
-	defer agentInfo("Sender loop for %s exited", gatewayAddr)
+	defer agentLog.Info().Msgf("Sender loop for %s exited", gatewayAddr)
 	var conn *grpc.ClientConn
 	var client pb.CommanderClient
 	ss := &StreamSync{}
@@ -1270,7 +1730,7 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 	for {
 		select {
 		case <-ctx.Done():
-			agentInfo("Sender loop for %s shutting down...", gatewayAddr)
+			agentLog.Info().Msgf("Sender loop for %s shutting down...", gatewayAddr)
 			if conn != nil {
 				conn.Close()
 			}
@@ -1280,29 +1740,50 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 
 		// 1. Connect / Reconnect
 		if ss.GetStream() == nil {
+			if wait := ss.DrainWait(); wait > 0 {
+				agentLog.Info().Msgf("Gateway %s is draining, reconnecting in %s", gatewayAddr, wait.Round(time.Second))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+			// A new connection, so a load balancer can pick another gateway
+			if conn != nil {
+				conn.Close()
+				conn = nil
+			}
 			var err error
 			// Gateway address already has protocol stripped
 			targetAddr := gatewayAddr
 
-					agentInfo("Connecting to gateway %s...", targetAddr)
+					agentLog.Info().Msgf("Connecting to gateway %s...", targetAddr)
 
-			dialOpts := []grpc.DialOption{}
+			// Keepalive pings detect a connection dropped without a FIN (e.g. by a NAT) so the agent
+			// reconnects; gateways accept pings every 10s or more
+			dialOpts := []grpc.DialOption{
+				grpc.WithKeepaliveParams(keepalive.ClientParameters{
+					Time:                20 * time.Second,
+					Timeout:             10 * time.Second,
+					PermitWithoutStream: true,
+				}),
+			}
 
 			if *enableTLS {
 				tlsCreds, err := loadAgentTLSCredentials()
 				if err != nil {
-					agentWarn("Failed to load TLS credentials: %v using insecure as fallback", err)
+					agentLog.Warn().Msgf("Failed to load TLS credentials: %v using insecure as fallback", err)
 					dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 				} else {
 					dialOpts = append(dialOpts, grpc.WithTransportCredentials(tlsCreds))
-					agentInfo("Using TLS for gateway connection")
+					agentLog.Info().Msg("Using TLS for gateway connection")
 				}
 			} else {
 				dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 			}
 
 			if *pskKey != "" {
-				agentInfo("Using PSK authentication")
+				agentLog.Info().Msg("Using PSK authentication")
 				h, _ := os.Hostname()
 				if h == "" {
 					h = "unknown"
@@ -1317,7 +1798,7 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 			// Dial with backoff? Simple wait for now
 			conn, err = grpc.Dial(targetAddr, dialOpts...)
 			if err != nil {
-				agentWarn("Connection failed: %v. Retrying in 5s...", err)
+				agentLog.Warn().Msgf("Connection failed: %v. Retrying in 5s...", err)
 				select {
 				case <-ctx.Done():
 					return
@@ -1330,7 +1811,7 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 			// Use the main context so connection attempt is cancelled on shutdown
 			stream, err := client.Connect(ctx)
 			if err != nil {
-				agentWarn("Stream creation failed: %v. Retrying in 5s...", err)
+				agentLog.Warn().Msgf("Stream creation failed: %v. Retrying in 5s...", err)
 				conn.Close()
 				select {
 				case <-ctx.Done():
@@ -1340,11 +1821,11 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 				}
 			}
 			ss.SetStream(stream)
-			agentInfo("Connected to Gateway %s", targetAddr)
+			agentLog.Info().Msgf("Connected to Gateway %s", targetAddr)
 
 			// Send one heartbeat immediately so the gateway registers this agent (session) even if the WAL is corrupt.
 			if err := ss.Send(buildBootstrapHeartbeat(agentID)); err != nil {
-				agentWarn("Bootstrap heartbeat failed: %v", err)
+				agentLog.Warn().Msgf("Bootstrap heartbeat failed: %v", err)
 				ss.SetStream(nil)
 				conn.Close()
 				select {
@@ -1355,46 +1836,47 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 				}
 			}
 
-			// Start Receiver routine (for commands)
-			go func() {
-				// Ensure receiver exits when context is done
+			go func(stream pb.Commander_ConnectClient) {
+				select {
+				case <-ctx.Done():
+				case <-time.After(updateStatusSettle):
+					if ss.GetStream() == stream {
+						gatewayConnectedOnce.Do(func() { close(gatewayConnected) })
+					}
+				}
+			}(stream)
+
+			// Start Receiver routine (for commands). It sticks to this stream: after a drain the
+			// sender reconnects and starts a receiver for the new one.
+			go func(stream pb.Commander_ConnectClient) {
 				defer func() {
-					agentInfo("Receiver routine exiting")
+					agentLog.Info().Msg("Receiver routine exiting")
 				}()
 
 				for {
-					select {
-					case <-ctx.Done():
-						return
-					default:
-					}
-
-					currentStream := ss.GetStream()
-					if currentStream == nil {
-						return
-					}
-					cmd, err := currentStream.Recv()
+					// Recv fails once the context is done, as the stream was opened with it
+					cmd, err := stream.Recv()
 					if err != nil {
-						agentWarn("Stream disconnected (Recv): %v", err)
-						ss.SetStream(nil)
+						agentLog.Warn().Msgf("Stream disconnected (Recv): %v", err)
+						ss.ClearStream(stream)
 						return
 					}
 					handleCommand(cmd, ss, agentID)
 				}
-			}()
+			}(stream)
 		}
 
 		// 2. Read from Buffer & Send
 		data, offset, err := wal.ReadNext()
 		if err != nil {
-			log.Printf("Buffer read error: %v", err)
+			agentLog.Error().Msgf("Buffer read error: %v", err)
 			if strings.Contains(err.Error(), "suspiciously large message length") {
-				agentWarn("CRITICAL: Buffer corruption detected at offset %d. Message length reported as huge. This usually means the WAL file is corrupted.", offset)
-				agentWarn("Attempting to skip the corrupted length header (4 bytes) to realign...")
+				agentLog.Error().Msgf("CRITICAL: Buffer corruption detected at offset %d. Message length reported as huge. This usually means the WAL file is corrupted.", offset)
+				agentLog.Warn().Msg("Attempting to skip the corrupted length header (4 bytes) to realign...")
 				if skipErr := wal.SkipCorrupt(offset); skipErr != nil {
-					agentError("Failed to skip corrupt message: %v", skipErr)
+					agentLog.Error().Msgf("Failed to skip corrupt message: %v", skipErr)
 				} else {
-					agentInfo("Successfully advanced read offset past corruption.")
+					agentLog.Info().Msg("Successfully advanced read offset past corruption.")
 				}
 			}
 			select {
@@ -1418,16 +1900,16 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 		// Unmarshal to verify/check or just send?
 		var msg pb.AgentMessage
 		if err := proto.Unmarshal(data, &msg); err != nil {
-			log.Printf("Corrupt message in buffer at offset %d, skipping: %v", offset, err)
+			agentLog.Warn().Msgf("Corrupt message in buffer at offset %d, skipping: %v", offset, err)
 			wal.Ack(offset) // Skip corrupt message
 			continue
 		}
 
 		// Send
 		ptype := getPayloadType(&msg)
-		agentDebug("[%s] Sending message from buffer: type %s (%d bytes) at offset %d", gatewayAddr, ptype, len(data), offset)
+		agentLog.Debug().Msgf("[%s] Sending message from buffer: type %s (%d bytes) at offset %d", gatewayAddr, ptype, len(data), offset)
 		if err := ss.Send(&msg); err != nil {
-			log.Printf("Failed to send: %v. Reconnecting...", err)
+			agentLog.Error().Msgf("Failed to send: %v. Reconnecting...", err)
 			ss.SetStream(nil)
 			if conn != nil {
 				conn.Close()
@@ -1439,11 +1921,11 @@ func senderLoop(ctx context.Context, wal *buffer.FileBuffer, agentID string, gat
 				continue // Retry loop will handle reconnection
 			}
 		}
-		agentInfo("[%s] Successfully sent message type %s (%d bytes)", gatewayAddr, getPayloadType(&msg), len(data))
+		agentLog.Info().Msgf("[%s] Successfully sent message type %s (%d bytes)", gatewayAddr, getPayloadType(&msg), len(data))
 
 		// Success -> Ack
 		if err := wal.Ack(offset); err != nil {
-			log.Printf("Failed to ack offset: %v", err)
+			agentLog.Error().Msgf("Failed to ack offset: %v", err)
 		}
 	}
 }
@@ -1483,51 +1965,15 @@ func isRunningInContainer() bool {
 	return false
 }
 
-// log level order for filtering (higher = more severe)
-const (
-	agentLevelDebug = iota
-	agentLevelInfo
-	agentLevelWarn
-	agentLevelError
-)
+// agentLog is the agent's logger; packages log through components of their own (buffer, logs,
+// updater, ...), whose levels can be changed at runtime through the health server's /log-levels.
+var agentLog = logging.NewLogger("agent")
 
-var currentLogLevel int = agentLevelInfo
-
-func parseLogLevel(s string) int {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "debug":
-		return agentLevelDebug
-	case "info":
-		return agentLevelInfo
-	case "warn", "warning":
-		return agentLevelWarn
-	case "error":
-		return agentLevelError
-	default:
-		return agentLevelInfo
-	}
-}
-
-// agentLog writes a formatted log line with timestamp and level if level is enabled. Use agentDebug/agentInfo/agentWarn/agentError.
-func agentLog(level string, levelNum int, format string, args ...interface{}) {
-	if levelNum < currentLogLevel {
-		return
-	}
-	msg := fmt.Sprintf(format, args...)
-	ts := time.Now().UTC().Format(time.RFC3339)
-	line := fmt.Sprintf("%s [%s] %s", ts, strings.ToUpper(level), msg)
-	_ = log.Output(2, line)
-}
-
-func agentDebug(format string, args ...interface{}) { agentLog("debug", agentLevelDebug, format, args...) }
-func agentInfo(format string, args ...interface{})  { agentLog("info", agentLevelInfo, format, args...) }
-func agentWarn(format string, args ...interface{}) { agentLog("warn", agentLevelWarn, format, args...) }
-func agentError(format string, args ...interface{}) { agentLog("error", agentLevelError, format, args...) }
+// mgmtLog logs the management service the gateway calls
+var mgmtLog = logging.NewLogger("mgmt")
 
 func setupLogging() error {
-	// Apply dynamic log level from flag/env (default: info)
-	currentLogLevel = parseLogLevel(*logLevel)
-
+	var out io.Writer = os.Stdout
 	if *logFile != "" {
 		// Create log directory if it doesn't exist
 		logDir := filepath.Dir(*logFile)
@@ -1546,26 +1992,52 @@ func setupLogging() error {
 				fmt.Printf("Warning: failed to open log file %s: %v. Falling back to stdout logging.\n", *logFile, err)
 				*logFile = ""
 			} else {
-				log.SetOutput(f)
-				agentInfo("Logging to file: %s", *logFile)
+				out = f
 			}
 		}
 	}
-	
-	if *logFile == "" {
+
+	// Text is one line per event: time, level, message and fields; json is one object per event
+	format := "console"
+	if strings.EqualFold(*logEncoding, "json") {
+		format = "json"
+	}
+	// Apply dynamic log level from flag/env (default: info)
+	logging.Setup(&logging.Config{
+		Level:      *logLevel,
+		Format:     format,
+		Output:     out,
+		TimeFormat: time.RFC3339,
+		NoColor:    true,
+		Service:    "agent",
+		Version:    Version,
+	})
+	for _, spec := range strings.Split(*logComponents, ",") {
+		component, level, _ := strings.Cut(strings.TrimSpace(spec), "=")
+		if component == "" {
+			continue
+		}
+		if err := logging.SetLevel(component, level); err != nil {
+			agentLog.Warn().Err(err).Msg("Ignoring log-components entry")
+		}
+	}
+	// What dependencies still write through the standard log package
+	logging.RedirectStdLog(logging.NewLogger("stdlog"))
+
+	if *logFile != "" {
+		agentLog.Info().Msgf("Logging to file: %s", *logFile)
+	} else {
 		// Log to stdout - provide context about where logs will go
 		if isRunningInContainer() {
-			agentInfo("Logging to stdout (container mode - use 'kubectl logs' or container runtime to view)")
+			agentLog.Info().Msg("Logging to stdout (container mode - use 'kubectl logs' or container runtime to view)")
 		} else {
 			if os.Getenv("INVOCATION_ID") == "" && os.Getppid() != 1 {
-				agentWarn("Logging to stdout but not running under systemd. Consider setting LOG_FILE for persistent logs.")
+				agentLog.Warn().Msg("Logging to stdout but not running under systemd. Consider setting LOG_FILE for persistent logs.")
 			} else {
-				agentInfo("Logging to stdout (systemd mode - use 'journalctl -u avika-agent' to view)")
+				agentLog.Info().Msg("Logging to stdout (systemd mode - use 'journalctl -u avika-agent' to view)")
 			}
 		}
 	}
-	// Single-line format: timestamp [LEVEL] message (no extra prefix from log package)
-	log.SetFlags(0)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,21 @@ import (
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
+// Optional collectors an operator can disable, e.g. where probing or /proc scans are expensive
+const (
+	CollectorAdvanced = "advanced" // NGINX Plus API
+	CollectorVTS      = "vts"      // nginx-module-vts
+	CollectorWorkers  = "workers"  // worker processes from /proc
+	CollectorDisks    = "disks"    // filesystems and log files
+	CollectorFDs      = "fds"      // file descriptors and conntrack
+	CollectorService  = "service"  // systemd/OpenRC unit state
+)
+
+// OptionalCollectors lists the collectors SetDisabledCollectors accepts
+var OptionalCollectors = []string{CollectorAdvanced, CollectorVTS, CollectorWorkers, CollectorDisks, CollectorFDs, CollectorService}
+
+var errCollectorDisabled = errors.New("collector disabled")
+
 // NginxCollector collects metrics from NGINX stub_status
 type NginxCollector struct {
 	stubStatusURL     string
@@ -22,6 +38,12 @@ type NginxCollector struct {
 	systemCollector   *SystemCollector
 	vtsCollector      *VtsCollector
 	advancedCollector *AdvancedCollector
+	workerCollector   *WorkerCollector
+	disabled          map[string]bool
+	// Set on collectors of additional nginx instances: system metrics are reported once, by the
+	// primary collector, and the Plus API and VTS are only tried at URLs derived from the stub_status one
+	skipSystem bool
+	stubOnly   bool
 }
 
 func NewNginxCollector(url string) *NginxCollector {
@@ -50,17 +72,39 @@ func NewNginxCollector(url string) *NginxCollector {
 		systemCollector:   NewSystemCollector(),
 		vtsCollector:      NewVtsCollector(vtsURL),
 		advancedCollector: NewAdvancedCollector(advancedURL),
+		workerCollector:   NewWorkerCollector(),
 	}
 }
 
-// Collect scrapes metrics and returns them. It tries Advanced API, then VTS, then stub_status.
+// NewInstanceCollector returns a collector for one of several nginx instances on the host: its
+// stub_status URL and the workers of its master process, without system metrics
+func NewInstanceCollector(url string, masterPID int) *NginxCollector {
+	c := NewNginxCollector(url)
+	c.skipSystem = true
+	c.stubOnly = !strings.Contains(url, "nginx_status")
+	c.workerCollector.masterPID = masterPID
+	return c
+}
+
+// SetMasterPID limits the reported workers to those of one master process; 0 reports all
+func (c *NginxCollector) SetMasterPID(pid int) {
+	if c.workerCollector.masterPID != pid {
+		c.workerCollector.Reset()
+		c.workerCollector.masterPID = pid
+	}
+}
+
+// Collect scrapes metrics and returns them. It tries Advanced API, then VTS, then stub_status,
+// skipping disabled collectors.
 func (c *NginxCollector) Collect() (*pb.NginxMetrics, error) {
 	var metrics *pb.NginxMetrics
-	var err error
+	err := errCollectorDisabled
 
 	// 1. Try Advanced NGINX API first
-	metrics, err = c.advancedCollector.Collect()
-	if err != nil {
+	if !c.disabled[CollectorAdvanced] && !c.stubOnly {
+		metrics, err = c.advancedCollector.Collect()
+	}
+	if err != nil && !c.disabled[CollectorVTS] && !c.stubOnly {
 		// 2. Try VTS next
 		metrics, err = c.vtsCollector.Collect()
 	}
@@ -96,14 +140,46 @@ func (c *NginxCollector) Collect() (*pb.NginxMetrics, error) {
 	metrics.Labels["server"] = hostname
 
 	// Collect system metrics
-	systemMetrics, err := c.systemCollector.Collect()
-	if err == nil {
-		metrics.System = systemMetrics
+	if !c.skipSystem {
+		if systemMetrics, err := c.systemCollector.Collect(); err == nil {
+			metrics.System = systemMetrics
+		}
+	}
+
+	if !c.disabled[CollectorWorkers] {
+		metrics.Workers = c.workerCollector.Collect()
 	}
 
 	return metrics, nil
 }
 
+// SetDisabledCollectors sets the optional collectors to skip. Unknown names are ignored. It is
+// not safe to call concurrently with Collect.
+func (c *NginxCollector) SetDisabledCollectors(names ...string) {
+	disabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		disabled[name] = true
+	}
+	if !disabled[CollectorWorkers] && c.disabled[CollectorWorkers] {
+		// Start over when re-enabled instead of reporting the gap as churn
+		c.workerCollector.Reset()
+	}
+	c.disabled = disabled
+	c.systemCollector.skipDisks = disabled[CollectorDisks]
+	c.systemCollector.skipFDs = disabled[CollectorFDs]
+}
+
+// SetLogFiles sets the nginx log files reported with system metrics
+func (c *NginxCollector) SetLogFiles(paths ...string) {
+	c.systemCollector.SetLogFiles(paths...)
+}
+
+// ObserveErrorLogLine looks for worker crashes in an error log line; they are reported with the
+// next metrics
+func (c *NginxCollector) ObserveErrorLogLine(line string) {
+	c.workerCollector.ObserveLogLine(line)
+}
+
 // GetLastDetectedVersion returns the last NGINX version detected by sub-collectors
 func (c *NginxCollector) GetLastDetectedVersion() string {
 	if c.advancedCollector.LastDetectedVersion != "" {
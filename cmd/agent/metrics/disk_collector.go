@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// pseudoFilesystems are not reported as disks
+var pseudoFilesystems = map[string]bool{
+	"proc": true, "sysfs": true, "cgroup": true, "cgroup2": true, "devpts": true, "devtmpfs": true,
+	"tmpfs": true, "mqueue": true, "debugfs": true, "tracefs": true, "securityfs": true, "pstore": true,
+	"bpf": true, "autofs": true, "configfs": true, "fusectl": true, "hugetlbfs": true, "binfmt_misc": true,
+	"squashfs": true, "nsfs": true, "rpc_pipefs": true, "selinuxfs": true, "efivarfs": true, "ramfs": true,
+}
+
+type mountEntry struct {
+	device string
+	mount  string
+	fstype string
+}
+
+type logFileSample struct {
+	size uint64
+	at   time.Time
+}
+
+// DiskCollector reports the usage of mounted filesystems and the size and growth of log files
+type DiskCollector struct {
+	mountsPath string
+	logFiles   []string
+	lastLogs   map[string]logFileSample
+}
+
+func NewDiskCollector() *DiskCollector {
+	return &DiskCollector{mountsPath: "/proc/mounts", lastLogs: make(map[string]logFileSample)}
+}
+
+// Collect returns the disks, one per device, and the log files. Disks holding a log file are
+// flagged so the log partition can be watched.
+func (c *DiskCollector) Collect() ([]*pb.DiskUsage, []*pb.LogFileUsage) {
+	mounts := c.readMounts()
+	now := time.Now()
+
+	var logs []*pb.LogFileUsage
+	logMounts := make(map[string]bool)
+	seenLogs := make(map[string]bool)
+	for _, path := range expandLogFiles(c.logFiles) {
+		if seenLogs[path] {
+			continue
+		}
+		seenLogs[path] = true
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		lf := &pb.LogFileUsage{Path: path, Mount: mountOf(mounts, path), SizeBytes: uint64(info.Size())}
+		if last, ok := c.lastLogs[path]; ok && lf.SizeBytes >= last.size {
+			if elapsed := now.Sub(last.at).Seconds(); elapsed > 0 {
+				lf.GrowthBytesPerSec = float32(float64(lf.SizeBytes-last.size) / elapsed)
+			}
+		}
+		c.lastLogs[path] = logFileSample{size: lf.SizeBytes, at: now}
+		logMounts[lf.Mount] = true
+		logs = append(logs, lf)
+	}
+	for path := range c.lastLogs {
+		if !seenLogs[path] {
+			delete(c.lastLogs, path)
+		}
+	}
+
+	var disks []*pb.DiskUsage
+	seenDevices := make(map[string]*pb.DiskUsage)
+	for _, m := range mounts {
+		if pseudoFilesystems[m.fstype] {
+			continue
+		}
+		// Bind mounts repeat a device; report it once, flagged if any of its mounts holds logs
+		if d, ok := seenDevices[m.device]; ok {
+			d.NginxLogs = d.NginxLogs || logMounts[m.mount]
+			continue
+		}
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(m.mount, &st); err != nil || st.Blocks == 0 {
+			continue
+		}
+		bsize := uint64(st.Bsize)
+		d := &pb.DiskUsage{
+			Mount:          m.mount,
+			Device:         m.device,
+			Fstype:         m.fstype,
+			TotalBytes:     st.Blocks * bsize,
+			UsedBytes:      (st.Blocks - st.Bfree) * bsize,
+			AvailableBytes: st.Bavail * bsize,
+			NginxLogs:      logMounts[m.mount],
+		}
+		if used := d.UsedBytes + d.AvailableBytes; used > 0 {
+			d.UsedPercent = float32(d.UsedBytes) / float32(used) * 100
+		}
+		if st.Files > 0 {
+			d.InodesUsedPercent = float32(st.Files-st.Ffree) / float32(st.Files) * 100
+		}
+		seenDevices[m.device] = d
+		disks = append(disks, d)
+	}
+	return disks, logs
+}
+
+// expandLogFiles resolves log paths that are globs to the files they match
+func expandLogFiles(patterns []string) []string {
+	var paths []string
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, "*?[") {
+			if p != "" {
+				paths = append(paths, p)
+			}
+			continue
+		}
+		matches, _ := filepath.Glob(p)
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// readMounts parses the mount table, shortest mount point first so a device is reported under
+// its top-level mount
+func (c *DiskCollector) readMounts() []mountEntry {
+	file, err := os.Open(c.mountsPath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		mounts = append(mounts, mountEntry{device: fields[0], mount: unescapeMount(fields[1]), fstype: fields[2]})
+	}
+	sort.SliceStable(mounts, func(i, j int) bool { return len(mounts[i].mount) < len(mounts[j].mount) })
+	return mounts
+}
+
+// unescapeMount decodes the octal escapes of /proc/mounts (\040 for a space)
+func unescapeMount(s string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(s)
+}
+
+// mountOf returns the mount point a path lives on: the longest mount point containing it
+func mountOf(mounts []mountEntry, path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	best := "/"
+	for _, m := range mounts {
+		if pseudoFilesystems[m.fstype] {
+			continue
+		}
+		if (m.mount == "/" || path == m.mount || strings.HasPrefix(path, m.mount+"/")) && len(m.mount) > len(best) {
+			best = m.mount
+		}
+	}
+	return best
+}
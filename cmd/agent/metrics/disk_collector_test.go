@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCollector(t *testing.T) {
+	dir := t.TempDir()
+	mounts := filepath.Join(dir, "mounts")
+	table := "/dev/root /etc/hosts ext4 rw 0 0\n" +
+		"proc /proc proc rw 0 0\n" +
+		"/dev/root / ext4 rw 0 0\n" +
+		"/dev/sdb1 /mnt/no\\040such ext4 rw 0 0\n"
+	if err := os.WriteFile(mounts, []byte(table), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logFile := filepath.Join(dir, "access.log")
+	if err := os.WriteFile(logFile, make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &DiskCollector{mountsPath: mounts, lastLogs: map[string]logFileSample{}, logFiles: []string{logFile, filepath.Join(dir, "missing.log")}}
+	disks, logs := c.Collect()
+	if len(disks) != 1 || disks[0].Mount != "/" || !disks[0].NginxLogs || disks[0].TotalBytes == 0 {
+		t.Fatalf("disks = %+v", disks)
+	}
+	if len(logs) != 1 || logs[0].Mount != "/" || logs[0].SizeBytes != 1000 || logs[0].GrowthBytesPerSec != 0 {
+		t.Fatalf("logs = %+v", logs)
+	}
+
+	// Growth is measured from the previous sample
+	c.lastLogs[logFile] = logFileSample{size: 500, at: time.Now().Add(-10 * time.Second)}
+	_, logs = c.Collect()
+	if g := logs[0].GrowthBytesPerSec; g < 45 || g > 55 {
+		t.Errorf("growth = %v, want about 50 B/s", g)
+	}
+}
+
+func TestMountOf(t *testing.T) {
+	mounts := []mountEntry{{"/dev/root", "/", "ext4"}, {"/dev/sdb1", "/var/log", "xfs"}, {"/dev/sdc1", "/var/lognot", "xfs"}}
+	tests := map[string]string{
+		"/var/log/nginx/access.log": "/var/log",
+		"/var/lognot/x.log":         "/var/lognot",
+		"/srv/logs/error.log":       "/",
+	}
+	for path, want := range tests {
+		if got := mountOf(mounts, path); got != want {
+			t.Errorf("mountOf(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// FDCollector reports file descriptor usage, system-wide and of the nginx processes, and the
+// fill of the connection tracking table. Exhausting either silently breaks keepalive traffic.
+type FDCollector struct {
+	procRoot string
+}
+
+func NewFDCollector() *FDCollector {
+	return &FDCollector{procRoot: "/proc"}
+}
+
+// Collect fills the file descriptor and conntrack fields of metrics
+func (c *FDCollector) Collect(metrics *pb.SystemMetrics) {
+	// file-nr is "allocated unused max"; unused is always 0 on current kernels
+	if fields := strings.Fields(c.readFile("sys/fs/file-nr")); len(fields) == 3 {
+		metrics.FdAllocated = parseUint64(fields[0]) - min(parseUint64(fields[1]), parseUint64(fields[0]))
+		metrics.FdMax = parseUint64(fields[2])
+		metrics.FdUsedPercent = percent(metrics.FdAllocated, metrics.FdMax)
+	}
+
+	metrics.ConntrackCount = parseUint64(c.readFile("sys/net/netfilter/nf_conntrack_count"))
+	metrics.ConntrackMax = parseUint64(c.readFile("sys/net/netfilter/nf_conntrack_max"))
+	metrics.ConntrackUsedPercent = percent(metrics.ConntrackCount, metrics.ConntrackMax)
+
+	metrics.NginxFds = c.nginxFDs()
+}
+
+// nginxFDs counts the open descriptors of the nginx master and worker processes
+func (c *FDCollector) nginxFDs() []*pb.ProcessFdUsage {
+	var out []*pb.ProcessFdUsage
+	entries, _ := os.ReadDir(c.procRoot)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		cmdline := c.readFile(filepath.Join(e.Name(), "cmdline"))
+		var role string
+		switch {
+		case strings.HasPrefix(cmdline, "nginx: master process"):
+			role = "master"
+		case strings.HasPrefix(cmdline, "nginx: worker process"):
+			role = "worker"
+		default:
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(c.procRoot, e.Name(), "fd"))
+		if err != nil {
+			// Another user's process; the agent needs root or CAP_SYS_PTRACE to see it
+			continue
+		}
+		p := &pb.ProcessFdUsage{
+			Pid:     int32(pid),
+			Role:    role,
+			OpenFds: uint64(len(fds)),
+			Limit:   c.openFilesLimit(e.Name()),
+		}
+		p.UsedPercent = percent(p.OpenFds, p.Limit)
+		out = append(out, p)
+	}
+	return out
+}
+
+// openFilesLimit returns the soft "Max open files" limit of a process, 0 when unlimited or unknown
+func (c *FDCollector) openFilesLimit(pid string) uint64 {
+	file, err := os.Open(filepath.Join(c.procRoot, pid, "limits"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "Max open files"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				return parseUint64(fields[0])
+			}
+		}
+	}
+	return 0
+}
+
+func (c *FDCollector) readFile(name string) string {
+	data, err := os.ReadFile(filepath.Join(c.procRoot, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+}
+
+func percent(used, limit uint64) float32 {
+	if limit == 0 {
+		return 0
+	}
+	return float32(used) / float32(limit) * 100
+}
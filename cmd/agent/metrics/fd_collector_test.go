@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func writeProcFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func addFDs(t *testing.T, root, pid string, n int) {
+	t.Helper()
+	dir := filepath.Join(root, pid, "fd")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(dir, string(rune('a'+i))), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFDCollector(t *testing.T) {
+	root := t.TempDir()
+	writeProcFile(t, root, "sys/fs/file-nr", "9000\t0\t10000\n")
+	writeProcFile(t, root, "sys/net/netfilter/nf_conntrack_count", "200\n")
+	writeProcFile(t, root, "sys/net/netfilter/nf_conntrack_max", "262144\n")
+
+	limits := "Limit                     Soft Limit           Hard Limit           Units     \n" +
+		"Max open files            8                    4096                 files     \n"
+	writeProcFile(t, root, "100/cmdline", "nginx: master process /usr/sbin/nginx\x00")
+	writeProcFile(t, root, "100/limits", limits)
+	addFDs(t, root, "100", 2)
+	writeProcFile(t, root, "101/cmdline", "nginx: worker process\x00")
+	writeProcFile(t, root, "101/limits", "Max open files            unlimited            unlimited            files     \n")
+	addFDs(t, root, "101", 4)
+	writeProcFile(t, root, "102/cmdline", "/usr/bin/python3\x00")
+	addFDs(t, root, "102", 3)
+
+	m := &pb.SystemMetrics{}
+	(&FDCollector{procRoot: root}).Collect(m)
+
+	if m.FdAllocated != 9000 || m.FdMax != 10000 || m.FdUsedPercent != 90 {
+		t.Errorf("system fds = %d/%d (%.1f%%)", m.FdAllocated, m.FdMax, m.FdUsedPercent)
+	}
+	if m.ConntrackCount != 200 || m.ConntrackMax != 262144 {
+		t.Errorf("conntrack = %d/%d", m.ConntrackCount, m.ConntrackMax)
+	}
+	if len(m.NginxFds) != 2 {
+		t.Fatalf("nginx fds = %+v", m.NginxFds)
+	}
+	master, worker := m.NginxFds[0], m.NginxFds[1]
+	if master.Role != "master" || master.OpenFds != 2 || master.Limit != 8 || master.UsedPercent != 25 {
+		t.Errorf("master = %+v", master)
+	}
+	if worker.Role != "worker" || worker.OpenFds != 4 || worker.Limit != 0 || worker.UsedPercent != 0 {
+		t.Errorf("worker = %+v", worker)
+	}
+}
+
+func TestFDCollectorWithoutConntrack(t *testing.T) {
+	m := &pb.SystemMetrics{}
+	(&FDCollector{procRoot: t.TempDir()}).Collect(m)
+	if m.ConntrackMax != 0 || m.ConntrackUsedPercent != 0 || m.FdMax != 0 || len(m.NginxFds) != 0 {
+		t.Errorf("metrics = %+v", m)
+	}
+}
+
+func TestSystemCollectorSkipsDisabledCollectors(t *testing.T) {
+	c := NewNginxCollector("")
+	c.SetDisabledCollectors(CollectorDisks, CollectorFDs)
+	m, err := c.CollectSystemOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Disks) != 0 || m.FdMax != 0 || len(m.NginxFds) != 0 {
+		t.Errorf("disabled collectors reported: disks=%d fd_max=%d", len(m.Disks), m.FdMax)
+	}
+}
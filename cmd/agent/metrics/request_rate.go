@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+type requestSample struct {
+	total int64
+	at    time.Time
+}
+
+// RequestRates derives requests per second from the request counter of stub_status, VTS or the
+// Plus API, which counts every request since nginx started, by the difference between an nginx
+// instance's consecutive samples
+type RequestRates struct {
+	last map[string]requestSample // instance ID -> previous sample
+}
+
+func NewRequestRates() *RequestRates {
+	return &RequestRates{last: make(map[string]requestSample)}
+}
+
+// Set fills m.RequestsPerSecond with the rate since the previous sample of m's instance, taken
+// at now. The first sample gives 0, and so do metrics without a request counter (system only).
+// A counter lower than before means nginx restarted, so the requests since the restart are
+// spread over the interval.
+func (r *RequestRates) Set(m *pb.NginxMetrics, now time.Time) {
+	if m == nil || m.TotalRequests == 0 {
+		return
+	}
+	prev, ok := r.last[m.InstanceId]
+	r.last[m.InstanceId] = requestSample{total: m.TotalRequests, at: now}
+	elapsed := now.Sub(prev.at).Seconds()
+	if !ok || elapsed <= 0 {
+		m.RequestsPerSecond = 0
+		return
+	}
+	requests := m.TotalRequests - prev.total
+	if requests < 0 {
+		requests = m.TotalRequests
+	}
+	m.RequestsPerSecond = float64(requests) / elapsed
+}
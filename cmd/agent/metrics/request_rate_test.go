@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestRequestRates(t *testing.T) {
+	r := NewRequestRates()
+	start := time.Now()
+	sample := func(instance string, total int64, after time.Duration) float64 {
+		m := &pb.NginxMetrics{InstanceId: instance, TotalRequests: total}
+		r.Set(m, start.Add(after))
+		return m.RequestsPerSecond
+	}
+
+	if got := sample("a", 1000, 0); got != 0 {
+		t.Errorf("first sample = %v, want 0", got)
+	}
+	if got := sample("a", 1500, 10*time.Second); got != 50 {
+		t.Errorf("rate = %v, want 50", got)
+	}
+	// Another instance has its own counter
+	if got := sample("b", 10, 10*time.Second); got != 0 {
+		t.Errorf("first sample of b = %v, want 0", got)
+	}
+	// nginx restarted: 200 requests since
+	if got := sample("a", 200, 20*time.Second); got != 20 {
+		t.Errorf("rate after restart = %v, want 20", got)
+	}
+	// System-only metrics keep the previous sample
+	sys := &pb.NginxMetrics{InstanceId: "a"}
+	r.Set(sys, start.Add(25*time.Second))
+	if sys.RequestsPerSecond != 0 {
+		t.Errorf("system-only rate = %v, want 0", sys.RequestsPerSecond)
+	}
+	if got := sample("a", 400, 30*time.Second); got != 20 {
+		t.Errorf("rate = %v, want 20", got)
+	}
+}
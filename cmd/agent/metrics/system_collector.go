@@ -16,6 +16,10 @@ type SystemCollector struct {
 	lastCPU     cpuStats
 	lastNetwork networkStats
 	lastTime    time.Time
+	disks       *DiskCollector
+	fds         *FDCollector
+	skipDisks   bool
+	skipFDs     bool
 }
 
 type cpuStats struct {
@@ -36,9 +40,17 @@ type networkStats struct {
 func NewSystemCollector() *SystemCollector {
 	return &SystemCollector{
 		lastTime: time.Now(),
+		disks:    NewDiskCollector(),
+		fds:      NewFDCollector(),
 	}
 }
 
+// SetLogFiles sets the nginx log files whose size and growth are reported. It is not safe to
+// call concurrently with Collect.
+func (c *SystemCollector) SetLogFiles(paths ...string) {
+	c.disks.logFiles = paths
+}
+
 // Collect gathers system metrics
 func (c *SystemCollector) Collect() (*pb.SystemMetrics, error) {
 	metrics := &pb.SystemMetrics{}
@@ -69,6 +81,13 @@ func (c *SystemCollector) Collect() (*pb.SystemMetrics, error) {
 		metrics.NetworkTxRate = txRate
 	}
 
+	if !c.skipDisks {
+		metrics.Disks, metrics.LogFiles = c.disks.Collect()
+	}
+	if !c.skipFDs {
+		c.fds.Collect(metrics)
+	}
+
 	return metrics, nil
 }
 
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/<pid>/stat (100 on Linux)
+const clockTicks = 100
+
+// workerCrashPattern matches the error log lines nginx writes when a worker dies abnormally;
+// graceful exits ("exited with code 0") do not match
+var workerCrashPattern = regexp.MustCompile(`worker process \d+ exited (on signal \d+|with fatal code \d+)`)
+
+// WorkerCollector reports nginx worker processes from /proc: CPU, RSS, start time, and how
+// many workers were replaced or crashed since the previous sample
+type WorkerCollector struct {
+	procRoot  string
+	masterPID int            // only workers of this master when set
+	lastCPU   map[int]uint64 // pid -> utime+stime ticks
+	lastTime  time.Time
+	crashes   atomic.Int32
+}
+
+func NewWorkerCollector() *WorkerCollector {
+	return &WorkerCollector{procRoot: "/proc"}
+}
+
+// ObserveLogLine counts worker crashes reported in an error log line
+func (c *WorkerCollector) ObserveLogLine(line string) {
+	if workerCrashPattern.MatchString(line) {
+		c.crashes.Add(1)
+	}
+}
+
+// Reset forgets the previous sample and the crashes observed since, so the next sample is
+// treated as the first
+func (c *WorkerCollector) Reset() {
+	c.lastCPU = nil
+	c.crashes.Store(0)
+}
+
+// Collect returns the current workers. Workers missing from the previous sample count as
+// restarts, except on the first sample.
+func (c *WorkerCollector) Collect() *pb.NginxWorkers {
+	now := time.Now()
+	bootTime := c.bootTime()
+	pageSize := uint64(os.Getpagesize())
+	elapsed := now.Sub(c.lastTime).Seconds()
+
+	workers := &pb.NginxWorkers{Crashes: c.crashes.Swap(0)}
+	cpu := make(map[int]uint64)
+	entries, _ := os.ReadDir(c.procRoot)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(c.procRoot, e.Name(), "cmdline"))
+		if err != nil || !strings.HasPrefix(string(cmdline), "nginx: worker process") {
+			continue
+		}
+		stat, ok := c.readStat(pid)
+		if !ok || (c.masterPID > 0 && stat.ppid != c.masterPID) {
+			continue
+		}
+		ticks := stat.utime + stat.stime
+		cpu[pid] = ticks
+		p := &pb.NginxWorkerProcess{
+			Pid:       int32(pid),
+			MasterPid: int32(stat.ppid),
+			RssBytes:  stat.rssPages * pageSize,
+		}
+		if bootTime > 0 {
+			p.StartedAt = bootTime + int64(stat.startTicks/clockTicks)
+		}
+		if last, seen := c.lastCPU[pid]; seen && elapsed > 0 && ticks >= last {
+			p.CpuPercent = float32(float64(ticks-last) / clockTicks / elapsed * 100)
+		} else if !seen && c.lastCPU != nil {
+			workers.Restarts++
+		}
+		workers.Processes = append(workers.Processes, p)
+	}
+	c.lastCPU = cpu
+	c.lastTime = now
+	return workers
+}
+
+type procStat struct {
+	ppid       int
+	utime      uint64
+	stime      uint64
+	startTicks uint64
+	rssPages   uint64
+}
+
+// readStat parses /proc/<pid>/stat. The command name is in parentheses and may contain spaces,
+// so fields are counted from the last ")".
+func (c *WorkerCollector) readStat(pid int) (procStat, bool) {
+	data, err := os.ReadFile(filepath.Join(c.procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procStat{}, false
+	}
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return procStat{}, false
+	}
+	// fields[0] is field 3 (state) of proc(5)
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return procStat{
+		ppid:       ppid,
+		utime:      parseUint64(fields[11]),
+		stime:      parseUint64(fields[12]),
+		startTicks: parseUint64(fields[19]),
+		rssPages:   parseUint64(fields[21]),
+	}, true
+}
+
+// bootTime returns the boot time from the btime line of /proc/stat, 0 when unknown
+func (c *WorkerCollector) bootTime() int64 {
+	file, err := os.Open(filepath.Join(c.procRoot, "stat"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			return int64(parseUint64(strings.TrimSpace(v)))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProc adds a process to a fake /proc
+func writeProc(t *testing.T, root string, pid, ppid int, cmdline string, utime, startTicks, rssPages uint64) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	stat := fmt.Sprintf("%d (nginx) S %d %d %d 0 -1 4194624 10 0 0 0 %d 0 0 0 20 0 1 0 %d 100000000 %d 18446744073709551615\n",
+		pid, ppid, ppid, ppid, utime, startTicks, rssPages)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline+"\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkerCollector(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte("cpu  1 2 3 4\nbtime 1700000000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeProc(t, root, 100, 1, "nginx: master process /usr/sbin/nginx", 5, 1000, 500)
+	writeProc(t, root, 101, 100, "nginx: worker process", 10, 2000, 1000)
+	writeProc(t, root, 102, 100, "nginx: worker process", 20, 2000, 1000)
+	writeProc(t, root, 103, 100, "nginx: cache manager process", 1, 2000, 100)
+
+	c := &WorkerCollector{procRoot: root}
+	first := c.Collect()
+	if len(first.Processes) != 2 || first.Restarts != 0 {
+		t.Fatalf("first sample = %+v", first)
+	}
+	p := first.Processes[0]
+	if p.Pid != 101 || p.MasterPid != 100 || p.StartedAt != 1700000020 || p.RssBytes != 1000*uint64(os.Getpagesize()) {
+		t.Errorf("worker = %+v", p)
+	}
+
+	// Worker 102 crashed and was replaced by 104
+	if err := os.RemoveAll(filepath.Join(root, "102")); err != nil {
+		t.Fatal(err)
+	}
+	writeProc(t, root, 104, 100, "nginx: worker process", 0, 5000, 900)
+	c.ObserveLogLine("2024/01/01 00:00:00 [alert] 100#100: worker process 102 exited on signal 11 (core dumped)")
+	c.ObserveLogLine("2024/01/01 00:00:00 [notice] 100#100: worker process 99 exited with code 0")
+	second := c.Collect()
+	if len(second.Processes) != 2 || second.Restarts != 1 || second.Crashes != 1 {
+		t.Errorf("second sample = %+v", second)
+	}
+	if third := c.Collect(); third.Restarts != 0 || third.Crashes != 0 {
+		t.Errorf("third sample = %+v", third)
+	}
+}
+
+func TestWorkerCollectorMasterPID(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, 100, 1, "nginx: master process /usr/sbin/nginx", 5, 1000, 500)
+	writeProc(t, root, 101, 100, "nginx: worker process", 10, 2000, 1000)
+	writeProc(t, root, 200, 1, "nginx: master process /usr/sbin/nginx -c /etc/nginx-api/nginx.conf", 5, 1000, 500)
+	writeProc(t, root, 201, 200, "nginx: worker process", 10, 2000, 1000)
+	writeProc(t, root, 202, 200, "nginx: worker process", 10, 2000, 1000)
+
+	c := &WorkerCollector{procRoot: root}
+	if got := c.Collect(); len(got.Processes) != 3 {
+		t.Errorf("all masters: %d workers, want 3", len(got.Processes))
+	}
+	c = &WorkerCollector{procRoot: root, masterPID: 200}
+	got := c.Collect()
+	if len(got.Processes) != 2 || got.Processes[0].MasterPid != 200 {
+		t.Errorf("master 200: %+v", got.Processes)
+	}
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/avika-ai/avika/cmd/agent/certs"
@@ -37,14 +37,17 @@ var allowedNginxConfigPaths = []string{
 
 type mgmtServer struct {
 	pb.UnimplementedAgentServiceServer
+	configPath    string
 	configManager *config.Manager
 	certManager   *certs.Manager
+	configMu      sync.Mutex // serializes structured config edits
 }
 
 func newMgmtServer(configPath string) *mgmtServer {
 	// Ensure default cert directory exists
 	os.MkdirAll("/etc/nginx/ssl", 0755)
 	return &mgmtServer{
+		configPath:    configPath,
 		configManager: config.NewManager(configPath),
 		certManager:   certs.NewManager([]string{"/etc/nginx/ssl", "/etc/ssl/certs"}),
 	}
@@ -62,38 +65,71 @@ func (s *mgmtServer) UploadCertificate(ctx context.Context, req *pb.UploadCertif
 	if req.Domain == "" || len(req.CertContent) == 0 || len(req.KeyContent) == 0 {
 		return &pb.UploadCertificateResponse{Success: false, Error: "missing domain, cert, or key content"}, nil
 	}
+	if err := certs.ValidateKeyPair(req.CertContent, req.KeyContent, req.ChainContent); err != nil {
+		return &pb.UploadCertificateResponse{Success: false, Error: err.Error()}, nil
+	}
 
 	// For now, write to /etc/nginx/ssl
 	certDir := "/etc/nginx/ssl"
 	if _, err := os.Stat(certDir); os.IsNotExist(err) {
 		// Fallback to local certs if /etc/nginx/ssl is not writable or doesn't exist
 		certDir = "certs"
-		os.MkdirAll(certDir, 0755)
 	}
 
-	certPath := filepath.Join(certDir, req.Domain+".crt")
-	keyPath := filepath.Join(certDir, req.Domain+".key")
-	chainPath := filepath.Join(certDir, req.Domain+".chain.crt")
+	installed, err := certs.Install(certDir, req.Domain, req.CertContent, req.KeyContent, req.ChainContent, req.BackupExisting)
+	if err != nil {
+		return &pb.UploadCertificateResponse{Success: false, Error: fmt.Sprintf("failed to write certificate: %v", err)}, nil
+	}
 
-	if err := os.WriteFile(certPath, req.CertContent, 0644); err != nil {
-		return &pb.UploadCertificateResponse{Success: false, Error: fmt.Sprintf("failed to write cert: %v", err)}, nil
+	// Point matching server blocks at the new files
+	changed, err := installed.UpdateServerBlocks(nginxConfigFiles(s.configPath), req.Domain)
+	if err != nil {
+		installed.Rollback()
+		return &pb.UploadCertificateResponse{Success: false, Error: fmt.Sprintf("failed to update server blocks: %v", err)}, nil
 	}
-	if err := os.WriteFile(keyPath, req.KeyContent, 0600); err != nil {
-		return &pb.UploadCertificateResponse{Success: false, Error: fmt.Sprintf("failed to write key: %v", err)}, nil
+	if len(changed) > 0 {
+		mgmtLog.Info().Msgf("Certificate for %s: updated server blocks in %s", req.Domain, strings.Join(changed, ", "))
 	}
-	if len(req.ChainContent) > 0 {
-		if err := os.WriteFile(chainPath, req.ChainContent, 0644); err != nil {
-			return &pb.UploadCertificateResponse{Success: false, Error: fmt.Sprintf("failed to write chain: %v", err)}, nil
+
+	// Validate before anything is reloaded; roll back cert files and config on failure
+	if err := s.configManager.TestConfig(); err != nil {
+		if rbErr := installed.Rollback(); rbErr != nil {
+			mgmtLog.Error().Msgf("Certificate rollback for %s failed: %v", req.Domain, rbErr)
 		}
+		return &pb.UploadCertificateResponse{Success: false, Error: "nginx -t failed, changes rolled back: " + err.Error()}, nil
 	}
 
+	result := &pb.CertDeploymentResult{
+		Success:  true,
+		CertPath: installed.CertPath,
+		KeyPath:  installed.KeyPath,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		result.Hostname = hostname
+	}
+	resp := &pb.UploadCertificateResponse{Success: true, Deployments: []*pb.CertDeploymentResult{result}}
+
 	if req.ReloadNginx {
 		if err := s.configManager.Reload(); err != nil {
-			return &pb.UploadCertificateResponse{Success: true, Error: "cert uploaded but nginx reload failed: " + err.Error()}, nil
+			resp.Error = "cert uploaded but nginx reload failed: " + err.Error()
 		}
 	}
 
-	return &pb.UploadCertificateResponse{Success: true}, nil
+	return resp, nil
+}
+
+// nginxConfigFiles returns the main config plus the conventional include directories next to it.
+func nginxConfigFiles(mainConfig string) []string {
+	if mainConfig == "" {
+		mainConfig = "/etc/nginx/nginx.conf"
+	}
+	files := []string{mainConfig}
+	dir := filepath.Dir(mainConfig)
+	for _, pattern := range []string{"conf.d/*.conf", "sites-enabled/*", "http.d/*.conf"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	return files
 }
 
 func (s *mgmtServer) DeleteCertificate(ctx context.Context, req *pb.DeleteCertificateRequest) (*pb.DeleteCertificateResponse, error) {
@@ -131,13 +167,21 @@ func (s *mgmtServer) DeleteCertificate(ctx context.Context, req *pb.DeleteCertif
 func (s *mgmtServer) SetMaintenance(ctx context.Context, req *pb.SetMaintenanceRequest) (*pb.SetMaintenanceResponse, error) {
 	// TODO: Implement maintenance mode logic
 	// This would involve creating a maintenance.html and updating nginx config
-	log.Printf("SetMaintenance called: action=%s", req.Action)
+	mgmtLog.Info().Msgf("SetMaintenance called: action=%s", req.Action)
 	return &pb.SetMaintenanceResponse{Success: true}, nil
 }
 
 func (s *mgmtServer) GetConfig(ctx context.Context, req *pb.ConfigRequest) (*pb.ConfigResponse, error) {
+	_, mainConfig, err := s.instanceTarget(req.NginxInstanceId)
+	if err != nil {
+		return &pb.ConfigResponse{InstanceId: req.InstanceId, Error: err.Error()}, nil
+	}
 	configPath := req.ConfigPath
-	if configPath == "" {
+	if path, ok := includeFilePath(mainConfig, configPath); ok {
+		configPath = path
+	} else if configPath == "" && req.NginxInstanceId != "" {
+		configPath = mainConfig
+	} else if configPath == "" {
 		// Try a few common paths
 		if _, err := os.Stat("/etc/nginx/nginx.conf"); err == nil {
 			configPath = "/etc/nginx/nginx.conf"
@@ -156,14 +200,7 @@ func (s *mgmtServer) GetConfig(ctx context.Context, req *pb.ConfigRequest) (*pb.
 			Error:      "invalid config path",
 		}, nil
 	}
-	allowed := false
-	for _, base := range allowedNginxConfigPaths {
-		if strings.HasPrefix(absPath, base) {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
+	if !isAllowedConfigPath(absPath) {
 		return &pb.ConfigResponse{
 			InstanceId: req.InstanceId,
 			Error:      fmt.Sprintf("config path %q is outside allowed directories", configPath),
@@ -172,7 +209,12 @@ func (s *mgmtServer) GetConfig(ctx context.Context, req *pb.ConfigRequest) (*pb.
 
 	parser := config.NewParser(configPath)
 
-	nginxConfig, err := parser.Parse()
+	var nginxConfig *pb.NginxConfig
+	if req.Structured {
+		nginxConfig, err = parser.ParseStructured()
+	} else {
+		nginxConfig, err = parser.Parse()
+	}
 	if err != nil {
 		return &pb.ConfigResponse{
 			InstanceId: req.InstanceId,
@@ -186,7 +228,29 @@ func (s *mgmtServer) GetConfig(ctx context.Context, req *pb.ConfigRequest) (*pb.
 	}, nil
 }
 
+// isAllowedConfigPath reports whether an absolute path is inside allowedNginxConfigPaths or the
+// configuration directory of a discovered NGINX instance.
+func isAllowedConfigPath(absPath string) bool {
+	for _, base := range append(nginxInstances.configDirs(), allowedNginxConfigPaths...) {
+		if absPath == base || strings.HasPrefix(absPath, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *mgmtServer) UpdateConfig(ctx context.Context, req *pb.ConfigUpdate) (*pb.ConfigUpdateResponse, error) {
+	manager, mainConfig, err := s.instanceTarget(req.NginxInstanceId)
+	if err != nil {
+		return &pb.ConfigUpdateResponse{Success: false, Error: err.Error()}, nil
+	}
+	if len(req.Files) > 0 {
+		return s.updateStructuredConfig(manager, req)
+	}
+	if path, ok := includeFilePath(mainConfig, req.ConfigPath); ok {
+		return s.updateIncludeFile(manager, path, req)
+	}
+
 	parser := config.NewParser(req.ConfigPath)
 	validation, err := parser.Validate(req.NewContent)
 	if err != nil || !validation.Valid {
@@ -202,7 +266,7 @@ func (s *mgmtServer) UpdateConfig(ctx context.Context, req *pb.ConfigUpdate) (*p
 		}, nil
 	}
 
-	backupPath, err := s.configManager.Update(req.NewContent, req.Backup)
+	backupPath, err := manager.Update(req.NewContent, req.Backup)
 	if err != nil {
 		return &pb.ConfigUpdateResponse{
 			Success: false,
@@ -210,7 +274,7 @@ func (s *mgmtServer) UpdateConfig(ctx context.Context, req *pb.ConfigUpdate) (*p
 		}, nil
 	}
 
-	if err := s.configManager.Reload(); err != nil {
+	if err := manager.Reload(); err != nil {
 		return &pb.ConfigUpdateResponse{
 			Success:    false,
 			Error:      "config updated but reload failed: " + err.Error(),
@@ -224,8 +288,111 @@ func (s *mgmtServer) UpdateConfig(ctx context.Context, req *pb.ConfigUpdate) (*p
 	}, nil
 }
 
+// includeFilePath resolves a relative config path (e.g. "conf.d/app.conf") against the main
+// config's directory. Absolute paths keep their historical meaning of "the main config".
+func includeFilePath(mainConfig, p string) (string, bool) {
+	if p == "" || filepath.IsAbs(p) {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(mainConfig), filepath.Clean("/"+p)), true
+}
+
+// updateIncludeFile writes a single included file (snippet, conf.d entry), tests the whole
+// config and reloads, restoring the previous file on failure.
+func (s *mgmtServer) updateIncludeFile(manager *config.Manager, path string, req *pb.ConfigUpdate) (*pb.ConfigUpdateResponse, error) {
+	if !isAllowedConfigPath(path) {
+		return &pb.ConfigUpdateResponse{
+			Success: false,
+			Error:   fmt.Sprintf("config path %q is outside allowed directories", req.ConfigPath),
+		}, nil
+	}
+	if req.Backup {
+		if err := config.BackupNginxConfig("include_update"); err != nil {
+			mgmtLog.Warn().Msgf("Full config backup failed: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return &pb.ConfigUpdateResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := s.applyConfigFiles(manager, map[string]string{path: req.NewContent}); err != nil {
+		return &pb.ConfigUpdateResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &pb.ConfigUpdateResponse{Success: true}, nil
+}
+
+// updateStructuredConfig renders each edited directive tree back to its file, runs nginx -t
+// against the result and restores the previous files if the test or reload fails.
+func (s *mgmtServer) updateStructuredConfig(manager *config.Manager, req *pb.ConfigUpdate) (*pb.ConfigUpdateResponse, error) {
+	contents := make(map[string]string, len(req.Files))
+	for _, f := range req.Files {
+		absPath, err := filepath.Abs(f.File)
+		if err != nil || !isAllowedConfigPath(absPath) {
+			return &pb.ConfigUpdateResponse{
+				Success: false,
+				Error:   fmt.Sprintf("config path %q is outside allowed directories", f.File),
+			}, nil
+		}
+		contents[absPath] = config.BuildConfig(f.Parsed)
+	}
+
+	var backupPath string
+	if req.Backup {
+		var err error
+		if backupPath, err = manager.Backup(); err != nil {
+			return &pb.ConfigUpdateResponse{Success: false, Error: "backup failed: " + err.Error()}, nil
+		}
+		if err := config.BackupNginxConfig("structured_update"); err != nil {
+			mgmtLog.Warn().Msgf("Full config backup failed: %v", err)
+		}
+	}
+
+	if err := s.applyConfigFiles(manager, contents); err != nil {
+		return &pb.ConfigUpdateResponse{Success: false, Error: err.Error(), BackupPath: backupPath}, nil
+	}
+
+	return &pb.ConfigUpdateResponse{
+		Success:    true,
+		BackupPath: backupPath,
+	}, nil
+}
+
+// applyConfigFiles writes the given files, runs nginx -t and reloads the instance manager
+// manages. If the test or the reload fails, every file is restored to its previous content.
+func (s *mgmtServer) applyConfigFiles(manager *config.Manager, contents map[string]string) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	restore, err := manager.UpdateFiles(contents)
+	if err != nil {
+		return err
+	}
+
+	if err := manager.TestConfig(); err != nil {
+		if rbErr := restore(); rbErr != nil {
+			mgmtLog.Error().Msgf("Failed to restore config after failed test: %v", rbErr)
+		}
+		return fmt.Errorf("validation failed, changes rolled back: %w", err)
+	}
+
+	if err := manager.Reload(); err != nil {
+		if rbErr := restore(); rbErr != nil {
+			mgmtLog.Error().Msgf("Failed to restore config after failed reload: %v", rbErr)
+		}
+		return fmt.Errorf("reload failed, changes rolled back: %w", err)
+	}
+	return nil
+}
+
 func (s *mgmtServer) ValidateConfig(ctx context.Context, req *pb.ConfigValidation) (*pb.ValidationResult, error) {
-	parser := config.NewParser("/etc/nginx/nginx.conf")
+	confPath := "/etc/nginx/nginx.conf"
+	if req.NginxInstanceId != "" {
+		_, mainConfig, err := s.instanceTarget(req.NginxInstanceId)
+		if err != nil {
+			return &pb.ValidationResult{Valid: false, Errors: []string{err.Error()}}, nil
+		}
+		confPath = mainConfig
+	}
+	parser := config.NewParser(confPath)
 	result, err := parser.Validate(req.ConfigContent)
 	if err != nil {
 		return &pb.ValidationResult{
@@ -237,7 +404,11 @@ func (s *mgmtServer) ValidateConfig(ctx context.Context, req *pb.ConfigValidatio
 }
 
 func (s *mgmtServer) ReloadNginx(ctx context.Context, req *pb.ReloadRequest) (*pb.ReloadResponse, error) {
-	if err := s.configManager.Reload(); err != nil {
+	manager, _, err := s.instanceTarget(req.NginxInstanceId)
+	if err != nil {
+		return &pb.ReloadResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := manager.Reload(); err != nil {
 		return &pb.ReloadResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -247,7 +418,11 @@ func (s *mgmtServer) ReloadNginx(ctx context.Context, req *pb.ReloadRequest) (*p
 }
 
 func (s *mgmtServer) RestartNginx(ctx context.Context, req *pb.RestartRequest) (*pb.RestartResponse, error) {
-	if err := s.configManager.Restart(); err != nil {
+	manager, _, err := s.instanceTarget(req.NginxInstanceId)
+	if err != nil {
+		return &pb.RestartResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := manager.Restart(); err != nil {
 		return &pb.RestartResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -257,7 +432,11 @@ func (s *mgmtServer) RestartNginx(ctx context.Context, req *pb.RestartRequest) (
 }
 
 func (s *mgmtServer) StopNginx(ctx context.Context, req *pb.StopRequest) (*pb.StopResponse, error) {
-	if err := s.configManager.Stop(); err != nil {
+	manager, _, err := s.instanceTarget(req.NginxInstanceId)
+	if err != nil {
+		return &pb.StopResponse{Success: false, Error: err.Error()}, nil
+	}
+	if err := manager.Stop(); err != nil {
 		return &pb.StopResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -267,9 +446,9 @@ func (s *mgmtServer) StopNginx(ctx context.Context, req *pb.StopRequest) (*pb.St
 }
 
 func (s *mgmtServer) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsServer) error {
-	logPath := *accessLogPath
-	if req.LogType == "error" {
-		logPath = *errorLogPath
+	logPath, _, err := instanceLogPath(req)
+	if err != nil {
+		return err
 	}
 
 	if !req.Follow {
@@ -278,6 +457,7 @@ func (s *mgmtServer) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsS
 			return err
 		}
 		for _, entry := range entries {
+			currentLogRedactor().Apply(entry)
 			if err := stream.Send(entry); err != nil {
 				return err
 			}
@@ -293,6 +473,7 @@ func (s *mgmtServer) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsS
 	defer tailer.Stop()
 
 	for entry := range entryChan {
+		currentLogRedactor().Apply(entry)
 		if err := stream.Send(entry); err != nil {
 			return err
 		}
@@ -309,7 +490,7 @@ func (s *mgmtServer) ApplyAugment(ctx context.Context, req *pb.ApplyAugmentReque
 		}, nil
 	}
 
-	log.Printf("Applying augment: %s (context: %s)", req.Augment.Name, req.Augment.Context)
+	mgmtLog.Info().Msgf("Applying augment: %s (context: %s)", req.Augment.Name, req.Augment.Context)
 
 	// 1. Apply Snippet
 	backupPath, err := s.configManager.UpdateSnippet(req.Augment.Snippet, req.Augment.Context)
@@ -322,7 +503,7 @@ func (s *mgmtServer) ApplyAugment(ctx context.Context, req *pb.ApplyAugmentReque
 
 	// 2. Reload NGINX
 	if err := s.configManager.Reload(); err != nil {
-		log.Printf("Reload failed, rolling back... Error: %v", err)
+		mgmtLog.Error().Msgf("Reload failed, rolling back... Error: %v", err)
 
 		// 3. Rollback on failure
 		if rbErr := s.configManager.Rollback(); rbErr != nil {
@@ -351,12 +532,12 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 	var ptmx *os.File
 	var done = make(chan struct{})
 
-	log.Printf("New Execute session started")
+	mgmtLog.Info().Msg("New Execute session started")
 
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
-			log.Printf("Execute session ended (EOF)")
+			mgmtLog.Info().Msg("Execute session ended (EOF)")
 			if ptmx != nil {
 				ptmx.Close()
 			}
@@ -366,7 +547,7 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 			return nil
 		}
 		if err != nil {
-			log.Printf("Execute session recv error: %v", err)
+			mgmtLog.Error().Msgf("Execute session recv error: %v", err)
 			if ptmx != nil {
 				ptmx.Close()
 			}
@@ -387,10 +568,10 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 			// Validate shell against whitelist
 			if !allowedShells[shell] {
 				errMsg := fmt.Sprintf("shell %q is not in the allowed list", shell)
-				log.Printf("Execute rejected: %s for instance: %s", errMsg, req.InstanceId)
+				mgmtLog.Warn().Msgf("Execute rejected: %s for instance: %s", errMsg, req.InstanceId)
 				return stream.Send(&pb.ExecResponse{Error: errMsg})
 			}
-			log.Printf("Starting shell with PTY: %s for instance: %s", shell, req.InstanceId)
+			mgmtLog.Info().Msgf("Starting shell with PTY: %s for instance: %s", shell, req.InstanceId)
 
 			cmdArgs := []string{shell}
 
@@ -401,11 +582,11 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 			var err error
 			ptmx, err = pty.Start(cmd)
 			if err != nil {
-				log.Printf("Failed to start shell with PTY %s: %v", shell, err)
+				mgmtLog.Error().Msgf("Failed to start shell with PTY %s: %v", shell, err)
 				return stream.Send(&pb.ExecResponse{Error: err.Error()})
 			}
 
-			log.Printf("Shell started with PTY, streaming output...")
+			mgmtLog.Info().Msg("Shell started with PTY, streaming output...")
 
 			// Goroutine to stream PTY output back
 			go func() {
@@ -415,13 +596,13 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 					n, err := ptmx.Read(buf)
 					if n > 0 {
 						if sendErr := stream.Send(&pb.ExecResponse{Output: buf[:n]}); sendErr != nil {
-							log.Printf("Failed to send PTY output: %v", sendErr)
+							mgmtLog.Error().Msgf("Failed to send PTY output: %v", sendErr)
 							return
 						}
 					}
 					if err != nil {
 						if err != io.EOF {
-							log.Printf("PTY read error: %v", err)
+							mgmtLog.Error().Msgf("PTY read error: %v", err)
 						}
 						return
 					}
@@ -432,7 +613,7 @@ func (s *mgmtServer) Execute(stream pb.AgentService_ExecuteServer) error {
 		// Write input to PTY
 		if len(req.Input) > 0 && ptmx != nil {
 			if _, err := ptmx.Write(req.Input); err != nil {
-				log.Printf("PTY write error: %v", err)
+				mgmtLog.Error().Msgf("PTY write error: %v", err)
 			}
 		}
 	}
@@ -483,7 +664,7 @@ func startMgmtService(ctx context.Context, configPath string, port int, tlsCreds
 	addr := fmt.Sprintf(":%d", port)
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Printf("Failed to listen on %s: %v", addr, err)
+		mgmtLog.Error().Msgf("Failed to listen on %s: %v", addr, err)
 		return
 	}
 
@@ -496,18 +677,18 @@ func startMgmtService(ctx context.Context, configPath string, port int, tlsCreds
 	pb.RegisterAgentServiceServer(grpcServer, newMgmtServer(configPath))
 	pb.RegisterAgentConfigServiceServer(grpcServer, &agentConfigServer{})
 
-	log.Printf("Agent Management Service listening on %s", addr)
+	mgmtLog.Info().Msgf("Agent Management Service listening on %s", addr)
 
 	// Start server in goroutine
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
-			log.Printf("Management service error: %v", err)
+			mgmtLog.Error().Msgf("Management service error: %v", err)
 		}
 	}()
 
 	// Wait for context cancellation
 	<-ctx.Done()
-	log.Println("Shutting down management service...")
+	mgmtLog.Info().Msg("Shutting down management service...")
 
 	// Use a goroutine with timeout for graceful stop
 	stopped := make(chan struct{})
@@ -518,9 +699,9 @@ func startMgmtService(ctx context.Context, configPath string, port int, tlsCreds
 
 	select {
 	case <-stopped:
-		log.Println("Management service stopped gracefully")
+		mgmtLog.Info().Msg("Management service stopped gracefully")
 	case <-time.After(3 * time.Second):
-		log.Println("Management service graceful stop timeout, forcing stop")
+		mgmtLog.Warn().Msg("Management service graceful stop timeout, forcing stop")
 		grpcServer.Stop()
 	}
 }
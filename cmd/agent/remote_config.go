@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remoteConfigCacheFile keeps the last bundle so the agent starts with it when the gateway is down
+const remoteConfigCacheFile = "agent-config.json"
+
+// remoteConfig is the configuration bundle served by the gateway's /api/agent-config
+type remoteConfig struct {
+	EnvironmentID string            `json:"environment_id"`
+	Version       string            `json:"version"`
+	Settings      map[string]string `json:"settings"`
+}
+
+// deriveAgentConfigURL returns the gateway's agent configuration endpoint, next to its update server
+func deriveAgentConfigURL(gatewayAddr string) string {
+	updates := deriveUpdateServerFromGateway(gatewayAddr)
+	if updates == "" {
+		return ""
+	}
+	return strings.TrimSuffix(updates, "/updates") + "/api/agent-config"
+}
+
+// fetchRemoteConfig downloads the bundle of the token's environment. cached is returned as is when
+// the gateway reports it unchanged.
+func fetchRemoteConfig(client *http.Client, url, token string, cached *remoteConfig) (*remoteConfig, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if cached != nil && cached.Version != "" {
+		req.Header.Set("If-None-Match", `"`+cached.Version+`"`)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var c remoteConfig
+		if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+			return nil, fmt.Errorf("invalid agent config: %w", err)
+		}
+		return &c, nil
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, errors.New("gateway reported an unchanged config but none is cached")
+		}
+		return cached, nil
+	default:
+		return nil, fmt.Errorf("gateway returned %s", resp.Status)
+	}
+}
+
+func readRemoteConfigCache(path string) *remoteConfig {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var c remoteConfig
+	if json.Unmarshal(data, &c) != nil {
+		return nil
+	}
+	return &c
+}
+
+func writeRemoteConfigCache(path string, c *remoteConfig) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// The bundle may carry the PSK
+	return os.WriteFile(path, data, 0600)
+}
+
+// applyRemoteConfig sets the bundle's settings on flags not given on the command line
+func applyRemoteConfig(c *remoteConfig) {
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+	for key, val := range c.Settings {
+		applyConfigValue(key, val, setFlags)
+	}
+}
+
+// loadRemoteConfig fetches and applies the gateway's configuration bundle, falling back to the
+// last one fetched. Logging is not set up yet, so problems go to stderr. It reports whether a
+// bundle was applied.
+func loadRemoteConfig() bool {
+	url := *agentConfigURL
+	if url == "" {
+		url = deriveAgentConfigURL(getGatewayAddresses()[0])
+	}
+	cachePath := filepath.Join(*bufferDir, remoteConfigCacheFile)
+	cached := readRemoteConfigCache(cachePath)
+
+	c, err := fetchRemoteConfig(&http.Client{Timeout: 10 * time.Second}, url, *agentConfigToken, cached)
+	if err != nil {
+		if cached == nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to fetch agent config from %s: %v\n", url, err)
+			return false
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Failed to fetch agent config from %s, using cached version %s: %v\n", url, cached.Version, err)
+		c = cached
+	} else if c != cached {
+		if err := writeRemoteConfigCache(cachePath, c); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to cache agent config: %v\n", err)
+		}
+	}
+	applyRemoteConfig(c)
+	return true
+}
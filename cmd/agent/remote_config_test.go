@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeriveAgentConfigURL(t *testing.T) {
+	if got := deriveAgentConfigURL("gw-1:5020,gw-2:5020"); got != "http://gw-1:5021/api/agent-config" {
+		t.Errorf("got %q", got)
+	}
+	if got := deriveAgentConfigURL("https://gw:7000"); got != "http://gw:7001/api/agent-config" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFetchRemoteConfig(t *testing.T) {
+	bundle := remoteConfig{EnvironmentID: "e1", Version: "v1", Settings: map[string]string{"LOG_LEVEL": "debug"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(bundle)
+	}))
+	defer srv.Close()
+
+	c, err := fetchRemoteConfig(srv.Client(), srv.URL, "tok", nil)
+	if err != nil || c.Version != "v1" || c.Settings["LOG_LEVEL"] != "debug" {
+		t.Fatalf("fetch = %+v, %v", c, err)
+	}
+	if again, err := fetchRemoteConfig(srv.Client(), srv.URL, "tok", c); err != nil || again != c {
+		t.Errorf("unchanged config should return the cached one, got %+v, %v", again, err)
+	}
+	if _, err := fetchRemoteConfig(srv.Client(), srv.URL, "bad", nil); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+
+	path := filepath.Join(t.TempDir(), "data", remoteConfigCacheFile)
+	if err := writeRemoteConfigCache(path, c); err != nil {
+		t.Fatal(err)
+	}
+	if cached := readRemoteConfigCache(path); cached == nil || cached.Version != "v1" {
+		t.Errorf("cache = %+v", cached)
+	}
+}
+
+func TestApplyRemoteConfig(t *testing.T) {
+	oldSyslog, oldLabels := *syslogTarget, agentLabels
+	defer func() { *syslogTarget, agentLabels = oldSyslog, oldLabels }()
+	agentLabels = map[string]string{}
+
+	applyRemoteConfig(&remoteConfig{Settings: map[string]string{
+		"SYSLOG_TARGET": "udp://siem:514",
+		"LABEL_project": "shop",
+	}})
+	if *syslogTarget != "udp://siem:514" || agentLabels["project"] != "shop" {
+		t.Errorf("syslog target = %q, labels = %v", *syslogTarget, agentLabels)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/agent/logs"
+	"github.com/avika-ai/avika/cmd/agent/metrics"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/redact"
+)
+
+// runtimeMu guards the flags ConfigureAgent changes while the agent runs
+var runtimeMu sync.RWMutex
+
+// effectiveConfig returns the value of every flag, after the config file and environment, for the
+// health server's /debug/config; keys and tokens are masked
+func effectiveConfig() any {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	out := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value != "" && (f.Name == "psk" || strings.HasSuffix(f.Name, "-token")) {
+			value = "***"
+		}
+		out[f.Name] = value
+	})
+	return out
+}
+
+// runtimeLogCollector is restarted when the log paths or format change
+var runtimeLogCollector *logs.LogCollector
+
+// runtimeLogRedactor scrubs access log entries with the LOG_REDACT settings; nil when they select
+// no rules
+var runtimeLogRedactor atomic.Pointer[redact.Redactor]
+
+func setRuntimeLogCollector(c *logs.LogCollector) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	runtimeLogCollector = c
+}
+
+// parsePathSetting requires an absolute log path
+func parsePathSetting(val string) (string, error) {
+	if !filepath.IsAbs(val) {
+		return "", fmt.Errorf("expected an absolute path")
+	}
+	return filepath.Clean(val), nil
+}
+
+// parseIntervalSetting parses a duration of at least a second and, unless maxInterval is 0, at
+// most maxInterval
+func parseIntervalSetting(val string, maxInterval time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if maxInterval > 0 && (err != nil || d < time.Second || d > maxInterval) {
+		return 0, fmt.Errorf("expected a duration from 1s to %s", maxInterval)
+	}
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("expected a duration of at least 1s")
+	}
+	return d, nil
+}
+
+// runtimeSettings are the avika-agent.conf keys the gateway can change with ConfigureAgent, which
+// take effect without a restart, and how to read them back. Called with runtimeMu held.
+var runtimeSettings = map[string]func() string{
+	"ACCESS_LOG_PATH":        func() string { return *accessLogPath },
+	"ERROR_LOG_PATH":         func() string { return *errorLogPath },
+	"LOG_FORMAT":             func() string { return *logFormat },
+	"LOG_FORMAT_STRING":      func() string { return *logFormatString },
+	"LOG_LEVEL":              func() string { return *logLevel },
+	"METRICS_INTERVAL":       func() string { return metricsInterval.String() },
+	"DISABLED_COLLECTORS":    func() string { return *disabledCollectors },
+	"HEARTBEAT_INTERVAL":     func() string { return heartbeatInterval.String() },
+	"DISCOVERY_INTERVAL":     func() string { return discoveryInterval.String() },
+	"LOG_REDACT":             func() string { return *logRedact },
+	"LOG_REDACT_PARAMS":      func() string { return *logRedactParams },
+	"LOG_REDACT_IPV4_PREFIX": func() string { return strconv.Itoa(*logRedactIPv4Prefix) },
+	"LOG_REDACT_IPV6_PREFIX": func() string { return strconv.Itoa(*logRedactIPv6Prefix) },
+	"MAX_CPU_PERCENT":        func() string { return strconv.FormatFloat(*maxCPUPercent, 'f', -1, 64) },
+	"MAX_MEMORY_MB":          func() string { return strconv.Itoa(*maxMemoryMB) },
+	"NGINX_METRICS_ENABLED":  func() string { return strconv.FormatBool(*nginxMetricsEnabled) },
+	"SYSTEM_METRICS_ENABLED": func() string { return strconv.FormatBool(*systemMetricsEnabled) },
+}
+
+// maxHeartbeatInterval keeps heartbeats well inside the gateway's 5 minute offline timeout
+const maxHeartbeatInterval = time.Minute
+
+// parseCollectorList normalizes a comma-separated list of optional collectors
+func parseCollectorList(val string) (string, error) {
+	names := splitCollectorList(val)
+	for _, name := range names {
+		if !slices.Contains(metrics.OptionalCollectors, name) {
+			return "", fmt.Errorf("unknown collector %q, expected some of %s", name, strings.Join(metrics.OptionalCollectors, ", "))
+		}
+	}
+	return strings.Join(names, ","), nil
+}
+
+// splitCollectorList returns the sorted, de-duplicated names of a comma-separated list
+func splitCollectorList(val string) []string {
+	var names []string
+	for _, name := range strings.Split(val, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// accessLogFormat is the format the access log is parsed with: the log_format string when one is
+// configured, LOG_FORMAT otherwise. Called with runtimeMu held or before the agent starts.
+func accessLogFormat() string {
+	if *logFormatString != "" {
+		return *logFormatString
+	}
+	return *logFormat
+}
+
+// newLogRedactor builds the redactor of the LOG_REDACT settings. Values from the config file are
+// not validated: unknown rules scrub with every rule rather than let personal data through, and
+// prefixes out of range fall back to the defaults. Called with runtimeMu held or before the agent
+// starts.
+func newLogRedactor() *redact.Redactor {
+	rules, err := redact.ParseRules(*logRedact)
+	if err != nil {
+		agentLog.Warn().Msgf("Invalid LOG_REDACT, applying every rule: %v", err)
+		rules = redact.AllRules
+	}
+	params, err := redact.ParseParams(*logRedactParams)
+	if err != nil {
+		agentLog.Warn().Msgf("Invalid LOG_REDACT_PARAMS, masking the built-in parameters only: %v", err)
+	}
+	v4, err := redact.ParsePrefix(strconv.Itoa(*logRedactIPv4Prefix), 32, redact.DefaultIPv4Prefix)
+	if err != nil {
+		v4 = redact.DefaultIPv4Prefix
+	}
+	v6, err := redact.ParsePrefix(strconv.Itoa(*logRedactIPv6Prefix), 128, redact.DefaultIPv6Prefix)
+	if err != nil {
+		v6 = redact.DefaultIPv6Prefix
+	}
+	return redact.New(redact.Config{Rules: rules, Params: params, IPv4Prefix: v4, IPv6Prefix: v6})
+}
+
+// updateLogRedactor rebuilds the redactor after the LOG_REDACT settings changed and returns it.
+// Called with runtimeMu held or before the agent starts.
+func updateLogRedactor() *redact.Redactor {
+	r := newLogRedactor()
+	runtimeLogRedactor.Store(r)
+	if rules := r.Rules(); len(rules) > 0 {
+		agentLog.Info().Msgf("Redacting access logs: %s", strings.Join(rules, ", "))
+	}
+	return r
+}
+
+// currentLogRedactor returns the redactor access log entries pass before leaving the agent
+func currentLogRedactor() *redact.Redactor {
+	return runtimeLogRedactor.Load()
+}
+
+// metricsSettings returns which metrics to collect and how often, less often while the agent
+// sheds load
+func metricsSettings() (nginx, system bool, interval time.Duration) {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return *nginxMetricsEnabled, *systemMetricsEnabled, shedInterval(*metricsInterval, 0)
+}
+
+// resourceLimits returns the agent's CPU limit in percent of one core and memory limit in bytes,
+// 0 when unlimited
+func resourceLimits() (cpuPercent float64, memoryBytes uint64) {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return max(*maxCPUPercent, 0), uint64(max(*maxMemoryMB, 0)) << 20
+}
+
+// collectorSettings returns the optional collectors to skip
+func collectorSettings() []string {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return splitCollectorList(*disabledCollectors)
+}
+
+// heartbeatSettings returns how often to send heartbeats and to rescan for NGINX instances,
+// less often while the agent sheds load. Values from the config file are not validated, so
+// they are clamped here.
+func heartbeatSettings() (heartbeat, discovery time.Duration) {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	heartbeat = min(max(*heartbeatInterval, time.Second), maxHeartbeatInterval)
+	return shedInterval(heartbeat, maxHeartbeatInterval), shedInterval(max(*discoveryInterval, time.Second), 0)
+}
+
+// logFilePaths returns the nginx access and error log paths in effect
+func logFilePaths() (access, errorLog string) {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return *accessLogPath, *errorLogPath
+}
+
+// currentRuntimeSettings returns the value of every runtime setting
+func currentRuntimeSettings() map[string]string {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	current := make(map[string]string, len(runtimeSettings))
+	for key, get := range runtimeSettings {
+		current[key] = get()
+	}
+	return current
+}
+
+// applyRuntimeSettings applies settings the way UpdateAgentConfig does, refusing any that need a
+// restart, and persists them to the config file. It returns the settings in effect afterwards.
+func applyRuntimeSettings(settings map[string]string) (map[string]string, error) {
+	for key := range settings {
+		if _, ok := runtimeSettings[strings.ToUpper(strings.TrimSpace(key))]; !ok {
+			return nil, fmt.Errorf("%s cannot be changed at runtime", key)
+		}
+	}
+	if _, _, err := applyAgentUpdates(settings, true); err != nil {
+		return nil, err
+	}
+
+	applied := currentRuntimeSettings()
+	normalized := make(map[string]string, len(settings))
+	for key := range settings {
+		key = strings.ToUpper(strings.TrimSpace(key))
+		normalized[key] = applied[key]
+	}
+	if err := persistAgentConfigUpdates(*configFile, normalized); err != nil {
+		return applied, fmt.Errorf("applied but not saved to %s: %v", *configFile, err)
+	}
+	return applied, nil
+}
+
+// handleConfigureAgent applies a ConfigureAgent command and acks with the settings in effect
+func handleConfigureAgent(cmdID string, req *pb.ConfigureAgent, ss *StreamSync, agentID string) {
+	applied, err := applyRuntimeSettings(req.Settings)
+	result := &pb.CommandResult{
+		CommandId:       cmdID,
+		Success:         err == nil,
+		AppliedSettings: applied,
+	}
+	if err != nil {
+		agentLog.Warn().Msgf("ConfigureAgent %s: %v", cmdID, err)
+		result.ErrorMessage = err.Error()
+		if applied == nil {
+			result.AppliedSettings = currentRuntimeSettings()
+		}
+	} else {
+		agentLog.Info().Msgf("ConfigureAgent %s applied %d setting(s)", cmdID, len(req.Settings))
+	}
+
+	msg := &pb.AgentMessage{
+		AgentId:   agentID,
+		Timestamp: time.Now().Unix(),
+		Payload: &pb.AgentMessage_CommandResult{
+			CommandResult: result,
+		},
+	}
+	if err := ss.Send(msg); err != nil {
+		agentLog.Warn().Msgf("Failed to ack ConfigureAgent %s: %v", cmdID, err)
+	}
+}
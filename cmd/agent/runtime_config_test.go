@@ -0,0 +1,225 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestPersistAgentConfigUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "avika-agent.conf")
+	orig := "# Avika agent\nGATEWAYS=gw:5020\nLOG_LEVEL=info\n\nLABEL_team=edge\n"
+	if err := os.WriteFile(path, []byte(orig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := persistAgentConfigUpdates(path, map[string]string{"LOG_LEVEL": "debug", "METRICS_INTERVAL": "5s", "DISCOVERY_INTERVAL": "1m0s"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# Avika agent\nGATEWAYS=gw:5020\nLOG_LEVEL=debug\n\nLABEL_team=edge\n\nDISCOVERY_INTERVAL=1m0s\nMETRICS_INTERVAL=5s\n"
+	if string(data) != want {
+		t.Errorf("config =\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestLogFormatStringRoundTrip(t *testing.T) {
+	oldFormat := *logFormatString
+	defer func() { *logFormatString = oldFormat }()
+	path := filepath.Join(t.TempDir(), "avika-agent.conf")
+	format := `$remote_addr [$time_local] "$request" $status "$http_user_agent"`
+	if err := persistAgentConfigUpdates(path, map[string]string{"LOG_FORMAT_STRING": format}); err != nil {
+		t.Fatal(err)
+	}
+	*logFormatString = ""
+	if err := loadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if *logFormatString != format {
+		t.Errorf("LOG_FORMAT_STRING = %q, want %q", *logFormatString, format)
+	}
+
+	for raw, want := range map[string]string{
+		`'"$request" $status'`: `"$request" $status`,
+		`"/var/log/a b.log"`:   "/var/log/a b.log",
+		`"unbalanced`:          "unbalanced",
+		"plain":                "plain",
+	} {
+		if got := unquoteConfigValue(raw); got != want {
+			t.Errorf("unquoteConfigValue(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestApplyRuntimeSettings(t *testing.T) {
+	oldConfig, oldInterval, oldNginx, oldLevel := *configFile, *metricsInterval, *nginxMetricsEnabled, *logLevel
+	defer func() {
+		*configFile, *metricsInterval, *nginxMetricsEnabled, *logLevel = oldConfig, oldInterval, oldNginx, oldLevel
+		_ = logging.SetLevel("", "info")
+	}()
+	*configFile = filepath.Join(t.TempDir(), "avika-agent.conf")
+
+	// Nothing is applied when one setting is invalid
+	if _, err := applyRuntimeSettings(map[string]string{"METRICS_INTERVAL": "10s", "LOG_FORMAT": "xml"}); err == nil {
+		t.Fatal("expected an error for an invalid log format")
+	}
+	if *metricsInterval != oldInterval {
+		t.Error("valid settings should not be applied with invalid ones")
+	}
+	if _, err := applyRuntimeSettings(map[string]string{"GATEWAYS": "evil:5020"}); err == nil {
+		t.Error("expected an error for a setting that needs a restart")
+	}
+	oldGateway := *gatewayAddr
+	if _, _, err := applyAgentUpdates(map[string]string{"GATEWAYS": "other:5020", "LOG_LEVEL": "verbose"}, true); err == nil {
+		t.Error("expected an error for an invalid log level")
+	}
+	if *gatewayAddr != oldGateway {
+		t.Error("UpdateAgentConfig should not apply valid updates with invalid ones")
+	}
+
+	applied, err := applyRuntimeSettings(map[string]string{"metrics_interval": "10s", "NGINX_METRICS_ENABLED": "0", "LOG_LEVEL": "WARN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied["METRICS_INTERVAL"] != "10s" || applied["NGINX_METRICS_ENABLED"] != "false" || applied["LOG_LEVEL"] != "warn" {
+		t.Errorf("applied = %v", applied)
+	}
+	if nginx, _, interval := metricsSettings(); nginx || interval != 10*time.Second {
+		t.Errorf("metrics settings not applied: nginx=%v interval=%s", nginx, interval)
+	}
+	if level, _ := logging.Levels(); level != "warn" {
+		t.Errorf("log level = %s, want warn", level)
+	}
+	data, err := os.ReadFile(*configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "LOG_LEVEL=warn\nMETRICS_INTERVAL=10s\nNGINX_METRICS_ENABLED=false\n" {
+		t.Errorf("persisted config = %q", data)
+	}
+}
+
+func TestApplyCollectionSettings(t *testing.T) {
+	oldConfig, oldHeartbeat, oldDiscovery, oldDisabled := *configFile, *heartbeatInterval, *discoveryInterval, *disabledCollectors
+	defer func() {
+		*configFile, *heartbeatInterval, *discoveryInterval, *disabledCollectors = oldConfig, oldHeartbeat, oldDiscovery, oldDisabled
+	}()
+	*configFile = filepath.Join(t.TempDir(), "avika-agent.conf")
+
+	for _, bad := range []map[string]string{
+		{"HEARTBEAT_INTERVAL": "500ms"},
+		{"HEARTBEAT_INTERVAL": "10m"},
+		{"DISCOVERY_INTERVAL": "0s"},
+		{"DISABLED_COLLECTORS": "workers,gpu"},
+	} {
+		if _, err := applyRuntimeSettings(bad); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+
+	applied, err := applyRuntimeSettings(map[string]string{"HEARTBEAT_INTERVAL": "15s", "DISCOVERY_INTERVAL": "5m", "DISABLED_COLLECTORS": " FDs, workers,fds "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied["DISABLED_COLLECTORS"] != "fds,workers" || applied["HEARTBEAT_INTERVAL"] != "15s" || applied["DISCOVERY_INTERVAL"] != "5m0s" {
+		t.Errorf("applied = %v", applied)
+	}
+	if heartbeat, discovery := heartbeatSettings(); heartbeat != 15*time.Second || discovery != 5*time.Minute {
+		t.Errorf("heartbeat settings = %s, %s", heartbeat, discovery)
+	}
+	if got := collectorSettings(); len(got) != 2 || got[0] != "fds" || got[1] != "workers" {
+		t.Errorf("disabled collectors = %v", got)
+	}
+
+	// Values from the config file are clamped rather than rejected
+	*heartbeatInterval, *discoveryInterval = time.Hour, 0
+	if heartbeat, discovery := heartbeatSettings(); heartbeat != maxHeartbeatInterval || discovery != time.Second {
+		t.Errorf("clamped heartbeat settings = %s, %s", heartbeat, discovery)
+	}
+}
+
+func TestApplyRedactionSettings(t *testing.T) {
+	oldConfig, oldRules, oldParams, oldV4 := *configFile, *logRedact, *logRedactParams, *logRedactIPv4Prefix
+	defer func() {
+		*configFile, *logRedact, *logRedactParams, *logRedactIPv4Prefix = oldConfig, oldRules, oldParams, oldV4
+		runtimeLogRedactor.Store(nil)
+	}()
+	*configFile = filepath.Join(t.TempDir(), "avika-agent.conf")
+
+	for _, bad := range []map[string]string{
+		{"LOG_REDACT": "query,phones"},
+		{"LOG_REDACT_PARAMS": "otp,a b"},
+		{"LOG_REDACT_IPV4_PREFIX": "33"},
+	} {
+		if _, err := applyRuntimeSettings(bad); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+
+	applied, err := applyRuntimeSettings(map[string]string{"LOG_REDACT": "IPs, query", "LOG_REDACT_PARAMS": "OTP", "LOG_REDACT_IPV4_PREFIX": "16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied["LOG_REDACT"] != "query,ips" || applied["LOG_REDACT_PARAMS"] != "otp" || applied["LOG_REDACT_IPV4_PREFIX"] != "16" {
+		t.Errorf("applied = %v", applied)
+	}
+	entry := &pb.LogEntry{RemoteAddr: "203.0.113.77", RequestUri: "/verify?otp=123456&next=/"}
+	currentLogRedactor().Apply(entry)
+	if entry.RemoteAddr != "203.0.0.0" || entry.RequestUri != "/verify?otp=REDACTED&next=/" {
+		t.Errorf("redacted entry = %v", entry)
+	}
+
+	if _, err := applyRuntimeSettings(map[string]string{"LOG_REDACT": "off"}); err != nil {
+		t.Fatal(err)
+	}
+	if currentLogRedactor() != nil {
+		t.Error("redaction should stop with no rules")
+	}
+
+	// An invalid value in the config file scrubs with every rule
+	*logRedact = "everything"
+	if rules := newLogRedactor().Rules(); len(rules) != 4 {
+		t.Errorf("rules for an invalid LOG_REDACT = %v", rules)
+	}
+}
+
+func TestRuntimeSettingsRejectControlChars(t *testing.T) {
+	oldConfig, oldAccess := *configFile, *accessLogPath
+	defer func() { *configFile, *accessLogPath = oldConfig, oldAccess }()
+	*configFile = filepath.Join(t.TempDir(), "avika-agent.conf")
+	if err := os.WriteFile(*configFile, []byte("GATEWAYS=gw:5020\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range []string{
+		"/var/log/nginx/access.log\nUPDATE_SERVER=http://evil.example.com",
+		"/var/log/nginx/access.log\r\nPSK=stolen",
+		"/var/log/nginx/access.log\x00",
+	} {
+		if _, err := applyRuntimeSettings(map[string]string{"ACCESS_LOG_PATH": payload}); err == nil {
+			t.Errorf("expected an error for %q", payload)
+		}
+	}
+	if *accessLogPath != oldAccess {
+		t.Errorf("ACCESS_LOG_PATH = %q, should not change", *accessLogPath)
+	}
+	if data, _ := os.ReadFile(*configFile); string(data) != "GATEWAYS=gw:5020\n" {
+		t.Errorf("config file changed:\n%s", data)
+	}
+
+	if err := persistAgentConfigUpdates(*configFile, map[string]string{"LOG_LEVEL": "info\nPSK=stolen"}); err == nil {
+		t.Error("persistAgentConfigUpdates should refuse a value with a newline")
+	}
+	if _, err := formatConfigValue("a\nb"); err == nil {
+		t.Error("formatConfigValue should refuse a newline")
+	}
+	if v, err := formatConfigValue("a\tb"); err != nil || v != "\"a\tb\"" {
+		t.Errorf("formatConfigValue(tab) = %q, %v; want it quoted", v, err)
+	}
+}
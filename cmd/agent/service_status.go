@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	// restartLoopCount restarts within restartLoopWindow are a restart loop
+	restartLoopCount  = 3
+	restartLoopWindow = 10 * time.Minute
+	// serviceSampleInterval is how often metrics reports include the unit state; systemctl is too
+	// heavy to run every second
+	serviceSampleInterval = 15 * time.Second
+	serviceHistoryMax     = 50
+	defaultJournalLines   = 50
+	maxJournalLines       = 500
+)
+
+// nginxServices remembers the restarts and failures of the units running nginx
+var nginxServices = &serviceTracker{query: queryServiceManager}
+
+type serviceTracker struct {
+	query func(unit string, pid int) (*pb.ServiceStatus, error)
+
+	mu    sync.Mutex
+	units map[string]*unitHistory
+}
+
+type unitHistory struct {
+	last         *pb.ServiceStatus
+	events       []*pb.ServiceEvent
+	failures     int32
+	lastFailure  int64
+	newRestarts  int32 // since the last sample
+	newFailures  int32
+	lastSampleAt time.Time
+}
+
+// serviceTarget returns the unit running an instance and its master pid. The primary instance
+// falls back to the "nginx" service the config manager restarts; other instances have no unit
+// unless discovery found one.
+func serviceTarget(inst *pb.NginxInstance) (string, int) {
+	if inst == nil {
+		return "nginx", 0
+	}
+	pid, _ := strconv.Atoi(inst.Pid)
+	switch {
+	case inst.SystemdUnit != "":
+		return inst.SystemdUnit, pid
+	case inst.Primary:
+		return "nginx", pid
+	}
+	return "", pid
+}
+
+// status queries the unit and records what changed since the last query
+func (t *serviceTracker) status(unit string, pid int) *pb.ServiceStatus {
+	if unit == "" {
+		return &pb.ServiceStatus{Error: "NGINX instance is not run by a service manager"}
+	}
+	st, err := t.query(unit, pid)
+	if err != nil {
+		return &pb.ServiceStatus{Unit: unit, Error: err.Error()}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observe(unit, st, time.Now())
+	return st
+}
+
+// sample returns the unit state for a metrics report at most every serviceSampleInterval, with
+// the restarts and failures since the previous one; nil in between or when there is no unit
+func (t *serviceTracker) sample(unit string, pid int) *pb.ServiceStatus {
+	if unit == "" {
+		return nil
+	}
+	t.mu.Lock()
+	if t.units == nil {
+		t.units = make(map[string]*unitHistory)
+	}
+	h, ok := t.units[unit]
+	if !ok {
+		h = &unitHistory{}
+		t.units[unit] = h
+	}
+	due := time.Since(h.lastSampleAt) >= serviceSampleInterval
+	if due {
+		// Also when the query fails, so a missing unit is not asked for every second
+		h.lastSampleAt = time.Now()
+	}
+	t.mu.Unlock()
+	if !due {
+		return nil
+	}
+	st, err := t.query(unit, pid)
+	if err != nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h = t.observe(unit, st, time.Now())
+	st.NewRestarts, st.NewFailures = h.newRestarts, h.newFailures
+	h.newRestarts, h.newFailures = 0, 0
+	st.History = nil
+	return st
+}
+
+// observe compares st with the previous state of the unit and fills in the history. A changed
+// start time or main pid is a restart; automatic restarts and entering the failed or crashed
+// state are failures. Must be called with t.mu held.
+func (t *serviceTracker) observe(unit string, st *pb.ServiceStatus, now time.Time) *unitHistory {
+	if t.units == nil {
+		t.units = make(map[string]*unitHistory)
+	}
+	h, ok := t.units[unit]
+	if !ok {
+		h = &unitHistory{}
+		t.units[unit] = h
+	}
+	if prev := h.last; prev != nil {
+		restarted := (prev.StartedAt != 0 && st.StartedAt != 0 && st.StartedAt != prev.StartedAt) ||
+			(prev.MainPid != 0 && st.MainPid != 0 && st.MainPid != prev.MainPid)
+		if restarted {
+			at := st.StartedAt
+			if at == 0 {
+				at = now.Unix()
+			}
+			h.add(&pb.ServiceEvent{Timestamp: at, Type: "restart", Detail: fmt.Sprintf("pid %d", st.MainPid)})
+			h.newRestarts++
+		}
+		failed := st.RestartCount - prev.RestartCount
+		if failed < 0 {
+			failed = 0 // reset by a manual restart
+		}
+		if isFailedState(st.State) && !isFailedState(prev.State) {
+			failed = max(failed, 1)
+		}
+		for i := int32(0); i < failed; i++ {
+			at := st.LastFailureAt
+			if at == 0 {
+				at = now.Unix()
+			}
+			h.add(&pb.ServiceEvent{Timestamp: at, Type: "failure", Detail: "result=" + firstOr(st.Result, st.State)})
+			h.failures++
+			h.newFailures++
+			h.lastFailure = at
+		}
+	}
+	h.last = st
+
+	st.FailureCount = h.failures
+	if st.LastFailureAt == 0 {
+		st.LastFailureAt = h.lastFailure
+	}
+	st.History = append([]*pb.ServiceEvent(nil), h.events...)
+	st.RestartLoop = st.Result == "start-limit-hit" || h.restartsSince(now.Add(-restartLoopWindow)) >= restartLoopCount
+	return h
+}
+
+func (h *unitHistory) add(e *pb.ServiceEvent) {
+	h.events = append(h.events, e)
+	if len(h.events) > serviceHistoryMax {
+		h.events = h.events[len(h.events)-serviceHistoryMax:]
+	}
+}
+
+func (h *unitHistory) restartsSince(since time.Time) int {
+	n := 0
+	for _, e := range h.events {
+		if e.Type == "restart" && e.Timestamp >= since.Unix() {
+			n++
+		}
+	}
+	return n
+}
+
+func isFailedState(state string) bool {
+	return state == "failed" || state == "crashed"
+}
+
+// queryServiceManager reads the state of unit from systemd, or from OpenRC on hosts without it
+func queryServiceManager(unit string, pid int) (*pb.ServiceStatus, error) {
+	if fi, err := os.Stat("/run/systemd/system"); err == nil && fi.IsDir() {
+		out, err := exec.Command("systemctl", "show", unit, "--no-pager",
+			"-p", "LoadState,ActiveState,SubState,Result,MainPID,NRestarts,ExecMainStartTimestampMonotonic,InactiveEnterTimestampMonotonic").Output()
+		if err != nil {
+			return nil, fmt.Errorf("systemctl show %s: %w", unit, err)
+		}
+		return parseSystemdShow(unit, string(out), bootTime())
+	}
+	if _, err := exec.LookPath("rc-service"); err == nil {
+		// Exits non-zero unless the service is started
+		out, _ := exec.Command("rc-service", unit, "status").CombinedOutput()
+		st := &pb.ServiceStatus{Manager: "openrc", Unit: unit, State: parseOpenRCStatus(string(out))}
+		if st.State == "" {
+			return nil, fmt.Errorf("rc-service %s status: %s", unit, strings.TrimSpace(string(out)))
+		}
+		if st.State == "started" {
+			st.MainPid = int32(pid)
+		}
+		return st, nil
+	}
+	return nil, fmt.Errorf("no service manager found (systemd or OpenRC)")
+}
+
+// parseSystemdShow parses systemctl show key=value output. Monotonic timestamps are microseconds
+// since boot.
+func parseSystemdShow(unit, out string, boot int64) (*pb.ServiceStatus, error) {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] == "not-found" {
+		return nil, fmt.Errorf("unit %s not found", unit)
+	}
+	wallTime := func(key string) int64 {
+		usec, _ := strconv.ParseInt(props[key], 10, 64)
+		if usec == 0 || boot == 0 {
+			return 0
+		}
+		return boot + usec/1e6
+	}
+	st := &pb.ServiceStatus{
+		Manager:  "systemd",
+		Unit:     unit,
+		State:    props["ActiveState"],
+		SubState: props["SubState"],
+		Result:   props["Result"],
+	}
+	if pid, _ := strconv.Atoi(props["MainPID"]); pid > 0 {
+		st.MainPid = int32(pid)
+		st.StartedAt = wallTime("ExecMainStartTimestampMonotonic")
+	}
+	restarts, _ := strconv.Atoi(props["NRestarts"])
+	st.RestartCount = int32(restarts)
+	if st.Result != "" && st.Result != "success" {
+		st.LastFailureAt = wallTime("InactiveEnterTimestampMonotonic")
+	}
+	return st, nil
+}
+
+// parseOpenRCStatus returns the state in rc-service status output, e.g. " * status: crashed"
+func parseOpenRCStatus(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if _, state, ok := strings.Cut(line, "status:"); ok {
+			return strings.TrimSpace(state)
+		}
+	}
+	return ""
+}
+
+// bootTime returns the boot time from the btime line of /proc/stat, 0 when unknown
+func bootTime() int64 {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			boot, _ := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			return boot
+		}
+	}
+	return 0
+}
+
+// serviceJournal returns the journal of a systemd unit around its last failure, or its latest
+// lines when it has not failed
+func serviceJournal(unit string, lastFailure int64, lines int) ([]string, error) {
+	args := []string{"-u", unit, "--no-pager", "-o", "short-iso", "-n", strconv.Itoa(lines)}
+	if lastFailure > 0 {
+		args = append(args, "--since", fmt.Sprintf("@%d", lastFailure-120), "--until", fmt.Sprintf("@%d", lastFailure+30))
+	}
+	out, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl: %w", err)
+	}
+	var journal []string
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if line != "" && !strings.HasPrefix(line, "-- ") {
+			journal = append(journal, line)
+		}
+	}
+	return journal, nil
+}
+
+// GetServiceStatus reports the service manager state of an NGINX instance, its restart and
+// failure history and the journal around its last failure
+func (s *mgmtServer) GetServiceStatus(ctx context.Context, req *pb.ServiceStatusRequest) (*pb.ServiceStatus, error) {
+	inst, ok := nginxInstances.get(firstOr(req.NginxInstanceId, nginxInstances.primaryID()))
+	if !ok && req.NginxInstanceId != "" {
+		return &pb.ServiceStatus{Error: fmt.Sprintf("unknown NGINX instance %q", req.NginxInstanceId)}, nil
+	}
+	st := nginxServices.status(serviceTarget(inst))
+	if st.Manager != "systemd" {
+		return st, nil
+	}
+	lines := int(req.JournalLines)
+	if lines <= 0 {
+		lines = defaultJournalLines
+	}
+	journal, err := serviceJournal(st.Unit, st.LastFailureAt, min(lines, maxJournalLines))
+	if err != nil {
+		st.Error = fmt.Sprintf("reading the journal failed: %v", err)
+	}
+	st.Journal = journal
+	return st, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestParseSystemdShow(t *testing.T) {
+	out := "LoadState=loaded\nActiveState=activating\nSubState=auto-restart\nResult=exit-code\nMainPID=0\nNRestarts=4\n" +
+		"ExecMainStartTimestampMonotonic=5000000\nInactiveEnterTimestampMonotonic=7500000\n"
+	st, err := parseSystemdShow("nginx.service", out, 1700000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Manager != "systemd" || st.State != "activating" || st.SubState != "auto-restart" || st.RestartCount != 4 {
+		t.Errorf("status = %+v", st)
+	}
+	if st.StartedAt != 0 || st.LastFailureAt != 1700000007 {
+		t.Errorf("started at %d, last failure at %d", st.StartedAt, st.LastFailureAt)
+	}
+
+	if _, err := parseSystemdShow("nginx-api.service", "LoadState=not-found\nActiveState=inactive\n", 1700000000); err == nil {
+		t.Error("expected an error for a missing unit")
+	}
+}
+
+func TestParseOpenRCStatus(t *testing.T) {
+	if got := parseOpenRCStatus(" * status: crashed\n"); got != "crashed" {
+		t.Errorf("state = %q", got)
+	}
+	if got := parseOpenRCStatus(" * rc-service: service `nginx' does not exist\n"); got != "" {
+		t.Errorf("state = %q", got)
+	}
+}
+
+func TestServiceTrackerRestartLoop(t *testing.T) {
+	var current *pb.ServiceStatus
+	tracker := &serviceTracker{query: func(string, int) (*pb.ServiceStatus, error) { return current, nil }}
+	now := time.Now()
+
+	current = &pb.ServiceStatus{State: "active", MainPid: 100, StartedAt: now.Add(-time.Hour).Unix()}
+	if st := tracker.sample("nginx.service", 100); st == nil || st.NewRestarts != 0 || st.RestartLoop {
+		t.Fatalf("first sample = %+v", st)
+	}
+	for i := int32(1); i <= 3; i++ {
+		current = &pb.ServiceStatus{State: "active", Result: "exit-code", MainPid: 100 + i, RestartCount: i,
+			StartedAt: now.Add(time.Duration(i) * time.Minute).Unix()}
+		tracker.status("nginx.service", 0)
+	}
+	st := tracker.status("nginx.service", 0)
+	if !st.RestartLoop || st.FailureCount != 3 || len(st.History) != 6 {
+		t.Errorf("status = %+v", st)
+	}
+
+	// The next metrics sample reports the churn once
+	tracker.units["nginx.service"].lastSampleAt = time.Time{}
+	if st := tracker.sample("nginx.service", 0); st == nil || st.NewRestarts != 3 || st.NewFailures != 3 || st.History != nil {
+		t.Errorf("sample = %+v", st)
+	}
+	if st := tracker.sample("nginx.service", 0); st != nil {
+		t.Errorf("sample within the interval = %+v", st)
+	}
+
+	if st := tracker.status("", 0); st.Error == "" {
+		t.Error("expected an error for an instance without a unit")
+	}
+}
@@ -0,0 +1,180 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Update states recorded in the state file and reported in heartbeats
+const (
+	StatePending    = "pending"
+	StateSucceeded  = "succeeded"
+	StateRolledBack = "rolled_back"
+	StateFailed     = "failed"
+)
+
+// maxUpdateBoots is how many times the new binary may start without reaching a gateway before
+// it is rolled back right away, so a crash-looping update does not wait for the health timeout
+const maxUpdateBoots = 3
+
+// preflightTimeout bounds the "-version" run of a downloaded binary
+const preflightTimeout = 10 * time.Second
+
+// Status is the outcome of the last self-update, kept in the state file across the restart
+type Status struct {
+	State       string    `json:"state"`
+	FromVersion string    `json:"from_version"`
+	ToVersion   string    `json:"to_version"`
+	Error       string    `json:"error,omitempty"`
+	BinaryPath  string    `json:"binary_path,omitempty"`
+	BackupPath  string    `json:"backup_path,omitempty"`
+	Boots       int       `json:"boots,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at,omitempty"`
+}
+
+var (
+	statusMu   sync.Mutex
+	lastStatus *Status
+)
+
+// LastStatus returns the outcome of the last self-update, or nil when the agent never updated
+func LastStatus() *Status {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	if lastStatus == nil {
+		return nil
+	}
+	s := *lastStatus
+	return &s
+}
+
+// LoadStatus reads the state file, making its status the one LastStatus reports
+func LoadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid update state %s: %w", path, err)
+	}
+	statusMu.Lock()
+	lastStatus = &s
+	statusMu.Unlock()
+	return &s, nil
+}
+
+func saveStatus(path string, s *Status) {
+	statusMu.Lock()
+	c := *s
+	lastStatus = &c
+	statusMu.Unlock()
+	if path == "" {
+		return
+	}
+	data, _ := json.Marshal(s)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		err = os.Rename(tmp, path)
+		if err == nil {
+			return
+		}
+	}
+	updateLog.Warn().Msgf("Failed to save update state to %s", path)
+}
+
+// finishStatus records the final state of an update
+func finishStatus(path string, s *Status, state, errMsg string) {
+	s.State = state
+	s.Error = errMsg
+	s.FinishedAt = time.Now()
+	saveStatus(path, s)
+}
+
+// preflight runs the downloaded binary with -version and checks it reports the expected version
+func preflight(binary, version string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, binary, "-version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("new binary does not start: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "Version:"); ok {
+			if v = strings.TrimSpace(v); v != version {
+				return fmt.Errorf("new binary reports version %s, expected %s", v, version)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("new binary did not report its version")
+}
+
+// ConfirmUpdate runs the health gate of a pending update once the new binary started: the agent
+// must reach a gateway (connected is closed) within timeout, otherwise the previous binary is
+// restored and the agent restarted. Without a pending update it only loads the last status.
+func (u *Updater) ConfirmUpdate(ctx context.Context, connected <-chan struct{}, timeout time.Duration) {
+	s, err := LoadStatus(u.StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			updateLog.Warn().Msgf("%v", err)
+		}
+		return
+	}
+	if s.State != StatePending {
+		return
+	}
+	if u.CurrentVersion != s.ToVersion {
+		// The swap did not take or something else restored the old binary
+		finishStatus(u.StateFile, s, StateFailed, fmt.Sprintf("agent restarted on %s instead of %s", u.CurrentVersion, s.ToVersion))
+		return
+	}
+
+	s.Boots++
+	saveStatus(u.StateFile, s)
+	if s.Boots > maxUpdateBoots {
+		u.rollback(s, fmt.Sprintf("%s restarted %d times without reaching a gateway", s.ToVersion, s.Boots-1))
+		return
+	}
+
+	updateLog.Info().Msgf("🩺 Update to %s pending: waiting up to %s for a gateway connection", s.ToVersion, timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-connected:
+		if s.BackupPath != "" {
+			_ = os.Remove(s.BackupPath)
+		}
+		finishStatus(u.StateFile, s, StateSucceeded, "")
+		updateLog.Info().Msgf("✅ Update %s -> %s confirmed", s.FromVersion, s.ToVersion)
+	case <-timer.C:
+		u.rollback(s, fmt.Sprintf("no gateway connection within %s", timeout))
+	}
+}
+
+// rollback restores the previous binary and restarts the agent on it
+func (u *Updater) rollback(s *Status, reason string) {
+	updateLog.Info().Msgf("⏪ Rolling back update %s -> %s: %s", s.FromVersion, s.ToVersion, reason)
+	if s.BackupPath == "" || s.BinaryPath == "" {
+		finishStatus(u.StateFile, s, StateFailed, reason+"; no previous binary to roll back to")
+		return
+	}
+	if err := replaceBinary(s.BackupPath, s.BinaryPath); err != nil {
+		finishStatus(u.StateFile, s, StateFailed, fmt.Sprintf("%s; rollback failed: %v", reason, err))
+		return
+	}
+	finishStatus(u.StateFile, s, StateRolledBack, reason)
+	u.restart()
+}
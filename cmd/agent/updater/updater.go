@@ -1,20 +1,26 @@
 package updater
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
+var updateLog = logging.NewLogger("updater")
+
 type Manifest struct {
 	Version     string            `json:"version"`
 	ReleaseDate string            `json:"release_date"`
@@ -24,12 +30,27 @@ type Manifest struct {
 type Binary struct {
 	URL    string `json:"url"`
 	SHA256 string `json:"sha256"`
+	// Signature is the base64 ed25519 signature of SignedMessage(version, platform, sha256)
+	Signature string `json:"signature,omitempty"`
 }
 
 type Updater struct {
 	ServerURL      string
 	CurrentVersion string
 	IsContainer    bool
+	// AgentID is sent with manifest requests so the gateway can serve the release chosen for
+	// this agent (channel, environment pin or staged rollout)
+	AgentID string
+
+	// PublicKey, when set, requires every binary to be signed by the matching release key
+	PublicKey ed25519.PublicKey
+	// RequireHTTPS refuses manifests and binaries served over plain HTTP
+	RequireHTTPS bool
+	// OnRejected is called for updates refused because they failed verification
+	OnRejected func(*RejectedError)
+	// StateFile keeps the status of an update across the restart into the new binary, which
+	// confirms or rolls it back (see ConfirmUpdate)
+	StateFile string
 }
 
 func New(serverURL, currentVersion string) *Updater {
@@ -50,7 +71,7 @@ func New(serverURL, currentVersion string) *Updater {
 }
 
 func (u *Updater) Run(interval time.Duration) {
-	log.Printf("🔄 Self-update poller started (Interval: %v, Server: %s)", interval, u.ServerURL)
+	updateLog.Info().Msgf("🔄 Self-update poller started (Interval: %v, Server: %s)", interval, u.ServerURL)
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -60,41 +81,85 @@ func (u *Updater) Run(interval time.Duration) {
 }
 
 func (u *Updater) CheckAndApply(overrideURL string) {
-	manifest, err := u.fetchManifest(overrideURL)
+	u.CheckAndApplyVersion(overrideURL, "")
+}
+
+// CheckAndApplyVersion updates to the served version only when it is version; an empty version
+// or "latest" accepts whatever the server offers
+func (u *Updater) CheckAndApplyVersion(overrideURL, version string) {
+	manifest, err := u.fetchManifest(overrideURL, version)
 	if err != nil {
-		log.Printf("⚠️  Update check failed: %v", err)
+		u.fail(err)
 		return
 	}
 
 	if manifest.Version == u.CurrentVersion {
 		return
 	}
+	if version != "" && version != "latest" && manifest.Version != version {
+		updateLog.Warn().Msgf("Update to %s skipped: the update server offers %s", version, manifest.Version)
+		return
+	}
 
-	log.Printf("✨ New version found: %s (Current: %s). Starting update...", manifest.Version, u.CurrentVersion)
+	updateLog.Info().Msgf("✨ New version found: %s (Current: %s). Starting update...", manifest.Version, u.CurrentVersion)
 
 	archKey := fmt.Sprintf("%s-%s", runtime.GOOS, runtime.GOARCH)
 	binaryInfo, ok := manifest.Binaries[archKey]
 	if !ok {
-		log.Printf("❌ No binary found in manifest for architecture: %s", archKey)
+		updateLog.Error().Msgf("No binary found in manifest for architecture: %s", archKey)
 		return
 	}
 
-	if err := u.applyUpdate(binaryInfo); err != nil {
-		log.Printf("❌ Update failed: %v", err)
+	if err := u.verifyBinary(manifest.Version, archKey, binaryInfo); err != nil {
+		u.fail(err)
+		return
+	}
+
+	if err := u.applyUpdate(manifest.Version, archKey, binaryInfo); err != nil {
+		u.fail(err)
 		return
 	}
 }
 
-func (u *Updater) fetchManifest(overrideURL string) (*Manifest, error) {
+// fail logs a failed update and reports it when the package was rejected
+func (u *Updater) fail(err error) {
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		updateLog.Error().Msgf("Update failed: %v", err)
+		return
+	}
+	updateLog.Info().Msgf("🛑 %v", rejected)
+	if u.OnRejected != nil {
+		u.OnRejected(rejected)
+	}
+}
+
+// fetchManifest gets version.json, asking for version when one is requested
+func (u *Updater) fetchManifest(overrideURL, version string) (*Manifest, error) {
 	serverURL := u.ServerURL
 	if overrideURL != "" {
 		serverURL = overrideURL
 	}
+	if u.RequireHTTPS && !isHTTPS(serverURL) {
+		return nil, &RejectedError{URL: serverURL + "/version.json", Reason: "update server is not HTTPS"}
+	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(serverURL + "/version.json")
+	manifestURL := serverURL + "/version.json"
+	query := url.Values{}
+	if u.AgentID != "" {
+		query.Set("agent_id", u.AgentID)
+	}
+	if version != "" && version != "latest" {
+		query.Set("version", version)
+	}
+	if len(query) > 0 {
+		manifestURL += "?" + query.Encode()
+	}
+
+	client := u.httpClient(10 * time.Second)
+	resp, err := client.Get(manifestURL)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("update check failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -112,10 +177,8 @@ func (u *Updater) fetchManifest(overrideURL string) (*Manifest, error) {
 	if overrideURL != "" {
 		for k, b := range m.Binaries {
 			if strings.Contains(b.URL, "localhost") {
-				m.Binaries[k] = Binary{
-					URL:    strings.Replace(b.URL, "localhost", getHost(overrideURL), 1),
-					SHA256: b.SHA256,
-				}
+				b.URL = strings.Replace(b.URL, "localhost", getHost(overrideURL), 1)
+				m.Binaries[k] = b
 			}
 		}
 	}
@@ -123,6 +186,23 @@ func (u *Updater) fetchManifest(overrideURL string) (*Manifest, error) {
 	return &m, nil
 }
 
+// httpClient returns a client that, with RequireHTTPS, also refuses redirects to plain HTTP
+func (u *Updater) httpClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if u.RequireHTTPS {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s is not HTTPS", req.URL)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	return client
+}
+
 func getHost(url string) string {
 	// Simple helper to extract host:port from URL
 	parts := strings.Split(url, "//")
@@ -136,7 +216,7 @@ func getHost(url string) string {
 	return parts[1][:end]
 }
 
-func (u *Updater) applyUpdate(b Binary) error {
+func (u *Updater) applyUpdate(version, platform string, b Binary) error {
 	// 1. Download to temp file
 	tmpFile, err := os.CreateTemp("", "agent-update-*")
 	if err != nil {
@@ -144,8 +224,8 @@ func (u *Updater) applyUpdate(b Binary) error {
 	}
 	defer os.Remove(tmpFile.Name())
 
-	log.Printf("💾 Downloading update from %s...", b.URL)
-	resp, err := http.Get(b.URL)
+	updateLog.Info().Msgf("💾 Downloading update from %s...", b.URL)
+	resp, err := u.httpClient(0).Get(b.URL)
 	if err != nil {
 		return err
 	}
@@ -161,66 +241,108 @@ func (u *Updater) applyUpdate(b Binary) error {
 
 	// 2. Verify SHA256
 	downloadedHash := hex.EncodeToString(hasher.Sum(nil))
-	if downloadedHash != b.SHA256 {
-		return fmt.Errorf("checksum mismatch! Expected %s, got %s", b.SHA256, downloadedHash)
+	if !strings.EqualFold(downloadedHash, b.SHA256) {
+		return &RejectedError{Version: version, Platform: platform, URL: b.URL,
+			Reason: fmt.Sprintf("checksum mismatch: expected %s, got %s", b.SHA256, downloadedHash)}
+	}
+	updateLog.Info().Msg("✅ Checksum verified")
+	if u.PublicKey != nil {
+		updateLog.Info().Msg("✅ Signature verified")
 	}
-	log.Println("✅ Checksum verified")
 
-	// 3. Make executable
+	// 3. Make executable and check it starts and reports the expected version
 	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
 		return err
 	}
+	status := &Status{State: StatePending, FromVersion: u.CurrentVersion, ToVersion: version, StartedAt: time.Now()}
+	if err := preflight(tmpFile.Name(), version); err != nil {
+		finishStatus(u.StateFile, status, StateFailed, err.Error())
+		return err
+	}
+	updateLog.Info().Msg("✅ New binary starts")
 
-	// 4. Overwrite current binary
+	// 4. Keep the current binary for a rollback, then overwrite it
 	selfPath, err := os.Executable()
 	if err != nil {
 		return err
 	}
+	status.BinaryPath = selfPath
+	status.BackupPath = selfPath + ".prev"
+	if err := copyBinary(selfPath, status.BackupPath); err != nil {
+		err = fmt.Errorf("failed to back up the current binary: %w", err)
+		finishStatus(u.StateFile, status, StateFailed, err.Error())
+		return err
+	}
+	saveStatus(u.StateFile, status)
+
+	updateLog.Info().Msgf("🚀 Swapping binary at %s", selfPath)
+	if err := replaceBinary(tmpFile.Name(), selfPath); err != nil {
+		finishStatus(u.StateFile, status, StateFailed, err.Error())
+		return err
+	}
 
-	log.Printf("🚀 Swapping binary at %s", selfPath)
+	// 5. Restart or Exit; the new binary confirms the update once it reaches a gateway
+	u.restart()
+	return nil
+}
 
+// replaceBinary moves src over dst, falling back to a copy and to sudo when the agent cannot
+// write dst itself
+func replaceBinary(src, dst string) error {
 	// Try direct rename first (works in containers and when agent has write permissions)
-	if err := os.Rename(tmpFile.Name(), selfPath); err != nil {
-		log.Printf("⚠️  Direct rename failed: %v. Trying fallback methods...", err)
+	if err := os.Rename(src, dst); err != nil {
+		updateLog.Warn().Msgf("Direct rename failed: %v. Trying fallback methods...", err)
 
 		// Fallback 1: Try copy (for cross-filesystem)
-		if err := copyFile(tmpFile.Name(), selfPath); err != nil {
-			log.Printf("⚠️  Direct copy failed: %v. Trying sudo method...", err)
+		if err := copyFile(src, dst); err != nil {
+			updateLog.Warn().Msgf("Direct copy failed: %v. Trying sudo method...", err)
 
 			// Fallback 2: Use sudo for privileged binary replacement
-			cmd := exec.Command("sudo", "cp", tmpFile.Name(), selfPath)
+			cmd := exec.Command("sudo", "cp", src, dst)
 			if output, err := cmd.CombinedOutput(); err != nil {
 				return fmt.Errorf("failed to replace binary (all methods failed). Last error: %w, output: %s", err, string(output))
 			}
-			log.Println("✅ Binary replaced using sudo")
+			updateLog.Info().Msg("✅ Binary replaced using sudo")
 
 			// Ensure it's executable
-			cmd = exec.Command("sudo", "chmod", "755", selfPath)
+			cmd = exec.Command("sudo", "chmod", "755", dst)
 			if err := cmd.Run(); err != nil {
-				log.Printf("⚠️  Failed to chmod: %v", err)
+				updateLog.Warn().Msgf("Failed to chmod: %v", err)
 			}
 		} else {
-			log.Println("✅ Binary replaced using copy")
+			updateLog.Info().Msg("✅ Binary replaced using copy")
 		}
 	} else {
-		log.Println("✅ Binary replaced using rename")
+		updateLog.Info().Msg("✅ Binary replaced using rename")
 	}
+	return nil
+}
 
-	// 5. Restart or Exit
+// copyBinary copies src to dst, with sudo when the agent cannot write next to its binary
+func copyBinary(src, dst string) error {
+	if err := copyFile(src, dst); err != nil {
+		if output, err := exec.Command("sudo", "cp", src, dst).CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// restart runs the agent's new binary: containers exit for the pod to restart, hosts restart
+// the service
+func (u *Updater) restart() {
 	if u.IsContainer {
-		log.Println("🐳 Container detected. Exiting for pod restart...")
+		updateLog.Info().Msg("🐳 Container detected. Exiting for pod restart...")
 		os.Exit(100) // Special exit code for "Updated"
 	} else {
-		log.Println("🖥️  Standalone host detected. Attempting service restart...")
+		updateLog.Info().Msg("🖥️  Standalone host detected. Attempting service restart...")
 		// Try systemd restart if available, otherwise just exit and let manager (like supervisord) handle it
 		cmd := exec.Command("sudo", "systemctl", "restart", "avika-agent")
 		if err := cmd.Start(); err != nil {
-			log.Printf("Warning: Failed to trigger systemctl restart: %v. Exiting manually.", err)
+			updateLog.Warn().Msgf("Failed to trigger systemctl restart: %v. Exiting manually.", err)
 			os.Exit(0)
 		}
 	}
-
-	return nil
 }
 
 func copyFile(src, dst string) error {
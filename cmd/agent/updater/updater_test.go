@@ -0,0 +1,190 @@
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func signedBinary(t *testing.T, priv ed25519.PrivateKey, version, platform, url string, content []byte) Binary {
+	t.Helper()
+	sum := sha256.Sum256(content)
+	b := Binary{URL: url, SHA256: hex.EncodeToString(sum[:])}
+	b.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, SignedMessage(version, platform, b.SHA256)))
+	return b
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	for name, in := range map[string]string{
+		"raw base64": base64.StdEncoding.EncodeToString(pub),
+		"raw hex":    hex.EncodeToString(pub),
+		"spki":       base64.StdEncoding.EncodeToString(der),
+		"pem":        pemKey,
+	} {
+		got, err := ParsePublicKey(in)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !got.Equal(pub) {
+			t.Errorf("%s: parsed a different key", name)
+		}
+	}
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("garbage accepted")
+	}
+}
+
+func TestVerifyBinary(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	content := []byte("agent-binary")
+	good := signedBinary(t, priv, "1.2.0", "linux-amd64", "https://updates/bin/agent-linux-amd64", content)
+
+	u := &Updater{PublicKey: pub}
+	if err := u.verifyBinary("1.2.0", "linux-amd64", good); err != nil {
+		t.Fatalf("valid package rejected: %v", err)
+	}
+
+	cases := map[string]struct {
+		version, platform string
+		b                 Binary
+		u                 *Updater
+	}{
+		"unsigned":         {"1.2.0", "linux-amd64", Binary{URL: good.URL, SHA256: good.SHA256}, u},
+		"wrong key":        {"1.2.0", "linux-amd64", signedBinary(t, other, "1.2.0", "linux-amd64", good.URL, content), u},
+		"replayed version": {"1.3.0", "linux-amd64", good, u},
+		"other platform":   {"1.2.0", "linux-arm64", good, u},
+		"no checksum":      {"1.2.0", "linux-amd64", Binary{URL: good.URL}, &Updater{}},
+		"plain http":       {"1.2.0", "linux-amd64", Binary{URL: "http://updates/bin/agent", SHA256: good.SHA256}, &Updater{RequireHTTPS: true}},
+	}
+	for name, c := range cases {
+		err := c.u.verifyBinary(c.version, c.platform, c.b)
+		var rejected *RejectedError
+		if !errors.As(err, &rejected) {
+			t.Errorf("%s: err = %v, want a RejectedError", name, err)
+		}
+	}
+
+	// Without a public key, checksummed packages are still accepted
+	if err := (&Updater{}).verifyBinary("1.2.0", "linux-amd64", Binary{URL: good.URL, SHA256: good.SHA256}); err != nil {
+		t.Errorf("unsigned package without a key: %v", err)
+	}
+}
+
+func TestCheckAndApplyRejectsBadSignature(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, attacker, _ := ed25519.GenerateKey(rand.Reader)
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+
+	var downloads atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/version.json") {
+			_ = json.NewEncoder(w).Encode(Manifest{
+				Version:  "9.9.9",
+				Binaries: map[string]Binary{platform: signedBinary(t, attacker, "9.9.9", platform, srv.URL+"/bin/agent", []byte("evil"))},
+			})
+			return
+		}
+		downloads.Add(1)
+		_, _ = w.Write([]byte("evil"))
+	}))
+	defer srv.Close()
+
+	var reported *RejectedError
+	u := &Updater{ServerURL: srv.URL, CurrentVersion: "1.0.0", PublicKey: pub, OnRejected: func(r *RejectedError) { reported = r }}
+	u.CheckAndApply("")
+
+	if reported == nil || reported.Version != "9.9.9" || reported.Reason != "signature mismatch" {
+		t.Fatalf("reported rejection = %+v", reported)
+	}
+	if downloads.Load() != 0 {
+		t.Error("binary downloaded despite the signature mismatch")
+	}
+
+	// The update server itself must be HTTPS when required
+	reported = nil
+	u = &Updater{ServerURL: srv.URL, CurrentVersion: "1.0.0", RequireHTTPS: true, OnRejected: func(r *RejectedError) { reported = r }}
+	u.CheckAndApply("")
+	if reported == nil {
+		t.Error("plain HTTP update server not rejected")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho 'Avika Agent'\necho 'Version:    1.2.0'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := preflight(bin, "1.2.0"); err != nil {
+		t.Errorf("matching version: %v", err)
+	}
+	if err := preflight(bin, "1.3.0"); err == nil {
+		t.Error("wrong version accepted")
+	}
+	if err := preflight(filepath.Join(t.TempDir(), "missing"), "1.2.0"); err == nil {
+		t.Error("missing binary accepted")
+	}
+}
+
+func TestConfirmUpdate(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "agent.prev")
+	pending := func() string {
+		path := filepath.Join(dir, "update-state.json")
+		saveStatus(path, &Status{State: StatePending, FromVersion: "1.0.0", ToVersion: "1.1.0", BinaryPath: filepath.Join(dir, "agent"), BackupPath: backup, StartedAt: time.Now()})
+		if err := os.WriteFile(backup, []byte("old"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Reaching a gateway confirms the update and drops the backup
+	connected := make(chan struct{})
+	close(connected)
+	u := &Updater{CurrentVersion: "1.1.0", StateFile: pending()}
+	u.ConfirmUpdate(context.Background(), connected, time.Minute)
+	if s := LastStatus(); s == nil || s.State != StateSucceeded || s.Boots != 1 {
+		t.Errorf("confirmed status = %+v", s)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("backup kept after a confirmed update")
+	}
+	if s, err := LoadStatus(u.StateFile); err != nil || s.State != StateSucceeded {
+		t.Errorf("state file = %+v, %v", s, err)
+	}
+
+	// Starting on another version than the one installed records a failure
+	u = &Updater{CurrentVersion: "1.0.0", StateFile: pending()}
+	u.ConfirmUpdate(context.Background(), make(chan struct{}), time.Minute)
+	if s := LastStatus(); s.State != StateFailed || !strings.Contains(s.Error, "instead of 1.1.0") {
+		t.Errorf("mismatched version status = %+v", s)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/agent/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// plusPeer is a server of an upstream as reported by the NGINX Plus API.
+type plusPeer struct {
+	ID     int64  `json:"id"`
+	Server string `json:"server"`
+	State  string `json:"state"`
+	Weight int32  `json:"weight"`
+	Backup bool   `json:"backup"`
+}
+
+// plusAPIClient talks to the NGINX Plus REST API (/api/<version>).
+type plusAPIClient struct {
+	base   string
+	client *http.Client
+}
+
+func newPlusAPIClient(base string) *plusAPIClient {
+	if base == "" {
+		return nil
+	}
+	return &plusAPIClient{
+		base:   strings.TrimRight(base, "/"),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *plusAPIClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("plus api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Text string `json:"text"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error.Text != "" {
+			return fmt.Errorf("plus api: %s", apiErr.Error.Text)
+		}
+		return fmt.Errorf("plus api: %s %s returned %s", method, path, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// upstreamPeers returns the runtime peers of every upstream with a shared memory zone.
+func (c *plusAPIClient) upstreamPeers(ctx context.Context) (map[string][]plusPeer, error) {
+	var upstreams map[string]struct {
+		Peers []plusPeer `json:"peers"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/http/upstreams", nil, &upstreams); err != nil {
+		return nil, err
+	}
+	peers := make(map[string][]plusPeer, len(upstreams))
+	for name, u := range upstreams {
+		peers[name] = u.Peers
+	}
+	return peers, nil
+}
+
+func (s *mgmtServer) ListUpstreams(ctx context.Context, req *pb.UpstreamListRequest) (*pb.UpstreamListResponse, error) {
+	files, err := config.ParseTree(s.configPath)
+	if err != nil {
+		return &pb.UpstreamListResponse{Error: err.Error()}, nil
+	}
+
+	resp := &pb.UpstreamListResponse{}
+	var peers map[string][]plusPeer
+	if plus := newPlusAPIClient(*nginxPlusAPIURL); plus != nil {
+		if peers, err = plus.upstreamPeers(ctx); err == nil {
+			resp.PlusApi = true
+		} else {
+			agentLog.Info().Msgf("Plus API unavailable, showing configured upstreams only: %v", err)
+		}
+	}
+
+	for _, ref := range config.FindUpstreams(files) {
+		pool := ref.UpstreamPool()
+		if runtime, ok := peers[pool.Name]; ok {
+			mergePlusPeers(pool, runtime)
+		}
+		resp.Upstreams = append(resp.Upstreams, pool)
+	}
+	return resp, nil
+}
+
+// mergePlusPeers overlays runtime state from the Plus API on the configured servers.
+// Servers added at runtime (not in the config) are appended.
+func mergePlusPeers(pool *pb.UpstreamPool, peers []plusPeer) {
+	pool.Dynamic = true
+	byAddr := make(map[string]*pb.UpstreamServer, len(pool.Servers))
+	for _, srv := range pool.Servers {
+		byAddr[srv.Address] = srv
+	}
+	for _, p := range peers {
+		srv, ok := byAddr[p.Server]
+		if !ok {
+			srv = &pb.UpstreamServer{Address: p.Server, Weight: p.Weight, Backup: p.Backup}
+			pool.Servers = append(pool.Servers, srv)
+			byAddr[p.Server] = srv
+		}
+		srv.PeerId = p.ID
+		srv.State = p.State
+		srv.Down = p.State == "down"
+		srv.Drain = p.State == "draining"
+	}
+}
+
+func (s *mgmtServer) UpdateUpstreamServer(ctx context.Context, req *pb.UpstreamServerUpdate) (*pb.UpstreamServerUpdateResponse, error) {
+	if req.Upstream == "" || req.Address == "" {
+		return &pb.UpstreamServerUpdateResponse{Error: "upstream and address are required"}, nil
+	}
+
+	if plus := newPlusAPIClient(*nginxPlusAPIURL); plus != nil {
+		peers, err := plus.upstreamPeers(ctx)
+		if err == nil {
+			if runtime, ok := peers[req.Upstream]; ok {
+				if err := updatePlusUpstream(ctx, plus, req, runtime); err != nil {
+					return &pb.UpstreamServerUpdateResponse{Error: err.Error(), Method: "plus_api"}, nil
+				}
+				agentLog.Info().Msgf("Upstream %s: %s %s via Plus API", req.Upstream, req.Action, req.Address)
+				return &pb.UpstreamServerUpdateResponse{Success: true, Method: "plus_api"}, nil
+			}
+		} else {
+			agentLog.Warn().Msgf("Plus API unavailable, falling back to config edit: %v", err)
+		}
+	}
+
+	files, err := config.ParseTree(s.configPath)
+	if err != nil {
+		return &pb.UpstreamServerUpdateResponse{Error: err.Error(), Method: "config"}, nil
+	}
+	var target *config.UpstreamRef
+	for _, ref := range config.FindUpstreams(files) {
+		if ref.Block.Args[0] == req.Upstream {
+			ref := ref
+			target = &ref
+			break
+		}
+	}
+	if target == nil {
+		return &pb.UpstreamServerUpdateResponse{Error: fmt.Sprintf("upstream %q not found", req.Upstream), Method: "config"}, nil
+	}
+	if len(target.File.Errors) > 0 {
+		return &pb.UpstreamServerUpdateResponse{
+			Error:  fmt.Sprintf("refusing to rewrite %s: %s", target.File.File, target.File.Errors[0]),
+			Method: "config",
+		}, nil
+	}
+
+	if err := config.SetUpstreamServer(target.Block, req.Action, req.Address, req.Params); err != nil {
+		return &pb.UpstreamServerUpdateResponse{Error: err.Error(), Method: "config"}, nil
+	}
+	if err := s.applyConfigFiles(s.configManager, map[string]string{target.File.File: config.BuildConfig(target.File.Parsed)}); err != nil {
+		return &pb.UpstreamServerUpdateResponse{Error: err.Error(), Method: "config"}, nil
+	}
+
+	agentLog.Info().Msgf("Upstream %s: %s %s via config reload", req.Upstream, req.Action, req.Address)
+	return &pb.UpstreamServerUpdateResponse{Success: true, Method: "config"}, nil
+}
+
+func updatePlusUpstream(ctx context.Context, plus *plusAPIClient, req *pb.UpstreamServerUpdate, peers []plusPeer) error {
+	base := "/http/upstreams/" + url.PathEscape(req.Upstream) + "/servers"
+	var id int64 = -1
+	for _, p := range peers {
+		if p.Server == req.Address {
+			id = p.ID
+			break
+		}
+	}
+
+	if req.Action == "add" {
+		if id >= 0 {
+			return fmt.Errorf("server %s already exists in upstream %s", req.Address, req.Upstream)
+		}
+		body := plusServerParams(req.Params)
+		body["server"] = req.Address
+		return plus.do(ctx, http.MethodPost, base, body, nil)
+	}
+
+	if id < 0 {
+		return fmt.Errorf("server %s not found in upstream %s", req.Address, req.Upstream)
+	}
+	path := base + "/" + strconv.FormatInt(id, 10)
+	switch req.Action {
+	case "remove":
+		return plus.do(ctx, http.MethodDelete, path, nil, nil)
+	case "drain":
+		return plus.do(ctx, http.MethodPatch, path, map[string]interface{}{"drain": true}, nil)
+	case "disable":
+		return plus.do(ctx, http.MethodPatch, path, map[string]interface{}{"down": true}, nil)
+	case "enable":
+		return plus.do(ctx, http.MethodPatch, path, map[string]interface{}{"down": false}, nil)
+	default:
+		return fmt.Errorf("unknown action %q", req.Action)
+	}
+}
+
+// plusServerParams converts nginx server parameters ("weight=2", "backup") to Plus API fields.
+func plusServerParams(params []string) map[string]interface{} {
+	body := make(map[string]interface{}, len(params))
+	for _, p := range params {
+		key, val, hasVal := strings.Cut(p, "=")
+		switch {
+		case !hasVal:
+			body[key] = true
+		case key == "weight" || key == "max_fails" || key == "max_conns":
+			if n, err := strconv.Atoi(val); err == nil {
+				body[key] = n
+			} else {
+				body[key] = val
+			}
+		default:
+			body[key] = val
+		}
+	}
+	return body
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestUpdatePlusUpstream_Drain(t *testing.T) {
+	var gotMethod, gotPath string
+	var gotBody map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plus := newPlusAPIClient(ts.URL + "/api/9")
+	peers := []plusPeer{{ID: 0, Server: "10.0.0.1:8080"}, {ID: 1, Server: "10.0.0.2:8080"}}
+	req := &pb.UpstreamServerUpdate{Upstream: "backend", Address: "10.0.0.2:8080", Action: "drain"}
+
+	if err := updatePlusUpstream(context.Background(), plus, req, peers); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodPatch || gotPath != "/api/9/http/upstreams/backend/servers/1" || gotBody["drain"] != true {
+		t.Errorf("unexpected request %s %s %v", gotMethod, gotPath, gotBody)
+	}
+
+	req.Address = "10.9.9.9:80"
+	if err := updatePlusUpstream(context.Background(), plus, req, peers); err == nil {
+		t.Error("expected unknown server to fail")
+	}
+}
+
+func TestMergePlusPeers(t *testing.T) {
+	pool := &pb.UpstreamPool{Name: "backend", Servers: []*pb.UpstreamServer{{Address: "10.0.0.1:8080", Weight: 1}}}
+	mergePlusPeers(pool, []plusPeer{
+		{ID: 0, Server: "10.0.0.1:8080", State: "draining"},
+		{ID: 3, Server: "10.0.0.5:8080", State: "up", Weight: 2},
+	})
+	if !pool.Dynamic || !pool.Servers[0].Drain || len(pool.Servers) != 2 || pool.Servers[1].PeerId != 3 {
+		t.Errorf("unexpected merge result: %+v", pool)
+	}
+}
+
+func TestPlusServerParams(t *testing.T) {
+	got := plusServerParams([]string{"weight=3", "backup", "fail_timeout=10s"})
+	if got["weight"] != 3 || got["backup"] != true || got["fail_timeout"] != "10s" {
+		t.Errorf("plusServerParams = %v", got)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	watchdogInterval = 5 * time.Second
+	// Samples over a limit before degrading (15s) and under limitRecoverRatio of every limit
+	// before recovering (30s), so a short spike neither sheds load nor flaps
+	watchdogDegradeAfter = 3
+	watchdogRecoverAfter = 6
+	limitRecoverRatio    = 0.8
+	// shedIntervalFactor stretches heartbeat, discovery and metrics intervals while degraded
+	shedIntervalFactor = 5
+)
+
+// watchdog keeps the agent within its CPU and memory self-limits. While it is over a limit it
+// sheds load: log collection pauses and the collection intervals stretch.
+var watchdog = newResourceWatchdog()
+
+// loadShedder is what the watchdog pauses while degraded
+type loadShedder interface {
+	Pause()
+	Resume()
+}
+
+type resourceWatchdog struct {
+	shedding atomic.Bool
+
+	mu        sync.Mutex
+	status    pb.AgentResourceStatus
+	lastCPU   time.Duration
+	lastAt    time.Time
+	over      int
+	under     int
+	sample    func() (cpu time.Duration, rss uint64)
+	onDegrade func()
+	onRecover func()
+}
+
+func newResourceWatchdog() *resourceWatchdog {
+	return &resourceWatchdog{sample: sampleSelf}
+}
+
+// Shedding reports whether the agent is degraded and shedding load
+func (w *resourceWatchdog) Shedding() bool {
+	return w.shedding.Load()
+}
+
+// Status returns the agent's resource use for heartbeats, nil before the first sample
+func (w *resourceWatchdog) Status() *pb.AgentResourceStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lastAt.IsZero() {
+		return nil
+	}
+	return &pb.AgentResourceStatus{
+		Degraded:         w.status.Degraded,
+		Reason:           w.status.Reason,
+		DegradedSince:    w.status.DegradedSince,
+		CpuPercent:       w.status.CpuPercent,
+		MemoryBytes:      w.status.MemoryBytes,
+		CpuLimitPercent:  w.status.CpuLimitPercent,
+		MemoryLimitBytes: w.status.MemoryLimitBytes,
+	}
+}
+
+// Start samples the agent every watchdogInterval until ctx is done, pausing shedder while degraded
+func (w *resourceWatchdog) Start(ctx context.Context, shedder loadShedder) {
+	w.onDegrade, w.onRecover = shedder.Pause, shedder.Resume
+	go func() {
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				cpuLimit, memLimit := resourceLimits()
+				cpu, rss := w.sample()
+				w.observe(now, cpu, rss, cpuLimit, memLimit)
+			}
+		}
+	}()
+}
+
+// observe records a sample and degrades or recovers
+func (w *resourceWatchdog) observe(now time.Time, cpu time.Duration, rss uint64, cpuLimit float64, memLimit uint64) {
+	w.mu.Lock()
+	var cpuPercent float64
+	if !w.lastAt.IsZero() {
+		if elapsed := now.Sub(w.lastAt); elapsed > 0 && cpu >= w.lastCPU {
+			cpuPercent = float64(cpu-w.lastCPU) / float64(elapsed) * 100
+		}
+	}
+	first := w.lastAt.IsZero()
+	w.lastCPU, w.lastAt = cpu, now
+	w.status.CpuPercent, w.status.MemoryBytes = float32(cpuPercent), rss
+	w.status.CpuLimitPercent, w.status.MemoryLimitBytes = float32(cpuLimit), memLimit
+
+	var reasons []string
+	if cpuLimit > 0 && cpuPercent > cpuLimit && !first {
+		reasons = append(reasons, fmt.Sprintf("cpu %.1f%% over limit %g%%", cpuPercent, cpuLimit))
+	}
+	if memLimit > 0 && rss > memLimit {
+		reasons = append(reasons, fmt.Sprintf("memory %d MB over limit %d MB", rss>>20, memLimit>>20))
+	}
+	recovered := (cpuLimit == 0 || cpuPercent <= cpuLimit*limitRecoverRatio) &&
+		(memLimit == 0 || float64(rss) <= float64(memLimit)*limitRecoverRatio)
+
+	var transition func()
+	switch {
+	case len(reasons) > 0:
+		w.over, w.under = w.over+1, 0
+		if w.status.Degraded {
+			w.status.Reason = strings.Join(reasons, ", ")
+		} else if w.over >= watchdogDegradeAfter {
+			w.status.Degraded, w.status.Reason, w.status.DegradedSince = true, strings.Join(reasons, ", "), now.Unix()
+			transition = w.onDegrade
+			agentLog.Warn().Msgf("Agent over its resource limits (%s), shedding load", w.status.Reason)
+		}
+	case recovered:
+		w.over, w.under = 0, w.under+1
+		if w.status.Degraded && w.under >= watchdogRecoverAfter {
+			w.status.Degraded, w.status.Reason, w.status.DegradedSince = false, "", 0
+			transition = w.onRecover
+			agentLog.Info().Msg("Agent back within its resource limits, resuming normal operation")
+		}
+	default:
+		// Between the recovery threshold and the limit: hold the current mode
+		w.over, w.under = 0, 0
+	}
+	w.shedding.Store(w.status.Degraded)
+	w.mu.Unlock()
+
+	if transition != nil {
+		transition()
+	}
+}
+
+// sampleSelf returns the CPU time the agent has used and its resident set size
+func sampleSelf() (time.Duration, uint64) {
+	var cpu time.Duration
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err == nil {
+		cpu = time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+	}
+	// statm is "size resident shared ..." in pages
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return cpu, pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return cpu, ms.Sys
+}
+
+// shedInterval stretches an interval while the watchdog sheds load, up to limit when set
+func shedInterval(d, limit time.Duration) time.Duration {
+	if !watchdog.Shedding() {
+		return d
+	}
+	d *= shedIntervalFactor
+	if limit > 0 && d > limit {
+		d = limit
+	}
+	return d
+}
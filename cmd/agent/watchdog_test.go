@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeShedder struct{ paused, resumed int }
+
+func (f *fakeShedder) Pause()  { f.paused++ }
+func (f *fakeShedder) Resume() { f.resumed++ }
+
+func TestResourceWatchdog(t *testing.T) {
+	w := newResourceWatchdog()
+	old := watchdog
+	watchdog = w
+	defer func() { watchdog = old }()
+	shedder := &fakeShedder{}
+	w.onDegrade, w.onRecover = shedder.Pause, shedder.Resume
+	if w.Status() != nil {
+		t.Fatal("expected no status before the first sample")
+	}
+
+	const memLimit = 100 << 20
+	now := time.Unix(1700000000, 0)
+	var cpu time.Duration
+	step := func(cpuPerSample time.Duration, rss uint64) {
+		now = now.Add(watchdogInterval)
+		cpu += cpuPerSample
+		w.observe(now, cpu, rss, 50, memLimit)
+	}
+
+	// 4s of CPU per 5s sample is 80%, over the 50% limit; degrades on the third sample over
+	step(0, 10<<20)
+	step(4*time.Second, 10<<20)
+	step(4*time.Second, 10<<20)
+	if w.Shedding() || shedder.paused != 0 {
+		t.Fatal("degraded before enough samples over the limit")
+	}
+	step(4*time.Second, 200<<20)
+	st := w.Status()
+	if !w.Shedding() || shedder.paused != 1 || !st.Degraded || st.DegradedSince != now.Unix() {
+		t.Fatalf("expected degraded, status = %+v", st)
+	}
+	if st.Reason != "cpu 80.0% over limit 50%, memory 200 MB over limit 100 MB" || st.CpuPercent != 80 {
+		t.Errorf("status = %+v", st)
+	}
+	if got := shedInterval(time.Second, 0); got != 5*time.Second {
+		t.Errorf("shed interval = %s", got)
+	}
+	if got := shedInterval(30*time.Second, time.Minute); got != time.Minute {
+		t.Errorf("capped shed interval = %s", got)
+	}
+
+	// 45% is under the limit but over the recovery threshold: stays degraded
+	for i := 0; i < watchdogRecoverAfter; i++ {
+		step(2250*time.Millisecond, 10<<20)
+	}
+	if !w.Shedding() {
+		t.Fatal("recovered above the recovery threshold")
+	}
+	for i := 0; i < watchdogRecoverAfter; i++ {
+		step(time.Second, 10<<20)
+	}
+	if w.Shedding() || shedder.resumed != 1 || w.Status().Degraded || w.Status().Reason != "" {
+		t.Errorf("expected recovery, status = %+v", w.Status())
+	}
+}
+
+func TestResourceWatchdogUnlimited(t *testing.T) {
+	w := newResourceWatchdog()
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < 10; i++ {
+		w.observe(now.Add(time.Duration(i)*watchdogInterval), time.Duration(i)*10*time.Second, 1<<40, 0, 0)
+	}
+	if w.Shedding() || w.Status().Degraded {
+		t.Error("degraded without limits")
+	}
+}
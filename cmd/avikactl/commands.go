@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// agentInfo is the part of an agent the table shows
+type agentInfo struct {
+	AgentID      string `json:"agent_id"`
+	Hostname     string `json:"hostname"`
+	Status       string `json:"status"`
+	Version      string `json:"version"`
+	AgentVersion string `json:"agent_version"`
+	IP           string `json:"ip"`
+	LastSeen     int64  `json:"last_seen"`
+}
+
+func runLogin(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	user := fs.String("user", "", "username")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of AVIKA_PASSWORD")
+	if _, err := parseFlags(fs, c, args); err != nil {
+		return err
+	}
+	if *user == "" {
+		return errUsage
+	}
+	password := os.Getenv("AVIKA_PASSWORD")
+	if *passwordStdin {
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return fmt.Errorf("set AVIKA_PASSWORD or use -password-stdin")
+	}
+	var resp struct {
+		Token     string `json:"token"`
+		ExpiresAt string `json:"expires_at"`
+		Message   string `json:"message"`
+	}
+	if err := c.api.Do(ctx, http.MethodPost, "/api/auth/login", nil, map[string]string{
+		"username": *user,
+		"password": password,
+	}, &resp); err != nil {
+		return err
+	}
+	if resp.Token == "" {
+		return fmt.Errorf("login failed: %s", resp.Message)
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	fmt.Fprintln(c.stdout, resp.Token)
+	return nil
+}
+
+func runAgents(ctx context.Context, c *cli, args []string) error {
+	if len(args) > 0 && args[0] == "get" {
+		if len(args) != 2 {
+			return errUsage
+		}
+		var agent map[string]interface{}
+		if err := c.api.CallRPC(ctx, "GetAgent", map[string]string{"agent_id": args[1]}, &agent); err != nil {
+			return err
+		}
+		return c.printJSON(agent)
+	}
+	if len(args) > 0 && args[0] == "list" {
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("agents", flag.ContinueOnError)
+	search := fs.String("search", "", "substring of the hostname, IP, agent ID or versions")
+	status := fs.String("status", "", "online or offline")
+	project := fs.String("project", "", "project ID")
+	env := fs.String("env", "", "environment ID")
+	tags := fs.String("tag", "", "comma-separated tags the agents must all carry")
+	positional, err := parseFlags(fs, c, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errUsage
+	}
+	q := url.Values{}
+	for k, v := range map[string]string{"search": *search, "status": *status, "project_id": *project, "environment_id": *env} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	for _, t := range splitList(*tags) {
+		q.Add("tags", t)
+	}
+
+	var resp struct {
+		Agents []agentInfo `json:"agents"`
+		Total  int         `json:"total"`
+	}
+	if err := c.api.ListAgents(ctx, q, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp.Agents)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT ID\tHOSTNAME\tSTATUS\tNGINX\tAGENT\tIP\tLAST SEEN")
+	for _, a := range resp.Agents {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", a.AgentID, a.Hostname, a.Status, a.Version, a.AgentVersion, a.IP, ago(a.LastSeen))
+	}
+	return tw.Flush()
+}
+
+func runLogs(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	tail := fs.Int("n", 200, "lines to show from the end of the log (at most 1000)")
+	logType := fs.String("type", "access", "access or error")
+	follow := fs.Bool("f", false, "keep streaming new lines")
+	status := fs.String("status", "", "status code (404) or class (5xx)")
+	method := fs.String("method", "", "request method")
+	text := fs.String("q", "", "text the line must contain")
+	positional, err := parseFlags(fs, c, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+	q := url.Values{"tail": {strconv.Itoa(*tail)}, "log_type": {*logType}}
+	if !*follow {
+		q.Set("follow", "0")
+	}
+	for k, v := range map[string]string{"status": *status, "method": *method, "q": *text} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	return c.streamLogs(ctx, "/api/servers/"+url.PathEscape(positional[0])+"/logs/stream?"+q.Encode())
+}
+
+// streamLogs prints the log events of an SSE stream until the gateway ends it or ctx is done
+func (c *cli) streamLogs(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.api.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.api.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.api.Token)
+	}
+	// no client timeout: a followed stream lasts until it is interrupted
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("%s: %s", resp.Status, e.Error)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	var event string
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := strings.TrimPrefix(line, "data: ")
+			switch event {
+			case "log":
+				c.printLogLine(data)
+			case "end":
+				return nil
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sc.Err()
+}
+
+func (c *cli) printLogLine(data string) {
+	if c.json {
+		fmt.Fprintln(c.stdout, data)
+		return
+	}
+	var e struct {
+		Content       string `json:"content"`
+		Status        int    `json:"status"`
+		RequestMethod string `json:"request_method"`
+		RequestURI    string `json:"request_uri"`
+		RemoteAddr    string `json:"remote_addr"`
+	}
+	if json.Unmarshal([]byte(data), &e) != nil {
+		return
+	}
+	if e.Content == "" {
+		e.Content = fmt.Sprintf("%s %s %s %d", e.RemoteAddr, e.RequestMethod, e.RequestURI, e.Status)
+	}
+	fmt.Fprintln(c.stdout, e.Content)
+}
+
+func runConfig(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	path := fs.String("path", "", "config file on the agent (default: the main nginx.conf)")
+	noBackup := fs.Bool("no-backup", false, "push: do not back up the current file")
+	reload := fs.Bool("reload", false, "push: reload NGINX after writing the file")
+	instance := fs.String("instance", "", "NGINX instance on the agent (default: the primary one)")
+	positional, err := parseFlags(fs, c, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return errUsage
+	}
+	action, agent := positional[0], positional[1]
+
+	switch action {
+	case "get":
+		if len(positional) != 2 {
+			return errUsage
+		}
+		var resp struct {
+			Config struct {
+				ConfigPath string `json:"config_path"`
+				Content    string `json:"content"`
+			} `json:"config"`
+			Error string `json:"error"`
+		}
+		err := c.api.CallRPC(ctx, "GetConfig", map[string]string{
+			"instance_id": agent, "config_path": *path, "nginx_instance_id": *instance,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("%s", resp.Error)
+		}
+		if c.json {
+			return c.printJSON(resp.Config)
+		}
+		fmt.Fprint(c.stdout, resp.Config.Content)
+		return nil
+
+	case "validate", "push":
+		if len(positional) != 3 {
+			return errUsage
+		}
+		content, err := readInput(c, positional[2])
+		if err != nil {
+			return err
+		}
+		if err := c.validateConfig(ctx, agent, *instance, content); err != nil {
+			return err
+		}
+		if action == "validate" {
+			return nil
+		}
+		var resp struct {
+			Success    bool   `json:"success"`
+			Error      string `json:"error"`
+			BackupPath string `json:"backup_path"`
+		}
+		err = c.api.CallRPC(ctx, "UpdateConfig", map[string]interface{}{
+			"instance_id":       agent,
+			"config_path":       *path,
+			"new_content":       content,
+			"backup":            !*noBackup,
+			"nginx_instance_id": *instance,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("push failed: %s", resp.Error)
+		}
+		fmt.Fprintf(c.stderr, "config pushed to %s", agent)
+		if resp.BackupPath != "" {
+			fmt.Fprintf(c.stderr, " (backup %s)", resp.BackupPath)
+		}
+		fmt.Fprintln(c.stderr)
+		if *reload {
+			return c.reload(ctx, agent, *instance)
+		}
+		return nil
+	}
+	return errUsage
+}
+
+// validateConfig runs nginx -t on the agent against content, printing its errors and warnings;
+// an invalid config is an error
+func (c *cli) validateConfig(ctx context.Context, agent, instance, content string) error {
+	var resp struct {
+		Valid    bool     `json:"valid"`
+		Errors   []string `json:"errors"`
+		Warnings []string `json:"warnings"`
+	}
+	err := c.api.CallRPC(ctx, "ValidateConfig", map[string]string{
+		"instance_id": agent, "config_content": content, "nginx_instance_id": instance,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		if err := c.printJSON(resp); err != nil {
+			return err
+		}
+	} else {
+		for _, w := range resp.Warnings {
+			fmt.Fprintf(c.stderr, "warning: %s\n", w)
+		}
+		for _, e := range resp.Errors {
+			fmt.Fprintf(c.stderr, "error: %s\n", e)
+		}
+	}
+	if !resp.Valid {
+		return fmt.Errorf("config is invalid on %s", agent)
+	}
+	if !c.json {
+		fmt.Fprintf(c.stderr, "config is valid on %s\n", agent)
+	}
+	return nil
+}
+
+func runReload(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	instance := fs.String("instance", "", "NGINX instance on the agent (default: the primary one)")
+	positional, err := parseFlags(fs, c, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+	return c.reload(ctx, positional[0], *instance)
+}
+
+func (c *cli) reload(ctx context.Context, agent, instance string) error {
+	var resp struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := c.api.CallRPC(ctx, "ReloadNginx", map[string]string{"instance_id": agent, "nginx_instance_id": instance}, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("reload failed on %s: %s", agent, resp.Error)
+	}
+	fmt.Fprintf(c.stderr, "nginx reloaded on %s\n", agent)
+	return nil
+}
+
+func runAlerts(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "list":
+		var resp struct {
+			Rules []struct {
+				ID         string  `json:"id"`
+				Name       string  `json:"name"`
+				MetricType string  `json:"metric_type"`
+				Comparison string  `json:"comparison"`
+				Threshold  float64 `json:"threshold"`
+				WindowSec  int     `json:"window_sec"`
+				Severity   string  `json:"severity"`
+				Enabled    bool    `json:"enabled"`
+			} `json:"rules"`
+		}
+		if err := c.api.CallRPC(ctx, "ListAlertRules", nil, &resp); err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(resp.Rules)
+		}
+		tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tCONDITION\tWINDOW\tSEVERITY\tENABLED")
+		for _, r := range resp.Rules {
+			fmt.Fprintf(tw, "%s\t%s\t%s %s %g\t%ds\t%s\t%t\n", r.ID, r.Name, r.MetricType, r.Comparison, r.Threshold, r.WindowSec, r.Severity, r.Enabled)
+		}
+		return tw.Flush()
+
+	case "create":
+		fs := flag.NewFlagSet("alerts create", flag.ContinueOnError)
+		file := fs.String("f", "", "rule as JSON (- for stdin), e.g. {\"name\": \"errors\", \"metric_type\": \"error_rate\", \"comparison\": \"gt\", \"threshold\": 5, \"window_sec\": 300, \"enabled\": true}")
+		if _, err := parseFlags(fs, c, args[1:]); err != nil {
+			return err
+		}
+		if *file == "" {
+			return errUsage
+		}
+		data, err := readInput(c, *file)
+		if err != nil {
+			return err
+		}
+		var rule json.RawMessage
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			return fmt.Errorf("%s: %w", *file, err)
+		}
+		var created map[string]interface{}
+		if err := c.api.CallRPC(ctx, "CreateAlertRule", rule, &created); err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(created)
+		}
+		fmt.Fprintln(c.stdout, created["id"])
+		return nil
+
+	case "delete":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return c.api.CallRPC(ctx, "DeleteAlertRule", map[string]string{"id": args[1]}, nil)
+	}
+	return errUsage
+}
+
+func runReport(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.Duration("since", 24*time.Hour, "period covered, ending now")
+	reportType := fs.String("type", "summary", "summary, detailed or security")
+	agents := fs.String("agents", "", "comma-separated agent IDs (default: all)")
+	format := fs.String("format", "pdf", "download format: pdf or excel")
+	out := fs.String("out", "", "download the report to this file instead of printing its summary")
+	positional, err := parseFlags(fs, c, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 || *since <= 0 {
+		return errUsage
+	}
+	now := time.Now()
+	req := map[string]interface{}{
+		"start_time":  now.Add(-*since).Unix(),
+		"end_time":    now.Unix(),
+		"agent_ids":   splitList(*agents),
+		"report_type": *reportType,
+		"format":      *format,
+	}
+
+	if *out != "" {
+		var resp struct {
+			Content  []byte `json:"content"` // base64 in the protobuf JSON mapping
+			FileName string `json:"file_name"`
+		}
+		if err := c.api.CallRPC(ctx, "DownloadReport", req, &resp); err != nil {
+			return err
+		}
+		if err := os.WriteFile(*out, resp.Content, 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "report written to %s (%d bytes)\n", *out, len(resp.Content))
+		return nil
+	}
+
+	var resp map[string]interface{}
+	if err := c.api.CallRPC(ctx, "GenerateReport", req, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	summary, _ := resp["summary"].(map[string]interface{})
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Period\t%s\n", *since)
+	for _, k := range []string{"total_requests", "error_rate", "avg_latency", "peak_rps", "unique_visitors", "total_bandwidth"} {
+		fmt.Fprintf(tw, "%s\t%v\n", strings.ReplaceAll(k, "_", " "), summary[k])
+	}
+	tw.Flush()
+	if s, _ := resp["executive_summary"].(string); s != "" {
+		fmt.Fprintf(c.stdout, "\n%s\n", s)
+	}
+	if issues, _ := resp["top_issues"].([]interface{}); len(issues) > 0 {
+		fmt.Fprintln(c.stdout, "\nTop issues:")
+		for _, i := range issues {
+			fmt.Fprintf(c.stdout, "  - %v\n", i)
+		}
+	}
+	return nil
+}
+
+// readInput reads a file, or stdin for "-"
+func readInput(c *cli, name string) (string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	return string(data), err
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ago renders a Unix timestamp relative to now, e.g. "5m ago"
+func ago(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	d := time.Since(time.Unix(unix, 0))
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
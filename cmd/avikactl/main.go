@@ -0,0 +1,168 @@
+// Command avikactl runs fleet operations against the Avika gateway API: listing agents, tailing
+// logs, validating and pushing NGINX configs, reloads, alert rules and reports.
+//
+// The gateway and credentials come from -server and -token, or AVIKA_URL and AVIKA_TOKEN:
+//
+//	export AVIKA_URL=https://avika.example.com
+//	export AVIKA_TOKEN=$(avikactl login -user ci)
+//	avikactl agents -status online
+//	avikactl config push web-01 nginx.conf -reload
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/avika-ai/avika/pkg/client"
+)
+
+// Set at build time with -ldflags "-X main.Version=..."
+var Version = "dev"
+
+// Exit codes: failed operations (including invalid configs) exit 1, usage errors 2
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// errUsage marks errors caused by the command line rather than the gateway
+var errUsage = errors.New("usage")
+
+// cli is the state shared by the commands
+type cli struct {
+	api    *client.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"login":   {"login -user NAME [-password-stdin]    print a session token for AVIKA_TOKEN", runLogin},
+	"agents":  {"agents [get ID] [-search S] [-status online|offline] [-project ID] [-env ID] [-tag T]", runAgents},
+	"logs":    {"logs AGENT [-n 200] [-type access|error] [-f] [-status 5xx] [-method GET] [-q TEXT]", runLogs},
+	"config":  {"config get|validate|push AGENT [FILE] [-path PATH] [-no-backup] [-reload]", runConfig},
+	"reload":  {"reload AGENT [-instance ID]", runReload},
+	"alerts":  {"alerts list | alerts create -f RULE.json | alerts delete ID", runAlerts},
+	"report":  {"report [-since 24h] [-type summary|detailed|security] [-agents A,B] [-format pdf|excel] [-out FILE]", runReport},
+	"version": {"version", runVersion},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("avikactl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	server := fs.String("server", os.Getenv("AVIKA_URL"), "gateway HTTP URL (env AVIKA_URL)")
+	token := fs.String("token", os.Getenv("AVIKA_TOKEN"), "session token (env AVIKA_TOKEN)")
+	output := fs.String("o", "table", "output format: table or json")
+	fs.Usage = func() { usage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		usage(stderr, fs)
+		return exitUsage
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "avikactl: unknown command %q\n", fs.Arg(0))
+		usage(stderr, fs)
+		return exitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "avikactl: -o must be table or json\n")
+		return exitUsage
+	}
+	if *server == "" && fs.Arg(0) != "version" {
+		fmt.Fprintln(stderr, "avikactl: set -server or AVIKA_URL to the gateway URL")
+		return exitUsage
+	}
+
+	c := &cli{
+		api:    client.New(*server, *token),
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		json:   *output == "json",
+	}
+	err := cmd.run(ctx, c, fs.Args()[1:])
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "usage: avikactl %s\n", cmd.usage)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "avikactl: %v\n", err)
+		return exitError
+	}
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: avikactl [-server URL] [-token TOKEN] [-o table|json] COMMAND [ARGS]")
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fs.PrintDefaults()
+}
+
+// parseFlags parses a command's flags, which may come before or after its positional arguments
+func parseFlags(fs *flag.FlagSet, c *cli, args []string) ([]string, error) {
+	fs.SetOutput(c.stderr)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			// the flag package has printed the problem and the command's flags
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runVersion(_ context.Context, c *cli, _ []string) error {
+	fmt.Fprintf(c.stdout, "avikactl %s\n", Version)
+	return nil
+}
+
+// splitList turns "a, b" into [a b]
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
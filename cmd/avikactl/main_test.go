@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeGateway answers the endpoints the tests use
+func fakeGateway(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/servers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"error":"unauthorized","message":"missing token"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("status") != "online" {
+			t.Errorf("status filter not sent: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"agents":[{"agent_id":"web-01","hostname":"web-01.local","status":"online","version":"1.27.1","agent_version":"2.4.0","ip":"10.0.0.5"}],"total":1}`))
+	})
+	mux.HandleFunc("POST /api/rpc/ValidateConfig", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req["config_content"], "broken") {
+			w.Write([]byte(`{"valid":false,"errors":["unexpected end of file"],"warnings":[]}`))
+			return
+		}
+		w.Write([]byte(`{"valid":true,"errors":[],"warnings":[]}`))
+	})
+	mux.HandleFunc("GET /api/servers/{agentId}/logs/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("follow") != "0" || r.URL.Query().Get("status") != "5xx" {
+			t.Errorf("unexpected log query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: connected\ndata: {}\n\n")
+		fmt.Fprint(w, "event: log\ndata: {\"content\":\"GET /a 502\",\"status\":502}\n\n")
+		fmt.Fprint(w, "event: log\ndata: {\"status\":503,\"request_method\":\"GET\",\"request_uri\":\"/b\",\"remote_addr\":\"10.0.0.1\"}\n\n")
+		fmt.Fprint(w, "event: end\ndata: {\"reason\":\"tail_complete\"}\n\n")
+	})
+	return httptest.NewServer(mux)
+}
+
+func runCLI(t *testing.T, srv *httptest.Server, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-server", srv.URL, "-token", "tok"}, args...)
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAgentsList(t *testing.T) {
+	srv := fakeGateway(t)
+	defer srv.Close()
+
+	code, out, errOut := runCLI(t, srv, "", "agents", "-status", "online")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "AGENT ID") || !strings.Contains(out, "web-01.local") || !strings.Contains(out, "1.27.1") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+
+	code, out, _ = runCLI(t, srv, "", "-o", "json", "agents", "list", "-status=online")
+	var agents []agentInfo
+	if code != exitOK || json.Unmarshal([]byte(out), &agents) != nil || len(agents) != 1 || agents[0].AgentID != "web-01" {
+		t.Errorf("json output: exit %d\n%s", code, out)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	srv := fakeGateway(t)
+	defer srv.Close()
+
+	if code, _, errOut := runCLI(t, srv, "events {}\n", "config", "validate", "web-01", "-"); code != exitOK || !strings.Contains(errOut, "valid") {
+		t.Errorf("valid config: exit %d: %s", code, errOut)
+	}
+	code, _, errOut := runCLI(t, srv, "broken {", "config", "validate", "web-01", "-")
+	if code != exitError || !strings.Contains(errOut, "unexpected end of file") {
+		t.Errorf("invalid config should exit %d with the errors, got %d: %s", exitError, code, errOut)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	srv := fakeGateway(t)
+	defer srv.Close()
+
+	code, out, errOut := runCLI(t, srv, "", "logs", "web-01", "-status", "5xx")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	if out != "GET /a 502\n10.0.0.1 GET /b 503\n" {
+		t.Errorf("unexpected lines:\n%s", out)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	srv := fakeGateway(t)
+	defer srv.Close()
+
+	for _, args := range [][]string{{"nope"}, {"logs"}, {"config", "get"}, {"agents", "-bogus"}} {
+		if code, _, _ := runCLI(t, srv, "", args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-server", srv.URL, "agents"}, nil, &bytes.Buffer{}, &stderr); code != exitError || !strings.Contains(stderr.String(), "missing token") {
+		t.Errorf("unauthenticated call: exit %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// upstreamTrace is the trace context a request carried from an upstream tracing system
+type upstreamTrace struct {
+	traceID      string
+	parentSpanID string // span of the caller; empty with X-Request-ID
+	source       string // header it came from: traceparent or x-request-id
+}
+
+// parseTraceparent returns the trace and parent span IDs of a W3C traceparent header
+// (version-traceid-parentid-flags), rejecting malformed and all-zero IDs
+func parseTraceparent(h string) (traceID, parentSpanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	traceID, parentSpanID = strings.ToLower(parts[1]), strings.ToLower(parts[2])
+	if !isHexID(traceID, 32) || !isHexID(parentSpanID, 16) {
+		return "", "", false
+	}
+	return traceID, parentSpanID, true
+}
+
+// isHexID reports whether s is n lowercase hex digits, not all zero
+func isHexID(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	nonZero := false
+	for _, c := range s {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			nonZero = true
+		default:
+			return false
+		}
+	}
+	return nonZero
+}
+
+// upstreamTraceOf picks the trace context of an access log: the authoritative header when the
+// request carried it, else the other one
+func upstreamTraceOf(entry *pb.LogEntry, authoritative string) (upstreamTrace, bool) {
+	fromTraceparent := func() (upstreamTrace, bool) {
+		traceID, parent, ok := parseTraceparent(entry.Traceparent)
+		return upstreamTrace{traceID: traceID, parentSpanID: parent, source: "traceparent"}, ok
+	}
+	fromRequestID := func() (upstreamTrace, bool) {
+		id := strings.TrimSpace(entry.XRequestId)
+		return upstreamTrace{traceID: id, source: "x-request-id"}, id != ""
+	}
+	first, second := fromTraceparent, fromRequestID
+	if authoritative == "x-request-id" {
+		first, second = fromRequestID, fromTraceparent
+	}
+	if t, ok := first(); ok {
+		return t, true
+	}
+	return second()
+}
+
+// newSpanID returns a random W3C span ID (16 hex digits)
+func newSpanID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// insertAccessLogSpans builds the spans of an access log as tracing.access_log_spans asks
+func (s *server) insertAccessLogSpans(entry *pb.LogEntry, agentID string) {
+	cfg := config.TracingConfig{TraceIDHeader: "traceparent", AccessLogSpans: "traced"}
+	if s.config != nil {
+		cfg = s.config.Tracing
+	}
+	trace, traced := upstreamTraceOf(entry, cfg.TraceIDHeader)
+	if cfg.AccessLogSpans == "off" || (cfg.AccessLogSpans != "all" && !traced) {
+		return
+	}
+	requestTime := time.Unix(entry.Timestamp, 0)
+	if entry.Timestamp == 0 {
+		requestTime = time.Now()
+	}
+	_ = s.clickhouse.InsertSpans(entry, agentID, requestTime, trace)
+}
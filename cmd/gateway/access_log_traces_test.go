@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, parent, ok := parseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || parent != "00f067aa0ba902b7" {
+		t.Errorf("parseTraceparent = %q, %q, %v", traceID, parent, ok)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",    // no flags
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", // invalid version
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", // zero parent
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",   // short trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", // not hex
+	} {
+		if _, _, ok := parseTraceparent(bad); ok {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestUpstreamTraceOf(t *testing.T) {
+	both := &pb.LogEntry{
+		Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		XRequestId:  "req-42",
+	}
+	if tr, ok := upstreamTraceOf(both, "traceparent"); !ok || tr.traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tr.parentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("traceparent authoritative = %+v", tr)
+	}
+	if tr, ok := upstreamTraceOf(both, "x-request-id"); !ok || tr.traceID != "req-42" || tr.parentSpanID != "" || tr.source != "x-request-id" {
+		t.Errorf("x-request-id authoritative = %+v", tr)
+	}
+
+	// The other header stands in for a missing or malformed authoritative one
+	onlyID := &pb.LogEntry{Traceparent: "garbage", XRequestId: "req-42"}
+	if tr, ok := upstreamTraceOf(onlyID, "traceparent"); !ok || tr.traceID != "req-42" {
+		t.Errorf("fallback = %+v", tr)
+	}
+	if _, ok := upstreamTraceOf(&pb.LogEntry{RequestId: "nginx-id"}, "traceparent"); ok {
+		t.Error("NGINX's own request ID is not an upstream trace context")
+	}
+
+	if id := newSpanID(); !isHexID(id, 16) {
+		t.Errorf("newSpanID = %q", id)
+	}
+}
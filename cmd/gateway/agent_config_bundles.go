@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// agentConfigBundleKeys are the avika-agent.conf keys a bundle may set. AGENT_ID and MGMT_ADVERTISE
+// are per agent and PSK_KEY comes from the gateway's own key through include_psk; LABEL_* keys are
+// also accepted.
+var agentConfigBundleKeys = map[string]bool{
+	"GATEWAYS":                   true,
+	"HEALTH_PORT":                true,
+	"UPDATE_SERVER":              true,
+	"UPDATE_INTERVAL":            true,
+	"UPDATE_PUBLIC_KEY":          true,
+	"UPDATE_REQUIRE_HTTPS":       true,
+	"UPDATE_HEALTH_TIMEOUT":      true,
+	"NGINX_STATUS_URL":           true,
+	"NGINX_INSTANCE_STATUS_URLS": true,
+	"NGINX_PLUS_API_URL":         true,
+	"TLS":                        true,
+	"TLS_CERT":                   true,
+	"TLS_KEY":                    true,
+	"TLS_CA":                     true,
+	"TLS_INSECURE":               true,
+	"ACCESS_LOG_PATH":            true,
+	"ERROR_LOG_PATH":             true,
+	"LOG_FORMAT":                 true,
+	"LOG_FORMAT_STRING":          true,
+	"LOG_SOURCE":                 true,
+	"LOG_SYSLOG_LISTEN":          true,
+	"LOG_JOURNALD_MATCH":         true,
+	"NGINX_CONFIG_PATH":          true,
+	"BUFFER_DIR":                 true,
+	"LOG_LEVEL":                  true,
+	"LOG_FILE":                   true,
+	"MGMT_PORT":                  true,
+	"MGMT_NAT_CIDR":              true,
+	"SYSLOG_ENABLED":             true,
+	"SYSLOG_TARGET":              true,
+	"SYSLOG_FACILITY":            true,
+	"SYSLOG_SEVERITY":            true,
+	"METRICS_INTERVAL":           true,
+	"NGINX_METRICS_ENABLED":      true,
+	"SYSTEM_METRICS_ENABLED":     true,
+	"DISABLED_COLLECTORS":        true,
+	"HEARTBEAT_INTERVAL":         true,
+	"DISCOVERY_INTERVAL":         true,
+	"MAX_CPU_PERCENT":            true,
+	"MAX_MEMORY_MB":              true,
+	"COLLECTORS_DIR":             true,
+	"LOG_REDACT":                 true,
+	"LOG_REDACT_PARAMS":          true,
+	"LOG_REDACT_IPV4_PREFIX":     true,
+	"LOG_REDACT_IPV6_PREFIX":     true,
+}
+
+// fleetAgentConfigKeys may only be set in the fleet-wide bundle, which takes a super admin: they
+// choose where agents fetch updates and which key verifies them, whether agents check the
+// gateway's certificate and which collector plugins they run
+var fleetAgentConfigKeys = map[string]bool{
+	"UPDATE_SERVER":        true,
+	"UPDATE_PUBLIC_KEY":    true,
+	"UPDATE_REQUIRE_HTTPS": true,
+	"TLS_INSECURE":         true,
+	"TLS_CA":               true,
+	"COLLECTORS_DIR":       true,
+}
+
+// RenderedAgentConfig is the configuration an agent of an environment receives
+type RenderedAgentConfig struct {
+	EnvironmentID string            `json:"environment_id"`
+	Version       string            `json:"version"` // changes whenever a setting does
+	Settings      map[string]string `json:"settings"`
+}
+
+// validateAgentConfigBundle normalizes keys to upper case and rejects unknown keys and values
+// that would break the key=value file format
+func validateAgentConfigBundle(b *AgentConfigBundle) error {
+	settings := make(map[string]string, len(b.Settings))
+	for key, value := range b.Settings {
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !agentConfigBundleKeys[key] && !(strings.HasPrefix(key, "LABEL_") && len(key) > len("LABEL_")) {
+			return fmt.Errorf("unsupported agent setting %s", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of %s must be a single line", key)
+		}
+		if err := validateRedactionSetting(key, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
+		}
+		settings[key] = strings.TrimSpace(value)
+	}
+	b.Settings = settings
+	return nil
+}
+
+// checkEnvironmentAgentConfigBundle rejects what only the fleet-wide bundle may set: the
+// gateway's PSK, which the unauthenticated /api/agent-config hands to any enrollment token of the
+// environment, and the fleetAgentConfigKeys. Called after validateAgentConfigBundle.
+func checkEnvironmentAgentConfigBundle(b *AgentConfigBundle) error {
+	if b.IncludePSK != nil && *b.IncludePSK {
+		return fmt.Errorf("include_psk can only be set in the global agent config")
+	}
+	for _, key := range sortedKeys(b.Settings) {
+		if fleetAgentConfigKeys[key] {
+			return fmt.Errorf("%s can only be set in the global agent config", key)
+		}
+	}
+	return nil
+}
+
+// renderAgentConfig merges the fleet-wide and environment bundles, either of which may be nil.
+// Agents are labelled with their project and environment for auto-assignment unless a bundle
+// sets those labels, and receive psk when the global bundle includes it and the environment does
+// not opt out. Fleet-only settings of an environment bundle saved before they were refused are
+// ignored.
+func renderAgentConfig(global, env *AgentConfigBundle, environment *Environment, project *Project, psk string) RenderedAgentConfig {
+	settings := map[string]string{}
+	if project != nil {
+		settings["LABEL_project"] = project.Slug
+	}
+	if environment != nil {
+		settings["LABEL_environment"] = environment.Slug
+	}
+	includePSK := false
+	for _, b := range []*AgentConfigBundle{global, env} {
+		if b == nil {
+			continue
+		}
+		for key, value := range b.Settings {
+			if b == env && fleetAgentConfigKeys[key] {
+				continue
+			}
+			if strings.HasPrefix(key, "LABEL_") {
+				// Keys are stored upper case; label names are matched lower case by auto-assignment
+				key = "LABEL_" + strings.ToLower(strings.TrimPrefix(key, "LABEL_"))
+			}
+			settings[key] = value
+		}
+		if b.IncludePSK != nil && (b == global || !*b.IncludePSK) {
+			includePSK = *b.IncludePSK
+		}
+	}
+	if includePSK && psk != "" {
+		settings["PSK_KEY"] = psk
+	}
+
+	rendered := RenderedAgentConfig{Settings: settings, Version: agentConfigVersion(settings)}
+	if environment != nil {
+		rendered.EnvironmentID = environment.ID
+	}
+	return rendered
+}
+
+// agentConfigVersion hashes the settings so agents and caches can tell when they change
+func agentConfigVersion(settings map[string]string) string {
+	h := sha256.New()
+	for _, key := range sortedKeys(settings) {
+		fmt.Fprintf(h, "%s=%s\n", key, settings[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// formatAgentConfigFile renders settings in the avika-agent.conf format
+func formatAgentConfigFile(c RenderedAgentConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Rendered by the Avika gateway, version %s\n", c.Version)
+	for _, key := range sortedKeys(c.Settings) {
+		val := c.Settings[key]
+		if strings.ContainsAny(val, " \t\"") && !strings.Contains(val, "'") {
+			val = "'" + val + "'" // log_format strings keep their inner quotes
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, val)
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateAgentConfigBundle(t *testing.T) {
+	b := &AgentConfigBundle{Settings: map[string]string{
+		"gateways":      " gw-1:5020,gw-2:5020 ",
+		"LABEL_team":    "edge",
+		"LOG_LEVEL":     "debug",
+		"update_server": "http://updates:5021",
+	}}
+	if err := validateAgentConfigBundle(b); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if b.Settings["GATEWAYS"] != "gw-1:5020,gw-2:5020" || b.Settings["LABEL_TEAM"] != "edge" || b.Settings["UPDATE_SERVER"] == "" {
+		t.Errorf("settings not normalized: %v", b.Settings)
+	}
+
+	for _, settings := range []map[string]string{
+		{"AGENT_ID": "a"},
+		{"PSK_KEY": "secret"},
+		{"LABEL_": "x"},
+		{"LOG_LEVEL": "info\nGATEWAYS=evil:5020"},
+	} {
+		if err := validateAgentConfigBundle(&AgentConfigBundle{Settings: settings}); err == nil {
+			t.Errorf("%v should be rejected", settings)
+		}
+	}
+}
+
+func TestRenderAgentConfig(t *testing.T) {
+	yes, no := true, false
+	project := &Project{ID: "p1", Slug: "shop"}
+	env := &Environment{ID: "e1", ProjectID: "p1", Slug: "prod"}
+	global := &AgentConfigBundle{
+		Settings:   map[string]string{"GATEWAYS": "gw:5020", "LOG_LEVEL": "info", "LABEL_TEAM": "edge"},
+		IncludePSK: &yes,
+	}
+	envBundle := &AgentConfigBundle{
+		EnvironmentID: "e1",
+		Settings:      map[string]string{"LOG_LEVEL": "warn", "ACCESS_LOG_PATH": "/data/access.log"},
+	}
+
+	rendered := renderAgentConfig(global, envBundle, env, project, "s3cret")
+	want := map[string]string{
+		"GATEWAYS":          "gw:5020",
+		"LOG_LEVEL":         "warn",
+		"ACCESS_LOG_PATH":   "/data/access.log",
+		"LABEL_team":        "edge",
+		"LABEL_project":     "shop",
+		"LABEL_environment": "prod",
+		"PSK_KEY":           "s3cret",
+	}
+	if len(rendered.Settings) != len(want) {
+		t.Errorf("settings = %v", rendered.Settings)
+	}
+	for k, v := range want {
+		if rendered.Settings[k] != v {
+			t.Errorf("%s = %q, want %q", k, rendered.Settings[k], v)
+		}
+	}
+	if rendered.EnvironmentID != "e1" || len(rendered.Version) != 16 {
+		t.Errorf("rendered = %+v", rendered)
+	}
+
+	// The environment can opt out of the fleet-wide PSK
+	envBundle.IncludePSK = &no
+	withoutPSK := renderAgentConfig(global, envBundle, env, project, "s3cret")
+	if _, ok := withoutPSK.Settings["PSK_KEY"]; ok {
+		t.Error("PSK_KEY should not be served when the environment opts out")
+	}
+	if withoutPSK.Version == rendered.Version {
+		t.Error("version should change with the settings")
+	}
+	if again := renderAgentConfig(global, envBundle, env, project, "s3cret"); again.Version != withoutPSK.Version {
+		t.Error("version should be stable for the same settings")
+	}
+
+	// An environment bundle cannot opt in to the PSK or set fleet-only keys, even one stored
+	// before they were refused
+	envBundle.IncludePSK = &yes
+	envBundle.Settings["UPDATE_SERVER"] = "http://evil.example.com"
+	noFleetPSK := renderAgentConfig(&AgentConfigBundle{Settings: map[string]string{"UPDATE_SERVER": "http://updates:5021"}}, envBundle, env, project, "s3cret")
+	if _, ok := noFleetPSK.Settings["PSK_KEY"]; ok {
+		t.Error("PSK_KEY served on the environment's include_psk alone")
+	}
+	if noFleetPSK.Settings["UPDATE_SERVER"] != "http://updates:5021" {
+		t.Errorf("UPDATE_SERVER = %q, want the global one", noFleetPSK.Settings["UPDATE_SERVER"])
+	}
+
+	// No PSK is served while the gateway has none
+	if bare := renderAgentConfig(global, nil, env, project, ""); bare.Settings["PSK_KEY"] != "" {
+		t.Error("PSK_KEY served without a gateway key")
+	}
+}
+
+func TestSaveEnvironmentAgentConfigFleetOnly(t *testing.T) {
+	// Environment bundles are saved by project admins; the fleet-only settings are refused before
+	// anything is stored
+	srv := &server{}
+	for _, body := range []string{
+		`{"include_psk": true}`,
+		`{"settings": {"update_server": "http://evil.example.com"}}`,
+		`{"settings": {"UPDATE_PUBLIC_KEY": "ed25519:AAAA"}}`,
+		`{"settings": {"TLS_INSECURE": "true"}}`,
+		`{"settings": {"COLLECTORS_DIR": "/tmp/plugins"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/api/environments/e1/agent-config", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.saveAgentConfigBundle(w, req, "e1", "alice")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", body, w.Code)
+		}
+	}
+
+	ok := &AgentConfigBundle{Settings: map[string]string{"LOG_LEVEL": "warn"}}
+	if err := checkEnvironmentAgentConfigBundle(ok); err != nil {
+		t.Errorf("environment setting refused: %v", err)
+	}
+	no := false
+	if err := checkEnvironmentAgentConfigBundle(&AgentConfigBundle{IncludePSK: &no}); err != nil {
+		t.Errorf("opting out of the PSK refused: %v", err)
+	}
+}
+
+func TestFormatAgentConfigFile(t *testing.T) {
+	c := RenderedAgentConfig{Version: "abc", Settings: map[string]string{"LOG_LEVEL": "warn", "GATEWAYS": "gw:5020"}}
+	out := formatAgentConfigFile(c)
+	if !strings.HasPrefix(out, "# ") || !strings.Contains(out, "version abc") {
+		t.Errorf("missing header: %q", out)
+	}
+	if !strings.HasSuffix(out, "GATEWAYS=gw:5020\nLOG_LEVEL=warn\n") {
+		t.Errorf("settings not sorted: %q", out)
+	}
+}
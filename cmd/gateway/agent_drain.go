@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errGatewayDraining turns away agents connecting while the gateway shuts down
+var errGatewayDraining = status.Error(codes.Unavailable, "gateway is shutting down")
+
+// drainAgents sends a Drain command to the agents connected here so they move to another gateway,
+// then waits until their streams close or timeout passes. New streams are refused from now on.
+// It returns the number of agents still connected.
+func (s *server) drainAgents(timeout time.Duration) int {
+	s.draining.Store(true)
+	ids := s.connectedAgentIDs()
+	if len(ids) == 0 {
+		return 0
+	}
+
+	// Agents with other gateways stay away until this one is gone
+	retryAfter := timeout
+	if s.config != nil {
+		retryAfter += s.config.Security.ShutdownTimeout
+	}
+	cmd := &pb.ServerCommand{
+		CommandId: fmt.Sprintf("drain-%d", time.Now().UnixNano()),
+		Payload: &pb.ServerCommand_Drain{
+			Drain: &pb.Drain{Reason: "gateway shutting down", RetryAfterSeconds: int32(retryAfter.Seconds())},
+		},
+	}
+	for _, id := range ids {
+		val, ok := s.sessions.Load(id)
+		if !ok {
+			continue
+		}
+		session := val.(*AgentSession)
+		session.mu.Lock()
+		if session.stream != nil {
+			if err := session.stream.Send(cmd); err != nil {
+				gatewayLog.Warn().Err(err).Str("agent_id", id).Msg("Failed to send drain command")
+			}
+		}
+		session.mu.Unlock()
+	}
+	gatewayLog.Info().Int("agents", len(ids)).Dur("timeout", timeout).Msg("Draining agents")
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := 0
+		for _, id := range ids {
+			if s.connectedHere(id) {
+				remaining++
+			}
+		}
+		if remaining == 0 || !time.Now().Before(deadline) {
+			return remaining
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// drainingStream closes the agent's stream when it receives a Drain, as a current agent does
+type drainingStream struct {
+	pb.Commander_ConnectServer
+	session *AgentSession
+	ignore  bool
+	drains  int
+}
+
+func (d *drainingStream) Send(cmd *pb.ServerCommand) error {
+	if cmd.GetDrain() == nil {
+		return nil
+	}
+	d.drains++
+	if !d.ignore {
+		// Connect clears the stream once the agent closed it; the session is locked while sending
+		go func() {
+			d.session.mu.Lock()
+			d.session.stream = nil
+			d.session.status = "offline"
+			d.session.mu.Unlock()
+		}()
+	}
+	return nil
+}
+
+func TestDrainAgents(t *testing.T) {
+	s := &server{}
+	moving := &AgentSession{id: "moving", status: "online"}
+	movingStream := &drainingStream{session: moving}
+	moving.stream = movingStream
+	s.sessions.Store("moving", moving)
+	s.sessions.Store("down", &AgentSession{id: "down", status: "offline"})
+
+	if remaining := s.drainAgents(2 * time.Second); remaining != 0 {
+		t.Errorf("remaining = %d, want 0", remaining)
+	}
+	if movingStream.drains != 1 {
+		t.Errorf("drain commands sent = %d, want 1", movingStream.drains)
+	}
+	if err := s.Connect(nil); err != errGatewayDraining {
+		t.Errorf("Connect while draining = %v", err)
+	}
+
+	// Old agents ignore the command; the drain gives up after its timeout
+	s = &server{}
+	stuck := &AgentSession{id: "stuck", status: "online"}
+	stuck.stream = &drainingStream{session: stuck, ignore: true}
+	s.sessions.Store("stuck", stuck)
+	if remaining := s.drainAgents(50 * time.Millisecond); remaining != 1 {
+		t.Errorf("remaining = %d, want 1", remaining)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentSortKeys compares two agents by a ListAgentsRequest sort_by
+var agentSortKeys = map[string]func(a, b *pb.AgentInfo) int{
+	"hostname": func(a, b *pb.AgentInfo) int {
+		return strings.Compare(strings.ToLower(a.Hostname), strings.ToLower(b.Hostname))
+	},
+	"last_seen":     func(a, b *pb.AgentInfo) int { return compareInt64(a.LastSeen, b.LastSeen) },
+	"status":        func(a, b *pb.AgentInfo) int { return strings.Compare(a.Status, b.Status) },
+	"ip":            func(a, b *pb.AgentInfo) int { return strings.Compare(a.Ip, b.Ip) },
+	"version":       func(a, b *pb.AgentInfo) int { return strings.Compare(a.Version, b.Version) },
+	"agent_version": func(a, b *pb.AgentInfo) int { return strings.Compare(a.AgentVersion, b.AgentVersion) },
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// validateListAgentsRequest rejects sort keys and pages ListAgents cannot serve
+func validateListAgentsRequest(req *pb.ListAgentsRequest) error {
+	if req.SortBy != "" && agentSortKeys[req.SortBy] == nil {
+		return fmt.Errorf("invalid sort_by %q", req.SortBy)
+	}
+	if req.PageSize < 0 || req.Offset < 0 {
+		return fmt.Errorf("page_size and offset must not be negative")
+	}
+	return nil
+}
+
+// agentListNeedsAssignments reports whether the request filters on server assignments
+func agentListNeedsAssignments(req *pb.ListAgentsRequest) bool {
+	return req.EnvironmentId != "" || req.ProjectId != "" || len(req.Tags) > 0
+}
+
+// matchesAgentFilters reports whether an agent passes the filters of a ListAgentsRequest;
+// assignment is nil for an agent not assigned to an environment
+func matchesAgentFilters(info *pb.AgentInfo, req *pb.ListAgentsRequest, assignment *ServerAssignmentWithDetails) bool {
+	if req.Status != "" && !strings.EqualFold(info.Status, req.Status) {
+		return false
+	}
+	if search := strings.ToLower(strings.TrimSpace(req.Search)); search != "" {
+		found := false
+		for _, field := range []string{info.Hostname, info.Ip, info.AgentId, info.Version, info.AgentVersion} {
+			if strings.Contains(strings.ToLower(field), search) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !agentListNeedsAssignments(req) {
+		return true
+	}
+	if assignment == nil {
+		return false
+	}
+	if req.EnvironmentId != "" && assignment.EnvironmentID != req.EnvironmentId {
+		return false
+	}
+	if req.ProjectId != "" && assignment.ProjectID != req.ProjectId {
+		return false
+	}
+	for _, tag := range req.Tags {
+		if !slices.Contains(assignment.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryAgentList filters, sorts and pages agents as asked by req. It returns the page and the
+// number of agents matching the filters.
+func queryAgentList(agents []*pb.AgentInfo, req *pb.ListAgentsRequest, assignments map[string]*ServerAssignmentWithDetails) ([]*pb.AgentInfo, int) {
+	matched := make([]*pb.AgentInfo, 0, len(agents))
+	for _, info := range agents {
+		if matchesAgentFilters(info, req, assignments[info.AgentId]) {
+			matched = append(matched, info)
+		}
+	}
+
+	// Ties keep a stable order by agent ID so pages do not overlap
+	compare := agentSortKeys[req.SortBy]
+	if compare == nil {
+		compare = agentSortKeys["hostname"]
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		c := compare(matched[i], matched[j])
+		if req.Descending {
+			c = -c
+		}
+		if c == 0 {
+			return matched[i].AgentId < matched[j].AgentId
+		}
+		return c < 0
+	})
+
+	total := len(matched)
+	start, end := min(int(req.Offset), total), total
+	if req.PageSize > 0 {
+		end = min(start+int(req.PageSize), total)
+	}
+	return matched[start:end], total
+}
+
+// listAgentsRequestFromQuery reads the REST mirror of ListAgentsRequest: page_size, offset,
+// search, status, environment_id, project_id, tag (repeated or comma separated), sort_by and
+// order=desc
+func listAgentsRequestFromQuery(query url.Values) (*pb.ListAgentsRequest, error) {
+	req := &pb.ListAgentsRequest{
+		Search:        query.Get("search"),
+		Status:        query.Get("status"),
+		EnvironmentId: query.Get("environment_id"),
+		ProjectId:     query.Get("project_id"),
+		SortBy:        query.Get("sort_by"),
+		Descending:    strings.EqualFold(query.Get("order"), "desc"),
+	}
+	for name, dst := range map[string]*int32{"page_size": &req.PageSize, "offset": &req.Offset} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", name, v)
+			}
+			*dst = int32(n)
+		}
+	}
+	for _, v := range query["tag"] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+	}
+	if err := validateListAgentsRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func agentIDs(agents []*pb.AgentInfo) string {
+	ids := make([]string, len(agents))
+	for i, a := range agents {
+		ids[i] = a.AgentId
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestQueryAgentList(t *testing.T) {
+	agents := []*pb.AgentInfo{
+		{AgentId: "c", Hostname: "web-2", Ip: "10.0.0.3", Status: "online", LastSeen: 30, Version: "1.25.3"},
+		{AgentId: "a", Hostname: "Web-1", Ip: "10.0.0.1", Status: "offline", LastSeen: 10, Version: "1.24.0"},
+		{AgentId: "b", Hostname: "db-1", Ip: "10.0.1.7", Status: "online", LastSeen: 20, Version: "1.25.3"},
+		{AgentId: "d", Hostname: "web-2", Ip: "10.0.0.4", Status: "online", LastSeen: 40, Version: "1.25.3"},
+	}
+	assignments := map[string]*ServerAssignmentWithDetails{
+		"a": {AgentID: "a", EnvironmentID: "prod", ProjectID: "shop", Tags: []string{"edge", "eu"}},
+		"c": {AgentID: "c", EnvironmentID: "prod", ProjectID: "shop", Tags: []string{"edge"}},
+		"d": {AgentID: "d", EnvironmentID: "dev", ProjectID: "shop"},
+	}
+
+	tests := []struct {
+		name  string
+		req   *pb.ListAgentsRequest
+		want  string
+		total int
+	}{
+		{"all by hostname, ties by ID", &pb.ListAgentsRequest{}, "b,a,c,d", 4},
+		{"search is case-insensitive", &pb.ListAgentsRequest{Search: "WEB"}, "a,c,d", 3},
+		{"search by IP", &pb.ListAgentsRequest{Search: "10.0.1."}, "b", 1},
+		{"status", &pb.ListAgentsRequest{Status: "online", SortBy: "last_seen", Descending: true}, "d,c,b", 3},
+		{"environment", &pb.ListAgentsRequest{EnvironmentId: "prod"}, "a,c", 2},
+		{"project and tags", &pb.ListAgentsRequest{ProjectId: "shop", Tags: []string{"edge", "eu"}}, "a", 1},
+		{"page", &pb.ListAgentsRequest{PageSize: 2, Offset: 1}, "a,c", 4},
+		{"page past the end", &pb.ListAgentsRequest{PageSize: 2, Offset: 9}, "", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total := queryAgentList(append([]*pb.AgentInfo(nil), agents...), tt.req, assignments)
+			if got := agentIDs(page); got != tt.want || total != tt.total {
+				t.Errorf("got %q (total %d), want %q (total %d)", got, total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestListAgentsRequestFromQuery(t *testing.T) {
+	query, _ := url.ParseQuery("page_size=50&offset=100&search=web&tag=edge,eu&tag=prod&sort_by=last_seen&order=desc")
+	req, err := listAgentsRequestFromQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.PageSize != 50 || req.Offset != 100 || req.Search != "web" || req.SortBy != "last_seen" || !req.Descending ||
+		strings.Join(req.Tags, ",") != "edge,eu,prod" {
+		t.Errorf("unexpected request %v", req)
+	}
+
+	for _, raw := range []string{"sort_by=cpu", "page_size=ten", "offset=-1"} {
+		query, _ := url.ParseQuery(raw)
+		if _, err := listAgentsRequestFromQuery(query); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
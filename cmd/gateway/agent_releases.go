@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Release channels agents follow; agents outside a configured environment follow stable
+const (
+	releaseChannelStable = "stable"
+	releaseChannelBeta   = "beta"
+)
+
+var releaseChannels = []string{releaseChannelStable, releaseChannelBeta}
+
+// releaseVersionPattern keeps release versions usable as a directory name under releases/
+var releaseVersionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+_-]*$`)
+
+// ReleaseDecision is the agent version the release policy picks for an agent, and why
+type ReleaseDecision struct {
+	AgentID       string `json:"agent_id"`
+	Version       string `json:"version"`
+	Channel       string `json:"channel,omitempty"`
+	EnvironmentID string `json:"environment_id,omitempty"`
+	Reason        string `json:"reason"` // pinned, rollout, channel or served
+}
+
+// releasePolicy is what release decisions are made from: the version served by default, the
+// channels and the environment settings by environment ID
+type releasePolicy struct {
+	Served       string
+	Channels     map[string]ReleaseChannel
+	Environments map[string]EnvironmentRelease
+}
+
+// resolve picks the version of an agent: its environment's pinned version, else the version of
+// its environment's channel (stable by default), where agents in the staged rollout share get
+// the rollout version. Channels without a version fall back to the served version.
+func (p releasePolicy) resolve(agentID, environmentID string) ReleaseDecision {
+	d := ReleaseDecision{AgentID: agentID, EnvironmentID: environmentID, Channel: releaseChannelStable}
+	if env, ok := p.Environments[environmentID]; ok && environmentID != "" {
+		if env.PinnedVersion != "" {
+			d.Channel, d.Version, d.Reason = "", env.PinnedVersion, "pinned"
+			return d
+		}
+		if env.Channel != "" {
+			d.Channel = env.Channel
+		}
+	}
+	ch, ok := p.Channels[d.Channel]
+	if !ok || ch.Version == "" {
+		d.Version, d.Reason = p.Served, "served"
+		return d
+	}
+	if ch.RolloutVersion != "" && rolloutBucket(agentID, ch.RolloutVersion) < ch.RolloutPercent {
+		d.Version, d.Reason = ch.RolloutVersion, "rollout"
+		return d
+	}
+	d.Version, d.Reason = ch.Version, "channel"
+	return d
+}
+
+// rolloutBucket places an agent in 0-99 for a rollout version. Raising the percentage keeps the
+// agents already in the rollout, and each version draws a different share of the fleet.
+func rolloutBucket(agentID, version string) int {
+	h := fnv.New32a()
+	h.Write([]byte(agentID + "@" + version))
+	return int(h.Sum32() % 100)
+}
+
+// releaseManifestPath returns the version.json of a release the gateway can serve: the served
+// version at the root of the updates directory, others under releases/<version>/
+func releaseManifestPath(dir, version string) (string, bool) {
+	if !releaseVersionPattern.MatchString(version) {
+		return "", false
+	}
+	if served, err := servedAgentVersion(dir); err == nil && served == version {
+		return filepath.Join(dir, "version.json"), true
+	}
+	releaseDir := filepath.Join(dir, "releases", version)
+	if v, err := servedAgentVersion(releaseDir); err != nil || v != version {
+		return "", false
+	}
+	return filepath.Join(releaseDir, "version.json"), true
+}
+
+// listAgentReleases returns the versions the gateway can serve, sorted
+func listAgentReleases(dir string) []string {
+	var versions []string
+	if served, err := servedAgentVersion(dir); err == nil && served != "" {
+		versions = append(versions, served)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "releases"))
+	for _, e := range entries {
+		if !e.IsDir() || slices.Contains(versions, e.Name()) {
+			continue
+		}
+		if _, ok := releaseManifestPath(dir, e.Name()); ok {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// validateReleaseChannel checks a channel update against the releases the gateway serves
+func validateReleaseChannel(c *ReleaseChannel, available []string) error {
+	if !slices.Contains(releaseChannels, c.Name) {
+		return status.Errorf(codes.InvalidArgument, "unknown channel %q (expected one of %s)", c.Name, strings.Join(releaseChannels, ", "))
+	}
+	if !slices.Contains(available, c.Version) {
+		return status.Errorf(codes.FailedPrecondition, "version %q is not served by the gateway", c.Version)
+	}
+	if c.RolloutPercent < 0 || c.RolloutPercent > 100 {
+		return status.Error(codes.InvalidArgument, "rollout_percent must be within 0-100")
+	}
+	if c.RolloutVersion == "" {
+		c.RolloutPercent = 0
+		return nil
+	}
+	if !slices.Contains(available, c.RolloutVersion) {
+		return status.Errorf(codes.FailedPrecondition, "rollout version %q is not served by the gateway", c.RolloutVersion)
+	}
+	return nil
+}
+
+// validateEnvironmentRelease checks an environment's channel or pinned version
+func validateEnvironmentRelease(e *EnvironmentRelease, available []string) error {
+	if e.Channel == "" {
+		e.Channel = releaseChannelStable
+	}
+	if !slices.Contains(releaseChannels, e.Channel) {
+		return status.Errorf(codes.InvalidArgument, "unknown channel %q (expected one of %s)", e.Channel, strings.Join(releaseChannels, ", "))
+	}
+	if e.PinnedVersion != "" && !slices.Contains(available, e.PinnedVersion) {
+		return status.Errorf(codes.FailedPrecondition, "pinned version %q is not served by the gateway", e.PinnedVersion)
+	}
+	return nil
+}
+
+// loadReleasePolicy reads the channels and environment settings
+func (s *server) loadReleasePolicy() (releasePolicy, error) {
+	policy := releasePolicy{Channels: map[string]ReleaseChannel{}, Environments: map[string]EnvironmentRelease{}}
+	policy.Served, _ = servedAgentVersion(s.updatesDir())
+	if s.db == nil {
+		return policy, nil
+	}
+	channels, err := s.db.ListReleaseChannels()
+	if err != nil {
+		return policy, err
+	}
+	for _, c := range channels {
+		policy.Channels[c.Name] = c
+	}
+	envs, err := s.db.ListEnvironmentReleases()
+	if err != nil {
+		return policy, err
+	}
+	for _, e := range envs {
+		policy.Environments[e.EnvironmentID] = e
+	}
+	return policy, nil
+}
+
+// resolveAgentRelease decides which version an agent should receive. A decided version the
+// gateway no longer serves falls back to the served one.
+func (s *server) resolveAgentRelease(agentID string) (ReleaseDecision, error) {
+	policy, err := s.loadReleasePolicy()
+	if err != nil {
+		return ReleaseDecision{}, err
+	}
+	environmentID := ""
+	if s.db != nil {
+		if sa, err := s.db.GetServerAssignment(agentID); err == nil && sa != nil {
+			environmentID = sa.EnvironmentID
+		}
+	}
+	d := policy.resolve(agentID, environmentID)
+	if _, ok := releaseManifestPath(s.updatesDir(), d.Version); !ok && d.Version != policy.Served {
+		jobsLog.Warn().Msgf("Release %s picked for agent %s (%s) is not served; falling back to %s", d.Version, agentID, d.Reason, policy.Served)
+		d.Version, d.Reason = policy.Served, "served"
+	}
+	return d, nil
+}
+
+// releaseManifestHandler serves version.json per agent: the release asked for with ?version=,
+// else the one the release policy picks for ?agent_id=. Other requests go to next.
+func (s *server) releaseManifestHandler(dir string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/updates/") != "version.json" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		query := r.URL.Query()
+		version := query.Get("version")
+		if agentID := query.Get("agent_id"); version == "" && agentID != "" {
+			if resolved, ok := s.resolveAgentID(agentID); ok {
+				agentID = resolved
+			}
+			d, err := s.resolveAgentRelease(agentID)
+			if err != nil {
+				jobsLog.Warn().Msgf("Failed to resolve the release of agent %s: %v", agentID, err)
+			}
+			version = d.Version
+		}
+		path, ok := releaseManifestPath(dir, version)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, path)
+	})
+}
+
+// releaseNotServedError is returned for versions the gateway has no release of
+func releaseNotServedError(version string, available []string) error {
+	return status.Error(codes.FailedPrecondition, fmt.Sprintf("version %s is not served by the gateway (available: %s)", version, strings.Join(available, ", ")))
+}
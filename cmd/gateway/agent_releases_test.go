@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func writeRelease(t *testing.T, dir, version string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "version.json"), []byte(`{"version":"`+version+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReleasePolicyResolve(t *testing.T) {
+	policy := releasePolicy{
+		Served: "1.0.0",
+		Channels: map[string]ReleaseChannel{
+			"stable": {Name: "stable", Version: "1.1.0"},
+			"beta":   {Name: "beta", Version: "1.2.0", RolloutVersion: "1.3.0", RolloutPercent: 100},
+		},
+		Environments: map[string]EnvironmentRelease{
+			"prod":    {EnvironmentID: "prod", Channel: "stable", PinnedVersion: "1.0.5"},
+			"staging": {EnvironmentID: "staging", Channel: "beta"},
+		},
+	}
+	for _, c := range []struct {
+		env, version, reason string
+	}{
+		{"", "1.1.0", "channel"},
+		{"dev", "1.1.0", "channel"},
+		{"prod", "1.0.5", "pinned"},
+		{"staging", "1.3.0", "rollout"},
+	} {
+		d := policy.resolve("agent-1", c.env)
+		if d.Version != c.version || d.Reason != c.reason {
+			t.Errorf("environment %q: got %s (%s), want %s (%s)", c.env, d.Version, d.Reason, c.version, c.reason)
+		}
+	}
+
+	policy.Channels = nil
+	if d := policy.resolve("agent-1", ""); d.Version != "1.0.0" || d.Reason != "served" {
+		t.Errorf("without channels: %+v", d)
+	}
+}
+
+func TestRolloutBucketShare(t *testing.T) {
+	policy := releasePolicy{Channels: map[string]ReleaseChannel{
+		"stable": {Name: "stable", Version: "1.0.0", RolloutVersion: "1.1.0", RolloutPercent: 20},
+	}}
+	inRollout := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("agent-%d", i)
+		if policy.resolve(id, "").Version == "1.1.0" {
+			inRollout[id] = true
+		}
+	}
+	if n := len(inRollout); n < 120 || n > 280 {
+		t.Errorf("%d of 1000 agents in a 20%% rollout", n)
+	}
+
+	// Raising the percentage keeps every agent already in the rollout
+	ch := policy.Channels["stable"]
+	ch.RolloutPercent = 50
+	policy.Channels["stable"] = ch
+	for id := range inRollout {
+		if policy.resolve(id, "").Version != "1.1.0" {
+			t.Fatalf("agent %s left the rollout when it grew", id)
+		}
+	}
+}
+
+func TestListAgentReleases(t *testing.T) {
+	dir := t.TempDir()
+	writeRelease(t, dir, "1.2.0")
+	writeRelease(t, filepath.Join(dir, "releases", "1.1.0"), "1.1.0")
+	writeRelease(t, filepath.Join(dir, "releases", "1.2.0"), "1.2.0")
+	writeRelease(t, filepath.Join(dir, "releases", "bogus"), "1.0.0")
+
+	if got := strings.Join(listAgentReleases(dir), ","); got != "1.1.0,1.2.0" {
+		t.Errorf("releases = %s", got)
+	}
+	if path, ok := releaseManifestPath(dir, "1.2.0"); !ok || path != filepath.Join(dir, "version.json") {
+		t.Errorf("served release path = %s, %v", path, ok)
+	}
+	for _, v := range []string{"bogus", "../1.1.0", "", "9.9.9"} {
+		if _, ok := releaseManifestPath(dir, v); ok {
+			t.Errorf("release %q reported as served", v)
+		}
+	}
+}
+
+func TestValidateReleaseChannel(t *testing.T) {
+	available := []string{"1.1.0", "1.2.0"}
+	for name, c := range map[string]ReleaseChannel{
+		"unknown channel":     {Name: "nightly", Version: "1.1.0"},
+		"unserved version":    {Name: "stable", Version: "0.9.0"},
+		"unserved rollout":    {Name: "stable", Version: "1.1.0", RolloutVersion: "2.0.0", RolloutPercent: 10},
+		"percent over 100":    {Name: "stable", Version: "1.1.0", RolloutVersion: "1.2.0", RolloutPercent: 101},
+		"negative percentage": {Name: "beta", Version: "1.1.0", RolloutVersion: "1.2.0", RolloutPercent: -1},
+	} {
+		if err := validateReleaseChannel(&c, available); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	c := ReleaseChannel{Name: "beta", Version: "1.1.0", RolloutPercent: 30}
+	if err := validateReleaseChannel(&c, available); err != nil || c.RolloutPercent != 0 {
+		t.Errorf("channel without rollout: %v, percent %d", err, c.RolloutPercent)
+	}
+
+	e := EnvironmentRelease{EnvironmentID: "prod", PinnedVersion: "1.2.0"}
+	if err := validateEnvironmentRelease(&e, available); err != nil || e.Channel != "stable" {
+		t.Errorf("pinned environment: %v, channel %q", err, e.Channel)
+	}
+	if err := validateEnvironmentRelease(&EnvironmentRelease{PinnedVersion: "3.0.0"}, available); err == nil {
+		t.Error("unserved pinned version accepted")
+	}
+}
+
+func TestReleaseManifestHandler(t *testing.T) {
+	dir := t.TempDir()
+	writeRelease(t, dir, "1.2.0")
+	writeRelease(t, filepath.Join(dir, "releases", "1.1.0"), "1.1.0")
+	s := &server{config: &config.Config{Server: config.ServerConfig{UpdatesDir: dir}}}
+	h := s.releaseManifestHandler(dir, updatesHandlerForDir(dir))
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", target, rec.Code)
+		}
+		var m struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		return m.Version
+	}
+	if v := get("/updates/version.json"); v != "1.2.0" {
+		t.Errorf("default manifest = %s", v)
+	}
+	if v := get("/updates/version.json?version=1.1.0"); v != "1.1.0" {
+		t.Errorf("requested release = %s", v)
+	}
+	if v := get("/updates/version.json?version=9.9.9"); v != "1.2.0" {
+		t.Errorf("unknown release = %s, want the served one", v)
+	}
+	// Without channels configured an agent gets the served version
+	if v := get("/updates/version.json?agent_id=agent-1"); v != "1.2.0" {
+		t.Errorf("agent manifest = %s", v)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PruneCandidate is an offline agent that is, or will soon be, deregistered
+type PruneCandidate struct {
+	AgentID          string    `json:"agent_id"`
+	Hostname         string    `json:"hostname"`
+	IP               string    `json:"ip,omitempty"`
+	EnvironmentID    string    `json:"environment_id,omitempty"`
+	EnvironmentName  string    `json:"environment_name,omitempty"`
+	LastSeen         time.Time `json:"last_seen"`
+	PruneAfterSec    int64     `json:"prune_after_sec"`
+	ArchiveAnalytics bool      `json:"archive_analytics"`
+	PruneAt          time.Time `json:"prune_at"`
+	Due              bool      `json:"due"` // pruned on the next run
+}
+
+// evaluateAgentRetention returns the agents whose policy prunes them before now+lookahead, soonest first
+func evaluateAgentRetention(agents []OfflineAgent, defaults AgentRetentionPolicy, now time.Time, lookahead time.Duration) []PruneCandidate {
+	var candidates []PruneCandidate
+	for _, a := range agents {
+		c := newPruneCandidate(a, defaults)
+		if c.PruneAfterSec <= 0 {
+			continue
+		}
+		c.PruneAt = a.LastSeen.Add(time.Duration(c.PruneAfterSec) * time.Second)
+		if c.PruneAt.After(now.Add(lookahead)) {
+			continue
+		}
+		c.Due = !c.PruneAt.After(now)
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].PruneAt.Before(candidates[j].PruneAt) })
+	return candidates
+}
+
+// newPruneCandidate describes an offline agent under its environment's policy, or the defaults
+func newPruneCandidate(a OfflineAgent, defaults AgentRetentionPolicy) PruneCandidate {
+	policy := defaults
+	if a.Policy != nil {
+		policy = *a.Policy
+	}
+	return PruneCandidate{
+		AgentID:          a.AgentID,
+		Hostname:         a.Hostname,
+		IP:               a.IP,
+		EnvironmentID:    a.EnvironmentID,
+		EnvironmentName:  a.EnvironmentName,
+		LastSeen:         a.LastSeen,
+		PruneAfterSec:    policy.PruneAfterSec,
+		ArchiveAnalytics: policy.ArchiveAnalytics,
+	}
+}
+
+// agentRetentionDefaults is the policy of agents whose environment has none
+func (s *server) agentRetentionDefaults() AgentRetentionPolicy {
+	defaults := AgentRetentionPolicy{PruneAfterSec: int64((10 * 24 * time.Hour).Seconds()), Inherited: true}
+	if s.config != nil {
+		defaults.PruneAfterSec = int64(s.config.Agent.RetentionPeriod.Seconds())
+		defaults.ArchiveAnalytics = s.config.Agent.ArchiveOnPrune
+	}
+	return defaults
+}
+
+// pruneStaleAgents deregisters agents offline past their retention and deletes their analytics
+// unless the policy archives them
+func (s *server) pruneStaleAgents() {
+	pruned, err := s.db.PruneStaleAgents(s.agentRetentionDefaults())
+	if err != nil {
+		jobsLog.Error().Msgf("Failed to prune stale agents: %v", err)
+	}
+	for _, c := range pruned {
+		s.finishAgentPrune(c, fmt.Sprintf("offline %s", time.Since(c.LastSeen).Round(time.Hour)))
+	}
+}
+
+// finishAgentPrune drops the session of an agent removed from the database and deletes its
+// analytics unless the policy archives them
+func (s *server) finishAgentPrune(c PruneCandidate, reason string) {
+	// Keep the in-memory session if the agent reconnected meanwhile
+	if val, ok := s.sessions.Load(c.AgentID); ok {
+		session := val.(*AgentSession)
+		session.mu.Lock()
+		offline := session.status != "online"
+		session.mu.Unlock()
+		if offline {
+			s.sessions.Delete(c.AgentID)
+		}
+	}
+
+	if c.ArchiveAnalytics || s.clickhouse == nil {
+		jobsLog.Info().Msgf("Pruned agent %s (%s, %s), analytics kept", c.AgentID, c.Hostname, reason)
+		return
+	}
+	if err := s.clickhouse.DeleteAgentData(c.AgentID); err != nil {
+		jobsLog.Error().Msgf("Failed to cleanup ClickHouse data for pruned agent %s: %v", c.AgentID, err)
+	} else {
+		jobsLog.Info().Msgf("Pruned agent %s (%s, %s) and its analytics", c.AgentID, c.Hostname, reason)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateAgentRetention(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := int64((24 * time.Hour).Seconds())
+	defaults := AgentRetentionPolicy{PruneAfterSec: 10 * day, Inherited: true}
+	agents := []OfflineAgent{
+		{AgentID: "vm-old", LastSeen: now.Add(-11 * 24 * time.Hour)},
+		{AgentID: "vm-new", LastSeen: now.Add(-2 * 24 * time.Hour)},
+		{AgentID: "pod-old", LastSeen: now.Add(-2 * time.Hour), Policy: &AgentRetentionPolicy{PruneAfterSec: 3600, ArchiveAnalytics: true}},
+		{AgentID: "pod-soon", LastSeen: now.Add(-30 * time.Minute), Policy: &AgentRetentionPolicy{PruneAfterSec: 3600}},
+		{AgentID: "pinned", LastSeen: now.Add(-400 * 24 * time.Hour), Policy: &AgentRetentionPolicy{PruneAfterSec: 0}},
+	}
+
+	due := evaluateAgentRetention(agents, defaults, now, 0)
+	if len(due) != 2 || due[0].AgentID != "vm-old" || due[1].AgentID != "pod-old" {
+		t.Fatalf("due = %+v", due)
+	}
+	if !due[1].ArchiveAnalytics || due[0].ArchiveAnalytics {
+		t.Error("archive flag should follow the environment policy")
+	}
+	for _, c := range due {
+		if !c.Due {
+			t.Errorf("%s should be due", c.AgentID)
+		}
+	}
+
+	preview := evaluateAgentRetention(agents, defaults, now, time.Hour)
+	if len(preview) != 3 || preview[2].AgentID != "pod-soon" || preview[2].Due {
+		t.Errorf("preview = %+v", preview)
+	}
+	if want := now.Add(30 * time.Minute); !preview[2].PruneAt.Equal(want) {
+		t.Errorf("prune_at = %s, want %s", preview[2].PruneAt, want)
+	}
+}
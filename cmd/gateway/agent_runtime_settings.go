@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentCommandTimeout bounds how long an HTTP request waits for an agent to ack a command
+const agentCommandTimeout = 15 * time.Second
+
+var (
+	errAgentOffline     = errors.New("agent is offline or has no active stream")
+	errAgentNoAck       = errors.New("agent did not acknowledge the command; it may be too old to support it")
+	errAgentCommandSent = errors.New("failed to send command to agent")
+)
+
+// sendAgentCommand sends a command over the agent's stream and waits for its CommandResult
+func (s *server) sendAgentCommand(agentID string, cmd *pb.ServerCommand, timeout time.Duration) (*pb.CommandResult, error) {
+	if address, ok := s.peerGatewayOf(agentID); ok {
+		return s.forwardAgentCommand(address, agentID, cmd, timeout)
+	}
+	val, ok := s.sessions.Load(agentID)
+	if !ok {
+		return nil, errAgentOffline
+	}
+	session := val.(*AgentSession)
+
+	ch := make(chan *pb.CommandResult, 1)
+	s.commandResults.Store(cmd.CommandId, ch)
+	defer s.commandResults.Delete(cmd.CommandId)
+
+	session.mu.Lock()
+	if session.status != "online" || session.stream == nil {
+		session.mu.Unlock()
+		return nil, errAgentOffline
+	}
+	err := session.stream.Send(cmd)
+	session.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errAgentCommandSent, err)
+	}
+
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-time.After(timeout):
+		return nil, errAgentNoAck
+	}
+}
+
+// deliverCommandResult hands an agent's ack to the request waiting for it, if any
+func (s *server) deliverCommandResult(res *pb.CommandResult) {
+	if res == nil {
+		return
+	}
+	if val, ok := s.commandResults.LoadAndDelete(res.CommandId); ok {
+		val.(chan *pb.CommandResult) <- res
+	}
+}
+
+// configureAgent sends a ConfigureAgent command; empty settings just read the current values
+func (s *server) configureAgent(agentID string, settings map[string]string) (*pb.CommandResult, error) {
+	return s.sendAgentCommand(agentID, &pb.ServerCommand{
+		CommandId: fmt.Sprintf("cfg-%d", time.Now().UnixNano()),
+		Payload: &pb.ServerCommand_ConfigureAgent{
+			ConfigureAgent: &pb.ConfigureAgent{Settings: settings},
+		},
+	}, agentCommandTimeout)
+}
+
+func writeAgentCommandError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway
+	switch {
+	case errors.Is(err, errAgentOffline):
+		code = http.StatusConflict
+	case errors.Is(err, errAgentNoAck):
+		code = http.StatusGatewayTimeout
+	}
+	http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), code)
+}
+
+// handleGetAgentRuntimeSettings handles GET /api/servers/{agentId}/runtime-settings: the
+// settings the running agent reports
+func (s *server) handleGetAgentRuntimeSettings(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := s.resolveAccessibleAgent(w, r)
+	if !ok {
+		return
+	}
+	res, err := s.configureAgent(agentID, nil)
+	if err != nil {
+		writeAgentCommandError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agentID,
+		"settings": res.AppliedSettings,
+	})
+}
+
+// handleUpdateAgentRuntimeSettings handles PUT /api/servers/{agentId}/runtime-settings with
+// {"settings": {"LOG_LEVEL": "debug", ...}}. The agent applies them without a restart, saves
+// them to avika-agent.conf and acks with the values in effect.
+func (s *server) handleUpdateAgentRuntimeSettings(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := s.resolveAccessibleAgent(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Settings map[string]string `json:"settings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Settings) == 0 {
+		http.Error(w, `{"error":"settings are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.configureAgent(agentID, req.Settings)
+	if err != nil {
+		writeAgentCommandError(w, err)
+		return
+	}
+	if s.db != nil {
+		username := ""
+		if user := middleware.GetUserFromContext(r.Context()); user != nil {
+			username = user.Username
+		}
+		_ = s.db.CreateAuditLog(username, "configure", "agent", agentID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+			"settings": req.Settings,
+			"success":  res.Success,
+			"error":    res.ErrorMessage,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Success {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id": agentID,
+		"success":  res.Success,
+		"error":    res.ErrorMessage,
+		"settings": res.AppliedSettings,
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// ackingStream answers every ConfigureAgent with the settings it was sent
+type ackingStream struct {
+	pb.Commander_ConnectServer
+	s      *server
+	silent bool
+}
+
+func (a *ackingStream) Send(cmd *pb.ServerCommand) error {
+	if a.silent {
+		return nil
+	}
+	settings := cmd.GetConfigureAgent().GetSettings()
+	go a.s.deliverCommandResult(&pb.CommandResult{CommandId: cmd.CommandId, Success: true, AppliedSettings: settings})
+	return nil
+}
+
+func TestSendAgentCommand(t *testing.T) {
+	s := &server{}
+	s.sessions.Store("a1", &AgentSession{id: "a1", status: "online", stream: &ackingStream{s: s}})
+	s.sessions.Store("quiet", &AgentSession{id: "quiet", status: "online", stream: &ackingStream{s: s, silent: true}})
+	s.sessions.Store("down", &AgentSession{id: "down", status: "offline"})
+
+	res, err := s.configureAgent("a1", map[string]string{"LOG_LEVEL": "debug"})
+	if err != nil || !res.Success || res.AppliedSettings["LOG_LEVEL"] != "debug" {
+		t.Fatalf("configureAgent = %+v, %v", res, err)
+	}
+
+	if _, err := s.configureAgent("down", nil); !errors.Is(err, errAgentOffline) {
+		t.Errorf("offline agent: err = %v", err)
+	}
+	if _, err := s.configureAgent("missing", nil); !errors.Is(err, errAgentOffline) {
+		t.Errorf("unknown agent: err = %v", err)
+	}
+
+	cmd := &pb.ServerCommand{CommandId: "cfg-quiet", Payload: &pb.ServerCommand_ConfigureAgent{ConfigureAgent: &pb.ConfigureAgent{}}}
+	if _, err := s.sendAgentCommand("quiet", cmd, 10*time.Millisecond); !errors.Is(err, errAgentNoAck) {
+		t.Errorf("silent agent: err = %v", err)
+	}
+	if _, pending := s.commandResults.Load("cfg-quiet"); pending {
+		t.Error("timed out command should no longer wait for a result")
+	}
+	// A late ack is dropped without blocking
+	s.deliverCommandResult(&pb.CommandResult{CommandId: "cfg-quiet"})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentUpdateRejectedMetric is the alert rule metric type whose recipients are notified when an
+// agent refuses an update package that failed verification (checksum, signature or HTTPS policy)
+const agentUpdateRejectedMetric = "agent_update_rejected"
+
+// updateRejectionRealert is how long the same rejection on an agent is not alerted again; agents
+// retry on every update check and campaign batch
+const updateRejectionRealert = time.Hour
+
+type updateRejectionKey struct {
+	agentID string
+	version string
+	reason  string
+}
+
+// handleUpdateRejected records an update an agent refused and notifies agent_update_rejected rules
+func (s *server) handleUpdateRejected(session *AgentSession, r *pb.UpdateRejected) {
+	session.mu.Lock()
+	agentID, hostname := session.id, session.hostname
+	session.mu.Unlock()
+
+	jobsLog.Warn().Msgf("🛑 Agent %s (%s) rejected update %s for %s: %s (%s)", hostname, agentID, r.Version, r.Platform, r.Reason, r.Url)
+
+	now := time.Now()
+	key := updateRejectionKey{agentID: agentID, version: r.Version, reason: r.Reason}
+	if last, ok := s.updateRejections.Load(key); ok && now.Sub(last.(time.Time)) < updateRejectionRealert {
+		return
+	}
+	s.updateRejections.Store(key, now)
+
+	if s.db == nil || s.alerts == nil {
+		return
+	}
+	rules, err := s.db.ListAlertRules()
+	if err != nil {
+		jobsLog.Error().Msgf("Failed to list alert rules for rejected update: %v", err)
+		return
+	}
+	version := r.Version
+	if version == "" {
+		version = "(unknown version)"
+	}
+	subject := fmt.Sprintf("[CRITICAL] Agent %s rejected update %s", hostname, version)
+	body := fmt.Sprintf("Agent %s (%s) refused to install update %s for %s.\n\nReason: %s\nURL: %s\nTime: %s\n\n"+
+		"The update package failed verification and may have been tampered with.",
+		hostname, agentID, version, r.Platform, r.Reason, r.Url, now.UTC().Format(time.RFC3339))
+	for _, rule := range rules {
+		if rule.Enabled && rule.MetricType == agentUpdateRejectedMetric && rule.Recipients != "" {
+			s.alerts.notifyRecipients(rule.Recipients, "critical", subject, body)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestHandleUpdateRejectedRealert(t *testing.T) {
+	s := &server{}
+	session := &AgentSession{id: "a1", hostname: "web-1"}
+	r := &pb.UpdateRejected{Version: "1.2.0", Platform: "linux-amd64", Reason: "signature mismatch"}
+	key := updateRejectionKey{agentID: "a1", version: "1.2.0", reason: "signature mismatch"}
+
+	s.handleUpdateRejected(session, r)
+	first, ok := s.updateRejections.Load(key)
+	if !ok {
+		t.Fatal("rejection not recorded")
+	}
+
+	// A repeat within the realert window keeps the first alert time
+	s.handleUpdateRejected(session, r)
+	if again, _ := s.updateRejections.Load(key); again != first {
+		t.Error("repeated rejection alerted again")
+	}
+
+	// Once the window passed the rejection alerts again
+	s.updateRejections.Store(key, time.Now().Add(-2*updateRejectionRealert))
+	s.handleUpdateRejected(session, r)
+	if again, _ := s.updateRejections.Load(key); time.Since(again.(time.Time)) > time.Minute {
+		t.Error("rejection after the realert window not alerted")
+	}
+}
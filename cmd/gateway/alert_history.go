@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// States of an alert rule. A rule is pending while it triggers but its cooldown holds back the
+// notification, e.g. when it triggers again soon after resolving.
+const (
+	alertStatePending  = "pending"
+	alertStateFiring   = "firing"
+	alertStateResolved = "resolved"
+)
+
+// alertTransitionRetention is how long transitions are kept; the latest of each rule always stays
+const alertTransitionRetention = 180 * 24 * time.Hour
+
+// AlertTransition is a state change of an alert rule, with the metric at the time
+type AlertTransition struct {
+	ID         int64     `json:"id"`
+	RuleID     string    `json:"rule_id"`
+	RuleName   string    `json:"rule_name"`
+	ProjectID  string    `json:"project_id,omitempty"`
+	MetricType string    `json:"metric_type"`
+	Subject    string    `json:"subject,omitempty"` // the agent or gateway of per-agent and gateway health rules
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Comparison string    `json:"comparison,omitempty"`
+	Severity   string    `json:"severity,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func newAlertTransition(rule *pb.AlertRule, subject, from, to string, value float64) AlertTransition {
+	return AlertTransition{
+		RuleID:     rule.GetId(),
+		RuleName:   rule.GetName(),
+		ProjectID:  rule.GetProjectId(),
+		MetricType: rule.GetMetricType(),
+		Subject:    subject,
+		From:       from,
+		To:         to,
+		Value:      value,
+		Threshold:  float64(rule.GetThreshold()),
+		Comparison: rule.GetComparison(),
+		Severity:   rule.GetSeverity(),
+		OccurredAt: time.Now(),
+	}
+}
+
+// recordAlertTransition persists a state change of a rule
+func (s *server) recordAlertTransition(t AlertTransition) {
+	if s.db == nil {
+		return
+	}
+	if err := s.db.RecordAlertTransition(t); err != nil {
+		alertsLog.Error().Msgf("Failed to record alert rule %s going %s: %v", t.RuleName, t.To, err)
+	}
+}
+
+// pruneTransitions deletes the transitions older than alertTransitionRetention
+func (e *AlertEngine) pruneTransitions(now time.Time) {
+	if e.db == nil {
+		return
+	}
+	if n, err := e.db.PruneAlertTransitions(now.Add(-alertTransitionRetention)); err != nil {
+		alertsLog.Error().Msgf("Failed to prune alert transitions: %v", err)
+	} else if n > 0 {
+		alertsLog.Info().Msgf("Pruned %d alert transitions", n)
+	}
+}
+
+// AlertRuleHistory sums up the transitions of a rule (and subject) over a window
+type AlertRuleHistory struct {
+	RuleID     string `json:"rule_id"`
+	RuleName   string `json:"rule_name"`
+	ProjectID  string `json:"project_id,omitempty"`
+	MetricType string `json:"metric_type"`
+	Subject    string `json:"subject,omitempty"`
+	State      string `json:"state"`   // at the end of the window
+	Firings    int    `json:"firings"` // times it started firing in the window
+	Pending    int    `json:"pending"` // times it became pending in the window
+	FiringSec  int64  `json:"firing_sec"`
+	// LongestFiringSec includes time before the window when the rule was already firing
+	LongestFiringSec int64 `json:"longest_firing_sec"`
+	// MeanTimeToResolveSec averages the firings that resolved in the window; null when none did
+	MeanTimeToResolveSec *int64 `json:"mean_time_to_resolve_sec"`
+}
+
+// computeAlertHistory sums up transitions per rule and subject over [start, end]. transitions
+// holds the last transition of each before start, if any, followed by those in the window, oldest
+// first. The result is ordered by time spent firing.
+func computeAlertHistory(transitions []AlertTransition, start, end time.Time) []AlertRuleHistory {
+	type key struct{ rule, subject string }
+	type tally struct {
+		h            AlertRuleHistory
+		firingSince  time.Time // when the current firing started, zero when not firing
+		resolved     int
+		resolvedSecs int64
+	}
+	tallies := map[key]*tally{}
+	var order []key
+	for _, t := range transitions {
+		k := key{t.RuleID, t.Subject}
+		tl := tallies[k]
+		if tl == nil {
+			tl = &tally{h: AlertRuleHistory{RuleID: t.RuleID, Subject: t.Subject}}
+			tallies[k] = tl
+			order = append(order, k)
+		}
+		tl.h.RuleName, tl.h.ProjectID, tl.h.MetricType, tl.h.State = t.RuleName, t.ProjectID, t.MetricType, t.To
+		inWindow := !t.OccurredAt.Before(start)
+		if !tl.firingSince.IsZero() && t.To != alertStateFiring {
+			d := int64(t.OccurredAt.Sub(tl.firingSince).Seconds())
+			tl.h.LongestFiringSec = max(tl.h.LongestFiringSec, d)
+			if inWindow {
+				tl.h.FiringSec += int64(t.OccurredAt.Sub(laterOf(tl.firingSince, start)).Seconds())
+				tl.resolved++
+				tl.resolvedSecs += d
+			}
+			tl.firingSince = time.Time{}
+		}
+		switch t.To {
+		case alertStateFiring:
+			if tl.firingSince.IsZero() {
+				tl.firingSince = t.OccurredAt
+				if inWindow {
+					tl.h.Firings++
+				}
+			}
+		case alertStatePending:
+			if inWindow {
+				tl.h.Pending++
+			}
+		}
+	}
+
+	histories := make([]AlertRuleHistory, 0, len(order))
+	for _, k := range order {
+		tl := tallies[k]
+		if !tl.firingSince.IsZero() {
+			tl.h.FiringSec += int64(end.Sub(laterOf(tl.firingSince, start)).Seconds())
+			tl.h.LongestFiringSec = max(tl.h.LongestFiringSec, int64(end.Sub(tl.firingSince).Seconds()))
+		}
+		if tl.resolved > 0 {
+			mean := tl.resolvedSecs / int64(tl.resolved)
+			tl.h.MeanTimeToResolveSec = &mean
+		}
+		histories = append(histories, tl.h)
+	}
+	sort.SliceStable(histories, func(i, j int) bool {
+		if histories[i].FiringSec != histories[j].FiringSec {
+			return histories[i].FiringSec > histories[j].FiringSec
+		}
+		return histories[i].Firings > histories[j].Firings
+	})
+	return histories
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// handleAlertHistory handles GET /api/alerts/history: the state changes of alert rules over a
+// window (window=7d by default, or from/to in unix seconds) and per rule the time spent firing.
+// Filters: rule_id, project_id, metric_type, subject, state (the state changed to; it does not
+// narrow the stats) and limit (500 transitions by default).
+func (s *server) handleAlertHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	window := query.Get("window")
+	if window == "" && query.Get("from") == "" {
+		window = "7d"
+	}
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	m, err := buildMetricQuery(MetricQuery{Metric: "requests", Window: window, From: from, To: to, Interval: "auto"}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	filter := AlertHistoryFilter{RuleID: query.Get("rule_id"), MetricType: query.Get("metric_type"), Subject: query.Get("subject")}
+	if filter.RuleID != "" {
+		if _, err := uuid.Parse(filter.RuleID); err != nil {
+			http.Error(w, `{"error":"invalid rule_id"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	state := query.Get("state")
+	switch state {
+	case "", alertStatePending, alertStateFiring, alertStateResolved:
+	default:
+		http.Error(w, fmt.Sprintf(`{"error":"unknown state %s"}`, escapeJSON(state)), http.StatusBadRequest)
+		return
+	}
+	limit := 500
+	if v := query.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = min(n, 5000)
+		}
+	}
+
+	// Non-superadmins see the fleet-wide rules and those of their projects
+	projects, all, err := s.callerProjects(r.Context())
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	switch projectID := query.Get("project_id"); {
+	case projectID != "" && (all || stringInSlice(projects, projectID)):
+		filter.Projects = []string{projectID}
+	case projectID != "":
+		filter.Projects = []string{}
+	case !all:
+		filter.Projects, filter.FleetRules = append([]string{}, projects...), true
+	}
+
+	transitions, err := s.db.ListAlertTransitions(filter, m.From, m.To)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	rules := computeAlertHistory(transitions, m.From, m.To)
+	listed := []AlertTransition{}
+	for i := len(transitions) - 1; i >= 0 && len(listed) < limit; i-- {
+		t := transitions[i]
+		if !t.OccurredAt.Before(m.From) && (state == "" || t.To == state) {
+			listed = append(listed, t)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":        m.From,
+		"to":          m.To,
+		"rules":       rules,
+		"transitions": listed,
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestComputeAlertHistory(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	at := func(h float64) time.Time { return start.Add(time.Duration(h * float64(time.Hour))) }
+	tr := func(rule, from, to string, h float64) AlertTransition {
+		return AlertTransition{RuleID: rule, RuleName: "rule " + rule, From: from, To: to, OccurredAt: at(h)}
+	}
+	history := computeAlertHistory([]AlertTransition{
+		// r1 was firing for an hour before the window, then fired twice more and is still firing
+		tr("r1", alertStateResolved, alertStateFiring, -1),
+		tr("r1", alertStateFiring, alertStateResolved, 1),
+		tr("r1", alertStateResolved, alertStatePending, 2),
+		tr("r1", alertStatePending, alertStateFiring, 2.5),
+		tr("r1", alertStateFiring, alertStateResolved, 4.5),
+		tr("r1", alertStateResolved, alertStateFiring, 20),
+		// r2 only resolved before the window
+		tr("r2", alertStateFiring, alertStateResolved, -3),
+	}, start, end)
+
+	if len(history) != 2 {
+		t.Fatalf("got %d rules, want 2", len(history))
+	}
+	r1, r2 := history[0], history[1]
+	if r1.RuleID != "r1" || r1.State != alertStateFiring || r1.RuleName != "rule r1" {
+		t.Fatalf("unexpected first rule %+v", r1)
+	}
+	if r1.Firings != 2 || r1.Pending != 1 {
+		t.Errorf("firings, pending = %d, %d; want 2, 1", r1.Firings, r1.Pending)
+	}
+	if want := int64((1 + 2 + 4) * 3600); r1.FiringSec != want {
+		t.Errorf("firing_sec = %d, want %d", r1.FiringSec, want)
+	}
+	if want := int64(4 * 3600); r1.LongestFiringSec != want {
+		t.Errorf("longest_firing_sec = %d, want %d", r1.LongestFiringSec, want)
+	}
+	// Both resolved firings count whole, the one begun before the window included
+	if r1.MeanTimeToResolveSec == nil || *r1.MeanTimeToResolveSec != 2*3600 {
+		t.Errorf("mean_time_to_resolve_sec = %v, want 7200", r1.MeanTimeToResolveSec)
+	}
+	if r2.State != alertStateResolved || r2.Firings != 0 || r2.FiringSec != 0 || r2.MeanTimeToResolveSec != nil {
+		t.Errorf("a rule quiet all window should have no firings: %+v", r2)
+	}
+}
+
+func TestAlertEngineTransitions(t *testing.T) {
+	engine := NewAlertEngine(nil, nil, &config.Config{HA: config.HAConfig{InstanceID: "gw-1"}})
+	value := 95.0
+	engine.gatewayHealth = func(ctx context.Context, metricType string, windowSec int) (float64, error) {
+		return value, nil
+	}
+	var transitions []AlertTransition
+	engine.recordTransition = func(t AlertTransition) { transitions = append(transitions, t) }
+	rule := &pb.AlertRule{Id: "q", Name: "Queue saturated", MetricType: gatewayQueueSaturationMetric,
+		Threshold: 80, Comparison: "gt", WindowSec: 300, Enabled: true}
+
+	engine.evaluateRule(rule) // fires
+	engine.evaluateRule(rule) // still firing, within the cooldown
+	value = 10
+	engine.evaluateRule(rule) // resolves
+	value = 95
+	engine.evaluateRule(rule) // triggers again within the cooldown
+	engine.lastFired[rule.Id] = time.Now().Add(-time.Hour)
+	engine.evaluateRule(rule) // fires once the cooldown is over
+
+	want := [][2]string{
+		{alertStateResolved, alertStateFiring},
+		{alertStateFiring, alertStateResolved},
+		{alertStateResolved, alertStatePending},
+		{alertStatePending, alertStateFiring},
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %+v, want %v", transitions, want)
+	}
+	for i, w := range want {
+		if transitions[i].From != w[0] || transitions[i].To != w[1] {
+			t.Errorf("transition %d = %s→%s, want %s→%s", i, transitions[i].From, transitions[i].To, w[0], w[1])
+		}
+	}
+	if transitions[0].Value != 95 || transitions[1].Value != 10 || transitions[0].Subject != "gw-1" {
+		t.Errorf("transitions should carry the value and gateway: %+v", transitions[:2])
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// AlertRuleTemplate is a rule of a rule pack. Its threshold is the default of Variable, which a
+// project enabling the pack may override.
+type AlertRuleTemplate struct {
+	Key         string  `json:"key"`
+	Name        string  `json:"name"`
+	MetricType  string  `json:"metric_type"`
+	Comparison  string  `json:"comparison"`
+	Variable    string  `json:"variable,omitempty"` // "" when the threshold is fixed
+	Threshold   float64 `json:"threshold"`
+	Unit        string  `json:"unit,omitempty"`
+	WindowSec   int32   `json:"window_sec"`
+	Severity    string  `json:"severity"`
+	CooldownSec int32   `json:"cooldown_sec,omitempty"`
+}
+
+// AlertRulePack is a set of rules a project enables together
+type AlertRulePack struct {
+	Key         string              `json:"key"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Default     bool                `json:"default"` // enabled for new projects
+	Templates   []AlertRuleTemplate `json:"templates"`
+}
+
+// alertRulePacks are the built-in rule packs
+var alertRulePacks = []AlertRulePack{
+	{
+		Key: "http", Name: "HTTP errors and latency", Default: true,
+		Description: "Failing and slow requests across the project's NGINX instances",
+		Templates: []AlertRuleTemplate{
+			{Key: "error_rate", Name: "High error rate", MetricType: "error_rate", Comparison: "gt",
+				Variable: "error_rate_percent", Threshold: 5, Unit: "%", WindowSec: 300, Severity: "warning"},
+			{Key: "p95_latency", Name: "High p95 latency", MetricType: "latency", Comparison: "gt",
+				Variable: "p95_latency_ms", Threshold: 1000, Unit: "ms", WindowSec: 300, Severity: "warning"},
+		},
+	},
+	{
+		Key: "availability", Name: "Availability", Default: true,
+		Description: "Agents that stop reporting and TLS certificates about to expire",
+		Templates: []AlertRuleTemplate{
+			{Key: "agent_offline", Name: "Agent offline", MetricType: agentOfflineMetric, Comparison: "gt",
+				Variable: "offline_minutes", Threshold: 10, Unit: "min", Severity: "critical"},
+			// Certificates alert at fixed milestones before expiry (cert_monitor.go)
+			{Key: "cert_expiry", Name: "Certificate expiring", MetricType: certExpiryMetric, Comparison: "lt", Severity: "warning"},
+		},
+	},
+	{
+		Key: "capacity", Name: "Capacity", Default: true,
+		Description: "Hosts running out of disk",
+		Templates: []AlertRuleTemplate{
+			{Key: "disk_full", Name: "Disk nearly full", MetricType: "disk_usage", Comparison: "gt",
+				Variable: "disk_used_percent", Threshold: 90, Unit: "%", WindowSec: 300, Severity: "critical", CooldownSec: 3600},
+		},
+	},
+}
+
+// alertPackNamespace derives the IDs of pack rules, so enabling a pack again updates its rules
+var alertPackNamespace = uuid.MustParse("5b0c3f8e-7a4d-4e61-9f2a-3c8d1e6b7a90")
+
+func findAlertRulePack(key string) (AlertRulePack, bool) {
+	for _, p := range alertRulePacks {
+		if p.Key == key {
+			return p, true
+		}
+	}
+	return AlertRulePack{}, false
+}
+
+// AlertPackSettings enables a rule pack for a project
+type AlertPackSettings struct {
+	Variables  map[string]float64 `json:"variables,omitempty"` // overrides of the templates' thresholds
+	Recipients string             `json:"recipients,omitempty"`
+	Disabled   bool               `json:"disabled,omitempty"` // create the rules disabled
+}
+
+// rules returns the rules of the pack for a project; their names are prefixed with the project's
+func (p AlertRulePack) rules(project *Project, settings AlertPackSettings) ([]*pb.AlertRule, error) {
+	known := map[string]bool{}
+	for _, t := range p.Templates {
+		if t.Variable != "" {
+			known[t.Variable] = true
+		}
+	}
+	for name, v := range settings.Variables {
+		if !known[name] {
+			return nil, fmt.Errorf("pack %s has no variable %q", p.Key, name)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	rules := make([]*pb.AlertRule, 0, len(p.Templates))
+	for _, t := range p.Templates {
+		threshold := t.Threshold
+		if v, ok := settings.Variables[t.Variable]; ok {
+			threshold = v
+		}
+		pack := p.Key + "/" + t.Key
+		rules = append(rules, &pb.AlertRule{
+			Id:          uuid.NewSHA1(alertPackNamespace, []byte(project.ID+"/"+pack)).String(),
+			Name:        project.Name + ": " + t.Name,
+			MetricType:  t.MetricType,
+			Threshold:   float32(threshold),
+			Comparison:  t.Comparison,
+			WindowSec:   t.WindowSec,
+			Enabled:     !settings.Disabled,
+			Recipients:  settings.Recipients,
+			CooldownSec: t.CooldownSec,
+			Severity:    t.Severity,
+			ProjectId:   project.ID,
+			Pack:        pack,
+		})
+	}
+	return rules, nil
+}
+
+// saveAlertRules creates or updates rules
+func (s *server) saveAlertRules(rules []*pb.AlertRule) error {
+	for _, rule := range rules {
+		if err := s.db.UpsertAlertRule(rule); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// enableDefaultAlertPacks gives a new project the default packs with their default thresholds.
+// The rules have no recipients until the project sets them: until then metric rules only record
+// alert events, and agent offline and certificate rules wait.
+func (s *server) enableDefaultAlertPacks(project *Project) {
+	for _, pack := range alertRulePacks {
+		if !pack.Default {
+			continue
+		}
+		rules, err := pack.rules(project, AlertPackSettings{})
+		if err == nil {
+			err = s.saveAlertRules(rules)
+		}
+		if err != nil {
+			alertsLog.Error().Msgf("Enabling alert pack %s for project %s failed: %v", pack.Key, project.Slug, err)
+		}
+	}
+}
+
+// enableDefaultAlertPacksFor enables the default packs of the projects among tenancy changes
+func (s *server) enableDefaultAlertPacksFor(changes []TenancyChange) {
+	for _, c := range changes {
+		if c.Action != "create" || c.Kind != "project" {
+			continue
+		}
+		project, err := s.db.GetProject(c.ID)
+		if err != nil || project == nil {
+			alertsLog.Error().Msgf("Enabling alert packs for project %s failed: %v", c.Key, err)
+			continue
+		}
+		s.enableDefaultAlertPacks(project)
+	}
+}
+
+// ProjectAlertPack is a pack and the rules a project has from it
+type ProjectAlertPack struct {
+	AlertRulePack
+	Enabled bool            `json:"enabled"` // the project has rules from the pack
+	Rules   []*pb.AlertRule `json:"rules"`
+}
+
+// handleListAlertPacks handles GET /api/alerts/packs: the built-in rule packs and their templates
+func (s *server) handleListAlertPacks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"packs": alertRulePacks})
+}
+
+// handleListProjectAlertPacks handles GET /api/projects/{id}/alerts/packs: every rule pack with the
+// rules the project has from it
+func (s *server) handleListProjectAlertPacks(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadQuotaProject(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	all, err := s.db.ListAlertRules()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	byPack := map[string][]*pb.AlertRule{}
+	for _, rule := range all {
+		if rule.ProjectId == project.ID && rule.Pack != "" {
+			key, _, _ := strings.Cut(rule.Pack, "/")
+			byPack[key] = append(byPack[key], rule)
+		}
+	}
+	packs := make([]ProjectAlertPack, 0, len(alertRulePacks))
+	for _, p := range alertRulePacks {
+		rules := byPack[p.Key]
+		sort.Slice(rules, func(i, j int) bool { return rules[i].Pack < rules[j].Pack })
+		if rules == nil {
+			rules = []*pb.AlertRule{}
+		}
+		packs = append(packs, ProjectAlertPack{AlertRulePack: p, Enabled: len(rules) > 0, Rules: rules})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"packs": packs})
+}
+
+// handlePutProjectAlertPack handles PUT /api/projects/{id}/alerts/packs/{pack}: creates the rules of
+// the pack for the project, or updates them with new variables and recipients. Needs admin on the
+// project.
+func (s *server) handlePutProjectAlertPack(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadQuotaProject(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	pack, ok := findAlertRulePack(r.PathValue("pack"))
+	if !ok {
+		http.Error(w, `{"error":"rule pack not found"}`, http.StatusNotFound)
+		return
+	}
+	var settings AlertPackSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	rules, err := pack.rules(project, settings)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if err := s.saveAlertRules(rules); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		s.db.CreateAuditLog(user.Username, "enable", "alert_pack", project.ID+"/"+pack.Key, r.RemoteAddr, r.UserAgent(), map[string]string{
+			"recipients": settings.Recipients,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProjectAlertPack{AlertRulePack: pack, Enabled: true, Rules: rules})
+}
+
+// handleDeleteProjectAlertPack handles DELETE /api/projects/{id}/alerts/packs/{pack}: deletes the
+// rules the project has from the pack. Needs admin on the project.
+func (s *server) handleDeleteProjectAlertPack(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadQuotaProject(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	pack, ok := findAlertRulePack(r.PathValue("pack"))
+	if !ok {
+		http.Error(w, `{"error":"rule pack not found"}`, http.StatusNotFound)
+		return
+	}
+	if _, err := s.db.DeleteAlertPackRules(project.ID, pack.Key); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		s.db.CreateAuditLog(user.Username, "disable", "alert_pack", project.ID+"/"+pack.Key, r.RemoteAddr, r.UserAgent(), nil)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestAlertRulePackRules(t *testing.T) {
+	pack, ok := findAlertRulePack("http")
+	if !ok {
+		t.Fatal("http pack not found")
+	}
+	shop := &Project{ID: "11111111-1111-1111-1111-111111111111", Name: "Shop"}
+	rules, err := pack.rules(shop, AlertPackSettings{Variables: map[string]float64{"p95_latency_ms": 250}, Recipients: "ops@example.com"})
+	if err != nil {
+		t.Fatalf("rules: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	errors, latency := rules[0], rules[1]
+	if errors.Threshold != 5 || latency.Threshold != 250 {
+		t.Errorf("thresholds = %v, %v; want the default 5 and the override 250", errors.Threshold, latency.Threshold)
+	}
+	if latency.Name != "Shop: High p95 latency" || latency.Pack != "http/p95_latency" || latency.ProjectId != shop.ID {
+		t.Errorf("unexpected rule %+v", latency)
+	}
+	if !latency.Enabled || latency.Recipients != "ops@example.com" {
+		t.Errorf("rule should be enabled with the recipients: %+v", latency)
+	}
+
+	again, _ := pack.rules(shop, AlertPackSettings{})
+	other, _ := pack.rules(&Project{ID: "22222222-2222-2222-2222-222222222222", Name: "Blog"}, AlertPackSettings{})
+	if again[0].Id != errors.Id {
+		t.Error("enabling a pack again should keep the rule IDs")
+	}
+	if other[0].Id == errors.Id {
+		t.Error("rule IDs should differ between projects")
+	}
+}
+
+func TestAlertRulePackRulesInvalid(t *testing.T) {
+	pack, _ := findAlertRulePack("capacity")
+	project := &Project{ID: "p1", Name: "P"}
+	for name, vars := range map[string]map[string]float64{
+		"unknown variable":  {"error_rate_percent": 10},
+		"negative variable": {"disk_used_percent": -1},
+	} {
+		if _, err := pack.rules(project, AlertPackSettings{Variables: vars}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRuleScope(t *testing.T) {
+	fleet := &pb.AlertRule{}
+	project := &pb.AlertRule{ProjectId: "p1"}
+	if f := ruleFilter(fleet); len(f.ProjectIDs) != 0 {
+		t.Errorf("fleet-wide rule filtered by %v", f.ProjectIDs)
+	}
+	if f := ruleFilter(project); len(f.ProjectIDs) != 1 || f.ProjectIDs[0] != "p1" {
+		t.Errorf("project rule filtered by %v", f.ProjectIDs)
+	}
+	if !ruleCovers(fleet, "p2") || !ruleCovers(fleet, "") {
+		t.Error("a fleet-wide rule should cover every agent")
+	}
+	if !ruleCovers(project, "p1") || ruleCovers(project, "p2") || ruleCovers(project, "") {
+		t.Error("a project rule should only cover the project's agents")
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -25,6 +24,27 @@ type AlertEngine struct {
 	// Cooldown tracking: ruleID -> last fired timestamp
 	lastFired   map[string]time.Time
 	lastFiredMu sync.RWMutex
+
+	// banOffenders runs the "ban" action of a fired rule; nil disables the action
+	banOffenders func(rule *pb.AlertRule, value float64)
+
+	// isLeader reports whether this gateway evaluates the rules when several run; nil always does
+	isLeader func() bool
+
+	// emitEvent publishes alert.fired and alert.resolved events; nil disables them
+	emitEvent func(ev SystemEvent)
+	// recordTransition persists state changes of rules (alert_history.go); nil disables it
+	recordTransition func(t AlertTransition)
+	// states holds the rules that are pending or firing, guarded by lastFiredMu; resolved rules
+	// have no entry
+	states map[string]string
+
+	// gatewayHealth returns the value of a gateway health metric (self_monitoring.go); nil
+	// disables those rules
+	gatewayHealth func(ctx context.Context, metricType string, windowSec int) (float64, error)
+	// healthRules are the gateway health rules last listed, evaluated while PostgreSQL is down
+	healthRules   []*pb.AlertRule
+	healthRulesMu sync.Mutex
 }
 
 func NewAlertEngine(db *DB, ch *ClickHouseDB, cfg *config.Config) *AlertEngine {
@@ -34,18 +54,27 @@ func NewAlertEngine(db *DB, ch *ClickHouseDB, cfg *config.Config) *AlertEngine {
 		config:     cfg,
 		stopChan:   make(chan struct{}),
 		lastFired:  make(map[string]time.Time),
+		states:     make(map[string]string),
 	}
 }
 
 func (e *AlertEngine) Start() {
 	ticker := time.NewTicker(1 * time.Minute)
-	log.Printf("Starting Alert Engine (evaluation interval: 1m)")
+	alertsLog.Info().Msg("Starting Alert Engine (evaluation interval: 1m)")
 
 	go func() {
+		var lastPrune time.Time
 		for {
 			select {
-			case <-ticker.C:
-				e.evaluateRules()
+			case now := <-ticker.C:
+				e.evaluateGatewayHealthRules()
+				if e.isLeader == nil || e.isLeader() {
+					e.evaluateRules()
+					if now.Sub(lastPrune) >= 24*time.Hour {
+						e.pruneTransitions(now)
+						lastPrune = now
+					}
+				}
 			case <-e.stopChan:
 				ticker.Stop()
 				return
@@ -61,7 +90,7 @@ func (e *AlertEngine) Stop() {
 func (e *AlertEngine) evaluateRules() {
 	rules, err := e.db.ListAlertRules()
 	if err != nil {
-		log.Printf("AlertEngine: Failed to list rules: %v", err)
+		alertsLog.Error().Msgf("AlertEngine: Failed to list rules: %v", err)
 		return
 	}
 
@@ -69,11 +98,34 @@ func (e *AlertEngine) evaluateRules() {
 		if !rule.Enabled {
 			continue
 		}
+		// Certificate expiry, synthetic check, agent offline and rejected update rules are event
+		// driven (see cert_monitor.go, synthetic_checks.go, availability.go, agent_update_rejections.go)
+		if rule.MetricType == certExpiryMetric || rule.MetricType == syntheticCheckMetric || rule.MetricType == agentOfflineMetric ||
+			rule.MetricType == agentUpdateRejectedMetric {
+			continue
+		}
+		// Every gateway evaluates its own health (evaluateGatewayHealthRules)
+		if isGatewayHealthMetric(rule.MetricType) {
+			continue
+		}
 
 		go e.evaluateRule(rule)
 	}
 }
 
+// ruleFilter selects the rows a rule is evaluated on: its project's, or the whole fleet's
+func ruleFilter(rule *pb.AlertRule) tenantFilter {
+	if rule.ProjectId == "" {
+		return tenantFilter{}
+	}
+	return tenantFilter{ProjectIDs: []string{rule.ProjectId}}
+}
+
+// ruleCovers reports whether a rule watches an agent of the given project ("" for none)
+func ruleCovers(rule *pb.AlertRule, projectID string) bool {
+	return rule.ProjectId == "" || rule.ProjectId == projectID
+}
+
 // AlertCondition represents a single condition in a composite rule.
 type AlertCondition struct {
 	MetricType string  `json:"metric_type"`
@@ -99,14 +151,20 @@ func (e *AlertEngine) evaluateRule(rule *pb.AlertRule) {
 		// Count total drifted agents from latest drift reports
 		val, err = e.queryDriftedAgentCount(ctx)
 		if err != nil {
-			log.Printf("AlertEngine: Failed to query drift count for rule %s: %v", rule.Name, err)
+			alertsLog.Error().Msgf("AlertEngine: Failed to query drift count for rule %s: %v", rule.Name, err)
+			return
+		}
+	} else if isGatewayHealthMetric(rule.MetricType) {
+		val, err = e.gatewayHealth(ctx, rule.MetricType, int(rule.WindowSec))
+		if err != nil {
+			alertsLog.Error().Msgf("AlertEngine: Failed to read gateway health for rule %s: %v", rule.Name, err)
 			return
 		}
 	} else {
 		// Query ClickHouse for the aggregate metric
-		val, err = e.clickhouse.QueryMetricAverage(ctx, rule.MetricType, int(rule.WindowSec))
+		val, err = e.clickhouse.QueryMetricAverage(ctx, rule.MetricType, int(rule.WindowSec), ruleFilter(rule))
 		if err != nil {
-			log.Printf("AlertEngine: Failed to query metric for rule %s: %v", rule.Name, err)
+			alertsLog.Error().Msgf("AlertEngine: Failed to query metric for rule %s: %v", rule.Name, err)
 			return
 		}
 	}
@@ -115,10 +173,10 @@ func (e *AlertEngine) evaluateRule(rule *pb.AlertRule) {
 	triggered := evaluateComparison(rule.Comparison, val, float64(rule.Threshold))
 
 	// Rate-of-change comparisons: compare current window vs previous window
-	if !triggered && isRateComparison(rule.Comparison) {
+	if !triggered && isRateComparison(rule.Comparison) && !isGatewayHealthMetric(rule.MetricType) {
 		triggered, err = e.evaluateRateOfChange(ctx, rule, val)
 		if err != nil {
-			log.Printf("AlertEngine: Rate-of-change error for rule %s: %v", rule.Name, err)
+			alertsLog.Error().Msgf("AlertEngine: Rate-of-change error for rule %s: %v", rule.Name, err)
 			return
 		}
 	}
@@ -127,11 +185,14 @@ func (e *AlertEngine) evaluateRule(rule *pb.AlertRule) {
 	if rule.Conditions != "" {
 		triggered, err = e.evaluateCompositeRule(ctx, rule)
 		if err != nil {
-			log.Printf("AlertEngine: Composite rule error for rule %s: %v", rule.Name, err)
+			alertsLog.Error().Msgf("AlertEngine: Composite rule error for rule %s: %v", rule.Name, err)
 			return
 		}
 	}
 
+	if !triggered {
+		e.clearFiring(rule, val)
+	}
 	if triggered {
 		// Check cooldown
 		cooldown := time.Duration(rule.CooldownSec) * time.Second
@@ -139,7 +200,8 @@ func (e *AlertEngine) evaluateRule(rule *pb.AlertRule) {
 			cooldown = 5 * time.Minute // default cooldown
 		}
 		if e.isInCooldown(rule.Id, cooldown) {
-			log.Printf("AlertEngine: Rule [%s] triggered but in cooldown (last fired < %v ago)", rule.Name, cooldown)
+			alertsLog.Info().Msgf("AlertEngine: Rule [%s] triggered but in cooldown (last fired < %v ago)", rule.Name, cooldown)
+			e.transition(rule, alertStatePending, val)
 			return
 		}
 
@@ -148,11 +210,16 @@ func (e *AlertEngine) evaluateRule(rule *pb.AlertRule) {
 			severity = "warning"
 		}
 
-		log.Printf("ALERT TRIGGERED [%s]: Rule [%s] Metric [%s] Value [%.2f] Threshold [%s %.2f]",
+		alertsLog.Info().Msgf("ALERT TRIGGERED [%s]: Rule [%s] Metric [%s] Value [%.2f] Threshold [%s %.2f]",
 			strings.ToUpper(severity), rule.Name, rule.MetricType, val, rule.Comparison, rule.Threshold)
 
 		e.recordFired(rule.Id)
+		e.transition(rule, alertStateFiring, val)
 		e.sendNotifications(rule, val)
+		e.emit("alert.fired", rule, severity, val)
+		if rule.Action == alertActionBan && e.banOffenders != nil {
+			e.banOffenders(rule, val)
+		}
 	}
 }
 
@@ -188,7 +255,7 @@ func (e *AlertEngine) evaluateRateOfChange(ctx context.Context, rule *pb.AlertRu
 	}
 
 	// Query the previous window (shifted by window duration)
-	prevVal, err := e.clickhouse.QueryMetricAverageOffset(ctx, rule.MetricType, window, window)
+	prevVal, err := e.clickhouse.QueryMetricAverageOffset(ctx, rule.MetricType, window, window, ruleFilter(rule))
 	if err != nil {
 		return false, fmt.Errorf("failed to query previous window: %w", err)
 	}
@@ -228,12 +295,14 @@ func (e *AlertEngine) evaluateCompositeRule(ctx context.Context, rule *pb.AlertR
 		var err error
 		if cond.MetricType == "config_drift" {
 			val, err = e.queryDriftedAgentCount(ctx)
+		} else if isGatewayHealthMetric(cond.MetricType) && e.gatewayHealth != nil {
+			val, err = e.gatewayHealth(ctx, cond.MetricType, cond.WindowSec)
 		} else {
 			window := cond.WindowSec
 			if window <= 0 {
 				window = int(rule.WindowSec)
 			}
-			val, err = e.clickhouse.QueryMetricAverage(ctx, cond.MetricType, window)
+			val, err = e.clickhouse.QueryMetricAverage(ctx, cond.MetricType, window, ruleFilter(rule))
 		}
 
 		if err != nil {
@@ -281,6 +350,67 @@ func (e *AlertEngine) recordFired(ruleID string) {
 	e.lastFiredMu.Unlock()
 }
 
+// clearFiring resolves a rule that no longer triggers, emitting alert.resolved when it was firing
+func (e *AlertEngine) clearFiring(rule *pb.AlertRule, value float64) {
+	if e.transition(rule, alertStateResolved, value) == alertStateFiring {
+		e.emit("alert.resolved", rule, "info", value)
+	}
+}
+
+// transition moves a rule to a state and records the change; a firing rule held back by its
+// cooldown stays firing rather than becoming pending. It returns the previous state.
+func (e *AlertEngine) transition(rule *pb.AlertRule, to string, value float64) string {
+	e.lastFiredMu.Lock()
+	from := e.states[rule.Id]
+	if from == "" {
+		from = alertStateResolved
+	}
+	if to == alertStatePending && from == alertStateFiring {
+		to = from
+	}
+	if to == alertStateResolved {
+		delete(e.states, rule.Id)
+	} else {
+		e.states[rule.Id] = to
+	}
+	e.lastFiredMu.Unlock()
+
+	if from != to && e.recordTransition != nil {
+		// Every gateway evaluates its own health, so those transitions are the gateway's
+		subject := ""
+		if isGatewayHealthMetric(rule.MetricType) && e.config != nil {
+			subject = e.config.HA.InstanceID
+		}
+		e.recordTransition(newAlertTransition(rule, subject, from, to, value))
+	}
+	return from
+}
+
+// emit publishes an alert event to the activity feed and webhooks
+func (e *AlertEngine) emit(eventType string, rule *pb.AlertRule, severity string, value float64) {
+	verb := "resolved"
+	if eventType == "alert.fired" {
+		verb = "fired"
+	}
+	if e.emitEvent == nil {
+		return
+	}
+	e.emitEvent(SystemEvent{
+		Type:     eventType,
+		Severity: severity,
+		Message:  fmt.Sprintf("Alert rule %s %s (%s = %.2f)", rule.Name, verb, rule.MetricType, value),
+		Data: map[string]interface{}{
+			"rule_id":     rule.Id,
+			"rule_name":   rule.Name,
+			"metric_type": rule.MetricType,
+			"severity":    rule.Severity,
+			"value":       value,
+			"threshold":   rule.Threshold,
+			"comparison":  rule.Comparison,
+		},
+	})
+}
+
 // queryDriftedAgentCount counts total drifted agents from the most recent drift report per group.
 func (e *AlertEngine) queryDriftedAgentCount(ctx context.Context) (float64, error) {
 	query := `
@@ -325,13 +455,20 @@ func (e *AlertEngine) sendNotifications(rule *pb.AlertRule, value float64) {
 	if severity == "" {
 		severity = "warning"
 	}
-	color := SeverityColor(severity)
 
-	emails := strings.Split(rule.Recipients, ",")
 	subject := fmt.Sprintf("[%s] %s triggered", strings.ToUpper(severity), rule.Name)
 	body := fmt.Sprintf("Alert Rule '%s' has been triggered.\n\nSeverity: %s\nMetric: %s\nCurrent Value: %.2f\nThreshold: %s %.2f\nTime: %s",
 		rule.Name, strings.ToUpper(severity), rule.MetricType, value, rule.Comparison, rule.Threshold, time.Now().Format(time.RFC1123))
 
+	e.notifyRecipients(rule.Recipients, severity, subject, body)
+}
+
+// notifyRecipients delivers a notification to a comma-separated list of emails and webhook URLs
+// (Slack, Teams, PagerDuty, OpsGenie or generic JSON webhooks).
+func (e *AlertEngine) notifyRecipients(recipients, severity, subject, body string) {
+	color := SeverityColor(severity)
+
+	emails := strings.Split(recipients, ",")
 	for _, email := range emails {
 		email = strings.TrimSpace(email)
 		if email == "" {
@@ -360,18 +497,18 @@ func (e *AlertEngine) sendNotifications(rule *pb.AlertRule, value float64) {
 			}
 
 			if err != nil {
-				log.Printf("AlertEngine: Failed to send webhook to %s: %v", email, err)
+				alertsLog.Error().Msgf("AlertEngine: Failed to send webhook to %s: %v", email, err)
 			} else {
-				log.Printf("AlertEngine: Notification sent via webhook to %s", email)
+				alertsLog.Info().Msgf("AlertEngine: Notification sent via webhook to %s", email)
 			}
 		} else if strings.Contains(email, "@") {
 			// Send Email
 			err := SendReportEmail(e.config, []string{email}, subject, body, nil, "")
 			if err != nil {
-				log.Printf("AlertEngine: Failed to send alert email to %s: %v", email, err)
+				alertsLog.Error().Msgf("AlertEngine: Failed to send alert email to %s: %v", email, err)
 			}
 		} else {
-			log.Printf("AlertEngine: UNKNOWN notification recipient type: %s", email)
+			alertsLog.Info().Msgf("AlertEngine: UNKNOWN notification recipient type: %s", email)
 		}
 	}
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var avikaAnalyticsCacheRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "avika_analytics_cache_requests_total",
+		Help: "Analytics cache lookups by result: hit, shared (waited for an in-flight query) or miss",
+	},
+	[]string{"cache", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(avikaAnalyticsCacheRequests)
+}
+
+// analyticsCacheQueryTimeout bounds a query shared by several viewers, which outlives the
+// request of the viewer that started it
+const analyticsCacheQueryTimeout = 30 * time.Second
+
+// responseCache shares the responses of expensive read queries for ttl. Concurrent lookups of a
+// key being loaded wait for that load instead of starting their own, also with a zero ttl.
+// Errors are not cached. Cached values are shared between callers and must not be modified. A
+// nil cache loads every time.
+type responseCache[T any] struct {
+	name string
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*responseCacheEntry[T]
+}
+
+type responseCacheEntry[T any] struct {
+	at    time.Time
+	done  chan struct{} // closed once value and err are set
+	value T
+	err   error
+}
+
+// newResponseCache returns a cache reported as name in metrics. With a ttl of zero or less it
+// only coalesces concurrent loads.
+func newResponseCache[T any](name string, ttl time.Duration) *responseCache[T] {
+	return &responseCache[T]{name: name, ttl: ttl, entries: map[string]*responseCacheEntry[T]{}}
+}
+
+// get returns the cached value of key, or loads it
+func (c *responseCache[T]) get(ctx context.Context, key string, load func(context.Context) (T, error)) (T, error) {
+	if c == nil {
+		return load(ctx)
+	}
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if time.Since(e.at) < c.ttl {
+				c.mu.Unlock()
+				avikaAnalyticsCacheRequests.WithLabelValues(c.name, "hit").Inc()
+				return e.value, nil
+			}
+		default:
+			c.mu.Unlock()
+			avikaAnalyticsCacheRequests.WithLabelValues(c.name, "shared").Inc()
+			select {
+			case <-e.done:
+				return e.value, e.err
+			case <-ctx.Done():
+				var zero T
+				return zero, ctx.Err()
+			}
+		}
+	}
+	e := &responseCacheEntry[T]{done: make(chan struct{})}
+	c.entries[key] = e
+	c.sweepLocked()
+	c.mu.Unlock()
+	avikaAnalyticsCacheRequests.WithLabelValues(c.name, "miss").Inc()
+
+	// Viewers waiting on this load must not fail because the first one went away
+	loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), analyticsCacheQueryTimeout)
+	defer cancel()
+	e.value, e.err = load(loadCtx)
+	e.at = time.Now()
+	close(e.done)
+	if e.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	return e.value, e.err
+}
+
+// sweepLocked drops expired entries so keys of one-off windows don't accumulate
+func (c *responseCache[T]) sweepLocked() {
+	for k, e := range c.entries {
+		select {
+		case <-e.done:
+			if time.Since(e.at) >= c.ttl {
+				delete(c.entries, k)
+			}
+		default:
+		}
+	}
+}
+
+// purge drops every entry and returns how many were dropped; loads in flight still complete
+func (c *responseCache[T]) purge() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries = map[string]*responseCacheEntry[T]{}
+	return n
+}
+
+// analyticsCacheKey identifies an analytics query by its window and filters
+func analyticsCacheKey(req *pb.AnalyticsRequest, filter tenantFilter) string {
+	return strings.Join([]string{
+		req.TimeWindow,
+		fmt.Sprint(req.FromTimestamp),
+		fmt.Sprint(req.ToTimestamp),
+		req.AgentId,
+		req.EnvironmentId,
+		req.ProjectId,
+		req.UrlFilter,
+		req.StatusCodeFilter,
+		analyticsTimezone(req.Timezone),
+		compareCacheKey(req.Compare),
+		filter.cacheKey(),
+	}, "|")
+}
+
+// compareCacheKey identifies the baseline of a compare-mode request; empty without one
+func compareCacheKey(c *pb.AnalyticsComparison) string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join([]string{
+		c.Offset,
+		fmt.Sprint(c.FromTimestamp),
+		fmt.Sprint(c.ToTimestamp),
+		c.AgentId,
+		c.EnvironmentId,
+		c.ProjectId,
+	}, ",")
+}
+
+// geoCacheKey identifies a geo query by its window and the rows it covers
+func geoCacheKey(window string, filter tenantFilter) string {
+	return window + "|" + filter.cacheKey()
+}
+
+// handlePurgeAnalyticsCache handles POST /api/analytics/cache/purge: the next request of every
+// window queries ClickHouse again
+func (s *server) handlePurgeAnalyticsCache(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	purged := s.analyticsCache.purge() + s.geoCache.purge()
+	_ = s.db.CreateAuditLog(username, "purge", "analytics_cache", "", r.RemoteAddr, r.UserAgent(), map[string]int{"entries": purged})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"purged": purged})
+}
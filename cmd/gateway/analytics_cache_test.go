@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheSharesLoads(t *testing.T) {
+	c := newResponseCache[int]("test", time.Minute)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.get(context.Background(), "24h", load); v != 42 || err != nil {
+				t.Errorf("get = %d, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("%d loads for 50 concurrent viewers", n)
+	}
+	if v, _ := c.get(context.Background(), "24h", load); v != 42 || loads.Load() != 1 {
+		t.Error("fresh entry should be a hit")
+	}
+	if c.purge() != 1 {
+		t.Error("purge should drop the entry")
+	}
+	c.get(context.Background(), "24h", load)
+	if loads.Load() != 2 {
+		t.Error("purged entry should be loaded again")
+	}
+}
+
+func TestResponseCacheExpiryAndErrors(t *testing.T) {
+	c := newResponseCache[string]("test", 20*time.Millisecond)
+	calls := 0
+	failing := func(ctx context.Context) (string, error) {
+		calls++
+		return "", errors.New("clickhouse down")
+	}
+	c.get(context.Background(), "k", failing)
+	if _, err := c.get(context.Background(), "k", failing); err == nil || calls != 2 {
+		t.Errorf("errors must not be cached: calls = %d", calls)
+	}
+
+	ok := func(ctx context.Context) (string, error) { calls++; return "v", nil }
+	c.get(context.Background(), "k", ok)
+	c.get(context.Background(), "k", ok)
+	time.Sleep(30 * time.Millisecond)
+	c.get(context.Background(), "k", ok)
+	if calls != 4 {
+		t.Errorf("calls = %d, want a load before and after expiry", calls)
+	}
+
+	var disabled *responseCache[string]
+	disabled.get(context.Background(), "k", ok)
+	disabled.get(context.Background(), "k", ok)
+	if calls != 6 {
+		t.Error("a nil cache loads every time")
+	}
+	uncached := newResponseCache[string]("off", 0)
+	uncached.get(context.Background(), "k", ok)
+	uncached.get(context.Background(), "k", ok)
+	if calls != 8 {
+		t.Error("a zero ttl must not cache finished loads")
+	}
+}
+
+func TestGeoCacheKeyIgnoresAgentOrder(t *testing.T) {
+	if geoCacheKey("24h", tenantFilter{Agents: []string{"b", "a"}}) != geoCacheKey("24h", tenantFilter{Agents: []string{"a", "b"}}) {
+		t.Error("agent order changed the key")
+	}
+	if geoCacheKey("24h", tenantFilter{}) == geoCacheKey("1h", tenantFilter{}) {
+		t.Error("window missing from the key")
+	}
+	if geoCacheKey("24h", tenantFilter{EnvironmentID: "e1"}) == geoCacheKey("24h", tenantFilter{ProjectIDs: []string{"e1"}}) {
+		t.Error("environment and project filters share a key")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+)
+
+// analyticsCompareOffsets are the shifts a compare-mode request may ask for
+var analyticsCompareOffsets = map[string]time.Duration{
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// baselineAnalyticsRequest is the request of the baseline of a compare-mode request: req with the
+// range and agents of req.Compare, always as an absolute range
+func baselineAnalyticsRequest(req *pb.AnalyticsRequest, now time.Time) (*pb.AnalyticsRequest, error) {
+	c := req.Compare
+	otherAgents := c.AgentId != "" || c.EnvironmentId != "" || c.ProjectId != ""
+	if (c.FromTimestamp > 0) != (c.ToTimestamp > 0) {
+		return nil, fmt.Errorf("compare needs both from_timestamp and to_timestamp")
+	}
+
+	start, end := analyticsTimeRange(req, now)
+	switch {
+	case c.FromTimestamp > 0:
+		if c.Offset != "" {
+			return nil, fmt.Errorf("compare takes an offset or a baseline range, not both")
+		}
+		if c.FromTimestamp >= c.ToTimestamp {
+			return nil, fmt.Errorf("compare from_timestamp must be before to_timestamp")
+		}
+		start, end = time.UnixMilli(c.FromTimestamp), time.UnixMilli(c.ToTimestamp)
+	case c.Offset != "":
+		offset, ok := analyticsCompareOffsets[c.Offset]
+		if !ok {
+			return nil, fmt.Errorf("unknown compare offset %q", c.Offset)
+		}
+		start, end = start.Add(-offset), end.Add(-offset)
+	case !otherAgents:
+		return nil, fmt.Errorf("compare needs an offset, a baseline range or baseline agents")
+	}
+
+	baseline := proto.Clone(req).(*pb.AnalyticsRequest)
+	baseline.Compare = nil
+	baseline.Deltas = false
+	baseline.TimeWindow = ""
+	baseline.FromTimestamp, baseline.ToTimestamp = start.UnixMilli(), end.UnixMilli()
+	if otherAgents {
+		baseline.AgentId, baseline.EnvironmentId, baseline.ProjectId = c.AgentId, c.EnvironmentId, c.ProjectId
+	}
+	return baseline, nil
+}
+
+// compareAnalytics adds the baseline of a compare-mode request to its response
+func (s *server) compareAnalytics(ctx context.Context, req *pb.AnalyticsRequest, resp *pb.AnalyticsResponse) error {
+	baselineReq, err := baselineAnalyticsRequest(req, time.Now())
+	if err != nil {
+		return err
+	}
+	baseline, err := s.GetAnalytics(ctx, baselineReq)
+	if err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	resp.Baseline = baseline
+	resp.Comparison = newAnalyticsComparison(resp.Summary, baseline.Summary)
+	resp.Comparison.FromTimestamp, resp.Comparison.ToTimestamp = baselineReq.FromTimestamp, baselineReq.ToTimestamp
+	return nil
+}
+
+// newAnalyticsComparison compares the key metrics of two summaries
+func newAnalyticsComparison(current, baseline *pb.AnalyticsSummary) *pb.AnalyticsComparisonSummary {
+	metric := func(s *pb.AnalyticsSummary) map[string]float64 {
+		return map[string]float64{
+			"total_requests":  float64(s.GetTotalRequests()),
+			"error_rate":      float64(s.GetErrorRate()),
+			"avg_latency":     float64(s.GetAvgLatency()),
+			"requests_5xx":    float64(s.GetRequests_5Xx()),
+			"total_bandwidth": float64(s.GetTotalBandwidth()),
+		}
+	}
+	cur, base := metric(current), metric(baseline)
+	summary := &pb.AnalyticsComparisonSummary{}
+	for _, name := range []string{"total_requests", "error_rate", "avg_latency", "requests_5xx", "total_bandwidth"} {
+		change := &pb.MetricChange{Metric: name, Current: cur[name], Baseline: base[name], Change: cur[name] - base[name]}
+		if base[name] != 0 {
+			change.ChangePercent = change.Change / base[name] * 100
+		}
+		summary.Changes = append(summary.Changes, change)
+	}
+	return summary
+}
+
+// analyticsComparisonParams reads the compare_* query parameters of GET /api/analytics; nil
+// without any
+func analyticsComparisonParams(get func(string) string) *pb.AnalyticsComparison {
+	from, _ := strconv.ParseInt(get("compare_from"), 10, 64)
+	to, _ := strconv.ParseInt(get("compare_to"), 10, 64)
+	c := &pb.AnalyticsComparison{
+		Offset:        get("compare_offset"),
+		FromTimestamp: from,
+		ToTimestamp:   to,
+		AgentId:       get("compare_agent_id"),
+		EnvironmentId: get("compare_environment_id"),
+		ProjectId:     get("compare_project_id"),
+	}
+	if proto.Equal(c, &pb.AnalyticsComparison{}) {
+		return nil
+	}
+	return c
+}
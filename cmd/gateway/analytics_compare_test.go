@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestBaselineAnalyticsRequest(t *testing.T) {
+	now := time.UnixMilli(1_800_000_000_000)
+	week := 7 * 24 * time.Hour
+
+	// This week vs last week, from a relative window
+	req := &pb.AnalyticsRequest{TimeWindow: "24h", EnvironmentId: "canary", Deltas: true, Compare: &pb.AnalyticsComparison{Offset: "7d"}}
+	base, err := baselineAnalyticsRequest(req, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.FromTimestamp != now.Add(-week-24*time.Hour).UnixMilli() || base.ToTimestamp != now.Add(-week).UnixMilli() {
+		t.Errorf("baseline range = %d..%d", base.FromTimestamp, base.ToTimestamp)
+	}
+	if base.EnvironmentId != "canary" || base.Compare != nil || base.Deltas || req.Compare == nil {
+		t.Errorf("baseline = %+v, request = %+v", base, req)
+	}
+
+	// Canary vs stable over the same range
+	req = &pb.AnalyticsRequest{FromTimestamp: 1000, ToTimestamp: 5000, EnvironmentId: "canary", Compare: &pb.AnalyticsComparison{EnvironmentId: "stable"}}
+	if base, err = baselineAnalyticsRequest(req, now); err != nil {
+		t.Fatal(err)
+	}
+	if base.FromTimestamp != 1000 || base.ToTimestamp != 5000 || base.EnvironmentId != "stable" {
+		t.Errorf("baseline = %+v", base)
+	}
+
+	for _, bad := range []*pb.AnalyticsComparison{
+		{},
+		{Offset: "2w"},
+		{FromTimestamp: 1000},
+		{FromTimestamp: 5000, ToTimestamp: 1000},
+		{Offset: "1d", FromTimestamp: 1000, ToTimestamp: 5000},
+	} {
+		if _, err := baselineAnalyticsRequest(&pb.AnalyticsRequest{TimeWindow: "1h", Compare: bad}, now); err == nil {
+			t.Errorf("%+v should be rejected", bad)
+		}
+	}
+}
+
+func TestNewAnalyticsComparison(t *testing.T) {
+	summary := newAnalyticsComparison(
+		&pb.AnalyticsSummary{TotalRequests: 150, ErrorRate: 2, AvgLatency: 40},
+		&pb.AnalyticsSummary{TotalRequests: 100, ErrorRate: 1},
+	)
+	changes := map[string]*pb.MetricChange{}
+	for _, c := range summary.Changes {
+		changes[c.Metric] = c
+	}
+	if c := changes["total_requests"]; c.Change != 50 || c.ChangePercent != 50 {
+		t.Errorf("total_requests = %+v", c)
+	}
+	if c := changes["error_rate"]; c.Change != 1 || c.ChangePercent != 100 {
+		t.Errorf("error_rate = %+v", c)
+	}
+	if c := changes["avg_latency"]; c.Current != 40 || c.ChangePercent != 0 {
+		t.Errorf("avg_latency against an empty baseline = %+v", c)
+	}
+}
+
+func TestAnalyticsComparisonParams(t *testing.T) {
+	params := map[string]string{}
+	get := func(k string) string { return params[k] }
+	if analyticsComparisonParams(get) != nil {
+		t.Error("no compare parameters should give no comparison")
+	}
+	params["compare_offset"] = "7d"
+	params["compare_environment_id"] = "stable"
+	c := analyticsComparisonParams(get)
+	if c == nil || c.Offset != "7d" || c.EnvironmentId != "stable" {
+		t.Errorf("comparison = %+v", c)
+	}
+	if analyticsCacheKey(&pb.AnalyticsRequest{Compare: c}, tenantFilter{}) == analyticsCacheKey(&pb.AnalyticsRequest{}, tenantFilter{}) {
+		t.Error("a compare-mode request should not share the cache entry of a plain one")
+	}
+}
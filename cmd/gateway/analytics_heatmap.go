@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// latencyHeatmapBoundsMs are the upper bounds of the latency buckets of the heatmap, roughly
+// logarithmic; the last bucket holds every request above the last bound
+var latencyHeatmapBoundsMs = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// LatencyHeatmap is a 2D histogram of request latency: Counts[t][b] is the number of requests of
+// the time bucket starting at Times[t] whose latency falls in bucket b. Time buckets without
+// requests are included, so the grid is complete.
+type LatencyHeatmap struct {
+	From     int64      `json:"from"`
+	To       int64      `json:"to"`
+	Step     int64      `json:"step"`      // time bucket size in seconds
+	BoundsMs []float64  `json:"bounds_ms"` // upper bound of each latency bucket but the last
+	Times    []int64    `json:"times"`     // unix seconds
+	Counts   [][]uint64 `json:"counts"`
+	Max      uint64     `json:"max"` // largest cell, to scale colors
+}
+
+// latencyHeatmapCell is one non-empty cell as returned by ClickHouse
+type latencyHeatmapCell struct {
+	t      int64
+	bucket int
+	count  uint64
+}
+
+// GetLatencyHeatmap counts the access_logs requests of [from, to] per time bucket of step and
+// latency bucket. An empty filter covers every agent.
+func (db *ClickHouseDB) GetLatencyHeatmap(ctx context.Context, from, to time.Time, step time.Duration, filter tenantFilter) (*LatencyHeatmap, error) {
+	bounds := make([]string, len(latencyHeatmapBoundsMs))
+	for i, b := range latencyHeatmapBoundsMs {
+		bounds[i] = strconv.FormatFloat(b, 'f', -1, 64)
+	}
+	filterClause, filterArgs := filter.and()
+	where := "WHERE timestamp >= ? AND timestamp <= ? AND status > 0" + filterClause
+	args := append([]interface{}{from, to}, filterArgs...)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			toUnixTimestamp(toStartOfInterval(timestamp, INTERVAL %d SECOND)) AS t,
+			toUInt8(arrayCount(b -> request_time * 1000 >= b, [%s])) AS bucket,
+			count() AS requests
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY t, bucket
+		ORDER BY t, bucket
+	`, int64(step.Seconds()), strings.Join(bounds, ", "), where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []latencyHeatmapCell
+	for rows.Next() {
+		var t uint32
+		var bucket uint8
+		var count uint64
+		if err := rows.Scan(&t, &bucket, &count); err != nil {
+			return nil, err
+		}
+		cells = append(cells, latencyHeatmapCell{t: int64(t), bucket: int(bucket), count: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newLatencyHeatmap(from, to, step, cells), nil
+}
+
+// newLatencyHeatmap lays the cells out on the full grid of the range
+func newLatencyHeatmap(from, to time.Time, step time.Duration, cells []latencyHeatmapCell) *LatencyHeatmap {
+	stepSecs := int64(step.Seconds())
+	h := &LatencyHeatmap{
+		From:     from.Unix(),
+		To:       to.Unix(),
+		Step:     stepSecs,
+		BoundsMs: latencyHeatmapBoundsMs,
+		Times:    []int64{},
+		Counts:   [][]uint64{},
+	}
+	// Same alignment as toStartOfInterval: multiples of the step since the epoch
+	first := from.Unix() - from.Unix()%stepSecs
+	for t := first; t <= to.Unix(); t += stepSecs {
+		h.Times = append(h.Times, t)
+		h.Counts = append(h.Counts, make([]uint64, len(latencyHeatmapBoundsMs)+1))
+	}
+	for _, c := range cells {
+		i := (c.t - first) / stepSecs
+		if c.t < first || i >= int64(len(h.Times)) || c.bucket >= len(latencyHeatmapBoundsMs)+1 {
+			continue
+		}
+		h.Counts[i][c.bucket] += c.count
+		h.Max = max(h.Max, h.Counts[i][c.bucket])
+	}
+	return h
+}
+
+// handleLatencyHeatmap handles GET /api/analytics/latency-heatmap?window=1h (or from/to in unix
+// seconds) with optional agent_id (comma separated), project_id or environment_id, and
+// interval ("1m", "5m", "15m", "1h", "1d" or "auto").
+func (s *server) handleLatencyHeatmap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = "auto"
+	}
+	// The range and interval follow the rules of the metric query API
+	m, err := buildMetricQuery(MetricQuery{Metric: "latency", Window: query.Get("window"), From: from, To: to, Interval: interval}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, visible, ok := s.analyticsFilterOf(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !visible {
+		json.NewEncoder(w).Encode(newLatencyHeatmap(m.From, m.To, m.Step, nil))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	heatmap, err := s.clickhouse.GetLatencyHeatmap(ctx, m.From, m.To, m.Step, filter)
+	if err != nil {
+		logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Latency heatmap query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(heatmap)
+}
+
+// analyticsFilterOf resolves the agent_id (comma separated), environment_id or project_id of an
+// analytics HTTP request with analyticsTenantFilter, writing any error response itself
+func (s *server) analyticsFilterOf(w http.ResponseWriter, r *http.Request) (filter tenantFilter, visible, ok bool) {
+	query := r.URL.Query()
+	filter, visible, err := s.analyticsTenantFilter(r.Context(), query.Get("agent_id"), query.Get("environment_id"), query.Get("project_id"))
+	if err != nil {
+		logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Analytics agent filter: %v", err)
+		http.Error(w, `{"error":"failed to resolve agents"}`, http.StatusInternalServerError)
+		return tenantFilter{}, false, false
+	}
+	return filter, visible, true
+}
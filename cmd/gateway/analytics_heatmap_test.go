@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewLatencyHeatmap(t *testing.T) {
+	from := time.Unix(1_800_000_030, 0) // 30s into a minute
+	to := from.Add(3 * time.Minute)
+	first := from.Unix() - 30
+	h := newLatencyHeatmap(from, to, time.Minute, []latencyHeatmapCell{
+		{t: first, bucket: 0, count: 7},
+		{t: first + 120, bucket: 4, count: 3},
+		{t: first + 120, bucket: len(latencyHeatmapBoundsMs), count: 9}, // above the last bound
+		{t: first + 3600, bucket: 1, count: 1},                          // outside the range
+	})
+
+	if h.Step != 60 || len(h.Times) != 4 || h.Times[0] != first || h.Times[3] != first+180 {
+		t.Fatalf("times = %v (step %d), want 4 minutes from %d", h.Times, h.Step, first)
+	}
+	for i, row := range h.Counts {
+		if len(row) != len(latencyHeatmapBoundsMs)+1 {
+			t.Fatalf("row %d has %d buckets", i, len(row))
+		}
+	}
+	if h.Counts[0][0] != 7 || h.Counts[2][4] != 3 || h.Counts[2][len(latencyHeatmapBoundsMs)] != 9 {
+		t.Errorf("counts = %v", h.Counts)
+	}
+	if h.Counts[1][0] != 0 || h.Max != 9 {
+		t.Errorf("empty minute = %v, max = %d", h.Counts[1], h.Max)
+	}
+}
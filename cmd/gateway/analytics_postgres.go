@@ -0,0 +1,597 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/lib/pq"
+)
+
+// With analytics.backend postgres, access logs and agent metrics go to tables of the gateway's own
+// database (migration 052) instead of ClickHouse. They are copied in in batches, like the ClickHouse
+// flushers do, and read back by a pared-down GetAnalyticsWithFilter: request rate, status and
+// latency distributions, latency trend, top endpoints, servers, and system and connection history.
+// When TimescaleDB is installed the tables are hypertables and old rows are dropped chunk by chunk.
+
+const (
+	pgAnalyticsQueueSize     = 10000
+	pgAnalyticsBatchSize     = 1000
+	pgAnalyticsFlushInterval = 3 * time.Second
+)
+
+// pgAnalyticsTable is a table rows are queued for and copied into in batches
+type pgAnalyticsTable struct {
+	name    string
+	columns []string
+	rows    chan []interface{}
+}
+
+func newPGAnalyticsTable(name string, columns ...string) *pgAnalyticsTable {
+	return &pgAnalyticsTable{name: name, columns: columns, rows: make(chan []interface{}, pgAnalyticsQueueSize)}
+}
+
+// queue queues one row, in the order of the table's columns
+func (t *pgAnalyticsTable) queue(row ...interface{}) error {
+	select {
+	case t.rows <- row:
+		return nil
+	default:
+		return fmt.Errorf("%s queue full, dropping record", t.name)
+	}
+}
+
+// PostgresAnalytics is the AnalyticsStore of installs without ClickHouse
+type PostgresAnalytics struct {
+	conn      *sql.DB
+	tenants   *tenantCache
+	timescale bool
+	// Endpoint each request URI is grouped under (uri_templates.go)
+	endpoints *uriNormalizer
+
+	logs   *pgAnalyticsTable
+	system *pgAnalyticsTable
+	nginx  *pgAnalyticsTable
+}
+
+// NewPostgresAnalytics stores analytics in db, stamping rows with the tenant of their agent as
+// tenants returns them and with their endpoint, and starts its flushers
+func NewPostgresAnalytics(db *DB, tenants func() (map[string]agentTenant, error), endpoints *uriNormalizer) *PostgresAnalytics {
+	pa := &PostgresAnalytics{
+		conn:      db.conn,
+		tenants:   &tenantCache{load: tenants},
+		endpoints: endpoints,
+		logs: newPGAnalyticsTable("analytics_access_logs",
+			"timestamp", "instance_id", "project_id", "environment_id", "request_method", "request_uri",
+			"status", "body_bytes_sent", "request_length", "request_time", "endpoint"),
+		system: newPGAnalyticsTable("analytics_system_metrics",
+			"timestamp", "instance_id", "project_id", "environment_id", "cpu_usage", "memory_usage",
+			"network_rx_rate", "network_tx_rate", "cpu_user", "cpu_system", "cpu_iowait"),
+		nginx: newPGAnalyticsTable("analytics_nginx_metrics",
+			"timestamp", "instance_id", "project_id", "environment_id", "active_connections",
+			"reading", "writing", "waiting", "requests_per_second"),
+	}
+	pa.setupTimescale()
+	for _, t := range pa.tables() {
+		go pa.runFlusher(t)
+	}
+	return pa
+}
+
+func (pa *PostgresAnalytics) tables() []*pgAnalyticsTable {
+	return []*pgAnalyticsTable{pa.logs, pa.system, pa.nginx}
+}
+
+// setupTimescale turns the tables into hypertables when the server has TimescaleDB; the extension
+// needs timescaledb in shared_preload_libraries, and otherwise the tables stay plain
+func (pa *PostgresAnalytics) setupTimescale() {
+	var available bool
+	if err := pa.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')`).Scan(&available); err != nil || !available {
+		return
+	}
+	if _, err := pa.conn.Exec(`CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+		analyticsLog.Error().Msgf("TimescaleDB is available but could not be enabled, keeping plain analytics tables: %v", err)
+		return
+	}
+	for _, t := range pa.tables() {
+		if _, err := pa.conn.Exec(`SELECT create_hypertable($1, 'timestamp', if_not_exists => TRUE, migrate_data => TRUE)`, t.name); err != nil {
+			analyticsLog.Error().Msgf("Failed to make %s a hypertable, keeping plain analytics tables: %v", t.name, err)
+			return
+		}
+	}
+	pa.timescale = true
+	analyticsLog.Info().Msg("Analytics tables are TimescaleDB hypertables")
+}
+
+func (pa *PostgresAnalytics) runFlusher(t *pgAnalyticsTable) {
+	ticker := time.NewTicker(pgAnalyticsFlushInterval)
+	batch := make([][]interface{}, 0, pgAnalyticsBatchSize)
+	flush := func() {
+		if err := pa.flush(t, batch); err != nil {
+			analyticsLog.Error().Msgf("Failed to write %d rows to %s: %v", len(batch), t.name, err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case row := <-t.rows:
+			batch = append(batch, row)
+			if len(batch) >= pgAnalyticsBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flush()
+			}
+		}
+	}
+}
+
+// flush copies a batch of rows into a table
+func (pa *PostgresAnalytics) flush(t *pgAnalyticsTable, batch [][]interface{}) error {
+	ctx := context.Background()
+	conn, err := pa.conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		_, err := driverConn.(*stdlib.Conn).Conn().CopyFrom(ctx, pgx.Identifier{t.name}, t.columns, pgx.CopyFromRows(batch))
+		return err
+	})
+}
+
+// pgText makes a logged string storable in a text column, which takes neither NUL bytes nor
+// invalid UTF-8; one such row would fail its whole batch
+func pgText(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+}
+
+func (pa *PostgresAnalytics) InsertAccessLog(entry *pb.LogEntry, agentID string) error {
+	ts := time.Unix(entry.Timestamp, 0)
+	if entry.Timestamp == 0 {
+		ts = time.Now()
+	}
+	tenant := pa.tenants.get(agentID)
+	return pa.logs.queue(ts.UTC(), agentID, tenant.ProjectID, tenant.EnvironmentID,
+		pgText(entry.RequestMethod), pgText(entry.RequestUri), entry.Status, entry.BodyBytesSent,
+		entry.RequestLength, float64(entry.RequestTime), pgText(pa.endpoints.Normalize(entry.RequestUri)))
+}
+
+func (pa *PostgresAnalytics) InsertSystemMetrics(metrics *pb.SystemMetrics, agentID string) error {
+	if metrics == nil {
+		return nil
+	}
+	tenant := pa.tenants.get(agentID)
+	return pa.system.queue(time.Now().UTC(), agentID, tenant.ProjectID, tenant.EnvironmentID,
+		metrics.CpuUsagePercent, metrics.MemoryUsagePercent, metrics.NetworkRxRate, metrics.NetworkTxRate,
+		metrics.CpuUserPercent, metrics.CpuSystemPercent, metrics.CpuIowaitPercent)
+}
+
+func (pa *PostgresAnalytics) InsertNginxMetrics(metrics *pb.NginxMetrics, agentID string) error {
+	if metrics == nil {
+		return nil
+	}
+	tenant := pa.tenants.get(agentID)
+	return pa.nginx.queue(time.Now().UTC(), agentID, tenant.ProjectID, tenant.EnvironmentID,
+		metrics.ActiveConnections, metrics.Reading, metrics.Writing, metrics.Waiting, metrics.RequestsPerSecond)
+}
+
+// Prune removes rows older than before from every table and returns how many rows it deleted, or
+// on TimescaleDB how many chunks it dropped
+func (pa *PostgresAnalytics) Prune(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for _, t := range pa.tables() {
+		var n int64
+		if pa.timescale {
+			if err := pa.conn.QueryRowContext(ctx, `SELECT count(*) FROM drop_chunks($1, older_than => $2::timestamptz)`, t.name, before).Scan(&n); err != nil {
+				return total, fmt.Errorf("%s: %w", t.name, err)
+			}
+		} else {
+			res, err := pa.conn.ExecContext(ctx, `DELETE FROM `+t.name+` WHERE timestamp < $1`, before)
+			if err != nil {
+				return total, fmt.Errorf("%s: %w", t.name, err)
+			}
+			n, _ = res.RowsAffected()
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// pgArgs collects the arguments of a query, numbering their placeholders
+type pgArgs []interface{}
+
+func (a *pgArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// pgAnalyticsWhere renders the WHERE clause of an analytics read from from until to (no end when
+// zero) over the rows filter selects, else the request's agent. With accessLogs the request's URL
+// and status filters apply too; the metrics tables have neither column.
+func pgAnalyticsWhere(req *pb.AnalyticsRequest, filter tenantFilter, from, to time.Time, accessLogs bool) (string, []interface{}) {
+	var args pgArgs
+	conds := []string{"timestamp >= " + args.add(from)}
+	if !to.IsZero() {
+		conds = append(conds, "timestamp <= "+args.add(to))
+	}
+	switch {
+	case len(filter.Agents) > 0:
+		conds = append(conds, "instance_id = ANY("+args.add(pq.Array(filter.Agents))+")")
+	case filter.EnvironmentID == "" && len(filter.ProjectIDs) == 0 && req.AgentId != "" && req.AgentId != "all":
+		conds = append(conds, "instance_id = "+args.add(req.AgentId))
+	}
+	if filter.EnvironmentID != "" {
+		conds = append(conds, "environment_id = "+args.add(filter.EnvironmentID))
+	}
+	if len(filter.ProjectIDs) > 0 {
+		conds = append(conds, "project_id = ANY("+args.add(pq.Array(filter.ProjectIDs))+")")
+	}
+	if accessLogs && req.UrlFilter != "" {
+		url := args.add(req.UrlFilter)
+		conds = append(conds, "(request_uri = "+url+" OR endpoint = "+url+")")
+	}
+	if accessLogs && req.StatusCodeFilter != "" {
+		if code := req.StatusCodeFilter; len(code) == 3 && strings.HasSuffix(code, "xx") {
+			if class, err := strconv.Atoi(code[:1]); err == nil {
+				conds = append(conds, "status >= "+args.add(class*100)+" AND status < "+args.add((class+1)*100))
+			}
+		} else if n, err := strconv.Atoi(code); err == nil {
+			conds = append(conds, "status = "+args.add(n))
+		}
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// pgBucket returns the time series buckets of a window the way GetAnalyticsWithFilter buckets them
+// on ClickHouse: the expression of a bucket's start as wall clock time in tz, and the Go layout of
+// its labels
+func pgBucket(start, end time.Time, loc *time.Location, tz string) (expr, layout string) {
+	local := fmt.Sprintf("(timestamp AT TIME ZONE '%s')", tz)
+	trunc := func(unit string) string { return fmt.Sprintf("date_trunc('%s', %s)", unit, local) }
+	minutes := func(n int) string {
+		return fmt.Sprintf("%s + floor(date_part('minute', %s) / %d) * interval '%d minutes'", trunc("hour"), local, n, n)
+	}
+	switch d := end.Sub(start); {
+	case d <= time.Hour:
+		return trunc("minute"), "15:04"
+	case d <= 3*time.Hour:
+		return minutes(5), "15:04"
+	case d <= 6*time.Hour:
+		return minutes(15), "15:04"
+	case d <= 12*time.Hour:
+		if start.In(loc).Day() != end.In(loc).Day() {
+			return trunc("hour"), "01-02 15:04"
+		}
+		return trunc("hour"), "15:04"
+	case d <= 24*time.Hour:
+		return trunc("hour"), "01-02 15:04"
+	case d <= 7*24*time.Hour:
+		return trunc("hour"), "01-02 15:00"
+	default:
+		return trunc("day"), "2006-01-02"
+	}
+}
+
+// pgBucketTime returns the start of a bucket scanned as wall clock time in loc, with its label and
+// ISO 8601 time
+func pgBucketTime(wall time.Time, loc *time.Location, layout string) (t time.Time, label, iso string) {
+	t = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+	return t, t.Format(layout), t.Format("2006-01-02T15:04:05-07:00")
+}
+
+// GetAnalyticsWithFilter answers the core of an analytics request from the PostgreSQL tables:
+// summary, request rate, status and latency distributions, latency trend, top endpoints, servers,
+// and system and connection history. Other sections are left empty.
+func (pa *PostgresAnalytics) GetAnalyticsWithFilter(ctx context.Context, req *pb.AnalyticsRequest, filter tenantFilter) (*pb.AnalyticsResponse, error) {
+	tz := analyticsTimezone(req.Timezone)
+	loc, _ := time.LoadLocation(tz)
+	startTime, endTime := analyticsTimeRange(req, time.Now())
+	duration := endTime.Sub(startTime)
+	var to time.Time
+	if req.FromTimestamp > 0 && req.ToTimestamp > 0 {
+		to = endTime
+	}
+	bucket, layout := pgBucket(startTime, endTime, loc, tz)
+	where, args := pgAnalyticsWhere(req, filter, startTime, to, true)
+	metricsWhere, metricsArgs := pgAnalyticsWhere(req, filter, startTime, to, false)
+	prevWhere, prevArgs := pgAnalyticsWhere(req, filter, startTime.Add(-duration), startTime, true)
+
+	resp := &pb.AnalyticsResponse{}
+	var sections []analyticsSection
+
+	// Request rate
+	sections = append(sections, analyticsSection{"request_rate", func(ctx context.Context) error {
+		rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT %s AS bucket, count(*), count(*) FILTER (WHERE status >= 400)
+			FROM analytics_access_logs %s
+			GROUP BY bucket ORDER BY bucket`, bucket, where), args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var wall time.Time
+			var reqs, errs int64
+			if err := rows.Scan(&wall, &reqs, &errs); err != nil {
+				return err
+			}
+			_, label, iso := pgBucketTime(wall, loc, layout)
+			resp.RequestRate = append(resp.RequestRate, &pb.TimeSeriesPoint{Time: label, TimeIso: iso, Requests: reqs, Errors: errs})
+		}
+		return rows.Err()
+	}})
+
+	// Top endpoints
+	sections = append(sections, analyticsSection{"top_endpoints", func(ctx context.Context) error {
+		rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT COALESCE(NULLIF(endpoint, ''), split_part(request_uri, '?', 1)) AS path,
+				count(*) AS requests, count(*) FILTER (WHERE status >= 400),
+				COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY request_time), 0),
+				COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY request_time), 0),
+				COALESCE(sum(body_bytes_sent), 0)
+			FROM analytics_access_logs %s
+			GROUP BY path ORDER BY requests DESC LIMIT 10`, where), args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var uri string
+			var reqs, errs, bytes int64
+			var p50, p95 float64
+			if err := rows.Scan(&uri, &reqs, &errs, &p50, &p95, &bytes); err != nil {
+				return err
+			}
+			resp.TopEndpoints = append(resp.TopEndpoints, &pb.EndpointStat{
+				Uri:      uri,
+				Requests: reqs,
+				Errors:   errs,
+				P50:      float32(p50 * 1000),
+				P95:      float32(p95 * 1000),
+				Traffic:  formatBytes(bytes),
+			})
+		}
+		return rows.Err()
+	}})
+
+	// Latency trend
+	sections = append(sections, analyticsSection{"latency_trend", func(ctx context.Context) error {
+		rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT %s AS bucket,
+				percentile_cont(0.50) WITHIN GROUP (ORDER BY request_time),
+				percentile_cont(0.95) WITHIN GROUP (ORDER BY request_time),
+				percentile_cont(0.99) WITHIN GROUP (ORDER BY request_time)
+			FROM analytics_access_logs %s
+			GROUP BY bucket ORDER BY bucket`, bucket, where), args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var wall time.Time
+			var p50, p95, p99 float64
+			if err := rows.Scan(&wall, &p50, &p95, &p99); err != nil {
+				return err
+			}
+			_, label, iso := pgBucketTime(wall, loc, layout)
+			resp.LatencyTrend = append(resp.LatencyTrend, &pb.LatencyPercentiles{
+				Time:    label,
+				TimeIso: iso,
+				P50:     float32(p50 * 1000),
+				P95:     float32(p95 * 1000),
+				P99:     float32(p99 * 1000),
+			})
+		}
+		return rows.Err()
+	}})
+
+	// Summary KPIs with the status and latency distributions, over rows with a status
+	var currReqs, currErrors, currBytes, currBytesIn, prevReqs, prevErrors, prevBytes int64
+	var currLat, prevLat float64
+	var summaryOK bool
+	sections = append(sections, analyticsSection{"summary", func(ctx context.Context) error {
+		var s2xx, s3xx, s4xx, s5xx, lt0, lt1, lt2, lt3, lt4 int64
+		err := pa.conn.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT count(*), count(*) FILTER (WHERE status >= 400),
+				COALESCE(sum(body_bytes_sent), 0), COALESCE(avg(request_time), 0),
+				count(*) FILTER (WHERE status >= 200 AND status < 300),
+				count(*) FILTER (WHERE status >= 300 AND status < 400),
+				count(*) FILTER (WHERE status >= 400 AND status < 500),
+				count(*) FILTER (WHERE status >= 500),
+				count(*) FILTER (WHERE request_time < 0.05),
+				count(*) FILTER (WHERE request_time >= 0.05 AND request_time < 0.1),
+				count(*) FILTER (WHERE request_time >= 0.1 AND request_time < 0.2),
+				count(*) FILTER (WHERE request_time >= 0.2 AND request_time < 0.5),
+				count(*) FILTER (WHERE request_time >= 0.5),
+				COALESCE(sum(request_length), 0)
+			FROM analytics_access_logs %s AND status > 0`, where), args...).Scan(
+			&currReqs, &currErrors, &currBytes, &currLat, &s2xx, &s3xx, &s4xx, &s5xx,
+			&lt0, &lt1, &lt2, &lt3, &lt4, &currBytesIn)
+		if err != nil {
+			return err
+		}
+		summaryOK = true
+		for _, sc := range []struct {
+			code  string
+			count int64
+		}{
+			{"2xx", s2xx}, {"3xx", s3xx}, {"4xx", s4xx}, {"5xx", s5xx},
+		} {
+			if sc.count > 0 {
+				resp.StatusDistribution = append(resp.StatusDistribution, &pb.StatusCount{Code: sc.code, Count: sc.count})
+			}
+		}
+		for _, lb := range []struct {
+			bucket string
+			count  int64
+		}{
+			{"0-50ms", lt0}, {"50-100ms", lt1}, {"100-200ms", lt2}, {"200-500ms", lt3}, {"500ms+", lt4},
+		} {
+			if lb.count > 0 {
+				resp.LatencyDistribution = append(resp.LatencyDistribution, &pb.LatencyBucket{Bucket: lb.bucket, Count: lb.count})
+			}
+		}
+		return nil
+	}})
+	// Without the previous period the deltas are left at zero
+	sections = append(sections, analyticsSection{"summary_previous", func(ctx context.Context) error {
+		return pa.conn.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT count(*), count(*) FILTER (WHERE status >= 400),
+				COALESCE(sum(body_bytes_sent), 0), COALESCE(avg(request_time), 0)
+			FROM analytics_access_logs %s AND status > 0`, prevWhere), prevArgs...).Scan(&prevReqs, &prevErrors, &prevBytes, &prevLat)
+	}})
+
+	// Servers, when viewing all agents or a project, environment or several agents
+	if req.AgentId == "" || req.AgentId == "all" || len(filter.Agents) > 0 {
+		sections = append(sections, analyticsSection{"server_distribution", func(ctx context.Context) error {
+			rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+				SELECT instance_id, count(*) AS requests, count(*) FILTER (WHERE status >= 400),
+					COALESCE(sum(body_bytes_sent), 0)
+				FROM analytics_access_logs %s
+				GROUP BY instance_id ORDER BY requests DESC`, where), args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var id string
+				var reqs, errs, traffic int64
+				if err := rows.Scan(&id, &reqs, &errs, &traffic); err != nil {
+					return err
+				}
+				errRate := 0.0
+				if reqs > 0 {
+					errRate = float64(errs) / float64(reqs) * 100
+				}
+				resp.ServerDistribution = append(resp.ServerDistribution, &pb.ServerStat{
+					Hostname:  id,
+					Requests:  reqs,
+					ErrorRate: float32(errRate),
+					Traffic:   uint64(traffic),
+				})
+			}
+			return rows.Err()
+		}})
+	}
+
+	// System metrics history
+	sections = append(sections, analyticsSection{"system_metrics", func(ctx context.Context) error {
+		rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT %s AS bucket, avg(cpu_usage), avg(memory_usage), avg(network_rx_rate),
+				avg(network_tx_rate), avg(cpu_user), avg(cpu_system), avg(cpu_iowait)
+			FROM analytics_system_metrics %s
+			GROUP BY bucket ORDER BY bucket`, bucket, metricsWhere), metricsArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var wall time.Time
+			var cpu, mem, rx, tx, user, system, iowait float64
+			if err := rows.Scan(&wall, &cpu, &mem, &rx, &tx, &user, &system, &iowait); err != nil {
+				return err
+			}
+			_, label, iso := pgBucketTime(wall, loc, layout)
+			resp.SystemMetrics = append(resp.SystemMetrics, &pb.SystemMetricPoint{
+				Time:          label,
+				TimeIso:       iso,
+				CpuUsage:      float32(cpu),
+				MemoryUsage:   float32(mem),
+				NetworkRxRate: float32(rx),
+				NetworkTxRate: float32(tx),
+				CpuUser:       float32(user),
+				CpuSystem:     float32(system),
+				CpuIowait:     float32(iowait),
+			})
+		}
+		return rows.Err()
+	}})
+
+	// NGINX connections history
+	sections = append(sections, analyticsSection{"connections_history", func(ctx context.Context) error {
+		rows, err := pa.conn.QueryContext(ctx, fmt.Sprintf(`
+			SELECT %s AS bucket, avg(active_connections), avg(requests_per_second)
+			FROM analytics_nginx_metrics %s
+			GROUP BY bucket ORDER BY bucket`, bucket, metricsWhere), metricsArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var wall time.Time
+			var active, rps float64
+			if err := rows.Scan(&wall, &active, &rps); err != nil {
+				return err
+			}
+			t, label, iso := pgBucketTime(wall, loc, layout)
+			resp.ConnectionsHistory = append(resp.ConnectionsHistory, &pb.NginxMetricPoint{
+				Timestamp: t.Unix(),
+				Time:      label,
+				TimeIso:   iso,
+				Active:    int64(active),
+				Requests:  int64(rps),
+			})
+		}
+		return rows.Err()
+	}})
+
+	resp.Warnings = runAnalyticsSections(ctx, analyticsWorkers, analyticsSectionTimeout, sections)
+	if len(resp.Warnings) == len(sections) {
+		return nil, fmt.Errorf("all analytics queries failed: %s", resp.Warnings[0])
+	}
+	if summaryOK {
+		currErrRate, prevErrRate := 0.0, 0.0
+		if currReqs > 0 {
+			currErrRate = float64(currErrors) / float64(currReqs) * 100
+		}
+		if prevReqs > 0 {
+			prevErrRate = float64(prevErrors) / float64(prevReqs) * 100
+		}
+		resp.Summary = &pb.AnalyticsSummary{
+			TotalRequests:  currReqs,
+			ErrorRate:      float32(currErrRate),
+			AvgLatency:     float32(currLat * 1000),
+			TotalBandwidth: uint64(currBytes),
+			TotalBytesIn:   uint64(currBytesIn),
+			RequestsDelta:  float32(currReqs) - float32(prevReqs),
+			LatencyDelta:   float32((currLat - prevLat) * 1000),
+			ErrorRateDelta: float32(currErrRate - prevErrRate),
+		}
+	}
+	return resp, nil
+}
+
+// startPostgresAnalyticsRetention deletes rows of the postgres analytics backend older than
+// analytics.retention_days every hour, on the leader
+func (s *server) startPostgresAnalyticsRetention() {
+	pa, ok := s.analyticsStore.(*PostgresAnalytics)
+	if !ok || s.config == nil {
+		return
+	}
+	retention := time.Duration(s.config.Analytics.RetentionDays) * 24 * time.Hour
+	if retention <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			if n, err := pa.Prune(context.Background(), now.Add(-retention)); err != nil {
+				analyticsLog.Error().Msgf("Failed to prune analytics tables: %v", err)
+			} else if n > 0 {
+				analyticsLog.Info().Msgf("Pruned %d rows (or chunks) from the analytics tables", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/lib/pq"
+)
+
+func TestPGAnalyticsWhere(t *testing.T) {
+	from := time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	where, args := pgAnalyticsWhere(&pb.AnalyticsRequest{AgentId: "a1"}, tenantFilter{}, from, time.Time{}, true)
+	if where != "WHERE timestamp >= $1 AND instance_id = $2" || len(args) != 2 || args[1] != "a1" {
+		t.Errorf("single agent: %q %v", where, args)
+	}
+
+	req := &pb.AnalyticsRequest{AgentId: "all", UrlFilter: "/checkout", StatusCodeFilter: "5xx"}
+	filter := tenantFilter{EnvironmentID: "e1", ProjectIDs: []string{"p1", "p2"}}
+	where, args = pgAnalyticsWhere(req, filter, from, to, true)
+	want := "WHERE timestamp >= $1 AND timestamp <= $2 AND environment_id = $3 AND project_id = ANY($4) AND (request_uri = $5 OR endpoint = $5) AND status >= $6 AND status < $7"
+	if where != want {
+		t.Errorf("tenant filter:\n%s\nwant\n%s", where, want)
+	}
+	if projects, ok := args[3].(*pq.StringArray); !ok || fmt.Sprint(*projects) != "[p1 p2]" {
+		t.Errorf("projects %v", args[3])
+	}
+	if got := fmt.Sprint(args[2], args[4:]); got != "e1[/checkout 500 600]" {
+		t.Errorf("tenant filter args %s", got)
+	}
+
+	// The metrics tables have no URL or status, and agents of the filter win over the request's
+	where, _ = pgAnalyticsWhere(&pb.AnalyticsRequest{AgentId: "a1", StatusCodeFilter: "404"}, tenantFilter{Agents: []string{"a2", "a3"}}, from, time.Time{}, false)
+	if where != "WHERE timestamp >= $1 AND instance_id = ANY($2)" {
+		t.Errorf("metrics: %q", where)
+	}
+	where, args = pgAnalyticsWhere(&pb.AnalyticsRequest{StatusCodeFilter: "404"}, tenantFilter{}, from, time.Time{}, true)
+	if where != "WHERE timestamp >= $1 AND status = $2" || args[1] != 404 {
+		t.Errorf("status code: %q %v", where, args)
+	}
+}
+
+func TestPGBucket(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	end := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window time.Duration
+		trunc  string
+		layout string
+	}{
+		{time.Hour, "date_trunc('minute'", "15:04"},
+		{3 * time.Hour, "interval '5 minutes'", "15:04"},
+		{6 * time.Hour, "interval '15 minutes'", "15:04"},
+		{24 * time.Hour, "date_trunc('hour'", "01-02 15:04"},
+		{7 * 24 * time.Hour, "date_trunc('hour'", "01-02 15:00"},
+		{30 * 24 * time.Hour, "date_trunc('day'", "2006-01-02"},
+	}
+	for _, tt := range tests {
+		expr, layout := pgBucket(end.Add(-tt.window), end, loc, "Asia/Kolkata")
+		if !strings.Contains(expr, tt.trunc) || !strings.Contains(expr, "(timestamp AT TIME ZONE 'Asia/Kolkata')") || layout != tt.layout {
+			t.Errorf("%s: %q %q", tt.window, expr, layout)
+		}
+	}
+
+	// Buckets come back as wall clock time of the timezone
+	wall := time.Date(2026, 10, 17, 17, 30, 0, 0, time.UTC)
+	bucket, label, iso := pgBucketTime(wall, loc, "15:04")
+	if !bucket.Equal(end) || label != "17:30" || iso != "2026-10-17T17:30:00+05:30" {
+		t.Errorf("bucket %v %q %q", bucket, label, iso)
+	}
+}
+
+func TestPGText(t *testing.T) {
+	if got := pgText("/a\x00b\xffc"); got != "/ab�c" {
+		t.Errorf("pgText = %q", got)
+	}
+}
@@ -0,0 +1,682 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// AnalyticsQuery is an ad-hoc query of one ClickHouse table, given as a small AST rather than SQL:
+// tables, columns, functions and operators come from queryTables and the lists below, values are
+// bound as parameters, and the rows are always limited and narrowed to what the caller may see.
+type AnalyticsQuery struct {
+	Table    string           `json:"table"`
+	Select   []QuerySelect    `json:"select"`
+	Where    []QueryCondition `json:"where,omitempty"`
+	GroupBy  []string         `json:"group_by,omitempty"`
+	Interval string           `json:"interval,omitempty"` // time bucket, returned first as column "t"; see metricIntervals, or "auto"
+	OrderBy  []QueryOrder     `json:"order_by,omitempty"`
+	Window   string           `json:"window,omitempty"` // e.g. "1h", "24h", "7d"; ignored when from/to are set
+	From     int64            `json:"from,omitempty"`   // unix seconds
+	To       int64            `json:"to,omitempty"`     // unix seconds
+	Limit    int              `json:"limit,omitempty"`  // max rows, 100 by default, capped by the caller's role
+
+	AgentID       string `json:"agent_id,omitempty"` // comma separated
+	ProjectID     string `json:"project_id,omitempty"`
+	EnvironmentID string `json:"environment_id,omitempty"`
+}
+
+// QuerySelect is one result column: a column, or a function of one (count needs none)
+type QuerySelect struct {
+	Column string `json:"column,omitempty"`
+	Func   string `json:"func,omitempty"`
+	As     string `json:"as,omitempty"` // name in the result; by default the column, or func_column
+}
+
+// QueryCondition filters rows on a column. Value is a string or number matching the column,
+// or a list of them for "in" and "not in".
+type QueryCondition struct {
+	Column string      `json:"column"`
+	Op     string      `json:"op"`
+	Value  interface{} `json:"value"`
+}
+
+// QueryOrder sorts by a result column
+type QueryOrder struct {
+	By   string `json:"by"`
+	Desc bool   `json:"desc,omitempty"`
+}
+
+// QueryResultColumn names a result column and its type: string, number or time (unix seconds)
+type QueryResultColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type AnalyticsQueryResult struct {
+	Columns   []QueryResultColumn `json:"columns"`
+	Rows      [][]interface{}     `json:"rows"`
+	From      int64               `json:"from"`
+	To        int64               `json:"to"`
+	Truncated bool                `json:"truncated"` // more rows matched than the limit
+}
+
+const (
+	queryString = "string"
+	queryNumber = "number"
+	queryTime   = "time"
+)
+
+// queryColumn is a queryable column; expr is its SQL when it is not the column itself
+type queryColumn struct {
+	Type string `json:"type"`
+	expr string
+}
+
+func (c queryColumn) sql(name string) string {
+	if c.expr != "" {
+		return c.expr
+	}
+	return name
+}
+
+type queryTable struct {
+	TimeColumn string                 `json:"time_column"`
+	Columns    map[string]queryColumn `json:"columns"`
+}
+
+var (
+	queryStringColumn = queryColumn{Type: queryString}
+	queryNumberColumn = queryColumn{Type: queryNumber}
+	queryTimeColumn   = queryColumn{Type: queryTime}
+)
+
+// queryTables are the tables open to ad-hoc queries, all stamped with tenant columns
+// (clickhouse_tenancy.go) so callers only see their projects' rows
+var queryTables = map[string]queryTable{
+	"access_logs": {TimeColumn: "timestamp", Columns: map[string]queryColumn{
+		"timestamp": queryTimeColumn, "instance_id": queryStringColumn, "client_ip": queryStringColumn,
+		"request_method": queryStringColumn, "request_uri": queryStringColumn, "endpoint": {Type: queryString, expr: endpointSQL},
+		"status": queryNumberColumn, "status_class": {Type: queryString, expr: metricDimensions["status_class"]},
+		"body_bytes_sent": queryNumberColumn, "request_length": queryNumberColumn, "request_time": queryNumberColumn,
+		"upstream_addr": queryStringColumn, "upstream_status": queryStringColumn, "upstream_cache_status": queryStringColumn,
+		"upstream_connect_time": queryNumberColumn, "upstream_header_time": queryNumberColumn, "upstream_response_time": queryNumberColumn,
+		"user_agent": queryStringColumn, "referer": queryStringColumn, "country_code": queryStringColumn, "city": queryStringColumn,
+		"asn": queryNumberColumn, "is_bot": queryNumberColumn, "bot_category": queryStringColumn, "browser_family": queryStringColumn,
+		"os_family": queryStringColumn, "device_type": queryStringColumn, "threat_category": queryStringColumn,
+		"project_id": queryStringColumn, "environment_id": queryStringColumn,
+	}},
+	"system_metrics": {TimeColumn: "timestamp", Columns: map[string]queryColumn{
+		"timestamp": queryTimeColumn, "instance_id": queryStringColumn, "cpu_usage": queryNumberColumn,
+		"memory_usage": queryNumberColumn, "memory_total": queryNumberColumn, "memory_used": queryNumberColumn,
+		"network_rx_rate": queryNumberColumn, "network_tx_rate": queryNumberColumn, "cpu_user": queryNumberColumn,
+		"cpu_system": queryNumberColumn, "cpu_iowait": queryNumberColumn, "fd_used_percent": queryNumberColumn,
+		"nginx_fd_used_percent": queryNumberColumn, "conntrack_used_percent": queryNumberColumn,
+		"project_id": queryStringColumn, "environment_id": queryStringColumn,
+	}},
+	"nginx_metrics": {TimeColumn: "timestamp", Columns: map[string]queryColumn{
+		"timestamp": queryTimeColumn, "instance_id": queryStringColumn, "nginx_instance": {Type: queryString, expr: metricDimensions["nginx_instance"]},
+		"active_connections": queryNumberColumn, "requests_per_second": queryNumberColumn, "reading": queryNumberColumn,
+		"writing": queryNumberColumn, "waiting": queryNumberColumn, "status_2xx": queryNumberColumn, "status_3xx": queryNumberColumn,
+		"status_4xx": queryNumberColumn, "status_5xx": queryNumberColumn, "worker_count": queryNumberColumn,
+		"worker_restarts": queryNumberColumn, "worker_crashes": queryNumberColumn,
+		"project_id": queryStringColumn, "environment_id": queryStringColumn,
+	}},
+	"spans": {TimeColumn: "start_time", Columns: map[string]queryColumn{
+		"start_time": queryTimeColumn, "instance_id": queryStringColumn, "trace_id": queryStringColumn,
+		"span_id": queryStringColumn, "parent_span_id": queryStringColumn, "name": queryStringColumn,
+		"project_id": queryStringColumn, "environment_id": queryStringColumn,
+		"duration_ms": {Type: queryNumber, expr: "(toUnixTimestamp64Milli(end_time) - toUnixTimestamp64Milli(start_time))"},
+	}},
+}
+
+// queryFuncs are the aggregate functions, with the column types they accept ("" for count,
+// which needs no column)
+var queryFuncs = map[string][]string{
+	"count": {"", queryString, queryNumber, queryTime},
+	"uniq":  {queryString, queryNumber},
+	"sum":   {queryNumber}, "avg": {queryNumber}, "min": {queryNumber}, "max": {queryNumber},
+	"p50": {queryNumber}, "p90": {queryNumber}, "p95": {queryNumber}, "p99": {queryNumber},
+}
+
+var queryOps = map[string]string{
+	"=": "=", "!=": "!=", ">": ">", ">=": ">=", "<": "<", "<=": "<=",
+	"in": "IN", "not in": "NOT IN", "like": "LIKE", "not like": "NOT LIKE",
+}
+
+var queryNameRe = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+const (
+	queryMaxSelect     = 20
+	queryMaxConditions = 20
+	queryMaxGroupBy    = 5
+	queryDefaultLimit  = 100
+)
+
+// analyticsQuerySQL is a validated, ready-to-run ad-hoc query
+type analyticsQuerySQL struct {
+	SQL      string
+	Args     []interface{}
+	Columns  []QueryResultColumn
+	From, To time.Time
+	Limit    int
+}
+
+// buildAnalyticsQuery validates q and produces its ClickHouse query, rows narrowed by filter and
+// bounded by the caller's policy. Result columns are selected without aliases, so they never
+// shadow the columns of WHERE, and come back in the order of Columns.
+func buildAnalyticsQuery(q AnalyticsQuery, policy config.QueryRolePolicy, filter tenantFilter, now time.Time) (*analyticsQuerySQL, error) {
+	table, ok := queryTables[q.Table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", q.Table)
+	}
+	if len(q.Select) == 0 || len(q.Select) > queryMaxSelect {
+		return nil, fmt.Errorf("select 1 to %d columns", queryMaxSelect)
+	}
+	if len(q.Where) > queryMaxConditions {
+		return nil, fmt.Errorf("at most %d conditions", queryMaxConditions)
+	}
+	if len(q.GroupBy) > queryMaxGroupBy {
+		return nil, fmt.Errorf("group by at most %d columns", queryMaxGroupBy)
+	}
+	column := func(name string) (queryColumn, error) {
+		c, ok := table.Columns[name]
+		if !ok {
+			return queryColumn{}, fmt.Errorf("unknown column %q of %s", name, q.Table)
+		}
+		return c, nil
+	}
+
+	m := &analyticsQuerySQL{To: now.UTC()}
+	switch {
+	case q.From > 0:
+		m.From = time.Unix(q.From, 0).UTC()
+		if q.To > 0 {
+			m.To = time.Unix(q.To, 0).UTC()
+		}
+	default:
+		window := q.Window
+		if window == "" {
+			window = "1h"
+		}
+		d, ok := metricWindows[window]
+		if !ok {
+			return nil, fmt.Errorf("unknown window %q", window)
+		}
+		m.From = m.To.Add(-d)
+	}
+	if !m.To.After(m.From) {
+		return nil, fmt.Errorf("'to' must be after 'from'")
+	}
+	if policy.MaxRange > 0 && m.To.Sub(m.From) > policy.MaxRange {
+		return nil, fmt.Errorf("time range exceeds %s", policy.MaxRange)
+	}
+
+	var step time.Duration
+	switch q.Interval {
+	case "":
+	case "auto":
+		step = autoMetricInterval(m.To.Sub(m.From))
+	default:
+		d, ok := metricIntervals[q.Interval]
+		if !ok {
+			return nil, fmt.Errorf("unknown interval %q", q.Interval)
+		}
+		step = d
+	}
+	if step > 0 && m.To.Sub(m.From)/step > metricMaxBuckets {
+		return nil, fmt.Errorf("interval %s is too small for the time range", q.Interval)
+	}
+
+	groupBy := make([]string, 0, len(q.GroupBy)+1)
+	grouped := map[string]bool{}
+	for _, name := range q.GroupBy {
+		c, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		if c.Type == queryTime {
+			return nil, fmt.Errorf("group %s by time with interval", q.Table)
+		}
+		grouped[name] = true
+		groupBy = append(groupBy, c.sql(name))
+	}
+
+	// exprs holds the SQL of each result column, by name, for ORDER BY
+	exprs := map[string]string{}
+	var sel []string
+	add := func(name, typ, expr string) error {
+		if !queryNameRe.MatchString(name) {
+			return fmt.Errorf("invalid column name %q", name)
+		}
+		if _, dup := exprs[name]; dup {
+			return fmt.Errorf("duplicate column name %q", name)
+		}
+		exprs[name] = expr
+		sel = append(sel, expr)
+		m.Columns = append(m.Columns, QueryResultColumn{Name: name, Type: typ})
+		return nil
+	}
+	if step > 0 {
+		bucket := fmt.Sprintf("toStartOfInterval(%s, INTERVAL %d SECOND)", table.TimeColumn, int64(step.Seconds()))
+		if err := add("t", queryTime, "toInt64(toUnixTimestamp("+bucket+"))"); err != nil {
+			return nil, err
+		}
+		groupBy = append([]string{bucket}, groupBy...)
+	}
+	aggregated := false
+	for _, s := range q.Select {
+		if s.Func != "" {
+			aggregated = true
+		}
+	}
+	for _, s := range q.Select {
+		name := s.As
+		if s.Func == "" {
+			c, err := column(s.Column)
+			if err != nil {
+				return nil, err
+			}
+			if len(groupBy) > 0 || aggregated {
+				if !grouped[s.Column] {
+					return nil, fmt.Errorf("column %q must be in group_by or aggregated", s.Column)
+				}
+			}
+			if name == "" {
+				name = s.Column
+			}
+			expr := c.sql(s.Column)
+			switch c.Type {
+			case queryString:
+				expr = "toString(" + expr + ")"
+			case queryNumber:
+				expr = "toFloat64(" + expr + ")"
+			case queryTime:
+				expr = "toInt64(toUnixTimestamp(" + expr + "))"
+			}
+			if err := add(name, c.Type, expr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		types, ok := queryFuncs[s.Func]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", s.Func)
+		}
+		var c queryColumn
+		if s.Column != "" {
+			var err error
+			if c, err = column(s.Column); err != nil {
+				return nil, err
+			}
+		}
+		if s.Column == "" && s.Func != "count" {
+			return nil, fmt.Errorf("%s needs a column", s.Func)
+		}
+		if !stringInSlice(types, c.Type) {
+			return nil, fmt.Errorf("%s does not apply to %q", s.Func, s.Column)
+		}
+		if name == "" {
+			name = s.Func
+			if s.Column != "" {
+				name += "_" + s.Column
+			}
+		}
+		var agg string
+		switch s.Func {
+		case "count":
+			agg = "count()"
+		case "p50", "p90", "p95", "p99":
+			agg = fmt.Sprintf("quantile(0.%s)(%s)", s.Func[1:], c.sql(s.Column))
+		default:
+			agg = s.Func + "(" + c.sql(s.Column) + ")"
+		}
+		if err := add(name, queryNumber, "toFloat64("+agg+")"); err != nil {
+			return nil, err
+		}
+	}
+
+	where := []string{table.TimeColumn + " >= ?", table.TimeColumn + " <= ?"}
+	args := []interface{}{m.From, m.To}
+	if cond, condArgs := filter.cond(""); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+	for _, cond := range q.Where {
+		c, err := column(cond.Column)
+		if err != nil {
+			return nil, err
+		}
+		op, ok := queryOps[cond.Op]
+		if !ok {
+			return nil, fmt.Errorf("unknown operator %q", cond.Op)
+		}
+		if c.Type == queryTime {
+			return nil, fmt.Errorf("filter %s by time with window or from/to", q.Table)
+		}
+		value, err := queryValue(cond, c.Type)
+		if err != nil {
+			return nil, err
+		}
+		if op == "IN" || op == "NOT IN" {
+			where = append(where, c.sql(cond.Column)+" "+op+" (?)")
+		} else {
+			where = append(where, c.sql(cond.Column)+" "+op+" ?")
+		}
+		args = append(args, value)
+	}
+
+	var order []string
+	for _, o := range q.OrderBy {
+		expr, ok := exprs[o.By]
+		if !ok {
+			return nil, fmt.Errorf("cannot order by %q: not a result column", o.By)
+		}
+		if o.Desc {
+			expr += " DESC"
+		}
+		order = append(order, expr)
+	}
+	if len(order) == 0 {
+		switch {
+		case step > 0:
+			order = append(order, exprs["t"])
+		case !aggregated && len(groupBy) == 0:
+			order = append(order, table.TimeColumn+" DESC")
+		}
+	}
+
+	m.Limit = q.Limit
+	if m.Limit <= 0 {
+		m.Limit = queryDefaultLimit
+	}
+	if policy.MaxRows > 0 && m.Limit > policy.MaxRows {
+		m.Limit = policy.MaxRows
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM nginx_analytics.%s WHERE %s", strings.Join(sel, ", "), q.Table, strings.Join(where, " AND "))
+	if len(groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(groupBy, ", ")
+	}
+	if len(order) > 0 {
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	// One row past the limit tells whether the result was truncated
+	query += fmt.Sprintf(" LIMIT %d", m.Limit+1)
+
+	m.SQL = query
+	m.Args = args
+	return m, nil
+}
+
+// queryValue checks the value of a condition against the type of its column
+func queryValue(cond QueryCondition, typ string) (interface{}, error) {
+	list := cond.Op == "in" || cond.Op == "not in"
+	if (cond.Op == "like" || cond.Op == "not like") && typ != queryString {
+		return nil, fmt.Errorf("%s applies to string columns, not %q", cond.Op, cond.Column)
+	}
+	scalar := func(v interface{}) (interface{}, bool) {
+		switch v := v.(type) {
+		case string:
+			return v, typ == queryString
+		case float64:
+			return v, typ == queryNumber
+		}
+		return nil, false
+	}
+	if !list {
+		v, ok := scalar(cond.Value)
+		if !ok {
+			return nil, fmt.Errorf("%q needs a %s value", cond.Column, typ)
+		}
+		return v, nil
+	}
+	values, ok := cond.Value.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("%s on %q needs a list of values", cond.Op, cond.Column)
+	}
+	strs := make([]string, 0, len(values))
+	nums := make([]float64, 0, len(values))
+	for _, v := range values {
+		v, ok := scalar(v)
+		if !ok {
+			return nil, fmt.Errorf("%q needs %s values", cond.Column, typ)
+		}
+		switch v := v.(type) {
+		case string:
+			strs = append(strs, v)
+		case float64:
+			nums = append(nums, v)
+		}
+	}
+	if typ == queryString {
+		return strs, nil
+	}
+	return nums, nil
+}
+
+// RunAnalyticsQuery executes a built ad-hoc query within the ClickHouse limits of cfg
+func (db *ClickHouseDB) RunAnalyticsQuery(ctx context.Context, m *analyticsQuerySQL, cfg config.AnalyticsQueryConfig) (*AnalyticsQueryResult, error) {
+	settings := clickhouse.Settings{}
+	if cfg.Timeout > 0 {
+		settings["max_execution_time"] = int(math.Ceil(cfg.Timeout.Seconds()))
+	}
+	if cfg.MaxRowsRead > 0 {
+		settings["max_rows_to_read"] = cfg.MaxRowsRead
+	}
+	rows, err := db.conn.Query(clickhouse.Context(ctx, clickhouse.WithSettings(settings)), m.SQL, m.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &AnalyticsQueryResult{Columns: m.Columns, Rows: [][]interface{}{}, From: m.From.Unix(), To: m.To.Unix()}
+	for rows.Next() {
+		if len(result.Rows) == m.Limit {
+			result.Truncated = true
+			break
+		}
+		dest := make([]interface{}, len(m.Columns))
+		for i, c := range m.Columns {
+			switch c.Type {
+			case queryString:
+				dest[i] = new(string)
+			case queryNumber:
+				dest[i] = new(float64)
+			case queryTime:
+				dest[i] = new(int64)
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, len(dest))
+		for i, d := range dest {
+			switch d := d.(type) {
+			case *string:
+				row[i] = *d
+			case *float64:
+				// avg and quantiles of no rows are NaN, which JSON cannot carry
+				if math.IsNaN(*d) || math.IsInf(*d, 0) {
+					row[i] = nil
+				} else {
+					row[i] = *d
+				}
+			case *int64:
+				row[i] = *d
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+// queryQuotas counts the ad-hoc queries of each user in hourly windows starting at their first
+// query
+type queryQuotas struct {
+	mu        sync.Mutex
+	windows   map[string]queryWindow
+	lastPrune time.Time
+}
+
+type queryWindow struct {
+	start time.Time
+	count int
+}
+
+func newQueryQuotas() *queryQuotas {
+	return &queryQuotas{windows: map[string]queryWindow{}}
+}
+
+// take counts a query of user against perHour (0 unlimited). When the quota is used up it
+// returns false and how long until the window ends. A nil tracker counts nothing.
+func (q *queryQuotas) take(user string, perHour int, now time.Time) (time.Duration, bool) {
+	if q == nil || perHour <= 0 {
+		return 0, true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if now.Sub(q.lastPrune) >= time.Minute {
+		for u, w := range q.windows {
+			if now.Sub(w.start) >= time.Hour {
+				delete(q.windows, u)
+			}
+		}
+		q.lastPrune = now
+	}
+	w, ok := q.windows[user]
+	if !ok || now.Sub(w.start) >= time.Hour {
+		w = queryWindow{start: now}
+	}
+	if w.count >= perHour {
+		return w.start.Add(time.Hour).Sub(now), false
+	}
+	w.count++
+	q.windows[user] = w
+	return 0, true
+}
+
+// queryPolicyOf returns the query policy of the caller's role; ok is false when the role may not
+// run ad-hoc queries. Without authentication the caller is an admin.
+func (s *server) queryPolicyOf(r *http.Request) (username string, policy config.QueryRolePolicy, ok bool, err error) {
+	role := "admin"
+	user, err := s.callerOf(r.Context())
+	if err != nil {
+		return "", config.QueryRolePolicy{}, false, err
+	}
+	if user != nil {
+		username, role = user.Username, user.Role
+	}
+	policy, ok = s.config.Analytics.Query.Roles[role]
+	return username, policy, ok, nil
+}
+
+// handleAnalyticsQuery handles POST /api/analytics/query: runs an AnalyticsQuery within the limits
+// of the caller's role. For example, the slowest endpoints among server errors:
+// {"table":"access_logs","select":[{"column":"endpoint"},{"func":"p95","column":"request_time"}],
+// "where":[{"column":"status","op":">=","value":500}],"group_by":["endpoint"],"window":"24h",
+// "order_by":[{"by":"p95_request_time","desc":true}],"limit":20}
+func (s *server) handleAnalyticsQuery(w http.ResponseWriter, r *http.Request) {
+	var q AnalyticsQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	username, policy, allowed, err := s.queryPolicyOf(r)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, `{"error":"your role may not run ad-hoc queries"}`, http.StatusForbidden)
+		return
+	}
+	filter, visible, err := s.analyticsTenantFilter(r.Context(), q.AgentID, q.EnvironmentID, q.ProjectID)
+	if err != nil {
+		logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Analytics agent filter: %v", err)
+		http.Error(w, `{"error":"failed to resolve agents"}`, http.StatusInternalServerError)
+		return
+	}
+	m, err := buildAnalyticsQuery(q, policy, filter, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !visible {
+		json.NewEncoder(w).Encode(&AnalyticsQueryResult{Columns: m.Columns, Rows: [][]interface{}{}, From: m.From.Unix(), To: m.To.Unix()})
+		return
+	}
+	if wait, ok := s.queryQuotas.take(username, policy.QueriesPerHour, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, fmt.Sprintf(`{"error":"query quota of %d per hour used up"}`, policy.QueriesPerHour), http.StatusTooManyRequests)
+		return
+	}
+
+	analyticsLog.Info().Msgf("Ad-hoc query by %q of %s: %s", username, q.Table, m.SQL)
+	timeout := s.config.Analytics.Query.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	// Leave ClickHouse's own max_execution_time the chance to fail the query first
+	ctx, cancel := context.WithTimeout(r.Context(), timeout+5*time.Second)
+	defer cancel()
+	result, err := s.clickhouse.RunAnalyticsQuery(ctx, m, s.config.Analytics.Query)
+	if err != nil {
+		logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Ad-hoc query of %s failed: %v", q.Table, err)
+		http.Error(w, fmt.Sprintf(`{"error":"query failed: %s"}`, escapeJSON(err.Error())), clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAnalyticsQueryCatalog handles GET /api/analytics/query/catalog: the tables, columns,
+// functions and operators of /api/analytics/query, and the caller's limits
+func (s *server) handleAnalyticsQueryCatalog(w http.ResponseWriter, r *http.Request) {
+	_, policy, allowed, err := s.queryPolicyOf(r)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	funcs := make([]string, 0, len(queryFuncs))
+	for f := range queryFuncs {
+		funcs = append(funcs, f)
+	}
+	ops := make([]string, 0, len(queryOps))
+	for op := range queryOps {
+		ops = append(ops, op)
+	}
+	sort.Strings(funcs)
+	sort.Strings(ops)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tables":    queryTables,
+		"functions": funcs,
+		"operators": ops,
+		"allowed":   allowed,
+		"limits": map[string]interface{}{
+			"queries_per_hour": policy.QueriesPerHour,
+			"max_rows":         policy.MaxRows,
+			"max_range_sec":    int64(policy.MaxRange.Seconds()),
+		},
+	})
+}
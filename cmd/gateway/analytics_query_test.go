@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+var testQueryPolicy = config.QueryRolePolicy{QueriesPerHour: 60, MaxRows: 1000, MaxRange: 7 * 24 * time.Hour}
+
+func TestBuildAnalyticsQuery(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	var q AnalyticsQuery
+	if err := json.Unmarshal([]byte(`{"table":"access_logs",
+		"select":[{"column":"endpoint"},{"func":"count","as":"requests"},{"func":"p95","column":"request_time"}],
+		"where":[{"column":"status","op":">=","value":500},{"column":"request_method","op":"in","value":["GET","POST"]}],
+		"group_by":["endpoint"],"interval":"1h","window":"24h",
+		"order_by":[{"by":"requests","desc":true}],"limit":5000}`), &q); err != nil {
+		t.Fatal(err)
+	}
+	m, err := buildAnalyticsQuery(q, testQueryPolicy, tenantFilter{ProjectIDs: []string{"p1"}}, now)
+	if err != nil {
+		t.Fatalf("buildAnalyticsQuery: %v", err)
+	}
+	for _, want := range []string{
+		"SELECT toInt64(toUnixTimestamp(toStartOfInterval(timestamp, INTERVAL 3600 SECOND))), toString(" + endpointSQL + "), toFloat64(count()), toFloat64(quantile(0.95)(request_time))",
+		"FROM nginx_analytics.access_logs WHERE timestamp >= ? AND timestamp <= ? AND project_id IN (?) AND status >= ? AND request_method IN (?)",
+		"GROUP BY toStartOfInterval(timestamp, INTERVAL 3600 SECOND), " + endpointSQL,
+		"ORDER BY toFloat64(count()) DESC LIMIT 1001",
+	} {
+		if !strings.Contains(m.SQL, want) {
+			t.Errorf("SQL missing %q:\n%s", want, m.SQL)
+		}
+	}
+	wantArgs := []interface{}{now.Add(-24 * time.Hour), now, []string{"p1"}, 500.0, []string{"GET", "POST"}}
+	if !reflect.DeepEqual(m.Args, wantArgs) {
+		t.Errorf("args = %v, want %v", m.Args, wantArgs)
+	}
+	wantColumns := []QueryResultColumn{{"t", "time"}, {"endpoint", "string"}, {"requests", "number"}, {"p95_request_time", "number"}}
+	if !reflect.DeepEqual(m.Columns, wantColumns) {
+		t.Errorf("columns = %v, want %v", m.Columns, wantColumns)
+	}
+	if m.Limit != 1000 {
+		t.Errorf("limit = %d, want the role's 1000", m.Limit)
+	}
+}
+
+func TestBuildAnalyticsQueryRawRows(t *testing.T) {
+	m, err := buildAnalyticsQuery(AnalyticsQuery{
+		Table:  "spans",
+		Select: []QuerySelect{{Column: "start_time"}, {Column: "name"}, {Column: "duration_ms"}},
+		Where:  []QueryCondition{{Column: "name", Op: "like", Value: "GET %"}},
+	}, testQueryPolicy, tenantFilter{}, time.Now())
+	if err != nil {
+		t.Fatalf("buildAnalyticsQuery: %v", err)
+	}
+	if !strings.Contains(m.SQL, "name LIKE ? ORDER BY start_time DESC LIMIT 101") {
+		t.Errorf("raw rows should be newest first with the default limit:\n%s", m.SQL)
+	}
+	if strings.Contains(m.SQL, "GROUP BY") || strings.Contains(m.SQL, " AS ") {
+		t.Errorf("raw rows need no grouping or aliases:\n%s", m.SQL)
+	}
+}
+
+func TestBuildAnalyticsQueryInvalid(t *testing.T) {
+	count := QuerySelect{Func: "count"}
+	for name, q := range map[string]AnalyticsQuery{
+		"unknown table":        {Table: "users", Select: []QuerySelect{count}},
+		"no select":            {Table: "access_logs"},
+		"unknown column":       {Table: "access_logs", Select: []QuerySelect{{Column: "password"}}},
+		"unknown function":     {Table: "access_logs", Select: []QuerySelect{{Func: "sleep", Column: "status"}}},
+		"sum of a string":      {Table: "access_logs", Select: []QuerySelect{{Func: "sum", Column: "request_uri"}}},
+		"function needs col":   {Table: "access_logs", Select: []QuerySelect{{Func: "avg"}}},
+		"column not grouped":   {Table: "access_logs", Select: []QuerySelect{{Column: "request_uri"}, count}},
+		"group by time":        {Table: "access_logs", Select: []QuerySelect{count}, GroupBy: []string{"timestamp"}},
+		"bad alias":            {Table: "access_logs", Select: []QuerySelect{{Func: "count", As: "x; DROP TABLE"}}},
+		"duplicate name":       {Table: "access_logs", Select: []QuerySelect{count, count}},
+		"unknown operator":     {Table: "access_logs", Select: []QuerySelect{count}, Where: []QueryCondition{{Column: "status", Op: "~", Value: 1.0}}},
+		"string for a number":  {Table: "access_logs", Select: []QuerySelect{count}, Where: []QueryCondition{{Column: "status", Op: "=", Value: "500"}}},
+		"like on a number":     {Table: "access_logs", Select: []QuerySelect{count}, Where: []QueryCondition{{Column: "status", Op: "like", Value: "5%"}}},
+		"in without a list":    {Table: "access_logs", Select: []QuerySelect{count}, Where: []QueryCondition{{Column: "status", Op: "in", Value: 500.0}}},
+		"filter by time":       {Table: "access_logs", Select: []QuerySelect{count}, Where: []QueryCondition{{Column: "timestamp", Op: ">", Value: 1.0}}},
+		"order by non-result":  {Table: "access_logs", Select: []QuerySelect{count}, OrderBy: []QueryOrder{{By: "status"}}},
+		"range over the role":  {Table: "access_logs", Select: []QuerySelect{count}, Window: "30d"},
+		"interval too small":   {Table: "access_logs", Select: []QuerySelect{count}, Window: "7d", Interval: "1m"},
+		"unknown time window":  {Table: "access_logs", Select: []QuerySelect{count}, Window: "2w"},
+		"to before from":       {Table: "access_logs", Select: []QuerySelect{count}, From: 2000, To: 1000},
+		"too many group by":    {Table: "access_logs", Select: []QuerySelect{count}, GroupBy: []string{"status", "city", "asn", "referer", "os_family", "device_type"}},
+		"time column in count": {Table: "access_logs", Select: []QuerySelect{{Func: "uniq", Column: "timestamp"}}},
+	} {
+		if _, err := buildAnalyticsQuery(q, testQueryPolicy, tenantFilter{}, time.Now()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestQueryQuotas(t *testing.T) {
+	q := newQueryQuotas()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if _, ok := q.take("alice", 3, now.Add(time.Duration(i)*time.Minute)); !ok {
+			t.Fatalf("query %d refused within the quota", i+1)
+		}
+	}
+	wait, ok := q.take("alice", 3, now.Add(10*time.Minute))
+	if ok || wait != 50*time.Minute {
+		t.Errorf("fourth query = %v, %v; want refused for 50m", ok, wait)
+	}
+	if _, ok := q.take("bob", 3, now.Add(10*time.Minute)); !ok {
+		t.Error("quotas should be per user")
+	}
+	if _, ok := q.take("alice", 3, now.Add(time.Hour)); !ok {
+		t.Error("a new window should start after an hour")
+	}
+	if _, ok := q.take("alice", 0, now.Add(time.Hour)); !ok {
+		t.Error("0 should be unlimited")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// callerOf returns the user behind a request: the one the auth middleware put in the context of
+// HTTP requests, or the one of the session token a gRPC client forwarded in its authorization
+// metadata ("Bearer <token>"). It is nil for gRPC calls without a token (trusted services such as
+// the frontend acting for the whole fleet) and when authentication is disabled.
+func (s *server) callerOf(ctx context.Context) (*middleware.User, error) {
+	if user := middleware.GetUserFromContext(ctx); user != nil {
+		return user, nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || s.authManager == nil || !s.authManager.IsEnabled() {
+		return nil, nil
+	}
+	for _, v := range md.Get("authorization") {
+		token := strings.TrimSpace(strings.TrimPrefix(v, "Bearer "))
+		if token == "" {
+			continue
+		}
+		user, valid := s.authManager.ValidateToken(token)
+		if !valid {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		return user, nil
+	}
+	return nil, nil
+}
+
+// tenantFilter selects the rows of a ClickHouse read. Agents narrow it by instance_id; otherwise
+// EnvironmentID and ProjectIDs narrow it by the environment_id and project_id columns stamped at
+// ingest (clickhouse_tenancy.go), one cheap condition however many agents a project has. The zero
+// value selects every row.
+type tenantFilter struct {
+	Agents        []string
+	EnvironmentID string
+	ProjectIDs    []string
+}
+
+// cond renders the filter as a condition on the columns of alias ("" for none); "" when it selects
+// every row
+func (f tenantFilter) cond(alias string) (string, []interface{}) {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+	var conds []string
+	var args []interface{}
+	if len(f.Agents) > 0 {
+		conds = append(conds, col("instance_id")+" IN (?)")
+		args = append(args, f.Agents)
+	}
+	if f.EnvironmentID != "" {
+		conds = append(conds, col("environment_id")+" = ?")
+		args = append(args, f.EnvironmentID)
+	}
+	if len(f.ProjectIDs) > 0 {
+		conds = append(conds, col("project_id")+" IN (?)")
+		args = append(args, f.ProjectIDs)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// and renders the filter to append to a WHERE clause: " AND <cond>", "" when it selects every row
+func (f tenantFilter) and() (string, []interface{}) {
+	cond, args := f.cond("")
+	if cond == "" {
+		return "", nil
+	}
+	return " AND " + cond, args
+}
+
+// cacheKey identifies the rows the filter selects, whatever the order of its lists
+func (f tenantFilter) cacheKey() string {
+	agents := append([]string(nil), f.Agents...)
+	projects := append([]string(nil), f.ProjectIDs...)
+	sort.Strings(agents)
+	sort.Strings(projects)
+	return strings.Join(agents, ",") + "/" + f.EnvironmentID + "/" + strings.Join(projects, ",")
+}
+
+// callerProjects returns the projects the caller of ctx may see; all is set for superadmins,
+// trusted gRPC services and when authentication is disabled
+func (s *server) callerProjects(ctx context.Context) (projects []string, all bool, err error) {
+	user, err := s.callerOf(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, true, nil
+	}
+	if isSuperAdmin, err := s.db.IsSuperAdmin(user.Username); err != nil {
+		return nil, false, err
+	} else if isSuperAdmin {
+		return nil, true, nil
+	}
+	list, err := s.db.ListProjectsForUser(user.Username)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, p := range list {
+		projects = append(projects, p.ID)
+	}
+	return projects, false, nil
+}
+
+// analyticsTenantFilter resolves the row filter of an analytics request: its environment, else its
+// project, else its agent_id (comma separated; "all" or empty for every agent), narrowed to what the
+// caller may see. Without an agent_id, non-superadmins are scoped by the projects they can access.
+// visible is false when nothing is visible.
+func (s *server) analyticsTenantFilter(ctx context.Context, agentID, environmentID, projectID string) (f tenantFilter, visible bool, err error) {
+	if environmentID == "" && projectID == "" && agentID != "" && agentID != "all" {
+		agents, visible, err := s.scopeAgents(ctx, strings.Split(agentID, ","))
+		return tenantFilter{Agents: agents}, visible, err
+	}
+	projects, all, err := s.callerProjects(ctx)
+	if err != nil {
+		return tenantFilter{}, false, err
+	}
+	switch {
+	case environmentID != "":
+		f.EnvironmentID = environmentID
+		if all {
+			return f, true, nil
+		}
+		env, err := s.db.GetEnvironment(environmentID)
+		if err != nil {
+			return tenantFilter{}, false, fmt.Errorf("failed to get environment %s: %w", environmentID, err)
+		}
+		return f, env != nil && stringInSlice(projects, env.ProjectID), nil
+	case projectID != "":
+		f.ProjectIDs = []string{projectID}
+		return f, all || stringInSlice(projects, projectID), nil
+	case all:
+		return f, true, nil
+	}
+	f.ProjectIDs = projects
+	return f, len(projects) > 0, nil
+}
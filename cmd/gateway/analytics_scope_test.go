@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+func TestCallerOf(t *testing.T) {
+	am := middleware.NewAuthManager(middleware.AuthConfig{
+		Enabled:     true,
+		Username:    "admin",
+		JWTSecret:   "test-secret",
+		TokenExpiry: time.Hour,
+		CookieName:  "avika_session",
+	})
+	s := &server{authManager: am}
+	token, _, err := am.GenerateToken(&middleware.User{Username: "alice", Role: "viewer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grpcCtx := func(authorization string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+	}
+	if user, err := s.callerOf(grpcCtx("Bearer " + token)); err != nil || user == nil || user.Username != "alice" {
+		t.Errorf("forwarded session = %+v, %v", user, err)
+	}
+	if _, err := s.callerOf(grpcCtx("Bearer nope")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("invalid token error = %v", err)
+	}
+	if user, err := s.callerOf(context.Background()); user != nil || err != nil {
+		t.Errorf("a call without a token should be a trusted service, got %+v, %v", user, err)
+	}
+
+	httpCtx := context.WithValue(context.Background(), middleware.UserContextKey, &middleware.User{Username: "bob"})
+	if user, _ := s.callerOf(httpCtx); user == nil || user.Username != "bob" {
+		t.Errorf("HTTP caller = %+v", user)
+	}
+}
+
+func TestTenantFilter(t *testing.T) {
+	if cond, args := (tenantFilter{}).cond(""); cond != "" || args != nil {
+		t.Errorf("zero filter = %q %v", cond, args)
+	}
+	if clause, _ := (tenantFilter{}).and(); clause != "" {
+		t.Errorf("zero filter clause = %q", clause)
+	}
+
+	f := tenantFilter{EnvironmentID: "e1", ProjectIDs: []string{"p2", "p1"}}
+	cond, args := f.cond("c")
+	if cond != "c.environment_id = ? AND c.project_id IN (?)" || len(args) != 2 || args[0] != "e1" {
+		t.Errorf("cond = %q %v", cond, args)
+	}
+	if clause, _ := (tenantFilter{Agents: []string{"a1"}}).and(); clause != " AND instance_id IN (?)" {
+		t.Errorf("agents clause = %q", clause)
+	}
+	if f.cacheKey() != (tenantFilter{EnvironmentID: "e1", ProjectIDs: []string{"p1", "p2"}}).cacheKey() {
+		t.Error("project order changed the key")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GetAnalytics concurrency (configurable via environment)
+var (
+	analyticsWorkers        = getEnvInt("CH_ANALYTICS_WORKERS", 4)
+	analyticsSectionTimeout = time.Duration(getEnvInt("CH_ANALYTICS_SECTION_TIMEOUT_MS", 15000)) * time.Millisecond
+)
+
+// analyticsSection is one independent query of an analytics response. run fills fields of the
+// response that no other section touches.
+type analyticsSection struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// runAnalyticsSections runs sections on at most workers goroutines, each bounded by timeout, and
+// returns a warning for every section that failed, in section order. It returns once every
+// section has returned, so the response is never written after it.
+func runAnalyticsSections(ctx context.Context, workers int, timeout time.Duration, sections []analyticsSection) []string {
+	failures := make([]error, len(sections))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(1, workers), len(sections)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				sectionCtx, cancel := context.WithTimeout(ctx, timeout)
+				failures[i] = sections[i].run(sectionCtx)
+				if failures[i] != nil && errors.Is(sectionCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+					failures[i] = fmt.Errorf("timed out after %s", timeout)
+				}
+				cancel()
+			}
+		}()
+	}
+	for i := range sections {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var warnings []string
+	for i, err := range failures {
+		if err != nil {
+			analyticsLog.Error().Msgf("GetAnalytics: %s query failed: %v", sections[i].name, err)
+			warnings = append(warnings, fmt.Sprintf("%s: %v", sections[i].name, err))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunAnalyticsSections(t *testing.T) {
+	var active, peak atomic.Int32
+	busy := func(ctx context.Context) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	var filled atomic.Bool
+	sections := []analyticsSection{
+		{"a", busy}, {"b", busy}, {"c", busy}, {"d", busy}, {"e", busy},
+		{"broken", func(ctx context.Context) error { return errors.New("code 60: table does not exist") }},
+		{"slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{"last", func(ctx context.Context) error {
+			filled.Store(true)
+			return nil
+		}},
+	}
+
+	start := time.Now()
+	warnings := runAnalyticsSections(context.Background(), 3, 100*time.Millisecond, sections)
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d sections ran at once, want at most 3", p)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("a slow section held the response past its timeout")
+	}
+	if !filled.Load() {
+		t.Error("every section must have returned")
+	}
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "broken: code 60") || warnings[1] != "slow: timed out after 100ms" {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
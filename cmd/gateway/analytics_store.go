@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// Analytics backends. ClickHouse serves every analytics feature; small installs without it can keep
+// access logs and agent metrics in PostgreSQL (analytics_postgres.go), which serves the core of the
+// analytics page. Features beyond the AnalyticsStore (traces, geo, visitors, security events,
+// rollups, ...) use s.clickhouse directly and stay unavailable without it.
+const (
+	analyticsBackendClickHouse = "clickhouse"
+	analyticsBackendPostgres   = "postgres"
+)
+
+// AnalyticsStore keeps the access logs and agent metrics behind the analytics page
+type AnalyticsStore interface {
+	// InsertAccessLog, InsertSystemMetrics and InsertNginxMetrics queue rows for batched writes,
+	// failing when the queue is full
+	InsertAccessLog(entry *pb.LogEntry, agentID string) error
+	InsertSystemMetrics(metrics *pb.SystemMetrics, agentID string) error
+	InsertNginxMetrics(metrics *pb.NginxMetrics, agentID string) error
+	// GetAnalyticsWithFilter answers an analytics request over the rows filter selects. Sections a
+	// backend does not serve are left empty.
+	GetAnalyticsWithFilter(ctx context.Context, req *pb.AnalyticsRequest, filter tenantFilter) (*pb.AnalyticsResponse, error)
+}
+
+var (
+	_ AnalyticsStore = (*ClickHouseDB)(nil)
+	_ AnalyticsStore = (*PostgresAnalytics)(nil)
+)
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// analyticsStreamResync is how often a delta stream sends a full snapshot, so a client that
+// applied a patch wrongly recovers
+const analyticsStreamResync = time.Minute
+
+// analyticsSeriesFields are the time series of AnalyticsResponse patched point by point in deltas
+var analyticsSeriesFields = map[protoreflect.Name]bool{
+	"request_rate":        true,
+	"latency_trend":       true,
+	"connections_history": true,
+	"system_metrics":      true,
+	"gateway_metrics":     true,
+	"bandwidth":           true,
+}
+
+// timedPoint is a point of an analytics time series
+type timedPoint interface {
+	proto.Message
+	GetTime() string
+}
+
+// diffAnalytics returns next as a delta against prev: changed fields are replaced, time series
+// only carry their new points, and unchanged fields are left empty. ok is false when nothing
+// changed. Neither response is modified.
+func diffAnalytics(prev, next *pb.AnalyticsResponse) (delta *pb.AnalyticsResponse, ok bool) {
+	delta = &pb.AnalyticsResponse{Delta: &pb.AnalyticsDelta{}}
+	pr, nr, dr := prev.ProtoReflect(), next.ProtoReflect(), delta.ProtoReflect()
+	fields := nr.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Name() == "delta" {
+			continue
+		}
+		if analyticsSeriesFields[fd.Name()] {
+			prevList := pr.Get(fd).List()
+			patch, tail := diffSeries(prevList, nr.Get(fd).List())
+			if patch.Drop == 0 && int(patch.Keep) == prevList.Len() && tail.Len() == 0 {
+				continue
+			}
+			patch.Field = string(fd.Name())
+			delta.Delta.Series = append(delta.Delta.Series, patch)
+			list := dr.Mutable(fd).List()
+			for j := 0; j < tail.Len(); j++ {
+				list.Append(tail.Get(j))
+			}
+			continue
+		}
+		if fieldEqual(pr, nr, fd) {
+			continue
+		}
+		delta.Delta.Replaced = append(delta.Delta.Replaced, string(fd.Name()))
+		if nr.Has(fd) {
+			dr.Set(fd, nr.Get(fd))
+		}
+	}
+	return delta, len(delta.Delta.Replaced) > 0 || len(delta.Delta.Series) > 0
+}
+
+// diffSeries aligns next with prev by the time of its first point: the points of prev before it
+// are dropped, the following ones equal to next are kept, and the rest of next is the tail
+func diffSeries(prev, next protoreflect.List) (*pb.SeriesPatch, protoreflect.List) {
+	timeAt := func(l protoreflect.List, i int) string {
+		return l.Get(i).Message().Interface().(timedPoint).GetTime()
+	}
+	drop := prev.Len()
+	if next.Len() > 0 {
+		for i := 0; i < prev.Len(); i++ {
+			if timeAt(prev, i) == timeAt(next, 0) {
+				drop = i
+				break
+			}
+		}
+	}
+	keep := 0
+	for drop+keep < prev.Len() && keep < next.Len() &&
+		proto.Equal(prev.Get(drop+keep).Message().Interface(), next.Get(keep).Message().Interface()) {
+		keep++
+	}
+	return &pb.SeriesPatch{Drop: int32(drop), Keep: int32(keep)}, listTail{next, keep}
+}
+
+// listTail is l without its first n elements
+type listTail struct {
+	protoreflect.List
+	n int
+}
+
+func (l listTail) Len() int                     { return l.List.Len() - l.n }
+func (l listTail) Get(i int) protoreflect.Value { return l.List.Get(l.n + i) }
+
+// fieldEqual reports whether a field has the same value in both messages
+func fieldEqual(a, b protoreflect.Message, fd protoreflect.FieldDescriptor) bool {
+	if a.Has(fd) != b.Has(fd) {
+		return false
+	}
+	if !a.Has(fd) {
+		return true
+	}
+	x, y := a.New(), b.New()
+	x.Set(fd, a.Get(fd))
+	y.Set(fd, b.Get(fd))
+	return proto.Equal(x.Interface(), y.Interface())
+}
+
+// analyticsStreamer turns the snapshots of StreamAnalytics into what is sent: unchanged when the
+// client wants snapshots, otherwise a full resync first and every analyticsStreamResync, and
+// deltas in between
+type analyticsStreamer struct {
+	deltas     bool
+	prev       *pb.AnalyticsResponse
+	lastResync time.Time
+}
+
+// next returns the message to send for resp, or nil when there is nothing new
+func (a *analyticsStreamer) next(resp *pb.AnalyticsResponse, now time.Time) *pb.AnalyticsResponse {
+	if !a.deltas {
+		return resp
+	}
+	prev := a.prev
+	a.prev = resp
+	if prev == nil || now.Sub(a.lastResync) >= analyticsStreamResync {
+		a.lastResync = now
+		// resp may be shared through the analytics cache, so the delta marker goes on a copy
+		full := proto.Clone(resp).(*pb.AnalyticsResponse)
+		full.Delta = &pb.AnalyticsDelta{Resync: true}
+		return full
+	}
+	delta, changed := diffAnalytics(prev, resp)
+	if !changed {
+		return nil
+	}
+	return delta
+}
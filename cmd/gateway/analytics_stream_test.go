@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// applyAnalyticsDelta applies a delta the way a streaming client does
+func applyAnalyticsDelta(prev, delta *pb.AnalyticsResponse) *pb.AnalyticsResponse {
+	out := proto.Clone(prev).(*pb.AnalyticsResponse)
+	or, dr := out.ProtoReflect(), delta.ProtoReflect()
+	fields := or.Descriptor().Fields()
+	for _, name := range delta.Delta.Replaced {
+		fd := fields.ByName(protoreflect.Name(name))
+		or.Clear(fd)
+		if dr.Has(fd) {
+			or.Set(fd, dr.Get(fd))
+		}
+	}
+	for _, p := range delta.Delta.Series {
+		fd := fields.ByName(protoreflect.Name(p.Field))
+		old, tail := or.Get(fd).List(), dr.Get(fd).List()
+		merged := or.NewField(fd).List()
+		for i := int(p.Drop); i < int(p.Drop+p.Keep); i++ {
+			merged.Append(old.Get(i))
+		}
+		for i := 0; i < tail.Len(); i++ {
+			merged.Append(tail.Get(i))
+		}
+		or.Set(fd, protoreflect.ValueOfList(merged))
+	}
+	return out
+}
+
+func points(times ...string) []*pb.TimeSeriesPoint {
+	var ps []*pb.TimeSeriesPoint
+	for i, t := range times {
+		ps = append(ps, &pb.TimeSeriesPoint{Time: t, Requests: int64(100 + i)})
+	}
+	return ps
+}
+
+func TestDiffAnalytics(t *testing.T) {
+	prev := &pb.AnalyticsResponse{
+		RequestRate:  points("10:00", "10:01", "10:02"),
+		Summary:      &pb.AnalyticsSummary{TotalRequests: 303},
+		TopEndpoints: []*pb.EndpointStat{{Uri: "/", Requests: 300}},
+		Warnings:     []string{"top_asns: timed out after 15s"},
+	}
+	// The window slid by a minute, the last bucket grew, and the warning is gone
+	next := &pb.AnalyticsResponse{
+		RequestRate:  append(points("10:00", "10:01", "10:02")[1:2], &pb.TimeSeriesPoint{Time: "10:02", Requests: 150}, &pb.TimeSeriesPoint{Time: "10:03", Requests: 7}),
+		Summary:      &pb.AnalyticsSummary{TotalRequests: 358},
+		TopEndpoints: []*pb.EndpointStat{{Uri: "/", Requests: 300}},
+	}
+
+	delta, changed := diffAnalytics(prev, next)
+	if !changed {
+		t.Fatal("expected changes")
+	}
+	if len(delta.TopEndpoints) != 0 || len(delta.RequestRate) != 2 {
+		t.Errorf("delta carries unchanged data: %v", delta)
+	}
+	if p := delta.Delta.Series[0]; p.Field != "request_rate" || p.Drop != 1 || p.Keep != 1 {
+		t.Errorf("patch = %v", p)
+	}
+	if got := applyAnalyticsDelta(prev, delta); !proto.Equal(got, next) {
+		t.Errorf("applied delta =\n%v\nwant\n%v", got, next)
+	}
+
+	if _, changed := diffAnalytics(next, proto.Clone(next).(*pb.AnalyticsResponse)); changed {
+		t.Error("identical snapshots should produce no delta")
+	}
+	shorter := proto.Clone(next).(*pb.AnalyticsResponse)
+	shorter.RequestRate = shorter.RequestRate[:1]
+	if delta, _ := diffAnalytics(next, shorter); !proto.Equal(applyAnalyticsDelta(next, delta), shorter) {
+		t.Error("points removed from the end must be patched too")
+	}
+}
+
+func TestAnalyticsStreamer(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	snap := &pb.AnalyticsResponse{RequestRate: points("10:00")}
+
+	if msg := (&analyticsStreamer{}).next(snap, now); msg != snap {
+		t.Error("without deltas snapshots are sent as is")
+	}
+	s := &analyticsStreamer{deltas: true}
+	if msg := s.next(snap, now); !msg.Delta.GetResync() || snap.Delta != nil {
+		t.Error("first message must be a resync on a copy of the snapshot")
+	}
+	if msg := s.next(snap, now.Add(2*time.Second)); msg != nil {
+		t.Error("unchanged snapshot should send nothing")
+	}
+	if msg := s.next(snap, now.Add(analyticsStreamResync)); !msg.Delta.GetResync() {
+		t.Error("expected a periodic resync")
+	}
+}
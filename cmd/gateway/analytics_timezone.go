@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// timezoneNamePattern keeps timezone names safe to inline in SQL
+var timezoneNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+\-/]*$`)
+
+// analyticsTimezone returns the IANA timezone time series of a request are bucketed in: its
+// timezone when known, else UTC
+func analyticsTimezone(name string) string {
+	if name == "" || name == "Local" || !timezoneNamePattern.MatchString(name) {
+		return "UTC"
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return "UTC"
+	}
+	return name
+}
+
+// bucketColumns returns the bucket, time and time_iso columns of a time series over column, with
+// buckets (bucketSize, a toStartOf* function) and labels (timeFormat) in tz. Queries group and
+// order by bucket: labels such as "23:55" and "00:00" don't sort in time order.
+func bucketColumns(bucketSize, timeFormat, tz, column string) string {
+	return fmt.Sprintf(`%s(%s, '%s') AS bucket,
+			formatDateTime(bucket, '%s', '%s') AS time,
+			%s AS time_iso`, bucketSize, column, tz, timeFormat, tz, isoTimeExpr("bucket", tz))
+}
+
+// isoTimeExpr formats a DateTime column as ISO 8601 in tz, with its UTC offset ("+05:30")
+func isoTimeExpr(column, tz string) string {
+	offset := fmt.Sprintf("timeZoneOffset(toTimeZone(%s, '%s'))", column, tz)
+	return fmt.Sprintf(`concat(formatDateTime(%s, '%%Y-%%m-%%dT%%H:%%i:%%S', '%s'), if(%s < 0, '-', '+'), formatDateTime(toDateTime(abs(%s), 'UTC'), '%%H:%%i', 'UTC'))`,
+		column, tz, offset, offset)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyticsTimezone(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                  "UTC",
+		"Europe/Berlin":     "Europe/Berlin",
+		"Asia/Kolkata":      "Asia/Kolkata",
+		"Mars/Olympus_Mons": "UTC",
+		"Local":             "UTC",
+		"UTC'; DROP TABLE":  "UTC",
+		"../../etc/passwd":  "UTC",
+	} {
+		if got := analyticsTimezone(in); got != want {
+			t.Errorf("analyticsTimezone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBucketColumns(t *testing.T) {
+	cols := bucketColumns("toStartOfHour", "%H:%i", "Asia/Kolkata", "ts")
+	for _, want := range []string{
+		"toStartOfHour(ts, 'Asia/Kolkata') AS bucket",
+		"formatDateTime(bucket, '%H:%i', 'Asia/Kolkata') AS time",
+		"formatDateTime(bucket, '%Y-%m-%dT%H:%i:%S', 'Asia/Kolkata')",
+		"timeZoneOffset(toTimeZone(bucket, 'Asia/Kolkata'))",
+		"AS time_iso",
+	} {
+		if !strings.Contains(cols, want) {
+			t.Errorf("bucket columns lack %q:\n%s", want, cols)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+// Package apispec builds the OpenAPI document of the gateway's REST API from its source: the routes
+// registered on the HTTP mux in createHTTPServer and the handlers they call. Paths, methods and path
+// parameters come from the route patterns; summaries from the handlers' doc comments; query
+// parameters from the r.URL.Query().Get calls in the handlers; and authentication from whether a
+// route is wrapped in AuthMiddleware.
+package apispec
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// RegisterFunc is the function whose mux registrations define the API
+	RegisterFunc = "createHTTPServer"
+
+	Title   = "Avika Gateway API"
+	Version = "1.0"
+)
+
+var (
+	restMethods      = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+	pathParamPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)(\.\.\.)?\}`)
+	versionPattern   = regexp.MustCompile(`^v[0-9]+$`)
+	// "LoginHandler returns an HTTP handler for login requests"
+	handlerFactoryPattern = regexp.MustCompile(`^[A-Z]\w*Handler returns an HTTP handler (?:that |for )?`)
+	// a doc comment line naming the route, e.g. "GET /api/servers/{agentId}/upstreams" or
+	// "GET and POST /api/rpc/{method}"
+	routeLinePattern = regexp.MustCompile(`^(?:(?:GET|POST|PUT|PATCH|DELETE)(?:,? and |, |\s+))*/\S*?([:.]?)(?:\s+|$)`)
+)
+
+// route is a registration on the mux
+type route struct {
+	Method  string // empty when the pattern matches every method
+	Path    string
+	Handler string // the handler method called, when one was found
+	Public  bool   // not wrapped in AuthMiddleware
+}
+
+// handlerInfo is what the spec uses from a handler declaration
+type handlerInfo struct {
+	doc         string
+	queryParams []string
+	readsBody   bool
+	methods     []string // compared against r.Method, for routes registered without one
+	helpers     []string // functions the query is passed to, e.g. parseEventFilter(r.URL.Query())
+}
+
+// Generate parses the non-test Go files of the package in dir and returns its OpenAPI document as
+// indented JSON
+func Generate(dir, title, version string) ([]byte, error) {
+	routes, handlers, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	doc := build(routes, handlers, title, version)
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// parsePackage returns the routes of the package in dir and the methods declared in it and in its
+// subpackages, where handlers such as the auth middleware's live
+func parsePackage(dir string) ([]route, map[string]handlerInfo, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sub, err := filepath.Glob(filepath.Join(dir, "*", "*.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+	sort.Strings(sub)
+	files = append(files, sub...)
+	fset := token.NewFileSet()
+	handlers := map[string]handlerInfo{}
+	funcs := map[string]handlerInfo{}
+	var register *ast.FuncDecl
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			if fn.Name.Name == RegisterFunc && filepath.Dir(path) == filepath.Clean(dir) {
+				register = fn
+			}
+			if _, dup := handlers[fn.Name.Name]; fn.Recv != nil && !dup {
+				handlers[fn.Name.Name] = inspectHandler(fn)
+			}
+			if fn.Recv == nil && filepath.Dir(path) == filepath.Clean(dir) {
+				funcs[fn.Name.Name] = inspectHandler(fn)
+			}
+		}
+	}
+	if register == nil {
+		return nil, nil, fmt.Errorf("%s not found in %s", RegisterFunc, dir)
+	}
+	for name, info := range handlers {
+		for _, helper := range info.helpers {
+			info.queryParams = append(info.queryParams, funcs[helper].queryParams...)
+		}
+		info.queryParams = uniqueSorted(info.queryParams)
+		handlers[name] = info
+	}
+	return collectRoutes(register), handlers, nil
+}
+
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	out := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// collectRoutes finds the mux.Handle and mux.HandleFunc calls with a literal pattern
+func collectRoutes(fn *ast.FuncDecl) []route {
+	var routes []route
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") {
+			return true
+		}
+		if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != "mux" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		pattern, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		method, path, found := strings.Cut(pattern, " ")
+		if !found {
+			method, path = "", pattern
+		}
+		routes = append(routes, route{
+			Method:  method,
+			Path:    path,
+			Handler: handlerName(call.Args[1]),
+			Public:  !callsFunc(call.Args[1], "AuthMiddleware"),
+		})
+		return true
+	})
+	return routes
+}
+
+// handlerName returns the method the handler expression calls: srv.handleX, or else a provider's
+// XHandler or HandleX method
+func handlerName(expr ast.Expr) string {
+	var name string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || name != "" {
+			return name == ""
+		}
+		s := sel.Sel.Name
+		if s == "HandlerFunc" {
+			return true
+		}
+		if strings.HasPrefix(s, "handle") || strings.HasPrefix(s, "Handle") || strings.HasSuffix(s, "Handler") {
+			name = s
+		}
+		return true
+	})
+	return name
+}
+
+func callsFunc(expr ast.Expr, name string) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// inspectHandler reads the doc comment, query parameters and body use of a function. Parameters of
+// type url.Values are taken to be the request's query.
+func inspectHandler(fn *ast.FuncDecl) handlerInfo {
+	info := handlerInfo{doc: fn.Doc.Text()}
+	queryVars := map[string]bool{}
+	for _, field := range fn.Type.Params.List {
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Values" {
+			for _, name := range field.Names {
+				queryVars[name.Name] = true
+			}
+		}
+	}
+	isQuery := func(e ast.Expr) bool {
+		id, ok := e.(*ast.Ident)
+		return isQueryCall(e) || (ok && queryVars[id.Name])
+	}
+	seen := map[string]bool{}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			// q := r.URL.Query()
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 && isQueryCall(n.Rhs[0]) {
+				if id, ok := n.Lhs[0].(*ast.Ident); ok {
+					queryVars[id.Name] = true
+				}
+			}
+		case *ast.BinaryExpr:
+			if m := comparedMethod(n); restMethods[m] && !seen["method "+m] {
+				seen["method "+m] = true
+				info.methods = append(info.methods, m)
+			}
+		case *ast.SelectorExpr:
+			if n.Sel.Name == "Body" {
+				if id, ok := n.X.(*ast.Ident); ok && id.Name == "r" {
+					info.readsBody = true
+				}
+			}
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok {
+				for _, arg := range n.Args {
+					if isQuery(arg) {
+						info.helpers = append(info.helpers, id.Name)
+					}
+				}
+				return true
+			}
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || len(n.Args) != 1 {
+				return true
+			}
+			lit, ok := n.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			name, err := strconv.Unquote(lit.Value)
+			if err != nil || seen[name] {
+				return true
+			}
+			param := false
+			switch sel.Sel.Name {
+			case "Get", "Has":
+				param = isQuery(sel.X)
+			case "FormValue":
+				id, ok := sel.X.(*ast.Ident)
+				param = ok && id.Name == "r"
+			}
+			if param {
+				seen[name] = true
+				info.queryParams = append(info.queryParams, name)
+			}
+		}
+		return true
+	})
+	return info
+}
+
+// comparedMethod returns the method in r.Method == http.MethodPost or r.Method != "POST"
+func comparedMethod(expr *ast.BinaryExpr) string {
+	if expr.Op != token.EQL && expr.Op != token.NEQ {
+		return ""
+	}
+	isRequestMethod := func(e ast.Expr) bool {
+		sel, ok := e.(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == "Method"
+	}
+	other := expr.Y
+	if !isRequestMethod(expr.X) {
+		if !isRequestMethod(expr.Y) {
+			return ""
+		}
+		other = expr.X
+	}
+	switch v := other.(type) {
+	case *ast.SelectorExpr:
+		if m, ok := strings.CutPrefix(v.Sel.Name, "Method"); ok {
+			return strings.ToUpper(m)
+		}
+	case *ast.BasicLit:
+		if m, err := strconv.Unquote(v.Value); err == nil {
+			return strings.ToUpper(m)
+		}
+	}
+	return ""
+}
+
+// isQueryCall matches r.URL.Query()
+func isQueryCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Query" {
+		return false
+	}
+	inner, ok := sel.X.(*ast.SelectorExpr)
+	return ok && inner.Sel.Name == "URL"
+}
+
+// build assembles the OpenAPI 3.0 document. Subtree patterns ending in "/" serve files and are left
+// out; patterns without a method get the methods their handler compares r.Method with, or GET.
+func build(routes []route, handlers map[string]handlerInfo, title, version string) map[string]interface{} {
+	uses := map[string]int{}
+	for _, rt := range routes {
+		if rt.Handler != "" {
+			uses[rt.Handler]++
+		}
+	}
+	paths := map[string]map[string]interface{}{}
+	tags := map[string]bool{}
+	operationIDs := map[string]bool{}
+	for _, rt := range routes {
+		if strings.HasSuffix(rt.Path, "/") {
+			continue
+		}
+		methods := []string{rt.Method}
+		if rt.Method == "" {
+			methods = handlers[rt.Handler].methods
+			if len(methods) == 0 {
+				methods = []string{"GET"}
+			}
+		}
+		for _, m := range methods {
+			addOperation(paths, tags, operationIDs, rt, strings.ToLower(m), uses[rt.Handler] > 1 || len(methods) > 1, handlers[rt.Handler])
+		}
+	}
+
+	tagList := []map[string]string{}
+	for tag := range tags {
+		tagList = append(tagList, map[string]string{"name": tag})
+	}
+	sort.Slice(tagList, func(i, j int) bool { return tagList[i]["name"] < tagList[j]["name"] })
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]string{"title": title, "version": version},
+		"tags":    tagList,
+		"paths":   paths,
+		"security": []interface{}{
+			map[string][]string{"bearerAuth": {}},
+			map[string][]string{"cookieAuth": {}},
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"cookieAuth": map[string]string{"type": "apiKey", "in": "cookie", "name": "avika_session"},
+			},
+			"schemas": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"error": map[string]string{"type": "string"}},
+				},
+			},
+		},
+	}
+}
+
+// addOperation documents one method of a route
+func addOperation(paths map[string]map[string]interface{}, tags, operationIDs map[string]bool, rt route, method string, shared bool, info handlerInfo) {
+	path := pathParamPattern.ReplaceAllString(rt.Path, "{$1}")
+	if paths[path] == nil {
+		paths[path] = map[string]interface{}{}
+	}
+	if _, dup := paths[path][method]; dup {
+		return
+	}
+
+	// handlers serving one operation name it; shared ones are named after the route
+	id := exportedName(rt.Handler)
+	if id == "" || shared || operationIDs[id] {
+		id = routeName(method, path)
+	}
+	operationIDs[id] = true
+	tag := pathTag(path)
+	tags[tag] = true
+
+	op := map[string]interface{}{
+		"operationId": id,
+		"summary":     summarize(info.doc, id),
+		"tags":        []string{tag},
+		"responses": map[string]interface{}{
+			"200":     map[string]interface{}{"description": "Success", "content": jsonContent(map[string]interface{}{})},
+			"default": map[string]interface{}{"description": "Error", "content": jsonContent(map[string]interface{}{"$ref": "#/components/schemas/Error"})},
+		},
+	}
+	var params []interface{}
+	for _, m := range pathParamPattern.FindAllStringSubmatch(rt.Path, -1) {
+		params = append(params, map[string]interface{}{
+			"name": m[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+		})
+	}
+	for _, q := range info.queryParams {
+		params = append(params, map[string]interface{}{
+			"name": q, "in": "query", "schema": map[string]string{"type": "string"},
+		})
+	}
+	if params != nil {
+		op["parameters"] = params
+	}
+	if info.readsBody && method != "get" {
+		op["requestBody"] = map[string]interface{}{"content": jsonContent(map[string]interface{}{"type": "object"})}
+	}
+	if rt.Public {
+		op["security"] = []interface{}{}
+	}
+	paths[path][method] = op
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// exportedName turns handleListWebhooks, HandleGetErrorTrend or LoginHandler into ListWebhooks,
+// GetErrorTrend or Login
+func exportedName(handler string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(handler, "handle"), "Handle")
+	name = strings.TrimSuffix(name, "Handler")
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// routeName names an operation after its method and path: DELETE /api/teams/{slug} is DeleteTeamsBySlug
+func routeName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(method[:1]) + method[1:])
+	for _, seg := range strings.Split(strings.TrimPrefix(path, "/api"), "/") {
+		if param, ok := strings.CutPrefix(seg, "{"); ok {
+			seg = "by-" + strings.TrimSuffix(param, "}")
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// pathTag groups operations by their first path segment after /api (and its version)
+func pathTag(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) < 2 || segs[0] != "api" {
+		return "system"
+	}
+	segs = segs[1:]
+	if len(segs) > 1 && versionPattern.MatchString(segs[0]) {
+		segs = segs[1:]
+	}
+	tag, _, _ := strings.Cut(segs[0], ".")
+	return tag
+}
+
+// summarize takes the first sentence of a handler's doc comment, without the handler name and the
+// route it repeats; operations without one are summarized from their ID
+func summarize(doc, operationID string) string {
+	var text []string
+	for i, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			if name, rest, ok := strings.Cut(line, " "); ok && !strings.HasPrefix(name, "/") && strings.HasPrefix(strings.ToLower(name), "handle") {
+				line = strings.TrimPrefix(rest, "handles ")
+			}
+		}
+		if m := routeLinePattern.FindStringSubmatch(line); m != nil {
+			if m[1] == "." && len(text) == 0 {
+				// the comment only names the route
+				break
+			}
+			line = strings.TrimPrefix(strings.TrimSpace(line[len(m[0]):]), "- ")
+			if strings.HasPrefix(line, "| ") {
+				// an alternative query, e.g. "?window=24h | from=&to="
+				_, line, _ = strings.Cut(line[2:], " ")
+			}
+		}
+		if strings.HasPrefix(line, "Body:") {
+			continue
+		}
+		line = handlerFactoryPattern.ReplaceAllString(line, "")
+		if line != "" {
+			text = append(text, line)
+		}
+	}
+	s := strings.Join(text, " ")
+	if end := strings.Index(s, ". "); end >= 0 {
+		s = s[:end]
+	}
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return splitWords(operationID)
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// splitWords turns ListWebhookDeliveries into "List webhook deliveries"
+func splitWords(id string) string {
+	rs := []rune(id)
+	var words []string
+	start := 0
+	for i := 1; i <= len(rs); i++ {
+		// a word ends before an upper case letter, except inside an acronym: "OpenAPI" is Open API
+		if i < len(rs) && (!unicode.IsUpper(rs[i]) ||
+			unicode.IsUpper(rs[i-1]) && (i+1 == len(rs) || !unicode.IsLower(rs[i+1]))) {
+			continue
+		}
+		w := string(rs[start:i])
+		if start > 0 && (len(w) == 1 || strings.ToUpper(w) != w) {
+			w = strings.ToLower(w)
+		}
+		words = append(words, w)
+		start = i
+	}
+	return strings.Join(words, " ")
+}
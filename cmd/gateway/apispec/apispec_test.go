@@ -0,0 +1,142 @@
+package apispec
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSource = `package main
+
+import "net/http"
+
+func createHTTPServer(mux *http.ServeMux, srv *server, am *authManager) {
+	mux.Handle("GET /api/widgets", am.AuthMiddleware(nil)(http.HandlerFunc(srv.handleListWidgets)))
+	mux.Handle("DELETE /api/widgets/{id}", am.AuthMiddleware(nil)(http.HandlerFunc(srv.handleDeleteWidget)))
+	mux.Handle("PUT /api/v1/widgets/{id}/parts/{part}", am.AuthMiddleware(nil)(http.HandlerFunc(srv.handlePart)))
+	mux.Handle("DELETE /api/v1/widgets/{id}/parts/{part}", am.AuthMiddleware(nil)(http.HandlerFunc(srv.handlePart)))
+	mux.HandleFunc("/api/login", am.LoginHandler())
+	mux.HandleFunc("/", srv.handleIndex)
+}
+
+// handleListWidgets handles GET /api/widgets: the widgets, newest first. Paginated.
+func (s *server) handleListWidgets(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	_ = q.Get("limit")
+	_ = r.URL.Query().Get("kind")
+}
+
+// DELETE /api/widgets/{id}
+func (s *server) handleDeleteWidget(w http.ResponseWriter, r *http.Request) {}
+
+func (s *server) handlePart(w http.ResponseWriter, r *http.Request) {
+	_ = r.Body
+}
+
+// LoginHandler returns an HTTP handler for login requests
+func (am *authManager) LoginHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+		_ = r.Body
+	}
+}
+`
+
+type testOperation struct {
+	OperationID string                   `json:"operationId"`
+	Summary     string                   `json:"summary"`
+	Tags        []string                 `json:"tags"`
+	Parameters  []map[string]interface{} `json:"parameters"`
+	RequestBody interface{}              `json:"requestBody"`
+	Security    []interface{}            `json:"security"`
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(testSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Generate(dir, "Test", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]testOperation `json:"paths"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths) != 4 {
+		t.Errorf("expected 4 paths without the catch-all, got %d", len(doc.Paths))
+	}
+
+	list := doc.Paths["/api/widgets"]["get"]
+	if list.OperationID != "ListWidgets" || list.Summary != "The widgets, newest first" || list.Tags[0] != "widgets" {
+		t.Errorf("list operation: %+v", list)
+	}
+	if len(list.Parameters) != 2 || list.Parameters[0]["name"] != "kind" || list.Parameters[1]["name"] != "limit" {
+		t.Errorf("list query parameters: %v", list.Parameters)
+	}
+	if list.Security != nil {
+		t.Errorf("authenticated operation should inherit the global security: %v", list.Security)
+	}
+
+	del := doc.Paths["/api/widgets/{id}"]["delete"]
+	if del.OperationID != "DeleteWidget" || del.Summary != "Delete widget" || del.Parameters[0]["in"] != "path" {
+		t.Errorf("delete operation: %+v", del)
+	}
+
+	put := doc.Paths["/api/v1/widgets/{id}/parts/{part}"]["put"]
+	if put.OperationID != "PutV1WidgetsByIdPartsByPart" || put.RequestBody == nil || put.Tags[0] != "widgets" {
+		t.Errorf("shared handler operation: %+v", put)
+	}
+
+	login, ok := doc.Paths["/api/login"]["post"]
+	if !ok || login.OperationID != "Login" || login.Summary != "Login requests" || login.Security == nil || len(login.Security) != 0 {
+		t.Errorf("login operation: %+v", doc.Paths["/api/login"])
+	}
+}
+
+func TestGenerateWithoutRegisterFunc(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(dir, "Test", "1"); err == nil {
+		t.Error("expected an error when createHTTPServer is missing")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cases := []struct{ doc, id, want string }{
+		{"handleGetX handles GET /api/x: the x of a server. More detail.", "GetX", "The x of a server"},
+		{"handleGetX handles GET /api/x.\nMore detail.", "GetX", "Get x"},
+		{"GET /api/x - Lists the x", "GetX", "Lists the x"},
+		{"handleX handles GET and POST /api/x: calls x", "GetX", "Calls x"},
+		{"GET /api/x?window=24h | from=&to=\nRuns the query", "GetX", "Runs the query"},
+		{"POST /api/x\nBody: {\"a\": 1}\nCreates an x", "CreateX", "Creates an x"},
+		{"", "OpenAPI", "Open API"},
+	}
+	for _, c := range cases {
+		if got := summarize(c.doc, c.id); got != c.want {
+			t.Errorf("summarize(%q) = %q, want %q", c.doc, got, c.want)
+		}
+	}
+}
+
+func TestPathTag(t *testing.T) {
+	cases := map[string]string{
+		"/api/servers/{agentId}": "servers",
+		"/api/v1/export":         "export",
+		"/api/openapi.json":      "openapi",
+		"/health":                "system",
+	}
+	for path, want := range cases {
+		if got := pathTag(path); got != want {
+			t.Errorf("pathTag(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
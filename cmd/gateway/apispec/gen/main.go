@@ -0,0 +1,24 @@
+// Command gen writes the gateway's OpenAPI document. Run it with go generate from cmd/gateway.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/avika-ai/avika/cmd/gateway/apispec"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the gateway package")
+	out := flag.String("o", "openapi.json", "output file")
+	flag.Parse()
+
+	spec, err := apispec.Generate(*dir, apispec.Title, apispec.Version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/geo"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// Security event types written to nginx_analytics.security_events
+const (
+	eventSQLi          = "sqli"
+	eventXSS           = "xss"
+	eventPathTraversal = "path_traversal"
+	eventBruteForce    = "brute_force"
+	eventRateLimited   = "rate_limited" // limit_req rejections parsed from error logs
+)
+
+// securityEventMetrics are the alert rule metric types backed by security_events.
+// The value is the event_type filter; empty counts every event.
+var securityEventMetrics = map[string]string{
+	"security_events":         "",
+	"sqli_attempts":           eventSQLi,
+	"xss_attempts":            eventXSS,
+	"path_traversal_attempts": eventPathTraversal,
+	"brute_force_attempts":    eventBruteForce,
+	"rate_limited_requests":   eventRateLimited,
+}
+
+// SecurityEvent is one detection finding
+type SecurityEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	AgentID   string    `json:"agent_id"`
+	ClientIP  string    `json:"client_ip"`
+	EventType string    `json:"event_type"`
+	Severity  string    `json:"severity"`
+	RuleID    string    `json:"rule_id"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Status    int       `json:"status"`
+	UserAgent string    `json:"user_agent"`
+	Count     uint32    `json:"count"` // Requests covered by the event (brute force)
+	Details   string    `json:"details"`
+}
+
+type attackSignature struct {
+	id        string
+	eventType string
+	severity  string
+	pattern   *regexp.Regexp
+}
+
+// attackSignatures are matched against the decoded request URI. Only the first match
+// per event type is reported for a request.
+var attackSignatures = []attackSignature{
+	{"sqli-union-select", eventSQLi, "critical", regexp.MustCompile(`(?i)\bunion[\s/*+(]+(all[\s/*+(]+)?select\b`)},
+	{"sqli-tautology", eventSQLi, "critical", regexp.MustCompile(`(?i)['"]\s*(or|and)\s+['"]?\w+['"]?\s*=\s*['"]?\w+`)},
+	{"sqli-time-based", eventSQLi, "critical", regexp.MustCompile(`(?i)\b(sleep|benchmark|pg_sleep)\s*\(|\bwaitfor\s+delay\b`)},
+	{"sqli-stacked-query", eventSQLi, "critical", regexp.MustCompile(`(?i)[;'"]\s*(drop|truncate|insert|delete|update|alter)\s+(table|into|from|database)\b`)},
+	{"sqli-schema-probe", eventSQLi, "critical", regexp.MustCompile(`(?i)\b(information_schema|xp_cmdshell|sysobjects|pg_catalog)\b`)},
+	{"xss-script-tag", eventXSS, "warning", regexp.MustCompile(`(?i)<\s*/?\s*script\b`)},
+	{"xss-event-handler", eventXSS, "warning", regexp.MustCompile(`(?i)<[^>]*\bon(error|load|mouseover|focus|click|toggle|begin)\s*=`)},
+	{"xss-js-uri", eventXSS, "warning", regexp.MustCompile(`(?i)\bjavascript\s*:`)},
+	{"xss-dom-access", eventXSS, "warning", regexp.MustCompile(`(?i)\bdocument\.(cookie|domain|location)\b|\balert\s*\(`)},
+	{"traversal-dot-dot", eventPathTraversal, "critical", regexp.MustCompile(`(\.\.[/\\]){2,}|[/\\]\.\.[/\\]`)},
+	{"traversal-sensitive-file", eventPathTraversal, "critical", regexp.MustCompile(`(?i)/etc/(passwd|shadow|hosts)\b|/proc/self/|\bboot\.ini\b|\\windows\\win\.ini|\bweb\.config\b`)},
+}
+
+// AttackDetector scans access log entries for attack signatures and brute-force patterns
+type AttackDetector struct {
+	threshold int           // 401/403 responses from one IP that count as brute force
+	window    time.Duration // Fixed window over which those responses are counted
+
+	mu        sync.Mutex
+	failures  map[string]*authFailures // agentID|clientIP
+	lastPrune time.Time
+}
+
+type authFailures struct {
+	windowStart time.Time
+	count       int
+	reported    bool
+	lastURI     string
+	lastMethod  string
+	lastUA      string
+}
+
+// NewAttackDetector creates a detector; non-positive settings fall back to 20 failures per 5 minutes
+func NewAttackDetector(threshold int, window time.Duration) *AttackDetector {
+	if threshold <= 0 {
+		threshold = 20
+	}
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	return &AttackDetector{
+		threshold: threshold,
+		window:    window,
+		failures:  make(map[string]*authFailures),
+	}
+}
+
+// decodeRequestURI undoes up to two rounds of percent-encoding so double-encoded payloads match
+func decodeRequestURI(uri string) string {
+	for i := 0; i < 2; i++ {
+		decoded, err := url.QueryUnescape(uri)
+		if err != nil || decoded == uri {
+			break
+		}
+		uri = decoded
+	}
+	return uri
+}
+
+// matchSignatures returns the first matching signature per event type
+func matchSignatures(uri string) []attackSignature {
+	decoded := decodeRequestURI(uri)
+	var matches []attackSignature
+	seen := make(map[string]bool)
+	for _, sig := range attackSignatures {
+		if seen[sig.eventType] {
+			continue
+		}
+		if sig.pattern.MatchString(decoded) {
+			seen[sig.eventType] = true
+			matches = append(matches, sig)
+		}
+	}
+	return matches
+}
+
+// Inspect returns the findings for one log entry
+func (d *AttackDetector) Inspect(agentID string, entry *pb.LogEntry) []SecurityEvent {
+	if entry.LogType != "" && entry.LogType != "access" {
+		return nil
+	}
+	ts := time.Unix(entry.Timestamp, 0)
+	if entry.Timestamp == 0 {
+		ts = time.Now()
+	}
+	clientIP := geo.ExtractClientIP(entry.XForwardedFor, entry.RemoteAddr)
+
+	var events []SecurityEvent
+	for _, sig := range matchSignatures(entry.RequestUri) {
+		events = append(events, SecurityEvent{
+			Timestamp: ts,
+			AgentID:   agentID,
+			ClientIP:  clientIP,
+			EventType: sig.eventType,
+			Severity:  sig.severity,
+			RuleID:    sig.id,
+			Method:    entry.RequestMethod,
+			URI:       entry.RequestUri,
+			Status:    int(entry.Status),
+			UserAgent: entry.UserAgent,
+			Count:     1,
+		})
+	}
+
+	if (entry.Status == 401 || entry.Status == 403) && clientIP != "" {
+		if ev := d.recordAuthFailure(agentID, clientIP, ts, entry); ev != nil {
+			events = append(events, *ev)
+		}
+	}
+	return events
+}
+
+// recordAuthFailure counts a 401/403 and reports brute force once per window when the threshold is reached
+func (d *AttackDetector) recordAuthFailure(agentID, clientIP string, ts time.Time, entry *pb.LogEntry) *SecurityEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pruneLocked(ts)
+
+	key := agentID + "|" + clientIP
+	f, ok := d.failures[key]
+	if !ok || ts.Sub(f.windowStart) >= d.window {
+		f = &authFailures{windowStart: ts}
+		d.failures[key] = f
+	}
+	f.count++
+	f.lastURI, f.lastMethod, f.lastUA = entry.RequestUri, entry.RequestMethod, entry.UserAgent
+
+	if f.reported || f.count < d.threshold {
+		return nil
+	}
+	f.reported = true
+	return &SecurityEvent{
+		Timestamp: ts,
+		AgentID:   agentID,
+		ClientIP:  clientIP,
+		EventType: eventBruteForce,
+		Severity:  "warning",
+		RuleID:    "brute-force-auth-failures",
+		Method:    f.lastMethod,
+		URI:       f.lastURI,
+		Status:    int(entry.Status),
+		UserAgent: f.lastUA,
+		Count:     uint32(f.count),
+		Details:   fmt.Sprintf("%d 401/403 responses within %s", f.count, d.window),
+	}
+}
+
+// pruneLocked drops expired counters at most once per window
+func (d *AttackDetector) pruneLocked(now time.Time) {
+	if now.Sub(d.lastPrune) < d.window {
+		return
+	}
+	d.lastPrune = now
+	for key, f := range d.failures {
+		if now.Sub(f.windowStart) >= d.window {
+			delete(d.failures, key)
+		}
+	}
+}
+
+// securityEventsMetricFilter reports whether metricType is backed by security_events
+func securityEventsMetricFilter(metricType string) (string, bool) {
+	eventType, ok := securityEventMetrics[strings.ToLower(metricType)]
+	return eventType, ok
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestMatchSignatures(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want []string
+	}{
+		{"/products?id=1%20UNION%20SELECT%20password%20FROM%20users", []string{eventSQLi}},
+		{"/login?user=admin'%20OR%20'1'='1", []string{eventSQLi}},
+		{"/search?q=%3Cscript%3Ealert(1)%3C/script%3E", []string{eventXSS}},
+		{"/static/..%2f..%2f..%2fetc/passwd", []string{eventPathTraversal}},
+		{"/download?file=%252e%252e%252f%252e%252e%252fboot.ini", []string{eventPathTraversal}},
+		{"/api/users?page=2&sort=name", nil},
+		{"/blog/union-station-selects-new-mayor", nil},
+	}
+	for _, tt := range tests {
+		matches := matchSignatures(tt.uri)
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.eventType)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("matchSignatures(%q) = %v, want %v", tt.uri, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("matchSignatures(%q) = %v, want %v", tt.uri, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestAttackDetectorBruteForce(t *testing.T) {
+	d := NewAttackDetector(3, time.Minute)
+	base := time.Unix(1700000000, 0)
+	entry := func(offset time.Duration, status int32) *pb.LogEntry {
+		return &pb.LogEntry{
+			Timestamp:     base.Add(offset).Unix(),
+			RemoteAddr:    "203.0.113.7",
+			RequestMethod: "POST",
+			RequestUri:    "/login",
+			Status:        status,
+		}
+	}
+
+	var reported int
+	for i := 0; i < 5; i++ {
+		for _, ev := range d.Inspect("agent-1", entry(time.Duration(i)*time.Second, 401)) {
+			if ev.EventType != eventBruteForce {
+				t.Fatalf("unexpected event %s", ev.EventType)
+			}
+			if ev.Count != 3 {
+				t.Errorf("Count = %d, want 3", ev.Count)
+			}
+			reported++
+		}
+	}
+	if reported != 1 {
+		t.Fatalf("reported %d brute force events in one window, want 1", reported)
+	}
+
+	// Successful responses never count
+	if evs := d.Inspect("agent-1", entry(10*time.Second, 200)); len(evs) != 0 {
+		t.Errorf("200 response produced %v", evs)
+	}
+
+	// A new window starts counting again
+	reported = 0
+	for i := 0; i < 3; i++ {
+		reported += len(d.Inspect("agent-1", entry(2*time.Minute+time.Duration(i)*time.Second, 403)))
+	}
+	if reported != 1 {
+		t.Errorf("reported %d brute force events after window reset, want 1", reported)
+	}
+}
+
+func TestAttackDetectorIgnoresErrorLogs(t *testing.T) {
+	d := NewAttackDetector(0, 0)
+	evs := d.Inspect("agent-1", &pb.LogEntry{LogType: "error", RequestUri: "/?q=<script>"})
+	if len(evs) != 0 {
+		t.Errorf("error log produced %v", evs)
+	}
+}
+
+func TestSecurityEventsMetricFilter(t *testing.T) {
+	if f, ok := securityEventsMetricFilter("SQLI_Attempts"); !ok || f != eventSQLi {
+		t.Errorf("sqli_attempts = %q, %v", f, ok)
+	}
+	if f, ok := securityEventsMetricFilter("security_events"); !ok || f != "" {
+		t.Errorf("security_events = %q, %v", f, ok)
+	}
+	if _, ok := securityEventsMetricFilter("cpu_usage"); ok {
+		t.Error("cpu_usage should not be a security metric")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentOfflineMetric is the alert rule metric type that fires when an agent stays offline. Rules
+// are event driven: the threshold is the offline time in minutes, 0 meaning agent.offline_alert_after.
+const agentOfflineMetric = "agent_offline"
+
+// Reasons recorded with availability transitions
+const (
+	availabilityConnect          = "connect"
+	availabilityDisconnect       = "disconnect"
+	availabilityHeartbeatTimeout = "heartbeat_timeout"
+	availabilityStaleStream      = "stale_stream"
+)
+
+// agentAvailabilityRetention is how long transitions are kept; each agent's latest always stays
+const agentAvailabilityRetention = 180 * 24 * time.Hour
+
+// AgentAvailability is an agent's connectivity over a window. Time before its first known
+// transition counts neither as online nor offline.
+type AgentAvailability struct {
+	AgentID    string                   `json:"agent_id"`
+	Hostname   string                   `json:"hostname,omitempty"`
+	Status     string                   `json:"status"`
+	UptimePct  *float64                 `json:"uptime_pct"` // null when the state was never known in the window
+	OnlineSec  int64                    `json:"online_sec"`
+	OfflineSec int64                    `json:"offline_sec"`
+	Outages    int                      `json:"outages"`
+	Events     []AgentAvailabilityEvent `json:"events,omitempty"`
+}
+
+type offlineAlertKey struct {
+	agentID string
+	ruleID  string
+}
+
+// offlineAlert remembers a fired agent_offline alert so the recovery goes to the same recipients
+type offlineAlert struct {
+	ruleName   string
+	recipients string
+	firedAt    time.Time
+	rule       *pb.AlertRule
+}
+
+// computeAgentAvailability sums online and offline time in [start, end]. events holds the last
+// transition before start, if any, followed by those in the window, oldest first.
+func computeAgentAvailability(events []AgentAvailabilityEvent, start, end time.Time) AgentAvailability {
+	var a AgentAvailability
+	state, cursor := "", start
+	add := func(until time.Time) {
+		d := int64(until.Sub(cursor).Seconds())
+		switch state {
+		case "online":
+			a.OnlineSec += d
+		case "offline":
+			a.OfflineSec += d
+		}
+	}
+	for _, e := range events {
+		if e.OccurredAt.Before(start) {
+			state = e.Status
+			continue
+		}
+		add(e.OccurredAt)
+		if e.Status == "offline" && state != "offline" {
+			a.Outages++
+		}
+		state, cursor = e.Status, e.OccurredAt
+	}
+	add(end)
+	if total := a.OnlineSec + a.OfflineSec; total > 0 {
+		pct := float64(a.OnlineSec) / float64(total) * 100
+		a.UptimePct = &pct
+	}
+	return a
+}
+
+// recordAgentTransition persists an online/offline transition. Coming back online resolves any
+// offline alerts raised for the agent.
+func (s *server) recordAgentTransition(agentID, status, reason string) {
+	if s.db != nil {
+		if err := s.db.RecordAgentAvailability(agentID, status, reason, time.Now()); err != nil {
+			alertsLog.Error().Msgf("Failed to record availability of agent %s: %v", agentID, err)
+		}
+	}
+	if status == "online" {
+		s.resolveOfflineAlerts(agentID)
+	}
+	ev := SystemEvent{
+		Type:    "agent." + status,
+		AgentID: agentID,
+		Message: fmt.Sprintf("Agent %s is %s (%s)", agentID, status, reason),
+		Data:    map[string]interface{}{"agent_id": agentID, "reason": reason},
+	}
+	if status == "offline" {
+		ev.Severity = "warning"
+	}
+	s.publishEvent(ev)
+}
+
+// startAvailabilityMonitor raises agent_offline alerts and prunes old transitions
+func (s *server) startAvailabilityMonitor() {
+	if s.db == nil {
+		return
+	}
+	go func() {
+		alertsLog.Info().Msg("Starting agent availability monitor")
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			s.checkOfflineAgents(now)
+			if now.Sub(lastPrune) >= 24*time.Hour {
+				if n, err := s.db.PruneAgentAvailability(now.Add(-agentAvailabilityRetention)); err != nil {
+					alertsLog.Error().Msgf("Failed to prune agent availability: %v", err)
+				} else if n > 0 {
+					alertsLog.Info().Msgf("Pruned %d agent availability events", n)
+				}
+				lastPrune = now
+			}
+		}
+	}()
+}
+
+// offlineThreshold is how long an agent must be offline before rule fires
+func (s *server) offlineThreshold(thresholdMin float64) time.Duration {
+	if thresholdMin > 0 {
+		return time.Duration(thresholdMin * float64(time.Minute))
+	}
+	if s.config != nil && s.config.Agent.OfflineAlertAfter > 0 {
+		return s.config.Agent.OfflineAlertAfter
+	}
+	return 10 * time.Minute
+}
+
+// projectRuleTenants returns the project of every agent when one of rules is scoped to a project,
+// nil (every agent outside any project) otherwise
+func (s *server) projectRuleTenants(rules []*pb.AlertRule) (map[string]agentTenant, error) {
+	for _, rule := range rules {
+		if rule.ProjectId != "" {
+			return s.db.ListAgentTenants()
+		}
+	}
+	return nil, nil
+}
+
+// checkOfflineAgents notifies the recipients of enabled agent_offline rules about agents offline
+// longer than the rule's threshold, once per outage. Rules of a project only watch its agents.
+func (s *server) checkOfflineAgents(now time.Time) {
+	rules, err := s.db.ListAlertRules()
+	if err != nil {
+		alertsLog.Error().Msgf("Availability monitor: failed to list alert rules: %v", err)
+		return
+	}
+	active := rules[:0]
+	for _, rule := range rules {
+		if rule.Enabled && rule.MetricType == agentOfflineMetric && rule.Recipients != "" {
+			active = append(active, rule)
+		}
+	}
+	if len(active) == 0 || s.alerts == nil {
+		return
+	}
+	tenants, err := s.projectRuleTenants(active)
+	if err != nil {
+		alertsLog.Error().Msgf("Availability monitor: failed to load agent projects: %v", err)
+		return
+	}
+
+	s.sessions.Range(func(key, value interface{}) bool {
+		agentID := key.(string)
+		session := value.(*AgentSession)
+		session.mu.Lock()
+		status, since, hostname := session.status, session.lastActive, session.hostname
+		session.mu.Unlock()
+		if status != "offline" {
+			return true
+		}
+		offlineFor := now.Sub(since)
+		for _, rule := range active {
+			if !ruleCovers(rule, tenants[agentID].ProjectID) || offlineFor < s.offlineThreshold(float64(rule.Threshold)) {
+				continue
+			}
+			alert := &offlineAlert{ruleName: rule.Name, recipients: rule.Recipients, firedAt: now, rule: rule}
+			if _, fired := s.offlineAlerts.LoadOrStore(offlineAlertKey{agentID, rule.Id}, alert); fired {
+				continue
+			}
+			subject := fmt.Sprintf("[CRITICAL] Agent %s is offline", hostname)
+			body := fmt.Sprintf("Alert: %s\nAgent %s (%s) has been offline for %s, since %s.",
+				rule.Name, hostname, agentID, offlineFor.Round(time.Minute), since.UTC().Format(time.RFC3339))
+			s.alerts.notifyRecipients(rule.Recipients, "critical", subject, body)
+			s.recordAlertTransition(newAlertTransition(rule, agentID, alertStateResolved, alertStateFiring, offlineFor.Minutes()))
+			s.publishEvent(SystemEvent{
+				Type:     "alert.fired",
+				Severity: "critical",
+				AgentID:  agentID,
+				Message:  fmt.Sprintf("Alert rule %s fired: agent %s is offline", rule.Name, hostname),
+				Data: map[string]interface{}{
+					"rule_id":     rule.Id,
+					"rule_name":   rule.Name,
+					"metric_type": rule.MetricType,
+					"severity":    "critical",
+					"agent_id":    agentID,
+					"hostname":    hostname,
+				},
+			})
+		}
+		return true
+	})
+}
+
+// resolveOfflineAlerts sends recoveries for the offline alerts fired for an agent
+func (s *server) resolveOfflineAlerts(agentID string) {
+	s.offlineAlerts.Range(func(key, value interface{}) bool {
+		k := key.(offlineAlertKey)
+		if k.agentID != agentID {
+			return true
+		}
+		s.offlineAlerts.Delete(k)
+		alert := value.(*offlineAlert)
+		if s.alerts != nil {
+			subject := fmt.Sprintf("[RESOLVED] Agent %s is back online", agentID)
+			body := fmt.Sprintf("Alert: %s\nAgent %s reconnected %s after the offline alert fired.",
+				alert.ruleName, agentID, time.Since(alert.firedAt).Round(time.Minute))
+			s.alerts.notifyRecipients(alert.recipients, "info", subject, body)
+		}
+		s.recordAlertTransition(newAlertTransition(alert.rule, agentID, alertStateFiring, alertStateResolved, 0))
+		s.publishEvent(SystemEvent{
+			Type:    "alert.resolved",
+			AgentID: agentID,
+			Message: fmt.Sprintf("Alert rule %s resolved: agent %s is back online", alert.ruleName, agentID),
+			Data: map[string]interface{}{
+				"rule_id":   k.ruleID,
+				"rule_name": alert.ruleName,
+				"agent_id":  agentID,
+			},
+		})
+		return true
+	})
+}
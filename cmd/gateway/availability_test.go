@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func TestComputeAgentAvailability(t *testing.T) {
+	start := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	at := func(h int) time.Time { return start.Add(time.Duration(h) * time.Hour) }
+
+	events := []AgentAvailabilityEvent{
+		{Status: "online", OccurredAt: start.Add(-48 * time.Hour)},
+		{Status: "offline", OccurredAt: at(2)},
+		{Status: "online", OccurredAt: at(3)},
+		{Status: "offline", OccurredAt: at(8)},
+		{Status: "offline", OccurredAt: at(9)}, // Duplicate transition is not a new outage
+	}
+	a := computeAgentAvailability(events, start, end)
+	if a.OnlineSec != 7*3600 || a.OfflineSec != 3*3600 {
+		t.Errorf("online %ds, offline %ds", a.OnlineSec, a.OfflineSec)
+	}
+	if a.Outages != 2 {
+		t.Errorf("outages = %d, want 2", a.Outages)
+	}
+	if a.UptimePct == nil || *a.UptimePct != 70 {
+		t.Errorf("uptime = %v, want 70", a.UptimePct)
+	}
+
+	// The time before an agent's first transition is not counted
+	a = computeAgentAvailability([]AgentAvailabilityEvent{{Status: "online", OccurredAt: at(5)}}, start, end)
+	if a.OnlineSec != 5*3600 || a.UptimePct == nil || *a.UptimePct != 100 {
+		t.Errorf("new agent: online %ds, uptime %v", a.OnlineSec, a.UptimePct)
+	}
+	if a = computeAgentAvailability(nil, start, end); a.UptimePct != nil {
+		t.Errorf("unknown agent uptime = %v, want nil", *a.UptimePct)
+	}
+}
+
+func TestOfflineThreshold(t *testing.T) {
+	s := &server{config: &config.Config{}}
+	if got := s.offlineThreshold(0); got != 10*time.Minute {
+		t.Errorf("default = %s", got)
+	}
+	s.config.Agent.OfflineAlertAfter = 30 * time.Minute
+	if got := s.offlineThreshold(0); got != 30*time.Minute {
+		t.Errorf("configured = %s", got)
+	}
+	if got := s.offlineThreshold(2.5); got != 150*time.Second {
+		t.Errorf("rule threshold = %s", got)
+	}
+}
+
+func TestResolveOfflineAlerts(t *testing.T) {
+	s := &server{}
+	s.offlineAlerts.Store(offlineAlertKey{"a1", "r1"}, &offlineAlert{})
+	s.offlineAlerts.Store(offlineAlertKey{"a1", "r2"}, &offlineAlert{})
+	s.offlineAlerts.Store(offlineAlertKey{"a2", "r1"}, &offlineAlert{})
+
+	s.resolveOfflineAlerts("a1")
+	var left []offlineAlertKey
+	s.offlineAlerts.Range(func(key, _ interface{}) bool {
+		left = append(left, key.(offlineAlertKey))
+		return true
+	})
+	if len(left) != 1 || left[0].agentID != "a2" {
+		t.Errorf("remaining alerts = %v", left)
+	}
+}
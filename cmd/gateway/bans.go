@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	alertActionBan     = "ban"
+	defaultBanDuration = time.Hour
+	banSweepInterval   = time.Minute
+)
+
+// banSyncMu serialises ban include pushes so a slow push cannot overwrite a newer render
+var banSyncMu sync.Mutex
+
+// parseBanIgnore parses the configured never-ban addresses and CIDRs, skipping invalid entries
+func parseBanIgnore(entries []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if p, err := netip.ParsePrefix(e); err == nil {
+			prefixes = append(prefixes, p.Masked())
+		} else if a, err := netip.ParseAddr(e); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+		} else if e != "" {
+			securityLog.Warn().Msgf("Ignoring invalid auto-ban ignore entry %q", e)
+		}
+	}
+	return prefixes
+}
+
+// renderBanConfig produces the http-context include holding one deny rule per active ban
+func renderBanConfig(bans []IPBan, now time.Time) string {
+	active := make([]IPBan, 0, len(bans))
+	for _, b := range bans {
+		if b.LiftedAt == nil && b.ExpiresAt.After(now) {
+			active = append(active, b)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ClientIP < active[j].ClientIP })
+
+	var sb strings.Builder
+	sb.WriteString("# Managed by Avika: alert-driven bans. Manual changes are overwritten.\n")
+	fmt.Fprintf(&sb, "# Generated %s, %d entries\n", now.UTC().Format(time.RFC3339), len(active))
+	for _, b := range active {
+		fmt.Fprintf(&sb, "deny %s; # until %s\n", b.ClientIP, b.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return sb.String()
+}
+
+// banAlertOffenders handles the "ban" action of a fired alert rule: the clients behind the metric are
+// banned for the rule's duration on the agents that served them.
+func (s *server) banAlertOffenders(rule *pb.AlertRule, value float64) {
+	if s.db == nil || s.clickhouse == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	window := int(rule.WindowSec)
+	if window <= 0 {
+		window = 60
+	}
+	offenders, err := s.clickhouse.GetAlertOffenders(ctx, rule.MetricType, window, s.config.Security.AutoBanMaxIPs)
+	if err != nil {
+		securityLog.Error().Msgf("AutoBan: rule %s: %v", rule.Name, err)
+		return
+	}
+	duration := time.Duration(rule.BanDurationSec) * time.Second
+	if duration <= 0 {
+		duration = defaultBanDuration
+	}
+	expiresAt := time.Now().Add(duration)
+	ignore := parseBanIgnore(s.config.Security.AutoBanIgnore)
+
+	affected := make(map[string]bool)
+	for _, o := range offenders {
+		ip, err := normalizeBlockCIDR(o.ClientIP)
+		if err != nil || len(o.Agents) == 0 || isBlocked(ignore, ip) {
+			continue
+		}
+		ban := IPBan{
+			ClientIP:  ip,
+			AgentIDs:  o.Agents,
+			RuleID:    rule.Id,
+			RuleName:  rule.Name,
+			Reason:    fmt.Sprintf("%s %s %.2f (threshold %.2f)", rule.MetricType, rule.Comparison, value, rule.Threshold),
+			Hits:      int64(o.Hits),
+			CreatedBy: "system",
+			ExpiresAt: expiresAt,
+		}
+		if err := s.db.UpsertIPBan(&ban); err != nil {
+			securityLog.Error().Msgf("AutoBan: failed to record ban for %s: %v", ip, err)
+			continue
+		}
+		s.db.CreateAuditLog("system", "auto_ban_ip", "ip_ban", ban.ID, "", "", map[string]string{
+			"client_ip":  ip,
+			"rule":       rule.Name,
+			"agents":     strings.Join(o.Agents, ","),
+			"expires_at": ban.ExpiresAt.UTC().Format(time.RFC3339),
+		})
+		for _, a := range o.Agents {
+			affected[a] = true
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+	securityLog.Info().Msgf("AutoBan: rule %s banned clients on %d agent(s) for %s", rule.Name, len(affected), duration)
+	s.syncBans(ctx, mapKeys(affected))
+}
+
+// syncBans re-renders and pushes the ban include of each agent
+func (s *server) syncBans(ctx context.Context, agentIDs []string) []IncludePushResult {
+	banSyncMu.Lock()
+	defer banSyncMu.Unlock()
+
+	results := make([]IncludePushResult, len(agentIDs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for i, id := range agentIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = IncludePushResult{AgentID: id}
+			bans, err := s.db.ListActiveBansForAgent(id)
+			if err == nil {
+				content := renderBanConfig(bans, time.Now())
+				_, _, err = s.pushBatchTarget(ctx, &batchTarget{agentID: id, configPath: s.config.Security.AutoBanPath, content: content}, false)
+			}
+			if err != nil {
+				securityLog.Error().Msgf("AutoBan: failed to sync bans to %s: %v", id, err)
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+		}(i, id)
+	}
+	wg.Wait()
+	return results
+}
+
+// startBanExpiry lifts expired bans and removes their deny rules from the agents
+func (s *server) startBanExpiry() {
+	if s.db == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(banSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if s.isLeader() {
+				s.expireBans()
+			}
+		}
+	}()
+}
+
+func (s *server) expireBans() {
+	expired, err := s.db.ExpireIPBans()
+	if err != nil {
+		securityLog.Error().Msgf("AutoBan: failed to expire bans: %v", err)
+		return
+	}
+	if len(expired) == 0 {
+		return
+	}
+	affected := make(map[string]bool)
+	for _, b := range expired {
+		s.db.CreateAuditLog("system", "ban_expired", "ip_ban", b.ID, "", "", map[string]string{
+			"client_ip": b.ClientIP,
+			"agents":    strings.Join(b.AgentIDs, ","),
+		})
+		for _, a := range b.AgentIDs {
+			affected[a] = true
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	s.syncBans(ctx, mapKeys(affected))
+}
+
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
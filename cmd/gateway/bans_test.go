@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderBanConfig(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lifted := now.Add(-time.Minute)
+	bans := []IPBan{
+		{ClientIP: "198.51.100.7", ExpiresAt: now.Add(time.Hour)},
+		{ClientIP: "2001:db8::1", ExpiresAt: now.Add(30 * time.Minute)},
+		{ClientIP: "192.0.2.1", ExpiresAt: now.Add(-time.Second)},
+		{ClientIP: "192.0.2.2", ExpiresAt: now.Add(time.Hour), LiftedAt: &lifted},
+	}
+	content := renderBanConfig(bans, now)
+
+	if !strings.Contains(content, "deny 198.51.100.7; # until 2024-05-01T13:00:00Z\n") {
+		t.Errorf("missing active IPv4 ban:\n%s", content)
+	}
+	if !strings.Contains(content, "deny 2001:db8::1;") {
+		t.Errorf("missing active IPv6 ban:\n%s", content)
+	}
+	if strings.Contains(content, "192.0.2.1") || strings.Contains(content, "192.0.2.2") {
+		t.Errorf("expired or lifted ban rendered:\n%s", content)
+	}
+	if !strings.Contains(content, "2 entries") {
+		t.Errorf("header count wrong:\n%s", content)
+	}
+
+	empty := renderBanConfig(nil, now)
+	if strings.Contains(empty, "deny") {
+		t.Errorf("empty ban list rendered rules:\n%s", empty)
+	}
+}
+
+func TestParseBanIgnore(t *testing.T) {
+	ignore := parseBanIgnore([]string{"127.0.0.0/8", " ::1 ", "10.1.2.3", "not-an-ip", ""})
+	if len(ignore) != 3 {
+		t.Fatalf("parsed %d prefixes, want 3", len(ignore))
+	}
+	for _, ip := range []string{"127.0.0.1", "::1", "10.1.2.3", "::ffff:127.0.0.5"} {
+		if !isBlocked(ignore, ip) {
+			t.Errorf("%s should be ignored", ip)
+		}
+	}
+	if isBlocked(ignore, "10.1.2.4") {
+		t.Error("10.1.2.4 should not be ignored")
+	}
+}
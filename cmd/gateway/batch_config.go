@@ -0,0 +1,617 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batchPushConcurrency bounds concurrent UpdateConfig calls within one batch
+const batchPushConcurrency = 10
+
+// batchTarget is the config to push to one agent
+type batchTarget struct {
+	agentID    string
+	hostname   string
+	configPath string // relative include path, or "" for the main nginx.conf
+	content    string
+	variables  map[string]string
+	err        string // render/validation error; the agent is skipped
+
+	snippetVersion int // version of the snippet pushed by a snippet batch
+}
+
+// planBatches splits the targets into the batches executed one after another.
+// parallel: one batch; rolling: batch_size per batch; canary: canary_percentage first, then rolling.
+func planBatches(agentIDs []string, strategy string, batchSize, canaryPercentage int) [][]string {
+	if len(agentIDs) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	var batches [][]string
+	rest := agentIDs
+	switch strategy {
+	case "parallel", "":
+		return [][]string{agentIDs}
+	case "canary":
+		n := (len(agentIDs)*canaryPercentage + 99) / 100
+		if n < 1 {
+			n = 1
+		}
+		if n > len(agentIDs) {
+			n = len(agentIDs)
+		}
+		batches = append(batches, agentIDs[:n])
+		rest = agentIDs[n:]
+	}
+	for len(rest) > 0 {
+		n := batchSize
+		if n > len(rest) {
+			n = len(rest)
+		}
+		batches = append(batches, rest[:n])
+		rest = rest[n:]
+	}
+	return batches
+}
+
+// BatchUpdateConfig pushes a config (template, raw content, copied from an agent or a shared
+// snippet) to a set of agents using a parallel, rolling or canary strategy. It returns immediately; progress is
+// persisted in batch_config_updates and exposed through GetBatchStatus.
+func (s *server) BatchUpdateConfig(ctx context.Context, req *pb.BatchConfigUpdateRequest) (*pb.BatchConfigUpdateResponse, error) {
+	sources := 0
+	for _, set := range []bool{req.TemplateId != "", req.RawContent != "", req.SourceAgentId != "", req.SnippetId != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of template_id, raw_content, source_agent_id or snippet_id is required")
+	}
+	switch req.Strategy {
+	case "", "parallel", "rolling", "canary":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown strategy %q", req.Strategy)
+	}
+
+	agentIDs, err := s.resolveTargetAgents(ctx, req.AgentIds, req.GroupId, req.EnvironmentId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve targets: %v", err)
+	}
+	if len(agentIDs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no target agents")
+	}
+
+	targets, err := s.buildBatchTargets(ctx, req, agentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = "parallel"
+	}
+	batches := planBatches(agentIDs, strategy, int(req.BatchSize), int(req.CanaryPercentage))
+	resp := &pb.BatchConfigUpdateResponse{
+		BatchId:      uuid.New().String(),
+		Status:       "pending",
+		Strategy:     strategy,
+		TotalAgents:  int32(len(agentIDs)),
+		TotalBatches: int32(len(batches)),
+		StartedAt:    time.Now().Unix(),
+	}
+	for _, id := range agentIDs {
+		t := targets[id]
+		result := &pb.AgentUpdateResult{AgentId: id, Hostname: t.hostname, Status: "pending"}
+		if t.err != "" {
+			result.Status, result.Error = "failed", t.err
+		} else {
+			result.ConfigHash = sha256Hex(t.content)
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	// Dry runs and validation-only requests report the rendered result without pushing
+	if req.DryRun || req.ValidateOnly {
+		for _, result := range resp.Results {
+			if result.Status == "pending" {
+				result.Status = "skipped"
+				if req.ValidateOnly && targets[result.AgentId].configPath == "" {
+					s.validateBatchTarget(ctx, targets[result.AgentId], result)
+				}
+			}
+		}
+		finalizeBatchCounts(resp)
+		resp.Status = "completed"
+		if resp.FailedCount > 0 {
+			resp.Status = "failed"
+		}
+		resp.CompletedAt = time.Now().Unix()
+		return resp, nil
+	}
+
+	if err := s.insertBatch(ctx, req, resp, agentIDs); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record batch: %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s.batches.Store(resp.BatchId, cancel)
+	go func() {
+		defer cancel()
+		defer s.batches.Delete(resp.BatchId)
+		s.runBatch(runCtx, req, resp, batches, targets)
+	}()
+
+	return resp, nil
+}
+
+// buildBatchTargets resolves the config content for every agent. Template renders are per agent
+// so built-in, environment and agent variables apply; agents whose render fails are skipped.
+func (s *server) buildBatchTargets(ctx context.Context, req *pb.BatchConfigUpdateRequest, agentIDs []string) (map[string]*batchTarget, error) {
+	targets := make(map[string]*batchTarget, len(agentIDs))
+	for _, id := range agentIDs {
+		t := &batchTarget{agentID: id}
+		if val, ok := s.sessions.Load(id); ok {
+			t.hostname = val.(*AgentSession).hostname
+		}
+		targets[id] = t
+	}
+
+	switch {
+	case req.TemplateId != "":
+		tmpl, err := s.GetConfigTemplate(ctx, &pb.GetConfigTemplateRequest{TemplateId: req.TemplateId})
+		if err != nil {
+			return nil, err
+		}
+		if !tmpl.IsActive {
+			return nil, status.Error(codes.FailedPrecondition, "template is not active")
+		}
+		for _, t := range targets {
+			t.configPath = configTemplateTargetPath(tmpl)
+			values, err := s.resolveTemplateVariables(ctx, tmpl, t.agentID, "", req.Variables)
+			if err != nil {
+				t.err = err.Error()
+				continue
+			}
+			rendered, errs := renderConfigTemplate(tmpl.Content, tmpl.Variables, values)
+			if len(errs) > 0 {
+				t.err = errs[0]
+				continue
+			}
+			t.content, t.variables = rendered, values
+		}
+
+	case req.SnippetId != "":
+		sn, err := s.db.GetConfigSnippet(req.SnippetId)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get snippet: %v", err)
+		}
+		if sn == nil {
+			return nil, status.Error(codes.NotFound, "snippet not found")
+		}
+		for _, t := range targets {
+			t.configPath, t.content, t.snippetVersion = sn.IncludePath, sn.Content, sn.Version
+		}
+
+	case req.SourceAgentId != "":
+		client, conn, err := s.getAgentClient(req.SourceAgentId)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "source agent: %v", err)
+		}
+		defer conn.Close()
+		cfg, err := client.GetConfig(ctx, &pb.ConfigRequest{InstanceId: req.SourceAgentId})
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "source agent: %v", err)
+		}
+		if cfg.Error != "" || cfg.Config == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "source agent: %s", cfg.Error)
+		}
+		for _, t := range targets {
+			t.content = cfg.Config.Content
+		}
+
+	default:
+		for _, t := range targets {
+			t.content = req.RawContent
+		}
+	}
+	return targets, nil
+}
+
+func (s *server) validateBatchTarget(ctx context.Context, t *batchTarget, result *pb.AgentUpdateResult) {
+	client, conn, err := s.getAgentClient(t.agentID)
+	if err != nil {
+		result.Status, result.Error = "failed", err.Error()
+		return
+	}
+	defer conn.Close()
+	v, err := client.ValidateConfig(ctx, &pb.ConfigValidation{InstanceId: t.agentID, ConfigContent: t.content})
+	if err != nil {
+		result.Status, result.Error = "failed", err.Error()
+	} else if !v.Valid {
+		result.Status = "failed"
+		if len(v.Errors) > 0 {
+			result.Error = v.Errors[0]
+		}
+	}
+}
+
+// runBatch executes the batches in order. With rollback_on_fail, a batch with failures stops the
+// run and every agent updated so far gets its previous config back.
+func (s *server) runBatch(ctx context.Context, req *pb.BatchConfigUpdateRequest, resp *pb.BatchConfigUpdateResponse,
+	batches [][]string, targets map[string]*batchTarget) {
+
+	var mu sync.Mutex
+	results := make(map[string]*pb.AgentUpdateResult, len(resp.Results))
+	for _, r := range resp.Results {
+		results[r.AgentId] = r
+	}
+	previous := make(map[string]string)
+	var updated []string
+
+	save := func() {
+		mu.Lock()
+		finalizeBatchCounts(resp)
+		mu.Unlock()
+		if err := s.saveBatch(resp); err != nil {
+			jobsLog.Error().Msgf("Batch %s: failed to save progress: %v", resp.BatchId, err)
+		}
+	}
+
+	resp.Status = "in_progress"
+	save()
+
+	failed := false
+	for i, batch := range batches {
+		if ctx.Err() != nil {
+			break
+		}
+		resp.CurrentBatch = int32(i + 1)
+
+		sem := make(chan struct{}, batchPushConcurrency)
+		var wg sync.WaitGroup
+		for _, id := range batch {
+			t := targets[id]
+			if t.err != "" {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(t *batchTarget) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				started := time.Now()
+				prev, backupPath, err := s.pushBatchTarget(ctx, t, req.BackupFirst)
+
+				mu.Lock()
+				defer mu.Unlock()
+				r := results[t.agentID]
+				r.DurationMs = int32(time.Since(started).Milliseconds())
+				r.CompletedAt = time.Now().Unix()
+				r.BackupPath = backupPath
+				if err != nil {
+					r.Status, r.Error = "failed", err.Error()
+					return
+				}
+				r.Status = "success"
+				if prev != nil {
+					previous[t.agentID] = *prev
+				}
+				updated = append(updated, t.agentID)
+			}(t)
+		}
+		wg.Wait()
+
+		for _, id := range batch {
+			if results[id].Status == "failed" {
+				failed = true
+			}
+		}
+		save()
+
+		if failed && req.RollbackOnFail {
+			break
+		}
+		if i == 0 && resp.Strategy == "canary" && req.CanaryDurationSeconds > 0 && len(batches) > 1 {
+			sleepCtx(ctx, time.Duration(req.CanaryDurationSeconds)*time.Second)
+		} else if i < len(batches)-1 && resp.Strategy != "parallel" && req.PauseBetweenBatchesSeconds > 0 {
+			sleepCtx(ctx, time.Duration(req.PauseBetweenBatchesSeconds)*time.Second)
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		resp.Status = "cancelled"
+	case failed && req.RollbackOnFail:
+		s.rollbackBatchTargets(updated, previous, targets, results)
+		resp.Status = "rolled_back"
+	default:
+		mu.Lock()
+		finalizeBatchCounts(resp)
+		mu.Unlock()
+		switch {
+		case resp.FailedCount == 0:
+			resp.Status = "completed"
+		case resp.CompletedCount == 0:
+			resp.Status = "failed"
+		default:
+			resp.Status = "partial_failure"
+		}
+	}
+	for _, r := range resp.Results {
+		if r.Status == "pending" {
+			r.Status = "skipped"
+		}
+	}
+	if req.TemplateId != "" {
+		s.recordTemplateAssignments(req.TemplateId, targets, results)
+	}
+	if req.SnippetId != "" {
+		s.recordSnippetDeployments(req.SnippetId, targets, results)
+	}
+	resp.CompletedAt = time.Now().Unix()
+	save()
+	jobsLog.Info().Msgf("Batch %s finished: %s (%d ok, %d failed)", resp.BatchId, resp.Status, resp.CompletedCount, resp.FailedCount)
+}
+
+// pushBatchTarget sends the config to one agent, first writing the snippets it includes. It returns the previous content of the target
+// file (nil when it could not be read, e.g. a new include file) so the batch can be rolled back.
+func (s *server) pushBatchTarget(ctx context.Context, t *batchTarget, backup bool) (*string, string, error) {
+	client, conn, err := s.getAgentClient(t.agentID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+
+	callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var prev *string
+	if cur, err := client.GetConfig(callCtx, &pb.ConfigRequest{InstanceId: t.agentID, ConfigPath: t.configPath}); err == nil && cur.Error == "" && cur.Config != nil {
+		prev = &cur.Config.Content
+	}
+
+	if err := s.materializeSnippets(callCtx, client, t.agentID, t.content); err != nil {
+		return nil, "", err
+	}
+	res, err := client.UpdateConfig(callCtx, &pb.ConfigUpdate{
+		InstanceId: t.agentID,
+		ConfigPath: t.configPath,
+		NewContent: t.content,
+		Backup:     backup,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if !res.Success {
+		return nil, res.BackupPath, fmt.Errorf("%s", res.Error)
+	}
+	return prev, res.BackupPath, nil
+}
+
+func (s *server) rollbackBatchTargets(updated []string, previous map[string]string, targets map[string]*batchTarget, results map[string]*pb.AgentUpdateResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	for _, id := range updated {
+		r := results[id]
+		prev, ok := previous[id]
+		if !ok {
+			r.Status, r.Error = "failed", "rollback skipped: previous config unknown"
+			continue
+		}
+		t := *targets[id]
+		t.content = prev
+		if _, _, err := s.pushBatchTarget(ctx, &t, false); err != nil {
+			r.Status, r.Error = "failed", "rollback failed: "+err.Error()
+			continue
+		}
+		r.Status = "rolled_back"
+	}
+}
+
+// recordTemplateAssignments tracks which template version/variables each agent received
+func (s *server) recordTemplateAssignments(templateID string, targets map[string]*batchTarget, results map[string]*pb.AgentUpdateResult) {
+	for id, r := range results {
+		state := "applied"
+		switch r.Status {
+		case "success":
+		case "failed":
+			state = "failed"
+		default:
+			continue
+		}
+		vars, _ := json.Marshal(targets[id].variables)
+		if _, err := s.db.conn.Exec(`
+			INSERT INTO agent_config_assignments (agent_id, template_id, applied_at, applied_content_hash, rendered_variables, status)
+			VALUES ($1, $2, NOW(), $3, $4, $5)
+			ON CONFLICT (agent_id, template_id) DO UPDATE SET
+				applied_at = NOW(),
+				applied_content_hash = EXCLUDED.applied_content_hash,
+				rendered_variables = EXCLUDED.rendered_variables,
+				status = EXCLUDED.status
+		`, id, templateID, r.ConfigHash, vars, state); err != nil {
+			jobsLog.Error().Msgf("Failed to record template assignment for %s: %v", id, err)
+		}
+	}
+}
+
+func finalizeBatchCounts(resp *pb.BatchConfigUpdateResponse) {
+	resp.CompletedCount, resp.FailedCount, resp.PendingCount = 0, 0, 0
+	for _, r := range resp.Results {
+		switch r.Status {
+		case "success":
+			resp.CompletedCount++
+		case "failed":
+			resp.FailedCount++
+		case "pending":
+			resp.PendingCount++
+		}
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func (s *server) insertBatch(ctx context.Context, req *pb.BatchConfigUpdateRequest, resp *pb.BatchConfigUpdateResponse, agentIDs []string) error {
+	targetType, targetID := "agents", strings.Join(agentIDs, ",")
+	switch {
+	case req.GroupId != "":
+		targetType, targetID = "group", req.GroupId
+	case req.EnvironmentId != "":
+		targetType, targetID = "environment", req.EnvironmentId
+	}
+	vars, _ := json.Marshal(nonNilMap(req.Variables))
+	results, _ := json.Marshal(resp.Results)
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	_, err := s.db.conn.ExecContext(ctx, `
+		INSERT INTO batch_config_updates (id, status, strategy, target_type, target_id, template_id, raw_content,
+			source_agent_id, snippet_id, variables, total_agents, total_batches, batch_size, pause_between_batches_seconds,
+			canary_percentage, canary_duration_seconds, rollback_on_fail, results, description, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	`, resp.BatchId, resp.Status, resp.Strategy, targetType, targetID, nullIfEmpty(req.TemplateId), nullIfEmpty(req.RawContent),
+		nullIfEmpty(req.SourceAgentId), nullIfEmpty(req.SnippetId), vars, resp.TotalAgents, resp.TotalBatches, batchSize, req.PauseBetweenBatchesSeconds,
+		req.CanaryPercentage, req.CanaryDurationSeconds, req.RollbackOnFail, results, req.Description, getUsernameFromContext(ctx))
+	return err
+}
+
+func (s *server) saveBatch(resp *pb.BatchConfigUpdateResponse) error {
+	results, _ := json.Marshal(resp.Results)
+	var completedAt interface{}
+	if resp.CompletedAt > 0 {
+		completedAt = time.Unix(resp.CompletedAt, 0)
+	}
+	_, err := s.db.conn.Exec(`
+		UPDATE batch_config_updates
+		SET status = $2, completed_count = $3, failed_count = $4, current_batch = $5, results = $6,
+			error = $7, completed_at = $8
+		WHERE id = $1
+	`, resp.BatchId, resp.Status, resp.CompletedCount, resp.FailedCount, resp.CurrentBatch, results,
+		nullIfEmpty(resp.Error), completedAt)
+	return err
+}
+
+// GetBatchStatus returns the persisted progress of a batch config update
+func (s *server) GetBatchStatus(ctx context.Context, req *pb.GetBatchStatusRequest) (*pb.BatchConfigUpdateResponse, error) {
+	if req.BatchId == "" {
+		return nil, status.Error(codes.InvalidArgument, "batch_id is required")
+	}
+	var resp pb.BatchConfigUpdateResponse
+	var results []byte
+	var errMsg sql.NullString
+	var startedAt, completedAt sql.NullTime
+	err := s.db.conn.QueryRowContext(ctx, `
+		SELECT id, status, strategy, total_agents, current_batch, total_batches, completed_count, failed_count,
+		       results, error, started_at, completed_at
+		FROM batch_config_updates WHERE id = $1
+	`, req.BatchId).Scan(&resp.BatchId, &resp.Status, &resp.Strategy, &resp.TotalAgents, &resp.CurrentBatch,
+		&resp.TotalBatches, &resp.CompletedCount, &resp.FailedCount, &results, &errMsg, &startedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "batch not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get batch: %v", err)
+	}
+	_ = json.Unmarshal(results, &resp.Results)
+	finalizeBatchCounts(&resp)
+	resp.Error = errMsg.String
+	if startedAt.Valid {
+		resp.StartedAt = startedAt.Time.Unix()
+	}
+	if completedAt.Valid {
+		resp.CompletedAt = completedAt.Time.Unix()
+	}
+	return &resp, nil
+}
+
+// CancelBatch stops a running batch after the agents currently being updated
+func (s *server) CancelBatch(ctx context.Context, req *pb.CancelBatchRequest) (*pb.CancelBatchResponse, error) {
+	val, ok := s.batches.Load(req.BatchId)
+	if !ok {
+		return &pb.CancelBatchResponse{Success: false, Message: "batch is not running on this gateway"}, nil
+	}
+	val.(context.CancelFunc)()
+	return &pb.CancelBatchResponse{Success: true, Message: "batch cancelled"}, nil
+}
+
+// POST /api/config/batch
+func (s *server) handleBatchUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	var req pb.BatchConfigUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.canUserDeployTo(w, r, req.AgentIds) {
+		return
+	}
+	resp, err := s.BatchUpdateConfig(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// POST /api/config-templates/{id}/apply
+// Body is a BatchConfigUpdateRequest without a config source; the template is rendered per agent.
+func (s *server) handleApplyConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	var req pb.BatchConfigUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	req.TemplateId = r.PathValue("id")
+	req.RawContent, req.SourceAgentId, req.SnippetId = "", "", ""
+	if !s.canUserDeployTo(w, r, req.AgentIds) {
+		return
+	}
+	resp, err := s.BatchUpdateConfig(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GET /api/config/batch/{id}
+func (s *server) handleGetBatchStatus(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GetBatchStatus(r.Context(), &pb.GetBatchStatusRequest{BatchId: r.PathValue("id")})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// POST /api/config/batch/{id}/cancel
+func (s *server) handleCancelBatch(w http.ResponseWriter, r *http.Request) {
+	resp, _ := s.CancelBatch(r.Context(), &pb.CancelBatchRequest{BatchId: r.PathValue("id")})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
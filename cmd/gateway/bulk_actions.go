@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBulkConcurrency = 5
+	maxBulkConcurrency     = 50
+	bulkActionAgentTimeout = 2 * time.Minute
+)
+
+// bulkActionNames are the operations a bulk action can fan out
+var bulkActionNames = map[string]bool{
+	"reload":  true,
+	"restart": true,
+	"update":  true,
+}
+
+// bulkActionRequest is the body of POST /api/bulk-actions
+type bulkActionRequest struct {
+	Action      string           `json:"action"`
+	Selector    BulkSelector     `json:"selector"`
+	Params      BulkActionParams `json:"params"`
+	Concurrency int              `json:"concurrency"`
+}
+
+// validate checks the request and fills in its defaults
+func (req *bulkActionRequest) validate() error {
+	if !bulkActionNames[req.Action] {
+		return fmt.Errorf("unknown action %q (reload, restart, update)", req.Action)
+	}
+	sel := &req.Selector
+	if len(sel.AgentIDs) == 0 && sel.GroupID == "" && sel.EnvironmentID == "" && len(sel.Tags) == 0 {
+		return fmt.Errorf("selector needs agent_ids, group_id, environment_id or tags")
+	}
+	switch {
+	case req.Concurrency == 0:
+		req.Concurrency = defaultBulkConcurrency
+	case req.Concurrency < 0 || req.Concurrency > maxBulkConcurrency:
+		return fmt.Errorf("concurrency must be within 1-%d", maxBulkConcurrency)
+	}
+	if req.Action == "update" && req.Params.NginxInstanceID != "" {
+		return fmt.Errorf("nginx_instance_id does not apply to agent updates")
+	}
+	if req.Action != "update" && req.Params.Version != "" {
+		return fmt.Errorf("version only applies to agent updates")
+	}
+	return nil
+}
+
+// agentsWithTags keeps the agents whose server assignment carries every tag, in order
+func agentsWithTags(agentIDs, tags []string, assignments map[string]*ServerAssignmentWithDetails) []string {
+	if len(tags) == 0 {
+		return agentIDs
+	}
+	var matched []string
+	for _, id := range agentIDs {
+		a := assignments[id]
+		if a == nil {
+			continue
+		}
+		all := true
+		for _, tag := range tags {
+			if !slices.Contains(a.Tags, tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			matched = append(matched, id)
+		}
+	}
+	return matched
+}
+
+// selectBulkTargets resolves the selector against the fleet. Tags alone narrow the whole fleet.
+func (s *server) selectBulkTargets(ctx context.Context, sel BulkSelector, agents map[string]*pb.AgentInfo) ([]string, error) {
+	var ids []string
+	if len(sel.AgentIDs) == 0 && sel.GroupID == "" && sel.EnvironmentID == "" {
+		for id := range agents {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	} else {
+		var err error
+		if ids, err = s.resolveTargetAgents(ctx, sel.AgentIDs, sel.GroupID, sel.EnvironmentID); err != nil {
+			return nil, err
+		}
+	}
+	if len(sel.Tags) == 0 {
+		return ids, nil
+	}
+	list, err := s.db.ListAllServerAssignments()
+	if err != nil {
+		return nil, err
+	}
+	assignments := make(map[string]*ServerAssignmentWithDetails, len(list))
+	for i := range list {
+		assignments[list[i].AgentID] = &list[i]
+	}
+	return agentsWithTags(ids, sel.Tags, assignments), nil
+}
+
+// planBulkAction builds the bulk action for the selected agents. Unknown and offline agents are
+// recorded as skipped; the others are pending.
+func planBulkAction(req bulkActionRequest, agentIDs []string, agents map[string]*pb.AgentInfo) *BulkAction {
+	a := &BulkAction{
+		ID:          uuid.New().String(),
+		Action:      req.Action,
+		Selector:    req.Selector,
+		Params:      req.Params,
+		Concurrency: req.Concurrency,
+		Status:      "running",
+		Results:     []BulkActionResult{},
+	}
+	for _, id := range agentIDs {
+		r := BulkActionResult{AgentID: id, Status: "pending"}
+		switch info := agents[id]; {
+		case info == nil:
+			r.Status, r.Error = "skipped", "unknown agent"
+		case info.Status != "online":
+			r.Hostname = info.Hostname
+			r.Status, r.Error = "skipped", "offline"
+		default:
+			r.Hostname = info.Hostname
+		}
+		a.Progress.add(r.Status, 1)
+		a.Results = append(a.Results, r)
+	}
+	return a
+}
+
+// CreateBulkAction resolves the selection and runs the action over it in the background on this
+// gateway. allowed restricts the selection to the agents a caller can see, nil to the whole
+// fleet; agents listed explicitly outside of it are refused.
+func (s *server) CreateBulkAction(ctx context.Context, req bulkActionRequest, username string, allowed map[string]bool) (*BulkAction, error) {
+	if err := req.validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Action == "update" && req.Params.Version != "" {
+		if _, ok := releaseManifestPath(s.updatesDir(), req.Params.Version); !ok {
+			return nil, releaseNotServedError(req.Params.Version, listAgentReleases(s.updatesDir()))
+		}
+	}
+	if allowed != nil {
+		for _, id := range req.Selector.AgentIDs {
+			if resolved, ok := s.resolveAgentID(id); ok {
+				id = resolved
+			}
+			if !allowed[id] {
+				return nil, status.Errorf(codes.PermissionDenied, "no access to agent %s", id)
+			}
+		}
+	}
+
+	agents, err := s.listAgentsByID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list agents: %v", err)
+	}
+	agentIDs, err := s.selectBulkTargets(ctx, req.Selector, agents)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve targets: %v", err)
+	}
+	if allowed != nil {
+		agentIDs = slices.DeleteFunc(agentIDs, func(id string) bool { return !allowed[id] })
+	}
+	if len(agentIDs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "the selector matches no agents")
+	}
+
+	a := planBulkAction(req, agentIDs, agents)
+	a.GatewayID, a.CreatedBy = s.instanceID, username
+	if err := s.db.CreateBulkAction(a); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record bulk action: %v", err)
+	}
+
+	// The runner updates a in place; the caller gets the action as it starts
+	started := *a
+	started.Results = slices.Clone(a.Results)
+
+	_, err = s.startJob("bulk_action", a.ID, username, a.Progress.Pending, func(ctx context.Context, run *jobRun) error {
+		runner := &bulkRunner{
+			action: a,
+			do:     s.runBulkActionOnAgent,
+			save: func(a *BulkAction, r *BulkActionResult) {
+				s.saveBulkActionProgress(a, r)
+				if r != nil {
+					trackBulkActionResult(run, r)
+				}
+			},
+			timeout: bulkActionAgentTimeout,
+		}
+		run.setStep(fmt.Sprintf("Running %s on %d agent(s), %d at a time", a.Action, a.Progress.Pending, a.Concurrency))
+		runner.run(ctx)
+		s.publishBulkActionEvent(a)
+		return bulkActionJobError(a)
+	})
+	if err != nil {
+		completed := time.Now()
+		a.Status, a.Error, a.CompletedAt = "failed", err.Error(), &completed
+		s.saveBulkActionProgress(a, nil)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &started, nil
+}
+
+// CancelBulkAction stops a bulk action from starting on more agents; those in flight finish
+func (s *server) CancelBulkAction(id string) bool {
+	return s.cancelJob(id)
+}
+
+// trackBulkActionResult counts a finished agent in the progress of the job and logs its failure
+func trackBulkActionResult(run *jobRun, r *BulkActionResult) {
+	switch r.Status {
+	case "succeeded":
+		run.advance(1)
+	case "failed":
+		run.advance(1)
+		run.logf("warn", "%s: %s", r.AgentID, r.Error)
+	}
+}
+
+// bulkActionJobError is the outcome of a finished bulk action for its job: any failed agent fails
+// the job
+func bulkActionJobError(a *BulkAction) error {
+	switch a.Status {
+	case "succeeded":
+		return nil
+	case "cancelled":
+		return context.Canceled
+	}
+	return errors.New(a.Error)
+}
+
+// runBulkActionOnAgent runs the action of a on one agent
+func (s *server) runBulkActionOnAgent(ctx context.Context, a *BulkAction, agentID string) error {
+	switch a.Action {
+	case "reload":
+		resp, err := s.ReloadNginx(ctx, &pb.ReloadRequest{InstanceId: agentID, NginxInstanceId: a.Params.NginxInstanceID})
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+	case "restart":
+		resp, err := s.RestartNginx(ctx, &pb.RestartRequest{InstanceId: agentID, NginxInstanceId: a.Params.NginxInstanceID})
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+	case "update":
+		return s.upgradeAgent(ctx, agentID, a.Params.Version)
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+// saveBulkActionProgress persists a result, or the final status of the action when r is nil
+func (s *server) saveBulkActionProgress(a *BulkAction, r *BulkActionResult) {
+	var err error
+	if r != nil {
+		err = s.db.SaveBulkActionResult(a.ID, r)
+	} else {
+		err = s.db.FinishBulkAction(a)
+	}
+	if err != nil {
+		jobsLog.Error().Msgf("Bulk action %s: failed to save progress: %v", a.ID, err)
+	}
+}
+
+// publishBulkActionEvent records the outcome of a bulk action in the activity feed
+func (s *server) publishBulkActionEvent(a *BulkAction) {
+	ev := SystemEvent{
+		Type:  "task.bulk_action",
+		Actor: a.CreatedBy,
+		Message: fmt.Sprintf("Bulk %s %s: %d succeeded, %d failed, %d skipped", a.Action, a.Status,
+			a.Progress.Succeeded, a.Progress.Failed, a.Progress.Skipped),
+		Data: map[string]interface{}{
+			"bulk_action_id": a.ID,
+			"action":         a.Action,
+			"status":         a.Status,
+			"succeeded":      a.Progress.Succeeded,
+			"failed":         a.Progress.Failed,
+			"skipped":        a.Progress.Skipped,
+		},
+	}
+	if a.Progress.Failed > 0 {
+		ev.Severity = "warning"
+	}
+	s.publishEvent(ev)
+}
+
+// interruptBulkActions closes the bulk actions this gateway was running before it restarted
+func (s *server) interruptBulkActions() {
+	if s.db == nil {
+		return
+	}
+	if n, err := s.db.InterruptBulkActions(s.instanceID); err != nil {
+		jobsLog.Error().Msgf("Failed to close interrupted bulk actions: %v", err)
+	} else if n > 0 {
+		jobsLog.Info().Msgf("Marked %d bulk action(s) interrupted by the gateway restart", n)
+	}
+}
+
+// bulkActionStatus is the final status of a bulk action from its results
+func bulkActionStatus(p BulkProgress, cancelled bool) (string, string) {
+	switch {
+	case cancelled:
+		return "cancelled", fmt.Sprintf("cancelled after %d of %d agents", p.Succeeded+p.Failed, p.Total)
+	case p.Failed > 0 && p.Succeeded == 0:
+		return "failed", fmt.Sprintf("failed on all %d attempted agents", p.Failed)
+	case p.Failed > 0:
+		return "partial", fmt.Sprintf("failed on %d of %d attempted agents", p.Failed, p.Succeeded+p.Failed)
+	case p.Succeeded == 0:
+		return "failed", "no selected agent was online"
+	}
+	return "succeeded", ""
+}
+
+// bulkRunner runs the action of a bulk action on its pending agents, at most concurrency at a
+// time. Each agent gets its own timeout and is not interrupted by a cancel once started.
+type bulkRunner struct {
+	action  *BulkAction
+	do      func(ctx context.Context, a *BulkAction, agentID string) error
+	save    func(a *BulkAction, r *BulkActionResult) // r nil saves the final status
+	timeout time.Duration
+}
+
+func (b *bulkRunner) run(ctx context.Context) {
+	a := b.action
+	var mu sync.Mutex // guards the results and progress, which are read by the caller afterwards
+	sem := make(chan struct{}, max(a.Concurrency, 1))
+	var wg sync.WaitGroup
+
+	for i := range a.Results {
+		r := &a.Results[i]
+		if r.Status != "pending" {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		mu.Lock()
+		started := time.Now()
+		r.Status, r.StartedAt = "running", &started
+		b.save(a, r)
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			agentCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.timeout)
+			err := b.do(agentCtx, a, r.AgentID)
+			cancel()
+
+			mu.Lock()
+			defer mu.Unlock()
+			completed := time.Now()
+			r.Status, r.CompletedAt = "succeeded", &completed
+			if err != nil {
+				r.Status, r.Error = "failed", err.Error()
+			}
+			b.save(a, r)
+		}()
+	}
+	wg.Wait()
+
+	cancelled := false
+	a.Progress = BulkProgress{}
+	for i := range a.Results {
+		r := &a.Results[i]
+		if r.Status == "pending" {
+			r.Status, r.Error = "skipped", "bulk action cancelled"
+			b.save(a, r)
+			cancelled = true
+		}
+		a.Progress.add(r.Status, 1)
+	}
+	a.Status, a.Error = bulkActionStatus(a.Progress, cancelled)
+	completed := time.Now()
+	a.CompletedAt = &completed
+	b.save(a, nil)
+	jobsLog.Info().Msgf("Bulk %s %s %s: %d succeeded, %d failed, %d skipped", a.Action, a.ID, a.Status,
+		a.Progress.Succeeded, a.Progress.Failed, a.Progress.Skipped)
+}
+
+// bulkActionSummary describes the selection of a bulk action for the audit log
+func bulkActionSummary(a *BulkAction) map[string]interface{} {
+	return map[string]interface{}{
+		"action":         a.Action,
+		"agents":         a.Progress.Pending,
+		"skipped":        a.Progress.Skipped,
+		"concurrency":    a.Concurrency,
+		"agent_ids":      strings.Join(a.Selector.AgentIDs, ","),
+		"group_id":       a.Selector.GroupID,
+		"environment_id": a.Selector.EnvironmentID,
+		"tags":           strings.Join(a.Selector.Tags, ","),
+	}
+}
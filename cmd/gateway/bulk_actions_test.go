@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestBulkActionRequestValidate(t *testing.T) {
+	req := bulkActionRequest{Action: "reload", Selector: BulkSelector{Tags: []string{"edge"}}}
+	if err := req.validate(); err != nil || req.Concurrency != defaultBulkConcurrency {
+		t.Errorf("validate = %v, concurrency %d", err, req.Concurrency)
+	}
+
+	cases := map[string]bulkActionRequest{
+		"unknown action":        {Action: "stop", Selector: BulkSelector{AgentIDs: []string{"a"}}},
+		"selector needs":        {Action: "reload"},
+		"concurrency must be":   {Action: "reload", Selector: BulkSelector{GroupID: "g"}, Concurrency: maxBulkConcurrency + 1},
+		"does not apply":        {Action: "update", Selector: BulkSelector{GroupID: "g"}, Params: BulkActionParams{NginxInstanceID: "n"}},
+		"only applies to agent": {Action: "restart", Selector: BulkSelector{GroupID: "g"}, Params: BulkActionParams{Version: "1.0.0"}},
+	}
+	for want, req := range cases {
+		err := req.validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestAgentsWithTags(t *testing.T) {
+	assignments := map[string]*ServerAssignmentWithDetails{
+		"a": {AgentID: "a", Tags: []string{"edge", "eu"}},
+		"b": {AgentID: "b", Tags: []string{"edge"}},
+		"c": {AgentID: "c", Tags: []string{"eu", "edge"}},
+	}
+	got := agentsWithTags([]string{"a", "b", "c", "unassigned"}, []string{"edge", "eu"}, assignments)
+	if strings.Join(got, ",") != "a,c" {
+		t.Errorf("agentsWithTags = %v", got)
+	}
+}
+
+func TestPlanBulkAction(t *testing.T) {
+	agents := map[string]*pb.AgentInfo{
+		"a": {AgentId: "a", Hostname: "web-1", Status: "online"},
+		"b": {AgentId: "b", Hostname: "web-2", Status: "offline"},
+	}
+	a := planBulkAction(bulkActionRequest{Action: "reload", Concurrency: 2}, []string{"a", "b", "ghost"}, agents)
+	got := []string{}
+	for _, r := range a.Results {
+		got = append(got, r.AgentID+"="+r.Status+":"+r.Error)
+	}
+	if strings.Join(got, " ") != "a=pending: b=skipped:offline ghost=skipped:unknown agent" {
+		t.Errorf("results = %v", got)
+	}
+	if a.Progress.Total != 3 || a.Progress.Pending != 1 || a.Progress.Skipped != 2 || a.Results[1].Hostname != "web-2" {
+		t.Errorf("progress = %+v", a.Progress)
+	}
+}
+
+func TestBulkActionStatus(t *testing.T) {
+	cases := []struct {
+		progress  BulkProgress
+		cancelled bool
+		want      string
+	}{
+		{BulkProgress{Total: 2, Succeeded: 2}, false, "succeeded"},
+		{BulkProgress{Total: 3, Succeeded: 2, Failed: 1}, false, "partial"},
+		{BulkProgress{Total: 2, Failed: 2}, false, "failed"},
+		{BulkProgress{Total: 2, Skipped: 2}, false, "failed"},
+		{BulkProgress{Total: 3, Succeeded: 1, Skipped: 2}, true, "cancelled"},
+	}
+	for _, c := range cases {
+		if got, _ := bulkActionStatus(c.progress, c.cancelled); got != c.want {
+			t.Errorf("bulkActionStatus(%+v, %v) = %s, want %s", c.progress, c.cancelled, got, c.want)
+		}
+	}
+}
+
+// newTestBulkAction returns a bulk action with n pending agents a0..a(n-1)
+func newTestBulkAction(n, concurrency int) *BulkAction {
+	a := &BulkAction{ID: "job", Action: "reload", Concurrency: concurrency, Status: "running"}
+	for i := 0; i < n; i++ {
+		a.Results = append(a.Results, BulkActionResult{AgentID: fmt.Sprintf("a%d", i), Status: "pending"})
+	}
+	return a
+}
+
+func TestBulkRunnerConcurrencyAndPartialFailure(t *testing.T) {
+	a := newTestBulkAction(8, 3)
+	var running, peak atomic.Int32
+	runner := &bulkRunner{
+		action: a,
+		do: func(ctx context.Context, _ *BulkAction, agentID string) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if agentID == "a2" || agentID == "a5" {
+				return fmt.Errorf("nginx -t failed")
+			}
+			return nil
+		},
+		save:    func(*BulkAction, *BulkActionResult) {},
+		timeout: time.Second,
+	}
+	runner.run(context.Background())
+
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Errorf("peak concurrency = %d, want at most 3", p)
+	}
+	if a.Status != "partial" || a.Progress.Succeeded != 6 || a.Progress.Failed != 2 || a.CompletedAt == nil {
+		t.Errorf("status %s, progress %+v", a.Status, a.Progress)
+	}
+	if r := a.Results[2]; r.Status != "failed" || r.Error != "nginx -t failed" || r.StartedAt == nil {
+		t.Errorf("failed result = %+v", r)
+	}
+}
+
+func TestBulkRunnerCancel(t *testing.T) {
+	a := newTestBulkAction(5, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	saved := 0
+	runner := &bulkRunner{
+		action: a,
+		do: func(ctx context.Context, _ *BulkAction, agentID string) error {
+			if agentID == "a1" {
+				cancel()
+				// agents in flight finish their action despite the cancel
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+			}
+			return nil
+		},
+		save: func(_ *BulkAction, r *BulkActionResult) {
+			mu.Lock()
+			saved++
+			mu.Unlock()
+		},
+		timeout: time.Second,
+	}
+	runner.run(ctx)
+
+	if a.Status != "cancelled" || a.Progress.Succeeded != 2 || a.Progress.Skipped != 3 {
+		t.Errorf("status %s, progress %+v", a.Status, a.Progress)
+	}
+	if r := a.Results[4]; r.Status != "skipped" || r.StartedAt != nil {
+		t.Errorf("agent after the cancel = %+v", r)
+	}
+	if saved == 0 {
+		t.Error("progress was never saved")
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// certExpiryMetric is the alert rule metric type whose recipients receive certificate expiry alerts.
+const certExpiryMetric = "cert_expiry"
+
+// certExpiryMilestones are the days-before-expiry at which an alert is raised (0 = expired).
+var certExpiryMilestones = []int{30, 14, 7, 0}
+
+// certExpiryMilestone returns the tightest milestone a certificate has crossed, or -1 if none.
+func certExpiryMilestone(daysLeft int) int {
+	milestone := -1
+	for _, m := range certExpiryMilestones {
+		if daysLeft <= m {
+			milestone = m
+		}
+	}
+	return milestone
+}
+
+// certExpirySeverity maps a milestone to an alert severity.
+func certExpirySeverity(milestone int) string {
+	switch {
+	case milestone <= 7:
+		return "critical"
+	case milestone <= 14:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// startCertificateMonitor polls every online agent's certificate inventory once a day,
+// stores it in Postgres and raises expiry alerts at 30/14/7 days.
+func (srv *server) startCertificateMonitor() {
+	go func() {
+		alertsLog.Info().Msg("Starting certificate expiry monitor (interval: 24h)")
+		// Give agents time to reconnect after a gateway restart before the first sweep
+		time.Sleep(2 * time.Minute)
+		srv.pollCertificates()
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			srv.pollCertificates()
+		}
+	}()
+}
+
+func (srv *server) pollCertificates() {
+	if srv.db == nil {
+		return
+	}
+
+	// Each gateway polls the agents connected to it
+	agentIDs := srv.connectedAgentIDs()
+
+	polled := 0
+	for _, agentID := range agentIDs {
+		if err := srv.syncAgentCertificates(agentID); err != nil {
+			alertsLog.Error().Msgf("Certificate monitor: failed to poll agent %s: %v", agentID, err)
+			continue
+		}
+		polled++
+	}
+	alertsLog.Info().Int("agents", polled).Msg("Certificate inventory refreshed")
+
+	if srv.isLeader() {
+		srv.raiseCertificateExpiryAlerts()
+	}
+}
+
+func (srv *server) syncAgentCertificates(agentID string) error {
+	client, conn, err := srv.getAgentClient(agentID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.ListCertificates(ctx, &pb.CertListRequest{InstanceId: agentID})
+	if err != nil {
+		return err
+	}
+	return srv.db.SyncAgentCertificates(ctx, agentID, resp.Certificates)
+}
+
+// raiseCertificateExpiryAlerts notifies the recipients of enabled cert_expiry alert rules
+// about every certificate that crossed a new milestone since the last sweep. Rules of a project
+// only cover the certificates of its agents.
+func (srv *server) raiseCertificateExpiryAlerts() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	certs, err := srv.db.ListFleetCertificates(ctx, nil, certExpiryMilestones[0])
+	if err != nil {
+		alertsLog.Error().Msgf("Certificate monitor: failed to list certificates: %v", err)
+		return
+	}
+
+	var rules []*pb.AlertRule
+	if all, err := srv.db.ListAlertRules(); err == nil {
+		for _, rule := range all {
+			if rule.Enabled && rule.MetricType == certExpiryMetric && rule.Recipients != "" {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	tenants, err := srv.projectRuleTenants(rules)
+	if err != nil {
+		alertsLog.Error().Msgf("Certificate monitor: failed to load agent projects: %v", err)
+		return
+	}
+
+	for _, c := range certs {
+		milestone := certExpiryMilestone(c.DaysUntilExpiry)
+		if milestone < 0 || (c.LastAlertDays != nil && *c.LastAlertDays <= milestone) {
+			continue
+		}
+
+		severity := certExpirySeverity(milestone)
+		subject := fmt.Sprintf("[%s] Certificate for %s expires in %d days", strings.ToUpper(severity), c.Domain, c.DaysUntilExpiry)
+		if c.DaysUntilExpiry < 0 {
+			subject = fmt.Sprintf("[%s] Certificate for %s has expired", strings.ToUpper(severity), c.Domain)
+		}
+		body := fmt.Sprintf("Certificate '%s' on %s (%s) expires on %s.\n\nPath: %s\nIssuer: %s\nDays remaining: %d",
+			c.Domain, c.Hostname, c.AgentID, c.ExpiryDate.Format(time.RFC1123), c.CertPath, c.Issuer, c.DaysUntilExpiry)
+
+		alertsLog.Info().Msgf("CERT EXPIRY ALERT [%s]: %s on agent %s expires in %d days", strings.ToUpper(severity), c.Domain, c.AgentID, c.DaysUntilExpiry)
+		if srv.alerts != nil {
+			for _, rule := range rules {
+				if ruleCovers(rule, tenants[c.AgentID].ProjectID) {
+					srv.alerts.notifyRecipients(rule.Recipients, severity, subject, body)
+				}
+			}
+		}
+
+		if err := srv.db.MarkCertificateAlerted(ctx, c.AgentID, c.CertPath, milestone); err != nil {
+			alertsLog.Error().Msgf("Certificate monitor: failed to record alert for %s on %s: %v", c.Domain, c.AgentID, err)
+		}
+	}
+}
+
+// GET /api/certificates?within_days=30&refresh=true
+// Fleet-wide certificate inventory sorted by expiry (soonest first).
+func (srv *server) handleListFleetCertificates(w http.ResponseWriter, r *http.Request) {
+	withinDays, _ := strconv.Atoi(r.URL.Query().Get("within_days"))
+
+	var agentIDs []string
+	user := middleware.GetUserFromContext(r.Context())
+	if user != nil {
+		if isSuperAdmin, _ := srv.db.IsSuperAdmin(user.Username); !isSuperAdmin {
+			visible, err := srv.db.GetVisibleAgentIDs(user.Username)
+			if err != nil {
+				http.Error(w, "Failed to check access permissions", http.StatusInternalServerError)
+				return
+			}
+			agentIDs = append([]string{}, visible...)
+		}
+	}
+
+	if r.URL.Query().Get("refresh") == "true" {
+		if agentID := r.URL.Query().Get("agent_id"); agentID != "" {
+			if resolved, ok := srv.resolveAgentID(agentID); ok {
+				agentID = resolved
+			}
+			if user != nil && !srv.canUserAccessAgent(user.Username, agentID) {
+				http.Error(w, "Access denied", http.StatusForbidden)
+				return
+			}
+			if err := srv.syncAgentCertificates(agentID); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to refresh certificates: %v", err), http.StatusBadGateway)
+				return
+			}
+		}
+	}
+
+	certs, err := srv.db.ListFleetCertificates(r.Context(), agentIDs, withinDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summary := map[string]int{"total": len(certs), "expired": 0, "expiring_7d": 0, "expiring_30d": 0}
+	for _, c := range certs {
+		switch {
+		case c.DaysUntilExpiry < 0:
+			summary["expired"]++
+		case c.DaysUntilExpiry <= 7:
+			summary["expiring_7d"]++
+		case c.DaysUntilExpiry <= 30:
+			summary["expiring_30d"]++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"certificates": certs,
+		"summary":      summary,
+	})
+}
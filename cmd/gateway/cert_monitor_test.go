@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCertExpiryMilestone(t *testing.T) {
+	tests := []struct {
+		days      int
+		milestone int
+		severity  string
+	}{
+		{90, -1, ""},
+		{31, -1, ""},
+		{30, 30, "info"},
+		{20, 30, "info"},
+		{14, 14, "warning"},
+		{8, 14, "warning"},
+		{7, 7, "critical"},
+		{1, 7, "critical"},
+		{0, 0, "critical"},
+		{-3, 0, "critical"},
+	}
+	for _, tt := range tests {
+		m := certExpiryMilestone(tt.days)
+		if m != tt.milestone {
+			t.Errorf("certExpiryMilestone(%d) = %d, want %d", tt.days, m, tt.milestone)
+			continue
+		}
+		if m >= 0 && certExpirySeverity(m) != tt.severity {
+			t.Errorf("certExpirySeverity(%d) = %s, want %s", m, certExpirySeverity(m), tt.severity)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if d := daysUntil(now, now.Add(36*time.Hour)); d != 1 {
+		t.Errorf("expected 1 day, got %d", d)
+	}
+	if d := daysUntil(now, now.Add(-time.Hour)); d != -1 {
+		t.Errorf("expected -1 day, got %d", d)
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/vault"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// certDeployConcurrency bounds how many agents receive a certificate at once.
+const certDeployConcurrency = 5
+
+// parsedCertificate holds the fields extracted from a PEM certificate for the inventory.
+type parsedCertificate struct {
+	Domain     string
+	Issuer     string
+	Serial     string
+	NotBefore  time.Time
+	NotAfter   time.Time
+	SANDomains []string
+	CertType   string
+}
+
+// parseCertificatePEM validates a cert/key pair and extracts inventory metadata from the leaf certificate.
+func parseCertificatePEM(certPEM, keyPEM, chainPEM []byte) (*parsedCertificate, error) {
+	full := certPEM
+	if len(chainPEM) > 0 {
+		full = append(append(append([]byte{}, certPEM...), '\n'), chainPEM...)
+	}
+	if _, err := tls.X509KeyPair(full, keyPEM); err != nil {
+		return nil, fmt.Errorf("certificate and key do not match: %w", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	p := &parsedCertificate{
+		Domain:     cert.Subject.CommonName,
+		Issuer:     cert.Issuer.CommonName,
+		Serial:     cert.SerialNumber.Text(16),
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		SANDomains: cert.DNSNames,
+		CertType:   "commercial",
+	}
+	if p.Domain == "" && len(p.SANDomains) > 0 {
+		p.Domain = p.SANDomains[0]
+	}
+	if cert.Issuer.String() == cert.Subject.String() {
+		p.CertType = "self_signed"
+	} else if cert.Issuer.Organization != nil && len(cert.Issuer.Organization) > 0 && cert.Issuer.Organization[0] == "Let's Encrypt" {
+		p.CertType = "letsencrypt"
+	}
+	return p, nil
+}
+
+// loadCertificateFromVault reads certificate material from a Vault KV v2 secret.
+func (s *server) loadCertificateFromVault(path string) (cert, key, chain []byte, err error) {
+	client, err := vault.NewClient(vault.Config{
+		Address: s.config.SecretsProvider.Vault.Address,
+		Token:   s.config.SecretsProvider.Vault.Token,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	secret, err := client.GetSecret(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	get := func(keys ...string) []byte {
+		for _, k := range keys {
+			if v, ok := secret[k].(string); ok && v != "" {
+				return []byte(v)
+			}
+		}
+		return nil
+	}
+	cert = get("certificate", "tls.crt", "cert")
+	key = get("private_key", "tls.key", "key")
+	chain = get("chain", "ca.crt")
+	if len(cert) == 0 || len(key) == 0 {
+		return nil, nil, nil, fmt.Errorf("vault secret %s has no certificate/private_key", path)
+	}
+	return cert, key, chain, nil
+}
+
+// UploadCertificate stores a certificate in the inventory and optionally deploys it to agents.
+func (s *server) UploadCertificate(ctx context.Context, req *pb.UploadCertificateRequest) (*pb.UploadCertificateResponse, error) {
+	if req.VaultPath != "" {
+		cert, key, chain, err := s.loadCertificateFromVault(req.VaultPath)
+		if err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to load certificate from vault: %v", err)
+		}
+		req.CertContent, req.KeyContent, req.ChainContent = cert, key, chain
+	}
+	if len(req.CertContent) == 0 || len(req.KeyContent) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "cert_content and key_content (or vault_path) are required")
+	}
+
+	parsed, err := parseCertificatePEM(req.CertContent, req.KeyContent, req.ChainContent)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Domain == "" {
+		req.Domain = parsed.Domain
+	}
+	if req.Domain == "" {
+		return nil, status.Error(codes.InvalidArgument, "domain is required")
+	}
+	certType := req.CertType
+	if certType == "" {
+		certType = parsed.CertType
+	}
+
+	var envID interface{}
+	if req.EnvironmentId != "" {
+		envID = req.EnvironmentId
+	}
+	var keyContent, vaultPath interface{}
+	if req.VaultPath != "" {
+		// Keys sourced from Vault are re-read at deploy time rather than copied into Postgres
+		vaultPath = req.VaultPath
+	} else {
+		keyContent = string(req.KeyContent)
+	}
+
+	certID := uuid.New().String()
+	_, err = s.db.conn.ExecContext(ctx, `
+		INSERT INTO certificate_inventory (id, domain, environment_id, cert_type, issuer, serial_number, expiry_date,
+			not_before, san_domains, cert_content_hash, key_content_hash, cert_content, chain_content, key_content, vault_path, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, certID, req.Domain, envID, certType, parsed.Issuer, parsed.Serial, parsed.NotAfter, parsed.NotBefore,
+		pq.Array(parsed.SANDomains), sha256Hex(string(req.CertContent)), sha256Hex(string(req.KeyContent)),
+		string(req.CertContent), string(req.ChainContent), keyContent, vaultPath, getUsernameFromContext(ctx))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store certificate: %v", err)
+	}
+
+	resp := &pb.UploadCertificateResponse{Success: true, CertificateId: certID}
+
+	targets, err := s.resolveTargetAgents(ctx, req.AgentIds, req.GroupId, "")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve targets: %v", err)
+	}
+	if len(targets) > 0 {
+		resp.Deployments = s.deployCertificate(ctx, certID, req.Domain, req.CertContent, req.KeyContent, req.ChainContent,
+			targets, req.BackupExisting, req.ReloadNginx)
+		resp.Success, resp.Error = summarizeCertDeployments(resp.Deployments)
+	}
+	return resp, nil
+}
+
+// DeployCertificate pushes an inventory certificate to the selected agents.
+func (s *server) DeployCertificate(ctx context.Context, req *pb.DeployCertificateRequest) (*pb.UploadCertificateResponse, error) {
+	if req.CertificateId == "" {
+		return nil, status.Error(codes.InvalidArgument, "certificate_id is required")
+	}
+
+	var domain, certContent string
+	var chainContent, keyContent, vaultPath sql.NullString
+	err := s.db.conn.QueryRowContext(ctx, `
+		SELECT domain, cert_content, chain_content, key_content, vault_path
+		FROM certificate_inventory WHERE id = $1
+	`, req.CertificateId).Scan(&domain, &certContent, &chainContent, &keyContent, &vaultPath)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "certificate not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load certificate: %v", err)
+	}
+
+	cert, key, chain := []byte(certContent), []byte(keyContent.String), []byte(chainContent.String)
+	if vaultPath.Valid && vaultPath.String != "" {
+		if cert, key, chain, err = s.loadCertificateFromVault(vaultPath.String); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to load certificate from vault: %v", err)
+		}
+	}
+	if len(key) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "no private key stored for this certificate; upload it again")
+	}
+
+	targets, err := s.resolveTargetAgents(ctx, req.AgentIds, req.GroupId, req.EnvironmentId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve targets: %v", err)
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no target agents selected")
+	}
+
+	deployments := s.deployCertificate(ctx, req.CertificateId, domain, cert, key, chain, targets, req.BackupExisting, req.ReloadNginx)
+	success, errMsg := summarizeCertDeployments(deployments)
+	return &pb.UploadCertificateResponse{
+		Success:       success,
+		CertificateId: req.CertificateId,
+		Deployments:   deployments,
+		Error:         errMsg,
+	}, nil
+}
+
+// GetCertificateInventory lists stored certificates with their deployment counts.
+func (s *server) GetCertificateInventory(ctx context.Context, req *pb.GetCertificateInventoryRequest) (*pb.GetCertificateInventoryResponse, error) {
+	withinDays := req.ExpiringWithinDays
+	if req.ExpiringOnly && withinDays <= 0 {
+		withinDays = 30
+	}
+
+	rows, err := s.db.conn.QueryContext(ctx, `
+		SELECT c.id, c.domain, COALESCE(c.environment_id::text, ''), COALESCE(c.cert_type, ''), COALESCE(c.issuer, ''),
+		       c.expiry_date, c.san_domains, COALESCE(c.auto_renew, false), COALESCE(c.cert_content_hash, ''),
+		       c.created_at, c.updated_at,
+		       (SELECT COUNT(*) FROM certificate_deployments d WHERE d.certificate_id = c.id AND d.status = 'deployed')
+		FROM certificate_inventory c
+		WHERE ($1 = '' OR c.environment_id::text = $1)
+		  AND ($2::int <= 0 OR c.expiry_date <= NOW() + make_interval(days => $2::int))
+		ORDER BY c.expiry_date ASC
+	`, req.EnvironmentId, withinDays)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list certificates: %v", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	resp := &pb.GetCertificateInventoryResponse{}
+	for rows.Next() {
+		var item pb.CertificateInventoryItem
+		var expiry, createdAt, updatedAt time.Time
+		var sans pq.StringArray
+		if err := rows.Scan(&item.Id, &item.Domain, &item.EnvironmentId, &item.CertType, &item.Issuer,
+			&expiry, &sans, &item.AutoRenew, &item.CertContentHash, &createdAt, &updatedAt, &item.DeployedToCount); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to scan certificate: %v", err)
+		}
+		item.ExpiryTimestamp = expiry.Unix()
+		item.DaysUntilExpiry = int32(daysUntil(now, expiry))
+		item.SanDomains = sans
+		item.CreatedAt = createdAt.Unix()
+		item.UpdatedAt = updatedAt.Unix()
+		resp.Certificates = append(resp.Certificates, &item)
+	}
+	return resp, rows.Err()
+}
+
+// resolveTargetAgents expands explicit agent IDs, a group and an environment into a de-duplicated agent list.
+func (s *server) resolveTargetAgents(ctx context.Context, agentIDs []string, groupID, environmentID string) ([]string, error) {
+	seen := make(map[string]bool)
+	var targets []string
+	add := func(id string) {
+		if resolved, ok := s.resolveAgentID(id); ok {
+			id = resolved
+		}
+		if id != "" && !seen[id] {
+			seen[id] = true
+			targets = append(targets, id)
+		}
+	}
+
+	for _, id := range agentIDs {
+		add(id)
+	}
+	if groupID != "" {
+		agents, err := s.getAgentsInGroup(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			add(a.agentID)
+		}
+	}
+	if environmentID != "" {
+		ids, err := s.db.GetAgentIDsForEnvironment(environmentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			add(id)
+		}
+	}
+	return targets, nil
+}
+
+// deployCertificate sends the certificate to each agent over the management channel. The agent writes
+// the files, updates matching server blocks and rolls everything back if nginx -t fails.
+func (s *server) deployCertificate(ctx context.Context, certID, domain string, cert, key, chain []byte,
+	targets []string, backup, reload bool) []*pb.CertDeploymentResult {
+
+	results := make([]*pb.CertDeploymentResult, len(targets))
+	sem := make(chan struct{}, certDeployConcurrency)
+	var wg sync.WaitGroup
+
+	for i, agentID := range targets {
+		wg.Add(1)
+		go func(i int, agentID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := &pb.CertDeploymentResult{AgentId: agentID}
+			if session, ok := s.getAgentSession(agentID); ok {
+				result.Hostname = session.hostname
+			}
+			results[i] = result
+
+			client, conn, err := s.getAgentClient(agentID)
+			if err != nil {
+				result.Error = err.Error()
+				s.recordCertDeployment(certID, result)
+				return
+			}
+			defer conn.Close()
+
+			callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+			defer cancel()
+			resp, err := client.UploadCertificate(callCtx, &pb.UploadCertificateRequest{
+				Domain:         domain,
+				CertContent:    cert,
+				KeyContent:     key,
+				ChainContent:   chain,
+				BackupExisting: backup,
+				ReloadNginx:    reload,
+			})
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case !resp.Success:
+				result.Error = resp.Error
+			default:
+				result.Success = true
+				result.Error = resp.Error // reload warning, if any
+				if len(resp.Deployments) > 0 {
+					result.CertPath = resp.Deployments[0].CertPath
+					result.KeyPath = resp.Deployments[0].KeyPath
+				}
+			}
+			s.recordCertDeployment(certID, result)
+		}(i, agentID)
+	}
+	wg.Wait()
+	return results
+}
+
+// recordCertDeployment stores the outcome of a deployment in certificate_deployments.
+func (s *server) recordCertDeployment(certID string, r *pb.CertDeploymentResult) {
+	status := "deployed"
+	if !r.Success {
+		status = "failed"
+	}
+	var errMsg interface{}
+	if r.Error != "" {
+		errMsg = r.Error
+	}
+	_, err := s.db.conn.Exec(`
+		INSERT INTO certificate_deployments (certificate_id, agent_id, cert_path, key_path, status, error, deployed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (certificate_id, agent_id) DO UPDATE SET
+			cert_path = EXCLUDED.cert_path,
+			key_path = EXCLUDED.key_path,
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			deployed_at = NOW()
+	`, certID, r.AgentId, r.CertPath, r.KeyPath, status, errMsg)
+	if err != nil {
+		gatewayLog.Error().Msgf("Failed to record certificate deployment %s -> %s: %v", certID, r.AgentId, err)
+	}
+}
+
+func summarizeCertDeployments(results []*pb.CertDeploymentResult) (bool, string) {
+	failed := 0
+	for _, r := range results {
+		if !r.Success {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return true, ""
+	}
+	return false, fmt.Sprintf("deployment failed on %d of %d agents", failed, len(results))
+}
@@ -3,16 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"math"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/avika-ai/avika/cmd/gateway/config"
 	"github.com/avika-ai/avika/cmd/gateway/geo"
+	"github.com/avika-ai/avika/cmd/gateway/threatintel"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 	"github.com/google/uuid"
 )
@@ -24,7 +26,25 @@ type ClickHouseDB struct {
 	sysChan   chan sysBatchItem
 	nginxChan chan nginxBatchItem
 	gwChan    chan gwBatchItem
+	secChan   chan SecurityEvent
+	synChan   chan SyntheticResult
+	genChan   chan genericMetricItem
 	geoLookup *geo.GeoIPLookup
+
+	uaParser    *UAParser
+	botNetworks *geo.BotNetworks
+	threats     *threatintel.Store
+	// Endpoint each request URI is grouped under (uri_templates.go)
+	endpoints *uriNormalizer
+
+	// Tenant of each agent stamped on its rows (clickhouse_tenancy.go); nil stamps none
+	tenants atomic.Pointer[tenantCache]
+
+	// Single node or cluster layout the schema statements are rewritten for (clickhouse_cluster.go)
+	topology clickHouseTopology
+
+	// Failed batch inserts per minute, for gateway_clickhouse_insert_failures (self_monitoring.go)
+	insertFailures failureCount
 }
 
 type logBatchItem struct {
@@ -39,6 +59,11 @@ type logBatchItem struct {
 	longitude   float64
 	timezone    string
 	isp         string
+	asn         uint32
+	ua          *ParsedUA
+	botCategory string
+	threat      threatintel.Match
+	endpoint    string
 }
 
 type spanBatchItem struct {
@@ -74,6 +99,9 @@ var (
 	sysBufferSize   = getEnvInt("CH_SYS_BUFFER_SIZE", 10000)
 	nginxBufferSize = getEnvInt("CH_NGINX_BUFFER_SIZE", 10000)
 	gwBufferSize    = getEnvInt("CH_GW_BUFFER_SIZE", 1000)
+	secBufferSize   = getEnvInt("CH_SECURITY_BUFFER_SIZE", 10000)
+	synBufferSize   = getEnvInt("CH_SYNTHETIC_BUFFER_SIZE", 10000)
+	genBufferSize   = getEnvInt("CH_GENERIC_BUFFER_SIZE", 50000)
 
 	// Batch flush sizes
 	logBatchSize  = getEnvInt("CH_LOG_BATCH_SIZE", 10000)
@@ -108,54 +136,66 @@ func formatBytes[T int64 | uint64](b T) string {
 	return fmt.Sprintf("%.1f %cB", val/div, "KMGTPE"[exp])
 }
 
-func NewClickHouseDB(addr, username, password string) (*ClickHouseDB, error) {
+// NewClickHouseDB connects to the nodes of cfg, creates or updates the nginx_analytics schema on
+// its topology and starts the batch flushers
+func NewClickHouseDB(cfg config.ClickHouseConfig) (*ClickHouseDB, error) {
 	// Log configuration for debugging
-	log.Printf("ClickHouse config: buffers(log=%d, span=%d, sys=%d, nginx=%d, gw=%d) batches(log=%d, span=%d) conns(open=%d, idle=%d)",
+	analyticsLog.Info().Msgf("ClickHouse config: buffers(log=%d, span=%d, sys=%d, nginx=%d, gw=%d) batches(log=%d, span=%d) conns(open=%d, idle=%d) reads(concurrent=%d, queued=%d)",
 		logBufferSize, spanBufferSize, sysBufferSize, nginxBufferSize, gwBufferSize,
-		logBatchSize, spanBatchSize, maxOpenConns, maxIdleConns)
+		logBatchSize, spanBatchSize, maxOpenConns, maxIdleConns, maxConcurrentQueries, maxQueuedQueries)
 
-	// Debug: log connection parameters (password redacted)
-	log.Printf("ClickHouse connecting to: %s user=%s password=***REDACTED***", addr, username)
-
-	// Use defaults if not provided
-	if username == "" {
-		username = "default"
+	topology := clickHouseTopology{
+		Cluster:         cfg.Cluster,
+		Replicated:      cfg.Replicated,
+		ReplicationPath: cfg.ReplicationPath,
+		Distributed:     cfg.Distributed,
+	}
+	if err := topology.validate(); err != nil {
+		return nil, err
 	}
 
-	conn, err := clickhouse.Open(&clickhouse.Options{
-		Addr: []string{addr},
-		Auth: clickhouse.Auth{
-			Database: "default",
-			Username: username,
-			Password: password,
-		},
-		Settings: clickhouse.Settings{
-			"max_execution_time": 60,
-		},
-		Compression: &clickhouse.Compression{
-			Method: clickhouse.CompressionLZ4,
-		},
-		DialTimeout:     10 * time.Second,
-		MaxOpenConns:    maxOpenConns,
-		MaxIdleConns:    maxIdleConns,
-		ConnMaxLifetime: time.Hour,
-	})
+	// Debug: log connection parameters (password redacted)
+	analyticsLog.Info().Msgf("ClickHouse connecting to: %s user=%s password=***REDACTED***", cfg.Address, cfg.Username)
+	if topology.Cluster != "" {
+		analyticsLog.Info().Msgf("ClickHouse cluster %s: replicated=%v distributed=%v", topology.Cluster, topology.Replicated, topology.Distributed)
+	}
 
+	conn, err := openClickHouse(clickHouseAddrs(cfg.Address), cfg.Username, cfg.Password)
 	if err != nil {
 		return nil, err
 	}
+	var pool driver.Conn = conn
+	if reads := clickHouseAddrs(cfg.ReadAddress); len(reads) > 0 {
+		analyticsLog.Info().Msgf("ClickHouse reads go to: %s", strings.Join(reads, ","))
+		readConn, err := openClickHouse(reads, cfg.Username, cfg.Password)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		pool = &splitConn{Conn: conn, reads: readConn}
+	}
 
 	db := &ClickHouseDB{
-		conn:      conn,
+		conn:      newGatedConn(pool, maxConcurrentQueries, maxQueuedQueries, queryQueueTimeout),
+		topology:  topology,
 		logChan:   make(chan logBatchItem, logBufferSize),
 		spanChan:  make(chan spanBatchItem, spanBufferSize),
 		sysChan:   make(chan sysBatchItem, sysBufferSize),
 		nginxChan: make(chan nginxBatchItem, nginxBufferSize),
 		gwChan:    make(chan gwBatchItem, gwBufferSize),
+		secChan:   make(chan SecurityEvent, secBufferSize),
+		synChan:   make(chan SyntheticResult, synBufferSize),
+		genChan:   make(chan genericMetricItem, genBufferSize),
 		geoLookup: geo.NewGeoIPLookup(),
+
+		botNetworks: geo.NewBotNetworks(),
+		threats:     threatintel.NewStore(),
+	}
+	if db.uaParser, err = NewUAParser(); err != nil {
+		return nil, err
 	}
 
-	log.Printf("GeoIP lookup initialized with well-known IP database")
+	analyticsLog.Info().Msg("GeoIP lookup initialized with well-known IP database")
 
 	if err := db.migrate(); err != nil {
 		// Migration failure (e.g. auth 516) means the connection is not usable;
@@ -169,10 +209,51 @@ func NewClickHouseDB(addr, username, password string) (*ClickHouseDB, error) {
 	go db.runSysFlusher()
 	go db.runNginxFlusher()
 	go db.runGwFlusher()
+	go db.runSecurityFlusher()
+	go db.runSyntheticFlusher()
+	go db.runGenericFlusher()
 
 	return db, nil
 }
 
+// clickHouseAddrs splits a comma-separated list of host:port
+func clickHouseAddrs(list string) []string {
+	var addrs []string
+	for _, a := range strings.Split(list, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// openClickHouse opens a connection pool over addrs, spread round-robin when there are several
+func openClickHouse(addrs []string, username, password string) (driver.Conn, error) {
+	// Use defaults if not provided
+	if username == "" {
+		username = "default"
+	}
+	return clickhouse.Open(&clickhouse.Options{
+		Addr: addrs,
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: username,
+			Password: password,
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		Compression: &clickhouse.Compression{
+			Method: clickhouse.CompressionLZ4,
+		},
+		ConnOpenStrategy: clickhouse.ConnOpenRoundRobin,
+		DialTimeout:      10 * time.Second,
+		MaxOpenConns:     maxOpenConns,
+		MaxIdleConns:     maxIdleConns,
+		ConnMaxLifetime:  time.Hour,
+	})
+}
+
 func (db *ClickHouseDB) GetVersion(ctx context.Context) string {
 	var version string
 	err := db.conn.QueryRow(ctx, "SELECT version()").Scan(&version)
@@ -184,204 +265,17 @@ func (db *ClickHouseDB) GetVersion(ctx context.Context) string {
 
 func (db *ClickHouseDB) migrate() error {
 	ctx := context.Background()
-	queries := []string{
-		"CREATE DATABASE IF NOT EXISTS nginx_analytics",
-
-		// ── Core tables (with partitioning and optimized ORDER BY) ────────────
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.gateway_metrics (
-			timestamp DateTime64(3),
-			gateway_id LowCardinality(String),
-			eps Float32,
-			active_connections UInt32,
-			cpu_usage Float32,
-			memory_mb Float32,
-			goroutines UInt32,
-			db_latency_ms Float32,
-			labels Map(String, String)
-		) ENGINE = MergeTree()
-		PARTITION BY toYYYYMM(toDateTime(timestamp))
-		ORDER BY (gateway_id, timestamp)
-		SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1`,
-
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs (
-			timestamp DateTime64(3),
-			instance_id LowCardinality(String),
-			remote_addr String,
-			request_method LowCardinality(String),
-			request_uri String,
-			status UInt16,
-			body_bytes_sent UInt64,
-			request_time Float32,
-			request_id String,
-			upstream_addr String,
-			upstream_status LowCardinality(String),
-			upstream_connect_time Float32,
-			upstream_header_time Float32,
-			upstream_response_time Float32,
-			user_agent String,
-			referer String,
-			labels Map(String, String),
-			INDEX idx_status (status) TYPE minmax GRANULARITY 4,
-			INDEX idx_uri (request_uri) TYPE bloom_filter(0.01) GRANULARITY 4,
-			INDEX idx_client_ip (client_ip) TYPE bloom_filter(0.01) GRANULARITY 4
-		) ENGINE = MergeTree()
-		PARTITION BY toYYYYMM(toDateTime(timestamp))
-		ORDER BY (instance_id, timestamp)
-		SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1`,
-
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.system_metrics (
-			timestamp DateTime64(3),
-			instance_id LowCardinality(String),
-			cpu_usage Float32,
-			memory_usage Float32,
-			memory_total UInt64,
-			memory_used UInt64,
-			network_rx_bytes UInt64,
-			network_tx_bytes UInt64,
-			network_rx_rate Float32,
-			network_tx_rate Float32,
-			cpu_user Float32,
-			cpu_system Float32,
-			cpu_iowait Float32,
-			labels Map(String, String)
-		) ENGINE = MergeTree()
-		PARTITION BY toYYYYMM(toDateTime(timestamp))
-		ORDER BY (instance_id, timestamp)
-		SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1`,
-
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.nginx_metrics (
-			timestamp DateTime64(3),
-			instance_id LowCardinality(String),
-			active_connections UInt32,
-			accepted_connections UInt64,
-			handled_connections UInt64,
-			total_requests UInt64,
-			reading UInt32,
-			writing UInt32,
-			waiting UInt32,
-			requests_per_second Float64,
-			status_2xx UInt64 DEFAULT 0,
-			status_3xx UInt64 DEFAULT 0,
-			status_4xx UInt64 DEFAULT 0,
-			status_5xx UInt64 DEFAULT 0,
-			bytes_in UInt64 DEFAULT 0,
-			bytes_out UInt64 DEFAULT 0,
-			labels Map(String, String)
-		) ENGINE = MergeTree()
-		PARTITION BY toYYYYMM(toDateTime(timestamp))
-		ORDER BY (instance_id, timestamp)
-		SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1`,
-
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.spans (
-			trace_id String,
-			span_id String,
-			parent_span_id String,
-			name String,
-			start_time DateTime64(9),
-			end_time DateTime64(9),
-			attributes Map(String, String),
-			instance_id LowCardinality(String)
-		) ENGINE = MergeTree()
-		PARTITION BY toYYYYMM(toDateTime(start_time))
-		ORDER BY (instance_id, trace_id, start_time)
-		SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1`,
-
-		// ── Column migrations (backward compat for existing tables) ──────────
-		"ALTER TABLE nginx_analytics.gateway_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String)",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS labels Map(String, String)",
-		"ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String)",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String)",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_2xx UInt64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_3xx UInt64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_4xx UInt64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_5xx UInt64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS bytes_in UInt64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS bytes_out UInt64 DEFAULT 0",
-		// Geo columns
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS client_ip String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS country String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS country_code String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS city String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS region String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS latitude Float64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS longitude Float64 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS timezone String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS isp String DEFAULT ''",
-		// Visitor analytics columns
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS is_bot UInt8 DEFAULT 0",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS browser_family String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS browser_version String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS os_family String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS os_version String DEFAULT ''",
-		"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS device_type String DEFAULT ''",
-
-		// ── Pre-aggregation: 5-minute traffic rollup for dashboard ────────────
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.traffic_5min (
-			ts DateTime,
-			instance_id LowCardinality(String),
-			requests UInt64,
-			errors UInt64,
-			s2xx UInt64,
-			s3xx UInt64,
-			s4xx UInt64,
-			s5xx UInt64,
-			total_bytes UInt64,
-			sum_latency Float64,
-			latency_count UInt64
-		) ENGINE = SummingMergeTree()
-		PARTITION BY toYYYYMM(ts)
-		ORDER BY (instance_id, ts)
-		TTL ts + INTERVAL 30 DAY`,
-
-		`CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.traffic_5min_mv
-		TO nginx_analytics.traffic_5min AS
-		SELECT
-			toStartOfFiveMinutes(toDateTime(timestamp)) AS ts,
-			instance_id,
-			count() AS requests,
-			countIf(status >= 400) AS errors,
-			countIf(status >= 200 AND status < 300) AS s2xx,
-			countIf(status >= 300 AND status < 400) AS s3xx,
-			countIf(status >= 400 AND status < 500) AS s4xx,
-			countIf(status >= 500) AS s5xx,
-			sum(body_bytes_sent) AS total_bytes,
-			sum(request_time) AS sum_latency,
-			count() AS latency_count
-		FROM nginx_analytics.access_logs
-		GROUP BY ts, instance_id`,
-
-		// ── Geo aggregation (hourly) ─────────────────────────────────────────
-		`CREATE TABLE IF NOT EXISTS nginx_analytics.geo_requests_hourly (
-			hour DateTime,
-			country LowCardinality(String),
-			country_code LowCardinality(String),
-			city LowCardinality(String),
-			latitude Float64,
-			longitude Float64,
-			request_count UInt64,
-			error_count UInt64,
-			total_bytes UInt64,
-			avg_latency Float64
-		) ENGINE = SummingMergeTree()
-		PARTITION BY toYYYYMM(hour)
-		ORDER BY (hour, country_code, city)
-		TTL hour + INTERVAL 90 DAY`,
-
-		// ── TTL policies ─────────────────────────────────────────────────────
-		"ALTER TABLE nginx_analytics.access_logs MODIFY TTL toDateTime(timestamp) + INTERVAL 7 DAY",
-		"ALTER TABLE nginx_analytics.spans MODIFY TTL toDateTime(start_time) + INTERVAL 7 DAY",
-		"ALTER TABLE nginx_analytics.system_metrics MODIFY TTL toDateTime(timestamp) + INTERVAL 30 DAY",
-		"ALTER TABLE nginx_analytics.nginx_metrics MODIFY TTL toDateTime(timestamp) + INTERVAL 30 DAY",
-		"ALTER TABLE nginx_analytics.gateway_metrics MODIFY TTL toDateTime(timestamp) + INTERVAL 30 DAY",
-	}
-
-	for _, q := range queries {
-		if err := db.conn.Exec(ctx, q); err != nil {
-			// ClickHouse might return error if column exists even with IF NOT EXISTS in some versions,
-			// though recent ones handle it well. We log and continue.
-			log.Printf("ClickHouse migration query failed [%s]: %v", q, err)
-		}
+	db.checkTopology(ctx)
+	// Versioned migrations (clickhouse_migrations.go), then the schema derived from Go tables. A
+	// connection that cannot record migrations is not usable.
+	if err := db.ensureMigrationsTable(ctx); err != nil {
+		return err
+	}
+	if err := db.runMigrations(ctx); err != nil {
+		analyticsLog.Error().Msgf("ClickHouse migrations stopped: %v", err)
 	}
+	db.migrateTenantColumns(ctx)
+	db.migrateRollups(ctx)
 	return nil
 }
 
@@ -407,9 +301,25 @@ func (db *ClickHouseDB) InsertAccessLog(entry *pb.LogEntry, agentID string) erro
 			item.longitude = loc.Longitude
 			item.timezone = loc.Timezone
 			item.isp = loc.ISP
+			item.asn = loc.ASN
 		}
 	}
 
+	if db.uaParser != nil {
+		item.ua = db.uaParser.Parse(entry.UserAgent)
+		item.botCategory = item.ua.BotCategory
+	}
+	// A request from a published crawler range is a bot whatever its user-agent claims
+	if db.botNetworks != nil && clientIP != "" {
+		if m, ok := db.botNetworks.Match(clientIP); ok {
+			item.botCategory = m.Category
+		}
+	}
+	if db.threats != nil && clientIP != "" {
+		item.threat, _ = db.threats.Lookup(clientIP)
+	}
+	item.endpoint = db.endpoints.Normalize(entry.RequestUri)
+
 	select {
 	case db.logChan <- item:
 		return nil
@@ -418,13 +328,29 @@ func (db *ClickHouseDB) InsertAccessLog(entry *pb.LogEntry, agentID string) erro
 	}
 }
 
-func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestTime time.Time) error {
+// queueSpan queues one span for batched insertion, reporting false when the queue is full
+func (db *ClickHouseDB) queueSpan(item spanBatchItem) bool {
+	select {
+	case db.spanChan <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// InsertSpans queues the spans of an access log: the request, and its upstream and upstream
+// connect phases. A request with an upstream trace context joins that trace as a child of the
+// caller's span; others get a trace of their own, keyed by the NGINX request ID when logged.
+func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestTime time.Time, upstream upstreamTrace) error {
 	// Root Span (Request)
-	traceID := entry.RequestId
+	traceID := upstream.traceID
+	if traceID == "" {
+		traceID = entry.RequestId
+	}
 	if traceID == "" {
 		traceID = uuid.New().String()
 	}
-	rootSpanID := uuid.New().String()
+	rootSpanID := newSpanID()
 
 	// Calculate times
 	duration := time.Duration(float64(entry.RequestTime) * float64(time.Second))
@@ -439,13 +365,19 @@ func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestT
 		"agent_id": agentID,
 		"client":   entry.RemoteAddr,
 	}
+	if entry.RequestId != "" {
+		rootAttrs["request_id"] = entry.RequestId
+	}
+	if upstream.source != "" {
+		rootAttrs["trace.source"] = upstream.source
+	}
 
 	// Push Root Span
 	select {
 	case db.spanChan <- spanBatchItem{
 		traceID: traceID,
 		spanID:  rootSpanID,
-		parent:  "",
+		parent:  upstream.parentSpanID,
 		name:    "request",
 		start:   startTime,
 		end:     endTime,
@@ -458,7 +390,7 @@ func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestT
 
 	// Upstream Span
 	if entry.UpstreamAddr != "" && entry.UpstreamResponseTime > 0 {
-		upstreamSpanID := uuid.New().String()
+		upstreamSpanID := newSpanID()
 		upstreamDuration := time.Duration(float64(entry.UpstreamResponseTime) * float64(time.Second))
 		upstreamEnd := endTime
 		upstreamStart := upstreamEnd.Add(-upstreamDuration)
@@ -484,7 +416,7 @@ func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestT
 
 		// Connect Span (Child of Upstream)
 		if entry.UpstreamConnectTime > 0 {
-			connectSpanID := uuid.New().String()
+			connectSpanID := newSpanID()
 			connectDuration := time.Duration(float64(entry.UpstreamConnectTime) * float64(time.Second))
 			connectStart := upstreamStart
 			connectEnd := connectStart.Add(connectDuration)
@@ -509,75 +441,84 @@ func (db *ClickHouseDB) InsertSpans(entry *pb.LogEntry, agentID string, requestT
 }
 
 func (db *ClickHouseDB) GetAnalytics(ctx context.Context, window string, agentID string) (*pb.AnalyticsResponse, error) {
-	return db.GetAnalyticsWithAgentFilter(ctx, &pb.AnalyticsRequest{TimeWindow: window, AgentId: agentID}, nil)
+	return db.GetAnalyticsWithFilter(ctx, &pb.AnalyticsRequest{TimeWindow: window, AgentId: agentID}, tenantFilter{})
 }
 
-// GetAnalyticsFiltered returns analytics filtered by a list of agent IDs
+// GetAnalyticsFiltered returns analytics of the rows of a tenant filter
 // This is used for project/environment filtering where multiple agents belong to the same scope
-func (db *ClickHouseDB) GetAnalyticsFiltered(ctx context.Context, window string, agentFilter []string) (*pb.AnalyticsResponse, error) {
-	return db.GetAnalyticsWithAgentFilter(ctx, &pb.AnalyticsRequest{TimeWindow: window}, agentFilter)
+func (db *ClickHouseDB) GetAnalyticsFiltered(ctx context.Context, window string, filter tenantFilter) (*pb.AnalyticsResponse, error) {
+	return db.GetAnalyticsWithFilter(ctx, &pb.AnalyticsRequest{TimeWindow: window}, filter)
 }
 
 // GetAnalyticsWithTimeRange supports both relative time windows and absolute time ranges (backward compatible wrapper)
 func (db *ClickHouseDB) GetAnalyticsWithTimeRange(ctx context.Context, window string, agentID string, fromTs, toTs int64, clientTimezone string) (*pb.AnalyticsResponse, error) {
-	return db.GetAnalyticsWithAgentFilter(ctx, &pb.AnalyticsRequest{
+	return db.GetAnalyticsWithFilter(ctx, &pb.AnalyticsRequest{
 		TimeWindow:    window,
 		AgentId:       agentID,
 		FromTimestamp: fromTs,
 		ToTimestamp:   toTs,
 		Timezone:      clientTimezone,
-	}, nil)
+	}, tenantFilter{})
+}
+
+// analyticsTimeRange is the range of an analytics request: its absolute timestamps (milliseconds)
+// when both are set, else its time window up to now (24h by default)
+func analyticsTimeRange(req *pb.AnalyticsRequest, now time.Time) (start, end time.Time) {
+	if req.FromTimestamp > 0 && req.ToTimestamp > 0 {
+		return time.UnixMilli(req.FromTimestamp).UTC(), time.UnixMilli(req.ToTimestamp).UTC()
+	}
+	duration := 24 * time.Hour
+	switch req.TimeWindow {
+	case "5m":
+		duration = 5 * time.Minute
+	case "15m":
+		duration = 15 * time.Minute
+	case "30m":
+		duration = 30 * time.Minute
+	case "1h":
+		duration = 1 * time.Hour
+	case "3h":
+		duration = 3 * time.Hour
+	case "6h":
+		duration = 6 * time.Hour
+	case "12h":
+		duration = 12 * time.Hour
+	case "24h":
+		duration = 24 * time.Hour
+	case "2d":
+		duration = 2 * 24 * time.Hour
+	case "3d":
+		duration = 3 * 24 * time.Hour
+	case "7d":
+		duration = 7 * 24 * time.Hour
+	case "30d":
+		duration = 30 * 24 * time.Hour
+	}
+	end = now.UTC()
+	return end.Add(-duration), end
+}
+
+// GetAnalyticsWithFilter supports filtering by single agent ID or a tenant filter (agents, project or environment)
+// rollupsMatchURL reports whether the per-path rollups can answer a URL filter. They hold
+// endpoints, so a filter with a query string, or a URI stored under a template (/users/123 of
+// /users/{id}), needs access_logs.
+func (db *ClickHouseDB) rollupsMatchURL(filter string) bool {
+	return !strings.Contains(filter, "?") && db.endpoints.Normalize(filter) == filter
 }
 
-// GetAnalyticsWithAgentFilter supports filtering by single agent ID or multiple agent IDs (for project/environment filtering)
-func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb.AnalyticsRequest, agentFilter []string) (*pb.AnalyticsResponse, error) {
-	window := req.TimeWindow
+func (db *ClickHouseDB) GetAnalyticsWithFilter(ctx context.Context, req *pb.AnalyticsRequest, filter tenantFilter) (*pb.AnalyticsResponse, error) {
 	agentID := req.AgentId
 	fromTs := req.FromTimestamp
 	toTs := req.ToTimestamp
-	// clientTimezone := req.Timezone // Not used currently in the body but available
-
-	var startTime, endTime time.Time
-	var duration time.Duration
+	// Time series are bucketed and labelled in the client's timezone
+	tz := analyticsTimezone(req.Timezone)
+	loc, _ := time.LoadLocation(tz)
 
-	// Determine time range - absolute takes precedence
+	now := time.Now()
+	startTime, endTime := analyticsTimeRange(req, now)
+	duration := endTime.Sub(startTime)
 	if fromTs > 0 && toTs > 0 {
-		// Absolute time range (timestamps in milliseconds)
-		startTime = time.UnixMilli(fromTs).UTC()
-		endTime = time.UnixMilli(toTs).UTC()
-		duration = endTime.Sub(startTime)
-		log.Printf("GetAnalytics: Using absolute time range: %v to %v (duration: %v)", startTime, endTime, duration)
-	} else {
-		// Relative time window
-		duration = 24 * time.Hour
-		switch window {
-		case "5m":
-			duration = 5 * time.Minute
-		case "15m":
-			duration = 15 * time.Minute
-		case "30m":
-			duration = 30 * time.Minute
-		case "1h":
-			duration = 1 * time.Hour
-		case "3h":
-			duration = 3 * time.Hour
-		case "6h":
-			duration = 6 * time.Hour
-		case "12h":
-			duration = 12 * time.Hour
-		case "24h":
-			duration = 24 * time.Hour
-		case "2d":
-			duration = 2 * 24 * time.Hour
-		case "3d":
-			duration = 3 * 24 * time.Hour
-		case "7d":
-			duration = 7 * 24 * time.Hour
-		case "30d":
-			duration = 30 * 24 * time.Hour
-		}
-		endTime = time.Now().UTC()
-		startTime = endTime.Add(-duration)
+		analyticsLog.Info().Msgf("GetAnalytics: Using absolute time range: %v to %v (duration: %v)", startTime, endTime, duration)
 	}
 
 	resp := &pb.AnalyticsResponse{}
@@ -597,7 +538,7 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 		timeFormat = "%H:%i"
 	} else if duration <= 12*time.Hour {
 		bucketSize = "toStartOfHour"
-		if startTime.Day() != endTime.Day() {
+		if startTime.In(loc).Day() != endTime.In(loc).Day() {
 			timeFormat = "%m-%d %H:%i"
 		} else {
 			timeFormat = "%H:%i"
@@ -624,22 +565,18 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 		args = []interface{}{startTime}
 	}
 
-	// Agent filtering
-	if len(agentFilter) > 0 {
-		placeholders := make([]string, len(agentFilter))
-		for i, id := range agentFilter {
-			placeholders[i] = "?"
-			args = append(args, id)
-		}
-		whereClause += fmt.Sprintf(" AND instance_id IN (%s)", strings.Join(placeholders, ","))
-	} else if req.AgentId != "" && req.AgentId != "all" {
-		whereClause += " AND instance_id = ?"
-		args = append(args, req.AgentId)
+	// Agent, project or environment filtering
+	agentClause, agentArgs := filter.and()
+	if agentClause == "" && req.AgentId != "" && req.AgentId != "all" {
+		agentClause = " AND instance_id = ?"
+		agentArgs = []interface{}{req.AgentId}
 	}
+	whereClause += agentClause
+	args = append(args, agentArgs...)
 
-	// NEW: URL Filtering
+	// NEW: URL Filtering, by URI or endpoint
 	if req.UrlFilter != "" {
-		whereClause += " AND request_uri = ?"
+		whereClause += " AND has([request_uri, endpoint], ?)"
 		args = append(args, req.UrlFilter)
 	}
 
@@ -659,60 +596,134 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 		}
 	}
 
+	// Windows over an hour without a status filter read the rollups (clickhouse_rollups.go) instead
+	// of scanning access_logs: at the resolution requestRollupFor picks for the window, which is
+	// widened to its start, or with a URL filter the per-path minutes while they are kept. Their
+	// filters take the same arguments as whereClause.
+	useRollup := duration > analyticsRollupMinDuration && req.StatusCodeFilter == "" && db.rollupsMatchURL(req.UrlFilter)
+	rollup := requestRollupFor(startTime, endTime, now)
+	uriTable := uriRollupTable(startTime, now)
+	if req.UrlFilter != "" {
+		rollup = requestRollup{table: "requests_uri_1m", floor: "toStartOfMinute"}
+		useRollup = useRollup && uriTable == "requests_uri_1m"
+	}
+	rollupTable := rollup.table
+	var rollupWhere, uriWhere string
+	if fromTs > 0 && toTs > 0 {
+		rollupWhere = "WHERE ts >= " + rollup.floor + "(?) AND ts <= ?" + agentClause
+		uriWhere = "WHERE hour >= toStartOfHour(?) AND hour <= ?" + agentClause
+	} else {
+		rollupWhere = "WHERE ts >= " + rollup.floor + "(?)" + agentClause
+		uriWhere = "WHERE hour >= toStartOfHour(?)" + agentClause
+	}
+	if req.UrlFilter != "" {
+		rollupWhere += " AND request_uri = ?"
+		uriWhere += " AND request_uri = ?"
+	}
+	// Per-path queries read the minutes while they are kept, and the hours (the window widened to
+	// the start of its first hour) beyond
+	if uriTable == "requests_uri_1m" {
+		uriWhere = rollupWhere
+	}
+
+	// Independent sections run concurrently (runAnalyticsSections); each fills its own fields of resp
+	var sections []analyticsSection
+
 	// 1. Request Rate
 	queryTimeSeries := fmt.Sprintf(`
 		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
+			%s,
 			count(*) as requests,
 			countIf(status >= 400) as errors
 		FROM nginx_analytics.access_logs
 		%s
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat, whereClause)
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+	if useRollup {
+		queryTimeSeries = fmt.Sprintf(`
+		SELECT
+			%s,
+			sum(requests) as requests,
+			sum(errors) as errors
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "ts"), rollupTable, rollupWhere)
+	}
 
-	rows, err := db.conn.Query(ctx, queryTimeSeries, args...)
-	if err != nil {
-		log.Printf("GetAnalytics: Request Rate query failed: %v", err)
-	} else {
+	sections = append(sections, analyticsSection{"request_rate", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryTimeSeries, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var timeStr string
+			var bucket time.Time
+			var timeStr, timeISO string
 			var reqs, errs uint64
-			if err := rows.Scan(&timeStr, &reqs, &errs); err == nil {
+			if err := rows.Scan(&bucket, &timeStr, &timeISO, &reqs, &errs); err == nil {
 				resp.RequestRate = append(resp.RequestRate, &pb.TimeSeriesPoint{
 					Time:     timeStr,
+					TimeIso:  timeISO,
 					Requests: int64(reqs),
 					Errors:   int64(errs),
 				})
 			} else {
-				log.Printf("GetAnalytics: Request Rate scan failed: %v", err)
+				analyticsLog.Error().Msgf("GetAnalytics: Request Rate scan failed: %v", err)
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 2. Status Distribution — computed in the combined summary query below (section 5)
 
 	// 3. Top Endpoints with traffic calculation
-	rows, err = db.conn.Query(ctx, fmt.Sprintf(`
+	queryTopEndpoints := fmt.Sprintf(`
 		SELECT
-			request_uri,
+			%s as path,
 			count(*) as requests,
 			countIf(status >= 400) as errors,
+			quantile(0.50)(request_time) as p50,
 			quantile(0.95)(request_time) as p95,
 			sum(body_bytes_sent) as bytes
 		FROM nginx_analytics.access_logs
 		%s
+		GROUP BY path
+		ORDER BY requests DESC
+		LIMIT 10
+	`, endpointSQL, whereClause)
+	if useRollup {
+		queryTopEndpoints = fmt.Sprintf(`
+		SELECT
+			request_uri,
+			sum(requests) as requests,
+			sum(errors) as errors,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[1]) as p50,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[2]) as p95,
+			sum(bytes) as bytes
+		FROM nginx_analytics.%s
+		%s
 		GROUP BY request_uri
 		ORDER BY requests DESC
 		LIMIT 10
-	`, whereClause), args...)
-	if err == nil {
+	`, uriTable, uriWhere)
+	}
+	sections = append(sections, analyticsSection{"top_endpoints", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryTopEndpoints, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
 			var uri string
 			var reqs, errs, bytes uint64
-			var p95 float64
-			if err := rows.Scan(&uri, &reqs, &errs, &p95, &bytes); err == nil {
+			var p50, p95 float64
+			if err := rows.Scan(&uri, &reqs, &errs, &p50, &p95, &bytes); err == nil {
+				if math.IsNaN(p50) {
+					p50 = 0
+				}
 				if math.IsNaN(p95) {
 					p95 = 0
 				}
@@ -722,33 +733,52 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 					Uri:      uri,
 					Requests: int64(reqs),
 					Errors:   int64(errs),
+					P50:      float32(p50 * 1000),
 					P95:      float32(p95 * 1000),
 					Traffic:  traffic,
 				})
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 4. Latency Trend with dynamic time format
 	queryLatency := fmt.Sprintf(`
 		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
+			%s,
 			quantile(0.50)(request_time) as p50,
 			quantile(0.95)(request_time) as p95,
 			quantile(0.99)(request_time) as p99
 		FROM nginx_analytics.access_logs
 		%s
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat, whereClause)
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+	if useRollup {
+		queryLatency = fmt.Sprintf(`
+		SELECT
+			%s,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[1]) as p50,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[2]) as p95,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[3]) as p99
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "ts"), rollupTable, rollupWhere)
+	}
 
-	rows, err = db.conn.Query(ctx, queryLatency, args...)
-	if err == nil {
+	sections = append(sections, analyticsSection{"latency_trend", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryLatency, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var timeStr string
+			var bucket time.Time
+			var timeStr, timeISO string
 			var p50, p95, p99 float64
-			if err := rows.Scan(&timeStr, &p50, &p95, &p99); err == nil {
+			if err := rows.Scan(&bucket, &timeStr, &timeISO, &p50, &p95, &p99); err == nil {
 				if math.IsNaN(p50) {
 					p50 = 0
 				}
@@ -759,22 +789,23 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 					p99 = 0
 				}
 				resp.LatencyTrend = append(resp.LatencyTrend, &pb.LatencyPercentiles{
-					Time: timeStr,
-					P50:  float32(p50 * 1000),
-					P95:  float32(p95 * 1000),
-					P99:  float32(p99 * 1000),
+					Time:    timeStr,
+					TimeIso: timeISO,
+					P50:     float32(p50 * 1000),
+					P95:     float32(p95 * 1000),
+					P99:     float32(p99 * 1000),
 				})
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 5. Summary KPIs & Deltas
 	// Filter out invalid status codes (0) for accurate metrics
 	prevStartTime := startTime.Add(-duration)
 	var currReqs, prevReqs uint64
 	var currErrors, prevErrors uint64
-	var currBytes, prevBytes uint64
+	var currBytes, prevBytes, currBytesIn uint64
 	var currLat, prevLat float64
 
 	// Add status > 0 filter to exclude invalid log entries
@@ -788,7 +819,7 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 	// Combined summary: KPIs + status distribution + latency distribution in one scan
 	var currS2xx, currS3xx, currS4xx, currS5xx uint64
 	var ltBucket0, ltBucket1, ltBucket2, ltBucket3, ltBucket4 int64
-	err = db.conn.QueryRow(ctx, fmt.Sprintf(`
+	querySummary := fmt.Sprintf(`
 		SELECT
 			count(*),
 			countIf(status >= 400),
@@ -802,17 +833,41 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 			countIf(request_time >= 0.05 AND request_time < 0.1),
 			countIf(request_time >= 0.1 AND request_time < 0.2),
 			countIf(request_time >= 0.2 AND request_time < 0.5),
-			countIf(request_time >= 0.5)
-		FROM nginx_analytics.access_logs %s`, currStatsWhereClause), args...).Scan(
-		&currReqs, &currErrors, &currBytes, &currLat,
-		&currS2xx, &currS3xx, &currS4xx, &currS5xx,
-		&ltBucket0, &ltBucket1, &ltBucket2, &ltBucket3, &ltBucket4)
-	if err != nil {
-		return nil, err
-	}
+			countIf(request_time >= 0.5),
+			sum(request_length)
+		FROM nginx_analytics.access_logs %s`, currStatsWhereClause)
+	if useRollup {
+		// The rollups only hold rows with a status, like currStatsWhereClause
+		querySummary = fmt.Sprintf(`
+		SELECT
+			sum(requests),
+			sum(errors),
+			sum(bytes),
+			sum(sum_latency) / sum(requests),
+			sum(s2xx),
+			sum(s3xx),
+			sum(s4xx),
+			sum(s5xx),
+			toInt64(sum(lat_0_50)),
+			toInt64(sum(lat_50_100)),
+			toInt64(sum(lat_100_200)),
+			toInt64(sum(lat_200_500)),
+			toInt64(sum(lat_500_plus)),
+			sum(bytes_in)
+		FROM nginx_analytics.%s %s`, rollupTable, rollupWhere)
+	}
+	var summaryOK bool
+	sections = append(sections, analyticsSection{"summary", func(ctx context.Context) error {
+		err := db.conn.QueryRow(ctx, querySummary, args...).Scan(
+			&currReqs, &currErrors, &currBytes, &currLat,
+			&currS2xx, &currS3xx, &currS4xx, &currS5xx,
+			&ltBucket0, &ltBucket1, &ltBucket2, &ltBucket3, &ltBucket4, &currBytesIn)
+		if err != nil {
+			return err
+		}
+		summaryOK = true
 
-	// Populate status distribution from the combined query (avoids a separate scan)
-	if resp.StatusDistribution == nil {
+		// Populate status distribution from the combined query (avoids a separate scan)
 		for _, sc := range []struct {
 			code  string
 			count uint64
@@ -823,10 +878,8 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 				resp.StatusDistribution = append(resp.StatusDistribution, &pb.StatusCount{Code: sc.code, Count: int64(sc.count)})
 			}
 		}
-	}
 
-	// Populate latency distribution from the combined query (avoids a separate scan)
-	if resp.LatencyDistribution == nil {
+		// Populate latency distribution from the combined query (avoids a separate scan)
 		for _, lb := range []struct {
 			bucket string
 			count  int64
@@ -838,57 +891,43 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 				resp.LatencyDistribution = append(resp.LatencyDistribution, &pb.LatencyBucket{Bucket: lb.bucket, Count: lb.count})
 			}
 		}
-	}
+		return nil
+	}})
 
 	// Deltas need a slightly different filter
 	prevWhereClause := "WHERE timestamp >= ? AND timestamp < ? AND status > 0"
-	prevArgs := []interface{}{prevStartTime, startTime}
-	if agentID != "" && agentID != "all" {
-		prevWhereClause += " AND instance_id = ?"
-		prevArgs = append(prevArgs, agentID)
-	}
+	prevRollup := requestRollupFor(prevStartTime, startTime, now)
+	prevRollupWhere := fmt.Sprintf("WHERE ts >= %s(?) AND ts < %s(?)", prevRollup.floor, prevRollup.floor)
+	prevWhereClause += agentClause
+	prevRollupWhere += agentClause
+	prevArgs := append([]interface{}{prevStartTime, startTime}, agentArgs...)
 
-	err = db.conn.QueryRow(ctx, fmt.Sprintf(`
+	queryPrev := fmt.Sprintf(`
 		SELECT 
 			count(*), 
 			countIf(status >= 400), 
 			sum(body_bytes_sent), 
 			avg(request_time) 
-		FROM nginx_analytics.access_logs %s`, prevWhereClause), prevArgs...).Scan(&prevReqs, &prevErrors, &prevBytes, &prevLat)
-	if err != nil {
-		return nil, err
-	}
-
-	currErrRate := 0.0
-	if currReqs > 0 {
-		currErrRate = (float64(currErrors) / float64(currReqs)) * 100
-	}
-	prevErrRate := 0.0
-	if prevReqs > 0 {
-		prevErrRate = (float64(prevErrors) / float64(prevReqs)) * 100
-	}
-	if math.IsNaN(currLat) {
-		currLat = 0
-	}
-	if math.IsNaN(prevLat) {
-		prevLat = 0
-	}
-
-	resp.Summary = &pb.AnalyticsSummary{
-		TotalRequests:  int64(currReqs),
-		ErrorRate:      float32(currErrRate),
-		AvgLatency:     float32(currLat * 1000),
-		TotalBandwidth: currBytes,
-		RequestsDelta:  float32(currReqs) - float32(prevReqs),
-		LatencyDelta:   float32((currLat - prevLat) * 1000),
-		ErrorRateDelta: float32(currErrRate - prevErrRate),
+		FROM nginx_analytics.access_logs %s`, prevWhereClause)
+	if useRollup {
+		queryPrev = fmt.Sprintf(`
+		SELECT
+			sum(requests),
+			sum(errors),
+			sum(bytes),
+			sum(sum_latency) / sum(requests)
+		FROM nginx_analytics.%s %s`, prevRollup.table, prevRollupWhere)
 	}
+	// Without the previous period the deltas are left at zero
+	sections = append(sections, analyticsSection{"summary_previous", func(ctx context.Context) error {
+		return db.conn.QueryRow(ctx, queryPrev, prevArgs...).Scan(&prevReqs, &prevErrors, &prevBytes, &prevLat)
+	}})
 
 	// 6. Latency Distribution — computed in the combined summary query above (section 5)
 
 	// 7. Server Distribution (Show when viewing all agents or filtering by project/environment)
-	if agentID == "" || agentID == "all" || len(agentFilter) > 0 {
-		rows, err = db.conn.Query(ctx, fmt.Sprintf(`
+	if agentID == "" || agentID == "all" || len(filter.Agents) > 0 {
+		queryServers := fmt.Sprintf(`
 			SELECT
 				instance_id,
 				count(*) as requests,
@@ -898,8 +937,26 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 			%s
 			GROUP BY instance_id
 			ORDER BY requests DESC
-		`, whereClause), args...)
-		if err == nil {
+		`, whereClause)
+		if useRollup {
+			queryServers = fmt.Sprintf(`
+			SELECT
+				instance_id,
+				sum(requests) as requests,
+				sum(errors) as errors,
+				sum(bytes) as traffic
+			FROM nginx_analytics.%s
+			%s
+			GROUP BY instance_id
+			ORDER BY requests DESC
+		`, rollupTable, rollupWhere)
+		}
+		sections = append(sections, analyticsSection{"server_distribution", func(ctx context.Context) error {
+			rows, err := db.conn.Query(ctx, queryServers, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
 			for rows.Next() {
 				var id string
 				var reqs, errs, traffic uint64
@@ -916,14 +973,14 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 					})
 				}
 			}
-			rows.Close()
-		}
+			return rows.Err()
+		}})
 	}
 
 	// 8. System Metrics History with dynamic time format
 	querySys := fmt.Sprintf(`
 		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
+			%s,
 			avg(cpu_usage),
 			avg(memory_usage),
 			avg(network_rx_rate),
@@ -933,20 +990,24 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 			avg(cpu_iowait)
 		FROM nginx_analytics.system_metrics
 		%s
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat, whereClause)
-
-	rows, err = db.conn.Query(ctx, querySys, args...)
-	if err != nil {
-		log.Printf("GetAnalytics: System metrics query failed: %v", err)
-	} else {
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+
+	sections = append(sections, analyticsSection{"system_metrics", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, querySys, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var t string
+			var bucket time.Time
+			var t, timeISO string
 			var cpu, mem, rx, tx, user, system, iowait float64
-			if err := rows.Scan(&t, &cpu, &mem, &rx, &tx, &user, &system, &iowait); err == nil {
+			if err := rows.Scan(&bucket, &t, &timeISO, &cpu, &mem, &rx, &tx, &user, &system, &iowait); err == nil {
 				resp.SystemMetrics = append(resp.SystemMetrics, &pb.SystemMetricPoint{
 					Time:          t,
+					TimeIso:       timeISO,
 					CpuUsage:      float32(cpu),
 					MemoryUsage:   float32(mem),
 					NetworkRxRate: float32(rx),
@@ -956,44 +1017,59 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 					CpuIowait:     float32(iowait),
 				})
 			} else {
-				log.Printf("GetAnalytics: System metrics scan failed: %v", err)
+				analyticsLog.Error().Msgf("GetAnalytics: System metrics scan failed: %v", err)
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
-	// 9. NGINX Connections History with dynamic time format
+	// 9. NGINX Connections History with dynamic time format. Requests per second are the growth of
+	// each nginx instance's request counter across the bucket, so rows stored before the rate was
+	// computed from it are right too; a bucket where the counter went back (restart) counts none.
+	// A bucket with a single sample has no growth to measure, so it takes the stored rate.
 	queryConn := fmt.Sprintf(`
-		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
-			avg(active_connections),
-			avg(waiting),
-			avg(requests_per_second)
-		FROM nginx_analytics.nginx_metrics
-		%s
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat, whereClause)
-
-	rows, err = db.conn.Query(ctx, queryConn, args...)
-	if err != nil {
-		log.Printf("GetAnalytics: Connections history query failed: %v", err)
-	} else {
+		SELECT bucket, time, time_iso, avg(active_avg), avg(waiting_avg), avg(rps)
+		FROM (
+			SELECT
+				%s,
+				avg(active_connections) AS active_avg,
+				avg(waiting) AS waiting_avg,
+				toInt64(argMax(total_requests, timestamp)) - toInt64(argMin(total_requests, timestamp)) AS requests,
+				dateDiff('millisecond', min(timestamp), max(timestamp)) / 1000 AS span_sec,
+				multiIf(count() < 2 OR span_sec = 0, avg(requests_per_second),
+					requests >= 0, requests / span_sec, 0) AS rps
+			FROM nginx_analytics.nginx_metrics
+			%s
+			GROUP BY bucket, instance_id, labels['nginx_instance']
+		)
+		GROUP BY bucket, time, time_iso
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+
+	sections = append(sections, analyticsSection{"connections_history", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryConn, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var t string
+			var bucket time.Time
+			var t, timeISO string
 			var active, waiting, rps float64
-			if err := rows.Scan(&t, &active, &waiting, &rps); err == nil {
+			if err := rows.Scan(&bucket, &t, &timeISO, &active, &waiting, &rps); err == nil {
 				resp.ConnectionsHistory = append(resp.ConnectionsHistory, &pb.NginxMetricPoint{
-					Time:     t,
-					Active:   int64(active),
-					Requests: int64(rps),
+					Timestamp: bucket.Unix(),
+					Time:      t,
+					TimeIso:   timeISO,
+					Active:    int64(active),
+					Requests:  int64(rps),
 				})
 			} else {
-				log.Printf("GetAnalytics: Connections history scan failed: %v", err)
+				analyticsLog.Error().Msgf("GetAnalytics: Connections history scan failed: %v", err)
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 10. HTTP Status Aggregations (Detailed)
 	resp.HttpStatusMetrics = &pb.HttpStatusMetricsResponse{}
@@ -1001,33 +1077,50 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 	// 10a. Time Series for Status Codes with dynamic time format
 	queryStatusTS := fmt.Sprintf(`
 		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
+			%s,
 			countIf(status >= 200 AND status < 300) as code_2xx,
 			countIf(status >= 300 AND status < 400) as code_3xx,
 			countIf(status >= 400 AND status < 500) as code_4xx,
 			countIf(status >= 500) as code_5xx
 		FROM nginx_analytics.access_logs
 		%s
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat, whereClause)
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+	if useRollup {
+		queryStatusTS = fmt.Sprintf(`
+		SELECT
+			%s,
+			sum(s2xx) as code_2xx,
+			sum(s3xx) as code_3xx,
+			sum(s4xx) as code_4xx,
+			sum(s5xx) as code_5xx
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "ts"), rollupTable, rollupWhere)
+	}
 
-	rows, err = db.conn.Query(ctx, queryStatusTS, args...)
-	if err != nil {
-		log.Printf("GetAnalytics: Status time series query failed: %v", err)
-	} else {
+	sections = append(sections, analyticsSection{"status_time_series", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryStatusTS, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var t string
+			var bucket time.Time
+			var t, timeISO string
 			var c2xx, c3xx, c4xx, c5xx uint64
-			if err := rows.Scan(&t, &c2xx, &c3xx, &c4xx, &c5xx); err == nil {
-				resp.HttpStatusMetrics.Status_2Xx_5Min = append(resp.HttpStatusMetrics.Status_2Xx_5Min, &pb.TimeSeriesPoint{Time: t, Requests: int64(c2xx)})
-				resp.HttpStatusMetrics.Status_3Xx = append(resp.HttpStatusMetrics.Status_3Xx, &pb.TimeSeriesPoint{Time: t, Requests: int64(c3xx)})
-				resp.HttpStatusMetrics.Status_4Xx_5Min = append(resp.HttpStatusMetrics.Status_4Xx_5Min, &pb.TimeSeriesPoint{Time: t, Requests: int64(c4xx)})
-				resp.HttpStatusMetrics.Status_5Xx = append(resp.HttpStatusMetrics.Status_5Xx, &pb.TimeSeriesPoint{Time: t, Requests: int64(c5xx)})
+			if err := rows.Scan(&bucket, &t, &timeISO, &c2xx, &c3xx, &c4xx, &c5xx); err == nil {
+				resp.HttpStatusMetrics.Status_2Xx_5Min = append(resp.HttpStatusMetrics.Status_2Xx_5Min, &pb.TimeSeriesPoint{Time: t, TimeIso: timeISO, Requests: int64(c2xx)})
+				resp.HttpStatusMetrics.Status_3Xx = append(resp.HttpStatusMetrics.Status_3Xx, &pb.TimeSeriesPoint{Time: t, TimeIso: timeISO, Requests: int64(c3xx)})
+				resp.HttpStatusMetrics.Status_4Xx_5Min = append(resp.HttpStatusMetrics.Status_4Xx_5Min, &pb.TimeSeriesPoint{Time: t, TimeIso: timeISO, Requests: int64(c4xx)})
+				resp.HttpStatusMetrics.Status_5Xx = append(resp.HttpStatusMetrics.Status_5Xx, &pb.TimeSeriesPoint{Time: t, TimeIso: timeISO, Requests: int64(c5xx)})
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 10b. 24h Totals
 	// We need a separate where clause for 24h fixed window
@@ -1038,73 +1131,26 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 		args24h = append(args24h, agentID)
 	}
 
-	row24h := db.conn.QueryRow(ctx, fmt.Sprintf(`
+	query24h := fmt.Sprintf(`
 		SELECT 
 			countIf(status = 200),
 			countIf(status = 404),
 			countIf(status = 503)
-		FROM nginx_analytics.access_logs %s`, where24h), args24h...)
+		FROM nginx_analytics.access_logs %s`, where24h)
 
-	var t200, t404, t503 uint64
-	if err := row24h.Scan(&t200, &t404, &t503); err != nil {
-		log.Printf("GetAnalytics: 24h totals query failed: %v", err)
-	} else {
+	sections = append(sections, analyticsSection{"status_totals_24h", func(ctx context.Context) error {
+		var t200, t404, t503 uint64
+		if err := db.conn.QueryRow(ctx, query24h, args24h...).Scan(&t200, &t404, &t503); err != nil {
+			return err
+		}
 		resp.HttpStatusMetrics.TotalStatus_200_24H = int64(t200)
 		resp.HttpStatusMetrics.TotalStatus_404_24H = int64(t404)
 		resp.HttpStatusMetrics.TotalStatus_503 = int64(t503)
-	}
-
-	// 11. Generate Actionable Insights (Decision Hub)
-	if resp.Summary != nil {
-		// Latency Insight
-		if resp.Summary.AvgLatency > 200 {
-			resp.Insights = append(resp.Insights, &pb.Insight{
-				Type:    "warning",
-				Title:   "High Latency Detected",
-				Message: fmt.Sprintf("Average latency is %.2fms, which is above the 200ms threshold.", resp.Summary.AvgLatency),
-			})
-		}
-
-		// Error Rate Insight
-		if resp.Summary.ErrorRate > 5.0 {
-			resp.Insights = append(resp.Insights, &pb.Insight{
-				Type:    "critical",
-				Title:   "Spike in Error Rate",
-				Message: fmt.Sprintf("Error rate has climbed to %.2f%%. Check upstream health.", resp.Summary.ErrorRate),
-			})
-		}
-	}
-
-	// System Resource Insights
-	if len(resp.SystemMetrics) > 0 {
-		lastPoint := resp.SystemMetrics[len(resp.SystemMetrics)-1]
-		if lastPoint.CpuUsage > 80 {
-			resp.Insights = append(resp.Insights, &pb.Insight{
-				Type:    "critical",
-				Title:   "CPU Exhaustion",
-				Message: fmt.Sprintf("CPU usage is currently at %.1f%% on selected node(s).", lastPoint.CpuUsage),
-			})
-		}
-		if lastPoint.MemoryUsage > 85 {
-			resp.Insights = append(resp.Insights, &pb.Insight{
-				Type:    "warning",
-				Title:   "High Memory Pressure",
-				Message: fmt.Sprintf("Memory usage is at %.1f%%. Consider scaling up.", lastPoint.MemoryUsage),
-			})
-		}
-	}
-
-	// Info insight if everything is looking good
-	if len(resp.Insights) == 0 {
-		resp.Insights = append(resp.Insights, &pb.Insight{
-			Type:    "info",
-			Title:   "Systems Healthy",
-			Message: "All metrics are within normal operational parameters.",
-		})
-	}
+		return nil
+	}})
 
 	// 12. Recent Requests (for detailed log view)
-	rows, err = db.conn.Query(ctx, fmt.Sprintf(`
+	queryRecent := fmt.Sprintf(`
 		SELECT
 			toUnixTimestamp(timestamp),
 			remote_addr,
@@ -1119,10 +1165,13 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 		%s
 		ORDER BY timestamp DESC
 		LIMIT 50
-	`, whereClause), args...)
-	if err != nil {
-		log.Printf("GetAnalytics: Recent requests query failed: %v", err)
-	} else {
+	`, whereClause)
+	sections = append(sections, analyticsSection{"recent_requests", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryRecent, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
 			var ts uint32
 			var addr, method, uri, upstream, upStatus string
@@ -1144,50 +1193,258 @@ func (db *ClickHouseDB) GetAnalyticsWithAgentFilter(ctx context.Context, req *pb
 					LogType:        "access",
 				})
 			} else {
-				log.Printf("GetAnalytics: Recent requests scan failed: %v", err)
+				analyticsLog.Error().Msgf("GetAnalytics: Recent requests scan failed: %v", err)
 			}
 		}
-		rows.Close()
-	}
+		return rows.Err()
+	}})
 
 	// 13. Gateway Metrics - Always show regardless of agent filter
 	// Gateway metrics are system-wide and not per-agent
 	queryGW := fmt.Sprintf(`
 		SELECT
-			formatDateTime(%s(timestamp), '%s') as time,
+			%s,
 			avg(eps),
 			avg(active_connections),
 			avg(cpu_usage),
 			avg(memory_mb),
 			avg(goroutines),
-			avg(db_latency_ms)
+			avg(db_latency_ms),
+			avg(load1),
+			avg(load5),
+			avg(load15)
 		FROM nginx_analytics.gateway_metrics
 		WHERE timestamp >= ?
-		GROUP BY time
-		ORDER BY time
-	`, bucketSize, timeFormat)
-
-	rows, err = db.conn.Query(ctx, queryGW, startTime)
-	if err == nil {
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"))
+
+	sections = append(sections, analyticsSection{"gateway_metrics", func(ctx context.Context) error {
+		rows, err := db.conn.Query(ctx, queryGW, startTime)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 		for rows.Next() {
-			var t string
-			var eps, cpu, mem, dbLat, conns, goro float64
-			if err := rows.Scan(&t, &eps, &conns, &cpu, &mem, &goro, &dbLat); err == nil {
+			var bucket time.Time
+			var t, timeISO string
+			var eps, cpu, mem, dbLat, conns, goro, load1, load5, load15 float64
+			if err := rows.Scan(&bucket, &t, &timeISO, &eps, &conns, &cpu, &mem, &goro, &dbLat, &load1, &load5, &load15); err == nil {
 				resp.GatewayMetrics = append(resp.GatewayMetrics, &pb.GatewayMetricPoint{
 					Time:              t,
+					TimeIso:           timeISO,
 					Eps:               float32(eps),
 					ActiveConnections: int32(conns),
 					CpuUsage:          float32(cpu),
 					MemoryMb:          float32(mem),
 					Goroutines:        int32(goro),
 					DbLatency:         float32(dbLat),
+					Load1:             float32(load1),
+					Load5:             float32(load5),
+					Load15:            float32(load15),
 				})
 			}
 		}
-		rows.Close()
+		return rows.Err()
+	}})
+
+	// 14. Network origin, automated traffic and proxy cache effectiveness
+	sections = append(sections,
+		analyticsSection{"top_asns", func(ctx context.Context) (err error) {
+			resp.TopAsns, err = db.queryTopASNs(ctx, whereClause, args, 10)
+			return err
+		}},
+		analyticsSection{"bot_traffic", func(ctx context.Context) (err error) {
+			resp.BotTraffic, err = db.queryBotTraffic(ctx, whereClause, args)
+			return err
+		}},
+		analyticsSection{"cache", func(ctx context.Context) (err error) {
+			resp.Cache, err = db.queryCacheStats(ctx, whereClause, args, 10)
+			return err
+		}},
+	)
+
+	// 15. Bandwidth in (request_length) and out (body_bytes_sent), over time and by agent and path
+	queryBandwidth := fmt.Sprintf(`
+		SELECT
+			%s,
+			sum(request_length) as bytes_in,
+			sum(body_bytes_sent) as bytes_out
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "timestamp"), whereClause)
+	queryBandwidthByAgent := fmt.Sprintf(`
+		SELECT
+			instance_id,
+			count() as requests,
+			sum(request_length) as bytes_in,
+			sum(body_bytes_sent) as bytes_out
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY instance_id
+		ORDER BY bytes_out DESC
+		LIMIT %d
+	`, whereClause, bandwidthTopN)
+	queryBandwidthByURI := fmt.Sprintf(`
+		SELECT
+			%s as path,
+			count() as requests,
+			sum(request_length) as bytes_in,
+			sum(body_bytes_sent) as bytes_out
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY path
+		ORDER BY bytes_out DESC
+		LIMIT %d
+	`, endpointSQL, whereClause, bandwidthTopN)
+	if useRollup {
+		queryBandwidth = fmt.Sprintf(`
+		SELECT
+			%s,
+			sum(bytes_in) as bytes_in,
+			sum(bytes) as bytes_out
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketColumns(bucketSize, timeFormat, tz, "ts"), rollupTable, rollupWhere)
+		queryBandwidthByAgent = fmt.Sprintf(`
+		SELECT
+			instance_id,
+			sum(requests) as requests,
+			sum(bytes_in) as bytes_in,
+			sum(bytes) as bytes_out
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY instance_id
+		ORDER BY bytes_out DESC
+		LIMIT %d
+	`, rollupTable, rollupWhere, bandwidthTopN)
+		queryBandwidthByURI = fmt.Sprintf(`
+		SELECT
+			request_uri as path,
+			sum(requests) as requests,
+			sum(bytes_in) as bytes_in,
+			sum(bytes) as bytes_out
+		FROM nginx_analytics.%s
+		%s
+		GROUP BY path
+		ORDER BY bytes_out DESC
+		LIMIT %d
+	`, uriTable, uriWhere, bandwidthTopN)
+	}
+	sections = append(sections,
+		analyticsSection{"bandwidth", func(ctx context.Context) error {
+			rows, err := db.conn.Query(ctx, queryBandwidth, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var bucket time.Time
+				var t, timeISO string
+				var in, out uint64
+				if err := rows.Scan(&bucket, &t, &timeISO, &in, &out); err == nil {
+					resp.Bandwidth = append(resp.Bandwidth, &pb.BandwidthPoint{Time: t, TimeIso: timeISO, BytesIn: in, BytesOut: out})
+				}
+			}
+			return rows.Err()
+		}},
+		analyticsSection{"bandwidth_by_agent", func(ctx context.Context) (err error) {
+			resp.BandwidthByAgent, err = db.queryBandwidthStats(ctx, queryBandwidthByAgent, args)
+			return err
+		}},
+		analyticsSection{"bandwidth_by_uri", func(ctx context.Context) (err error) {
+			resp.BandwidthByUri, err = db.queryBandwidthStats(ctx, queryBandwidthByURI, args)
+			return err
+		}},
+	)
+
+	resp.Warnings = runAnalyticsSections(ctx, analyticsWorkers, analyticsSectionTimeout, sections)
+	if len(resp.Warnings) == len(sections) {
+		return nil, fmt.Errorf("all analytics queries failed: %s", resp.Warnings[0])
+	}
+
+	if summaryOK {
+		currErrRate := 0.0
+		if currReqs > 0 {
+			currErrRate = (float64(currErrors) / float64(currReqs)) * 100
+		}
+		prevErrRate := 0.0
+		if prevReqs > 0 {
+			prevErrRate = (float64(prevErrors) / float64(prevReqs)) * 100
+		}
+		if math.IsNaN(currLat) {
+			currLat = 0
+		}
+		if math.IsNaN(prevLat) {
+			prevLat = 0
+		}
+
+		resp.Summary = &pb.AnalyticsSummary{
+			TotalRequests:  int64(currReqs),
+			ErrorRate:      float32(currErrRate),
+			AvgLatency:     float32(currLat * 1000),
+			TotalBandwidth: currBytes,
+			TotalBytesIn:   currBytesIn,
+			RequestsDelta:  float32(currReqs) - float32(prevReqs),
+			LatencyDelta:   float32((currLat - prevLat) * 1000),
+			ErrorRateDelta: float32(currErrRate - prevErrRate),
+		}
+	}
+
+	// 11. Generate Actionable Insights (Decision Hub)
+	if resp.Summary != nil {
+		// Latency Insight
+		if resp.Summary.AvgLatency > 200 {
+			resp.Insights = append(resp.Insights, &pb.Insight{
+				Type:    "warning",
+				Title:   "High Latency Detected",
+				Message: fmt.Sprintf("Average latency is %.2fms, which is above the 200ms threshold.", resp.Summary.AvgLatency),
+			})
+		}
+
+		// Error Rate Insight
+		if resp.Summary.ErrorRate > 5.0 {
+			resp.Insights = append(resp.Insights, &pb.Insight{
+				Type:    "critical",
+				Title:   "Spike in Error Rate",
+				Message: fmt.Sprintf("Error rate has climbed to %.2f%%. Check upstream health.", resp.Summary.ErrorRate),
+			})
+		}
 	}
 
-	log.Printf("GetAnalytics: generated %d insights, %d recent logs, %d gateway points", len(resp.Insights), len(resp.RecentRequests), len(resp.GatewayMetrics))
+	// System Resource Insights
+	if len(resp.SystemMetrics) > 0 {
+		lastPoint := resp.SystemMetrics[len(resp.SystemMetrics)-1]
+		if lastPoint.CpuUsage > 80 {
+			resp.Insights = append(resp.Insights, &pb.Insight{
+				Type:    "critical",
+				Title:   "CPU Exhaustion",
+				Message: fmt.Sprintf("CPU usage is currently at %.1f%% on selected node(s).", lastPoint.CpuUsage),
+			})
+		}
+		if lastPoint.MemoryUsage > 85 {
+			resp.Insights = append(resp.Insights, &pb.Insight{
+				Type:    "warning",
+				Title:   "High Memory Pressure",
+				Message: fmt.Sprintf("Memory usage is at %.1f%%. Consider scaling up.", lastPoint.MemoryUsage),
+			})
+		}
+	}
+
+	// Info insight if everything is looking good
+	if len(resp.Insights) == 0 {
+		resp.Insights = append(resp.Insights, &pb.Insight{
+			Type:    "info",
+			Title:   "Systems Healthy",
+			Message: "All metrics are within normal operational parameters.",
+		})
+	}
+
+	analyticsLog.Info().Msgf("GetAnalytics: generated %d insights, %d recent logs, %d gateway points", len(resp.Insights), len(resp.RecentRequests), len(resp.GatewayMetrics))
 
 	return resp, nil
 }
@@ -1219,7 +1476,7 @@ func (db *ClickHouseDB) GetReportData(ctx context.Context, start, end time.Time,
 	var reqs, errs, bytes, visitors uint64
 	var lat float64
 	if err := row.Scan(&reqs, &errs, &bytes, &lat, &visitors); err != nil {
-		log.Printf("Report: Summary query failed: %v", err)
+		analyticsLog.Error().Msgf("Report: Summary query failed: %v", err)
 	} else {
 		errRate := 0.0
 		if reqs > 0 {
@@ -1303,26 +1560,22 @@ func (db *ClickHouseDB) GetReportData(ctx context.Context, start, end time.Time,
 	}
 
 	// 3. Top URIs
-	rows, err = db.conn.Query(ctx, fmt.Sprintf(`
-		SELECT
-			request_uri,
-			count(*) as requests,
-			countIf(status >= 400) as errors,
-			quantile(0.95)(request_time) as p95
-		FROM nginx_analytics.access_logs
-		%s
-		GROUP BY request_uri
-		ORDER BY requests DESC
-		LIMIT 10
-	`, whereClause), args...)
+	var agentClause string
+	if len(agentIDs) > 0 {
+		agentClause = " AND instance_id IN (?)"
+	}
+	rows, err = db.conn.Query(ctx, topPathsQuery(start, end, time.Now(), agentClause), append(args, 10)...)
 
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
 			var uri string
-			var r, e uint64
-			var p float64
-			if err := rows.Scan(&uri, &r, &e, &p); err == nil {
+			var r, e, tr uint64
+			var p50, p float64
+			if err := rows.Scan(&uri, &r, &e, &p50, &p, &tr); err == nil {
+				if math.IsNaN(p50) {
+					p50 = 0
+				}
 				if math.IsNaN(p) {
 					p = 0
 				}
@@ -1330,6 +1583,7 @@ func (db *ClickHouseDB) GetReportData(ctx context.Context, start, end time.Time,
 					Uri:      uri,
 					Requests: int64(r),
 					Errors:   int64(e),
+					P50:      float32(p50 * 1000),
 					P95:      float32(p * 1000),
 				})
 			}
@@ -1373,12 +1627,87 @@ func (db *ClickHouseDB) GetReportData(ctx context.Context, start, end time.Time,
 	return resp, nil
 }
 
+// GetTopEndpoints returns the busiest request URIs in the range, for exports (GetReportData keeps only 10).
+func (db *ClickHouseDB) GetTopEndpoints(ctx context.Context, start, end time.Time, agentIDs []string, limit int) ([]*pb.EndpointStat, error) {
+	var agentClause string
+	args := []interface{}{start, end}
+	if len(agentIDs) > 0 {
+		agentClause = " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(ctx, topPathsQuery(start, end, time.Now(), agentClause), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*pb.EndpointStat
+	for rows.Next() {
+		var uri string
+		var r, e, tr uint64
+		var p50, p float64
+		if err := rows.Scan(&uri, &r, &e, &p50, &p, &tr); err != nil {
+			return nil, err
+		}
+		if math.IsNaN(p50) {
+			p50 = 0
+		}
+		if math.IsNaN(p) {
+			p = 0
+		}
+		stats = append(stats, &pb.EndpointStat{
+			Uri:      uri,
+			Requests: int64(r),
+			Errors:   int64(e),
+			P50:      float32(p50 * 1000),
+			P95:      float32(p * 1000),
+			Traffic:  fmt.Sprint(tr),
+		})
+	}
+	return stats, rows.Err()
+}
+
+// GetStatusCodeDistribution returns request counts per exact HTTP status code in the range.
+func (db *ClickHouseDB) GetStatusCodeDistribution(ctx context.Context, start, end time.Time, agentIDs []string) ([]*pb.StatusCount, error) {
+	whereClause := "WHERE timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{start, end}
+	if len(agentIDs) > 0 {
+		whereClause += " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT toString(status) as code, count(*) as requests
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY code
+		ORDER BY code
+	`, whereClause), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*pb.StatusCount
+	for rows.Next() {
+		var code string
+		var n uint64
+		if err := rows.Scan(&code, &n); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &pb.StatusCount{Code: code, Count: int64(n)})
+	}
+	return counts, rows.Err()
+}
+
 func (db *ClickHouseDB) GetTraces(ctx context.Context, req *pb.TraceRequest) (*pb.TraceList, error) {
-	return db.GetTracesWithFilter(ctx, req, nil)
+	return db.GetTracesWithFilter(ctx, req, tenantFilter{})
 }
 
-// GetTracesWithFilter supports filtering by multiple agent IDs (for project/environment filtering)
-func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRequest, agentFilter []string) (*pb.TraceList, error) {
+// GetTracesWithFilter supports filtering by agents, project or environment
+func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRequest, filter tenantFilter) (*pb.TraceList, error) {
 	limit := req.Limit
 	if limit <= 0 {
 		limit = 100
@@ -1399,21 +1728,33 @@ func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRe
 	}
 	startTime := time.Now().UTC().Add(-duration)
 
+	durationWhere, durationArgs, orderBy, err := traceSearchClauses(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Span count and duration cover every span of the trace, including those other sources
+	// (OTLP services, other agents) joined to it
 	query := `
-		SELECT trace_id, span_id, start_time, end_time, attributes
+		SELECT trace_id, span_id, start_time, end_time, attributes, t.span_count, t.duration_ms
 		FROM nginx_analytics.spans
+		INNER JOIN (
+			SELECT
+				trace_id,
+				count() AS span_count,
+				(toUnixTimestamp64Nano(max(end_time)) - toUnixTimestamp64Nano(min(start_time))) / 1e6 AS duration_ms
+			FROM nginx_analytics.spans
+			WHERE start_time >= ?
+			GROUP BY trace_id
+		) AS t USING (trace_id)
 		WHERE name = 'request' AND start_time >= ?
 	`
-	args := []interface{}{startTime}
+	args := []interface{}{startTime, startTime}
 
-	// Agent filtering - supports multiple agent IDs (for project/environment filtering)
-	if len(agentFilter) > 0 {
-		placeholders := make([]string, len(agentFilter))
-		for i, id := range agentFilter {
-			placeholders[i] = "?"
-			args = append(args, id)
-		}
-		query += fmt.Sprintf(" AND instance_id IN (%s)", strings.Join(placeholders, ","))
+	// Agent, project or environment filtering
+	if filterClause, filterArgs := filter.and(); filterClause != "" {
+		query += filterClause
+		args = append(args, filterArgs...)
 	} else if req.AgentId != "" && req.AgentId != "all" {
 		query += " AND instance_id = ?"
 		args = append(args, req.AgentId)
@@ -1440,7 +1781,10 @@ func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRe
 		args = append(args, "%"+req.UriFilter+"%")
 	}
 
-	query += " ORDER BY start_time DESC LIMIT ?"
+	query += durationWhere
+	args = append(args, durationArgs...)
+
+	query += " ORDER BY " + orderBy + " LIMIT ?"
 	args = append(args, limit)
 
 	// Query for root spans (name='request')
@@ -1455,9 +1799,11 @@ func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRe
 		var traceID, spanID string
 		var start, end time.Time
 		var attrs map[string]string
+		var spanCount uint64
+		var durationMs float64
 
-		if err := rows.Scan(&traceID, &spanID, &start, &end, &attrs); err != nil {
-			log.Printf("Error scanning trace row: %v", err)
+		if err := rows.Scan(&traceID, &spanID, &start, &end, &attrs, &spanCount, &durationMs); err != nil {
+			analyticsLog.Error().Msgf("Error scanning trace row: %v", err)
 			continue
 		}
 
@@ -1472,21 +1818,54 @@ func (db *ClickHouseDB) GetTracesWithFilter(ctx context.Context, req *pb.TraceRe
 		}
 
 		traces = append(traces, &pb.Trace{
-			RequestId: traceID,
-			Spans:     []*pb.Span{rootSpan},
+			RequestId:  traceID,
+			Spans:      []*pb.Span{rootSpan},
+			SpanCount:  int32(spanCount),
+			DurationMs: durationMs,
 		})
 	}
 
 	return &pb.TraceList{Traces: traces}, nil
 }
 
+// traceSearchClauses returns the trace duration conditions and the ORDER BY of a trace search
+func traceSearchClauses(req *pb.TraceRequest) (where string, args []interface{}, orderBy string, err error) {
+	if req.MinDurationMs < 0 || req.MaxDurationMs < 0 {
+		return "", nil, "", fmt.Errorf("duration filters must not be negative")
+	}
+	if req.MaxDurationMs > 0 && req.MinDurationMs > req.MaxDurationMs {
+		return "", nil, "", fmt.Errorf("min_duration_ms must not exceed max_duration_ms")
+	}
+	if req.MinDurationMs > 0 {
+		where += " AND t.duration_ms >= ?"
+		args = append(args, req.MinDurationMs)
+	}
+	if req.MaxDurationMs > 0 {
+		where += " AND t.duration_ms <= ?"
+		args = append(args, req.MaxDurationMs)
+	}
+
+	switch req.SortBy {
+	case "", "time":
+		orderBy = "start_time DESC"
+	case "duration":
+		orderBy = "t.duration_ms DESC, start_time DESC"
+	default:
+		return "", nil, "", fmt.Errorf("unknown sort_by %q", req.SortBy)
+	}
+	return where, args, orderBy, nil
+}
+
+// GetTraceDetails returns the spans of a trace of agentID, including the spans other sources
+// (OTLP services, other agents) added to the same trace through the upstream trace context
 func (db *ClickHouseDB) GetTraceDetails(ctx context.Context, agentID string, traceID string) (*pb.Trace, error) {
 	rows, err := db.conn.Query(ctx, `
 		SELECT span_id, parent_span_id, name, start_time, end_time, attributes
 		FROM nginx_analytics.spans
-		WHERE instance_id = ? AND trace_id = ?
+		WHERE trace_id = ?
+			AND ? IN (SELECT instance_id FROM nginx_analytics.spans WHERE trace_id = ?)
 		ORDER BY start_time ASC
-	`, agentID, traceID)
+	`, traceID, agentID, traceID)
 	if err != nil {
 		return nil, err
 	}
@@ -1534,16 +1913,19 @@ func (db *ClickHouseDB) DeleteAgentData(agentID string) error {
 	ctx := context.Background()
 	tables := []string{
 		"access_logs",
-		"error_logs",
 		"system_metrics",
 		"nginx_metrics",
+		"nginx_workers",
+		"disk_usage",
+		"log_files",
+		"generic_metrics",
 	}
 
 	for _, table := range tables {
 		// ClickHouse DELETE is an asynchronous mutation (ALTER TABLE ... DELETE)
 		// Note: table names are hardcoded above — never use user input here.
-		query := fmt.Sprintf("ALTER TABLE %s DELETE WHERE instance_id = ?", table)
-		if err := db.conn.Exec(ctx, query, agentID); err != nil {
+		query := fmt.Sprintf("ALTER TABLE nginx_analytics.%s DELETE WHERE instance_id = ?", table)
+		if err := db.execDDL(ctx, query, agentID); err != nil {
 			return fmt.Errorf("failed to delete from %s: %w", table, err)
 		}
 	}
@@ -1551,15 +1933,19 @@ func (db *ClickHouseDB) DeleteAgentData(agentID string) error {
 	return nil
 }
 
-func (db *ClickHouseDB) QueryMetricAverage(ctx context.Context, metricType string, windowSec int) (float64, error) {
-	return db.QueryMetricAverageOffset(ctx, metricType, windowSec, 0)
+// QueryMetricAverage returns the value of an alert metric over the last windowSec seconds, from the
+// rows filter selects
+func (db *ClickHouseDB) QueryMetricAverage(ctx context.Context, metricType string, windowSec int, filter tenantFilter) (float64, error) {
+	return db.QueryMetricAverageOffset(ctx, metricType, windowSec, 0, filter)
 }
 
-func (db *ClickHouseDB) QueryMetricAverageOffset(ctx context.Context, metricType string, windowSec int, offsetSec int) (float64, error) {
+func (db *ClickHouseDB) QueryMetricAverageOffset(ctx context.Context, metricType string, windowSec int, offsetSec int, filter tenantFilter) (float64, error) {
 	var query string
 	var table string
 	var column string
 
+	window := fmt.Sprintf("timestamp >= now() - INTERVAL %d SECOND AND timestamp < now() - INTERVAL %d SECOND", windowSec+offsetSec, offsetSec)
+	tenant, args := filter.and()
 	switch metricType {
 	case "cpu":
 		table = "nginx_analytics.system_metrics"
@@ -1572,25 +1958,95 @@ func (db *ClickHouseDB) QueryMetricAverageOffset(ctx context.Context, metricType
 		column = "requests_per_second"
 	case "error_rate":
 		// Special case for error rate
-		query = fmt.Sprintf(`
+		query = `
 			SELECT if(count(*) > 0, (countIf(status >= 400) / count(*)) * 100, 0)
 			FROM nginx_analytics.access_logs
-			WHERE timestamp >= now() - INTERVAL %d SECOND AND timestamp < now() - INTERVAL %d SECOND
-		`, windowSec+offsetSec, offsetSec)
+			WHERE ` + window + tenant
+	case "latency":
+		// In milliseconds; the slow tail rather than the average, which a few slow requests barely move
+		query = `
+			SELECT if(count(*) > 0, toFloat64(quantile(0.95)(request_time)) * 1000, 0)
+			FROM nginx_analytics.access_logs
+			WHERE ` + window + tenant
+	case "synthetic_failure_rate":
+		// Synthetic results come from the gateway's probes, not from a project's agents
+		query = `
+			SELECT if(count(*) > 0, (countIf(success = 0) / count(*)) * 100, 0)
+			FROM nginx_analytics.synthetic_results
+			WHERE ` + window
+		tenant, args = "", nil
+	case "synthetic_latency":
+		table = "nginx_analytics.synthetic_results"
+		column = "latency_ms"
+		tenant, args = "", nil
+	case "disk_usage", "log_disk_usage":
+		// The fullest disk across the fleet rather than an average, so one full partition alerts
+		logs := ""
+		if metricType == "log_disk_usage" {
+			logs = " AND nginx_logs = 1"
+		}
+		query = `
+			SELECT toFloat64(max(used_percent))
+			FROM nginx_analytics.disk_usage
+			WHERE ` + window + logs + tenant
+	case "fd_usage", "nginx_fd_usage", "conntrack_usage":
+		// The most saturated host across the fleet; exhaustion fails new connections silently
+		column := map[string]string{
+			"fd_usage":        "greatest(fd_used_percent, nginx_fd_used_percent)",
+			"nginx_fd_usage":  "nginx_fd_used_percent",
+			"conntrack_usage": "conntrack_used_percent",
+		}[metricType]
+		query = fmt.Sprintf(`
+			SELECT toFloat64(max(%s))
+			FROM nginx_analytics.system_metrics
+			WHERE %s%s
+		`, column, window, tenant)
+	case "worker_restarts", "worker_crashes":
+		// Workers replaced or crashed across the fleet within the window; churn often precedes outages
+		query = fmt.Sprintf(`
+			SELECT toFloat64(sum(%s))
+			FROM nginx_analytics.nginx_metrics
+			WHERE %s%s
+		`, metricType, window, tenant)
+	case "service_restarts", "service_failures":
+		// Restarts and failures of the NGINX systemd/OpenRC units across the fleet within the window
+		query = fmt.Sprintf(`
+			SELECT toFloat64(sum(%s))
+			FROM nginx_analytics.nginx_metrics
+			WHERE %s%s
+		`, metricType, window, tenant)
+	case "restart_loop":
+		// Agents whose NGINX unit restarted repeatedly or was given up on by systemd
+		query = `
+			SELECT toFloat64(uniqExactIf(instance_id, restart_loop = 1))
+			FROM nginx_analytics.nginx_metrics
+			WHERE ` + window + tenant
 	default:
-		return 0, fmt.Errorf("unknown metric type: %s", metricType)
+		// Finding counts from attack detection and limit_req rejections
+		eventType, ok := securityEventsMetricFilter(metricType)
+		if !ok {
+			return 0, fmt.Errorf("unknown metric type: %s", metricType)
+		}
+		events := ""
+		if eventType != "" {
+			events = fmt.Sprintf(" AND event_type = '%s'", eventType)
+		}
+		query = `
+			SELECT toFloat64(count())
+			FROM nginx_analytics.security_events
+			WHERE ` + window + events + tenant
 	}
 
 	if query == "" {
 		query = fmt.Sprintf(`
 			SELECT avg(%s)
 			FROM %s
-			WHERE timestamp >= now() - INTERVAL %d SECOND AND timestamp < now() - INTERVAL %d SECOND
-		`, column, table, windowSec+offsetSec, offsetSec)
+			WHERE %s%s
+		`, column, table, window, tenant)
 	}
 
 	var avg float64
-	err := db.conn.QueryRow(ctx, query).Scan(&avg)
+	err := db.conn.QueryRow(ctx, query, args...).Scan(&avg)
 	if err != nil {
 		// Log and return 0 if no data
 		return 0, nil
@@ -1626,10 +2082,14 @@ func (db *ClickHouseDB) flushLogs(batch []logBatchItem) {
 		timestamp, instance_id, remote_addr, request_method,
 		request_uri, status, body_bytes_sent, request_time,
 		request_id, upstream_addr, upstream_status, user_agent, referer,
-		client_ip, country, country_code, city, region, latitude, longitude, timezone, isp
+		client_ip, country, country_code, city, region, latitude, longitude, timezone, isp,
+		asn, is_bot, bot_category, browser_family, browser_version, os_family, os_version, device_type,
+		threat_feed, threat_category, labels, request_length, upstream_cache_status,
+		project_id, environment_id, endpoint
 	)`)
 	if err != nil {
-		log.Printf("FlushLogs: PrepareBatch failed: %v", err)
+		analyticsLog.Error().Msgf("FlushLogs: PrepareBatch failed: %v", err)
+		db.insertFailed("access_logs")
 		return
 	}
 
@@ -1638,19 +2098,37 @@ func (db *ClickHouseDB) flushLogs(batch []logBatchItem) {
 		if item.entry.Timestamp == 0 {
 			ts = time.Now()
 		}
+		ua := item.ua
+		if ua == nil {
+			ua = &ParsedUA{}
+		}
+		var isBot uint8
+		if item.botCategory != "" {
+			isBot = 1
+		}
+		labels := item.entry.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		tenant := db.tenantOf(item.agentID)
 		if err := b.Append(ts, item.agentID, item.entry.RemoteAddr, item.entry.RequestMethod,
 			item.entry.RequestUri, uint16(item.entry.Status), uint64(item.entry.BodyBytesSent),
 			float32(item.entry.RequestTime), item.entry.RequestId, item.entry.UpstreamAddr,
 			item.entry.UpstreamStatus, item.entry.UserAgent, item.entry.Referer,
 			item.clientIP, item.country, item.countryCode, item.city, item.region,
-			item.latitude, item.longitude, item.timezone, item.isp); err != nil {
-			log.Printf("FlushLogs: Append failed: %v", err)
+			item.latitude, item.longitude, item.timezone, item.isp,
+			item.asn, isBot, item.botCategory, ua.BrowserFamily, ua.BrowserVersion, ua.OSFamily, ua.OSVersion, ua.DeviceType,
+			item.threat.Feed, item.threat.Category, labels, uint64(item.entry.RequestLength), item.entry.UpstreamCacheStatus,
+			tenant.ProjectID, tenant.EnvironmentID, item.endpoint); err != nil {
+			analyticsLog.Error().Msgf("FlushLogs: Append failed: %v", err)
+			db.insertFailed("access_logs")
 			return
 		}
 	}
 
 	if err := b.Send(); err != nil {
-		log.Printf("FlushLogs: Send failed: %v", err)
+		analyticsLog.Error().Msgf("FlushLogs: Send failed: %v", err)
+		db.insertFailed("access_logs")
 	}
 }
 
@@ -1679,20 +2157,27 @@ func (db *ClickHouseDB) runSpanFlusher() {
 func (db *ClickHouseDB) flushSpans(batch []spanBatchItem) {
 	ctx := context.Background()
 	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.spans (
-		trace_id, span_id, parent_span_id, name, start_time, end_time, attributes, instance_id
+		trace_id, span_id, parent_span_id, name, start_time, end_time, attributes, instance_id,
+		project_id, environment_id
 	)`)
 	if err != nil {
+		analyticsLog.Error().Msgf("flushSpans: PrepareBatch failed: %v", err)
+		db.insertFailed("spans")
 		return
 	}
 
 	for _, s := range batch {
-		if err := b.Append(s.traceID, s.spanID, s.parent, s.name, s.start, s.end, s.attrs, s.agentID); err != nil {
-			log.Printf("flushSpans: Append failed: %v", err)
+		tenant := db.tenantOf(s.agentID)
+		if err := b.Append(s.traceID, s.spanID, s.parent, s.name, s.start, s.end, s.attrs, s.agentID,
+			tenant.ProjectID, tenant.EnvironmentID); err != nil {
+			analyticsLog.Error().Msgf("flushSpans: Append failed: %v", err)
+			db.insertFailed("spans")
 			return
 		}
 	}
 	if err := b.Send(); err != nil {
-		log.Printf("flushSpans: Send failed: %v", err)
+		analyticsLog.Error().Msgf("flushSpans: Send failed: %v", err)
+		db.insertFailed("spans")
 	}
 }
 
@@ -1718,12 +2203,15 @@ func (db *ClickHouseDB) runSysFlusher() {
 
 func (db *ClickHouseDB) flushSys(batch []sysBatchItem) {
 	ctx := context.Background()
-	b, err := db.conn.PrepareBatch(ctx, "INSERT INTO nginx_analytics.system_metrics (timestamp, instance_id, cpu_usage, memory_usage, memory_total, memory_used, network_rx_bytes, network_tx_bytes, network_rx_rate, network_tx_rate, cpu_user, cpu_system, cpu_iowait)")
+	b, err := db.conn.PrepareBatch(ctx, "INSERT INTO nginx_analytics.system_metrics (timestamp, instance_id, cpu_usage, memory_usage, memory_total, memory_used, network_rx_bytes, network_tx_bytes, network_rx_rate, network_tx_rate, cpu_user, cpu_system, cpu_iowait, fd_allocated, fd_max, fd_used_percent, nginx_open_fds, nginx_fd_limit, nginx_fd_used_percent, conntrack_count, conntrack_max, conntrack_used_percent, project_id, environment_id)")
 	if err != nil {
-		log.Printf("Failed to prepare system metrics batch: %v", err)
+		analyticsLog.Error().Msgf("Failed to prepare system metrics batch: %v", err)
+		db.insertFailed("system_metrics")
 		return
 	}
 	for _, item := range batch {
+		nginxFD := fullestProcessFD(item.entry.NginxFds)
+		tenant := db.tenantOf(item.agentID)
 		if err := b.Append(
 			time.Now(),
 			item.agentID,
@@ -1738,14 +2226,40 @@ func (db *ClickHouseDB) flushSys(batch []sysBatchItem) {
 			float32(item.entry.CpuUserPercent),
 			float32(item.entry.CpuSystemPercent),
 			float32(item.entry.CpuIowaitPercent),
+			item.entry.FdAllocated,
+			item.entry.FdMax,
+			item.entry.FdUsedPercent,
+			nginxFD.GetOpenFds(),
+			nginxFD.GetLimit(),
+			nginxFD.GetUsedPercent(),
+			item.entry.ConntrackCount,
+			item.entry.ConntrackMax,
+			item.entry.ConntrackUsedPercent,
+			tenant.ProjectID,
+			tenant.EnvironmentID,
 		); err != nil {
-			log.Printf("Failed to append system metrics: %v", err)
+			analyticsLog.Error().Msgf("Failed to append system metrics: %v", err)
+			db.insertFailed("system_metrics")
 			return
 		}
 	}
 	if err := b.Send(); err != nil {
-		log.Printf("Failed to send system metrics batch: %v", err)
+		analyticsLog.Error().Msgf("Failed to send system metrics batch: %v", err)
+		db.insertFailed("system_metrics")
+	}
+	db.flushDisks(ctx, batch)
+}
+
+// fullestProcessFD returns the nginx process closest to its open files limit, nil when none
+// reported one. Processes without a limit are only picked when no process has one.
+func fullestProcessFD(procs []*pb.ProcessFdUsage) *pb.ProcessFdUsage {
+	var fullest *pb.ProcessFdUsage
+	for _, p := range procs {
+		if fullest == nil || p.UsedPercent > fullest.UsedPercent || (fullest.Limit == 0 && p.Limit > 0) {
+			fullest = p
+		}
 	}
+	return fullest
 }
 
 func (db *ClickHouseDB) runNginxFlusher() {
@@ -1773,17 +2287,16 @@ func (db *ClickHouseDB) flushNginx(batch []nginxBatchItem) {
 	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.nginx_metrics (
 		timestamp, instance_id, active_connections, accepted_connections, handled_connections,
 		total_requests, reading, writing, waiting, requests_per_second,
-		status_2xx, status_3xx, status_4xx, status_5xx, bytes_in, bytes_out
+		status_2xx, status_3xx, status_4xx, status_5xx, bytes_in, bytes_out,
+		worker_count, worker_restarts, worker_crashes, labels,
+		service_restarts, service_failures, restart_loop, project_id, environment_id
 	)`)
 	if err != nil {
-		log.Printf("Failed to prepare nginx metrics batch: %v", err)
+		analyticsLog.Error().Msgf("Failed to prepare nginx metrics batch: %v", err)
+		db.insertFailed("nginx_metrics")
 		return
 	}
 	for _, item := range batch {
-		rps := float64(0)
-		if item.entry.ActiveConnections > 0 {
-			rps = float64(item.entry.TotalRequests) / 60.0
-		}
 		var s2xx, s3xx, s4xx, s5xx uint64
 		if item.entry.HttpStatus != nil {
 			s2xx = uint64(item.entry.HttpStatus.Status_2XxCount)
@@ -1793,6 +2306,18 @@ func (db *ClickHouseDB) flushNginx(batch []nginxBatchItem) {
 		}
 		bytesIn := uint64(item.entry.BytesInTotal)
 		bytesOut := uint64(item.entry.BytesOutTotal)
+		workers := item.entry.Workers
+		service := item.entry.Service
+		var restartLoop uint8
+		if service.GetRestartLoop() {
+			restartLoop = 1
+		}
+		// Hosts running several nginx instances report one sample per instance
+		labels := map[string]string{}
+		if item.entry.InstanceId != "" {
+			labels["nginx_instance"] = item.entry.InstanceId
+		}
+		tenant := db.tenantOf(item.agentID)
 		if err := b.Append(
 			time.Now(),
 			item.agentID,
@@ -1803,16 +2328,59 @@ func (db *ClickHouseDB) flushNginx(batch []nginxBatchItem) {
 			uint32(item.entry.Reading),
 			uint32(item.entry.Writing),
 			uint32(item.entry.Waiting),
-			rps,
+			item.entry.RequestsPerSecond,
 			s2xx, s3xx, s4xx, s5xx,
 			bytesIn, bytesOut,
+			uint32(len(workers.GetProcesses())), uint32(workers.GetRestarts()), uint32(workers.GetCrashes()),
+			labels,
+			uint32(service.GetNewRestarts()), uint32(service.GetNewFailures()), restartLoop,
+			tenant.ProjectID, tenant.EnvironmentID,
 		); err != nil {
-			log.Printf("Failed to append nginx metrics: %v", err)
+			analyticsLog.Error().Msgf("Failed to append nginx metrics: %v", err)
+			db.insertFailed("nginx_metrics")
 			return
 		}
 	}
 	if err := b.Send(); err != nil {
-		log.Printf("Failed to send nginx metrics batch: %v", err)
+		analyticsLog.Error().Msgf("Failed to send nginx metrics batch: %v", err)
+		db.insertFailed("nginx_metrics")
+	}
+	db.flushNginxWorkers(ctx, batch)
+}
+
+// flushNginxWorkers stores the worker processes reported with a batch of nginx metrics
+func (db *ClickHouseDB) flushNginxWorkers(ctx context.Context, batch []nginxBatchItem) {
+	var b driver.Batch
+	now := time.Now()
+	for _, item := range batch {
+		for _, p := range item.entry.Workers.GetProcesses() {
+			if b == nil {
+				var err error
+				b, err = db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.nginx_workers (
+					timestamp, instance_id, master_pid, pid, cpu_percent, rss_bytes, started_at,
+					project_id, environment_id
+				)`)
+				if err != nil {
+					analyticsLog.Error().Msgf("Failed to prepare nginx workers batch: %v", err)
+					db.insertFailed("nginx_workers")
+					return
+				}
+			}
+			tenant := db.tenantOf(item.agentID)
+			if err := b.Append(now, item.agentID, uint32(p.MasterPid), uint32(p.Pid), p.CpuPercent, p.RssBytes, time.Unix(p.StartedAt, 0),
+				tenant.ProjectID, tenant.EnvironmentID); err != nil {
+				analyticsLog.Error().Msgf("Failed to append nginx worker: %v", err)
+				db.insertFailed("nginx_workers")
+				return
+			}
+		}
+	}
+	if b == nil {
+		return
+	}
+	if err := b.Send(); err != nil {
+		analyticsLog.Error().Msgf("Failed to send nginx workers batch: %v", err)
+		db.insertFailed("nginx_workers")
 	}
 }
 func (db *ClickHouseDB) runGwFlusher() {
@@ -1839,35 +2407,42 @@ func (db *ClickHouseDB) flushGw(batch []gwBatchItem) {
 	ctx := context.Background()
 	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.gateway_metrics (
 		timestamp, gateway_id, eps, active_connections,
-		cpu_usage, memory_mb, goroutines, db_latency_ms
+		cpu_usage, memory_mb, goroutines, db_latency_ms, load1, load5, load15
 	)`)
 	if err != nil {
+		analyticsLog.Error().Msgf("flushGw: PrepareBatch failed: %v", err)
+		db.insertFailed("gateway_metrics")
 		return
 	}
 	for _, item := range batch {
 		if err := b.Append(time.Now(), item.metrics.gatewayID, item.metrics.metrics.Eps,
 			uint32(item.metrics.metrics.ActiveConnections), item.metrics.metrics.CpuUsage,
 			item.metrics.metrics.MemoryMb, uint32(item.metrics.metrics.Goroutines),
-			item.metrics.metrics.DbLatency); err != nil {
-			log.Printf("flushGw: Append failed: %v", err)
+			item.metrics.metrics.DbLatency, item.metrics.metrics.Load1, item.metrics.metrics.Load5,
+			item.metrics.metrics.Load15); err != nil {
+			analyticsLog.Error().Msgf("flushGw: Append failed: %v", err)
+			db.insertFailed("gateway_metrics")
 			return
 		}
 	}
 	if err := b.Send(); err != nil {
-		log.Printf("flushGw: Send failed: %v", err)
+		analyticsLog.Error().Msgf("flushGw: Send failed: %v", err)
+		db.insertFailed("gateway_metrics")
 	}
 }
 
 // GeoDataResponse represents geo analytics data
 type GeoDataResponse struct {
-	Locations      []GeoLocation `json:"locations"`
-	CountryStats   []CountryStat `json:"country_stats"`
-	CityStats      []CityStat    `json:"city_stats"`
-	RecentRequests []GeoRequest  `json:"recent_requests"`
-	TotalCountries uint64        `json:"total_countries"`
-	TotalCities    uint64        `json:"total_cities"`
-	TotalRequests  uint64        `json:"total_requests"`
-	TopCountryCode string        `json:"top_country_code"`
+	Locations      []GeoLocation  `json:"locations"`
+	CountryStats   []CountryStat  `json:"country_stats"`
+	CityStats      []CityStat     `json:"city_stats"`
+	RecentRequests []GeoRequest   `json:"recent_requests"`
+	TotalCountries uint64         `json:"total_countries"`
+	TotalCities    uint64         `json:"total_cities"`
+	TotalRequests  uint64         `json:"total_requests"`
+	TopCountryCode string         `json:"top_country_code"`
+	TopASNs        []*pb.ASNStat  `json:"top_asns"`
+	BotTraffic     *pb.BotTraffic `json:"bot_traffic"`
 }
 
 type GeoLocation struct {
@@ -1953,7 +2528,7 @@ func (db *ClickHouseDB) GetGeoData(ctx context.Context, window string) (*GeoData
 	`
 	rows, err := db.conn.Query(ctx, queryLocations, startTime)
 	if err != nil {
-		log.Printf("GetGeoData: locations query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoData: locations query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var loc GeoLocation
@@ -1981,7 +2556,7 @@ func (db *ClickHouseDB) GetGeoData(ctx context.Context, window string) (*GeoData
 	`
 	rows, err = db.conn.Query(ctx, queryCountries, startTime)
 	if err != nil {
-		log.Printf("GetGeoData: countries query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoData: countries query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var stat CountryStat
@@ -2013,7 +2588,7 @@ func (db *ClickHouseDB) GetGeoData(ctx context.Context, window string) (*GeoData
 	`
 	rows, err = db.conn.Query(ctx, queryCities, startTime)
 	if err != nil {
-		log.Printf("GetGeoData: cities query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoData: cities query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var stat CityStat
@@ -2045,7 +2620,7 @@ func (db *ClickHouseDB) GetGeoData(ctx context.Context, window string) (*GeoData
 	`
 	rows, err = db.conn.Query(ctx, queryRecent, startTime)
 	if err != nil {
-		log.Printf("GetGeoData: recent requests query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoData: recent requests query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var req GeoRequest
@@ -2079,14 +2654,23 @@ func (db *ClickHouseDB) GetGeoData(ctx context.Context, window string) (*GeoData
 		resp.TopCountryCode = resp.CountryStats[0].CountryCode
 	}
 
+	// 6. Get network origin and bot share
+	if resp.TopASNs, err = db.queryTopASNs(ctx, "WHERE timestamp >= ?", []interface{}{startTime}, 20); err != nil {
+		analyticsLog.Error().Msgf("GetGeoData: ASN query failed: %v", err)
+	}
+	if resp.BotTraffic, err = db.queryBotTraffic(ctx, "WHERE timestamp >= ?", []interface{}{startTime}); err != nil {
+		analyticsLog.Error().Msgf("GetGeoData: bot traffic query failed: %v", err)
+	}
+
 	return resp, nil
 }
 
-// GetGeoDataFiltered returns geo data filtered by a list of agent IDs (for RBAC)
-// If agentFilter is nil or empty, returns all data (for superadmins)
-func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, agentFilter []string) (*GeoDataResponse, error) {
+// GetGeoDataFiltered returns geo data of the rows of a tenant filter (for RBAC)
+// If the filter is empty, returns all data (for superadmins)
+func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, filter tenantFilter) (*GeoDataResponse, error) {
 	// If no filter, use the unfiltered version
-	if len(agentFilter) == 0 {
+	agentClause, filterArgs := filter.cond("")
+	if agentClause == "" {
 		return db.GetGeoData(ctx, window)
 	}
 
@@ -2110,15 +2694,7 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 		RecentRequests: []GeoRequest{},
 	}
 
-	// Build agent filter clause
-	agentPlaceholders := make([]string, len(agentFilter))
-	agentArgs := make([]interface{}, len(agentFilter)+1)
-	agentArgs[0] = startTime
-	for i, id := range agentFilter {
-		agentPlaceholders[i] = "?"
-		agentArgs[i+1] = id
-	}
-	agentClause := fmt.Sprintf("instance_id IN (%s)", strings.Join(agentPlaceholders, ","))
+	agentArgs := append([]interface{}{startTime}, filterArgs...)
 
 	// 1. Get unique locations with aggregated stats
 	queryLocations := fmt.Sprintf(`
@@ -2139,7 +2715,7 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 	`, agentClause)
 	rows, err := db.conn.Query(ctx, queryLocations, agentArgs...)
 	if err != nil {
-		log.Printf("GetGeoDataFiltered: locations query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: locations query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var loc GeoLocation
@@ -2167,7 +2743,7 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 	`, agentClause)
 	rows, err = db.conn.Query(ctx, queryCountries, agentArgs...)
 	if err != nil {
-		log.Printf("GetGeoDataFiltered: countries query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: countries query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var stat CountryStat
@@ -2199,7 +2775,7 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 	`, agentClause)
 	rows, err = db.conn.Query(ctx, queryCities, agentArgs...)
 	if err != nil {
-		log.Printf("GetGeoDataFiltered: cities query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: cities query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var stat CityStat
@@ -2231,7 +2807,7 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 	`, agentClause)
 	rows, err = db.conn.Query(ctx, queryRecent, agentArgs...)
 	if err != nil {
-		log.Printf("GetGeoDataFiltered: recent requests query failed: %v", err)
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: recent requests query failed: %v", err)
 	} else {
 		for rows.Next() {
 			var req GeoRequest
@@ -2265,5 +2841,13 @@ func (db *ClickHouseDB) GetGeoDataFiltered(ctx context.Context, window string, a
 		resp.TopCountryCode = resp.CountryStats[0].CountryCode
 	}
 
+	// 6. Get network origin and bot share
+	if resp.TopASNs, err = db.queryTopASNs(ctx, "WHERE timestamp >= ? AND "+agentClause, agentArgs, 20); err != nil {
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: ASN query failed: %v", err)
+	}
+	if resp.BotTraffic, err = db.queryBotTraffic(ctx, "WHERE timestamp >= ? AND "+agentClause, agentArgs); err != nil {
+		analyticsLog.Error().Msgf("GetGeoDataFiltered: bot traffic query failed: %v", err)
+	}
+
 	return resp, nil
 }
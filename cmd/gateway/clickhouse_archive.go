@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ArchivedLogEntry is an access log row read back from an archive object
+type ArchivedLogEntry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	AgentID       string    `json:"agent_id"`
+	RemoteAddr    string    `json:"remote_addr"`
+	RequestMethod string    `json:"request_method"`
+	RequestURI    string    `json:"request_uri"`
+	Status        uint16    `json:"status"`
+	BodyBytesSent uint64    `json:"body_bytes_sent"`
+	RequestTime   float32   `json:"request_time"`
+	UserAgent     string    `json:"user_agent"`
+	Referer       string    `json:"referer"`
+}
+
+// archiveObject is a Parquet object in the archive bucket and the credentials to reach it
+type archiveObject struct {
+	url       string
+	accessKey string
+	secretKey string
+}
+
+// tableFunction returns the s3() table function for the object and its bound arguments.
+// Without keys ClickHouse falls back to its own credentials (instance role, env).
+func (o archiveObject) tableFunction() (string, []interface{}) {
+	if o.accessKey == "" {
+		return "s3(?, 'Parquet')", []interface{}{o.url}
+	}
+	return "s3(?, ?, ?, 'Parquet')", []interface{}{o.url, o.accessKey, o.secretKey}
+}
+
+// ArchiveAccessLogDay writes the access logs of a UTC day to a Parquet object, replacing it if
+// present, and returns the rows written. Nothing is written for a day without logs.
+func (db *ClickHouseDB) ArchiveAccessLogDay(ctx context.Context, day time.Time, obj archiveObject) (uint64, error) {
+	start, end := day, day.AddDate(0, 0, 1)
+	var rows uint64
+	if err := db.conn.QueryRow(ctx, `
+		SELECT count() FROM nginx_analytics.access_logs WHERE timestamp >= ? AND timestamp < ?`,
+		start, end).Scan(&rows); err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, nil
+	}
+	fn, args := obj.tableFunction()
+	args = append(args, start, end)
+	err := db.conn.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO FUNCTION %s
+		SELECT * FROM nginx_analytics.access_logs WHERE timestamp >= ? AND timestamp < ?
+		SETTINGS s3_truncate_on_insert = 1`, fn), args...)
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// QueryArchivedAccessLogs reads access logs straight from an archive object, newest first
+func (db *ClickHouseDB) QueryArchivedAccessLogs(ctx context.Context, obj archiveObject, agentID string, status, limit int) ([]ArchivedLogEntry, error) {
+	fn, args := obj.tableFunction()
+	var conds []string
+	if agentID != "" {
+		conds = append(conds, "instance_id = ?")
+		args = append(args, agentID)
+	}
+	if status > 0 {
+		conds = append(conds, "status = ?")
+		args = append(args, status)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, limit)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT timestamp, instance_id, remote_addr, request_method, request_uri, status,
+			body_bytes_sent, request_time, user_agent, referer
+		FROM %s %s
+		ORDER BY timestamp DESC
+		LIMIT ?`, fn, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []ArchivedLogEntry{}
+	for rows.Next() {
+		var e ArchivedLogEntry
+		if err := rows.Scan(&e.Timestamp, &e.AgentID, &e.RemoteAddr, &e.RequestMethod, &e.RequestURI, &e.Status,
+			&e.BodyBytesSent, &e.RequestTime, &e.UserAgent, &e.Referer); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// RestoreAccessLogDay loads an archived day into access_logs_restored, which has no TTL, so it
+// can be queried like live data. Columns are matched by name: an archive written before a column
+// was added leaves it at its default.
+func (db *ClickHouseDB) RestoreAccessLogDay(ctx context.Context, day time.Time, obj archiveObject) error {
+	if err := db.ReleaseAccessLogDay(ctx, day); err != nil {
+		return err
+	}
+	fn, args := obj.tableFunction()
+	archived := make(map[string]bool)
+	rows, err := db.conn.Query(ctx, "DESCRIBE TABLE "+fn, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name, typ, defaultType, defaultExpr, comment, codec, ttl string
+		if err := rows.Scan(&name, &typ, &defaultType, &defaultExpr, &comment, &codec, &ttl); err != nil {
+			rows.Close()
+			return err
+		}
+		archived[name] = true
+	}
+	rows.Close()
+
+	var columns []string
+	rows, err = db.conn.Query(ctx, `
+		SELECT name FROM system.columns
+		WHERE database = 'nginx_analytics' AND table = 'access_logs_restored' AND default_kind NOT IN ('MATERIALIZED', 'ALIAS')
+		ORDER BY position`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if archived[name] {
+			columns = append(columns, "`"+name+"`")
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("archive has no access log columns")
+	}
+	cols := strings.Join(columns, ", ")
+	return db.conn.Exec(ctx, fmt.Sprintf(`INSERT INTO nginx_analytics.access_logs_restored (%s) SELECT %s FROM %s`, cols, cols, fn), args...)
+}
+
+// ReleaseAccessLogDay removes a restored day from access_logs_restored
+func (db *ClickHouseDB) ReleaseAccessLogDay(ctx context.Context, day time.Time) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	return db.execDDL(ctx, `
+		ALTER TABLE nginx_analytics.access_logs_restored DELETE WHERE timestamp >= ? AND timestamp < ?`,
+		day, day.AddDate(0, 0, 1))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// bandwidthTopN is how many agents and URI paths GetAnalytics lists by bytes sent
+const bandwidthTopN = 10
+
+// bytesPerGB is the unit of egress prices, as cloud providers bill it
+const bytesPerGB = 1 << 30
+
+// queryBandwidthStats runs a query selecting key, requests, bytes_in and bytes_out per row
+func (db *ClickHouseDB) queryBandwidthStats(ctx context.Context, query string, args []interface{}) ([]*pb.BandwidthStat, error) {
+	rows, err := db.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*pb.BandwidthStat{}
+	for rows.Next() {
+		var key string
+		var reqs, in, out uint64
+		if err := rows.Scan(&key, &reqs, &in, &out); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &pb.BandwidthStat{Key: key, Requests: int64(reqs), BytesIn: in, BytesOut: out})
+	}
+	return stats, rows.Err()
+}
+
+// applyEgressCost prices the bytes sent of an analytics response with the egress cost model.
+// Without a price the response is left as it is.
+func applyEgressCost(resp *pb.AnalyticsResponse, cfg config.BandwidthConfig) {
+	if cfg.EgressCostPerGB <= 0 {
+		return
+	}
+	cost := func(bytes uint64) float64 { return float64(bytes) / bytesPerGB * cfg.EgressCostPerGB }
+	for _, s := range resp.BandwidthByAgent {
+		s.EgressCost = cost(s.BytesOut)
+	}
+	for _, s := range resp.BandwidthByUri {
+		s.EgressCost = cost(s.BytesOut)
+	}
+
+	// The summary covers every agent; the series is the fallback when its query failed
+	var sent uint64
+	if resp.Summary != nil {
+		sent = resp.Summary.TotalBandwidth
+	} else {
+		for _, p := range resp.Bandwidth {
+			sent += p.BytesOut
+		}
+	}
+	resp.EgressCost = &pb.EgressCost{PricePerGb: cfg.EgressCostPerGB, Currency: cfg.Currency, Total: cost(sent)}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestApplyEgressCost(t *testing.T) {
+	newResp := func() *pb.AnalyticsResponse {
+		return &pb.AnalyticsResponse{
+			Summary:          &pb.AnalyticsSummary{TotalBandwidth: 4 << 30},
+			Bandwidth:        []*pb.BandwidthPoint{{Time: "10:00", BytesOut: 1 << 30}},
+			BandwidthByAgent: []*pb.BandwidthStat{{Key: "agent-1", BytesOut: 3 << 30}, {Key: "agent-2", BytesOut: 1 << 30}},
+			BandwidthByUri:   []*pb.BandwidthStat{{Key: "/video", BytesOut: 2 << 29}},
+		}
+	}
+
+	resp := newResp()
+	applyEgressCost(resp, config.BandwidthConfig{Currency: "USD"})
+	if resp.EgressCost != nil || resp.BandwidthByAgent[0].EgressCost != 0 {
+		t.Errorf("without a price nothing should be priced: %v", resp)
+	}
+
+	resp = newResp()
+	applyEgressCost(resp, config.BandwidthConfig{EgressCostPerGB: 0.09, Currency: "EUR"})
+	if c := resp.EgressCost; c == nil || c.Currency != "EUR" || c.PricePerGb != 0.09 || c.Total != 4*0.09 {
+		t.Errorf("egress cost = %v, want 4 GB at 0.09 EUR", c)
+	}
+	if got := resp.BandwidthByAgent[0].EgressCost; got != 3*0.09 {
+		t.Errorf("agent-1 cost = %v", got)
+	}
+	if got := resp.BandwidthByUri[0].EgressCost; got != 0.09 {
+		t.Errorf("/video cost = %v", got)
+	}
+
+	// The series stands in for a failed summary
+	resp = newResp()
+	resp.Summary = nil
+	applyEgressCost(resp, config.BandwidthConfig{EgressCostPerGB: 0.09})
+	if resp.EgressCost.Total != 0.09 {
+		t.Errorf("total without summary = %v", resp.EgressCost.Total)
+	}
+}
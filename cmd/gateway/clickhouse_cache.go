@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// cacheHitStatuses are the $upstream_cache_status values of responses served from the cache
+const cacheHitStatuses = "'HIT', 'REVALIDATED'"
+
+// queryCacheStats returns the proxy cache effectiveness and the paths with the most cached
+// requests, or nil when no request went through a cache.
+// whereClause must start with WHERE and is shared with the caller's other access_logs queries.
+func (db *ClickHouseDB) queryCacheStats(ctx context.Context, whereClause string, args []interface{}, limit int) (*pb.CacheStats, error) {
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT upstream_cache_status, count() as requests
+		FROM nginx_analytics.access_logs
+		%s AND upstream_cache_status != ''
+		GROUP BY upstream_cache_status
+		ORDER BY requests DESC
+	`, whereClause), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []*pb.CacheStatusCount
+	for rows.Next() {
+		var status string
+		var reqs uint64
+		if err := rows.Scan(&status, &reqs); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, &pb.CacheStatusCount{Status: status, Requests: int64(reqs)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	stats := newCacheStats(statuses)
+	if stats == nil {
+		return nil, nil
+	}
+
+	uriRows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			cutQueryString(request_uri) as path,
+			count() as requests,
+			countIf(upstream_cache_status IN (%s)) as hits
+		FROM nginx_analytics.access_logs
+		%s AND upstream_cache_status != ''
+		GROUP BY path
+		ORDER BY requests DESC
+		LIMIT %d
+	`, cacheHitStatuses, whereClause, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer uriRows.Close()
+	for uriRows.Next() {
+		var path string
+		var reqs, hits uint64
+		if err := uriRows.Scan(&path, &reqs, &hits); err != nil {
+			return nil, err
+		}
+		stats.TopUris = append(stats.TopUris, &pb.CacheUriStat{
+			Uri:      path,
+			Requests: int64(reqs),
+			Hits:     int64(hits),
+			HitRatio: float32(hits) / float32(reqs),
+		})
+	}
+	return stats, uriRows.Err()
+}
+
+// newCacheStats computes the ratios of the cache status counts, or returns nil without any
+func newCacheStats(statuses []*pb.CacheStatusCount) *pb.CacheStats {
+	var total, hits, misses, stale, bypass int64
+	for _, s := range statuses {
+		total += s.Requests
+		switch s.Status {
+		case "HIT", "REVALIDATED":
+			hits += s.Requests
+		case "MISS", "EXPIRED":
+			misses += s.Requests
+		case "STALE", "UPDATING":
+			stale += s.Requests
+		case "BYPASS":
+			bypass += s.Requests
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	ratio := func(n int64) float32 { return float32(n) / float32(total) }
+	return &pb.CacheStats{
+		CachedRequests: total,
+		HitRatio:       ratio(hits),
+		MissRatio:      ratio(misses),
+		StaleRatio:     ratio(stale),
+		BypassRatio:    ratio(bypass),
+		Statuses:       statuses,
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestNewCacheStats(t *testing.T) {
+	if newCacheStats(nil) != nil {
+		t.Error("no cache statuses should give no stats")
+	}
+
+	stats := newCacheStats([]*pb.CacheStatusCount{
+		{Status: "HIT", Requests: 60},
+		{Status: "MISS", Requests: 20},
+		{Status: "REVALIDATED", Requests: 10},
+		{Status: "STALE", Requests: 4},
+		{Status: "EXPIRED", Requests: 4},
+		{Status: "BYPASS", Requests: 2},
+	})
+	if stats.CachedRequests != 100 {
+		t.Errorf("cached requests = %d", stats.CachedRequests)
+	}
+	for name, got := range map[string]struct{ got, want float32 }{
+		"hit":    {stats.HitRatio, 0.7},
+		"miss":   {stats.MissRatio, 0.24},
+		"stale":  {stats.StaleRatio, 0.04},
+		"bypass": {stats.BypassRatio, 0.02},
+	} {
+		if got.got != got.want {
+			t.Errorf("%s ratio = %v, want %v", name, got.got, got.want)
+		}
+	}
+	if len(stats.Statuses) != 6 {
+		t.Errorf("statuses = %v", stats.Statuses)
+	}
+}
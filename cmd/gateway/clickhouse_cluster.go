@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// The schema statements in migrate() and elsewhere are written for a single node. On a cluster they
+// are rewritten by clickHouseTopology.ddl: every statement runs ON CLUSTER, MergeTree engines become
+// their Replicated variants, and with distributed tables each table keeps its rows in <table>_local
+// on every shard behind a Distributed table of the original name, so reads and inserts are unchanged.
+
+// defaultReplicationPath is the Keeper path of replicated tables; the macros are expanded by
+// ClickHouse on each replica
+const defaultReplicationPath = "/clickhouse/tables/{shard}/{database}/{table}"
+
+// localTableSuffix names the shard-local table behind a Distributed table
+const localTableSuffix = "_local"
+
+// clickHouseTopology is how nginx_analytics is laid out: on a single node when Cluster is empty
+type clickHouseTopology struct {
+	Cluster         string // cluster of the servers' remote_servers that DDL runs ON CLUSTER on
+	Replicated      bool   // Replicated*MergeTree engines
+	ReplicationPath string // Keeper path of replicated tables; defaultReplicationPath when empty
+	Distributed     bool   // rows in <table>_local on every shard behind a Distributed <table>
+}
+
+var (
+	createDatabaseRe = regexp.MustCompile(`^(\s*CREATE DATABASE IF NOT EXISTS \w+)`)
+	createTableRe    = regexp.MustCompile(`^(\s*CREATE TABLE IF NOT EXISTS nginx_analytics\.)(\w+)(\s+AS nginx_analytics\.(\w+))?`)
+	createViewRe     = regexp.MustCompile(`^(\s*CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics\.\w+)(\s+TO nginx_analytics\.)(\w+)`)
+	dropViewRe       = regexp.MustCompile(`^(\s*DROP VIEW IF EXISTS nginx_analytics\.\w+)`)
+	alterTableRe     = regexp.MustCompile(`^(\s*ALTER TABLE nginx_analytics\.)(\w+)(\s+)`)
+	engineRe         = regexp.MustCompile(`ENGINE = (\w*)MergeTree\(([^)]*)\)`)
+	fromTableRe      = regexp.MustCompile(`(\bFROM nginx_analytics\.)(\w+)`)
+	// ALTERs that change columns apply to the Distributed table too; the rest only to the shards
+	alterColumnsRe = regexp.MustCompile(`^\s*(ADD|DROP|MODIFY|RENAME|COMMENT) COLUMN\b`)
+	// Columns sharding keeps together, in order of preference: one agent's rows stay on one shard
+	shardingColumns = []string{"instance_id", "agent_id", "gateway_id", "check_id"}
+)
+
+// onCluster is the ON CLUSTER clause, "" on a single node
+func (t clickHouseTopology) onCluster() string {
+	if t.Cluster == "" {
+		return ""
+	}
+	return " ON CLUSTER '" + t.Cluster + "'"
+}
+
+// local returns the table rows of a table are stored in
+func (t clickHouseTopology) local(table string) string {
+	if t.Distributed {
+		return table + localTableSuffix
+	}
+	return table
+}
+
+// validate checks the options make sense together
+func (t clickHouseTopology) validate() error {
+	if t.Cluster == "" && (t.Replicated || t.Distributed) {
+		return fmt.Errorf("clickhouse.cluster is required for replicated or distributed tables")
+	}
+	if t.Cluster != "" && !regexp.MustCompile(`^[A-Za-z0-9_.-]+$`).MatchString(t.Cluster) {
+		return fmt.Errorf("invalid clickhouse.cluster %q", t.Cluster)
+	}
+	if strings.ContainsAny(t.ReplicationPath, "'\\") {
+		return fmt.Errorf("invalid clickhouse.replication_path %q", t.ReplicationPath)
+	}
+	return nil
+}
+
+// ddl rewrites a single-node schema statement into the statements that apply it on the topology, in
+// order. Statements it does not know, and every statement on a single node, are returned as they are.
+func (t clickHouseTopology) ddl(q string) []string {
+	if t.Cluster == "" {
+		return []string{q}
+	}
+	on := t.onCluster()
+	switch {
+	case createDatabaseRe.MatchString(q):
+		return []string{createDatabaseRe.ReplaceAllString(q, "${1}"+on)}
+
+	case createTableRe.MatchString(q):
+		m := createTableRe.FindStringSubmatch(q)
+		table := m[2]
+		head := m[1] + t.local(table) + on
+		if m[4] != "" {
+			head += " AS nginx_analytics." + t.local(m[4])
+		}
+		create := head + q[len(m[0]):]
+		if t.Replicated {
+			create = engineRe.ReplaceAllStringFunc(create, t.replicatedEngine)
+		}
+		if !t.Distributed {
+			return []string{create}
+		}
+		return []string{create, fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS nginx_analytics.%s%s AS nginx_analytics.%s ENGINE = Distributed('%s', nginx_analytics, %s, %s)",
+			table, on, t.local(table), t.Cluster, t.local(table), shardingKey(q))}
+
+	case createViewRe.MatchString(q):
+		// Each node's view fills its shard of the target from its shard of the source
+		q = createViewRe.ReplaceAllString(q, "${1}"+on+"${2}"+t.local("${3}"))
+		if t.Distributed {
+			q = fromTableRe.ReplaceAllString(q, "${1}${2}"+localTableSuffix)
+		}
+		return []string{q}
+
+	case dropViewRe.MatchString(q):
+		return []string{dropViewRe.ReplaceAllString(q, "${1}"+on)}
+
+	case alterTableRe.MatchString(q):
+		m := alterTableRe.FindStringSubmatch(q)
+		rest := q[len(m[0]):]
+		alter := m[1] + t.local(m[2]) + on + m[3] + rest
+		if t.Distributed && alterColumnsRe.MatchString(rest) {
+			return []string{alter, m[1] + m[2] + on + m[3] + rest}
+		}
+		return []string{alter}
+	}
+	return []string{q}
+}
+
+// replicatedEngine turns a MergeTree engine into its Replicated variant, keeping its parameters
+func (t clickHouseTopology) replicatedEngine(engine string) string {
+	m := engineRe.FindStringSubmatch(engine)
+	path := t.ReplicationPath
+	if path == "" {
+		path = defaultReplicationPath
+	}
+	args := fmt.Sprintf("'%s', '{replica}'", path)
+	if params := strings.TrimSpace(m[2]); params != "" {
+		args += ", " + params
+	}
+	return fmt.Sprintf("ENGINE = Replicated%sMergeTree(%s)", m[1], args)
+}
+
+// shardingKey picks the sharding key of a table from the columns of its CREATE statement
+func shardingKey(create string) string {
+	for _, col := range shardingColumns {
+		if regexp.MustCompile(`\b` + col + `\s+(LowCardinality\()?String`).MatchString(create) {
+			return "cityHash64(" + col + ")"
+		}
+	}
+	return "rand()"
+}
+
+// execDDL runs a schema statement, or the statements it becomes on the topology, stopping at the
+// first that fails
+func (db *ClickHouseDB) execDDL(ctx context.Context, q string, args ...interface{}) error {
+	for _, stmt := range db.topology.ddl(q) {
+		if err := db.conn.Exec(ctx, stmt, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableOf returns the table a shard-local table stores the rows of; other names are returned as
+// they are
+func (db *ClickHouseDB) tableOf(name string) string {
+	if db.topology.Distributed {
+		return strings.TrimSuffix(name, localTableSuffix)
+	}
+	return name
+}
+
+// systemTable is a system table over the cluster, one replica of each shard, or the node's own
+func (db *ClickHouseDB) systemTable(name string) string {
+	if db.topology.Cluster == "" {
+		return "system." + name
+	}
+	return fmt.Sprintf("cluster('%s', system.%s)", db.topology.Cluster, name)
+}
+
+// checkTopology warns when tables from a single-node install stand where the topology expects
+// Distributed tables; CREATE TABLE IF NOT EXISTS leaves them in place, unsharded
+func (db *ClickHouseDB) checkTopology(ctx context.Context) {
+	if !db.topology.Distributed {
+		return
+	}
+	var engine string
+	if err := db.conn.QueryRow(ctx, `
+		SELECT engine FROM system.tables WHERE database = 'nginx_analytics' AND name = 'access_logs'`).Scan(&engine); err != nil {
+		return
+	}
+	if engine != "Distributed" {
+		analyticsLog.Warn().Msgf("nginx_analytics.access_logs is a %s table, not Distributed; tables created before clickhouse.distributed was set must be moved to the *_local tables by hand (see docs/CLICKHOUSE_CLUSTER.md)", engine)
+	}
+}
+
+// splitConn sends reads (Query, QueryRow, Select) to a separate pool, such as replicas that take no
+// inserts, and everything else to the write pool
+type splitConn struct {
+	driver.Conn
+	reads driver.Conn
+}
+
+func (c *splitConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	return c.reads.Query(ctx, query, args...)
+}
+
+func (c *splitConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	return c.reads.QueryRow(ctx, query, args...)
+}
+
+func (c *splitConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return c.reads.Select(ctx, dest, query, args...)
+}
+
+func (c *splitConn) Close() error {
+	rerr := c.reads.Close()
+	if err := c.Conn.Close(); err != nil {
+		return err
+	}
+	return rerr
+}
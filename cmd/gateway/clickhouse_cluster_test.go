@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClickHouseTopologyDDL(t *testing.T) {
+	create := `CREATE TABLE IF NOT EXISTS nginx_analytics.requests_1m (
+			ts DateTime,
+			instance_id LowCardinality(String),
+			requests UInt64
+		) ENGINE = SummingMergeTree()
+		ORDER BY (instance_id, ts)`
+
+	single := clickHouseTopology{}
+	if got := single.ddl(create); len(got) != 1 || got[0] != create {
+		t.Errorf("a single node runs statements as they are, got %q", got)
+	}
+
+	replicated := clickHouseTopology{Cluster: "avika", Replicated: true}
+	got := replicated.ddl(create)
+	if len(got) != 1 || !strings.Contains(got[0], "nginx_analytics.requests_1m ON CLUSTER 'avika' (") ||
+		!strings.Contains(got[0], "ENGINE = ReplicatedSummingMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')") {
+		t.Errorf("replicated create = %q", got)
+	}
+
+	sharded := clickHouseTopology{Cluster: "avika", Replicated: true, Distributed: true}
+	got = sharded.ddl(create)
+	if len(got) != 2 || !strings.Contains(got[0], "nginx_analytics.requests_1m_local ON CLUSTER 'avika' (") {
+		t.Fatalf("distributed create = %q", got)
+	}
+	if want := "CREATE TABLE IF NOT EXISTS nginx_analytics.requests_1m ON CLUSTER 'avika' AS nginx_analytics.requests_1m_local ENGINE = Distributed('avika', nginx_analytics, requests_1m_local, cityHash64(instance_id))"; got[1] != want {
+		t.Errorf("distributed table = %q, want %q", got[1], want)
+	}
+
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{
+			"CREATE DATABASE IF NOT EXISTS nginx_analytics",
+			[]string{"CREATE DATABASE IF NOT EXISTS nginx_analytics ON CLUSTER 'avika'"},
+		},
+		{
+			"CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs_restored AS nginx_analytics.access_logs\n\t\tENGINE = MergeTree()\n\t\tORDER BY (instance_id, timestamp)",
+			[]string{
+				"CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs_restored_local ON CLUSTER 'avika' AS nginx_analytics.access_logs_local\n\t\tENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')\n\t\tORDER BY (instance_id, timestamp)",
+				"CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs_restored ON CLUSTER 'avika' AS nginx_analytics.access_logs_restored_local ENGINE = Distributed('avika', nginx_analytics, access_logs_restored_local, rand())",
+			},
+		},
+		{
+			"CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.requests_1m_mv TO nginx_analytics.requests_1m AS SELECT ts FROM nginx_analytics.access_logs WHERE status > 0",
+			[]string{"CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.requests_1m_mv ON CLUSTER 'avika' TO nginx_analytics.requests_1m_local AS SELECT ts FROM nginx_analytics.access_logs_local WHERE status > 0"},
+		},
+		{
+			"DROP VIEW IF EXISTS nginx_analytics.requests_1m_mv",
+			[]string{"DROP VIEW IF EXISTS nginx_analytics.requests_1m_mv ON CLUSTER 'avika'"},
+		},
+		{
+			"ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS project_id LowCardinality(String) DEFAULT ''",
+			[]string{
+				"ALTER TABLE nginx_analytics.access_logs_local ON CLUSTER 'avika' ADD COLUMN IF NOT EXISTS project_id LowCardinality(String) DEFAULT ''",
+				"ALTER TABLE nginx_analytics.access_logs ON CLUSTER 'avika' ADD COLUMN IF NOT EXISTS project_id LowCardinality(String) DEFAULT ''",
+			},
+		},
+		{
+			"ALTER TABLE nginx_analytics.access_logs MODIFY TTL toDateTime(timestamp) + INTERVAL 7 DAY",
+			[]string{"ALTER TABLE nginx_analytics.access_logs_local ON CLUSTER 'avika' MODIFY TTL toDateTime(timestamp) + INTERVAL 7 DAY"},
+		},
+		{
+			"\n\t\tALTER TABLE nginx_analytics.spans UPDATE project_id = ? WHERE instance_id IN (?)",
+			[]string{"\n\t\tALTER TABLE nginx_analytics.spans_local ON CLUSTER 'avika' UPDATE project_id = ? WHERE instance_id IN (?)"},
+		},
+		{
+			"INSERT INTO nginx_analytics.requests_1m SELECT 1",
+			[]string{"INSERT INTO nginx_analytics.requests_1m SELECT 1"},
+		},
+	}
+	for _, tt := range tests {
+		if got := sharded.ddl(tt.q); strings.Join(got, "\n;\n") != strings.Join(tt.want, "\n;\n") {
+			t.Errorf("ddl(%q) =\n%q\nwant\n%q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestClickHouseTopologyValidate(t *testing.T) {
+	for _, tc := range []struct {
+		topology clickHouseTopology
+		ok       bool
+	}{
+		{clickHouseTopology{}, true},
+		{clickHouseTopology{Cluster: "avika", Replicated: true, Distributed: true}, true},
+		{clickHouseTopology{Distributed: true}, false},
+		{clickHouseTopology{Cluster: "avika'; DROP"}, false},
+		{clickHouseTopology{Cluster: "avika", Replicated: true, ReplicationPath: "/ch/'x"}, false},
+	} {
+		if err := tc.topology.validate(); (err == nil) != tc.ok {
+			t.Errorf("%+v: err = %v", tc.topology, err)
+		}
+	}
+}
+
+func TestClickHouseAddrs(t *testing.T) {
+	if got := clickHouseAddrs(" ch-0:9000, ch-1:9000 ,,"); strings.Join(got, "|") != "ch-0:9000|ch-1:9000" {
+		t.Errorf("addrs = %q", got)
+	}
+	if got := clickHouseAddrs(""); got != nil {
+		t.Errorf("no addresses = %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// diskUsageWindow is how recent a disk or log file sample must be to be reported as current
+const diskUsageWindow = 15 * time.Minute
+
+// DiskUsage is the latest usage of one of an agent's disks. Disks holding nginx logs carry the
+// growth of those logs and, while they grow, an estimate of when the disk fills up.
+type DiskUsage struct {
+	Mount                string    `json:"mount"`
+	Device               string    `json:"device"`
+	Fstype               string    `json:"fstype"`
+	TotalBytes           uint64    `json:"total_bytes"`
+	UsedBytes            uint64    `json:"used_bytes"`
+	AvailableBytes       uint64    `json:"available_bytes"`
+	UsedPercent          float64   `json:"used_percent"`
+	InodesUsedPercent    float64   `json:"inodes_used_percent"`
+	NginxLogs            bool      `json:"nginx_logs"`
+	LogGrowthBytesPerSec float64   `json:"log_growth_bytes_per_sec,omitempty"`
+	HoursUntilFull       *float64  `json:"hours_until_full,omitempty"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// LogFileUsage is the latest size of an nginx log file and its average growth
+type LogFileUsage struct {
+	Path              string    `json:"path"`
+	Mount             string    `json:"mount"`
+	SizeBytes         uint64    `json:"size_bytes"`
+	GrowthBytesPerSec float64   `json:"growth_bytes_per_sec"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// flushDisks stores the disks and log files reported with a batch of system metrics
+func (db *ClickHouseDB) flushDisks(ctx context.Context, batch []sysBatchItem) {
+	var disks, logs driver.Batch
+	now := time.Now()
+	for _, item := range batch {
+		tenant := db.tenantOf(item.agentID)
+		for _, d := range item.entry.Disks {
+			if disks == nil {
+				var err error
+				if disks, err = db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.disk_usage (
+					timestamp, instance_id, mount, device, fstype, total_bytes, used_bytes, available_bytes,
+					used_percent, inodes_used_percent, nginx_logs, project_id, environment_id
+				)`); err != nil {
+					analyticsLog.Error().Msgf("Failed to prepare disk usage batch: %v", err)
+					db.insertFailed("disk_usage")
+					return
+				}
+			}
+			var nginxLogs uint8
+			if d.NginxLogs {
+				nginxLogs = 1
+			}
+			if err := disks.Append(now, item.agentID, d.Mount, d.Device, d.Fstype, d.TotalBytes, d.UsedBytes, d.AvailableBytes,
+				d.UsedPercent, d.InodesUsedPercent, nginxLogs, tenant.ProjectID, tenant.EnvironmentID); err != nil {
+				analyticsLog.Error().Msgf("Failed to append disk usage: %v", err)
+				db.insertFailed("disk_usage")
+				return
+			}
+		}
+		for _, f := range item.entry.LogFiles {
+			if logs == nil {
+				var err error
+				if logs, err = db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.log_files (
+					timestamp, instance_id, path, mount, size_bytes, growth_rate, project_id, environment_id
+				)`); err != nil {
+					analyticsLog.Error().Msgf("Failed to prepare log files batch: %v", err)
+					db.insertFailed("log_files")
+					return
+				}
+			}
+			if err := logs.Append(now, item.agentID, f.Path, f.Mount, f.SizeBytes, f.GrowthBytesPerSec, tenant.ProjectID, tenant.EnvironmentID); err != nil {
+				analyticsLog.Error().Msgf("Failed to append log file: %v", err)
+				db.insertFailed("log_files")
+				return
+			}
+		}
+	}
+	if disks != nil {
+		if err := disks.Send(); err != nil {
+			analyticsLog.Error().Msgf("Failed to send disk usage batch: %v", err)
+			db.insertFailed("disk_usage")
+		}
+	}
+	if logs != nil {
+		if err := logs.Send(); err != nil {
+			analyticsLog.Error().Msgf("Failed to send log files batch: %v", err)
+			db.insertFailed("log_files")
+		}
+	}
+}
+
+// GetDiskUsage returns the disks and nginx log files an agent reported recently
+func (db *ClickHouseDB) GetDiskUsage(ctx context.Context, agentID string) ([]DiskUsage, []LogFileUsage, error) {
+	since := time.Now().Add(-diskUsageWindow)
+	rows, err := db.conn.Query(ctx, `
+		SELECT mount, argMax(device, timestamp), argMax(fstype, timestamp), argMax(total_bytes, timestamp),
+			argMax(used_bytes, timestamp), argMax(available_bytes, timestamp), argMax(used_percent, timestamp),
+			argMax(inodes_used_percent, timestamp), argMax(nginx_logs, timestamp), max(timestamp)
+		FROM nginx_analytics.disk_usage
+		WHERE instance_id = ? AND timestamp >= ?
+		GROUP BY mount
+		ORDER BY mount`, agentID, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	disks := []DiskUsage{}
+	for rows.Next() {
+		var d DiskUsage
+		var usedPercent, inodesPercent float32
+		var nginxLogs uint8
+		if err := rows.Scan(&d.Mount, &d.Device, &d.Fstype, &d.TotalBytes, &d.UsedBytes, &d.AvailableBytes,
+			&usedPercent, &inodesPercent, &nginxLogs, &d.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		d.UsedPercent, d.InodesUsedPercent, d.NginxLogs = float64(usedPercent), float64(inodesPercent), nginxLogs == 1
+		disks = append(disks, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = db.conn.Query(ctx, `
+		SELECT path, argMax(mount, timestamp), argMax(size_bytes, timestamp), avg(growth_rate), max(timestamp)
+		FROM nginx_analytics.log_files
+		WHERE instance_id = ? AND timestamp >= ?
+		GROUP BY path
+		ORDER BY path`, agentID, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	logs := []LogFileUsage{}
+	for rows.Next() {
+		var f LogFileUsage
+		if err := rows.Scan(&f.Path, &f.Mount, &f.SizeBytes, &f.GrowthBytesPerSec, &f.UpdatedAt); err != nil {
+			return nil, nil, err
+		}
+		logs = append(logs, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	annotateLogGrowth(disks, logs)
+	return disks, logs, nil
+}
+
+// annotateLogGrowth adds the growth of the log files on each disk and, for growing ones, the
+// hours until the disk is full at that rate
+func annotateLogGrowth(disks []DiskUsage, logs []LogFileUsage) {
+	for i := range disks {
+		d := &disks[i]
+		for _, f := range logs {
+			if f.Mount == d.Mount && f.GrowthBytesPerSec > 0 {
+				d.LogGrowthBytesPerSec += f.GrowthBytesPerSec
+			}
+		}
+		if d.LogGrowthBytesPerSec > 0 {
+			hours := float64(d.AvailableBytes) / d.LogGrowthBytesPerSec / 3600
+			d.HoursUntilFull = &hours
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestAnnotateLogGrowth(t *testing.T) {
+	disks := []DiskUsage{
+		{Mount: "/", AvailableBytes: 100 << 30},
+		{Mount: "/var/log", AvailableBytes: 36 << 20, NginxLogs: true},
+	}
+	logs := []LogFileUsage{
+		{Path: "/var/log/nginx/access.log", Mount: "/var/log", GrowthBytesPerSec: 8192},
+		{Path: "/var/log/nginx/error.log", Mount: "/var/log", GrowthBytesPerSec: 2048},
+		{Path: "/var/log/nginx/old.log", Mount: "/var/log", GrowthBytesPerSec: 0},
+	}
+	annotateLogGrowth(disks, logs)
+	if disks[0].LogGrowthBytesPerSec != 0 || disks[0].HoursUntilFull != nil {
+		t.Errorf("root disk = %+v", disks[0])
+	}
+	d := disks[1]
+	if d.LogGrowthBytesPerSec != 10240 || d.HoursUntilFull == nil {
+		t.Fatalf("log disk = %+v", d)
+	}
+	if h := *d.HoursUntilFull; h < 1.02 || h > 1.03 {
+		t.Errorf("hours until full = %v, want about 1.02", h)
+	}
+}
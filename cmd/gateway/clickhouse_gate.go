@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ingestionConnReserve is the number of pool connections kept free of reads for the batch
+// flushers, one per flusher goroutine started by NewClickHouseDB
+const ingestionConnReserve = 8
+
+// Read concurrency (configurable via environment)
+var (
+	maxConcurrentQueries = getEnvInt("CH_MAX_CONCURRENT_QUERIES", max(1, maxOpenConns-ingestionConnReserve))
+	maxQueuedQueries     = getEnvInt("CH_MAX_QUEUED_QUERIES", 200)
+	queryQueueTimeout    = time.Duration(getEnvInt("CH_QUERY_QUEUE_TIMEOUT_MS", 10000)) * time.Millisecond
+)
+
+// errClickHouseBusy is returned, wrapped, for reads rejected by the query gate
+var errClickHouseBusy = errors.New("clickhouse is busy, retry shortly")
+
+// clickHouseErrorStatus returns the HTTP status of a failed ClickHouse read: 503 with a
+// Retry-After when the query gate rejected it, 500 otherwise
+func clickHouseErrorStatus(w http.ResponseWriter, err error) int {
+	if errors.Is(err, errClickHouseBusy) {
+		w.Header().Set("Retry-After", "5")
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+var (
+	avikaClickHouseQueriesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "avika_clickhouse_queries_in_flight",
+		Help: "ClickHouse reads holding a slot of the query gate",
+	})
+	avikaClickHouseQueryQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "avika_clickhouse_query_queue_depth",
+		Help: "ClickHouse reads waiting for a slot of the query gate",
+	})
+	avikaClickHouseQueryWaitSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "avika_clickhouse_query_wait_seconds",
+		Help:    "Time ClickHouse reads waited for a slot of the query gate",
+		Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10},
+	})
+	avikaClickHouseQueryRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "avika_clickhouse_query_rejections_total",
+		Help: "ClickHouse reads rejected by the query gate, by reason: queue_full or timeout",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(avikaClickHouseQueriesInFlight, avikaClickHouseQueryQueueDepth,
+		avikaClickHouseQueryWaitSeconds, avikaClickHouseQueryRejections)
+}
+
+// gatedConn limits concurrent reads (Query, QueryRow, Select) on a ClickHouse connection pool so
+// dashboard spikes queue instead of taking every connection from the ingestion batchers. Reads
+// beyond the queue, or waiting longer than the queue timeout, fail with errClickHouseBusy.
+// Writes go straight to the pool.
+type gatedConn struct {
+	driver.Conn
+	slots     chan struct{}
+	queued    atomic.Int64
+	maxQueued int64
+	timeout   time.Duration
+}
+
+func newGatedConn(conn driver.Conn, concurrency, queued int, timeout time.Duration) *gatedConn {
+	return &gatedConn{
+		Conn:      conn,
+		slots:     make(chan struct{}, max(1, concurrency)),
+		maxQueued: int64(queued),
+		timeout:   timeout,
+	}
+}
+
+// acquire waits for a read slot; the returned func releases it
+func (g *gatedConn) acquire(ctx context.Context) (func(), error) {
+	select {
+	case g.slots <- struct{}{}:
+		avikaClickHouseQueryWaitSeconds.Observe(0)
+		return g.releaser(), nil
+	default:
+	}
+	if g.queued.Add(1) > g.maxQueued {
+		g.queued.Add(-1)
+		avikaClickHouseQueryRejections.WithLabelValues("queue_full").Inc()
+		return nil, fmt.Errorf("%w: %d reads already queued", errClickHouseBusy, g.maxQueued)
+	}
+	avikaClickHouseQueryQueueDepth.Inc()
+	defer func() {
+		g.queued.Add(-1)
+		avikaClickHouseQueryQueueDepth.Dec()
+	}()
+
+	start := time.Now()
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case g.slots <- struct{}{}:
+		avikaClickHouseQueryWaitSeconds.Observe(time.Since(start).Seconds())
+		return g.releaser(), nil
+	case <-timer.C:
+		avikaClickHouseQueryRejections.WithLabelValues("timeout").Inc()
+		return nil, fmt.Errorf("%w: no read slot within %s", errClickHouseBusy, g.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *gatedConn) releaser() func() {
+	avikaClickHouseQueriesInFlight.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-g.slots
+			avikaClickHouseQueriesInFlight.Dec()
+		})
+	}
+}
+
+func (g *gatedConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	release, err := g.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.Conn.Query(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// The connection stays busy until the rows are drained or closed
+	return &gatedRows{Rows: rows, release: release}, nil
+}
+
+func (g *gatedConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	release, err := g.acquire(ctx)
+	if err != nil {
+		return rejectedRow{err: err}
+	}
+	// The connection stays busy until the row is scanned
+	return &gatedRow{Row: g.Conn.QueryRow(ctx, query, args...), release: release}
+}
+
+func (g *gatedConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	release, err := g.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return g.Conn.Select(ctx, dest, query, args...)
+}
+
+// gatedRows releases the read slot when the rows are closed, explicitly or by Next reaching the end
+type gatedRows struct {
+	driver.Rows
+	release func()
+}
+
+func (r *gatedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r *gatedRows) Close() error {
+	defer r.release()
+	return r.Rows.Close()
+}
+
+// gatedRow releases the read slot once scanned, or when the query failed
+type gatedRow struct {
+	driver.Row
+	release func()
+}
+
+func (r *gatedRow) Err() error {
+	err := r.Row.Err()
+	if err != nil {
+		r.release()
+	}
+	return err
+}
+
+func (r *gatedRow) Scan(dest ...any) error {
+	defer r.release()
+	return r.Row.Scan(dest...)
+}
+
+func (r *gatedRow) ScanStruct(dest any) error {
+	defer r.release()
+	return r.Row.ScanStruct(dest)
+}
+
+// rejectedRow is the result of a QueryRow rejected by the gate
+type rejectedRow struct{ err error }
+
+func (r rejectedRow) Err() error           { return r.err }
+func (r rejectedRow) Scan(...any) error    { return r.err }
+func (r rejectedRow) ScanStruct(any) error { return r.err }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// slowConn is a driver.Conn whose reads block until released
+type slowConn struct {
+	driver.Conn
+	release chan struct{}
+	active  atomic.Int32
+	peak    atomic.Int32
+}
+
+func (c *slowConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	n := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		p := c.peak.Load()
+		if n <= p || c.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	<-c.release
+	return nil
+}
+
+func (c *slowConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{ driver.Rows }
+
+func (emptyRows) Next() bool   { return false }
+func (emptyRows) Close() error { return nil }
+
+func TestGatedConnLimitsConcurrency(t *testing.T) {
+	conn := &slowConn{release: make(chan struct{})}
+	g := newGatedConn(conn, 2, 10, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.Select(context.Background(), nil, "SELECT 1"); err != nil {
+				t.Errorf("select: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(conn.release)
+	wg.Wait()
+	if p := conn.peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+}
+
+func TestGatedConnRejects(t *testing.T) {
+	conn := &slowConn{release: make(chan struct{})}
+	defer close(conn.release)
+	g := newGatedConn(conn, 1, 1, 50*time.Millisecond)
+
+	go g.Select(context.Background(), nil, "SELECT 1") // holds the only slot
+	time.Sleep(10 * time.Millisecond)
+	queued := make(chan error)
+	go func() { queued <- g.Select(context.Background(), nil, "SELECT 1") }()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := g.Select(context.Background(), nil, "SELECT 1"); !errors.Is(err, errClickHouseBusy) {
+		t.Errorf("full queue: err = %v", err)
+	}
+	if err := <-queued; !errors.Is(err, errClickHouseBusy) {
+		t.Errorf("queue timeout: err = %v", err)
+	}
+	w := httptest.NewRecorder()
+	if clickHouseErrorStatus(w, errClickHouseBusy) != 503 || w.Header().Get("Retry-After") == "" {
+		t.Error("busy reads should map to 503 with Retry-After")
+	}
+}
+
+func TestGatedRowsReleaseWhenDrained(t *testing.T) {
+	g := newGatedConn(&slowConn{}, 1, 0, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		rows, err := g.Query(context.Background(), "SELECT 1")
+		if err != nil {
+			t.Fatalf("query %d: %v", i, err)
+		}
+		for rows.Next() {
+		}
+	}
+	rows, _ := g.Query(context.Background(), "SELECT 1")
+	rows.Close()
+	rows.Close()
+	if len(g.slots) != 0 {
+		t.Error("closing twice must release once")
+	}
+}
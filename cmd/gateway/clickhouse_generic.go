@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+type genericMetricItem struct {
+	metric    *pb.GenericMetric
+	agentID   string
+	timestamp time.Time
+}
+
+// GenericMetricInfo describes one metric reported by agents' custom collectors
+type GenericMetricInfo struct {
+	Source    string    `json:"source"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Agents    uint64    `json:"agents"`
+	Series    uint64    `json:"series"`
+	LastValue float64   `json:"last_value"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// GenericSeries is one label set of a custom metric on one agent
+type GenericSeries struct {
+	AgentID string               `json:"agent_id"`
+	Labels  map[string]string    `json:"labels"`
+	Points  []GenericSeriesPoint `json:"points"`
+}
+
+type GenericSeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"` // average per bucket; the last value for counters
+}
+
+// InsertGenericMetrics queues custom collector samples for batched insertion. Samples without a
+// timestamp of their own take the message's.
+func (db *ClickHouseDB) InsertGenericMetrics(metrics []*pb.GenericMetric, agentID string, received time.Time) {
+	for _, m := range metrics {
+		ts := received
+		if m.TimestampMs > 0 {
+			ts = time.UnixMilli(m.TimestampMs)
+		}
+		select {
+		case db.genChan <- genericMetricItem{metric: m, agentID: agentID, timestamp: ts}:
+		default:
+			writeLog.Warn().Msgf("Generic metric queue full, dropping %d samples from agent %s", len(metrics), agentID)
+			return
+		}
+	}
+}
+
+func (db *ClickHouseDB) runGenericFlusher() {
+	ticker := time.NewTicker(5 * time.Second)
+	batch := make([]genericMetricItem, 0, 5000)
+	for {
+		select {
+		case item := <-db.genChan:
+			batch = append(batch, item)
+			if len(batch) >= 5000 {
+				db.flushGenericMetrics(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				db.flushGenericMetrics(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (db *ClickHouseDB) flushGenericMetrics(batch []genericMetricItem) {
+	ctx := context.Background()
+	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.generic_metrics (
+		timestamp, instance_id, source, name, type, value, labels, project_id, environment_id
+	)`)
+	if err != nil {
+		analyticsLog.Error().Msgf("flushGenericMetrics: PrepareBatch failed: %v", err)
+		db.insertFailed("generic_metrics")
+		return
+	}
+	for _, item := range batch {
+		m := item.metric
+		labels := m.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		tenant := db.tenantOf(item.agentID)
+		if err := b.Append(item.timestamp, item.agentID, m.Source, m.Name, m.Type, m.Value, labels, tenant.ProjectID, tenant.EnvironmentID); err != nil {
+			analyticsLog.Error().Msgf("flushGenericMetrics: Append failed: %v", err)
+			db.insertFailed("generic_metrics")
+			return
+		}
+	}
+	if err := b.Send(); err != nil {
+		analyticsLog.Error().Msgf("flushGenericMetrics: Send failed: %v", err)
+		db.insertFailed("generic_metrics")
+	}
+}
+
+// ListGenericMetrics returns the custom metrics reported within the window, by source and name
+func (db *ClickHouseDB) ListGenericMetrics(ctx context.Context, start, end time.Time, agentIDs []string) ([]GenericMetricInfo, error) {
+	where, args := "WHERE timestamp >= ? AND timestamp <= ?", []interface{}{start, end}
+	if len(agentIDs) > 0 {
+		where += " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT source, name, anyLast(type), uniq(instance_id), uniq(instance_id, labels),
+			argMax(value, timestamp), max(timestamp)
+		FROM nginx_analytics.generic_metrics
+		%s
+		GROUP BY source, name
+		ORDER BY source, name
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []GenericMetricInfo{}
+	for rows.Next() {
+		var m GenericMetricInfo
+		if err := rows.Scan(&m.Source, &m.Name, &m.Type, &m.Agents, &m.Series, &m.LastValue, &m.LastSeen); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// genericSeriesStep picks a bucket size giving a few hundred points over the window
+func genericSeriesStep(start, end time.Time) time.Duration {
+	step := end.Sub(start) / 240
+	if step < time.Minute {
+		return time.Minute
+	}
+	return step.Round(time.Minute)
+}
+
+// GetGenericMetricSeries returns a custom metric bucketed over the window, one series per agent
+// and label set. labels restricts the series to those with the given label values.
+func (db *ClickHouseDB) GetGenericMetricSeries(ctx context.Context, source, name string, labels map[string]string, start, end time.Time, agentIDs []string) ([]GenericSeries, error) {
+	where := "WHERE source = ? AND name = ? AND timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{source, name, start, end}
+	if len(agentIDs) > 0 {
+		where += " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+	for _, k := range sortedKeys(labels) {
+		where += " AND labels[?] = ?"
+		args = append(args, k, labels[k])
+	}
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT instance_id, labels, toStartOfInterval(timestamp, INTERVAL %d SECOND) AS bucket,
+			if(any(type) = 'counter', argMax(value, timestamp), avg(value))
+		FROM nginx_analytics.generic_metrics
+		%s
+		GROUP BY instance_id, labels, bucket
+		ORDER BY instance_id, bucket
+	`, int(genericSeriesStep(start, end).Seconds()), where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byKey := map[string]*GenericSeries{}
+	for rows.Next() {
+		var agentID string
+		var sampleLabels map[string]string
+		var p GenericSeriesPoint
+		if err := rows.Scan(&agentID, &sampleLabels, &p.Time, &p.Value); err != nil {
+			return nil, err
+		}
+		key := agentID + "\x00" + formatLabelSet(sampleLabels)
+		series, ok := byKey[key]
+		if !ok {
+			series = &GenericSeries{AgentID: agentID, Labels: sampleLabels}
+			byKey[key] = series
+		}
+		series.Points = append(series.Points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]GenericSeries, 0, len(byKey))
+	for _, key := range sortedSeriesKeys(byKey) {
+		result = append(result, *byKey[key])
+	}
+	return result, nil
+}
+
+// formatLabelSet renders labels as a stable {k="v",...} string
+func formatLabelSet(labels map[string]string) string {
+	s := "{"
+	for i, k := range sortedKeys(labels) {
+		if i > 0 {
+			s += ","
+		}
+		s += fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return s + "}"
+}
+
+func sortedSeriesKeys(m map[string]*GenericSeries) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenericSeriesStep(t *testing.T) {
+	end := time.Now()
+	for window, want := range map[time.Duration]time.Duration{
+		time.Hour:          time.Minute,
+		24 * time.Hour:     6 * time.Minute,
+		7 * 24 * time.Hour: 42 * time.Minute,
+	} {
+		if got := genericSeriesStep(end.Add(-window), end); got != want {
+			t.Errorf("step for %s = %s, want %s", window, got, want)
+		}
+	}
+}
+
+func TestFormatLabelSet(t *testing.T) {
+	a := formatLabelSet(map[string]string{"pool": "www", "host": `web "1"`})
+	b := formatLabelSet(map[string]string{"host": `web "1"`, "pool": "www"})
+	if a != b || a != `{host="web \"1\"",pool="www"}` {
+		t.Errorf("label sets = %s, %s", a, b)
+	}
+	if formatLabelSet(nil) != "{}" {
+		t.Error("empty label set")
+	}
+}
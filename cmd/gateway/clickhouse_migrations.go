@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/migrations"
+)
+
+// The ClickHouse schema is versioned like the PostgreSQL one: the embedded files of
+// migrations/clickhouse are applied once each, in order, and recorded in
+// nginx_analytics.schema_migrations. Their statements go through execDDL, so they are written for a
+// single node and rewritten for clusters. ClickHouse has no transactions: a migration whose
+// statements fail part way is not recorded and runs again from the start on the next startup, so
+// its statements must be idempotent (IF NOT EXISTS, IF EXISTS). Gateways starting together may
+// both run a pending migration for the same reason.
+
+// ensureMigrationsTable creates the database and the table migrations are recorded in
+func (db *ClickHouseDB) ensureMigrationsTable(ctx context.Context) error {
+	for _, q := range []string{
+		"CREATE DATABASE IF NOT EXISTS nginx_analytics",
+		`CREATE TABLE IF NOT EXISTS nginx_analytics.schema_migrations (
+			version String,
+			name String,
+			checksum String,
+			applied_at DateTime DEFAULT now()
+		) ENGINE = ReplacingMergeTree(applied_at)
+		ORDER BY version`,
+	} {
+		if err := db.execDDL(ctx, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the migrations recorded as applied, by version
+func (db *ClickHouseDB) appliedMigrations(ctx context.Context) (map[string]migrations.Applied, error) {
+	rows, err := db.conn.Query(ctx, `
+		SELECT version, any(name), any(checksum), min(applied_at)
+		FROM nginx_analytics.schema_migrations
+		GROUP BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[string]migrations.Applied{}
+	for rows.Next() {
+		var a migrations.Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.Version] = a
+	}
+	return applied, rows.Err()
+}
+
+// runMigrations applies the pending migrations in version order. Every statement of a migration
+// runs even when one fails, as the schema statements always have, but a migration with a failed
+// statement is not recorded and the ones after it wait for the next startup.
+func (db *ClickHouseDB) runMigrations(ctx context.Context) error {
+	list, err := migrations.ClickHouse()
+	if err != nil {
+		return err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range list {
+		if a, ok := applied[m.Version]; ok {
+			if a.Checksum != m.Checksum {
+				analyticsLog.Warn().Msgf("ClickHouse migration %s (%s) was changed after it was applied; changes need a new migration", m.Version, m.Name)
+			}
+			continue
+		}
+		analyticsLog.Info().Msgf("Applying ClickHouse migration %s (%s)...", m.Version, m.Name)
+		var failed error
+		for _, q := range migrations.Statements(m.SQL) {
+			if err := db.execDDL(ctx, q); err != nil {
+				analyticsLog.Error().Msgf("ClickHouse migration query failed [%s]: %v", q, err)
+				if failed == nil {
+					failed = err
+				}
+			}
+		}
+		if failed != nil {
+			return fmt.Errorf("migration %s (%s): %w", m.Version, m.Name, failed)
+		}
+		if err := db.conn.Exec(ctx, `INSERT INTO nginx_analytics.schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+			m.Version, m.Name, m.Checksum, time.Now().UTC()); err != nil {
+			return fmt.Errorf("recording migration %s: %w", m.Version, err)
+		}
+		analyticsLog.Info().Msgf("ClickHouse migration %s applied", m.Version)
+	}
+	for _, s := range migrations.Report(list, applied) {
+		if s.Unknown {
+			analyticsLog.Warn().Msgf("ClickHouse migration %s (%s) was applied by a newer gateway; this one may not know its schema", s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus reports every embedded ClickHouse migration and whether it was applied
+func (db *ClickHouseDB) MigrationStatus(ctx context.Context) ([]migrations.Status, error) {
+	list, err := migrations.ClickHouse()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return migrations.Report(list, applied), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// queryTopASNs returns the autonomous systems sending the most requests.
+// whereClause must start with WHERE and is shared with the caller's other access_logs queries.
+func (db *ClickHouseDB) queryTopASNs(ctx context.Context, whereClause string, args []interface{}, limit int) ([]*pb.ASNStat, error) {
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			asn,
+			anyLast(isp) as organization,
+			count() as requests,
+			countIf(status >= 400) as errors,
+			countIf(is_bot = 1) as bot_requests,
+			sum(body_bytes_sent) as traffic
+		FROM nginx_analytics.access_logs
+		%s AND asn != 0
+		GROUP BY asn
+		ORDER BY requests DESC
+		LIMIT %d
+	`, whereClause, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*pb.ASNStat{}
+	for rows.Next() {
+		var asn uint32
+		var org string
+		var reqs, errs, bots, traffic uint64
+		if err := rows.Scan(&asn, &org, &reqs, &errs, &bots, &traffic); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &pb.ASNStat{
+			Asn:          asn,
+			Organization: org,
+			Requests:     int64(reqs),
+			Errors:       int64(errs),
+			BotRequests:  int64(bots),
+			Traffic:      traffic,
+		})
+	}
+	return stats, rows.Err()
+}
+
+// queryBotTraffic returns the share of automated requests broken down by bot category
+func (db *ClickHouseDB) queryBotTraffic(ctx context.Context, whereClause string, args []interface{}) (*pb.BotTraffic, error) {
+	var total, bots uint64
+	if err := db.conn.QueryRow(ctx, fmt.Sprintf(`
+		SELECT count(), countIf(is_bot = 1)
+		FROM nginx_analytics.access_logs
+		%s
+	`, whereClause), args...).Scan(&total, &bots); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.BotTraffic{
+		TotalRequests: int64(total),
+		BotRequests:   int64(bots),
+		Categories:    []*pb.BotCategoryStat{},
+	}
+	if total > 0 {
+		resp.BotShare = float32(float64(bots) / float64(total) * 100)
+	}
+	if bots == 0 {
+		return resp, nil
+	}
+
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			if(bot_category = '', 'other', bot_category) as category,
+			count() as requests
+		FROM nginx_analytics.access_logs
+		%s AND is_bot = 1
+		GROUP BY category
+		ORDER BY requests DESC
+	`, whereClause), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		var reqs uint64
+		if err := rows.Scan(&category, &reqs); err != nil {
+			return nil, err
+		}
+		resp.Categories = append(resp.Categories, &pb.BotCategoryStat{Category: category, Requests: int64(reqs)})
+	}
+	return resp, rows.Err()
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricQuery is a constrained analytics query: one metric, one aggregation, an optional
+// dimension and an optional time bucket. Only catalog entries are accepted, so user input is
+// never spliced into SQL.
+type MetricQuery struct {
+	Metric      string            `json:"metric"`
+	Aggregation string            `json:"aggregation"`
+	GroupBy     string            `json:"group_by,omitempty"`
+	Window      string            `json:"window,omitempty"`   // e.g. "1h", "24h", "7d"; ignored when from/to are set
+	From        int64             `json:"from,omitempty"`     // unix seconds
+	To          int64             `json:"to,omitempty"`       // unix seconds
+	Interval    string            `json:"interval,omitempty"` // time bucket ("1m", "5m", "1h", "1d", "auto"); empty for a single value per group
+	AgentIDs    []string          `json:"agent_ids,omitempty"`
+	Filters     map[string]string `json:"filters,omitempty"` // dimension => exact value
+	Limit       int               `json:"limit,omitempty"`   // max groups
+}
+
+// MetricPoint is one (bucket, value) pair; Timestamp is 0 when the query has no interval
+type MetricPoint struct {
+	Timestamp int64   `json:"t"`
+	Value     float64 `json:"v"`
+}
+
+// MetricSeries holds the points for one group-by value
+type MetricSeries struct {
+	Group  string        `json:"group,omitempty"`
+	Points []MetricPoint `json:"points"`
+}
+
+type MetricQueryResult struct {
+	Query  MetricQuery    `json:"query"`
+	From   int64          `json:"from"`
+	To     int64          `json:"to"`
+	Step   int64          `json:"step,omitempty"` // bucket size in seconds
+	Series []MetricSeries `json:"series"`
+}
+
+// metricDef describes a queryable metric. expr is the aggregated column; cond turns count/rate into countIf.
+type metricDef struct {
+	Table        string   `json:"table"`
+	Description  string   `json:"description"`
+	Unit         string   `json:"unit,omitempty"`
+	Aggregations []string `json:"aggregations"`
+	GroupBy      []string `json:"group_by"`
+	expr         string
+	cond         string
+}
+
+var (
+	accessLogDimensions = []string{"agent", "nginx_instance", "status", "status_class", "method", "uri", "upstream", "cache_status"}
+	agentDimensions     = []string{"agent"}
+	nginxDimensions     = []string{"agent", "nginx_instance"}
+	valueAggregations   = []string{"avg", "min", "max", "p50", "p90", "p95", "p99"}
+)
+
+// metricCatalog is the set of metrics exposed by the query API
+var metricCatalog = map[string]metricDef{
+	"requests":            {Table: "access_logs", Description: "HTTP requests", Aggregations: []string{"count", "rate"}, GroupBy: accessLogDimensions},
+	"errors":              {Table: "access_logs", Description: "Requests with status >= 400", Aggregations: []string{"count", "rate"}, GroupBy: accessLogDimensions, cond: "status >= 400"},
+	"server_errors":       {Table: "access_logs", Description: "Requests with status >= 500", Aggregations: []string{"count", "rate"}, GroupBy: accessLogDimensions, cond: "status >= 500"},
+	"error_rate":          {Table: "access_logs", Description: "Share of requests with status >= 400", Unit: "%", Aggregations: []string{"avg"}, GroupBy: accessLogDimensions, expr: "(status >= 400) * 100"},
+	"latency":             {Table: "access_logs", Description: "Request time", Unit: "ms", Aggregations: valueAggregations, GroupBy: accessLogDimensions, expr: "request_time * 1000"},
+	"upstream_latency":    {Table: "access_logs", Description: "Upstream response time", Unit: "ms", Aggregations: valueAggregations, GroupBy: accessLogDimensions, expr: "upstream_response_time * 1000"},
+	"bytes_sent":          {Table: "access_logs", Description: "Response body bytes", Unit: "bytes", Aggregations: []string{"sum", "avg", "max"}, GroupBy: accessLogDimensions, expr: "body_bytes_sent"},
+	"bytes_received":      {Table: "access_logs", Description: "Request bytes: line, headers and body", Unit: "bytes", Aggregations: []string{"sum", "avg", "max"}, GroupBy: accessLogDimensions, expr: "request_length"},
+	"visitors":            {Table: "access_logs", Description: "Distinct client addresses", Aggregations: []string{"uniq"}, GroupBy: accessLogDimensions, expr: "remote_addr"},
+	"cpu_usage":           {Table: "system_metrics", Description: "Host CPU usage", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "cpu_usage"},
+	"memory_usage":        {Table: "system_metrics", Description: "Host memory usage", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "memory_usage"},
+	"network_rx_rate":     {Table: "system_metrics", Description: "Network receive rate", Unit: "bytes/s", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "network_rx_rate"},
+	"network_tx_rate":     {Table: "system_metrics", Description: "Network transmit rate", Unit: "bytes/s", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "network_tx_rate"},
+	"fd_usage":            {Table: "system_metrics", Description: "Host file handles in use against fs.file-max", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "fd_used_percent"},
+	"nginx_fd_usage":      {Table: "system_metrics", Description: "Open files of the fullest NGINX process against its limit", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "nginx_fd_used_percent"},
+	"conntrack_usage":     {Table: "system_metrics", Description: "Connection tracking table fill", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "conntrack_used_percent"},
+	"active_connections":  {Table: "nginx_metrics", Description: "NGINX active connections", Aggregations: valueAggregations, GroupBy: nginxDimensions, expr: "active_connections"},
+	"requests_per_second": {Table: "nginx_metrics", Description: "NGINX requests per second", Aggregations: valueAggregations, GroupBy: nginxDimensions, expr: "requests_per_second"},
+	"worker_restarts":     {Table: "nginx_metrics", Description: "NGINX workers replaced (crashes, reloads and restarts)", Aggregations: []string{"sum", "max"}, GroupBy: nginxDimensions, expr: "worker_restarts"},
+	"worker_crashes":      {Table: "nginx_metrics", Description: "NGINX workers that exited on a signal or fatal error", Aggregations: []string{"sum", "max"}, GroupBy: nginxDimensions, expr: "worker_crashes"},
+	"service_restarts":    {Table: "nginx_metrics", Description: "Restarts of the NGINX systemd/OpenRC unit", Aggregations: []string{"sum", "max"}, GroupBy: nginxDimensions, expr: "service_restarts"},
+	"service_failures":    {Table: "nginx_metrics", Description: "Failures of the NGINX systemd/OpenRC unit", Aggregations: []string{"sum", "max"}, GroupBy: nginxDimensions, expr: "service_failures"},
+	"worker_cpu":          {Table: "nginx_workers", Description: "CPU usage of an NGINX worker process", Unit: "%", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "cpu_percent"},
+	"worker_rss":          {Table: "nginx_workers", Description: "Resident memory of an NGINX worker process", Unit: "bytes", Aggregations: valueAggregations, GroupBy: agentDimensions, expr: "rss_bytes"},
+}
+
+// metricDimensions maps group-by/filter keys to column expressions
+var metricDimensions = map[string]string{
+	"agent":          "instance_id",
+	"nginx_instance": "labels['nginx_instance']",
+	"status":         "toString(status)",
+	"status_class":   "concat(toString(intDiv(status, 100)), 'xx')",
+	"method":         "request_method",
+	"uri":            "request_uri",
+	"upstream":       "upstream_addr",
+	"cache_status":   "upstream_cache_status",
+}
+
+var metricWindows = map[string]time.Duration{
+	"5m": 5 * time.Minute, "15m": 15 * time.Minute, "1h": time.Hour, "3h": 3 * time.Hour,
+	"6h": 6 * time.Hour, "12h": 12 * time.Hour, "24h": 24 * time.Hour, "7d": 7 * 24 * time.Hour, "30d": 30 * 24 * time.Hour,
+}
+
+var metricIntervals = map[string]time.Duration{
+	"1m": time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute, "1h": time.Hour, "1d": 24 * time.Hour,
+}
+
+const (
+	metricMaxRange   = 90 * 24 * time.Hour
+	metricMaxBuckets = 1500
+	metricMaxGroups  = 100
+)
+
+// metricSQL is a validated, ready-to-run query
+type metricSQL struct {
+	SQL      string
+	Args     []interface{}
+	From, To time.Time
+	Step     time.Duration // 0 without an interval
+	Grouped  bool
+}
+
+// buildMetricQuery validates q against the catalog and produces the ClickHouse query.
+func buildMetricQuery(q MetricQuery, now time.Time) (*metricSQL, error) {
+	def, ok := metricCatalog[q.Metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", q.Metric)
+	}
+	if q.Aggregation == "" {
+		q.Aggregation = def.Aggregations[0]
+	}
+	if !stringInSlice(def.Aggregations, q.Aggregation) {
+		return nil, fmt.Errorf("aggregation %q is not supported for %s (use %s)", q.Aggregation, q.Metric, strings.Join(def.Aggregations, ", "))
+	}
+	if q.GroupBy != "" && !stringInSlice(def.GroupBy, q.GroupBy) {
+		return nil, fmt.Errorf("cannot group %s by %q (use %s)", q.Metric, q.GroupBy, strings.Join(def.GroupBy, ", "))
+	}
+
+	m := &metricSQL{Grouped: q.GroupBy != ""}
+	switch {
+	case q.From > 0:
+		m.From = time.Unix(q.From, 0).UTC()
+		m.To = now.UTC()
+		if q.To > 0 {
+			m.To = time.Unix(q.To, 0).UTC()
+		}
+	default:
+		window := q.Window
+		if window == "" {
+			window = "1h"
+		}
+		d, ok := metricWindows[window]
+		if !ok {
+			return nil, fmt.Errorf("unknown window %q", window)
+		}
+		m.To = now.UTC()
+		m.From = m.To.Add(-d)
+	}
+	if !m.To.After(m.From) {
+		return nil, fmt.Errorf("'to' must be after 'from'")
+	}
+	if m.To.Sub(m.From) > metricMaxRange {
+		return nil, fmt.Errorf("time range exceeds %d days", int(metricMaxRange.Hours()/24))
+	}
+
+	switch q.Interval {
+	case "":
+	case "auto":
+		m.Step = autoMetricInterval(m.To.Sub(m.From))
+	default:
+		d, ok := metricIntervals[q.Interval]
+		if !ok {
+			return nil, fmt.Errorf("unknown interval %q", q.Interval)
+		}
+		m.Step = d
+	}
+	if m.Step > 0 && m.To.Sub(m.From)/m.Step > metricMaxBuckets {
+		return nil, fmt.Errorf("interval %s is too small for the time range", q.Interval)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > metricMaxGroups {
+		limit = metricMaxGroups
+	}
+
+	agg := metricAggregationExpr(def, q.Aggregation, m)
+	where := []string{"timestamp >= ?", "timestamp <= ?"}
+	args := []interface{}{m.From, m.To}
+	if len(q.AgentIDs) > 0 {
+		where = append(where, "instance_id IN (?)")
+		args = append(args, q.AgentIDs)
+	}
+	filterKeys := make([]string, 0, len(q.Filters))
+	for k := range q.Filters {
+		filterKeys = append(filterKeys, k)
+	}
+	sort.Strings(filterKeys)
+	for _, k := range filterKeys {
+		if !stringInSlice(def.GroupBy, k) {
+			return nil, fmt.Errorf("cannot filter %s by %q", q.Metric, k)
+		}
+		where = append(where, metricDimensions[k]+" = ?")
+		args = append(args, q.Filters[k])
+	}
+	table := "nginx_analytics." + def.Table
+	whereClause := "WHERE " + strings.Join(where, " AND ")
+
+	var sel, groupBy []string
+	if m.Step > 0 {
+		sel = append(sel, fmt.Sprintf("toUnixTimestamp(toStartOfInterval(timestamp, INTERVAL %d SECOND)) AS t", int64(m.Step.Seconds())))
+		groupBy = append(groupBy, "t")
+	}
+	if m.Grouped {
+		sel = append(sel, metricDimensions[q.GroupBy]+" AS g")
+		groupBy = append(groupBy, "g")
+	}
+	sel = append(sel, "toFloat64("+agg+") AS v")
+
+	query := fmt.Sprintf("SELECT %s FROM %s %s", strings.Join(sel, ", "), table, whereClause)
+	queryArgs := append([]interface{}{}, args...)
+	if m.Grouped && m.Step > 0 {
+		// Keep the top groups over the whole range so each series is complete
+		query += fmt.Sprintf(" AND %s IN (SELECT %s FROM %s %s GROUP BY %s ORDER BY %s DESC LIMIT %d)",
+			metricDimensions[q.GroupBy], metricDimensions[q.GroupBy], table, whereClause,
+			metricDimensions[q.GroupBy], metricAggregationExpr(def, q.Aggregation, &metricSQL{From: m.From, To: m.To}), limit)
+		queryArgs = append(queryArgs, args...)
+	}
+	if len(groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(groupBy, ", ")
+	}
+	switch {
+	case m.Step > 0:
+		query += " ORDER BY t"
+	case m.Grouped:
+		query += fmt.Sprintf(" ORDER BY v DESC LIMIT %d", limit)
+	}
+
+	m.SQL = query
+	m.Args = queryArgs
+	return m, nil
+}
+
+// metricAggregationExpr renders the aggregate; rate is per second over the bucket (or whole range).
+func metricAggregationExpr(def metricDef, aggregation string, m *metricSQL) string {
+	count := "count()"
+	if def.cond != "" {
+		count = "countIf(" + def.cond + ")"
+	}
+	switch aggregation {
+	case "count":
+		return count
+	case "rate":
+		seconds := int64(m.Step.Seconds())
+		if seconds == 0 {
+			seconds = int64(m.To.Sub(m.From).Seconds())
+		}
+		return fmt.Sprintf("%s / %d", count, seconds)
+	case "sum", "avg", "min", "max", "uniq":
+		return aggregation + "(" + def.expr + ")"
+	case "p50", "p90", "p95", "p99":
+		return fmt.Sprintf("quantile(0.%s)(%s)", aggregation[1:], def.expr)
+	}
+	return count
+}
+
+// autoMetricInterval picks a bucket giving roughly 60-300 points for the range.
+func autoMetricInterval(r time.Duration) time.Duration {
+	switch {
+	case r <= 6*time.Hour:
+		return time.Minute
+	case r <= 24*time.Hour:
+		return 5 * time.Minute
+	case r <= 3*24*time.Hour:
+		return 15 * time.Minute
+	case r <= 30*24*time.Hour:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// RunMetricQuery executes a catalog query and returns one series per group.
+func (db *ClickHouseDB) RunMetricQuery(ctx context.Context, q MetricQuery) (*MetricQueryResult, error) {
+	m, err := buildMetricQuery(q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(ctx, m.SQL, m.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &MetricQueryResult{Query: q, From: m.From.Unix(), To: m.To.Unix(), Step: int64(m.Step.Seconds())}
+	index := make(map[string]int)
+	for rows.Next() {
+		var ts uint32
+		var group string
+		var value float64
+		dest := make([]interface{}, 0, 3)
+		if m.Step > 0 {
+			dest = append(dest, &ts)
+		}
+		if m.Grouped {
+			dest = append(dest, &group)
+		}
+		dest = append(dest, &value)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[group]
+		if !ok {
+			i = len(result.Series)
+			index[group] = i
+			result.Series = append(result.Series, MetricSeries{Group: group})
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			value = 0
+		}
+		result.Series[i].Points = append(result.Series[i].Points, MetricPoint{Timestamp: int64(ts), Value: value})
+	}
+	if result.Series == nil {
+		result.Series = []MetricSeries{}
+	}
+	return result, rows.Err()
+}
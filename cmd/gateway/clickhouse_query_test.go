@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMetricQuery(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	m, err := buildMetricQuery(MetricQuery{Metric: "latency", Aggregation: "p95", GroupBy: "agent", Window: "24h", Interval: "1h", Limit: 5}, now)
+	if err != nil {
+		t.Fatalf("buildMetricQuery: %v", err)
+	}
+	for _, want := range []string{
+		"toStartOfInterval(timestamp, INTERVAL 3600 SECOND)) AS t",
+		"instance_id AS g",
+		"quantile(0.95)(request_time * 1000)",
+		"FROM nginx_analytics.access_logs",
+		"instance_id IN (SELECT instance_id",
+		"LIMIT 5)",
+		"GROUP BY t, g ORDER BY t",
+	} {
+		if !strings.Contains(m.SQL, want) {
+			t.Errorf("SQL missing %q:\n%s", want, m.SQL)
+		}
+	}
+	if !m.From.Equal(now.Add(-24*time.Hour)) || !m.To.Equal(now) {
+		t.Errorf("range = %v..%v", m.From, m.To)
+	}
+	// time range args are repeated for the top-groups subquery
+	if len(m.Args) != 4 {
+		t.Errorf("args = %d, want 4", len(m.Args))
+	}
+}
+
+func TestBuildMetricQuery_RateAndFilters(t *testing.T) {
+	now := time.Now()
+	m, err := buildMetricQuery(MetricQuery{
+		Metric:   "errors",
+		Window:   "1h",
+		AgentIDs: []string{"a"},
+		Filters:  map[string]string{"uri": "/login", "method": "POST"},
+	}, now)
+	if err != nil {
+		t.Fatalf("buildMetricQuery: %v", err)
+	}
+	if !strings.Contains(m.SQL, "countIf(status >= 400)") {
+		t.Errorf("default aggregation should count errors:\n%s", m.SQL)
+	}
+	if !strings.Contains(m.SQL, "request_method = ? AND request_uri = ?") {
+		t.Errorf("filters should be applied in key order:\n%s", m.SQL)
+	}
+	if got := m.Args[len(m.Args)-1]; got != "/login" {
+		t.Errorf("last arg = %v, want /login", got)
+	}
+
+	m, err = buildMetricQuery(MetricQuery{Metric: "requests", Aggregation: "rate", Window: "5m"}, now)
+	if err != nil {
+		t.Fatalf("buildMetricQuery: %v", err)
+	}
+	if !strings.Contains(m.SQL, "count() / 300") {
+		t.Errorf("rate without interval should divide by the window:\n%s", m.SQL)
+	}
+}
+
+func TestBuildMetricQuery_NginxInstance(t *testing.T) {
+	m, err := buildMetricQuery(MetricQuery{
+		Metric:  "active_connections",
+		GroupBy: "nginx_instance",
+		Window:  "1h",
+		Filters: map[string]string{"agent": "web-1"},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("buildMetricQuery: %v", err)
+	}
+	for _, want := range []string{"labels['nginx_instance'] AS g", "FROM nginx_analytics.nginx_metrics", "instance_id = ?"} {
+		if !strings.Contains(m.SQL, want) {
+			t.Errorf("SQL missing %q:\n%s", want, m.SQL)
+		}
+	}
+}
+
+func TestBuildMetricQuery_Rejects(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		q    MetricQuery
+	}{
+		{"unknown metric", MetricQuery{Metric: "passwords"}},
+		{"bad aggregation", MetricQuery{Metric: "cpu_usage", Aggregation: "count"}},
+		{"bad dimension", MetricQuery{Metric: "cpu_usage", GroupBy: "uri"}},
+		{"bad filter", MetricQuery{Metric: "cpu_usage", Filters: map[string]string{"status": "500"}}},
+		{"sql in group by", MetricQuery{Metric: "requests", GroupBy: "status; DROP TABLE x"}},
+		{"unknown window", MetricQuery{Metric: "requests", Window: "2y"}},
+		{"too many buckets", MetricQuery{Metric: "requests", Window: "30d", Interval: "1m"}},
+		{"range too long", MetricQuery{Metric: "requests", From: now.Add(-100 * 24 * time.Hour).Unix()}},
+		{"inverted range", MetricQuery{Metric: "requests", From: now.Unix(), To: now.Add(-time.Hour).Unix()}},
+	}
+	for _, tt := range tests {
+		if _, err := buildMetricQuery(tt.q, now); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestApplyPanelTimeRange(t *testing.T) {
+	q := MetricQuery{Metric: "requests"}
+	if got := applyPanelTimeRange(q, "7d", "", "", ""); got.Window != "7d" {
+		t.Errorf("dashboard default not applied: %+v", got)
+	}
+	q.Window = "1h"
+	if got := applyPanelTimeRange(q, "7d", "", "", ""); got.Window != "1h" {
+		t.Errorf("panel window should win over the dashboard default: %+v", got)
+	}
+	if got := applyPanelTimeRange(q, "7d", "24h", "", ""); got.Window != "24h" {
+		t.Errorf("window override not applied: %+v", got)
+	}
+	got := applyPanelTimeRange(q, "7d", "24h", "100", "200")
+	if got.From != 100 || got.To != 200 || got.Window != "" {
+		t.Errorf("from/to override not applied: %+v", got)
+	}
+}
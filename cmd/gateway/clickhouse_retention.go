@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// TableStorage is the disk usage and TTL of a ClickHouse table
+type TableStorage struct {
+	Rows        uint64 `json:"rows"`
+	BytesOnDisk uint64 `json:"bytes_on_disk"`
+	Parts       uint64 `json:"parts"`
+	CurrentTTL  string `json:"current_ttl,omitempty"` // as ClickHouse reports it
+}
+
+// SetTableTTL replaces the TTL of a table in nginx_analytics. table must be a managed table name,
+// it is not escaped.
+func (db *ClickHouseDB) SetTableTTL(ctx context.Context, table, expr string) error {
+	return db.execDDL(ctx, "ALTER TABLE nginx_analytics."+table+" MODIFY TTL "+expr)
+}
+
+// GetTableStorage returns the active parts and TTL of every table in nginx_analytics. On a cluster
+// parts are summed over the shards, one replica each, and the *_local tables of distributed ones
+// are reported under the table's name.
+func (db *ClickHouseDB) GetTableStorage(ctx context.Context) (map[string]TableStorage, error) {
+	storage := make(map[string]TableStorage)
+	rows, err := db.conn.Query(ctx, `
+		SELECT table, sum(rows), sum(bytes_on_disk), count()
+		FROM `+db.systemTable("parts")+`
+		WHERE database = 'nginx_analytics' AND active
+		GROUP BY table`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table string
+		var st TableStorage
+		if err := rows.Scan(&table, &st.Rows, &st.BytesOnDisk, &st.Parts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		storage[db.tableOf(table)] = st
+	}
+	rows.Close()
+
+	rows, err = db.conn.Query(ctx, `SELECT name, engine_full FROM system.tables WHERE database = 'nginx_analytics'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, engine string
+		if err := rows.Scan(&table, &engine); err != nil {
+			return nil, err
+		}
+		if ttl := tableTTLFromEngine(engine); ttl != "" {
+			st := storage[db.tableOf(table)]
+			st.CurrentTTL = ttl
+			storage[db.tableOf(table)] = st
+		}
+	}
+	return storage, rows.Err()
+}
+
+// tableTTLFromEngine extracts the TTL clause of a system.tables engine_full value
+func tableTTLFromEngine(engine string) string {
+	i := strings.Index(engine, " TTL ")
+	if i < 0 {
+		return ""
+	}
+	ttl := engine[i+len(" TTL "):]
+	if j := strings.Index(ttl, " SETTINGS "); j >= 0 {
+		ttl = ttl[:j]
+	}
+	return strings.TrimSpace(ttl)
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// analyticsRollupMinDuration is the shortest window GetAnalytics serves from the rollups; shorter
+// windows need per-request detail and are cheap to scan anyway
+const analyticsRollupMinDuration = time.Hour
+
+// requestRollup is a rollup of requests and their latency per agent at one resolution
+type requestRollup struct {
+	table string
+	// floor rounds a DateTime down to the rollup's resolution
+	floor string
+	// maxWindow is the longest window read at this resolution, 0 for any
+	maxWindow time.Duration
+}
+
+// requestRollups are the per-agent request rollups, finest first. The minutes are kept for 30 days
+// and downsampled into 5 minutes and hours kept for a year.
+var requestRollups = []requestRollup{
+	{table: "requests_1m", floor: "toStartOfMinute", maxWindow: 24 * time.Hour},
+	{table: "requests_5m", floor: "toStartOfFiveMinutes", maxWindow: 31 * 24 * time.Hour},
+	{table: "requests_1h", floor: "toStartOfHour"},
+}
+
+// requestRollupFor returns the rollup GetAnalytics reads for a window from start to end: the finest
+// that suits the window's length and, with its default retention, still reaches back to start.
+// Windows from a year ago read the hours, which may have expired too.
+func requestRollupFor(start, end, now time.Time) requestRollup {
+	for _, r := range requestRollups {
+		if r.maxWindow > 0 && end.Sub(start) > r.maxWindow {
+			continue
+		}
+		if t, ok := findRetentionTable(r.table); ok && now.Sub(start) > time.Duration(t.DefaultDays)*24*time.Hour {
+			continue
+		}
+		return r
+	}
+	return requestRollups[len(requestRollups)-1]
+}
+
+// uriMinuteRollupDays is how far back requests_uri_1m reaches (its default retention); per-path
+// queries over older windows read the hourly requests_uri_1h
+const uriMinuteRollupDays = 30
+
+// uriRollupTable returns the per-path rollup to read for a window starting at start
+func uriRollupTable(start, now time.Time) string {
+	if now.Sub(start) > uriMinuteRollupDays*24*time.Hour {
+		return "requests_uri_1h"
+	}
+	return "requests_uri_1m"
+}
+
+// topPathsQuery returns the top paths query over the range from start to end, with requests,
+// errors, p50 and p95 request times and bytes sent. Ranges over an hour merge the latency digests
+// of the per-path rollup instead of scanning access_logs, which is kept for less time.
+// agentClause filters both; the query takes start, end, the agentClause arguments and the limit.
+func topPathsQuery(start, end, now time.Time, agentClause string) string {
+	if end.Sub(start) <= analyticsRollupMinDuration {
+		return fmt.Sprintf(`
+		SELECT
+			%s as path,
+			count(*) as requests,
+			countIf(status >= 400) as errors,
+			quantile(0.50)(request_time) as p50,
+			quantile(0.95)(request_time) as p95,
+			sum(body_bytes_sent) as traffic
+		FROM nginx_analytics.access_logs
+		WHERE timestamp >= ? AND timestamp <= ?%s
+		GROUP BY path
+		ORDER BY requests DESC
+		LIMIT ?`, endpointSQL, agentClause)
+	}
+	table, where := uriRollupTable(start, now), "WHERE ts >= ? AND ts <= ?"
+	if table == "requests_uri_1h" {
+		where = "WHERE hour >= toStartOfHour(?) AND hour <= ?"
+	}
+	return fmt.Sprintf(`
+		SELECT
+			request_uri,
+			sum(requests) as requests,
+			sum(errors) as errors,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[1]) as p50,
+			toFloat64(quantilesTDigestMerge(0.5, 0.95, 0.99)(latency)[2]) as p95,
+			sum(bytes) as traffic
+		FROM nginx_analytics.%s
+		%s%s
+		GROUP BY request_uri
+		ORDER BY requests DESC
+		LIMIT ?`, table, where, agentClause)
+}
+
+// accessLogRollup is a pre-aggregated table of access_logs, or a downsampled one of a finer
+// rollup, filled by a materialized view
+type accessLogRollup struct {
+	table  string
+	create string
+	// selectFrom aggregates access_logs (or finer rollup) rows into the rollup; shared by the view
+	// and the backfill
+	selectFrom string
+	// timeColumn is the time column of the rows selectFrom reads, which the backfill filters on
+	timeColumn string
+}
+
+// downsampledRequests returns the requests rollup of requests_1m at the resolution of floor, kept
+// for a year
+func downsampledRequests(table, floor string) accessLogRollup {
+	return accessLogRollup{
+		table: table,
+		create: `CREATE TABLE IF NOT EXISTS nginx_analytics.` + table + ` (
+			ts DateTime,
+			instance_id LowCardinality(String),
+			requests UInt64,
+			errors UInt64,
+			s2xx UInt64,
+			s3xx UInt64,
+			s4xx UInt64,
+			s5xx UInt64,
+			bytes UInt64,
+			bytes_in UInt64,
+			sum_latency Float64,
+			lat_0_50 UInt64,
+			lat_50_100 UInt64,
+			lat_100_200 UInt64,
+			lat_200_500 UInt64,
+			lat_500_plus UInt64,
+			latency AggregateFunction(quantilesTDigest(0.5, 0.95, 0.99), Float32),
+			project_id LowCardinality(String) DEFAULT '',
+			environment_id LowCardinality(String) DEFAULT ''
+		) ENGINE = SummingMergeTree()
+		PARTITION BY toYYYYMM(ts)
+		ORDER BY (instance_id, ts)
+		TTL ts + INTERVAL 365 DAY`,
+		selectFrom: `SELECT
+			` + floor + `(ts) AS ts,
+			instance_id,
+			sum(requests) AS requests,
+			sum(errors) AS errors,
+			sum(s2xx) AS s2xx,
+			sum(s3xx) AS s3xx,
+			sum(s4xx) AS s4xx,
+			sum(s5xx) AS s5xx,
+			sum(bytes) AS bytes,
+			sum(bytes_in) AS bytes_in,
+			sum(sum_latency) AS sum_latency,
+			sum(lat_0_50) AS lat_0_50,
+			sum(lat_50_100) AS lat_50_100,
+			sum(lat_100_200) AS lat_100_200,
+			sum(lat_200_500) AS lat_200_500,
+			sum(lat_500_plus) AS lat_500_plus,
+			quantilesTDigestMergeState(0.5, 0.95, 0.99)(latency) AS latency,
+			project_id,
+			environment_id
+		FROM nginx_analytics.requests_1m
+		WHERE requests > 0 %s
+		GROUP BY ts, instance_id, project_id, environment_id`,
+		// Qualified: ts alone is the rounded alias
+		timeColumn: "requests_1m.ts",
+	}
+}
+
+var accessLogRollups = []accessLogRollup{
+	{
+		// Per agent and minute: request rate, status classes, latency buckets and percentiles
+		table: "requests_1m",
+		create: `CREATE TABLE IF NOT EXISTS nginx_analytics.requests_1m (
+			ts DateTime,
+			instance_id LowCardinality(String),
+			requests UInt64,
+			errors UInt64,
+			s2xx UInt64,
+			s3xx UInt64,
+			s4xx UInt64,
+			s5xx UInt64,
+			bytes UInt64,
+			bytes_in UInt64,
+			sum_latency Float64,
+			lat_0_50 UInt64,
+			lat_50_100 UInt64,
+			lat_100_200 UInt64,
+			lat_200_500 UInt64,
+			lat_500_plus UInt64,
+			latency AggregateFunction(quantilesTDigest(0.5, 0.95, 0.99), Float32),
+			project_id LowCardinality(String) DEFAULT '',
+			environment_id LowCardinality(String) DEFAULT ''
+		) ENGINE = SummingMergeTree()
+		PARTITION BY toYYYYMM(ts)
+		ORDER BY (instance_id, ts)
+		TTL ts + INTERVAL 30 DAY`,
+		selectFrom: `SELECT
+			toStartOfMinute(toDateTime(timestamp)) AS ts,
+			instance_id,
+			count() AS requests,
+			countIf(status >= 400) AS errors,
+			countIf(status >= 200 AND status < 300) AS s2xx,
+			countIf(status >= 300 AND status < 400) AS s3xx,
+			countIf(status >= 400 AND status < 500) AS s4xx,
+			countIf(status >= 500) AS s5xx,
+			sum(body_bytes_sent) AS bytes,
+			sum(request_length) AS bytes_in,
+			sum(request_time) AS sum_latency,
+			countIf(request_time < 0.05) AS lat_0_50,
+			countIf(request_time >= 0.05 AND request_time < 0.1) AS lat_50_100,
+			countIf(request_time >= 0.1 AND request_time < 0.2) AS lat_100_200,
+			countIf(request_time >= 0.2 AND request_time < 0.5) AS lat_200_500,
+			countIf(request_time >= 0.5) AS lat_500_plus,
+			quantilesTDigestState(0.5, 0.95, 0.99)(request_time) AS latency,
+			project_id,
+			environment_id
+		FROM nginx_analytics.access_logs
+		WHERE status > 0 %s
+		GROUP BY ts, instance_id, project_id, environment_id`,
+		timeColumn: "timestamp",
+	},
+	{
+		// Per agent, endpoint (uri_templates.go) and minute: the columns of requests_1m, for URL
+		// filtered analytics, and top endpoints with their percentiles over exact windows
+		table: "requests_uri_1m",
+		create: `CREATE TABLE IF NOT EXISTS nginx_analytics.requests_uri_1m (
+			ts DateTime,
+			instance_id LowCardinality(String),
+			request_uri String,
+			requests UInt64,
+			errors UInt64,
+			s2xx UInt64,
+			s3xx UInt64,
+			s4xx UInt64,
+			s5xx UInt64,
+			bytes UInt64,
+			bytes_in UInt64,
+			sum_latency Float64,
+			lat_0_50 UInt64,
+			lat_50_100 UInt64,
+			lat_100_200 UInt64,
+			lat_200_500 UInt64,
+			lat_500_plus UInt64,
+			latency AggregateFunction(quantilesTDigest(0.5, 0.95, 0.99), Float32),
+			project_id LowCardinality(String) DEFAULT '',
+			environment_id LowCardinality(String) DEFAULT ''
+		) ENGINE = SummingMergeTree()
+		PARTITION BY toYYYYMM(ts)
+		ORDER BY (instance_id, request_uri, ts)
+		TTL ts + INTERVAL 30 DAY`,
+		selectFrom: `SELECT
+			toStartOfMinute(toDateTime(timestamp)) AS ts,
+			instance_id,
+			` + endpointSQL + ` AS request_uri,
+			count() AS requests,
+			countIf(status >= 400) AS errors,
+			countIf(status >= 200 AND status < 300) AS s2xx,
+			countIf(status >= 300 AND status < 400) AS s3xx,
+			countIf(status >= 400 AND status < 500) AS s4xx,
+			countIf(status >= 500) AS s5xx,
+			sum(body_bytes_sent) AS bytes,
+			sum(request_length) AS bytes_in,
+			sum(request_time) AS sum_latency,
+			countIf(request_time < 0.05) AS lat_0_50,
+			countIf(request_time >= 0.05 AND request_time < 0.1) AS lat_50_100,
+			countIf(request_time >= 0.1 AND request_time < 0.2) AS lat_100_200,
+			countIf(request_time >= 0.2 AND request_time < 0.5) AS lat_200_500,
+			countIf(request_time >= 0.5) AS lat_500_plus,
+			quantilesTDigestState(0.5, 0.95, 0.99)(request_time) AS latency,
+			project_id,
+			environment_id
+		FROM nginx_analytics.access_logs
+		WHERE status > 0 %s
+		GROUP BY ts, instance_id, request_uri, project_id, environment_id`,
+		timeColumn: "timestamp",
+	},
+	{
+		// Per agent, endpoint and hour: top endpoints beyond requests_uri_1m
+		table: "requests_uri_1h",
+		create: `CREATE TABLE IF NOT EXISTS nginx_analytics.requests_uri_1h (
+			hour DateTime,
+			instance_id LowCardinality(String),
+			request_uri String,
+			requests UInt64,
+			errors UInt64,
+			bytes UInt64,
+			bytes_in UInt64,
+			latency AggregateFunction(quantilesTDigest(0.5, 0.95, 0.99), Float32),
+			project_id LowCardinality(String) DEFAULT '',
+			environment_id LowCardinality(String) DEFAULT ''
+		) ENGINE = SummingMergeTree()
+		PARTITION BY toYYYYMM(hour)
+		ORDER BY (instance_id, hour, request_uri)
+		TTL hour + INTERVAL 90 DAY`,
+		selectFrom: `SELECT
+			toStartOfHour(toDateTime(timestamp)) AS hour,
+			instance_id,
+			` + endpointSQL + ` AS request_uri,
+			count() AS requests,
+			countIf(status >= 400) AS errors,
+			sum(body_bytes_sent) AS bytes,
+			sum(request_length) AS bytes_in,
+			quantilesTDigestState(0.5, 0.95, 0.99)(request_time) AS latency,
+			project_id,
+			environment_id
+		FROM nginx_analytics.access_logs
+		WHERE status > 0 %s
+		GROUP BY hour, instance_id, request_uri, project_id, environment_id`,
+		timeColumn: "timestamp",
+	},
+	// Downsampled after requests_1m, so their backfill reads its month
+	downsampledRequests("requests_5m", "toStartOfFiveMinutes"),
+	downsampledRequests("requests_1h", "toStartOfHour"),
+}
+
+// currentView reports whether the stored query of a rollup's view is up to date: views from before
+// the tenant columns, or per-path views from before endpoints, are replaced
+func (r accessLogRollup) currentView(viewQuery string) bool {
+	if !strings.Contains(viewQuery, "environment_id") {
+		return false
+	}
+	return !strings.Contains(r.selectFrom, "endpoint") || strings.Contains(viewQuery, "endpoint")
+}
+
+// migrateRollups creates the rollup tables and their materialized views. A rollup created on a
+// table that already holds logs is backfilled once with the rows written before its view. Outdated
+// views are replaced (their rows are kept as they are); requests logged while one is swapped miss
+// the rollup.
+func (db *ClickHouseDB) migrateRollups(ctx context.Context) {
+	for _, r := range accessLogRollups {
+		if err := db.execDDL(ctx, r.create); err != nil {
+			analyticsLog.Error().Msgf("ClickHouse migration: creating %s failed: %v", r.table, err)
+			continue
+		}
+		for _, q := range tenantColumnsDDL(r.table) {
+			if err := db.execDDL(ctx, q); err != nil {
+				analyticsLog.Error().Msgf("ClickHouse migration query failed [%s]: %v", q, err)
+			}
+		}
+		var views uint64
+		var viewQuery string
+		if err := db.conn.QueryRow(ctx, `
+			SELECT count(), any(create_table_query) FROM system.tables WHERE database = 'nginx_analytics' AND name = ?`,
+			r.table+"_mv").Scan(&views, &viewQuery); err != nil {
+			continue
+		}
+		if views > 0 {
+			if r.currentView(viewQuery) {
+				continue
+			}
+			if err := db.execDDL(ctx, fmt.Sprintf("DROP VIEW IF EXISTS nginx_analytics.%s_mv", r.table)); err != nil {
+				analyticsLog.Error().Msgf("ClickHouse migration: replacing %s_mv failed: %v", r.table, err)
+				continue
+			}
+			view := fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.%s_mv TO nginx_analytics.%s AS %s",
+				r.table, r.table, fmt.Sprintf(r.selectFrom, ""))
+			if err := db.execDDL(ctx, view); err != nil {
+				analyticsLog.Error().Msgf("ClickHouse migration: replacing %s_mv failed: %v", r.table, err)
+				continue
+			}
+			analyticsLog.Info().Msgf("ClickHouse migration: replaced the view of rollup %s", r.table)
+			continue
+		}
+		cutoff := time.Now().UTC()
+		view := fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.%s_mv TO nginx_analytics.%s AS %s",
+			r.table, r.table, fmt.Sprintf(r.selectFrom, ""))
+		if err := db.execDDL(ctx, view); err != nil {
+			analyticsLog.Error().Msgf("ClickHouse migration: creating %s_mv failed: %v", r.table, err)
+			continue
+		}
+		backfill := fmt.Sprintf("INSERT INTO nginx_analytics.%s %s", r.table, fmt.Sprintf(r.selectFrom, "AND "+r.timeColumn+" < ?"))
+		if err := db.conn.Exec(ctx, backfill, cutoff); err != nil {
+			analyticsLog.Error().Msgf("ClickHouse migration: backfilling %s failed: %v", r.table, err)
+			continue
+		}
+		analyticsLog.Info().Msgf("ClickHouse migration: created rollup %s", r.table)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogRollups(t *testing.T) {
+	for _, r := range accessLogRollups {
+		if _, ok := findRetentionTable(r.table); !ok {
+			t.Errorf("%s has no retention policy", r.table)
+		}
+		if !strings.Contains(r.create, "nginx_analytics."+r.table+" (") {
+			t.Errorf("%s: create targets another table", r.table)
+		}
+		view := fmt.Sprintf(r.selectFrom, "")
+		backfill := fmt.Sprintf(r.selectFrom, "AND "+r.timeColumn+" < ?")
+		if strings.Contains(view, "%!") || strings.Count(backfill, "?") != 1 {
+			t.Errorf("%s: selectFrom must take exactly one filter: %s", r.table, backfill)
+		}
+		if r.timeColumn == "" {
+			t.Errorf("%s: no time column to backfill by", r.table)
+		}
+	}
+	// A downsampled rollup must come after the rollup it reads
+	seen := map[string]bool{"access_logs": true}
+	for _, r := range accessLogRollups {
+		from := strings.Fields(r.selectFrom[strings.Index(r.selectFrom, "FROM nginx_analytics.")+len("FROM nginx_analytics."):])[0]
+		if !seen[from] {
+			t.Errorf("%s reads %s, which is migrated after it", r.table, from)
+		}
+		seen[r.table] = true
+	}
+}
+
+func TestRequestRollupFor(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		table      string
+	}{
+		{"last 6 hours", now.Add(-6 * time.Hour), now, "requests_1m"},
+		{"last 24 hours", now.Add(-day), now, "requests_1m"},
+		{"last 7 days", now.Add(-7 * day), now, "requests_5m"},
+		{"last 30 days", now.Add(-30 * day), now, "requests_5m"},
+		{"last 90 days", now.Add(-90 * day), now, "requests_1h"},
+		// The minutes of two months ago have expired
+		{"a day two months ago", now.Add(-60 * day), now.Add(-59 * day), "requests_5m"},
+		{"the previous 30 days", now.Add(-60 * day), now.Add(-30 * day), "requests_5m"},
+		{"the previous year", now.Add(-730 * day), now.Add(-365 * day), "requests_1h"},
+	} {
+		if got := requestRollupFor(tc.start, tc.end, now).table; got != tc.table {
+			t.Errorf("%s: %s, want %s", tc.name, got, tc.table)
+		}
+	}
+}
+
+func TestTopPathsQuery(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name  string
+		start time.Time
+		table string
+	}{
+		{"last 30 minutes", now.Add(-30 * time.Minute), "access_logs"},
+		{"last 7 days", now.AddDate(0, 0, -7), "requests_uri_1m"},
+		{"last 30 days", now.AddDate(0, 0, -30), "requests_uri_1m"},
+		{"last 90 days", now.AddDate(0, 0, -90), "requests_uri_1h"},
+	} {
+		q := topPathsQuery(tc.start, now, now, " AND instance_id IN (?)")
+		if !strings.Contains(q, "FROM nginx_analytics."+tc.table+"\n") {
+			t.Errorf("%s: query does not read %s: %s", tc.name, tc.table, q)
+		}
+		// start, end, the agents and the limit
+		if n := strings.Count(q, "?"); n != 4 {
+			t.Errorf("%s: query takes %d arguments, want 4: %s", tc.name, n, q)
+		}
+	}
+}
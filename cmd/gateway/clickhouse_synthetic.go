@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InsertSyntheticResult queues a check result for batched insertion
+func (db *ClickHouseDB) InsertSyntheticResult(res SyntheticResult) {
+	select {
+	case db.synChan <- res:
+	default:
+		writeLog.Warn().Msgf("Synthetic result queue full, dropping result for check %s", res.CheckID)
+	}
+}
+
+func (db *ClickHouseDB) runSyntheticFlusher() {
+	ticker := time.NewTicker(5 * time.Second)
+	batch := make([]SyntheticResult, 0, 500)
+	for {
+		select {
+		case res := <-db.synChan:
+			batch = append(batch, res)
+			if len(batch) >= 500 {
+				db.flushSyntheticResults(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				db.flushSyntheticResults(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (db *ClickHouseDB) flushSyntheticResults(batch []SyntheticResult) {
+	ctx := context.Background()
+	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.synthetic_results (
+		timestamp, check_id, region, success, status_code, latency_ms, error
+	)`)
+	if err != nil {
+		analyticsLog.Error().Msgf("flushSyntheticResults: PrepareBatch failed: %v", err)
+		db.insertFailed("synthetic_results")
+		return
+	}
+	for _, res := range batch {
+		var success uint8
+		if res.Success {
+			success = 1
+		}
+		if err := b.Append(res.Timestamp, res.CheckID, res.Region, success, uint16(res.StatusCode),
+			float32(res.LatencyMs), res.Error); err != nil {
+			analyticsLog.Error().Msgf("flushSyntheticResults: Append failed: %v", err)
+			db.insertFailed("synthetic_results")
+			return
+		}
+	}
+	if err := b.Send(); err != nil {
+		analyticsLog.Error().Msgf("flushSyntheticResults: Send failed: %v", err)
+		db.insertFailed("synthetic_results")
+	}
+}
+
+// GetSyntheticResults returns the most recent results of a check, newest first
+func (db *ClickHouseDB) GetSyntheticResults(ctx context.Context, checkID string, start, end time.Time, limit int) ([]SyntheticResult, error) {
+	rows, err := db.conn.Query(ctx, `
+		SELECT timestamp, check_id, region, success, status_code, latency_ms, error
+		FROM nginx_analytics.synthetic_results
+		WHERE check_id = ? AND timestamp >= ? AND timestamp <= ?
+		ORDER BY timestamp DESC
+		LIMIT ?
+	`, checkID, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SyntheticResult{}
+	for rows.Next() {
+		var (
+			res        SyntheticResult
+			success    uint8
+			statusCode uint16
+			latency    float32
+		)
+		if err := rows.Scan(&res.Timestamp, &res.CheckID, &res.Region, &success, &statusCode, &latency, &res.Error); err != nil {
+			return nil, err
+		}
+		res.Success = success == 1
+		res.StatusCode = int(statusCode)
+		res.LatencyMs = float64(latency)
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// SyntheticSummary aggregates the results of one check over a window
+type SyntheticSummary struct {
+	CheckID      string    `json:"check_id"`
+	Runs         uint64    `json:"runs"`
+	Failures     uint64    `json:"failures"`
+	UptimePct    float64   `json:"uptime_pct"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"`
+	P95LatencyMs float64   `json:"p95_latency_ms"`
+	LastRun      time.Time `json:"last_run"`
+	LastSuccess  bool      `json:"last_success"`
+}
+
+// GetSyntheticSummaries returns uptime and latency per check. An empty checkIDs returns nothing.
+func (db *ClickHouseDB) GetSyntheticSummaries(ctx context.Context, checkIDs []string, start, end time.Time) ([]SyntheticSummary, error) {
+	summaries := []SyntheticSummary{}
+	if len(checkIDs) == 0 {
+		return summaries, nil
+	}
+	rows, err := db.conn.Query(ctx, `
+		SELECT check_id, count(), countIf(success = 0),
+			avg(latency_ms), quantile(0.95)(latency_ms),
+			max(timestamp), argMax(success, timestamp)
+		FROM nginx_analytics.synthetic_results
+		WHERE check_id IN (?) AND timestamp >= ? AND timestamp <= ?
+		GROUP BY check_id
+	`, checkIDs, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			s           SyntheticSummary
+			lastSuccess uint8
+		)
+		if err := rows.Scan(&s.CheckID, &s.Runs, &s.Failures, &s.AvgLatencyMs, &s.P95LatencyMs, &s.LastRun, &lastSuccess); err != nil {
+			return nil, err
+		}
+		if s.Runs > 0 {
+			s.UptimePct = float64(s.Runs-s.Failures) / float64(s.Runs) * 100
+		}
+		s.LastSuccess = lastSuccess == 1
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// SyntheticBucket is one point of a check's uptime and latency chart
+type SyntheticBucket struct {
+	Time         string  `json:"time"`
+	Runs         uint64  `json:"runs"`
+	Failures     uint64  `json:"failures"`
+	UptimePct    float64 `json:"uptime_pct"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+}
+
+// GetSyntheticSeries returns uptime and latency of a check bucketed over the window
+func (db *ClickHouseDB) GetSyntheticSeries(ctx context.Context, checkID string, start, end time.Time) ([]SyntheticBucket, error) {
+	bucketSize, timeFormat := "toStartOfMinute", "%H:%i"
+	switch duration := end.Sub(start); {
+	case duration <= 1*time.Hour:
+	case duration <= 6*time.Hour:
+		bucketSize = "toStartOfFiveMinutes"
+	case duration <= 24*time.Hour:
+		bucketSize, timeFormat = "toStartOfFifteenMinutes", "%m-%d %H:%i"
+	case duration <= 7*24*time.Hour:
+		bucketSize, timeFormat = "toStartOfHour", "%m-%d %H:00"
+	default:
+		bucketSize, timeFormat = "toStartOfDay", "%Y-%m-%d"
+	}
+
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT formatDateTime(%s(timestamp), '%s') as bucket, count(), countIf(success = 0),
+			avg(latency_ms), quantile(0.95)(latency_ms)
+		FROM nginx_analytics.synthetic_results
+		WHERE check_id = ? AND timestamp >= ? AND timestamp <= ?
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucketSize, timeFormat), checkID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []SyntheticBucket{}
+	for rows.Next() {
+		var b SyntheticBucket
+		if err := rows.Scan(&b.Time, &b.Runs, &b.Failures, &b.AvgLatencyMs, &b.P95LatencyMs); err != nil {
+			return nil, err
+		}
+		if b.Runs > 0 {
+			b.UptimePct = float64(b.Runs-b.Failures) / float64(b.Runs) * 100
+		}
+		series = append(series, b)
+	}
+	return series, rows.Err()
+}
+
+// SyntheticDay counts the runs of one check on one UTC day
+type SyntheticDay struct {
+	CheckID  string
+	Day      string // YYYY-MM-DD
+	Runs     uint64
+	Failures uint64
+}
+
+// GetSyntheticDailyCounts returns per-day run and failure counts of the checks
+func (db *ClickHouseDB) GetSyntheticDailyCounts(ctx context.Context, checkIDs []string, start, end time.Time) ([]SyntheticDay, error) {
+	days := []SyntheticDay{}
+	if len(checkIDs) == 0 {
+		return days, nil
+	}
+	rows, err := db.conn.Query(ctx, `
+		SELECT check_id, formatDateTime(toStartOfDay(timestamp, 'UTC'), '%Y-%m-%d') as day, count(), countIf(success = 0)
+		FROM nginx_analytics.synthetic_results
+		WHERE check_id IN (?) AND timestamp >= ? AND timestamp <= ?
+		GROUP BY check_id, day
+		ORDER BY day
+	`, checkIDs, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d SyntheticDay
+		if err := rows.Scan(&d.CheckID, &d.Day, &d.Runs, &d.Failures); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rows of agent telemetry carry the project and environment of their agent, stamped at ingest from
+// its server assignment. Project and environment reads, and the RBAC scoping of non-superadmins,
+// filter on these two columns instead of expanding a project into the IN list of its agents.
+
+// tenantTable is a ClickHouse table whose rows carry project_id and environment_id
+type tenantTable struct {
+	name        string
+	agentColumn string
+}
+
+// tenantTables are stamped by the flushers; the access log rollups (clickhouse_rollups.go) inherit
+// the columns from access_logs through their views
+var tenantTables = []tenantTable{
+	{"access_logs", "instance_id"},
+	{"access_logs_restored", "instance_id"},
+	{"spans", "instance_id"},
+	{"system_metrics", "instance_id"},
+	{"nginx_metrics", "instance_id"},
+	{"nginx_workers", "instance_id"},
+	{"disk_usage", "instance_id"},
+	{"log_files", "instance_id"},
+	{"security_events", "instance_id"},
+	{"generic_metrics", "instance_id"},
+	{"terminal_sessions", "agent_id"},
+}
+
+// tenantCacheTTL bounds how long rows keep the old tenant of an agent moved to another environment
+const tenantCacheTTL = time.Minute
+
+// tenantColumnsDDL adds the tenant columns and their skip indexes to a table. They stay out of the
+// sorting key, which existing tables cannot change, so rows of older agents are updated in place by
+// BackfillTenantColumns.
+func tenantColumnsDDL(table string) []string {
+	return []string{
+		fmt.Sprintf("ALTER TABLE nginx_analytics.%s ADD COLUMN IF NOT EXISTS project_id LowCardinality(String) DEFAULT ''", table),
+		fmt.Sprintf("ALTER TABLE nginx_analytics.%s ADD COLUMN IF NOT EXISTS environment_id LowCardinality(String) DEFAULT ''", table),
+		fmt.Sprintf("ALTER TABLE nginx_analytics.%s ADD INDEX IF NOT EXISTS idx_project_id project_id TYPE set(1024) GRANULARITY 4", table),
+		fmt.Sprintf("ALTER TABLE nginx_analytics.%s ADD INDEX IF NOT EXISTS idx_environment_id environment_id TYPE set(1024) GRANULARITY 4", table),
+	}
+}
+
+// migrateTenantColumns adds the tenant columns to every stamped table
+func (db *ClickHouseDB) migrateTenantColumns(ctx context.Context) {
+	for _, t := range tenantTables {
+		for _, q := range tenantColumnsDDL(t.name) {
+			if err := db.execDDL(ctx, q); err != nil {
+				analyticsLog.Error().Msgf("ClickHouse migration query failed [%s]: %v", q, err)
+			}
+		}
+	}
+}
+
+// agentTenant is the project and environment of an agent's server assignment; empty for
+// unassigned agents and for rows that do not come from an agent
+type agentTenant struct {
+	ProjectID     string
+	EnvironmentID string
+}
+
+// tenantCache maps agents to their tenant for the flushers, reloading every assignment at once
+// when stale. A failed reload keeps the previous map, so a database outage does not unstamp rows.
+type tenantCache struct {
+	load func() (map[string]agentTenant, error)
+
+	mu      sync.Mutex
+	tenants map[string]agentTenant
+	expires time.Time
+}
+
+func (c *tenantCache) get(agentID string) agentTenant {
+	if c == nil {
+		return agentTenant{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now := time.Now(); now.After(c.expires) {
+		if tenants, err := c.load(); err != nil {
+			analyticsLog.Error().Msgf("Loading agent tenants failed, keeping the previous ones: %v", err)
+		} else {
+			c.tenants = tenants
+		}
+		c.expires = now.Add(tenantCacheTTL)
+	}
+	return c.tenants[agentID]
+}
+
+// SetTenantLookup makes the flushers stamp rows with the tenant of their agent, as load returns them
+func (db *ClickHouseDB) SetTenantLookup(load func() (map[string]agentTenant, error)) {
+	db.tenants.Store(&tenantCache{load: load})
+}
+
+// tenantOf returns the tenant rows of an agent are stamped with
+func (db *ClickHouseDB) tenantOf(agentID string) agentTenant {
+	return db.tenants.Load().get(agentID)
+}
+
+// tenantGroup is the agents of one environment
+type tenantGroup struct {
+	tenant agentTenant
+	agents []string
+}
+
+// groupTenants groups agents by environment, sorted for stable mutations
+func groupTenants(tenants map[string]agentTenant) []tenantGroup {
+	byEnv := map[agentTenant][]string{}
+	for id, t := range tenants {
+		byEnv[t] = append(byEnv[t], id)
+	}
+	groups := make([]tenantGroup, 0, len(byEnv))
+	for t, agents := range byEnv {
+		sort.Strings(agents)
+		groups = append(groups, tenantGroup{tenant: t, agents: agents})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].tenant.EnvironmentID < groups[j].tenant.EnvironmentID })
+	return groups
+}
+
+// BackfillTenantColumns stamps rows without a tenant, ingested before the columns existed or while
+// their agent was unassigned, with the current tenant of their agent. It queues one mutation per
+// table and environment, which ClickHouse runs in the background, and returns how many it queued.
+func (db *ClickHouseDB) BackfillTenantColumns(ctx context.Context, tenants map[string]agentTenant) (int, error) {
+	tables := append([]tenantTable{}, tenantTables...)
+	for _, r := range accessLogRollups {
+		tables = append(tables, tenantTable{r.table, "instance_id"})
+	}
+	groups := groupTenants(tenants)
+	queued := 0
+	for _, t := range tables {
+		for _, g := range groups {
+			if err := db.execDDL(ctx, fmt.Sprintf(`
+				ALTER TABLE nginx_analytics.%s UPDATE project_id = ?, environment_id = ?
+				WHERE project_id = '' AND %s IN (?)`, t.name, t.agentColumn),
+				g.tenant.ProjectID, g.tenant.EnvironmentID, g.agents); err != nil {
+				return queued, fmt.Errorf("%s: %w", t.name, err)
+			}
+			queued++
+		}
+	}
+	return queued, nil
+}
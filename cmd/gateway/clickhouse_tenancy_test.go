@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTenantCache(t *testing.T) {
+	loads := 0
+	fail := false
+	c := &tenantCache{load: func() (map[string]agentTenant, error) {
+		loads++
+		if fail {
+			return nil, errors.New("database down")
+		}
+		return map[string]agentTenant{"a1": {ProjectID: "p1", EnvironmentID: "e1"}}, nil
+	}}
+	if got := c.get("a1"); got.ProjectID != "p1" || got.EnvironmentID != "e1" {
+		t.Errorf("a1 = %+v", got)
+	}
+	if got := c.get("unassigned"); got != (agentTenant{}) {
+		t.Errorf("unassigned = %+v", got)
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, want 1", loads)
+	}
+
+	// A failed reload keeps stamping with the previous tenants
+	fail = true
+	c.expires = c.expires.Add(-2 * tenantCacheTTL)
+	if got := c.get("a1"); got.EnvironmentID != "e1" || loads != 2 {
+		t.Errorf("after failed reload: %+v, %d loads", got, loads)
+	}
+
+	var none *tenantCache
+	if none.get("a1") != (agentTenant{}) {
+		t.Error("a nil cache stamps no tenant")
+	}
+}
+
+func TestGroupTenants(t *testing.T) {
+	groups := groupTenants(map[string]agentTenant{
+		"b": {ProjectID: "p1", EnvironmentID: "e2"},
+		"c": {ProjectID: "p1", EnvironmentID: "e1"},
+		"a": {ProjectID: "p1", EnvironmentID: "e1"},
+	})
+	if len(groups) != 2 || groups[0].tenant.EnvironmentID != "e1" || strings.Join(groups[0].agents, ",") != "a,c" {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestTenantColumnsDDL(t *testing.T) {
+	for _, q := range tenantColumnsDDL("access_logs") {
+		if !strings.HasPrefix(q, "ALTER TABLE nginx_analytics.access_logs ADD ") || !strings.Contains(q, "IF NOT EXISTS") {
+			t.Errorf("not an idempotent migration: %s", q)
+		}
+	}
+	for _, r := range accessLogRollups {
+		if !strings.Contains(r.create, "environment_id") || !strings.Contains(r.selectFrom, "environment_id") {
+			t.Errorf("%s does not carry the tenant columns", r.table)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InsertTerminalSession stores a finished terminal recording (metadata + asciicast body)
+func (db *ClickHouseDB) InsertTerminalSession(ctx context.Context, s TerminalSession, cast string) error {
+	batch, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.terminal_sessions (
+		session_id, agent_id, username, command, remote_addr, started_at, ended_at, duration_ms,
+		bytes_in, bytes_out, truncated, cast, project_id, environment_id
+	)`)
+	if err != nil {
+		return err
+	}
+	tenant := db.tenantOf(s.AgentID)
+	truncated := uint8(0)
+	if s.Truncated {
+		truncated = 1
+	}
+	if err := batch.Append(
+		s.SessionID,
+		s.AgentID,
+		s.Username,
+		s.Command,
+		s.RemoteAddr,
+		s.StartedAt,
+		s.EndedAt,
+		uint64(s.DurationMs),
+		s.BytesIn,
+		s.BytesOut,
+		truncated,
+		cast,
+		tenant.ProjectID,
+		tenant.EnvironmentID,
+	); err != nil {
+		return err
+	}
+	return batch.Send()
+}
+
+// ListTerminalSessions returns recording metadata for an agent, newest first
+func (db *ClickHouseDB) ListTerminalSessions(ctx context.Context, agentID string, limit int) ([]TerminalSession, error) {
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT session_id, agent_id, username, command, remote_addr, started_at, ended_at,
+		       duration_ms, bytes_in, bytes_out, truncated
+		FROM nginx_analytics.terminal_sessions
+		WHERE agent_id = ?
+		ORDER BY started_at DESC
+		LIMIT %d
+	`, limit), agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []TerminalSession{}
+	for rows.Next() {
+		s, err := scanTerminalSession(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetTerminalSession returns a single recording and its asciicast body
+func (db *ClickHouseDB) GetTerminalSession(ctx context.Context, sessionID string) (TerminalSession, string, error) {
+	var cast string
+	row := db.conn.QueryRow(ctx, `
+		SELECT session_id, agent_id, username, command, remote_addr, started_at, ended_at,
+		       duration_ms, bytes_in, bytes_out, truncated, cast
+		FROM nginx_analytics.terminal_sessions
+		WHERE session_id = ?
+		LIMIT 1
+	`, sessionID)
+	s, err := scanTerminalSession(func(dest ...interface{}) error {
+		return row.Scan(append(dest, &cast)...)
+	})
+	if err != nil {
+		return TerminalSession{}, "", err
+	}
+	return s, cast, nil
+}
+
+func scanTerminalSession(scan func(dest ...interface{}) error) (TerminalSession, error) {
+	var (
+		s          TerminalSession
+		startedAt  time.Time
+		endedAt    time.Time
+		durationMs uint64
+		truncated  uint8
+	)
+	if err := scan(&s.SessionID, &s.AgentID, &s.Username, &s.Command, &s.RemoteAddr,
+		&startedAt, &endedAt, &durationMs, &s.BytesIn, &s.BytesOut, &truncated); err != nil {
+		return s, err
+	}
+	s.StartedAt = startedAt
+	s.EndedAt = endedAt
+	s.DurationMs = int64(durationMs)
+	s.Truncated = truncated == 1
+	return s, nil
+}
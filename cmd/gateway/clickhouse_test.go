@@ -97,6 +97,23 @@ func TestSysBatchItem(t *testing.T) {
 	}
 }
 
+func TestFullestProcessFD(t *testing.T) {
+	if fullestProcessFD(nil) != nil {
+		t.Error("expected nil without processes")
+	}
+	procs := []*pb.ProcessFdUsage{
+		{Pid: 1, Role: "master", OpenFds: 40},
+		{Pid: 2, Role: "worker", OpenFds: 900, Limit: 1024, UsedPercent: 87.9},
+		{Pid: 3, Role: "worker", OpenFds: 100, Limit: 1024, UsedPercent: 9.8},
+	}
+	if got := fullestProcessFD(procs); got.Pid != 2 {
+		t.Errorf("fullest = %+v, want pid 2", got)
+	}
+	if got := fullestProcessFD(procs[:1]); got.Pid != 1 {
+		t.Errorf("fullest = %+v, want pid 1", got)
+	}
+}
+
 func TestNginxBatchItem(t *testing.T) {
 	entry := &pb.NginxMetrics{
 		ActiveConnections:  100,
@@ -384,3 +401,28 @@ func BenchmarkParseLogLine(b *testing.B) {
 		parseSimpleLogLine(line)
 	}
 }
+
+func TestTraceSearchClauses(t *testing.T) {
+	where, args, orderBy, err := traceSearchClauses(&pb.TraceRequest{})
+	if err != nil || where != "" || len(args) != 0 || orderBy != "start_time DESC" {
+		t.Errorf("defaults = %q, %v, %q, %v", where, args, orderBy, err)
+	}
+
+	where, args, orderBy, err = traceSearchClauses(&pb.TraceRequest{MinDurationMs: 500, MaxDurationMs: 2000, SortBy: "duration"})
+	if err != nil || where != " AND t.duration_ms >= ? AND t.duration_ms <= ?" || len(args) != 2 || args[0] != 500.0 || args[1] != 2000.0 {
+		t.Errorf("duration range = %q, %v, %v", where, args, err)
+	}
+	if orderBy != "t.duration_ms DESC, start_time DESC" {
+		t.Errorf("order by = %q", orderBy)
+	}
+
+	for _, bad := range []*pb.TraceRequest{
+		{MinDurationMs: -1},
+		{MinDurationMs: 2000, MaxDurationMs: 500},
+		{SortBy: "status"},
+	} {
+		if _, _, _, err := traceSearchClauses(bad); err == nil {
+			t.Errorf("%+v should be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ThreatOffender aggregates requests from one address listed by a threat feed
+type ThreatOffender struct {
+	ClientIP  string    `json:"client_ip"`
+	Feed      string    `json:"feed"`
+	Category  string    `json:"category"`
+	Requests  uint64    `json:"requests"`
+	Errors    uint64    `json:"errors"`
+	Agents    []string  `json:"agents"`
+	Country   string    `json:"country"`
+	ASN       uint32    `json:"asn"`
+	ISP       string    `json:"isp"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Blocked   bool      `json:"blocked"`
+}
+
+// ThreatFeedHits counts tagged requests per feed
+type ThreatFeedHits struct {
+	Feed     string `json:"feed"`
+	Category string `json:"category"`
+	Requests uint64 `json:"requests"`
+	Sources  uint64 `json:"sources"`
+}
+
+func threatWhereClause(start, end time.Time, agentIDs []string) (string, []interface{}) {
+	where := "WHERE timestamp >= ? AND timestamp <= ? AND threat_feed != ''"
+	args := []interface{}{start, end}
+	if len(agentIDs) > 0 {
+		where += " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+	return where, args
+}
+
+// GetThreatOffenders returns the most active addresses matched by threat feeds
+func (db *ClickHouseDB) GetThreatOffenders(ctx context.Context, start, end time.Time, agentIDs []string, limit int) ([]ThreatOffender, error) {
+	where, args := threatWhereClause(start, end, agentIDs)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			client_ip,
+			anyLast(threat_feed),
+			anyLast(threat_category),
+			count() as requests,
+			countIf(status >= 400),
+			groupUniqArray(10)(instance_id),
+			anyLast(country_code),
+			anyLast(asn),
+			anyLast(isp),
+			min(timestamp),
+			max(timestamp)
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY client_ip
+		ORDER BY requests DESC
+		LIMIT %d
+	`, where, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offenders := []ThreatOffender{}
+	for rows.Next() {
+		var o ThreatOffender
+		if err := rows.Scan(&o.ClientIP, &o.Feed, &o.Category, &o.Requests, &o.Errors, &o.Agents,
+			&o.Country, &o.ASN, &o.ISP, &o.FirstSeen, &o.LastSeen); err != nil {
+			return nil, err
+		}
+		offenders = append(offenders, o)
+	}
+	return offenders, rows.Err()
+}
+
+// GetThreatFeedHits returns tagged request counts per feed
+func (db *ClickHouseDB) GetThreatFeedHits(ctx context.Context, start, end time.Time, agentIDs []string) ([]ThreatFeedHits, error) {
+	where, args := threatWhereClause(start, end, agentIDs)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT threat_feed, anyLast(threat_category), count() as requests, uniq(client_ip)
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY threat_feed
+		ORDER BY requests DESC
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []ThreatFeedHits{}
+	for rows.Next() {
+		var h ThreatFeedHits
+		if err := rows.Scan(&h.Feed, &h.Category, &h.Requests, &h.Sources); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// InsertSecurityEvents queues attack detection findings for batched insertion
+func (db *ClickHouseDB) InsertSecurityEvents(events []SecurityEvent) {
+	for _, ev := range events {
+		select {
+		case db.secChan <- ev:
+		default:
+			writeLog.Warn().Msgf("Security event queue full, dropping %s event from %s", ev.EventType, ev.ClientIP)
+		}
+	}
+}
+
+func (db *ClickHouseDB) runSecurityFlusher() {
+	ticker := time.NewTicker(5 * time.Second)
+	batch := make([]SecurityEvent, 0, 500)
+	for {
+		select {
+		case ev := <-db.secChan:
+			batch = append(batch, ev)
+			if len(batch) >= 500 {
+				db.flushSecurityEvents(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				db.flushSecurityEvents(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (db *ClickHouseDB) flushSecurityEvents(batch []SecurityEvent) {
+	ctx := context.Background()
+	b, err := db.conn.PrepareBatch(ctx, `INSERT INTO nginx_analytics.security_events (
+		timestamp, instance_id, client_ip, event_type, severity, rule_id,
+		request_method, request_uri, status, user_agent, count, details, project_id, environment_id
+	)`)
+	if err != nil {
+		analyticsLog.Error().Msgf("flushSecurityEvents: PrepareBatch failed: %v", err)
+		db.insertFailed("security_events")
+		return
+	}
+	for _, ev := range batch {
+		tenant := db.tenantOf(ev.AgentID)
+		if err := b.Append(ev.Timestamp, ev.AgentID, ev.ClientIP, ev.EventType, ev.Severity, ev.RuleID,
+			ev.Method, ev.URI, uint16(ev.Status), ev.UserAgent, ev.Count, ev.Details, tenant.ProjectID, tenant.EnvironmentID); err != nil {
+			analyticsLog.Error().Msgf("flushSecurityEvents: Append failed: %v", err)
+			db.insertFailed("security_events")
+			return
+		}
+	}
+	if err := b.Send(); err != nil {
+		analyticsLog.Error().Msgf("flushSecurityEvents: Send failed: %v", err)
+		db.insertFailed("security_events")
+	}
+}
+
+// SecurityEventCount counts findings per event type
+type SecurityEventCount struct {
+	EventType string `json:"event_type"`
+	Events    uint64 `json:"events"`
+	Sources   uint64 `json:"sources"`
+}
+
+func securityEventsWhere(start, end time.Time, agentIDs []string, eventType string) (string, []interface{}) {
+	where := "WHERE timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{start, end}
+	if len(agentIDs) > 0 {
+		where += " AND instance_id IN (?)"
+		args = append(args, agentIDs)
+	}
+	if eventType != "" {
+		where += " AND event_type = ?"
+		args = append(args, eventType)
+	}
+	return where, args
+}
+
+// GetSecurityEvents returns the most recent findings, newest first
+func (db *ClickHouseDB) GetSecurityEvents(ctx context.Context, start, end time.Time, agentIDs []string, eventType string, limit int) ([]SecurityEvent, error) {
+	where, args := securityEventsWhere(start, end, agentIDs, eventType)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT timestamp, instance_id, client_ip, event_type, severity, rule_id,
+			request_method, request_uri, status, user_agent, count, details
+		FROM nginx_analytics.security_events
+		%s
+		ORDER BY timestamp DESC
+		LIMIT %d
+	`, where, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []SecurityEvent{}
+	for rows.Next() {
+		var ev SecurityEvent
+		var status uint16
+		if err := rows.Scan(&ev.Timestamp, &ev.AgentID, &ev.ClientIP, &ev.EventType, &ev.Severity, &ev.RuleID,
+			&ev.Method, &ev.URI, &status, &ev.UserAgent, &ev.Count, &ev.Details); err != nil {
+			return nil, err
+		}
+		ev.Status = int(status)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// GetSecurityEventCounts returns finding and distinct source counts per event type
+func (db *ClickHouseDB) GetSecurityEventCounts(ctx context.Context, start, end time.Time, agentIDs []string) ([]SecurityEventCount, error) {
+	where, args := securityEventsWhere(start, end, agentIDs, "")
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT event_type, count() as events, uniq(client_ip)
+		FROM nginx_analytics.security_events
+		%s
+		GROUP BY event_type
+		ORDER BY events DESC
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []SecurityEventCount{}
+	for rows.Next() {
+		var c SecurityEventCount
+		if err := rows.Scan(&c.EventType, &c.Events, &c.Sources); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// RateLimitCounter counts limit_req rejections per agent and zone
+type RateLimitCounter struct {
+	AgentID  string    `json:"agent_id"`
+	Zone     string    `json:"zone"`
+	Limited  uint64    `json:"limited"`
+	Sources  uint64    `json:"sources"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// GetRateLimitCounters returns rejected request counts parsed from agent error logs
+func (db *ClickHouseDB) GetRateLimitCounters(ctx context.Context, start, end time.Time, agentIDs []string) ([]RateLimitCounter, error) {
+	where, args := securityEventsWhere(start, end, agentIDs, eventRateLimited)
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT instance_id, rule_id, sum(count) as limited, uniq(client_ip), max(timestamp)
+		FROM nginx_analytics.security_events
+		%s
+		GROUP BY instance_id, rule_id
+		ORDER BY limited DESC
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counters := []RateLimitCounter{}
+	for rows.Next() {
+		var c RateLimitCounter
+		if err := rows.Scan(&c.AgentID, &c.Zone, &c.Limited, &c.Sources, &c.LastSeen); err != nil {
+			return nil, err
+		}
+		counters = append(counters, c)
+	}
+	return counters, rows.Err()
+}
+
+// AlertOffender is a client contributing to a fired alert
+type AlertOffender struct {
+	ClientIP string
+	Agents   []string
+	Hits     uint64
+}
+
+// GetAlertOffenders returns the clients behind metricType over the last windowSec, most active first.
+// Host metrics such as cpu and memory cannot be attributed to clients and return an error.
+func (db *ClickHouseDB) GetAlertOffenders(ctx context.Context, metricType string, windowSec, limit int) ([]AlertOffender, error) {
+	var query string
+	args := []interface{}{}
+	if eventType, ok := securityEventsMetricFilter(metricType); ok {
+		filter := ""
+		if eventType != "" {
+			filter = " AND event_type = ?"
+			args = append(args, eventType)
+		}
+		query = fmt.Sprintf(`
+			SELECT client_ip, groupUniqArray(instance_id), sum(count) as hits
+			FROM nginx_analytics.security_events
+			WHERE timestamp >= now() - INTERVAL %d SECOND AND client_ip != ''%s
+			GROUP BY client_ip
+			ORDER BY hits DESC
+			LIMIT %d
+		`, windowSec, filter, limit)
+	} else {
+		var hits string
+		switch metricType {
+		case "error_rate":
+			hits = "countIf(status >= 400)"
+		case "rps":
+			hits = "count()"
+		default:
+			return nil, fmt.Errorf("metric %s cannot be attributed to client addresses", metricType)
+		}
+		query = fmt.Sprintf(`
+			SELECT client_ip, groupUniqArray(instance_id), %s as hits
+			FROM nginx_analytics.access_logs
+			WHERE timestamp >= now() - INTERVAL %d SECOND AND client_ip != ''
+			GROUP BY client_ip
+			HAVING hits > 0
+			ORDER BY hits DESC
+			LIMIT %d
+		`, hits, windowSec, limit)
+	}
+
+	rows, err := db.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offenders := []AlertOffender{}
+	for rows.Next() {
+		var o AlertOffender
+		if err := rows.Scan(&o.ClientIP, &o.Agents, &o.Hits); err != nil {
+			return nil, err
+		}
+		offenders = append(offenders, o)
+	}
+	return offenders, rows.Err()
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"log"
 	"time"
 )
 
@@ -111,7 +110,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get summary stats
 	summary, err := db.getVisitorSummary(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: summary failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: summary failed: %v", err)
 	} else {
 		resp.Summary = *summary
 	}
@@ -119,7 +118,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get browser stats
 	browsers, err := db.getBrowserStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: browsers failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: browsers failed: %v", err)
 	} else {
 		resp.Browsers = browsers
 	}
@@ -127,7 +126,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get OS stats
 	osStats, err := db.getOSStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: os failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: os failed: %v", err)
 	} else {
 		resp.OperatingSystems = osStats
 	}
@@ -135,7 +134,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get referrer stats
 	referrers, err := db.getReferrerStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: referrers failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: referrers failed: %v", err)
 	} else {
 		resp.Referrers = referrers
 	}
@@ -143,7 +142,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get 404 stats
 	notFound, err := db.getNotFoundStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: notfound failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: notfound failed: %v", err)
 	} else {
 		resp.NotFound = notFound
 	}
@@ -151,7 +150,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get hourly distribution
 	hourly, err := db.getHourlyDistribution(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: hourly failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: hourly failed: %v", err)
 	} else {
 		resp.HourlyStats = hourly
 	}
@@ -159,7 +158,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get device type stats
 	devices, err := db.getDeviceStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: devices failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: devices failed: %v", err)
 	} else {
 		resp.DeviceTypes = devices
 	}
@@ -167,7 +166,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Get static file stats
 	staticFiles, err := db.getStaticFileStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: static files failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: static files failed: %v", err)
 	} else {
 		resp.StaticFiles = staticFiles
 	}
@@ -175,7 +174,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// Top requested URLs (GoAccess "Requested Files")
 	requestedURLs, err := db.getTopRequestedURLs(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: requested URLs failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: requested URLs failed: %v", err)
 	} else {
 		resp.RequestedURLs = requestedURLs
 	}
@@ -183,7 +182,7 @@ func (db *ClickHouseDB) GetVisitorAnalytics(ctx context.Context, timeWindow stri
 	// HTTP status code distribution (GoAccess "Status Codes")
 	statusCodes, err := db.getStatusCodeStats(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics: status codes failed: %v", err)
+		analyticsLog.Error().Msgf("GetVisitorAnalytics: status codes failed: %v", err)
 	} else {
 		resp.StatusCodes = statusCodes
 	}
@@ -3,16 +3,18 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/avika-ai/avika/internal/common/logging"
 	"github.com/avika-ai/avika/internal/common/vault"
 	"gopkg.in/yaml.v3"
 )
 
+var configLog = logging.NewLogger("config")
+
 // Port constants - all application ports in range 5020-5050
 const (
 	DefaultGRPCPort    = 5020
@@ -28,6 +30,9 @@ type ServerConfig struct {
 	MetricsPort int    `yaml:"metrics_port"`
 	Host        string `yaml:"host"`
 	UpdatesDir  string `yaml:"updates_dir"` // Directory for serving agent updates
+	// Base URL agents are told to update from (e.g. https://gateway.example.com/updates); empty
+	// uses http://<http address>/updates. Set an HTTPS URL for agents with UPDATE_REQUIRE_HTTPS.
+	UpdatesURL string `yaml:"updates_url"`
 
 	// Legacy fields for backward compatibility
 	Port   string `yaml:"port"`
@@ -41,26 +46,69 @@ type SecurityConfig struct {
 	RateLimitRPS      int           `yaml:"rate_limit_rps"`
 	RateLimitBurst    int           `yaml:"rate_limit_burst"`
 	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	DrainTimeout      time.Duration `yaml:"drain_timeout"` // Wait for drained agents to close their streams on shutdown; 0 disables draining
 	EnableTLS         bool          `yaml:"enable_tls"`
 	TLSCertFile       string        `yaml:"tls_cert_file"`
 	TLSKeyFile        string        `yaml:"tls_key_file"`
 	TLSCACertFile     string        `yaml:"tls_ca_cert_file"` // CA for verifying client certs (mTLS)
 	RequireClientCert bool          `yaml:"require_client_cert"`
+
+	// Attack detection on ingested access logs
+	AttackDetection     bool          `yaml:"attack_detection"`
+	BruteForceThreshold int           `yaml:"brute_force_threshold"` // 401/403 responses from one IP per window
+	BruteForceWindow    time.Duration `yaml:"brute_force_window"`
+
+	// nginx include written on agents for managed rate limit rules, relative to the main config dir
+	RateLimitConfigPath string `yaml:"rate_limit_config_path"`
+
+	// Alert-driven bans ("ban" action on alert rules)
+	AutoBanPath   string   `yaml:"auto_ban_path"`    // nginx include holding the deny rules, relative to the main config dir
+	AutoBanMaxIPs int      `yaml:"auto_ban_max_ips"` // Most clients banned per alert firing
+	AutoBanIgnore []string `yaml:"auto_ban_ignore"`  // Addresses/CIDRs never banned
+
+	// Interval between TLS/security header scans of agent virtual hosts; 0 disables scheduled scans
+	TLSScanInterval time.Duration `yaml:"tls_scan_interval"`
 }
 
 // DatabaseConfig holds PostgreSQL configuration
 type DatabaseConfig struct {
-	DSN             string        `yaml:"dsn"`
-	MaxOpenConns    int           `yaml:"max_open_conns"`
+	DSN          string `yaml:"dsn"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
+	// Deprecated: the pool closes connections idle for ConnMaxIdleTime instead
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	MinConns        int           `yaml:"min_conns"` // kept open even when idle
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 	MaxRetries      int           `yaml:"max_retries"`
 	RetryInterval   time.Duration `yaml:"retry_interval"`
+	// QueryTimeout bounds the queries of the context-aware DB methods that have no earlier
+	// deadline; StatementTimeout is PostgreSQL's statement_timeout for every query. 0 disables.
+	QueryTimeout     time.Duration `yaml:"query_timeout"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	// SlowQueryThreshold logs and counts the queries taking longer; 0 disables
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// StatementCacheSize is the number of prepared statements each connection keeps. 0 disables
+	// preparing statements, for PgBouncer in transaction pooling mode.
+	StatementCacheSize int `yaml:"statement_cache_size"`
 }
 
 // ClickHouseConfig holds ClickHouse configuration
 type ClickHouseConfig struct {
-	Address         string        `yaml:"address"`
+	// host:port, or a comma-separated list of the nodes of a cluster, which connections are
+	// spread over round-robin and fail over between
+	Address string `yaml:"address"`
+	// Nodes reads go to instead, e.g. replicas that take no inserts; comma-separated. Reads use
+	// Address when empty.
+	ReadAddress string `yaml:"read_address"`
+	// Cluster of the servers' remote_servers that schema changes run ON CLUSTER on; empty for a
+	// single node
+	Cluster string `yaml:"cluster"`
+	// Replicated*MergeTree tables under ReplicationPath in Keeper (default
+	// /clickhouse/tables/{shard}/{database}/{table}); requires Cluster
+	Replicated      bool   `yaml:"replicated"`
+	ReplicationPath string `yaml:"replication_path"`
+	// Rows stored in <table>_local on every shard behind Distributed tables; requires Cluster
+	Distributed     bool          `yaml:"distributed"`
 	Database        string        `yaml:"database"`
 	Username        string        `yaml:"username"`
 	Password        string        `yaml:"password"`
@@ -69,6 +117,44 @@ type ClickHouseConfig struct {
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 	BatchSize       int           `yaml:"batch_size"`
 	FlushInterval   time.Duration `yaml:"flush_interval"`
+	// AnalyticsCacheTTL is how long analytics and geo responses are shared between viewers of
+	// the same window and filters; with 0 only concurrent identical queries are shared
+	AnalyticsCacheTTL time.Duration `yaml:"analytics_cache_ttl"`
+}
+
+// AnalyticsConfig picks where access logs and agent metrics are kept for the analytics pages.
+// "postgres" keeps them in the gateway's own PostgreSQL database, as TimescaleDB hypertables when
+// the extension is installed, so small installs get persistent analytics without ClickHouse; the
+// features only ClickHouse serves (traces, geo, visitors, security events, ...) stay unavailable.
+type AnalyticsConfig struct {
+	Backend       string `yaml:"backend"`        // clickhouse (default) or postgres
+	RetentionDays int    `yaml:"retention_days"` // postgres: rows older than this are deleted
+	// URITemplates group request paths into the endpoints of top endpoint lists, first match wins;
+	// paths no template matches have their ids collapsed with CollapseIDs (default true)
+	URITemplates []URITemplate `yaml:"uri_templates"`
+	CollapseIDs  bool          `yaml:"collapse_ids"`
+	// Query bounds the ad-hoc queries of /api/analytics/query (ClickHouse only)
+	Query AnalyticsQueryConfig `yaml:"query"`
+}
+
+// AnalyticsQueryConfig holds the guard rails of ad-hoc analytics queries
+type AnalyticsQueryConfig struct {
+	Timeout     time.Duration              `yaml:"timeout"`       // ClickHouse max_execution_time of a query
+	MaxRowsRead int64                      `yaml:"max_rows_read"` // ClickHouse max_rows_to_read of a query, 0 unlimited
+	Roles       map[string]QueryRolePolicy `yaml:"roles"`         // Keyed by user role ("admin", "viewer", ...); roles without an entry may not query
+}
+
+// QueryRolePolicy is what one role may query
+type QueryRolePolicy struct {
+	QueriesPerHour int           `yaml:"queries_per_hour"` // Per user, 0 unlimited
+	MaxRows        int           `yaml:"max_rows"`         // Cap on the LIMIT of a query
+	MaxRange       time.Duration `yaml:"max_range"`        // Longest time range of a query
+}
+
+// URITemplate names the endpoint of the request paths a pattern matches
+type URITemplate struct {
+	Pattern  string `yaml:"pattern"`  // Go regexp over the path, without the query string
+	Template string `yaml:"template"` // e.g. /users/{id}/orders; $1 or ${name} insert the pattern's groups
 }
 
 // KafkaConfig holds Kafka/Redpanda configuration
@@ -89,10 +175,18 @@ type SMTPConfig struct {
 
 // AgentConfig holds agent-related gateway settings
 type AgentConfig struct {
-	MgmtPort         int           `yaml:"mgmt_port"`
+	MgmtPort int `yaml:"mgmt_port"`
+	// A Commander stream with no message for this long is half-open and dropped, 0 never
 	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout"`
+	// gRPC keepalive pings on idle agent connections and how long to wait for their ack
+	KeepaliveTime    time.Duration `yaml:"keepalive_time"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout"`
 	PruneInterval    time.Duration `yaml:"prune_interval"`
-	RetentionPeriod  time.Duration `yaml:"retention_period"`
+	RetentionPeriod  time.Duration `yaml:"retention_period"` // Offline agents are pruned after this, 0 never; environments may override
+	// Keep ClickHouse analytics of pruned agents instead of deleting them; environments may override
+	ArchiveOnPrune bool `yaml:"archive_on_prune"`
+	// Default offline time before agent_offline alert rules fire; a rule's threshold (minutes) overrides it
+	OfflineAlertAfter time.Duration `yaml:"offline_alert_after"`
 }
 
 // SecretsProviderConfig holds configuration for the secrets management provider
@@ -174,6 +268,154 @@ type SAMLConfig struct {
 	AutoProvision  bool              `yaml:"auto_provision"`
 }
 
+// TerminalConfig holds the access policy for the web terminal (/terminal)
+type TerminalConfig struct {
+	ProhibitSudo       bool                          `yaml:"prohibit_sudo"`        // Block sudo/su/doas for every role
+	MaxSessionDuration time.Duration                 `yaml:"max_session_duration"` // 0 = unlimited
+	Roles              map[string]TerminalRolePolicy `yaml:"roles"`                // Keyed by user role ("admin", "viewer", ...)
+}
+
+// TerminalRolePolicy restricts what a given role may do in a terminal session
+type TerminalRolePolicy struct {
+	ReadOnly           bool          `yaml:"read_only"`            // Drop all stdin; output is still streamed
+	AllowedCommands    []string      `yaml:"allowed_commands"`     // If set, only these commands may be run
+	DeniedCommands     []string      `yaml:"denied_commands"`      // Commands (or command prefixes) that are always blocked
+	MaxSessionDuration time.Duration `yaml:"max_session_duration"` // Overrides the global limit when > 0
+}
+
+// GeoIPConfig configures MaxMind GeoLite2/GeoIP2 databases for client IP geolocation
+type GeoIPConfig struct {
+	DatabaseDir    string        `yaml:"database_dir"`    // Where downloaded databases are stored
+	CityDatabase   string        `yaml:"city_database"`   // City .mmdb path (default: <database_dir>/GeoLite2-City.mmdb)
+	ASNDatabase    string        `yaml:"asn_database"`    // ASN .mmdb path (default: <database_dir>/GeoLite2-ASN.mmdb)
+	AccountID      string        `yaml:"account_id"`      // MaxMind account ID
+	LicenseKey     string        `yaml:"license_key"`     // MaxMind license key; enables auto-download
+	UpdateInterval time.Duration `yaml:"update_interval"` // Re-download databases older than this
+	Offline        bool          `yaml:"offline"`         // Air-gapped: never contact MaxMind, use local databases only
+	BotNetworks    string        `yaml:"bot_networks"`    // Extra crawler ranges, one "<cidr> [category] [name]" per line
+}
+
+// ThreatIntelConfig configures threat intelligence feeds used to tag requests from known bad IPs.
+// Enrichment is active when at least one feed is configured.
+type ThreatIntelConfig struct {
+	Feeds           []ThreatFeedConfig `yaml:"feeds"`
+	RefreshInterval time.Duration      `yaml:"refresh_interval"` // How often feeds are re-downloaded
+	BlocklistPath   string             `yaml:"blocklist_path"`   // nginx include written on agents, relative to the main config dir
+}
+
+// ThreatFeedConfig describes one threat intelligence feed
+type ThreatFeedConfig struct {
+	Name          string `yaml:"name"`
+	Type          string `yaml:"type"` // spamhaus_drop, abuseipdb, list
+	URL           string `yaml:"url"`
+	Path          string `yaml:"path"` // Local file for list feeds
+	APIKey        string `yaml:"api_key"`
+	MinConfidence int    `yaml:"min_confidence"` // AbuseIPDB confidence threshold
+	Category      string `yaml:"category"`
+}
+
+// VulnerabilityConfig configures the CVE feed agents' nginx, OpenSSL and kernel versions are
+// matched against. Without a feed URL or path the feed built into the gateway is used.
+type VulnerabilityConfig struct {
+	FeedURL         string        `yaml:"feed_url"`
+	FeedPath        string        `yaml:"feed_path"`        // Local feed file, used instead of feed_url
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // How often the feed is reloaded
+}
+
+// SyntheticConfig configures the synthetic check runner of this gateway
+type SyntheticConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Region      string `yaml:"region"`      // Checks limited to regions only run on gateways in one of them
+	Concurrency int    `yaml:"concurrency"` // Checks executed in parallel
+	// Loopback, private, link-local and cloud metadata addresses checks may reach, as addresses or
+	// CIDRs; any other internal address is refused
+	AllowedNetworks []string `yaml:"allowed_networks"`
+}
+
+// OTLPConfig configures the OTLP receiver, which ingests logs, metrics and traces from OpenTelemetry
+// collectors on hosts without an agent. OTLP/HTTP is served on the HTTP port under /v1/.
+type OTLPConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	GRPCPort  int    `yaml:"grpc_port"`  // OTLP/gRPC listener, separate from the agents' gRPC port
+	AuthToken string `yaml:"auth_token"` // Bearer token collectors must send; empty accepts any sender
+}
+
+// LogExportConfig configures the Kafka sink that mirrors ingested access logs to a topic, so
+// downstream consumers don't have to query ClickHouse. Brokers default to kafka.brokers.
+type LogExportConfig struct {
+	Enabled           bool              `yaml:"enabled"`
+	Brokers           string            `yaml:"brokers"`            // Comma-separated broker list
+	Topic             string            `yaml:"topic"`              // Topic for agents without a routed environment
+	EnvironmentTopics map[string]string `yaml:"environment_topics"` // Environment slug -> topic, e.g. {"production": "nginx-logs-prod"}
+	Format            string            `yaml:"format"`             // json or protobuf
+	BufferSize        int               `yaml:"buffer_size"`        // Entries queued before new ones are dropped
+}
+
+// LogRedactionConfig makes the gateway scrub ingested access logs with the LOG_REDACT settings of
+// each agent's environment, as agents do, so entries from agents too old to redact or with the
+// settings overridden locally are scrubbed before they are stored
+type LogRedactionConfig struct {
+	EnforceOnIngest bool `yaml:"enforce_on_ingest"`
+}
+
+// ArchiveConfig configures the nightly cold archive of access logs to S3-compatible object storage
+// (S3, GCS with HMAC keys, MinIO) as Parquet, so logs outlive the access_logs TTL. ClickHouse
+// writes and reads the objects itself, so it needs network access to the bucket.
+type ArchiveConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	URL              string `yaml:"url"`    // Path-style bucket URL with optional prefix, e.g. https://s3.eu-west-1.amazonaws.com/avika-logs/archive
+	Region           string `yaml:"region"` // Signing region for deletes; "auto" for GCS
+	AccessKeyID      string `yaml:"access_key_id"`
+	SecretAccessKey  string `yaml:"secret_access_key"`
+	RunAt            string `yaml:"run_at"`             // Nightly run time, HH:MM UTC
+	ArchiveAfterDays int    `yaml:"archive_after_days"` // A day is archived once it is this many days old (below the access_logs retention)
+	RetentionDays    int    `yaml:"retention_days"`     // Archives older than this are deleted; 0 keeps them forever
+}
+
+// RetentionConfig sets how many days ClickHouse tables keep rows, overriding the built-in
+// defaults. Retention set through the API, per table or per environment, takes precedence.
+type RetentionConfig struct {
+	Tables map[string]int `yaml:"tables"` // Table name -> days, e.g. {"access_logs": 14}
+}
+
+// BandwidthConfig is the optional egress cost model of the analytics page: bytes sent are priced
+// per GB (2^30 bytes). With no price set, no costs are shown.
+type BandwidthConfig struct {
+	EgressCostPerGB float64 `yaml:"egress_cost_per_gb"`
+	Currency        string  `yaml:"currency"` // ISO 4217 code, e.g. USD
+}
+
+// TracingConfig controls the traces built from access logs. Requests carrying a trace context from
+// an upstream tracing system (traceparent or X-Request-ID, logged by NGINX) join that trace.
+type TracingConfig struct {
+	// TraceIDHeader is the authoritative trace context when a request carries both: "traceparent"
+	// or "x-request-id". The other one is used when it is missing.
+	TraceIDHeader string `yaml:"trace_id_header"`
+	// AccessLogSpans is which access logs get spans: "traced" (requests with a trace context),
+	// "all" or "off"
+	AccessLogSpans string `yaml:"access_log_spans"`
+}
+
+// HAConfig runs several gateways behind one load balancer. Each agent is owned by the gateway
+// holding its stream, recorded in Postgres so every gateway lists the whole fleet, and singleton
+// background jobs (pruning, alerting, retention, ...) run on the gateway holding the leader lease.
+type HAConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	InstanceID string        `yaml:"instance_id"` // Unique per gateway (default: hostname, the pod name)
+	LeaseTTL   time.Duration `yaml:"lease_ttl"`   // Leases of a gateway that stopped renewing expire after this
+	// AdvertiseAddress is the gRPC host:port other gateways forward commands for this gateway's
+	// agents to (default: $POD_IP, else the instance ID, on the gRPC port)
+	AdvertiseAddress string `yaml:"advertise_address"`
+}
+
+// KubernetesConfig configures the pod garbage collector, which removes agents whose pod was deleted.
+// It uses the gateway's in-cluster service account, which needs get on pods.
+type KubernetesConfig struct {
+	PodGC         bool          `yaml:"pod_gc"`
+	PodGCInterval time.Duration `yaml:"pod_gc_interval"`
+	PodGCGrace    time.Duration `yaml:"pod_gc_grace"` // Offline time before the pod is looked up
+}
+
 // LLMConfig holds configuration for AI/LLM-powered features
 type LLMConfig struct {
 	Enabled          bool    `yaml:"enabled"`           // Enable AI-powered error analysis
@@ -197,6 +439,7 @@ type Config struct {
 	Security        SecurityConfig        `yaml:"security"`
 	Database        DatabaseConfig        `yaml:"database"`
 	ClickHouse      ClickHouseConfig      `yaml:"clickhouse"`
+	Analytics       AnalyticsConfig       `yaml:"analytics"`
 	Kafka           KafkaConfig           `yaml:"kafka"`
 	SMTP            SMTPConfig            `yaml:"smtp"`
 	Agent           AgentConfig           `yaml:"agent"`
@@ -207,10 +450,27 @@ type Config struct {
 	LDAP            LDAPConfig            `yaml:"ldap"`
 	SAML            SAMLConfig            `yaml:"saml"`
 	LLM             LLMConfig             `yaml:"llm"`
+	Terminal        TerminalConfig        `yaml:"terminal"`
+	GeoIP           GeoIPConfig           `yaml:"geoip"`
+	ThreatIntel     ThreatIntelConfig     `yaml:"threat_intel"`
+	Vulnerabilities VulnerabilityConfig   `yaml:"vulnerabilities"`
+	Synthetic       SyntheticConfig       `yaml:"synthetic"`
+	Kubernetes      KubernetesConfig      `yaml:"kubernetes"`
+	OTLP            OTLPConfig            `yaml:"otlp"`
+	LogExport       LogExportConfig       `yaml:"log_export"`
+	LogRedaction    LogRedactionConfig    `yaml:"log_redaction"`
+	Archive         ArchiveConfig         `yaml:"archive"`
+	Retention       RetentionConfig       `yaml:"retention"`
+	Bandwidth       BandwidthConfig       `yaml:"bandwidth"`
+	Tracing         TracingConfig         `yaml:"tracing"`
+	HA              HAConfig              `yaml:"ha"`
 	// LogLevel is the minimum log level: debug, info, warn, error (default: info). Set via LOG_LEVEL env.
 	LogLevel string `yaml:"log_level"`
 	// LogFormat is output format: json or console. Set via LOG_FORMAT env.
 	LogFormat string `yaml:"log_format"`
+	// LogComponents sets the level of single components, e.g. "db=debug,analytics=warn"; the others
+	// log at LogLevel. Set via LOG_COMPONENTS env; changed at runtime with PUT /api/ops/log-levels.
+	LogComponents string `yaml:"log_components"`
 }
 
 // GetGRPCAddress returns the formatted gRPC listen address
@@ -272,7 +532,7 @@ func LoadConfig(path string) (*Config, error) {
 	// Load secrets from external provider if configured
 	if cfg.SecretsProvider.Provider == "vault" || cfg.SecretsProvider.Provider == "cyberark" {
 		if err := loadExternalSecrets(cfg); err != nil {
-			log.Printf("Warning: Failed to load external secrets from %s: %v (using fallback config)", cfg.SecretsProvider.Provider, err)
+			configLog.Warn().Msgf("Failed to load external secrets from %s: %v (using fallback config)", cfg.SecretsProvider.Provider, err)
 		}
 	}
 
@@ -299,34 +559,34 @@ func loadExternalSecrets(cfg *Config) error {
 			if i == maxRetries-1 {
 				return fmt.Errorf("Vault is not available at %s after %d retries", cfg.SecretsProvider.Vault.Address, maxRetries)
 			}
-			log.Printf("Vault not ready, retrying in %d seconds... (attempt %d/%d)", i+1, i+1, maxRetries)
+			configLog.Warn().Msgf("Vault not ready, retrying in %d seconds... (attempt %d/%d)", i+1, i+1, maxRetries)
 			time.Sleep(time.Duration(i+1) * time.Second)
 		}
 
-		log.Printf("Loading secrets from Vault at %s", cfg.SecretsProvider.Vault.Address)
+		configLog.Info().Msgf("Loading secrets from Vault at %s", cfg.SecretsProvider.Vault.Address)
 
 		// Load PostgreSQL credentials
 		if pgDSN, err := vaultClient.GetPostgresDSN(); err == nil {
 			cfg.Database.DSN = pgDSN
-			log.Println("Loaded PostgreSQL credentials from Vault")
+			configLog.Info().Msg("Loaded PostgreSQL credentials from Vault")
 		} else {
-			log.Printf("Warning: Could not load PostgreSQL config from Vault: %v", err)
+			configLog.Warn().Msgf("Could not load PostgreSQL config from Vault: %v", err)
 		}
 
 		// Load ClickHouse credentials
 		if chAddr, err := vaultClient.GetClickHouseAddr(); err == nil {
 			cfg.ClickHouse.Address = chAddr
-			log.Println("Loaded ClickHouse credentials from Vault")
+			configLog.Info().Msg("Loaded ClickHouse credentials from Vault")
 		} else {
-			log.Printf("Warning: Could not load ClickHouse config from Vault: %v", err)
+			configLog.Warn().Msgf("Could not load ClickHouse config from Vault: %v", err)
 		}
 
 		// Load Redpanda/Kafka credentials
 		if rpCfg, err := vaultClient.GetRedpandaConfig(); err == nil {
 			cfg.Kafka.Brokers = rpCfg.Brokers
-			log.Println("Loaded Redpanda credentials from Vault")
+			configLog.Info().Msg("Loaded Redpanda credentials from Vault")
 		} else {
-			log.Printf("Warning: Could not load Redpanda config from Vault: %v", err)
+			configLog.Warn().Msgf("Could not load Redpanda config from Vault: %v", err)
 		}
 
 		return nil
@@ -334,7 +594,7 @@ func loadExternalSecrets(cfg *Config) error {
 		// Mock CyberArk implementation - in a real app, this would use the CyberArk Go SDK
 		// Due to time constraints, this logs the intention but relies on the external-secrets operator
 		// injecting standard kubernetes secrets, which means the config doesn't actually need to pull them directly.
-		log.Printf("CyberArk provider configured. Relying on external-secrets operator injected kubernetes secrets in deployment.")
+		configLog.Info().Msg("CyberArk provider configured. Relying on external-secrets operator injected kubernetes secrets in deployment.")
 		return nil
 	}
 
@@ -359,15 +619,31 @@ func defaultConfig() *Config {
 			RateLimitRPS:    100,
 			RateLimitBurst:  200,
 			ShutdownTimeout: 30 * time.Second,
+			DrainTimeout:    15 * time.Second,
 			EnableTLS:       false,
+
+			AttackDetection:     true,
+			BruteForceThreshold: 20,
+			BruteForceWindow:    5 * time.Minute,
+			RateLimitConfigPath: "conf.d/avika-ratelimits.conf",
+			AutoBanPath:         "conf.d/avika-bans.conf",
+			AutoBanMaxIPs:       20,
+			AutoBanIgnore:       []string{"127.0.0.0/8", "::1"},
+			TLSScanInterval:     24 * time.Hour,
 		},
 		Database: DatabaseConfig{
-			DSN:             "", // Set via DATABASE_URL or DB_DSN environment variable
-			MaxOpenConns:    25,
-			MaxIdleConns:    25,
-			ConnMaxLifetime: 5 * time.Minute,
-			MaxRetries:      3,
-			RetryInterval:   2 * time.Second,
+			DSN:                "", // Set via DATABASE_URL or DB_DSN environment variable
+			MaxOpenConns:       25,
+			MaxIdleConns:       25,
+			MinConns:           2,
+			ConnMaxLifetime:    5 * time.Minute,
+			ConnMaxIdleTime:    10 * time.Minute,
+			MaxRetries:         3,
+			RetryInterval:      2 * time.Second,
+			QueryTimeout:       30 * time.Second,
+			StatementTimeout:   5 * time.Minute,
+			SlowQueryThreshold: 500 * time.Millisecond,
+			StatementCacheSize: 512,
 		},
 		ClickHouse: ClickHouseConfig{
 			Address:         "localhost:9000",
@@ -379,6 +655,21 @@ func defaultConfig() *Config {
 			ConnMaxLifetime: 30 * time.Minute,
 			BatchSize:       10000,
 			FlushInterval:   time.Second,
+
+			AnalyticsCacheTTL: 10 * time.Second,
+		},
+		Analytics: AnalyticsConfig{
+			Backend:       "clickhouse",
+			RetentionDays: 7,
+			CollapseIDs:   true,
+			Query: AnalyticsQueryConfig{
+				Timeout:     30 * time.Second,
+				MaxRowsRead: 1_000_000_000,
+				Roles: map[string]QueryRolePolicy{
+					"admin":  {QueriesPerHour: 600, MaxRows: 10000, MaxRange: 90 * 24 * time.Hour},
+					"viewer": {QueriesPerHour: 60, MaxRows: 1000, MaxRange: 7 * 24 * time.Hour},
+				},
+			},
 		},
 		Kafka: KafkaConfig{
 			Brokers: "localhost:9092",
@@ -391,10 +682,13 @@ func defaultConfig() *Config {
 			UseTLS: true,
 		},
 		Agent: AgentConfig{
-			MgmtPort:         DefaultAgentPort,
-			HeartbeatTimeout: 30 * time.Second,
-			PruneInterval:    12 * time.Hour,
-			RetentionPeriod:  10 * 24 * time.Hour,
+			MgmtPort:          DefaultAgentPort,
+			HeartbeatTimeout:  30 * time.Second,
+			KeepaliveTime:     20 * time.Second,
+			KeepaliveTimeout:  10 * time.Second,
+			PruneInterval:     12 * time.Hour,
+			RetentionPeriod:   10 * 24 * time.Hour,
+			OfflineAlertAfter: 10 * time.Minute,
 		},
 		SecretsProvider: SecretsProviderConfig{
 			Provider: "none",
@@ -483,11 +777,75 @@ func defaultConfig() *Config {
 			CacheTTLMinutes:  30,
 			FallbackProvider: "",
 		},
+		Terminal: TerminalConfig{
+			ProhibitSudo:       false,
+			MaxSessionDuration: 0,
+			Roles: map[string]TerminalRolePolicy{
+				"viewer": {ReadOnly: true},
+			},
+		},
+		GeoIP: GeoIPConfig{
+			DatabaseDir:    "/var/lib/avika/geoip",
+			UpdateInterval: 7 * 24 * time.Hour,
+		},
+		ThreatIntel: ThreatIntelConfig{
+			RefreshInterval: 6 * time.Hour,
+			BlocklistPath:   "conf.d/avika-blocklist.conf",
+		},
+		Vulnerabilities: VulnerabilityConfig{
+			RefreshInterval: 24 * time.Hour,
+		},
+		Synthetic: SyntheticConfig{
+			Enabled:     true,
+			Region:      "default",
+			Concurrency: 20,
+		},
+		Kubernetes: KubernetesConfig{
+			PodGCInterval: 1 * time.Minute,
+			PodGCGrace:    2 * time.Minute,
+		},
+		OTLP: OTLPConfig{
+			GRPCPort: 4317,
+		},
+		LogExport: LogExportConfig{
+			Topic:             "avika-access-logs",
+			EnvironmentTopics: make(map[string]string),
+			Format:            "json",
+			BufferSize:        10000,
+		},
+		Archive: ArchiveConfig{
+			Region:           "us-east-1",
+			RunAt:            "02:00",
+			ArchiveAfterDays: 1,
+			RetentionDays:    365,
+		},
+		Retention: RetentionConfig{
+			Tables: make(map[string]int),
+		},
+		Bandwidth: BandwidthConfig{
+			Currency: "USD",
+		},
+		Tracing: TracingConfig{
+			TraceIDHeader:  "traceparent",
+			AccessLogSpans: "traced",
+		},
+		HA: HAConfig{
+			InstanceID: hostname(),
+			LeaseTTL:   30 * time.Second,
+		},
 		LogLevel:  "info",
 		LogFormat: "json",
 	}
 }
 
+// hostname is the default gateway instance ID
+func hostname() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "gateway"
+}
+
 // loadEnvOverrides applies environment variable overrides
 func loadEnvOverrides(cfg *Config) {
 	// Logging (dynamic level via LOG_LEVEL, format via LOG_FORMAT)
@@ -497,6 +855,9 @@ func loadEnvOverrides(cfg *Config) {
 	if v := os.Getenv("LOG_FORMAT"); v != "" {
 		cfg.LogFormat = v
 	}
+	if v := os.Getenv("LOG_COMPONENTS"); v != "" {
+		cfg.LogComponents = v
+	}
 	// Server
 	if v := os.Getenv("GATEWAY_GRPC_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
@@ -525,6 +886,9 @@ func loadEnvOverrides(cfg *Config) {
 	if v := os.Getenv("GATEWAY_UPDATES_DIR"); v != "" {
 		cfg.Server.UpdatesDir = v
 	}
+	if v := os.Getenv("GATEWAY_UPDATES_URL"); v != "" {
+		cfg.Server.UpdatesURL = v
+	}
 
 	// Security
 	if v := os.Getenv("ALLOWED_ORIGINS"); v != "" {
@@ -538,6 +902,38 @@ func loadEnvOverrides(cfg *Config) {
 			cfg.Security.RateLimitRPS = rps
 		}
 	}
+	if v := os.Getenv("ATTACK_DETECTION_ENABLED"); v != "" {
+		cfg.Security.AttackDetection = v == "true" || v == "1"
+	}
+	if v := os.Getenv("BRUTE_FORCE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Security.BruteForceThreshold = n
+		}
+	}
+	if v := os.Getenv("BRUTE_FORCE_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Security.BruteForceWindow = d
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_CONFIG_PATH"); v != "" {
+		cfg.Security.RateLimitConfigPath = v
+	}
+	if v := os.Getenv("AUTO_BAN_PATH"); v != "" {
+		cfg.Security.AutoBanPath = v
+	}
+	if v := os.Getenv("AUTO_BAN_MAX_IPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Security.AutoBanMaxIPs = n
+		}
+	}
+	if v := os.Getenv("AUTO_BAN_IGNORE"); v != "" {
+		cfg.Security.AutoBanIgnore = strings.Split(v, ",")
+	}
+	if v := os.Getenv("TLS_SCAN_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Security.TLSScanInterval = d
+		}
+	}
 	if v := os.Getenv("ENABLE_TLS"); v != "" {
 		cfg.Security.EnableTLS = v == "true" || v == "1"
 	}
@@ -564,11 +960,51 @@ func loadEnvOverrides(cfg *Config) {
 			cfg.Database.MaxIdleConns = conns
 		}
 	}
+	if v := os.Getenv("DB_MIN_CONNS"); v != "" {
+		if conns, err := strconv.Atoi(v); err == nil {
+			cfg.Database.MinConns = conns
+		}
+	}
+	if v := os.Getenv("DB_QUERY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Database.QueryTimeout = d
+		}
+	}
+	if v := os.Getenv("DB_STATEMENT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Database.StatementTimeout = d
+		}
+	}
+	if v := os.Getenv("DB_SLOW_QUERY_THRESHOLD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Database.SlowQueryThreshold = d
+		}
+	}
+	if v := os.Getenv("DB_STATEMENT_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Database.StatementCacheSize = n
+		}
+	}
 
 	// ClickHouse
 	if v := os.Getenv("CLICKHOUSE_ADDR"); v != "" {
 		cfg.ClickHouse.Address = v
 	}
+	if v := os.Getenv("CLICKHOUSE_READ_ADDR"); v != "" {
+		cfg.ClickHouse.ReadAddress = v
+	}
+	if v := os.Getenv("CLICKHOUSE_CLUSTER"); v != "" {
+		cfg.ClickHouse.Cluster = v
+	}
+	if v := os.Getenv("CLICKHOUSE_REPLICATED"); v != "" {
+		cfg.ClickHouse.Replicated = v == "true" || v == "1"
+	}
+	if v := os.Getenv("CLICKHOUSE_REPLICATION_PATH"); v != "" {
+		cfg.ClickHouse.ReplicationPath = v
+	}
+	if v := os.Getenv("CLICKHOUSE_DISTRIBUTED"); v != "" {
+		cfg.ClickHouse.Distributed = v == "true" || v == "1"
+	}
 	if v := os.Getenv("CLICKHOUSE_DATABASE"); v != "" {
 		cfg.ClickHouse.Database = v
 	}
@@ -583,6 +1019,24 @@ func loadEnvOverrides(cfg *Config) {
 			cfg.ClickHouse.BatchSize = size
 		}
 	}
+	if v := os.Getenv("ANALYTICS_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ClickHouse.AnalyticsCacheTTL = d
+		}
+	}
+
+	// Analytics backend
+	if v := os.Getenv("ANALYTICS_BACKEND"); v != "" {
+		cfg.Analytics.Backend = strings.ToLower(v)
+	}
+	if v := os.Getenv("ANALYTICS_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.Analytics.RetentionDays = n
+		}
+	}
+	if v := os.Getenv("ANALYTICS_COLLAPSE_IDS"); v != "" {
+		cfg.Analytics.CollapseIDs = v == "true" || v == "1"
+	}
 
 	// Kafka
 	if v := os.Getenv("KAFKA_BROKERS"); v != "" {
@@ -617,6 +1071,39 @@ func loadEnvOverrides(cfg *Config) {
 			cfg.Agent.MgmtPort = port
 		}
 	}
+	if v := os.Getenv("AGENT_PRUNE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.PruneInterval = d
+		}
+	}
+	if v := os.Getenv("AGENT_RETENTION_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.RetentionPeriod = d
+		}
+	}
+	if v := os.Getenv("AGENT_ARCHIVE_ON_PRUNE"); v != "" {
+		cfg.Agent.ArchiveOnPrune = v == "true" || v == "1"
+	}
+	if v := os.Getenv("AGENT_OFFLINE_ALERT_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.OfflineAlertAfter = d
+		}
+	}
+	if v := os.Getenv("AGENT_HEARTBEAT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.HeartbeatTimeout = d
+		}
+	}
+	if v := os.Getenv("AGENT_KEEPALIVE_TIME"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.KeepaliveTime = d
+		}
+	}
+	if v := os.Getenv("AGENT_KEEPALIVE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Agent.KeepaliveTimeout = d
+		}
+	}
 
 	// Secrets Provider (Replaces Vault toggle)
 	if v := os.Getenv("SECRETS_PROVIDER"); v != "" {
@@ -838,4 +1325,235 @@ func loadEnvOverrides(cfg *Config) {
 	if v := os.Getenv("LLM_FALLBACK_PROVIDER"); v != "" {
 		cfg.LLM.FallbackProvider = v
 	}
+
+	// Terminal access policy
+	if v := os.Getenv("TERMINAL_PROHIBIT_SUDO"); v != "" {
+		cfg.Terminal.ProhibitSudo = v == "true" || v == "1"
+	}
+	if v := os.Getenv("TERMINAL_MAX_SESSION_DURATION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Terminal.MaxSessionDuration = d
+		}
+	}
+
+	// GeoIP
+	if v := os.Getenv("GEOIP_DATABASE_DIR"); v != "" {
+		cfg.GeoIP.DatabaseDir = v
+	}
+	if v := os.Getenv("GEOIP_CITY_DATABASE"); v != "" {
+		cfg.GeoIP.CityDatabase = v
+	}
+	if v := os.Getenv("GEOIP_ASN_DATABASE"); v != "" {
+		cfg.GeoIP.ASNDatabase = v
+	}
+	if v := os.Getenv("MAXMIND_ACCOUNT_ID"); v != "" {
+		cfg.GeoIP.AccountID = v
+	}
+	if v := os.Getenv("MAXMIND_LICENSE_KEY"); v != "" {
+		cfg.GeoIP.LicenseKey = v
+	}
+	if v := os.Getenv("GEOIP_UPDATE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.GeoIP.UpdateInterval = d
+		}
+	}
+	if v := os.Getenv("GEOIP_OFFLINE"); v != "" {
+		cfg.GeoIP.Offline = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GEOIP_BOT_NETWORKS"); v != "" {
+		cfg.GeoIP.BotNetworks = v
+	}
+
+	// Threat intelligence
+	if v := os.Getenv("THREAT_INTEL_REFRESH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ThreatIntel.RefreshInterval = d
+		}
+	}
+	if v := os.Getenv("THREAT_INTEL_SPAMHAUS_DROP"); v == "true" || v == "1" {
+		cfg.ThreatIntel.Feeds = append(cfg.ThreatIntel.Feeds, ThreatFeedConfig{Name: "spamhaus-drop", Type: "spamhaus_drop"})
+	}
+	if v := os.Getenv("ABUSEIPDB_API_KEY"); v != "" {
+		cfg.ThreatIntel.Feeds = append(cfg.ThreatIntel.Feeds, ThreatFeedConfig{Name: "abuseipdb", Type: "abuseipdb", APIKey: v})
+	}
+	if v := os.Getenv("THREAT_INTEL_LISTS"); v != "" {
+		// Comma-separated URLs or local paths of plain IP/CIDR lists
+		for i, src := range strings.Split(v, ",") {
+			src = strings.TrimSpace(src)
+			if src == "" {
+				continue
+			}
+			feed := ThreatFeedConfig{Name: fmt.Sprintf("custom-%d", i+1), Type: "list"}
+			if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+				feed.URL = src
+			} else {
+				feed.Path = src
+			}
+			cfg.ThreatIntel.Feeds = append(cfg.ThreatIntel.Feeds, feed)
+		}
+	}
+	if v := os.Getenv("THREAT_INTEL_BLOCKLIST_PATH"); v != "" {
+		cfg.ThreatIntel.BlocklistPath = v
+	}
+
+	// CVE feed
+	if v := os.Getenv("CVE_FEED_URL"); v != "" {
+		cfg.Vulnerabilities.FeedURL = v
+	}
+	if v := os.Getenv("CVE_FEED_PATH"); v != "" {
+		cfg.Vulnerabilities.FeedPath = v
+	}
+	if v := os.Getenv("CVE_FEED_REFRESH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Vulnerabilities.RefreshInterval = d
+		}
+	}
+
+	// Synthetic checks
+	if v := os.Getenv("SYNTHETIC_ENABLED"); v != "" {
+		cfg.Synthetic.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("SYNTHETIC_REGION"); v != "" {
+		cfg.Synthetic.Region = v
+	}
+	if v := os.Getenv("SYNTHETIC_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Synthetic.Concurrency = n
+		}
+	}
+	if v := os.Getenv("SYNTHETIC_ALLOWED_NETWORKS"); v != "" {
+		cfg.Synthetic.AllowedNetworks = strings.Split(v, ",")
+	}
+
+	// Kubernetes pod GC
+	if v := os.Getenv("KUBE_POD_GC"); v != "" {
+		cfg.Kubernetes.PodGC = v == "true" || v == "1"
+	}
+	if v := os.Getenv("KUBE_POD_GC_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Kubernetes.PodGCInterval = d
+		}
+	}
+
+	// OTLP receiver
+	if v := os.Getenv("OTLP_ENABLED"); v != "" {
+		cfg.OTLP.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("OTLP_GRPC_PORT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.OTLP.GRPCPort = n
+		}
+	}
+	if v := os.Getenv("OTLP_AUTH_TOKEN"); v != "" {
+		cfg.OTLP.AuthToken = v
+	}
+
+	// Kafka access log export
+	if v := os.Getenv("LOG_EXPORT_ENABLED"); v != "" {
+		cfg.LogExport.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("LOG_EXPORT_BROKERS"); v != "" {
+		cfg.LogExport.Brokers = v
+	}
+	if v := os.Getenv("LOG_EXPORT_TOPIC"); v != "" {
+		cfg.LogExport.Topic = v
+	}
+	if v := os.Getenv("LOG_EXPORT_ENVIRONMENT_TOPICS"); v != "" {
+		var topics map[string]string
+		if err := json.Unmarshal([]byte(v), &topics); err == nil {
+			cfg.LogExport.EnvironmentTopics = topics
+		}
+	}
+	if v := os.Getenv("LOG_EXPORT_FORMAT"); v != "" {
+		cfg.LogExport.Format = v
+	}
+	if v := os.Getenv("LOG_EXPORT_BUFFER_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.LogExport.BufferSize = n
+		}
+	}
+
+	// Access log redaction on ingest
+	if v := os.Getenv("LOG_REDACTION_ENFORCE_ON_INGEST"); v != "" {
+		cfg.LogRedaction.EnforceOnIngest = v == "true" || v == "1"
+	}
+
+	// Access log cold archive
+	if v := os.Getenv("ARCHIVE_ENABLED"); v != "" {
+		cfg.Archive.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("ARCHIVE_URL"); v != "" {
+		cfg.Archive.URL = v
+	}
+	if v := os.Getenv("ARCHIVE_REGION"); v != "" {
+		cfg.Archive.Region = v
+	}
+	if v := os.Getenv("ARCHIVE_ACCESS_KEY_ID"); v != "" {
+		cfg.Archive.AccessKeyID = v
+	}
+	if v := os.Getenv("ARCHIVE_SECRET_ACCESS_KEY"); v != "" {
+		cfg.Archive.SecretAccessKey = v
+	}
+	if v := os.Getenv("ARCHIVE_RUN_AT"); v != "" {
+		cfg.Archive.RunAt = v
+	}
+	if v := os.Getenv("ARCHIVE_AFTER_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Archive.ArchiveAfterDays = n
+		}
+	}
+	if v := os.Getenv("ARCHIVE_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Archive.RetentionDays = n
+		}
+	}
+
+	// ClickHouse table retention
+	if v := os.Getenv("RETENTION_TABLES"); v != "" {
+		var tables map[string]int
+		if err := json.Unmarshal([]byte(v), &tables); err == nil {
+			cfg.Retention.Tables = tables
+		}
+	}
+
+	// Egress cost model
+	if v := os.Getenv("EGRESS_COST_PER_GB"); v != "" {
+		if price, err := strconv.ParseFloat(v, 64); err == nil && price >= 0 {
+			cfg.Bandwidth.EgressCostPerGB = price
+		}
+	}
+	if v := os.Getenv("EGRESS_COST_CURRENCY"); v != "" {
+		cfg.Bandwidth.Currency = v
+	}
+
+	// Tracing
+	if v := os.Getenv("TRACE_ID_HEADER"); v != "" {
+		cfg.Tracing.TraceIDHeader = strings.ToLower(v)
+	}
+	if v := os.Getenv("ACCESS_LOG_SPANS"); v != "" {
+		cfg.Tracing.AccessLogSpans = v
+	}
+
+	// Agent drain on shutdown
+	if v := os.Getenv("GATEWAY_DRAIN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Security.DrainTimeout = d
+		}
+	}
+
+	// High availability
+	if v := os.Getenv("HA_ENABLED"); v != "" {
+		cfg.HA.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("GATEWAY_INSTANCE_ID"); v != "" {
+		cfg.HA.InstanceID = v
+	}
+	if v := os.Getenv("GATEWAY_ADVERTISE_ADDRESS"); v != "" {
+		cfg.HA.AdvertiseAddress = v
+	}
+	if v := os.Getenv("HA_LEASE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.HA.LeaseTTL = d
+		}
+	}
 }
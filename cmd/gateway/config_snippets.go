@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// configSnippetCategories are the supported snippet categories
+var configSnippetCategories = map[string]bool{
+	"security_headers": true,
+	"logging":          true,
+	"tls":              true,
+	"other":            true,
+}
+
+var (
+	snippetSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,99}$`)
+	// snippetIncludeRe matches an include of a managed snippet file, quoted or not
+	snippetIncludeRe = regexp.MustCompile(`\binclude\s+["']?avika/snippets/([a-z0-9][a-z0-9-]*)\.conf["']?\s*;`)
+)
+
+// snippetIncludePath is where a snippet lands, relative to the NGINX conf dir
+func snippetIncludePath(slug string) string {
+	return "avika/snippets/" + slug + ".conf"
+}
+
+// snippetRefs returns the slugs of the snippets a config includes, in order of first use.
+// Commented-out includes are ignored.
+func snippetRefs(content string) []string {
+	var slugs []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, m := range snippetIncludeRe.FindAllStringSubmatch(line, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				slugs = append(slugs, m[1])
+			}
+		}
+	}
+	return slugs
+}
+
+// configSnippetRequest is the body of POST and PUT /api/config-snippets. The slug is only read on
+// create, as configs include the snippet by it; restore_version copies an earlier version's content.
+type configSnippetRequest struct {
+	Name           string `json:"name"`
+	Slug           string `json:"slug"`
+	Category       string `json:"category"`
+	Description    string `json:"description"`
+	Content        string `json:"content"`
+	RestoreVersion int    `json:"restore_version"`
+}
+
+// newConfigSnippet validates a create request; the slug defaults to one derived from the name
+func (req configSnippetRequest) newConfigSnippet() (*ConfigSnippet, error) {
+	if req.Name == "" || strings.TrimSpace(req.Content) == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and content are required")
+	}
+	sn := &ConfigSnippet{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Category:    req.Category,
+		Description: req.Description,
+		Content:     req.Content,
+	}
+	if sn.Slug == "" {
+		sn.Slug = strings.Trim(templateSlugRe.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	}
+	if !snippetSlugRe.MatchString(sn.Slug) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid slug %q: use lowercase letters, digits and dashes", sn.Slug)
+	}
+	if sn.Category == "" {
+		sn.Category = "other"
+	}
+	if !configSnippetCategories[sn.Category] {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown category %q", sn.Category)
+	}
+	if err := checkSnippetContent(sn); err != nil {
+		return nil, err
+	}
+	sn.IncludePath = snippetIncludePath(sn.Slug)
+	return sn, nil
+}
+
+// update applies the set fields of an update request to sn and reports whether its content changed
+func (req configSnippetRequest) update(sn *ConfigSnippet, restored *ConfigSnippetVersion) (bool, error) {
+	if req.Name != "" {
+		sn.Name = req.Name
+	}
+	if req.Category != "" {
+		if !configSnippetCategories[req.Category] {
+			return false, status.Errorf(codes.InvalidArgument, "unknown category %q", req.Category)
+		}
+		sn.Category = req.Category
+	}
+	if req.Description != "" {
+		sn.Description = req.Description
+	}
+	content := sn.Content
+	switch {
+	case restored != nil:
+		content = restored.Content
+	case strings.TrimSpace(req.Content) != "":
+		content = req.Content
+	}
+	changed := content != sn.Content
+	sn.Content = content
+	if err := checkSnippetContent(sn); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// checkSnippetContent rejects snippets including managed snippets: they are written on their own
+// and nothing would write what they include
+func checkSnippetContent(sn *ConfigSnippet) error {
+	if refs := snippetRefs(sn.Content); len(refs) > 0 {
+		return status.Errorf(codes.InvalidArgument, "snippets cannot include other snippets (%s)", snippetIncludePath(refs[0]))
+	}
+	return nil
+}
+
+// materializeSnippets writes the snippets a config includes to the agent before the config is
+// pushed, so its nginx -t finds them. Snippets whose current version is already on the agent are
+// left alone.
+func (s *server) materializeSnippets(ctx context.Context, client pb.AgentServiceClient, agentID, content string) error {
+	for _, slug := range snippetRefs(content) {
+		sn, err := s.db.GetConfigSnippetBySlug(slug)
+		if err != nil {
+			return fmt.Errorf("snippet %s: %w", slug, err)
+		}
+		if sn == nil {
+			return fmt.Errorf("config includes unknown snippet %s", snippetIncludePath(slug))
+		}
+		d, err := s.db.GetSnippetDeployment(sn.ID, agentID)
+		if err != nil {
+			return fmt.Errorf("snippet %s: %w", slug, err)
+		}
+		if d != nil && d.Version == sn.Version && d.Status == "applied" {
+			continue
+		}
+		if err := s.writeSnippet(ctx, client, agentID, sn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSnippet writes a snippet to its include file on the agent, which tests the config and
+// reloads, and records the outcome
+func (s *server) writeSnippet(ctx context.Context, client pb.AgentServiceClient, agentID string, sn *ConfigSnippet) error {
+	d := &SnippetDeployment{AgentID: agentID, Version: sn.Version, Status: "applied"}
+	res, err := client.UpdateConfig(ctx, &pb.ConfigUpdate{
+		InstanceId: agentID,
+		ConfigPath: sn.IncludePath,
+		NewContent: sn.Content,
+	})
+	switch {
+	case err != nil:
+		d.Status, d.Error = "failed", err.Error()
+	case !res.Success:
+		d.Status, d.Error = "failed", res.Error
+	}
+	if err := s.db.SaveSnippetDeployment(sn.ID, d); err != nil {
+		gatewayLog.Error().Msgf("Failed to record snippet %s on %s: %v", sn.Slug, agentID, err)
+	}
+	if d.Status == "failed" {
+		return fmt.Errorf("snippet %s: %s", sn.Slug, d.Error)
+	}
+	return nil
+}
+
+// recordSnippetDeployments tracks which snippet version each agent of a batch received. Rolled
+// back agents keep their previous record, as their previous file was restored.
+func (s *server) recordSnippetDeployments(snippetID string, targets map[string]*batchTarget, results map[string]*pb.AgentUpdateResult) {
+	for id, r := range results {
+		d := &SnippetDeployment{AgentID: id, Version: targets[id].snippetVersion, Status: "applied"}
+		switch r.Status {
+		case "success":
+		case "failed":
+			d.Status, d.Error = "failed", r.Error
+		default:
+			continue
+		}
+		if err := s.db.SaveSnippetDeployment(snippetID, d); err != nil {
+			gatewayLog.Error().Msgf("Failed to record snippet deployment for %s: %v", id, err)
+		}
+	}
+}
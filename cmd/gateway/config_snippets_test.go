@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnippetRefs(t *testing.T) {
+	content := `http {
+    include avika/snippets/security-headers.conf;
+    # include avika/snippets/old-logging.conf;
+    server { include "avika/snippets/tls.conf"; include avika/snippets/security-headers.conf; }
+    include conf.d/*.conf;
+    ssi_include avika/snippets/not-a-directive.conf;
+}`
+	want := []string{"security-headers", "tls"}
+	if got := snippetRefs(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("snippetRefs = %v, want %v", got, want)
+	}
+}
+
+func TestNewConfigSnippet(t *testing.T) {
+	sn, err := configSnippetRequest{Name: "Security Headers", Content: "add_header X-Frame-Options DENY;"}.newConfigSnippet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sn.Slug != "security-headers" || sn.Category != "other" || sn.IncludePath != "avika/snippets/security-headers.conf" {
+		t.Errorf("snippet = %+v", sn)
+	}
+
+	bad := []configSnippetRequest{
+		{Name: "TLS"},
+		{Name: "TLS", Slug: "../tls", Content: "ssl_protocols TLSv1.3;"},
+		{Name: "TLS", Category: "ssl", Content: "ssl_protocols TLSv1.3;"},
+		{Name: "Both", Content: "include avika/snippets/tls.conf;"},
+	}
+	for _, req := range bad {
+		if _, err := req.newConfigSnippet(); err == nil {
+			t.Errorf("%+v should be rejected", req)
+		}
+	}
+}
+
+func TestConfigSnippetUpdate(t *testing.T) {
+	sn := &ConfigSnippet{Name: "TLS", Category: "tls", Content: "ssl_protocols TLSv1.2 TLSv1.3;", Version: 2}
+
+	changed, err := configSnippetRequest{Description: "Modern TLS"}.update(sn, nil)
+	if err != nil || changed || sn.Description != "Modern TLS" {
+		t.Errorf("metadata update: changed=%v err=%v snippet=%+v", changed, err, sn)
+	}
+	changed, err = configSnippetRequest{Content: "ssl_protocols TLSv1.3;"}.update(sn, nil)
+	if err != nil || !changed || sn.Content != "ssl_protocols TLSv1.3;" {
+		t.Errorf("content update: changed=%v err=%v snippet=%+v", changed, err, sn)
+	}
+	changed, _ = configSnippetRequest{RestoreVersion: 1}.update(sn, &ConfigSnippetVersion{Version: 1, Content: "ssl_protocols TLSv1.2 TLSv1.3;"})
+	if !changed || sn.Content != "ssl_protocols TLSv1.2 TLSv1.3;" {
+		t.Errorf("restore: changed=%v snippet=%+v", changed, sn)
+	}
+}
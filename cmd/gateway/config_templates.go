@@ -0,0 +1,683 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// configTemplateTypes are the supported template_type values
+var configTemplateTypes = map[string]bool{
+	"nginx_main_conf": true,
+	"server_block":    true,
+	"location_block":  true,
+	"upstream_block":  true,
+	"ssl_params":      true,
+}
+
+var (
+	// templatePlaceholderRe matches {{name}} / {{ name }} placeholders
+	templatePlaceholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.]*)\s*\}\}`)
+	templateSlugRe        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+const configTemplateColumns = `id, project_id, environment_id, group_id, name, COALESCE(description, ''), template_type,
+	content, variables, defaults, version, is_active, created_by, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanConfigTemplate(row rowScanner) (*pb.ConfigTemplate, error) {
+	var t pb.ConfigTemplate
+	var projectID, environmentID, groupID, createdBy sql.NullString
+	var variablesData, defaultsData []byte
+	var createdAt, updatedAt sql.NullTime
+
+	if err := row.Scan(&t.Id, &projectID, &environmentID, &groupID, &t.Name, &t.Description, &t.TemplateType,
+		&t.Content, &variablesData, &defaultsData, &t.Version, &t.IsActive, &createdBy, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	t.ProjectId = projectID.String
+	t.EnvironmentId = environmentID.String
+	t.GroupId = groupID.String
+	t.CreatedBy = createdBy.String
+	if createdAt.Valid {
+		t.CreatedAt = createdAt.Time.Unix()
+	}
+	if updatedAt.Valid {
+		t.UpdatedAt = updatedAt.Time.Unix()
+	}
+
+	var vars []TemplateVar
+	_ = json.Unmarshal(variablesData, &vars)
+	t.Variables = convertTemplateVarsToProto(vars)
+	_ = json.Unmarshal(defaultsData, &t.Defaults)
+	return &t, nil
+}
+
+// ListConfigTemplates returns templates, optionally filtered by scope and type
+func (s *server) ListConfigTemplates(ctx context.Context, req *pb.ListConfigTemplatesRequest) (*pb.ListConfigTemplatesResponse, error) {
+	query := `SELECT ` + configTemplateColumns + `
+		FROM config_templates
+		WHERE ($1 = '' OR project_id::text = $1)
+		  AND ($2 = '' OR environment_id::text = $2)
+		  AND ($3 = '' OR group_id::text = $3)
+		  AND ($4 = '' OR template_type = $4)
+		ORDER BY name`
+
+	rows, err := s.db.conn.QueryContext(ctx, query, req.ProjectId, req.EnvironmentId, req.GroupId, req.TemplateType)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to query templates: %v", err)
+	}
+	defer rows.Close()
+
+	templates := []*pb.ConfigTemplate{}
+	for rows.Next() {
+		t, err := scanConfigTemplate(rows)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return &pb.ListConfigTemplatesResponse{Templates: templates}, nil
+}
+
+// GetConfigTemplate returns a single template
+func (s *server) GetConfigTemplate(ctx context.Context, req *pb.GetConfigTemplateRequest) (*pb.ConfigTemplate, error) {
+	if req.TemplateId == "" {
+		return nil, status.Error(codes.InvalidArgument, "template_id is required")
+	}
+	t, err := scanConfigTemplate(s.db.conn.QueryRowContext(ctx,
+		`SELECT `+configTemplateColumns+` FROM config_templates WHERE id = $1`, req.TemplateId))
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "template not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get template: %v", err)
+	}
+	return t, nil
+}
+
+// CreateConfigTemplate creates a template and records it as version 1
+func (s *server) CreateConfigTemplate(ctx context.Context, req *pb.CreateConfigTemplateRequest) (*pb.ConfigTemplate, error) {
+	if req.Name == "" || req.Content == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and content are required")
+	}
+	if !configTemplateTypes[req.TemplateType] {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown template_type %q", req.TemplateType)
+	}
+	if err := validateTemplateVariableDefs(req.Variables); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	variablesJSON, _ := json.Marshal(convertTemplateVarsFromProto(req.Variables))
+	defaultsJSON, _ := json.Marshal(nonNilMap(req.Defaults))
+	username := getUsernameFromContext(ctx)
+
+	tx, err := s.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create template: %v", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanConfigTemplate(tx.QueryRowContext(ctx, `
+		INSERT INTO config_templates (project_id, environment_id, group_id, name, description, template_type,
+			content, variables, defaults, version, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, $10)
+		RETURNING `+configTemplateColumns,
+		nullIfEmpty(req.ProjectId), nullIfEmpty(req.EnvironmentId), nullIfEmpty(req.GroupId),
+		req.Name, req.Description, req.TemplateType, req.Content, variablesJSON, defaultsJSON, username))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create template: %v", err)
+	}
+	if err := insertConfigTemplateVersion(ctx, tx, t, username); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record template version: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create template: %v", err)
+	}
+	return t, nil
+}
+
+// UpdateConfigTemplate updates a template. Changes to content, variables or defaults bump the
+// version and are kept in config_template_versions; restore_version copies an earlier version.
+func (s *server) UpdateConfigTemplate(ctx context.Context, req *pb.UpdateConfigTemplateRequest) (*pb.ConfigTemplate, error) {
+	current, err := s.GetConfigTemplate(ctx, &pb.GetConfigTemplateRequest{TemplateId: req.TemplateId})
+	if err != nil {
+		return nil, err
+	}
+
+	next := &pb.ConfigTemplate{
+		Name:        current.Name,
+		Description: current.Description,
+		Content:     current.Content,
+		Variables:   current.Variables,
+		Defaults:    current.Defaults,
+	}
+	if req.RestoreVersion > 0 {
+		v, err := s.getConfigTemplateVersion(ctx, req.TemplateId, req.RestoreVersion)
+		if err != nil {
+			return nil, err
+		}
+		next.Content, next.Variables, next.Defaults = v.Content, v.Variables, v.Defaults
+	}
+	if req.Name != "" {
+		next.Name = req.Name
+	}
+	if req.Description != "" {
+		next.Description = req.Description
+	}
+	if req.Content != "" {
+		next.Content = req.Content
+	}
+	if len(req.Variables) > 0 {
+		if err := validateTemplateVariableDefs(req.Variables); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		next.Variables = req.Variables
+	}
+	if len(req.Defaults) > 0 {
+		next.Defaults = req.Defaults
+	}
+
+	variablesJSON, _ := json.Marshal(convertTemplateVarsFromProto(next.Variables))
+	defaultsJSON, _ := json.Marshal(nonNilMap(next.Defaults))
+	currentVars, _ := json.Marshal(convertTemplateVarsFromProto(current.Variables))
+	currentDefaults, _ := json.Marshal(nonNilMap(current.Defaults))
+
+	version := current.Version
+	changed := next.Content != current.Content || string(variablesJSON) != string(currentVars) || string(defaultsJSON) != string(currentDefaults)
+	if changed {
+		version++
+	}
+
+	tx, err := s.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update template: %v", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanConfigTemplate(tx.QueryRowContext(ctx, `
+		UPDATE config_templates
+		SET name = $1, description = $2, content = $3, variables = $4, defaults = $5, version = $6, is_active = $7
+		WHERE id = $8
+		RETURNING `+configTemplateColumns,
+		next.Name, next.Description, next.Content, variablesJSON, defaultsJSON, version, req.IsActive, req.TemplateId))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update template: %v", err)
+	}
+	if changed {
+		if err := insertConfigTemplateVersion(ctx, tx, t, getUsernameFromContext(ctx)); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record template version: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update template: %v", err)
+	}
+	return t, nil
+}
+
+// DeleteConfigTemplate deletes a template with its versions and stored variable values
+func (s *server) DeleteConfigTemplate(ctx context.Context, req *pb.DeleteConfigTemplateRequest) (*pb.DeleteConfigTemplateResponse, error) {
+	if req.TemplateId == "" {
+		return nil, status.Error(codes.InvalidArgument, "template_id is required")
+	}
+	result, err := s.db.conn.ExecContext(ctx, "DELETE FROM config_templates WHERE id = $1", req.TemplateId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete template: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, status.Error(codes.NotFound, "template not found")
+	}
+	return &pb.DeleteConfigTemplateResponse{Success: true, Message: "template deleted"}, nil
+}
+
+func insertConfigTemplateVersion(ctx context.Context, tx *sql.Tx, t *pb.ConfigTemplate, username *string) error {
+	variablesJSON, _ := json.Marshal(convertTemplateVarsFromProto(t.Variables))
+	defaultsJSON, _ := json.Marshal(nonNilMap(t.Defaults))
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO config_template_versions (template_id, version, content, variables, defaults, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (template_id, version) DO UPDATE SET
+			content = EXCLUDED.content, variables = EXCLUDED.variables, defaults = EXCLUDED.defaults
+	`, t.Id, t.Version, t.Content, variablesJSON, defaultsJSON, username)
+	return err
+}
+
+// ListConfigTemplateVersions returns the version history of a template, newest first
+func (s *server) ListConfigTemplateVersions(ctx context.Context, req *pb.ListConfigTemplateVersionsRequest) (*pb.ListConfigTemplateVersionsResponse, error) {
+	if req.TemplateId == "" {
+		return nil, status.Error(codes.InvalidArgument, "template_id is required")
+	}
+	rows, err := s.db.conn.QueryContext(ctx, `
+		SELECT template_id, version, content, variables, defaults, created_by, created_at
+		FROM config_template_versions
+		WHERE template_id = $1
+		ORDER BY version DESC
+	`, req.TemplateId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to query versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := []*pb.ConfigTemplateVersion{}
+	for rows.Next() {
+		v, err := scanConfigTemplateVersion(rows)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return &pb.ListConfigTemplateVersionsResponse{Versions: versions}, nil
+}
+
+func (s *server) getConfigTemplateVersion(ctx context.Context, templateID string, version int32) (*pb.ConfigTemplateVersion, error) {
+	v, err := scanConfigTemplateVersion(s.db.conn.QueryRowContext(ctx, `
+		SELECT template_id, version, content, variables, defaults, created_by, created_at
+		FROM config_template_versions
+		WHERE template_id = $1 AND version = $2
+	`, templateID, version))
+	if err == sql.ErrNoRows {
+		return nil, status.Errorf(codes.NotFound, "version %d not found", version)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get version: %v", err)
+	}
+	return v, nil
+}
+
+func scanConfigTemplateVersion(row rowScanner) (*pb.ConfigTemplateVersion, error) {
+	var v pb.ConfigTemplateVersion
+	var variablesData, defaultsData []byte
+	var createdBy sql.NullString
+	var createdAt sql.NullTime
+	if err := row.Scan(&v.TemplateId, &v.Version, &v.Content, &variablesData, &defaultsData, &createdBy, &createdAt); err != nil {
+		return nil, err
+	}
+	var vars []TemplateVar
+	_ = json.Unmarshal(variablesData, &vars)
+	v.Variables = convertTemplateVarsToProto(vars)
+	_ = json.Unmarshal(defaultsData, &v.Defaults)
+	v.CreatedBy = createdBy.String
+	if createdAt.Valid {
+		v.CreatedAt = createdAt.Time.Unix()
+	}
+	return &v, nil
+}
+
+// SetConfigTemplateVariables stores the variable values used when rendering for an environment or agent
+func (s *server) SetConfigTemplateVariables(ctx context.Context, req *pb.SetConfigTemplateVariablesRequest) (*pb.ConfigTemplateVariables, error) {
+	if req.TemplateId == "" || req.ScopeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "template_id and scope_id are required")
+	}
+	if req.Scope != "environment" && req.Scope != "agent" {
+		return nil, status.Error(codes.InvalidArgument, "scope must be environment or agent")
+	}
+
+	if len(req.Variables) == 0 {
+		if _, err := s.db.conn.ExecContext(ctx,
+			`DELETE FROM config_template_values WHERE template_id = $1 AND scope = $2 AND scope_id = $3`,
+			req.TemplateId, req.Scope, req.ScopeId); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to clear variables: %v", err)
+		}
+		return &pb.ConfigTemplateVariables{TemplateId: req.TemplateId, Scope: req.Scope, ScopeId: req.ScopeId}, nil
+	}
+
+	valuesJSON, _ := json.Marshal(req.Variables)
+	var updatedAt sql.NullTime
+	err := s.db.conn.QueryRowContext(ctx, `
+		INSERT INTO config_template_values (template_id, scope, scope_id, variables, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (template_id, scope, scope_id) DO UPDATE SET
+			variables = EXCLUDED.variables, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`, req.TemplateId, req.Scope, req.ScopeId, valuesJSON, getUsernameFromContext(ctx)).Scan(&updatedAt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save variables: %v", err)
+	}
+	return &pb.ConfigTemplateVariables{
+		TemplateId: req.TemplateId,
+		Scope:      req.Scope,
+		ScopeId:    req.ScopeId,
+		Variables:  req.Variables,
+		UpdatedAt:  updatedAt.Time.Unix(),
+	}, nil
+}
+
+// RenderConfigTemplate renders a template for an agent/environment without pushing it (preview)
+func (s *server) RenderConfigTemplate(ctx context.Context, req *pb.RenderConfigTemplateRequest) (*pb.RenderConfigTemplateResponse, error) {
+	t, err := s.GetConfigTemplate(ctx, &pb.GetConfigTemplateRequest{TemplateId: req.TemplateId})
+	if err != nil {
+		return nil, err
+	}
+	if req.Version > 0 && req.Version != t.Version {
+		v, err := s.getConfigTemplateVersion(ctx, req.TemplateId, req.Version)
+		if err != nil {
+			return nil, err
+		}
+		t.Content, t.Variables, t.Defaults, t.Version = v.Content, v.Variables, v.Defaults, v.Version
+	}
+
+	values, err := s.resolveTemplateVariables(ctx, t, req.AgentId, req.EnvironmentId, req.Variables)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve variables: %v", err)
+	}
+	rendered, errs := renderConfigTemplate(t.Content, t.Variables, values)
+	return &pb.RenderConfigTemplateResponse{
+		RenderedContent:   rendered,
+		Valid:             len(errs) == 0,
+		ValidationErrors:  errs,
+		ResolvedVariables: values,
+		TargetPath:        configTemplateTargetPath(t),
+	}, nil
+}
+
+// resolveTemplateVariables builds the variable set for a render. Later sources win:
+// built-ins (agent_id, hostname, agent_ip, environment, environment_slug) < variable defaults <
+// template defaults < environment values < agent values < explicit overrides.
+func (s *server) resolveTemplateVariables(ctx context.Context, t *pb.ConfigTemplate, agentID, environmentID string, overrides map[string]string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if agentID != "" {
+		if resolved, ok := s.resolveAgentID(agentID); ok {
+			agentID = resolved
+		}
+		var hostname, ip, envID sql.NullString
+		err := s.db.conn.QueryRowContext(ctx, `
+			SELECT a.hostname, a.ip, sa.environment_id::text
+			FROM agents a
+			LEFT JOIN server_assignments sa ON sa.agent_id = a.agent_id
+			WHERE a.agent_id = $1
+		`, agentID).Scan(&hostname, &ip, &envID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		values["agent_id"] = agentID
+		values["hostname"] = hostname.String
+		values["agent_ip"] = ip.String
+		if environmentID == "" {
+			environmentID = envID.String
+		}
+	}
+	if environmentID != "" {
+		var name, slug string
+		err := s.db.conn.QueryRowContext(ctx,
+			`SELECT name, slug FROM environments WHERE id::text = $1`, environmentID).Scan(&name, &slug)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		values["environment"] = name
+		values["environment_slug"] = slug
+	}
+
+	for _, v := range t.Variables {
+		if v.DefaultValue != "" {
+			values[v.Name] = v.DefaultValue
+		}
+	}
+	for k, v := range t.Defaults {
+		values[k] = v
+	}
+
+	scopes := []struct{ scope, id string }{{"environment", environmentID}, {"agent", agentID}}
+	for _, sc := range scopes {
+		if sc.id == "" {
+			continue
+		}
+		var data []byte
+		err := s.db.conn.QueryRowContext(ctx,
+			`SELECT variables FROM config_template_values WHERE template_id = $1 AND scope = $2 AND scope_id = $3`,
+			t.Id, sc.scope, sc.id).Scan(&data)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var scoped map[string]string
+		if err := json.Unmarshal(data, &scoped); err != nil {
+			return nil, err
+		}
+		for k, v := range scoped {
+			values[k] = v
+		}
+	}
+
+	for k, v := range overrides {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// renderConfigTemplate substitutes {{name}} placeholders and checks declared variables
+// (required, validation regex, options). Placeholders without a value are reported as errors.
+func renderConfigTemplate(content string, defs []*pb.TemplateVariable, values map[string]string) (string, []string) {
+	var errs []string
+	for _, def := range defs {
+		value, ok := values[def.Name]
+		if !ok || value == "" {
+			if def.Required {
+				errs = append(errs, fmt.Sprintf("variable %q is required", def.Name))
+			}
+			continue
+		}
+		if def.Validation != "" {
+			re, err := regexp.Compile(def.Validation)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("variable %q has an invalid validation pattern", def.Name))
+			} else if !re.MatchString(value) {
+				errs = append(errs, fmt.Sprintf("variable %q value %q does not match %s", def.Name, value, def.Validation))
+			}
+		}
+		if len(def.Options) > 0 && !stringInSlice(def.Options, value) {
+			errs = append(errs, fmt.Sprintf("variable %q must be one of %s", def.Name, strings.Join(def.Options, ", ")))
+		}
+	}
+
+	missing := make(map[string]bool)
+	rendered := templatePlaceholderRe.ReplaceAllStringFunc(content, func(m string) string {
+		name := templatePlaceholderRe.FindStringSubmatch(m)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		missing[name] = true
+		return m
+	})
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, fmt.Sprintf("undefined variable %q", name))
+	}
+	return rendered, errs
+}
+
+// configTemplateTargetPath is where a rendered template lands, relative to the NGINX conf dir.
+// Main config templates replace nginx.conf (""); location blocks go to snippets/ so they can be
+// included from a server block; everything else goes to conf.d/ which is included from http.
+func configTemplateTargetPath(t *pb.ConfigTemplate) string {
+	if t.TemplateType == "nginx_main_conf" {
+		return ""
+	}
+	slug := strings.Trim(templateSlugRe.ReplaceAllString(strings.ToLower(t.Name), "-"), "-")
+	if slug == "" {
+		slug = t.Id
+	}
+	if t.TemplateType == "location_block" {
+		return "snippets/avika-" + slug + ".conf"
+	}
+	return "conf.d/avika-" + slug + ".conf"
+}
+
+func validateTemplateVariableDefs(defs []*pb.TemplateVariable) error {
+	for _, def := range defs {
+		if def.Name == "" || !templatePlaceholderRe.MatchString("{{"+def.Name+"}}") {
+			return fmt.Errorf("invalid variable name %q", def.Name)
+		}
+		if def.Validation != "" {
+			if _, err := regexp.Compile(def.Validation); err != nil {
+				return fmt.Errorf("variable %q: invalid validation pattern: %v", def.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func stringInSlice(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNilMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+// GET /api/config-templates?project_id=&environment_id=&group_id=&template_type=
+func (s *server) handleListConfigTemplates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	resp, err := s.ListConfigTemplates(r.Context(), &pb.ListConfigTemplatesRequest{
+		ProjectId:     q.Get("project_id"),
+		EnvironmentId: q.Get("environment_id"),
+		GroupId:       q.Get("group_id"),
+		TemplateType:  q.Get("template_type"),
+	})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp.Templates)
+}
+
+// POST /api/config-templates
+func (s *server) handleCreateConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	var req pb.CreateConfigTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	resp, err := s.CreateConfigTemplate(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GET /api/config-templates/{id}
+func (s *server) handleGetConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GetConfigTemplate(r.Context(), &pb.GetConfigTemplateRequest{TemplateId: r.PathValue("id")})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PUT /api/config-templates/{id}
+func (s *server) handleUpdateConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	current, err := s.GetConfigTemplate(r.Context(), &pb.GetConfigTemplateRequest{TemplateId: r.PathValue("id")})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	// is_active keeps its current value unless the body sets it
+	req := pb.UpdateConfigTemplateRequest{IsActive: current.IsActive}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	req.TemplateId = current.Id
+
+	resp, err := s.UpdateConfigTemplate(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DELETE /api/config-templates/{id}
+func (s *server) handleDeleteConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.DeleteConfigTemplate(r.Context(), &pb.DeleteConfigTemplateRequest{TemplateId: r.PathValue("id")})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GET /api/config-templates/{id}/versions
+func (s *server) handleListConfigTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.ListConfigTemplateVersions(r.Context(), &pb.ListConfigTemplateVersionsRequest{TemplateId: r.PathValue("id")})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp.Versions)
+}
+
+// PUT /api/config-templates/{id}/variables
+// Body: {"scope": "environment|agent", "scope_id": "...", "variables": {"server_name": "example.com"}}
+func (s *server) handleSetConfigTemplateVariables(w http.ResponseWriter, r *http.Request) {
+	var req pb.SetConfigTemplateVariablesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	req.TemplateId = r.PathValue("id")
+
+	resp, err := s.SetConfigTemplateVariables(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// POST /api/config-templates/{id}/render
+// Body: {"agent_id": "...", "environment_id": "...", "version": 0, "variables": {...}}
+func (s *server) handleRenderConfigTemplate(w http.ResponseWriter, r *http.Request) {
+	var req pb.RenderConfigTemplateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	req.TemplateId = r.PathValue("id")
+
+	resp, err := s.RenderConfigTemplate(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
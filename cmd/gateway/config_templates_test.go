@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestRenderConfigTemplate(t *testing.T) {
+	defs := []*pb.TemplateVariable{
+		{Name: "server_name", Required: true},
+		{Name: "port", Validation: `^\d+$`},
+		{Name: "mode", Options: []string{"a", "b"}},
+	}
+	content := "server { listen {{ port }}; server_name {{server_name}}; # {{mode}}\n}"
+
+	out, errs := renderConfigTemplate(content, defs, map[string]string{"server_name": "example.com", "port": "8080", "mode": "a"})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if want := "server { listen 8080; server_name example.com; # a\n}"; out != want {
+		t.Errorf("rendered = %q, want %q", out, want)
+	}
+
+	_, errs = renderConfigTemplate(content, defs, map[string]string{"port": "http", "mode": "c"})
+	joined := strings.Join(errs, "\n")
+	for _, want := range []string{`"server_name" is required`, `"port" value "http"`, `"mode" must be one of`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("errors %q missing %q", joined, want)
+		}
+	}
+}
+
+func TestRenderConfigTemplate_UndefinedPlaceholders(t *testing.T) {
+	out, errs := renderConfigTemplate("{{b}} {{a}} {{b}}", nil, nil)
+	if out != "{{b}} {{a}} {{b}}" {
+		t.Errorf("unresolved placeholders should be left as-is, got %q", out)
+	}
+	want := []string{`undefined variable "a"`, `undefined variable "b"`}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errs = %v, want %v", errs, want)
+	}
+}
+
+func TestConfigTemplateTargetPath(t *testing.T) {
+	tests := []struct {
+		tmpl *pb.ConfigTemplate
+		want string
+	}{
+		{&pb.ConfigTemplate{Name: "Main", TemplateType: "nginx_main_conf"}, ""},
+		{&pb.ConfigTemplate{Name: "API Server (v2)", TemplateType: "server_block"}, "conf.d/avika-api-server-v2.conf"},
+		{&pb.ConfigTemplate{Name: "Health", TemplateType: "location_block"}, "snippets/avika-health.conf"},
+		{&pb.ConfigTemplate{Id: "abc", Name: "!!", TemplateType: "upstream"}, "conf.d/avika-abc.conf"},
+	}
+	for _, tt := range tests {
+		if got := configTemplateTargetPath(tt.tmpl); got != tt.want {
+			t.Errorf("configTemplateTargetPath(%q) = %q, want %q", tt.tmpl.Name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatches(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		strategy  string
+		batchSize int
+		canaryPct int
+		want      [][]string
+	}{
+		{"parallel", 2, 0, [][]string{ids}},
+		{"rolling", 2, 0, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{"rolling", 0, 0, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}},
+		{"canary", 3, 20, [][]string{{"a"}, {"b", "c", "d"}, {"e"}}},
+		{"canary", 10, 30, [][]string{{"a", "b"}, {"c", "d", "e"}}},
+	}
+	for _, tt := range tests {
+		got := planBatches(ids, tt.strategy, tt.batchSize, tt.canaryPct)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("planBatches(%s, %d, %d) = %v, want %v", tt.strategy, tt.batchSize, tt.canaryPct, got, tt.want)
+		}
+	}
+	if got := planBatches(nil, "rolling", 1, 0); got != nil {
+		t.Errorf("planBatches(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 17, 42, 0, time.UTC) // a Saturday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 3, 15, 2, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 3 * * mon-fri", time.Date(2026, 3, 16, 3, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 */3 *", time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches
+		{"0 12 1 * sun", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.next(base); !got.Equal(tt.want) {
+			t.Errorf("%q: next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	c, _ := parseCron("0 0 30 feb *")
+	if got := c.next(base); !got.IsZero() {
+		t.Errorf("February 30th matched %v", got)
+	}
+}
+
+func TestCronNextTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	c, _ := parseCron("30 2 * * *")
+	// 02:30 does not exist on the day clocks spring forward, so that day has no run
+	got := c.next(time.Date(2026, 3, 8, 0, 0, 0, 0, loc))
+	if want := time.Date(2026, 3, 9, 2, 30, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+	got = c.next(time.Date(2026, 3, 20, 12, 0, 0, 0, loc))
+	if want := time.Date(2026, 3, 21, 6, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+}
@@ -1,35 +1,46 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/avika-ai/avika/cmd/gateway/config"
 	"github.com/avika-ai/avika/cmd/gateway/middleware"
 	"github.com/avika-ai/avika/cmd/gateway/migrations"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 type DB struct {
-	conn *sql.DB
+	conn         *sql.DB
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+	rbac         *rbacCache // access answers, see rbac_cache.go
 }
 
-func NewDB(dsn string) (*DB, error) {
-	conn, err := sql.Open("postgres", dsn)
+// NewDB connects to dsn through a pgx pool tuned by cfg and runs the pending migrations
+func NewDB(dsn string, cfg config.DatabaseConfig) (*DB, error) {
+	pool, err := newPostgresPool(dsn, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open db: %w", err)
+		return nil, err
 	}
+	conn := stdlib.OpenDBFromPool(pool)
 
 	if err := conn.Ping(); err != nil {
+		conn.Close()
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, pool: pool, queryTimeout: cfg.QueryTimeout, rbac: newRBACCache()}
+	postgresPool.Store(pool)
 
 	// Run embedded SQL migrations
 	runner := migrations.NewRunner(conn)
@@ -39,7 +50,7 @@ func NewDB(dsn string) (*DB, error) {
 
 	// Log current schema version
 	if version, err := runner.GetCurrentVersion(); err == nil {
-		log.Printf("Database schema version: %s", version)
+		dbLog.Info().Msgf("Database schema version: %s", version)
 	}
 
 	return db, nil
@@ -47,7 +58,7 @@ func NewDB(dsn string) (*DB, error) {
 
 func (db *DB) GetVersion() string {
 	var version string
-	err := db.conn.QueryRow("SHOW server_version").Scan(&version)
+	err := db.QueryRowContext(context.Background(), "SHOW server_version").Scan(&version)
 	if err != nil {
 		return "unknown"
 	}
@@ -58,7 +69,7 @@ func (db *DB) GetVersion() string {
 // GetSetting retrieves a setting value by key
 func (db *DB) GetSetting(key string) (string, error) {
 	var value string
-	err := db.conn.QueryRow("SELECT value FROM settings WHERE key = $1", key).Scan(&value)
+	err := db.QueryRowContext(context.Background(), "SELECT value FROM settings WHERE key = $1", key).Scan(&value)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
@@ -74,7 +85,7 @@ func (db *DB) SetSetting(key, value string) error {
 		value = EXCLUDED.value,
 		updated_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query, key, value)
+	_, err := db.ExecContext(context.Background(), query, key, value)
 	return err
 }
 
@@ -88,7 +99,7 @@ type UserRecord struct {
 // GetUser retrieves a user by username
 func (db *DB) GetUser(username string) (*UserRecord, error) {
 	var user UserRecord
-	err := db.conn.QueryRow(
+	err := db.QueryRowContext(context.Background(),
 		"SELECT username, password_hash, role FROM users WHERE username = $1",
 		username,
 	).Scan(&user.Username, &user.PasswordHash, &user.Role)
@@ -111,20 +122,20 @@ func (db *DB) UpsertUser(username, passwordHash, role string) error {
 		role = EXCLUDED.role,
 		updated_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query, username, passwordHash, role)
+	_, err := db.ExecContext(context.Background(), query, username, passwordHash, role)
 	return err
 }
 
 // UpdateUserPassword updates a user's password
 func (db *DB) UpdateUserPassword(username, passwordHash string) error {
 	query := `UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE username = $2`
-	_, err := db.conn.Exec(query, passwordHash, username)
+	_, err := db.ExecContext(context.Background(), query, passwordHash, username)
 	return err
 }
 
 // ListUsers returns all users
 func (db *DB) ListUsers() ([]*UserRecord, error) {
-	rows, err := db.conn.Query("SELECT username, password_hash, role FROM users")
+	rows, err := db.QueryContext(context.Background(), "SELECT username, password_hash, role FROM users")
 	if err != nil {
 		return nil, err
 	}
@@ -145,8 +156,8 @@ func (db *DB) UpsertAgent(session *AgentSession) error {
 	// We use ip as the unique identifier for a node to prevent duplicates.
 	// If an agent reconnects with a new agent_id but same ip, we update the record.
 	query := `
-	INSERT INTO agents (agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	INSERT INTO agents (agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated, kubernetes, gateway_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	ON CONFLICT (agent_id) DO UPDATE SET
 		hostname = EXCLUDED.hostname,
 		version = EXCLUDED.version,
@@ -158,9 +169,15 @@ func (db *DB) UpsertAgent(session *AgentSession) error {
 		is_pod = EXCLUDED.is_pod,
 		pod_ip = EXCLUDED.pod_ip,
 		agent_version = EXCLUDED.agent_version,
-		psk_authenticated = EXCLUDED.psk_authenticated;
+		psk_authenticated = EXCLUDED.psk_authenticated,
+		kubernetes = EXCLUDED.kubernetes,
+		gateway_id = EXCLUDED.gateway_id;
 	`
-	_, err := db.conn.Exec(query,
+	var kubernetes []byte
+	if session.kubernetes != nil {
+		kubernetes, _ = json.Marshal(session.kubernetes)
+	}
+	_, err := db.ExecContext(context.Background(), query,
 		session.id,
 		session.hostname,
 		session.version,
@@ -173,13 +190,15 @@ func (db *DB) UpsertAgent(session *AgentSession) error {
 		session.podIP,
 		session.agentVersion,
 		session.pskAuthenticated,
+		kubernetes,
+		session.gatewayID,
 	)
 	return err
 }
 
 func (db *DB) UpdateAgentStatus(agentID string, status string, lastSeen int64) error {
 	query := `UPDATE agents SET status = $1, last_seen = $2 WHERE agent_id = $3`
-	_, err := db.conn.Exec(query, status, lastSeen, agentID)
+	_, err := db.ExecContext(context.Background(), query, status, lastSeen, agentID)
 	return err
 }
 
@@ -190,98 +209,93 @@ func (db *DB) RemoveAgent(agentID string) error {
 	SELECT agent_id, hostname, ip FROM agents WHERE agent_id = $1
 	ON CONFLICT (agent_id) DO NOTHING;
 	`
-	_, _ = db.conn.Exec(insertQuery, agentID)
+	_, _ = db.ExecContext(context.Background(), insertQuery, agentID)
 
 	query := `DELETE FROM agents WHERE agent_id = $1`
-	_, err := db.conn.Exec(query, agentID)
+	_, err := db.ExecContext(context.Background(), query, agentID)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
+// agentSessionColumns are the agents columns scanned by scanAgentSession
+const agentSessionColumns = "agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated, kubernetes, gateway_id"
+
+// scanAgentSession builds a session, without stream, from a row of agentSessionColumns
+func scanAgentSession(row interface{ Scan(...interface{}) error }) (*AgentSession, error) {
+	var id, hostname, version, uptime, ip, status, podIP, agentVersion, gatewayID string
+	var instancesCount int
+	var lastSeen int64
+	var isPod, pskAuthenticated bool
+	var kubernetes []byte
+
+	if err := row.Scan(&id, &hostname, &version, &instancesCount, &uptime, &ip, &status, &lastSeen, &isPod, &podIP, &agentVersion, &pskAuthenticated, &kubernetes, &gatewayID); err != nil {
+		return nil, err
+	}
+	return &AgentSession{
+		id:               id,
+		hostname:         hostname,
+		version:          version,
+		instancesCount:   instancesCount,
+		uptime:           uptime,
+		ip:               ip,
+		status:           status,
+		lastActive:       time.Unix(lastSeen, 0),
+		isPod:            isPod,
+		podIP:            podIP,
+		agentVersion:     agentVersion,
+		pskAuthenticated: pskAuthenticated,
+		kubernetes:       decodeKubernetesMetadata(kubernetes),
+		gatewayID:        gatewayID,
+		logChans:         make(map[string]chan *pb.LogEntry),
+	}, nil
+}
+
 func (db *DB) LoadAgents(sessions *sync.Map) error {
-	rows, err := db.conn.Query("SELECT agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated FROM agents")
+	rows, err := db.QueryContext(context.Background(), "SELECT "+agentSessionColumns+" FROM agents")
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var id, hostname, version, uptime, ip, status, podIP, agentVersion string
-		var instancesCount int
-		var lastSeen int64
-		var isPod, pskAuthenticated bool
-
-		if err := rows.Scan(&id, &hostname, &version, &instancesCount, &uptime, &ip, &status, &lastSeen, &isPod, &podIP, &agentVersion, &pskAuthenticated); err != nil {
-			log.Printf("Failed to scan agent row: %v", err)
+		session, err := scanAgentSession(rows)
+		if err != nil {
+			dbLog.Error().Msgf("Failed to scan agent row: %v", err)
 			continue
 		}
-
-		session := &AgentSession{
-			id:               id,
-			hostname:         hostname,
-			version:          version,
-			instancesCount:   instancesCount,
-			uptime:           uptime,
-			ip:               ip,
-			status:           status,
-			lastActive:       time.Unix(lastSeen, 0),
-			isPod:            isPod,
-			podIP:            podIP,
-			agentVersion:     agentVersion,
-			pskAuthenticated: pskAuthenticated,
-			logChans:         make(map[string]chan *pb.LogEntry),
-		}
-		sessions.Store(id, session)
+		sessions.Store(session.id, session)
 	}
 	return nil
 }
 
-func (db *DB) PruneStaleAgents(maxAge time.Duration) ([]string, error) {
+// MarkStaleAgentsOffline updates the status of online agents to 'offline' if they haven't been seen recently.
+// It returns the IDs of the agents it marked.
+func (db *DB) MarkStaleAgentsOffline(maxAge time.Duration) ([]string, error) {
 	threshold := time.Now().Add(-maxAge).Unix()
-
-	// Get IDs before deleting for cascaded cleanup in ClickHouse
-	var ids []string
-	rows, err := db.conn.Query("SELECT agent_id FROM agents WHERE status = 'offline' AND last_seen < $1", threshold)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var id string
-			if err := rows.Scan(&id); err == nil {
-				ids = append(ids, id)
-			}
-		}
-	}
-
-	// Backup before deleting
-	insertQuery := `
-	INSERT INTO historical_agents (agent_id, hostname, ip)
-	SELECT agent_id, hostname, ip FROM agents WHERE status = 'offline' AND last_seen < $1
-	ON CONFLICT (agent_id) DO NOTHING;
-	`
-	_, _ = db.conn.Exec(insertQuery, threshold)
-
-	query := `DELETE FROM agents WHERE status = 'offline' AND last_seen < $1`
-	_, err = db.conn.Exec(query, threshold)
+	query := `UPDATE agents SET status = 'offline' WHERE status = 'online' AND last_seen < $1 RETURNING agent_id`
+	rows, err := db.QueryContext(context.Background(), query, threshold)
 	if err != nil {
 		return nil, err
 	}
-	return ids, nil
-}
+	defer rows.Close()
 
-// MarkStaleAgentsOffline updates the status of online agents to 'offline' if they haven't been seen recently.
-func (db *DB) MarkStaleAgentsOffline(maxAge time.Duration) (int64, error) {
-	threshold := time.Now().Add(-maxAge).Unix()
-	query := `UPDATE agents SET status = 'offline' WHERE status = 'online' AND last_seen < $1`
-	res, err := db.conn.Exec(query, threshold)
-	if err != nil {
-		return 0, err
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
 	}
-	return res.RowsAffected()
+	return ids, rows.Err()
 }
 
 func (db *DB) UpsertAlertRule(rule *pb.AlertRule) error {
 	query := `
-	INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients, action, ban_duration_sec, project_id, pack, severity, cooldown_sec)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	ON CONFLICT (id) DO UPDATE SET
 		name = EXCLUDED.name,
 		metric_type = EXCLUDED.metric_type,
@@ -289,9 +303,16 @@ func (db *DB) UpsertAlertRule(rule *pb.AlertRule) error {
 		comparison = EXCLUDED.comparison,
 		window_sec = EXCLUDED.window_sec,
 		enabled = EXCLUDED.enabled,
-		recipients = EXCLUDED.recipients;
+		recipients = EXCLUDED.recipients,
+		action = EXCLUDED.action,
+		ban_duration_sec = EXCLUDED.ban_duration_sec,
+		project_id = EXCLUDED.project_id,
+		pack = EXCLUDED.pack,
+		severity = EXCLUDED.severity,
+		cooldown_sec = EXCLUDED.cooldown_sec,
+		updated_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query,
+	_, err := db.ExecContext(context.Background(), query,
 		rule.Id,
 		rule.Name,
 		rule.MetricType,
@@ -300,18 +321,35 @@ func (db *DB) UpsertAlertRule(rule *pb.AlertRule) error {
 		rule.WindowSec,
 		rule.Enabled,
 		rule.Recipients,
+		rule.Action,
+		rule.BanDurationSec,
+		nullIfEmpty(rule.ProjectId),
+		rule.Pack,
+		rule.Severity,
+		rule.CooldownSec,
 	)
 	return err
 }
 
 func (db *DB) DeleteAlertRule(id string) error {
 	query := `DELETE FROM alert_rules WHERE id = $1`
-	_, err := db.conn.Exec(query, id)
+	_, err := db.ExecContext(context.Background(), query, id)
 	return err
 }
 
+// DeleteAlertPackRules deletes the rules a project has from a rule pack
+func (db *DB) DeleteAlertPackRules(projectID, pack string) (int64, error) {
+	res, err := db.ExecContext(context.Background(),
+		`DELETE FROM alert_rules WHERE project_id = $1 AND split_part(pack, '/', 1) = $2`, projectID, pack)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (db *DB) ListAlertRules() ([]*pb.AlertRule, error) {
-	rows, err := db.conn.Query("SELECT id, name, metric_type, threshold, comparison, window_sec, enabled, recipients FROM alert_rules")
+	rows, err := db.QueryContext(context.Background(), `SELECT id, name, metric_type, threshold, comparison, window_sec, enabled, recipients,
+		COALESCE(action, ''), COALESCE(ban_duration_sec, 0), COALESCE(project_id::text, ''), pack, severity, cooldown_sec FROM alert_rules`)
 	if err != nil {
 		return nil, err
 	}
@@ -320,8 +358,9 @@ func (db *DB) ListAlertRules() ([]*pb.AlertRule, error) {
 	var rules []*pb.AlertRule
 	for rows.Next() {
 		rule := &pb.AlertRule{}
-		if err := rows.Scan(&rule.Id, &rule.Name, &rule.MetricType, &rule.Threshold, &rule.Comparison, &rule.WindowSec, &rule.Enabled, &rule.Recipients); err != nil {
-			log.Printf("Failed to scan alert rule row: %v", err)
+		if err := rows.Scan(&rule.Id, &rule.Name, &rule.MetricType, &rule.Threshold, &rule.Comparison, &rule.WindowSec, &rule.Enabled, &rule.Recipients,
+			&rule.Action, &rule.BanDurationSec, &rule.ProjectId, &rule.Pack, &rule.Severity, &rule.CooldownSec); err != nil {
+			dbLog.Error().Msgf("Failed to scan alert rule row: %v", err)
 			continue
 		}
 		rules = append(rules, rule)
@@ -331,13 +370,13 @@ func (db *DB) ListAlertRules() ([]*pb.AlertRule, error) {
 
 // GetAgentCounts returns total agent count and count of online agents (for reports).
 func (db *DB) GetAgentCounts() (total, online int, err error) {
-	err = db.conn.QueryRow("SELECT count(*), COALESCE(sum(CASE WHEN status = 'online' THEN 1 ELSE 0 END), 0) FROM agents").Scan(&total, &online)
+	err = db.QueryRowContext(context.Background(), "SELECT count(*), COALESCE(sum(CASE WHEN status = 'online' THEN 1 ELSE 0 END), 0) FROM agents").Scan(&total, &online)
 	return total, online, err
 }
 
 // ListAgents returns all agents from the database as AgentInfo (for reports, insights, or callers that need a list from DB).
 func (db *DB) ListAgents() ([]*pb.AgentInfo, error) {
-	rows, err := db.conn.Query("SELECT agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated FROM agents")
+	rows, err := db.QueryContext(context.Background(), "SELECT agent_id, hostname, version, instances_count, uptime, ip, status, last_seen, is_pod, pod_ip, agent_version, psk_authenticated, kubernetes FROM agents")
 	if err != nil {
 		return nil, err
 	}
@@ -349,9 +388,10 @@ func (db *DB) ListAgents() ([]*pb.AgentInfo, error) {
 		var instancesCount int
 		var lastSeen int64
 		var isPod, pskAuthenticated bool
+		var kubernetes []byte
 
-		if err := rows.Scan(&id, &hostname, &version, &instancesCount, &uptime, &ip, &status, &lastSeen, &isPod, &podIP, &agentVersion, &pskAuthenticated); err != nil {
-			log.Printf("Failed to scan agent row: %v", err)
+		if err := rows.Scan(&id, &hostname, &version, &instancesCount, &uptime, &ip, &status, &lastSeen, &isPod, &podIP, &agentVersion, &pskAuthenticated, &kubernetes); err != nil {
+			dbLog.Error().Msgf("Failed to scan agent row: %v", err)
 			continue
 		}
 
@@ -368,11 +408,24 @@ func (db *DB) ListAgents() ([]*pb.AgentInfo, error) {
 			PodIp:            podIP,
 			AgentVersion:     agentVersion,
 			PskAuthenticated: pskAuthenticated,
+			Kubernetes:       decodeKubernetesMetadata(kubernetes),
 		})
 	}
 	return list, nil
 }
 
+// decodeKubernetesMetadata decodes the agents.kubernetes column, nil when unset or invalid
+func decodeKubernetesMetadata(data []byte) *pb.KubernetesMetadata {
+	if len(data) == 0 {
+		return nil
+	}
+	var meta pb.KubernetesMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
+
 // ============================================================================
 // OIDC Integration Methods (implements middleware.UserProvisioner and middleware.TeamMapper)
 // ============================================================================
@@ -380,7 +433,7 @@ func (db *DB) ListAgents() ([]*pb.AgentInfo, error) {
 // GetUserInfo retrieves user info for OIDC provisioning (implements middleware.UserProvisioner)
 func (db *DB) GetUserInfo(username string) (*middleware.UserInfo, error) {
 	var user middleware.UserInfo
-	err := db.conn.QueryRow(
+	err := db.QueryRowContext(context.Background(),
 		"SELECT username, COALESCE(email, ''), role FROM users WHERE username = $1",
 		username,
 	).Scan(&user.Username, &user.Email, &user.Role)
@@ -410,14 +463,14 @@ func (db *DB) CreateUser(username, email, role string) error {
 		role = EXCLUDED.role,
 		updated_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query, username, email, passwordHash, role)
+	_, err := db.ExecContext(context.Background(), query, username, email, passwordHash, role)
 	return err
 }
 
 // UpdateUserEmail updates a user's email address
 func (db *DB) UpdateUserEmail(username, email string) error {
 	query := `UPDATE users SET email = $1, updated_at = CURRENT_TIMESTAMP WHERE username = $2`
-	_, err := db.conn.Exec(query, email, username)
+	_, err := db.ExecContext(context.Background(), query, email, username)
 	return err
 }
 
@@ -425,7 +478,7 @@ func (db *DB) UpdateUserEmail(username, email string) error {
 func (db *DB) AddUserToTeamByName(username, teamName string) error {
 	// Find team by name
 	var teamID string
-	err := db.conn.QueryRow("SELECT id FROM teams WHERE name = $1 OR slug = $1", teamName).Scan(&teamID)
+	err := db.QueryRowContext(context.Background(), "SELECT id FROM teams WHERE name = $1 OR slug = $1", teamName).Scan(&teamID)
 	if err == sql.ErrNoRows {
 		return fmt.Errorf("team not found: %s", teamName)
 	}
@@ -439,21 +492,27 @@ func (db *DB) AddUserToTeamByName(username, teamName string) error {
 	VALUES ($1, $2, 'member', CURRENT_TIMESTAMP)
 	ON CONFLICT (team_id, username) DO NOTHING;
 	`
-	_, err = db.conn.Exec(query, teamID, username)
+	_, err = db.ExecContext(context.Background(), query, teamID, username)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
 // RemoveUserFromAllTeams removes a user from all teams
 func (db *DB) RemoveUserFromAllTeams(username string) error {
 	query := `DELETE FROM team_members WHERE username = $1`
-	_, err := db.conn.Exec(query, username)
+	_, err := db.ExecContext(context.Background(), query, username)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
 // GetTeamByName gets a team by name (implements middleware.TeamMapper)
 func (db *DB) GetTeamByName(name string) (*middleware.TeamInfo, error) {
 	var team middleware.TeamInfo
-	err := db.conn.QueryRow("SELECT id, name FROM teams WHERE name = $1 OR slug = $1", name).Scan(&team.ID, &team.Name)
+	err := db.QueryRowContext(context.Background(), "SELECT id, name FROM teams WHERE name = $1 OR slug = $1", name).Scan(&team.ID, &team.Name)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -486,13 +545,13 @@ func (db *DB) UpsertWAFPolicy(policy *WAFPolicy) error {
 		enabled = EXCLUDED.enabled,
 		updated_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query, policy.ID, policy.Name, policy.Description, policy.Rules, policy.Enabled)
+	_, err := db.ExecContext(context.Background(), query, policy.ID, policy.Name, policy.Description, policy.Rules, policy.Enabled)
 	return err
 }
 
 // ListWAFPolicies returns all WAF policies
 func (db *DB) ListWAFPolicies() ([]WAFPolicy, error) {
-	rows, err := db.conn.Query("SELECT id, name, description, rules, enabled, created_at, updated_at FROM waf_policies ORDER BY created_at DESC")
+	rows, err := db.QueryContext(context.Background(), "SELECT id, name, description, rules, enabled, created_at, updated_at FROM waf_policies ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -512,7 +571,7 @@ func (db *DB) ListWAFPolicies() ([]WAFPolicy, error) {
 // GetWAFPolicy returns a single WAF policy
 func (db *DB) GetWAFPolicy(id string) (*WAFPolicy, error) {
 	var p WAFPolicy
-	err := db.conn.QueryRow("SELECT id, name, description, rules, enabled, created_at, updated_at FROM waf_policies WHERE id = $1", id).
+	err := db.QueryRowContext(context.Background(), "SELECT id, name, description, rules, enabled, created_at, updated_at FROM waf_policies WHERE id = $1", id).
 		Scan(&p.ID, &p.Name, &p.Description, &p.Rules, &p.Enabled, &p.CreatedAt, &p.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -543,14 +602,14 @@ func (db *DB) UpsertStagedConfig(cfg *StagedConfig) error {
 		description = EXCLUDED.description,
 		created_at = CURRENT_TIMESTAMP;
 	`
-	_, err := db.conn.Exec(query, cfg.TargetID, cfg.TargetType, cfg.Content, cfg.ConfigPath, cfg.CreatedBy, cfg.Description)
+	_, err := db.ExecContext(context.Background(), query, cfg.TargetID, cfg.TargetType, cfg.Content, cfg.ConfigPath, cfg.CreatedBy, cfg.Description)
 	return err
 }
 
 // GetStagedConfig retrieves a staged config
 func (db *DB) GetStagedConfig(targetID, configPath string) (*StagedConfig, error) {
 	var c StagedConfig
-	err := db.conn.QueryRow("SELECT target_id, target_type, content, config_path, created_by, description, created_at FROM staged_configs WHERE target_id = $1 AND config_path = $2", targetID, configPath).
+	err := db.QueryRowContext(context.Background(), "SELECT target_id, target_type, content, config_path, created_by, description, created_at FROM staged_configs WHERE target_id = $1 AND config_path = $2", targetID, configPath).
 		Scan(&c.TargetID, &c.TargetType, &c.Content, &c.ConfigPath, &c.CreatedBy, &c.Description, &c.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -560,6 +619,6 @@ func (db *DB) GetStagedConfig(targetID, configPath string) (*StagedConfig, error
 
 // DeleteStagedConfig removes a staged config after apply/discard
 func (db *DB) DeleteStagedConfig(targetID, configPath string) error {
-	_, err := db.conn.Exec("DELETE FROM staged_configs WHERE target_id = $1 AND config_path = $2", targetID, configPath)
+	_, err := db.ExecContext(context.Background(), "DELETE FROM staged_configs WHERE target_id = $1 AND config_path = $2", targetID, configPath)
 	return err
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/avika-ai/avika/cmd/gateway/config"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 	"github.com/google/uuid"
 )
@@ -25,7 +26,7 @@ func getTestDSN() string {
 }
 
 func setupTestDB(t *testing.T) *DB {
-	db, err := NewDB(getTestDSN())
+	db, err := NewDB(getTestDSN(), config.DatabaseConfig{})
 	if err != nil {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
@@ -303,15 +304,15 @@ func TestPruneStaleAgents(t *testing.T) {
 	}
 
 	// Prune agents older than 10 days
-	prunedIDs, err := db.PruneStaleAgents(10 * 24 * time.Hour)
+	pruned, err := db.PruneStaleAgents(AgentRetentionPolicy{PruneAfterSec: int64((10 * 24 * time.Hour).Seconds())})
 	if err != nil {
 		t.Fatalf("Failed to prune stale agents: %v", err)
 	}
 
 	// Verify stale agent was pruned
 	found := false
-	for _, id := range prunedIDs {
-		if id == staleAgent.id {
+	for _, c := range pruned {
+		if c.AgentID == staleAgent.id {
 			found = true
 			break
 		}
@@ -472,7 +473,7 @@ func TestPodAgentHandling(t *testing.T) {
 
 // Benchmark tests
 func BenchmarkAgentUpsert(b *testing.B) {
-	db, err := NewDB(getTestDSN())
+	db, err := NewDB(getTestDSN(), config.DatabaseConfig{})
 	if err != nil {
 		b.Skip("Database not available for benchmark")
 	}
@@ -501,8 +502,60 @@ func BenchmarkAgentUpsert(b *testing.B) {
 	db.RemoveAgent(session.id)
 }
 
+func TestIPBanLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.conn.Close()
+	defer db.conn.Exec("DELETE FROM ip_bans WHERE client_ip = '192.0.2.99'")
+
+	ban := &IPBan{
+		ClientIP:  "192.0.2.99",
+		AgentIDs:  []string{"test-agent-a"},
+		Reason:    "test",
+		Hits:      5,
+		CreatedBy: "system",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := db.UpsertIPBan(ban); err != nil {
+		t.Fatalf("Failed to create ban: %v", err)
+	}
+
+	// A repeat offence extends the active ban and adds the agent
+	again := &IPBan{
+		ClientIP:  "192.0.2.99",
+		AgentIDs:  []string{"test-agent-b"},
+		Hits:      3,
+		CreatedBy: "system",
+		ExpiresAt: time.Now().Add(2 * time.Hour),
+	}
+	if err := db.UpsertIPBan(again); err != nil {
+		t.Fatalf("Failed to extend ban: %v", err)
+	}
+	if again.ID != ban.ID {
+		t.Errorf("repeat offence created a second ban")
+	}
+	if len(again.AgentIDs) != 2 || again.Hits != 8 || !again.ExpiresAt.After(ban.ExpiresAt) {
+		t.Errorf("ban not merged: %+v", again)
+	}
+
+	active, err := db.ListActiveBansForAgent("test-agent-b")
+	if err != nil || len(active) != 1 {
+		t.Fatalf("ListActiveBansForAgent = %v, %v", active, err)
+	}
+
+	lifted, err := db.LiftIPBan(ban.ID, "test-admin")
+	if err != nil || lifted == nil || lifted.LiftedAt == nil {
+		t.Fatalf("LiftIPBan = %+v, %v", lifted, err)
+	}
+	if again, _ := db.LiftIPBan(ban.ID, "test-admin"); again != nil {
+		t.Error("lifting a lifted ban should find nothing")
+	}
+	if active, _ := db.ListActiveBansForAgent("test-agent-a"); len(active) != 0 {
+		t.Errorf("lifted ban still active: %v", active)
+	}
+}
+
 func BenchmarkListAlertRules(b *testing.B) {
-	db, err := NewDB(getTestDSN())
+	db, err := NewDB(getTestDSN(), config.DatabaseConfig{})
 	if err != nil {
 		b.Skip("Database not available for benchmark")
 	}
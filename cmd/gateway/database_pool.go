@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The gateway reaches PostgreSQL through database/sql over a pgx pool: pgx keeps a cache of
+// prepared statements on each connection and traces queries for slow-query logging, and the pool
+// statistics are exported on /metrics.
+
+// slowQueryMaxLen is how much of a slow query's SQL is logged
+const slowQueryMaxLen = 300
+
+var avikaPostgresSlowQueries = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "avika_postgres_slow_queries_total",
+	Help: "PostgreSQL queries taking longer than the slow query threshold",
+})
+
+// postgresPool is the pool of the last DB opened, whose statistics postgresPoolCollector exports
+var postgresPool atomic.Pointer[pgxpool.Pool]
+
+func init() {
+	prometheus.MustRegister(avikaPostgresSlowQueries, postgresPoolCollector{})
+}
+
+// newPostgresPool opens a pgx pool on dsn, tuned by cfg; zero values keep pgx's defaults
+func newPostgresPool(dsn string, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse db dsn: %w", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = int32(min(cfg.MinConns, int(poolCfg.MaxConns)))
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	connCfg := poolCfg.ConnConfig
+	if cfg.StatementCacheSize > 0 {
+		connCfg.StatementCacheCapacity = cfg.StatementCacheSize
+	} else {
+		// Unnamed statements, described on every run, work through PgBouncer
+		connCfg.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+	if cfg.StatementTimeout > 0 {
+		connCfg.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		connCfg.Tracer = slowQueryTracer{threshold: cfg.SlowQueryThreshold}
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+	return pool, nil
+}
+
+type slowQueryStartKey struct{}
+
+type slowQueryStart struct {
+	sql string
+	at  time.Time
+}
+
+// slowQueryTracer logs the queries taking longer than threshold
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+func (t slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryStartKey{}, slowQueryStart{sql: data.SQL, at: time.Now()})
+}
+
+func (t slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(slowQueryStartKey{}).(slowQueryStart)
+	if !ok {
+		return
+	}
+	if elapsed := time.Since(start.at); elapsed >= t.threshold {
+		avikaPostgresSlowQueries.Inc()
+		status := "ok"
+		if data.Err != nil {
+			status = data.Err.Error()
+		}
+		dbLog.Info().Msgf("Slow PostgreSQL query (%s, %s): %s", elapsed.Round(time.Millisecond), status, compactSQL(start.sql))
+	}
+}
+
+// compactSQL puts a query on one line, cut at slowQueryMaxLen
+func compactSQL(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > slowQueryMaxLen {
+		query = query[:slowQueryMaxLen] + "..."
+	}
+	return query
+}
+
+var (
+	postgresPoolConnsDesc = prometheus.NewDesc("avika_postgres_pool_connections",
+		"PostgreSQL pool connections by state: acquired, idle or constructing", []string{"state"}, nil)
+	postgresPoolMaxConnsDesc = prometheus.NewDesc("avika_postgres_pool_max_connections",
+		"Maximum size of the PostgreSQL pool", nil, nil)
+	postgresPoolAcquiresDesc = prometheus.NewDesc("avika_postgres_pool_acquires_total",
+		"Connections acquired from the PostgreSQL pool", nil, nil)
+	postgresPoolEmptyAcquiresDesc = prometheus.NewDesc("avika_postgres_pool_empty_acquires_total",
+		"Acquires that waited for a connection because the PostgreSQL pool had none idle", nil, nil)
+	postgresPoolCanceledAcquiresDesc = prometheus.NewDesc("avika_postgres_pool_canceled_acquires_total",
+		"Acquires canceled, e.g. by a deadline, while waiting for a PostgreSQL connection", nil, nil)
+	postgresPoolAcquireWaitDesc = prometheus.NewDesc("avika_postgres_pool_acquire_wait_seconds_total",
+		"Time acquires spent waiting for a PostgreSQL connection", nil, nil)
+)
+
+// postgresPoolCollector exports the statistics of postgresPool
+type postgresPoolCollector struct{}
+
+func (postgresPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- postgresPoolConnsDesc
+	ch <- postgresPoolMaxConnsDesc
+	ch <- postgresPoolAcquiresDesc
+	ch <- postgresPoolEmptyAcquiresDesc
+	ch <- postgresPoolCanceledAcquiresDesc
+	ch <- postgresPoolAcquireWaitDesc
+}
+
+func (postgresPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	pool := postgresPool.Load()
+	if pool == nil {
+		return
+	}
+	stat := pool.Stat()
+	ch <- prometheus.MustNewConstMetric(postgresPoolConnsDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()), "acquired")
+	ch <- prometheus.MustNewConstMetric(postgresPoolConnsDesc, prometheus.GaugeValue, float64(stat.IdleConns()), "idle")
+	ch <- prometheus.MustNewConstMetric(postgresPoolConnsDesc, prometheus.GaugeValue, float64(stat.ConstructingConns()), "constructing")
+	ch <- prometheus.MustNewConstMetric(postgresPoolMaxConnsDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(postgresPoolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(postgresPoolEmptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(postgresPoolCanceledAcquiresDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(postgresPoolAcquireWaitDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+// withTimeout bounds ctx by the query timeout, unless it has an earlier deadline
+func (db *DB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= db.queryTimeout {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
+
+// dbRows are the rows of DB.QueryContext; closing them ends the query's deadline
+type dbRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *dbRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// dbRow is the row of DB.QueryRowContext; scanning it ends the query's deadline
+type dbRow struct {
+	*sql.Row
+	cancel context.CancelFunc
+}
+
+func (r *dbRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.Row.Scan(dest...)
+}
+
+// QueryContext runs a query under the query timeout. The caller must close the rows.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*dbRows, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &dbRows{Rows: rows, cancel: cancel}, nil
+}
+
+// QueryRowContext runs a query returning at most one row under the query timeout
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *dbRow {
+	ctx, cancel := db.withTimeout(ctx)
+	return &dbRow{Row: db.conn.QueryRowContext(ctx, query, args...), cancel: cancel}
+}
+
+// ExecContext runs a statement under the query timeout
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return db.conn.ExecContext(ctx, query, args...)
+}
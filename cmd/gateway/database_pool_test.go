@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactSQL(t *testing.T) {
+	if got := compactSQL("\n\tSELECT id\n\tFROM agents\n\tWHERE status = $1\n"); got != "SELECT id FROM agents WHERE status = $1" {
+		t.Errorf("compactSQL = %q", got)
+	}
+	long := compactSQL("SELECT " + strings.Repeat("x, ", 200) + "y")
+	if len(long) != slowQueryMaxLen+3 || !strings.HasSuffix(long, "...") {
+		t.Errorf("long query cut to %d: %q", len(long), long)
+	}
+}
+
+func TestDBWithTimeout(t *testing.T) {
+	db := &DB{queryTimeout: time.Minute}
+
+	ctx, cancel := db.withTimeout(context.Background())
+	deadline, ok := ctx.Deadline()
+	cancel()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("no deadline: %v %v", deadline, ok)
+	}
+
+	// An earlier deadline of the caller wins
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = db.withTimeout(parent)
+	deadline, _ = ctx.Deadline()
+	cancel()
+	if want, _ := parent.Deadline(); !deadline.Equal(want) {
+		t.Errorf("deadline %v, want the caller's %v", deadline, want)
+	}
+
+	// Canceling ends the query without canceling the caller's context
+	if ctx.Err() == nil || parent.Err() != nil {
+		t.Errorf("cancel: %v %v", ctx.Err(), parent.Err())
+	}
+
+	ctx, cancel = (&DB{}).withTimeout(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a zero query timeout set a deadline")
+	}
+}
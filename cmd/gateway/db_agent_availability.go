@@ -0,0 +1,65 @@
+package main
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AgentAvailabilityEvent is one online/offline transition of an agent
+type AgentAvailabilityEvent struct {
+	AgentID    string    `json:"agent_id"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (db *DB) RecordAgentAvailability(agentID, status, reason string, at time.Time) error {
+	_, err := db.conn.Exec(`INSERT INTO agent_availability_events (agent_id, status, reason, occurred_at)
+		VALUES ($1, $2, $3, $4)`, agentID, status, reason, at)
+	return err
+}
+
+// ListAgentAvailability returns, per agent, the last transition before start followed by the
+// transitions within [start, end], oldest first. An empty agentIDs covers every agent.
+func (db *DB) ListAgentAvailability(agentIDs []string, start, end time.Time) (map[string][]AgentAvailabilityEvent, error) {
+	rows, err := db.conn.Query(`
+		SELECT agent_id, status, reason, occurred_at FROM (
+			SELECT DISTINCT ON (agent_id) agent_id, status, reason, occurred_at
+			FROM agent_availability_events
+			WHERE occurred_at < $1 AND (cardinality($3::text[]) = 0 OR agent_id = ANY($3))
+			ORDER BY agent_id, occurred_at DESC
+		) prior
+		UNION ALL
+		SELECT agent_id, status, reason, occurred_at
+		FROM agent_availability_events
+		WHERE occurred_at >= $1 AND occurred_at <= $2 AND (cardinality($3::text[]) = 0 OR agent_id = ANY($3))
+		ORDER BY agent_id, occurred_at`, start, end, pq.Array(agentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := map[string][]AgentAvailabilityEvent{}
+	for rows.Next() {
+		var e AgentAvailabilityEvent
+		if err := rows.Scan(&e.AgentID, &e.Status, &e.Reason, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		events[e.AgentID] = append(events[e.AgentID], e)
+	}
+	return events, rows.Err()
+}
+
+// PruneAgentAvailability deletes transitions older than the cutoff, keeping each agent's latest so
+// its current state stays known
+func (db *DB) PruneAgentAvailability(before time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM agent_availability_events
+		WHERE occurred_at < $1 AND id NOT IN (
+			SELECT DISTINCT ON (agent_id) id FROM agent_availability_events ORDER BY agent_id, occurred_at DESC
+		)`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// globalAgentConfigScope is the scope of the fleet-wide bundle
+const globalAgentConfigScope = "global"
+
+// AgentConfigBundle holds avika-agent.conf settings served to agents by the gateway. The
+// environment bundle overrides the fleet-wide one key by key.
+type AgentConfigBundle struct {
+	EnvironmentID string            `json:"environment_id,omitempty"` // empty for the fleet-wide bundle
+	Settings      map[string]string `json:"settings"`
+	IncludePSK    *bool             `json:"include_psk,omitempty"` // nil inherits the fleet-wide bundle
+	UpdatedBy     string            `json:"updated_by,omitempty"`
+	UpdatedAt     time.Time         `json:"updated_at,omitempty"`
+}
+
+func agentConfigScope(environmentID string) string {
+	if environmentID == "" {
+		return globalAgentConfigScope
+	}
+	return environmentID
+}
+
+// GetAgentConfigBundle returns the bundle of an environment, or the fleet-wide one for an empty
+// id; nil when none is defined
+func (db *DB) GetAgentConfigBundle(environmentID string) (*AgentConfigBundle, error) {
+	var b AgentConfigBundle
+	var envID, updatedBy sql.NullString
+	var settings []byte
+	var includePSK sql.NullBool
+	err := db.conn.QueryRow(`
+		SELECT environment_id, settings, include_psk, updated_by, updated_at
+		FROM agent_config_bundles WHERE scope = $1`, agentConfigScope(environmentID)).
+		Scan(&envID, &settings, &includePSK, &updatedBy, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(settings, &b.Settings); err != nil {
+		return nil, fmt.Errorf("invalid settings of agent config bundle %s: %w", agentConfigScope(environmentID), err)
+	}
+	b.EnvironmentID, b.UpdatedBy = envID.String, updatedBy.String
+	if includePSK.Valid {
+		b.IncludePSK = &includePSK.Bool
+	}
+	return &b, nil
+}
+
+func (db *DB) UpsertAgentConfigBundle(b *AgentConfigBundle) error {
+	settings, err := json.Marshal(b.Settings)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO agent_config_bundles (scope, environment_id, settings, include_psk, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (scope) DO UPDATE SET
+			settings = EXCLUDED.settings,
+			include_psk = EXCLUDED.include_psk,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		agentConfigScope(b.EnvironmentID), nullIfEmpty(b.EnvironmentID), settings, b.IncludePSK, nullIfEmpty(b.UpdatedBy)).Scan(&b.UpdatedAt)
+}
+
+func (db *DB) DeleteAgentConfigBundle(environmentID string) error {
+	_, err := db.conn.Exec(`DELETE FROM agent_config_bundles WHERE scope = $1`, agentConfigScope(environmentID))
+	return err
+}
+
+// LookupEnrollmentToken returns the environment of an unexpired enrollment token without
+// counting a use, for agents authenticating repeat requests such as config fetches
+func (db *DB) LookupEnrollmentToken(token string) (string, error) {
+	var id, envID string
+	var expires sql.NullTime
+	err := db.conn.QueryRow(`SELECT id, environment_id, expires_at FROM enrollment_tokens WHERE token_hash = $1`,
+		sha256Hex(token)).Scan(&id, &envID, &expires)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("invalid token")
+	}
+	if err != nil {
+		return "", err
+	}
+	if expires.Valid && expires.Time.Before(time.Now()) {
+		return "", fmt.Errorf("token has expired")
+	}
+	_, _ = db.conn.Exec("UPDATE enrollment_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	return envID, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReleaseChannel is the agent version a channel delivers, with an optional staged rollout of
+// the next version to a share of its agents
+type ReleaseChannel struct {
+	Name           string    `json:"name"`
+	Version        string    `json:"version"`
+	RolloutVersion string    `json:"rollout_version,omitempty"`
+	RolloutPercent int       `json:"rollout_percent"`
+	UpdatedBy      string    `json:"updated_by,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// EnvironmentRelease selects the channel of an environment's agents or pins them to a version
+type EnvironmentRelease struct {
+	EnvironmentID string    `json:"environment_id"`
+	Channel       string    `json:"channel"`
+	PinnedVersion string    `json:"pinned_version,omitempty"`
+	UpdatedBy     string    `json:"updated_by,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ListReleaseChannels returns the configured release channels by name
+func (db *DB) ListReleaseChannels() ([]ReleaseChannel, error) {
+	rows, err := db.conn.Query(`
+		SELECT name, version, COALESCE(rollout_version, ''), rollout_percent, COALESCE(updated_by, ''), updated_at
+		FROM agent_release_channels ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []ReleaseChannel{}
+	for rows.Next() {
+		var c ReleaseChannel
+		if err := rows.Scan(&c.Name, &c.Version, &c.RolloutVersion, &c.RolloutPercent, &c.UpdatedBy, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+// SaveReleaseChannel creates or replaces a release channel
+func (db *DB) SaveReleaseChannel(c *ReleaseChannel) error {
+	return db.conn.QueryRow(`
+		INSERT INTO agent_release_channels (name, version, rollout_version, rollout_percent, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE SET
+			version = EXCLUDED.version,
+			rollout_version = EXCLUDED.rollout_version,
+			rollout_percent = EXCLUDED.rollout_percent,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		c.Name, c.Version, nullIfEmpty(c.RolloutVersion), c.RolloutPercent, nullIfEmpty(c.UpdatedBy)).Scan(&c.UpdatedAt)
+}
+
+// ListEnvironmentReleases returns the environments with a release channel or pinned version
+func (db *DB) ListEnvironmentReleases() ([]EnvironmentRelease, error) {
+	rows, err := db.conn.Query(`
+		SELECT environment_id, channel, COALESCE(pinned_version, ''), COALESCE(updated_by, ''), updated_at
+		FROM agent_release_environments ORDER BY environment_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	envs := []EnvironmentRelease{}
+	for rows.Next() {
+		var e EnvironmentRelease
+		if err := rows.Scan(&e.EnvironmentID, &e.Channel, &e.PinnedVersion, &e.UpdatedBy, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		envs = append(envs, e)
+	}
+	return envs, rows.Err()
+}
+
+// GetEnvironmentRelease returns the release settings of an environment; nil when it has none
+func (db *DB) GetEnvironmentRelease(environmentID string) (*EnvironmentRelease, error) {
+	var e EnvironmentRelease
+	err := db.conn.QueryRow(`
+		SELECT environment_id, channel, COALESCE(pinned_version, ''), COALESCE(updated_by, ''), updated_at
+		FROM agent_release_environments WHERE environment_id = $1`, environmentID).
+		Scan(&e.EnvironmentID, &e.Channel, &e.PinnedVersion, &e.UpdatedBy, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// SaveEnvironmentRelease sets the channel or pinned version of an environment
+func (db *DB) SaveEnvironmentRelease(e *EnvironmentRelease) error {
+	return db.conn.QueryRow(`
+		INSERT INTO agent_release_environments (environment_id, channel, pinned_version, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (environment_id) DO UPDATE SET
+			channel = EXCLUDED.channel,
+			pinned_version = EXCLUDED.pinned_version,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		e.EnvironmentID, e.Channel, nullIfEmpty(e.PinnedVersion), nullIfEmpty(e.UpdatedBy)).Scan(&e.UpdatedAt)
+}
+
+// DeleteEnvironmentRelease returns an environment to the default channel
+func (db *DB) DeleteEnvironmentRelease(environmentID string) error {
+	_, err := db.conn.Exec(`DELETE FROM agent_release_environments WHERE environment_id = $1`, environmentID)
+	return err
+}
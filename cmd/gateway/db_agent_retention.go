@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AgentRetentionPolicy controls when offline agents are deregistered
+type AgentRetentionPolicy struct {
+	EnvironmentID    string    `json:"environment_id,omitempty"`
+	PruneAfterSec    int64     `json:"prune_after_sec"`   // 0 keeps offline agents forever
+	ArchiveAnalytics bool      `json:"archive_analytics"` // keep ClickHouse data instead of deleting it
+	Inherited        bool      `json:"inherited"`         // the gateway defaults apply
+	UpdatedBy        string    `json:"updated_by,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at,omitempty"`
+}
+
+// OfflineAgent is an offline agent with the retention policy of its environment, if any
+type OfflineAgent struct {
+	AgentID         string
+	Hostname        string
+	IP              string
+	EnvironmentID   string
+	EnvironmentName string
+	LastSeen        time.Time
+	Policy          *AgentRetentionPolicy
+}
+
+// GetAgentRetentionPolicy returns the environment's policy, or nil when it uses the defaults
+func (db *DB) GetAgentRetentionPolicy(environmentID string) (*AgentRetentionPolicy, error) {
+	var p AgentRetentionPolicy
+	var updatedBy sql.NullString
+	err := db.conn.QueryRow(`
+		SELECT environment_id, prune_after_sec, archive_analytics, updated_by, updated_at
+		FROM agent_retention_policies WHERE environment_id = $1`, environmentID).
+		Scan(&p.EnvironmentID, &p.PruneAfterSec, &p.ArchiveAnalytics, &updatedBy, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.UpdatedBy = updatedBy.String
+	return &p, nil
+}
+
+func (db *DB) UpsertAgentRetentionPolicy(p *AgentRetentionPolicy) error {
+	return db.conn.QueryRow(`
+		INSERT INTO agent_retention_policies (environment_id, prune_after_sec, archive_analytics, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (environment_id) DO UPDATE SET
+			prune_after_sec = EXCLUDED.prune_after_sec,
+			archive_analytics = EXCLUDED.archive_analytics,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		p.EnvironmentID, p.PruneAfterSec, p.ArchiveAnalytics, nullIfEmpty(p.UpdatedBy)).Scan(&p.UpdatedAt)
+}
+
+func (db *DB) DeleteAgentRetentionPolicy(environmentID string) error {
+	_, err := db.conn.Exec(`DELETE FROM agent_retention_policies WHERE environment_id = $1`, environmentID)
+	return err
+}
+
+// ListOfflineAgentsForRetention returns every offline agent with its environment's policy
+func (db *DB) ListOfflineAgentsForRetention() ([]OfflineAgent, error) {
+	rows, err := db.conn.Query(`
+		SELECT a.agent_id, COALESCE(a.hostname, ''), COALESCE(a.ip, ''), COALESCE(a.last_seen, 0),
+			COALESCE(e.id::text, ''), COALESCE(e.name, ''),
+			p.prune_after_sec, p.archive_analytics
+		FROM agents a
+		LEFT JOIN server_assignments sa ON sa.agent_id = a.agent_id
+		LEFT JOIN environments e ON e.id = sa.environment_id
+		LEFT JOIN agent_retention_policies p ON p.environment_id = e.id
+		WHERE a.status = 'offline'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agents []OfflineAgent
+	for rows.Next() {
+		var a OfflineAgent
+		var lastSeen int64
+		var pruneAfter sql.NullInt64
+		var archive sql.NullBool
+		if err := rows.Scan(&a.AgentID, &a.Hostname, &a.IP, &lastSeen, &a.EnvironmentID, &a.EnvironmentName, &pruneAfter, &archive); err != nil {
+			return nil, err
+		}
+		a.LastSeen = time.Unix(lastSeen, 0)
+		if pruneAfter.Valid {
+			a.Policy = &AgentRetentionPolicy{EnvironmentID: a.EnvironmentID, PruneAfterSec: pruneAfter.Int64, ArchiveAnalytics: archive.Bool}
+		}
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
+
+// PruneStaleAgents deregisters the offline agents due under their environment's policy, or the
+// defaults, backing them up to historical_agents. Agents seen again since they were listed are skipped.
+func (db *DB) PruneStaleAgents(defaults AgentRetentionPolicy) ([]PruneCandidate, error) {
+	agents, err := db.ListOfflineAgentsForRetention()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []PruneCandidate
+	for _, c := range evaluateAgentRetention(agents, defaults, time.Now(), 0) {
+		ok, err := db.pruneAgent(c)
+		if err != nil {
+			return pruned, err
+		}
+		if ok {
+			pruned = append(pruned, c)
+		}
+	}
+	return pruned, nil
+}
+
+func (db *DB) pruneAgent(c PruneCandidate) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM agents WHERE agent_id = $1 AND status = 'offline' AND COALESCE(last_seen, 0) = $2`,
+		c.AgentID, c.LastSeen.Unix())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO historical_agents (agent_id, hostname, ip, analytics_archived)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (agent_id) DO UPDATE SET
+			hostname = EXCLUDED.hostname,
+			ip = EXCLUDED.ip,
+			analytics_archived = EXCLUDED.analytics_archived,
+			deleted_at = CURRENT_TIMESTAMP`,
+		c.AgentID, c.Hostname, nullIfEmpty(c.IP), c.ArchiveAnalytics); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AlertHistoryFilter selects the transitions of ListAlertTransitions
+type AlertHistoryFilter struct {
+	RuleID     string
+	MetricType string
+	Subject    string
+	Projects   []string // the rules of these projects; nil for every rule
+	FleetRules bool     // with Projects, also the rules of no project
+}
+
+// where returns the filter's conditions, numbering its parameters after args
+func (f AlertHistoryFilter) where(args []interface{}) (string, []interface{}) {
+	conds := []string{"TRUE"}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.RuleID != "" {
+		add("rule_id = $%d", f.RuleID)
+	}
+	if f.MetricType != "" {
+		add("metric_type = $%d", f.MetricType)
+	}
+	if f.Subject != "" {
+		add("subject = $%d", f.Subject)
+	}
+	if f.Projects != nil {
+		cond := "project_id = ANY($%d)"
+		if f.FleetRules {
+			cond = "(project_id = '' OR project_id = ANY($%d))"
+		}
+		add(cond, pq.Array(f.Projects))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func (db *DB) RecordAlertTransition(t AlertTransition) error {
+	_, err := db.conn.Exec(`INSERT INTO alert_transitions (rule_id, rule_name, project_id, metric_type, subject,
+			from_state, to_state, value, threshold, comparison, severity, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		t.RuleID, t.RuleName, t.ProjectID, t.MetricType, t.Subject, t.From, t.To, t.Value, t.Threshold,
+		t.Comparison, t.Severity, t.OccurredAt)
+	return err
+}
+
+// ListAlertTransitions returns the last transition before start of each rule and subject followed
+// by the transitions within [start, end], oldest first
+func (db *DB) ListAlertTransitions(f AlertHistoryFilter, start, end time.Time) ([]AlertTransition, error) {
+	where, args := f.where([]interface{}{start, end})
+	const columns = `id, rule_id, rule_name, project_id, metric_type, subject, from_state, to_state, value,
+		threshold, comparison, severity, occurred_at`
+	rows, err := db.conn.Query(`
+		SELECT `+columns+` FROM (
+			SELECT DISTINCT ON (rule_id, subject) `+columns+`
+			FROM alert_transitions
+			WHERE occurred_at < $1 AND `+where+`
+			ORDER BY rule_id, subject, occurred_at DESC, id DESC
+		) prior
+		UNION ALL
+		SELECT `+columns+`
+		FROM alert_transitions
+		WHERE occurred_at >= $1 AND occurred_at <= $2 AND `+where+`
+		ORDER BY occurred_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []AlertTransition
+	for rows.Next() {
+		var t AlertTransition
+		if err := rows.Scan(&t.ID, &t.RuleID, &t.RuleName, &t.ProjectID, &t.MetricType, &t.Subject, &t.From, &t.To,
+			&t.Value, &t.Threshold, &t.Comparison, &t.Severity, &t.OccurredAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+// PruneAlertTransitions deletes transitions older than the cutoff, keeping the latest of each rule
+// and subject so its current state stays known
+func (db *DB) PruneAlertTransitions(before time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM alert_transitions
+		WHERE occurred_at < $1 AND id NOT IN (
+			SELECT DISTINCT ON (rule_id, subject) id FROM alert_transitions ORDER BY rule_id, subject, occurred_at DESC, id DESC
+		)`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type IPBan struct {
+	ID        string     `json:"id"`
+	ClientIP  string     `json:"client_ip"`
+	AgentIDs  []string   `json:"agent_ids"`
+	RuleID    string     `json:"rule_id,omitempty"`
+	RuleName  string     `json:"rule_name,omitempty"`
+	Reason    string     `json:"reason"`
+	Hits      int64      `json:"hits"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  string     `json:"lifted_by,omitempty"`
+}
+
+const ipBanColumns = `id, client_ip, agent_ids, COALESCE(rule_id::text, ''), COALESCE(rule_name, ''), COALESCE(reason, ''),
+	COALESCE(hits, 0), COALESCE(created_by, ''), created_at, expires_at, lifted_at, COALESCE(lifted_by, '')`
+
+func scanIPBans(rows *sql.Rows) ([]IPBan, error) {
+	defer rows.Close()
+	bans := []IPBan{}
+	for rows.Next() {
+		var b IPBan
+		var lifted sql.NullTime
+		if err := rows.Scan(&b.ID, &b.ClientIP, pq.Array(&b.AgentIDs), &b.RuleID, &b.RuleName, &b.Reason,
+			&b.Hits, &b.CreatedBy, &b.CreatedAt, &b.ExpiresAt, &lifted, &b.LiftedBy); err != nil {
+			return nil, err
+		}
+		if lifted.Valid {
+			b.LiftedAt = &lifted.Time
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// ListIPBans returns active bans, or the most recent bans including lifted ones when includeLifted is set
+func (db *DB) ListIPBans(includeLifted bool, limit int) ([]IPBan, error) {
+	rows, err := db.conn.Query(`SELECT `+ipBanColumns+` FROM ip_bans
+		WHERE $1 OR lifted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $2`, includeLifted, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanIPBans(rows)
+}
+
+// ListActiveBansForAgent returns the unexpired bans that apply to one agent
+func (db *DB) ListActiveBansForAgent(agentID string) ([]IPBan, error) {
+	rows, err := db.conn.Query(`SELECT `+ipBanColumns+` FROM ip_bans
+		WHERE lifted_at IS NULL AND expires_at > CURRENT_TIMESTAMP AND $1 = ANY(agent_ids)
+		ORDER BY client_ip`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	return scanIPBans(rows)
+}
+
+// UpsertIPBan creates a ban or, when the address is already banned, extends it and adds the agents
+func (db *DB) UpsertIPBan(b *IPBan) error {
+	var lifted sql.NullTime
+	return db.conn.QueryRow(`
+		INSERT INTO ip_bans (client_ip, agent_ids, rule_id, rule_name, reason, hits, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (client_ip) WHERE lifted_at IS NULL DO UPDATE SET
+			agent_ids = ARRAY(SELECT DISTINCT unnest(ip_bans.agent_ids || EXCLUDED.agent_ids) ORDER BY 1),
+			rule_id = EXCLUDED.rule_id,
+			rule_name = EXCLUDED.rule_name,
+			reason = EXCLUDED.reason,
+			hits = ip_bans.hits + EXCLUDED.hits,
+			expires_at = GREATEST(ip_bans.expires_at, EXCLUDED.expires_at)
+		RETURNING `+ipBanColumns,
+		b.ClientIP, pq.Array(b.AgentIDs), nullIfEmpty(b.RuleID), nullIfEmpty(b.RuleName), nullIfEmpty(b.Reason), b.Hits,
+		nullIfEmpty(b.CreatedBy), b.ExpiresAt).
+		Scan(&b.ID, &b.ClientIP, pq.Array(&b.AgentIDs), &b.RuleID, &b.RuleName, &b.Reason,
+			&b.Hits, &b.CreatedBy, &b.CreatedAt, &b.ExpiresAt, &lifted, &b.LiftedBy)
+}
+
+// LiftIPBan ends an active ban early; it returns nil when no active ban had that ID
+func (db *DB) LiftIPBan(id, liftedBy string) (*IPBan, error) {
+	rows, err := db.conn.Query(`UPDATE ip_bans SET lifted_at = CURRENT_TIMESTAMP, lifted_by = $2
+		WHERE id = $1 AND lifted_at IS NULL
+		RETURNING `+ipBanColumns, id, liftedBy)
+	if err != nil {
+		return nil, err
+	}
+	bans, err := scanIPBans(rows)
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+	return &bans[0], nil
+}
+
+// ExpireIPBans lifts every ban past its expiry and returns them
+func (db *DB) ExpireIPBans() ([]IPBan, error) {
+	rows, err := db.conn.Query(`UPDATE ip_bans SET lifted_at = CURRENT_TIMESTAMP, lifted_by = 'expired'
+		WHERE lifted_at IS NULL AND expires_at <= CURRENT_TIMESTAMP
+		RETURNING ` + ipBanColumns)
+	if err != nil {
+		return nil, err
+	}
+	return scanIPBans(rows)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+type BlocklistEntry struct {
+	ID        string     `json:"id"`
+	CIDR      string     `json:"cidr"`
+	Reason    string     `json:"reason"`
+	Source    string     `json:"source"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListBlocklist returns blocked addresses; expired entries are omitted unless includeExpired is set
+func (db *DB) ListBlocklist(includeExpired bool) ([]BlocklistEntry, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, cidr, COALESCE(reason, ''), COALESCE(source, 'manual'), COALESCE(created_by, ''), created_at, expires_at
+		FROM ip_blocklist
+		WHERE $1 OR expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP
+		ORDER BY created_at DESC`, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []BlocklistEntry{}
+	for rows.Next() {
+		var e BlocklistEntry
+		var expires sql.NullTime
+		if err := rows.Scan(&e.ID, &e.CIDR, &e.Reason, &e.Source, &e.CreatedBy, &e.CreatedAt, &expires); err != nil {
+			return nil, err
+		}
+		if expires.Valid {
+			e.ExpiresAt = &expires.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// UpsertBlocklistEntry adds an address, refreshing reason and expiry when it is already listed
+func (db *DB) UpsertBlocklistEntry(e *BlocklistEntry) error {
+	return db.conn.QueryRow(`
+		INSERT INTO ip_blocklist (cidr, reason, source, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cidr) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			source = EXCLUDED.source,
+			expires_at = EXCLUDED.expires_at
+		RETURNING id, created_at`,
+		e.CIDR, nullIfEmpty(e.Reason), e.Source, nullIfEmpty(e.CreatedBy), e.ExpiresAt).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+// DeleteBlocklistEntry removes an entry; it reports false when no entry had that ID
+func (db *DB) DeleteBlocklistEntry(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM ip_blocklist WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// BulkSelector picks the agents of a bulk action: the listed agents, group and environment, or
+// the whole fleet when none is set, narrowed to the agents carrying every tag
+type BulkSelector struct {
+	AgentIDs      []string `json:"agent_ids,omitempty"`
+	GroupID       string   `json:"group_id,omitempty"`
+	EnvironmentID string   `json:"environment_id,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// BulkActionParams are the options passed to the action on each agent
+type BulkActionParams struct {
+	NginxInstanceID string `json:"nginx_instance_id,omitempty"` // reload and restart
+	Version         string `json:"version,omitempty"`           // update; the served version when empty
+}
+
+// BulkAction is a reload, restart or agent update fanned out over a selection of agents
+type BulkAction struct {
+	ID          string             `json:"id"`
+	Action      string             `json:"action"`
+	Selector    BulkSelector       `json:"selector"`
+	Params      BulkActionParams   `json:"params"`
+	Concurrency int                `json:"concurrency"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	GatewayID   string             `json:"gateway_id,omitempty"`
+	CreatedBy   string             `json:"created_by,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	Progress    BulkProgress       `json:"progress"`
+	Results     []BulkActionResult `json:"results,omitempty"`
+}
+
+// BulkProgress counts the agents of a bulk action by result status
+type BulkProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// BulkActionResult is the outcome of a bulk action on one agent
+type BulkActionResult struct {
+	AgentID     string     `json:"agent_id"`
+	Hostname    string     `json:"hostname,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (p *BulkProgress) add(status string, n int) {
+	p.Total += n
+	switch status {
+	case "pending":
+		p.Pending += n
+	case "running":
+		p.Running += n
+	case "succeeded":
+		p.Succeeded += n
+	case "failed":
+		p.Failed += n
+	case "skipped":
+		p.Skipped += n
+	}
+}
+
+const bulkActionColumns = `id, action, selector, params, concurrency, status, COALESCE(error, ''), COALESCE(gateway_id, ''),
+	COALESCE(created_by, ''), created_at, completed_at`
+
+func scanBulkAction(row interface{ Scan(...interface{}) error }) (*BulkAction, error) {
+	var a BulkAction
+	var selector, params []byte
+	var completedAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.Action, &selector, &params, &a.Concurrency, &a.Status, &a.Error, &a.GatewayID,
+		&a.CreatedBy, &a.CreatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(selector, &a.Selector)
+	json.Unmarshal(params, &a.Params)
+	a.CompletedAt = nullTimePtr(completedAt)
+	return &a, nil
+}
+
+// CreateBulkAction records a bulk action and the initial result of each of its agents
+func (db *DB) CreateBulkAction(a *BulkAction) error {
+	selector, err := json.Marshal(a.Selector)
+	if err != nil {
+		return err
+	}
+	params, err := json.Marshal(a.Params)
+	if err != nil {
+		return err
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`
+		INSERT INTO bulk_actions (id, action, selector, params, concurrency, status, gateway_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		a.ID, a.Action, selector, params, a.Concurrency, a.Status, nullIfEmpty(a.GatewayID),
+		nullIfEmpty(a.CreatedBy)).Scan(&a.CreatedAt); err != nil {
+		return err
+	}
+	for _, r := range a.Results {
+		if _, err := tx.Exec(`
+			INSERT INTO bulk_action_results (bulk_action_id, agent_id, hostname, status, error)
+			VALUES ($1, $2, $3, $4, $5)`,
+			a.ID, r.AgentID, nullIfEmpty(r.Hostname), r.Status, nullIfEmpty(r.Error)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveBulkActionResult saves the outcome of a bulk action for one agent
+func (db *DB) SaveBulkActionResult(id string, r *BulkActionResult) error {
+	_, err := db.conn.Exec(`
+		UPDATE bulk_action_results
+		SET status = $3, error = $4, started_at = $5, completed_at = $6
+		WHERE bulk_action_id = $1 AND agent_id = $2`,
+		id, r.AgentID, r.Status, nullIfEmpty(r.Error), r.StartedAt, r.CompletedAt)
+	return err
+}
+
+// FinishBulkAction saves the final status of a bulk action
+func (db *DB) FinishBulkAction(a *BulkAction) error {
+	_, err := db.conn.Exec(`UPDATE bulk_actions SET status = $2, error = $3, completed_at = $4 WHERE id = $1`,
+		a.ID, a.Status, nullIfEmpty(a.Error), a.CompletedAt)
+	return err
+}
+
+// GetBulkAction returns a bulk action with the result of each agent; nil when it does not exist
+func (db *DB) GetBulkAction(id string) (*BulkAction, error) {
+	a, err := scanBulkAction(db.conn.QueryRow(`SELECT `+bulkActionColumns+` FROM bulk_actions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT agent_id, COALESCE(hostname, ''), status, COALESCE(error, ''), started_at, completed_at
+		FROM bulk_action_results WHERE bulk_action_id = $1
+		ORDER BY agent_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	a.Results = []BulkActionResult{}
+	for rows.Next() {
+		var r BulkActionResult
+		var startedAt, completedAt sql.NullTime
+		if err := rows.Scan(&r.AgentID, &r.Hostname, &r.Status, &r.Error, &startedAt, &completedAt); err != nil {
+			return nil, err
+		}
+		r.StartedAt, r.CompletedAt = nullTimePtr(startedAt), nullTimePtr(completedAt)
+		a.Progress.add(r.Status, 1)
+		a.Results = append(a.Results, r)
+	}
+	return a, rows.Err()
+}
+
+// ListBulkActions returns the bulk actions created by createdBy, or all of them when it is empty,
+// newest first with their progress but no results
+func (db *DB) ListBulkActions(createdBy string, limit int) ([]BulkAction, error) {
+	rows, err := db.conn.Query(`SELECT `+bulkActionColumns+` FROM bulk_actions
+		WHERE $1 = '' OR created_by = $1
+		ORDER BY created_at DESC LIMIT $2`, createdBy, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []BulkAction{}
+	index := make(map[string]int)
+	var ids []string
+	for rows.Next() {
+		a, err := scanBulkAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[a.ID] = len(actions)
+		ids = append(ids, a.ID)
+		actions = append(actions, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return actions, nil
+	}
+
+	counts, err := db.conn.Query(`
+		SELECT bulk_action_id, status, COUNT(*) FROM bulk_action_results
+		WHERE bulk_action_id = ANY($1::uuid[])
+		GROUP BY bulk_action_id, status`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close()
+	for counts.Next() {
+		var id, status string
+		var n int
+		if err := counts.Scan(&id, &status, &n); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			actions[i].Progress.add(status, n)
+		}
+	}
+	return actions, counts.Err()
+}
+
+// InterruptBulkActions marks the bulk actions a gateway was running when it stopped; their agents
+// not acted on yet are skipped
+func (db *DB) InterruptBulkActions(gatewayID string) (int64, error) {
+	res, err := db.conn.Exec(`
+		UPDATE bulk_actions
+		SET status = 'interrupted', error = 'gateway restarted during the bulk action', completed_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND COALESCE(gateway_id, '') = $1`, gatewayID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.conn.Exec(`
+		UPDATE bulk_action_results SET status = 'skipped', error = 'bulk action interrupted'
+		WHERE status IN ('pending', 'running') AND bulk_action_id IN (
+			SELECT id FROM bulk_actions WHERE status = 'interrupted' AND COALESCE(gateway_id, '') = $1)`, gatewayID); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/lib/pq"
+)
+
+// AgentCertificate is a certificate discovered on an agent by the certificate monitor
+type AgentCertificate struct {
+	AgentID         string    `json:"agent_id"`
+	Hostname        string    `json:"hostname"`
+	Domain          string    `json:"domain"`
+	CertPath        string    `json:"cert_path"`
+	KeyPath         string    `json:"key_path"`
+	Issuer          string    `json:"issuer"`
+	SANDomains      []string  `json:"san_domains"`
+	ExpiryDate      time.Time `json:"expiry_date"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	LastAlertDays   *int      `json:"last_alert_days,omitempty"`
+	LastSeen        time.Time `json:"last_seen"`
+}
+
+// SyncAgentCertificates replaces the stored inventory for an agent with the certificates it reported.
+// The alert milestone is reset when a certificate's expiry changes (i.e. it was renewed).
+func (db *DB) SyncAgentCertificates(ctx context.Context, agentID string, certs []*pb.Certificate) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	paths := make([]string, 0, len(certs))
+	for _, c := range certs {
+		if c.CertPath == "" || c.ExpiryTimestamp == 0 {
+			continue
+		}
+		paths = append(paths, c.CertPath)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO agent_certificates (agent_id, cert_path, domain, key_path, issuer, san_domains, expiry_date, last_seen)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			ON CONFLICT (agent_id, cert_path) DO UPDATE SET
+				domain = EXCLUDED.domain,
+				key_path = EXCLUDED.key_path,
+				issuer = EXCLUDED.issuer,
+				san_domains = EXCLUDED.san_domains,
+				last_alert_days = CASE WHEN agent_certificates.expiry_date <> EXCLUDED.expiry_date
+					THEN NULL ELSE agent_certificates.last_alert_days END,
+				expiry_date = EXCLUDED.expiry_date,
+				last_seen = NOW()
+		`, agentID, c.CertPath, c.Domain, c.KeyPath, c.Issuer, pq.Array(c.SanDomains), time.Unix(c.ExpiryTimestamp, 0).UTC())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Drop certificates that are no longer present on the agent
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM agent_certificates WHERE agent_id = $1 AND NOT (cert_path = ANY($2))`,
+		agentID, pq.Array(paths)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListFleetCertificates returns certificates across all agents ordered by expiry (soonest first).
+// agentIDs restricts the result when non-nil; withinDays > 0 limits to certificates expiring within that window.
+func (db *DB) ListFleetCertificates(ctx context.Context, agentIDs []string, withinDays int) ([]AgentCertificate, error) {
+	query := `
+		SELECT c.agent_id, COALESCE(a.hostname, ''), c.domain, c.cert_path, COALESCE(c.key_path, ''),
+		       COALESCE(c.issuer, ''), c.san_domains, c.expiry_date, c.last_alert_days, c.last_seen
+		FROM agent_certificates c
+		LEFT JOIN agents a ON a.agent_id = c.agent_id
+		WHERE ($1::text[] IS NULL OR c.agent_id = ANY($1))
+		  AND ($2::int <= 0 OR c.expiry_date <= NOW() + make_interval(days => $2::int))
+		ORDER BY c.expiry_date ASC, c.domain ASC
+	`
+	var filter interface{}
+	if agentIDs != nil {
+		filter = pq.Array(agentIDs)
+	}
+	rows, err := db.conn.QueryContext(ctx, query, filter, withinDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	certs := []AgentCertificate{}
+	for rows.Next() {
+		var c AgentCertificate
+		var sans pq.StringArray
+		var lastAlert sql.NullInt64
+		if err := rows.Scan(&c.AgentID, &c.Hostname, &c.Domain, &c.CertPath, &c.KeyPath,
+			&c.Issuer, &sans, &c.ExpiryDate, &lastAlert, &c.LastSeen); err != nil {
+			return nil, err
+		}
+		c.SANDomains = sans
+		if lastAlert.Valid {
+			v := int(lastAlert.Int64)
+			c.LastAlertDays = &v
+		}
+		c.DaysUntilExpiry = daysUntil(now, c.ExpiryDate)
+		certs = append(certs, c)
+	}
+	return certs, rows.Err()
+}
+
+// MarkCertificateAlerted records the expiry milestone that was last alerted for a certificate
+func (db *DB) MarkCertificateAlerted(ctx context.Context, agentID, certPath string, milestone int) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE agent_certificates SET last_alert_days = $3 WHERE agent_id = $1 AND cert_path = $2`,
+		agentID, certPath, milestone)
+	return err
+}
+
+// daysUntil returns whole days from now until t (negative once t has passed).
+func daysUntil(now, t time.Time) int {
+	d := t.Sub(now)
+	if d < 0 {
+		return -int((-d).Hours()/24) - 1
+	}
+	return int(d.Hours() / 24)
+}
@@ -56,3 +56,14 @@ func (db *DB) GetConfigBackup(ctx context.Context, id int) (*ConfigBackup, error
 	}
 	return &b, nil
 }
+
+// CreateConfigBackup stores a copy of an agent's config and certificate list.
+func (db *DB) CreateConfigBackup(ctx context.Context, agentID, backupType, content string, certificatesJSON []byte) error {
+	query := `
+		INSERT INTO config_backups (
+			agent_id, backup_type, config_content, certificates_json, created_at
+		) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+	`
+	_, err := db.conn.ExecContext(ctx, query, agentID, backupType, content, certificatesJSON)
+	return err
+}
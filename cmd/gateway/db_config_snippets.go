@@ -0,0 +1,263 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ConfigSnippet is a piece of NGINX config kept on the gateway and written to the same managed
+// include file on every agent, so configs share it with `include avika/snippets/<slug>.conf;`
+type ConfigSnippet struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Category    string    `json:"category"`
+	Description string    `json:"description,omitempty"`
+	Content     string    `json:"content"`
+	Version     int       `json:"version"`
+	IncludePath string    `json:"include_path"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Agents counts the agents the snippet was written to, Outdated those holding an older version
+	Agents      int                 `json:"agents"`
+	Outdated    int                 `json:"outdated"`
+	Deployments []SnippetDeployment `json:"deployments,omitempty"`
+}
+
+// ConfigSnippetVersion is the content of a snippet at one version
+type ConfigSnippetVersion struct {
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SnippetDeployment is the version of a snippet last written to an agent
+type SnippetDeployment struct {
+	AgentID    string    `json:"agent_id"`
+	Version    int       `json:"version"`
+	Status     string    `json:"status"` // applied, failed
+	Error      string    `json:"error,omitempty"`
+	Outdated   bool      `json:"outdated"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+const configSnippetColumns = `id, name, slug, category, COALESCE(description, ''), content, version,
+	COALESCE(created_by, ''), COALESCE(updated_by, ''), created_at, updated_at,
+	(SELECT COUNT(*) FROM config_snippet_deployments d WHERE d.snippet_id = config_snippets.id),
+	(SELECT COUNT(*) FROM config_snippet_deployments d WHERE d.snippet_id = config_snippets.id
+		AND (d.version < config_snippets.version OR d.status <> 'applied'))`
+
+func scanConfigSnippet(row interface{ Scan(...interface{}) error }) (*ConfigSnippet, error) {
+	var sn ConfigSnippet
+	if err := row.Scan(&sn.ID, &sn.Name, &sn.Slug, &sn.Category, &sn.Description, &sn.Content, &sn.Version,
+		&sn.CreatedBy, &sn.UpdatedBy, &sn.CreatedAt, &sn.UpdatedAt, &sn.Agents, &sn.Outdated); err != nil {
+		return nil, err
+	}
+	sn.IncludePath = snippetIncludePath(sn.Slug)
+	return &sn, nil
+}
+
+// CreateConfigSnippet records a snippet as version 1
+func (db *DB) CreateConfigSnippet(sn *ConfigSnippet) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sn.Version = 1
+	if err := tx.QueryRow(`
+		INSERT INTO config_snippets (id, name, slug, category, description, content, version, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING created_at, updated_at`,
+		sn.ID, sn.Name, sn.Slug, sn.Category, nullIfEmpty(sn.Description), sn.Content, sn.Version,
+		nullIfEmpty(sn.CreatedBy)).Scan(&sn.CreatedAt, &sn.UpdatedAt); err != nil {
+		return err
+	}
+	if err := insertConfigSnippetVersion(tx, sn, sn.CreatedBy); err != nil {
+		return err
+	}
+	sn.UpdatedBy = sn.CreatedBy
+	return tx.Commit()
+}
+
+// UpdateConfigSnippet saves the name, category, description and content of a snippet. A content
+// change bumps the version, which is kept in config_snippet_versions.
+func (db *DB) UpdateConfigSnippet(sn *ConfigSnippet, contentChanged bool) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if contentChanged {
+		sn.Version++
+	}
+	if err := tx.QueryRow(`
+		UPDATE config_snippets
+		SET name = $2, category = $3, description = $4, content = $5, version = $6, updated_by = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		sn.ID, sn.Name, sn.Category, nullIfEmpty(sn.Description), sn.Content, sn.Version,
+		nullIfEmpty(sn.UpdatedBy)).Scan(&sn.UpdatedAt); err != nil {
+		return err
+	}
+	if contentChanged {
+		if err := insertConfigSnippetVersion(tx, sn, sn.UpdatedBy); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertConfigSnippetVersion(tx *sql.Tx, sn *ConfigSnippet, username string) error {
+	_, err := tx.Exec(`
+		INSERT INTO config_snippet_versions (snippet_id, version, content, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (snippet_id, version) DO UPDATE SET content = EXCLUDED.content`,
+		sn.ID, sn.Version, sn.Content, nullIfEmpty(username))
+	return err
+}
+
+// GetConfigSnippet returns a snippet; nil when it does not exist
+func (db *DB) GetConfigSnippet(id string) (*ConfigSnippet, error) {
+	sn, err := scanConfigSnippet(db.conn.QueryRow(`SELECT `+configSnippetColumns+` FROM config_snippets WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sn, err
+}
+
+// GetConfigSnippetBySlug returns the snippet written to avika/snippets/<slug>.conf; nil when there is none
+func (db *DB) GetConfigSnippetBySlug(slug string) (*ConfigSnippet, error) {
+	sn, err := scanConfigSnippet(db.conn.QueryRow(`SELECT `+configSnippetColumns+` FROM config_snippets WHERE slug = $1`, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sn, err
+}
+
+// ListConfigSnippets returns the snippets of category, or all of them when it is empty, by name
+func (db *DB) ListConfigSnippets(category string) ([]ConfigSnippet, error) {
+	rows, err := db.conn.Query(`SELECT `+configSnippetColumns+` FROM config_snippets
+		WHERE $1 = '' OR category = $1
+		ORDER BY name`, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []ConfigSnippet{}
+	for rows.Next() {
+		sn, err := scanConfigSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, *sn)
+	}
+	return snippets, rows.Err()
+}
+
+// DeleteConfigSnippet deletes a snippet with its versions and deployment records
+func (db *DB) DeleteConfigSnippet(id string) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM config_snippets WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListConfigSnippetVersions returns the version history of a snippet, newest first
+func (db *DB) ListConfigSnippetVersions(id string) ([]ConfigSnippetVersion, error) {
+	rows, err := db.conn.Query(`
+		SELECT version, content, COALESCE(created_by, ''), created_at
+		FROM config_snippet_versions WHERE snippet_id = $1
+		ORDER BY version DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []ConfigSnippetVersion{}
+	for rows.Next() {
+		var v ConfigSnippetVersion
+		if err := rows.Scan(&v.Version, &v.Content, &v.CreatedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetConfigSnippetVersion returns the content of a snippet at version; nil when there is no such version
+func (db *DB) GetConfigSnippetVersion(id string, version int) (*ConfigSnippetVersion, error) {
+	var v ConfigSnippetVersion
+	err := db.conn.QueryRow(`
+		SELECT version, content, COALESCE(created_by, ''), created_at
+		FROM config_snippet_versions WHERE snippet_id = $1 AND version = $2`, id, version).
+		Scan(&v.Version, &v.Content, &v.CreatedBy, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListSnippetDeployments returns the agents a snippet was written to, marking those behind its
+// current version
+func (db *DB) ListSnippetDeployments(sn *ConfigSnippet) ([]SnippetDeployment, error) {
+	rows, err := db.conn.Query(`
+		SELECT agent_id, version, status, COALESCE(error, ''), deployed_at
+		FROM config_snippet_deployments WHERE snippet_id = $1
+		ORDER BY agent_id`, sn.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deployments := []SnippetDeployment{}
+	for rows.Next() {
+		var d SnippetDeployment
+		if err := rows.Scan(&d.AgentID, &d.Version, &d.Status, &d.Error, &d.DeployedAt); err != nil {
+			return nil, err
+		}
+		d.Outdated = d.Version < sn.Version || d.Status != "applied"
+		deployments = append(deployments, d)
+	}
+	return deployments, rows.Err()
+}
+
+// GetSnippetDeployment returns what was last written of a snippet to an agent; nil when nothing was
+func (db *DB) GetSnippetDeployment(snippetID, agentID string) (*SnippetDeployment, error) {
+	d := SnippetDeployment{AgentID: agentID}
+	err := db.conn.QueryRow(`
+		SELECT version, status, COALESCE(error, ''), deployed_at
+		FROM config_snippet_deployments WHERE snippet_id = $1 AND agent_id = $2`, snippetID, agentID).
+		Scan(&d.Version, &d.Status, &d.Error, &d.DeployedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SaveSnippetDeployment records the outcome of writing a snippet version to an agent
+func (db *DB) SaveSnippetDeployment(snippetID string, d *SnippetDeployment) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO config_snippet_deployments (snippet_id, agent_id, version, status, error, deployed_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (snippet_id, agent_id) DO UPDATE SET
+			version = EXCLUDED.version, status = EXCLUDED.status, error = EXCLUDED.error,
+			deployed_at = EXCLUDED.deployed_at`,
+		snippetID, d.AgentID, d.Version, d.Status, nullIfEmpty(d.Error))
+	return err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PanelLayout is a panel's position on the dashboard grid
+type PanelLayout struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type DashboardPanel struct {
+	ID          string      `json:"id"`
+	DashboardID string      `json:"dashboard_id"`
+	Title       string      `json:"title"`
+	Type        string      `json:"type"` // timeseries, bar, pie, stat, table
+	Query       MetricQuery `json:"query"`
+	Layout      PanelLayout `json:"layout"`
+	Position    int         `json:"position"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type Dashboard struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Owner       string           `json:"owner"`
+	TeamID      string           `json:"team_id,omitempty"`
+	Shared      bool             `json:"is_shared"`
+	TimeWindow  string           `json:"time_window"`
+	Panels      []DashboardPanel `json:"panels,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+const dashboardColumns = `id, name, COALESCE(description, ''), owner, COALESCE(team_id::text, ''), COALESCE(is_shared, false),
+	COALESCE(time_window, '24h'), created_at, updated_at`
+
+func scanDashboard(row rowScanner) (*Dashboard, error) {
+	var d Dashboard
+	if err := row.Scan(&d.ID, &d.Name, &d.Description, &d.Owner, &d.TeamID, &d.Shared, &d.TimeWindow, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDashboardsForUser returns dashboards the user owns, shares a team with, or that are shared
+// with everyone. With all=true (superadmins) every dashboard is returned.
+func (db *DB) ListDashboardsForUser(username string, all bool) ([]Dashboard, error) {
+	query := `SELECT ` + dashboardColumns + ` FROM dashboards
+		WHERE $2 OR owner = $1 OR is_shared
+		   OR team_id IN (SELECT team_id FROM team_members WHERE username = $1)
+		ORDER BY name`
+	rows, err := db.conn.Query(query, username, all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dashboards []Dashboard
+	for rows.Next() {
+		d, err := scanDashboard(rows)
+		if err != nil {
+			return nil, err
+		}
+		dashboards = append(dashboards, *d)
+	}
+	return dashboards, rows.Err()
+}
+
+// GetDashboard retrieves a dashboard with its panels; it returns nil when not found
+func (db *DB) GetDashboard(id string) (*Dashboard, error) {
+	d, err := scanDashboard(db.conn.QueryRow(`SELECT `+dashboardColumns+` FROM dashboards WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, dashboard_id, title, panel_type, query, layout, position, created_at, updated_at
+		FROM dashboard_panels WHERE dashboard_id = $1 ORDER BY position, created_at
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	d.Panels = []DashboardPanel{}
+	for rows.Next() {
+		var p DashboardPanel
+		var query, layout []byte
+		if err := rows.Scan(&p.ID, &p.DashboardID, &p.Title, &p.Type, &query, &layout, &p.Position, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(query, &p.Query); err != nil {
+			return nil, fmt.Errorf("panel %s: invalid query: %w", p.ID, err)
+		}
+		_ = json.Unmarshal(layout, &p.Layout)
+		d.Panels = append(d.Panels, p)
+	}
+	return d, rows.Err()
+}
+
+// CreateDashboard inserts a dashboard owned by d.Owner
+func (db *DB) CreateDashboard(d *Dashboard) error {
+	return db.conn.QueryRow(`
+		INSERT INTO dashboards (name, description, owner, team_id, is_shared, time_window)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, d.Name, d.Description, d.Owner, nullIfEmpty(d.TeamID), d.Shared, d.TimeWindow).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+}
+
+// UpdateDashboard updates a dashboard's metadata (not its panels or owner)
+func (db *DB) UpdateDashboard(d *Dashboard) error {
+	return db.conn.QueryRow(`
+		UPDATE dashboards
+		SET name = $2, description = $3, team_id = $4, is_shared = $5, time_window = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`, d.ID, d.Name, d.Description, nullIfEmpty(d.TeamID), d.Shared, d.TimeWindow).Scan(&d.UpdatedAt)
+}
+
+// DeleteDashboard deletes a dashboard and its panels
+func (db *DB) DeleteDashboard(id string) error {
+	_, err := db.conn.Exec("DELETE FROM dashboards WHERE id = $1", id)
+	return err
+}
+
+// SaveDashboardPanel inserts the panel, or updates it when p.ID is set
+func (db *DB) SaveDashboardPanel(p *DashboardPanel) error {
+	query, err := json.Marshal(p.Query)
+	if err != nil {
+		return err
+	}
+	layout, err := json.Marshal(p.Layout)
+	if err != nil {
+		return err
+	}
+
+	if p.ID == "" {
+		return db.conn.QueryRow(`
+			INSERT INTO dashboard_panels (dashboard_id, title, panel_type, query, layout, position)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at, updated_at
+		`, p.DashboardID, p.Title, p.Type, query, layout, p.Position).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	}
+	err = db.conn.QueryRow(`
+		UPDATE dashboard_panels
+		SET title = $3, panel_type = $4, query = $5, layout = $6, position = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND dashboard_id = $2
+		RETURNING created_at, updated_at
+	`, p.ID, p.DashboardID, p.Title, p.Type, query, layout, p.Position).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`UPDATE dashboards SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, p.DashboardID)
+	return err
+}
+
+// DeleteDashboardPanel removes a panel from a dashboard
+func (db *DB) DeleteDashboardPanel(dashboardID, panelID string) error {
+	_, err := db.conn.Exec("DELETE FROM dashboard_panels WHERE id = $1 AND dashboard_id = $2", panelID, dashboardID)
+	return err
+}
+
+// IsTeamMember reports whether username belongs to the team
+func (db *DB) IsTeamMember(teamID, username string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND username = $2)`, teamID, username).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SystemEvent is an entry of the activity feed
+type SystemEvent struct {
+	ID            int64                  `json:"id"`
+	Type          string                 `json:"type"`
+	Category      string                 `json:"category"`
+	Severity      string                 `json:"severity"`
+	AgentID       string                 `json:"agent_id,omitempty"`
+	EnvironmentID string                 `json:"environment_id,omitempty"`
+	ProjectID     string                 `json:"project_id,omitempty"`
+	Actor         string                 `json:"actor,omitempty"`
+	Message       string                 `json:"message"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	GatewayID     string                 `json:"gateway_id,omitempty"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+}
+
+// EventFilter selects feed entries. A nil AgentIDs matches every agent, an empty one none.
+type EventFilter struct {
+	AgentIDs      []string
+	ProjectID     string
+	EnvironmentID string
+	Types         []string
+	Category      string
+	Severity      string
+	Since         time.Time
+	Until         time.Time
+	AfterID       int64 // when non-zero, newer than this ID oldest first; otherwise newest first
+	BeforeID      int64
+	Limit         int
+}
+
+// RecordEvent stores an event, tagging it with the environment and project its agent is assigned to
+func (db *DB) RecordEvent(ev *SystemEvent) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO events (type, category, severity, agent_id, environment_id, project_id, actor, message, data, gateway_id, occurred_at)
+		SELECT $1, $2, $3, $4::varchar, sa.environment_id, e.project_id, $5, $6, $7, $8, $9
+		FROM (SELECT 1) one
+		LEFT JOIN server_assignments sa ON sa.agent_id = $4
+		LEFT JOIN environments e ON e.id = sa.environment_id
+		RETURNING id, COALESCE(environment_id::text, ''), COALESCE(project_id::text, '')`,
+		ev.Type, ev.Category, ev.Severity, nullIfEmpty(ev.AgentID), nullIfEmpty(ev.Actor), ev.Message, data,
+		nullIfEmpty(ev.GatewayID), ev.OccurredAt).Scan(&ev.ID, &ev.EnvironmentID, &ev.ProjectID)
+}
+
+// ListEvents returns the events matching f
+func (db *DB) ListEvents(f EventFilter) ([]SystemEvent, error) {
+	order := "DESC"
+	if f.AfterID != 0 {
+		order = "ASC"
+	}
+	var agentIDs interface{}
+	if f.AgentIDs != nil {
+		agentIDs = pq.Array(f.AgentIDs)
+	}
+	var since, until interface{}
+	if !f.Since.IsZero() {
+		since = f.Since
+	}
+	if !f.Until.IsZero() {
+		until = f.Until
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, type, category, severity, COALESCE(agent_id, ''), COALESCE(environment_id::text, ''),
+			COALESCE(project_id::text, ''), COALESCE(actor, ''), message, COALESCE(data, '{}'), COALESCE(gateway_id, ''), occurred_at
+		FROM events
+		WHERE ($1::text[] IS NULL OR agent_id = ANY($1))
+			AND ($2 = '' OR project_id::text = $2)
+			AND ($3 = '' OR environment_id::text = $3)
+			AND (COALESCE(cardinality($4::text[]), 0) = 0 OR type = ANY($4))
+			AND ($5 = '' OR category = $5)
+			AND ($6 = '' OR severity = $6)
+			AND ($7::timestamptz IS NULL OR occurred_at >= $7)
+			AND ($8::timestamptz IS NULL OR occurred_at < $8)
+			AND ($9 = 0 OR id > $9)
+			AND ($10 = 0 OR id < $10)
+		ORDER BY id `+order+` LIMIT $11`,
+		agentIDs, f.ProjectID, f.EnvironmentID, pq.Array(f.Types), f.Category, f.Severity, since, until,
+		f.AfterID, f.BeforeID, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []SystemEvent{}
+	for rows.Next() {
+		var ev SystemEvent
+		var data []byte
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.Category, &ev.Severity, &ev.AgentID, &ev.EnvironmentID, &ev.ProjectID,
+			&ev.Actor, &ev.Message, &data, &ev.GatewayID, &ev.OccurredAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &ev.Data); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// PruneEvents deletes the events that occurred before cutoff
+func (db *DB) PruneEvents(cutoff time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM events WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// SharedAgent is an agent as recorded in Postgres by the gateway owning it
+type SharedAgent struct {
+	Info *pb.AgentInfo
+	// OwnerLive is true while the gateway in Info.GatewayId renews its leases
+	OwnerLive bool
+}
+
+// TouchGatewayInstance records that a gateway is alive, and the address other gateways reach it on
+func (db *DB) TouchGatewayInstance(instanceID, address string) error {
+	_, err := db.conn.Exec(`INSERT INTO gateway_instances (instance_id, address, last_seen) VALUES ($1, $2, NOW())
+		ON CONFLICT (instance_id) DO UPDATE SET address = EXCLUDED.address, last_seen = NOW()`, instanceID, address)
+	return err
+}
+
+// AcquireGatewayLease takes or renews the lease name for holder until ttl from now. It fails
+// (false, nil) while another gateway holds an unexpired lease.
+func (db *DB) AcquireGatewayLease(name, holder string, ttl time.Duration) (bool, error) {
+	var current string
+	err := db.conn.QueryRow(`
+		INSERT INTO gateway_leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE gateway_leases.holder = EXCLUDED.holder OR gateway_leases.expires_at < NOW()
+		RETURNING holder`, name, holder, ttl.Milliseconds()).Scan(&current)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// ReleaseGateway drops the leases of a gateway shutting down, and its instance so the agents it
+// owned are no longer attributed to a live gateway
+func (db *DB) ReleaseGateway(instanceID string) error {
+	if _, err := db.conn.Exec(`DELETE FROM gateway_leases WHERE holder = $1`, instanceID); err != nil {
+		return err
+	}
+	_, err := db.conn.Exec(`DELETE FROM gateway_instances WHERE instance_id = $1`, instanceID)
+	return err
+}
+
+// MarkAgentDisconnected marks an agent offline after its stream on gatewayID ended, unless it has
+// reconnected to another gateway since. It reports whether the agent was marked.
+func (db *DB) MarkAgentDisconnected(agentID, gatewayID string, lastSeen time.Time) (bool, error) {
+	res, err := db.conn.Exec(`UPDATE agents SET status = 'offline', last_seen = $3
+		WHERE agent_id = $1 AND gateway_id = $2`, agentID, gatewayID, lastSeen.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListSharedAgents returns every agent with the gateway owning it; a gateway is live while it
+// renewed its leases within ttl
+func (db *DB) ListSharedAgents(ttl time.Duration) ([]SharedAgent, error) {
+	rows, err := db.conn.Query(`
+		SELECT a.agent_id, a.hostname, a.version, a.instances_count, a.uptime, a.ip, a.status, a.last_seen,
+			COALESCE(a.is_pod, FALSE), a.pod_ip, a.agent_version, COALESCE(a.psk_authenticated, FALSE), a.kubernetes, a.gateway_id,
+			COALESCE(g.last_seen > NOW() - $1 * INTERVAL '1 millisecond', FALSE)
+		FROM agents a
+		LEFT JOIN gateway_instances g ON g.instance_id = a.gateway_id
+		ORDER BY a.agent_id`, ttl.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agents []SharedAgent
+	for rows.Next() {
+		info := &pb.AgentInfo{}
+		var hostname, version, uptime, ip, podIP, agentVersion sql.NullString
+		var instancesCount sql.NullInt32
+		var kubernetes []byte
+		var a SharedAgent
+		if err := rows.Scan(&info.AgentId, &hostname, &version, &instancesCount, &uptime, &ip, &info.Status, &info.LastSeen,
+			&info.IsPod, &podIP, &agentVersion, &info.PskAuthenticated, &kubernetes, &info.GatewayId, &a.OwnerLive); err != nil {
+			return nil, err
+		}
+		info.Hostname, info.Version, info.Uptime, info.Ip = hostname.String, version.String, uptime.String, ip.String
+		info.PodIp, info.AgentVersion, info.InstancesCount = podIP.String, agentVersion.String, instancesCount.Int32
+		info.Kubernetes = decodeKubernetesMetadata(kubernetes)
+		a.Info = info
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
+
+// AgentGateway returns the gateway owning an agent and its address; live is false when the agent
+// is unknown or its gateway stopped renewing its leases within ttl
+func (db *DB) AgentGateway(agentID string, ttl time.Duration) (instanceID, address string, live bool, err error) {
+	err = db.conn.QueryRow(`
+		SELECT a.gateway_id, COALESCE(g.address, ''), COALESCE(g.last_seen > NOW() - $2 * INTERVAL '1 millisecond', FALSE)
+		FROM agents a
+		LEFT JOIN gateway_instances g ON g.instance_id = a.gateway_id
+		WHERE a.agent_id = $1`, agentID, ttl.Milliseconds()).Scan(&instanceID, &address, &live)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	}
+	return instanceID, address, live, err
+}
+
+// FindAgentSession loads the session of an agent by its ID or normalized ID (see
+// normalizeAgentID); nil when there is no such agent
+func (db *DB) FindAgentSession(agentID string) (*AgentSession, error) {
+	session, err := scanAgentSession(db.conn.QueryRow(`SELECT `+agentSessionColumns+` FROM agents
+		WHERE agent_id = $1 OR replace(replace(agent_id, '+', '-'), '.', '-') = $2
+		ORDER BY agent_id = $1 DESC
+		LIMIT 1`, agentID, normalizeAgentID(agentID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
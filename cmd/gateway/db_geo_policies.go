@@ -0,0 +1,163 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// GeoPolicy blocks clients by country on the selected agents. The gateway compiles its countries
+// into a geo block of networks from its GeoIP database; server blocks enforce it with
+// `include avika/geo/<slug>.conf;`.
+type GeoPolicy struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	Mode        string           `json:"mode"` // allow, deny
+	Countries   []string         `json:"countries"`
+	StatusCode  int              `json:"status_code"`
+	Variable    string           `json:"variable"`
+	HTTPPath    string           `json:"http_path"`
+	IncludePath string           `json:"include_path"`
+	Selector    BulkSelector     `json:"selector"`
+	CreatedBy   string           `json:"created_by,omitempty"`
+	UpdatedBy   string           `json:"updated_by,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	Agents      []GeoPolicyAgent `json:"agents,omitempty"`
+}
+
+// GeoPolicyAgent is the outcome of the last write of a policy to an agent
+type GeoPolicyAgent struct {
+	AgentID   string    `json:"agent_id"`
+	Status    string    `json:"status"` // applied, failed
+	Error     string    `json:"error,omitempty"`
+	Networks  int       `json:"networks"` // networks in the geo block the agent runs with
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+const geoPolicyColumns = `id, name, slug, mode, countries, status_code, selector, COALESCE(created_by, ''),
+	COALESCE(updated_by, ''), created_at, updated_at`
+
+func scanGeoPolicy(row interface{ Scan(...interface{}) error }) (*GeoPolicy, error) {
+	var p GeoPolicy
+	var countries, selector []byte
+	if err := row.Scan(&p.ID, &p.Name, &p.Slug, &p.Mode, &countries, &p.StatusCode, &selector, &p.CreatedBy,
+		&p.UpdatedBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(countries, &p.Countries)
+	json.Unmarshal(selector, &p.Selector)
+	p.setPaths()
+	return &p, nil
+}
+
+// CreateGeoPolicy records a geo policy
+func (db *DB) CreateGeoPolicy(p *GeoPolicy) error {
+	countries, err := json.Marshal(p.Countries)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(p.Selector)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO geo_policies (id, name, slug, mode, countries, status_code, selector, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING created_at, updated_at`,
+		p.ID, p.Name, p.Slug, p.Mode, countries, p.StatusCode, selector, nullIfEmpty(p.CreatedBy)).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+// UpdateGeoPolicy saves the name, mode, countries, status code and selector of a policy
+func (db *DB) UpdateGeoPolicy(p *GeoPolicy) error {
+	countries, err := json.Marshal(p.Countries)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(p.Selector)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE geo_policies
+		SET name = $2, mode = $3, countries = $4, status_code = $5, selector = $6, updated_by = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		p.ID, p.Name, p.Mode, countries, p.StatusCode, selector, nullIfEmpty(p.UpdatedBy)).Scan(&p.UpdatedAt)
+}
+
+// GetGeoPolicy returns a policy with the outcome on each agent; nil when it does not exist
+func (db *DB) GetGeoPolicy(id string) (*GeoPolicy, error) {
+	p, err := scanGeoPolicy(db.conn.QueryRow(`SELECT `+geoPolicyColumns+` FROM geo_policies WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT agent_id, status, COALESCE(error, ''), networks, applied_at
+		FROM geo_policy_agents WHERE policy_id = $1
+		ORDER BY agent_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	p.Agents = []GeoPolicyAgent{}
+	for rows.Next() {
+		var a GeoPolicyAgent
+		if err := rows.Scan(&a.AgentID, &a.Status, &a.Error, &a.Networks, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		p.Agents = append(p.Agents, a)
+	}
+	return p, rows.Err()
+}
+
+// ListGeoPolicies returns every policy by name, without the outcome per agent
+func (db *DB) ListGeoPolicies() ([]GeoPolicy, error) {
+	rows, err := db.conn.Query(`SELECT ` + geoPolicyColumns + ` FROM geo_policies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []GeoPolicy{}
+	for rows.Next() {
+		p, err := scanGeoPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+// GeoPolicySlugExists reports whether a policy uses slug
+func (db *DB) GeoPolicySlugExists(slug string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM geo_policies WHERE slug = $1)`, slug).Scan(&exists)
+	return exists, err
+}
+
+// DeleteGeoPolicy deletes a policy with the outcome on each agent
+func (db *DB) DeleteGeoPolicy(id string) error {
+	_, err := db.conn.Exec(`DELETE FROM geo_policies WHERE id = $1`, id)
+	return err
+}
+
+// SaveGeoPolicyAgent records the outcome of writing a policy to an agent. A failed write keeps the
+// network count last applied, since the agent restored its previous file.
+func (db *DB) SaveGeoPolicyAgent(policyID string, a *GeoPolicyAgent) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO geo_policy_agents (policy_id, agent_id, status, error, networks, applied_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (policy_id, agent_id) DO UPDATE SET
+			status = EXCLUDED.status, error = EXCLUDED.error, applied_at = EXCLUDED.applied_at,
+			networks = CASE WHEN EXCLUDED.status = 'applied' THEN EXCLUDED.networks ELSE geo_policy_agents.networks END`,
+		policyID, a.AgentID, a.Status, nullIfEmpty(a.Error), a.Networks)
+	return err
+}
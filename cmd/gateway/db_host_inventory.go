@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentSoftware is what an agent last reported about the software it runs: the nginx version,
+// the nginx builds and the host inventory
+type agentSoftware struct {
+	AgentID      string
+	Hostname     string
+	Status       string
+	NginxVersion string
+	Builds       []*pb.NginxBuild
+	Host         *pb.HostInventory
+}
+
+// SaveAgentHostInventory stores the host inventory an agent reported
+func (db *DB) SaveAgentHostInventory(agentID string, inv *pb.HostInventory) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`UPDATE agents SET host_inventory = $2 WHERE agent_id = $1`, agentID, data)
+	return err
+}
+
+// ListAgentSoftware returns the software versions of every agent
+func (db *DB) ListAgentSoftware() ([]agentSoftware, error) {
+	rows, err := db.conn.Query(`
+		SELECT agent_id, COALESCE(hostname, ''), COALESCE(status, ''), COALESCE(version, ''), nginx_builds, host_inventory
+		FROM agents ORDER BY agent_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agents []agentSoftware
+	for rows.Next() {
+		var a agentSoftware
+		var builds, host []byte
+		if err := rows.Scan(&a.AgentID, &a.Hostname, &a.Status, &a.NginxVersion, &builds, &host); err != nil {
+			return nil, err
+		}
+		if builds != nil {
+			_ = json.Unmarshal(builds, &a.Builds)
+		}
+		if host != nil {
+			a.Host = &pb.HostInventory{}
+			if err := json.Unmarshal(host, a.Host); err != nil {
+				a.Host = nil
+			}
+		}
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
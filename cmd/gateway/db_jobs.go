@@ -0,0 +1,175 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Job is a long-running operation run in the background by one gateway
+type Job struct {
+	ID          string       `json:"id"`
+	Kind        string       `json:"kind"`
+	Status      string       `json:"status"`
+	Step        string       `json:"step,omitempty"`
+	Progress    JobProgress  `json:"progress"`
+	Error       string       `json:"error,omitempty"`
+	Artifact    *JobArtifact `json:"artifact,omitempty"`
+	GatewayID   string       `json:"gateway_id,omitempty"`
+	CreatedBy   string       `json:"created_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	Logs        []JobLog     `json:"logs,omitempty"`
+}
+
+// JobProgress is the work done by a job out of its total; Total is 0 when unknown
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// JobArtifact describes the file a job produced, downloaded from /api/jobs/{id}/artifact
+type JobArtifact struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// JobLog is a line of a job's log
+type JobLog struct {
+	Level    string    `json:"level"`
+	Message  string    `json:"message"`
+	LoggedAt time.Time `json:"logged_at"`
+}
+
+const jobColumns = `id, kind, status, COALESCE(step, ''), progress_done, progress_total, COALESCE(error, ''),
+	COALESCE(artifact_name, ''), COALESCE(artifact_type, ''), COALESCE(octet_length(artifact), 0),
+	COALESCE(gateway_id, ''), COALESCE(created_by, ''), created_at, completed_at`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	var j Job
+	var artifact JobArtifact
+	var completedAt sql.NullTime
+	if err := row.Scan(&j.ID, &j.Kind, &j.Status, &j.Step, &j.Progress.Done, &j.Progress.Total, &j.Error,
+		&artifact.Name, &artifact.ContentType, &artifact.Size, &j.GatewayID, &j.CreatedBy, &j.CreatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	if artifact.Name != "" {
+		j.Artifact = &artifact
+	}
+	j.CompletedAt = nullTimePtr(completedAt)
+	return &j, nil
+}
+
+// CreateJob records a running job
+func (db *DB) CreateJob(j *Job) error {
+	return db.conn.QueryRow(`
+		INSERT INTO jobs (id, kind, status, step, progress_total, gateway_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`,
+		j.ID, j.Kind, j.Status, nullIfEmpty(j.Step), j.Progress.Total, nullIfEmpty(j.GatewayID),
+		nullIfEmpty(j.CreatedBy)).Scan(&j.CreatedAt)
+}
+
+// UpdateJob saves the status, step and progress of a job
+func (db *DB) UpdateJob(j *Job) error {
+	_, err := db.conn.Exec(`
+		UPDATE jobs SET status = $2, step = $3, progress_done = $4, progress_total = $5, error = $6, completed_at = $7
+		WHERE id = $1`,
+		j.ID, j.Status, nullIfEmpty(j.Step), j.Progress.Done, j.Progress.Total, nullIfEmpty(j.Error), j.CompletedAt)
+	return err
+}
+
+// SaveJobArtifact stores the file a job produced
+func (db *DB) SaveJobArtifact(id, name, contentType string, data []byte) error {
+	_, err := db.conn.Exec(`UPDATE jobs SET artifact = $2, artifact_name = $3, artifact_type = $4 WHERE id = $1`,
+		id, data, name, contentType)
+	return err
+}
+
+// GetJobArtifact returns the file a job produced; nil data when it has none
+func (db *DB) GetJobArtifact(id string) (name, contentType string, data []byte, err error) {
+	err = db.conn.QueryRow(`SELECT COALESCE(artifact_name, ''), COALESCE(artifact_type, ''), artifact FROM jobs WHERE id = $1`, id).
+		Scan(&name, &contentType, &data)
+	if err == sql.ErrNoRows {
+		return "", "", nil, nil
+	}
+	return name, contentType, data, err
+}
+
+// AppendJobLog adds a line to a job's log
+func (db *DB) AppendJobLog(id string, l JobLog) error {
+	_, err := db.conn.Exec(`INSERT INTO job_logs (job_id, level, message, logged_at) VALUES ($1, $2, $3, $4)`,
+		id, l.Level, l.Message, l.LoggedAt)
+	return err
+}
+
+// GetJob returns a job with the last logLimit lines of its log; nil when it does not exist
+func (db *DB) GetJob(id string, logLimit int) (*Job, error) {
+	j, err := scanJob(db.conn.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT level, message, logged_at FROM (
+			SELECT id, level, message, logged_at FROM job_logs WHERE job_id = $1 ORDER BY id DESC LIMIT $2
+		) l ORDER BY id`, id, logLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	j.Logs = []JobLog{}
+	for rows.Next() {
+		var l JobLog
+		if err := rows.Scan(&l.Level, &l.Message, &l.LoggedAt); err != nil {
+			return nil, err
+		}
+		j.Logs = append(j.Logs, l)
+	}
+	return j, rows.Err()
+}
+
+// ListJobs returns the latest jobs matching the filters, without their logs. Empty filters match
+// every job.
+func (db *DB) ListJobs(createdBy, kind, status string, limit int) ([]Job, error) {
+	rows, err := db.conn.Query(`SELECT `+jobColumns+` FROM jobs
+		WHERE ($1 = '' OR created_by = $1) AND ($2 = '' OR kind = $2) AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC LIMIT $4`, createdBy, kind, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// InterruptJobs marks the jobs a gateway was running when it stopped
+func (db *DB) InterruptJobs(gatewayID string) (int64, error) {
+	res, err := db.conn.Exec(`
+		UPDATE jobs SET status = 'interrupted', error = 'gateway restarted during the job', completed_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND COALESCE(gateway_id, '') = $1`, gatewayID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneJobs deletes the jobs completed before cutoff with their logs and artifacts
+func (db *DB) PruneJobs(cutoff time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM jobs WHERE completed_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// LogArchive is one day of access logs archived to object storage
+type LogArchive struct {
+	Day        string     `json:"day"` // YYYY-MM-DD, UTC
+	ObjectURL  string     `json:"object_url"`
+	RowCount   int64      `json:"row_count"`
+	ArchivedAt time.Time  `json:"archived_at"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+const logArchiveColumns = `to_char(day, 'YYYY-MM-DD'), object_url, row_count, archived_at, restored_at, deleted_at`
+
+func scanLogArchive(row interface{ Scan(...interface{}) error }) (LogArchive, error) {
+	var a LogArchive
+	var restoredAt, deletedAt sql.NullTime
+	if err := row.Scan(&a.Day, &a.ObjectURL, &a.RowCount, &a.ArchivedAt, &restoredAt, &deletedAt); err != nil {
+		return a, err
+	}
+	if restoredAt.Valid {
+		a.RestoredAt = &restoredAt.Time
+	}
+	if deletedAt.Valid {
+		a.DeletedAt = &deletedAt.Time
+	}
+	return a, nil
+}
+
+// ListLogArchives returns the archived days, newest first
+func (db *DB) ListLogArchives() ([]LogArchive, error) {
+	rows, err := db.conn.Query(`SELECT ` + logArchiveColumns + ` FROM log_archives ORDER BY day DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	archives := []LogArchive{}
+	for rows.Next() {
+		a, err := scanLogArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+// GetLogArchive returns the archive of a day, nil when the day isn't archived
+func (db *DB) GetLogArchive(day string) (*LogArchive, error) {
+	a, err := scanLogArchive(db.conn.QueryRow(`SELECT `+logArchiveColumns+` FROM log_archives WHERE day = $1`, day))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// UpsertLogArchive records a day as archived, replacing an earlier archive of it
+func (db *DB) UpsertLogArchive(day, objectURL string, rowCount int64) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO log_archives (day, object_url, row_count, archived_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (day) DO UPDATE SET
+			object_url = EXCLUDED.object_url,
+			row_count = EXCLUDED.row_count,
+			archived_at = CURRENT_TIMESTAMP,
+			deleted_at = NULL`,
+		day, objectURL, rowCount)
+	return err
+}
+
+// SetLogArchiveRestored marks a day as loaded into, or released from, access_logs_restored
+func (db *DB) SetLogArchiveRestored(day string, restored bool) error {
+	var err error
+	if restored {
+		_, err = db.conn.Exec(`UPDATE log_archives SET restored_at = CURRENT_TIMESTAMP WHERE day = $1`, day)
+	} else {
+		_, err = db.conn.Exec(`UPDATE log_archives SET restored_at = NULL WHERE day = $1`, day)
+	}
+	return err
+}
+
+// MarkLogArchiveDeleted records that retention removed a day's object
+func (db *DB) MarkLogArchiveDeleted(day string) error {
+	_, err := db.conn.Exec(`UPDATE log_archives SET deleted_at = CURRENT_TIMESTAMP WHERE day = $1`, day)
+	return err
+}
+
+// ListExpiredLogArchives returns the days archived before cutoff whose object still exists
+func (db *DB) ListExpiredLogArchives(cutoff string) ([]LogArchive, error) {
+	rows, err := db.conn.Query(`SELECT `+logArchiveColumns+` FROM log_archives WHERE day < $1 AND deleted_at IS NULL ORDER BY day`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var archives []LogArchive
+	for rows.Next() {
+		a, err := scanLogArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
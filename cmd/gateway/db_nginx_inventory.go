@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// agentNginxBuilds is the nginx builds an agent last reported
+type agentNginxBuilds struct {
+	AgentID  string
+	Hostname string
+	Status   string
+	Builds   []*pb.NginxBuild
+}
+
+// SaveAgentNginxBuilds stores the nginx builds an agent reported
+func (db *DB) SaveAgentNginxBuilds(agentID string, builds []*pb.NginxBuild) error {
+	data, err := json.Marshal(builds)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`UPDATE agents SET nginx_builds = $2 WHERE agent_id = $1`, agentID, data)
+	return err
+}
+
+// ListAgentNginxBuilds returns the agents that reported their nginx builds
+func (db *DB) ListAgentNginxBuilds() ([]agentNginxBuilds, error) {
+	rows, err := db.conn.Query(`
+		SELECT agent_id, COALESCE(hostname, ''), COALESCE(status, ''), nginx_builds
+		FROM agents WHERE nginx_builds IS NOT NULL ORDER BY agent_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agents []agentNginxBuilds
+	for rows.Next() {
+		var a agentNginxBuilds
+		var data []byte
+		if err := rows.Scan(&a.AgentID, &a.Hostname, &a.Status, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &a.Builds); err != nil {
+			continue
+		}
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ProjectQuota caps the access log bytes a project stores in ClickHouse per UTC day. Above the
+// soft limit its admins are warned; above the hard limit entries are sampled or dropped.
+type ProjectQuota struct {
+	ProjectID       string    `json:"project_id"`
+	SoftBytesPerDay int64     `json:"soft_bytes_per_day"` // 0 for no soft limit
+	HardBytesPerDay int64     `json:"hard_bytes_per_day"` // 0 for no hard limit
+	HardAction      string    `json:"hard_action"`        // sample, drop
+	SamplePercent   float64   `json:"sample_percent"`     // share of entries stored above the hard limit
+	UpdatedBy       string    `json:"updated_by,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+// ProjectUsageDay is what a project ingested on a UTC day
+type ProjectUsageDay struct {
+	Day            string     `json:"day"` // YYYY-MM-DD
+	StoredBytes    int64      `json:"stored_bytes"`
+	StoredEntries  int64      `json:"stored_entries"`
+	DroppedBytes   int64      `json:"dropped_bytes"`
+	DroppedEntries int64      `json:"dropped_entries"`
+	SoftNotifiedAt *time.Time `json:"soft_notified_at,omitempty"`
+	HardNotifiedAt *time.Time `json:"hard_notified_at,omitempty"`
+}
+
+// projectUsageDelta is usage counted by one gateway since its last flush
+type projectUsageDelta struct {
+	storedBytes, storedEntries, droppedBytes, droppedEntries int64
+}
+
+const projectQuotaColumns = `project_id, soft_bytes_per_day, hard_bytes_per_day, hard_action, sample_percent,
+	COALESCE(updated_by, ''), COALESCE(updated_at, CURRENT_TIMESTAMP)`
+
+func scanProjectQuota(row interface{ Scan(...interface{}) error }) (*ProjectQuota, error) {
+	var q ProjectQuota
+	if err := row.Scan(&q.ProjectID, &q.SoftBytesPerDay, &q.HardBytesPerDay, &q.HardAction, &q.SamplePercent,
+		&q.UpdatedBy, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// GetProjectQuota returns the quota of a project, nil when it has none
+func (db *DB) GetProjectQuota(projectID string) (*ProjectQuota, error) {
+	q, err := scanProjectQuota(db.conn.QueryRow(`SELECT `+projectQuotaColumns+` FROM project_quotas WHERE project_id = $1`, projectID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// ListProjectQuotas returns the quota of every project that has one, by project ID
+func (db *DB) ListProjectQuotas() (map[string]ProjectQuota, error) {
+	rows, err := db.conn.Query(`SELECT ` + projectQuotaColumns + ` FROM project_quotas`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	quotas := map[string]ProjectQuota{}
+	for rows.Next() {
+		q, err := scanProjectQuota(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotas[q.ProjectID] = *q
+	}
+	return quotas, rows.Err()
+}
+
+// SaveProjectQuota creates or replaces the quota of a project
+func (db *DB) SaveProjectQuota(q *ProjectQuota) error {
+	return db.conn.QueryRow(`
+		INSERT INTO project_quotas (project_id, soft_bytes_per_day, hard_bytes_per_day, hard_action, sample_percent, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (project_id) DO UPDATE SET
+			soft_bytes_per_day = EXCLUDED.soft_bytes_per_day, hard_bytes_per_day = EXCLUDED.hard_bytes_per_day,
+			hard_action = EXCLUDED.hard_action, sample_percent = EXCLUDED.sample_percent,
+			updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		q.ProjectID, q.SoftBytesPerDay, q.HardBytesPerDay, q.HardAction, q.SamplePercent, nullIfEmpty(q.UpdatedBy)).Scan(&q.UpdatedAt)
+}
+
+// DeleteProjectQuota removes the quota of a project, which then ingests without limits
+func (db *DB) DeleteProjectQuota(projectID string) error {
+	_, err := db.conn.Exec(`DELETE FROM project_quotas WHERE project_id = $1`, projectID)
+	return err
+}
+
+// AddProjectIngestUsage adds the usage a gateway counted to a project's day and returns the bytes the
+// project stored that day over every gateway
+func (db *DB) AddProjectIngestUsage(projectID string, day time.Time, d projectUsageDelta) (int64, error) {
+	var stored int64
+	err := db.conn.QueryRow(`
+		INSERT INTO project_ingest_usage (project_id, day, stored_bytes, stored_entries, dropped_bytes, dropped_entries)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (project_id, day) DO UPDATE SET
+			stored_bytes = project_ingest_usage.stored_bytes + EXCLUDED.stored_bytes,
+			stored_entries = project_ingest_usage.stored_entries + EXCLUDED.stored_entries,
+			dropped_bytes = project_ingest_usage.dropped_bytes + EXCLUDED.dropped_bytes,
+			dropped_entries = project_ingest_usage.dropped_entries + EXCLUDED.dropped_entries
+		RETURNING stored_bytes`,
+		projectID, day, d.storedBytes, d.storedEntries, d.droppedBytes, d.droppedEntries).Scan(&stored)
+	return stored, err
+}
+
+// MarkProjectQuotaNotified records that the soft or hard limit notification of a project's day went
+// out. It reports false when another gateway sent it first.
+func (db *DB) MarkProjectQuotaNotified(projectID string, day time.Time, level string) (bool, error) {
+	column := "soft_notified_at"
+	if level == quotaLevelHard {
+		column = "hard_notified_at"
+	}
+	res, err := db.conn.Exec(`
+		INSERT INTO project_ingest_usage (project_id, day, `+column+`) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (project_id, day) DO UPDATE SET `+column+` = CURRENT_TIMESTAMP
+		WHERE project_ingest_usage.`+column+` IS NULL`, projectID, day)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListProjectIngestUsage returns the days a project ingested since a day, oldest first
+func (db *DB) ListProjectIngestUsage(projectID string, since time.Time) ([]ProjectUsageDay, error) {
+	rows, err := db.conn.Query(`
+		SELECT to_char(day, 'YYYY-MM-DD'), stored_bytes, stored_entries, dropped_bytes, dropped_entries,
+			soft_notified_at, hard_notified_at
+		FROM project_ingest_usage WHERE project_id = $1 AND day >= $2 ORDER BY day`, projectID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	days := []ProjectUsageDay{}
+	for rows.Next() {
+		var d ProjectUsageDay
+		var soft, hard sql.NullTime
+		if err := rows.Scan(&d.Day, &d.StoredBytes, &d.StoredEntries, &d.DroppedBytes, &d.DroppedEntries, &soft, &hard); err != nil {
+			return nil, err
+		}
+		if soft.Valid {
+			d.SoftNotifiedAt = &soft.Time
+		}
+		if hard.Valid {
+			d.HardNotifiedAt = &hard.Time
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// SumProjectIngestUsage returns what each project ingested since a day, by project ID
+func (db *DB) SumProjectIngestUsage(since time.Time) (map[string]ProjectUsageDay, error) {
+	rows, err := db.conn.Query(`
+		SELECT project_id, SUM(stored_bytes), SUM(stored_entries), SUM(dropped_bytes), SUM(dropped_entries)
+		FROM project_ingest_usage WHERE day >= $1 GROUP BY project_id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := map[string]ProjectUsageDay{}
+	for rows.Next() {
+		var id string
+		var d ProjectUsageDay
+		if err := rows.Scan(&id, &d.StoredBytes, &d.StoredEntries, &d.DroppedBytes, &d.DroppedEntries); err != nil {
+			return nil, err
+		}
+		usage[id] = d
+	}
+	return usage, rows.Err()
+}
+
+// PruneProjectIngestUsage deletes the usage of days before cutoff
+func (db *DB) PruneProjectIngestUsage(cutoff time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM project_ingest_usage WHERE day < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
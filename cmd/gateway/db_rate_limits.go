@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type RateLimitRule struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Zone       string    `json:"zone"`
+	KeyType    string    `json:"key_type"`
+	PathPrefix string    `json:"path_prefix,omitempty"`
+	Rate       int       `json:"rate"`
+	RateUnit   string    `json:"rate_unit"`
+	Burst      int       `json:"burst"`
+	NoDelay    bool      `json:"nodelay"`
+	ZoneSizeMB int       `json:"zone_size_mb"`
+	Enabled    bool      `json:"enabled"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type RateLimitDeployment struct {
+	AgentID    string    `json:"agent_id"`
+	ConfigHash string    `json:"config_hash"`
+	Zones      []string  `json:"zones"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	AppliedBy  string    `json:"applied_by"`
+	AppliedAt  time.Time `json:"applied_at"`
+}
+
+const rateLimitRuleColumns = `id, name, zone, key_type, COALESCE(path_prefix, ''), rate, rate_unit, COALESCE(burst, 0),
+	COALESCE(nodelay, false), COALESCE(zone_size_mb, 10), COALESCE(enabled, true), COALESCE(created_by, ''), created_at, updated_at`
+
+func scanRateLimitRule(row interface{ Scan(...interface{}) error }) (*RateLimitRule, error) {
+	var r RateLimitRule
+	if err := row.Scan(&r.ID, &r.Name, &r.Zone, &r.KeyType, &r.PathPrefix, &r.Rate, &r.RateUnit, &r.Burst,
+		&r.NoDelay, &r.ZoneSizeMB, &r.Enabled, &r.CreatedBy, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRateLimitRules returns all rules ordered by zone
+func (db *DB) ListRateLimitRules() ([]RateLimitRule, error) {
+	rows, err := db.conn.Query(`SELECT ` + rateLimitRuleColumns + ` FROM rate_limit_rules ORDER BY zone`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []RateLimitRule{}
+	for rows.Next() {
+		r, err := scanRateLimitRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// GetRateLimitRule returns nil when no rule has that ID
+func (db *DB) GetRateLimitRule(id string) (*RateLimitRule, error) {
+	r, err := scanRateLimitRule(db.conn.QueryRow(`SELECT `+rateLimitRuleColumns+` FROM rate_limit_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (db *DB) CreateRateLimitRule(r *RateLimitRule) error {
+	return db.conn.QueryRow(`
+		INSERT INTO rate_limit_rules (name, zone, key_type, path_prefix, rate, rate_unit, burst, nodelay, zone_size_mb, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at`,
+		r.Name, r.Zone, r.KeyType, nullIfEmpty(r.PathPrefix), r.Rate, r.RateUnit, r.Burst, r.NoDelay, r.ZoneSizeMB, r.Enabled, nullIfEmpty(r.CreatedBy)).
+		Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+}
+
+// UpdateRateLimitRule replaces the editable fields; it reports false when no rule had that ID
+func (db *DB) UpdateRateLimitRule(r *RateLimitRule) (bool, error) {
+	err := db.conn.QueryRow(`
+		UPDATE rate_limit_rules SET
+			name = $2, zone = $3, key_type = $4, path_prefix = $5, rate = $6, rate_unit = $7,
+			burst = $8, nodelay = $9, zone_size_mb = $10, enabled = $11, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at`,
+		r.ID, r.Name, r.Zone, r.KeyType, nullIfEmpty(r.PathPrefix), r.Rate, r.RateUnit, r.Burst, r.NoDelay, r.ZoneSizeMB, r.Enabled).
+		Scan(&r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (db *DB) DeleteRateLimitRule(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM rate_limit_rules WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordRateLimitDeployment stores the outcome of the latest push to an agent
+func (db *DB) RecordRateLimitDeployment(d *RateLimitDeployment) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO rate_limit_deployments (agent_id, config_hash, zones, status, error, applied_by, applied_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		ON CONFLICT (agent_id) DO UPDATE SET
+			config_hash = EXCLUDED.config_hash,
+			zones = EXCLUDED.zones,
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			applied_by = EXCLUDED.applied_by,
+			applied_at = EXCLUDED.applied_at`,
+		d.AgentID, d.ConfigHash, pq.Array(d.Zones), d.Status, nullIfEmpty(d.Error), nullIfEmpty(d.AppliedBy))
+	return err
+}
+
+func (db *DB) ListRateLimitDeployments() ([]RateLimitDeployment, error) {
+	rows, err := db.conn.Query(`
+		SELECT agent_id, config_hash, COALESCE(zones, '{}'), status, COALESCE(error, ''), COALESCE(applied_by, ''), applied_at
+		FROM rate_limit_deployments
+		ORDER BY agent_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deployments := []RateLimitDeployment{}
+	for rows.Next() {
+		var d RateLimitDeployment
+		if err := rows.Scan(&d.AgentID, &d.ConfigHash, pq.Array(&d.Zones), &d.Status, &d.Error, &d.AppliedBy, &d.AppliedAt); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, d)
+	}
+	return deployments, rows.Err()
+}
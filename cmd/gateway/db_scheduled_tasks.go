@@ -0,0 +1,254 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ScheduledTask is a recurring operation the gateway runs on a cron schedule against the agents
+// of an environment, or all agents when it has none
+type ScheduledTask struct {
+	ID               string              `json:"id"`
+	Name             string              `json:"name"`
+	Cron             string              `json:"cron"`
+	Timezone         string              `json:"timezone"`
+	Action           string              `json:"action"`
+	EnvironmentID    string              `json:"environment_id,omitempty"`
+	Params           ScheduledTaskParams `json:"params"`
+	NotifyRecipients string              `json:"notify_recipients,omitempty"`
+	Enabled          bool                `json:"enabled"`
+	NextRunAt        *time.Time          `json:"next_run_at,omitempty"`
+	LastRunAt        *time.Time          `json:"last_run_at,omitempty"`
+	LastStatus       string              `json:"last_status,omitempty"`
+	CreatedBy        string              `json:"created_by,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// ScheduledTaskParams are the options of the task action
+type ScheduledTaskParams struct {
+	NginxInstanceID  string   `json:"nginx_instance_id,omitempty"` // reload, restart, config_backup
+	ReportType       string   `json:"report_type,omitempty"`       // report: summary, detailed, security
+	ReportDays       int      `json:"report_days,omitempty"`       // report: period covered, default 7
+	ReportRecipients []string `json:"report_recipients,omitempty"` // report: email addresses
+}
+
+// ScheduledTaskRun is one execution of a task
+type ScheduledTaskRun struct {
+	ID          string                `json:"id"`
+	TaskID      string                `json:"task_id"`
+	Trigger     string                `json:"trigger"`
+	TriggeredBy string                `json:"triggered_by,omitempty"`
+	Status      string                `json:"status"`
+	Targets     int                   `json:"targets"`
+	Failed      int                   `json:"failed"`
+	Error       string                `json:"error,omitempty"`
+	Results     []ScheduledTaskResult `json:"results"`
+	GatewayID   string                `json:"gateway_id,omitempty"`
+	StartedAt   time.Time             `json:"started_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// ScheduledTaskResult is the outcome of a run for one agent
+type ScheduledTaskResult struct {
+	AgentID string `json:"agent_id"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+const scheduledTaskColumns = `id, name, cron, timezone, action, COALESCE(environment_id::text, ''), COALESCE(params, '{}'),
+	COALESCE(notify_recipients, ''), enabled, next_run_at, last_run_at, COALESCE(last_status, ''), COALESCE(created_by, ''),
+	created_at, updated_at`
+
+func scanScheduledTask(row interface{ Scan(...interface{}) error }) (*ScheduledTask, error) {
+	var t ScheduledTask
+	var params []byte
+	var nextRunAt, lastRunAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.Name, &t.Cron, &t.Timezone, &t.Action, &t.EnvironmentID, &params, &t.NotifyRecipients,
+		&t.Enabled, &nextRunAt, &lastRunAt, &t.LastStatus, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &t.Params); err != nil {
+		return nil, err
+	}
+	t.NextRunAt, t.LastRunAt = nullTimePtr(nextRunAt), nullTimePtr(lastRunAt)
+	return &t, nil
+}
+
+func (db *DB) queryScheduledTasks(where string, args ...interface{}) ([]ScheduledTask, error) {
+	rows, err := db.conn.Query(`SELECT `+scheduledTaskColumns+` FROM scheduled_tasks `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []ScheduledTask{}
+	for rows.Next() {
+		t, err := scanScheduledTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// ListScheduledTasks returns all tasks ordered by name
+func (db *DB) ListScheduledTasks() ([]ScheduledTask, error) {
+	return db.queryScheduledTasks(`ORDER BY name`)
+}
+
+// ListDueScheduledTasks returns the enabled tasks whose next run is at or before now
+func (db *DB) ListDueScheduledTasks(now time.Time) ([]ScheduledTask, error) {
+	return db.queryScheduledTasks(`WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at`, now)
+}
+
+// GetScheduledTask returns nil when no task has that ID
+func (db *DB) GetScheduledTask(id string) (*ScheduledTask, error) {
+	t, err := scanScheduledTask(db.conn.QueryRow(`SELECT `+scheduledTaskColumns+` FROM scheduled_tasks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+func (db *DB) CreateScheduledTask(t *ScheduledTask) error {
+	params, err := json.Marshal(t.Params)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO scheduled_tasks (id, name, cron, timezone, action, environment_id, params, notify_recipients, enabled,
+			next_run_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at`,
+		t.ID, t.Name, t.Cron, t.Timezone, t.Action, nullIfEmpty(t.EnvironmentID), params, nullIfEmpty(t.NotifyRecipients),
+		t.Enabled, t.NextRunAt, nullIfEmpty(t.CreatedBy)).Scan(&t.CreatedAt, &t.UpdatedAt)
+}
+
+func (db *DB) UpdateScheduledTask(t *ScheduledTask) error {
+	params, err := json.Marshal(t.Params)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE scheduled_tasks SET
+			name = $2, cron = $3, timezone = $4, action = $5, environment_id = $6, params = $7, notify_recipients = $8,
+			enabled = $9, next_run_at = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		t.ID, t.Name, t.Cron, t.Timezone, t.Action, nullIfEmpty(t.EnvironmentID), params, nullIfEmpty(t.NotifyRecipients),
+		t.Enabled, t.NextRunAt).Scan(&t.UpdatedAt)
+}
+
+func (db *DB) DeleteScheduledTask(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM scheduled_tasks WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimScheduledTask moves a due task to its next run. It fails when the task changed or another
+// gateway claimed it since it was listed, so a run is started once.
+func (db *DB) ClaimScheduledTask(id string, due time.Time, next *time.Time) (bool, error) {
+	res, err := db.conn.Exec(`UPDATE scheduled_tasks SET next_run_at = $3 WHERE id = $1 AND next_run_at = $2 AND enabled`,
+		id, due, next)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// CreateScheduledTaskRun records a run that started and marks it as the task's last run
+func (db *DB) CreateScheduledTaskRun(run *ScheduledTaskRun) error {
+	if err := db.conn.QueryRow(`
+		INSERT INTO scheduled_task_runs (id, task_id, trigger, triggered_by, status, gateway_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING started_at`,
+		run.ID, run.TaskID, run.Trigger, nullIfEmpty(run.TriggeredBy), run.Status, nullIfEmpty(run.GatewayID)).Scan(&run.StartedAt); err != nil {
+		return err
+	}
+	_, err := db.conn.Exec(`UPDATE scheduled_tasks SET last_run_at = $2, last_status = $3 WHERE id = $1`,
+		run.TaskID, run.StartedAt, run.Status)
+	return err
+}
+
+// FinishScheduledTaskRun saves the outcome of a run and the task's last status
+func (db *DB) FinishScheduledTaskRun(run *ScheduledTaskRun) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return err
+	}
+	if _, err := db.conn.Exec(`
+		UPDATE scheduled_task_runs
+		SET status = $2, targets = $3, failed = $4, error = $5, results = $6, completed_at = $7
+		WHERE id = $1`,
+		run.ID, run.Status, run.Targets, run.Failed, nullIfEmpty(run.Error), results, run.CompletedAt); err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`UPDATE scheduled_tasks SET last_status = $2 WHERE id = $1 AND last_run_at = $3`,
+		run.TaskID, run.Status, run.StartedAt)
+	return err
+}
+
+// ListScheduledTaskRuns returns the latest runs of a task, newest first
+func (db *DB) ListScheduledTaskRuns(taskID string, limit int) ([]ScheduledTaskRun, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, task_id, trigger, COALESCE(triggered_by, ''), status, targets, failed, COALESCE(error, ''),
+			COALESCE(results, '[]'), COALESCE(gateway_id, ''), started_at, completed_at
+		FROM scheduled_task_runs WHERE task_id = $1
+		ORDER BY started_at DESC LIMIT $2`, taskID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []ScheduledTaskRun{}
+	for rows.Next() {
+		var run ScheduledTaskRun
+		var results []byte
+		var completedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.TaskID, &run.Trigger, &run.TriggeredBy, &run.Status, &run.Targets, &run.Failed,
+			&run.Error, &results, &run.GatewayID, &run.StartedAt, &completedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(results, &run.Results); err != nil {
+			return nil, err
+		}
+		run.CompletedAt = nullTimePtr(completedAt)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// InterruptScheduledTaskRuns closes the runs a gateway had in progress when it stopped
+func (db *DB) InterruptScheduledTaskRuns(gatewayID string) (int64, error) {
+	res, err := db.conn.Exec(`
+		UPDATE scheduled_task_runs
+		SET status = 'interrupted', error = 'gateway restarted during the run', completed_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND COALESCE(gateway_id, '') = $1`, gatewayID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.conn.Exec(`
+		UPDATE scheduled_tasks t SET last_status = 'interrupted'
+		FROM scheduled_task_runs r
+		WHERE r.task_id = t.id AND r.started_at = t.last_run_at AND r.status = 'interrupted'
+			AND t.last_status = 'running' AND COALESCE(r.gateway_id, '') = $1`, gatewayID); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneScheduledTaskRuns deletes the runs that started before cutoff
+func (db *DB) PruneScheduledTaskRuns(cutoff time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM scheduled_task_runs WHERE started_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
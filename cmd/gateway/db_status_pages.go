@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StatusPageComponent is one row of a status page, healthy while all of its checks are up
+type StatusPageComponent struct {
+	Name     string   `json:"name"`
+	CheckIDs []string `json:"check_ids"`
+}
+
+type StatusPage struct {
+	ID           string                `json:"id"`
+	ProjectID    string                `json:"project_id"`
+	Slug         string                `json:"slug"`
+	Title        string                `json:"title"`
+	Description  string                `json:"description,omitempty"`
+	Public       bool                  `json:"public"`
+	Components   []StatusPageComponent `json:"components"`
+	SLOTargetIDs []string              `json:"slo_target_ids"`
+	CreatedBy    string                `json:"created_by"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+
+	tokenHash string
+}
+
+const statusPageColumns = `id, project_id, slug, title, COALESCE(description, ''), COALESCE(public, false), token_hash,
+	COALESCE(components, '[]'), COALESCE(slo_target_ids, '{}'), COALESCE(created_by, ''), created_at, updated_at`
+
+func scanStatusPage(row interface{ Scan(...interface{}) error }) (*StatusPage, error) {
+	var p StatusPage
+	var components []byte
+	if err := row.Scan(&p.ID, &p.ProjectID, &p.Slug, &p.Title, &p.Description, &p.Public, &p.tokenHash,
+		&components, pq.Array(&p.SLOTargetIDs), &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(components, &p.Components); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// newStatusPageToken returns a random access token and its hash
+func newStatusPageToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	return token, sha256Hex(token), nil
+}
+
+// ListStatusPages returns all status pages ordered by title
+func (db *DB) ListStatusPages() ([]StatusPage, error) {
+	rows, err := db.conn.Query(`SELECT ` + statusPageColumns + ` FROM status_pages ORDER BY title`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []StatusPage{}
+	for rows.Next() {
+		p, err := scanStatusPage(rows)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, *p)
+	}
+	return pages, rows.Err()
+}
+
+// GetStatusPage returns nil when no page has that ID
+func (db *DB) GetStatusPage(id string) (*StatusPage, error) {
+	p, err := scanStatusPage(db.conn.QueryRow(`SELECT `+statusPageColumns+` FROM status_pages WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetStatusPageBySlug returns nil when no page has that slug
+func (db *DB) GetStatusPageBySlug(slug string) (*StatusPage, error) {
+	p, err := scanStatusPage(db.conn.QueryRow(`SELECT `+statusPageColumns+` FROM status_pages WHERE slug = $1`, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// CreateStatusPage stores the page and returns its access token; only the hash is kept
+func (db *DB) CreateStatusPage(p *StatusPage) (string, error) {
+	components, err := json.Marshal(p.Components)
+	if err != nil {
+		return "", err
+	}
+	token, hash, err := newStatusPageToken()
+	if err != nil {
+		return "", err
+	}
+	err = db.conn.QueryRow(`
+		INSERT INTO status_pages (project_id, slug, title, description, public, token_hash, components, slo_target_ids, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`,
+		p.ProjectID, p.Slug, p.Title, nullIfEmpty(p.Description), p.Public, hash, components,
+		pq.Array(p.SLOTargetIDs), nullIfEmpty(p.CreatedBy)).
+		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return "", err
+	}
+	p.tokenHash = hash
+	return token, nil
+}
+
+func (db *DB) UpdateStatusPage(p *StatusPage) error {
+	components, err := json.Marshal(p.Components)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE status_pages SET
+			slug = $2, title = $3, description = $4, public = $5, components = $6, slo_target_ids = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		p.ID, p.Slug, p.Title, nullIfEmpty(p.Description), p.Public, components, pq.Array(p.SLOTargetIDs)).
+		Scan(&p.UpdatedAt)
+}
+
+// RotateStatusPageToken replaces the access token, invalidating shared links
+func (db *DB) RotateStatusPageToken(id string) (string, error) {
+	token, hash, err := newStatusPageToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := db.conn.Exec(`UPDATE status_pages SET token_hash = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, hash); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (db *DB) DeleteStatusPage(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM status_pages WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type SyntheticCheck struct {
+	ID               string                 `json:"id"`
+	Name             string                 `json:"name"`
+	CheckType        string                 `json:"check_type"`
+	Target           string                 `json:"target"`
+	Settings         SyntheticCheckSettings `json:"settings"`
+	IntervalSec      int                    `json:"interval_sec"`
+	TimeoutMs        int                    `json:"timeout_ms"`
+	MaxLatencyMs     int                    `json:"max_latency_ms"`
+	FailureThreshold int                    `json:"failure_threshold"`
+	Regions          []string               `json:"regions"`
+	ProjectID        string                 `json:"project_id,omitempty"`
+	AgentID          string                 `json:"agent_id,omitempty"`
+	Enabled          bool                   `json:"enabled"`
+	CreatedBy        string                 `json:"created_by"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+const syntheticCheckColumns = `id, name, check_type, target, COALESCE(settings, '{}'), COALESCE(interval_sec, 60),
+	COALESCE(timeout_ms, 10000), COALESCE(max_latency_ms, 0), COALESCE(failure_threshold, 2), COALESCE(regions, '{}'),
+	COALESCE(project_id::text, ''), COALESCE(agent_id, ''), COALESCE(enabled, true), COALESCE(created_by, ''), created_at, updated_at`
+
+func scanSyntheticCheck(row interface{ Scan(...interface{}) error }) (*SyntheticCheck, error) {
+	var c SyntheticCheck
+	var settings []byte
+	if err := row.Scan(&c.ID, &c.Name, &c.CheckType, &c.Target, &settings, &c.IntervalSec, &c.TimeoutMs, &c.MaxLatencyMs,
+		&c.FailureThreshold, pq.Array(&c.Regions), &c.ProjectID, &c.AgentID, &c.Enabled, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(settings, &c.Settings); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListSyntheticChecks returns all checks ordered by name
+func (db *DB) ListSyntheticChecks() ([]SyntheticCheck, error) {
+	rows, err := db.conn.Query(`SELECT ` + syntheticCheckColumns + ` FROM synthetic_checks ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []SyntheticCheck{}
+	for rows.Next() {
+		c, err := scanSyntheticCheck(rows)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, *c)
+	}
+	return checks, rows.Err()
+}
+
+// GetSyntheticCheck returns nil when no check has that ID
+func (db *DB) GetSyntheticCheck(id string) (*SyntheticCheck, error) {
+	c, err := scanSyntheticCheck(db.conn.QueryRow(`SELECT `+syntheticCheckColumns+` FROM synthetic_checks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (db *DB) CreateSyntheticCheck(c *SyntheticCheck) error {
+	settings, err := json.Marshal(c.Settings)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO synthetic_checks (name, check_type, target, settings, interval_sec, timeout_ms, max_latency_ms,
+			failure_threshold, regions, project_id, agent_id, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at`,
+		c.Name, c.CheckType, c.Target, settings, c.IntervalSec, c.TimeoutMs, c.MaxLatencyMs, c.FailureThreshold,
+		pq.Array(c.Regions), nullIfEmpty(c.ProjectID), nullIfEmpty(c.AgentID), c.Enabled, nullIfEmpty(c.CreatedBy)).
+		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (db *DB) UpdateSyntheticCheck(c *SyntheticCheck) error {
+	settings, err := json.Marshal(c.Settings)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE synthetic_checks SET
+			name = $2, check_type = $3, target = $4, settings = $5, interval_sec = $6, timeout_ms = $7,
+			max_latency_ms = $8, failure_threshold = $9, regions = $10, project_id = $11, agent_id = $12,
+			enabled = $13, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		c.ID, c.Name, c.CheckType, c.Target, settings, c.IntervalSec, c.TimeoutMs, c.MaxLatencyMs, c.FailureThreshold,
+		pq.Array(c.Regions), nullIfEmpty(c.ProjectID), nullIfEmpty(c.AgentID), c.Enabled).
+		Scan(&c.UpdatedAt)
+}
+
+func (db *DB) DeleteSyntheticCheck(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM synthetic_checks WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SyntheticIncident is a period during which a check was down in one region
+type SyntheticIncident struct {
+	ID         string     `json:"id"`
+	CheckID    string     `json:"check_id"`
+	Region     string     `json:"region"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// OpenSyntheticIncident records a check going down; an incident already open for the check and
+// region is kept
+func (db *DB) OpenSyntheticIncident(checkID, region, errMsg string, startedAt time.Time) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO synthetic_incidents (check_id, region, error, started_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (check_id, region) WHERE resolved_at IS NULL DO NOTHING`,
+		checkID, region, nullIfEmpty(errMsg), startedAt)
+	return err
+}
+
+// ResolveSyntheticIncident closes the open incident of a check in a region, if any
+func (db *DB) ResolveSyntheticIncident(checkID, region string, resolvedAt time.Time) error {
+	_, err := db.conn.Exec(`UPDATE synthetic_incidents SET resolved_at = $3
+		WHERE check_id = $1 AND region = $2 AND resolved_at IS NULL`, checkID, region, resolvedAt)
+	return err
+}
+
+// ListSyntheticIncidents returns incidents of the checks that were open at any point since, newest first
+func (db *DB) ListSyntheticIncidents(checkIDs []string, since time.Time, limit int) ([]SyntheticIncident, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, check_id, region, COALESCE(error, ''), started_at, resolved_at
+		FROM synthetic_incidents
+		WHERE check_id::text = ANY($1) AND (resolved_at IS NULL OR resolved_at >= $2)
+		ORDER BY started_at DESC
+		LIMIT $3`, pq.Array(checkIDs), since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := []SyntheticIncident{}
+	for rows.Next() {
+		var in SyntheticIncident
+		var resolved sql.NullTime
+		if err := rows.Scan(&in.ID, &in.CheckID, &in.Region, &in.Error, &in.StartedAt, &resolved); err != nil {
+			return nil, err
+		}
+		if resolved.Valid {
+			in.ResolvedAt = &resolved.Time
+		}
+		incidents = append(incidents, in)
+	}
+	return incidents, rows.Err()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// defaultRetentionScope is the scope of a table's fleet-wide retention
+const defaultRetentionScope = "default"
+
+// TableRetentionPolicy is the number of days a ClickHouse table keeps rows, fleet-wide or for the
+// agents of one environment
+type TableRetentionPolicy struct {
+	Table         string    `json:"table"`
+	EnvironmentID string    `json:"environment_id,omitempty"` // empty for the fleet-wide retention
+	Days          int       `json:"days"`
+	UpdatedBy     string    `json:"updated_by,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func retentionScope(environmentID string) string {
+	if environmentID == "" {
+		return defaultRetentionScope
+	}
+	return environmentID
+}
+
+// ListTableRetentionPolicies returns every stored policy, ordered by table
+func (db *DB) ListTableRetentionPolicies() ([]TableRetentionPolicy, error) {
+	rows, err := db.conn.Query(`
+		SELECT table_name, environment_id, days, updated_by, updated_at
+		FROM table_retention_policies ORDER BY table_name, scope`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var policies []TableRetentionPolicy
+	for rows.Next() {
+		var p TableRetentionPolicy
+		var envID, updatedBy sql.NullString
+		if err := rows.Scan(&p.Table, &envID, &p.Days, &updatedBy, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.EnvironmentID, p.UpdatedBy = envID.String, updatedBy.String
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// ReplaceTableRetentionPolicies replaces all policies of a table in one transaction
+func (db *DB) ReplaceTableRetentionPolicies(table string, policies []TableRetentionPolicy) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM table_retention_policies WHERE table_name = $1`, table); err != nil {
+		return err
+	}
+	for _, p := range policies {
+		if _, err := tx.Exec(`
+			INSERT INTO table_retention_policies (table_name, scope, environment_id, days, updated_by)
+			VALUES ($1, $2, $3, $4, $5)`,
+			table, retentionScope(p.EnvironmentID), nullIfEmpty(p.EnvironmentID), p.Days, nullIfEmpty(p.UpdatedBy)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
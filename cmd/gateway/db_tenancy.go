@@ -0,0 +1,398 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// errTenancyReference is returned when a declared member or grant names a user or project that
+// does not exist
+var errTenancyReference = errors.New("does not exist")
+
+// tenancyTx converges tenancy resources inside one transaction and records what it changed
+type tenancyTx struct {
+	tx      *sql.Tx
+	actor   string
+	changes []TenancyChange
+}
+
+// withTenancyTx runs fn in a transaction and returns its changes. A dry run rolls the transaction
+// back, so the changes reported are exactly those an apply would make.
+func (db *DB) withTenancyTx(actor string, dryRun bool, fn func(t *tenancyTx) error) ([]TenancyChange, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t := &tenancyTx{tx: tx, actor: actor, changes: []TenancyChange{}}
+	if err := fn(t); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return t.changes, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if len(t.changes) > 0 {
+		db.invalidateAccess()
+	}
+	return t.changes, nil
+}
+
+func (t *tenancyTx) record(action, kind, key, id string) {
+	t.changes = append(t.changes, TenancyChange{Action: action, Kind: kind, Key: key, ID: id})
+}
+
+// tenancyCreated reports whether the changes include the creation of the given resource
+func tenancyCreated(changes []TenancyChange, kind, key string) bool {
+	for _, c := range changes {
+		if c.Action == "create" && c.Kind == kind && c.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupID returns the ID of the project or team with the given slug
+func (t *tenancyTx) lookupID(table, slug string) (string, error) {
+	var id string
+	err := t.tx.QueryRow(`SELECT id FROM `+table+` WHERE slug = $1`, slug).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", errTenancyReference
+	}
+	return id, err
+}
+
+// upsertProject creates or updates the project with p.Slug and sets p.ID
+func (t *tenancyTx) upsertProject(p *DeclaredProject) error {
+	err := t.tx.QueryRow(`
+		INSERT INTO projects (id, name, slug, description, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id`,
+		uuid.New().String(), p.Name, p.Slug, p.Description, nullIfEmpty(t.actor)).Scan(&p.ID)
+	if err == nil {
+		t.record("create", "project", p.Slug, p.ID)
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("project %s: %w", p.Slug, err)
+	}
+	var name, description string
+	if err := t.tx.QueryRow(`SELECT id, name, COALESCE(description, '') FROM projects WHERE slug = $1 FOR UPDATE`,
+		p.Slug).Scan(&p.ID, &name, &description); err != nil {
+		return fmt.Errorf("project %s: %w", p.Slug, err)
+	}
+	if name == p.Name && description == p.Description {
+		return nil
+	}
+	if _, err := t.tx.Exec(`UPDATE projects SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		p.Name, p.Description, p.ID); err != nil {
+		return fmt.Errorf("project %s: %w", p.Slug, err)
+	}
+	t.record("update", "project", p.Slug, p.ID)
+	return nil
+}
+
+// upsertEnvironment creates or updates an environment of a project and sets e.ID
+func (t *tenancyTx) upsertEnvironment(projectID, projectSlug string, e *DeclaredEnvironment) error {
+	key := projectSlug + "/" + e.Slug
+	err := t.tx.QueryRow(`
+		INSERT INTO environments (id, project_id, name, slug, description, color, sort_order, is_production)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (project_id, slug) DO NOTHING
+		RETURNING id`,
+		uuid.New().String(), projectID, e.Name, e.Slug, e.Description, e.Color, e.SortOrder, e.IsProduction).Scan(&e.ID)
+	if err == nil {
+		t.record("create", "environment", key, e.ID)
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("environment %s: %w", key, err)
+	}
+	var cur DeclaredEnvironment
+	if err := t.tx.QueryRow(`
+		SELECT id, name, COALESCE(description, ''), COALESCE(color, ''), COALESCE(sort_order, 0), COALESCE(is_production, FALSE)
+		FROM environments WHERE project_id = $1 AND slug = $2 FOR UPDATE`,
+		projectID, e.Slug).Scan(&e.ID, &cur.Name, &cur.Description, &cur.Color, &cur.SortOrder, &cur.IsProduction); err != nil {
+		return fmt.Errorf("environment %s: %w", key, err)
+	}
+	if cur.Name == e.Name && cur.Description == e.Description && cur.Color == e.Color &&
+		cur.SortOrder == e.SortOrder && cur.IsProduction == e.IsProduction {
+		return nil
+	}
+	if _, err := t.tx.Exec(`
+		UPDATE environments SET name = $1, description = $2, color = $3, sort_order = $4, is_production = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6`,
+		e.Name, e.Description, e.Color, e.SortOrder, e.IsProduction, e.ID); err != nil {
+		return fmt.Errorf("environment %s: %w", key, err)
+	}
+	t.record("update", "environment", key, e.ID)
+	return nil
+}
+
+// upsertTeam creates or updates the team with tm.Slug and sets tm.ID
+func (t *tenancyTx) upsertTeam(tm *DeclaredTeam) error {
+	err := t.tx.QueryRow(`
+		INSERT INTO teams (id, name, slug, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id`,
+		uuid.New().String(), tm.Name, tm.Slug, tm.Description).Scan(&tm.ID)
+	if err == nil {
+		t.record("create", "team", tm.Slug, tm.ID)
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("team %s: %w", tm.Slug, err)
+	}
+	var name, description string
+	if err := t.tx.QueryRow(`SELECT id, name, COALESCE(description, '') FROM teams WHERE slug = $1 FOR UPDATE`,
+		tm.Slug).Scan(&tm.ID, &name, &description); err != nil {
+		return fmt.Errorf("team %s: %w", tm.Slug, err)
+	}
+	if name == tm.Name && description == tm.Description {
+		return nil
+	}
+	if _, err := t.tx.Exec(`UPDATE teams SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		tm.Name, tm.Description, tm.ID); err != nil {
+		return fmt.Errorf("team %s: %w", tm.Slug, err)
+	}
+	t.record("update", "team", tm.Slug, tm.ID)
+	return nil
+}
+
+// upsertMember adds a user to a team or changes their role
+func (t *tenancyTx) upsertMember(teamID, teamSlug string, m DeclaredMember) error {
+	key := teamSlug + "/" + m.Username
+	var role TeamRole
+	err := t.tx.QueryRow(`SELECT role FROM team_members WHERE team_id = $1 AND username = $2 FOR UPDATE`,
+		teamID, m.Username).Scan(&role)
+	switch {
+	case err == sql.ErrNoRows:
+		var exists bool
+		if err := t.tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, m.Username).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("member %s: user %w", key, errTenancyReference)
+		}
+		if _, err := t.tx.Exec(`INSERT INTO team_members (team_id, username, role) VALUES ($1, $2, $3)`,
+			teamID, m.Username, m.Role); err != nil {
+			return fmt.Errorf("member %s: %w", key, err)
+		}
+		t.record("create", "member", key, "")
+	case err != nil:
+		return fmt.Errorf("member %s: %w", key, err)
+	case role != m.Role:
+		if _, err := t.tx.Exec(`UPDATE team_members SET role = $1 WHERE team_id = $2 AND username = $3`,
+			m.Role, teamID, m.Username); err != nil {
+			return fmt.Errorf("member %s: %w", key, err)
+		}
+		t.record("update", "member", key, "")
+	}
+	return nil
+}
+
+// upsertAccess grants a team a permission on a project, by project slug
+func (t *tenancyTx) upsertAccess(teamID, teamSlug string, a DeclaredAccess) error {
+	key := teamSlug + "/" + a.Project
+	projectID, err := t.lookupID("projects", a.Project)
+	if err != nil {
+		return fmt.Errorf("access %s: project %w", key, err)
+	}
+	var permission Permission
+	err = t.tx.QueryRow(`SELECT permission FROM team_project_access WHERE team_id = $1 AND project_id = $2 FOR UPDATE`,
+		teamID, projectID).Scan(&permission)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err := t.tx.Exec(`INSERT INTO team_project_access (team_id, project_id, permission, granted_by) VALUES ($1, $2, $3, $4)`,
+			teamID, projectID, a.Permission, nullIfEmpty(t.actor)); err != nil {
+			return fmt.Errorf("access %s: %w", key, err)
+		}
+		t.record("create", "access", key, "")
+	case err != nil:
+		return fmt.Errorf("access %s: %w", key, err)
+	case permission != a.Permission:
+		if _, err := t.tx.Exec(`UPDATE team_project_access SET permission = $1, granted_by = $2, granted_at = CURRENT_TIMESTAMP
+			WHERE team_id = $3 AND project_id = $4`,
+			a.Permission, nullIfEmpty(t.actor), teamID, projectID); err != nil {
+			return fmt.Errorf("access %s: %w", key, err)
+		}
+		t.record("update", "access", key, "")
+	}
+	return nil
+}
+
+// prune deletes the rows a query returns as (id, key) and records them under kind
+func (t *tenancyTx) prune(kind, query string, args ...interface{}) error {
+	rows, err := t.tx.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("pruning %ss: %w", kind, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return err
+		}
+		t.record("delete", kind, key, id)
+	}
+	return rows.Err()
+}
+
+// apply converges on st. With prune, projects and teams missing from st are deleted, as are the
+// environments, members and grants of declared projects and teams that st does not list.
+func (t *tenancyTx) apply(st *TenancyState, prune bool) error {
+	projectSlugs := []string{}
+	for i := range st.Projects {
+		p := &st.Projects[i]
+		projectSlugs = append(projectSlugs, p.Slug)
+		if err := t.upsertProject(p); err != nil {
+			return err
+		}
+		envSlugs := []string{}
+		for j := range p.Environments {
+			envSlugs = append(envSlugs, p.Environments[j].Slug)
+			if err := t.upsertEnvironment(p.ID, p.Slug, &p.Environments[j]); err != nil {
+				return err
+			}
+		}
+		if prune {
+			if err := t.prune("environment", `
+				DELETE FROM environments WHERE project_id = $1 AND NOT (slug = ANY($2::text[]))
+				RETURNING id::text, $3::text || '/' || slug`,
+				p.ID, pq.Array(envSlugs), p.Slug); err != nil {
+				return err
+			}
+		}
+	}
+
+	teamSlugs := []string{}
+	for i := range st.Teams {
+		tm := &st.Teams[i]
+		teamSlugs = append(teamSlugs, tm.Slug)
+		if err := t.upsertTeam(tm); err != nil {
+			return err
+		}
+		usernames := []string{}
+		for _, m := range tm.Members {
+			usernames = append(usernames, m.Username)
+			if err := t.upsertMember(tm.ID, tm.Slug, m); err != nil {
+				return err
+			}
+		}
+		granted := []string{}
+		for _, a := range tm.Access {
+			granted = append(granted, a.Project)
+			if err := t.upsertAccess(tm.ID, tm.Slug, a); err != nil {
+				return err
+			}
+		}
+		if prune {
+			if err := t.prune("member", `
+				DELETE FROM team_members WHERE team_id = $1 AND NOT (username = ANY($2::text[]))
+				RETURNING '', $3::text || '/' || username`,
+				tm.ID, pq.Array(usernames), tm.Slug); err != nil {
+				return err
+			}
+			if err := t.prune("access", `
+				DELETE FROM team_project_access tpa USING projects p
+				WHERE tpa.project_id = p.id AND tpa.team_id = $1 AND NOT (p.slug = ANY($2::text[]))
+				RETURNING '', $3::text || '/' || p.slug`,
+				tm.ID, pq.Array(granted), tm.Slug); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !prune {
+		return nil
+	}
+	if err := t.prune("team", `DELETE FROM teams WHERE NOT (slug = ANY($1::text[])) RETURNING id::text, slug`,
+		pq.Array(teamSlugs)); err != nil {
+		return err
+	}
+	return t.prune("project", `DELETE FROM projects WHERE NOT (slug = ANY($1::text[])) RETURNING id::text, slug`,
+		pq.Array(projectSlugs))
+}
+
+// ApplyTenancy converges the projects, environments, teams, members and grants on st in a single
+// transaction, filling in the IDs of st
+func (db *DB) ApplyTenancy(st *TenancyState, prune, dryRun bool, actor string) ([]TenancyChange, error) {
+	return db.withTenancyTx(actor, dryRun, func(t *tenancyTx) error {
+		return t.apply(st, prune)
+	})
+}
+
+// GetTeamBySlug retrieves a team by slug
+func (db *DB) GetTeamBySlug(slug string) (*Team, error) {
+	var t Team
+	var desc sql.NullString
+	err := db.conn.QueryRow(`SELECT id, name, slug, description, created_at, updated_at FROM teams WHERE slug = $1`,
+		slug).Scan(&t.ID, &t.Name, &t.Slug, &desc, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Description = desc.String
+	return &t, nil
+}
+
+// ExportTenancy returns the current projects and teams in the shape ApplyTenancy takes
+func (db *DB) ExportTenancy() (*TenancyState, error) {
+	st := &TenancyState{Projects: []DeclaredProject{}, Teams: []DeclaredTeam{}}
+	projects, err := db.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	projectSlugs := map[string]string{}
+	for _, p := range projects {
+		projectSlugs[p.ID] = p.Slug
+		envs, err := db.ListEnvironments(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		dp := DeclaredProject{ID: p.ID, Slug: p.Slug, Name: p.Name, Description: p.Description}
+		for _, e := range envs {
+			dp.Environments = append(dp.Environments, DeclaredEnvironment{
+				ID: e.ID, Slug: e.Slug, Name: e.Name, Description: e.Description, Color: e.Color,
+				SortOrder: e.SortOrder, IsProduction: e.IsProduction,
+			})
+		}
+		st.Projects = append(st.Projects, dp)
+	}
+
+	teams, err := db.ListTeams()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		dt := DeclaredTeam{ID: t.ID, Slug: t.Slug, Name: t.Name, Description: t.Description}
+		members, err := db.ListTeamMembers(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			dt.Members = append(dt.Members, DeclaredMember{Username: m.Username, Role: m.Role})
+		}
+		access, err := db.ListTeamProjectAccess(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range access {
+			dt.Access = append(dt.Access, DeclaredAccess{Project: projectSlugs[a.ProjectID], Permission: a.Permission})
+		}
+		st.Teams = append(st.Teams, dt)
+	}
+	return st, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// InsertTLSScanResult stores one scan; the full result is kept as JSON for history views
+func (db *DB) InsertTLSScanResult(r *TLSScanResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO tls_scan_results (agent_id, server_name, address, grade, score, result, scanned_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		r.AgentID, r.ServerName, r.Address, r.Grade, r.Score, data, r.ScannedAt).Scan(&r.ID)
+}
+
+func scanTLSScanResults(rows *sql.Rows) ([]TLSScanResult, error) {
+	defer rows.Close()
+	results := []TLSScanResult{}
+	for rows.Next() {
+		var id string
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		var r TLSScanResult
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		r.ID = id
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// ListLatestTLSScans returns the newest scan of every virtual host; an empty agentIDs means all agents
+func (db *DB) ListLatestTLSScans(agentIDs []string) ([]TLSScanResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT ON (agent_id, server_name) id, result
+		FROM tls_scan_results
+		WHERE cardinality($1::text[]) = 0 OR agent_id = ANY($1)
+		ORDER BY agent_id, server_name, scanned_at DESC`, pq.Array(agentIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanTLSScanResults(rows)
+}
+
+// ListTLSScanHistory returns past scans of one agent, newest first, optionally for a single server name
+func (db *DB) ListTLSScanHistory(agentID, serverName string, limit int) ([]TLSScanResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, result
+		FROM tls_scan_results
+		WHERE agent_id = $1 AND ($2 = '' OR server_name = $2)
+		ORDER BY scanned_at DESC
+		LIMIT $3`, agentID, serverName, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanTLSScanResults(rows)
+}
+
+// PruneTLSScanResults deletes scans older than retention
+func (db *DB) PruneTLSScanResults(retention time.Duration) (int64, error) {
+	res, err := db.conn.Exec("DELETE FROM tls_scan_results WHERE scanned_at < $1", time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// TrafficMirror shadows a sample of the requests of the selected agents to a staging backend with
+// the nginx mirror module. Server blocks opt in with `include avika/mirror/<slug>.conf;`.
+type TrafficMirror struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	Slug          string               `json:"slug"`
+	Target        string               `json:"target"`
+	SamplePercent float64              `json:"sample_percent"`
+	Locations     []string             `json:"locations"` // path prefixes; every path when empty
+	MirrorBody    bool                 `json:"mirror_body"`
+	Selector      BulkSelector         `json:"selector"`
+	Enabled       bool                 `json:"enabled"`
+	EnabledAt     *time.Time           `json:"enabled_at,omitempty"`
+	DisabledAt    *time.Time           `json:"disabled_at,omitempty"`
+	HTTPPath      string               `json:"http_path"`
+	IncludePath   string               `json:"include_path"`
+	CreatedBy     string               `json:"created_by,omitempty"`
+	UpdatedBy     string               `json:"updated_by,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	Agents        []TrafficMirrorAgent `json:"agents,omitempty"`
+}
+
+// TrafficMirrorAgent is the outcome of the last write of a mirror to an agent
+type TrafficMirrorAgent struct {
+	AgentID   string    `json:"agent_id"`
+	Status    string    `json:"status"` // applied, failed
+	Error     string    `json:"error,omitempty"`
+	Enabled   bool      `json:"enabled"` // whether the agent mirrors
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+const trafficMirrorColumns = `id, name, slug, target, sample_percent, locations, mirror_body, selector, enabled,
+	enabled_at, disabled_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), created_at, updated_at`
+
+func scanTrafficMirror(row interface{ Scan(...interface{}) error }) (*TrafficMirror, error) {
+	var m TrafficMirror
+	var locations, selector []byte
+	var enabledAt, disabledAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.Name, &m.Slug, &m.Target, &m.SamplePercent, &locations, &m.MirrorBody, &selector,
+		&m.Enabled, &enabledAt, &disabledAt, &m.CreatedBy, &m.UpdatedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(locations, &m.Locations)
+	json.Unmarshal(selector, &m.Selector)
+	if enabledAt.Valid {
+		m.EnabledAt = &enabledAt.Time
+	}
+	if disabledAt.Valid {
+		m.DisabledAt = &disabledAt.Time
+	}
+	m.setPaths()
+	return &m, nil
+}
+
+// CreateTrafficMirror records a mirror, enabled from now
+func (db *DB) CreateTrafficMirror(m *TrafficMirror) error {
+	locations, err := json.Marshal(m.Locations)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(m.Selector)
+	if err != nil {
+		return err
+	}
+	var enabledAt time.Time
+	err = db.conn.QueryRow(`
+		INSERT INTO traffic_mirrors (id, name, slug, target, sample_percent, locations, mirror_body, selector,
+			enabled, enabled_at, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, CURRENT_TIMESTAMP, $9, $9)
+		RETURNING enabled_at, created_at, updated_at`,
+		m.ID, m.Name, m.Slug, m.Target, m.SamplePercent, locations, m.MirrorBody, selector,
+		nullIfEmpty(m.CreatedBy)).Scan(&enabledAt, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	m.Enabled, m.EnabledAt = true, &enabledAt
+	return nil
+}
+
+// UpdateTrafficMirror saves the name, target, sampling, locations and selector of a mirror
+func (db *DB) UpdateTrafficMirror(m *TrafficMirror) error {
+	locations, err := json.Marshal(m.Locations)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(m.Selector)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE traffic_mirrors
+		SET name = $2, target = $3, sample_percent = $4, locations = $5, mirror_body = $6, selector = $7,
+			updated_by = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		m.ID, m.Name, m.Target, m.SamplePercent, locations, m.MirrorBody, selector, nullIfEmpty(m.UpdatedBy)).Scan(&m.UpdatedAt)
+}
+
+// SetTrafficMirrorEnabled enables or disables a mirror, stamping when
+func (db *DB) SetTrafficMirrorEnabled(m *TrafficMirror, enabled bool, username string) error {
+	var enabledAt, disabledAt sql.NullTime
+	err := db.conn.QueryRow(`
+		UPDATE traffic_mirrors
+		SET enabled = $2,
+			enabled_at = CASE WHEN $2 THEN CURRENT_TIMESTAMP ELSE enabled_at END,
+			disabled_at = CASE WHEN $2 THEN NULL ELSE CURRENT_TIMESTAMP END,
+			updated_by = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING enabled_at, disabled_at, updated_at`,
+		m.ID, enabled, nullIfEmpty(username)).Scan(&enabledAt, &disabledAt, &m.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	m.Enabled, m.EnabledAt, m.DisabledAt, m.UpdatedBy = enabled, nil, nil, username
+	if enabledAt.Valid {
+		m.EnabledAt = &enabledAt.Time
+	}
+	if disabledAt.Valid {
+		m.DisabledAt = &disabledAt.Time
+	}
+	return nil
+}
+
+// GetTrafficMirror returns a mirror with the outcome on each agent; nil when it does not exist
+func (db *DB) GetTrafficMirror(id string) (*TrafficMirror, error) {
+	m, err := scanTrafficMirror(db.conn.QueryRow(`SELECT `+trafficMirrorColumns+` FROM traffic_mirrors WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT agent_id, status, COALESCE(error, ''), enabled, applied_at
+		FROM traffic_mirror_agents WHERE mirror_id = $1
+		ORDER BY agent_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	m.Agents = []TrafficMirrorAgent{}
+	for rows.Next() {
+		var a TrafficMirrorAgent
+		if err := rows.Scan(&a.AgentID, &a.Status, &a.Error, &a.Enabled, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		m.Agents = append(m.Agents, a)
+	}
+	return m, rows.Err()
+}
+
+// ListTrafficMirrors returns every mirror by name, without the outcome per agent
+func (db *DB) ListTrafficMirrors() ([]TrafficMirror, error) {
+	rows, err := db.conn.Query(`SELECT ` + trafficMirrorColumns + ` FROM traffic_mirrors ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mirrors := []TrafficMirror{}
+	for rows.Next() {
+		m, err := scanTrafficMirror(rows)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, *m)
+	}
+	return mirrors, rows.Err()
+}
+
+// TrafficMirrorSlugExists reports whether a mirror uses slug
+func (db *DB) TrafficMirrorSlugExists(slug string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM traffic_mirrors WHERE slug = $1)`, slug).Scan(&exists)
+	return exists, err
+}
+
+// DeleteTrafficMirror deletes a mirror with the outcome on each agent
+func (db *DB) DeleteTrafficMirror(id string) error {
+	_, err := db.conn.Exec(`DELETE FROM traffic_mirrors WHERE id = $1`, id)
+	return err
+}
+
+// SaveTrafficMirrorAgent records the outcome of writing a mirror to an agent. A failed write keeps
+// whether the agent mirrored, since the agent restored its previous file.
+func (db *DB) SaveTrafficMirrorAgent(mirrorID string, a *TrafficMirrorAgent) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO traffic_mirror_agents (mirror_id, agent_id, status, error, enabled, applied_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (mirror_id, agent_id) DO UPDATE SET
+			status = EXCLUDED.status, error = EXCLUDED.error, applied_at = EXCLUDED.applied_at,
+			enabled = CASE WHEN EXCLUDED.status = 'applied' THEN EXCLUDED.enabled ELSE traffic_mirror_agents.enabled END`,
+		mirrorID, a.AgentID, a.Status, nullIfEmpty(a.Error), a.Enabled)
+	return err
+}
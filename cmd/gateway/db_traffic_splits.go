@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// TrafficSplitVariant is one side of a traffic split: the upstream that serves a share of clients
+type TrafficSplitVariant struct {
+	Name     string  `json:"name"`
+	Upstream string  `json:"upstream"`
+	Weight   float64 `json:"weight"` // percent of clients, up to two decimals
+}
+
+// TrafficSplit sends weighted shares of clients to upstreams through a split_clients block
+// written to the selected agents. Configs route on it with `proxy_pass http://$avika_split_<slug>;`.
+type TrafficSplit struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Slug        string                `json:"slug"`
+	Variable    string                `json:"variable"`
+	IncludePath string                `json:"include_path"`
+	Key         string                `json:"key"`
+	Variants    []TrafficSplitVariant `json:"variants"`
+	Selector    BulkSelector          `json:"selector"`
+	CreatedBy   string                `json:"created_by,omitempty"`
+	UpdatedBy   string                `json:"updated_by,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	Agents      []TrafficSplitAgent   `json:"agents,omitempty"`
+}
+
+// TrafficSplitAgent is the outcome of the last write of a split to an agent
+type TrafficSplitAgent struct {
+	AgentID   string                `json:"agent_id"`
+	Status    string                `json:"status"` // applied, failed
+	Error     string                `json:"error,omitempty"`
+	Variants  []TrafficSplitVariant `json:"variants"` // the weights the agent runs with when applied
+	AppliedAt time.Time             `json:"applied_at"`
+}
+
+const trafficSplitColumns = `id, name, slug, split_key, variants, selector, COALESCE(created_by, ''),
+	COALESCE(updated_by, ''), created_at, updated_at`
+
+func scanTrafficSplit(row interface{ Scan(...interface{}) error }) (*TrafficSplit, error) {
+	var sp TrafficSplit
+	var variants, selector []byte
+	if err := row.Scan(&sp.ID, &sp.Name, &sp.Slug, &sp.Key, &variants, &selector, &sp.CreatedBy, &sp.UpdatedBy,
+		&sp.CreatedAt, &sp.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(variants, &sp.Variants)
+	json.Unmarshal(selector, &sp.Selector)
+	sp.Variable, sp.IncludePath = trafficSplitVariable(sp.Slug), trafficSplitIncludePath(sp.Slug)
+	return &sp, nil
+}
+
+// CreateTrafficSplit records a traffic split
+func (db *DB) CreateTrafficSplit(sp *TrafficSplit) error {
+	variants, err := json.Marshal(sp.Variants)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(sp.Selector)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		INSERT INTO traffic_splits (id, name, slug, split_key, variants, selector, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING created_at, updated_at`,
+		sp.ID, sp.Name, sp.Slug, sp.Key, variants, selector, nullIfEmpty(sp.CreatedBy)).Scan(&sp.CreatedAt, &sp.UpdatedAt)
+}
+
+// UpdateTrafficSplit saves the name, key, variants and selector of a split
+func (db *DB) UpdateTrafficSplit(sp *TrafficSplit) error {
+	variants, err := json.Marshal(sp.Variants)
+	if err != nil {
+		return err
+	}
+	selector, err := json.Marshal(sp.Selector)
+	if err != nil {
+		return err
+	}
+	return db.conn.QueryRow(`
+		UPDATE traffic_splits
+		SET name = $2, split_key = $3, variants = $4, selector = $5, updated_by = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		sp.ID, sp.Name, sp.Key, variants, selector, nullIfEmpty(sp.UpdatedBy)).Scan(&sp.UpdatedAt)
+}
+
+// GetTrafficSplit returns a split with the outcome on each agent; nil when it does not exist
+func (db *DB) GetTrafficSplit(id string) (*TrafficSplit, error) {
+	sp, err := scanTrafficSplit(db.conn.QueryRow(`SELECT `+trafficSplitColumns+` FROM traffic_splits WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT agent_id, status, COALESCE(error, ''), variants, applied_at
+		FROM traffic_split_agents WHERE split_id = $1
+		ORDER BY agent_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sp.Agents = []TrafficSplitAgent{}
+	for rows.Next() {
+		var a TrafficSplitAgent
+		var variants []byte
+		if err := rows.Scan(&a.AgentID, &a.Status, &a.Error, &variants, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(variants, &a.Variants)
+		sp.Agents = append(sp.Agents, a)
+	}
+	return sp, rows.Err()
+}
+
+// ListTrafficSplits returns every split by name, without the outcome per agent
+func (db *DB) ListTrafficSplits() ([]TrafficSplit, error) {
+	rows, err := db.conn.Query(`SELECT ` + trafficSplitColumns + ` FROM traffic_splits ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := []TrafficSplit{}
+	for rows.Next() {
+		sp, err := scanTrafficSplit(rows)
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, *sp)
+	}
+	return splits, rows.Err()
+}
+
+// TrafficSplitSlugExists reports whether a split uses slug
+func (db *DB) TrafficSplitSlugExists(slug string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM traffic_splits WHERE slug = $1)`, slug).Scan(&exists)
+	return exists, err
+}
+
+// DeleteTrafficSplit deletes a split with the outcome on each agent
+func (db *DB) DeleteTrafficSplit(id string) error {
+	_, err := db.conn.Exec(`DELETE FROM traffic_splits WHERE id = $1`, id)
+	return err
+}
+
+// SaveTrafficSplitAgent records the outcome of writing a split to an agent. A failed write keeps
+// the weights last applied, since the agent restored its previous file.
+func (db *DB) SaveTrafficSplitAgent(splitID string, a *TrafficSplitAgent) error {
+	applied := a.Variants
+	if applied == nil {
+		applied = []TrafficSplitVariant{}
+	}
+	variants, err := json.Marshal(applied)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO traffic_split_agents (split_id, agent_id, status, error, variants, applied_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (split_id, agent_id) DO UPDATE SET
+			status = EXCLUDED.status, error = EXCLUDED.error, applied_at = EXCLUDED.applied_at,
+			variants = CASE WHEN EXCLUDED.status = 'applied' THEN EXCLUDED.variants ELSE traffic_split_agents.variants END`,
+		splitID, a.AgentID, a.Status, nullIfEmpty(a.Error), variants)
+	return err
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UpgradeCampaign rolls a target agent version out to a set of agents in batches
+type UpgradeCampaign struct {
+	ID                  string          `json:"id"`
+	Name                string          `json:"name"`
+	TargetVersion       string          `json:"target_version"`
+	BatchSize           int             `json:"batch_size"`
+	SoakSeconds         int             `json:"soak_seconds"`
+	FailureThresholdPct int             `json:"failure_threshold_pct"`
+	Status              string          `json:"status"`
+	CurrentBatch        int             `json:"current_batch"`
+	TotalBatches        int             `json:"total_batches"`
+	Error               string          `json:"error,omitempty"`
+	GatewayID           string          `json:"gateway_id,omitempty"`
+	CreatedBy           string          `json:"created_by,omitempty"`
+	CreatedAt           time.Time       `json:"created_at"`
+	StartedAt           *time.Time      `json:"started_at,omitempty"`
+	CompletedAt         *time.Time      `json:"completed_at,omitempty"`
+	Progress            UpgradeProgress `json:"progress"`
+	Results             []UpgradeResult `json:"results,omitempty"`
+}
+
+// UpgradeProgress counts the agents of a campaign by result status
+type UpgradeProgress struct {
+	Total    int `json:"total"`
+	Pending  int `json:"pending"`
+	Updating int `json:"updating"`
+	Upgraded int `json:"upgraded"`
+	Failed   int `json:"failed"`
+	Skipped  int `json:"skipped"`
+}
+
+// UpgradeResult is the outcome of a campaign for one agent
+type UpgradeResult struct {
+	AgentID     string     `json:"agent_id"`
+	Batch       int        `json:"batch"`
+	Hostname    string     `json:"hostname,omitempty"`
+	FromVersion string     `json:"from_version,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (p *UpgradeProgress) add(status string, n int) {
+	p.Total += n
+	switch status {
+	case "pending":
+		p.Pending += n
+	case "updating":
+		p.Updating += n
+	case "upgraded":
+		p.Upgraded += n
+	case "failed":
+		p.Failed += n
+	case "skipped":
+		p.Skipped += n
+	}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+const upgradeCampaignColumns = `id, name, target_version, batch_size, soak_seconds, failure_threshold_pct, status,
+	current_batch, total_batches, COALESCE(error, ''), COALESCE(gateway_id, ''), COALESCE(created_by, ''), created_at,
+	started_at, completed_at`
+
+func scanUpgradeCampaign(row interface{ Scan(...interface{}) error }) (*UpgradeCampaign, error) {
+	var c UpgradeCampaign
+	var startedAt, completedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &c.TargetVersion, &c.BatchSize, &c.SoakSeconds, &c.FailureThresholdPct, &c.Status,
+		&c.CurrentBatch, &c.TotalBatches, &c.Error, &c.GatewayID, &c.CreatedBy, &c.CreatedAt, &startedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	c.StartedAt, c.CompletedAt = nullTimePtr(startedAt), nullTimePtr(completedAt)
+	return &c, nil
+}
+
+// CreateUpgradeCampaign records a campaign and the pending result of each of its agents
+func (db *DB) CreateUpgradeCampaign(c *UpgradeCampaign) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`
+		INSERT INTO agent_upgrade_campaigns (id, name, target_version, batch_size, soak_seconds, failure_threshold_pct,
+			status, total_batches, gateway_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at`,
+		c.ID, c.Name, c.TargetVersion, c.BatchSize, c.SoakSeconds, c.FailureThresholdPct, c.Status, c.TotalBatches,
+		nullIfEmpty(c.GatewayID), nullIfEmpty(c.CreatedBy)).Scan(&c.CreatedAt); err != nil {
+		return err
+	}
+	for _, r := range c.Results {
+		if _, err := tx.Exec(`
+			INSERT INTO agent_upgrade_results (campaign_id, agent_id, batch, hostname, from_version, status, error)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			c.ID, r.AgentID, r.Batch, nullIfEmpty(r.Hostname), nullIfEmpty(r.FromVersion), r.Status, nullIfEmpty(r.Error)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateUpgradeCampaign saves the status and batch of a running campaign
+func (db *DB) UpdateUpgradeCampaign(c *UpgradeCampaign) error {
+	_, err := db.conn.Exec(`
+		UPDATE agent_upgrade_campaigns
+		SET status = $2, current_batch = $3, error = $4, started_at = $5, completed_at = $6
+		WHERE id = $1`,
+		c.ID, c.Status, c.CurrentBatch, nullIfEmpty(c.Error), c.StartedAt, c.CompletedAt)
+	return err
+}
+
+// SaveUpgradeResult saves the outcome of a campaign for one agent
+func (db *DB) SaveUpgradeResult(campaignID string, r *UpgradeResult) error {
+	_, err := db.conn.Exec(`
+		UPDATE agent_upgrade_results
+		SET status = $3, error = $4, started_at = $5, completed_at = $6
+		WHERE campaign_id = $1 AND agent_id = $2`,
+		campaignID, r.AgentID, r.Status, nullIfEmpty(r.Error), r.StartedAt, r.CompletedAt)
+	return err
+}
+
+// GetUpgradeCampaign returns a campaign with the result of each agent; nil when it does not exist
+func (db *DB) GetUpgradeCampaign(id string) (*UpgradeCampaign, error) {
+	c, err := scanUpgradeCampaign(db.conn.QueryRow(`SELECT `+upgradeCampaignColumns+` FROM agent_upgrade_campaigns WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT agent_id, batch, COALESCE(hostname, ''), COALESCE(from_version, ''), status, COALESCE(error, ''),
+			started_at, completed_at
+		FROM agent_upgrade_results WHERE campaign_id = $1
+		ORDER BY batch, agent_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r UpgradeResult
+		var startedAt, completedAt sql.NullTime
+		if err := rows.Scan(&r.AgentID, &r.Batch, &r.Hostname, &r.FromVersion, &r.Status, &r.Error, &startedAt, &completedAt); err != nil {
+			return nil, err
+		}
+		r.StartedAt, r.CompletedAt = nullTimePtr(startedAt), nullTimePtr(completedAt)
+		c.Progress.add(r.Status, 1)
+		c.Results = append(c.Results, r)
+	}
+	return c, rows.Err()
+}
+
+// ListUpgradeCampaigns returns the campaigns, newest first, with their progress but no results
+func (db *DB) ListUpgradeCampaigns() ([]UpgradeCampaign, error) {
+	rows, err := db.conn.Query(`SELECT ` + upgradeCampaignColumns + ` FROM agent_upgrade_campaigns ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []UpgradeCampaign{}
+	index := make(map[string]int)
+	for rows.Next() {
+		c, err := scanUpgradeCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[c.ID] = len(campaigns)
+		campaigns = append(campaigns, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts, err := db.conn.Query(`SELECT campaign_id, status, COUNT(*) FROM agent_upgrade_results GROUP BY campaign_id, status`)
+	if err != nil {
+		return nil, err
+	}
+	defer counts.Close()
+	for counts.Next() {
+		var id, status string
+		var n int
+		if err := counts.Scan(&id, &status, &n); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			campaigns[i].Progress.add(status, n)
+		}
+	}
+	return campaigns, counts.Err()
+}
+
+// InterruptUpgradeCampaigns marks the campaigns a gateway was running when it stopped; their
+// agents not upgraded yet are skipped
+func (db *DB) InterruptUpgradeCampaigns(gatewayID string) (int64, error) {
+	res, err := db.conn.Exec(`
+		UPDATE agent_upgrade_campaigns
+		SET status = 'interrupted', error = 'gateway restarted during the campaign', completed_at = CURRENT_TIMESTAMP
+		WHERE status IN ('pending', 'running') AND COALESCE(gateway_id, '') = $1`, gatewayID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.conn.Exec(`
+		UPDATE agent_upgrade_results SET status = 'skipped'
+		WHERE status IN ('pending', 'updating') AND campaign_id IN (
+			SELECT id FROM agent_upgrade_campaigns WHERE status = 'interrupted' AND COALESCE(gateway_id, '') = $1)`, gatewayID); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
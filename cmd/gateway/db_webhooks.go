@@ -0,0 +1,201 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WebhookEndpoint is a user-configured URL lifecycle events are posted to
+type WebhookEndpoint struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // only returned when the endpoint is created
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is an event queued for or delivered to an endpoint
+type WebhookDelivery struct {
+	ID            string     `json:"id"`
+	EndpointID    string     `json:"endpoint_id"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Payload       []byte     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+const webhookEndpointColumns = `id, name, url, secret, events, enabled, COALESCE(created_by, ''), created_at, updated_at`
+
+func scanWebhookEndpoint(row interface{ Scan(...interface{}) error }) (*WebhookEndpoint, error) {
+	var e WebhookEndpoint
+	if err := row.Scan(&e.ID, &e.Name, &e.URL, &e.Secret, pq.Array(&e.Events), &e.Enabled, &e.CreatedBy,
+		&e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListWebhookEndpoints returns all endpoints ordered by name, with their secrets
+func (db *DB) ListWebhookEndpoints() ([]WebhookEndpoint, error) {
+	rows, err := db.conn.Query(`SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []WebhookEndpoint{}
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, rows.Err()
+}
+
+// GetWebhookEndpoint returns nil when no endpoint has that ID
+func (db *DB) GetWebhookEndpoint(id string) (*WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(db.conn.QueryRow(`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (db *DB) CreateWebhookEndpoint(e *WebhookEndpoint) error {
+	return db.conn.QueryRow(`
+		INSERT INTO webhook_endpoints (id, name, url, secret, events, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`,
+		e.ID, e.Name, e.URL, e.Secret, pq.Array(e.Events), e.Enabled, nullIfEmpty(e.CreatedBy)).Scan(&e.CreatedAt, &e.UpdatedAt)
+}
+
+// UpdateWebhookEndpoint saves the endpoint; its secret only changes when rotated
+func (db *DB) UpdateWebhookEndpoint(e *WebhookEndpoint) error {
+	return db.conn.QueryRow(`
+		UPDATE webhook_endpoints SET name = $2, url = $3, secret = $4, events = $5, enabled = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at`,
+		e.ID, e.Name, e.URL, e.Secret, pq.Array(e.Events), e.Enabled).Scan(&e.UpdatedAt)
+}
+
+func (db *DB) DeleteWebhookEndpoint(id string) (bool, error) {
+	res, err := db.conn.Exec("DELETE FROM webhook_endpoints WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// CreateWebhookDelivery queues an event for an endpoint
+func (db *DB) CreateWebhookDelivery(d *WebhookDelivery) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)`,
+		d.ID, d.EndpointID, d.EventID, d.EventType, d.Payload)
+	return err
+}
+
+// ListDueWebhookDeliveries returns the pending deliveries whose next attempt has come, oldest
+// first, with the URL and secret of their enabled endpoint
+func (db *DB) ListDueWebhookDeliveries(now time.Time, limit int) ([]WebhookDelivery, map[string]*WebhookEndpoint, error) {
+	rows, err := db.conn.Query(`
+		SELECT d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.attempts, e.url, e.secret
+		FROM webhook_deliveries d JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND e.enabled
+		ORDER BY d.next_attempt_at LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	endpoints := make(map[string]*WebhookEndpoint)
+	for rows.Next() {
+		var d WebhookDelivery
+		var url, secret string
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Attempts, &url, &secret); err != nil {
+			return nil, nil, err
+		}
+		d.Status = "pending"
+		deliveries = append(deliveries, d)
+		endpoints[d.EndpointID] = &WebhookEndpoint{ID: d.EndpointID, URL: url, Secret: secret}
+	}
+	return deliveries, endpoints, rows.Err()
+}
+
+// SaveWebhookAttempt records the outcome of a delivery attempt
+func (db *DB) SaveWebhookAttempt(d *WebhookDelivery) error {
+	_, err := db.conn.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_code = $4, error = $5, next_attempt_at = $6, delivered_at = $7
+		WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.ResponseCode, nullIfEmpty(d.Error), d.NextAttemptAt, d.DeliveredAt)
+	return err
+}
+
+// RetryWebhookDelivery queues a delivery again for an immediate attempt; false when it does not
+// belong to the endpoint
+func (db *DB) RetryWebhookDelivery(endpointID, id string) (bool, error) {
+	res, err := db.conn.Exec(`
+		UPDATE webhook_deliveries SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND endpoint_id = $2`, id, endpointID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListWebhookDeliveries returns the latest deliveries to an endpoint, newest first
+func (db *DB) ListWebhookDeliveries(endpointID, status string, limit int) ([]WebhookDelivery, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, endpoint_id, event_id, event_type, status, attempts, COALESCE(response_code, 0), COALESCE(error, ''),
+			next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC LIMIT $3`, endpointID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		var nextAttemptAt, deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.ResponseCode,
+			&d.Error, &nextAttemptAt, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		if d.Status == "pending" {
+			d.NextAttemptAt = nullTimePtr(nextAttemptAt)
+		}
+		d.DeliveredAt = nullTimePtr(deliveredAt)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// PruneWebhookDeliveries deletes the finished deliveries created before cutoff
+func (db *DB) PruneWebhookDeliveries(cutoff time.Time) (int64, error) {
+	res, err := db.conn.Exec(`DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
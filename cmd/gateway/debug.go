@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"regexp"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	"gopkg.in/yaml.v3"
+)
+
+// The /debug endpoints help diagnose a gateway in production: pprof profiles, a goroutine dump,
+// expvar, the effective configuration without its secrets and the depth of the batch write
+// queues. They are superadmin only.
+
+// debugWriteTimeout is how long a debug response may take to write, e.g. a CPU profile or trace of
+// ?seconds=60, instead of the server's WriteTimeout
+const debugWriteTimeout = 5 * time.Minute
+
+// redactedValue replaces secrets in the configuration dump
+const redactedValue = "***"
+
+// dsnPassword matches the password of a key=value DSN, e.g. host=db user=avika password='s3 cret',
+// and of a URL query (?password=...)
+var dsnPassword = regexp.MustCompile(`(?i)\b(password\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s&]*)`)
+
+// contextWithoutServer hides the *http.Server of a request from pprof's WriteTimeout check, once
+// the deadline was extended
+type contextWithoutServer struct {
+	context.Context
+}
+
+func (c contextWithoutServer) Value(key any) any {
+	if key == http.ServerContextKey {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// handleDebugPprof serves the pprof index and profiles under /debug/pprof/, e.g.
+// /debug/pprof/profile?seconds=30 (CPU), /debug/pprof/heap and /debug/pprof/trace?seconds=5.
+// Superadmin only.
+func (s *server) handleDebugPprof(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	// Profiles may take longer than the server's WriteTimeout, which pprof checks for
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(debugWriteTimeout))
+	r = r.WithContext(contextWithoutServer{r.Context()})
+
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
+
+// handleDebugGoroutines handles GET /debug/goroutines: the stack of every goroutine, as a panic
+// prints them. Superadmin only.
+func (s *server) handleDebugGoroutines(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// handleDebugVars handles GET /debug/vars: the expvar variables, memstats and cmdline among them.
+// Superadmin only.
+func (s *server) handleDebugVars(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+// handleDebugConfig handles GET /debug/config: the configuration the gateway runs with, after
+// environment overrides and secrets from the secrets provider, with passwords, keys and tokens
+// replaced by ***. Superadmin only.
+func (s *server) handleDebugConfig(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	if s.config == nil {
+		http.Error(w, `{"error":"configuration not loaded"}`, http.StatusServiceUnavailable)
+		return
+	}
+	cfg, err := sanitizedConfig(s.config)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// sanitizedConfig returns cfg by its YAML keys, with secrets replaced by redactedValue
+func sanitizedConfig(cfg *config.Config) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	redactConfig(out)
+	return out, nil
+}
+
+// redactConfig replaces the string values of secret keys, and the credentials of URLs, in place
+func redactConfig(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				if s != "" && secretConfigKey(key) {
+					v[key] = redactedValue
+				} else {
+					v[key] = redactURLCredentials(s)
+				}
+				continue
+			}
+			redactConfig(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			if s, ok := value.(string); ok {
+				v[i] = redactURLCredentials(s)
+				continue
+			}
+			redactConfig(value)
+		}
+	}
+}
+
+// secretConfigKey reports whether a configuration key holds a secret: passwords, secrets, keys and
+// tokens, but not e.g. token_expiry, max_tokens or key_file
+func secretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") ||
+		key == "key" || key == "token" || key == "psk" ||
+		strings.HasSuffix(key, "_key") || strings.HasSuffix(key, "_token")
+}
+
+// redactURLCredentials masks the user and password of a URL or DSN, e.g. ClickHouse's address or
+// the PostgreSQL DSN, and the password of a key=value DSN
+func redactURLCredentials(s string) string {
+	s = dsnPassword.ReplaceAllString(s, "${1}"+redactedValue)
+	if !strings.Contains(s, "://") || !strings.Contains(s, "@") {
+		return s
+	}
+	return maskDSN(s)
+}
+
+// BatchQueue is a queue of rows waiting for a batched write
+type BatchQueue struct {
+	Backend  string `json:"backend"`
+	Name     string `json:"name"`
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+}
+
+// batchQueues returns the write queues of ClickHouse
+func (db *ClickHouseDB) batchQueues() []BatchQueue {
+	queue := func(name string, depth, capacity int) BatchQueue {
+		return BatchQueue{Backend: "clickhouse", Name: name, Depth: depth, Capacity: capacity}
+	}
+	return []BatchQueue{
+		queue("access_logs", len(db.logChan), cap(db.logChan)),
+		queue("spans", len(db.spanChan), cap(db.spanChan)),
+		queue("system_metrics", len(db.sysChan), cap(db.sysChan)),
+		queue("nginx_metrics", len(db.nginxChan), cap(db.nginxChan)),
+		queue("gateway_metrics", len(db.gwChan), cap(db.gwChan)),
+		queue("security_events", len(db.secChan), cap(db.secChan)),
+		queue("synthetic_results", len(db.synChan), cap(db.synChan)),
+		queue("generic_metrics", len(db.genChan), cap(db.genChan)),
+	}
+}
+
+// batchQueues returns the write queues of the PostgreSQL analytics tables
+func (pa *PostgresAnalytics) batchQueues() []BatchQueue {
+	var queues []BatchQueue
+	for _, t := range []*pgAnalyticsTable{pa.logs, pa.system, pa.nginx} {
+		queues = append(queues, BatchQueue{Backend: "postgres", Name: t.name, Depth: len(t.rows), Capacity: cap(t.rows)})
+	}
+	return queues
+}
+
+// batchQueues returns the write queues of ClickHouse and of the PostgreSQL analytics store
+func (s *server) batchQueues() []BatchQueue {
+	queues := []BatchQueue{}
+	if s.clickhouse != nil {
+		queues = append(queues, s.clickhouse.batchQueues()...)
+	}
+	if pa, ok := s.analyticsStore.(*PostgresAnalytics); ok {
+		queues = append(queues, pa.batchQueues()...)
+	}
+	return queues
+}
+
+// handleDebugQueues handles GET /debug/queues: how many rows wait in each batch write queue of
+// ClickHouse, or of the PostgreSQL analytics store, and how many fit. A queue near its capacity
+// means writes fall behind ingestion; rows are dropped once it is full. Superadmin only.
+func (s *server) handleDebugQueues(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"queues": s.batchQueues()})
+}
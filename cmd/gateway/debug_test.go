@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func TestSanitizedConfig(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Database.DSN = "postgres://avika:hunter2@db:5432/avika?sslmode=disable"
+	cfg.ClickHouse.Password = "chpass"
+	cfg.Auth.JWTSecret = "jwt"
+	cfg.Auth.TokenExpiry = "24h"
+	cfg.PSK.Key = "abcd"
+
+	out, err := sanitizedConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := func(name string) map[string]interface{} {
+		return out[name].(map[string]interface{})
+	}
+	if got := section("database")["dsn"]; got != "postgres://***@db:5432/avika?sslmode=disable" {
+		t.Errorf("dsn = %v", got)
+	}
+	for _, c := range []struct {
+		section, key string
+		want         interface{}
+	}{
+		{"clickhouse", "password", redactedValue},
+		{"auth", "jwt_secret", redactedValue},
+		{"auth", "token_expiry", "24h"},
+		{"psk", "key", redactedValue},
+	} {
+		if got := section(c.section)[c.key]; got != c.want {
+			t.Errorf("%s.%s = %v, want %v", c.section, c.key, got, c.want)
+		}
+	}
+	// Empty secrets stay empty, so a dump shows what is not set
+	if got := section("auth")["password_hash"]; got != "" {
+		t.Errorf("empty password_hash = %v", got)
+	}
+}
+
+func TestRedactURLCredentials(t *testing.T) {
+	for in, want := range map[string]string{
+		"host=db user=avika password=secret dbname=avika":           "host=db user=avika password=*** dbname=avika",
+		"host=db password='s3 cr\\'et' sslmode=disable":             "host=db password=*** sslmode=disable",
+		"host=db PASSWORD = secret":                                 "host=db PASSWORD = ***",
+		"postgres://db/avika?user=avika&password=secret&sslmode=on": "postgres://db/avika?user=avika&password=***&sslmode=on",
+		"clickhouse://default:chpass@ch:9000/default":               "clickhouse://***@ch:9000/default",
+		"host=db user=avika":                                        "host=db user=avika",
+	} {
+		if got := redactURLCredentials(in); got != want {
+			t.Errorf("redactURLCredentials(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSecretConfigKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"password": true, "bind_password": true, "client_secret": true, "api_key": true,
+		"license_key": true, "secret_access_key": true, "auth_token": true, "key": true,
+		"token_expiry": false, "max_tokens": false, "key_file": false, "username": false,
+	} {
+		if got := secretConfigKey(key); got != want {
+			t.Errorf("secretConfigKey(%q) = %v", key, got)
+		}
+	}
+}
+
+func TestBatchQueues(t *testing.T) {
+	db := &ClickHouseDB{logChan: make(chan logBatchItem, 4), spanChan: make(chan spanBatchItem, 2)}
+	db.logChan <- logBatchItem{}
+	queues := db.batchQueues()
+	if len(queues) != 8 || queues[0] != (BatchQueue{Backend: "clickhouse", Name: "access_logs", Depth: 1, Capacity: 4}) {
+		t.Errorf("queues = %+v", queues)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
-	"log"
 	"strings"
 	"time"
 
@@ -238,6 +237,28 @@ func (s *server) checkGroupDrift(ctx context.Context, req *pb.DriftCheckRequest)
 		// Log but don't fail - the check succeeded
 		fmt.Printf("Warning: failed to store drift report: %v\n", err)
 	}
+	if driftedCount > 0 {
+		drifted := []string{}
+		for _, item := range items {
+			if item.Status == "drifted" {
+				drifted = append(drifted, item.AgentId)
+			}
+		}
+		s.publishEvent(SystemEvent{
+			Type:     "drift.detected",
+			Severity: "warning",
+			Message:  fmt.Sprintf("Drift check found %d of %d agents drifted", driftedCount, len(agents)),
+			Data: map[string]interface{}{
+				"report_id":     reportID,
+				"scope":         req.Scope,
+				"scope_id":      req.ScopeId,
+				"check_type":    checkType,
+				"total_agents":  len(agents),
+				"drifted_count": driftedCount,
+				"drifted":       drifted,
+			},
+		})
+	}
 
 	return &pb.DriftCheckResponse{
 		ReportId:        reportID,
@@ -345,7 +366,7 @@ func (s *server) GetDriftReport(ctx context.Context, req *pb.GetDriftReportReque
 		report.BaselineAgentID = &baselineAgentID.String
 	}
 	if err := json.Unmarshal(itemsJSON, &report.Items); err != nil {
-		log.Printf("Failed to unmarshal items for report %s: %v", report.ID, err)
+		jobsLog.Error().Msgf("Failed to unmarshal items for report %s: %v", report.ID, err)
 	}
 
 	return driftReportToProto(&report), nil
@@ -394,7 +415,7 @@ func (s *server) ListDriftReports(ctx context.Context, req *pb.ListDriftReportsR
 			report.BaselineAgentID = &baselineAgentID.String
 		}
 		if err := json.Unmarshal(itemsJSON, &report.Items); err != nil {
-			log.Printf("Failed to unmarshal items for report %s: %v", report.ID, err)
+			jobsLog.Error().Msgf("Failed to unmarshal items for report %s: %v", report.ID, err)
 		}
 
 		reports = append(reports, driftReportToProto(&report))
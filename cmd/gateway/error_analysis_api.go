@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
@@ -107,28 +107,28 @@ func (api *ErrorAnalysisAPI) HandleGetErrorAnalysis(w http.ResponseWriter, r *ht
 	// Get error summary
 	summary, err := api.getErrorSummary(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("Error getting summary: %v", err)
+		logging.Ctx(r.Context(), aiLog).Error().Msgf("Error getting summary: %v", err)
 	}
 	response.Summary = summary
 
 	// Get error patterns
 	patterns, err := api.getErrorPatterns(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("Error getting patterns: %v", err)
+		logging.Ctx(r.Context(), aiLog).Error().Msgf("Error getting patterns: %v", err)
 	}
 	response.Patterns = patterns
 
 	// Get error trend
 	trend, err := api.getErrorTrend(ctx, startTime, duration, agentID)
 	if err != nil {
-		log.Printf("Error getting trend: %v", err)
+		logging.Ctx(r.Context(), aiLog).Error().Msgf("Error getting trend: %v", err)
 	}
 	response.Trend = trend
 
 	// Get top error endpoints
 	endpoints, err := api.getTopErrorEndpoints(ctx, startTime, agentID)
 	if err != nil {
-		log.Printf("Error getting endpoints: %v", err)
+		logging.Ctx(r.Context(), aiLog).Error().Msgf("Error getting endpoints: %v", err)
 	}
 	response.TopErrorEndpoints = endpoints
 
@@ -139,7 +139,7 @@ func (api *ErrorAnalysisAPI) HandleGetErrorAnalysis(w http.ResponseWriter, r *ht
 		// Get AI analysis
 		aiAnalysis, err := api.recEngine.AnalyzeErrors(ctx, analysisCtx)
 		if err != nil {
-			log.Printf("AI analysis failed: %v", err)
+			logging.Ctx(r.Context(), aiLog).Error().Msgf("AI analysis failed: %v", err)
 		} else {
 			response.AIAnalysis = aiAnalysis
 		}
@@ -147,7 +147,7 @@ func (api *ErrorAnalysisAPI) HandleGetErrorAnalysis(w http.ResponseWriter, r *ht
 		// Generate recommendations
 		recs, err := api.recEngine.GenerateRecommendations(ctx, analysisCtx)
 		if err != nil {
-			log.Printf("Recommendation generation failed: %v", err)
+			logging.Ctx(r.Context(), aiLog).Error().Msgf("Recommendation generation failed: %v", err)
 		} else {
 			response.Recommendations = recs
 		}
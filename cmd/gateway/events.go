@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+const (
+	eventRetention      = 90 * 24 * time.Hour
+	eventStreamInterval = 2 * time.Second
+	defaultEventLimit   = 100
+	maxEventLimit       = 1000
+)
+
+// eventCategory groups event types for the activity feed filters
+func eventCategory(eventType string) string {
+	switch {
+	case eventType == "agent.online" || eventType == "agent.offline":
+		return "connection"
+	case eventType == "drift.detected":
+		return "config"
+	}
+	category, _, _ := strings.Cut(eventType, ".")
+	switch category {
+	case "agent", "command", "alert", "config", "task":
+		return category
+	}
+	return "system"
+}
+
+// publishEvent records an event in the activity feed and queues it for the webhooks subscribed
+// to its type. It returns at once; callers may hold session locks.
+func (s *server) publishEvent(ev SystemEvent) {
+	if s.db == nil {
+		return
+	}
+	if ev.Category == "" {
+		ev.Category = eventCategory(ev.Type)
+	}
+	if ev.Severity == "" {
+		ev.Severity = "info"
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	ev.GatewayID = s.instanceID
+	go func() {
+		if err := s.db.RecordEvent(&ev); err != nil {
+			ingestLog.Error().Msgf("Failed to record %s event: %v", ev.Type, err)
+		}
+		if !slices.Contains(webhookEvents, ev.Type) {
+			return
+		}
+		if _, err := s.queueWebhookEvent(ev.Type, ev.Data, ev.OccurredAt, ""); err != nil {
+			ingestLog.Error().Msgf("Webhooks: failed to queue %s event: %v", ev.Type, err)
+		}
+	}()
+}
+
+// publishCommandEvent records a command sent to an agent by the user in ctx, if any. failure is
+// the error reported for it, empty when it succeeded.
+func (s *server) publishCommandEvent(ctx context.Context, command, agentID, nginxInstanceID, failure string) {
+	ev := SystemEvent{
+		Type:    "command." + command,
+		AgentID: agentID,
+		Message: command + " sent to " + agentID,
+		Data:    map[string]interface{}{"nginx_instance_id": nginxInstanceID},
+	}
+	if user := middleware.GetUserFromContext(ctx); user != nil {
+		ev.Actor = user.Username
+	}
+	if failure != "" {
+		ev.Severity = "warning"
+		ev.Message = command + " failed on " + agentID
+		ev.Data["error"] = failure
+	}
+	s.publishEvent(ev)
+}
+
+// commandFailure describes why an agent command failed, or returns "" when it succeeded
+func commandFailure(err error, success bool, message string) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case !success && message == "":
+		return "command failed"
+	case !success:
+		return message
+	}
+	return ""
+}
+
+// startEventRetention prunes feed entries older than eventRetention on the leader gateway
+func (s *server) startEventRetention() {
+	if s.db == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			if n, err := s.db.PruneEvents(now.Add(-eventRetention)); err != nil {
+				ingestLog.Error().Msgf("Failed to prune events: %v", err)
+			} else if n > 0 {
+				ingestLog.Info().Msgf("Pruned %d events", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEventCategory(t *testing.T) {
+	cases := map[string]string{
+		"agent.online":     "connection",
+		"agent.offline":    "connection",
+		"agent.updated":    "agent",
+		"command.reload":   "command",
+		"alert.fired":      "alert",
+		"config.changed":   "config",
+		"drift.detected":   "config",
+		"task.failed":      "task",
+		"something.else":   "system",
+		"no-category-here": "system",
+	}
+	for eventType, want := range cases {
+		if got := eventCategory(eventType); got != want {
+			t.Errorf("eventCategory(%q) = %q, want %q", eventType, got, want)
+		}
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	if got := commandFailure(nil, true, ""); got != "" {
+		t.Errorf("success = %q", got)
+	}
+	if got := commandFailure(errors.New("agent offline"), false, ""); got != "agent offline" {
+		t.Errorf("transport error = %q", got)
+	}
+	if got := commandFailure(nil, false, "nginx: [emerg]"); got != "nginx: [emerg]" {
+		t.Errorf("reported error = %q", got)
+	}
+	if got := commandFailure(nil, false, ""); got != "command failed" {
+		t.Errorf("bare failure = %q", got)
+	}
+}
+
+func TestParseEventFilter(t *testing.T) {
+	q := url.Values{
+		"agent_id":  {"web-01"},
+		"type":      {"agent.offline, alert.fired,"},
+		"since":     {"2026-03-14T10:00:00Z"},
+		"before_id": {"42"},
+		"limit":     {"5000"},
+	}
+	f, err := parseEventFilter(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.AgentIDs, []string{"web-01"}) || !slices.Equal(f.Types, []string{"agent.offline", "alert.fired"}) {
+		t.Errorf("agents %v, types %v", f.AgentIDs, f.Types)
+	}
+	if !f.Since.Equal(time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)) || !f.Until.IsZero() {
+		t.Errorf("since %v, until %v", f.Since, f.Until)
+	}
+	if f.BeforeID != 42 || f.AfterID != 0 || f.Limit != maxEventLimit {
+		t.Errorf("before %d, after %d, limit %d", f.BeforeID, f.AfterID, f.Limit)
+	}
+
+	f, err = parseEventFilter(url.Values{})
+	if err != nil || f.AgentIDs != nil || f.Types != nil || f.Limit != defaultEventLimit {
+		t.Errorf("empty query: %+v, %v", f, err)
+	}
+
+	for _, bad := range []url.Values{
+		{"since": {"yesterday"}},
+		{"after_id": {"-1"}},
+		{"limit": {"0"}},
+	} {
+		if _, err := parseEventFilter(bad); err == nil {
+			t.Errorf("%v: expected an error", bad)
+		}
+	}
+}
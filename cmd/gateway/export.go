@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportDatasets are the tables available from /api/export/{dataset}, in sheet order for "all"
+var exportDatasets = []string{"agents", "top-endpoints", "status-distribution", "certificates"}
+
+// exportTable is one tabular dataset, written as a CSV file or an XLSX sheet
+type exportTable struct {
+	Name    string
+	Header  []string
+	Rows    [][]string
+	Numeric []int // column indexes written as numbers in XLSX
+}
+
+// reportScope reads the start/end/agent_ids query parameters shared by the report and export
+// endpoints and narrows agent_ids to what the caller may see. It writes the error response itself.
+func (srv *server) reportScope(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, []string, bool) {
+	startUnix, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	endUnix, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+	agentIDs := r.URL.Query()["agent_ids"]
+
+	if startUnix == 0 {
+		startUnix = time.Now().Add(-24 * time.Hour).Unix()
+	}
+	if endUnix == 0 {
+		endUnix = time.Now().Unix()
+	}
+
+	// RBAC: Filter agent IDs to only those the user can access
+	user := middleware.GetUserFromContext(r.Context())
+	if user != nil {
+		visibleAgents, err := srv.db.GetVisibleAgentIDs(user.Username)
+		if err != nil {
+			logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Export report RBAC error for user %s: %v", user.Username, err)
+			http.Error(w, "Failed to check access permissions", http.StatusInternalServerError)
+			return time.Time{}, time.Time{}, nil, false
+		}
+		// Build set of visible agents for fast lookup
+		visibleSet := make(map[string]bool)
+		for _, a := range visibleAgents {
+			visibleSet[a] = true
+		}
+		// Filter requested agent IDs
+		if len(agentIDs) > 0 {
+			filteredIDs := make([]string, 0, len(agentIDs))
+			for _, id := range agentIDs {
+				if visibleSet[id] {
+					filteredIDs = append(filteredIDs, id)
+				}
+			}
+			agentIDs = filteredIDs
+		} else {
+			// No specific agents requested, use all visible agents
+			agentIDs = visibleAgents
+		}
+	}
+
+	return time.Unix(startUnix, 0), time.Unix(endUnix, 0), agentIDs, true
+}
+
+// GET /api/export/{dataset}?format=csv|xlsx&start=&end=&agent_ids=
+// dataset is one of exportDatasets, or "all" for a multi-sheet workbook (xlsx only).
+func (srv *server) handleExport(w http.ResponseWriter, r *http.Request) {
+	dataset := r.PathValue("dataset")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format == "excel" {
+		format = "xlsx"
+	}
+	if format != "csv" && format != "xlsx" {
+		http.Error(w, "format must be csv or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	datasets := []string{dataset}
+	if dataset == "all" {
+		if format == "csv" {
+			http.Error(w, "dataset \"all\" is only available as xlsx", http.StatusBadRequest)
+			return
+		}
+		datasets = exportDatasets
+	} else if !stringInSlice(exportDatasets, dataset) {
+		http.Error(w, fmt.Sprintf("unknown dataset %q", dataset), http.StatusNotFound)
+		return
+	}
+
+	start, end, agentIDs, ok := srv.reportScope(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	tables := make([]exportTable, 0, len(datasets))
+	for _, name := range datasets {
+		table, err := srv.buildExportTable(ctx, name, start, end, agentIDs, limit)
+		if err != nil {
+			logging.Ctx(r.Context(), analyticsLog).Error().Msgf("Export %s failed: %v", name, err)
+			status := http.StatusInternalServerError
+			if err == errClickHouseUnavailable {
+				status = http.StatusServiceUnavailable
+			}
+			http.Error(w, fmt.Sprintf("Failed to export %s: %v", name, err), status)
+			return
+		}
+		tables = append(tables, table)
+	}
+
+	filename := fmt.Sprintf("avika-%s-%d.%s", dataset, time.Now().Unix(), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+		if err := writeExportCSV(w, tables[0]); err != nil {
+			logging.Ctx(r.Context(), analyticsLog).Error().Msgf("handleExport: failed to write CSV response: %v", err)
+		}
+		return
+	}
+
+	data, err := generateExportXLSX(tables, start, end)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to generate Excel: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if _, err := w.Write(data); err != nil {
+		logging.Ctx(r.Context(), analyticsLog).Error().Msgf("handleExport: failed to write XLSX response: %v", err)
+	}
+}
+
+var errClickHouseUnavailable = fmt.Errorf("ClickHouse connection not available")
+
+func (srv *server) buildExportTable(ctx context.Context, dataset string, start, end time.Time, agentIDs []string, limit int) (exportTable, error) {
+	switch dataset {
+	case "agents":
+		resp, err := srv.ListAgents(ctx, &pb.ListAgentsRequest{})
+		if err != nil {
+			return exportTable{}, err
+		}
+		return agentInventoryTable(filterAgentInfos(resp.Agents, agentIDs)), nil
+
+	case "top-endpoints":
+		if srv.clickhouse == nil {
+			return exportTable{}, errClickHouseUnavailable
+		}
+		stats, err := srv.clickhouse.GetTopEndpoints(ctx, start, end, agentIDs, limit)
+		if err != nil {
+			return exportTable{}, err
+		}
+		return topEndpointsTable(stats), nil
+
+	case "status-distribution":
+		if srv.clickhouse == nil {
+			return exportTable{}, errClickHouseUnavailable
+		}
+		counts, err := srv.clickhouse.GetStatusCodeDistribution(ctx, start, end, agentIDs)
+		if err != nil {
+			return exportTable{}, err
+		}
+		return statusDistributionTable(counts), nil
+
+	case "certificates":
+		certs, err := srv.db.ListFleetCertificates(ctx, agentIDs, 0)
+		if err != nil {
+			return exportTable{}, err
+		}
+		return certificateExpiryTable(certs), nil
+	}
+	return exportTable{}, fmt.Errorf("unknown dataset %q", dataset)
+}
+
+// filterAgentInfos keeps the agents in agentIDs; an empty filter keeps all of them.
+func filterAgentInfos(agents []*pb.AgentInfo, agentIDs []string) []*pb.AgentInfo {
+	if len(agentIDs) == 0 {
+		return agents
+	}
+	allowed := make(map[string]bool, len(agentIDs))
+	for _, id := range agentIDs {
+		allowed[id] = true
+	}
+	filtered := make([]*pb.AgentInfo, 0, len(agents))
+	for _, a := range agents {
+		if allowed[a.AgentId] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+func agentInventoryTable(agents []*pb.AgentInfo) exportTable {
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Hostname < agents[j].Hostname })
+	t := exportTable{
+		Name:    "Agents",
+		Header:  []string{"Agent ID", "Hostname", "IP", "Status", "NGINX Version", "Agent Version", "Instances", "Uptime", "Last Seen", "Pod", "PSK Authenticated"},
+		Numeric: []int{6},
+	}
+	for _, a := range agents {
+		t.Rows = append(t.Rows, []string{
+			a.AgentId, a.Hostname, a.Ip, a.Status, a.Version, a.AgentVersion,
+			strconv.Itoa(int(a.InstancesCount)), a.Uptime, formatExportTime(a.LastSeen),
+			strconv.FormatBool(a.IsPod), strconv.FormatBool(a.PskAuthenticated),
+		})
+	}
+	return t
+}
+
+func topEndpointsTable(stats []*pb.EndpointStat) exportTable {
+	t := exportTable{
+		Name:    "Top Endpoints",
+		Header:  []string{"URI", "Requests", "Errors", "Error Rate (%)", "P95 (ms)", "Traffic (bytes)"},
+		Numeric: []int{1, 2, 3, 4, 5},
+	}
+	for _, s := range stats {
+		errRate := 0.0
+		if s.Requests > 0 {
+			errRate = float64(s.Errors) / float64(s.Requests) * 100
+		}
+		t.Rows = append(t.Rows, []string{
+			s.Uri, strconv.FormatInt(s.Requests, 10), strconv.FormatInt(s.Errors, 10),
+			fmt.Sprintf("%.2f", errRate), fmt.Sprintf("%.2f", s.P95), s.Traffic,
+		})
+	}
+	return t
+}
+
+func statusDistributionTable(counts []*pb.StatusCount) exportTable {
+	t := exportTable{
+		Name:    "Status Distribution",
+		Header:  []string{"Status", "Requests", "Share (%)"},
+		Numeric: []int{1, 2},
+	}
+	var total int64
+	for _, c := range counts {
+		total += c.Count
+	}
+	for _, c := range counts {
+		share := 0.0
+		if total > 0 {
+			share = float64(c.Count) / float64(total) * 100
+		}
+		t.Rows = append(t.Rows, []string{c.Code, strconv.FormatInt(c.Count, 10), fmt.Sprintf("%.2f", share)})
+	}
+	return t
+}
+
+func certificateExpiryTable(certs []AgentCertificate) exportTable {
+	t := exportTable{
+		Name:    "Certificates",
+		Header:  []string{"Domain", "Agent ID", "Hostname", "Certificate Path", "Issuer", "SANs", "Expires", "Days Until Expiry"},
+		Numeric: []int{7},
+	}
+	for _, c := range certs {
+		t.Rows = append(t.Rows, []string{
+			c.Domain, c.AgentID, c.Hostname, c.CertPath, c.Issuer, strings.Join(c.SANDomains, " "),
+			c.ExpiryDate.UTC().Format("2006-01-02"), strconv.Itoa(c.DaysUntilExpiry),
+		})
+	}
+	return t
+}
+
+func formatExportTime(unix int64) string {
+	if unix <= 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+func writeExportCSV(w io.Writer, t exportTable) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// generateExportXLSX writes one sheet per table. Numeric columns are stored as numbers so they
+// sort and sum in Excel.
+func generateExportXLSX(tables []exportTable, start, end time.Time) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	header, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	for i, t := range tables {
+		if i == 0 {
+			_ = f.SetSheetName(f.GetSheetName(0), t.Name)
+		} else if _, err := f.NewSheet(t.Name); err != nil {
+			return nil, err
+		}
+		for col, h := range t.Header {
+			cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+			_ = f.SetCellValue(t.Name, cell, h)
+			_ = f.SetCellStyle(t.Name, cell, cell, header)
+		}
+		numeric := make(map[int]bool, len(t.Numeric))
+		for _, col := range t.Numeric {
+			numeric[col] = true
+		}
+		for row, values := range t.Rows {
+			for col, v := range values {
+				cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
+				if n, err := strconv.ParseFloat(v, 64); err == nil && numeric[col] {
+					_ = f.SetCellValue(t.Name, cell, n)
+				} else {
+					_ = f.SetCellValue(t.Name, cell, v)
+				}
+			}
+		}
+		_ = f.SetPanes(t.Name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	}
+
+	props := &excelize.DocProperties{
+		Title:       "Avika Export",
+		Description: fmt.Sprintf("Period: %s — %s", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04")),
+		Created:     time.Now().UTC().Format(time.RFC3339),
+	}
+	_ = f.SetDocProps(props)
+	f.SetActiveSheet(0)
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
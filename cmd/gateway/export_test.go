@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteExportCSV(t *testing.T) {
+	table := statusDistributionTable([]*pb.StatusCount{{Code: "200", Count: 3}, {Code: "404", Count: 1}})
+
+	var buf bytes.Buffer
+	if err := writeExportCSV(&buf, table); err != nil {
+		t.Fatalf("writeExportCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"Status", "Requests", "Share (%)"},
+		{"200", "3", "75.00"},
+		{"404", "1", "25.00"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV = %v, want %v", records, want)
+	}
+}
+
+func TestFilterAgentInfos(t *testing.T) {
+	agents := []*pb.AgentInfo{{AgentId: "a"}, {AgentId: "b"}, {AgentId: "c"}}
+	if got := filterAgentInfos(agents, nil); len(got) != 3 {
+		t.Errorf("empty filter kept %d agents, want 3", len(got))
+	}
+	got := filterAgentInfos(agents, []string{"c", "a", "missing"})
+	if len(got) != 2 || got[0].AgentId != "a" || got[1].AgentId != "c" {
+		t.Errorf("filterAgentInfos = %v", got)
+	}
+}
+
+func TestGenerateExportXLSX(t *testing.T) {
+	tables := []exportTable{
+		agentInventoryTable([]*pb.AgentInfo{{AgentId: "agent-1", Hostname: "web-1", Version: "1.25", InstancesCount: 2}}),
+		certificateExpiryTable([]AgentCertificate{{Domain: "example.com", AgentID: "agent-1", ExpiryDate: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), DaysUntilExpiry: 12}}),
+	}
+	data, err := generateExportXLSX(tables, time.Now().Add(-time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("generateExportXLSX: %v", err)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid xlsx: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); !reflect.DeepEqual(sheets, []string{"Agents", "Certificates"}) {
+		t.Fatalf("sheets = %v", sheets)
+	}
+	if v, _ := f.GetCellValue("Agents", "B2"); v != "web-1" {
+		t.Errorf("Agents!B2 = %q, want web-1", v)
+	}
+	// NGINX version stays text; the instance count is numeric
+	if typ, _ := f.GetCellType("Agents", "E2"); typ == excelize.CellTypeNumber {
+		t.Error("version column should not be stored as a number")
+	}
+	if v, _ := f.GetCellValue("Certificates", "G2"); v != "2030-01-02" {
+		t.Errorf("Certificates!G2 = %q, want 2030-01-02", v)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/mem"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// With several gateways, a gateway reaches an agent connected to another one through that
+// gateway. Calls to the agent's own services are renamed under gatewayForwardPrefix and proxied
+// as is by the owning gateway; commands sent over the agent's stream use gatewayCommandMethod.
+const (
+	gatewayForwardPrefix = "/avika.gateway.forward"
+	gatewayCommandMethod = gatewayForwardPrefix + "/command"
+	// forwardAgentKey is the metadata naming the agent of a forwarded call
+	forwardAgentKey = "x-avika-forward-agent"
+)
+
+// rawFrame is a message proxied without decoding it
+type rawFrame struct {
+	data []byte
+}
+
+// forwardCodec passes rawFrames through and leaves every other message to the proto codec
+type forwardCodec struct {
+	proto encoding.CodecV2
+}
+
+func newForwardCodec() forwardCodec {
+	return forwardCodec{proto: encoding.GetCodecV2("proto")}
+}
+
+func (c forwardCodec) Marshal(v any) (mem.BufferSlice, error) {
+	if f, ok := v.(*rawFrame); ok {
+		return mem.BufferSlice{mem.SliceBuffer(f.data)}, nil
+	}
+	return c.proto.Marshal(v)
+}
+
+func (c forwardCodec) Unmarshal(data mem.BufferSlice, v any) error {
+	if f, ok := v.(*rawFrame); ok {
+		f.data = data.Materialize()
+		return nil
+	}
+	return c.proto.Unmarshal(data, v)
+}
+
+func (c forwardCodec) Name() string {
+	return c.proto.Name()
+}
+
+// advertiseAddress is the gRPC address other gateways reach this one on
+func (s *server) advertiseAddress() string {
+	if s.config.HA.AdvertiseAddress != "" {
+		return s.config.HA.AdvertiseAddress
+	}
+	host := os.Getenv("POD_IP")
+	if host == "" {
+		host = s.instanceID
+	}
+	_, port, _ := net.SplitHostPort(s.config.GetGRPCAddress())
+	return net.JoinHostPort(host, port)
+}
+
+// connectedHere reports whether this gateway holds the stream of an agent
+func (s *server) connectedHere(agentID string) bool {
+	val, ok := s.sessions.Load(agentID)
+	if !ok {
+		return false
+	}
+	session := val.(*AgentSession)
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.status == "online" && session.stream != nil
+}
+
+// peerGatewayOf returns the address of the live gateway holding the stream of an agent that is
+// not connected here; false without HA or when no other gateway has it
+func (s *server) peerGatewayOf(agentID string) (string, bool) {
+	if s.leases == nil || s.connectedHere(agentID) {
+		return "", false
+	}
+	owner, address, live, err := s.db.AgentGateway(agentID, s.leases.ttl)
+	if err != nil {
+		ingestLog.Error().Msgf("Cannot look up the gateway of agent %s: %v", agentID, err)
+		return "", false
+	}
+	if !live || owner == s.instanceID || address == "" {
+		return "", false
+	}
+	return address, true
+}
+
+// gatewayTransportCredentials secures connections from the gateway to agents and other gateways
+// with the gateway's own certificate when TLS is enabled
+func (s *server) gatewayTransportCredentials() grpc.DialOption {
+	if s.config.Security.EnableTLS && s.config.Security.TLSCertFile != "" {
+		tlsConfig, err := loadServerTLSConfig(s.config)
+		if err == nil {
+			return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		}
+		ingestLog.Error().Msgf("Failed to load TLS config for dialing: %v. Falling back to insecure.", err)
+	}
+	return grpc.WithTransportCredentials(insecure.NewCredentials())
+}
+
+// dialPeerGateway connects to another gateway. With agentID set, calls on the connection go to
+// that agent through the gateway instead of to the gateway's own services.
+func (s *server) dialPeerGateway(address, agentID string) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{s.gatewayTransportCredentials()}
+	if agentID != "" {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				ctx = metadata.AppendToOutgoingContext(ctx, forwardAgentKey, agentID)
+				return invoker(ctx, gatewayForwardPrefix+method, req, reply, cc, opts...)
+			}),
+			grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				ctx = metadata.AppendToOutgoingContext(ctx, forwardAgentKey, agentID)
+				return streamer(ctx, desc, cc, gatewayForwardPrefix+method, opts...)
+			}),
+		)
+	}
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gateway %s: %v", address, err)
+	}
+	return conn, nil
+}
+
+// forwardedAgentOf returns the agent a forwarded call is for
+func forwardedAgentOf(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(forwardAgentKey); len(ids) > 0 && ids[0] != "" {
+		return ids[0], nil
+	}
+	return "", status.Error(codes.InvalidArgument, "forwarded call without agent")
+}
+
+// handleForwardedCall serves the calls other gateways forward for agents connected here; it is
+// the gRPC server's handler for unknown services
+func (s *server) handleForwardedCall(_ any, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	method, ok := strings.CutPrefix(fullMethod, gatewayForwardPrefix)
+	if !ok || s.leases == nil {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+	agentID, err := forwardedAgentOf(stream.Context())
+	if err != nil {
+		return err
+	}
+	if fullMethod == gatewayCommandMethod {
+		return s.handleForwardedCommand(agentID, stream)
+	}
+
+	var conn *grpc.ClientConn
+	if strings.HasPrefix(method, "/"+pb.AgentConfigService_ServiceDesc.ServiceName+"/") {
+		_, conn, err = s.getAgentConfigClient(agentID)
+	} else {
+		_, conn, err = s.getAgentClient(agentID)
+	}
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer conn.Close()
+	return proxyStream(stream, conn, method)
+}
+
+// proxyStream relays a call, unary or streaming, to method on conn without decoding it
+func proxyStream(stream grpc.ServerStream, conn *grpc.ClientConn, method string) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	upstream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method, grpc.ForceCodecV2(newForwardCodec()))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			f := &rawFrame{}
+			if err := stream.RecvMsg(f); err != nil {
+				if err == io.EOF {
+					upstream.CloseSend()
+				} else {
+					cancel()
+				}
+				return
+			}
+			if err := upstream.SendMsg(f); err != nil {
+				return
+			}
+		}
+	}()
+
+	if header, err := upstream.Header(); err == nil {
+		_ = stream.SendHeader(header)
+	}
+	for {
+		f := &rawFrame{}
+		if err := upstream.RecvMsg(f); err != nil {
+			stream.SetTrailer(upstream.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := stream.SendMsg(f); err != nil {
+			return err
+		}
+	}
+}
+
+// handleForwardedCommand sends a command another gateway forwarded over the agent's stream and
+// returns the agent's ack
+func (s *server) handleForwardedCommand(agentID string, stream grpc.ServerStream) error {
+	cmd := &pb.ServerCommand{}
+	if err := stream.RecvMsg(cmd); err != nil {
+		return err
+	}
+	res, err := s.sendAgentCommand(agentID, cmd, agentCommandTimeout)
+	switch {
+	case err == errAgentOffline:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case err == errAgentNoAck:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case err != nil:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return stream.SendMsg(res)
+}
+
+// forwardAgentCommand sends a command to an agent connected to the gateway at address
+func (s *server) forwardAgentCommand(address, agentID string, cmd *pb.ServerCommand, timeout time.Duration) (*pb.CommandResult, error) {
+	conn, err := s.dialPeerGateway(address, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errAgentCommandSent, err)
+	}
+	defer conn.Close()
+
+	// Leave the owning gateway time to report a missing ack
+	ctx, cancel := context.WithTimeout(metadata.AppendToOutgoingContext(context.Background(), forwardAgentKey, agentID), timeout+5*time.Second)
+	defer cancel()
+	res := &pb.CommandResult{}
+	err = conn.Invoke(ctx, gatewayCommandMethod, cmd, res)
+	switch status.Code(err) {
+	case codes.OK:
+		return res, nil
+	case codes.FailedPrecondition:
+		return nil, errAgentOffline
+	case codes.DeadlineExceeded:
+		return nil, errAgentNoAck
+	default:
+		return nil, fmt.Errorf("%w: %v", errAgentCommandSent, err)
+	}
+}
+
+// forwardLogs streams the logs of an agent connected to the gateway at address
+func (s *server) forwardLogs(address string, req *pb.LogRequest, stream pb.AgentService_GetLogsServer) error {
+	conn, err := s.dialPeerGateway(address, "")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logs, err := pb.NewAgentServiceClient(conn).GetLogs(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for {
+		entry, err := logs.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(entry); err != nil {
+			return err
+		}
+	}
+}
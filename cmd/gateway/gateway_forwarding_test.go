@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// fakeAgent answers GetConfig and streams two log entries
+type fakeAgent struct {
+	pb.UnimplementedAgentServiceServer
+}
+
+func (fakeAgent) GetConfig(ctx context.Context, req *pb.ConfigRequest) (*pb.ConfigResponse, error) {
+	return &pb.ConfigResponse{InstanceId: req.InstanceId, Error: "from " + req.ConfigPath}, nil
+}
+
+func (fakeAgent) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsServer) error {
+	for _, uri := range []string{"/a", "/b"} {
+		if err := stream.Send(&pb.LogEntry{RequestUri: uri}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func serveGRPC(t *testing.T, s *grpc.Server) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestForwardedCallsReachTheAgent(t *testing.T) {
+	agent := grpc.NewServer()
+	pb.RegisterAgentServiceServer(agent, fakeAgent{})
+	agentConn, err := grpc.NewClient(serveGRPC(t, agent), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agentConn.Close()
+
+	// The owning gateway: proxies forwarded calls for agent a1 to the agent
+	owner := grpc.NewServer(
+		grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			fullMethod, _ := grpc.MethodFromServerStream(stream)
+			if agentID, err := forwardedAgentOf(stream.Context()); err != nil || agentID != "a1" {
+				t.Errorf("forwarded agent = %q, %v", agentID, err)
+			}
+			return proxyStream(stream, agentConn, strings.TrimPrefix(fullMethod, gatewayForwardPrefix))
+		}),
+		grpc.ForceServerCodecV2(newForwardCodec()),
+	)
+	ownerAddr := serveGRPC(t, owner)
+
+	s := &server{config: &config.Config{}}
+	conn, err := s.dialPeerGateway(ownerAddr, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewAgentServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.GetConfig(ctx, &pb.ConfigRequest{InstanceId: "a1", ConfigPath: "nginx.conf"})
+	if err != nil || resp.InstanceId != "a1" || resp.Error != "from nginx.conf" {
+		t.Fatalf("GetConfig = %v, %v", resp, err)
+	}
+
+	logs, err := client.GetLogs(ctx, &pb.LogRequest{InstanceId: "a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uris []string
+	for {
+		entry, err := logs.Recv()
+		if err != nil {
+			break
+		}
+		uris = append(uris, entry.RequestUri)
+	}
+	if strings.Join(uris, ",") != "/a,/b" {
+		t.Errorf("streamed logs = %v", uris)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// gatewayLeaderLease is held by the gateway running the singleton background jobs
+const gatewayLeaderLease = "leader"
+
+// syntheticLease is held by the gateway running the synthetic checks of a region
+func syntheticLease(region string) string {
+	return "synthetic:" + region
+}
+
+// gatewayLeases keeps the leases of this gateway in Postgres, renewed every third of their TTL so
+// another gateway takes over within a TTL of this one stopping
+type gatewayLeases struct {
+	db         *DB
+	instanceID string
+	address    string // gRPC address other gateways forward commands to
+	ttl        time.Duration
+	names      []string
+
+	mu   sync.RWMutex
+	held map[string]time.Time // lease name -> when it expires unless renewed
+}
+
+func newGatewayLeases(db *DB, instanceID, address string, ttl time.Duration, names ...string) *gatewayLeases {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &gatewayLeases{db: db, instanceID: instanceID, address: address, ttl: ttl, names: names, held: make(map[string]time.Time)}
+}
+
+// holds reports whether this gateway holds the lease name. Without HA (nil leases) a gateway
+// holds every lease.
+func (l *gatewayLeases) holds(name string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return time.Now().Before(l.held[name])
+}
+
+// renew marks this gateway alive and takes or renews each of its leases
+func (l *gatewayLeases) renew() {
+	if err := l.db.TouchGatewayInstance(l.instanceID, l.address); err != nil {
+		gatewayLog.Warn().Err(err).Msg("HA: cannot record gateway heartbeat")
+	}
+	for _, name := range l.names {
+		// Count the TTL from before the query so this gateway never outlives what Postgres recorded
+		start := time.Now()
+		ok, err := l.db.AcquireGatewayLease(name, l.instanceID, l.ttl)
+		if err != nil {
+			gatewayLog.Warn().Err(err).Str("lease", name).Msg("HA: cannot renew lease")
+		}
+		l.mu.Lock()
+		had := start.Before(l.held[name])
+		if ok {
+			l.held[name] = start.Add(l.ttl)
+		} else if err == nil {
+			delete(l.held, name)
+		}
+		l.mu.Unlock()
+		switch {
+		case ok && !had:
+			gatewayLog.Info().Str("lease", name).Str("instance", l.instanceID).Msg("HA: lease acquired")
+		case !ok && err == nil && had:
+			gatewayLog.Warn().Str("lease", name).Str("instance", l.instanceID).Msg("HA: lease lost to another gateway")
+		}
+	}
+}
+
+// start takes the leases before the background jobs start, then keeps renewing them
+func (l *gatewayLeases) start() {
+	l.renew()
+	go func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for range ticker.C {
+			l.renew()
+		}
+	}()
+}
+
+// release hands the leases over to the other gateways on shutdown
+func (l *gatewayLeases) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.held = make(map[string]time.Time)
+	l.mu.Unlock()
+	if err := l.db.ReleaseGateway(l.instanceID); err != nil {
+		gatewayLog.Warn().Err(err).Msg("HA: cannot release leases")
+	}
+}
+
+// isLeader reports whether this gateway runs the singleton background jobs: pruning, alerting,
+// availability, retention, archiving, ban expiry and pod GC
+func (s *server) isLeader() bool {
+	return s.leases.holds(gatewayLeaderLease)
+}
+
+// startGatewayLeases joins the other gateways when ha.enabled
+func (s *server) startGatewayLeases() {
+	if s.config == nil || !s.config.HA.Enabled || s.db == nil {
+		return
+	}
+	names := []string{gatewayLeaderLease}
+	if s.synthetic != nil {
+		names = append(names, syntheticLease(s.synthetic.region))
+	}
+	s.leases = newGatewayLeases(s.db, s.instanceID, s.advertiseAddress(), s.config.HA.LeaseTTL, names...)
+	s.leases.start()
+	gatewayLog.Info().Str("instance", s.instanceID).Str("address", s.leases.address).Bool("leader", s.isLeader()).Msg("HA enabled")
+}
+
+// connectedAgentIDs returns the online agents whose stream this gateway holds
+func (s *server) connectedAgentIDs() []string {
+	var ids []string
+	s.sessions.Range(func(key, value interface{}) bool {
+		session := value.(*AgentSession)
+		session.mu.Lock()
+		connected := session.status == "online" && session.stream != nil
+		session.mu.Unlock()
+		if connected {
+			ids = append(ids, key.(string))
+		}
+		return true
+	})
+	return ids
+}
+
+// mergeAgentLists merges the agents of this gateway with those recorded by every gateway. An
+// agent connected here is listed as seen here, one owned by another live gateway as that gateway
+// recorded it; otherwise the local session wins as it carries more (labels, build info). Local
+// sessions missing from Postgres were pruned or removed by another gateway unless connected here.
+func mergeAgentLists(instanceID string, local map[string]*pb.AgentInfo, connected map[string]bool, shared []SharedAgent) []*pb.AgentInfo {
+	agents := make([]*pb.AgentInfo, 0, len(shared))
+	listed := make(map[string]bool, len(shared))
+	for _, a := range shared {
+		id := a.Info.AgentId
+		listed[id] = true
+		info, ok := local[id]
+		switch {
+		case connected[id]:
+		case a.OwnerLive && a.Info.GatewayId != instanceID, !ok:
+			info = a.Info
+		}
+		agents = append(agents, info)
+	}
+	for id, info := range local {
+		if !listed[id] && connected[id] {
+			agents = append(agents, info)
+		}
+	}
+	return agents
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestMergeAgentLists(t *testing.T) {
+	local := map[string]*pb.AgentInfo{
+		"here":    {AgentId: "here", Status: "online", GatewayId: "gw-a", Labels: map[string]string{"env": "prod"}},
+		"moved":   {AgentId: "moved", Status: "online", GatewayId: "gw-a"},
+		"idle":    {AgentId: "idle", Status: "offline", GatewayId: "gw-a", Labels: map[string]string{"env": "dev"}},
+		"pruned":  {AgentId: "pruned", Status: "offline", GatewayId: "gw-a"},
+		"unsaved": {AgentId: "unsaved", Status: "online", GatewayId: "gw-a"},
+	}
+	connected := map[string]bool{"here": true, "unsaved": true}
+	shared := []SharedAgent{
+		{Info: &pb.AgentInfo{AgentId: "here", Status: "online", GatewayId: "gw-a"}, OwnerLive: true},
+		{Info: &pb.AgentInfo{AgentId: "moved", Status: "online", GatewayId: "gw-b"}, OwnerLive: true},
+		{Info: &pb.AgentInfo{AgentId: "idle", Status: "offline", GatewayId: "gw-c"}, OwnerLive: false},
+		{Info: &pb.AgentInfo{AgentId: "remote", Status: "online", GatewayId: "gw-b"}, OwnerLive: true},
+	}
+
+	got := make(map[string]*pb.AgentInfo)
+	for _, a := range mergeAgentLists("gw-a", local, connected, shared) {
+		got[a.AgentId] = a
+	}
+	if len(got) != 5 {
+		t.Fatalf("merged %d agents, want 5: %v", len(got), got)
+	}
+	if got["here"] != local["here"] || got["unsaved"] != local["unsaved"] {
+		t.Error("agents connected here should be listed as seen here")
+	}
+	if got["moved"].GatewayId != "gw-b" || got["remote"].GatewayId != "gw-b" {
+		t.Error("agents owned by another live gateway should be listed as it recorded them")
+	}
+	if got["idle"] != local["idle"] {
+		t.Error("the local session should win for agents without a live owner")
+	}
+	if _, ok := got["pruned"]; ok {
+		t.Error("sessions removed from the database should not be listed")
+	}
+}
+
+func TestGatewayLeasesHolds(t *testing.T) {
+	var none *gatewayLeases
+	if !none.holds(gatewayLeaderLease) {
+		t.Error("a gateway without HA holds every lease")
+	}
+
+	l := newGatewayLeases(nil, "gw-a", "10.0.0.1:5020", time.Minute)
+	l.held[gatewayLeaderLease] = time.Now().Add(time.Minute)
+	l.held[syntheticLease("eu")] = time.Now().Add(-time.Second)
+	if !l.holds(gatewayLeaderLease) {
+		t.Error("unexpired lease not held")
+	}
+	if l.holds(syntheticLease("eu")) || l.holds(syntheticLease("us")) {
+		t.Error("expired or never acquired leases should not be held")
+	}
+}
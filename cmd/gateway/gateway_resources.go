@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/<pid>/stat (100 on Linux)
+const clockTicks = 100
+
+// resourceSampler reads the gateway's CPU usage and the host's load averages from /proc for
+// gateway_metrics. Without /proc (not Linux) both read as 0.
+type resourceSampler struct {
+	procRoot string
+	lastCPU  uint64 // utime+stime ticks of the previous sample
+	lastTime time.Time
+}
+
+func newResourceSampler() *resourceSampler {
+	return &resourceSampler{procRoot: "/proc"}
+}
+
+// resourceSample is the gateway's CPU and the host's load at one sample
+type resourceSample struct {
+	// CPUPercent is the CPU time the gateway process used since the previous sample, in percent
+	// of one core (above 100 when it uses several); 0 on the first sample
+	CPUPercent float32
+	Load1      float32
+	Load5      float32
+	Load15     float32
+}
+
+// sample reads the current usage
+func (r *resourceSampler) sample(now time.Time) resourceSample {
+	var s resourceSample
+	if ticks, ok := r.processCPU(); ok {
+		elapsed := now.Sub(r.lastTime).Seconds()
+		if !r.lastTime.IsZero() && elapsed > 0 && ticks >= r.lastCPU {
+			s.CPUPercent = float32(float64(ticks-r.lastCPU) / clockTicks / elapsed * 100)
+		}
+		r.lastCPU, r.lastTime = ticks, now
+	}
+	s.Load1, s.Load5, s.Load15 = r.loadAverage()
+	return s
+}
+
+// processCPU returns the utime+stime ticks of the gateway from /proc/self/stat. The command
+// name is in parentheses and may contain spaces, so fields are counted from the last ")".
+func (r *resourceSampler) processCPU() (uint64, bool) {
+	data, err := os.ReadFile(filepath.Join(r.procRoot, "self", "stat"))
+	if err != nil {
+		return 0, false
+	}
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, false
+	}
+	// fields[0] is field 3 (state) of proc(5); utime and stime are fields 14 and 15
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return utime + stime, true
+}
+
+// loadAverage returns the 1, 5 and 15 minute load averages from /proc/loadavg, zeros when
+// unknown
+func (r *resourceSampler) loadAverage() (load1, load5, load15 float32) {
+	data, err := os.ReadFile(filepath.Join(r.procRoot, "loadavg"))
+	if err != nil {
+		return 0, 0, 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return 0, 0, 0
+	}
+	loads := make([]float32, 3)
+	for i := range loads {
+		v, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return 0, 0, 0
+		}
+		loads[i] = float32(v)
+	}
+	return loads[0], loads[1], loads[2]
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResourceSampler(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeStat := func(utime, stime string) {
+		t.Helper()
+		// The command name may contain spaces and parentheses
+		stat := "4242 (gate way) S 1 4242 4242 0 -1 4194560 100 0 0 0 " + utime + " " + stime + " 0 0 20 0 12 0 500 0 0\n"
+		if err := os.WriteFile(filepath.Join(root, "self", "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "loadavg"), []byte("0.52 1.25 2.00 3/512 4242\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &resourceSampler{procRoot: root}
+	start := time.Now()
+	writeStat("1000", "500")
+	first := r.sample(start)
+	if first.CPUPercent != 0 {
+		t.Errorf("first sample CPU = %v, want 0", first.CPUPercent)
+	}
+	if first.Load1 != 0.52 || first.Load5 != 1.25 || first.Load15 != 2 {
+		t.Errorf("load = %v %v %v", first.Load1, first.Load5, first.Load15)
+	}
+
+	// 150 ticks = 1.5s of CPU over 10s
+	writeStat("1100", "550")
+	if got := r.sample(start.Add(10 * time.Second)).CPUPercent; got != 15 {
+		t.Errorf("CPU = %v, want 15", got)
+	}
+	// 2 cores busy for 10s
+	writeStat("2100", "1550")
+	if got := r.sample(start.Add(20 * time.Second)).CPUPercent; got != 200 {
+		t.Errorf("CPU = %v, want 200", got)
+	}
+}
+
+func TestResourceSamplerWithoutProc(t *testing.T) {
+	r := &resourceSampler{procRoot: filepath.Join(t.TempDir(), "missing")}
+	if s := r.sample(time.Now()); s != (resourceSample{}) {
+		t.Errorf("sample without /proc = %+v, want zeros", s)
+	}
+}
@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBotNetworksMatch(t *testing.T) {
+	b := NewBotNetworks()
+
+	if m, ok := b.Match("66.249.66.1"); !ok || m.Name != "Googlebot" || m.Category != BotCategorySearchEngine {
+		t.Errorf("Googlebot range: %+v, %v", m, ok)
+	}
+	if _, ok := b.Match("8.8.8.8"); ok {
+		t.Error("8.8.8.8 should not match a crawler range")
+	}
+	if _, ok := b.Match("not-an-ip"); ok {
+		t.Error("invalid IP should not match")
+	}
+}
+
+func TestBotNetworksLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	content := "# internal scanners\n\n10.20.0.0/16 monitoring Internal Probe\n192.0.2.7\n2001:db8::/32 seo\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBotNetworks()
+	if err := b.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if m, ok := b.Match("10.20.3.4"); !ok || m.Category != BotCategoryMonitoring || m.Name != "Internal Probe" {
+		t.Errorf("10.20.3.4: %+v, %v", m, ok)
+	}
+	if m, ok := b.Match("192.0.2.7"); !ok || m.Category != BotCategoryOther {
+		t.Errorf("single address should default to other: %+v, %v", m, ok)
+	}
+	if m, ok := b.Match("2001:db8::1"); !ok || m.Category != BotCategorySEO {
+		t.Errorf("IPv6 range: %+v, %v", m, ok)
+	}
+
+	if err := os.WriteFile(path, []byte("10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBotNetworks().LoadFile(path); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
@@ -2,14 +2,18 @@ package geo
 
 import (
 	"encoding/csv"
-	"log"
 	"net"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/oschwald/maxminddb-golang"
 )
 
+var geoLog = logging.NewLogger("geo")
+
 // GeoLocation represents geographic location data
 type GeoLocation struct {
 	Country     string  `json:"country"`
@@ -20,6 +24,7 @@ type GeoLocation struct {
 	Longitude   float64 `json:"longitude"`
 	Timezone    string  `json:"timezone"`
 	ISP         string  `json:"isp"`
+	ASN         uint32  `json:"asn,omitempty"`
 }
 
 // GeoIPLookup provides IP to location lookup
@@ -28,6 +33,9 @@ type GeoIPLookup struct {
 	networks map[string]*GeoLocation
 	// Embedded well-known IP ranges for demo/testing
 	wellKnownIPs map[string]*GeoLocation
+	// MaxMind GeoLite2/GeoIP2 databases; nil until loaded
+	cityDB *maxminddb.Reader
+	asnDB  *maxminddb.Reader
 }
 
 // NewGeoIPLookup creates a new GeoIP lookup instance
@@ -136,8 +144,14 @@ func (g *GeoIPLookup) Lookup(ipStr string) *GeoLocation {
 		}
 	}
 
+	if loc := g.lookupMMDB(ip); loc != nil {
+		return loc
+	}
+
 	// For IPs not in our database, try to guess based on first octet ranges
-	return g.guessLocationByRange(ip)
+	loc := g.guessLocationByRange(ip)
+	loc.ASN, loc.ISP = g.lookupASN(ip)
+	return loc
 }
 
 // guessLocationByRange provides rough estimates based on IP ranges
@@ -209,7 +223,7 @@ func (g *GeoIPLookup) LoadFromCSV(filepath string) error {
 		}
 	}
 
-	log.Printf("Loaded %d geo records from CSV", len(records)-1)
+	geoLog.Info().Msgf("Loaded %d geo records from CSV", len(records)-1)
 	return nil
 }
 
@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbCity is the subset of the GeoLite2/GeoIP2 City record we use
+type mmdbCity struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		TimeZone  string  `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+// mmdbASN is the GeoLite2/GeoIP2 ASN record
+type mmdbASN struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// LoadMMDB opens MaxMind City and ASN databases and swaps them in. Either path may be empty.
+// Databases that were loaded before are closed once replaced.
+func (g *GeoIPLookup) LoadMMDB(cityPath, asnPath string) error {
+	var city, asn *maxminddb.Reader
+	var err error
+	if cityPath != "" {
+		if city, err = maxminddb.Open(cityPath); err != nil {
+			return fmt.Errorf("open %s: %w", cityPath, err)
+		}
+	}
+	if asnPath != "" {
+		if asn, err = maxminddb.Open(asnPath); err != nil {
+			if city != nil {
+				city.Close()
+			}
+			return fmt.Errorf("open %s: %w", asnPath, err)
+		}
+	}
+
+	g.mu.Lock()
+	oldCity, oldASN := g.cityDB, g.asnDB
+	if city != nil {
+		g.cityDB = city
+	}
+	if asn != nil {
+		g.asnDB = asn
+	}
+	g.mu.Unlock()
+
+	if city != nil && oldCity != nil {
+		oldCity.Close()
+	}
+	if asn != nil && oldASN != nil {
+		oldASN.Close()
+	}
+	if city != nil {
+		geoLog.Info().Msgf("GeoIP: loaded %s (built %d)", city.Metadata.DatabaseType, city.Metadata.BuildEpoch)
+	}
+	if asn != nil {
+		geoLog.Info().Msgf("GeoIP: loaded %s (built %d)", asn.Metadata.DatabaseType, asn.Metadata.BuildEpoch)
+	}
+	return nil
+}
+
+// LoadLocal loads whichever of the given databases exist on disk
+func (g *GeoIPLookup) LoadLocal(cityPath, asnPath string) error {
+	if !fileExists(cityPath) {
+		cityPath = ""
+	}
+	if !fileExists(asnPath) {
+		asnPath = ""
+	}
+	if cityPath == "" && asnPath == "" {
+		return nil
+	}
+	return g.LoadMMDB(cityPath, asnPath)
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// HasMMDB reports whether a MaxMind City database is loaded
+func (g *GeoIPLookup) HasMMDB() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.cityDB != nil
+}
+
+// Close releases any loaded MaxMind databases
+func (g *GeoIPLookup) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cityDB != nil {
+		g.cityDB.Close()
+		g.cityDB = nil
+	}
+	if g.asnDB != nil {
+		g.asnDB.Close()
+		g.asnDB = nil
+	}
+	return nil
+}
+
+// lookupMMDB resolves ip from the loaded MaxMind databases; nil when no City database is loaded
+// or the address is not in it.
+func (g *GeoIPLookup) lookupMMDB(ip net.IP) *GeoLocation {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.cityDB == nil {
+		return nil
+	}
+
+	var rec mmdbCity
+	if err := g.cityDB.Lookup(ip, &rec); err != nil || rec.Country.ISOCode == "" {
+		return nil
+	}
+	loc := &GeoLocation{
+		Country:     rec.Country.Names["en"],
+		CountryCode: rec.Country.ISOCode,
+		City:        rec.City.Names["en"],
+		Latitude:    rec.Location.Latitude,
+		Longitude:   rec.Location.Longitude,
+		Timezone:    rec.Location.TimeZone,
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].Names["en"]
+	}
+
+	if g.asnDB != nil {
+		var asn mmdbASN
+		if err := g.asnDB.Lookup(ip, &asn); err == nil {
+			loc.ASN = uint32(asn.Number)
+			loc.ISP = asn.Organization
+		}
+	}
+	return loc
+}
+
+// lookupASN resolves only the autonomous system for ip; zero values when no ASN database is loaded
+func (g *GeoIPLookup) lookupASN(ip net.IP) (uint32, string) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.asnDB == nil {
+		return 0, ""
+	}
+	var asn mmdbASN
+	if err := g.asnDB.Lookup(ip, &asn); err != nil {
+		return 0, ""
+	}
+	return uint32(asn.Number), asn.Organization
+}
@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"errors"
+	"net/netip"
+	"sort"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ErrNoCityDB is returned when country networks are asked for without a MaxMind City database
+var ErrNoCityDB = errors.New("no MaxMind City database loaded")
+
+// mmdbCountry is the part of a City record needed to place a network in a country
+type mmdbCountry struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// CountryNetworks returns the networks of each of the given ISO country codes in the loaded City
+// database, with adjacent networks merged. Codes without networks are left out of the result.
+func (g *GeoIPLookup) CountryNetworks(codes []string) (map[string][]netip.Prefix, error) {
+	want := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		want[c] = true
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.cityDB == nil {
+		return nil, ErrNoCityDB
+	}
+
+	found := make(map[string][]netip.Prefix)
+	networks := g.cityDB.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		var rec mmdbCountry
+		subnet, err := networks.Network(&rec)
+		if err != nil {
+			return nil, err
+		}
+		if !want[rec.Country.ISOCode] {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(subnet.IP)
+		if !ok {
+			continue
+		}
+		bits, _ := subnet.Mask.Size()
+		addr = addr.Unmap()
+		if addr.Is4() && bits > 32 {
+			bits -= 96
+		}
+		found[rec.Country.ISOCode] = append(found[rec.Country.ISOCode], netip.PrefixFrom(addr, bits).Masked())
+	}
+	if err := networks.Err(); err != nil {
+		return nil, err
+	}
+	for code, prefixes := range found {
+		found[code] = AggregatePrefixes(prefixes)
+	}
+	return found, nil
+}
+
+// AggregatePrefixes sorts prefixes, drops those another one covers and merges sibling pairs into
+// their parent until none are left
+func AggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		sorted = append(sorted, p.Masked())
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Addr().Compare(sorted[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Bits() < sorted[j].Bits()
+	})
+
+	out := make([]netip.Prefix, 0, len(sorted))
+	for _, p := range sorted {
+		if n := len(out); n > 0 && out[n-1].Bits() <= p.Bits() && out[n-1].Contains(p.Addr()) {
+			continue
+		}
+		out = append(out, p)
+		for len(out) >= 2 {
+			a, b := out[len(out)-2], out[len(out)-1]
+			if a.Bits() != b.Bits() || a.Bits() == 0 {
+				break
+			}
+			parent, err := a.Addr().Prefix(a.Bits() - 1)
+			if err != nil || parent.Addr() != a.Addr() || !parent.Contains(b.Addr()) {
+				break
+			}
+			out = append(out[:len(out)-2], parent)
+		}
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package geo
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestAggregatePrefixes(t *testing.T) {
+	in := []netip.Prefix{
+		netip.MustParsePrefix("10.0.1.0/24"),
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.2.0/24"),
+		netip.MustParsePrefix("10.0.3.0/25"),
+		netip.MustParsePrefix("10.0.3.128/25"),
+		netip.MustParsePrefix("10.0.1.7/32"),
+		netip.MustParsePrefix("10.0.5.0/24"),
+		netip.MustParsePrefix("2001:db8::/33"),
+		netip.MustParsePrefix("2001:db8:8000::/33"),
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/22"),
+		netip.MustParsePrefix("10.0.5.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	if got := AggregatePrefixes(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregatePrefixes = %v, want %v", got, want)
+	}
+}
+
+func TestCountryNetworksWithoutDatabase(t *testing.T) {
+	if _, err := NewGeoIPLookup().CountryNetworks([]string{"US"}); err != ErrNoCityDB {
+		t.Errorf("err = %v, want ErrNoCityDB", err)
+	}
+}
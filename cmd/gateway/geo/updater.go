@@ -0,0 +1,184 @@
+package geo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+const (
+	EditionCity = "GeoLite2-City"
+	EditionASN  = "GeoLite2-ASN"
+
+	defaultDownloadURL    = "https://download.maxmind.com"
+	defaultUpdateInterval = 7 * 24 * time.Hour
+)
+
+// UpdaterConfig configures periodic MaxMind database downloads
+type UpdaterConfig struct {
+	AccountID  string            // Enables the basic-auth download endpoint; legacy license_key URLs are used when empty
+	LicenseKey string            // MaxMind license key
+	Databases  map[string]string // Edition ID => local .mmdb path
+	Interval   time.Duration     // Re-download when the local file is older than this
+	BaseURL    string            // Override for tests/mirrors (default https://download.maxmind.com)
+}
+
+// Updater keeps local MaxMind databases fresh and reloads them into a GeoIPLookup
+type Updater struct {
+	cfg    UpdaterConfig
+	lookup *GeoIPLookup
+	client *http.Client
+}
+
+// NewUpdater creates an updater for the given lookup
+func NewUpdater(lookup *GeoIPLookup, cfg UpdaterConfig) *Updater {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultUpdateInterval
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultDownloadURL
+	}
+	return &Updater{cfg: cfg, lookup: lookup, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Start downloads stale or missing databases now and then re-checks daily until ctx is done.
+func (u *Updater) Start(ctx context.Context) {
+	go func() {
+		if err := u.Update(ctx); err != nil {
+			geoLog.Error().Msgf("GeoIP: update failed: %v", err)
+		}
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := u.Update(ctx); err != nil {
+					geoLog.Error().Msgf("GeoIP: update failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Update downloads every database older than the interval and reloads the lookup if anything changed.
+func (u *Updater) Update(ctx context.Context) error {
+	changed := false
+	var firstErr error
+	for edition, path := range u.cfg.Databases {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < u.cfg.Interval {
+			continue
+		}
+		if err := u.download(ctx, edition, path); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", edition, err)
+			}
+			continue
+		}
+		geoLog.Info().Msgf("GeoIP: downloaded %s to %s", edition, path)
+		changed = true
+	}
+
+	if changed {
+		if err := u.lookup.LoadLocal(u.cfg.Databases[EditionCity], u.cfg.Databases[EditionASN]); err != nil {
+			return err
+		}
+	}
+	return firstErr
+}
+
+// download fetches the edition's tar.gz archive, extracts the .mmdb and atomically replaces path.
+func (u *Updater) download(ctx context.Context, edition, path string) error {
+	var reqURL string
+	if u.cfg.AccountID != "" {
+		reqURL = fmt.Sprintf("%s/geoip/databases/%s/download?suffix=tar.gz", u.cfg.BaseURL, url.PathEscape(edition))
+	} else {
+		reqURL = fmt.Sprintf("%s/app/geoip_download?edition_id=%s&license_key=%s&suffix=tar.gz",
+			u.cfg.BaseURL, url.QueryEscape(edition), url.QueryEscape(u.cfg.LicenseKey))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	if u.cfg.AccountID != "" {
+		req.SetBasicAuth(u.cfg.AccountID, u.cfg.LicenseKey)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		// The legacy URL carries the license key; keep it out of logs
+		return fmt.Errorf("download failed: %v", redactLicenseKey(err, u.cfg.LicenseKey))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+edition+"-*.mmdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := extractMMDB(resp.Body, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Refuse to replace a working database with a corrupt one
+	reader, err := maxminddb.Open(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("downloaded database is invalid: %w", err)
+	}
+	reader.Close()
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// extractMMDB copies the first .mmdb file in a tar.gz stream to w
+func extractMMDB(r io.Reader, w io.Writer) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("archive contains no .mmdb file")
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg && strings.HasSuffix(hdr.Name, ".mmdb") {
+			_, err := io.Copy(w, tr)
+			return err
+		}
+	}
+}
+
+func redactLicenseKey(err error, key string) string {
+	if key == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), url.QueryEscape(key), "REDACTED")
+}
@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestExtractMMDB(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"GeoLite2-City_20260101/LICENSE.txt":        "license",
+		"GeoLite2-City_20260101/GeoLite2-City.mmdb": "mmdb-bytes",
+	})
+	var out bytes.Buffer
+	if err := extractMMDB(bytes.NewReader(archive), &out); err != nil {
+		t.Fatalf("extractMMDB: %v", err)
+	}
+	if out.String() != "mmdb-bytes" {
+		t.Errorf("extracted %q", out.String())
+	}
+
+	if err := extractMMDB(bytes.NewReader(tarGz(t, map[string]string{"README": "x"})), &out); err == nil {
+		t.Error("expected an error for an archive without a database")
+	}
+}
+
+func TestUpdaterRejectsInvalidDatabase(t *testing.T) {
+	var gotAuth, gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		gotAuth, gotPath = user+":"+pass, r.URL.Path
+		w.Write(tarGz(t, map[string]string{"x/GeoLite2-City.mmdb": "not a database"}))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "GeoLite2-City.mmdb")
+	lookup := NewGeoIPLookup()
+	u := NewUpdater(lookup, UpdaterConfig{
+		AccountID:  "123",
+		LicenseKey: "secret",
+		Databases:  map[string]string{EditionCity: path},
+		BaseURL:    ts.URL,
+	})
+
+	err := u.Update(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Fatalf("Update error = %v, want invalid database", err)
+	}
+	if gotAuth != "123:secret" || gotPath != "/geoip/databases/GeoLite2-City/download" {
+		t.Errorf("request auth=%q path=%q", gotAuth, gotPath)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("invalid download must not be installed")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	if lookup.HasMMDB() {
+		t.Error("lookup should not have a database loaded")
+	}
+}
+
+func TestLookupWithoutMMDB(t *testing.T) {
+	lookup := NewGeoIPLookup()
+	if err := lookup.LoadLocal(filepath.Join(t.TempDir(), "missing.mmdb"), ""); err != nil {
+		t.Fatalf("LoadLocal with missing files should be a no-op: %v", err)
+	}
+	if loc := lookup.Lookup("8.8.8.8"); loc == nil || loc.CountryCode != "US" {
+		t.Errorf("well-known lookup = %+v", loc)
+	}
+	if loc := lookup.Lookup("10.0.0.1"); loc == nil || loc.Country != "Local" {
+		t.Errorf("private lookup = %+v", loc)
+	}
+}
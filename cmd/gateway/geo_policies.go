@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/geo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultGeoPolicyStatus = 403
+	// geoPolicyMaxBytes keeps the geo block well below the agent's gRPC message limit
+	geoPolicyMaxBytes = 3 << 20
+)
+
+var (
+	geoPolicyCountryRe   = regexp.MustCompile(`^[A-Z]{2}$`)
+	geoPolicyStatusCodes = map[int]bool{403: true, 404: true, 444: true, 451: true}
+)
+
+// geoPolicyPrivateNetworks stay allowed by allow policies, so health checks and internal clients
+// are not locked out; they are in no country
+var geoPolicyPrivateNetworks = []string{
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8", "169.254.0.0/16",
+	"::1/128", "fc00::/7", "fe80::/10",
+}
+
+// setPaths derives the variable and files of a policy from its slug: the geo block lands in conf.d/,
+// included from http, and the check in avika/geo/, included from the server blocks it guards
+func (p *GeoPolicy) setPaths() {
+	p.Variable = "$avika_geo_" + strings.ReplaceAll(p.Slug, "-", "_")
+	p.HTTPPath = "conf.d/avika-geo-" + p.Slug + ".conf"
+	p.IncludePath = "avika/geo/" + p.Slug + ".conf"
+}
+
+// geoPolicyRequest is the body of POST and PUT /api/geo-policies. The slug is only read on create,
+// as configs include the policy's file; unset fields keep their value on update.
+type geoPolicyRequest struct {
+	Name       string        `json:"name"`
+	Slug       string        `json:"slug"`
+	Mode       string        `json:"mode"`
+	Countries  []string      `json:"countries"`
+	StatusCode int           `json:"status_code"`
+	Selector   *BulkSelector `json:"selector"`
+}
+
+// newGeoPolicy validates a create request; the slug defaults to one derived from the name and the
+// status code to 403
+func (req geoPolicyRequest) newGeoPolicy() (*GeoPolicy, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.Selector == nil {
+		return nil, status.Error(codes.InvalidArgument, "selector is required")
+	}
+	p := &GeoPolicy{Name: req.Name, Slug: req.Slug, StatusCode: defaultGeoPolicyStatus}
+	if p.Slug == "" {
+		p.Slug = strings.Trim(templateSlugRe.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	}
+	if !snippetSlugRe.MatchString(p.Slug) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid slug %q: use lowercase letters, digits and dashes", p.Slug)
+	}
+	p.setPaths()
+	if err := req.update(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// update applies the set fields of a request to p and validates the result
+func (req geoPolicyRequest) update(p *GeoPolicy) error {
+	if req.Name != "" {
+		p.Name = req.Name
+	}
+	if req.Mode != "" {
+		p.Mode = req.Mode
+	}
+	if req.Countries != nil {
+		p.Countries = req.Countries
+	}
+	if req.StatusCode != 0 {
+		p.StatusCode = req.StatusCode
+	}
+	if req.Selector != nil {
+		p.Selector = *req.Selector
+	}
+	if p.Mode != "allow" && p.Mode != "deny" {
+		return status.Error(codes.InvalidArgument, "mode must be allow or deny")
+	}
+	if !geoPolicyStatusCodes[p.StatusCode] {
+		return status.Error(codes.InvalidArgument, "status_code must be 403, 404, 444 or 451")
+	}
+	seen := make(map[string]bool)
+	countries := make([]string, 0, len(p.Countries))
+	for _, c := range p.Countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !geoPolicyCountryRe.MatchString(c) {
+			return status.Errorf(codes.InvalidArgument, "invalid country %q: use ISO 3166-1 alpha-2 codes", c)
+		}
+		if !seen[c] {
+			seen[c] = true
+			countries = append(countries, c)
+		}
+	}
+	if len(countries) == 0 {
+		return status.Error(codes.InvalidArgument, "countries must list at least one country")
+	}
+	sort.Strings(countries)
+	p.Countries = countries
+	if len(p.Selector.AgentIDs) == 0 && p.Selector.GroupID == "" && p.Selector.EnvironmentID == "" && len(p.Selector.Tags) == 0 {
+		return status.Error(codes.InvalidArgument, "selector must name agents, a group, an environment or tags")
+	}
+	return nil
+}
+
+// geoPolicyNetworks looks up the networks of a policy's countries in the gateway's GeoIP database.
+// A country without networks is rejected, as it is most likely a typo.
+func (s *server) geoPolicyNetworks(p *GeoPolicy) (map[string][]netip.Prefix, error) {
+	if s.clickhouse == nil || s.clickhouse.geoLookup == nil {
+		return nil, status.Error(codes.Unavailable, geo.ErrNoCityDB.Error())
+	}
+	networks, err := s.clickhouse.geoLookup.CountryNetworks(p.Countries)
+	if err == geo.ErrNoCityDB {
+		return nil, status.Errorf(codes.Unavailable, "%v; configure geoip to compile geo policies", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "reading the GeoIP database: %v", err)
+	}
+	for _, c := range p.Countries {
+		if len(networks[c]) == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "the GeoIP database has no networks in %s", c)
+		}
+	}
+	return networks, nil
+}
+
+// renderGeoPolicy compiles a policy into its files, in the order they must be written: the geo
+// block setting the variable to 1 for blocked clients, then the check. It returns the number of
+// networks in the block.
+func renderGeoPolicy(p *GeoPolicy, networks map[string][]netip.Prefix) ([]managedFile, int, error) {
+	// Deny policies block the networks listed, allow policies everything else
+	def, match := "0", "1"
+	if p.Mode == "allow" {
+		def, match = "1", "0"
+	}
+	var h strings.Builder
+	fmt.Fprintf(&h, "# Managed by Avika: geo policy %q, %s %s. Changes are overwritten.\n", p.Name, p.Mode, strings.Join(p.Countries, " "))
+	fmt.Fprintf(&h, "geo %s {\n    default %s;\n", p.Variable, def)
+	count := 0
+	if p.Mode == "allow" {
+		for _, n := range geoPolicyPrivateNetworks {
+			fmt.Fprintf(&h, "    %s 0;\n", n)
+		}
+	}
+	for _, c := range p.Countries {
+		fmt.Fprintf(&h, "    # %s\n", c)
+		for _, n := range networks[c] {
+			fmt.Fprintf(&h, "    %s %s;\n", n, match)
+			count++
+		}
+	}
+	h.WriteString("}\n")
+	if h.Len() > geoPolicyMaxBytes {
+		return nil, 0, status.Errorf(codes.InvalidArgument, "the policy compiles to %d networks, too many for one file; list fewer countries", count)
+	}
+
+	include := fmt.Sprintf("# Managed by Avika: geo policy %q. Changes are overwritten.\nif (%s) {\n    return %d;\n}\n",
+		p.Name, p.Variable, p.StatusCode)
+	return []managedFile{
+		{Path: p.HTTPPath, Content: h.String()},
+		{Path: p.IncludePath, Content: include},
+	}, count, nil
+}
+
+// geoPolicyOffFiles lift a policy on an agent: the check first, then the geo block. The files stay
+// as comments so server blocks including them remain valid.
+func geoPolicyOffFiles(p *GeoPolicy) []managedFile {
+	off := fmt.Sprintf("# Managed by Avika: geo policy %q, deleted. Changes are overwritten.\n", p.Name)
+	return []managedFile{
+		{Path: p.IncludePath, Content: off},
+		{Path: p.HTTPPath, Content: off},
+	}
+}
+
+// applyGeoPolicy writes a compiled policy to the agents, batchPushConcurrency at a time, and
+// records the outcome on each
+func (s *server) applyGeoPolicy(ctx context.Context, p *GeoPolicy, agentIDs []string, files []managedFile, networks int) []GeoPolicyAgent {
+	results := make([]GeoPolicyAgent, len(agentIDs))
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range agentIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *GeoPolicyAgent, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.AgentID, r.Status, r.Networks = id, "applied", networks
+			if err := s.writeManagedFiles(ctx, id, files); err != nil {
+				r.Status, r.Error = "failed", err.Error()
+			}
+			r.AppliedAt = time.Now()
+			if err := s.db.SaveGeoPolicyAgent(p.ID, r); err != nil {
+				securityLog.Error().Msgf("Failed to record geo policy %s on %s: %v", p.Slug, id, err)
+			}
+		}(&results[i], id)
+	}
+	wg.Wait()
+	return results
+}
+
+// GeoPolicyStats is the traffic a policy blocked over a time range, from the access logs of its
+// agents: requests from its blocked countries answered with its status code
+type GeoPolicyStats struct {
+	From      int64                 `json:"from"`
+	To        int64                 `json:"to"`
+	Blocked   uint64                `json:"blocked_requests"`
+	Countries []GeoPolicyCountryHit `json:"countries"`
+	Agents    []GeoPolicyAgentHit   `json:"agents"`
+}
+
+// GeoPolicyCountryHit is the blocked traffic from one country
+type GeoPolicyCountryHit struct {
+	CountryCode string `json:"country_code"`
+	Country     string `json:"country"`
+	Requests    uint64 `json:"requests"`
+}
+
+// GeoPolicyAgentHit is the traffic one agent blocked
+type GeoPolicyAgentHit struct {
+	AgentID  string `json:"agent_id"`
+	Requests uint64 `json:"requests"`
+}
+
+// geoPolicyHits is a row of blocked requests by agent and country
+type geoPolicyHits struct {
+	AgentID     string
+	CountryCode string
+	Country     string
+	Requests    uint64
+}
+
+// GetGeoPolicyHits counts the requests of a policy's blocked countries its agents answered with its
+// status code. Allow policies leave out clients in no country, which they do not block.
+func (db *ClickHouseDB) GetGeoPolicyHits(ctx context.Context, from, to time.Time, agents []string, p *GeoPolicy) ([]geoPolicyHits, error) {
+	countryCond := "country_code IN (?)"
+	if p.Mode == "allow" {
+		countryCond = "country_code NOT IN (?) AND country_code NOT IN ('', 'XX')"
+	}
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT instance_id, country_code, any(country), count()
+		FROM nginx_analytics.access_logs
+		WHERE timestamp >= ? AND timestamp <= ? AND instance_id IN (?) AND status = ? AND %s
+		GROUP BY instance_id, country_code
+	`, countryCond), from, to, agents, uint16(p.StatusCode), p.Countries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []geoPolicyHits
+	for rows.Next() {
+		var h geoPolicyHits
+		if err := rows.Scan(&h.AgentID, &h.CountryCode, &h.Country, &h.Requests); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// newGeoPolicyStats totals blocked requests by country and by agent, most blocked first
+func newGeoPolicyStats(from, to time.Time, hits []geoPolicyHits) *GeoPolicyStats {
+	stats := &GeoPolicyStats{From: from.Unix(), To: to.Unix(), Countries: []GeoPolicyCountryHit{}, Agents: []GeoPolicyAgentHit{}}
+	countries := make(map[string]*GeoPolicyCountryHit)
+	agents := make(map[string]*GeoPolicyAgentHit)
+	for _, h := range hits {
+		stats.Blocked += h.Requests
+		c, ok := countries[h.CountryCode]
+		if !ok {
+			c = &GeoPolicyCountryHit{CountryCode: h.CountryCode, Country: h.Country}
+			countries[h.CountryCode] = c
+		}
+		c.Requests += h.Requests
+		a, ok := agents[h.AgentID]
+		if !ok {
+			a = &GeoPolicyAgentHit{AgentID: h.AgentID}
+			agents[h.AgentID] = a
+		}
+		a.Requests += h.Requests
+	}
+	for _, c := range countries {
+		stats.Countries = append(stats.Countries, *c)
+	}
+	for _, a := range agents {
+		stats.Agents = append(stats.Agents, *a)
+	}
+	sort.Slice(stats.Countries, func(i, j int) bool {
+		if stats.Countries[i].Requests != stats.Countries[j].Requests {
+			return stats.Countries[i].Requests > stats.Countries[j].Requests
+		}
+		return stats.Countries[i].CountryCode < stats.Countries[j].CountryCode
+	})
+	sort.Slice(stats.Agents, func(i, j int) bool {
+		if stats.Agents[i].Requests != stats.Agents[j].Requests {
+			return stats.Agents[i].Requests > stats.Agents[j].Requests
+		}
+		return stats.Agents[i].AgentID < stats.Agents[j].AgentID
+	})
+	return stats
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewGeoPolicy(t *testing.T) {
+	req := geoPolicyRequest{
+		Name:      "Block Sanctioned",
+		Mode:      "deny",
+		Countries: []string{"ru", " CN", "RU"},
+		Selector:  &BulkSelector{EnvironmentID: "prod"},
+	}
+	p, err := req.newGeoPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Slug != "block-sanctioned" || p.Variable != "$avika_geo_block_sanctioned" || p.StatusCode != 403 ||
+		p.HTTPPath != "conf.d/avika-geo-block-sanctioned.conf" || p.IncludePath != "avika/geo/block-sanctioned.conf" {
+		t.Errorf("policy = %+v", p)
+	}
+	if !reflect.DeepEqual(p.Countries, []string{"CN", "RU"}) {
+		t.Errorf("countries = %v, want normalized and deduplicated", p.Countries)
+	}
+
+	for _, bad := range []geoPolicyRequest{
+		{Name: "No mode", Countries: req.Countries, Selector: req.Selector},
+		{Name: "No countries", Mode: "deny", Selector: req.Selector},
+		{Name: "Bad country", Mode: "deny", Countries: []string{"USA"}, Selector: req.Selector},
+		{Name: "Bad status", Mode: "deny", Countries: req.Countries, StatusCode: 200, Selector: req.Selector},
+		{Name: "Empty selector", Mode: "deny", Countries: req.Countries, Selector: &BulkSelector{}},
+	} {
+		if _, err := bad.newGeoPolicy(); err == nil {
+			t.Errorf("%s should be rejected", bad.Name)
+		}
+	}
+}
+
+func TestRenderGeoPolicy(t *testing.T) {
+	networks := map[string][]netip.Prefix{
+		"CN": {netip.MustParsePrefix("1.0.1.0/24"), netip.MustParsePrefix("2001:250::/30")},
+	}
+	p := &GeoPolicy{Name: "cn", Slug: "cn", Mode: "deny", Countries: []string{"CN"}, StatusCode: 451}
+	p.setPaths()
+
+	files, count, err := renderGeoPolicy(p, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || len(files) != 2 || files[0].Path != p.HTTPPath || files[1].Path != p.IncludePath {
+		t.Fatalf("files = %+v, count %d", files, count)
+	}
+	if want := "geo $avika_geo_cn {\n    default 0;\n    # CN\n    1.0.1.0/24 1;\n    2001:250::/30 1;\n}\n"; !strings.HasSuffix(files[0].Content, want) {
+		t.Errorf("deny block:\n%s", files[0].Content)
+	}
+	if want := "if ($avika_geo_cn) {\n    return 451;\n}\n"; !strings.HasSuffix(files[1].Content, want) {
+		t.Errorf("check:\n%s", files[1].Content)
+	}
+
+	p.Mode = "allow"
+	files, _, _ = renderGeoPolicy(p, networks)
+	for _, want := range []string{"    default 1;\n", "    10.0.0.0/8 0;\n", "    1.0.1.0/24 0;\n"} {
+		if !strings.Contains(files[0].Content, want) {
+			t.Errorf("allow block lacks %q:\n%s", want, files[0].Content)
+		}
+	}
+
+	if off := geoPolicyOffFiles(p); off[0].Path != p.IncludePath || strings.Contains(off[0].Content, "return") {
+		t.Errorf("off files = %+v, want the check lifted first", off)
+	}
+}
+
+func TestGeoPolicyStats(t *testing.T) {
+	from := time.Unix(0, 0)
+	stats := newGeoPolicyStats(from, from.Add(time.Hour), []geoPolicyHits{
+		{AgentID: "edge-1", CountryCode: "CN", Country: "China", Requests: 40},
+		{AgentID: "edge-2", CountryCode: "CN", Country: "China", Requests: 10},
+		{AgentID: "edge-2", CountryCode: "RU", Country: "Russia", Requests: 70},
+	})
+	if stats.Blocked != 120 || stats.To != 3600 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if want := []GeoPolicyCountryHit{{"RU", "Russia", 70}, {"CN", "China", 50}}; !reflect.DeepEqual(stats.Countries, want) {
+		t.Errorf("countries = %+v, want %+v", stats.Countries, want)
+	}
+	if want := []GeoPolicyAgentHit{{"edge-2", 80}, {"edge-1", 40}}; !reflect.DeepEqual(stats.Agents, want) {
+		t.Errorf("agents = %+v, want %+v", stats.Agents, want)
+	}
+}
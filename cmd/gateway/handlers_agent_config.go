@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,10 +11,9 @@ import (
 
 	"github.com/avika-ai/avika/cmd/gateway/config"
 	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -77,6 +75,13 @@ func (s *server) getAgentConfigClient(agentID string) (pb.AgentConfigServiceClie
 	if !ok {
 		return nil, nil, fmt.Errorf("agent %s not found", agentID)
 	}
+	if address, ok := s.peerGatewayOf(resolved); ok {
+		conn, err := s.dialPeerGateway(address, resolved)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pb.NewAgentConfigServiceClient(conn), conn, nil
+	}
 	val, _ := s.sessions.Load(resolved)
 	session := val.(*AgentSession)
 
@@ -108,20 +113,7 @@ func (s *server) getAgentConfigClient(agentID string) (pb.AgentConfigServiceClie
 		return nil, nil, fmt.Errorf("agent %s: no reachable mgmt address", agentID)
 	}
 
-	var dialOpts []grpc.DialOption
-	if s.config.Security.EnableTLS && s.config.Security.TLSCertFile != "" {
-		tlsConfig, err := loadServerTLSConfig(s.config)
-		if err != nil {
-			log.Printf("Failed to load TLS config for dialing agent: %v. Falling back to insecure.", err)
-			dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		} else {
-			dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-		}
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	}
-
-	conn, err := grpc.NewClient(target, dialOpts...)
+	conn, err := grpc.NewClient(target, s.gatewayTransportCredentials())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to connect to agent %s: %v", agentID, err)
 	}
@@ -182,7 +174,7 @@ func (srv *server) handleGetAgentRuntimeConfig(w http.ResponseWriter, r *http.Re
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(cfg); err != nil {
-		log.Printf("handleGetAgentRuntimeConfig: failed to encode response for agent %s: %v", resolved, err)
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("handleGetAgentRuntimeConfig: failed to encode response for agent %s: %v", resolved, err)
 	}
 }
 
@@ -257,13 +249,13 @@ func (srv *server) handleUpdateAgentRuntimeConfig(w http.ResponseWriter, r *http
 			"hot_reload": body.HotReload,
 			"updates":    updates,
 		}); err != nil {
-			log.Printf("handleUpdateAgentRuntimeConfig: failed to create audit log for user %s agent %s: %v", user.Username, resolved, err)
+			logging.Ctx(r.Context(), apiLog).Error().Msgf("handleUpdateAgentRuntimeConfig: failed to create audit log for user %s agent %s: %v", user.Username, resolved, err)
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("handleUpdateAgentRuntimeConfig: failed to encode response for agent %s: %v", resolved, err)
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("handleUpdateAgentRuntimeConfig: failed to encode response for agent %s: %v", resolved, err)
 	}
 }
 
@@ -354,12 +346,12 @@ func (srv *server) handleRestoreAgentConfigBackup(w http.ResponseWriter, r *http
 		if err := srv.db.CreateAuditLog(user.Username, "restore_agent_config_backup", "agent", resolved, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
 			"backup_name": body.BackupName,
 		}); err != nil {
-			log.Printf("handleRestoreAgentConfigBackup: failed to create audit log for user %s agent %s: %v", user.Username, resolved, err)
+			logging.Ctx(r.Context(), apiLog).Error().Msgf("handleRestoreAgentConfigBackup: failed to create audit log for user %s agent %s: %v", user.Username, resolved, err)
 		}
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("handleRestoreAgentConfigBackup: failed to encode response for agent %s: %v", resolved, err)
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("handleRestoreAgentConfigBackup: failed to encode response for agent %s: %v", resolved, err)
 	}
 }
 
@@ -421,7 +413,7 @@ func (srv *server) canUserAccessAgent(username, agentID string) bool {
 
 	visibleAgents, err := srv.db.GetVisibleAgentIDs(username)
 	if err != nil {
-		log.Printf("RBAC visible agents error for user %s: %v", username, err)
+		apiLog.Error().Msgf("RBAC visible agents error for user %s: %v", username, err)
 		return false
 	}
 
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// gatewayPSK is the key handed to agents whose bundle includes it, empty when PSK auth is off
+func (s *server) gatewayPSK() string {
+	if s.config == nil || !s.config.PSK.Enabled {
+		return ""
+	}
+	return s.config.PSK.Key
+}
+
+// renderEnvironmentAgentConfig merges the fleet-wide bundle with the environment's
+func (s *server) renderEnvironmentAgentConfig(env *Environment) (RenderedAgentConfig, error) {
+	global, err := s.db.GetAgentConfigBundle("")
+	if err != nil {
+		return RenderedAgentConfig{}, err
+	}
+	bundle, err := s.db.GetAgentConfigBundle(env.ID)
+	if err != nil {
+		return RenderedAgentConfig{}, err
+	}
+	project, err := s.db.GetProject(env.ProjectID)
+	if err != nil {
+		return RenderedAgentConfig{}, err
+	}
+	return renderAgentConfig(global, bundle, env, project, s.gatewayPSK()), nil
+}
+
+// handleGetAgentConfig handles GET /api/agent-config?format=conf. Agents authenticate with an
+// enrollment token of their environment (Authorization: Bearer or X-Enrollment-Token); fetches do
+// not count as token uses.
+func (s *server) handleGetAgentConfig(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		token = r.Header.Get("X-Enrollment-Token")
+	}
+	if token == "" {
+		http.Error(w, `{"error":"enrollment token required"}`, http.StatusUnauthorized)
+		return
+	}
+	envID, err := s.db.LookupEnrollmentToken(token)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusUnauthorized)
+		return
+	}
+	env, err := s.db.GetEnvironment(envID)
+	if err != nil || env == nil {
+		http.Error(w, `{"error":"environment not found"}`, http.StatusNotFound)
+		return
+	}
+	rendered, err := s.renderEnvironmentAgentConfig(env)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+
+	etag := `"` + rendered.Version + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.URL.Query().Get("format") == "conf" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(formatAgentConfigFile(rendered)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rendered)
+}
+
+// handleGetGlobalAgentConfig handles GET /api/agent-config/global
+func (s *server) handleGetGlobalAgentConfig(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	s.writeAgentConfigBundle(w, "")
+}
+
+// handlePutGlobalAgentConfig handles PUT /api/agent-config/global
+func (s *server) handlePutGlobalAgentConfig(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	s.saveAgentConfigBundle(w, r, "", username)
+}
+
+// handleGetEnvironmentAgentConfig handles GET /api/environments/{id}/agent-config: the
+// environment's own bundle and the configuration its agents receive, PSK masked
+func (s *server) handleGetEnvironmentAgentConfig(w http.ResponseWriter, r *http.Request) {
+	env, _, ok := s.loadRetentionEnvironment(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	bundle, err := s.db.GetAgentConfigBundle(env.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	rendered, err := s.renderEnvironmentAgentConfig(env)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if _, ok := rendered.Settings["PSK_KEY"]; ok {
+		rendered.Settings["PSK_KEY"] = "********"
+	}
+	if bundle == nil {
+		bundle = &AgentConfigBundle{EnvironmentID: env.ID, Settings: map[string]string{}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bundle":   bundle,
+		"rendered": rendered,
+	})
+}
+
+// handlePutEnvironmentAgentConfig handles PUT /api/environments/{id}/agent-config
+func (s *server) handlePutEnvironmentAgentConfig(w http.ResponseWriter, r *http.Request) {
+	env, username, ok := s.loadRetentionEnvironment(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	s.saveAgentConfigBundle(w, r, env.ID, username)
+}
+
+// handleDeleteEnvironmentAgentConfig handles DELETE /api/environments/{id}/agent-config
+func (s *server) handleDeleteEnvironmentAgentConfig(w http.ResponseWriter, r *http.Request) {
+	env, username, ok := s.loadRetentionEnvironment(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteAgentConfigBundle(env.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "agent_config_bundle", env.ID, r.RemoteAddr, r.UserAgent(), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) writeAgentConfigBundle(w http.ResponseWriter, environmentID string) {
+	bundle, err := s.db.GetAgentConfigBundle(environmentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if bundle == nil {
+		bundle = &AgentConfigBundle{EnvironmentID: environmentID, Settings: map[string]string{}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
+
+func (s *server) saveAgentConfigBundle(w http.ResponseWriter, r *http.Request, environmentID, username string) {
+	var bundle AgentConfigBundle
+	if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := validateAgentConfigBundle(&bundle); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if environmentID != "" {
+		if err := checkEnvironmentAgentConfigBundle(&bundle); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusForbidden)
+			return
+		}
+	}
+	bundle.EnvironmentID, bundle.UpdatedBy = environmentID, username
+	if err := s.db.UpsertAgentConfigBundle(&bundle); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	details := map[string]interface{}{
+		"keys":        sortedKeys(bundle.Settings),
+		"include_psk": bundle.IncludePSK,
+	}
+	if redaction := redactionAuditDetails(bundle.Settings); redaction != nil {
+		details["log_redaction"] = redaction
+	}
+	_ = s.db.CreateAuditLog(username, "update", "agent_config_bundle", agentConfigScope(environmentID), r.RemoteAddr, r.UserAgent(), details)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// agentReleasesResponse is the release configuration of the fleet
+type agentReleasesResponse struct {
+	ServedVersion string               `json:"served_version,omitempty"`
+	Versions      []string             `json:"versions"` // releases the gateway can serve
+	Channels      []ReleaseChannel     `json:"channels"`
+	Environments  []EnvironmentRelease `json:"environments"`
+}
+
+// handleGetAgentReleases handles GET /api/fleet/releases
+func (s *server) handleGetAgentReleases(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	channels, err := s.db.ListReleaseChannels()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	envs, err := s.db.ListEnvironmentReleases()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	resp := agentReleasesResponse{Versions: listAgentReleases(s.updatesDir()), Channels: channels, Environments: envs}
+	resp.ServedVersion, _ = servedAgentVersion(s.updatesDir())
+	if resp.Versions == nil {
+		resp.Versions = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSaveReleaseChannel handles PUT /api/fleet/releases/channels/{name}: the channel's
+// version and staged rollout
+func (s *server) handleSaveReleaseChannel(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var c ReleaseChannel
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	c.Name, c.UpdatedBy = r.PathValue("name"), username
+	if err := validateReleaseChannel(&c, listAgentReleases(s.updatesDir())); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	if err := s.db.SaveReleaseChannel(&c); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "release_channel", c.Name, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"version":         c.Version,
+		"rollout_version": c.RolloutVersion,
+		"rollout_percent": c.RolloutPercent,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleSaveEnvironmentRelease handles PUT /api/fleet/releases/environments/{id}: the channel
+// or pinned version of an environment's agents
+func (s *server) handleSaveEnvironmentRelease(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var e EnvironmentRelease
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	e.EnvironmentID, e.UpdatedBy = r.PathValue("id"), username
+	if env, err := s.db.GetEnvironment(e.EnvironmentID); err != nil || env == nil {
+		http.Error(w, `{"error":"environment not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := validateEnvironmentRelease(&e, listAgentReleases(s.updatesDir())); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	if err := s.db.SaveEnvironmentRelease(&e); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "environment_release", e.EnvironmentID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"channel":        e.Channel,
+		"pinned_version": e.PinnedVersion,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// handleDeleteEnvironmentRelease handles DELETE /api/fleet/releases/environments/{id}: the
+// environment's agents go back to the stable channel
+func (s *server) handleDeleteEnvironmentRelease(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	if err := s.db.DeleteEnvironmentRelease(id); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "environment_release", id, r.RemoteAddr, r.UserAgent(), nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
+
+// handleResolveAgentRelease handles GET /api/fleet/releases/resolve?agent_id=: the version the
+// gateway serves the agent and why
+func (s *server) handleResolveAgentRelease(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	agentID, ok := s.resolveAgentID(r.URL.Query().Get("agent_id"))
+	if !ok {
+		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+		return
+	}
+	d, err := s.resolveAgentRelease(agentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// minAgentRetention keeps a short policy from pruning agents that are merely restarting
+const minAgentRetention = 5 * time.Minute
+
+// maxPrunePreviewWindow bounds the ?within= look-ahead of the prune preview
+const maxPrunePreviewWindow = 365 * 24 * time.Hour
+
+// loadRetentionEnvironment resolves the {id} environment and checks the caller holds perm on its
+// project, writing any error response itself
+func (s *server) loadRetentionEnvironment(w http.ResponseWriter, r *http.Request, perm Permission) (*Environment, string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, "", false
+	}
+	env, err := s.db.GetEnvironment(r.PathValue("id"))
+	if err != nil || env == nil {
+		http.Error(w, `{"error":"environment not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	if hasAccess, _ := s.db.HasProjectAccess(user.Username, env.ProjectID, perm); !hasAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, "", false
+	}
+	return env, user.Username, true
+}
+
+// handleGetAgentRetentionPolicy handles GET /api/environments/{id}/retention-policy
+func (s *server) handleGetAgentRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	env, _, ok := s.loadRetentionEnvironment(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	policy, err := s.db.GetAgentRetentionPolicy(env.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if policy == nil {
+		defaults := s.agentRetentionDefaults()
+		defaults.EnvironmentID = env.ID
+		policy = &defaults
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handleUpdateAgentRetentionPolicy handles PUT /api/environments/{id}/retention-policy
+func (s *server) handleUpdateAgentRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	env, username, ok := s.loadRetentionEnvironment(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	var req struct {
+		PruneAfterSec    *int64 `json:"prune_after_sec"`
+		ArchiveAnalytics bool   `json:"archive_analytics"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.PruneAfterSec == nil || *req.PruneAfterSec < 0 {
+		http.Error(w, `{"error":"prune_after_sec must be 0 (never) or a number of seconds"}`, http.StatusBadRequest)
+		return
+	}
+	if *req.PruneAfterSec > 0 && *req.PruneAfterSec < int64(minAgentRetention.Seconds()) {
+		http.Error(w, fmt.Sprintf(`{"error":"prune_after_sec must be at least %d"}`, int64(minAgentRetention.Seconds())), http.StatusBadRequest)
+		return
+	}
+
+	policy := &AgentRetentionPolicy{
+		EnvironmentID:    env.ID,
+		PruneAfterSec:    *req.PruneAfterSec,
+		ArchiveAnalytics: req.ArchiveAnalytics,
+		UpdatedBy:        username,
+	}
+	if err := s.db.UpsertAgentRetentionPolicy(policy); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "agent_retention_policy", env.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"environment":       env.Name,
+		"prune_after_sec":   policy.PruneAfterSec,
+		"archive_analytics": policy.ArchiveAnalytics,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handleDeleteAgentRetentionPolicy handles DELETE /api/environments/{id}/retention-policy,
+// returning the environment to the gateway defaults
+func (s *server) handleDeleteAgentRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	env, username, ok := s.loadRetentionEnvironment(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteAgentRetentionPolicy(env.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "agent_retention_policy", env.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"environment": env.Name,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePrunePreview handles GET /api/agents/prune-preview?within=24h: the visible agents the next
+// pruning run deregisters, plus those due within the look-ahead
+func (s *server) handlePrunePreview(w http.ResponseWriter, r *http.Request) {
+	var within time.Duration
+	if v := r.URL.Query().Get("within"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > maxPrunePreviewWindow {
+			http.Error(w, `{"error":"within must be a duration up to 8760h"}`, http.StatusBadRequest)
+			return
+		}
+		within = d
+	}
+	agentIDs, visible, err := s.scopeMetricAgents(r, nil)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	candidates := []PruneCandidate{}
+	if visible {
+		agents, err := s.db.ListOfflineAgentsForRetention()
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		for _, c := range evaluateAgentRetention(agents, s.agentRetentionDefaults(), time.Now(), within) {
+			if agentIDs == nil || stringInSlice(agentIDs, c.AgentID) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"defaults":   s.agentRetentionDefaults(),
+		"candidates": candidates,
+	})
+}
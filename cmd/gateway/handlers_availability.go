@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// handleListAvailability handles GET /api/availability?window=7d: connectivity uptime of every visible agent
+func (s *server) handleListAvailability(w http.ResponseWriter, r *http.Request) {
+	start, end, window, ok := parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+	agentIDs, visible, err := s.scopeMetricAgents(r, nil)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	results := []AgentAvailability{}
+	if visible {
+		events, err := s.db.ListAgentAvailability(agentIDs, start, end)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		allowed := make(map[string]bool, len(agentIDs))
+		for _, id := range agentIDs {
+			allowed[id] = true
+		}
+		s.sessions.Range(func(key, value interface{}) bool {
+			agentID := key.(string)
+			if agentIDs != nil && !allowed[agentID] {
+				return true
+			}
+			a := computeAgentAvailability(events[agentID], start, end)
+			session := value.(*AgentSession)
+			session.mu.Lock()
+			a.AgentID, a.Hostname, a.Status = agentID, session.hostname, session.status
+			session.mu.Unlock()
+			results = append(results, a)
+			return true
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Hostname < results[j].Hostname })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window": window,
+		"agents": results,
+	})
+}
+
+// handleGetServerAvailability handles GET /api/servers/{agentId}/availability?window=7d: uptime and
+// the transitions within the window
+func (s *server) handleGetServerAvailability(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := s.resolveAccessibleAgent(w, r)
+	if !ok {
+		return
+	}
+	start, end, window, ok := parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+	events, err := s.db.ListAgentAvailability([]string{agentID}, start, end)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	a := computeAgentAvailability(events[agentID], start, end)
+	a.AgentID = agentID
+	if val, ok := s.sessions.Load(agentID); ok {
+		session := val.(*AgentSession)
+		session.mu.Lock()
+		a.Hostname, a.Status = session.hostname, session.status
+		session.mu.Unlock()
+	}
+	for _, e := range events[agentID] {
+		if !e.OccurredAt.Before(start) {
+			a.Events = append(a.Events, e)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window":       window,
+		"availability": a,
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// binaryUpgradeTimeout bounds a whole upgrade: six steps of up to timeout_sec each plus rollback
+const binaryUpgradeTimeout = 10 * time.Minute
+
+func (s *server) UpgradeNginxBinary(ctx context.Context, req *pb.BinaryUpgradeRequest) (*pb.BinaryUpgradeResponse, error) {
+	client, conn, err := s.getAgentClient(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return client.UpgradeNginxBinary(ctx, req)
+}
+
+// POST /api/servers/{agentId}/nginx/upgrade
+// Body: {"binary_path": "/opt/nginx-1.27.1/sbin/nginx", "expected_version": "1.27.1", "nginx_instance": "", "timeout_sec": 30}
+// Replaces the running NGINX binary without dropping connections (USR2, WINCH, QUIT), rolling
+// back when a step fails.
+func (srv *server) handleUpgradeNginxBinary(w http.ResponseWriter, r *http.Request) {
+	agentID, user, ok := srv.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+	if user.Role == "viewer" {
+		http.Error(w, `{"error":"viewers cannot upgrade NGINX"}`, http.StatusForbidden)
+		return
+	}
+
+	var body struct {
+		BinaryPath      string `json:"binary_path"`
+		ExpectedVersion string `json:"expected_version"`
+		NginxInstance   string `json:"nginx_instance"`
+		TimeoutSec      int32  `json:"timeout_sec"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), binaryUpgradeTimeout)
+	defer cancel()
+
+	resp, err := srv.UpgradeNginxBinary(ctx, &pb.BinaryUpgradeRequest{
+		InstanceId:      agentID,
+		NginxInstanceId: body.NginxInstance,
+		BinaryPath:      body.BinaryPath,
+		ExpectedVersion: body.ExpectedVersion,
+		TimeoutSec:      body.TimeoutSec,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+
+	if err := srv.db.CreateAuditLog(user.Username, "nginx_binary_upgrade", "agent", agentID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"binary_path":    body.BinaryPath,
+		"nginx_instance": body.NginxInstance,
+		"old_version":    resp.OldVersion,
+		"new_version":    resp.NewVersion,
+		"success":        resp.Success,
+		"rolled_back":    resp.RolledBack,
+		"error":          resp.Error,
+	}); err != nil {
+		logging.Ctx(r.Context(), jobsLog).Error().Msgf("Failed to write audit log for NGINX binary upgrade on %s: %v", agentID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Success {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	defaultBulkActionLimit = 50
+	maxBulkActionLimit     = 500
+)
+
+// handleCreateBulkAction handles POST /api/bulk-actions: starts a reload, restart or agent update
+// over the selected agents and returns 202 with the job to poll
+func (s *server) handleCreateBulkAction(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	if user.Role == "viewer" {
+		http.Error(w, `{"error":"viewers cannot run bulk actions"}`, http.StatusForbidden)
+		return
+	}
+	var req bulkActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	var allowed map[string]bool
+	if !isSuperAdmin {
+		visible, err := s.db.GetVisibleAgentIDs(user.Username)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		allowed = make(map[string]bool, len(visible))
+		for _, id := range visible {
+			allowed[id] = true
+		}
+	}
+
+	a, err := s.CreateBulkAction(r.Context(), req, user.Username, allowed)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	_ = s.db.CreateAuditLog(user.Username, "create", "bulk_action", a.ID, r.RemoteAddr, r.UserAgent(), bulkActionSummary(a))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/bulk-actions/"+a.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(a)
+}
+
+// handleListBulkActions handles GET /api/bulk-actions?limit=: the latest bulk actions with their
+// progress; superadmins see everyone's, other users their own
+func (s *server) handleListBulkActions(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := listLimit(w, r, defaultBulkActionLimit, maxBulkActionLimit)
+	if !ok {
+		return
+	}
+	createdBy := user.Username
+	if isSuperAdmin {
+		createdBy = ""
+	}
+	actions, err := s.db.ListBulkActions(createdBy, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actions)
+}
+
+// getCallerBulkAction loads the bulk action of the path, writing 404 when it does not exist or
+// belongs to another user and the caller is not a superadmin
+func (s *server) getCallerBulkAction(w http.ResponseWriter, r *http.Request) (*BulkAction, string, bool) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	a, err := s.db.GetBulkAction(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if a == nil || (!isSuperAdmin && a.CreatedBy != user.Username) {
+		http.Error(w, `{"error":"bulk action not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	return a, user.Username, true
+}
+
+// handleGetBulkAction handles GET /api/bulk-actions/{id}: the status and progress of the job with
+// the result of each agent
+func (s *server) handleGetBulkAction(w http.ResponseWriter, r *http.Request) {
+	a, _, ok := s.getCallerBulkAction(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// handleCancelBulkAction handles POST /api/bulk-actions/{id}/cancel
+func (s *server) handleCancelBulkAction(w http.ResponseWriter, r *http.Request) {
+	a, username, ok := s.getCallerBulkAction(w, r)
+	if !ok {
+		return
+	}
+	if !s.CancelBulkAction(a.ID) {
+		http.Error(w, `{"error":"bulk action is not running on this gateway"}`, http.StatusConflict)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "cancel", "bulk_action", a.ID, r.RemoteAddr, r.UserAgent(), nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
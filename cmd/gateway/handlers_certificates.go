@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
@@ -112,3 +114,114 @@ func (s *server) handleDeleteCertificate(w http.ResponseWriter, r *http.Request)
 }
 
 
+
+// certificateUploadHTTP is the JSON body for POST /api/certificates (PEM as plain strings)
+type certificateUploadHTTP struct {
+	Domain         string   `json:"domain"`
+	Certificate    string   `json:"certificate"`
+	PrivateKey     string   `json:"private_key"`
+	Chain          string   `json:"chain"`
+	VaultPath      string   `json:"vault_path"`
+	CertType       string   `json:"cert_type"`
+	EnvironmentID  string   `json:"environment_id"`
+	AgentIDs       []string `json:"agent_ids"`
+	GroupID        string   `json:"group_id"`
+	BackupExisting bool     `json:"backup_existing"`
+	ReloadNginx    bool     `json:"reload_nginx"`
+}
+
+// handleUploadFleetCertificate stores a certificate in the inventory and deploys it to the selected agents
+func (s *server) handleUploadFleetCertificate(w http.ResponseWriter, r *http.Request) {
+	var body certificateUploadHTTP
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.canUserDeployTo(w, r, body.AgentIDs) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	resp, err := s.UploadCertificate(ctx, &pb.UploadCertificateRequest{
+		Domain:         body.Domain,
+		CertContent:    []byte(body.Certificate),
+		KeyContent:     []byte(body.PrivateKey),
+		ChainContent:   []byte(body.Chain),
+		VaultPath:      body.VaultPath,
+		CertType:       body.CertType,
+		EnvironmentId:  body.EnvironmentID,
+		AgentIds:       body.AgentIDs,
+		GroupId:        body.GroupID,
+		BackupExisting: body.BackupExisting,
+		ReloadNginx:    body.ReloadNginx,
+	})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleDeployFleetCertificate deploys an inventory certificate to agents, a group or an environment
+func (s *server) handleDeployFleetCertificate(w http.ResponseWriter, r *http.Request) {
+	var req pb.DeployCertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	req.CertificateId = r.PathValue("id")
+	if !s.canUserDeployTo(w, r, req.AgentIds) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	resp, err := s.DeployCertificate(ctx, &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGetCertificateInventory lists certificates stored in the gateway inventory
+func (s *server) handleGetCertificateInventory(w http.ResponseWriter, r *http.Request) {
+	withinDays, _ := strconv.Atoi(r.URL.Query().Get("expiring_within_days"))
+	resp, err := s.GetCertificateInventory(r.Context(), &pb.GetCertificateInventoryRequest{
+		EnvironmentId:      r.URL.Query().Get("environment_id"),
+		ExpiringOnly:       r.URL.Query().Get("expiring_only") == "true",
+		ExpiringWithinDays: int32(withinDays),
+	})
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp.Certificates)
+}
+
+// canUserDeployTo checks RBAC for explicitly targeted agents; it writes a 403 when access is denied.
+func (s *server) canUserDeployTo(w http.ResponseWriter, r *http.Request, agentIDs []string) bool {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		return true
+	}
+	for _, id := range agentIDs {
+		if resolved, ok := s.resolveAgentID(id); ok {
+			id = resolved
+		}
+		if !s.canUserAccessAgent(user.Username, id) {
+			http.Error(w, fmt.Sprintf(`{"error":"access denied to agent %s"}`, escapeJSON(id)), http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
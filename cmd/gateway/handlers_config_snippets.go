@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/google/uuid"
+)
+
+// getConfigSnippet loads the snippet of the path, writing 404 when it does not exist
+func (s *server) getConfigSnippet(w http.ResponseWriter, r *http.Request) (*ConfigSnippet, bool) {
+	sn, err := s.db.GetConfigSnippet(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, false
+	}
+	if sn == nil {
+		http.Error(w, `{"error":"snippet not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	return sn, true
+}
+
+// handleListConfigSnippets handles GET /api/config-snippets?category=: the snippets with how many
+// agents have them and how many of those are behind
+func (s *server) handleListConfigSnippets(w http.ResponseWriter, r *http.Request) {
+	snippets, err := s.db.ListConfigSnippets(r.URL.Query().Get("category"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snippets)
+}
+
+// handleCreateConfigSnippet handles POST /api/config-snippets (superadmin)
+func (s *server) handleCreateConfigSnippet(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req configSnippetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	sn, err := req.newConfigSnippet()
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	if existing, err := s.db.GetConfigSnippetBySlug(sn.Slug); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	} else if existing != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"a snippet with slug %s already exists"}`, escapeJSON(sn.Slug)), http.StatusConflict)
+		return
+	}
+	sn.ID, sn.CreatedBy = uuid.New().String(), username
+	if err := s.db.CreateConfigSnippet(sn); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "create", "config_snippet", sn.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug": sn.Slug,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sn)
+}
+
+// handleGetConfigSnippet handles GET /api/config-snippets/{id}: the snippet with the version each
+// agent has
+func (s *server) handleGetConfigSnippet(w http.ResponseWriter, r *http.Request) {
+	sn, ok := s.getConfigSnippet(w, r)
+	if !ok {
+		return
+	}
+	deployments, err := s.db.ListSnippetDeployments(sn)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	sn.Deployments = deployments
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sn)
+}
+
+// handleUpdateConfigSnippet handles PUT /api/config-snippets/{id} (superadmin). A content change
+// bumps the version; agents get it through POST /api/config-snippets/{id}/rollout.
+func (s *server) handleUpdateConfigSnippet(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	sn, ok := s.getConfigSnippet(w, r)
+	if !ok {
+		return
+	}
+	var req configSnippetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	var restored *ConfigSnippetVersion
+	if req.RestoreVersion > 0 {
+		v, err := s.db.GetConfigSnippetVersion(sn.ID, req.RestoreVersion)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		if v == nil {
+			http.Error(w, fmt.Sprintf(`{"error":"version %d not found"}`, req.RestoreVersion), http.StatusNotFound)
+			return
+		}
+		restored = v
+	}
+	changed, err := req.update(sn, restored)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	sn.UpdatedBy = username
+	if err := s.db.UpdateConfigSnippet(sn, changed); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		sn.Outdated = sn.Agents
+	}
+	_ = s.db.CreateAuditLog(username, "update", "config_snippet", sn.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":    sn.Slug,
+		"version": sn.Version,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sn)
+}
+
+// handleDeleteConfigSnippet handles DELETE /api/config-snippets/{id}?force= (superadmin). A snippet
+// written to agents is only deleted with force=true; the files stay on the agents.
+func (s *server) handleDeleteConfigSnippet(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	sn, ok := s.getConfigSnippet(w, r)
+	if !ok {
+		return
+	}
+	if sn.Agents > 0 && r.URL.Query().Get("force") != "true" {
+		http.Error(w, fmt.Sprintf(`{"error":"snippet is on %d agent(s); configs may still include it, delete with force=true"}`, sn.Agents), http.StatusConflict)
+		return
+	}
+	if _, err := s.db.DeleteConfigSnippet(sn.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "config_snippet", sn.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug": sn.Slug,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
+
+// handleListConfigSnippetVersions handles GET /api/config-snippets/{id}/versions, newest first
+func (s *server) handleListConfigSnippetVersions(w http.ResponseWriter, r *http.Request) {
+	sn, ok := s.getConfigSnippet(w, r)
+	if !ok {
+		return
+	}
+	versions, err := s.db.ListConfigSnippetVersions(sn.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// handleRolloutConfigSnippet handles POST /api/config-snippets/{id}/rollout (superadmin).
+// Body is a BatchConfigUpdateRequest without a config source; the current version is written to
+// the snippet's include file with the requested strategy. Without agent_ids, group_id or
+// environment_id it goes to every agent that has the snippet.
+func (s *server) handleRolloutConfigSnippet(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	sn, ok := s.getConfigSnippet(w, r)
+	if !ok {
+		return
+	}
+	var req pb.BatchConfigUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	req.SnippetId = sn.ID
+	req.TemplateId, req.RawContent, req.SourceAgentId = "", "", ""
+	if len(req.AgentIds) == 0 && req.GroupId == "" && req.EnvironmentId == "" {
+		deployments, err := s.db.ListSnippetDeployments(sn)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		for _, d := range deployments {
+			req.AgentIds = append(req.AgentIds, d.AgentID)
+		}
+		if len(req.AgentIds) == 0 {
+			http.Error(w, `{"error":"snippet is not on any agent yet; set agent_ids, group_id or environment_id"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Description == "" {
+		req.Description = fmt.Sprintf("Snippet %s v%d", sn.Slug, sn.Version)
+	}
+
+	resp, err := s.BatchUpdateConfig(r.Context(), &req)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	if !req.DryRun && !req.ValidateOnly {
+		_ = s.db.CreateAuditLog(username, "rollout", "config_snippet", sn.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+			"slug":     sn.Slug,
+			"version":  sn.Version,
+			"batch_id": resp.BatchId,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
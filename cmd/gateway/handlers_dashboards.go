@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+var dashboardPanelTypes = []string{"timeseries", "bar", "pie", "stat", "table"}
+
+// scopeMetricAgents narrows the requested agents to those the caller may see. ok is false when
+// nothing is visible, in which case the query must return no data.
+func (s *server) scopeMetricAgents(r *http.Request, requested []string) ([]string, bool, error) {
+	return s.scopeAgents(r.Context(), requested)
+}
+
+// scopeAgents is scopeMetricAgents for the caller of ctx, an HTTP or gRPC request (see callerOf)
+func (s *server) scopeAgents(ctx context.Context, requested []string) ([]string, bool, error) {
+	user, err := s.callerOf(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return requested, true, nil
+	}
+	if isSuperAdmin, err := s.db.IsSuperAdmin(user.Username); err != nil {
+		return nil, false, err
+	} else if isSuperAdmin {
+		return requested, true, nil
+	}
+
+	visible, err := s.db.GetVisibleAgentIDs(user.Username)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(requested) == 0 {
+		return visible, len(visible) > 0, nil
+	}
+	visibleSet := make(map[string]bool, len(visible))
+	for _, id := range visible {
+		visibleSet[id] = true
+	}
+	scoped := make([]string, 0, len(requested))
+	for _, id := range requested {
+		if resolved, ok := s.resolveAgentID(id); ok {
+			id = resolved
+		}
+		if visibleSet[id] {
+			scoped = append(scoped, id)
+		}
+	}
+	return scoped, len(scoped) > 0, nil
+}
+
+// runMetricQuery applies RBAC scoping and executes q, writing any error response itself.
+func (s *server) runMetricQuery(w http.ResponseWriter, r *http.Request, q MetricQuery) (*MetricQueryResult, bool) {
+	m, err := buildMetricQuery(q, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return nil, false
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	agents, ok, err := s.scopeMetricAgents(r, q.AgentIDs)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	if !ok {
+		return &MetricQueryResult{Query: q, From: m.From.Unix(), To: m.To.Unix(), Step: int64(m.Step.Seconds()), Series: []MetricSeries{}}, true
+	}
+	q.AgentIDs = agents
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	result, err := s.clickhouse.RunMetricQuery(ctx, q)
+	if err != nil {
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Metric query %s/%s failed: %v", q.Metric, q.Aggregation, err)
+		http.Error(w, `{"error":"query failed"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	return result, true
+}
+
+// GET /api/metrics/catalog
+// Lists the metrics, aggregations, dimensions, windows and intervals accepted by /api/metrics/query.
+func (s *server) handleGetMetricCatalog(w http.ResponseWriter, r *http.Request) {
+	windows := make([]string, 0, len(metricWindows))
+	for k := range metricWindows {
+		windows = append(windows, k)
+	}
+	intervals := make([]string, 0, len(metricIntervals)+1)
+	for k := range metricIntervals {
+		intervals = append(intervals, k)
+	}
+	byDuration := func(m map[string]time.Duration, keys []string) {
+		sort.Slice(keys, func(i, j int) bool { return m[keys[i]] < m[keys[j]] })
+	}
+	byDuration(metricWindows, windows)
+	byDuration(metricIntervals, intervals)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"metrics":     metricCatalog,
+		"windows":     windows,
+		"intervals":   append(intervals, "auto"),
+		"panel_types": dashboardPanelTypes,
+	})
+}
+
+// POST /api/metrics/query
+// Body: MetricQuery, e.g. {"metric":"latency","aggregation":"p95","group_by":"agent","window":"24h","interval":"auto"}
+func (s *server) handleMetricQuery(w http.ResponseWriter, r *http.Request) {
+	var q MetricQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	result, ok := s.runMetricQuery(w, r, q)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// dashboardAccess loads the {id} dashboard and checks the caller may view it (and edit it when
+// edit is set). It writes the error response itself.
+func (s *server) dashboardAccess(w http.ResponseWriter, r *http.Request, edit bool) (*Dashboard, *middleware.User, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, nil, false
+	}
+	d, err := s.db.GetDashboard(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, `{"error":"failed to load dashboard"}`, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if d == nil {
+		http.Error(w, `{"error":"dashboard not found"}`, http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username)
+	allowed := isSuperAdmin || d.Owner == user.Username
+	if !allowed && !edit {
+		allowed = d.Shared
+		if !allowed && d.TeamID != "" {
+			allowed, _ = s.db.IsTeamMember(d.TeamID, user.Username)
+		}
+	}
+	if !allowed {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, nil, false
+	}
+	return d, user, true
+}
+
+func validateDashboard(d *Dashboard) error {
+	if d.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if d.TimeWindow == "" {
+		d.TimeWindow = "24h"
+	}
+	if _, ok := metricWindows[d.TimeWindow]; !ok {
+		return fmt.Errorf("unknown time_window %q", d.TimeWindow)
+	}
+	return nil
+}
+
+func validateDashboardPanel(p *DashboardPanel) error {
+	if p.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if p.Type == "" {
+		p.Type = "timeseries"
+	}
+	if !stringInSlice(dashboardPanelTypes, p.Type) {
+		return fmt.Errorf("unknown panel type %q", p.Type)
+	}
+	if _, err := buildMetricQuery(p.Query, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GET /api/dashboards
+func (s *server) handleListDashboards(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username)
+	dashboards, err := s.db.ListDashboardsForUser(user.Username, isSuperAdmin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if dashboards == nil {
+		dashboards = []Dashboard{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboards)
+}
+
+// POST /api/dashboards
+// Body: {"name": "...", "description": "...", "team_id": "", "is_shared": false, "time_window": "24h", "panels": [...]}
+func (s *server) handleCreateDashboard(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var d Dashboard
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := validateDashboard(&d); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	for i := range d.Panels {
+		if err := validateDashboardPanel(&d.Panels[i]); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"panel %d: %s"}`, i, escapeJSON(err.Error())), http.StatusBadRequest)
+			return
+		}
+	}
+	if d.TeamID != "" {
+		if member, _ := s.db.IsTeamMember(d.TeamID, user.Username); !member {
+			http.Error(w, `{"error":"you are not a member of that team"}`, http.StatusForbidden)
+			return
+		}
+	}
+
+	d.Owner = user.Username
+	if err := s.db.CreateDashboard(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := range d.Panels {
+		p := &d.Panels[i]
+		p.ID, p.DashboardID = "", d.ID
+		if p.Position == 0 {
+			p.Position = i
+		}
+		if err := s.db.SaveDashboardPanel(p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// GET /api/dashboards/{id}
+func (s *server) handleGetDashboard(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.dashboardAccess(w, r, false)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// PUT /api/dashboards/{id}
+// Updates name, description, team, sharing and default window. Panels are managed separately.
+func (s *server) handleUpdateDashboard(w http.ResponseWriter, r *http.Request) {
+	d, user, ok := s.dashboardAccess(w, r, true)
+	if !ok {
+		return
+	}
+	teamID, owner, panels := d.TeamID, d.Owner, d.Panels
+	if err := json.NewDecoder(r.Body).Decode(d); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	d.ID, d.Owner, d.Panels = r.PathValue("id"), owner, panels
+	if err := validateDashboard(d); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if d.TeamID != "" && d.TeamID != teamID {
+		if member, _ := s.db.IsTeamMember(d.TeamID, user.Username); !member {
+			http.Error(w, `{"error":"you are not a member of that team"}`, http.StatusForbidden)
+			return
+		}
+	}
+	if err := s.db.UpdateDashboard(d); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// DELETE /api/dashboards/{id}
+func (s *server) handleDeleteDashboard(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.dashboardAccess(w, r, true)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteDashboard(d.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/dashboards/{id}/panels and PUT /api/dashboards/{id}/panels/{panelId}
+func (s *server) handleSaveDashboardPanel(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.dashboardAccess(w, r, true)
+	if !ok {
+		return
+	}
+	var p DashboardPanel
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	p.ID, p.DashboardID = r.PathValue("panelId"), d.ID
+	if p.ID != "" && findDashboardPanel(d, p.ID) == nil {
+		http.Error(w, `{"error":"panel not found"}`, http.StatusNotFound)
+		return
+	}
+	if p.ID == "" && p.Position == 0 {
+		p.Position = len(d.Panels)
+	}
+	if err := validateDashboardPanel(&p); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+
+	created := p.ID == ""
+	if err := s.db.SaveDashboardPanel(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+// DELETE /api/dashboards/{id}/panels/{panelId}
+func (s *server) handleDeleteDashboardPanel(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.dashboardAccess(w, r, true)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteDashboardPanel(d.ID, r.PathValue("panelId")); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/dashboards/{id}/panels/{panelId}/data?window=24h | from=&to=
+// Runs the saved panel query. The window/from/to parameters override the saved range, and the
+// dashboard's default window applies when the panel has none.
+func (s *server) handleGetDashboardPanelData(w http.ResponseWriter, r *http.Request) {
+	d, _, ok := s.dashboardAccess(w, r, false)
+	if !ok {
+		return
+	}
+	p := findDashboardPanel(d, r.PathValue("panelId"))
+	if p == nil {
+		http.Error(w, `{"error":"panel not found"}`, http.StatusNotFound)
+		return
+	}
+
+	q := applyPanelTimeRange(p.Query, d.TimeWindow, r.URL.Query().Get("window"), r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	result, ok := s.runMetricQuery(w, r, q)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func findDashboardPanel(d *Dashboard, id string) *DashboardPanel {
+	for i := range d.Panels {
+		if d.Panels[i].ID == id {
+			return &d.Panels[i]
+		}
+	}
+	return nil
+}
+
+// applyPanelTimeRange resolves the range for a panel render: explicit from/to, then a window
+// override, then the panel's own range, then the dashboard default.
+func applyPanelTimeRange(q MetricQuery, dashboardWindow, window, from, to string) MetricQuery {
+	if f, _ := strconv.ParseInt(from, 10, 64); f > 0 {
+		q.From = f
+		q.To, _ = strconv.ParseInt(to, 10, 64)
+		q.Window = ""
+		return q
+	}
+	if window != "" {
+		q.Window, q.From, q.To = window, 0, 0
+		return q
+	}
+	if q.Window == "" && q.From == 0 {
+		q.Window = dashboardWindow
+	}
+	return q
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// handleServerDisks handles GET /api/servers/{agentId}/disks: the disk usage and nginx log
+// growth the agent reported in the last 15 minutes
+func (s *server) handleServerDisks(w http.ResponseWriter, r *http.Request) {
+	agentID := r.PathValue("agentId")
+	if resolved, ok := s.resolveAgentID(agentID); ok {
+		agentID = resolved
+	}
+	if _, visible, err := s.scopeAgents(r.Context(), []string{agentID}); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	} else if !visible {
+		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse is not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	disks, logs, err := s.clickhouse.GetDiskUsage(ctx, agentID)
+	if err != nil {
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Disk usage query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"disks": disks, "log_files": logs})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/gorilla/websocket"
+)
+
+// parseEventFilter reads the feed filters: agent_id, project_id, environment_id, type (comma
+// separated), category, severity, since and until (RFC3339), after_id, before_id and limit
+func parseEventFilter(q url.Values) (EventFilter, error) {
+	f := EventFilter{
+		ProjectID:     q.Get("project_id"),
+		EnvironmentID: q.Get("environment_id"),
+		Category:      q.Get("category"),
+		Severity:      q.Get("severity"),
+		Limit:         defaultEventLimit,
+	}
+	if agentID := q.Get("agent_id"); agentID != "" {
+		f.AgentIDs = []string{agentID}
+	}
+	for _, t := range strings.Split(q.Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.Types = append(f.Types, t)
+		}
+	}
+	for name, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return f, fmt.Errorf("%s must be an RFC3339 time", name)
+			}
+			*dst = t
+		}
+	}
+	for name, dst := range map[string]*int64{"after_id": &f.AfterID, "before_id": &f.BeforeID} {
+		if v := q.Get(name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id < 0 {
+				return f, fmt.Errorf("%s must be a positive integer", name)
+			}
+			*dst = id
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return f, fmt.Errorf("limit must be a positive integer")
+		}
+		f.Limit = min(limit, maxEventLimit)
+	}
+	return f, nil
+}
+
+// scopeEventFilter restricts f to what the caller may see, writing any error response itself.
+// Superadmins see every event; other users see the events of a project they can read, or else
+// those of the agents visible to them.
+func (s *server) scopeEventFilter(w http.ResponseWriter, r *http.Request, f *EventFilter) bool {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+	for i, id := range f.AgentIDs {
+		if resolved, ok := s.resolveAgentID(id); ok {
+			f.AgentIDs[i] = resolved
+		}
+	}
+	if isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username); isSuperAdmin {
+		return true
+	}
+	if f.ProjectID != "" {
+		if ok, err := s.db.HasProjectAccess(user.Username, f.ProjectID, PermissionRead); err == nil && ok {
+			return true
+		}
+	}
+	visible, err := s.db.GetVisibleAgentIDs(user.Username)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return false
+	}
+	if f.AgentIDs != nil {
+		visible = slices.DeleteFunc(f.AgentIDs, func(id string) bool { return !slices.Contains(visible, id) })
+	}
+	f.AgentIDs = append([]string{}, visible...)
+	return true
+}
+
+// writeEvents lists the events matching f. next_before_id pages back through older events.
+func (s *server) writeEvents(w http.ResponseWriter, f EventFilter) {
+	events, err := s.db.ListEvents(f)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{"events": events}
+	if f.AfterID == 0 && len(events) == f.Limit {
+		resp["next_before_id"] = events[len(events)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleListEvents handles GET /api/events: the activity feed, newest first
+func (s *server) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	f, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if !s.scopeEventFilter(w, r, &f) {
+		return
+	}
+	s.writeEvents(w, f)
+}
+
+// handleListServerEvents handles GET /api/servers/{agentId}/events: the timeline of one agent
+func (s *server) handleListServerEvents(w http.ResponseWriter, r *http.Request) {
+	agentID, _, ok := s.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+	f, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	f.AgentIDs = []string{agentID}
+	s.writeEvents(w, f)
+}
+
+// handleListProjectEvents handles GET /api/projects/{id}/events: the timeline of a project's agents
+func (s *server) handleListProjectEvents(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	projectID := r.PathValue("id")
+	if ok, err := s.db.HasProjectAccess(user.Username, projectID, PermissionRead); err != nil || !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	f, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	f.ProjectID = projectID
+	s.writeEvents(w, f)
+}
+
+// handleEventStream handles GET /api/events/stream, a WebSocket receiving each new event matching
+// the feed filters as a JSON message. Events are read back from the database so the stream sees
+// those recorded by every gateway replica.
+func (s *server) handleEventStream(w http.ResponseWriter, r *http.Request, upgrader websocket.Upgrader) {
+	f, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if !s.scopeEventFilter(w, r, &f) {
+		return
+	}
+	if f.AfterID == 0 {
+		latest, err := s.db.ListEvents(EventFilter{Limit: 1})
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		// any non-zero AfterID pages forward; -1 matches every event of an empty feed
+		f.AfterID = -1
+		if len(latest) > 0 {
+			f.AfterID = latest[0].ID
+		}
+	}
+	f.BeforeID, f.Limit = 0, maxEventLimit
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logging.Ctx(r.Context(), ingestLog).Error().Msgf("Event stream upgrade error: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(eventStreamInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+		events, err := s.db.ListEvents(f)
+		if err != nil {
+			logging.Ctx(r.Context(), ingestLog).Error().Msgf("Event stream: failed to list events: %v", err)
+			continue
+		}
+		for _, ev := range events {
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := ws.WriteJSON(ev); err != nil {
+				return
+			}
+			f.AfterID = ev.ID
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// genericMetricsQuery parses the window and agent_id parameters shared by the custom metric
+// endpoints and scopes the agents to those the caller can see, writing any error response itself
+func (s *server) genericMetricsQuery(w http.ResponseWriter, r *http.Request) (start, end time.Time, window string, agentIDs []string, visible, ok bool) {
+	start, end, window, ok = parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return start, end, window, nil, false, false
+	}
+	var requested []string
+	if id := r.URL.Query().Get("agent_id"); id != "" {
+		requested = []string{id}
+	}
+	agentIDs, visible, err := s.scopeMetricAgents(r, requested)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return start, end, window, nil, false, false
+	}
+	return start, end, window, agentIDs, visible, true
+}
+
+// handleListGenericMetrics handles GET /api/generic-metrics?window=24h&agent_id=x: the metrics
+// agents' custom collectors reported within the window
+func (s *server) handleListGenericMetrics(w http.ResponseWriter, r *http.Request) {
+	start, end, window, agentIDs, visible, ok := s.genericMetricsQuery(w, r)
+	if !ok {
+		return
+	}
+	metrics := []GenericMetricInfo{}
+	if visible {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		var err error
+		if metrics, err = s.clickhouse.ListGenericMetrics(ctx, start, end, agentIDs); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window":  window,
+		"metrics": metrics,
+	})
+}
+
+// handleGetGenericMetricSeries handles
+// GET /api/generic-metrics/series?source=php-fpm&name=active_processes&label.pool=www&window=6h
+func (s *server) handleGetGenericMetricSeries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	source, name := q.Get("source"), q.Get("name")
+	if source == "" || name == "" {
+		http.Error(w, `{"error":"source and name are required"}`, http.StatusBadRequest)
+		return
+	}
+	labels := map[string]string{}
+	for key, values := range q {
+		if label := strings.TrimPrefix(key, "label."); label != key && label != "" && len(values) > 0 {
+			labels[label] = values[0]
+		}
+	}
+	start, end, window, agentIDs, visible, ok := s.genericMetricsQuery(w, r)
+	if !ok {
+		return
+	}
+	series := []GenericSeries{}
+	if visible {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		var err error
+		if series, err = s.clickhouse.GetGenericMetricSeries(ctx, source, name, labels, start, end, agentIDs); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"source": source,
+		"name":   name,
+		"window": window,
+		"step":   int(genericSeriesStep(start, end).Seconds()),
+		"series": series,
+	})
+}
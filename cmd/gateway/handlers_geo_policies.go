@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/google/uuid"
+)
+
+// getGeoPolicy loads the policy of the path, writing 404 when it does not exist
+func (s *server) getGeoPolicy(w http.ResponseWriter, r *http.Request) (*GeoPolicy, bool) {
+	p, err := s.db.GetGeoPolicy(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, false
+	}
+	if p == nil {
+		http.Error(w, `{"error":"geo policy not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	return p, true
+}
+
+// writeGeoPolicyResult writes a policy after it was applied, with the outcome on each agent
+// written now; 502 when no agent took it
+func writeGeoPolicyResult(w http.ResponseWriter, p *GeoPolicy, results []GeoPolicyAgent, created bool) {
+	p.Agents = results
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	applied := 0
+	for _, a := range results {
+		if a.Status == "applied" {
+			applied++
+		}
+	}
+	if applied == 0 {
+		code = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(p)
+}
+
+// handleListGeoPolicies handles GET /api/geo-policies
+func (s *server) handleListGeoPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := s.db.ListGeoPolicies()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+// handleCreateGeoPolicy handles POST /api/geo-policies: compiles the policy, records it and writes
+// it to the selected agents, returning the outcome on each
+func (s *server) handleCreateGeoPolicy(w http.ResponseWriter, r *http.Request) {
+	var req geoPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	p, err := req.newGeoPolicy()
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, p.Selector, "geo policies")
+	if !ok {
+		return
+	}
+	if exists, err := s.db.GeoPolicySlugExists(p.Slug); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	} else if exists {
+		http.Error(w, fmt.Sprintf(`{"error":"a geo policy with slug %s already exists"}`, escapeJSON(p.Slug)), http.StatusConflict)
+		return
+	}
+	networks, err := s.geoPolicyNetworks(p)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	files, count, err := renderGeoPolicy(p, networks)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	p.ID, p.CreatedBy, p.UpdatedBy = uuid.New().String(), username, username
+	if err := s.db.CreateGeoPolicy(p); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyGeoPolicy(context.WithoutCancel(r.Context()), p, agentIDs, files, count)
+	_ = s.db.CreateAuditLog(username, "create", "geo_policy", p.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":      p.Slug,
+		"mode":      p.Mode,
+		"countries": p.Countries,
+		"agents":    len(agentIDs),
+	})
+	writeGeoPolicyResult(w, p, results, true)
+}
+
+// handleGetGeoPolicy handles GET /api/geo-policies/{id}: the policy with the outcome on each agent
+func (s *server) handleGetGeoPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.getGeoPolicy(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// handleUpdateGeoPolicy handles PUT /api/geo-policies/{id}: changes the mode, countries, status code
+// or selector and writes the policy to its agents at once
+func (s *server) handleUpdateGeoPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.getGeoPolicy(w, r)
+	if !ok {
+		return
+	}
+	var req geoPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.update(p); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, p.Selector, "geo policies")
+	if !ok {
+		return
+	}
+	networks, err := s.geoPolicyNetworks(p)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	files, count, err := renderGeoPolicy(p, networks)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	p.UpdatedBy = username
+	if err := s.db.UpdateGeoPolicy(p); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyGeoPolicy(context.WithoutCancel(r.Context()), p, agentIDs, files, count)
+	_ = s.db.CreateAuditLog(username, "update", "geo_policy", p.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":      p.Slug,
+		"mode":      p.Mode,
+		"countries": p.Countries,
+		"agents":    len(agentIDs),
+	})
+	writeGeoPolicyResult(w, p, results, false)
+}
+
+// handleApplyGeoPolicy handles POST /api/geo-policies/{id}/apply: compiles the policy again, e.g.
+// after the GeoIP database was updated, and writes it to the agents of its selection
+func (s *server) handleApplyGeoPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.getGeoPolicy(w, r)
+	if !ok {
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, p.Selector, "geo policies")
+	if !ok {
+		return
+	}
+	networks, err := s.geoPolicyNetworks(p)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	files, count, err := renderGeoPolicy(p, networks)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	results := s.applyGeoPolicy(context.WithoutCancel(r.Context()), p, agentIDs, files, count)
+	_ = s.db.CreateAuditLog(username, "apply", "geo_policy", p.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":   p.Slug,
+		"agents": len(agentIDs),
+	})
+	writeGeoPolicyResult(w, p, results, false)
+}
+
+// handleDeleteGeoPolicy handles DELETE /api/geo-policies/{id}: lifts the policy on the agents it
+// was written to, leaving its files as comments so the includes stay valid, then deletes it
+func (s *server) handleDeleteGeoPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.getGeoPolicy(w, r)
+	if !ok {
+		return
+	}
+	_, username, ok := s.selectorTargets(w, r, p.Selector, "geo policies")
+	if !ok {
+		return
+	}
+	failed := map[string]string{}
+	for _, a := range p.Agents {
+		if err := s.writeManagedFiles(context.WithoutCancel(r.Context()), a.AgentID, geoPolicyOffFiles(p)); err != nil {
+			failed[a.AgentID] = err.Error()
+		}
+	}
+	if err := s.db.DeleteGeoPolicy(p.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	details := map[string]interface{}{"slug": p.Slug}
+	if len(failed) > 0 {
+		details["failed"] = failed
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "geo_policy", p.ID, r.RemoteAddr, r.UserAgent(), details)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "failed": failed})
+}
+
+// handleGeoPolicyStats handles GET /api/geo-policies/{id}/stats?window=24h (or from/to in unix
+// seconds): the requests the policy blocked by country and by agent
+func (s *server) handleGeoPolicyStats(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	p, ok := s.getGeoPolicy(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	m, err := buildMetricQuery(MetricQuery{Metric: "requests", Window: query.Get("window"), From: from, To: to, Interval: "auto"}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var agentIDs []string
+	for _, a := range p.Agents {
+		if isSuperAdmin || s.canUserAccessAgent(user.Username, a.AgentID) {
+			agentIDs = append(agentIDs, a.AgentID)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if len(agentIDs) == 0 {
+		json.NewEncoder(w).Encode(newGeoPolicyStats(m.From, m.To, nil))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	hits, err := s.clickhouse.GetGeoPolicyHits(ctx, m.From, m.To, agentIDs, p)
+	if err != nil {
+		logging.Ctx(r.Context(), securityLog).Error().Msgf("Geo policy stats query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(newGeoPolicyStats(m.From, m.To, hits))
+}
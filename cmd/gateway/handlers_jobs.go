@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 500
+)
+
+// jobCaller returns the caller and whether they are a superadmin, writing 401 when there is no
+// user. Superadmins see every job and bulk action, other users their own.
+func (s *server) jobCaller(w http.ResponseWriter, r *http.Request) (*middleware.User, bool, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, false, false
+	}
+	isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username)
+	return user, isSuperAdmin, true
+}
+
+// listLimit reads the limit query parameter, writing 400 when it is invalid
+func listLimit(w http.ResponseWriter, r *http.Request, def, max int) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+		return 0, false
+	}
+	return min(n, max), true
+}
+
+// handleListJobs handles GET /api/jobs?kind=&status=&limit=: the latest jobs with their progress
+func (s *server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := listLimit(w, r, defaultJobLimit, maxJobLimit)
+	if !ok {
+		return
+	}
+	createdBy := user.Username
+	if isSuperAdmin {
+		createdBy = ""
+	}
+	query := r.URL.Query()
+	jobs, err := s.db.ListJobs(createdBy, query.Get("kind"), query.Get("status"), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// getCallerJob loads the job of the path with the end of its log, writing 404 when it does not
+// exist or belongs to another user and the caller is not a superadmin
+func (s *server) getCallerJob(w http.ResponseWriter, r *http.Request) (*Job, string, bool) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	j, err := s.db.GetJob(r.PathValue("id"), jobLogLimit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if j == nil || (!isSuperAdmin && j.CreatedBy != user.Username) {
+		http.Error(w, `{"error":"job not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	return j, user.Username, true
+}
+
+// handleGetJob handles GET /api/jobs/{id}: the status, step and progress of a job with the last
+// 500 lines of its log
+func (s *server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	j, _, ok := s.getCallerJob(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(j)
+}
+
+// handleGetJobArtifact handles GET /api/jobs/{id}/artifact: downloads the file a job produced
+func (s *server) handleGetJobArtifact(w http.ResponseWriter, r *http.Request) {
+	j, _, ok := s.getCallerJob(w, r)
+	if !ok {
+		return
+	}
+	if j.Artifact == nil {
+		http.Error(w, `{"error":"job has no artifact"}`, http.StatusNotFound)
+		return
+	}
+	name, contentType, data, err := s.db.GetJobArtifact(j.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(data)
+}
+
+// handleCancelJob handles POST /api/jobs/{id}/cancel
+func (s *server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	j, username, ok := s.getCallerJob(w, r)
+	if !ok {
+		return
+	}
+	if !s.cancelJob(j.ID) {
+		http.Error(w, `{"error":"job is not running on this gateway"}`, http.StatusConflict)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "cancel", "job", j.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"kind": j.Kind,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
+
+// handleCreateReportJob handles POST /api/reports/jobs?start=&end=&agent_ids=&format=pdf|xlsx:
+// generates the report in the background and returns 202 with the job; the report is then
+// downloaded from /api/jobs/{id}/artifact
+func (s *server) handleCreateReportJob(w http.ResponseWriter, r *http.Request) {
+	user, _, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = "pdf"
+	case "pdf", "xlsx", "excel":
+	default:
+		http.Error(w, `{"error":"format must be pdf or xlsx"}`, http.StatusBadRequest)
+		return
+	}
+	start, end, agentIDs, ok := s.reportScope(w, r)
+	if !ok {
+		return
+	}
+	if !end.After(start) {
+		http.Error(w, `{"error":"end must be after start"}`, http.StatusBadRequest)
+		return
+	}
+
+	req := &pb.ReportRequest{StartTime: start.Unix(), EndTime: end.Unix(), AgentIds: agentIDs, Format: format}
+	j, err := s.startJob("report", "", user.Username, 2, func(ctx context.Context, run *jobRun) error {
+		run.setStep("Collecting report data")
+		report, err := s.GenerateReport(ctx, req)
+		if err != nil {
+			return err
+		}
+		run.advance(1)
+		run.setStep("Rendering the " + format + " report")
+		file, err := renderReport(report, start, end, format)
+		if err != nil {
+			return err
+		}
+		if err := s.db.SaveJobArtifact(run.job.ID, file.FileName, file.ContentType, file.Content); err != nil {
+			return fmt.Errorf("failed to save report: %w", err)
+		}
+		run.advance(1)
+		run.logf("info", "Report %s ready (%d bytes)", file.FileName, len(file.Content))
+		return nil
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/jobs/"+j.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(j)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// loadLogArchive resolves the {day} path value to an archive whose object still exists,
+// writing any error response itself
+func (s *server) loadLogArchive(w http.ResponseWriter, r *http.Request) (*LogArchive, time.Time, bool) {
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse is not available"}`, http.StatusServiceUnavailable)
+		return nil, time.Time{}, false
+	}
+	day, err := time.Parse("2006-01-02", r.PathValue("day"))
+	if err != nil {
+		http.Error(w, `{"error":"day must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return nil, time.Time{}, false
+	}
+	archive, err := s.db.GetLogArchive(r.PathValue("day"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, time.Time{}, false
+	}
+	if archive == nil {
+		http.Error(w, `{"error":"day is not archived"}`, http.StatusNotFound)
+		return nil, time.Time{}, false
+	}
+	if archive.DeletedAt != nil {
+		http.Error(w, `{"error":"archive was deleted by retention"}`, http.StatusGone)
+		return nil, time.Time{}, false
+	}
+	return archive, day, true
+}
+
+// handleListLogArchives handles GET /api/archive/logs
+func (s *server) handleListLogArchives(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	archives, err := s.db.ListLogArchives()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	cfg := s.config.Archive
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled":        cfg.Enabled,
+		"url":            cfg.URL,
+		"run_at":         cfg.RunAt,
+		"retention_days": cfg.RetentionDays,
+		"archives":       archives,
+	})
+}
+
+// handleQueryLogArchive handles GET /api/archive/logs/{day}?agent_id=x&status=502&limit=100,
+// reading the day's logs straight from object storage
+func (s *server) handleQueryLogArchive(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	archive, _, ok := s.loadLogArchive(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	status, _ := strconv.Atoi(q.Get("status"))
+	limit := 100
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		limit = min(n, 1000)
+	}
+	agentID := q.Get("agent_id")
+	if agentID != "" {
+		if resolved, ok := s.resolveAgentID(agentID); ok {
+			agentID = resolved
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	entries, err := s.clickhouse.QueryArchivedAccessLogs(ctx, s.archiveObjectFor(archive.ObjectURL), agentID, status, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"day":     archive.Day,
+		"entries": entries,
+	})
+}
+
+// handleRestoreLogArchive handles POST /api/archive/logs/{day}/restore: loads the day into
+// access_logs_restored
+func (s *server) handleRestoreLogArchive(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	archive, day, ok := s.loadLogArchive(w, r)
+	if !ok {
+		return
+	}
+	// Not tied to the request: a client disconnecting must not leave a half-loaded day
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	if err := s.clickhouse.RestoreAccessLogDay(ctx, day, s.archiveObjectFor(archive.ObjectURL)); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+	if err := s.db.SetLogArchiveRestored(archive.Day, true); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "restore", "log_archive", archive.Day, r.RemoteAddr, r.UserAgent(), nil)
+	archive, _ = s.db.GetLogArchive(archive.Day)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(archive)
+}
+
+// handleReleaseLogArchive handles DELETE /api/archive/logs/{day}/restore: removes a restored day
+// from access_logs_restored; the archive itself is kept
+func (s *server) handleReleaseLogArchive(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	archive, day, ok := s.loadLogArchive(w, r)
+	if !ok {
+		return
+	}
+	if err := s.clickhouse.ReleaseAccessLogDay(r.Context(), day); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.SetLogArchiveRestored(archive.Day, false); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "release", "log_archive", archive.Day, r.RemoteAddr, r.UserAgent(), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// LogLevels is the default log level and the level of every component
+type LogLevels struct {
+	Default    string                   `json:"default"`
+	Components []logging.ComponentLevel `json:"components"`
+}
+
+// LogLevelUpdate sets the level of a component, or the default level when Component is empty.
+// An empty Level makes the component follow the default again.
+type LogLevelUpdate struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+func currentLogLevels() LogLevels {
+	def, components := logging.Levels()
+	return LogLevels{Default: def, Components: components}
+}
+
+// handleGetLogLevels handles GET /api/ops/log-levels: the default log level and the level of every
+// component. Superadmin only.
+func (s *server) handleGetLogLevels(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentLogLevels())
+}
+
+// handlePutLogLevels handles PUT /api/ops/log-levels: changes the log level of a component, or the
+// default level, on this gateway until it restarts. Superadmin only.
+func (s *server) handlePutLogLevels(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req LogLevelUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Component == "" && req.Level == "" {
+		http.Error(w, `{"error":"level is required to change the default level"}`, http.StatusBadRequest)
+		return
+	}
+	if err := logging.SetLevel(req.Component, req.Level); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "log_level", req.Component, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"level": req.Level,
+	})
+	logging.Ctx(r.Context(), apiLog).Info().Str("log_component", req.Component).Str("level", req.Level).Str("user", username).Msg("Log level changed")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentLogLevels())
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/avika-ai/avika/internal/common/redact"
+)
+
+// LogRedactionAgent is the scrubbing an agent of an environment reports it applies
+type LogRedactionAgent struct {
+	AgentID  string            `json:"agent_id"`
+	Status   string            `json:"status"` // in_sync, drifted, offline, unknown
+	Settings map[string]string `json:"settings,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// environmentRedactionAgents asks each agent of an environment for its LOG_REDACT settings,
+// batchPushConcurrency at a time; with push, the policy is applied first
+func (s *server) environmentRedactionAgents(envID string, policy LogRedactionPolicy, push bool) ([]LogRedactionAgent, error) {
+	servers, err := s.db.ListServersInEnvironment(envID)
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]string(nil)
+	if push {
+		settings = map[string]string{
+			"LOG_REDACT":             strings.Join(policy.Rules, ","),
+			"LOG_REDACT_PARAMS":      strings.Join(policy.Params, ","),
+			"LOG_REDACT_IPV4_PREFIX": strconv.Itoa(policy.IPv4Prefix),
+			"LOG_REDACT_IPV6_PREFIX": strconv.Itoa(policy.IPv6Prefix),
+		}
+	}
+
+	agents := make([]LogRedactionAgent, len(servers))
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for i, sa := range servers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(a *LogRedactionAgent, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			a.AgentID = id
+			res, err := s.configureAgent(id, settings)
+			switch {
+			case err == errAgentOffline:
+				a.Status = "offline"
+				return
+			case err != nil:
+				a.Status, a.Error = "unknown", err.Error()
+				return
+			case !res.Success:
+				a.Error = res.ErrorMessage
+			}
+			a.Settings = map[string]string{}
+			for _, key := range logRedactionKeys {
+				if val, ok := res.AppliedSettings[key]; ok {
+					a.Settings[key] = val
+				}
+			}
+			a.Status = "drifted"
+			if len(a.Settings) == 0 {
+				// Agents that predate redaction report none of the settings
+				a.Status, a.Error = "unknown", "agent does not support log redaction"
+			} else if policy.matches(a.Settings) {
+				a.Status = "in_sync"
+			}
+		}(&agents[i], sa.AgentID)
+	}
+	wg.Wait()
+	return agents, nil
+}
+
+// writeLogRedaction writes the policy of an environment with what its agents apply
+func (s *server) writeLogRedaction(w http.ResponseWriter, env *Environment, policy LogRedactionPolicy, agents []LogRedactionAgent) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"environment_id":     env.ID,
+		"policy":             policy,
+		"default_params":     redact.DefaultParams,
+		"enforced_on_ingest": s.logRedaction != nil,
+		"agents":             agents,
+		"in_sync":            countRedactionAgents(agents, "in_sync"),
+	})
+}
+
+// handleGetLogRedaction handles GET /api/environments/{id}/log-redaction: the access log scrubbing
+// the environment's agent config bundles select, whether the gateway also enforces it on ingest,
+// and the settings each agent reports it runs with
+func (s *server) handleGetLogRedaction(w http.ResponseWriter, r *http.Request) {
+	env, _, ok := s.loadRetentionEnvironment(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	policy, err := s.environmentLogRedactionPolicy(env)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	agents, err := s.environmentRedactionAgents(env.ID, policy, false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.writeLogRedaction(w, env, policy, agents)
+}
+
+// handleApplyLogRedaction handles POST /api/environments/{id}/log-redaction/apply: pushes the
+// environment's scrubbing to its online agents, which apply it without a restart and save it to
+// avika-agent.conf, and returns what each then runs with
+func (s *server) handleApplyLogRedaction(w http.ResponseWriter, r *http.Request) {
+	env, username, ok := s.loadRetentionEnvironment(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	policy, err := s.environmentLogRedactionPolicy(env)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	agents, err := s.environmentRedactionAgents(env.ID, policy, true)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "apply", "log_redaction", env.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"rules":       policy.Rules,
+		"params":      policy.Params,
+		"ipv4_prefix": policy.IPv4Prefix,
+		"ipv6_prefix": policy.IPv6Prefix,
+		"agents":      len(agents),
+		"in_sync":     countRedactionAgents(agents, "in_sync"),
+	})
+	s.writeLogRedaction(w, env, policy, agents)
+}
+
+func countRedactionAgents(agents []LogRedactionAgent, status string) int {
+	n := 0
+	for _, a := range agents {
+		if a.Status == status {
+			n++
+		}
+	}
+	return n
+}
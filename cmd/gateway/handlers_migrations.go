@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/avika-ai/avika/cmd/gateway/migrations"
+)
+
+// MigrationReport is where the schema of one database stands against the gateway's migrations
+type MigrationReport struct {
+	Available      bool                `json:"available"`
+	CurrentVersion string              `json:"current_version,omitempty"` // latest applied
+	Pending        int                 `json:"pending"`
+	Modified       int                 `json:"modified"` // applied, then changed in the gateway
+	Unknown        int                 `json:"unknown"`  // applied by a newer gateway
+	Error          string              `json:"error,omitempty"`
+	Migrations     []migrations.Status `json:"migrations,omitempty"`
+}
+
+// newMigrationReport summarizes the status of a database's migrations
+func newMigrationReport(list []migrations.Status, err error) MigrationReport {
+	if err != nil {
+		return MigrationReport{Available: true, Error: err.Error()}
+	}
+	r := MigrationReport{Available: true, Migrations: list}
+	for _, m := range list {
+		switch {
+		case !m.Applied:
+			r.Pending++
+		case m.Unknown:
+			r.Unknown++
+		case m.Modified:
+			r.Modified++
+		}
+		if m.Applied {
+			r.CurrentVersion = m.Version
+		}
+	}
+	return r
+}
+
+// MigrationStatus reports every embedded PostgreSQL migration and whether it was applied
+func (db *DB) MigrationStatus() ([]migrations.Status, error) {
+	return migrations.NewRunner(db.conn).Status()
+}
+
+// handleGetMigrations handles GET /api/ops/migrations: the schema migrations of PostgreSQL and
+// ClickHouse, applied and pending, to check an upgrade went through on every database. Superadmin
+// only.
+func (s *server) handleGetMigrations(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	resp := map[string]MigrationReport{
+		"postgres":   newMigrationReport(s.db.MigrationStatus()),
+		"clickhouse": {},
+	}
+	if s.clickhouse != nil {
+		resp["clickhouse"] = newMigrationReport(s.clickhouse.MigrationStatus(r.Context()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
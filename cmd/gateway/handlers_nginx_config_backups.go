@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 )
 
@@ -55,7 +55,7 @@ func (srv *server) handleListNginxConfigBackups(w http.ResponseWriter, r *http.R
 
 	backups, err := srv.db.ListConfigBackups(ctx, resolved, limit)
 	if err != nil {
-		log.Printf("Failed to list config backups for %s: %v", resolved, err)
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Failed to list config backups for %s: %v", resolved, err)
 		http.Error(w, `{"error":"failed to fetch backups from database"}`, http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// handleNginxInventory handles GET /api/fleet/nginx-modules: the nginx modules and OpenSSL
+// versions of the agents the caller can see. With ?module=brotli the agents are split by
+// whether they have a matching module.
+func (s *server) handleNginxInventory(w http.ResponseWriter, r *http.Request) {
+	agents, err := s.db.ListAgentNginxBuilds()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		if isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username); !isSuperAdmin {
+			visible, err := s.db.GetVisibleAgentIDs(user.Username)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+				return
+			}
+			allowed := make(map[string]bool, len(visible))
+			for _, id := range visible {
+				allowed[id] = true
+			}
+			all := agents
+			agents = agents[:0:0]
+			for _, a := range all {
+				if allowed[a.AgentID] {
+					agents = append(agents, a)
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildNginxInventory(agents, strings.TrimSpace(r.URL.Query().Get("module"))))
+}
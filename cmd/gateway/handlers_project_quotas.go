@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+const (
+	defaultProjectUsageDays = 30
+	maxProjectUsageDays     = 400
+)
+
+// ProjectUsage is what a project ingested over a range of days, against its quota
+type ProjectUsage struct {
+	ProjectID   string            `json:"project_id"`
+	ProjectName string            `json:"project_name,omitempty"`
+	ProjectSlug string            `json:"project_slug,omitempty"`
+	Quota       *ProjectQuota     `json:"quota"`
+	Today       ProjectUsageDay   `json:"today"`
+	Status      string            `json:"status"`                  // ok, soft_exceeded, hard_exceeded
+	QuotaPct    float64           `json:"quota_percent,omitempty"` // of the hard limit today, else the soft one
+	Window      ProjectUsageDay   `json:"window"`                  // sums over the days requested; day is the first
+	Days        []ProjectUsageDay `json:"days,omitempty"`
+}
+
+// setStatus fills in where today's usage stands against the quota
+func (u *ProjectUsage) setStatus() {
+	u.Status = "ok"
+	q := u.Quota
+	if q == nil {
+		return
+	}
+	used := u.Today.StoredBytes
+	switch {
+	case q.HardBytesPerDay > 0 && used >= q.HardBytesPerDay:
+		u.Status = "hard_exceeded"
+	case q.SoftBytesPerDay > 0 && used >= q.SoftBytesPerDay:
+		u.Status = "soft_exceeded"
+	}
+	if limit := q.HardBytesPerDay; limit > 0 {
+		u.QuotaPct = float64(used) * 100 / float64(limit)
+	} else if limit := q.SoftBytesPerDay; limit > 0 {
+		u.QuotaPct = float64(used) * 100 / float64(limit)
+	}
+}
+
+// usageDays parses the days query parameter: how many UTC days, today included, usage covers
+func usageDays(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("days")
+	if v == "" {
+		return defaultProjectUsageDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 || days > maxProjectUsageDays {
+		return 0, fmt.Errorf("days must be from 1 to %d", maxProjectUsageDays)
+	}
+	return days, nil
+}
+
+// loadQuotaProject loads the project of the path for a user with permission on it, writing the
+// error response when there is none
+func (s *server) loadQuotaProject(w http.ResponseWriter, r *http.Request, perm Permission) (*Project, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	projectID := r.PathValue("id")
+	if hasAccess, _ := s.db.HasProjectAccess(user.Username, projectID, perm); !hasAccess {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	project, err := s.db.GetProject(projectID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, false
+	}
+	if project == nil {
+		http.Error(w, `{"error":"project not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	return project, true
+}
+
+// handleGetProjectQuota handles GET /api/projects/{id}/quota: the daily ingest quota of a project,
+// with zero limits when it has none
+func (s *server) handleGetProjectQuota(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadQuotaProject(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	q, err := s.db.GetProjectQuota(project.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if q == nil {
+		q = &ProjectQuota{ProjectID: project.ID, HardAction: quotaActionSample, SamplePercent: defaultQuotaSamplePercent}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(q)
+}
+
+// handlePutProjectQuota handles PUT /api/projects/{id}/quota: sets the bytes of access logs the
+// project may store per UTC day. Past soft_bytes_per_day its admins are warned; past
+// hard_bytes_per_day entries are sampled or dropped. Zero limits remove the quota. Superadmin only,
+// since the quota protects the cluster the projects share.
+func (s *server) handlePutProjectQuota(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	project, ok := s.loadQuotaProject(w, r, PermissionAdmin)
+	if !ok {
+		return
+	}
+	var q ProjectQuota
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := q.validate(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	q.ProjectID, q.UpdatedBy = project.ID, username
+
+	var err error
+	action := "update"
+	if q.SoftBytesPerDay == 0 && q.HardBytesPerDay == 0 {
+		action = "delete"
+		err = s.db.DeleteProjectQuota(project.ID)
+		q.UpdatedAt = time.Now().UTC()
+	} else {
+		err = s.db.SaveProjectQuota(&q)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if s.ingestQuotas != nil {
+		s.ingestQuotas.Invalidate()
+	}
+	_ = s.db.CreateAuditLog(username, action, "project_quota", project.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"soft_bytes_per_day": q.SoftBytesPerDay,
+		"hard_bytes_per_day": q.HardBytesPerDay,
+		"hard_action":        q.HardAction,
+		"sample_percent":     q.SamplePercent,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(q)
+}
+
+// handleGetProjectUsage handles GET /api/projects/{id}/usage: the access log bytes a project
+// stored and had dropped on each of the last days (default 30), and today's usage against its quota
+func (s *server) handleGetProjectUsage(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadQuotaProject(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	days, err := usageDays(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	today := usageDay(time.Now())
+	since := today.AddDate(0, 0, 1-days)
+	q, err := s.db.GetProjectQuota(project.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	list, err := s.db.ListProjectIngestUsage(project.ID, since)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+
+	u := ProjectUsage{ProjectID: project.ID, ProjectName: project.Name, ProjectSlug: project.Slug, Quota: q, Days: list}
+	u.Today.Day = today.Format("2006-01-02")
+	u.Window.Day = since.Format("2006-01-02")
+	for _, d := range list {
+		if d.Day == u.Today.Day {
+			u.Today = d
+		}
+		u.Window.StoredBytes += d.StoredBytes
+		u.Window.StoredEntries += d.StoredEntries
+		u.Window.DroppedBytes += d.DroppedBytes
+		u.Window.DroppedEntries += d.DroppedEntries
+	}
+	u.setStatus()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// handleListProjectUsage handles GET /api/usage/projects: every project's usage today against its
+// quota and over the last days (default 30), heaviest today first, to find noisy tenants.
+// Superadmin only.
+func (s *server) handleListProjectUsage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	days, err := usageDays(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	today := usageDay(time.Now())
+	since := today.AddDate(0, 0, 1-days)
+	projects, err := s.db.ListProjects()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	quotas, err := s.db.ListProjectQuotas()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	todays, err := s.db.SumProjectIngestUsage(today)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	windows, err := s.db.SumProjectIngestUsage(since)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+
+	usage := make([]ProjectUsage, 0, len(projects))
+	for _, p := range projects {
+		u := ProjectUsage{ProjectID: p.ID, ProjectName: p.Name, ProjectSlug: p.Slug, Today: todays[p.ID], Window: windows[p.ID]}
+		if q, ok := quotas[p.ID]; ok {
+			u.Quota = &q
+		}
+		u.Today.Day = today.Format("2006-01-02")
+		u.Window.Day = since.Format("2006-01-02")
+		u.setStatus()
+		usage = append(usage, u)
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Today.StoredBytes > usage[j].Today.StoredBytes })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"day":      today.Format("2006-01-02"),
+		"since":    since.Format("2006-01-02"),
+		"projects": usage,
+	})
+}
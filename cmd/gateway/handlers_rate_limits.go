@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// RateLimitAgentStatus is the latest push to an agent and whether it matches the current rules
+type RateLimitAgentStatus struct {
+	RateLimitDeployment
+	InSync  bool   `json:"in_sync"`
+	Limited uint64 `json:"limited"` // Rejections across all zones in the window
+}
+
+// RateLimitStatusView is the response of GET /api/security/rate-limits/status
+type RateLimitStatusView struct {
+	Window      string                 `json:"window"`
+	CurrentHash string                 `json:"current_hash"`
+	Zones       []string               `json:"zones"`
+	Agents      []RateLimitAgentStatus `json:"agents"`
+	Counters    []RateLimitCounter     `json:"counters"`
+}
+
+func (s *server) handleListRateLimitRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.db.ListRateLimitRules()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// handleCreateRateLimitRule handles POST /api/security/rate-limits
+func (s *server) handleCreateRateLimitRule(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	rule := RateLimitRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := validateRateLimitRule(&rule); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	rule.CreatedBy = username
+	if err := s.db.CreateRateLimitRule(&rule); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			http.Error(w, `{"error":"a rule with this zone already exists"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "create_rate_limit", "rate_limit", rule.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"zone": rule.Zone,
+		"rate": fmt.Sprintf("%d%s", rule.Rate, rule.RateUnit),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// handleUpdateRateLimitRule handles PUT /api/security/rate-limits/{id}
+func (s *server) handleUpdateRateLimitRule(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	rule, err := s.db.GetRateLimitRule(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if rule == nil {
+		http.Error(w, `{"error":"rate limit rule not found"}`, http.StatusNotFound)
+		return
+	}
+	// Decode over the stored rule so omitted fields keep their values
+	if err := json.NewDecoder(r.Body).Decode(rule); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	rule.ID = r.PathValue("id")
+	if err := validateRateLimitRule(rule); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.db.UpdateRateLimitRule(rule); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			http.Error(w, `{"error":"a rule with this zone already exists"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "update_rate_limit", "rate_limit", rule.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"zone":    rule.Zone,
+		"rate":    fmt.Sprintf("%d%s", rule.Rate, rule.RateUnit),
+		"enabled": fmt.Sprintf("%t", rule.Enabled),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// handleDeleteRateLimitRule handles DELETE /api/security/rate-limits/{id}
+func (s *server) handleDeleteRateLimitRule(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	found, err := s.db.DeleteRateLimitRule(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, `{"error":"rate limit rule not found"}`, http.StatusNotFound)
+		return
+	}
+	s.db.CreateAuditLog(username, "delete_rate_limit", "rate_limit", id, r.RemoteAddr, r.UserAgent(), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePushRateLimits handles POST /api/security/rate-limits/push. It renders the enabled rules into
+// one include, deploys it to the selected agents (nginx -t and reload on each) and records the outcome.
+func (s *server) handlePushRateLimits(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AgentIDs      []string `json:"agent_ids"`
+		GroupID       string   `json:"group_id"`
+		EnvironmentID string   `json:"environment_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	targets, err := s.resolveTargetAgents(ctx, req.AgentIDs, req.GroupID, req.EnvironmentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if len(targets) == 0 {
+		http.Error(w, `{"error":"no target agents"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.canUserDeployTo(w, r, targets) {
+		return
+	}
+
+	rules, err := s.db.ListRateLimitRules()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	content, zones := renderRateLimitConfig(rules)
+	hash := sha256Hex(content)
+	path := s.config.Security.RateLimitConfigPath
+
+	var username string
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		username = user.Username
+	}
+
+	results := make([]IncludePushResult, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for i, id := range targets {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = IncludePushResult{AgentID: id}
+			d := &RateLimitDeployment{AgentID: id, ConfigHash: hash, Zones: zones, Status: "applied", AppliedBy: username}
+			if _, _, err := s.pushBatchTarget(ctx, &batchTarget{agentID: id, configPath: path, content: content}, true); err != nil {
+				results[i].Error = err.Error()
+				d.Status, d.Error = "failed", err.Error()
+			} else {
+				results[i].Success = true
+			}
+			if err := s.db.RecordRateLimitDeployment(d); err != nil {
+				logging.Ctx(r.Context(), securityLog).Error().Msgf("Failed to record rate limit deployment for %s: %v", id, err)
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	if username != "" {
+		s.db.CreateAuditLog(username, "push_rate_limits", "rate_limit", strings.Join(targets, ","), r.RemoteAddr, r.UserAgent(), map[string]string{
+			"zones": strings.Join(zones, ","),
+			"hash":  hash,
+			"path":  path,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleGetRateLimitStatus handles GET /api/security/rate-limits/status?window=24h
+func (s *server) handleGetRateLimitStatus(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.parseSecurityScope(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := s.db.ListRateLimitRules()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	content, zones := renderRateLimitConfig(rules)
+	view := RateLimitStatusView{
+		Window:      sc.window,
+		CurrentHash: sha256Hex(content),
+		Zones:       zones,
+		Agents:      []RateLimitAgentStatus{},
+		Counters:    []RateLimitCounter{},
+	}
+	if !sc.visible {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+		return
+	}
+
+	if s.clickhouse != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		if view.Counters, err = s.clickhouse.GetRateLimitCounters(ctx, sc.start, sc.end, sc.agentIDs); err != nil {
+			logging.Ctx(r.Context(), securityLog).Error().Msgf("GetRateLimitCounters failed: %v", err)
+			http.Error(w, `{"error":"failed to query rate limit counters"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	limited := make(map[string]uint64)
+	for _, c := range view.Counters {
+		limited[c.AgentID] += c.Limited
+	}
+
+	deployments, err := s.db.ListRateLimitDeployments()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	for _, d := range deployments {
+		if len(sc.agentIDs) > 0 && !stringInSlice(sc.agentIDs, d.AgentID) {
+			continue
+		}
+		view.Agents = append(view.Agents, RateLimitAgentStatus{
+			RateLimitDeployment: d,
+			InSync:              d.Status == "applied" && d.ConfigHash == view.CurrentHash,
+			Limited:             limited[d.AgentID],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -11,6 +10,7 @@ import (
 	"time"
 
 	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
 // slugify converts a name to a URL-friendly slug
@@ -45,7 +45,7 @@ func (srv *server) handleListProjects(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("Error listing projects for user %s (superadmin=%v): %v", user.Username, isSuperAdmin, err)
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Error listing projects for user %s (superadmin=%v): %v", user.Username, isSuperAdmin, err)
 		http.Error(w, fmt.Sprintf(`{"error":"Failed to fetch projects","message":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
 		return
 	}
@@ -122,6 +122,8 @@ func (srv *server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 	// Do not create default environments; they are created when agents connect
 	// with LABEL_ENVIRONMENT/AVIKA_LABEL_ENVIRONMENT or by admin in Settings.
 
+	srv.enableDefaultAlertPacks(project)
+
 	// Audit log
 	if err := srv.db.CreateAuditLog(user.Username, "create", "project", project.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
 		"name": req.Name,
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// maxRPCBody caps the JSON body of a mirrored call; config updates carry whole files
+const maxRPCBody = 16 << 20
+
+// rpcMethod is a unary AgentService RPC served over HTTP at /api/rpc/{method}
+type rpcMethod struct {
+	desc     grpc.MethodDesc
+	input    protoreflect.MessageType
+	output   protoreflect.FullName
+	readOnly bool
+}
+
+// readOnlyRPCs are the RPCs besides Get* and List* that change nothing; viewers may call them and
+// they accept GET
+var readOnlyRPCs = map[string]bool{
+	"ValidateConfig":             true,
+	"RenderConfigTemplate":       true,
+	"PreviewMaintenanceTemplate": true,
+	"CheckDrift":                 true,
+	"DownloadReport":             true,
+}
+
+// rpcAccess is who may call an RPC over HTTP, mirroring the checks of its HTTP route
+type rpcAccess int
+
+const (
+	// rpcDenied is for RPCs without an entry in rpcAccessLevels; nobody may call them over HTTP
+	rpcDenied rpcAccess = iota
+	// rpcRead RPCs need read access to the projects and agents the request names
+	rpcRead
+	// rpcOperate RPCs need operate access to the projects and agents the request names; without
+	// a target they act on the whole fleet and are for superadmins
+	rpcOperate
+	// rpcProjectAdmin RPCs need admin access to the project of the group or environment named
+	rpcProjectAdmin
+	// rpcSuperAdmin RPCs act on the whole fleet, like the routes behind requireSuperAdmin
+	rpcSuperAdmin
+)
+
+// rpcAccessLevels gives every mirrored RPC its access level; an RPC missing here is refused
+var rpcAccessLevels = map[string]rpcAccess{
+	// NGINX and agents
+	"GetConfig":            rpcRead,
+	"UpdateConfig":         rpcOperate,
+	"ValidateConfig":       rpcRead,
+	"ReloadNginx":          rpcOperate,
+	"RestartNginx":         rpcOperate,
+	"StopNginx":            rpcOperate,
+	"ListCertificates":     rpcRead,
+	"ListAgents":           rpcRead,
+	"GetAgent":             rpcRead,
+	"RemoveAgent":          rpcSuperAdmin,
+	"ApplyAugment":         rpcOperate,
+	"UpdateAgent":          rpcOperate,
+	"GetUpdateStatus":      rpcRead,
+	"GetAgentConfig":       rpcRead,
+	"UpdateAgentConfig":    rpcOperate,
+	"GetServiceStatus":     rpcRead,
+	"ListUpstreams":        rpcRead,
+	"UpdateUpstreamServer": rpcOperate,
+	"UpgradeNginxBinary":   rpcOperate,
+	"UpdateSiteLocation":   rpcOperate,
+
+	// Analytics and reports
+	"GetUptimeReports":   rpcRead,
+	"GetAnalytics":       rpcRead,
+	"GetTraces":          rpcRead,
+	"GetTraceDetails":    rpcRead,
+	"GetRecommendations": rpcRead,
+	"GenerateReport":     rpcRead,
+	"SendReport":         rpcOperate,
+	"DownloadReport":     rpcRead,
+
+	// Project admins manage their project's rules through the alert packs; other rules are fleet-wide
+	"ListAlertRules":  rpcRead,
+	"CreateAlertRule": rpcSuperAdmin,
+	"DeleteAlertRule": rpcSuperAdmin,
+
+	// Groups belong to an environment and are managed by its project's admins, like environments
+	"ListGroups":           rpcRead,
+	"GetGroup":             rpcRead,
+	"GetGroupAgents":       rpcRead,
+	"CreateGroup":          rpcProjectAdmin,
+	"UpdateGroup":          rpcProjectAdmin,
+	"DeleteGroup":          rpcProjectAdmin,
+	"AddAgentsToGroup":     rpcProjectAdmin,
+	"RemoveAgentFromGroup": rpcProjectAdmin,
+	"SetGoldenAgent":       rpcProjectAdmin,
+
+	// Drift and environment comparison
+	"CheckDrift":          rpcRead,
+	"GetDriftReport":      rpcRead,
+	"ListDriftReports":    rpcRead,
+	"ResolveDrift":        rpcOperate,
+	"CompareEnvironments": rpcRead,
+	"GetComparison":       rpcRead,
+
+	// Batch config updates; a batch is only known by its ID, so cancelling and rolling back are fleet-wide
+	"BatchUpdateConfig": rpcOperate,
+	"GetBatchStatus":    rpcRead,
+	"CancelBatch":       rpcSuperAdmin,
+	"RollbackBatch":     rpcSuperAdmin,
+
+	// Config templates; those of no project apply fleet-wide
+	"ListConfigTemplates":        rpcRead,
+	"GetConfigTemplate":          rpcRead,
+	"CreateConfigTemplate":       rpcSuperAdmin,
+	"UpdateConfigTemplate":       rpcSuperAdmin,
+	"DeleteConfigTemplate":       rpcSuperAdmin,
+	"RenderConfigTemplate":       rpcRead,
+	"ListConfigTemplateVersions": rpcRead,
+	"SetConfigTemplateVariables": rpcOperate,
+
+	// Maintenance
+	"SetMaintenance":             rpcOperate,
+	"GetMaintenanceStatus":       rpcRead,
+	"ListMaintenanceStates":      rpcRead,
+	"ListMaintenanceTemplates":   rpcRead,
+	"CreateMaintenanceTemplate":  rpcSuperAdmin,
+	"UpdateMaintenanceTemplate":  rpcSuperAdmin,
+	"DeleteMaintenanceTemplate":  rpcSuperAdmin,
+	"PreviewMaintenanceTemplate": rpcRead,
+
+	// Certificates; the inventory is shared by every project
+	"UploadCertificate":       rpcOperate,
+	"GetCertificateInventory": rpcRead,
+	"DeployCertificate":       rpcOperate,
+	"DeleteCertificate":       rpcSuperAdmin,
+}
+
+// rpcAgentFields are the request fields naming agents, at any depth; callers must be able to see
+// each of them
+var rpcAgentFields = []protoreflect.Name{"agent_id", "instance_id", "agent_ids", "golden_agent_id",
+	"baseline_agent_id", "source_agent_id"}
+
+// rpcEnvironmentFields are the request fields naming environments
+var rpcEnvironmentFields = []protoreflect.Name{"environment_id", "source_environment_id", "target_environment_id"}
+
+var (
+	rpcJSONIn  = protojson.UnmarshalOptions{}
+	rpcJSONOut = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+// agentServiceRPCs are the unary RPCs of AgentService by name. The streaming ones (GetLogs,
+// StreamAnalytics, Execute) have their own SSE and WebSocket endpoints.
+var agentServiceRPCs = newRPCMirror(pb.AgentService_ServiceDesc)
+
+func newRPCMirror(sd grpc.ServiceDesc) map[string]rpcMethod {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(sd.ServiceName))
+	if err != nil {
+		panic(fmt.Sprintf("rpc mirror: %v", err))
+	}
+	service := d.(protoreflect.ServiceDescriptor)
+	methods := make(map[string]rpcMethod, len(sd.Methods))
+	for _, m := range sd.Methods {
+		md := service.Methods().ByName(protoreflect.Name(m.MethodName))
+		input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+		if err != nil {
+			panic(fmt.Sprintf("rpc mirror: %s: %v", m.MethodName, err))
+		}
+		methods[m.MethodName] = rpcMethod{
+			desc:   m,
+			input:  input,
+			output: md.Output().FullName(),
+			readOnly: strings.HasPrefix(m.MethodName, "Get") || strings.HasPrefix(m.MethodName, "List") ||
+				readOnlyRPCs[m.MethodName],
+		}
+	}
+	return methods
+}
+
+// handleListRPCs handles GET /api/rpc: the AgentService RPCs that can be called over HTTP
+func (srv *server) handleListRPCs(w http.ResponseWriter, r *http.Request) {
+	type rpcInfo struct {
+		Method   string `json:"method"`
+		Input    string `json:"input"`
+		Output   string `json:"output"`
+		ReadOnly bool   `json:"read_only"`
+	}
+	list := make([]rpcInfo, 0, len(agentServiceRPCs))
+	for name, m := range agentServiceRPCs {
+		list = append(list, rpcInfo{
+			Method:   name,
+			Input:    string(m.input.Descriptor().FullName()),
+			Output:   string(m.output),
+			ReadOnly: m.readOnly,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Method < list[j].Method })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"methods": list})
+}
+
+// handleRPC handles GET and POST /api/rpc/{method}: calls an AgentService RPC with the request
+// message as the JSON body (POST) or as query parameters (GET, read-only RPCs only), and writes
+// the response message as JSON with the proto field names. gRPC errors keep their HTTP equivalent
+// status.
+func (srv *server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("method")
+	m, ok := agentServiceRPCs[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown method %s"}`, escapeJSON(name)), http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodGet && !m.readOnly {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, fmt.Sprintf(`{"error":"%s changes state; use POST"}`, name), http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := m.input.New().Interface()
+	var err error
+	if r.Method == http.MethodGet {
+		err = rpcRequestFromQuery(req, r.URL.Query())
+	} else {
+		err = rpcRequestFromBody(req, http.MaxBytesReader(w, r.Body, maxRPCBody))
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+
+	// The user is nil when authentication is disabled, as for trusted gRPC clients
+	user := middleware.GetUserFromContext(r.Context())
+	if user != nil && !srv.authorizeRPC(w, r, user, name, m, req) {
+		return
+	}
+
+	resp, err := m.desc.Handler(srv, r.Context(), func(v interface{}) error {
+		proto.Merge(v.(proto.Message), req)
+		return nil
+	}, nil)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	if user != nil && !m.readOnly {
+		srv.db.CreateAuditLog(user.Username, "rpc", "agent_service", name, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+			"agents": rpcAgentIDs(req),
+		})
+	}
+
+	data, err := rpcJSONOut.Marshal(resp.(proto.Message))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// authorizeRPC checks the caller may make the call, writing 403 when not
+func (srv *server) authorizeRPC(w http.ResponseWriter, r *http.Request, user *middleware.User, name string, m rpcMethod, req proto.Message) bool {
+	deny := func(format string, args ...interface{}) bool {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(fmt.Sprintf(format, args...))), http.StatusForbidden)
+		return false
+	}
+	access := rpcAccessLevels[name]
+	if access == rpcDenied {
+		return deny("%s cannot be called over HTTP", name)
+	}
+	if !m.readOnly && user.Role == "viewer" {
+		return deny("viewers cannot call %s", name)
+	}
+	if isSuperAdmin, _ := srv.db.IsSuperAdmin(user.Username); isSuperAdmin {
+		return true
+	}
+	if access == rpcSuperAdmin {
+		return deny("%s is for superadmins", name)
+	}
+
+	t := rpcTargetsOf(req)
+	if access == rpcProjectAdmin && len(t.environments)+len(t.groups) == 0 {
+		return deny("%s needs a group or environment", name)
+	}
+	if access == rpcOperate && len(t.agents)+len(t.projects)+len(t.environments)+len(t.groups) == 0 {
+		return deny("%s without agents, a group or an environment is for superadmins", name)
+	}
+	for _, agentID := range t.agents {
+		if resolved, ok := srv.resolveAgentID(agentID); ok {
+			agentID = resolved
+		}
+		if !srv.canUserAccessAgent(user.Username, agentID) {
+			return deny("no access to agent %s", agentID)
+		}
+	}
+	if len(t.projects)+len(t.environments)+len(t.groups) == 0 {
+		return true
+	}
+
+	permission := PermissionRead
+	switch access {
+	case rpcOperate:
+		permission = PermissionOperate
+	case rpcProjectAdmin:
+		permission = PermissionAdmin
+	}
+	// Callers without that permission on any project are turned away before looking up groups and
+	// environments
+	ua, err := srv.db.GetUserAccess(user.Username)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return false
+	}
+	granted := false
+	for _, p := range ua.ProjectAccess {
+		granted = granted || permissionLevel(p) >= permissionLevel(permission)
+	}
+	if !granted {
+		return deny("%s needs %s access to the project", name, permission)
+	}
+
+	projects, err := srv.rpcTargetProjects(r.Context(), t)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return false
+	}
+	for _, projectID := range projects {
+		if ok, _ := srv.db.HasProjectAccess(user.Username, projectID, permission); !ok {
+			return deny("%s needs %s access to project %s", name, permission, projectID)
+		}
+	}
+	return true
+}
+
+// rpcTargets are what a request acts on
+type rpcTargets struct {
+	agents       []string
+	projects     []string
+	environments []string
+	groups       []string
+}
+
+// rpcTargetsOf returns the agents a request names at any depth, and the projects, environments and
+// groups it names at its top level, including through scope and scope_id (or target and target_id)
+func rpcTargetsOf(req proto.Message) rpcTargets {
+	msg := req.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	str := func(name protoreflect.Name) string {
+		if fd := fields.ByName(name); fd != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() {
+			return strings.TrimSpace(msg.Get(fd).String())
+		}
+		return ""
+	}
+	t := rpcTargets{agents: rpcAgentIDs(req)}
+	if id := str("project_id"); id != "" {
+		t.projects = append(t.projects, id)
+	}
+	for _, name := range rpcEnvironmentFields {
+		if id := str(name); id != "" {
+			t.environments = append(t.environments, id)
+		}
+	}
+	if id := str("group_id"); id != "" {
+		t.groups = append(t.groups, id)
+	}
+	for _, scope := range [][2]protoreflect.Name{{"scope", "scope_id"}, {"target", "target_id"}} {
+		id := str(scope[1])
+		if id == "" {
+			continue
+		}
+		switch str(scope[0]) {
+		case "agent":
+			t.agents = append(t.agents, id)
+		case "group":
+			t.groups = append(t.groups, id)
+		case "environment":
+			t.environments = append(t.environments, id)
+		}
+	}
+	return t
+}
+
+// rpcTargetProjects returns the projects of the targets' projects, environments and groups
+func (srv *server) rpcTargetProjects(ctx context.Context, t rpcTargets) ([]string, error) {
+	projects := append([]string{}, t.projects...)
+	environments := append([]string{}, t.environments...)
+	for _, id := range t.groups {
+		group, err := srv.getGroupByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		environments = append(environments, group.EnvironmentID)
+	}
+	for _, id := range environments {
+		env, err := srv.db.GetEnvironment(id)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to query environment: %v", err)
+		}
+		if env == nil {
+			return nil, status.Errorf(codes.NotFound, "environment %s not found", id)
+		}
+		projects = append(projects, env.ProjectID)
+	}
+	return projects, nil
+}
+
+// rpcRequestFromBody decodes the protobuf JSON mapping of a request; an empty body is an empty
+// request
+func rpcRequestFromBody(req proto.Message, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err := rpcJSONIn.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// rpcRequestFromQuery sets the scalar fields of a request from query parameters named after the
+// proto fields (agent_id) or their JSON names (agentId). Repeated fields take repeated parameters.
+func rpcRequestFromQuery(req proto.Message, query url.Values) error {
+	msg := req.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for key, values := range query {
+		fd := fields.ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = fields.ByJSONName(key)
+		}
+		if fd == nil {
+			return fmt.Errorf("unknown field %s", key)
+		}
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return fmt.Errorf("field %s cannot be set from the query; use POST", key)
+		}
+		if !fd.IsList() {
+			values = values[len(values)-1:]
+		}
+		for _, s := range values {
+			v, err := rpcScalar(fd, s)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", key, err)
+			}
+			if fd.IsList() {
+				msg.Mutable(fd).List().Append(v)
+			} else {
+				msg.Set(fd, v)
+			}
+		}
+	}
+	return nil
+}
+
+// rpcScalar parses a query value for a scalar field; enums take their name or number
+func rpcScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || fd.Enum().Values().ByNumber(protoreflect.EnumNumber(v)) == nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value %q", fd.Enum().Name(), s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
+
+// rpcAgentIDs returns the agents a request names, in nested messages too. "all" and empty values
+// name no agent; the RPCs taking them scope their results to the caller themselves.
+func rpcAgentIDs(req proto.Message) []string {
+	var ids []string
+	add := func(s string) {
+		for _, id := range strings.Split(s, ",") {
+			if id = strings.TrimSpace(id); id != "" && id != "all" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	var walk func(msg protoreflect.Message)
+	walk = func(msg protoreflect.Message) {
+		fields := msg.Descriptor().Fields()
+		for _, name := range rpcAgentFields {
+			fd := fields.ByName(name)
+			if fd == nil || fd.Kind() != protoreflect.StringKind {
+				continue
+			}
+			if fd.IsList() {
+				list := msg.Get(fd).List()
+				for i := 0; i < list.Len(); i++ {
+					add(list.Get(i).String())
+				}
+			} else {
+				add(msg.Get(fd).String())
+			}
+		}
+		msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			if fd.IsMap() || fd.Message() == nil {
+				return true
+			}
+			if fd.IsList() {
+				for i := 0; i < v.List().Len(); i++ {
+					walk(v.List().Get(i).Message())
+				}
+			} else {
+				walk(v.Message())
+			}
+			return true
+		})
+	}
+	walk(req.ProtoReflect())
+	return ids
+}
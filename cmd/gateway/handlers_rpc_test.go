@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestRPCMirrorCoversUnaryRPCs(t *testing.T) {
+	if len(agentServiceRPCs) != len(pb.AgentService_ServiceDesc.Methods) {
+		t.Errorf("mirror has %d methods, AgentService %d unary RPCs", len(agentServiceRPCs), len(pb.AgentService_ServiceDesc.Methods))
+	}
+	for _, stream := range pb.AgentService_ServiceDesc.Streams {
+		if _, ok := agentServiceRPCs[stream.StreamName]; ok {
+			t.Errorf("streaming RPC %s should not be mirrored", stream.StreamName)
+		}
+	}
+	readOnly := map[string]bool{"ListAgents": true, "GetTraces": true, "ValidateConfig": true, "ReloadNginx": false, "CreateAlertRule": false}
+	for name, want := range readOnly {
+		if got := agentServiceRPCs[name].readOnly; got != want {
+			t.Errorf("%s read-only = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRPCRequestFromQuery(t *testing.T) {
+	req := &pb.ListAgentsRequest{}
+	q := url.Values{"page_size": {"20"}, "sortBy": {"last_seen"}, "descending": {"true"}, "tags": {"web", "eu"}}
+	if err := rpcRequestFromQuery(req, q); err != nil {
+		t.Fatal(err)
+	}
+	if req.PageSize != 20 || req.SortBy != "last_seen" || !req.Descending || !reflect.DeepEqual(req.Tags, []string{"web", "eu"}) {
+		t.Errorf("unexpected request %v", req)
+	}
+
+	for _, q := range []url.Values{{"nope": {"1"}}, {"page_size": {"many"}}} {
+		if err := rpcRequestFromQuery(&pb.ListAgentsRequest{}, q); err == nil {
+			t.Errorf("expected an error for %v", q)
+		}
+	}
+}
+
+func TestRPCAgentIDs(t *testing.T) {
+	if got := rpcAgentIDs(&pb.RemoveAgentRequest{AgentId: "web-1"}); !reflect.DeepEqual(got, []string{"web-1"}) {
+		t.Errorf("agent_id: %v", got)
+	}
+	if got := rpcAgentIDs(&pb.AddAgentsToGroupRequest{GroupId: "g", AgentIds: []string{"a", "b"}}); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("agent_ids: %v", got)
+	}
+	if got := rpcAgentIDs(&pb.AnalyticsRequest{AgentId: "all"}); got != nil {
+		t.Errorf("all should name no agent: %v", got)
+	}
+	if got := rpcAgentIDs(&pb.ConfigRequest{InstanceId: "web-1"}); !reflect.DeepEqual(got, []string{"web-1"}) {
+		t.Errorf("instance_id: %v", got)
+	}
+	if got := rpcAgentIDs(&pb.SendReportRequest{Request: &pb.ReportRequest{AgentIds: []string{"web-2"}}}); !reflect.DeepEqual(got, []string{"web-2"}) {
+		t.Errorf("nested agent_ids: %v", got)
+	}
+}
+
+func TestRPCAccessLevels(t *testing.T) {
+	for name, m := range agentServiceRPCs {
+		access, ok := rpcAccessLevels[name]
+		if !ok {
+			t.Errorf("%s has no access level", name)
+		}
+		if m.readOnly && access != rpcRead {
+			t.Errorf("%s is read-only but has access level %d", name, access)
+		}
+	}
+	for name := range rpcAccessLevels {
+		if _, ok := agentServiceRPCs[name]; !ok {
+			t.Errorf("access level for unknown RPC %s", name)
+		}
+	}
+}
+
+func TestRPCTargetsOf(t *testing.T) {
+	got := rpcTargetsOf(&pb.SetMaintenanceRequest{Scope: "group", ScopeId: "g1"})
+	if !reflect.DeepEqual(got.groups, []string{"g1"}) || got.agents != nil {
+		t.Errorf("group scope: %+v", got)
+	}
+	got = rpcTargetsOf(&pb.CompareEnvironmentsRequest{SourceEnvironmentId: "e1", TargetEnvironmentId: "e2"})
+	if !reflect.DeepEqual(got.environments, []string{"e1", "e2"}) {
+		t.Errorf("environments: %+v", got)
+	}
+	got = rpcTargetsOf(&pb.DriftCheckRequest{Scope: "agent", ScopeId: "web-1", BaselineAgentId: "web-2"})
+	if !reflect.DeepEqual(got.agents, []string{"web-2", "web-1"}) {
+		t.Errorf("agent scope: %+v", got)
+	}
+}
+
+func TestHandleRPCPermissions(t *testing.T) {
+	srv := &server{db: &DB{rbac: newRBACCache()}}
+	seed := func(key string, value any) {
+		srv.db.rbac.entries[key] = rbacCacheEntry{value: value, expires: time.Now().Add(time.Hour)}
+	}
+	// The operator operates a project and sees web-1; the outsider is a member of no project
+	seed("superadmin:olivia", false)
+	seed("agents:olivia", []string{"web-1"})
+	seed("access:olivia", &UserAccess{Username: "olivia", ProjectAccess: map[string]Permission{"p1": PermissionOperate}})
+	seed("superadmin:oscar", false)
+	seed("agents:oscar", []string{})
+	seed("access:oscar", &UserAccess{Username: "oscar", ProjectAccess: map[string]Permission{}})
+
+	calls := map[string]string{
+		"CreateAlertRule":      `{"name": "x", "metric_type": "error_rate", "threshold": 5}`,
+		"DeleteCertificate":    `{"certificate_id": "c1"}`,
+		"CreateConfigTemplate": `{"project_id": "p1", "name": "base"}`,
+		"UpgradeNginxBinary":   `{"instance_id": "web-2", "binary_path": "/usr/sbin/nginx"}`,
+		"RemoveAgent":          `{"agent_id": "web-1"}`,
+		"CreateGroup":          `{"environment_id": "e1", "name": "edge"}`,
+		"ReloadNginx":          `{}`,
+		"SendReport":           `{"request": {"agent_ids": ["web-2"]}, "recipients": ["ops@example.com"]}`,
+	}
+	for _, username := range []string{"olivia", "oscar"} {
+		user := &middleware.User{Username: username, Role: "operator"}
+		for method, body := range calls {
+			req := httptest.NewRequest(http.MethodPost, "/api/rpc/"+method, strings.NewReader(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+			req.SetPathValue("method", method)
+			w := httptest.NewRecorder()
+			srv.handleRPC(w, req)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s calling %s: status %d, want 403 (%s)", username, method, w.Code, w.Body.String())
+			}
+		}
+	}
+
+	delete(rpcAccessLevels, "GetAgent")
+	defer func() { rpcAccessLevels["GetAgent"] = rpcRead }()
+	req := httptest.NewRequest(http.MethodGet, "/api/rpc/GetAgent?agent_id=web-1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, &middleware.User{Username: "olivia", Role: "admin"}))
+	req.SetPathValue("method", "GetAgent")
+	w := httptest.NewRecorder()
+	srv.handleRPC(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("an RPC without an access level: status %d, want 403", w.Code)
+	}
+}
+
+func TestHandleRPCRejects(t *testing.T) {
+	srv := &server{}
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/api/rpc/Nope", "", http.StatusNotFound},
+		{http.MethodGet, "/api/rpc/ReloadNginx", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/rpc/ReloadNginx", `{"instance_id": 1}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/rpc/ListAgents?bogus=1", "", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		req.SetPathValue("method", strings.TrimPrefix(req.URL.Path, "/api/rpc/"))
+		w := httptest.NewRecorder()
+		srv.handleRPC(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s %s: status %d, want %d (%s)", c.method, c.path, w.Code, c.status, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/google/uuid"
+)
+
+// canAccessScheduledTask reports whether username holds perm on the project of the task's
+// environment. Tasks without an environment run on the whole fleet and belong to the superadmins.
+func (s *server) canAccessScheduledTask(username string, t *ScheduledTask, perm Permission) bool {
+	if t.EnvironmentID != "" {
+		if env, err := s.db.GetEnvironment(t.EnvironmentID); err == nil && env != nil {
+			ok, _ := s.db.HasProjectAccess(username, env.ProjectID, perm)
+			return ok
+		}
+	}
+	ok, _ := s.db.IsSuperAdmin(username)
+	return ok
+}
+
+// loadScheduledTask resolves the {id} path value and checks the caller holds perm on it, writing
+// any error response itself
+func (s *server) loadScheduledTask(w http.ResponseWriter, r *http.Request, perm Permission) (*ScheduledTask, string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, "", false
+	}
+	t, err := s.db.GetScheduledTask(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if t == nil || !s.canAccessScheduledTask(user.Username, t, PermissionRead) {
+		http.Error(w, `{"error":"scheduled task not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	if perm != PermissionRead && !s.canAccessScheduledTask(user.Username, t, perm) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, "", false
+	}
+	return t, user.Username, true
+}
+
+// validateScheduledTaskScope validates the task and that the caller may operate its environment
+func (s *server) validateScheduledTaskScope(w http.ResponseWriter, username string, t *ScheduledTask) bool {
+	if err := validateScheduledTask(t, time.Now()); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return false
+	}
+	if t.EnvironmentID != "" {
+		if env, err := s.db.GetEnvironment(t.EnvironmentID); err != nil || env == nil {
+			http.Error(w, `{"error":"environment not found"}`, http.StatusBadRequest)
+			return false
+		}
+	}
+	if !s.canAccessScheduledTask(username, t, PermissionOperate) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// handleListScheduledTasks handles GET /api/scheduled-tasks?environment_id=<id>
+func (s *server) handleListScheduledTasks(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	tasks, err := s.db.ListScheduledTasks()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	envID := r.URL.Query().Get("environment_id")
+	visible := []ScheduledTask{}
+	for i := range tasks {
+		if envID != "" && tasks[i].EnvironmentID != envID {
+			continue
+		}
+		if s.canAccessScheduledTask(user.Username, &tasks[i], PermissionRead) {
+			visible = append(visible, tasks[i])
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(visible)
+}
+
+// handleCreateScheduledTask handles POST /api/scheduled-tasks
+func (s *server) handleCreateScheduledTask(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	t := ScheduledTask{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.validateScheduledTaskScope(w, user.Username, &t) {
+		return
+	}
+	t.ID, t.CreatedBy = uuid.New().String(), user.Username
+	if err := s.db.CreateScheduledTask(&t); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(user.Username, "create_scheduled_task", "scheduled_task", t.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":        t.Name,
+		"action":      t.Action,
+		"cron":        t.Cron,
+		"environment": t.EnvironmentID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleGetScheduledTask handles GET /api/scheduled-tasks/{id}
+func (s *server) handleGetScheduledTask(w http.ResponseWriter, r *http.Request) {
+	t, _, ok := s.loadScheduledTask(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleUpdateScheduledTask handles PUT /api/scheduled-tasks/{id}
+func (s *server) handleUpdateScheduledTask(w http.ResponseWriter, r *http.Request) {
+	t, username, ok := s.loadScheduledTask(w, r, PermissionOperate)
+	if !ok {
+		return
+	}
+	// Decode over the stored task so omitted fields keep their values
+	if err := json.NewDecoder(r.Body).Decode(t); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	t.ID = r.PathValue("id")
+	if !s.validateScheduledTaskScope(w, username, t) {
+		return
+	}
+	if err := s.db.UpdateScheduledTask(t); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "update_scheduled_task", "scheduled_task", t.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":    t.Name,
+		"action":  t.Action,
+		"cron":    t.Cron,
+		"enabled": fmt.Sprintf("%t", t.Enabled),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleDeleteScheduledTask handles DELETE /api/scheduled-tasks/{id}
+func (s *server) handleDeleteScheduledTask(w http.ResponseWriter, r *http.Request) {
+	t, username, ok := s.loadScheduledTask(w, r, PermissionOperate)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteScheduledTask(t.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "delete_scheduled_task", "scheduled_task", t.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name": t.Name,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunScheduledTask handles POST /api/scheduled-tasks/{id}/run: starts a run now, outside the
+// schedule, and returns it while it executes
+func (s *server) handleRunScheduledTask(w http.ResponseWriter, r *http.Request) {
+	t, username, ok := s.loadScheduledTask(w, r, PermissionOperate)
+	if !ok {
+		return
+	}
+	run, err := s.startScheduledTaskRun(t, "manual", username)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusConflict)
+		return
+	}
+	s.db.CreateAuditLog(username, "run_scheduled_task", "scheduled_task", t.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":   t.Name,
+		"action": t.Action,
+		"run_id": run.ID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(run)
+}
+
+// handleListScheduledTaskRuns handles GET /api/scheduled-tasks/{id}/runs?limit=50
+func (s *server) handleListScheduledTaskRuns(w http.ResponseWriter, r *http.Request) {
+	t, _, ok := s.loadScheduledTask(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	limit := 50
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	runs, err := s.db.ListScheduledTaskRuns(t.ID, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id": t.ID,
+		"runs":    runs,
+	})
+}
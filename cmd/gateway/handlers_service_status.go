@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func (s *server) GetServiceStatus(ctx context.Context, req *pb.ServiceStatusRequest) (*pb.ServiceStatus, error) {
+	client, conn, err := s.getAgentClient(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return client.GetServiceStatus(ctx, req)
+}
+
+// GET /api/servers/{agentId}/service?nginx_instance=<id>&journal_lines=100
+// Reports the systemd/OpenRC state of the NGINX unit, its restart and failure history and the
+// journal around its last failure.
+func (srv *server) handleGetServiceStatus(w http.ResponseWriter, r *http.Request) {
+	agentID, _, ok := srv.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+	journalLines, _ := strconv.Atoi(r.URL.Query().Get("journal_lines"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	resp, err := srv.GetServiceStatus(ctx, &pb.ServiceStatusRequest{
+		InstanceId:      agentID,
+		NginxInstanceId: r.URL.Query().Get("nginx_instance"),
+		JournalLines:    int32(journalLines),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// loadStatusPage resolves the {id} path value and checks the caller holds perm on the page's
+// project, writing any error response itself
+func (s *server) loadStatusPage(w http.ResponseWriter, r *http.Request, perm Permission) (*StatusPage, string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, "", false
+	}
+	p, err := s.db.GetStatusPage(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if p == nil {
+		http.Error(w, `{"error":"status page not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	if ok, _ := s.db.HasProjectAccess(user.Username, p.ProjectID, PermissionRead); !ok {
+		http.Error(w, `{"error":"status page not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	if ok, _ := s.db.HasProjectAccess(user.Username, p.ProjectID, perm); !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, "", false
+	}
+	return p, user.Username, true
+}
+
+// validateStatusPageScope validates the page and that its checks belong to its project and its SLO
+// targets exist
+func (s *server) validateStatusPageScope(w http.ResponseWriter, username string, p *StatusPage) bool {
+	if err := validateStatusPage(p); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return false
+	}
+	if ok, _ := s.db.HasProjectAccess(username, p.ProjectID, PermissionWrite); !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	for _, comp := range p.Components {
+		for _, id := range comp.CheckIDs {
+			c, err := s.db.GetSyntheticCheck(id)
+			if err != nil || c == nil || c.ProjectID != p.ProjectID {
+				http.Error(w, fmt.Sprintf(`{"error":"check %s is not a synthetic check of this project"}`, escapeJSON(id)), http.StatusBadRequest)
+				return false
+			}
+		}
+	}
+	if len(p.SLOTargetIDs) > 0 {
+		targets, err := s.db.ListSLOTargets()
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return false
+		}
+		known := make([]string, 0, len(targets))
+		for _, t := range targets {
+			known = append(known, t.ID)
+		}
+		for _, id := range p.SLOTargetIDs {
+			if !stringInSlice(known, id) {
+				http.Error(w, fmt.Sprintf(`{"error":"unknown SLO target %s"}`, escapeJSON(id)), http.StatusBadRequest)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// handleListStatusPages handles GET /api/status-pages
+func (s *server) handleListStatusPages(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	pages, err := s.db.ListStatusPages()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	visible := []StatusPage{}
+	for _, p := range pages {
+		if ok, _ := s.db.HasProjectAccess(user.Username, p.ProjectID, PermissionRead); ok {
+			visible = append(visible, p)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(visible)
+}
+
+// handleCreateStatusPage handles POST /api/status-pages. The access token is only returned here
+// and when rotated.
+func (s *server) handleCreateStatusPage(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	var p StatusPage
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.validateStatusPageScope(w, user.Username, &p) {
+		return
+	}
+	p.CreatedBy = user.Username
+	token, err := s.db.CreateStatusPage(&p)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			http.Error(w, `{"error":"the project already has a status page or the slug is taken"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(user.Username, "create_status_page", "status_page", p.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug":       p.Slug,
+		"project_id": p.ProjectID,
+		"public":     fmt.Sprintf("%t", p.Public),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"page": p, "token": token})
+}
+
+// handleUpdateStatusPage handles PUT /api/status-pages/{id}
+func (s *server) handleUpdateStatusPage(w http.ResponseWriter, r *http.Request) {
+	p, username, ok := s.loadStatusPage(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	projectID := p.ProjectID
+	// Decode over the stored page so omitted fields keep their values
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	p.ID, p.ProjectID = r.PathValue("id"), projectID
+	if !s.validateStatusPageScope(w, username, p) {
+		return
+	}
+	if err := s.db.UpdateStatusPage(p); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			http.Error(w, `{"error":"the slug is taken"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.statusPageCache.Delete(p.ID)
+	s.db.CreateAuditLog(username, "update_status_page", "status_page", p.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug":   p.Slug,
+		"public": fmt.Sprintf("%t", p.Public),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// handleDeleteStatusPage handles DELETE /api/status-pages/{id}
+func (s *server) handleDeleteStatusPage(w http.ResponseWriter, r *http.Request) {
+	p, username, ok := s.loadStatusPage(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteStatusPage(p.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.statusPageCache.Delete(p.ID)
+	s.db.CreateAuditLog(username, "delete_status_page", "status_page", p.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug": p.Slug,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRotateStatusPageToken handles POST /api/status-pages/{id}/token: issues a new access token,
+// invalidating links that carry the old one
+func (s *server) handleRotateStatusPageToken(w http.ResponseWriter, r *http.Request) {
+	p, username, ok := s.loadStatusPage(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	token, err := s.db.RotateStatusPageToken(p.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "rotate_status_page_token", "status_page", p.ID, r.RemoteAddr, r.UserAgent(), nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// handlePreviewStatusPage handles GET /api/status-pages/{id}/view for signed-in project members
+func (s *server) handlePreviewStatusPage(w http.ResponseWriter, r *http.Request) {
+	p, _, ok := s.loadStatusPage(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	view, err := s.buildStatusPageView(r.Context(), p)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// handlePublicStatusPage handles GET /api/public/status/{slug}. Public pages need no credentials;
+// the others require the page token as ?token= or the X-Status-Token header. Unknown pages and bad
+// tokens both answer 404 so slugs cannot be probed.
+func (s *server) handlePublicStatusPage(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		http.Error(w, `{"error":"status pages unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+	p, err := s.db.GetStatusPageBySlug(r.PathValue("slug"))
+	if err != nil {
+		http.Error(w, `{"error":"status page unavailable"}`, http.StatusInternalServerError)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.Header.Get("X-Status-Token")
+	}
+	if p == nil || (!p.Public && !statusPageTokenValid(p, token)) {
+		http.Error(w, `{"error":"status page not found"}`, http.StatusNotFound)
+		return
+	}
+	view, err := s.cachedStatusPageView(r.Context(), p)
+	if err != nil {
+		http.Error(w, `{"error":"status page unavailable"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if p.Public {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=30")
+	}
+	json.NewEncoder(w).Encode(view)
+}
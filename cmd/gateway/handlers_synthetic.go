@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// SyntheticCheckView is a check with its uptime and latency over the requested window
+type SyntheticCheckView struct {
+	SyntheticCheck
+	Summary *SyntheticSummary `json:"summary,omitempty"`
+}
+
+// canAccessSyntheticCheck reports whether username holds perm on the check's project. Checks
+// without a project belong to the superadmins.
+func (s *server) canAccessSyntheticCheck(username string, c *SyntheticCheck, perm Permission) bool {
+	if c.ProjectID == "" {
+		ok, _ := s.db.IsSuperAdmin(username)
+		return ok
+	}
+	ok, _ := s.db.HasProjectAccess(username, c.ProjectID, perm)
+	return ok
+}
+
+// loadSyntheticCheck resolves the {id} path value and checks the caller holds perm on it,
+// writing any error response itself
+func (s *server) loadSyntheticCheck(w http.ResponseWriter, r *http.Request, perm Permission) (*SyntheticCheck, string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return nil, "", false
+	}
+	c, err := s.db.GetSyntheticCheck(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if c == nil || !s.canAccessSyntheticCheck(user.Username, c, PermissionRead) {
+		http.Error(w, `{"error":"synthetic check not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	if perm != PermissionRead && !s.canAccessSyntheticCheck(user.Username, c, perm) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, "", false
+	}
+	return c, user.Username, true
+}
+
+// validateSyntheticCheckScope validates the check and that the caller may place it in its project
+// and bind it to its agent
+func (s *server) validateSyntheticCheckScope(w http.ResponseWriter, username string, c *SyntheticCheck) bool {
+	if err := validateSyntheticCheck(c); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return false
+	}
+	if err := checkSyntheticTarget(c, parseSyntheticNetworks(s.config.Synthetic.AllowedNetworks)); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return false
+	}
+	if c.AgentID != "" {
+		agentID, ok := s.resolveAgentID(c.AgentID)
+		if !ok {
+			http.Error(w, `{"error":"agent not found"}`, http.StatusBadRequest)
+			return false
+		}
+		if !s.canUserAccessAgent(username, agentID) {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return false
+		}
+		c.AgentID = agentID
+	}
+	if !s.canAccessSyntheticCheck(username, c, PermissionWrite) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// handleListSyntheticChecks handles GET /api/synthetic/checks?window=24h
+func (s *server) handleListSyntheticChecks(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	start, end, window, ok := parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+
+	checks, err := s.db.ListSyntheticChecks()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	views := []SyntheticCheckView{}
+	var ids []string
+	for i := range checks {
+		if s.canAccessSyntheticCheck(user.Username, &checks[i], PermissionRead) {
+			views = append(views, SyntheticCheckView{SyntheticCheck: checks[i]})
+			ids = append(ids, checks[i].ID)
+		}
+	}
+
+	if s.clickhouse != nil && len(ids) > 0 {
+		summaries, err := s.clickhouse.GetSyntheticSummaries(r.Context(), ids, start, end)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		byID := make(map[string]*SyntheticSummary, len(summaries))
+		for i := range summaries {
+			byID[summaries[i].CheckID] = &summaries[i]
+		}
+		for i := range views {
+			views[i].Summary = byID[views[i].ID]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window": window,
+		"region": s.config.Synthetic.Region,
+		"checks": views,
+	})
+}
+
+// handleCreateSyntheticCheck handles POST /api/synthetic/checks
+func (s *server) handleCreateSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	c := SyntheticCheck{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.validateSyntheticCheckScope(w, user.Username, &c) {
+		return
+	}
+	c.CreatedBy = user.Username
+	if err := s.db.CreateSyntheticCheck(&c); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(user.Username, "create_synthetic_check", "synthetic_check", c.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":   c.Name,
+		"type":   c.CheckType,
+		"target": c.Target,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleGetSyntheticCheck handles GET /api/synthetic/checks/{id}
+func (s *server) handleGetSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	c, _, ok := s.loadSyntheticCheck(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleUpdateSyntheticCheck handles PUT /api/synthetic/checks/{id}
+func (s *server) handleUpdateSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	c, username, ok := s.loadSyntheticCheck(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	// Decode over the stored check so omitted fields keep their values
+	if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	c.ID = r.PathValue("id")
+	if !s.validateSyntheticCheckScope(w, username, c) {
+		return
+	}
+	if err := s.db.UpdateSyntheticCheck(c); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "update_synthetic_check", "synthetic_check", c.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":    c.Name,
+		"target":  c.Target,
+		"enabled": fmt.Sprintf("%t", c.Enabled),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleDeleteSyntheticCheck handles DELETE /api/synthetic/checks/{id}
+func (s *server) handleDeleteSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	c, username, ok := s.loadSyntheticCheck(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteSyntheticCheck(c.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "delete_synthetic_check", "synthetic_check", c.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name": c.Name,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunSyntheticCheck handles POST /api/synthetic/checks/{id}/run: executes the check once from
+// this gateway and records the result
+func (s *server) handleRunSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	c, _, ok := s.loadSyntheticCheck(w, r, PermissionWrite)
+	if !ok {
+		return
+	}
+	res := runSyntheticCheck(r.Context(), c, s.config.Synthetic.Region, parseSyntheticNetworks(s.config.Synthetic.AllowedNetworks))
+	s.recordSyntheticResult(c, res)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// handleGetSyntheticResults handles GET /api/synthetic/checks/{id}/results?window=24h&limit=100
+func (s *server) handleGetSyntheticResults(w http.ResponseWriter, r *http.Request) {
+	c, _, ok := s.loadSyntheticCheck(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	start, end, window, ok := parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+
+	results := []SyntheticResult{}
+	if s.clickhouse != nil {
+		var err error
+		if results, err = s.clickhouse.GetSyntheticResults(r.Context(), c.ID, start, end, limit); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"check_id": c.ID,
+		"window":   window,
+		"results":  results,
+	})
+}
+
+// handleGetSyntheticStats handles GET /api/synthetic/checks/{id}/stats?window=24h: uptime and
+// latency over the window plus a bucketed series for charts
+func (s *server) handleGetSyntheticStats(w http.ResponseWriter, r *http.Request) {
+	c, _, ok := s.loadSyntheticCheck(w, r, PermissionRead)
+	if !ok {
+		return
+	}
+	start, end, window, ok := parseSyntheticWindow(w, r)
+	if !ok {
+		return
+	}
+
+	resp := map[string]interface{}{
+		"check_id": c.ID,
+		"window":   window,
+		"summary":  nil,
+		"series":   []SyntheticBucket{},
+	}
+	if s.clickhouse != nil {
+		summaries, err := s.clickhouse.GetSyntheticSummaries(r.Context(), []string{c.ID}, start, end)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		if len(summaries) > 0 {
+			resp["summary"] = summaries[0]
+		}
+		series, err := s.clickhouse.GetSyntheticSeries(r.Context(), c.ID, start, end)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		resp["series"] = series
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parseSyntheticWindow(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, string, bool) {
+	window := strings.TrimSpace(r.URL.Query().Get("window"))
+	if window == "" {
+		window = "24h"
+	}
+	d, ok := metricWindows[window]
+	if !ok {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown window %s"}`, escapeJSON(window)), http.StatusBadRequest)
+		return time.Time{}, time.Time{}, "", false
+	}
+	end := time.Now()
+	return end.Add(-d), end, window, true
+}
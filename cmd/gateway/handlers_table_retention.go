@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// EnvironmentRetention is the retention of a table for the agents of one environment
+type EnvironmentRetention struct {
+	EnvironmentID   string `json:"environment_id"`
+	EnvironmentName string `json:"environment_name,omitempty"`
+	Days            int    `json:"days"`
+}
+
+// TableRetentionView is one table of GET /api/retention/tables
+type TableRetentionView struct {
+	Table        string                 `json:"table"`
+	DefaultDays  int                    `json:"default_days"` // built-in or from the config file
+	Days         int                    `json:"days"`
+	Environments []EnvironmentRetention `json:"environments"`
+	TTL          string                 `json:"ttl"` // TTL the gateway applies
+	TableStorage
+}
+
+// tableRetentionUpdate is the body of PUT /api/retention/tables/{table}
+type tableRetentionUpdate struct {
+	Days         int            `json:"days"`         // 0 reverts to the default
+	Environments map[string]int `json:"environments"` // environment id -> days; replaces all overrides
+}
+
+// validateRetentionUpdate checks an update against the table and the archive settings
+func (s *server) validateRetentionUpdate(t retentionTable, req tableRetentionUpdate) error {
+	if req.Days < 0 || req.Days > maxRetentionDays {
+		return fmt.Errorf("days must be between 1 and %d", maxRetentionDays)
+	}
+	if len(req.Environments) > 0 && t.AgentColumn == "" {
+		return fmt.Errorf("%s rows don't belong to an agent, so retention can't be set per environment", t.Name)
+	}
+	for id, days := range req.Environments {
+		if days < 1 || days > maxRetentionDays {
+			return fmt.Errorf("days of environment %s must be between 1 and %d", id, maxRetentionDays)
+		}
+		if env, err := s.db.GetEnvironment(id); err != nil || env == nil {
+			return fmt.Errorf("environment %s not found", id)
+		}
+	}
+	if t.Name == "access_logs" && s.config.Archive.Enabled {
+		shortest := req.Days
+		if shortest == 0 {
+			shortest = effectiveRetention(s.config.Retention.Tables, nil)[t.Name].DefaultDays
+		}
+		for _, days := range req.Environments {
+			shortest = min(shortest, days)
+		}
+		if shortest <= s.config.Archive.ArchiveAfterDays {
+			return fmt.Errorf("access_logs must keep rows longer than archive.archive_after_days (%d), or they are dropped before being archived", s.config.Archive.ArchiveAfterDays)
+		}
+	}
+	return nil
+}
+
+// tableRetentionView renders the retention of a table
+func (s *server) tableRetentionView(t retentionTable, r tableRetention, storage map[string]TableStorage) (TableRetentionView, error) {
+	ttl, err := s.desiredTTL(t, r)
+	if err != nil {
+		return TableRetentionView{}, err
+	}
+	view := TableRetentionView{
+		Table:        t.Name,
+		DefaultDays:  r.DefaultDays,
+		Days:         r.Days,
+		Environments: []EnvironmentRetention{},
+		TTL:          ttl,
+		TableStorage: storage[t.Name],
+	}
+	for id, days := range r.Environments {
+		er := EnvironmentRetention{EnvironmentID: id, Days: days}
+		if env, err := s.db.GetEnvironment(id); err == nil && env != nil {
+			er.EnvironmentName = env.Name
+		}
+		view.Environments = append(view.Environments, er)
+	}
+	sort.Slice(view.Environments, func(i, j int) bool {
+		return view.Environments[i].EnvironmentName < view.Environments[j].EnvironmentName
+	})
+	return view, nil
+}
+
+// handleListTableRetention handles GET /api/retention/tables: retention and disk usage per table
+func (s *server) handleListTableRetention(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	eff, err := s.loadRetention()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	storage := map[string]TableStorage{}
+	if s.clickhouse != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		if storage, err = s.clickhouse.GetTableStorage(ctx); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+			return
+		}
+	}
+	views := make([]TableRetentionView, 0, len(retentionTables))
+	for _, t := range retentionTables {
+		view, err := s.tableRetentionView(t, eff[t.Name], storage)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		views = append(views, view)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"tables": views})
+}
+
+// handleUpdateTableRetention handles PUT /api/retention/tables/{table}[?dry_run=true]. A dry run
+// validates the update and returns the resulting TTL without storing or applying it.
+func (s *server) handleUpdateTableRetention(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	t, found := findRetentionTable(r.PathValue("table"))
+	if !found {
+		http.Error(w, `{"error":"unknown table"}`, http.StatusNotFound)
+		return
+	}
+	var req tableRetentionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := s.validateRetentionUpdate(t, req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+
+	var policies []TableRetentionPolicy
+	if req.Days > 0 {
+		policies = append(policies, TableRetentionPolicy{Table: t.Name, Days: req.Days, UpdatedBy: username})
+	}
+	for id, days := range req.Environments {
+		policies = append(policies, TableRetentionPolicy{Table: t.Name, EnvironmentID: id, Days: days, UpdatedBy: username})
+	}
+	retention := effectiveRetention(s.config.Retention.Tables, policies)[t.Name]
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		view, err := s.tableRetentionView(t, retention, nil)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+		return
+	}
+
+	if err := s.db.ReplaceTableRetentionPolicies(t.Name, policies); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	if err := s.applyTableRetention(ctx, t.Name, true); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "table_retention", t.Name, r.RemoteAddr, r.UserAgent(), req)
+
+	view, err := s.tableRetentionView(t, retention, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// handleTenantBackfill handles POST /api/retention/tenant-backfill: stamps the rows of every
+// tenant-aware table ingested without a project and environment, such as those from before the
+// columns existed, with the current assignment of their agent. The updates run in the background
+// in ClickHouse; until they finish, project and environment views miss those rows.
+func (s *server) handleTenantBackfill(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+	tenants, err := s.db.ListAgentTenants()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	queued, err := s.clickhouse.BackfillTenantColumns(ctx, tenants)
+	_ = s.db.CreateAuditLog(username, "backfill", "tenant_columns", "", r.RemoteAddr, r.UserAgent(), map[string]int{
+		"agents":    len(tenants),
+		"mutations": queued,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), clickHouseErrorStatus(w, err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int{"agents": len(tenants), "mutations": queued})
+}
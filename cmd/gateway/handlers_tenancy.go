@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// writeTenancyError maps apply errors: unknown users and projects are the caller's mistake
+func writeTenancyError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, errTenancyReference) {
+		status = http.StatusUnprocessableEntity
+	}
+	http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), status)
+}
+
+// applyTenancyChange runs a single-resource change for a superadmin and writes resp: 201 when the
+// resource was created, 200 otherwise, or 204 when resp is nil
+func (s *server) applyTenancyChange(w http.ResponseWriter, r *http.Request, username, kind, key string, fn func(t *tenancyTx) error, resp interface{}) {
+	changes, err := s.db.withTenancyTx(username, false, fn)
+	if err != nil {
+		writeTenancyError(w, err)
+		return
+	}
+	for _, c := range changes {
+		s.db.CreateAuditLog(username, c.Action, "tenancy_"+c.Kind, c.Key, r.RemoteAddr, r.UserAgent(), map[string]string{
+			"id": c.ID,
+		})
+	}
+	s.enableDefaultAlertPacksFor(changes)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if tenancyCreated(changes, kind, key) {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleExportTenancy handles GET /api/tenancy: every project and team in the shape apply takes
+func (s *server) handleExportTenancy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	st, err := s.db.ExportTenancy()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+// handleApplyTenancy handles POST /api/tenancy/apply: converges on the desired projects and teams
+// in one transaction. dry_run (in the body or the query) reports the changes without making them.
+func (s *server) handleApplyTenancy(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		TenancyState
+		Prune  bool `json:"prune"`
+		DryRun bool `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if v, err := strconv.ParseBool(r.URL.Query().Get("dry_run")); err == nil && v {
+		req.DryRun = true
+	}
+	st := &req.TenancyState
+	if err := st.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	changes, err := s.db.ApplyTenancy(st, req.Prune, req.DryRun, username)
+	if err != nil {
+		writeTenancyError(w, err)
+		return
+	}
+	if !req.DryRun && len(changes) > 0 {
+		counts := map[string]int{}
+		for _, c := range changes {
+			counts[c.Action]++
+		}
+		s.db.CreateAuditLog(username, "apply", "tenancy", "", r.RemoteAddr, r.UserAgent(), map[string]string{
+			"created": strconv.Itoa(counts["create"]),
+			"updated": strconv.Itoa(counts["update"]),
+			"deleted": strconv.Itoa(counts["delete"]),
+			"prune":   strconv.FormatBool(req.Prune),
+		})
+		s.enableDefaultAlertPacksFor(changes)
+	}
+	if req.DryRun {
+		// the IDs of resources a dry run would create are discarded with its transaction
+		st = nil
+		for i := range changes {
+			if changes[i].Action == "create" {
+				changes[i].ID = ""
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dry_run": req.DryRun,
+		"changes": changes,
+		"state":   st,
+	})
+}
+
+// handlePutTenancyProject handles PUT /api/tenancy/projects/{slug}
+func (s *server) handlePutTenancyProject(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var p DeclaredProject
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	p.Slug, p.Environments = r.PathValue("slug"), nil
+	st := TenancyState{Projects: []DeclaredProject{p}}
+	if err := st.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	p = st.Projects[0]
+	s.applyTenancyChange(w, r, username, "project", p.Slug, func(t *tenancyTx) error {
+		return t.upsertProject(&p)
+	}, &p)
+}
+
+// handlePutTenancyEnvironment handles PUT /api/tenancy/projects/{slug}/environments/{env}
+func (s *server) handlePutTenancyEnvironment(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var e DeclaredEnvironment
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	projectSlug := r.PathValue("slug")
+	e.Slug = r.PathValue("env")
+	if err := e.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	s.applyTenancyChange(w, r, username, "environment", projectSlug+"/"+e.Slug, func(t *tenancyTx) error {
+		projectID, err := t.lookupID("projects", projectSlug)
+		if err != nil {
+			return fmt.Errorf("project %s %w", projectSlug, err)
+		}
+		return t.upsertEnvironment(projectID, projectSlug, &e)
+	}, &e)
+}
+
+// handlePutTenancyTeam handles PUT /api/tenancy/teams/{slug}
+func (s *server) handlePutTenancyTeam(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var tm DeclaredTeam
+	if err := json.NewDecoder(r.Body).Decode(&tm); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	tm.Slug, tm.Members, tm.Access = r.PathValue("slug"), nil, nil
+	st := TenancyState{Teams: []DeclaredTeam{tm}}
+	if err := st.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	tm = st.Teams[0]
+	s.applyTenancyChange(w, r, username, "team", tm.Slug, func(t *tenancyTx) error {
+		return t.upsertTeam(&tm)
+	}, &tm)
+}
+
+// handlePutTenancyMember handles PUT /api/tenancy/teams/{slug}/members/{username}
+func (s *server) handlePutTenancyMember(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var m DeclaredMember
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	teamSlug := r.PathValue("slug")
+	m.Username = r.PathValue("username")
+	if err := m.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	s.applyTenancyChange(w, r, username, "member", teamSlug+"/"+m.Username, func(t *tenancyTx) error {
+		teamID, err := t.lookupID("teams", teamSlug)
+		if err != nil {
+			return fmt.Errorf("team %s %w", teamSlug, err)
+		}
+		return t.upsertMember(teamID, teamSlug, m)
+	}, &m)
+}
+
+// handlePutTenancyAccess handles PUT /api/tenancy/teams/{slug}/access/{project}
+func (s *server) handlePutTenancyAccess(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var a DeclaredAccess
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	teamSlug := r.PathValue("slug")
+	a.Project = r.PathValue("project")
+	if err := a.normalize(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	s.applyTenancyChange(w, r, username, "access", teamSlug+"/"+a.Project, func(t *tenancyTx) error {
+		teamID, err := t.lookupID("teams", teamSlug)
+		if err != nil {
+			return fmt.Errorf("team %s %w", teamSlug, err)
+		}
+		return t.upsertAccess(teamID, teamSlug, a)
+	}, &a)
+}
+
+// handleDeleteTenancyResource handles DELETE on the project, environment, team, member and access
+// paths. Deleting a resource that does not exist succeeds, so destroys can be retried.
+func (s *server) handleDeleteTenancyResource(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	slug := r.PathValue("slug")
+	var kind, query string
+	var args []interface{}
+	switch {
+	case r.PathValue("env") != "":
+		kind = "environment"
+		query = `DELETE FROM environments e USING projects p WHERE e.project_id = p.id AND p.slug = $1 AND e.slug = $2
+			RETURNING e.id::text, p.slug || '/' || e.slug`
+		args = []interface{}{slug, r.PathValue("env")}
+	case r.PathValue("username") != "":
+		kind = "member"
+		query = `DELETE FROM team_members tm USING teams t WHERE tm.team_id = t.id AND t.slug = $1 AND tm.username = $2
+			RETURNING '', t.slug || '/' || tm.username`
+		args = []interface{}{slug, r.PathValue("username")}
+	case r.PathValue("project") != "":
+		kind = "access"
+		query = `DELETE FROM team_project_access tpa USING teams t, projects p
+			WHERE tpa.team_id = t.id AND tpa.project_id = p.id AND t.slug = $1 AND p.slug = $2
+			RETURNING '', t.slug || '/' || p.slug`
+		args = []interface{}{slug, r.PathValue("project")}
+	case strings.HasPrefix(r.URL.Path, "/api/tenancy/projects/"):
+		kind, query, args = "project", `DELETE FROM projects WHERE slug = $1 RETURNING id::text, slug`, []interface{}{slug}
+	default:
+		kind, query, args = "team", `DELETE FROM teams WHERE slug = $1 RETURNING id::text, slug`, []interface{}{slug}
+	}
+	s.applyTenancyChange(w, r, username, kind, "", func(t *tenancyTx) error {
+		return t.prune(kind, query, args...)
+	}, nil)
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/cmd/gateway/threatintel"
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// ThreatsView is the response of GET /api/security/threats
+type ThreatsView struct {
+	Window    string                   `json:"window"`
+	Feeds     []threatintel.FeedStatus `json:"feeds"`
+	Hits      []ThreatFeedHits         `json:"hits"`
+	Offenders []ThreatOffender         `json:"offenders"`
+}
+
+// IncludePushResult is the outcome of writing a managed include (deny rules, rate limits) to one agent
+type IncludePushResult struct {
+	AgentID string `json:"agent_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// normalizeBlockCIDR validates an address or CIDR and returns its canonical form
+func normalizeBlockCIDR(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid CIDR %q", s)
+		}
+		if p.Bits() == 0 {
+			return "", fmt.Errorf("refusing to block every address (%s)", s)
+		}
+		p = p.Masked()
+		if p.Bits() == p.Addr().BitLen() {
+			return p.Addr().String(), nil
+		}
+		return p.String(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid IP address %q", s)
+	}
+	return addr.Unmap().String(), nil
+}
+
+// blocklistPrefixes parses stored entries for containment checks
+func blocklistPrefixes(entries []BlocklistEntry) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		if p, err := netip.ParsePrefix(e.CIDR); err == nil {
+			prefixes = append(prefixes, p)
+		} else if a, err := netip.ParseAddr(e.CIDR); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+func isBlocked(prefixes []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderBlocklistConfig produces the nginx include holding one deny rule per active entry.
+// It is included in the http context, so the rules apply to every server on the agent.
+func renderBlocklistConfig(entries []BlocklistEntry, now time.Time) string {
+	cidrs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.ExpiresAt != nil && !e.ExpiresAt.After(now) {
+			continue
+		}
+		cidrs = append(cidrs, e.CIDR)
+	}
+	sort.Strings(cidrs)
+
+	var b strings.Builder
+	b.WriteString("# Managed by Avika: threat blocklist. Manual changes are overwritten.\n")
+	fmt.Fprintf(&b, "# Generated %s, %d entries\n", now.UTC().Format(time.RFC3339), len(cidrs))
+	for _, c := range cidrs {
+		fmt.Fprintf(&b, "deny %s;\n", c)
+	}
+	return b.String()
+}
+
+// requireSuperAdmin writes 401/403 and returns false unless the caller is a superadmin
+func (s *server) requireSuperAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return "", false
+	}
+	if isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username); !isSuperAdmin {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return "", false
+	}
+	return user.Username, true
+}
+
+// securityScope is the time range, agent scope and row limit shared by the security views
+type securityScope struct {
+	window   string
+	start    time.Time
+	end      time.Time
+	agentIDs []string
+	visible  bool // false when the caller can see no agents; the view must be empty
+	limit    int
+}
+
+// parseSecurityScope reads window, limit and agent_ids and applies RBAC, writing any error response itself
+func (s *server) parseSecurityScope(w http.ResponseWriter, r *http.Request) (*securityScope, bool) {
+	q := r.URL.Query()
+	sc := &securityScope{window: q.Get("window"), limit: 100}
+	if sc.window == "" {
+		sc.window = "24h"
+	}
+	d, ok := metricWindows[sc.window]
+	if !ok {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown window %s"}`, escapeJSON(sc.window)), http.StatusBadRequest)
+		return nil, false
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 1000 {
+		sc.limit = v
+	}
+
+	var requested []string
+	if v := q.Get("agent_ids"); v != "" {
+		requested = strings.Split(v, ",")
+	}
+	var err error
+	if sc.agentIDs, sc.visible, err = s.scopeMetricAgents(r, requested); err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	sc.end = time.Now()
+	sc.start = sc.end.Add(-d)
+	return sc, true
+}
+
+// handleGetThreats handles GET /api/security/threats
+func (s *server) handleGetThreats(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.parseSecurityScope(w, r)
+	if !ok {
+		return
+	}
+
+	view := ThreatsView{Window: sc.window, Feeds: []threatintel.FeedStatus{}, Hits: []ThreatFeedHits{}, Offenders: []ThreatOffender{}}
+	if s.clickhouse != nil {
+		view.Feeds = s.clickhouse.threats.Status()
+	}
+	if !sc.visible || s.clickhouse == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	if view.Hits, err = s.clickhouse.GetThreatFeedHits(ctx, sc.start, sc.end, sc.agentIDs); err != nil {
+		logging.Ctx(r.Context(), securityLog).Error().Msgf("GetThreatFeedHits failed: %v", err)
+		http.Error(w, `{"error":"failed to query threat data"}`, http.StatusInternalServerError)
+		return
+	}
+	if view.Offenders, err = s.clickhouse.GetThreatOffenders(ctx, sc.start, sc.end, sc.agentIDs, sc.limit); err != nil {
+		logging.Ctx(r.Context(), securityLog).Error().Msgf("GetThreatOffenders failed: %v", err)
+		http.Error(w, `{"error":"failed to query threat data"}`, http.StatusInternalServerError)
+		return
+	}
+	if entries, err := s.db.ListBlocklist(false); err == nil {
+		prefixes := blocklistPrefixes(entries)
+		for i := range view.Offenders {
+			view.Offenders[i].Blocked = isBlocked(prefixes, view.Offenders[i].ClientIP)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// SecurityEventsView is the response of GET /api/security/events
+type SecurityEventsView struct {
+	Window string               `json:"window"`
+	Counts []SecurityEventCount `json:"counts"`
+	Events []SecurityEvent      `json:"events"`
+}
+
+// handleGetSecurityEvents handles GET /api/security/events?window=24h&type=sqli
+func (s *server) handleGetSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.parseSecurityScope(w, r)
+	if !ok {
+		return
+	}
+	eventType := r.URL.Query().Get("type")
+	if eventType != "" && !stringInSlice([]string{eventSQLi, eventXSS, eventPathTraversal, eventBruteForce, eventRateLimited}, eventType) {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown event type %s"}`, escapeJSON(eventType)), http.StatusBadRequest)
+		return
+	}
+
+	view := SecurityEventsView{Window: sc.window, Counts: []SecurityEventCount{}, Events: []SecurityEvent{}}
+	if !sc.visible || s.clickhouse == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	if view.Counts, err = s.clickhouse.GetSecurityEventCounts(ctx, sc.start, sc.end, sc.agentIDs); err != nil {
+		logging.Ctx(r.Context(), securityLog).Error().Msgf("GetSecurityEventCounts failed: %v", err)
+		http.Error(w, `{"error":"failed to query security events"}`, http.StatusInternalServerError)
+		return
+	}
+	if view.Events, err = s.clickhouse.GetSecurityEvents(ctx, sc.start, sc.end, sc.agentIDs, eventType, sc.limit); err != nil {
+		logging.Ctx(r.Context(), securityLog).Error().Msgf("GetSecurityEvents failed: %v", err)
+		http.Error(w, `{"error":"failed to query security events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// handleListBlocklist handles GET /api/security/blocklist
+func (s *server) handleListBlocklist(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.db.ListBlocklist(r.URL.Query().Get("include_expired") == "true")
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleAddBlocklist handles POST /api/security/blocklist
+func (s *server) handleAddBlocklist(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CIDR      string   `json:"cidr"`
+		CIDRs     []string `json:"cidrs"`
+		Reason    string   `json:"reason"`
+		Source    string   `json:"source"`
+		ExpiresIn string   `json:"expires_in"` // Go duration, e.g. "24h"; empty = permanent
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.CIDR != "" {
+		req.CIDRs = append(req.CIDRs, req.CIDR)
+	}
+	if len(req.CIDRs) == 0 {
+		http.Error(w, `{"error":"cidr or cidrs is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Source == "" {
+		req.Source = "manual"
+	}
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			http.Error(w, `{"error":"expires_in must be a positive duration such as 24h"}`, http.StatusBadRequest)
+			return
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+
+	entries := make([]BlocklistEntry, 0, len(req.CIDRs))
+	for _, c := range req.CIDRs {
+		cidr, err := normalizeBlockCIDR(c)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+			return
+		}
+		entries = append(entries, BlocklistEntry{CIDR: cidr, Reason: req.Reason, Source: req.Source, CreatedBy: username, ExpiresAt: expiresAt})
+	}
+	for i := range entries {
+		if err := s.db.UpsertBlocklistEntry(&entries[i]); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	cidrs := make([]string, len(entries))
+	for i, e := range entries {
+		cidrs[i] = e.CIDR
+	}
+	s.db.CreateAuditLog(username, "block_ip", "blocklist", strings.Join(cidrs, ","), r.RemoteAddr, r.UserAgent(), map[string]string{
+		"reason": req.Reason,
+		"source": req.Source,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleDeleteBlocklist handles DELETE /api/security/blocklist/{id}
+func (s *server) handleDeleteBlocklist(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	found, err := s.db.DeleteBlocklistEntry(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, `{"error":"blocklist entry not found"}`, http.StatusNotFound)
+		return
+	}
+	s.db.CreateAuditLog(username, "unblock_ip", "blocklist", id, r.RemoteAddr, r.UserAgent(), nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePushBlocklist handles POST /api/security/blocklist/push. It writes the active entries as
+// nginx deny rules to the selected agents; each agent tests the config and reloads.
+func (s *server) handlePushBlocklist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AgentIDs      []string `json:"agent_ids"`
+		GroupID       string   `json:"group_id"`
+		EnvironmentID string   `json:"environment_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	targets, err := s.resolveTargetAgents(ctx, req.AgentIDs, req.GroupID, req.EnvironmentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if len(targets) == 0 {
+		http.Error(w, `{"error":"no target agents"}`, http.StatusBadRequest)
+		return
+	}
+	if !s.canUserDeployTo(w, r, targets) {
+		return
+	}
+
+	entries, err := s.db.ListBlocklist(false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	content := renderBlocklistConfig(entries, time.Now())
+	path := s.config.ThreatIntel.BlocklistPath
+
+	results := make([]IncludePushResult, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for i, id := range targets {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = IncludePushResult{AgentID: id}
+			if _, _, err := s.pushBatchTarget(ctx, &batchTarget{agentID: id, configPath: path, content: content}, true); err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+		}(i, id)
+	}
+	wg.Wait()
+
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		s.db.CreateAuditLog(user.Username, "push_blocklist", "blocklist", strings.Join(targets, ","), r.RemoteAddr, r.UserAgent(), map[string]string{
+			"entries": fmt.Sprintf("%d", len(entries)),
+			"path":    path,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleListBans handles GET /api/security/bans?include_lifted=true&limit=100. Callers that are not
+// superadmins only see bans touching agents they can access.
+func (s *server) handleListBans(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+	agentIDs, visible, err := s.scopeMetricAgents(r, nil)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	bans := []IPBan{}
+	if visible {
+		all, err := s.db.ListIPBans(r.URL.Query().Get("include_lifted") == "true", limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		for _, b := range all {
+			if len(agentIDs) == 0 || anyStringInSlice(agentIDs, b.AgentIDs) {
+				bans = append(bans, b)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bans)
+}
+
+// handleLiftBan handles DELETE /api/security/bans/{id}: the ban ends now and its deny rule is removed
+func (s *server) handleLiftBan(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	ban, err := s.db.LiftIPBan(r.PathValue("id"), username)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if ban == nil {
+		http.Error(w, `{"error":"active ban not found"}`, http.StatusNotFound)
+		return
+	}
+	s.db.CreateAuditLog(username, "lift_ban", "ip_ban", ban.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"client_ip": ban.ClientIP,
+		"agents":    strings.Join(ban.AgentIDs, ","),
+	})
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	results := s.syncBans(ctx, ban.AgentIDs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ban":     ban,
+		"results": results,
+	})
+}
+
+func anyStringInSlice(list, candidates []string) bool {
+	for _, c := range candidates {
+		if stringInSlice(list, c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeBlockCIDR(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"203.0.113.7", "203.0.113.7", false},
+		{" 203.0.113.7/32 ", "203.0.113.7", false},
+		{"203.0.113.77/24", "203.0.113.0/24", false},
+		{"::ffff:203.0.113.7", "203.0.113.7", false},
+		{"2001:db8::1/48", "2001:db8::/48", false},
+		{"0.0.0.0/0", "", true},
+		{"example.com", "", true},
+		{"10.0.0.0/33", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeBlockCIDR(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeBlockCIDR(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestRenderBlocklistConfig(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	out := renderBlocklistConfig([]BlocklistEntry{
+		{CIDR: "198.51.100.0/24"},
+		{CIDR: "192.0.2.1", ExpiresAt: &future},
+		{CIDR: "192.0.2.2", ExpiresAt: &past},
+	}, now)
+
+	if !strings.Contains(out, "deny 192.0.2.1;\ndeny 198.51.100.0/24;\n") {
+		t.Errorf("deny rules missing or unsorted:\n%s", out)
+	}
+	if strings.Contains(out, "192.0.2.2") {
+		t.Errorf("expired entry rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "2 entries") {
+		t.Errorf("header should count active entries:\n%s", out)
+	}
+
+	prefixes := blocklistPrefixes([]BlocklistEntry{{CIDR: "198.51.100.0/24"}, {CIDR: "192.0.2.1"}})
+	if !isBlocked(prefixes, "198.51.100.9") || !isBlocked(prefixes, "192.0.2.1") || isBlocked(prefixes, "192.0.2.3") {
+		t.Error("isBlocked containment mismatch")
+	}
+}
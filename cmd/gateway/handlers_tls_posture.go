@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+)
+
+// resolveAccessibleAgent resolves the {agentId} path value and checks the caller may access it,
+// writing any error response itself
+func (s *server) resolveAccessibleAgent(w http.ResponseWriter, r *http.Request) (string, bool) {
+	agentID, ok := s.resolveAgentID(r.PathValue("agentId"))
+	if !ok {
+		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+		return "", false
+	}
+	if user := middleware.GetUserFromContext(r.Context()); user != nil && !s.canUserAccessAgent(user.Username, agentID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return "", false
+	}
+	return agentID, true
+}
+
+// handleListTLSPosture handles GET /api/tls-posture: the latest grade of every scanned virtual host
+func (s *server) handleListTLSPosture(w http.ResponseWriter, r *http.Request) {
+	agentIDs, visible, err := s.scopeMetricAgents(r, nil)
+	if err != nil {
+		http.Error(w, `{"error":"failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	results := []TLSScanResult{}
+	if visible {
+		if results, err = s.db.ListLatestTLSScans(agentIDs); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+	}
+	grades := map[string]int{"A": 0, "B": 0, "C": 0, "D": 0, "F": 0}
+	for _, res := range results {
+		grades[res.Grade]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"grades":  grades,
+	})
+}
+
+// handleGetServerTLSPosture handles GET /api/servers/{agentId}/tls-posture?server_name=x&history=30
+func (s *server) handleGetServerTLSPosture(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := s.resolveAccessibleAgent(w, r)
+	if !ok {
+		return
+	}
+	latest, err := s.db.ListLatestTLSScans([]string{agentID})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{"agent_id": agentID, "latest": latest}
+
+	if n, err := strconv.Atoi(r.URL.Query().Get("history")); err == nil && n > 0 {
+		if n > 500 {
+			n = 500
+		}
+		history, err := s.db.ListTLSScanHistory(agentID, r.URL.Query().Get("server_name"), n)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		resp["history"] = history
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleScanServerTLS handles POST /api/servers/{agentId}/tls-posture/scan
+func (s *server) handleScanServerTLS(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := s.resolveAccessibleAgent(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+	results, err := s.scanAgentTLS(ctx, agentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/google/uuid"
+)
+
+// getTrafficMirror loads the mirror of the path, writing 404 when it does not exist
+func (s *server) getTrafficMirror(w http.ResponseWriter, r *http.Request) (*TrafficMirror, bool) {
+	m, err := s.db.GetTrafficMirror(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, false
+	}
+	if m == nil {
+		http.Error(w, `{"error":"traffic mirror not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	return m, true
+}
+
+// writeTrafficMirrorResult writes a mirror after it was applied, with the outcome on each agent
+// written now; 502 when no agent took it
+func writeTrafficMirrorResult(w http.ResponseWriter, m *TrafficMirror, results []TrafficMirrorAgent, created bool) {
+	m.Agents = results
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	applied := 0
+	for _, a := range results {
+		if a.Status == "applied" {
+			applied++
+		}
+	}
+	if applied == 0 {
+		code = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(m)
+}
+
+// trafficMirrorAuditDetails describes a mirror in the audit log
+func trafficMirrorAuditDetails(m *TrafficMirror, agents int) map[string]interface{} {
+	return map[string]interface{}{
+		"slug":           m.Slug,
+		"target":         m.Target,
+		"sample_percent": m.SamplePercent,
+		"locations":      m.Locations,
+		"agents":         agents,
+	}
+}
+
+// handleListTrafficMirrors handles GET /api/traffic-mirrors
+func (s *server) handleListTrafficMirrors(w http.ResponseWriter, r *http.Request) {
+	mirrors, err := s.db.ListTrafficMirrors()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mirrors)
+}
+
+// handleCreateTrafficMirror handles POST /api/traffic-mirrors: records the mirror and enables it on
+// the selected agents, returning the outcome on each
+func (s *server) handleCreateTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	var req trafficMirrorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	m, err := req.newTrafficMirror()
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, m.Selector, "traffic mirrors")
+	if !ok {
+		return
+	}
+	if exists, err := s.db.TrafficMirrorSlugExists(m.Slug); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	} else if exists {
+		http.Error(w, fmt.Sprintf(`{"error":"a traffic mirror with slug %s already exists"}`, escapeJSON(m.Slug)), http.StatusConflict)
+		return
+	}
+	m.ID, m.CreatedBy, m.UpdatedBy = uuid.New().String(), username, username
+	if err := s.db.CreateTrafficMirror(m); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyTrafficMirror(context.WithoutCancel(r.Context()), m, agentIDs, true)
+	_ = s.db.CreateAuditLog(username, "create", "traffic_mirror", m.ID, r.RemoteAddr, r.UserAgent(), trafficMirrorAuditDetails(m, len(agentIDs)))
+	writeTrafficMirrorResult(w, m, results, true)
+}
+
+// handleGetTrafficMirror handles GET /api/traffic-mirrors/{id}: the mirror with the outcome on each
+// agent
+func (s *server) handleGetTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.getTrafficMirror(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// handleUpdateTrafficMirror handles PUT /api/traffic-mirrors/{id}: changes the target, sampling,
+// locations or selector and writes an enabled mirror to its agents at once
+func (s *server) handleUpdateTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.getTrafficMirror(w, r)
+	if !ok {
+		return
+	}
+	var req trafficMirrorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.update(m); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, m.Selector, "traffic mirrors")
+	if !ok {
+		return
+	}
+	m.UpdatedBy = username
+	if err := s.db.UpdateTrafficMirror(m); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "update", "traffic_mirror", m.ID, r.RemoteAddr, r.UserAgent(), trafficMirrorAuditDetails(m, len(agentIDs)))
+	if !m.Enabled {
+		m.Agents = nil
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+		return
+	}
+	results := s.applyTrafficMirror(context.WithoutCancel(r.Context()), m, agentIDs, true)
+	writeTrafficMirrorResult(w, m, results, false)
+}
+
+// handleEnableTrafficMirror handles POST /api/traffic-mirrors/{id}/enable: starts shadowing on the
+// agents of the mirror's selection
+func (s *server) handleEnableTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	s.setTrafficMirrorEnabled(w, r, true)
+}
+
+// handleDisableTrafficMirror handles POST /api/traffic-mirrors/{id}/disable: stops shadowing on the
+// agents of the mirror's selection and on those it was enabled on that have since left it
+func (s *server) handleDisableTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	s.setTrafficMirrorEnabled(w, r, false)
+}
+
+// setTrafficMirrorEnabled records the state of a mirror and writes its files to the agents
+func (s *server) setTrafficMirrorEnabled(w http.ResponseWriter, r *http.Request, enable bool) {
+	m, ok := s.getTrafficMirror(w, r)
+	if !ok {
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, m.Selector, "traffic mirrors")
+	if !ok {
+		return
+	}
+	if !enable {
+		selected := map[string]bool{}
+		for _, id := range agentIDs {
+			selected[id] = true
+		}
+		for _, a := range m.Agents {
+			if a.Enabled && !selected[a.AgentID] {
+				agentIDs = append(agentIDs, a.AgentID)
+			}
+		}
+	}
+	if err := s.db.SetTrafficMirrorEnabled(m, enable, username); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyTrafficMirror(context.WithoutCancel(r.Context()), m, agentIDs, enable)
+	action := "enable"
+	if !enable {
+		action = "disable"
+	}
+	_ = s.db.CreateAuditLog(username, action, "traffic_mirror", m.ID, r.RemoteAddr, r.UserAgent(), trafficMirrorAuditDetails(m, len(agentIDs)))
+	writeTrafficMirrorResult(w, m, results, false)
+}
+
+// handleDeleteTrafficMirror handles DELETE /api/traffic-mirrors/{id}: stops the mirror on the
+// agents it was written to, leaving its files as comments so the includes stay valid, then deletes it
+func (s *server) handleDeleteTrafficMirror(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.getTrafficMirror(w, r)
+	if !ok {
+		return
+	}
+	_, username, ok := s.selectorTargets(w, r, m.Selector, "traffic mirrors")
+	if !ok {
+		return
+	}
+	failed := map[string]string{}
+	for _, a := range m.Agents {
+		if err := s.writeManagedFiles(context.WithoutCancel(r.Context()), a.AgentID, trafficMirrorOffFiles(m)); err != nil {
+			failed[a.AgentID] = err.Error()
+		}
+	}
+	if err := s.db.DeleteTrafficMirror(m.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	details := map[string]interface{}{"slug": m.Slug}
+	if len(failed) > 0 {
+		details["failed"] = failed
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "traffic_mirror", m.ID, r.RemoteAddr, r.UserAgent(), details)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "failed": failed})
+}
+
+// handleTrafficMirrorStats handles GET /api/traffic-mirrors/{id}/stats?window=24h (or from/to in
+// unix seconds): the requests on the mirrored locations and how many were shadowed, over time
+func (s *server) handleTrafficMirrorStats(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	m, ok := s.getTrafficMirror(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	mq, err := buildMetricQuery(MetricQuery{Metric: "requests", Window: query.Get("window"), From: from, To: to, Interval: "auto"}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	// Only agents that mirror count, or that did when the mirror is disabled, over the time it ran
+	var agentIDs []string
+	for _, a := range m.Agents {
+		if (a.Enabled || !m.Enabled) && (isSuperAdmin || s.canUserAccessAgent(user.Username, a.AgentID)) {
+			agentIDs = append(agentIDs, a.AgentID)
+		}
+	}
+	rangeFrom, rangeTo := trafficMirrorRange(m, mq.From, mq.To)
+	w.Header().Set("Content-Type", "application/json")
+	if len(agentIDs) == 0 || !rangeFrom.Before(rangeTo) {
+		json.NewEncoder(w).Encode(newTrafficMirrorStats(m, mq.From, mq.To, mq.Step, nil))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	buckets, err := s.clickhouse.GetTrafficMirrorTraffic(ctx, rangeFrom, rangeTo, mq.Step, agentIDs, m)
+	if err != nil {
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Traffic mirror stats query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(newTrafficMirrorStats(m, mq.From, mq.To, mq.Step, buckets))
+}
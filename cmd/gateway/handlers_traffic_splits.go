@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/google/uuid"
+)
+
+// selectorTargets resolves the agents managed config such as a traffic split is written to, writing
+// 403 for viewers and for users who cannot access every selected agent
+func (s *server) selectorTargets(w http.ResponseWriter, r *http.Request, sel BulkSelector, noun string) ([]string, string, bool) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	if user.Role == "viewer" {
+		http.Error(w, fmt.Sprintf(`{"error":"viewers cannot change %s"}`, noun), http.StatusForbidden)
+		return nil, "", false
+	}
+	agents, err := s.listAgentsByID(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	agentIDs, err := s.selectBulkTargets(r.Context(), sel, agents)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if len(agentIDs) == 0 {
+		http.Error(w, `{"error":"no agents match the selector"}`, http.StatusBadRequest)
+		return nil, "", false
+	}
+	if !isSuperAdmin {
+		for _, id := range agentIDs {
+			if !s.canUserAccessAgent(user.Username, id) {
+				http.Error(w, fmt.Sprintf(`{"error":"access denied to agent %s"}`, escapeJSON(id)), http.StatusForbidden)
+				return nil, "", false
+			}
+		}
+	}
+	return agentIDs, user.Username, true
+}
+
+// getTrafficSplit loads the split of the path, writing 404 when it does not exist
+func (s *server) getTrafficSplit(w http.ResponseWriter, r *http.Request) (*TrafficSplit, bool) {
+	sp, err := s.db.GetTrafficSplit(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, false
+	}
+	if sp == nil {
+		http.Error(w, `{"error":"traffic split not found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	return sp, true
+}
+
+// writeTrafficSplitResult writes a split after it was applied, with the outcome on each agent
+// written now; 502 when no agent took it
+func writeTrafficSplitResult(w http.ResponseWriter, sp *TrafficSplit, results []TrafficSplitAgent, created bool) {
+	sp.Agents = results
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	applied := 0
+	for _, a := range results {
+		if a.Status == "applied" {
+			applied++
+		}
+	}
+	if applied == 0 {
+		code = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(sp)
+}
+
+// handleListTrafficSplits handles GET /api/traffic-splits
+func (s *server) handleListTrafficSplits(w http.ResponseWriter, r *http.Request) {
+	splits, err := s.db.ListTrafficSplits()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(splits)
+}
+
+// handleCreateTrafficSplit handles POST /api/traffic-splits: records the split and writes it to the
+// selected agents, returning the outcome on each
+func (s *server) handleCreateTrafficSplit(w http.ResponseWriter, r *http.Request) {
+	var req trafficSplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	sp, err := req.newTrafficSplit()
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, sp.Selector, "traffic splits")
+	if !ok {
+		return
+	}
+	if exists, err := s.db.TrafficSplitSlugExists(sp.Slug); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	} else if exists {
+		http.Error(w, fmt.Sprintf(`{"error":"a traffic split with slug %s already exists"}`, escapeJSON(sp.Slug)), http.StatusConflict)
+		return
+	}
+	sp.ID, sp.CreatedBy, sp.UpdatedBy = uuid.New().String(), username, username
+	if err := s.db.CreateTrafficSplit(sp); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyTrafficSplit(context.WithoutCancel(r.Context()), sp, agentIDs)
+	_ = s.db.CreateAuditLog(username, "create", "traffic_split", sp.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":     sp.Slug,
+		"variants": sp.Variants,
+		"agents":   len(agentIDs),
+	})
+	writeTrafficSplitResult(w, sp, results, true)
+}
+
+// handleGetTrafficSplit handles GET /api/traffic-splits/{id}: the split with the weights each agent
+// runs with
+func (s *server) handleGetTrafficSplit(w http.ResponseWriter, r *http.Request) {
+	sp, ok := s.getTrafficSplit(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sp)
+}
+
+// handleUpdateTrafficSplit handles PUT /api/traffic-splits/{id}: changes the weights, variants, key
+// or selector and writes the split to its agents at once
+func (s *server) handleUpdateTrafficSplit(w http.ResponseWriter, r *http.Request) {
+	sp, ok := s.getTrafficSplit(w, r)
+	if !ok {
+		return
+	}
+	var req trafficSplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.update(sp); err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, sp.Selector, "traffic splits")
+	if !ok {
+		return
+	}
+	sp.UpdatedBy = username
+	if err := s.db.UpdateTrafficSplit(sp); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	results := s.applyTrafficSplit(context.WithoutCancel(r.Context()), sp, agentIDs)
+	_ = s.db.CreateAuditLog(username, "update", "traffic_split", sp.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":     sp.Slug,
+		"variants": sp.Variants,
+		"agents":   len(agentIDs),
+	})
+	writeTrafficSplitResult(w, sp, results, false)
+}
+
+// handleApplyTrafficSplit handles POST /api/traffic-splits/{id}/apply: writes the split again, e.g.
+// to agents that joined its selection or failed before
+func (s *server) handleApplyTrafficSplit(w http.ResponseWriter, r *http.Request) {
+	sp, ok := s.getTrafficSplit(w, r)
+	if !ok {
+		return
+	}
+	agentIDs, username, ok := s.selectorTargets(w, r, sp.Selector, "traffic splits")
+	if !ok {
+		return
+	}
+	results := s.applyTrafficSplit(context.WithoutCancel(r.Context()), sp, agentIDs)
+	_ = s.db.CreateAuditLog(username, "apply", "traffic_split", sp.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"slug":   sp.Slug,
+		"agents": len(agentIDs),
+	})
+	writeTrafficSplitResult(w, sp, results, false)
+}
+
+// handleDeleteTrafficSplit handles DELETE /api/traffic-splits/{id}. The split's file stays on the
+// agents, since their configs may still route on its variable.
+func (s *server) handleDeleteTrafficSplit(w http.ResponseWriter, r *http.Request) {
+	sp, ok := s.getTrafficSplit(w, r)
+	if !ok {
+		return
+	}
+	_, username, ok := s.selectorTargets(w, r, sp.Selector, "traffic splits")
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteTrafficSplit(sp.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "delete", "traffic_split", sp.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"slug": sp.Slug,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
+
+// handleTrafficSplitStats handles GET /api/traffic-splits/{id}/stats?window=1h (or from/to in unix
+// seconds): requests, error rate and latency of each variant on the agents running the split
+func (s *server) handleTrafficSplitStats(w http.ResponseWriter, r *http.Request) {
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	sp, ok := s.getTrafficSplit(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	m, err := buildMetricQuery(MetricQuery{Metric: "requests", Window: query.Get("window"), From: from, To: to, Interval: "auto"}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var agentIDs []string
+	for _, a := range sp.Agents {
+		if isSuperAdmin || s.canUserAccessAgent(user.Username, a.AgentID) {
+			agentIDs = append(agentIDs, a.AgentID)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if len(agentIDs) == 0 {
+		json.NewEncoder(w).Encode(newTrafficSplitStats(sp, m.From, m.To, nil, nil))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	addrs := trafficSplitAddresses(ctx, sp.Variants, s.trafficSplitPools(ctx, agentIDs), net.DefaultResolver.LookupHost)
+	rows, err := s.clickhouse.GetVariantTraffic(ctx, m.From, m.To, agentIDs, addrs)
+	if err != nil {
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Traffic split stats query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(newTrafficSplitStats(sp, m.From, m.To, addrs, rows))
+}
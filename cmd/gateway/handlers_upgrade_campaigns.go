@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// handleFleetVersions handles GET /api/fleet/versions: the agents the caller can see grouped by
+// agent and nginx version
+func (s *server) handleFleetVersions(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.ListAgents(r.Context(), &pb.ListAgentsRequest{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	agents := resp.Agents
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		if isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username); !isSuperAdmin {
+			visible, err := s.db.GetVisibleAgentIDs(user.Username)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+				return
+			}
+			allowed := make(map[string]bool, len(visible))
+			for _, id := range visible {
+				allowed[id] = true
+			}
+			agents = agents[:0:0]
+			for _, a := range resp.Agents {
+				if allowed[a.AgentId] {
+					agents = append(agents, a)
+				}
+			}
+		}
+	}
+	served, _ := servedAgentVersion(s.updatesDir())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fleetVersions(agents, served))
+}
+
+// handleListUpgradeCampaigns handles GET /api/fleet/upgrade-campaigns
+func (s *server) handleListUpgradeCampaigns(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	campaigns, err := s.db.ListUpgradeCampaigns()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaigns)
+}
+
+// handleCreateUpgradeCampaign handles POST /api/fleet/upgrade-campaigns
+func (s *server) handleCreateUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req upgradeCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	c, err := s.CreateUpgradeCampaign(r.Context(), req, username)
+	if err != nil {
+		writeGRPCErrorJSON(w, err)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "create", "upgrade_campaign", c.ID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"name":           c.Name,
+		"target_version": c.TargetVersion,
+		"agents":         c.Progress.Pending,
+		"batches":        c.TotalBatches,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleGetUpgradeCampaign handles GET /api/fleet/upgrade-campaigns/{id}: the campaign with the
+// result of each agent
+func (s *server) handleGetUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	c, err := s.db.GetUpgradeCampaign(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if c == nil {
+		http.Error(w, `{"error":"campaign not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleCancelUpgradeCampaign handles POST /api/fleet/upgrade-campaigns/{id}/cancel
+func (s *server) handleCancelUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	if !s.CancelUpgradeCampaign(id) {
+		http.Error(w, `{"error":"campaign is not running on this gateway"}`, http.StatusConflict)
+		return
+	}
+	_ = s.db.CreateAuditLog(username, "cancel", "upgrade_campaign", id, r.RemoteAddr, r.UserAgent(), nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"success":true}`))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/internal/common/logging"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// upstreamServerActions are the actions accepted by UpdateUpstreamServer
+var upstreamServerActions = map[string]bool{
+	"add":     true,
+	"remove":  true,
+	"drain":   true,
+	"disable": true,
+	"enable":  true,
+}
+
+func (s *server) ListUpstreams(ctx context.Context, req *pb.UpstreamListRequest) (*pb.UpstreamListResponse, error) {
+	client, conn, err := s.getAgentClient(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return client.ListUpstreams(ctx, req)
+}
+
+func (s *server) UpdateUpstreamServer(ctx context.Context, req *pb.UpstreamServerUpdate) (*pb.UpstreamServerUpdateResponse, error) {
+	if !upstreamServerActions[req.Action] {
+		return &pb.UpstreamServerUpdateResponse{Error: fmt.Sprintf("unknown action %q", req.Action)}, nil
+	}
+
+	client, conn, err := s.getAgentClient(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return client.UpdateUpstreamServer(ctx, req)
+}
+
+// resolveUpstreamAgent resolves the {agentId} path value and checks the caller may manage it.
+func (srv *server) resolveUpstreamAgent(w http.ResponseWriter, r *http.Request) (string, *middleware.User, bool) {
+	resolved, ok := srv.resolveAgentID(r.PathValue("agentId"))
+	if !ok {
+		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+		return "", nil, false
+	}
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return "", nil, false
+	}
+	if !srv.canUserAccessAgent(user.Username, resolved) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return "", nil, false
+	}
+	return resolved, user, true
+}
+
+// GET /api/servers/{agentId}/upstreams
+// Lists upstream pools with their servers (and runtime state when the Plus API is available).
+func (srv *server) handleListUpstreams(w http.ResponseWriter, r *http.Request) {
+	agentID, _, ok := srv.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	resp, err := srv.ListUpstreams(ctx, &pb.UpstreamListRequest{InstanceId: agentID})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+	if resp.Error != "" {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(resp.Error)), http.StatusInternalServerError)
+		return
+	}
+
+	upstreams := resp.Upstreams
+	if upstreams == nil {
+		upstreams = []*pb.UpstreamPool{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"upstreams": upstreams,
+		"plus_api":  resp.PlusApi,
+	})
+}
+
+// POST /api/servers/{agentId}/upstreams/{name}/servers
+// Body: {"action": "add|remove|drain|disable|enable", "address": "10.0.0.1:8080", "params": ["weight=2"]}
+func (srv *server) handleUpdateUpstreamServer(w http.ResponseWriter, r *http.Request) {
+	agentID, user, ok := srv.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+	if user.Role == "viewer" {
+		http.Error(w, `{"error":"viewers cannot modify upstreams"}`, http.StatusForbidden)
+		return
+	}
+
+	var body struct {
+		Action  string   `json:"action"`
+		Address string   `json:"address"`
+		Params  []string `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if body.Address == "" || !upstreamServerActions[body.Action] {
+		http.Error(w, `{"error":"address and a valid action (add, remove, drain, disable, enable) are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	upstream := r.PathValue("name")
+	resp, err := srv.UpdateUpstreamServer(ctx, &pb.UpstreamServerUpdate{
+		InstanceId: agentID,
+		Upstream:   upstream,
+		Address:    body.Address,
+		Action:     body.Action,
+		Params:     body.Params,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadGateway)
+		return
+	}
+
+	if err := srv.db.CreateAuditLog(user.Username, "upstream_"+body.Action, "agent", agentID, r.RemoteAddr, r.UserAgent(), map[string]interface{}{
+		"upstream": upstream,
+		"address":  body.Address,
+		"method":   resp.Method,
+		"success":  resp.Success,
+		"error":    resp.Error,
+	}); err != nil {
+		logging.Ctx(r.Context(), apiLog).Error().Msgf("Failed to write audit log for upstream change on %s: %v", agentID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Success {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
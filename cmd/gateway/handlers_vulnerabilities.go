@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/avika-ai/avika/cmd/gateway/vulns"
+)
+
+// handleVulnerabilities handles GET /api/security/vulnerabilities: the agents the caller can see
+// whose nginx, OpenSSL or kernel version is affected by an advisory of the CVE feed.
+// ?severity=high keeps high and critical findings; ?agent_id= narrows the report to one agent.
+func (s *server) handleVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	minSeverity := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("severity")))
+	if minSeverity != "" && vulns.SeverityRank(minSeverity) == 0 {
+		http.Error(w, `{"error":"severity must be one of low, medium, high, critical"}`, http.StatusBadRequest)
+		return
+	}
+	agents, err := s.db.ListAgentSoftware()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	agentID := r.URL.Query().Get("agent_id")
+	if agentID != "" {
+		if resolved, ok := s.resolveAgentID(agentID); ok {
+			agentID = resolved
+		}
+	}
+	var allowed map[string]bool
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		if isSuperAdmin, _ := s.db.IsSuperAdmin(user.Username); !isSuperAdmin {
+			visible, err := s.db.GetVisibleAgentIDs(user.Username)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+				return
+			}
+			allowed = make(map[string]bool, len(visible))
+			for _, id := range visible {
+				allowed[id] = true
+			}
+		}
+	}
+	all := agents
+	agents = agents[:0:0]
+	for _, a := range all {
+		if (allowed == nil || allowed[a.AgentID]) && (agentID == "" || a.AgentID == agentID) {
+			agents = append(agents, a)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildVulnerabilityReport(s.cveFeed, agents, minSeverity))
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookEndpointRequest is the body of create and update requests. Omitted fields keep their
+// values on update; rotate_secret issues a new signing secret.
+type webhookEndpointRequest struct {
+	Name         *string  `json:"name"`
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Enabled      *bool    `json:"enabled"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// apply merges the request into e and validates the result
+func (req *webhookEndpointRequest) apply(e *WebhookEndpoint) error {
+	if req.Name != nil {
+		e.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		e.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Events != nil {
+		e.Events = req.Events
+	}
+	if req.Enabled != nil {
+		e.Enabled = *req.Enabled
+	}
+	if e.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL")
+	}
+	if len(e.Events) == 0 {
+		return fmt.Errorf("events is required (%s or *)", strings.Join(webhookEvents, ", "))
+	}
+	for _, ev := range e.Events {
+		if ev != "*" && !slices.Contains(webhookEvents, ev) {
+			return fmt.Errorf("unknown event %q", ev)
+		}
+	}
+	return nil
+}
+
+// loadWebhookEndpoint resolves the {id} path value for a superadmin, writing any error response itself
+func (s *server) loadWebhookEndpoint(w http.ResponseWriter, r *http.Request) (*WebhookEndpoint, string, bool) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	e, err := s.db.GetWebhookEndpoint(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if e == nil {
+		http.Error(w, `{"error":"webhook not found"}`, http.StatusNotFound)
+		return nil, "", false
+	}
+	return e, username, true
+}
+
+// handleListWebhooks handles GET /api/webhooks: the endpoints without their secrets and the events
+// they can subscribe to
+func (s *server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireSuperAdmin(w, r); !ok {
+		return
+	}
+	endpoints, err := s.db.ListWebhookEndpoints()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": endpoints,
+		"events":   webhookEvents,
+	})
+}
+
+// handleCreateWebhook handles POST /api/webhooks. The response is the only one carrying the
+// signing secret.
+func (s *server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	username, ok := s.requireSuperAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req webhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	e := &WebhookEndpoint{ID: uuid.New().String(), Enabled: true, CreatedBy: username}
+	if err := req.apply(e); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	e.Secret = secret
+	if err := s.db.CreateWebhookEndpoint(e); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "create_webhook", "webhook", e.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":   e.Name,
+		"url":    e.URL,
+		"events": strings.Join(e.Events, ","),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(e)
+}
+
+// handleGetWebhook handles GET /api/webhooks/{id}
+func (s *server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	e, _, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	e.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// handleUpdateWebhook handles PUT /api/webhooks/{id}; the new secret is returned when rotated
+func (s *server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	e, username, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	var req webhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := req.apply(e); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if req.RotateSecret {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+			return
+		}
+		e.Secret = secret
+	}
+	if err := s.db.UpdateWebhookEndpoint(e); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "update_webhook", "webhook", e.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name":           e.Name,
+		"url":            e.URL,
+		"events":         strings.Join(e.Events, ","),
+		"enabled":        fmt.Sprintf("%t", e.Enabled),
+		"secret_rotated": fmt.Sprintf("%t", req.RotateSecret),
+	})
+
+	if !req.RotateSecret {
+		e.Secret = ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// handleDeleteWebhook handles DELETE /api/webhooks/{id}
+func (s *server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	e, username, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteWebhookEndpoint(e.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	s.db.CreateAuditLog(username, "delete_webhook", "webhook", e.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"name": e.Name,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestWebhook handles POST /api/webhooks/{id}/test: queues a ping event for the endpoint
+func (s *server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	e, username, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	if !e.Enabled {
+		http.Error(w, `{"error":"webhook is disabled"}`, http.StatusConflict)
+		return
+	}
+	if _, err := s.queueWebhookEvent("ping", map[string]interface{}{"triggered_by": username}, time.Now().UTC(), e.ID); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"success":true}`))
+}
+
+// handleListWebhookDeliveries handles GET /api/webhooks/{id}/deliveries?status=failed&limit=100
+func (s *server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	e, _, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+	deliveries, err := s.db.ListWebhookDeliveries(e.ID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook_id": e.ID,
+		"deliveries": deliveries,
+	})
+}
+
+// handleRedeliverWebhook handles POST /api/webhooks/{id}/deliveries/{deliveryId}/redeliver
+func (s *server) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	e, username, ok := s.loadWebhookEndpoint(w, r)
+	if !ok {
+		return
+	}
+	deliveryID := r.PathValue("deliveryId")
+	found, err := s.db.RetryWebhookDelivery(e.ID, deliveryID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, `{"error":"delivery not found"}`, http.StatusNotFound)
+		return
+	}
+	s.db.CreateAuditLog(username, "redeliver_webhook", "webhook", e.ID, r.RemoteAddr, r.UserAgent(), map[string]string{
+		"delivery_id": deliveryID,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"success":true}`))
+}
@@ -399,6 +399,11 @@ func TestAnalyticsFallbackIntegration(t *testing.T) {
 		Requests: 100,
 		Errors:   5,
 	})
+	latency := newTDigest()
+	for i := range 100 {
+		latency.Add(float64(i) / 1000)
+	}
+	srv.analytics.LatencyHistory = append(srv.analytics.LatencyHistory, latency)
 	srv.analytics.Unlock()
 
 	// Get analytics
@@ -414,6 +419,10 @@ func TestAnalyticsFallbackIntegration(t *testing.T) {
 	if len(resp.RequestRate) == 0 {
 		t.Error("Expected request rate data")
 	}
+
+	if len(resp.LatencyTrend) != 1 || resp.LatencyTrend[0].P95 < 90 || resp.LatencyTrend[0].P95 > 99 {
+		t.Errorf("Expected a latency trend with P95 of about 95ms, got %v", resp.LatencyTrend)
+	}
 }
 
 func TestConcurrentAgentAccess(t *testing.T) {
@@ -496,7 +505,7 @@ func BenchmarkHealthEndpoint(b *testing.B) {
 		b.Skip("TEST_DB_DSN or DB_DSN environment variable required")
 	}
 
-	db, err := NewDB(dsn)
+	db, err := NewDB(dsn, config.DatabaseConfig{})
 	if err != nil {
 		b.Skip("Database not available")
 	}
@@ -50,6 +50,18 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Flush lets streaming handlers (SSE, terminal playback) flush through the recorder.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // metricsAndLogMiddleware records Prometheus HTTP metrics and optionally logs each request.
 func metricsAndLogMiddleware(logger zerolog.Logger, logRequests bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -74,7 +86,7 @@ func metricsAndLogMiddleware(logger zerolog.Logger, logRequests bool) func(http.
 				if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 					remoteAddr = xff
 				}
-				logging.LogHTTPRequest(logger, method, path, remoteAddr, status, duration, rec.bytes)
+				logging.LogHTTPRequest(*logging.Ctx(r.Context(), logger), method, path, remoteAddr, status, duration, rec.bytes)
 			}
 		})
 	}
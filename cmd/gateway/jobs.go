@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	jobRetention = 30 * 24 * time.Hour
+	jobLogLimit  = 500 // log lines returned with a job
+)
+
+// jobRun reports the progress of a running job. Its methods may be called from the job's
+// goroutines concurrently; every change is persisted at once so any gateway can serve it.
+type jobRun struct {
+	mu       sync.Mutex
+	job      *Job
+	save     func(j *Job)
+	appendTo func(id string, l JobLog)
+}
+
+// setTotal sets the amount of work of the job
+func (r *jobRun) setTotal(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job.Progress.Total = n
+	r.save(r.job)
+}
+
+// advance records n more units of work done
+func (r *jobRun) advance(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job.Progress.Done += n
+	r.save(r.job)
+}
+
+// setStep records what the job is doing now and logs it when it changes
+func (r *jobRun) setStep(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Step == step {
+		return
+	}
+	r.job.Step = step
+	r.save(r.job)
+	r.appendTo(r.job.ID, JobLog{Level: "info", Message: step, LoggedAt: time.Now()})
+}
+
+// logf adds a line to the job's log at level info, warn or error
+func (r *jobRun) logf(level, format string, args ...interface{}) {
+	r.appendTo(r.job.ID, JobLog{Level: level, Message: fmt.Sprintf(format, args...), LoggedAt: time.Now()})
+}
+
+// finish records the outcome of the job: the error it returned, or context.Canceled when it stopped
+// because it was cancelled
+func (r *jobRun) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job.Status, r.job.Error = jobOutcome(err)
+	completed := time.Now()
+	r.job.CompletedAt = &completed
+	r.save(r.job)
+	if r.job.Error != "" {
+		r.appendTo(r.job.ID, JobLog{Level: "error", Message: r.job.Error, LoggedAt: completed})
+	}
+}
+
+// jobOutcome is the final status and error of a job from the error it returned
+func jobOutcome(err error) (string, string) {
+	switch {
+	case err == nil:
+		return "succeeded", ""
+	case errors.Is(err, context.Canceled):
+		return "cancelled", "cancelled"
+	}
+	return "failed", err.Error()
+}
+
+// startJob records a job of kind and runs fn in the background on this gateway until it returns.
+// id is the ID of the resource the job runs, such as a bulk action, or empty for a new one. total
+// is the amount of work when known up front. fn's context is cancelled by cancelJob.
+func (s *server) startJob(kind, id, username string, total int, fn func(ctx context.Context, run *jobRun) error) (*Job, error) {
+	if id == "" {
+		id = uuid.New().String()
+	}
+	j := &Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    "running",
+		Progress:  JobProgress{Total: total},
+		GatewayID: s.instanceID,
+		CreatedBy: username,
+	}
+	if err := s.db.CreateJob(j); err != nil {
+		return nil, fmt.Errorf("failed to record job: %w", err)
+	}
+	started := *j
+
+	run := &jobRun{job: j, save: s.saveJob, appendTo: s.appendJobLog}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.jobs.Store(j.ID, cancel)
+	go func() {
+		defer cancel()
+		defer s.jobs.Delete(j.ID)
+		run.finish(fn(ctx, run))
+		jobsLog.Info().Msgf("Job %s (%s) %s", j.ID, j.Kind, j.Status)
+	}()
+	return &started, nil
+}
+
+// cancelJob cancels the context of a job running on this gateway
+func (s *server) cancelJob(id string) bool {
+	val, ok := s.jobs.Load(id)
+	if ok {
+		val.(context.CancelFunc)()
+	}
+	return ok
+}
+
+func (s *server) saveJob(j *Job) {
+	if err := s.db.UpdateJob(j); err != nil {
+		jobsLog.Error().Msgf("Job %s: failed to save progress: %v", j.ID, err)
+	}
+}
+
+func (s *server) appendJobLog(id string, l JobLog) {
+	if err := s.db.AppendJobLog(id, l); err != nil {
+		jobsLog.Error().Msgf("Job %s: failed to save log: %v", id, err)
+	}
+}
+
+// interruptJobs closes the jobs this gateway was running before it restarted
+func (s *server) interruptJobs() {
+	if s.db == nil {
+		return
+	}
+	if n, err := s.db.InterruptJobs(s.instanceID); err != nil {
+		jobsLog.Error().Msgf("Failed to close interrupted jobs: %v", err)
+	} else if n > 0 {
+		jobsLog.Info().Msgf("Marked %d job(s) interrupted by the gateway restart", n)
+	}
+}
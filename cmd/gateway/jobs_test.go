@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newTestJobRun returns a jobRun recording its saves and log lines instead of persisting them
+func newTestJobRun(total int) (*jobRun, *[]JobProgress, *[]JobLog) {
+	saves, logs := &[]JobProgress{}, &[]JobLog{}
+	run := &jobRun{
+		job:      &Job{ID: "job", Kind: "report", Status: "running", Progress: JobProgress{Total: total}},
+		save:     func(j *Job) { *saves = append(*saves, j.Progress) },
+		appendTo: func(_ string, l JobLog) { *logs = append(*logs, l) },
+	}
+	return run, saves, logs
+}
+
+func TestJobOutcome(t *testing.T) {
+	cases := []struct {
+		err          error
+		status, want string
+	}{
+		{nil, "succeeded", ""},
+		{context.Canceled, "cancelled", "cancelled"},
+		{fmt.Errorf("generate: %w", context.Canceled), "cancelled", "cancelled"},
+		{errors.New("clickhouse connection not available"), "failed", "clickhouse connection not available"},
+	}
+	for _, c := range cases {
+		if status, msg := jobOutcome(c.err); status != c.status || msg != c.want {
+			t.Errorf("jobOutcome(%v) = %s, %q", c.err, status, msg)
+		}
+	}
+}
+
+func TestJobRunProgressAndSteps(t *testing.T) {
+	run, saves, logs := newTestJobRun(3)
+	run.setStep("Collecting report data")
+	run.setStep("Collecting report data")
+	run.advance(2)
+	run.logf("warn", "agent %s skipped", "a1")
+	run.finish(errors.New("render failed"))
+
+	j := run.job
+	if j.Step != "Collecting report data" || j.Progress != (JobProgress{Done: 2, Total: 3}) {
+		t.Errorf("job = %+v", j)
+	}
+	if j.Status != "failed" || j.Error != "render failed" || j.CompletedAt == nil {
+		t.Errorf("finished job = %+v", j)
+	}
+	if len(*saves) != 3 {
+		t.Errorf("saves = %v, want one per change and the repeated step skipped", *saves)
+	}
+	var lines []string
+	for _, l := range *logs {
+		lines = append(lines, l.Level+": "+l.Message)
+	}
+	want := "info: Collecting report data|warn: agent a1 skipped|error: render failed"
+	if strings.Join(lines, "|") != want {
+		t.Errorf("logs = %q", lines)
+	}
+}
+
+func TestTrackBulkActionResult(t *testing.T) {
+	run, _, logs := newTestJobRun(3)
+	trackBulkActionResult(run, &BulkActionResult{AgentID: "a", Status: "succeeded"})
+	trackBulkActionResult(run, &BulkActionResult{AgentID: "b", Status: "running"})
+	trackBulkActionResult(run, &BulkActionResult{AgentID: "c", Status: "failed", Error: "timeout"})
+	if run.job.Progress.Done != 2 || len(*logs) != 1 || (*logs)[0].Message != "c: timeout" {
+		t.Errorf("progress %+v, logs %+v", run.job.Progress, *logs)
+	}
+}
+
+func TestTrackUpgradeProgress(t *testing.T) {
+	run, _, logs := newTestJobRun(2)
+	c := &UpgradeCampaign{Status: "running", CurrentBatch: 1, TotalBatches: 2, TargetVersion: "1.4.0"}
+	trackUpgradeProgress(run, c, nil)
+	trackUpgradeProgress(run, c, &UpgradeResult{AgentID: "a", Status: "updating"})
+	trackUpgradeProgress(run, c, &UpgradeResult{AgentID: "a", Status: "upgraded"})
+	c.Status = "completed"
+	trackUpgradeProgress(run, c, nil)
+
+	if run.job.Step != "Batch 1 of 2: upgrading to 1.4.0" || run.job.Progress.Done != 1 || len(*logs) != 1 {
+		t.Errorf("job %+v, logs %+v", run.job, *logs)
+	}
+}
+
+func TestResourceJobErrors(t *testing.T) {
+	if err := bulkActionJobError(&BulkAction{Status: "succeeded"}); err != nil {
+		t.Errorf("succeeded bulk action: %v", err)
+	}
+	if err := bulkActionJobError(&BulkAction{Status: "cancelled"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled bulk action: %v", err)
+	}
+	if err := bulkActionJobError(&BulkAction{Status: "partial", Error: "failed on 1 of 3 attempted agents"}); err == nil || err.Error() != "failed on 1 of 3 attempted agents" {
+		t.Errorf("partial bulk action: %v", err)
+	}
+	if err := upgradeCampaignJobError(&UpgradeCampaign{Status: "completed"}); err != nil {
+		t.Errorf("completed campaign: %v", err)
+	}
+	if err := upgradeCampaignJobError(&UpgradeCampaign{Status: "aborted", Error: "above the threshold"}); err == nil {
+		t.Error("aborted campaign should fail its job")
+	}
+}
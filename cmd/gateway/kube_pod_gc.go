@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/kube"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// podGetter is the part of the kube client the pod GC uses
+type podGetter interface {
+	GetPod(ctx context.Context, namespace, name string) (*kube.Pod, error)
+}
+
+// startPodGC removes agents whose pod is confirmed deleted, instead of waiting for their
+// environment's retention to expire. Enabled with kubernetes.pod_gc.
+func (s *server) startPodGC() {
+	if s.config == nil || !s.config.Kubernetes.PodGC || s.db == nil {
+		return
+	}
+	client, err := kube.NewInClusterClient()
+	if err != nil {
+		jobsLog.Warn().Err(err).Msg("Kubernetes pod GC disabled")
+		return
+	}
+	interval := s.config.Kubernetes.PodGCInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	go func() {
+		jobsLog.Info().Dur("interval", interval).Msg("Starting Kubernetes pod GC")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			s.collectDeletedPods(ctx, client, now)
+			cancel()
+		}
+	}()
+}
+
+// podDeleted reports whether the agent's pod is gone: not found, or replaced by a new pod of
+// the same name (StatefulSets)
+func podDeleted(ctx context.Context, client podGetter, meta *pb.KubernetesMetadata) (bool, error) {
+	pod, err := client.GetPod(ctx, meta.Namespace, meta.PodName)
+	if errors.Is(err, kube.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return meta.PodUid != "" && pod.Metadata.UID != meta.PodUid, nil
+}
+
+// deletedPodAgents returns the agents offline for longer than grace whose pod is confirmed deleted
+func (s *server) deletedPodAgents(ctx context.Context, client podGetter, now time.Time, grace time.Duration) []string {
+	var gone []string
+	s.sessions.Range(func(key, value interface{}) bool {
+		session := value.(*AgentSession)
+		session.mu.Lock()
+		status, lastActive, meta := session.status, session.lastActive, session.kubernetes
+		session.mu.Unlock()
+		if status == "online" || meta == nil || meta.Namespace == "" || meta.PodName == "" || now.Sub(lastActive) < grace {
+			return true
+		}
+		deleted, err := podDeleted(ctx, client, meta)
+		if err != nil {
+			jobsLog.Error().Msgf("Pod GC: failed to look up pod %s/%s of agent %s: %v", meta.Namespace, meta.PodName, key, err)
+			return ctx.Err() == nil
+		}
+		if deleted {
+			gone = append(gone, key.(string))
+		}
+		return true
+	})
+	return gone
+}
+
+func (s *server) collectDeletedPods(ctx context.Context, client podGetter, now time.Time) {
+	grace := s.config.Kubernetes.PodGCGrace
+	gone := s.deletedPodAgents(ctx, client, now, grace)
+	if len(gone) == 0 {
+		return
+	}
+	agents, err := s.db.ListOfflineAgentsForRetention()
+	if err != nil {
+		jobsLog.Error().Msgf("Pod GC: failed to list offline agents: %v", err)
+		return
+	}
+	defaults := s.agentRetentionDefaults()
+	for _, a := range agents {
+		if !stringInSlice(gone, a.AgentID) {
+			continue
+		}
+		// The pod is gone for good, so its environment's retention no longer matters, only whether
+		// analytics are archived
+		c := newPruneCandidate(a, defaults)
+		c.PruneAt, c.Due = now, true
+		pruned, err := s.db.pruneAgent(c)
+		if err != nil {
+			jobsLog.Error().Msgf("Pod GC: failed to remove agent %s: %v", a.AgentID, err)
+			continue
+		}
+		if pruned {
+			s.finishAgentPrune(c, "pod deleted")
+		}
+	}
+}
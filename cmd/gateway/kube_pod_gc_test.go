@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/kube"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+type fakePods map[string]string // namespace/name -> uid
+
+func (f fakePods) GetPod(ctx context.Context, namespace, name string) (*kube.Pod, error) {
+	if name == "api-error" {
+		return nil, errors.New("forbidden")
+	}
+	uid, ok := f[namespace+"/"+name]
+	if !ok {
+		return nil, kube.ErrNotFound
+	}
+	pod := &kube.Pod{}
+	pod.Metadata.UID = uid
+	return pod, nil
+}
+
+func TestDeletedPodAgents(t *testing.T) {
+	now := time.Now()
+	s := &server{}
+	add := func(id, status string, offlineFor time.Duration, meta *pb.KubernetesMetadata) {
+		s.sessions.Store(id, &AgentSession{id: id, status: status, lastActive: now.Add(-offlineFor), kubernetes: meta})
+	}
+	add("deleted", "offline", time.Hour, &pb.KubernetesMetadata{Namespace: "web", PodName: "nginx-1", PodUid: "u1"})
+	add("replaced", "offline", time.Hour, &pb.KubernetesMetadata{Namespace: "web", PodName: "db-0", PodUid: "old"})
+	add("running", "offline", time.Hour, &pb.KubernetesMetadata{Namespace: "web", PodName: "nginx-2", PodUid: "u2"})
+	add("recent", "offline", time.Second, &pb.KubernetesMetadata{Namespace: "web", PodName: "nginx-3"})
+	add("online", "online", time.Hour, &pb.KubernetesMetadata{Namespace: "web", PodName: "nginx-4"})
+	add("vm", "offline", time.Hour, nil)
+	add("unknown", "offline", time.Hour, &pb.KubernetesMetadata{Namespace: "web", PodName: "api-error"})
+
+	pods := fakePods{"web/db-0": "new", "web/nginx-2": "u2"}
+	gone := s.deletedPodAgents(context.Background(), pods, now, time.Minute)
+	sort.Strings(gone)
+	if len(gone) != 2 || gone[0] != "deleted" || gone[1] != "replaced" {
+		t.Errorf("deleted pod agents = %v, want [deleted replaced]", gone)
+	}
+}
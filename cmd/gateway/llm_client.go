@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -199,7 +198,7 @@ func NewLLMClient(config *LLMConfig) (LLMClient, error) {
 	providerLower := strings.ToLower(config.Provider)
 	_, isOpenAICompatible := openAICompatibleProviders[providerLower]
 	if config.APIKey == "" && config.Provider != "ollama" && !isOpenAICompatible {
-		log.Printf("LLM: No API key configured, using mock client")
+		aiLog.Info().Msg("LLM: No API key configured, using mock client")
 		return NewMockLLMClient(), nil
 	}
 
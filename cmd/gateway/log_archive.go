@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadSHA256 is the SHA-256 of an empty request body
+const emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// archiveDayURL returns the object holding the access logs of a day
+func archiveDayURL(base string, day time.Time) string {
+	return strings.TrimRight(base, "/") + "/access_logs/" + day.Format("2006-01-02") + ".parquet"
+}
+
+// nextArchiveRun returns the next occurrence of runAt (HH:MM, UTC) after now; 02:00 when invalid
+func nextArchiveRun(now time.Time, runAt string) time.Time {
+	t, err := time.Parse("15:04", runAt)
+	if err != nil {
+		t = time.Date(0, 1, 1, 2, 0, 0, 0, time.UTC)
+	}
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// archiveDaysDue returns the UTC days, oldest first, that are at least afterDays old, still
+// within the access_logs retention (ttlDays) and not archived yet
+func archiveDaysDue(now time.Time, afterDays, ttlDays int, archived map[string]bool) []time.Time {
+	if afterDays < 1 {
+		afterDays = 1 // today is still being written
+	}
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var due []time.Time
+	for age := ttlDays - 1; age >= afterDays; age-- {
+		day := today.AddDate(0, 0, -age)
+		if !archived[day.Format("2006-01-02")] {
+			due = append(due, day)
+		}
+	}
+	return due
+}
+
+// archiveObjectFor returns the archive object at url with the configured credentials
+func (s *server) archiveObjectFor(url string) archiveObject {
+	return archiveObject{url: url, accessKey: s.config.Archive.AccessKeyID, secretKey: s.config.Archive.SecretAccessKey}
+}
+
+// startLogArchiver archives access logs to object storage every night before the ClickHouse TTL
+// drops them, and deletes archives past their retention. Enabled with archive.enabled.
+func (s *server) startLogArchiver() {
+	if s.config == nil || !s.config.Archive.Enabled || s.clickhouse == nil || s.db == nil {
+		return
+	}
+	if s.config.Archive.URL == "" {
+		analyticsLog.Info().Msg("Log archive disabled: archive.url is not set")
+		return
+	}
+	go func() {
+		for {
+			next := nextArchiveRun(time.Now(), s.config.Archive.RunAt)
+			analyticsLog.Info().Time("next_run", next).Str("url", s.config.Archive.URL).Msg("Log archive scheduled")
+			time.Sleep(time.Until(next))
+			if !s.isLeader() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+			s.runLogArchive(ctx, time.Now())
+			cancel()
+		}
+	}()
+}
+
+func (s *server) runLogArchive(ctx context.Context, now time.Time) {
+	cfg := s.config.Archive
+	archives, err := s.db.ListLogArchives()
+	if err != nil {
+		analyticsLog.Error().Err(err).Msg("Log archive: cannot list archives")
+		return
+	}
+	archived := make(map[string]bool, len(archives))
+	for _, a := range archives {
+		if a.DeletedAt == nil {
+			archived[a.Day] = true
+		}
+	}
+
+	for _, day := range archiveDaysDue(now, cfg.ArchiveAfterDays, s.accessLogRetentionDays(), archived) {
+		url := archiveDayURL(cfg.URL, day)
+		rows, err := s.clickhouse.ArchiveAccessLogDay(ctx, day, s.archiveObjectFor(url))
+		if err != nil {
+			analyticsLog.Error().Err(err).Str("day", day.Format("2006-01-02")).Msg("Log archive: export failed")
+			continue
+		}
+		if rows == 0 {
+			continue
+		}
+		if err := s.db.UpsertLogArchive(day.Format("2006-01-02"), url, int64(rows)); err != nil {
+			analyticsLog.Error().Err(err).Str("day", day.Format("2006-01-02")).Msg("Log archive: cannot record archive")
+			continue
+		}
+		analyticsLog.Info().Str("day", day.Format("2006-01-02")).Uint64("rows", rows).Str("url", url).Msg("Archived access logs")
+	}
+
+	if cfg.RetentionDays <= 0 {
+		return
+	}
+	cutoff := now.UTC().AddDate(0, 0, -cfg.RetentionDays).Format("2006-01-02")
+	expired, err := s.db.ListExpiredLogArchives(cutoff)
+	if err != nil {
+		analyticsLog.Error().Err(err).Msg("Log archive: cannot list expired archives")
+		return
+	}
+	for _, a := range expired {
+		if err := deleteArchiveObject(ctx, http.DefaultClient, cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey, a.ObjectURL, time.Now()); err != nil {
+			analyticsLog.Error().Err(err).Str("day", a.Day).Msg("Log archive: cannot delete expired archive")
+			continue
+		}
+		if err := s.db.MarkLogArchiveDeleted(a.Day); err != nil {
+			analyticsLog.Error().Err(err).Str("day", a.Day).Msg("Log archive: cannot record deletion")
+		}
+	}
+}
+
+// deleteArchiveObject deletes an object with an S3 DELETE signed with AWS Signature V4. A missing
+// object counts as deleted.
+func deleteArchiveObject(ctx context.Context, client *http.Client, region, accessKey, secretKey, url string, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	if accessKey != "" {
+		signS3Request(req, region, accessKey, secretKey, now)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("DELETE %s: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signS3Request adds AWS Signature V4 headers for an S3 request without a body. Every header
+// already set on the request is signed.
+func signS3Request(req *http.Request, region, accessKey, secretKey string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadSHA256)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.Query().Encode(), canonicalHeaders.String(), signedHeaders, emptyPayloadSHA256,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	logExportBatchSize     = 500
+	logExportFlushInterval = time.Second
+	logExportEnvCacheTTL   = 5 * time.Minute
+)
+
+var (
+	avikaLogExportMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "avika_log_export_messages_total",
+			Help: "Access log entries written to Kafka by the log export sink",
+		},
+		[]string{"topic", "result"},
+	)
+	avikaLogExportDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "avika_log_export_dropped_total",
+			Help: "Access log entries dropped because the log export queue was full",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(avikaLogExportMessagesTotal, avikaLogExportDroppedTotal)
+}
+
+// kafkaMessageWriter is the part of *kafka.Writer the exporter uses.
+type kafkaMessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type logExportItem struct {
+	agentID string
+	entry   *pb.LogEntry
+}
+
+type envCacheEntry struct {
+	slug    string
+	expires time.Time
+}
+
+// LogExporter mirrors ingested access logs to Kafka. Entries are queued without blocking
+// ingestion and written in batches; when the queue is full new entries are dropped.
+type LogExporter struct {
+	writer    kafkaMessageWriter
+	format    string
+	topic     string
+	envTopics map[string]string
+	// envOf returns the environment slug of an agent, "" when unassigned
+	envOf    func(agentID string) string
+	envCache map[string]envCacheEntry // only touched by run
+
+	queue chan logExportItem
+	done  chan struct{}
+}
+
+// NewLogExporter creates the Kafka sink and starts its writer loop.
+func NewLogExporter(cfg config.LogExportConfig, brokers string, envOf func(agentID string) string) (*LogExporter, error) {
+	if cfg.Format != "json" && cfg.Format != "protobuf" {
+		return nil, fmt.Errorf("unsupported log export format %q (json or protobuf)", cfg.Format)
+	}
+	var addrs []string
+	for _, b := range strings.Split(brokers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			addrs = append(addrs, b)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+	// Topic is set per message, so the writer must not have one
+	w := &kafka.Writer{
+		Addr:                   kafka.TCP(addrs...),
+		Balancer:               &kafka.Hash{},
+		BatchSize:              logExportBatchSize,
+		BatchTimeout:           50 * time.Millisecond,
+		RequiredAcks:           kafka.RequireOne,
+		AllowAutoTopicCreation: true,
+	}
+	return newLogExporter(w, cfg, envOf), nil
+}
+
+func newLogExporter(w kafkaMessageWriter, cfg config.LogExportConfig, envOf func(agentID string) string) *LogExporter {
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = 10000
+	}
+	e := &LogExporter{
+		writer:    w,
+		format:    cfg.Format,
+		topic:     cfg.Topic,
+		envTopics: cfg.EnvironmentTopics,
+		envOf:     envOf,
+		envCache:  make(map[string]envCacheEntry),
+		queue:     make(chan logExportItem, size),
+		done:      make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues an entry for Kafka; it never blocks.
+func (e *LogExporter) Export(agentID string, entry *pb.LogEntry) {
+	select {
+	case e.queue <- logExportItem{agentID: agentID, entry: entry}:
+	default:
+		avikaLogExportDroppedTotal.Inc()
+	}
+}
+
+// Close writes what is still queued and closes the Kafka writer.
+func (e *LogExporter) Close() {
+	close(e.queue)
+	<-e.done
+	if err := e.writer.Close(); err != nil {
+		analyticsLog.Error().Msgf("Log export: closing Kafka writer: %v", err)
+	}
+}
+
+func (e *LogExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(logExportFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]kafka.Message, 0, logExportBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.write(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case item, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			msg, err := e.message(item)
+			if err != nil {
+				analyticsLog.Error().Msgf("Log export: encoding entry from %s: %v", item.agentID, err)
+				continue
+			}
+			batch = append(batch, msg)
+			if len(batch) >= logExportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *LogExporter) write(batch []kafka.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := e.writer.WriteMessages(ctx, batch...)
+
+	// A batch can partially fail; kafka.WriteErrors reports the failed messages by index
+	var writeErrs kafka.WriteErrors
+	if err != nil {
+		if errs, ok := err.(kafka.WriteErrors); ok {
+			writeErrs = errs
+		}
+		analyticsLog.Error().Msgf("Log export: writing %d entries to Kafka: %v", len(batch), err)
+	}
+	for i, msg := range batch {
+		result := "delivered"
+		if err != nil && (writeErrs == nil || i >= len(writeErrs) || writeErrs[i] != nil) {
+			result = "failed"
+		}
+		avikaLogExportMessagesTotal.WithLabelValues(msg.Topic, result).Inc()
+	}
+}
+
+// message encodes an entry, keyed by agent so one agent's logs stay ordered within a partition.
+func (e *LogExporter) message(item logExportItem) (kafka.Message, error) {
+	var value []byte
+	var err error
+	if e.format == "protobuf" {
+		value, err = proto.Marshal(item.entry)
+	} else {
+		value, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(item.entry)
+	}
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	env := e.environment(item.agentID)
+	topic := e.topic
+	if t, ok := e.envTopics[env]; ok && t != "" {
+		topic = t
+	}
+	return kafka.Message{
+		Topic: topic,
+		Key:   []byte(item.agentID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "agent_id", Value: []byte(item.agentID)},
+			{Key: "environment", Value: []byte(env)},
+			{Key: "format", Value: []byte(e.format)},
+		},
+	}, nil
+}
+
+// environment resolves an agent's environment slug, cached so ingestion doesn't hit PostgreSQL per entry.
+func (e *LogExporter) environment(agentID string) string {
+	if e.envOf == nil {
+		return ""
+	}
+	if c, ok := e.envCache[agentID]; ok && time.Now().Before(c.expires) {
+		return c.slug
+	}
+	slug := e.envOf(agentID)
+	e.envCache[agentID] = envCacheEntry{slug: slug, expires: time.Now().Add(logExportEnvCacheTTL)}
+	return slug
+}
+
+// agentEnvironmentSlug returns the slug of the environment an agent is assigned to, "" when unassigned.
+func (s *server) agentEnvironmentSlug(agentID string) string {
+	if s.db == nil {
+		return ""
+	}
+	sa, err := s.db.GetServerAssignment(agentID)
+	if err != nil || sa == nil || sa.EnvironmentID == "" {
+		return ""
+	}
+	env, err := s.db.GetEnvironment(sa.EnvironmentID)
+	if err != nil || env == nil {
+		return ""
+	}
+	return env.Slug
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type fakeKafkaWriter struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	err    error
+	closed bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return w.err
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestLogExporterRouting(t *testing.T) {
+	w := &fakeKafkaWriter{}
+	lookups := 0
+	e := newLogExporter(w, config.LogExportConfig{
+		Topic:             "nginx-logs",
+		EnvironmentTopics: map[string]string{"production": "nginx-logs-prod"},
+		Format:            "json",
+	}, func(agentID string) string {
+		lookups++
+		if agentID == "web-1" {
+			return "production"
+		}
+		return ""
+	})
+	e.Export("web-1", &pb.LogEntry{RequestUri: "/cart", Status: 200})
+	e.Export("web-1", &pb.LogEntry{RequestUri: "/pay", Status: 502})
+	e.Export("lab-1", &pb.LogEntry{RequestUri: "/", Status: 200})
+	e.Close()
+
+	if !w.closed || len(w.msgs) != 3 {
+		t.Fatalf("closed=%v, %d messages", w.closed, len(w.msgs))
+	}
+	if w.msgs[0].Topic != "nginx-logs-prod" || string(w.msgs[0].Key) != "web-1" || header(w.msgs[0], "environment") != "production" {
+		t.Errorf("routed message = %s %s %v", w.msgs[0].Topic, w.msgs[0].Key, w.msgs[0].Headers)
+	}
+	if w.msgs[2].Topic != "nginx-logs" || header(w.msgs[2], "agent_id") != "lab-1" {
+		t.Errorf("default message = %s %v", w.msgs[2].Topic, w.msgs[2].Headers)
+	}
+	if lookups != 2 {
+		t.Errorf("environment looked up %d times, want once per agent", lookups)
+	}
+	var entry pb.LogEntry
+	if err := protojson.Unmarshal(w.msgs[1].Value, &entry); err != nil || entry.RequestUri != "/pay" || entry.Status != 502 {
+		t.Errorf("value = %s (%v)", w.msgs[1].Value, err)
+	}
+}
+
+func TestLogExporterProtobufAndFailures(t *testing.T) {
+	w := &fakeKafkaWriter{err: kafka.WriteErrors{nil, errors.New("leader not available")}}
+	e := newLogExporter(w, config.LogExportConfig{Topic: "raw-logs", Format: "protobuf"}, nil)
+	delivered := testutil.ToFloat64(avikaLogExportMessagesTotal.WithLabelValues("raw-logs", "delivered"))
+	failed := testutil.ToFloat64(avikaLogExportMessagesTotal.WithLabelValues("raw-logs", "failed"))
+
+	e.Export("web-1", &pb.LogEntry{RequestUri: "/a"})
+	e.Export("web-1", &pb.LogEntry{RequestUri: "/b"})
+	e.Close()
+
+	var entry pb.LogEntry
+	if err := proto.Unmarshal(w.msgs[0].Value, &entry); err != nil || entry.RequestUri != "/a" {
+		t.Errorf("value = %x (%v)", w.msgs[0].Value, err)
+	}
+	if got := testutil.ToFloat64(avikaLogExportMessagesTotal.WithLabelValues("raw-logs", "delivered")) - delivered; got != 1 {
+		t.Errorf("delivered = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(avikaLogExportMessagesTotal.WithLabelValues("raw-logs", "failed")) - failed; got != 1 {
+		t.Errorf("failed = %v, want 1", got)
+	}
+}
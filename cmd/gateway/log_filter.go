@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// logTailIdle ends a log stream without follow once the agents have sent nothing for this long
+const logTailIdle = 3 * time.Second
+
+// logFilter selects the entries of a log stream
+type logFilter struct {
+	status string // an exact status (404) or a class (5xx)
+	method string
+	query  string // a case-insensitive substring of the raw line or the request URI
+}
+
+func logFilterFromQuery(q url.Values) logFilter {
+	return logFilter{
+		status: strings.ToLower(q.Get("status")),
+		method: strings.ToUpper(q.Get("method")),
+		query:  strings.ToLower(q.Get("q")),
+	}
+}
+
+func (f logFilter) match(e *pb.LogEntry) bool {
+	if f.status != "" {
+		code := strconv.Itoa(int(e.Status))
+		if class, ok := strings.CutSuffix(f.status, "xx"); ok {
+			if !strings.HasPrefix(code, class) || len(code) != 3 {
+				return false
+			}
+		} else if code != f.status {
+			return false
+		}
+	}
+	if f.method != "" && !strings.EqualFold(e.RequestMethod, f.method) {
+		return false
+	}
+	if f.query != "" && !strings.Contains(strings.ToLower(e.Content), f.query) &&
+		!strings.Contains(strings.ToLower(e.RequestUri), f.query) {
+		return false
+	}
+	return true
+}
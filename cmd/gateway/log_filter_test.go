@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestLogFilter(t *testing.T) {
+	entry := &pb.LogEntry{Status: 502, RequestMethod: "POST", RequestUri: "/api/Checkout", Content: `10.0.0.1 - - "POST /api/Checkout HTTP/1.1" 502`}
+	cases := map[string]bool{
+		"":                    true,
+		"status=5xx":          true,
+		"status=502":          true,
+		"status=4xx":          false,
+		"status=500":          false,
+		"method=post":         true,
+		"method=GET":          false,
+		"q=checkout":          true,
+		"q=cart":              false,
+		"status=5xx&q=10.0.0": true,
+	}
+	for query, want := range cases {
+		q, _ := url.ParseQuery(query)
+		if got := logFilterFromQuery(q).match(entry); got != want {
+			t.Errorf("%q: match = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/avika-ai/avika/internal/common/redact"
+)
+
+// logRedactionKeys are the agent settings that scrub access logs
+var logRedactionKeys = []string{"LOG_REDACT", "LOG_REDACT_PARAMS", "LOG_REDACT_IPV4_PREFIX", "LOG_REDACT_IPV6_PREFIX"}
+
+// logRedactionCacheTTL bounds how long ingest keeps scrubbing with an agent's old rules after its
+// environment's bundle changed
+const logRedactionCacheTTL = time.Minute
+
+// validateRedactionSetting checks the value of a LOG_REDACT setting in a bundle; other keys pass
+func validateRedactionSetting(key, value string) error {
+	var err error
+	switch key {
+	case "LOG_REDACT":
+		_, err = redact.ParseRules(value)
+	case "LOG_REDACT_PARAMS":
+		_, err = redact.ParseParams(value)
+	case "LOG_REDACT_IPV4_PREFIX":
+		_, err = redact.ParsePrefix(value, 32, redact.DefaultIPv4Prefix)
+	case "LOG_REDACT_IPV6_PREFIX":
+		_, err = redact.ParsePrefix(value, 128, redact.DefaultIPv6Prefix)
+	}
+	return err
+}
+
+// LogRedactionPolicy is the scrubbing the agents of an environment apply, from the merged
+// agent config bundles
+type LogRedactionPolicy struct {
+	Rules      []string          `json:"rules"`
+	Params     []string          `json:"params"` // masked besides the built-in secret parameters
+	IPv4Prefix int               `json:"ipv4_prefix"`
+	IPv6Prefix int               `json:"ipv6_prefix"`
+	Sources    map[string]string `json:"sources"` // setting -> global or environment, for those set
+}
+
+// logRedactionPolicy reads the policy of rendered agent settings; env is the environment's own
+// bundle, which settings it sets come from
+func logRedactionPolicy(settings map[string]string, env *AgentConfigBundle) LogRedactionPolicy {
+	p := LogRedactionPolicy{Sources: map[string]string{}}
+	p.Rules, _ = redact.ParseRules(settings["LOG_REDACT"])
+	p.Params, _ = redact.ParseParams(settings["LOG_REDACT_PARAMS"])
+	var err error
+	if p.IPv4Prefix, err = redact.ParsePrefix(settings["LOG_REDACT_IPV4_PREFIX"], 32, redact.DefaultIPv4Prefix); err != nil {
+		p.IPv4Prefix = redact.DefaultIPv4Prefix
+	}
+	if p.IPv6Prefix, err = redact.ParsePrefix(settings["LOG_REDACT_IPV6_PREFIX"], 128, redact.DefaultIPv6Prefix); err != nil {
+		p.IPv6Prefix = redact.DefaultIPv6Prefix
+	}
+	if p.Rules == nil {
+		p.Rules = []string{}
+	}
+	if p.Params == nil {
+		p.Params = []string{}
+	}
+	for _, key := range logRedactionKeys {
+		if _, ok := settings[key]; !ok {
+			continue
+		}
+		p.Sources[key] = "global"
+		if env != nil {
+			if _, ok := env.Settings[key]; ok {
+				p.Sources[key] = "environment"
+			}
+		}
+	}
+	return p
+}
+
+// redactor returns the Redactor of a policy, nil when it has no rules
+func (p LogRedactionPolicy) redactor() *redact.Redactor {
+	return redact.New(redact.Config{Rules: p.Rules, Params: p.Params, IPv4Prefix: p.IPv4Prefix, IPv6Prefix: p.IPv6Prefix})
+}
+
+// matches reports whether settings an agent reports carry the policy
+func (p LogRedactionPolicy) matches(settings map[string]string) bool {
+	rules, _ := redact.ParseRules(settings["LOG_REDACT"])
+	params, _ := redact.ParseParams(settings["LOG_REDACT_PARAMS"])
+	if strings.Join(rules, ",") != strings.Join(p.Rules, ",") || strings.Join(params, ",") != strings.Join(p.Params, ",") {
+		return false
+	}
+	return settings["LOG_REDACT_IPV4_PREFIX"] == strconv.Itoa(p.IPv4Prefix) && settings["LOG_REDACT_IPV6_PREFIX"] == strconv.Itoa(p.IPv6Prefix)
+}
+
+// ingestRedactor scrubs entries as the gateway ingests them, with the policy of each agent's
+// environment
+type ingestRedactor struct {
+	policyOf func(agentID string) (LogRedactionPolicy, error)
+
+	mu    sync.Mutex
+	cache map[string]ingestRedactorEntry
+}
+
+type ingestRedactorEntry struct {
+	redactor *redact.Redactor
+	expires  time.Time
+}
+
+func newIngestRedactor(policyOf func(agentID string) (LogRedactionPolicy, error)) *ingestRedactor {
+	return &ingestRedactor{policyOf: policyOf, cache: map[string]ingestRedactorEntry{}}
+}
+
+// Apply scrubs an entry of an agent in place. Agents whose policy cannot be read keep the last one
+// read, so a database outage does not stop scrubbing.
+func (ir *ingestRedactor) Apply(agentID string, entry *pb.LogEntry) {
+	ir.mu.Lock()
+	c, ok := ir.cache[agentID]
+	ir.mu.Unlock()
+	if !ok || time.Now().After(c.expires) {
+		if p, err := ir.policyOf(agentID); err == nil {
+			c.redactor = p.redactor()
+		}
+		c.expires = time.Now().Add(logRedactionCacheTTL)
+		ir.mu.Lock()
+		ir.cache[agentID] = c
+		ir.mu.Unlock()
+	}
+	c.redactor.Apply(entry)
+}
+
+// agentLogRedactionPolicy returns the policy of an agent's environment, the fleet-wide one when
+// it is unassigned
+func (s *server) agentLogRedactionPolicy(agentID string) (LogRedactionPolicy, error) {
+	sa, err := s.db.GetServerAssignment(agentID)
+	if err != nil {
+		return LogRedactionPolicy{}, err
+	}
+	if sa != nil && sa.EnvironmentID != "" {
+		env, err := s.db.GetEnvironment(sa.EnvironmentID)
+		if err != nil {
+			return LogRedactionPolicy{}, err
+		}
+		if env != nil {
+			return s.environmentLogRedactionPolicy(env)
+		}
+	}
+	global, err := s.db.GetAgentConfigBundle("")
+	if err != nil {
+		return LogRedactionPolicy{}, err
+	}
+	return logRedactionPolicy(renderAgentConfig(global, nil, nil, nil, "").Settings, nil), nil
+}
+
+// environmentLogRedactionPolicy returns the policy the agents of an environment receive
+func (s *server) environmentLogRedactionPolicy(env *Environment) (LogRedactionPolicy, error) {
+	rendered, err := s.renderEnvironmentAgentConfig(env)
+	if err != nil {
+		return LogRedactionPolicy{}, err
+	}
+	bundle, err := s.db.GetAgentConfigBundle(env.ID)
+	if err != nil {
+		return LogRedactionPolicy{}, err
+	}
+	return logRedactionPolicy(rendered.Settings, bundle), nil
+}
+
+// redactionAuditDetails lists the LOG_REDACT settings of a bundle for the audit log, nil when it
+// sets none
+func redactionAuditDetails(settings map[string]string) map[string]string {
+	var details map[string]string
+	for _, key := range logRedactionKeys {
+		if val, ok := settings[key]; ok {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details[key] = val
+		}
+	}
+	return details
+}
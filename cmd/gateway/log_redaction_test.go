@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestLogRedactionPolicy(t *testing.T) {
+	env := &AgentConfigBundle{Settings: map[string]string{"LOG_REDACT": "ips,query"}}
+	settings := map[string]string{"LOG_REDACT": "ips,query", "LOG_REDACT_PARAMS": "OTP,pin", "LOG_LEVEL": "info"}
+	p := logRedactionPolicy(settings, env)
+	if !reflect.DeepEqual(p.Rules, []string{"query", "ips"}) || !reflect.DeepEqual(p.Params, []string{"otp", "pin"}) {
+		t.Errorf("policy = %+v", p)
+	}
+	if p.IPv4Prefix != 24 || p.IPv6Prefix != 48 {
+		t.Errorf("prefixes = %d, %d", p.IPv4Prefix, p.IPv6Prefix)
+	}
+	if !reflect.DeepEqual(p.Sources, map[string]string{"LOG_REDACT": "environment", "LOG_REDACT_PARAMS": "global"}) {
+		t.Errorf("sources = %v", p.Sources)
+	}
+
+	reported := map[string]string{"LOG_REDACT": "query,ips", "LOG_REDACT_PARAMS": "pin,otp", "LOG_REDACT_IPV4_PREFIX": "24", "LOG_REDACT_IPV6_PREFIX": "48"}
+	if !p.matches(reported) {
+		t.Error("equivalent settings should match")
+	}
+	reported["LOG_REDACT_IPV4_PREFIX"] = "16"
+	if p.matches(reported) {
+		t.Error("a different prefix should not match")
+	}
+	if logRedactionPolicy(nil, nil).redactor() != nil {
+		t.Error("no rules should give no redactor")
+	}
+}
+
+func TestValidateAgentConfigBundleRedaction(t *testing.T) {
+	ok := &AgentConfigBundle{Settings: map[string]string{"log_redact": "all", "LOG_REDACT_IPV4_PREFIX": "16"}}
+	if err := validateAgentConfigBundle(ok); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, settings := range []map[string]string{
+		{"LOG_REDACT": "query,phones"},
+		{"LOG_REDACT_PARAMS": "bad name"},
+		{"LOG_REDACT_IPV4_PREFIX": "33"},
+		{"LOG_REDACT_IPV6_PREFIX": "0"},
+	} {
+		if err := validateAgentConfigBundle(&AgentConfigBundle{Settings: settings}); err == nil {
+			t.Errorf("%v should be rejected", settings)
+		}
+	}
+}
+
+func TestIngestRedactor(t *testing.T) {
+	calls := 0
+	fail := false
+	ir := newIngestRedactor(func(agentID string) (LogRedactionPolicy, error) {
+		calls++
+		if fail {
+			return LogRedactionPolicy{}, errors.New("database down")
+		}
+		if agentID == "open" {
+			return LogRedactionPolicy{}, nil
+		}
+		return LogRedactionPolicy{Rules: []string{"ips"}, IPv4Prefix: 24, IPv6Prefix: 48}, nil
+	})
+
+	e := &pb.LogEntry{RemoteAddr: "203.0.113.77"}
+	ir.Apply("a1", e)
+	if e.RemoteAddr != "203.0.113.0" {
+		t.Errorf("remote_addr = %q", e.RemoteAddr)
+	}
+	open := &pb.LogEntry{RemoteAddr: "203.0.113.77"}
+	ir.Apply("open", open)
+	if open.RemoteAddr != "203.0.113.77" {
+		t.Errorf("agent without rules got %q", open.RemoteAddr)
+	}
+
+	// Cached policies are reused, and kept when refreshing them fails
+	fail = true
+	ir.Apply("a1", &pb.LogEntry{})
+	if calls != 2 {
+		t.Errorf("policy read %d times, want 2", calls)
+	}
+	ir.cache["a1"] = ingestRedactorEntry{redactor: ir.cache["a1"].redactor}
+	e = &pb.LogEntry{RemoteAddr: "198.51.100.9"}
+	ir.Apply("a1", e)
+	if calls != 3 || e.RemoteAddr != "198.51.100.0" {
+		t.Errorf("after failed refresh: calls = %d, remote_addr = %q", calls, e.RemoteAddr)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+	"github.com/google/uuid"
+)
+
+// Component loggers. Each component's level can be changed at runtime through
+// PUT /api/ops/log-levels, e.g. to debug ClickHouse writes without the noise of everything else.
+var (
+	analyticsLog = logging.NewLogger("analytics")
+	dbLog        = logging.NewLogger("db")
+	alertsLog    = logging.NewLogger("alerts")
+	jobsLog      = logging.NewLogger("jobs")
+	securityLog  = logging.NewLogger("security")
+	ingestLog    = logging.NewLogger("ingest")
+	aiLog        = logging.NewLogger("ai")
+	apiLog       = logging.NewLogger("api")
+	httpLog      = logging.NewLogger("http")
+)
+
+// writeLog logs failed writes of access logs, metrics and events. Those run for every agent message
+// and fail together while a backend is down, so they are sampled.
+var writeLog = logging.Sampled(analyticsLog, 10, time.Second)
+
+// requestIDHeader carries the ID of a request, taken from the caller or generated, so its log
+// lines can be found from a response
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds the request IDs taken from callers
+const maxRequestIDLen = 128
+
+// requestIDMiddleware gives every request an ID, echoed in the X-Request-ID response header and
+// added to the lines logged through logging.Ctx(r.Context(), ...)
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts the IDs of callers that are short and printable, so they cannot forge
+// log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	return !strings.ContainsFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e })
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		name, header string
+		keep         bool
+	}{
+		{"caller's ID", "req-123", true},
+		{"generated", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLen+1), false},
+		{"forged line", "abc\n{\"level\":\"error\"}", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		if tc.header != "" {
+			req.Header.Set(requestIDHeader, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		got := rec.Header().Get(requestIDHeader)
+		if got == "" || got != seen {
+			t.Errorf("%s: header %q, context %q", tc.name, got, seen)
+		}
+		if (got == tc.header) != tc.keep {
+			t.Errorf("%s: kept = %v, want %v", tc.name, got == tc.header, tc.keep)
+		}
+	}
+}
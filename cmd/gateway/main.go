@@ -7,14 +7,15 @@ import (
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -24,6 +25,9 @@ import (
 	"time"
 
 	"github.com/avika-ai/avika/cmd/gateway/config"
+	"github.com/avika-ai/avika/cmd/gateway/geo"
+	"github.com/avika-ai/avika/cmd/gateway/threatintel"
+	"github.com/avika-ai/avika/cmd/gateway/vulns"
 	"github.com/avika-ai/avika/cmd/gateway/middleware"
 	"github.com/avika-ai/avika/internal/common/logging"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
@@ -31,7 +35,6 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/expfmt"
-	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -42,8 +45,7 @@ import (
 type EndpointStats struct {
 	Requests  int64
 	Errors    int64
-	Latency   float64 // Sum of latency
-	P95       float64 // Approximate
+	Latency   *tdigest // Request times in seconds, for percentiles
 	BytesSent int64
 }
 
@@ -52,9 +54,11 @@ type AnalyticsCache struct {
 	TotalRequests  int64
 	TotalErrors    int64
 	TotalBytes     int64
+	TotalLatency   float64 // Sum of request times in seconds
 	StatusCodes    map[string]int64
 	EndpointStats  map[string]*EndpointStats
 	RequestHistory []*pb.TimeSeriesPoint // Last 24h by hour or minute
+	LatencyHistory []*tdigest            // Request times of each RequestHistory bucket
 }
 
 type server struct {
@@ -67,23 +71,94 @@ type server struct {
 	// Map agent_id -> []*pb.UptimeReport
 	uptimeReports sync.Map
 
+	// Map batch_id -> context.CancelFunc for running batch config updates
+	batches sync.Map
+
+	// Map job id -> context.CancelFunc for background jobs (bulk actions, upgrade campaigns,
+	// reports) running here
+	jobs sync.Map
+
+	// Map scheduled task id -> run id for task runs in progress here
+	scheduledTaskRuns sync.Map
+
 	// List of recommendations (simple in-memory store for MVP)
 	recommendations []*pb.Recommendation
 	recMu           sync.RWMutex
+	// requestRates derives requests per second for agents that do not report them
+	requestRates *requestRates
+	// queryQuotas counts the ad-hoc analytics queries of each user (analytics_query.go)
+	queryQuotas *queryQuotas
+
+	// recConsumer tracks read failures of the recommendation consumer, for self-monitoring
+	recConsumer consumerHealth
 
 	db         *DB
 	clickhouse *ClickHouseDB
 	alerts     *AlertEngine
 	analytics  *AnalyticsCache // Keep for legacy/fallback or remove later
+	endpoints  *uriNormalizer  // groups request URIs into endpoints (uri_templates.go)
 	config     *config.Config
 	pskManager *middleware.PSKManager
 
+	// Where the analytics page reads from: ClickHouse, or PostgreSQL with analytics.backend postgres;
+	// nil without either, leaving the in-memory counters of this gateway (analytics_store.go)
+	analyticsStore AnalyticsStore
+
+	// Validates the session tokens gRPC clients forward for RBAC (see callerOf)
+	authManager *middleware.AuthManager
+
+	// Gateway recorded as the owner of the agents connected here (ha.instance_id)
+	instanceID string
+	// Leases of the singleton background jobs shared with other gateways; nil without HA
+	leases *gatewayLeases
+
 	// AI Error Analysis
 	errorAnalysisAPI *ErrorAnalysisAPI
 
 	// Real-time log analysis (sliding-window per agent / group)
 	realtimeAggregator *RealtimeAggregator
 
+	// SQLi/XSS/path traversal and brute-force detection on ingested logs; nil when disabled
+	attackDetector *AttackDetector
+
+	// Scheduler for user-defined synthetic checks; nil when disabled
+	synthetic *SyntheticRunner
+
+	// CVE feed agents' nginx, OpenSSL and kernel versions are matched against
+	cveFeed *vulns.Store
+
+	// Kafka sink mirroring ingested access logs; nil when disabled
+	logExport *LogExporter
+
+	// Scrubs ingested access logs with the LOG_REDACT settings of each agent's environment; nil
+	// unless log_redaction.enforce_on_ingest is set
+	logRedaction *ingestRedactor
+
+	// Counts and limits the access logs each project stores in ClickHouse; nil without PostgreSQL
+	ingestQuotas *quotaEnforcer
+
+	// Map ClickHouse table -> TTL expression last applied by the retention manager
+	retentionApplied sync.Map
+
+	// Short-lived responses shared by dashboards viewing the same window; nil when disabled
+	analyticsCache *responseCache[*pb.AnalyticsResponse]
+	geoCache       *responseCache[*GeoDataResponse]
+
+	// Map status page id -> *cachedStatusPage for the public endpoint
+	statusPageCache sync.Map
+
+	// Map offlineAlertKey -> *offlineAlert for agent_offline alerts awaiting recovery
+	offlineAlerts sync.Map
+
+	// Map updateRejectionKey -> time.Time of the last agent_update_rejected alert
+	updateRejections sync.Map
+
+	// Map command id -> chan *pb.CommandResult for commands awaiting the agent's ack
+	commandResults sync.Map
+
+	// Set on shutdown once agents are asked to move to another gateway; new streams are refused
+	draining atomic.Bool
+
 	// Monitoring stats (atomic)
 	messageCount int64 // total messages received since last tick
 	dbLatencySum int64 // sum of DB latency in ns (use atomic)
@@ -91,7 +166,7 @@ type server struct {
 }
 
 // gatewayLog is the structured logger for the gateway (agent_id, hostname, ip added per event where available).
-var gatewayLog zerolog.Logger
+var gatewayLog = logging.NewLogger("gateway")
 
 type AgentSession struct {
 	id               string
@@ -115,12 +190,23 @@ type AgentSession struct {
 	podIP            string
 	pskAuthenticated bool              // true if agent connected with valid PSK
 	labels           map[string]string // Agent labels for auto-assignment (project, environment)
+	kubernetes       *pb.KubernetesMetadata  // Pod metadata when the agent runs in Kubernetes
+	gatewayID        string                  // Gateway instance holding the stream
+	updateStatus     *pb.UpdateStatus        // Outcome of the agent's last self-update
+	nginxBuilds      []*pb.NginxBuild        // Build and modules of each nginx binary
+	hostInventory    *pb.HostInventory       // OS release, kernel and package versions of the host
+	resourceStatus   *pb.AgentResourceStatus // Agent CPU and memory against its self-limits
+	nginxInstances   []*pb.NginxInstance     // NGINX instances on the host, primary first
 }
 
 func (s *server) Connect(stream pb.Commander_ConnectServer) error {
+	if s.draining.Load() {
+		return errGatewayDraining
+	}
 	// ... (existing logging) ...
 
 	var currentSession *AgentSession
+	offlineReason := availabilityDisconnect
 
 	defer func() {
 		if currentSession != nil {
@@ -133,18 +219,54 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				currentSession.stream = nil // Clear stream
 
 				agentLog := logging.WithAgent(gatewayLog, currentSession.id, currentSession.hostname, currentSession.ip)
-				// Persist offline status
-				if err := s.db.UpsertAgent(currentSession); err != nil {
+				// Persist offline status, unless the agent reconnected to another gateway meanwhile
+				marked, err := s.db.MarkAgentDisconnected(currentSession.id, currentSession.gatewayID, currentSession.lastActive)
+				if err != nil {
 					agentLog.Warn().Err(err).Msg("Failed to update agent status in DB")
 				}
-				agentLog.Info().Msg("Agent disconnected (marked offline)")
+				if marked || err != nil {
+					s.recordAgentTransition(currentSession.id, "offline", offlineReason)
+					agentLog.Info().Msg("Agent disconnected (marked offline)")
+				} else {
+					agentLog.Info().Msg("Agent disconnected (now connected to another gateway)")
+				}
 			}
 			currentSession.mu.Unlock()
 		}
 	}()
 
+	// Agents send a heartbeat every second: a stream silent for the heartbeat timeout is half-open
+	messages := receiveAgentMessages(stream)
+	var stale <-chan time.Time
+	var staleTimer *time.Timer
+	timeout := s.heartbeatTimeout()
+	if timeout > 0 {
+		staleTimer = time.NewTimer(timeout)
+		defer staleTimer.Stop()
+		stale = staleTimer.C
+	}
+
 	for {
-		msg, err := stream.Recv()
+		// Only time the wait, not how long the previous message took to handle
+		if staleTimer != nil {
+			staleTimer.Reset(timeout)
+		}
+		var msg *pb.AgentMessage
+		var err error
+		select {
+		case r := <-messages:
+			msg, err = r.msg, r.err
+		case <-stale:
+			avikaAgentStreamsStaleTotal.Inc()
+			offlineReason = availabilityStaleStream
+			if currentSession != nil {
+				agentLog := logging.WithAgent(gatewayLog, currentSession.id, currentSession.hostname, currentSession.ip)
+				agentLog.Warn().Dur("timeout", timeout).Msg("Agent stream stale, dropping it")
+			} else {
+				gatewayLog.Warn().Dur("timeout", timeout).Msg("Agent stream stale before any heartbeat, dropping it")
+			}
+			return errStreamStale
+		}
 		if err == io.EOF {
 			return nil
 		}
@@ -231,8 +353,14 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 					podIP:            hb.PodIp,
 					pskAuthenticated: pskAuthenticated,
 					labels:           hb.Labels,
+					kubernetes:       hb.Kubernetes,
+					gatewayID:        s.instanceID,
+					updateStatus:     hb.UpdateStatus,
+					resourceStatus:   hb.ResourceStatus,
+					nginxInstances:   hb.Instances,
 				}
 				s.sessions.Store(agentID, currentSession)
+				s.recordAgentTransition(agentID, "online", availabilityConnect)
 				agentLog := logging.WithAgent(gatewayLog, agentID, hb.Hostname, ip)
 				mgmt := hb.GetMgmtAddress()
 				if mgmt != "" {
@@ -249,6 +377,8 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				// Reconnecting - update existing session
 				currentSession = val.(*AgentSession)
 				currentSession.mu.Lock()
+				wasOnline := currentSession.status == "online"
+				prevAgentVersion := currentSession.agentVersion
 				currentSession.stream = stream
 				currentSession.status = "online"
 				currentSession.hostname = hb.Hostname
@@ -267,7 +397,28 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				currentSession.pskAuthenticated = pskAuthenticated
 				currentSession.lastActive = time.Now()
 				currentSession.labels = hb.Labels
+				currentSession.kubernetes = hb.Kubernetes
+				currentSession.gatewayID = s.instanceID
+				currentSession.updateStatus = hb.UpdateStatus
+				currentSession.resourceStatus = hb.ResourceStatus
+				currentSession.nginxInstances = hb.Instances
 				currentSession.mu.Unlock()
+				if !wasOnline {
+					s.recordAgentTransition(agentID, "online", availabilityConnect)
+				}
+				if prevAgentVersion != "" && agentVer != "" && prevAgentVersion != agentVer {
+					s.publishEvent(SystemEvent{
+						Type:    "agent.updated",
+						AgentID: agentID,
+						Message: fmt.Sprintf("Agent %s updated from %s to %s", agentID, prevAgentVersion, agentVer),
+						Data: map[string]interface{}{
+							"agent_id":     agentID,
+							"hostname":     hb.Hostname,
+							"from_version": prevAgentVersion,
+							"to_version":   agentVer,
+						},
+					})
+				}
 
 				// Try auto-assignment on reconnection if agent has labels but no assignment
 				if len(hb.Labels) > 0 {
@@ -285,6 +436,8 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				agentLog := logging.WithAgent(gatewayLog, currentSession.id, currentSession.hostname, currentSession.ip)
 				agentLog.Warn().Err(err).Msg("Failed to persist agent heartbeat")
 			}
+			s.recordNginxBuilds(currentSession, hb.Instances)
+			s.recordHostInventory(currentSession, hb.Host)
 
 			// Log heartbeat at debug to avoid flooding (info shows register/disconnect only)
 			agentLog := logging.WithAgent(gatewayLog, currentSession.id, currentSession.hostname, currentSession.ip)
@@ -293,6 +446,9 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 		case *pb.AgentMessage_LogEntry:
 			if currentSession != nil {
 				entry := payload.LogEntry
+				if s.logRedaction != nil {
+					s.logRedaction.Apply(currentSession.id, entry)
+				}
 
 				// 1. Distribute to subscribers
 				currentSession.mu.Lock()
@@ -304,13 +460,30 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				}
 				currentSession.mu.Unlock()
 
-				// 2. Insert into ClickHouse
-				if s.clickhouse != nil {
+				// 1b. Mirror to the Kafka log export topic
+				if s.logExport != nil {
+					s.logExport.Export(currentSession.id, entry)
+				}
+
+				// 2. Insert into the analytics store, within the daily quota of the agent's project
+				if s.analyticsStore != nil && (s.ingestQuotas == nil || s.ingestQuotas.Admit(currentSession.id, entry)) {
 					// Async insert/batching would be better, but sync for now
 					go func(e *pb.LogEntry, agentID string) {
 						start := time.Now()
-						if err := s.clickhouse.InsertAccessLog(e, agentID); err != nil {
-							log.Printf("Failed to insert log to CH: %v", err)
+						if err := s.analyticsStore.InsertAccessLog(e, agentID); err != nil {
+							writeLog.Error().Msgf("Failed to insert access log: %v", err)
+						}
+						// Traces and security events are kept in ClickHouse only
+						if s.clickhouse != nil {
+							s.insertAccessLogSpans(e, agentID)
+							if s.attackDetector != nil {
+								if events := s.attackDetector.Inspect(agentID, e); len(events) > 0 {
+									s.clickhouse.InsertSecurityEvents(events)
+								}
+							}
+							if ev, ok := parseRateLimitLog(agentID, e); ok {
+								s.clickhouse.InsertSecurityEvents([]SecurityEvent{ev})
+							}
 						}
 						s.trackDBOp(start)
 					}(entry, currentSession.id)
@@ -332,17 +505,19 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				s.analytics.StatusCodes[statusKey]++
 
 				// Endpoint Stats
-				if _, ok := s.analytics.EndpointStats[entry.RequestUri]; !ok {
-					s.analytics.EndpointStats[entry.RequestUri] = &EndpointStats{}
+				endpoint := s.endpoints.Normalize(entry.RequestUri)
+				if _, ok := s.analytics.EndpointStats[endpoint]; !ok {
+					s.analytics.EndpointStats[endpoint] = &EndpointStats{Latency: newTDigest()}
 				}
-				stats := s.analytics.EndpointStats[entry.RequestUri]
+				stats := s.analytics.EndpointStats[endpoint]
 				stats.Requests++
 				stats.BytesSent += entry.BodyBytesSent
 				if entry.Status >= 400 {
 					stats.Errors++
 				}
-				stats.Latency += float64(entry.RequestTime)
+				stats.Latency.Add(float64(entry.RequestTime))
 				s.analytics.TotalBytes += entry.BodyBytesSent
+				s.analytics.TotalLatency += float64(entry.RequestTime)
 
 				// Update TimeSeries (bucketing by hour for simplicity in this snippet)
 				// In a real impl, we'd have a ticker to create new buckets.
@@ -350,34 +525,61 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 				nowStr := time.Now().Format("15:00")
 				if len(s.analytics.RequestHistory) == 0 {
 					s.analytics.RequestHistory = append(s.analytics.RequestHistory, &pb.TimeSeriesPoint{Time: nowStr})
+					s.analytics.LatencyHistory = append(s.analytics.LatencyHistory, newTDigest())
 				}
 				lastPoint := s.analytics.RequestHistory[len(s.analytics.RequestHistory)-1]
 				if lastPoint.Time != nowStr {
 					s.analytics.RequestHistory = append(s.analytics.RequestHistory, &pb.TimeSeriesPoint{Time: nowStr})
+					s.analytics.LatencyHistory = append(s.analytics.LatencyHistory, newTDigest())
 					lastPoint = s.analytics.RequestHistory[len(s.analytics.RequestHistory)-1]
 					// Keep history small
 					if len(s.analytics.RequestHistory) > 24 {
 						s.analytics.RequestHistory = s.analytics.RequestHistory[1:]
+						s.analytics.LatencyHistory = s.analytics.LatencyHistory[1:]
 					}
 				}
 				lastPoint.Requests++
 				if entry.Status >= 400 {
 					lastPoint.Errors++
 				}
+				s.analytics.LatencyHistory[len(s.analytics.LatencyHistory)-1].Add(float64(entry.RequestTime))
 
 				s.analytics.Unlock()
 			}
 
+		case *pb.AgentMessage_CommandResult:
+			s.deliverCommandResult(payload.CommandResult)
+
+		case *pb.AgentMessage_UpdateRejected:
+			if currentSession != nil {
+				s.handleUpdateRejected(currentSession, payload.UpdateRejected)
+			}
+
+		case *pb.AgentMessage_GenericMetrics:
+			if currentSession != nil && s.clickhouse != nil {
+				s.clickhouse.InsertGenericMetrics(payload.GenericMetrics.GetMetrics(), currentSession.id, time.Unix(msg.Timestamp, 0))
+			}
+
 		case *pb.AgentMessage_Metrics:
 			if currentSession != nil {
 				metrics := payload.Metrics
+				// Agents compute requests per second from their own sampling times; older agents
+				// report none, so the gateway derives it from consecutive samples instead. Samples are
+				// timed when taken, so a backlog replayed after a reconnect keeps its spacing.
+				sampledAt := time.Now()
+				if msg.Timestamp > 0 {
+					sampledAt = time.Unix(msg.Timestamp, 0)
+				}
+				if rate := s.requestRates.observe(currentSession.id, metrics.InstanceId, metrics.TotalRequests, sampledAt); metrics.RequestsPerSecond == 0 {
+					metrics.RequestsPerSecond = rate
+				}
 
 				// Insert NGINX metrics
-				if s.clickhouse != nil {
+				if s.analyticsStore != nil {
 					go func(m *pb.NginxMetrics, agentID string) {
 						start := time.Now()
-						if err := s.clickhouse.InsertNginxMetrics(m, agentID); err != nil {
-							log.Printf("Failed to insert NGINX metrics to CH: %v", err)
+						if err := s.analyticsStore.InsertNginxMetrics(m, agentID); err != nil {
+							writeLog.Error().Msgf("Failed to insert NGINX metrics: %v", err)
 						}
 						s.trackDBOp(start)
 					}(metrics, currentSession.id)
@@ -386,8 +588,8 @@ func (s *server) Connect(stream pb.Commander_ConnectServer) error {
 					if metrics.System != nil {
 						go func(sm *pb.SystemMetrics, agentID string) {
 							start := time.Now()
-							if err := s.clickhouse.InsertSystemMetrics(sm, agentID); err != nil {
-								log.Printf("Failed to insert system metrics to CH: %v", err)
+							if err := s.analyticsStore.InsertSystemMetrics(sm, agentID); err != nil {
+								writeLog.Error().Msgf("Failed to insert system metrics: %v", err)
 							}
 							s.trackDBOp(start)
 						}(metrics.System, currentSession.id)
@@ -411,10 +613,12 @@ func (s *server) startGatewayMonitoring() {
 		gatewayID = hostname
 	}
 
-	log.Printf("Starting gateway monitoring for %s", gatewayID)
+	gatewayLog.Info().Msgf("Starting gateway monitoring for %s", gatewayID)
 
+	resources := newResourceSampler()
+	resources.sample(time.Now()) // CPU is measured from here
 	go func() {
-		for range ticker.C {
+		for now := range ticker.C {
 			// 1. Collect EPS (Events Per Second)
 			msgs := atomic.SwapInt64(&s.messageCount, 0)
 			eps := float32(msgs) / 10.0
@@ -443,21 +647,24 @@ func (s *server) startGatewayMonitoring() {
 				avgDBLat = float32(dbLatSum) / float32(dbOps) / 1000000.0 // ns to ms
 			}
 
-			// 5. CPU Usage (simple mock for now)
-			cpu := float32(0.5)
+			// 5. Process CPU and host load
+			usage := resources.sample(now)
 
 			// 6. Persist to ClickHouse
 			if s.clickhouse != nil {
 				metricPoint := &pb.GatewayMetricPoint{
 					Eps:               eps,
 					ActiveConnections: int32(activeConns),
-					CpuUsage:          cpu,
+					CpuUsage:          usage.CPUPercent,
 					MemoryMb:          memMB,
 					Goroutines:        int32(goro),
 					DbLatency:         avgDBLat,
+					Load1:             usage.Load1,
+					Load5:             usage.Load5,
+					Load15:            usage.Load15,
 				}
 				if err := s.clickhouse.InsertGatewayMetrics(gatewayID, metricPoint); err != nil {
-					log.Printf("Failed to persist gateway metrics: %v", err)
+					gatewayLog.Error().Msgf("Failed to persist gateway metrics: %v", err)
 				}
 			}
 		}
@@ -465,6 +672,9 @@ func (s *server) startGatewayMonitoring() {
 }
 
 func (s *server) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsServer) error {
+	if address, ok := s.peerGatewayOf(req.InstanceId); ok {
+		return s.forwardLogs(address, req, stream)
+	}
 	val, ok := s.sessions.Load(req.InstanceId)
 	if !ok {
 		return fmt.Errorf("agent %s not connected", req.InstanceId)
@@ -521,7 +731,11 @@ func (s *server) GetLogs(req *pb.LogRequest, stream pb.AgentService_GetLogsServe
 	}
 }
 func (s *server) ListAgents(ctx context.Context, req *pb.ListAgentsRequest) (*pb.ListAgentsResponse, error) {
+	if err := validateListAgentsRequest(req); err != nil {
+		return nil, err
+	}
 	var agents []*pb.AgentInfo
+	local := make(map[string]*pb.AgentInfo)
 
 	s.sessions.Range(func(key, value interface{}) bool {
 		session := value.(*AgentSession)
@@ -531,7 +745,7 @@ func (s *server) ListAgents(ctx context.Context, req *pb.ListAgentsRequest) (*pb
 			status = "online" // Default fallback
 		}
 
-		agents = append(agents, &pb.AgentInfo{
+		info := &pb.AgentInfo{
 			AgentId:          session.id,
 			Hostname:         session.hostname,
 			Version:          session.version,
@@ -547,10 +761,43 @@ func (s *server) ListAgents(ctx context.Context, req *pb.ListAgentsRequest) (*pb
 			GitCommit:        session.gitCommit,
 			GitBranch:        session.gitBranch,
 			PskAuthenticated: session.pskAuthenticated,
-		})
+			Labels:           session.labels,
+			Kubernetes:       session.kubernetes,
+			GatewayId:        session.gatewayID,
+			UpdateStatus:     session.updateStatus,
+			ResourceStatus:   session.resourceStatus,
+		}
+		agents = append(agents, info)
+		local[session.id] = info
 		return true
 	})
 
+	// With several gateways, add the agents connected to the others
+	if s.leases != nil {
+		connected := make(map[string]bool)
+		for _, id := range s.connectedAgentIDs() {
+			connected[id] = true
+		}
+		if shared, err := s.db.ListSharedAgents(s.leases.ttl); err != nil {
+			gatewayLog.Warn().Err(err).Msg("Cannot list agents of other gateways")
+		} else {
+			agents = mergeAgentLists(s.instanceID, local, connected, shared)
+		}
+	}
+
+	var assignments map[string]*ServerAssignmentWithDetails
+	if agentListNeedsAssignments(req) {
+		all, err := s.db.ListAllServerAssignments()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load server assignments: %v", err)
+		}
+		assignments = make(map[string]*ServerAssignmentWithDetails, len(all))
+		for i := range all {
+			assignments[all[i].AgentID] = &all[i]
+		}
+	}
+	agents, total := queryAgentList(agents, req, assignments)
+
 	// Use build-time version, fallback to file if needed
 	sysVersion := Version
 	if strings.Contains(sysVersion, "dev") || sysVersion == "0.0.1" {
@@ -562,6 +809,7 @@ func (s *server) ListAgents(ctx context.Context, req *pb.ListAgentsRequest) (*pb
 	return &pb.ListAgentsResponse{
 		Agents:        agents,
 		SystemVersion: sysVersion,
+		Total:         int32(total),
 	}, nil
 }
 
@@ -586,6 +834,12 @@ func (s *server) GetAgent(ctx context.Context, req *pb.GetAgentRequest) (*pb.Age
 		PodIp:            session.podIP,
 		AgentVersion:     session.agentVersion,
 		PskAuthenticated: session.pskAuthenticated,
+		Labels:           session.labels,
+		Kubernetes:       session.kubernetes,
+		UpdateStatus:     session.updateStatus,
+		NginxBuilds:      session.nginxBuilds,
+		ResourceStatus:   session.resourceStatus,
+		NginxInstances:   session.nginxInstances,
 	}, nil
 }
 
@@ -618,6 +872,14 @@ func (s *server) UpdateAgent(ctx context.Context, req *pb.UpdateAgentRequest) (*
 	if !ok {
 		return nil, fmt.Errorf("agent %s not found", req.AgentId)
 	}
+	if address, ok := s.peerGatewayOf(resolved); ok {
+		conn, err := s.dialPeerGateway(address, "")
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return pb.NewAgentServiceClient(conn).UpdateAgent(ctx, &pb.UpdateAgentRequest{AgentId: resolved, Version: req.Version})
+	}
 	val, _ := s.sessions.Load(resolved)
 	session := val.(*AgentSession)
 
@@ -631,35 +893,69 @@ func (s *server) UpdateAgent(ctx context.Context, req *pb.UpdateAgentRequest) (*
 		}, nil
 	}
 
-	// Construct the update URL from gateway's HTTP address
+	// Construct the update URL from gateway's HTTP address unless a public one is configured
 	// The gateway serves updates at /updates/ on its HTTP port
-	updateURL := fmt.Sprintf("http://%s/updates", s.config.GetHTTPAddress())
+	updateURL := strings.TrimRight(s.config.Server.UpdatesURL, "/")
+	if updateURL == "" {
+		updateURL = fmt.Sprintf("http://%s/updates", s.config.GetHTTPAddress())
+	}
+	version := req.Version
+	if version == "" {
+		version = "latest"
+	}
 
 	// Send update command
 	err := session.stream.Send(&pb.ServerCommand{
 		CommandId: fmt.Sprintf("upd-%d", time.Now().Unix()),
 		Payload: &pb.ServerCommand_Update{
 			Update: &pb.Update{
-				Version:   "latest",
+				Version:   version,
 				UpdateUrl: updateURL,
 			},
 		},
 	})
 
 	if err != nil {
+		s.publishCommandEvent(ctx, "update", resolved, "", err.Error())
 		return &pb.UpdateAgentResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to send update command: %v", err),
 		}, nil
 	}
+	s.publishCommandEvent(ctx, "update", resolved, "", "")
 
-	log.Printf("🚀 Triggered remote update for agent %s", req.AgentId)
+	gatewayLog.Info().Msgf("🚀 Triggered remote update for agent %s", req.AgentId)
 	return &pb.UpdateAgentResponse{
 		Success: true,
 		Message: "Update command sent to agent",
 	}, nil
 }
 
+// GetUpdateStatus returns the outcome of the agent's last self-update as reported in its heartbeats
+func (s *server) GetUpdateStatus(ctx context.Context, req *pb.GetUpdateStatusRequest) (*pb.UpdateStatus, error) {
+	resolved, ok := s.resolveAgentID(req.AgentId)
+	if !ok {
+		return nil, fmt.Errorf("agent %s not found", req.AgentId)
+	}
+	if address, ok := s.peerGatewayOf(resolved); ok {
+		conn, err := s.dialPeerGateway(address, "")
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return pb.NewAgentServiceClient(conn).GetUpdateStatus(ctx, &pb.GetUpdateStatusRequest{AgentId: resolved})
+	}
+	val, _ := s.sessions.Load(resolved)
+	session := val.(*AgentSession)
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.updateStatus == nil {
+		return &pb.UpdateStatus{}, nil
+	}
+	return session.updateStatus, nil
+}
+
 func (s *server) GetUptimeReports(ctx context.Context, req *pb.UptimeRequest) (*pb.UptimeResponse, error) {
 	val, ok := s.uptimeReports.Load(req.AgentId)
 	if !ok {
@@ -676,41 +972,27 @@ func (s *server) GetUptimeReports(ctx context.Context, req *pb.UptimeRequest) (*
 }
 
 func (s *server) GetAnalytics(ctx context.Context, req *pb.AnalyticsRequest) (*pb.AnalyticsResponse, error) {
-	if s.clickhouse != nil {
-		// Use the enhanced function with absolute time range support
-		timezone := req.Timezone
-		if timezone == "" {
-			timezone = "UTC"
-		}
-
-		// Project/environment filtering takes precedence over single agent_id
-		var agentFilter []string
-		if req.EnvironmentId != "" {
-			// Filter by specific environment
-			agents, err := s.db.GetAgentIDsForEnvironment(req.EnvironmentId)
-			if err != nil {
-				log.Printf("GetAnalytics: Failed to get agents for environment %s: %v", req.EnvironmentId, err)
-			} else {
-				agentFilter = agents
-			}
-		} else if req.ProjectId != "" {
-			// Filter by project (all environments)
-			agents, err := s.db.GetAgentIDsForProject(req.ProjectId)
-			if err != nil {
-				log.Printf("GetAnalytics: Failed to get agents for project %s: %v", req.ProjectId, err)
-			} else {
-				agentFilter = agents
-			}
-		}
-
-		// Use filtered query if we have agent filter, otherwise use standard query
-		if len(agentFilter) > 0 {
-			return s.clickhouse.GetAnalyticsWithAgentFilter(ctx, req, agentFilter)
+	if req.Compare != nil {
+		if _, err := baselineAnalyticsRequest(req, time.Now()); err != nil {
+			return nil, err
 		}
-		return s.clickhouse.GetAnalyticsWithTimeRange(ctx, req.TimeWindow, req.AgentId, req.FromTimestamp, req.ToTimestamp, timezone)
+	}
+	// Environment/project/agent filters and the caller's RBAC visibility, for gRPC, streams and HTTP alike
+	filter, visible, err := s.analyticsTenantFilter(ctx, req.AgentId, req.EnvironmentId, req.ProjectId)
+	if err != nil {
+		gatewayLog.Error().Msgf("GetAnalytics: %v", err)
+		return nil, err
+	}
+	if !visible {
+		return &pb.AnalyticsResponse{Summary: &pb.AnalyticsSummary{}}, nil
+	}
+	if s.analyticsStore != nil {
+		return s.analyticsCache.get(ctx, analyticsCacheKey(req, filter), func(ctx context.Context) (*pb.AnalyticsResponse, error) {
+			return s.queryAnalytics(ctx, req, filter)
+		})
 	}
 
-	// Fallback to in-memory if ClickHouse not available
+	// Fallback to in-memory without an analytics store
 	s.analytics.RLock()
 	defer s.analytics.RUnlock()
 
@@ -743,64 +1025,106 @@ func (s *server) GetAnalytics(ctx context.Context, req *pb.AnalyticsRequest) (*p
 
 	// Filter RequestHistory based on time window
 	requestHistory := s.analytics.RequestHistory
+	latencyHistory := s.analytics.LatencyHistory
 	if len(requestHistory) > maxPoints {
 		requestHistory = requestHistory[len(requestHistory)-maxPoints:]
 	}
+	if len(latencyHistory) > len(requestHistory) {
+		latencyHistory = latencyHistory[len(latencyHistory)-len(requestHistory):]
+	}
+
+	// Request times are in seconds, percentiles in ms
+	var latencyTrend []*pb.LatencyPercentiles
+	for i, digest := range latencyHistory {
+		point := requestHistory[i]
+		latencyTrend = append(latencyTrend, &pb.LatencyPercentiles{
+			Time: point.Time,
+			P50:  float32(digest.Quantile(0.50) * 1000),
+			P95:  float32(digest.Quantile(0.95) * 1000),
+			P99:  float32(digest.Quantile(0.99) * 1000),
+		})
+	}
 
 	// Convert Status Codes
 	var statusDist []*pb.StatusCount
+	summary := &pb.AnalyticsSummary{
+		TotalRequests:  s.analytics.TotalRequests,
+		TotalBandwidth: uint64(s.analytics.TotalBytes),
+	}
 	for k, v := range s.analytics.StatusCodes {
 		statusDist = append(statusDist, &pb.StatusCount{Code: k, Count: v})
+		switch k[0] {
+		case '2':
+			summary.Requests_2Xx += v
+		case '3':
+			summary.Requests_3Xx += v
+		case '4':
+			summary.Requests_4Xx += v
+		case '5':
+			summary.Requests_5Xx += v
+		}
+	}
+	if s.analytics.TotalRequests > 0 {
+		summary.ErrorRate = float32(float64(s.analytics.TotalErrors) / float64(s.analytics.TotalRequests) * 100)
+		summary.AvgLatency = float32(s.analytics.TotalLatency / float64(s.analytics.TotalRequests) * 1000)
 	}
 
 	// Convert Top Endpoints
 	var topEndpoints []*pb.EndpointStat
 	for k, v := range s.analytics.EndpointStats {
-		avgLat := 0.0
-		if v.Requests > 0 {
-			avgLat = v.Latency / float64(v.Requests)
-		}
 		topEndpoints = append(topEndpoints, &pb.EndpointStat{
 			Uri:      k,
 			Requests: v.Requests,
 			Errors:   v.Errors,
-			P95:      float32(avgLat * 1.5), // Approximation for MVP
+			P50:      float32(v.Latency.Quantile(0.50) * 1000),
+			P95:      float32(v.Latency.Quantile(0.95) * 1000),
 			Traffic:  formatBytes(v.BytesSent),
 		})
 	}
 
-	// Return mock response with summary for UI testing
 	return &pb.AnalyticsResponse{
 		RequestRate:        requestHistory,
 		StatusDistribution: statusDist,
 		TopEndpoints:       topEndpoints,
-		LatencyTrend:       []*pb.LatencyPercentiles{}, // Todo
-		Summary: &pb.AnalyticsSummary{
-			TotalRequests:  12500,
-			Requests_2Xx:   11800,
-			Requests_3Xx:   450,
-			Requests_4Xx:   180,
-			Requests_5Xx:   70,
-			ErrorRate:      2.0,
-			AvgLatency:     42.5,
-			TotalBandwidth: 1024 * 1024 * 850, // 850MB
-		},
+		LatencyTrend:       latencyTrend,
+		Summary:            summary,
 	}, nil
 }
 
+// queryAnalytics runs the ClickHouse analytics queries of a request over the rows of filter
+// (every row when empty)
+func (s *server) queryAnalytics(ctx context.Context, req *pb.AnalyticsRequest, filter tenantFilter) (*pb.AnalyticsResponse, error) {
+	resp, err := s.analyticsStore.GetAnalyticsWithFilter(ctx, req, filter)
+	if err != nil {
+		return nil, err
+	}
+	if s.config != nil {
+		applyEgressCost(resp, s.config.Bandwidth)
+	}
+	if req.Compare != nil {
+		if err := s.compareAnalytics(ctx, req, resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
 func (s *server) StreamAnalytics(req *pb.AnalyticsRequest, stream pb.AgentService_StreamAnalyticsServer) error {
-	log.Printf("Starting analytics stream for agent %s (window: %s)", req.AgentId, req.TimeWindow)
+	gatewayLog.Info().Msgf("Starting analytics stream for agent %s (window: %s, deltas: %v)", req.AgentId, req.TimeWindow, req.Deltas)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	// With req.Deltas only changes are sent between periodic full snapshots (analytics_stream.go)
+	streamer := &analyticsStreamer{deltas: req.Deltas}
+
 	// Send initial data immediately
 	if resp, err := s.GetAnalytics(stream.Context(), req); err == nil {
-		if err := stream.Send(resp); err != nil {
-			log.Printf("StreamAnalytics initial send error: %v", err)
+		if err := stream.Send(streamer.next(resp, time.Now())); err != nil {
+			gatewayLog.Error().Msgf("StreamAnalytics initial send error: %v", err)
 			return err
 		}
 	} else {
-		log.Printf("StreamAnalytics initial fetch error: %v", err)
+		gatewayLog.Error().Msgf("StreamAnalytics initial fetch error: %v", err)
 	}
 
 	for {
@@ -810,11 +1134,15 @@ func (s *server) StreamAnalytics(req *pb.AnalyticsRequest, stream pb.AgentServic
 		case <-ticker.C:
 			resp, err := s.GetAnalytics(stream.Context(), req)
 			if err != nil {
-				log.Printf("StreamAnalytics error: %v", err)
+				gatewayLog.Error().Msgf("StreamAnalytics error: %v", err)
 				continue
 			}
-			if err := stream.Send(resp); err != nil {
-				log.Printf("StreamAnalytics send error: %v", err)
+			msg := streamer.next(resp, time.Now())
+			if msg == nil {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				gatewayLog.Error().Msgf("StreamAnalytics send error: %v", err)
 				return err
 			}
 		}
@@ -873,6 +1201,13 @@ func (s *server) resolveAgentID(requestedID string) (actualKey string, ok bool)
 		}
 		return true
 	})
+	if found == "" && s.leases != nil {
+		// An agent that connected to another gateway after this one loaded the agents
+		if session, err := s.db.FindAgentSession(requestedID); err == nil && session != nil {
+			actual, _ := s.sessions.LoadOrStore(session.id, session)
+			found = actual.(*AgentSession).id
+		}
+	}
 	return found, found != ""
 }
 
@@ -907,9 +1242,16 @@ func (s *server) pickReachableMgmtTarget(session *AgentSession) string {
 func (s *server) getAgentClient(agentID string) (pb.AgentServiceClient, *grpc.ClientConn, error) {
 	resolved, ok := s.resolveAgentID(agentID)
 	if !ok {
-		log.Printf("Agent lookup failed for ID: %s", agentID)
+		gatewayLog.Warn().Msgf("Agent lookup failed for ID: %s", agentID)
 		return nil, nil, fmt.Errorf("agent %s not found", agentID)
 	}
+	if address, ok := s.peerGatewayOf(resolved); ok {
+		conn, err := s.dialPeerGateway(address, resolved)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pb.NewAgentServiceClient(conn), conn, nil
+	}
 	val, _ := s.sessions.Load(resolved)
 	session := val.(*AgentSession)
 
@@ -918,7 +1260,7 @@ func (s *server) getAgentClient(agentID string) (pb.AgentServiceClient, *grpc.Cl
 	if len(session.mgmtCandidates) > 0 {
 		if t := s.pickReachableMgmtTarget(session); t != "" {
 			target = t
-			log.Printf("Using probed mgmt address %s for agent %s (from %d candidates)", target, agentID, len(session.mgmtCandidates))
+			gatewayLog.Info().Msgf("Using probed mgmt address %s for agent %s (from %d candidates)", target, agentID, len(session.mgmtCandidates))
 		}
 	}
 	// Fallback: existing single mgmt_address / connection peer logic
@@ -939,7 +1281,7 @@ func (s *server) getAgentClient(agentID string) (pb.AgentServiceClient, *grpc.Cl
 					}
 				}
 				target = net.JoinHostPort(session.ip, port)
-				log.Printf("Using connection peer %s for agent %s (mgmt_address %s differs, peer is reachable)", target, agentID, session.mgmtAddress)
+				gatewayLog.Info().Msgf("Using connection peer %s for agent %s (mgmt_address %s differs, peer is reachable)", target, agentID, session.mgmtAddress)
 			} else {
 				if mgmtPortStr != "" {
 					target = session.mgmtAddress
@@ -950,16 +1292,16 @@ func (s *server) getAgentClient(agentID string) (pb.AgentServiceClient, *grpc.Cl
 					}
 					target = net.JoinHostPort(mgmtHost, strconv.Itoa(port))
 				}
-				log.Printf("Using mgmt_address %s for agent %s", target, agentID)
+				gatewayLog.Info().Msgf("Using mgmt_address %s for agent %s", target, agentID)
 			}
 		} else {
 			targetIP := session.ip
 			if session.isPod && session.podIP != "" {
 				targetIP = session.podIP
-				log.Printf("Using podIP %s for pod agent %s (connection IP was %s)", targetIP, agentID, session.ip)
+				gatewayLog.Info().Msgf("Using podIP %s for pod agent %s (connection IP was %s)", targetIP, agentID, session.ip)
 			}
 			if targetIP == "" {
-				log.Printf("Agent %s has no IP (isPod: %v, podIP: %s, ip: %s)", agentID, session.isPod, session.podIP, session.ip)
+				gatewayLog.Info().Msgf("Agent %s has no IP (isPod: %v, podIP: %s, ip: %s)", agentID, session.isPod, session.podIP, session.ip)
 				return nil, nil, fmt.Errorf("agent %s has no IP", agentID)
 			}
 			agentPort := s.config.Agent.MgmtPort
@@ -969,7 +1311,7 @@ func (s *server) getAgentClient(agentID string) (pb.AgentServiceClient, *grpc.Cl
 			target = fmt.Sprintf("%s:%d", targetIP, agentPort)
 		}
 	}
-	log.Printf("Found session for %s, dialing %s", agentID, target)
+	gatewayLog.Info().Msgf("Found session for %s, dialing %s", agentID, target)
 
 	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -1070,16 +1412,41 @@ func (s *server) UpdateConfig(ctx context.Context, req *pb.ConfigUpdate) (*pb.Co
 			_, backupErr := s.db.conn.ExecContext(ctx, query,
 				req.InstanceId, "manual", currentCfgResp.Config.Content, certs)
 			if backupErr != nil {
-				log.Printf("Warning: Failed to save config backup to DB for agent %s: %v", req.InstanceId, backupErr)
+				gatewayLog.Warn().Msgf("Failed to save config backup to DB for agent %s: %v", req.InstanceId, backupErr)
 			} else {
-				log.Printf("Successfully created config backup in DB for agent %s prior to update", req.InstanceId)
+				gatewayLog.Info().Msgf("Successfully created config backup in DB for agent %s prior to update", req.InstanceId)
 			}
 		} else {
-			log.Printf("Could not fetch current config for agent %s prior to update, skipping DB backup. Err: %v", req.InstanceId, err)
+			gatewayLog.Error().Msgf("Could not fetch current config for agent %s prior to update, skipping DB backup. Err: %v", req.InstanceId, err)
 		}
 	}
 
-	return client.UpdateConfig(ctx, req)
+	// Snippets the new config includes are written first so the agent's nginx -t finds them
+	if req.NewContent != "" {
+		if err := s.materializeSnippets(ctx, client, req.InstanceId, req.NewContent); err != nil {
+			return &pb.ConfigUpdateResponse{Success: false, Error: err.Error()}, nil
+		}
+	}
+
+	resp, err := client.UpdateConfig(ctx, req)
+	if err == nil && resp.Success {
+		ev := SystemEvent{
+			Type:    "config.changed",
+			AgentID: req.InstanceId,
+			Message: fmt.Sprintf("Config %s updated on %s", req.ConfigPath, req.InstanceId),
+			Data: map[string]interface{}{
+				"agent_id":          req.InstanceId,
+				"nginx_instance_id": req.NginxInstanceId,
+				"config_path":       req.ConfigPath,
+				"backup_path":       resp.BackupPath,
+			},
+		}
+		if user := middleware.GetUserFromContext(ctx); user != nil {
+			ev.Actor = user.Username
+		}
+		s.publishEvent(ev)
+	}
+	return resp, err
 }
 
 func (s *server) ValidateConfig(ctx context.Context, req *pb.ConfigValidation) (*pb.ValidationResult, error) {
@@ -1099,7 +1466,9 @@ func (s *server) ReloadNginx(ctx context.Context, req *pb.ReloadRequest) (*pb.Re
 	}
 	defer conn.Close()
 
-	return client.ReloadNginx(ctx, req)
+	resp, err := client.ReloadNginx(ctx, req)
+	s.publishCommandEvent(ctx, "reload", req.InstanceId, req.NginxInstanceId, commandFailure(err, resp.GetSuccess(), resp.GetError()))
+	return resp, err
 }
 
 func (s *server) RestartNginx(ctx context.Context, req *pb.RestartRequest) (*pb.RestartResponse, error) {
@@ -1109,7 +1478,9 @@ func (s *server) RestartNginx(ctx context.Context, req *pb.RestartRequest) (*pb.
 	}
 	defer conn.Close()
 
-	return client.RestartNginx(ctx, req)
+	resp, err := client.RestartNginx(ctx, req)
+	s.publishCommandEvent(ctx, "restart", req.InstanceId, req.NginxInstanceId, commandFailure(err, resp.GetSuccess(), resp.GetError()))
+	return resp, err
 }
 
 func (s *server) StopNginx(ctx context.Context, req *pb.StopRequest) (*pb.StopResponse, error) {
@@ -1119,7 +1490,9 @@ func (s *server) StopNginx(ctx context.Context, req *pb.StopRequest) (*pb.StopRe
 	}
 	defer conn.Close()
 
-	return client.StopNginx(ctx, req)
+	resp, err := client.StopNginx(ctx, req)
+	s.publishCommandEvent(ctx, "stop", req.InstanceId, req.NginxInstanceId, commandFailure(err, resp.GetSuccess(), resp.GetError()))
+	return resp, err
 }
 
 func (s *server) ListCertificates(ctx context.Context, req *pb.CertListRequest) (*pb.CertListResponse, error) {
@@ -1132,59 +1505,9 @@ func (s *server) ListCertificates(ctx context.Context, req *pb.CertListRequest)
 	return client.ListCertificates(ctx, req)
 }
 
-func (s *server) startUptimeCrawler() {
-	ticker := time.NewTicker(30 * time.Second)
-	go func() {
-		for range ticker.C {
-			s.sessions.Range(func(key, value interface{}) bool {
-				session := value.(*AgentSession)
-				agentID := key.(string)
-
-				// Skip offline agents for uptime checks?
-				// Currently just mocking, but logically we can't check if offline.
-				if session.status == "offline" {
-					return true
-				}
-
-				status := "UP"
-				latency := 0.0 // Real probes not implemented yet, using 0 instead of mock
-				var errStr string
-
-				// In a real crawler, we'd do:
-				// start := time.Now()
-				// resp, err := http.Get(fmt.Sprintf("http://%s", session.hostname))
-				// latency = float64(time.Since(start).Milliseconds())
-				// if err != nil { status = "DOWN"; errStr = err.Error() }
-
-				report := &pb.UptimeReport{
-					Timestamp: time.Now().Unix(),
-					Status:    status,
-					LatencyMs: float32(latency),
-					CheckType: "HTTP",
-					Target:    session.hostname,
-					Error:     errStr,
-				}
-
-				// Store report
-				var reports []*pb.UptimeReport
-				if val, ok := s.uptimeReports.Load(agentID); ok {
-					reports = val.([]*pb.UptimeReport)
-				}
-				reports = append([]*pb.UptimeReport{report}, reports...)
-				if len(reports) > 50 {
-					reports = reports[:50]
-				}
-				s.uptimeReports.Store(agentID, reports)
-
-				return true
-			})
-		}
-	}()
-}
-
 func (s *server) startRecommendationConsumer() {
 	if s.config == nil || !s.config.LLM.Enabled {
-		log.Println("AI Engine disabled, skipping recommendation consumer")
+		gatewayLog.Info().Msg("AI Engine disabled, skipping recommendation consumer")
 		return
 	}
 
@@ -1193,27 +1516,34 @@ func (s *server) startRecommendationConsumer() {
 		if brokers == "" {
 			brokers = "redpanda:9092"
 		}
+		// Recommendations are kept in memory, so with several gateways each one reads all of them
+		groupID := "gateway-recommendation-consumer"
+		if s.leases != nil {
+			groupID += "-" + s.instanceID
+		}
 		r := kafka.NewReader(kafka.ReaderConfig{
 			Brokers:  []string{brokers},
 			Topic:    "optimization-recommendations",
-			GroupID:  "gateway-recommendation-consumer",
+			GroupID:  groupID,
 			MinBytes: 10e3, // 10KB
 			MaxBytes: 10e6, // 10MB
 		})
 
-		log.Printf("Started consuming recommendations from Kafka (%s)", brokers)
+		gatewayLog.Info().Msgf("Started consuming recommendations from Kafka (%s)", brokers)
 
 		for {
 			m, err := r.ReadMessage(context.Background())
 			if err != nil {
-				log.Printf("Error reading recommendation: %v", err)
+				gatewayLog.Error().Msgf("Error reading recommendation: %v", err)
+				s.recConsumer.failed(time.Now())
 				time.Sleep(5 * time.Second) // backoff
 				continue
 			}
+			s.recConsumer.ok()
 
 			var rec pb.Recommendation
 			if err := json.Unmarshal(m.Value, &rec); err != nil {
-				log.Printf("Error unmarshalling recommendation: %v", err)
+				gatewayLog.Error().Msgf("Error unmarshalling recommendation: %v", err)
 				continue
 			}
 
@@ -1226,47 +1556,29 @@ func (s *server) startRecommendationConsumer() {
 			}
 			s.recMu.Unlock()
 
-			log.Printf("Received recommendation: %s", rec.Title)
+			gatewayLog.Info().Msgf("Received recommendation: %s", rec.Title)
 		}
 	}()
 }
 
 func (srv *server) startBackgroundPruning() {
 	go func() {
-		// Prune more frequently (every 12 hours) to keep it clean
-		ticker := time.NewTicker(12 * time.Hour)
+		interval := 12 * time.Hour
+		if srv.config != nil && srv.config.Agent.PruneInterval > 0 {
+			interval = srv.config.Agent.PruneInterval
+		}
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
-		// Retention is now 10 days for offline agents
-		retentionPeriod := 10 * 24 * time.Hour
-
-		prune := func() {
-			ids, err := srv.db.PruneStaleAgents(retentionPeriod)
-			if err != nil {
-				log.Printf("Failed to prune stale agents: %v", err)
-				return
-			}
-			if len(ids) > 0 {
-				log.Printf("Pruned %d stale agents (offline > 10 days): %v", len(ids), ids)
-
-				// Cleanup ClickHouse data for these agents
-				if srv.clickhouse != nil {
-					for _, id := range ids {
-						if err := srv.clickhouse.DeleteAgentData(id); err != nil {
-							log.Printf("Failed to cleanup ClickHouse data for pruned agent %s: %v", id, err)
-						} else {
-							log.Printf("Cleaned up ClickHouse analytics for pruned agent %s", id)
-						}
-					}
-				}
-			}
+		// Run once at startup; retention comes from the agent's environment policy or agent.retention_period
+		if srv.isLeader() {
+			srv.pruneStaleAgents()
 		}
 
-		// Run once at startup
-		prune()
-
 		for range ticker.C {
-			prune()
+			if srv.isLeader() {
+				srv.pruneStaleAgents()
+			}
 		}
 	}()
 }
@@ -1282,13 +1594,23 @@ func (srv *server) startHeartbeatMonitoring() {
 		timeout := 5 * time.Minute
 
 		monitor := func() {
-			count, err := srv.db.MarkStaleAgentsOffline(timeout)
+			ids, err := srv.db.MarkStaleAgentsOffline(timeout)
 			if err != nil {
 				gatewayLog.Error().Err(err).Msg("Heartbeat monitor failed")
 				return
 			}
-			if count > 0 {
-				gatewayLog.Info().Int64("count", count).Msg("Marked stale agents as offline")
+			for _, id := range ids {
+				// Mirror the change in memory so the next heartbeat is seen as a reconnection
+				if val, ok := srv.sessions.Load(id); ok {
+					session := val.(*AgentSession)
+					session.mu.Lock()
+					session.status = "offline"
+					session.mu.Unlock()
+				}
+				srv.recordAgentTransition(id, "offline", availabilityHeartbeatTimeout)
+			}
+			if len(ids) > 0 {
+				gatewayLog.Info().Int("count", len(ids)).Msg("Marked stale agents as offline")
 			}
 		}
 
@@ -1318,7 +1640,7 @@ func main() {
 
 	cfg, err := config.LoadConfig(*configFile)
 	if err != nil {
-		log.Printf("Failed to load config: %v", err)
+		gatewayLog.Error().Msgf("Failed to load config: %v", err)
 	}
 
 	// Structured logging: default info, level/format configurable via LOG_LEVEL and LOG_FORMAT
@@ -1335,7 +1657,17 @@ func main() {
 		Service: "gateway",
 		Version: Version,
 	})
-	gatewayLog = logging.NewLogger("gateway")
+	for _, spec := range strings.Split(cfg.LogComponents, ",") {
+		component, level, _ := strings.Cut(strings.TrimSpace(spec), "=")
+		if component == "" {
+			continue
+		}
+		if err := logging.SetLevel(component, level); err != nil {
+			gatewayLog.Warn().Err(err).Msg("Ignoring log_components entry")
+		}
+	}
+	// What dependencies still write through the standard log package
+	logging.RedirectStdLog(logging.NewLogger("stdlog"))
 
 	// Log startup configuration
 	gatewayLog.Info().
@@ -1365,19 +1697,42 @@ func main() {
 	}
 	gatewayLog.Info().Msg("PostgreSQL connected and migrations applied")
 
-	// ── ClickHouse ──────────────────────────────────────────────────────
-	gatewayLog.Info().
-		Str("address", cfg.ClickHouse.Address).
-		Msg("Connecting to ClickHouse...")
-	chDB, err := connectToClickHouse(cfg)
+	// Request URIs are grouped into endpoints at ingest (uri_templates.go)
+	endpoints, err := newURINormalizer(cfg.Analytics)
 	if err != nil {
-		gatewayLog.Warn().Err(err).
-			Str("address", cfg.ClickHouse.Address).
-			Msg("ClickHouse is not available. Analytics, visitor stats, and geo data will be unavailable. " +
-				"The gateway will continue to operate for agent management and configuration. " +
-				"To enable analytics, ensure ClickHouse is running and accessible.")
+		gatewayLog.Fatal().Err(err).Msg("Invalid analytics.uri_templates")
+	}
+
+	// ── ClickHouse ──────────────────────────────────────────────────────
+	// With analytics.backend postgres analytics are kept in PostgreSQL instead (analytics_postgres.go)
+	var chDB *ClickHouseDB
+	if cfg.Analytics.Backend == analyticsBackendPostgres {
+		gatewayLog.Info().Msg("Analytics backend is PostgreSQL. Traces, visitor stats, geo data and security events need ClickHouse and will be unavailable.")
 	} else {
-		gatewayLog.Info().Str("address", cfg.ClickHouse.Address).Msg("ClickHouse connected")
+		if cfg.Analytics.Backend != analyticsBackendClickHouse {
+			gatewayLog.Warn().Str("backend", cfg.Analytics.Backend).Msg("Unknown analytics.backend, using clickhouse")
+		}
+		gatewayLog.Info().
+			Str("address", cfg.ClickHouse.Address).
+			Msg("Connecting to ClickHouse...")
+		chDB, err = connectToClickHouse(cfg)
+		if err != nil {
+			gatewayLog.Warn().Err(err).
+				Str("address", cfg.ClickHouse.Address).
+				Msg("ClickHouse is not available. Analytics, visitor stats, and geo data will be unavailable. " +
+					"The gateway will continue to operate for agent management and configuration. " +
+					"To enable analytics, ensure ClickHouse is running and accessible, or set analytics.backend to postgres.")
+		} else {
+			gatewayLog.Info().Str("address", cfg.ClickHouse.Address).Msg("ClickHouse connected")
+			chDB.endpoints = endpoints
+			startGeoIP(ctx, cfg.GeoIP, chDB.geoLookup)
+			if cfg.GeoIP.BotNetworks != "" {
+				if err := chDB.botNetworks.LoadFile(cfg.GeoIP.BotNetworks); err != nil {
+					gatewayLog.Error().Msgf("Failed to load bot networks: %v", err)
+				}
+			}
+			startThreatIntel(ctx, cfg, chDB.threats)
+		}
 	}
 
 	// Kafka configuration
@@ -1426,7 +1781,6 @@ func main() {
 		)
 		gatewayLog.Info().Msg("PSK authentication enabled for agent connections")
 	}
-	s := grpc.NewServer(grpcOpts...)
 
 	// Initialize server
 	srv := &server{
@@ -1438,10 +1792,64 @@ func main() {
 			EndpointStats:  make(map[string]*EndpointStats),
 			RequestHistory: []*pb.TimeSeriesPoint{},
 		},
+		endpoints:          endpoints,
 		config:             cfg,
 		alerts:             NewAlertEngine(db, chDB, cfg),
+		requestRates:       newRequestRates(),
+		queryQuotas:        newQueryQuotas(),
 		pskManager:         pskManager,
 		realtimeAggregator: NewRealtimeAggregator(),
+		analyticsCache:     newResponseCache[*pb.AnalyticsResponse]("analytics", cfg.ClickHouse.AnalyticsCacheTTL),
+		geoCache:           newResponseCache[*GeoDataResponse]("geo", cfg.ClickHouse.AnalyticsCacheTTL),
+		instanceID:         cfg.HA.InstanceID,
+		cveFeed:            vulns.NewStore(),
+	}
+	// The GeoIP offline flag marks an air-gapped site; only a local feed file is loaded there
+	vulns.NewRefresher(srv.cveFeed, cfg.Vulnerabilities.FeedURL, cfg.Vulnerabilities.FeedPath, cfg.Vulnerabilities.RefreshInterval, cfg.GeoIP.Offline).Start(ctx)
+	if cfg.Security.AttackDetection {
+		srv.attackDetector = NewAttackDetector(cfg.Security.BruteForceThreshold, cfg.Security.BruteForceWindow)
+	}
+	srv.alerts.banOffenders = srv.banAlertOffenders
+	srv.alerts.isLeader = srv.isLeader
+	srv.alerts.emitEvent = srv.publishEvent
+	srv.alerts.recordTransition = srv.recordAlertTransition
+	srv.alerts.gatewayHealth = srv.gatewayHealthValue
+	if cfg.Synthetic.Enabled {
+		srv.synthetic = NewSyntheticRunner(cfg.Synthetic.Region, cfg.Synthetic.Concurrency, parseSyntheticNetworks(cfg.Synthetic.AllowedNetworks))
+	}
+	if cfg.LogExport.Enabled {
+		brokers := cfg.LogExport.Brokers
+		if brokers == "" {
+			brokers = cfg.Kafka.Brokers
+		}
+		if exporter, err := NewLogExporter(cfg.LogExport, brokers, srv.agentEnvironmentSlug); err != nil {
+			gatewayLog.Error().Err(err).Msg("Kafka log export disabled")
+		} else {
+			srv.logExport = exporter
+			gatewayLog.Info().Str("brokers", brokers).Str("topic", cfg.LogExport.Topic).Str("format", cfg.LogExport.Format).Msg("Mirroring access logs to Kafka")
+		}
+	}
+
+	// Rows are stamped with the project and environment of their agent (clickhouse_tenancy.go)
+	if chDB != nil && srv.db != nil {
+		chDB.SetTenantLookup(srv.db.ListAgentTenants)
+	}
+
+	// Access logs and agent metrics are stored for the analytics page (analytics_store.go)
+	if chDB != nil {
+		srv.analyticsStore = chDB
+	} else if cfg.Analytics.Backend == analyticsBackendPostgres && srv.db != nil {
+		srv.analyticsStore = NewPostgresAnalytics(srv.db, srv.db.ListAgentTenants, endpoints)
+	}
+
+	// Access logs count toward the daily ingest quota of their project (project_quotas.go)
+	if srv.db != nil {
+		srv.ingestQuotas = newQuotaEnforcer(srv.db.ListAgentTenants, srv.db.ListProjectQuotas, srv.db.AddProjectIngestUsage, srv.notifyProjectQuota)
+	}
+
+	if cfg.LogRedaction.EnforceOnIngest && srv.db != nil {
+		srv.logRedaction = newIngestRedactor(srv.agentLogRedactionPolicy)
+		gatewayLog.Info().Msg("Redacting ingested access logs with each environment's LOG_REDACT settings")
 	}
 
 	// ── AI / LLM ───────────────────────────────────────────────────────
@@ -1478,13 +1886,31 @@ func main() {
 	}
 
 	// Start background services
-	srv.startUptimeCrawler()
+	srv.startGatewayLeases()
+	srv.interruptJobs()
+	srv.interruptUpgradeCampaigns()
+	srv.interruptBulkActions()
+	srv.startSyntheticChecks()
 	if cfg.LLM.Enabled {
 		srv.startRecommendationConsumer()
 	}
 	srv.startBackgroundPruning()
 	srv.startHeartbeatMonitoring()
+	srv.startAvailabilityMonitor()
+	srv.startPodGC()
 	srv.startGatewayMonitoring()
+	srv.startCertificateMonitor()
+	srv.startBanExpiry()
+	srv.startTLSScanner()
+	srv.startRetentionManager()
+	srv.startLogArchiver()
+	srv.startTaskScheduler()
+	srv.startMaintenanceScheduler()
+	srv.startWebhookDispatcher()
+	srv.startEventRetention()
+	srv.startProjectQuotas()
+	srv.startPostgresAnalyticsRetention()
+	srv.startAccessCacheListener()
 	srv.alerts.Start()
 
 	// ── HTTP server ─────────────────────────────────────────────────────
@@ -1511,9 +1937,32 @@ func main() {
 			Msg("Cannot bind gRPC port. Check if another process is using this port or if you have permission.")
 	}
 
+	// Other gateways forward calls for the agents connected here
+	if cfg.HA.Enabled {
+		grpcOpts = append(grpcOpts,
+			grpc.UnknownServiceHandler(srv.handleForwardedCall),
+			grpc.ForceServerCodecV2(newForwardCodec()),
+		)
+	}
+	grpcOpts = append(grpcOpts, grpcKeepaliveOptions(cfg)...)
+	s := grpc.NewServer(grpcOpts...)
 	pb.RegisterCommanderServer(s, srv)
 	pb.RegisterAgentServiceServer(s, srv)
 
+	// ── OTLP receiver for agentless hosts ───────────────────────────────
+	var otlpServer *grpc.Server
+	if cfg.OTLP.Enabled {
+		var creds credentials.TransportCredentials
+		if cfg.Security.EnableTLS {
+			if tlsConfig, err := loadServerTLSConfig(cfg); err == nil {
+				creds = credentials.NewTLS(tlsConfig)
+			}
+		}
+		if otlpServer, err = srv.startOTLPReceiver(creds); err != nil {
+			gatewayLog.Error().Err(err).Int("port", cfg.OTLP.GRPCPort).Msg("Cannot start OTLP gRPC receiver")
+		}
+	}
+
 	// ── gRPC server ─────────────────────────────────────────────────────
 	go func() {
 		gatewayLog.Info().Str("address", cfg.GetGRPCAddress()).Msg("gRPC server listening")
@@ -1529,6 +1978,7 @@ func main() {
 		Bool("tls", cfg.Security.EnableTLS).
 		Bool("psk", cfg.PSK.Enabled).
 		Bool("clickhouse", chDB != nil).
+		Str("analytics", cfg.Analytics.Backend).
 		Bool("llm", cfg.LLM.Enabled).
 		Msg("Gateway started successfully — ready to accept connections")
 
@@ -1536,18 +1986,27 @@ func main() {
 	sig := <-sigChan
 	gatewayLog.Info().Str("signal", sig.String()).Msg("Received shutdown signal, initiating graceful shutdown...")
 
+	// Move connected agents to other gateways before their streams are cut
+	if cfg.Security.DrainTimeout > 0 {
+		if remaining := srv.drainAgents(cfg.Security.DrainTimeout); remaining > 0 {
+			gatewayLog.Warn().Int("agents", remaining).Msg("Agents still connected after drain timeout")
+		} else {
+			gatewayLog.Info().Msg("All agents drained")
+		}
+	}
+
 	// Create shutdown context with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, cfg.Security.ShutdownTimeout)
 	defer shutdownCancel()
 
 	// Shutdown HTTP server
-	log.Println("Shutting down HTTP server...")
+	gatewayLog.Info().Msg("Shutting down HTTP server...")
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+		gatewayLog.Error().Msgf("HTTP server shutdown error: %v", err)
 	}
 
 	// Gracefully stop gRPC server
-	log.Println("Shutting down gRPC server...")
+	gatewayLog.Info().Msg("Shutting down gRPC server...")
 	stopped := make(chan struct{})
 	go func() {
 		s.GracefulStop()
@@ -1556,16 +2015,26 @@ func main() {
 
 	select {
 	case <-stopped:
-		log.Println("gRPC server stopped gracefully")
+		gatewayLog.Info().Msg("gRPC server stopped gracefully")
 	case <-shutdownCtx.Done():
-		log.Println("Shutdown timeout, forcing gRPC stop")
+		gatewayLog.Warn().Msg("Shutdown timeout, forcing gRPC stop")
 		s.Stop()
 	}
 
+	if otlpServer != nil {
+		otlpServer.Stop()
+	}
+	if srv.logExport != nil {
+		srv.logExport.Close()
+	}
+
 	// Stop alert engine
 	srv.alerts.Stop()
 
-	log.Println("Gateway shutdown complete")
+	// Hand the singleton jobs over to the other gateways
+	srv.leases.release()
+
+	gatewayLog.Info().Msg("Gateway shutdown complete")
 }
 
 // connectToDatabase connects to PostgreSQL with retries
@@ -1574,7 +2043,7 @@ func connectToDatabase(cfg *config.Config) (*DB, error) {
 	var err error
 
 	for i := 0; i < cfg.Database.MaxRetries; i++ {
-		db, err = NewDB(cfg.Database.DSN)
+		db, err = NewDB(cfg.Database.DSN, cfg.Database)
 		if err == nil {
 			return db, nil
 		}
@@ -1591,7 +2060,7 @@ func connectToDatabase(cfg *config.Config) (*DB, error) {
 	fallbackDSN := os.Getenv("DB_DSN")
 	if fallbackDSN != "" {
 		gatewayLog.Info().Str("dsn", maskDSN(fallbackDSN)).Msg("Trying fallback PostgreSQL connection from DB_DSN env var...")
-		db, err = NewDB(fallbackDSN)
+		db, err = NewDB(fallbackDSN, cfg.Database)
 		if err == nil {
 			return db, nil
 		}
@@ -1602,14 +2071,12 @@ func connectToDatabase(cfg *config.Config) (*DB, error) {
 
 // connectToClickHouse connects to ClickHouse with fallback
 func connectToClickHouse(cfg *config.Config) (*ClickHouseDB, error) {
-	chDB, err := NewClickHouseDB(
-		cfg.ClickHouse.Address,
-		cfg.ClickHouse.Username,
-		cfg.ClickHouse.Password,
-	)
-	if err != nil {
+	chDB, err := NewClickHouseDB(cfg.ClickHouse)
+	if err != nil && cfg.ClickHouse.Cluster == "" {
 		// Try fallback with same credentials
-		chDB, err = NewClickHouseDB("127.0.0.1:9000", cfg.ClickHouse.Username, cfg.ClickHouse.Password)
+		local := cfg.ClickHouse
+		local.Address, local.ReadAddress = "127.0.0.1:9000", ""
+		chDB, err = NewClickHouseDB(local)
 		if err != nil {
 			return nil, err
 		}
@@ -1617,6 +2084,59 @@ func connectToClickHouse(cfg *config.Config) (*ClickHouseDB, error) {
 	return chDB, nil
 }
 
+// startGeoIP loads local MaxMind databases into the lookup and, unless offline, keeps them
+// up to date when a license key is configured.
+func startGeoIP(ctx context.Context, cfg config.GeoIPConfig, lookup *geo.GeoIPLookup) {
+	cityPath, asnPath := cfg.CityDatabase, cfg.ASNDatabase
+	if cityPath == "" {
+		cityPath = filepath.Join(cfg.DatabaseDir, geo.EditionCity+".mmdb")
+	}
+	if asnPath == "" {
+		asnPath = filepath.Join(cfg.DatabaseDir, geo.EditionASN+".mmdb")
+	}
+
+	if err := lookup.LoadLocal(cityPath, asnPath); err != nil {
+		gatewayLog.Error().Msgf("GeoIP: failed to load local databases: %v", err)
+	}
+
+	switch {
+	case cfg.Offline:
+		gatewayLog.Info().Msg("GeoIP: offline mode, automatic database downloads disabled")
+	case cfg.LicenseKey == "":
+		if !lookup.HasMMDB() {
+			gatewayLog.Info().Msgf("GeoIP: no MaxMind database found at %s and no license key configured; using built-in estimates", cityPath)
+		}
+	default:
+		geo.NewUpdater(lookup, geo.UpdaterConfig{
+			AccountID:  cfg.AccountID,
+			LicenseKey: cfg.LicenseKey,
+			Databases:  map[string]string{geo.EditionCity: cityPath, geo.EditionASN: asnPath},
+			Interval:   cfg.UpdateInterval,
+		}).Start(ctx)
+	}
+}
+
+// startThreatIntel loads the configured threat feeds and keeps them refreshed
+func startThreatIntel(ctx context.Context, cfg *config.Config, store *threatintel.Store) {
+	if len(cfg.ThreatIntel.Feeds) == 0 {
+		return
+	}
+	feeds := make([]threatintel.Feed, 0, len(cfg.ThreatIntel.Feeds))
+	for _, f := range cfg.ThreatIntel.Feeds {
+		feeds = append(feeds, threatintel.Feed{
+			Name:          f.Name,
+			Type:          f.Type,
+			URL:           f.URL,
+			Path:          f.Path,
+			APIKey:        f.APIKey,
+			MinConfidence: f.MinConfidence,
+			Category:      f.Category,
+		})
+	}
+	// The GeoIP offline flag marks an air-gapped site; only local list files are loaded there
+	threatintel.NewRefresher(store, feeds, cfg.ThreatIntel.RefreshInterval, cfg.GeoIP.Offline).Start(ctx)
+}
+
 // ensureUpdatesDir creates updates dir and bin/, writes version.json if missing, copies agent binaries from repo bin/ when present.
 // It always (re)generates .sha256 from the actual binary on disk so checksums stay in sync when you deploy a new binary or restart the gateway.
 func ensureUpdatesDir(dir string) {
@@ -1633,7 +2153,7 @@ func ensureUpdatesDir(dir string) {
 			}
 		}
 		_ = os.WriteFile(versionPath, []byte(fmt.Sprintf(`{"version":%q,"build_date":"","git_commit":""}`, v)), 0644)
-		log.Printf("Wrote %s (version %s)", versionPath, v)
+		gatewayLog.Info().Msgf("Wrote %s (version %s)", versionPath, v)
 	}
 	for _, name := range []string{"agent-linux-amd64", "agent-linux-arm64"} {
 		dst := binDir + "/" + name
@@ -1646,7 +2166,7 @@ func ensureUpdatesDir(dir string) {
 			if err := os.WriteFile(dst, data, 0755); err != nil {
 				continue
 			}
-			log.Printf("Copied %s -> %s", src, dst)
+			gatewayLog.Info().Msgf("Copied %s -> %s", src, dst)
 		}
 		// Always (re)generate .sha256 from the binary we serve so checksum never gets out of sync
 		sum, err := sha256SumFile(dst)
@@ -1657,7 +2177,7 @@ func ensureUpdatesDir(dir string) {
 		if err := os.WriteFile(shaPath, []byte(sum+"\n"), 0644); err != nil {
 			continue
 		}
-		log.Printf("Wrote %s (sha256 of %s)", shaPath, name)
+		gatewayLog.Info().Msgf("Wrote %s (sha256 of %s)", shaPath, name)
 	}
 }
 
@@ -1834,6 +2354,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 		CookieDomain: cfg.Auth.CookieDomain,
 		UserLookup:   userLookup,
 	})
+	srv.authManager = authManager
 
 	// Public paths that don't require authentication
 	publicPaths := []string{
@@ -1880,12 +2401,12 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 			srv.db, // implements TeamMapper
 		)
 		if err != nil {
-			log.Printf("Warning: Failed to initialize OIDC provider: %v", err)
+			gatewayLog.Warn().Msgf("Failed to initialize OIDC provider: %v", err)
 		} else {
 			mux.HandleFunc("/api/auth/oidc/login", oidcProvider.InitHandler())
 			mux.HandleFunc("/api/auth/oidc/callback", oidcProvider.CallbackHandler())
 			mux.HandleFunc("/api/auth/oidc/status", oidcProvider.StatusHandler())
-			log.Printf("OIDC SSO enabled with provider: %s", cfg.OIDC.ProviderURL)
+			gatewayLog.Info().Msgf("OIDC SSO enabled with provider: %s", cfg.OIDC.ProviderURL)
 		}
 	}
 
@@ -1909,7 +2430,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 			srv.db, // implements TeamMapper
 		)
 		if err != nil {
-			log.Printf("Warning: Failed to initialize LDAP provider: %v", err)
+			gatewayLog.Warn().Msgf("Failed to initialize LDAP provider: %v", err)
 		} else {
 			mux.HandleFunc("/api/auth/ldap/login", ldapProvider.LoginHandler())
 		}
@@ -1935,7 +2456,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 			srv.db, // implements TeamMapper
 		)
 		if err != nil {
-			log.Printf("Warning: Failed to initialize SAML provider: %v", err)
+			gatewayLog.Warn().Msgf("Failed to initialize SAML provider: %v", err)
 		} else {
 			mux.Handle("/saml/", samlProvider.HTTPHandlers())
 		}
@@ -1952,9 +2473,9 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	})
 
 	if cfg.Auth.Enabled {
-		log.Printf("Authentication enabled for user: %s", cfg.Auth.Username)
+		gatewayLog.Info().Msgf("Authentication enabled for user: %s", cfg.Auth.Username)
 	} else {
-		log.Printf("Authentication disabled - all endpoints are public")
+		gatewayLog.Info().Msg("Authentication disabled - all endpoints are public")
 	}
 
 	// WebSocket upgrader with origin validation
@@ -1971,7 +2492,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 					return true
 				}
 			}
-			log.Printf("Rejected WebSocket connection from origin: %s", origin)
+			gatewayLog.Warn().Msgf("Rejected WebSocket connection from origin: %s", origin)
 			return false
 		},
 	}
@@ -1986,6 +2507,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 
 	// Export report endpoint with rate limiting and auth
 	mux.Handle("/export-report", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleExportReport))))
+	mux.Handle("GET /api/export/{dataset}", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleExport))))
 
 	// Geo API endpoint
 	mux.Handle("/api/geo", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGeoData)))
@@ -1995,6 +2517,11 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 
 	// Main analytics API with URL and Status Filtering
 	mux.Handle("/api/analytics", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleAnalytics)))
+	mux.Handle("POST /api/analytics/cache/purge", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePurgeAnalyticsCache)))
+	mux.Handle("GET /api/analytics/latency-heatmap", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleLatencyHeatmap)))
+	mux.Handle("GET /api/analytics/service-map", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleServiceMap)))
+	mux.Handle("POST /api/analytics/query", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleAnalyticsQuery)))
+	mux.Handle("GET /api/analytics/query/catalog", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleAnalyticsQueryCatalog)))
 
 	// ============================================================================
 	// RBAC / Multi-Tenancy API Endpoints
@@ -2005,6 +2532,15 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("POST /api/projects", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateProject)))
 	mux.Handle("GET /api/projects/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetProject)))
 	mux.Handle("PUT /api/projects/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateProject)))
+	mux.Handle("GET /api/projects/{id}/quota", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetProjectQuota)))
+	mux.Handle("PUT /api/projects/{id}/quota", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutProjectQuota)))
+	mux.Handle("GET /api/projects/{id}/usage", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetProjectUsage)))
+	mux.Handle("GET /api/projects/{id}/alerts/packs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListProjectAlertPacks)))
+	mux.Handle("PUT /api/projects/{id}/alerts/packs/{pack}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutProjectAlertPack)))
+	mux.Handle("DELETE /api/projects/{id}/alerts/packs/{pack}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteProjectAlertPack)))
+	mux.Handle("GET /api/alerts/packs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListAlertPacks)))
+	mux.Handle("GET /api/alerts/history", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleAlertHistory)))
+	mux.Handle("GET /api/usage/projects", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListProjectUsage)))
 	mux.Handle("DELETE /api/projects/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteProject)))
 
 	// Environments API
@@ -2014,6 +2550,11 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("PUT /api/environments/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateEnvironment)))
 	mux.Handle("DELETE /api/environments/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteEnvironment)))
 
+	// JSON mirror of the unary AgentService RPCs
+	mux.Handle("GET /api/rpc", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListRPCs)))
+	mux.Handle("GET /api/rpc/{method}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRPC)))
+	mux.Handle("POST /api/rpc/{method}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRPC)))
+
 	// Server Assignment API
 	mux.Handle("GET /api/server-assignments", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListServerAssignments)))
 	mux.Handle("GET /api/servers", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListAgents)))
@@ -2022,16 +2563,106 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("DELETE /api/servers/{agentId}/assign", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUnassignServer)))
 	mux.Handle("PUT /api/servers/{agentId}/tags", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateServerTags)))
 	mux.Handle("GET /api/servers/{agentId}/drift", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetServerDrift)))
+	mux.Handle("GET /api/servers/{agentId}/disks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleServerDisks)))
 	mux.Handle("GET /api/servers/{agentId}/realtime-stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleServerRealtimeStats)))
 	mux.Handle("GET /api/projects/{id}/drift/compare", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCompareDrift)))
 	mux.Handle("GET /api/groups/{id}/logs/stream", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGroupLogsStream)))
+	mux.Handle("GET /api/servers/{agentId}/logs/stream", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleServerLogsStream)))
 	mux.Handle("GET /api/groups/{id}/realtime-stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGroupRealtimeStats)))
 
 	// Certificate Management API (proxy to agent)
+	mux.Handle("GET /api/certificates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListFleetCertificates)))
+	mux.Handle("POST /api/certificates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUploadFleetCertificate)))
+	mux.Handle("GET /api/certificates/inventory", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetCertificateInventory)))
+	mux.Handle("POST /api/certificates/{id}/deploy", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeployFleetCertificate)))
 	mux.Handle("GET /api/servers/{agentId}/certificates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListCertificates)))
 	mux.Handle("POST /api/servers/{agentId}/certificates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUploadCertificate)))
 	mux.Handle("DELETE /api/servers/{agentId}/certificates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteCertificate)))
 
+	// TLS / security header posture of agent virtual hosts
+	mux.Handle("GET /api/tls-posture", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListTLSPosture)))
+	mux.Handle("GET /api/servers/{agentId}/tls-posture", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetServerTLSPosture)))
+	mux.Handle("POST /api/servers/{agentId}/tls-posture/scan", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleScanServerTLS))))
+
+	// Deregistration of offline agents, per environment
+	mux.Handle("GET /api/environments/{id}/retention-policy", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetAgentRetentionPolicy)))
+	mux.Handle("PUT /api/environments/{id}/retention-policy", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateAgentRetentionPolicy)))
+	mux.Handle("DELETE /api/environments/{id}/retention-policy", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteAgentRetentionPolicy)))
+	mux.Handle("GET /api/agents/prune-preview", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePrunePreview)))
+
+	// ClickHouse table retention, fleet-wide and per environment
+	mux.Handle("GET /api/retention/tables", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListTableRetention)))
+	mux.Handle("PUT /api/retention/tables/{table}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateTableRetention)))
+	mux.Handle("POST /api/retention/tenant-backfill", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleTenantBackfill)))
+
+	// Schema migrations of PostgreSQL and ClickHouse
+	mux.Handle("GET /api/ops/migrations", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetMigrations)))
+
+	// Runtime diagnostics: pprof, goroutines, expvar, effective config and batch queues
+	mux.Handle("GET /debug/pprof/", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDebugPprof)))
+	mux.Handle("GET /debug/goroutines", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDebugGoroutines)))
+	mux.Handle("GET /debug/vars", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDebugVars)))
+	mux.Handle("GET /debug/config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDebugConfig)))
+	mux.Handle("GET /debug/queues", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDebugQueues)))
+
+	// Log levels per component, changed at runtime
+	mux.Handle("GET /api/ops/log-levels", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetLogLevels)))
+	mux.Handle("PUT /api/ops/log-levels", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutLogLevels)))
+
+	// Cold archive of access logs in object storage
+	mux.Handle("GET /api/archive/logs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListLogArchives)))
+	mux.Handle("GET /api/archive/logs/{day}", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleQueryLogArchive))))
+	mux.Handle("POST /api/archive/logs/{day}/restore", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRestoreLogArchive)))
+	mux.Handle("DELETE /api/archive/logs/{day}/restore", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleReleaseLogArchive)))
+
+	// Agent configuration bundles, fleet-wide and per environment
+	mux.Handle("GET /api/agent-config", middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleGetAgentConfig))) // No auth - agents use enrollment tokens
+	mux.Handle("GET /api/agent-config/global", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetGlobalAgentConfig)))
+	mux.Handle("PUT /api/agent-config/global", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutGlobalAgentConfig)))
+	mux.Handle("GET /api/environments/{id}/agent-config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetEnvironmentAgentConfig)))
+	mux.Handle("PUT /api/environments/{id}/agent-config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutEnvironmentAgentConfig)))
+	mux.Handle("DELETE /api/environments/{id}/agent-config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteEnvironmentAgentConfig)))
+
+	// Historical agent connectivity
+	mux.Handle("GET /api/availability", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListAvailability)))
+	mux.Handle("GET /api/servers/{agentId}/availability", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetServerAvailability)))
+
+	// Samples from agents' custom collectors
+	mux.Handle("GET /api/generic-metrics", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListGenericMetrics)))
+	mux.Handle("GET /api/generic-metrics/series", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetGenericMetricSeries)))
+
+	// Agent runtime settings, changed over the command channel
+	mux.Handle("GET /api/servers/{agentId}/runtime-settings", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetAgentRuntimeSettings)))
+	mux.Handle("PUT /api/servers/{agentId}/runtime-settings", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateAgentRuntimeSettings)))
+
+	// Synthetic checks run by the gateways
+	mux.Handle("GET /api/synthetic/checks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListSyntheticChecks)))
+	mux.Handle("POST /api/synthetic/checks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateSyntheticCheck)))
+	mux.Handle("GET /api/synthetic/checks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetSyntheticCheck)))
+	mux.Handle("PUT /api/synthetic/checks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateSyntheticCheck)))
+	mux.Handle("DELETE /api/synthetic/checks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteSyntheticCheck)))
+	mux.Handle("POST /api/synthetic/checks/{id}/run", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleRunSyntheticCheck))))
+	mux.Handle("GET /api/synthetic/checks/{id}/results", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetSyntheticResults)))
+	mux.Handle("GET /api/synthetic/checks/{id}/stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetSyntheticStats)))
+
+	// Status pages built from synthetic checks and SLOs; the public view authenticates with the page token
+	mux.Handle("GET /api/status-pages", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListStatusPages)))
+	mux.Handle("POST /api/status-pages", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateStatusPage)))
+	mux.Handle("PUT /api/status-pages/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateStatusPage)))
+	mux.Handle("DELETE /api/status-pages/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteStatusPage)))
+	mux.Handle("POST /api/status-pages/{id}/token", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRotateStatusPageToken)))
+	mux.Handle("GET /api/status-pages/{id}/view", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePreviewStatusPage)))
+	mux.Handle("GET /api/public/status/{slug}", middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handlePublicStatusPage)))
+
+	// Terminal session recordings
+	mux.Handle("GET /api/servers/{agentId}/upstreams", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListUpstreams)))
+	mux.Handle("POST /api/servers/{agentId}/upstreams/{name}/servers", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateUpstreamServer)))
+	mux.Handle("GET /api/servers/{agentId}/service", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetServiceStatus)))
+	mux.Handle("POST /api/servers/{agentId}/nginx/upgrade", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpgradeNginxBinary)))
+	mux.Handle("GET /api/servers/{agentId}/terminal-sessions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListTerminalSessions)))
+	mux.Handle("GET /api/terminal-sessions/{id}/cast", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetTerminalRecording)))
+	mux.Handle("GET /api/terminal-sessions/{id}/playback", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleStreamTerminalPlayback)))
+
 	// Maintenance Mode API
 	mux.Handle("GET /api/maintenance/templates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListMaintenanceTemplates)))
 	mux.Handle("POST /api/maintenance/templates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateMaintenanceTemplate)))
@@ -2041,6 +2672,121 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("GET /api/maintenance/states", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListMaintenanceStates)))
 	mux.Handle("POST /api/maintenance/set", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSetMaintenance)))
 
+	// Config Templates & Batch Config Updates
+	mux.Handle("GET /api/config-templates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListConfigTemplates)))
+	mux.Handle("POST /api/config-templates", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateConfigTemplate)))
+	mux.Handle("GET /api/config-templates/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetConfigTemplate)))
+	mux.Handle("PUT /api/config-templates/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateConfigTemplate)))
+	mux.Handle("DELETE /api/config-templates/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteConfigTemplate)))
+	mux.Handle("GET /api/config-templates/{id}/versions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListConfigTemplateVersions)))
+	mux.Handle("PUT /api/config-templates/{id}/variables", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSetConfigTemplateVariables)))
+	mux.Handle("POST /api/config-templates/{id}/render", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRenderConfigTemplate)))
+	mux.Handle("POST /api/config-templates/{id}/apply", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleApplyConfigTemplate)))
+	mux.Handle("GET /api/config-snippets", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListConfigSnippets)))
+	mux.Handle("POST /api/config-snippets", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateConfigSnippet)))
+	mux.Handle("GET /api/config-snippets/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetConfigSnippet)))
+	mux.Handle("PUT /api/config-snippets/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateConfigSnippet)))
+	mux.Handle("DELETE /api/config-snippets/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteConfigSnippet)))
+	mux.Handle("GET /api/config-snippets/{id}/versions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListConfigSnippetVersions)))
+	mux.Handle("POST /api/config-snippets/{id}/rollout", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRolloutConfigSnippet)))
+	mux.Handle("GET /api/traffic-splits", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListTrafficSplits)))
+	mux.Handle("POST /api/traffic-splits", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateTrafficSplit)))
+	mux.Handle("GET /api/traffic-splits/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetTrafficSplit)))
+	mux.Handle("PUT /api/traffic-splits/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateTrafficSplit)))
+	mux.Handle("DELETE /api/traffic-splits/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTrafficSplit)))
+	mux.Handle("POST /api/traffic-splits/{id}/apply", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleApplyTrafficSplit)))
+	mux.Handle("GET /api/traffic-splits/{id}/stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleTrafficSplitStats)))
+	mux.Handle("GET /api/geo-policies", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListGeoPolicies)))
+	mux.Handle("POST /api/geo-policies", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateGeoPolicy)))
+	mux.Handle("GET /api/geo-policies/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetGeoPolicy)))
+	mux.Handle("PUT /api/geo-policies/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateGeoPolicy)))
+	mux.Handle("DELETE /api/geo-policies/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteGeoPolicy)))
+	mux.Handle("POST /api/geo-policies/{id}/apply", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleApplyGeoPolicy)))
+	mux.Handle("GET /api/geo-policies/{id}/stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGeoPolicyStats)))
+	mux.Handle("GET /api/traffic-mirrors", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListTrafficMirrors)))
+	mux.Handle("POST /api/traffic-mirrors", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateTrafficMirror)))
+	mux.Handle("GET /api/traffic-mirrors/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetTrafficMirror)))
+	mux.Handle("PUT /api/traffic-mirrors/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateTrafficMirror)))
+	mux.Handle("DELETE /api/traffic-mirrors/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTrafficMirror)))
+	mux.Handle("POST /api/traffic-mirrors/{id}/enable", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleEnableTrafficMirror)))
+	mux.Handle("POST /api/traffic-mirrors/{id}/disable", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDisableTrafficMirror)))
+	mux.Handle("GET /api/traffic-mirrors/{id}/stats", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleTrafficMirrorStats)))
+	mux.Handle("GET /api/environments/{id}/log-redaction", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetLogRedaction)))
+	mux.Handle("POST /api/environments/{id}/log-redaction/apply", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleApplyLogRedaction)))
+	mux.Handle("POST /api/config/batch", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleBatchUpdateConfig)))
+	mux.Handle("GET /api/config/batch/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetBatchStatus)))
+	mux.Handle("POST /api/config/batch/{id}/cancel", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCancelBatch)))
+
+	// Fleet versions, nginx module inventory and agent upgrade campaigns
+	mux.Handle("GET /api/fleet/versions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleFleetVersions)))
+	mux.Handle("GET /api/fleet/nginx-modules", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleNginxInventory)))
+	mux.Handle("GET /api/fleet/upgrade-campaigns", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListUpgradeCampaigns)))
+	mux.Handle("POST /api/fleet/upgrade-campaigns", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateUpgradeCampaign)))
+	mux.Handle("GET /api/fleet/upgrade-campaigns/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetUpgradeCampaign)))
+	mux.Handle("POST /api/fleet/upgrade-campaigns/{id}/cancel", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCancelUpgradeCampaign)))
+
+	// Bulk reloads, restarts and agent updates over a selection of agents, polled as jobs
+	mux.Handle("GET /api/bulk-actions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListBulkActions)))
+	mux.Handle("POST /api/bulk-actions", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateBulkAction)))
+	mux.Handle("GET /api/bulk-actions/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetBulkAction)))
+	mux.Handle("POST /api/bulk-actions/{id}/cancel", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCancelBulkAction)))
+
+	// Background jobs: progress, logs, artifacts and cancellation of bulk actions, upgrade
+	// campaigns and report generation
+	mux.Handle("GET /api/jobs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListJobs)))
+	mux.Handle("GET /api/jobs/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetJob)))
+	mux.Handle("GET /api/jobs/{id}/artifact", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetJobArtifact)))
+	mux.Handle("POST /api/jobs/{id}/cancel", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCancelJob)))
+	mux.Handle("POST /api/reports/jobs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateReportJob)))
+
+	// Scheduled operations (cron-like recurring reloads, restarts, config backups and reports)
+	mux.Handle("GET /api/scheduled-tasks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListScheduledTasks)))
+	mux.Handle("POST /api/scheduled-tasks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateScheduledTask)))
+	mux.Handle("GET /api/scheduled-tasks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetScheduledTask)))
+	mux.Handle("PUT /api/scheduled-tasks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateScheduledTask)))
+	mux.Handle("DELETE /api/scheduled-tasks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteScheduledTask)))
+	mux.Handle("GET /api/scheduled-tasks/{id}/runs", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListScheduledTaskRuns)))
+	mux.Handle("POST /api/scheduled-tasks/{id}/run", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRunScheduledTask)))
+
+	// Outbound webhooks for lifecycle events
+	mux.Handle("GET /api/webhooks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListWebhooks)))
+	mux.Handle("POST /api/webhooks", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateWebhook)))
+	mux.Handle("GET /api/webhooks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetWebhook)))
+	mux.Handle("PUT /api/webhooks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateWebhook)))
+	mux.Handle("DELETE /api/webhooks/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteWebhook)))
+	mux.Handle("POST /api/webhooks/{id}/test", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleTestWebhook)))
+	mux.Handle("GET /api/webhooks/{id}/deliveries", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListWebhookDeliveries)))
+	mux.Handle("POST /api/webhooks/{id}/deliveries/{deliveryId}/redeliver", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleRedeliverWebhook)))
+
+	// Activity feed
+	mux.Handle("GET /api/events", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListEvents)))
+	mux.Handle("GET /api/events/stream", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.handleEventStream(w, r, upgrader)
+	})))
+	mux.Handle("GET /api/servers/{agentId}/events", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListServerEvents)))
+	mux.Handle("GET /api/projects/{id}/events", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListProjectEvents)))
+
+	// Declarative tenancy for infrastructure-as-code tools
+	mux.Handle("GET /api/tenancy", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleExportTenancy)))
+	mux.Handle("POST /api/tenancy/apply", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleApplyTenancy)))
+	mux.Handle("PUT /api/tenancy/projects/{slug}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutTenancyProject)))
+	mux.Handle("PUT /api/tenancy/projects/{slug}/environments/{env}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutTenancyEnvironment)))
+	mux.Handle("PUT /api/tenancy/teams/{slug}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutTenancyTeam)))
+	mux.Handle("PUT /api/tenancy/teams/{slug}/members/{username}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutTenancyMember)))
+	mux.Handle("PUT /api/tenancy/teams/{slug}/access/{project}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePutTenancyAccess)))
+	mux.Handle("DELETE /api/tenancy/projects/{slug}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTenancyResource)))
+	mux.Handle("DELETE /api/tenancy/projects/{slug}/environments/{env}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTenancyResource)))
+	mux.Handle("DELETE /api/tenancy/teams/{slug}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTenancyResource)))
+	mux.Handle("DELETE /api/tenancy/teams/{slug}/members/{username}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTenancyResource)))
+	mux.Handle("DELETE /api/tenancy/teams/{slug}/access/{project}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteTenancyResource)))
+
+	// Agent release channels, environment pins and staged rollouts
+	mux.Handle("GET /api/fleet/releases", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetAgentReleases)))
+	mux.Handle("GET /api/fleet/releases/resolve", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleResolveAgentRelease)))
+	mux.Handle("PUT /api/fleet/releases/channels/{name}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSaveReleaseChannel)))
+	mux.Handle("PUT /api/fleet/releases/environments/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSaveEnvironmentRelease)))
+	mux.Handle("DELETE /api/fleet/releases/environments/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteEnvironmentRelease)))
+
 	// Agent Runtime Configuration API (agent self-config, persisted on agent)
 	mux.Handle("GET /api/agents/{id}/config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetAgentRuntimeConfig)))
 	mux.Handle("PATCH /api/agents/{id}/config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateAgentRuntimeConfig)))
@@ -2053,6 +2799,40 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("DELETE /api/slo-targets", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteSLOTarget)))
 	mux.Handle("GET /api/slo-compliance", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetSLOCompliance)))
 
+	// Custom Dashboards
+	mux.Handle("GET /api/metrics/catalog", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetMetricCatalog)))
+	mux.Handle("POST /api/metrics/query", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleMetricQuery))))
+	mux.Handle("GET /api/dashboards", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListDashboards)))
+	mux.Handle("POST /api/dashboards", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateDashboard)))
+	mux.Handle("GET /api/dashboards/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetDashboard)))
+	mux.Handle("PUT /api/dashboards/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateDashboard)))
+	mux.Handle("DELETE /api/dashboards/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteDashboard)))
+	mux.Handle("POST /api/dashboards/{id}/panels", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSaveDashboardPanel)))
+	mux.Handle("PUT /api/dashboards/{id}/panels/{panelId}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleSaveDashboardPanel)))
+	mux.Handle("DELETE /api/dashboards/{id}/panels/{panelId}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteDashboardPanel)))
+	mux.Handle("GET /api/dashboards/{id}/panels/{panelId}/data", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleGetDashboardPanelData)))
+
+	// Threat Intelligence & Blocklist
+	mux.Handle("GET /api/security/vulnerabilities", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleVulnerabilities)))
+	mux.Handle("GET /api/security/threats", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleGetThreats))))
+	mux.Handle("GET /api/security/blocklist", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListBlocklist)))
+	mux.Handle("POST /api/security/blocklist", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleAddBlocklist)))
+	mux.Handle("DELETE /api/security/blocklist/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteBlocklist)))
+	mux.Handle("GET /api/security/events", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleGetSecurityEvents))))
+	mux.Handle("POST /api/security/blocklist/push", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePushBlocklist)))
+
+	// Alert-driven bans
+	mux.Handle("GET /api/security/bans", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListBans)))
+	mux.Handle("DELETE /api/security/bans/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleLiftBan)))
+
+	// Managed L7 rate limits (limit_req_zone/limit_req include pushed to agents)
+	mux.Handle("GET /api/security/rate-limits", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListRateLimitRules)))
+	mux.Handle("POST /api/security/rate-limits", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleCreateRateLimitRule)))
+	mux.Handle("PUT /api/security/rate-limits/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleUpdateRateLimitRule)))
+	mux.Handle("DELETE /api/security/rate-limits/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteRateLimitRule)))
+	mux.Handle("POST /api/security/rate-limits/push", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handlePushRateLimits)))
+	mux.Handle("GET /api/security/rate-limits/status", authManager.AuthMiddleware(publicPaths)(middleware.RateLimitMiddleware(rateLimiter, cfg.Security.EnableRateLimit)(http.HandlerFunc(srv.handleGetRateLimitStatus))))
+
 	// Config Scoring
 	mux.Handle("POST /api/config/score", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleScoreConfig)))
 	mux.Handle("GET /api/agents/{id}/nginx/backups", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleListNginxConfigBackups)))
@@ -2111,6 +2891,9 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 	mux.Handle("DELETE /api/enrollment-tokens/{id}", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.handleDeleteEnrollmentToken)))
 	mux.HandleFunc("POST /api/enrollment-tokens/validate", srv.handleValidateEnrollmentToken) // No auth - agents use tokens
 
+	// OpenAPI description of this API (no auth - used to generate clients)
+	mux.HandleFunc("GET /api/openapi.json", srv.handleOpenAPI)
+
 	// Health check endpoint (no rate limiting)
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -2121,7 +2904,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 		// Query PG version
 		if srv.db != nil && srv.db.conn != nil {
 			var v string
-			if err := srv.db.conn.QueryRow("SHOW server_version").Scan(&v); err == nil {
+			if err := srv.db.QueryRowContext(r.Context(), "SHOW server_version").Scan(&v); err == nil {
 				pgVer = v
 			}
 		}
@@ -2147,7 +2930,7 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 		allHealthy := true
 
 		// Check PostgreSQL connectivity
-		if err := srv.db.conn.Ping(); err != nil {
+		if err := srv.db.conn.PingContext(r.Context()); err != nil {
 			pgStatus = "disconnected"
 			allHealthy = false
 		}
@@ -2186,8 +2969,8 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 		updatesDir = "./updates"
 	}
 	ensureUpdatesDir(updatesDir)
-	mux.Handle("/updates/", updatesHandlerForDir(updatesDir))
-	log.Printf("Serving agent updates from %s on /updates/", updatesDir)
+	mux.Handle("/updates/", srv.releaseManifestHandler(updatesDir, updatesHandlerForDir(updatesDir)))
+	gatewayLog.Info().Msgf("Serving agent updates from %s on /updates/", updatesDir)
 
 	// AI Error Analysis API (LLM-powered)
 	if srv.errorAnalysisAPI != nil {
@@ -2197,9 +2980,16 @@ func (srv *server) createHTTPServer(cfg *config.Config) *http.Server {
 		mux.Handle("GET /api/v1/recommendations", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.errorAnalysisAPI.HandleGetRecommendations)))
 		mux.Handle("GET /api/v1/admin/llm/config", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.errorAnalysisAPI.HandleGetLLMConfig)))
 		mux.Handle("POST /api/v1/admin/llm/test", authManager.AuthMiddleware(publicPaths)(http.HandlerFunc(srv.errorAnalysisAPI.HandleTestLLMConnection)))
-		log.Printf("AI Error Analysis API routes registered")
+		gatewayLog.Info().Msg("AI Error Analysis API routes registered")
 	}
-	handler := metricsAndLogMiddleware(gatewayLog, false)(mux)
+
+	// OTLP/HTTP receiver for OpenTelemetry collectors; authenticates with otlp.auth_token
+	if cfg.OTLP.Enabled {
+		mux.HandleFunc("POST /v1/logs", srv.handleOTLP)
+		mux.HandleFunc("POST /v1/metrics", srv.handleOTLP)
+		mux.HandleFunc("POST /v1/traces", srv.handleOTLP)
+	}
+	handler := requestIDMiddleware(metricsAndLogMiddleware(httpLog, false)(mux))
 
 	// Wrap with a global request body size limiter (10MB) to prevent DoS via large payloads.
 	// Streaming endpoints (SSE, WebSocket) are not affected as they use different read patterns.
@@ -2272,7 +3062,7 @@ func (srv *server) handleGroupRealtimeStats(w http.ResponseWriter, r *http.Reque
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		if _, err := w.Write([]byte(`{"error":"no agents in group or group not found"}`)); err != nil {
-			log.Printf("handleGroupRealtimeStats: failed to write error response: %v", err)
+			logging.Ctx(r.Context(), gatewayLog).Error().Msgf("handleGroupRealtimeStats: failed to write error response: %v", err)
 		}
 		return
 	}
@@ -2302,11 +3092,32 @@ func (srv *server) handleGroupLogsStream(w http.ResponseWriter, r *http.Request)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 		if _, err := w.Write([]byte(`{"error":"no agents in group or group not found"}`)); err != nil {
-			log.Printf("handleGroupLogsStream: failed to write error response: %v", err)
+			logging.Ctx(r.Context(), gatewayLog).Error().Msgf("handleGroupLogsStream: failed to write error response: %v", err)
 		}
 		return
 	}
 
+	agentIDs := make([]string, 0, len(resp.Agents))
+	for _, a := range resp.Agents {
+		agentIDs = append(agentIDs, a.AgentId)
+	}
+	srv.streamAgentLogs(w, r, "group-"+groupID, agentIDs, map[string]interface{}{"group_id": groupID})
+}
+
+// handleServerLogsStream handles GET /api/servers/{agentId}/logs/stream: the logs of one agent as
+// SSE, with the same parameters as the group stream
+func (srv *server) handleServerLogsStream(w http.ResponseWriter, r *http.Request) {
+	agentID, _, ok := srv.resolveUpstreamAgent(w, r)
+	if !ok {
+		return
+	}
+	srv.streamAgentLogs(w, r, "server-"+agentID, []string{agentID}, map[string]interface{}{"agent_id": agentID})
+}
+
+// streamAgentLogs streams the logs of agents as SSE: tail (default 200, at most 1000), log_type
+// (access or error), follow (0 to stop after the tail) and the filters status (404, 5xx), method
+// and q (a substring of the line). The connected event carries info.
+func (srv *server) streamAgentLogs(w http.ResponseWriter, r *http.Request, subPrefix string, agentIDs []string, info map[string]interface{}) {
 	tailStr := r.URL.Query().Get("tail")
 	if tailStr == "" {
 		tailStr = "200"
@@ -2337,17 +3148,17 @@ func (srv *server) handleGroupLogsStream(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	agentIDs := make([]string, 0, len(resp.Agents))
-	for _, a := range resp.Agents {
-		agentIDs = append(agentIDs, a.AgentId)
-	}
-	sseEvent("connected", map[string]interface{}{"group_id": groupID, "agent_ids": agentIDs, "log_type": logType, "tail": tail, "follow": follow})
+	filter := logFilterFromQuery(r.URL.Query())
+	info["agent_ids"] = agentIDs
+	info["log_type"] = logType
+	info["tail"] = tail
+	info["follow"] = follow
+	sseEvent("connected", info)
 
 	mergeCh := make(chan groupLogEntry, 100)
 	var cleanup []func()
 
-	for _, agent := range resp.Agents {
-		agentID := agent.AgentId
+	for _, agentID := range agentIDs {
 		val, ok := srv.sessions.Load(agentID)
 		if !ok {
 			continue
@@ -2358,7 +3169,7 @@ func (srv *server) handleGroupLogsStream(w http.ResponseWriter, r *http.Request)
 			session.mu.Unlock()
 			continue
 		}
-		subID := fmt.Sprintf("group-%s-%s-%d", groupID, agentID, time.Now().UnixNano())
+		subID := fmt.Sprintf("%s-%s-%d", subPrefix, agentID, time.Now().UnixNano())
 		logChan := make(chan *pb.LogEntry, 50)
 		session.logChans[subID] = logChan
 		stream := session.stream
@@ -2401,17 +3212,35 @@ func (srv *server) handleGroupLogsStream(w http.ResponseWriter, r *http.Request)
 		}
 	}()
 
+	// Without follow the agents send their tail and go quiet; the stream ends once they have
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if !follow {
+		idleTimer = time.NewTimer(logTailIdle)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
 	ctx := r.Context()
 	for {
 		select {
 		case <-ctx.Done():
 			sseEvent("end", map[string]string{"reason": "client_disconnect"})
 			return
+		case <-idle:
+			sseEvent("end", map[string]string{"reason": "tail_complete"})
+			return
 		case t, ok := <-mergeCh:
 			if !ok {
 				sseEvent("end", map[string]string{"reason": "stream_end"})
 				return
 			}
+			if idleTimer != nil {
+				idleTimer.Reset(logTailIdle)
+			}
+			if !filter.match(t.entry) {
+				continue
+			}
 			payload := map[string]interface{}{
 				"agent_id":        t.agentID,
 				"timestamp":       t.entry.Timestamp,
@@ -2437,7 +3266,7 @@ func (srv *server) handleGroupLogsStream(w http.ResponseWriter, r *http.Request)
 // handleTerminal handles WebSocket terminal connections
 func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrader websocket.Upgrader) {
 	agentID := r.URL.Query().Get("agent_id")
-	log.Printf("Terminal request for agent: %s", agentID)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Terminal request for agent: %s", agentID)
 	if agentID == "" {
 		http.Error(w, "agent_id is required", http.StatusBadRequest)
 		return
@@ -2453,7 +3282,7 @@ func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrad
 	if user != nil {
 		visibleAgents, err := srv.db.GetVisibleAgentIDs(user.Username)
 		if err != nil {
-			log.Printf("Terminal RBAC error for user %s: %v", user.Username, err)
+			logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Terminal RBAC error for user %s: %v", user.Username, err)
 			http.Error(w, "Failed to check access permissions", http.StatusInternalServerError)
 			return
 		}
@@ -2465,7 +3294,7 @@ func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrad
 			}
 		}
 		if !hasAccess {
-			log.Printf("Terminal access denied: user %s cannot access agent %s", user.Username, agentID)
+			logging.Ctx(r.Context(), gatewayLog).Warn().Msgf("Terminal access denied: user %s cannot access agent %s", user.Username, agentID)
 			http.Error(w, "Access denied: you don't have permission to access this server", http.StatusForbidden)
 			return
 		}
@@ -2473,50 +3302,91 @@ func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrad
 
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WS upgrade error for agent %s: %v", agentID, err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("WS upgrade error for agent %s: %v", agentID, err)
 		return
 	}
-	log.Printf("WS upgraded for agent %s", agentID)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("WS upgraded for agent %s", agentID)
 	defer ws.Close()
 
+	// gorilla/websocket allows one concurrent writer; policy notices are written from the reader goroutine
+	var wsMu sync.Mutex
+	writeWS := func(messageType int, data []byte) error {
+		wsMu.Lock()
+		defer wsMu.Unlock()
+		return ws.WriteMessage(messageType, data)
+	}
+
 	// Radar-style structured errors: send JSON { type: "error", error: "...", code: "..." } so frontend can show clear message
 	writeExecError := func(code, msg string) {
 		payload := fmt.Sprintf(`{"type":"error","error":%q,"code":%q}`, msg, code)
-		_ = ws.WriteMessage(websocket.TextMessage, []byte(payload))
+		_ = writeWS(websocket.TextMessage, []byte(payload))
+	}
+
+	// Terminal access policy (per role): read-only, command allow/deny lists, sudo prohibition, time limit
+	role := ""
+	if user != nil {
+		role = user.Role
+	}
+	policy := terminalPolicyForRole(srv.config, role)
+	// command is the shell to start; the policy checks the commands typed into it (inputFilter below)
+	cmd := r.URL.Query().Get("command")
+	if reason, ok := checkTerminalShell(cmd); !ok {
+		logging.Ctx(r.Context(), gatewayLog).Warn().Msgf("Terminal shell denied for agent %s (role %s): %s", agentID, role, reason)
+		writeExecError("shell_not_allowed", reason)
+		return
 	}
 
 	client, conn, err := srv.getAgentClient(agentID)
 	if err != nil {
-		log.Printf("Terminal error: agent %s client failed: %v", agentID, err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Terminal error: agent %s client failed: %v", agentID, err)
 		writeExecError("agent_not_found", fmt.Sprintf("Agent unavailable: %v", err))
 		return
 	}
 	defer func() {
-		log.Printf("Closing gRPC connection for terminal session %s", agentID)
+		logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Closing gRPC connection for terminal session %s", agentID)
 		conn.Close()
 	}()
 
-	sessionCtx, sessionCancel := context.WithCancel(context.Background())
+	var (
+		sessionCtx    context.Context
+		sessionCancel context.CancelFunc
+	)
+	if policy.MaxDuration > 0 {
+		sessionCtx, sessionCancel = context.WithTimeout(context.Background(), policy.MaxDuration)
+	} else {
+		sessionCtx, sessionCancel = context.WithCancel(context.Background())
+	}
 	defer sessionCancel()
 
 	stream, err := client.Execute(sessionCtx)
 	if err != nil {
-		log.Printf("Terminal error: exec stream failed for %s: %v", agentID, err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Terminal error: exec stream failed for %s: %v", agentID, err)
 		writeExecError("stream_failed", fmt.Sprintf("Exec stream failed: %v", err))
 		return
 	}
-	log.Printf("Exec stream established for agent %s", agentID)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Exec stream established for agent %s", agentID)
 
-	cmd := r.URL.Query().Get("command")
 	if err := stream.Send(&pb.ExecRequest{
 		InstanceId: agentID,
 		Command:    cmd,
 	}); err != nil {
-		log.Printf("Terminal error: failed to send initial request to %s: %v", agentID, err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Terminal error: failed to send initial request to %s: %v", agentID, err)
 		writeExecError("init_failed", fmt.Sprintf("Failed to start shell: %v", err))
 		return
 	}
-	log.Printf("Initial exec request sent for agent %s (cmd: %s)", agentID, cmd)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Initial exec request sent for agent %s (cmd: %s)", agentID, cmd)
+
+	// Record the session (asciicast v2) for compliance review; persisted when the session ends
+	username := ""
+	if user != nil {
+		username = user.Username
+	}
+	cols, _ := strconv.Atoi(r.URL.Query().Get("cols"))
+	rows, _ := strconv.Atoi(r.URL.Query().Get("rows"))
+	recorder := newTerminalRecorder(resolved, username, cmd, r.RemoteAddr, cols, rows)
+	defer srv.saveTerminalRecording(recorder)
+
+	inputFilter := newTerminalInputFilter(policy)
 
 	// WS -> gRPC
 	go func() {
@@ -2524,37 +3394,54 @@ func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrad
 		for {
 			_, msg, err := ws.ReadMessage()
 			if err != nil {
-				log.Printf("WS read error for agent %s: %v", agentID, err)
+				logging.Ctx(r.Context(), gatewayLog).Error().Msgf("WS read error for agent %s: %v", agentID, err)
 				return
 			}
-			if err := stream.Send(&pb.ExecRequest{Input: msg}); err != nil {
-				log.Printf("gRPC send error for agent %s: %v", agentID, err)
+			recorder.RecordInput(msg)
+			input, denied := inputFilter.Filter(msg)
+			for _, reason := range denied {
+				logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Terminal policy blocked input for agent %s (user %s): %s", agentID, username, reason)
+				notice := []byte("\r\n[avika] blocked by terminal policy: " + reason + "\r\n")
+				recorder.RecordOutput(notice)
+				_ = writeWS(websocket.BinaryMessage, notice)
+			}
+			if len(input) == 0 {
+				continue
+			}
+			if err := stream.Send(&pb.ExecRequest{Input: input}); err != nil {
+				logging.Ctx(r.Context(), gatewayLog).Error().Msgf("gRPC send error for agent %s: %v", agentID, err)
 				return
 			}
 		}
 	}()
 
 	// gRPC -> WS
-	log.Printf("Starting gRPC -> WS proxy for agent %s", agentID)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Starting gRPC -> WS proxy for agent %s", agentID)
 	for {
 		resp, err := stream.Recv()
 		if err == io.EOF {
-			log.Printf("gRPC stream closed (EOF) for %s", agentID)
+			logging.Ctx(r.Context(), gatewayLog).Info().Msgf("gRPC stream closed (EOF) for %s", agentID)
 			break
 		}
 		if err != nil {
-			log.Printf("gRPC recv error for agent %s: %v", agentID, err)
+			if errors.Is(sessionCtx.Err(), context.DeadlineExceeded) {
+				logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Terminal session for agent %s reached its time limit (%s)", agentID, policy.MaxDuration)
+				writeExecError("session_expired", fmt.Sprintf("Session time limit of %s reached", policy.MaxDuration))
+				break
+			}
+			logging.Ctx(r.Context(), gatewayLog).Error().Msgf("gRPC recv error for agent %s: %v", agentID, err)
 			writeExecError("stream_error", err.Error())
 			break
 		}
 		if len(resp.Output) > 0 {
-			if err := ws.WriteMessage(websocket.BinaryMessage, resp.Output); err != nil {
-				log.Printf("WS write error for agent %s: %v", agentID, err)
+			recorder.RecordOutput(resp.Output)
+			if err := writeWS(websocket.BinaryMessage, resp.Output); err != nil {
+				logging.Ctx(r.Context(), gatewayLog).Error().Msgf("WS write error for agent %s: %v", agentID, err)
 				break
 			}
 		}
 		if resp.Error != "" {
-			log.Printf("Exec error reported by agent %s: %s", agentID, resp.Error)
+			logging.Ctx(r.Context(), gatewayLog).Warn().Msgf("Exec error reported by agent %s: %s", agentID, resp.Error)
 			code := "agent_error"
 			if strings.Contains(strings.ToLower(resp.Error), "shell") || strings.Contains(strings.ToLower(resp.Error), "not found") {
 				code = "shell_not_found"
@@ -2563,49 +3450,14 @@ func (srv *server) handleTerminal(w http.ResponseWriter, r *http.Request, upgrad
 			break
 		}
 	}
-	log.Printf("Terminal session ended for agent %s", agentID)
+	logging.Ctx(r.Context(), gatewayLog).Info().Msgf("Terminal session ended for agent %s", agentID)
 }
 
 // handleExportReport handles PDF report export requests
 func (srv *server) handleExportReport(w http.ResponseWriter, r *http.Request) {
-	startUnix, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
-	endUnix, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
-	agentIDs := r.URL.Query()["agent_ids"]
-
-	if startUnix == 0 {
-		startUnix = time.Now().Add(-24 * time.Hour).Unix()
-	}
-	if endUnix == 0 {
-		endUnix = time.Now().Unix()
-	}
-
-	// RBAC: Filter agent IDs to only those the user can access
-	user := middleware.GetUserFromContext(r.Context())
-	if user != nil {
-		visibleAgents, err := srv.db.GetVisibleAgentIDs(user.Username)
-		if err != nil {
-			log.Printf("Export report RBAC error for user %s: %v", user.Username, err)
-			http.Error(w, "Failed to check access permissions", http.StatusInternalServerError)
-			return
-		}
-		// Build set of visible agents for fast lookup
-		visibleSet := make(map[string]bool)
-		for _, a := range visibleAgents {
-			visibleSet[a] = true
-		}
-		// Filter requested agent IDs
-		if len(agentIDs) > 0 {
-			filteredIDs := make([]string, 0, len(agentIDs))
-			for _, id := range agentIDs {
-				if visibleSet[id] {
-					filteredIDs = append(filteredIDs, id)
-				}
-			}
-			agentIDs = filteredIDs
-		} else {
-			// No specific agents requested, use all visible agents
-			agentIDs = visibleAgents
-		}
+	start, end, agentIDs, ok := srv.reportScope(w, r)
+	if !ok {
+		return
 	}
 
 	ctx := context.Background()
@@ -2614,13 +3466,13 @@ func (srv *server) handleExportReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	report, err := srv.clickhouse.GetReportData(ctx, time.Unix(startUnix, 0), time.Unix(endUnix, 0), agentIDs)
+	report, err := srv.clickhouse.GetReportData(ctx, start, end, agentIDs)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to generate report data: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	pdfData, err := GeneratePDFReport(report, time.Unix(startUnix, 0), time.Unix(endUnix, 0))
+	pdfData, err := GeneratePDFReport(report, start, end)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to generate PDF: %v", err), http.StatusInternalServerError)
 		return
@@ -2629,7 +3481,7 @@ func (srv *server) handleExportReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/pdf")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=nginx-report-%d.pdf", time.Now().Unix()))
 	if _, err := w.Write(pdfData); err != nil {
-		log.Printf("handleExportReport: failed to write PDF response: %v", err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("handleExportReport: failed to write PDF response: %v", err)
 	}
 }
 
@@ -2715,7 +3567,7 @@ func (srv *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	if mfs, err := prometheus.DefaultGatherer.Gather(); err == nil {
 		for _, mf := range mfs {
 			if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
-				log.Printf("metrics: write avika_http metrics: %v", err)
+				logging.Ctx(r.Context(), gatewayLog).Error().Msgf("metrics: write avika_http metrics: %v", err)
 				break
 			}
 		}
@@ -2726,29 +3578,22 @@ func (srv *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 // Kept for reference only
 
 func (s *server) GetTraces(ctx context.Context, req *pb.TraceRequest) (*pb.TraceList, error) {
+	if _, _, _, err := traceSearchClauses(req); err != nil {
+		return nil, err
+	}
 	if s.clickhouse == nil {
 		return &pb.TraceList{}, nil
 	}
 
 	// Project/environment filtering
-	var agentFilter []string
+	var filter tenantFilter
 	if req.EnvironmentId != "" {
-		agents, err := s.db.GetAgentIDsForEnvironment(req.EnvironmentId)
-		if err != nil {
-			log.Printf("GetTraces: Failed to get agents for environment %s: %v", req.EnvironmentId, err)
-		} else {
-			agentFilter = agents
-		}
+		filter.EnvironmentID = req.EnvironmentId
 	} else if req.ProjectId != "" {
-		agents, err := s.db.GetAgentIDsForProject(req.ProjectId)
-		if err != nil {
-			log.Printf("GetTraces: Failed to get agents for project %s: %v", req.ProjectId, err)
-		} else {
-			agentFilter = agents
-		}
+		filter.ProjectIDs = []string{req.ProjectId}
 	}
 
-	return s.clickhouse.GetTracesWithFilter(ctx, req, agentFilter)
+	return s.clickhouse.GetTracesWithFilter(ctx, req, filter)
 }
 
 func (s *server) GetTraceDetails(ctx context.Context, req *pb.TraceRequest) (*pb.Trace, error) {
@@ -2774,6 +3619,18 @@ func (s *server) CreateAlertRule(ctx context.Context, req *pb.AlertRule) (*pb.Al
 		// Invalid UUID provided, generate a new one
 		req.Id = uuid.New().String()
 	}
+	switch req.Action {
+	case "":
+	case alertActionBan:
+		if req.BanDurationSec < 0 {
+			return nil, fmt.Errorf("ban_duration_sec must not be negative")
+		}
+		if _, ok := securityEventsMetricFilter(req.MetricType); !ok && req.MetricType != "rps" && req.MetricType != "error_rate" {
+			return nil, fmt.Errorf("ban action needs a metric attributable to clients, not %s", req.MetricType)
+		}
+	default:
+		return nil, fmt.Errorf("unknown alert action %q", req.Action)
+	}
 	if err := s.db.UpsertAlertRule(req); err != nil {
 		return nil, err
 	}
@@ -2801,48 +3658,25 @@ func (srv *server) handleGeoData(w http.ResponseWriter, r *http.Request) {
 		window = "24h"
 	}
 
-	// Project/environment filtering (explicit filter takes precedence)
-	environmentID := r.URL.Query().Get("environment_id")
-	projectID := r.URL.Query().Get("project_id")
-
-	var agentFilter []string
-	if environmentID != "" {
-		// Filter by specific environment
-		agents, err := srv.db.GetAgentIDsForEnvironment(environmentID)
-		if err != nil {
-			log.Printf("Geo data: Failed to get agents for environment %s: %v", environmentID, err)
-			http.Error(w, `{"error":"Failed to get agents for environment"}`, http.StatusInternalServerError)
-			return
-		}
-		agentFilter = agents
-	} else if projectID != "" {
-		// Filter by project (all environments)
-		agents, err := srv.db.GetAgentIDsForProject(projectID)
-		if err != nil {
-			log.Printf("Geo data: Failed to get agents for project %s: %v", projectID, err)
-			http.Error(w, `{"error":"Failed to get agents for project"}`, http.StatusInternalServerError)
-			return
-		}
-		agentFilter = agents
-	} else {
-		// RBAC: Get visible agents for the user to filter geo data
-		user := middleware.GetUserFromContext(r.Context())
-		if user != nil {
-			visibleAgents, err := srv.db.GetVisibleAgentIDs(user.Username)
-			if err != nil {
-				log.Printf("Geo data RBAC error for user %s: %v", user.Username, err)
-				http.Error(w, `{"error":"Failed to check access permissions"}`, http.StatusInternalServerError)
-				return
-			}
-			agentFilter = visibleAgents
-		}
+	// Project/environment filtering (explicit filter takes precedence), else the projects the
+	// caller may see
+	filter, visible, err := srv.analyticsTenantFilter(r.Context(), "", r.URL.Query().Get("environment_id"), r.URL.Query().Get("project_id"))
+	if err != nil {
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Geo data filter: %v", err)
+		http.Error(w, `{"error":"Failed to check access permissions"}`, http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		json.NewEncoder(w).Encode(&GeoDataResponse{Locations: []GeoLocation{}, CountryStats: []CountryStat{}, CityStats: []CityStat{}, RecentRequests: []GeoRequest{}})
+		return
 	}
 
-	ctx := r.Context()
-	geoData, err := srv.clickhouse.GetGeoDataFiltered(ctx, window, agentFilter)
+	geoData, err := srv.geoCache.get(r.Context(), geoCacheKey(window, filter), func(ctx context.Context) (*GeoDataResponse, error) {
+		return srv.clickhouse.GetGeoDataFiltered(ctx, window, filter)
+	})
 	if err != nil {
-		log.Printf("GetGeoData error: %v", err)
-		http.Error(w, fmt.Sprintf(`{"error":"Failed to get geo data: %v"}`, err), http.StatusInternalServerError)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("GetGeoData error: %v", err)
+		http.Error(w, fmt.Sprintf(`{"error":"Failed to get geo data: %v"}`, err), clickHouseErrorStatus(w, err))
 		return
 	}
 
@@ -2870,9 +3704,14 @@ type visitorAnalyticsFrontendShape struct {
 	StatusCodes      []map[string]interface{} `json:"status_codes"`
 }
 
-// handleListAgents handles GET /api/servers
+// handleListAgents handles GET /api/servers; query parameters mirror ListAgentsRequest
 func (srv *server) handleListAgents(w http.ResponseWriter, r *http.Request) {
-	resp, err := srv.ListAgents(r.Context(), &pb.ListAgentsRequest{})
+	req, err := listAgentsRequestFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	resp, err := srv.ListAgents(r.Context(), req)
 	if err != nil {
 		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
 		return
@@ -2909,28 +3748,19 @@ func (srv *server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
 		req.StatusCodeFilter = query.Get("status_code")
 	}
 
-	ctx := r.Context()
-	var resp *pb.AnalyticsResponse
-	var err error
-
-	if srv.clickhouse != nil {
-		// Use ClickHouse logic
-		if req.EnvironmentId != "" {
-			agents, _ := srv.db.GetAgentIDsForEnvironment(req.EnvironmentId)
-			resp, err = srv.clickhouse.GetAnalyticsWithAgentFilter(ctx, req, agents)
-		} else if req.ProjectId != "" {
-			agents, _ := srv.db.GetAgentIDsForProject(req.ProjectId)
-			resp, err = srv.clickhouse.GetAnalyticsWithAgentFilter(ctx, req, agents)
-		} else {
-			resp, err = srv.clickhouse.GetAnalyticsWithAgentFilter(ctx, req, nil)
+	// Compare mode: compare_offset, compare_from/compare_to and compare_agent_id,
+	// compare_environment_id or compare_project_id pick the baseline
+	if req.Compare = analyticsComparisonParams(query.Get); req.Compare != nil {
+		if _, err := baselineAnalyticsRequest(req, time.Now()); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+			return
 		}
-	} else {
-		// Fallback to in-memory/mock (simplified)
-		resp, err = srv.GetAnalytics(ctx, req)
 	}
 
+	// Served from ClickHouse through the analytics cache, or the in-memory fallback
+	resp, err := srv.GetAnalytics(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), clickHouseErrorStatus(w, err))
 		return
 	}
 
@@ -2963,7 +3793,7 @@ func (srv *server) handleVisitorAnalytics(w http.ResponseWriter, r *http.Request
 	ctx := r.Context()
 	resp, err := srv.clickhouse.GetVisitorAnalytics(ctx, timeWindow, agentID)
 	if err != nil {
-		log.Printf("GetVisitorAnalytics error: %v", err)
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("GetVisitorAnalytics error: %v", err)
 		empty := visitorAnalyticsFrontendShape{
 			Summary: map[string]string{"unique_visitors": "0", "total_hits": "0", "total_bandwidth": "0", "bot_hits": "0", "human_hits": "0"},
 			Devices: map[string]string{"desktop": "0", "mobile": "0", "tablet": "0", "other": "0"},
@@ -3104,14 +3934,14 @@ func (s *server) autoAssignAgentToEnvironment(agentID string, labels map[string]
 	// Find project by slug
 	project, err := s.db.GetProjectBySlug(projectSlug)
 	if err != nil || project == nil {
-		log.Printf("Auto-assign: project '%s' not found for agent %s", projectSlug, agentID)
+		gatewayLog.Warn().Msgf("Auto-assign: project '%s' not found for agent %s", projectSlug, agentID)
 		return
 	}
 
 	// Find or create environment by project and slug (environments driven by agent config)
 	env, err := s.db.EnsureEnvironment(project.ID, envSlug)
 	if err != nil || env == nil {
-		log.Printf("Auto-assign: failed to ensure environment '%s' in project '%s' for agent %s: %v", envSlug, projectSlug, agentID, err)
+		gatewayLog.Error().Msgf("Auto-assign: failed to ensure environment '%s' in project '%s' for agent %s: %v", envSlug, projectSlug, agentID, err)
 		return
 	}
 
@@ -3119,7 +3949,7 @@ func (s *server) autoAssignAgentToEnvironment(agentID string, labels map[string]
 	existing, err := s.db.GetServerAssignment(agentID)
 	if err == nil && existing != nil {
 		// Already assigned, skip
-		log.Printf("Auto-assign: agent %s already assigned to environment %s", agentID, existing.EnvironmentID)
+		gatewayLog.Info().Msgf("Auto-assign: agent %s already assigned to environment %s", agentID, existing.EnvironmentID)
 		return
 	}
 
@@ -3130,11 +3960,11 @@ func (s *server) autoAssignAgentToEnvironment(agentID string, labels map[string]
 	}
 	_, err = s.db.AssignServer(agentID, env.ID, displayName, "", nil) // Empty string for auto-assignment
 	if err != nil {
-		log.Printf("Auto-assign failed for agent %s: %v", agentID, err)
+		gatewayLog.Error().Msgf("Auto-assign failed for agent %s: %v", agentID, err)
 		return
 	}
 
-	log.Printf("Auto-assigned agent %s to project '%s', environment '%s'", agentID, project.Name, env.Name)
+	gatewayLog.Info().Msgf("Auto-assigned agent %s to project '%s', environment '%s'", agentID, project.Name, env.Name)
 }
 
 func loadServerTLSConfig(cfg *config.Config) (*tls.Config, error) {
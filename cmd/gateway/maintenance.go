@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	pb "github.com/avika-ai/avika/internal/common/proto/agent"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -287,7 +288,7 @@ func (s *server) PreviewMaintenanceTemplate(ctx context.Context, req *pb.Preview
 
 	var htmlContent, cssContent string
 	err := s.db.conn.QueryRowContext(ctx,
-		"SELECT html_content, css_content FROM maintenance_templates WHERE id = $1",
+		"SELECT html_content, COALESCE(css_content, '') FROM maintenance_templates WHERE id = $1",
 		req.TemplateId,
 	).Scan(&htmlContent, &cssContent)
 	if err == sql.ErrNoRows {
@@ -297,30 +298,21 @@ func (s *server) PreviewMaintenanceTemplate(ctx context.Context, req *pb.Preview
 		return nil, status.Errorf(codes.Internal, "failed to get template: %v", err)
 	}
 
-	// Simple variable substitution
-	rendered := htmlContent
-	for key, value := range req.Variables {
-		rendered = strings.ReplaceAll(rendered, "{{"+key+"}}", value)
-	}
-
-	// Inject CSS if present
-	if cssContent != "" {
-		rendered = strings.Replace(rendered, "</head>", "<style>"+cssContent+"</style></head>", 1)
-	}
-
 	return &pb.PreviewMaintenanceTemplateResponse{
-		RenderedHtml: rendered,
+		RenderedHtml: renderMaintenanceTemplate(htmlContent, cssContent, req.Variables),
 	}, nil
 }
 
 // SetMaintenance enables or disables maintenance mode
 func (s *server) SetMaintenance(ctx context.Context, req *pb.SetMaintenanceRequest) (*pb.SetMaintenanceResponse, error) {
+	return s.setMaintenance(ctx, req, getUsernameFromContext(ctx))
+}
+
+func (s *server) setMaintenance(ctx context.Context, req *pb.SetMaintenanceRequest, username *string) (*pb.SetMaintenanceResponse, error) {
 	if req.Scope == "" || req.ScopeId == "" {
 		return nil, status.Error(codes.InvalidArgument, "scope and scope_id are required")
 	}
 
-	username := getUsernameFromContext(ctx)
-
 	switch req.Action {
 	case "enable":
 		return s.enableMaintenance(ctx, req, username)
@@ -336,6 +328,15 @@ func (s *server) SetMaintenance(ctx context.Context, req *pb.SetMaintenanceReque
 }
 
 func (s *server) enableMaintenance(ctx context.Context, req *pb.SetMaintenanceRequest, username *string) (*pb.SetMaintenanceResponse, error) {
+	if err := validateMaintenanceRequest(req); err != nil {
+		return nil, err
+	}
+	// Render the page first so a missing template fails before the state changes
+	html, err := s.maintenancePageHTML(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -387,20 +388,28 @@ func (s *server) enableMaintenance(ctx context.Context, req *pb.SetMaintenanceRe
 		scheduledEnd = &t
 	}
 
-	err := s.db.conn.QueryRowContext(ctx, query,
+	err = s.db.conn.QueryRowContext(ctx, query,
 		id, req.Scope, req.ScopeId, nullIfEmpty(req.SiteFilter), nullIfEmpty(req.LocationFilter),
 		templateID, templateVarsJSON, true, now, username, scheduleType, scheduledStart, scheduledEnd,
-		nullIfEmpty(req.RecurrenceRule), timezone, req.BypassIps, bypassHeadersJSON, nullIfEmpty(req.Reason),
+		nullIfEmpty(req.RecurrenceRule), timezone, pq.Array(req.BypassIps), bypassHeadersJSON, nullIfEmpty(req.Reason),
 	).Scan(&id)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to enable maintenance: %v", err)
 	}
 
-	// Apply maintenance to affected agents
-	results, err := s.applyMaintenanceToAgents(ctx, req, true)
+	page := &maintenancePage{
+		HTML:          html,
+		BypassIPs:     req.BypassIps,
+		BypassHeaders: req.BypassHeaders,
+		Hosts:         maintenanceHosts(req.SiteFilter),
+		Location:      req.LocationFilter,
+	}
+	if scheduledEnd != nil {
+		page.RetryAfter = *scheduledEnd
+	}
+	results, err := s.applyMaintenanceToAgents(ctx, req, maintenanceFiles(page))
 	if err != nil {
-		// Log but don't fail
-		fmt.Printf("Warning: failed to apply maintenance to some agents: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "failed to resolve maintenance agents: %v", err)
 	}
 
 	return &pb.SetMaintenanceResponse{
@@ -411,9 +420,10 @@ func (s *server) enableMaintenance(ctx context.Context, req *pb.SetMaintenanceRe
 }
 
 func (s *server) disableMaintenance(ctx context.Context, req *pb.SetMaintenanceRequest) (*pb.SetMaintenanceResponse, error) {
+	// Clearing the scheduled start keeps the scheduler from enabling it again
 	query := `
 		UPDATE maintenance_state
-		SET is_enabled = false, updated_at = NOW()
+		SET is_enabled = false, scheduled_start = NULL, updated_at = NOW()
 		WHERE scope = $1 AND scope_id = $2 
 			AND COALESCE(site_filter, '') = COALESCE($3, '')
 			AND COALESCE(location_filter, '') = COALESCE($4, '')
@@ -429,10 +439,9 @@ func (s *server) disableMaintenance(ctx context.Context, req *pb.SetMaintenanceR
 		return nil, status.Errorf(codes.Internal, "failed to disable maintenance: %v", err)
 	}
 
-	// Remove maintenance from affected agents
-	results, err := s.applyMaintenanceToAgents(ctx, req, false)
+	results, err := s.applyMaintenanceToAgents(ctx, req, maintenanceOffFiles())
 	if err != nil {
-		fmt.Printf("Warning: failed to remove maintenance from some agents: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "failed to resolve maintenance agents: %v", err)
 	}
 
 	return &pb.SetMaintenanceResponse{
@@ -446,6 +455,9 @@ func (s *server) scheduleMaintenance(ctx context.Context, req *pb.SetMaintenance
 	if req.ScheduledStart == 0 {
 		return nil, status.Error(codes.InvalidArgument, "scheduled_start is required for scheduling")
 	}
+	if err := validateMaintenanceRequest(req); err != nil {
+		return nil, err
+	}
 
 	id := uuid.New().String()
 	templateVarsJSON, _ := json.Marshal(req.TemplateVars)
@@ -496,7 +508,7 @@ func (s *server) scheduleMaintenance(ctx context.Context, req *pb.SetMaintenance
 	err := s.db.conn.QueryRowContext(ctx, query,
 		id, req.Scope, req.ScopeId, nullIfEmpty(req.SiteFilter), nullIfEmpty(req.LocationFilter),
 		templateID, templateVarsJSON, scheduleType, scheduledStart, scheduledEnd,
-		nullIfEmpty(req.RecurrenceRule), timezone, req.BypassIps, bypassHeadersJSON, nullIfEmpty(req.Reason),
+		nullIfEmpty(req.RecurrenceRule), timezone, pq.Array(req.BypassIps), bypassHeadersJSON, nullIfEmpty(req.Reason),
 	).Scan(&id)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to schedule maintenance: %v", err)
@@ -591,70 +603,13 @@ func (s *server) ListMaintenanceStates(ctx context.Context, req *pb.ListMaintena
 
 // Helper functions
 
-func (s *server) applyMaintenanceToAgents(ctx context.Context, req *pb.SetMaintenanceRequest, enable bool) ([]*pb.AgentMaintenanceResult, error) {
-	// Get affected agents based on scope
-	var agentIDs []string
-
-	switch req.Scope {
-	case "agent":
-		agentIDs = []string{req.ScopeId}
-	case "group":
-		agents, err := s.getAgentsInGroup(ctx, req.ScopeId)
-		if err != nil {
-			return nil, err
-		}
-		for _, a := range agents {
-			agentIDs = append(agentIDs, a.agentID)
-		}
-	case "environment":
-		// Get all agents in environment
-		rows, err := s.db.conn.QueryContext(ctx,
-			"SELECT agent_id FROM server_assignments WHERE environment_id = $1",
-			req.ScopeId,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-		for rows.Next() {
-			var agentID string
-			if err := rows.Scan(&agentID); err != nil {
-				continue
-			}
-			agentIDs = append(agentIDs, agentID)
-		}
-	}
-
-	// For now, just return success for each agent
-	// In a full implementation, this would send commands to agents
-	results := make([]*pb.AgentMaintenanceResult, 0, len(agentIDs))
-	for _, agentID := range agentIDs {
-		result := &pb.AgentMaintenanceResult{
-			AgentId: agentID,
-		}
-
-		// Get agent session from sync.Map
-		if val, ok := s.sessions.Load(agentID); ok {
-			if session, ok := val.(*AgentSession); ok {
-				result.Hostname = session.hostname
-				// In a real implementation, send maintenance config to agent
-				// For now, mark as success if agent is online
-				if session.status == "online" {
-					result.Success = true
-				} else {
-					result.Success = false
-					result.Error = "agent offline"
-				}
-			}
-		} else {
-			result.Success = false
-			result.Error = "agent not found"
-		}
-
-		results = append(results, result)
+// applyMaintenanceToAgents writes the maintenance files to the agents of the request's scope
+func (s *server) applyMaintenanceToAgents(ctx context.Context, req *pb.SetMaintenanceRequest, files []managedFile) ([]*pb.AgentMaintenanceResult, error) {
+	agentIDs, err := s.maintenanceAgentIDs(ctx, req.Scope, req.ScopeId)
+	if err != nil {
+		return nil, err
 	}
-
-	return results, nil
+	return s.pushMaintenance(ctx, agentIDs, files), nil
 }
 
 func (s *server) scanMaintenanceState(ctx context.Context, query string, args ...interface{}) (*MaintenanceState, error) {
@@ -673,7 +628,7 @@ func (s *server) scanMaintenanceStateFromRow(row *sql.Row) (*MaintenanceState, e
 		&state.ID, &state.Scope, &state.ScopeID, &siteFilter, &locationFilter,
 		&templateID, &templateVarsJSON, &state.IsEnabled, &enabledAt, &enabledBy,
 		&state.ScheduleType, &scheduledStart, &scheduledEnd, &recurrenceRule,
-		&state.Timezone, &bypassIPs, &bypassHeadersJSON, &reason,
+		&state.Timezone, pq.Array(&bypassIPs), &bypassHeadersJSON, &reason,
 	)
 	if err != nil {
 		return nil, err
@@ -729,7 +684,7 @@ func (s *server) scanMaintenanceStateFromRows(rows *sql.Rows) (*MaintenanceState
 		&state.ID, &state.Scope, &state.ScopeID, &siteFilter, &locationFilter,
 		&templateID, &templateVarsJSON, &state.IsEnabled, &enabledAt, &enabledBy,
 		&state.ScheduleType, &scheduledStart, &scheduledEnd, &recurrenceRule,
-		&state.Timezone, &bypassIPs, &bypassHeadersJSON, &reason,
+		&state.Timezone, pq.Array(&bypassIPs), &bypassHeadersJSON, &reason,
 	)
 	if err != nil {
 		return nil, err
@@ -937,7 +892,9 @@ func (s *server) handleCreateMaintenanceTemplate(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(resp)
 }
 
-// handleSetMaintenance enables or disables maintenance mode
+// handleSetMaintenance enables, disables or schedules maintenance for a scope. Enabling and
+// disabling write the maintenance page to the scope's agents at once and answer with the outcome on
+// each; 502 when there were agents and none took the change.
 func (s *server) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
 	var req pb.SetMaintenanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -945,13 +902,48 @@ func (s *server) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := s.SetMaintenance(r.Context(), &req)
+	user, isSuperAdmin, ok := s.jobCaller(w, r)
+	if !ok {
+		return
+	}
+	if user.Role == "viewer" {
+		http.Error(w, `{"error":"viewers cannot change maintenance"}`, http.StatusForbidden)
+		return
+	}
+	if !isSuperAdmin && req.Scope != "" && req.ScopeId != "" {
+		agentIDs, err := s.maintenanceAgentIDs(r.Context(), req.Scope, req.ScopeId)
+		if err != nil {
+			writeGRPCErrorJSON(w, err)
+			return
+		}
+		for _, id := range agentIDs {
+			if !s.canUserAccessAgent(user.Username, id) {
+				http.Error(w, fmt.Sprintf(`{"error":"access denied to agent %s"}`, escapeJSON(id)), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	resp, err := s.setMaintenance(context.WithoutCancel(r.Context()), &req, &user.Username)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(`{"error":"%v"}`, err), http.StatusInternalServerError)
+		writeGRPCErrorJSON(w, err)
 		return
 	}
+	_ = s.db.CreateAuditLog(user.Username, "maintenance_"+req.Action, "maintenance", resp.MaintenanceStateId,
+		r.RemoteAddr, r.UserAgent(), maintenanceAuditDetails(&req, resp))
 
+	code := http.StatusOK
+	if len(resp.Results) > 0 {
+		code = http.StatusBadGateway
+		for _, res := range resp.Results {
+			if res.Success {
+				code = http.StatusOK
+				break
+			}
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(resp)
 }
 
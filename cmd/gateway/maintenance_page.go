@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The maintenance page lands in two managed files on each agent: the http-level variables in
+// conf.d, which the default nginx.conf includes, and the server-level directives, which each server
+// block that can be put in maintenance includes with `include avika/maintenance.conf;`. Disabling
+// maintenance writes both back as comments, so the include stays valid.
+const (
+	maintenanceHTTPPath    = "conf.d/avika-maintenance.conf"
+	maintenanceIncludePath = "avika/maintenance.conf"
+
+	managedFileTimeout       = 60 * time.Second
+	maintenanceSchedulerTick = time.Minute
+)
+
+var (
+	maintenanceHostRe     = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+	maintenanceLocationRe = regexp.MustCompile(`^/[A-Za-z0-9/._~%-]*$`)
+	maintenanceHeaderRe   = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	maintenanceIfRe       = regexp.MustCompile(`(?s)\{\{#if (\w+)\}\}(.*?)\{\{/if\}\}`)
+	maintenanceVarRe      = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+)
+
+// maintenancePage is what an agent serves while in maintenance: the page, who bypasses it and the
+// hosts and paths it covers
+type maintenancePage struct {
+	HTML          string
+	BypassIPs     []string
+	BypassHeaders map[string]string
+	Hosts         []string // server names from site_filter; every host when empty
+	Location      string   // path prefix from location_filter; every path when empty
+	RetryAfter    time.Time
+}
+
+// managedFile is one managed file written to an agent
+type managedFile struct {
+	Path    string
+	Content string
+}
+
+// validateMaintenanceRequest checks the parts of an enable request that end up in the agents'
+// config, so a bad allowlist entry is rejected before anything is written
+func validateMaintenanceRequest(req *pb.SetMaintenanceRequest) error {
+	for _, ip := range req.BypassIps {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return status.Errorf(codes.InvalidArgument, "bypass_ips: %q is not an IP address or CIDR", ip)
+			}
+		}
+	}
+	for name, value := range req.BypassHeaders {
+		if !maintenanceHeaderRe.MatchString(name) {
+			return status.Errorf(codes.InvalidArgument, "bypass_headers: invalid header name %q", name)
+		}
+		if value == "" || strings.ContainsAny(value, "\"\\$\r\n") {
+			return status.Errorf(codes.InvalidArgument, "bypass_headers: the value of %s must be non-empty without quotes, backslashes or $", name)
+		}
+	}
+	for _, host := range maintenanceHosts(req.SiteFilter) {
+		if !maintenanceHostRe.MatchString(host) {
+			return status.Errorf(codes.InvalidArgument, "site_filter: invalid server name %q", host)
+		}
+	}
+	if req.LocationFilter != "" && !maintenanceLocationRe.MatchString(req.LocationFilter) {
+		return status.Error(codes.InvalidArgument, "location_filter must be a path prefix such as /shop/")
+	}
+	return nil
+}
+
+// maintenanceHosts splits a comma separated site_filter into server names
+func maintenanceHosts(siteFilter string) []string {
+	var hosts []string
+	for _, h := range strings.Split(siteFilter, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// renderMaintenanceTemplate fills a template's {{name}} placeholders and {{#if name}}...{{/if}}
+// blocks from vars, then inlines its CSS
+func renderMaintenanceTemplate(html, css string, vars map[string]string) string {
+	rendered := maintenanceIfRe.ReplaceAllStringFunc(html, func(block string) string {
+		m := maintenanceIfRe.FindStringSubmatch(block)
+		if vars[m[1]] == "" {
+			return ""
+		}
+		return m[2]
+	})
+	rendered = maintenanceVarRe.ReplaceAllStringFunc(rendered, func(placeholder string) string {
+		return vars[maintenanceVarRe.FindStringSubmatch(placeholder)[1]]
+	})
+	if css != "" {
+		rendered = strings.Replace(rendered, "</head>", "<style>"+css+"</style></head>", 1)
+	}
+	return rendered
+}
+
+// maintenancePageHTML renders the template of an enable request, or the default template when it
+// names none. The variables fall back to the template's defaults, and reason and the scheduled end
+// to the request's.
+func (s *server) maintenancePageHTML(ctx context.Context, req *pb.SetMaintenanceRequest) (string, error) {
+	query := `SELECT id, project_id, name, description, html_content, css_content, assets, variables,
+		is_default, is_built_in, created_by, created_at, updated_at FROM maintenance_templates`
+	args := []interface{}{}
+	if req.TemplateId != "" {
+		query += ` WHERE id = $1`
+		args = append(args, req.TemplateId)
+	} else {
+		query += ` WHERE is_default ORDER BY is_built_in, updated_at DESC LIMIT 1`
+	}
+	rows, err := s.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to get template: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", status.Errorf(codes.Internal, "failed to get template: %v", err)
+		}
+		return "", status.Error(codes.NotFound, "template not found")
+	}
+	tmpl, err := scanMaintenanceTemplate(rows)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to get template: %v", err)
+	}
+	return renderMaintenanceTemplate(tmpl.HTMLContent, tmpl.CSSContent, maintenanceTemplateVars(tmpl, req)), nil
+}
+
+// maintenanceTemplateVars is the request's variables over the template's defaults
+func maintenanceTemplateVars(tmpl *MaintenanceTemplate, req *pb.SetMaintenanceRequest) map[string]string {
+	vars := map[string]string{}
+	for _, v := range tmpl.Variables {
+		vars[v.Name] = v.Default
+	}
+	if req.Reason != "" {
+		vars["reason"] = req.Reason
+	}
+	if req.ScheduledEnd > 0 {
+		end := time.Unix(req.ScheduledEnd, 0).UTC()
+		vars["scheduled_end"] = end.Format(time.RFC1123)
+		vars["scheduled_end_timestamp"] = fmt.Sprint(req.ScheduledEnd)
+		vars["estimated_end"] = end.Format(time.RFC1123)
+	}
+	for k, v := range req.TemplateVars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// nginxString quotes s for a config string. $ cannot be escaped in nginx, so it goes through the
+// $avika_dollar variable the http-level file defines.
+func nginxString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `${avika_dollar}`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// maintenanceFiles renders the files that put an agent in maintenance, in the order they must be
+// written: the variables before the directives that use them
+func maintenanceFiles(p *maintenancePage) []managedFile {
+	var h strings.Builder
+	h.WriteString("# Managed by Avika: maintenance page, enabled. Changes are overwritten.\n")
+	h.WriteString("geo $avika_maintenance_bypass {\n    default 0;\n")
+	for _, ip := range p.BypassIPs {
+		fmt.Fprintf(&h, "    %s 1;\n", ip)
+	}
+	h.WriteString("}\n")
+	if len(p.Hosts) == 0 {
+		h.WriteString("map $host $avika_maintenance_host {\n    default 1;\n}\n")
+	} else {
+		h.WriteString("map $host $avika_maintenance_host {\n    hostnames;\n    default 0;\n")
+		for _, host := range p.Hosts {
+			fmt.Fprintf(&h, "    %s 1;\n", host)
+		}
+		h.WriteString("}\n")
+	}
+	if p.Location == "" {
+		h.WriteString("map $uri $avika_maintenance_path {\n    default 1;\n}\n")
+	} else {
+		fmt.Fprintf(&h, "map $uri $avika_maintenance_path {\n    default 0;\n    \"~^%s\" 1;\n}\n", regexp.QuoteMeta(p.Location))
+	}
+	h.WriteString("geo $avika_dollar {\n    default \"$\";\n}\n")
+
+	var sv strings.Builder
+	sv.WriteString("# Managed by Avika: maintenance page, enabled. Changes are overwritten.\n")
+	sv.WriteString("set $avika_maintenance \"${avika_maintenance_host}${avika_maintenance_path}\";\n")
+	sv.WriteString("if ($avika_maintenance_bypass) {\n    set $avika_maintenance 0;\n}\n")
+	headers := make([]string, 0, len(p.BypassHeaders))
+	for name := range p.BypassHeaders {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		variable := "$http_" + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+		fmt.Fprintf(&sv, "if (%s = \"%s\") {\n    set $avika_maintenance 0;\n}\n", variable, p.BypassHeaders[name])
+	}
+	sv.WriteString("if ($avika_maintenance = 11) {\n    return 503;\n}\n")
+	sv.WriteString("error_page 503 @avika_maintenance;\n")
+	sv.WriteString("location @avika_maintenance {\n    default_type text/html;\n")
+	sv.WriteString("    add_header Cache-Control \"no-store\" always;\n")
+	if !p.RetryAfter.IsZero() {
+		fmt.Fprintf(&sv, "    add_header Retry-After \"%s\" always;\n", p.RetryAfter.UTC().Format(http.TimeFormat))
+	}
+	fmt.Fprintf(&sv, "    return 503 %s;\n}\n", nginxString(p.HTML))
+
+	return []managedFile{
+		{Path: maintenanceHTTPPath, Content: h.String()},
+		{Path: maintenanceIncludePath, Content: sv.String()},
+	}
+}
+
+// maintenanceOffFiles renders the files that take an agent out of maintenance, directives first
+func maintenanceOffFiles() []managedFile {
+	const off = "# Managed by Avika: maintenance page, disabled. Changes are overwritten.\n"
+	return []managedFile{
+		{Path: maintenanceIncludePath, Content: off},
+		{Path: maintenanceHTTPPath, Content: off},
+	}
+}
+
+// maintenanceAgentIDs resolves the agents of a maintenance scope
+func (s *server) maintenanceAgentIDs(ctx context.Context, scope, scopeID string) ([]string, error) {
+	var agentIDs []string
+	switch scope {
+	case "agent":
+		if id, ok := s.resolveAgentID(scopeID); ok {
+			scopeID = id
+		}
+		agentIDs = []string{scopeID}
+	case "group":
+		agents, err := s.getAgentsInGroup(ctx, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			agentIDs = append(agentIDs, a.agentID)
+		}
+	case "environment":
+		rows, err := s.db.conn.QueryContext(ctx,
+			"SELECT agent_id FROM server_assignments WHERE environment_id = $1",
+			scopeID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var agentID string
+			if err := rows.Scan(&agentID); err != nil {
+				return nil, err
+			}
+			agentIDs = append(agentIDs, agentID)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown scope: %s", scope)
+	}
+	return agentIDs, nil
+}
+
+// pushMaintenance writes files to each agent in order, stopping at an agent's first failure; the
+// agent tests the config and reloads after each file, restoring it when the test fails
+func (s *server) pushMaintenance(ctx context.Context, agentIDs []string, files []managedFile) []*pb.AgentMaintenanceResult {
+	results := make([]*pb.AgentMaintenanceResult, len(agentIDs))
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range agentIDs {
+		results[i] = &pb.AgentMaintenanceResult{AgentId: id, Success: true}
+		if val, ok := s.sessions.Load(id); ok {
+			results[i].Hostname = val.(*AgentSession).hostname
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *pb.AgentMaintenanceResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := s.writeManagedFiles(ctx, r.AgentId, files); err != nil {
+				r.Success, r.Error = false, err.Error()
+			}
+		}(results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *server) writeManagedFiles(ctx context.Context, agentID string, files []managedFile) error {
+	client, conn, err := s.getAgentClient(agentID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, managedFileTimeout)
+	defer cancel()
+
+	for _, f := range files {
+		res, err := client.UpdateConfig(ctx, &pb.ConfigUpdate{InstanceId: agentID, ConfigPath: f.Path, NewContent: f.Content})
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+		if !res.Success {
+			return fmt.Errorf("%s: %w", f.Path, errors.New(res.Error))
+		}
+	}
+	return nil
+}
+
+// maintenanceStateRequest turns a stored state back into the request that set it, for the
+// scheduler
+func maintenanceStateRequest(st *MaintenanceState, action string) *pb.SetMaintenanceRequest {
+	req := &pb.SetMaintenanceRequest{
+		Action:         action,
+		Scope:          st.Scope,
+		ScopeId:        st.ScopeID,
+		SiteFilter:     st.SiteFilter,
+		LocationFilter: st.LocationFilter,
+		TemplateVars:   st.TemplateVars,
+		BypassIps:      st.BypassIPs,
+		BypassHeaders:  st.BypassHeaders,
+		Reason:         st.Reason,
+		ScheduleType:   st.ScheduleType,
+		Timezone:       st.Timezone,
+	}
+	if st.TemplateID != nil {
+		req.TemplateId = *st.TemplateID
+	}
+	if st.ScheduledStart != nil {
+		req.ScheduledStart = st.ScheduledStart.Unix()
+	}
+	if st.ScheduledEnd != nil {
+		req.ScheduledEnd = st.ScheduledEnd.Unix()
+	}
+	return req
+}
+
+// startMaintenanceScheduler starts and ends scheduled maintenance on the leader gateway
+func (s *server) startMaintenanceScheduler() {
+	if s.db == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(maintenanceSchedulerTick)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if s.isLeader() {
+				s.runDueMaintenance(context.Background(), now)
+			}
+		}
+	}()
+}
+
+// runDueMaintenance starts scheduled maintenance whose start has come and ends maintenance whose
+// scheduled end has passed
+func (s *server) runDueMaintenance(ctx context.Context, now time.Time) {
+	rows, err := s.db.conn.QueryContext(ctx, `
+		SELECT id, scope, scope_id, site_filter, location_filter, template_id, template_vars,
+			   is_enabled, enabled_at, enabled_by, schedule_type, scheduled_start, scheduled_end,
+			   recurrence_rule, timezone, bypass_ips, bypass_headers, reason
+		FROM maintenance_state
+		WHERE (is_enabled AND scheduled_end <= $1)
+			OR (NOT is_enabled AND scheduled_start <= $1 AND (scheduled_end IS NULL OR scheduled_end > $1))`, now)
+	if err != nil {
+		gatewayLog.Error().Err(err).Msg("Failed to list due maintenance")
+		return
+	}
+	var due []*MaintenanceState
+	for rows.Next() {
+		st, err := s.scanMaintenanceStateFromRows(rows)
+		if err != nil {
+			gatewayLog.Error().Err(err).Msg("Failed to read maintenance state")
+			continue
+		}
+		due = append(due, st)
+	}
+	rows.Close()
+
+	system := "system"
+	for _, st := range due {
+		action := "enable"
+		if st.IsEnabled {
+			action = "disable"
+		}
+		req := maintenanceStateRequest(st, action)
+		resp, err := s.setMaintenance(ctx, req, &system)
+		if err != nil {
+			gatewayLog.Error().Err(err).Str("scope", st.Scope).Str("scope_id", st.ScopeID).Msgf("Scheduled maintenance %s failed", action)
+			continue
+		}
+		_ = s.db.CreateAuditLog(system, "maintenance_"+action, "maintenance", resp.MaintenanceStateId, "", "scheduler",
+			maintenanceAuditDetails(req, resp))
+	}
+}
+
+// maintenanceAuditDetails summarizes a maintenance change for the audit log
+func maintenanceAuditDetails(req *pb.SetMaintenanceRequest, resp *pb.SetMaintenanceResponse) map[string]interface{} {
+	details := map[string]interface{}{
+		"scope":    req.Scope,
+		"scope_id": req.ScopeId,
+	}
+	if req.SiteFilter != "" {
+		details["site_filter"] = req.SiteFilter
+	}
+	if req.LocationFilter != "" {
+		details["location_filter"] = req.LocationFilter
+	}
+	if req.Action == "enable" {
+		details["template_id"] = req.TemplateId
+		details["bypass_ips"] = req.BypassIps
+		details["reason"] = req.Reason
+	}
+	failed := map[string]string{}
+	for _, r := range resp.Results {
+		if !r.Success {
+			failed[r.AgentId] = r.Error
+		}
+	}
+	details["agents"] = len(resp.Results)
+	if len(failed) > 0 {
+		details["failed"] = failed
+	}
+	return details
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestValidateMaintenanceRequest(t *testing.T) {
+	ok := &pb.SetMaintenanceRequest{
+		BypassIps:      []string{"10.0.0.0/8", "203.0.113.7", "2001:db8::/32"},
+		BypassHeaders:  map[string]string{"X-Maintenance-Bypass": "s3cret"},
+		SiteFilter:     "shop.example.com, *.example.org",
+		LocationFilter: "/checkout/",
+	}
+	if err := validateMaintenanceRequest(ok); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+
+	bad := map[string]*pb.SetMaintenanceRequest{
+		"ip":           {BypassIps: []string{"10.0.0.1; return 200"}},
+		"header name":  {BypassHeaders: map[string]string{"X Bypass": "x"}},
+		"header value": {BypassHeaders: map[string]string{"X-Bypass": `x" ) { return 200; } #`}},
+		"site":         {SiteFilter: "shop.example.com;"},
+		"location":     {LocationFilter: "shop/"},
+	}
+	for name, req := range bad {
+		if err := validateMaintenanceRequest(req); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRenderMaintenanceTemplate(t *testing.T) {
+	html := `<head></head><h1>{{company_name}}</h1>{{#if reason}}<p>{{reason}}</p>{{/if}}{{#if support_email}}<a>{{support_email}}</a>{{/if}}`
+	got := renderMaintenanceTemplate(html, "h1{color:red}", map[string]string{"company_name": "Acme", "reason": "Upgrading"})
+	want := `<head><style>h1{color:red}</style></head><h1>Acme</h1><p>Upgrading</p>`
+	if got != want {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestMaintenanceFiles(t *testing.T) {
+	files := maintenanceFiles(&maintenancePage{
+		HTML:          `<p class="x">Back at 10$</p>`,
+		BypassIPs:     []string{"10.0.0.0/8"},
+		BypassHeaders: map[string]string{"X-Maintenance-Bypass": "s3cret"},
+		Hosts:         []string{"shop.example.com"},
+		Location:      "/checkout/",
+		RetryAfter:    time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+	})
+	if len(files) != 2 || files[0].Path != maintenanceHTTPPath || files[1].Path != maintenanceIncludePath {
+		t.Fatalf("files = %+v, want the http-level file first", files)
+	}
+	for _, want := range []string{"    10.0.0.0/8 1;\n", "    hostnames;\n", "    shop.example.com 1;\n", `"~^/checkout/" 1;`, `default "$";`} {
+		if !strings.Contains(files[0].Content, want) {
+			t.Errorf("http-level file lacks %q:\n%s", want, files[0].Content)
+		}
+	}
+	for _, want := range []string{
+		"if ($http_x_maintenance_bypass = \"s3cret\") {\n",
+		"if ($avika_maintenance = 11) {\n    return 503;\n}\n",
+		`add_header Retry-After "Sat, 17 Oct 2026 12:00:00 GMT" always;`,
+		`return 503 "<p class=\"x\">Back at 10${avika_dollar}</p>";`,
+	} {
+		if !strings.Contains(files[1].Content, want) {
+			t.Errorf("server-level file lacks %q:\n%s", want, files[1].Content)
+		}
+	}
+
+	off := maintenanceOffFiles()
+	if off[0].Path != maintenanceIncludePath || strings.Contains(off[0].Content, "return") {
+		t.Errorf("off files = %+v, want the server-level file emptied first", off)
+	}
+}
@@ -9,16 +9,19 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/avika-ai/avika/internal/common/logging"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// authLog logs logins, sessions and agent authentication
+var authLog = logging.NewLogger("auth")
+
 // User represents an authenticated user.
 type User struct {
 	Username string `json:"username"`
@@ -86,12 +89,12 @@ func NewAuthManager(config AuthConfig) *AuthManager {
 	if config.JWTSecret == "" {
 		secret := make([]byte, 32)
 		if _, err := rand.Read(secret); err != nil {
-			log.Printf("Warning: failed to generate JWT secret: %v", err)
+			authLog.Warn().Msgf("Failed to generate JWT secret: %v", err)
 			config.JWTSecret = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("avika-fallback-%d", time.Now().UnixNano())))
 		} else {
 			config.JWTSecret = base64.StdEncoding.EncodeToString(secret)
 		}
-		log.Printf("Auto-generated JWT secret (store this for persistence across restarts)")
+		authLog.Info().Msg("Auto-generated JWT secret (store this for persistence across restarts)")
 	}
 
 	am := &AuthManager{
@@ -112,28 +115,18 @@ func NewAuthManager(config AuthConfig) *AuthManager {
 		// Write initial password to a secure file if path is configured
 		if config.InitialSecretPath != "" {
 			if err := os.WriteFile(config.InitialSecretPath, []byte(defaultPassword), 0600); err != nil {
-				log.Printf("Warning: failed to write initial secret to %s: %v", config.InitialSecretPath, err)
+				authLog.Warn().Msgf("Failed to write initial secret to %s: %v", config.InitialSecretPath, err)
 			}
 		}
 
-		log.Println("")
-		log.Println("*************************************************************")
-		log.Println("*************************************************************")
-		log.Println("")
-		log.Println("  AVIKA FIRST-TIME SETUP")
-		log.Println("")
-		log.Printf("    Username: %s", config.Username)
+		// First-time setup: one event, so the credentials stay together in JSON output
+		setup := authLog.Warn().Str("username", config.Username)
 		if config.InitialSecretPath != "" {
-			log.Printf("    Password written to: %s", config.InitialSecretPath)
+			setup = setup.Str("password_file", config.InitialSecretPath)
 		} else {
-			log.Printf("    Initial password: %s", defaultPassword)
+			setup = setup.Str("initial_password", defaultPassword)
 		}
-		log.Println("")
-		log.Println("  You will be required to change your password on first login.")
-		log.Println("")
-		log.Println("*************************************************************")
-		log.Println("*************************************************************")
-		log.Println("")
+		setup.Msg("Avika first-time setup: log in with these credentials; the password must be changed on first login")
 	}
 
 	// Start cleanup goroutine
@@ -158,7 +151,7 @@ func (am *AuthManager) generateInitialPassword() string {
 func HashPassword(password string) string {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Warning: bcrypt hash failed, this should not happen: %v", err)
+		authLog.Warn().Msgf("Bcrypt hash failed, this should not happen: %v", err)
 		return ""
 	}
 	return string(hash)
@@ -310,7 +303,7 @@ func (am *AuthManager) LoginHandler() http.HandlerFunc {
 
 		valid, role := am.ValidateCredentials(req.Username, req.Password)
 		if !valid {
-			log.Printf("Failed login attempt for user: %s from IP: %s", req.Username, getClientIP(r))
+			authLog.Warn().Msgf("Failed login attempt for user: %s from IP: %s", req.Username, getClientIP(r))
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			_ = json.NewEncoder(w).Encode(LoginResponse{
@@ -332,7 +325,7 @@ func (am *AuthManager) LoginHandler() http.HandlerFunc {
 
 		token, expiresAt, err := am.GenerateTokenWithFlags(user, requirePassChange)
 		if err != nil {
-			log.Printf("Failed to generate token: %v", err)
+			authLog.Error().Msgf("Failed to generate token: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			_ = json.NewEncoder(w).Encode(LoginResponse{
@@ -354,7 +347,7 @@ func (am *AuthManager) LoginHandler() http.HandlerFunc {
 			Domain:   am.config.CookieDomain,
 		})
 
-		log.Printf("Successful login for user: %s from IP: %s (password_change_required=%v)", req.Username, getClientIP(r), requirePassChange)
+		authLog.Info().Msgf("Successful login for user: %s from IP: %s (password_change_required=%v)", req.Username, getClientIP(r), requirePassChange)
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(LoginResponse{
@@ -457,7 +450,7 @@ func (am *AuthManager) ChangePasswordHandler(onPasswordChanged func(username, ne
 		// Callback to persist new password hash
 		if onPasswordChanged != nil {
 			if err := onPasswordChanged(user.Username, newHash); err != nil {
-				log.Printf("Warning: Failed to persist password change: %v", err)
+				authLog.Warn().Msgf("Failed to persist password change: %v", err)
 			}
 		}
 
@@ -466,7 +459,7 @@ func (am *AuthManager) ChangePasswordHandler(onPasswordChanged func(username, ne
 			os.Remove(am.config.InitialSecretPath)
 		}
 
-		log.Printf("Password changed for user: %s from IP: %s", user.Username, getClientIP(r))
+		authLog.Info().Msgf("Password changed for user: %s from IP: %s", user.Username, getClientIP(r))
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(ChangePasswordResponse{
@@ -5,7 +5,6 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -198,7 +197,7 @@ func (p *LDAPProvider) syncTeamMembership(username string, groups []string) erro
 		for mappingGroup, teamName := range p.config.GroupMapping {
 			if strings.Contains(group, mappingGroup) || group == mappingGroup {
 				if err := p.teamMapper.AddUserToTeamByName(username, teamName); err != nil {
-					log.Printf("LDAP: Failed to add %s to team %s: %v", username, teamName, err)
+					authLog.Error().Msgf("LDAP: Failed to add %s to team %s: %v", username, teamName, err)
 				}
 			}
 		}
@@ -227,7 +226,7 @@ func (p *LDAPProvider) LoginHandler() http.HandlerFunc {
 
 		username, email, groups, err := p.Authenticate(req.Username, req.Password)
 		if err != nil {
-			log.Printf("LDAP login failed for user %s: %v", req.Username, err)
+			authLog.Error().Msgf("LDAP login failed for user %s: %v", req.Username, err)
 			http.Error(w, "Invalid username or password", http.StatusUnauthorized)
 			return
 		}
@@ -237,10 +236,10 @@ func (p *LDAPProvider) LoginHandler() http.HandlerFunc {
 		if p.config.AutoProvision && p.userProvisioner != nil {
 			existing, err := p.userProvisioner.GetUserInfo(username)
 			if err != nil {
-				log.Printf("LDAP provision error checking user %s: %v", username, err)
+				authLog.Error().Msgf("LDAP provision error checking user %s: %v", username, err)
 			} else if existing == nil {
 				if err := p.userProvisioner.CreateUser(username, email, role); err != nil {
-					log.Printf("LDAP failed to create user %s: %v", username, err)
+					authLog.Error().Msgf("LDAP failed to create user %s: %v", username, err)
 				}
 			} else if existing.Email != email {
 				_ = p.userProvisioner.UpdateUserEmail(username, email)
@@ -257,7 +256,7 @@ func (p *LDAPProvider) LoginHandler() http.HandlerFunc {
 
 		token, expiresAt, err := p.authManager.GenerateToken(user)
 		if err != nil {
-			log.Printf("Failed to generate session token for LDAP user %s: %v", username, err)
+			authLog.Error().Msgf("Failed to generate session token for LDAP user %s: %v", username, err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -9,12 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
 // OIDCConfig holds OIDC provider configuration
@@ -171,7 +172,7 @@ func (p *OIDCProvider) discoverMetadata() error {
 	p.userinfoEndpoint = metadata.UserinfoEndpoint
 	p.jwksURI = metadata.JwksURI
 
-	log.Printf("OIDC provider discovered: issuer=%s", metadata.Issuer)
+	authLog.Info().Msgf("OIDC provider discovered: issuer=%s", metadata.Issuer)
 	return nil
 }
 
@@ -257,7 +258,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Check for errors from the provider
 	if errParam := r.URL.Query().Get("error"); errParam != "" {
 		errDesc := r.URL.Query().Get("error_description")
-		log.Printf("OIDC error: %s - %s", errParam, errDesc)
+		logging.Ctx(r.Context(), authLog).Warn().Msgf("OIDC error: %s - %s", errParam, errDesc)
 		http.Error(w, fmt.Sprintf("Authentication error: %s", errDesc), http.StatusBadRequest)
 		return
 	}
@@ -272,7 +273,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Exchange code for tokens
 	tokens, err := p.exchangeCode(r.Context(), code)
 	if err != nil {
-		log.Printf("OIDC token exchange failed: %v", err)
+		logging.Ctx(r.Context(), authLog).Error().Msgf("OIDC token exchange failed: %v", err)
 		http.Error(w, "Failed to exchange authorization code", http.StatusInternalServerError)
 		return
 	}
@@ -280,7 +281,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Get user info
 	userInfo, err := p.getUserInfo(r.Context(), tokens.AccessToken)
 	if err != nil {
-		log.Printf("OIDC userinfo fetch failed: %v", err)
+		logging.Ctx(r.Context(), authLog).Error().Msgf("OIDC userinfo fetch failed: %v", err)
 		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
 		return
 	}
@@ -297,7 +298,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Provision user if auto-provisioning is enabled
 	if p.config.AutoProvision {
 		if err := p.provisionUser(username, userInfo); err != nil {
-			log.Printf("OIDC user provisioning failed for %s: %v", username, err)
+			logging.Ctx(r.Context(), authLog).Error().Msgf("OIDC user provisioning failed for %s: %v", username, err)
 			http.Error(w, "Failed to provision user", http.StatusInternalServerError)
 			return
 		}
@@ -313,7 +314,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	token, expiresAt, err := p.authManager.GenerateToken(user)
 	if err != nil {
-		log.Printf("Failed to generate session token for OIDC user %s: %v", username, err)
+		logging.Ctx(r.Context(), authLog).Error().Msgf("Failed to generate session token for OIDC user %s: %v", username, err)
 		http.Error(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
@@ -330,7 +331,7 @@ func (p *OIDCProvider) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	})
 
-	log.Printf("OIDC login successful for user: %s (role: %s)", username, role)
+	logging.Ctx(r.Context(), authLog).Info().Msgf("OIDC login successful for user: %s (role: %s)", username, role)
 
 	// Redirect to the original destination or dashboard
 	if redirectAfterLogin == "" {
@@ -420,7 +421,7 @@ func (p *OIDCProvider) provisionUser(username string, info *OIDCUserInfo) error
 		if err := p.userProvisioner.CreateUser(username, info.Email, role); err != nil {
 			return fmt.Errorf("failed to create user: %w", err)
 		}
-		log.Printf("OIDC: Provisioned new user %s with role %s", username, role)
+		authLog.Info().Msgf("OIDC: Provisioned new user %s with role %s", username, role)
 	} else if existing.Email != info.Email {
 		// Update email if changed
 		if err := p.userProvisioner.UpdateUserEmail(username, info.Email); err != nil {
@@ -431,7 +432,7 @@ func (p *OIDCProvider) provisionUser(username string, info *OIDCUserInfo) error
 	// Sync team membership based on groups
 	if p.teamMapper != nil {
 		if err := p.syncTeamMembership(username, info.Groups); err != nil {
-			log.Printf("OIDC: Warning - failed to sync team membership for %s: %v", username, err)
+			authLog.Error().Msgf("OIDC: Warning - failed to sync team membership for %s: %v", username, err)
 		}
 	}
 
@@ -449,7 +450,7 @@ func (p *OIDCProvider) syncTeamMembership(username string, groups []string) erro
 	for _, group := range groups {
 		if teamName, ok := p.config.GroupMapping[group]; ok {
 			if err := p.teamMapper.AddUserToTeamByName(username, teamName); err != nil {
-				log.Printf("OIDC: Failed to add %s to team %s: %v", username, teamName, err)
+				authLog.Error().Msgf("OIDC: Failed to add %s to team %s: %v", username, teamName, err)
 			}
 		}
 	}
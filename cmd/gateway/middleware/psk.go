@@ -9,7 +9,6 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
@@ -63,26 +62,16 @@ func NewPSKManager(config PSKConfig) *PSKManager {
 	if config.Enabled && config.Key == "" {
 		key := make([]byte, 32)
 		if _, err := rand.Read(key); err != nil {
-			log.Printf("Warning: failed to generate PSK: %v", err)
+			authLog.Warn().Msgf("Failed to generate PSK: %v", err)
 			config.Key = hex.EncodeToString([]byte(fmt.Sprintf("avika-psk-fallback-%d", time.Now().UnixNano())))
 		} else {
 			config.Key = hex.EncodeToString(key)
 		}
 
-		log.Println("")
-		log.Println("*************************************************************")
-		log.Println("Agent PSK Authentication Enabled")
-		log.Println("")
-		log.Println("A Pre-Shared Key has been auto-generated for agent authentication.")
-		log.Println("Configure this key on all agents that need to connect:")
-		log.Println("")
-		log.Printf("PSK: %s", config.Key)
-		log.Println("")
-		log.Println("Add to agent config (avika-agent.conf):")
-		log.Printf("PSK=\"%s\"", config.Key)
-		log.Println("")
-		log.Println("*************************************************************")
-		log.Println("")
+		// One event, so the key stays together in JSON output
+		authLog.Warn().
+			Str("psk", config.Key).
+			Msg("Agent PSK authentication enabled with an auto-generated key; configure it on every agent as PSK=\"<psk>\" in avika-agent.conf")
 	}
 
 	return &PSKManager{
@@ -150,7 +139,7 @@ func (pm *PSKManager) ValidateAgentAuth(agentID, hostname, signature, timestamp
 				LastSeen:     now,
 				Approved:     true, // Auto-approved in auto-enroll mode
 			}
-			log.Printf("Auto-enrolled new agent: %s (hostname: %s)", agentID, hostname)
+			authLog.Info().Msgf("Auto-enrolled new agent: %s (hostname: %s)", agentID, hostname)
 		} else {
 			return fmt.Errorf("agent not registered and auto-enrollment is disabled")
 		}
@@ -194,7 +183,7 @@ func (pm *PSKManager) RegisterAgent(agentID, hostname string, approved bool) {
 		Approved:     approved,
 	}
 
-	log.Printf("Registered agent: %s (hostname: %s, approved: %v)", agentID, hostname, approved)
+	authLog.Info().Msgf("Registered agent: %s (hostname: %s, approved: %v)", agentID, hostname, approved)
 }
 
 // ApproveAgent approves a pending agent.
@@ -208,7 +197,7 @@ func (pm *PSKManager) ApproveAgent(agentID string) error {
 	}
 
 	agent.Approved = true
-	log.Printf("Approved agent: %s", agentID)
+	authLog.Info().Msgf("Approved agent: %s", agentID)
 	return nil
 }
 
@@ -222,7 +211,7 @@ func (pm *PSKManager) RevokeAgent(agentID string) error {
 	}
 
 	delete(pm.registeredAgents, agentID)
-	log.Printf("Revoked agent: %s", agentID)
+	authLog.Info().Msgf("Revoked agent: %s", agentID)
 	return nil
 }
 
@@ -289,7 +278,7 @@ func (pm *PSKManager) UnaryPSKInterceptor() grpc.UnaryServerInterceptor {
 		timestamp := getMetadataValue(md, PSKTimestampKey)
 
 		if err := pm.ValidateAgentAuth(agentID, hostname, signature, timestamp); err != nil {
-			log.Printf("PSK auth failed for agent %s: %v", agentID, err)
+			authLog.Error().Msgf("PSK auth failed for agent %s: %v", agentID, err)
 			return nil, status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
 		}
 
@@ -333,7 +322,7 @@ func (pm *PSKManager) StreamPSKInterceptor() grpc.StreamServerInterceptor {
 		timestamp := getMetadataValue(md, PSKTimestampKey)
 
 		if err := pm.ValidateAgentAuth(agentID, hostname, signature, timestamp); err != nil {
-			log.Printf("PSK auth failed for agent %s: %v", agentID, err)
+			authLog.Error().Msgf("PSK auth failed for agent %s: %v", agentID, err)
 			return status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
 		}
 
@@ -2,12 +2,17 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
+// rateLimitLog logs rejected requests, sampled since a client over its limit is rejected on
+// every request
+var rateLimitLog = logging.Sampled(logging.NewLogger("ratelimit"), 10, time.Minute)
+
 // RateLimiter implements a token bucket rate limiter per client IP.
 type RateLimiter struct {
 	mu       sync.RWMutex
@@ -108,7 +113,7 @@ func RateLimitMiddleware(limiter *RateLimiter, enabled bool) func(http.Handler)
 
 			ip := getClientIP(r)
 			if !limiter.Allow(ip) {
-				log.Printf("Rate limit exceeded for IP: %s", ip)
+				rateLimitLog.Warn().Msgf("Rate limit exceeded for IP: %s", ip)
 				w.Header().Set("Retry-After", "1")
 				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 				return
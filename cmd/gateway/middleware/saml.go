@@ -7,11 +7,11 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/avika-ai/avika/internal/common/logging"
 	"github.com/crewjam/saml/samlsp"
 )
 
@@ -117,7 +117,7 @@ func (p *SAMLProvider) syncTeamMembership(username string, groups []string) erro
 	for _, group := range groups {
 		if teamName, ok := p.config.GroupMapping[group]; ok {
 			if err := p.teamMapper.AddUserToTeamByName(username, teamName); err != nil {
-				log.Printf("SAML: Failed to add %s to team %s: %v", username, teamName, err)
+				authLog.Error().Msgf("SAML: Failed to add %s to team %s: %v", username, teamName, err)
 			}
 		}
 	}
@@ -181,10 +181,10 @@ func (p *SAMLProvider) finalizeSAMLSession(w http.ResponseWriter, r *http.Reques
 	if p.config.AutoProvision && p.userProvisioner != nil {
 		existing, err := p.userProvisioner.GetUserInfo(username)
 		if err != nil {
-			log.Printf("SAML provision error checking user %s: %v", username, err)
+			logging.Ctx(r.Context(), authLog).Error().Msgf("SAML provision error checking user %s: %v", username, err)
 		} else if existing == nil {
 			if err := p.userProvisioner.CreateUser(username, email, role); err != nil {
-				log.Printf("SAML failed to create user %s: %v", username, err)
+				logging.Ctx(r.Context(), authLog).Error().Msgf("SAML failed to create user %s: %v", username, err)
 			}
 		} else if existing.Email != email {
 			_ = p.userProvisioner.UpdateUserEmail(username, email)
@@ -200,7 +200,7 @@ func (p *SAMLProvider) finalizeSAMLSession(w http.ResponseWriter, r *http.Reques
 
 	token, expiresAt, err := p.authManager.GenerateToken(user)
 	if err != nil {
-		log.Printf("Failed to generate session token for SAML user %s: %v", username, err)
+		logging.Ctx(r.Context(), authLog).Error().Msgf("Failed to generate session token for SAML user %s: %v", username, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -216,7 +216,7 @@ func (p *SAMLProvider) finalizeSAMLSession(w http.ResponseWriter, r *http.Reques
 		SameSite: http.SameSiteLaxMode,
 	})
 
-	log.Printf("SAML login successful for user: %s (role: %s)", username, role)
+	logging.Ctx(r.Context(), authLog).Info().Msgf("SAML login successful for user: %s (role: %s)", username, role)
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
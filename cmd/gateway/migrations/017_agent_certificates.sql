@@ -0,0 +1,19 @@
+-- Migration: 017_agent_certificates.sql
+-- Description: Fleet-wide certificate inventory discovered from agents (polled daily)
+
+CREATE TABLE IF NOT EXISTS agent_certificates (
+    agent_id TEXT NOT NULL REFERENCES agents(agent_id) ON DELETE CASCADE,
+    cert_path TEXT NOT NULL,
+    domain VARCHAR(255) NOT NULL,
+    key_path TEXT,
+    issuer VARCHAR(255),
+    san_domains TEXT[] DEFAULT '{}',
+    expiry_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_alert_days INTEGER, -- Last expiry milestone alerted (30/14/7/0); reset when the cert is renewed
+    first_seen TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (agent_id, cert_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_certificates_expiry ON agent_certificates(expiry_date);
+CREATE INDEX IF NOT EXISTS idx_agent_certificates_domain ON agent_certificates(domain);
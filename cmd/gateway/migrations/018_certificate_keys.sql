@@ -0,0 +1,5 @@
+-- Migration: 018_certificate_keys.sql
+-- Description: Store private keys (or their Vault source) so inventory certificates can be redeployed
+
+ALTER TABLE certificate_inventory ADD COLUMN IF NOT EXISTS key_content TEXT;
+ALTER TABLE certificate_inventory ADD COLUMN IF NOT EXISTS vault_path TEXT;
@@ -0,0 +1,30 @@
+-- Migration: 019_config_template_versions.sql
+-- Description: Config template version history and per-environment/per-agent variable values
+
+CREATE TABLE IF NOT EXISTS config_template_versions (
+    template_id UUID NOT NULL REFERENCES config_templates(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    variables JSONB DEFAULT '[]',
+    defaults JSONB DEFAULT '{}',
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (template_id, version)
+);
+
+-- Existing templates get their current content recorded as a version
+INSERT INTO config_template_versions (template_id, version, content, variables, defaults, created_by, created_at)
+SELECT id, COALESCE(version, 1), content, variables, defaults, created_by, updated_at
+FROM config_templates
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS config_template_values (
+    template_id UUID NOT NULL REFERENCES config_templates(id) ON DELETE CASCADE,
+    scope VARCHAR(20) NOT NULL, -- 'environment' or 'agent'
+    scope_id TEXT NOT NULL,     -- environment UUID or agent_id
+    variables JSONB NOT NULL DEFAULT '{}',
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (template_id, scope, scope_id),
+    CONSTRAINT config_template_values_scope CHECK (scope IN ('environment', 'agent'))
+);
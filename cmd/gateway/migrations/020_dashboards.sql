@@ -0,0 +1,31 @@
+-- Migration: 020_dashboards.sql
+-- Description: User/team dashboards composed of panels backed by the metric query API
+
+CREATE TABLE IF NOT EXISTS dashboards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    owner VARCHAR(100) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+    team_id UUID REFERENCES teams(id) ON DELETE SET NULL, -- Visible to team members when set
+    is_shared BOOLEAN DEFAULT false,                      -- Visible to every user when true
+    time_window VARCHAR(20) DEFAULT '24h',                -- Default window for panels without one
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dashboards_owner ON dashboards(owner);
+CREATE INDEX IF NOT EXISTS idx_dashboards_team ON dashboards(team_id);
+
+CREATE TABLE IF NOT EXISTS dashboard_panels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dashboard_id UUID NOT NULL REFERENCES dashboards(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    panel_type VARCHAR(50) NOT NULL DEFAULT 'timeseries', -- 'timeseries', 'bar', 'pie', 'stat', 'table'
+    query JSONB NOT NULL,                                  -- MetricQuery
+    layout JSONB DEFAULT '{}',                             -- {"x":0,"y":0,"w":6,"h":4}
+    position INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dashboard_panels_dashboard ON dashboard_panels(dashboard_id, position);
@@ -0,0 +1,14 @@
+-- Migration: 021_ip_blocklist.sql
+-- Description: Addresses blocked on nginx agents via generated deny rules
+
+CREATE TABLE IF NOT EXISTS ip_blocklist (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cidr VARCHAR(64) NOT NULL UNIQUE,          -- Single address or CIDR, normalized
+    reason TEXT,
+    source VARCHAR(100) DEFAULT 'manual',       -- 'manual' or the threat feed that listed it
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE         -- NULL = permanent
+);
+
+CREATE INDEX IF NOT EXISTS idx_ip_blocklist_expires ON ip_blocklist(expires_at);
@@ -0,0 +1,29 @@
+-- Migration: 022_rate_limit_rules.sql
+-- Description: L7 rate limit rules rendered to limit_req_zone/limit_req and their per-agent deployment state
+
+CREATE TABLE IF NOT EXISTS rate_limit_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(200) NOT NULL,
+    zone VARCHAR(64) NOT NULL UNIQUE,             -- nginx zone name without the avika_ prefix
+    key_type VARCHAR(20) NOT NULL DEFAULT 'ip',   -- 'ip', 'ip_uri', 'server'
+    path_prefix VARCHAR(500),                     -- Only requests under this path are limited; NULL = all
+    rate INTEGER NOT NULL,
+    rate_unit VARCHAR(2) NOT NULL DEFAULT 'r/s',  -- 'r/s' or 'r/m'
+    burst INTEGER DEFAULT 0,
+    nodelay BOOLEAN DEFAULT false,
+    zone_size_mb INTEGER DEFAULT 10,
+    enabled BOOLEAN DEFAULT true,
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS rate_limit_deployments (
+    agent_id VARCHAR(255) PRIMARY KEY,
+    config_hash VARCHAR(64) NOT NULL,             -- sha256 of the pushed include
+    zones TEXT[] DEFAULT '{}',                    -- Zones contained in the pushed include
+    status VARCHAR(20) NOT NULL,                  -- 'applied' or 'failed'
+    error TEXT,
+    applied_by VARCHAR(100),
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
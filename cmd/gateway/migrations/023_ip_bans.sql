@@ -0,0 +1,24 @@
+-- Migration: 023_ip_bans.sql
+-- Description: Alert-driven temporary bans pushed to agents as deny rules
+
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS action VARCHAR(20) DEFAULT '';       -- '' or 'ban'
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS ban_duration_sec INTEGER DEFAULT 3600;
+
+CREATE TABLE IF NOT EXISTS ip_bans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_ip VARCHAR(64) NOT NULL,
+    agent_ids TEXT[] NOT NULL DEFAULT '{}',        -- Agents that received the deny rule
+    rule_id UUID REFERENCES alert_rules(id) ON DELETE SET NULL,
+    rule_name TEXT,
+    reason TEXT,
+    hits BIGINT DEFAULT 0,                         -- Offending requests/events seen when banned
+    created_by VARCHAR(100),                       -- 'system' for alert-driven bans
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    lifted_at TIMESTAMP WITH TIME ZONE,            -- Set on expiry or manual lift
+    lifted_by VARCHAR(100)
+);
+
+-- At most one active ban per address; repeat offences extend it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ip_bans_active ON ip_bans(client_ip) WHERE lifted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_ip_bans_expires ON ip_bans(expires_at) WHERE lifted_at IS NULL;
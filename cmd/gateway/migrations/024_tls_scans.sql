@@ -0,0 +1,16 @@
+-- Migration: 024_tls_scans.sql
+-- Description: History of TLS and security header scans of agent virtual hosts
+
+CREATE TABLE IF NOT EXISTS tls_scan_results (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id VARCHAR(255) NOT NULL,
+    server_name VARCHAR(255) NOT NULL,
+    address VARCHAR(100) NOT NULL,                -- IP:port the scan connected to
+    grade VARCHAR(2) NOT NULL,                    -- 'A'..'F'
+    score INTEGER NOT NULL,
+    result JSONB NOT NULL,                        -- Full TLSScanResult
+    scanned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tls_scan_results_vhost ON tls_scan_results(agent_id, server_name, scanned_at DESC);
+CREATE INDEX IF NOT EXISTS idx_tls_scan_results_scanned ON tls_scan_results(scanned_at);
@@ -0,0 +1,23 @@
+-- Migration: 025_synthetic_checks.sql
+-- Description: User-defined synthetic checks run by the gateway; results are stored in ClickHouse
+
+CREATE TABLE IF NOT EXISTS synthetic_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(200) NOT NULL,
+    check_type VARCHAR(20) NOT NULL DEFAULT 'http',
+    target TEXT NOT NULL,                          -- URL for HTTP checks
+    settings JSONB DEFAULT '{}',                   -- Request options and assertions, see SyntheticCheckSettings
+    interval_sec INTEGER DEFAULT 60,
+    timeout_ms INTEGER DEFAULT 10000,
+    max_latency_ms INTEGER DEFAULT 0,              -- 0 = no latency assertion
+    failure_threshold INTEGER DEFAULT 2,           -- Consecutive failures before the check is reported down
+    regions TEXT[] DEFAULT '{}',                   -- Gateways (by region) that run the check; empty = all
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    agent_id VARCHAR(255),                         -- Optional server the check covers; feeds its uptime view
+    enabled BOOLEAN DEFAULT true,
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_synthetic_checks_project ON synthetic_checks(project_id);
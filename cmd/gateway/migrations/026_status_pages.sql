@@ -0,0 +1,30 @@
+-- Migration: 026_status_pages.sql
+-- Description: Per-project status pages built from synthetic checks and SLOs, and the check incidents they list
+
+CREATE TABLE IF NOT EXISTS status_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL UNIQUE REFERENCES projects(id) ON DELETE CASCADE,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    public BOOLEAN DEFAULT false,                  -- false = the access token is required
+    token_hash VARCHAR(64) NOT NULL,               -- SHA256 of the access token
+    components JSONB DEFAULT '[]',                 -- [{"name": ..., "check_ids": [...]}]
+    slo_target_ids TEXT[] DEFAULT '{}',
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A check is down from the moment it crosses its failure threshold until it passes again
+CREATE TABLE IF NOT EXISTS synthetic_incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    check_id UUID NOT NULL REFERENCES synthetic_checks(id) ON DELETE CASCADE,
+    region VARCHAR(100) NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_synthetic_incidents_open ON synthetic_incidents(check_id, region) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_synthetic_incidents_started ON synthetic_incidents(check_id, started_at DESC);
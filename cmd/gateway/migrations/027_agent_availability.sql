@@ -0,0 +1,13 @@
+-- Migration: 027_agent_availability.sql
+-- Description: Agent online/offline transitions for connectivity uptime and offline alerts
+
+CREATE TABLE IF NOT EXISTS agent_availability_events (
+    id BIGSERIAL PRIMARY KEY,
+    agent_id VARCHAR(255) NOT NULL,
+    status VARCHAR(10) NOT NULL,                  -- 'online' or 'offline'
+    reason VARCHAR(30) NOT NULL,                  -- 'connect', 'disconnect' or 'heartbeat_timeout'
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_availability_agent ON agent_availability_events(agent_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_agent_availability_occurred ON agent_availability_events(occurred_at);
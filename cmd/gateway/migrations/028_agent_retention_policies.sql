@@ -0,0 +1,13 @@
+-- Migration: 028_agent_retention_policies.sql
+-- Description: Per-environment deregistration of offline agents, overriding agent.retention_period
+
+CREATE TABLE IF NOT EXISTS agent_retention_policies (
+    environment_id UUID PRIMARY KEY REFERENCES environments(id) ON DELETE CASCADE,
+    prune_after_sec BIGINT NOT NULL CHECK (prune_after_sec >= 0),  -- 0 keeps offline agents forever
+    archive_analytics BOOLEAN NOT NULL DEFAULT FALSE,              -- keep ClickHouse data of pruned agents
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Whether the pruned agent's analytics were kept rather than deleted
+ALTER TABLE historical_agents ADD COLUMN IF NOT EXISTS analytics_archived BOOLEAN DEFAULT FALSE;
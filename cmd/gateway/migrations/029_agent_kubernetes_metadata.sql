@@ -0,0 +1,6 @@
+-- Migration: 029_agent_kubernetes_metadata.sql
+-- Description: Pod namespace, owner and labels reported by agents running in Kubernetes
+
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS kubernetes JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_agents_k8s_namespace ON agents ((kubernetes->>'namespace')) WHERE kubernetes IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Migration: 030_agent_config_bundles.sql
+-- Description: Fleet-wide and per-environment agent settings served to agents at startup
+
+CREATE TABLE IF NOT EXISTS agent_config_bundles (
+    scope VARCHAR(64) PRIMARY KEY,                -- 'global' or the environment id
+    environment_id UUID REFERENCES environments(id) ON DELETE CASCADE,
+    settings JSONB NOT NULL DEFAULT '{}',         -- avika-agent.conf keys and values
+    include_psk BOOLEAN,                          -- NULL inherits the global bundle
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Migration: 031_log_archives.sql
+-- Description: Days of access logs archived to object storage as Parquet
+
+CREATE TABLE IF NOT EXISTS log_archives (
+    day DATE PRIMARY KEY,
+    object_url TEXT NOT NULL,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    restored_at TIMESTAMP,                        -- loaded into access_logs_restored; NULL when not
+    deleted_at TIMESTAMP                          -- object removed by retention
+);
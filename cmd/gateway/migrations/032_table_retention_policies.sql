@@ -0,0 +1,12 @@
+-- Migration: 032_table_retention_policies.sql
+-- Description: ClickHouse TTLs per table, with per-environment overrides
+
+CREATE TABLE IF NOT EXISTS table_retention_policies (
+    table_name VARCHAR(64) NOT NULL,
+    scope VARCHAR(64) NOT NULL,                   -- 'default' or the environment id
+    environment_id UUID REFERENCES environments(id) ON DELETE CASCADE,
+    days INTEGER NOT NULL CHECK (days > 0),
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (table_name, scope)
+);
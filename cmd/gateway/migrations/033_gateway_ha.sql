@@ -0,0 +1,17 @@
+-- Migration: 033_gateway_ha.sql
+-- Description: Gateway instances, leases of singleton jobs and the gateway owning each agent
+
+CREATE TABLE IF NOT EXISTS gateway_instances (
+    instance_id VARCHAR(255) PRIMARY KEY,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP   -- renewed with the gateway's leases
+);
+
+CREATE TABLE IF NOT EXISTS gateway_leases (
+    name VARCHAR(100) PRIMARY KEY,                -- 'leader' or 'synthetic:<region>'
+    holder VARCHAR(255) NOT NULL,                 -- gateway instance_id
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Gateway holding the agent's stream
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS gateway_id VARCHAR(255) NOT NULL DEFAULT '';
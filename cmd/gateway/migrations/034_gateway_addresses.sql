@@ -0,0 +1,4 @@
+-- Migration: 034_gateway_addresses.sql
+-- Description: gRPC address other gateways forward commands for a gateway's agents to
+
+ALTER TABLE gateway_instances ADD COLUMN IF NOT EXISTS address VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,35 @@
+-- Migration: 035_agent_upgrade_campaigns.sql
+-- Description: Agent upgrade campaigns rolled out in batches, with a result per agent
+
+CREATE TABLE IF NOT EXISTS agent_upgrade_campaigns (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    target_version VARCHAR(64) NOT NULL,
+    batch_size INTEGER NOT NULL DEFAULT 1,
+    soak_seconds INTEGER NOT NULL DEFAULT 0,            -- wait after a batch before the next one
+    failure_threshold_pct INTEGER NOT NULL DEFAULT 0,   -- abort once more than this share of attempted agents failed
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',      -- pending, running, completed, aborted, cancelled, interrupted
+    current_batch INTEGER NOT NULL DEFAULT 0,
+    total_batches INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    gateway_id VARCHAR(255),                            -- gateway instance running the campaign
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent_upgrade_results (
+    campaign_id UUID NOT NULL REFERENCES agent_upgrade_campaigns(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    batch INTEGER NOT NULL,                             -- 1-based; 0 for agents skipped up front
+    hostname VARCHAR(255),
+    from_version VARCHAR(64),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',      -- pending, updating, upgraded, failed, skipped
+    error TEXT,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (campaign_id, agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_upgrade_campaigns_created ON agent_upgrade_campaigns(created_at DESC);
@@ -0,0 +1,19 @@
+-- Migration: 036_agent_release_channels.sql
+-- Description: Agent release channels with staged rollouts and per-environment channel/version pins
+
+CREATE TABLE IF NOT EXISTS agent_release_channels (
+    name VARCHAR(32) PRIMARY KEY,                   -- stable, beta
+    version VARCHAR(64) NOT NULL,                   -- version agents on the channel receive
+    rollout_version VARCHAR(64),                    -- candidate staged to rollout_percent of the channel
+    rollout_percent INTEGER NOT NULL DEFAULT 0,
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS agent_release_environments (
+    environment_id UUID PRIMARY KEY REFERENCES environments(id) ON DELETE CASCADE,
+    channel VARCHAR(32) NOT NULL DEFAULT 'stable',
+    pinned_version VARCHAR(64),                     -- overrides the channel when set
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Migration: 037_agent_nginx_builds.sql
+-- Description: Build, OpenSSL version and modules of each nginx binary an agent runs
+
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS nginx_builds JSONB;
@@ -0,0 +1,4 @@
+-- Migration: 038_agent_host_inventory.sql
+-- Description: OS release, kernel and package versions an agent's host reports
+
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS host_inventory JSONB;
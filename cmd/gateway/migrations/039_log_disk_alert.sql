@@ -0,0 +1,6 @@
+-- Migration: 039_log_disk_alert.sql
+-- Description: Default alert on the disk holding nginx logs filling up
+
+INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients)
+VALUES ('6f1c2a4e-8d3b-4f5a-9c7e-1b2d3e4f5a60', 'Log partition nearly full', 'log_disk_usage', 90, 'gt', 300, TRUE, '')
+ON CONFLICT (id) DO NOTHING;
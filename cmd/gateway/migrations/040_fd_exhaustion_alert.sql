@@ -0,0 +1,6 @@
+-- Migration: 040_fd_exhaustion_alert.sql
+-- Description: Default alert on hosts or nginx processes running out of file descriptors
+
+INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients)
+VALUES ('7a2d3b5f-9e4c-4a6b-8d1f-2c3e4f5a6b71', 'File descriptors nearly exhausted', 'fd_usage', 90, 'gt', 300, TRUE, '')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration: 041_restart_loop_alert.sql
+-- Description: Default alert on NGINX units stuck in a restart loop
+
+INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients)
+VALUES ('8b3e4c6a-0f5d-4b7c-9e2a-3d4f5a6b7c82', 'NGINX restart loop', 'restart_loop', 0, 'gt', 300, TRUE, '')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,38 @@
+-- Migration: 042_scheduled_tasks.sql
+-- Description: Recurring operations run by the gateway on a cron schedule, with their run history
+
+CREATE TABLE IF NOT EXISTS scheduled_tasks (
+    id UUID PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    cron VARCHAR(100) NOT NULL,                          -- five-field cron expression or @daily, @weekly...
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',         -- IANA zone the expression is evaluated in
+    action VARCHAR(30) NOT NULL,                         -- reload, restart, config_backup, report
+    environment_id UUID REFERENCES environments(id) ON DELETE CASCADE, -- NULL = all agents, superadmins only
+    params JSONB DEFAULT '{}',                           -- action options, see ScheduledTaskParams
+    notify_recipients TEXT,                              -- emails and webhooks alerted when a run fails
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_status VARCHAR(20),                             -- succeeded, failed
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_task_runs (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES scheduled_tasks(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL,                        -- schedule, manual
+    triggered_by VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'running',       -- running, succeeded, failed, interrupted
+    targets INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    results JSONB DEFAULT '[]',                          -- outcome per agent
+    gateway_id VARCHAR(255),
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_tasks_due ON scheduled_tasks(next_run_at) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_scheduled_task_runs_task ON scheduled_task_runs(task_id, started_at DESC);
@@ -0,0 +1,32 @@
+-- Migration: 043_webhooks.sql
+-- Description: Signed outbound webhooks for lifecycle events, with a delivery log that doubles as the retry queue
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,                        -- HMAC-SHA256 key of the X-Avika-Signature header
+    events TEXT[] NOT NULL DEFAULT '{}',                 -- event types delivered; '*' = all
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',       -- pending, delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER,
+    error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
@@ -0,0 +1,22 @@
+-- Migration: 044_events.sql
+-- Description: Unified feed of notable system events (connections, commands, alerts, config changes)
+
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,                            -- feed cursor
+    type VARCHAR(50) NOT NULL,                           -- e.g. agent.offline, command.reload, alert.fired
+    category VARCHAR(20) NOT NULL,                       -- connection, agent, command, alert, config, task
+    severity VARCHAR(10) NOT NULL DEFAULT 'info',        -- info, warning, critical
+    agent_id VARCHAR(255),
+    environment_id UUID,                                 -- environment and project of the agent when recorded
+    project_id UUID,
+    actor VARCHAR(100),                                  -- user who caused the event, if any
+    message TEXT NOT NULL,
+    data JSONB DEFAULT '{}',
+    gateway_id VARCHAR(255),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_agent ON events(agent_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_events_project ON events(project_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_events_type ON events(type, id DESC);
+CREATE INDEX IF NOT EXISTS idx_events_occurred ON events(occurred_at);
@@ -0,0 +1,29 @@
+-- Migration: 045_bulk_actions.sql
+-- Description: Reloads, restarts and agent updates fanned out over a selection of agents, with a result per agent
+
+CREATE TABLE IF NOT EXISTS bulk_actions (
+    id UUID PRIMARY KEY,
+    action VARCHAR(20) NOT NULL,                        -- reload, restart, update
+    selector JSONB NOT NULL DEFAULT '{}',               -- agent_ids, group_id, environment_id, tags as requested
+    params JSONB NOT NULL DEFAULT '{}',                 -- nginx_instance_id, version
+    concurrency INTEGER NOT NULL DEFAULT 1,             -- agents acted on at once
+    status VARCHAR(20) NOT NULL DEFAULT 'running',      -- running, succeeded, partial, failed, cancelled, interrupted
+    error TEXT,
+    gateway_id VARCHAR(255),                            -- gateway instance running the action
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bulk_action_results (
+    bulk_action_id UUID NOT NULL REFERENCES bulk_actions(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    hostname VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',      -- pending, running, succeeded, failed, skipped
+    error TEXT,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (bulk_action_id, agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_actions_created ON bulk_actions(created_at DESC);
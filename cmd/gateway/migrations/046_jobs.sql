@@ -0,0 +1,30 @@
+-- Migration: 046_jobs.sql
+-- Description: Long-running background jobs (bulk actions, upgrade campaigns, reports) with progress and logs
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY,                                -- the ID of the bulk action or campaign the job runs
+    kind VARCHAR(30) NOT NULL,                          -- bulk_action, upgrade_campaign, report
+    status VARCHAR(20) NOT NULL DEFAULT 'running',      -- running, succeeded, failed, cancelled, interrupted
+    step VARCHAR(255),                                  -- what the job is doing now
+    progress_done INTEGER NOT NULL DEFAULT 0,
+    progress_total INTEGER NOT NULL DEFAULT 0,          -- 0 when the amount of work is unknown
+    error TEXT,
+    artifact BYTEA,                                     -- file produced by the job, such as a report
+    artifact_name VARCHAR(255),
+    artifact_type VARCHAR(100),
+    gateway_id VARCHAR(255),                            -- gateway instance running the job
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS job_logs (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    level VARCHAR(10) NOT NULL DEFAULT 'info',          -- info, warn, error
+    message TEXT NOT NULL,
+    logged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_created ON jobs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_job_logs_job ON job_logs(job_id, id);
@@ -0,0 +1,40 @@
+-- Migration: 047_config_snippets.sql
+-- Description: Shared config snippets (security headers, log formats, TLS settings) written to managed include files on agents
+
+CREATE TABLE IF NOT EXISTS config_snippets (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,                  -- file name of the include: avika/snippets/<slug>.conf
+    category VARCHAR(30) NOT NULL DEFAULT 'other',      -- security_headers, logging, tls, other
+    description TEXT,
+    content TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS config_snippet_versions (
+    snippet_id UUID NOT NULL REFERENCES config_snippets(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snippet_id, version)
+);
+
+-- The version of each snippet last written to each agent
+CREATE TABLE IF NOT EXISTS config_snippet_deployments (
+    snippet_id UUID NOT NULL REFERENCES config_snippets(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,                        -- applied, failed
+    error TEXT,
+    deployed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snippet_id, agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_snippet_deployments_agent ON config_snippet_deployments(agent_id);
+
+ALTER TABLE batch_config_updates ADD COLUMN IF NOT EXISTS snippet_id UUID REFERENCES config_snippets(id) ON DELETE SET NULL;
@@ -0,0 +1,26 @@
+-- Migration: 048_traffic_splits.sql
+-- Description: Weighted traffic splits between upstreams (split_clients) for blue/green and A/B releases
+
+CREATE TABLE IF NOT EXISTS traffic_splits (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,                  -- variable $avika_split_<slug> and file conf.d/avika-split-<slug>.conf
+    split_key TEXT NOT NULL,                            -- what clients are hashed on, e.g. ${remote_addr}${http_user_agent}
+    variants JSONB NOT NULL DEFAULT '[]',               -- name, upstream, weight
+    selector JSONB NOT NULL DEFAULT '{}',               -- agent_ids, group_id, environment_id, tags
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The outcome of the last write of each split to each agent
+CREATE TABLE IF NOT EXISTS traffic_split_agents (
+    split_id UUID NOT NULL REFERENCES traffic_splits(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,                        -- applied, failed
+    error TEXT,
+    variants JSONB NOT NULL DEFAULT '[]',               -- the weights last applied
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (split_id, agent_id)
+);
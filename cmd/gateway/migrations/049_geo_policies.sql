@@ -0,0 +1,27 @@
+-- Migration: 049_geo_policies.sql
+-- Description: Country allow/deny policies compiled into geo blocks and written to agents
+
+CREATE TABLE IF NOT EXISTS geo_policies (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,                  -- variable $avika_geo_<slug>, files conf.d/avika-geo-<slug>.conf and avika/geo/<slug>.conf
+    mode VARCHAR(10) NOT NULL,                          -- allow: only the countries listed; deny: all but them
+    countries JSONB NOT NULL DEFAULT '[]',              -- ISO 3166-1 alpha-2 codes
+    status_code INTEGER NOT NULL DEFAULT 403,           -- what blocked clients get
+    selector JSONB NOT NULL DEFAULT '{}',               -- agent_ids, group_id, environment_id, tags
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The outcome of the last write of each policy to each agent
+CREATE TABLE IF NOT EXISTS geo_policy_agents (
+    policy_id UUID NOT NULL REFERENCES geo_policies(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,                        -- applied, failed
+    error TEXT,
+    networks INTEGER NOT NULL DEFAULT 0,                -- networks in the geo block last applied
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (policy_id, agent_id)
+);
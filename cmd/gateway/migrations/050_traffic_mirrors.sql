@@ -0,0 +1,31 @@
+-- Migration: 050_traffic_mirrors.sql
+-- Description: Request mirroring (nginx mirror) of sampled production traffic to a staging backend
+
+CREATE TABLE IF NOT EXISTS traffic_mirrors (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,                  -- files conf.d/avika-mirror-<slug>.conf and avika/mirror/<slug>.conf
+    target TEXT NOT NULL,                               -- where mirrored requests go, e.g. http://staging.internal:8080
+    sample_percent NUMERIC(5, 2) NOT NULL,              -- share of requests mirrored
+    locations JSONB NOT NULL DEFAULT '[]',              -- path prefixes mirrored; every path when empty
+    mirror_body BOOLEAN NOT NULL DEFAULT true,
+    selector JSONB NOT NULL DEFAULT '{}',               -- agent_ids, group_id, environment_id, tags
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    enabled_at TIMESTAMP,
+    disabled_at TIMESTAMP,
+    created_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The outcome of the last write of each mirror to each agent
+CREATE TABLE IF NOT EXISTS traffic_mirror_agents (
+    mirror_id UUID NOT NULL REFERENCES traffic_mirrors(id) ON DELETE CASCADE,
+    agent_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,                        -- applied, failed
+    error TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT false,             -- whether the agent mirrors after the last write
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (mirror_id, agent_id)
+);
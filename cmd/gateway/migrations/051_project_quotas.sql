@@ -0,0 +1,25 @@
+-- Migration: 051_project_quotas.sql
+-- Description: Daily ingest quotas per project and the access log bytes each project stores per day
+
+CREATE TABLE IF NOT EXISTS project_quotas (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    soft_bytes_per_day BIGINT NOT NULL DEFAULT 0,       -- warn above; 0 for no soft limit
+    hard_bytes_per_day BIGINT NOT NULL DEFAULT 0,       -- sample or drop above; 0 for no hard limit
+    hard_action VARCHAR(10) NOT NULL DEFAULT 'sample',  -- sample, drop
+    sample_percent NUMERIC(5, 2) NOT NULL DEFAULT 10,   -- share of entries still stored above the hard limit
+    updated_by VARCHAR(100) REFERENCES users(username) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Access log bytes stored in and kept out of ClickHouse per project and UTC day, summed over gateways
+CREATE TABLE IF NOT EXISTS project_ingest_usage (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    stored_bytes BIGINT NOT NULL DEFAULT 0,
+    stored_entries BIGINT NOT NULL DEFAULT 0,
+    dropped_bytes BIGINT NOT NULL DEFAULT 0,
+    dropped_entries BIGINT NOT NULL DEFAULT 0,
+    soft_notified_at TIMESTAMP,                         -- when the soft limit warning went out
+    hard_notified_at TIMESTAMP,                         -- when the hard limit notification went out
+    PRIMARY KEY (project_id, day)
+);
@@ -0,0 +1,52 @@
+-- Migration: 052_analytics_postgres.sql
+-- Description: Access logs and agent metrics for analytics.backend postgres, for installs without ClickHouse.
+-- The gateway turns these into TimescaleDB hypertables when the extension is installed.
+
+CREATE TABLE IF NOT EXISTS analytics_access_logs (
+    timestamp TIMESTAMPTZ NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    project_id VARCHAR(36) NOT NULL DEFAULT '',       -- stamped from the agent's server assignment
+    environment_id VARCHAR(36) NOT NULL DEFAULT '',
+    request_method TEXT NOT NULL DEFAULT '',
+    request_uri TEXT NOT NULL DEFAULT '',
+    status INTEGER NOT NULL DEFAULT 0,
+    body_bytes_sent BIGINT NOT NULL DEFAULT 0,
+    request_length BIGINT NOT NULL DEFAULT 0,
+    request_time DOUBLE PRECISION NOT NULL DEFAULT 0  -- seconds
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_access_logs_timestamp ON analytics_access_logs(timestamp);
+CREATE INDEX IF NOT EXISTS idx_analytics_access_logs_instance ON analytics_access_logs(instance_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_analytics_access_logs_project ON analytics_access_logs(project_id, timestamp);
+
+CREATE TABLE IF NOT EXISTS analytics_system_metrics (
+    timestamp TIMESTAMPTZ NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    project_id VARCHAR(36) NOT NULL DEFAULT '',
+    environment_id VARCHAR(36) NOT NULL DEFAULT '',
+    cpu_usage REAL NOT NULL DEFAULT 0,
+    memory_usage REAL NOT NULL DEFAULT 0,
+    network_rx_rate REAL NOT NULL DEFAULT 0,
+    network_tx_rate REAL NOT NULL DEFAULT 0,
+    cpu_user REAL NOT NULL DEFAULT 0,
+    cpu_system REAL NOT NULL DEFAULT 0,
+    cpu_iowait REAL NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_system_metrics_instance ON analytics_system_metrics(instance_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_analytics_system_metrics_timestamp ON analytics_system_metrics(timestamp);
+
+CREATE TABLE IF NOT EXISTS analytics_nginx_metrics (
+    timestamp TIMESTAMPTZ NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    project_id VARCHAR(36) NOT NULL DEFAULT '',
+    environment_id VARCHAR(36) NOT NULL DEFAULT '',
+    active_connections BIGINT NOT NULL DEFAULT 0,
+    reading BIGINT NOT NULL DEFAULT 0,
+    writing BIGINT NOT NULL DEFAULT 0,
+    waiting BIGINT NOT NULL DEFAULT 0,
+    requests_per_second DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_nginx_metrics_instance ON analytics_nginx_metrics(instance_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_analytics_nginx_metrics_timestamp ON analytics_nginx_metrics(timestamp);
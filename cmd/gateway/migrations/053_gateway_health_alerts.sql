@@ -0,0 +1,11 @@
+-- Migration: 053_gateway_health_alerts.sql
+-- Description: Default alerts on the gateway's own health: failed ClickHouse inserts, a stalled Kafka
+-- consumer, full batch write queues and PostgreSQL not answering
+
+INSERT INTO alert_rules (id, name, metric_type, threshold, comparison, window_sec, enabled, recipients)
+VALUES
+    ('9c4f5d7b-1a6e-4c8d-8f3b-4e5a6b7c8d93', 'Gateway: ClickHouse inserts failing', 'gateway_clickhouse_insert_failures', 0, 'gt', 300, TRUE, ''),
+    ('ad506e8c-2b7f-4d9e-9a4c-5f6b7c8d9ea4', 'Gateway: Kafka consumer stalled', 'gateway_kafka_consumer_stall', 300, 'gt', 300, TRUE, ''),
+    ('be617f9d-3c8a-4eaf-8b5d-6a7c8d9eafb5', 'Gateway: batch write queue saturated', 'gateway_queue_saturation', 80, 'gt', 300, TRUE, ''),
+    ('cf728a0e-4d9b-4fba-9c6e-7b8d9eafb0c6', 'Gateway: PostgreSQL down', 'gateway_postgres_down', 0, 'gt', 60, TRUE, '')
+ON CONFLICT (id) DO NOTHING;
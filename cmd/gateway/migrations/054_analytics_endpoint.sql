@@ -0,0 +1,5 @@
+-- Migration: 054_analytics_endpoint.sql
+-- Description: Endpoint of each request (its URI template, analytics.uri_templates), which top
+-- endpoints group by; request_uri keeps the raw URI. Rows from before have it empty.
+
+ALTER TABLE analytics_access_logs ADD COLUMN IF NOT EXISTS endpoint TEXT NOT NULL DEFAULT '';
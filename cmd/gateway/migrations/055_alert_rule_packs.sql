@@ -0,0 +1,10 @@
+-- Migration: 055_alert_rule_packs.sql
+-- Description: Alert rules scoped to a project, created from built-in rule packs, and keeping their
+-- severity and cooldown
+
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE; -- NULL for the whole fleet
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS pack TEXT NOT NULL DEFAULT '';        -- '<pack>/<template>' for rules of a rule pack
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS severity VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS cooldown_sec INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_project ON alert_rules(project_id) WHERE project_id IS NOT NULL;
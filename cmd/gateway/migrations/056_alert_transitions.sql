@@ -0,0 +1,21 @@
+-- Migration: 056_alert_transitions.sql
+-- Description: State changes of alert rules (pending, firing, resolved) for the alert history
+
+CREATE TABLE IF NOT EXISTS alert_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id UUID NOT NULL,                        -- no foreign key: history outlives deleted rules
+    rule_name TEXT NOT NULL,
+    project_id VARCHAR(36) NOT NULL DEFAULT '',   -- '' for fleet-wide rules
+    metric_type TEXT NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',     -- the agent of agent_offline rules, the gateway of gateway health rules
+    from_state VARCHAR(10) NOT NULL,              -- 'pending', 'firing' or 'resolved'
+    to_state VARCHAR(10) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,              -- the metric when the state changed
+    threshold DOUBLE PRECISION NOT NULL,
+    comparison TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_transitions_rule ON alert_transitions(rule_id, subject, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_alert_transitions_occurred ON alert_transitions(occurred_at);
@@ -0,0 +1,338 @@
+-- Migration: 001_baseline.sql
+-- Description: The nginx_analytics schema as migrate() created it before versioned migrations. Every
+-- statement is idempotent, so existing installs apply it over their tables unchanged.
+
+CREATE DATABASE IF NOT EXISTS nginx_analytics;
+
+-- ── Core tables (with partitioning and optimized ORDER BY) ────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.gateway_metrics (
+    timestamp DateTime64(3),
+    gateway_id LowCardinality(String),
+    eps Float32,
+    active_connections UInt32,
+    cpu_usage Float32,
+    memory_mb Float32,
+    goroutines UInt32,
+    db_latency_ms Float32,
+    labels Map(String, String)
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (gateway_id, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    remote_addr String,
+    client_ip String DEFAULT '',
+    request_method LowCardinality(String),
+    request_uri String,
+    status UInt16,
+    body_bytes_sent UInt64,
+    request_length UInt64 DEFAULT 0,
+    request_time Float32,
+    request_id String,
+    upstream_addr String,
+    upstream_status LowCardinality(String),
+    upstream_cache_status LowCardinality(String) DEFAULT '',
+    upstream_connect_time Float32,
+    upstream_header_time Float32,
+    upstream_response_time Float32,
+    user_agent String,
+    referer String,
+    labels Map(String, String),
+    INDEX idx_status (status) TYPE minmax GRANULARITY 4,
+    INDEX idx_uri (request_uri) TYPE bloom_filter(0.01) GRANULARITY 4,
+    INDEX idx_client_ip (client_ip) TYPE bloom_filter(0.01) GRANULARITY 4
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.system_metrics (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    cpu_usage Float32,
+    memory_usage Float32,
+    memory_total UInt64,
+    memory_used UInt64,
+    network_rx_bytes UInt64,
+    network_tx_bytes UInt64,
+    network_rx_rate Float32,
+    network_tx_rate Float32,
+    cpu_user Float32,
+    cpu_system Float32,
+    cpu_iowait Float32,
+    labels Map(String, String),
+    fd_allocated UInt64 DEFAULT 0,
+    fd_max UInt64 DEFAULT 0,
+    fd_used_percent Float32 DEFAULT 0,
+    nginx_open_fds UInt64 DEFAULT 0,
+    nginx_fd_limit UInt64 DEFAULT 0,
+    nginx_fd_used_percent Float32 DEFAULT 0,
+    conntrack_count UInt64 DEFAULT 0,
+    conntrack_max UInt64 DEFAULT 0,
+    conntrack_used_percent Float32 DEFAULT 0
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.disk_usage (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    mount String,
+    device String,
+    fstype LowCardinality(String),
+    total_bytes UInt64,
+    used_bytes UInt64,
+    available_bytes UInt64,
+    used_percent Float32,
+    inodes_used_percent Float32,
+    nginx_logs UInt8
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, mount, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.log_files (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    path String,
+    mount String,
+    size_bytes UInt64,
+    growth_rate Float32
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, path, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.nginx_metrics (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    active_connections UInt32,
+    accepted_connections UInt64,
+    handled_connections UInt64,
+    total_requests UInt64,
+    reading UInt32,
+    writing UInt32,
+    waiting UInt32,
+    requests_per_second Float64,
+    status_2xx UInt64 DEFAULT 0,
+    status_3xx UInt64 DEFAULT 0,
+    status_4xx UInt64 DEFAULT 0,
+    status_5xx UInt64 DEFAULT 0,
+    bytes_in UInt64 DEFAULT 0,
+    bytes_out UInt64 DEFAULT 0,
+    labels Map(String, String)
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.nginx_workers (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    master_pid UInt32,
+    pid UInt32,
+    cpu_percent Float32,
+    rss_bytes UInt64,
+    started_at DateTime
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+CREATE TABLE IF NOT EXISTS nginx_analytics.spans (
+    trace_id String,
+    span_id String,
+    parent_span_id String,
+    name String,
+    start_time DateTime64(9),
+    end_time DateTime64(9),
+    attributes Map(String, String),
+    instance_id LowCardinality(String)
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(start_time))
+ORDER BY (instance_id, trace_id, start_time)
+SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;
+
+-- ── Column migrations (backward compat for existing tables) ──────────
+ALTER TABLE nginx_analytics.gateway_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String);
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS labels Map(String, String);
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String);
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS fd_allocated UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS fd_max UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS fd_used_percent Float32 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS nginx_open_fds UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS nginx_fd_limit UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS nginx_fd_used_percent Float32 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS conntrack_count UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS conntrack_max UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.system_metrics ADD COLUMN IF NOT EXISTS conntrack_used_percent Float32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS labels Map(String, String);
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_2xx UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_3xx UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_4xx UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS status_5xx UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS bytes_in UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS bytes_out UInt64 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS worker_count UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS worker_restarts UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS worker_crashes UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS service_restarts UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS service_failures UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.nginx_metrics ADD COLUMN IF NOT EXISTS restart_loop UInt8 DEFAULT 0;
+-- Geo columns
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS client_ip String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS country String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS country_code String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS city String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS region String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS latitude Float64 DEFAULT 0;
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS longitude Float64 DEFAULT 0;
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS timezone String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS isp String DEFAULT '';
+-- Visitor analytics columns
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS is_bot UInt8 DEFAULT 0;
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS browser_family String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS browser_version String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS os_family String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS os_version String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS device_type String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS asn UInt32 DEFAULT 0;
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS bot_category LowCardinality(String) DEFAULT '';
+-- Threat intelligence enrichment
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS threat_feed LowCardinality(String) DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS threat_category LowCardinality(String) DEFAULT '';
+-- Request size for bandwidth in
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS request_length UInt64 DEFAULT 0;
+-- Proxy cache effectiveness
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS upstream_cache_status LowCardinality(String) DEFAULT '';
+
+-- ── Pre-aggregation: 5-minute traffic rollup for dashboard ────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.traffic_5min (
+    ts DateTime,
+    instance_id LowCardinality(String),
+    requests UInt64,
+    errors UInt64,
+    s2xx UInt64,
+    s3xx UInt64,
+    s4xx UInt64,
+    s5xx UInt64,
+    total_bytes UInt64,
+    sum_latency Float64,
+    latency_count UInt64
+) ENGINE = SummingMergeTree()
+PARTITION BY toYYYYMM(ts)
+ORDER BY (instance_id, ts)
+TTL ts + INTERVAL 30 DAY;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS nginx_analytics.traffic_5min_mv
+TO nginx_analytics.traffic_5min AS
+SELECT
+    toStartOfFiveMinutes(toDateTime(timestamp)) AS ts,
+    instance_id,
+    count() AS requests,
+    countIf(status >= 400) AS errors,
+    countIf(status >= 200 AND status < 300) AS s2xx,
+    countIf(status >= 300 AND status < 400) AS s3xx,
+    countIf(status >= 400 AND status < 500) AS s4xx,
+    countIf(status >= 500) AS s5xx,
+    sum(body_bytes_sent) AS total_bytes,
+    sum(request_time) AS sum_latency,
+    count() AS latency_count
+FROM nginx_analytics.access_logs
+GROUP BY ts, instance_id;
+
+-- ── Geo aggregation (hourly) ─────────────────────────────────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.geo_requests_hourly (
+    hour DateTime,
+    country LowCardinality(String),
+    country_code LowCardinality(String),
+    city LowCardinality(String),
+    latitude Float64,
+    longitude Float64,
+    request_count UInt64,
+    error_count UInt64,
+    total_bytes UInt64,
+    avg_latency Float64
+) ENGINE = SummingMergeTree()
+PARTITION BY toYYYYMM(hour)
+ORDER BY (hour, country_code, city)
+TTL hour + INTERVAL 90 DAY;
+
+-- ── Terminal session recordings (asciicast v2) ───────────────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.terminal_sessions (
+    session_id String,
+    agent_id LowCardinality(String),
+    username LowCardinality(String),
+    command String,
+    remote_addr String,
+    started_at DateTime64(3),
+    ended_at DateTime64(3),
+    duration_ms UInt64,
+    bytes_in UInt64,
+    bytes_out UInt64,
+    truncated UInt8,
+    cast String CODEC(ZSTD(3))
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(started_at))
+ORDER BY (agent_id, started_at, session_id)
+TTL toDateTime(started_at) + INTERVAL 365 DAY;
+
+-- ── Attack detection findings ───────────────────────────────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.security_events (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    client_ip String,
+    event_type LowCardinality(String),
+    severity LowCardinality(String),
+    rule_id LowCardinality(String),
+    request_method LowCardinality(String),
+    request_uri String,
+    status UInt16,
+    user_agent String,
+    count UInt32,
+    details String
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp)
+TTL toDateTime(timestamp) + INTERVAL 90 DAY;
+
+-- ── Synthetic check results ─────────────────────────────────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.synthetic_results (
+    timestamp DateTime64(3),
+    check_id LowCardinality(String),
+    region LowCardinality(String),
+    success UInt8,
+    status_code UInt16,
+    latency_ms Float32,
+    error String
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (check_id, timestamp)
+TTL toDateTime(timestamp) + INTERVAL 90 DAY;
+
+-- ── Samples from agents' custom collectors ──────────────────────────
+CREATE TABLE IF NOT EXISTS nginx_analytics.generic_metrics (
+    timestamp DateTime64(3),
+    instance_id LowCardinality(String),
+    source LowCardinality(String),
+    name LowCardinality(String),
+    type LowCardinality(String),
+    value Float64,
+    labels Map(String, String)
+) ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, source, name, timestamp)
+TTL toDateTime(timestamp) + INTERVAL 30 DAY;
+
+-- TTLs are set by the retention manager (table_retention.go) once the gateway is up
+
+-- ── Cold archive ─────────────────────────────────────────────────────
+-- Archived days loaded back from object storage; no TTL, a day stays until it is released
+CREATE TABLE IF NOT EXISTS nginx_analytics.access_logs_restored AS nginx_analytics.access_logs
+ENGINE = MergeTree()
+PARTITION BY toYYYYMM(toDateTime(timestamp))
+ORDER BY (instance_id, timestamp);
@@ -0,0 +1,6 @@
+-- Migration: 002_gateway_load_average.sql
+-- Description: Load averages of each gateway's host, recorded with its process CPU
+
+ALTER TABLE nginx_analytics.gateway_metrics ADD COLUMN IF NOT EXISTS load1 Float32 DEFAULT 0;
+ALTER TABLE nginx_analytics.gateway_metrics ADD COLUMN IF NOT EXISTS load5 Float32 DEFAULT 0;
+ALTER TABLE nginx_analytics.gateway_metrics ADD COLUMN IF NOT EXISTS load15 Float32 DEFAULT 0;
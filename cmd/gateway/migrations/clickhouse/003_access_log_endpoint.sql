@@ -0,0 +1,6 @@
+-- Migration: 003_access_log_endpoint.sql
+-- Description: Endpoint of each request (its URI template, analytics.uri_templates), which top
+-- endpoints group by; request_uri keeps the raw URI. Rows from before have it empty.
+
+ALTER TABLE nginx_analytics.access_logs ADD COLUMN IF NOT EXISTS endpoint String DEFAULT '';
+ALTER TABLE nginx_analytics.access_logs_restored ADD COLUMN IF NOT EXISTS endpoint String DEFAULT '';
@@ -1,24 +1,194 @@
 // Package migrations handles database schema migrations for the gateway.
+//
+// Migrations are embedded SQL files named NNN_name.sql, applied once each in version order: the
+// PostgreSQL ones in this directory by Runner, the ClickHouse ones in clickhouse/ by the gateway's
+// ClickHouse client, which rewrites their statements for clusters. Every database records what it
+// applied, with a checksum of the file, in its schema_migrations table.
 package migrations
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
 	"fmt"
-	"log"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
 )
 
+var migrateLog = logging.NewLogger("migrations")
+
 //go:embed *.sql
 var sqlFiles embed.FS
 
+//go:embed clickhouse/*.sql
+var clickHouseFiles embed.FS
+
+// lockID is the PostgreSQL advisory lock gateways starting together take in turn, so one applies
+// the pending migrations while the others wait and then find nothing left to do
+const lockID = 7_248_563_301
+
+// sharedVersions were given to two files before versions were checked. Their files run together, in
+// file name order, as one migration, the way databases already recorded them.
+var sharedVersions = map[string]bool{"016": true}
+
 // Migration represents a single database migration
 type Migration struct {
-	Version string
-	Name    string
-	SQL     string
+	Version  string
+	Name     string
+	SQL      string
+	Checksum string // SHA-256 of SQL, hex
+}
+
+// Applied is a migration as a database recorded it
+type Applied struct {
+	Version   string
+	Name      string
+	Checksum  string // empty for migrations applied before checksums were recorded
+	AppliedAt time.Time
+}
+
+// Status is where one migration stands on a database
+type Status struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Modified is set when the embedded file no longer matches the one that was applied
+	Modified bool `json:"modified,omitempty"`
+	// Unknown is set for migrations applied by a newer gateway that this one does not embed
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// Postgres returns the embedded PostgreSQL migrations in version order
+func Postgres() ([]Migration, error) {
+	return load(sqlFiles, ".")
+}
+
+// ClickHouse returns the embedded ClickHouse migrations in version order
+func ClickHouse() ([]Migration, error) {
+	return load(clickHouseFiles, "clickhouse")
+}
+
+// load reads the migrations of dir, failing on a file without a numeric version and on two files
+// with the same version, sharedVersions aside
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := map[string]int{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		// Extract version from filename (e.g., "001_init_schema.sql" -> "001")
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		parts := strings.SplitN(name, "_", 2)
+		version := parts[0]
+		if _, err := strconv.Atoi(version); err != nil {
+			return nil, fmt.Errorf("%s: file name must start with a numeric version", entry.Name())
+		}
+		migrationName := name
+		if len(parts) > 1 {
+			migrationName = parts[1]
+		}
+		if i, ok := seen[version]; ok {
+			if !sharedVersions[version] {
+				return nil, fmt.Errorf("%s_%s.sql and %s have the same version", version, migrations[i].Name, entry.Name())
+			}
+			// ReadDir returns file names sorted
+			migrations[i].Name += "+" + migrationName
+			migrations[i].SQL += "\n" + string(content)
+			continue
+		}
+		seen[version] = len(migrations)
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    migrationName,
+			SQL:     string(content),
+		})
+	}
+	for i := range migrations {
+		sum := sha256.Sum256([]byte(migrations[i].SQL))
+		migrations[i].Checksum = hex.EncodeToString(sum[:])
+	}
+
+	// Sort by version
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Statements splits a migration into its statements, for drivers that run one at a time: a
+// statement ends with a semicolon at the end of a line. Comment lines are dropped.
+func Statements(sql string) []string {
+	var stmts []string
+	var cur []string
+	flush := func() {
+		if stmt := strings.TrimSpace(strings.Join(cur, "\n")); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		cur = cur[:0]
+	}
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		trimmed := strings.TrimRight(line, " \t\r")
+		if strings.HasSuffix(trimmed, ";") {
+			cur = append(cur, strings.TrimSuffix(trimmed, ";"))
+			flush()
+			continue
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return stmts
+}
+
+// Report merges the embedded migrations with those a database applied, in version order
+func Report(migrations []Migration, applied map[string]Applied) []Status {
+	var report []Status
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			at := a.AppliedAt
+			s.Applied, s.AppliedAt = true, &at
+			s.Modified = a.Checksum != "" && a.Checksum != m.Checksum
+		}
+		report = append(report, s)
+	}
+	embedded := map[string]bool{}
+	for _, m := range migrations {
+		embedded[m.Version] = true
+	}
+	for _, a := range applied {
+		if !embedded[a.Version] {
+			at := a.AppliedAt
+			report = append(report, Status{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: &at, Unknown: true})
+		}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Version < report[j].Version })
+	return report
 }
 
 // Runner handles database migrations
@@ -31,15 +201,27 @@ func NewRunner(db *sql.DB) *Runner {
 	return &Runner{db: db}
 }
 
-// Run executes all pending migrations
+// Run executes all pending migrations, holding the advisory lock so gateways starting together
+// apply them once and in order
 func (r *Runner) Run() error {
+	ctx := context.Background()
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+
 	// Ensure migrations table exists
 	if err := r.ensureMigrationsTable(); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
 	// Get all migrations
-	migrations, err := r.loadMigrations()
+	migrations, err := Postgres()
 	if err != nil {
 		return fmt.Errorf("failed to load migrations: %w", err)
 	}
@@ -52,16 +234,29 @@ func (r *Runner) Run() error {
 
 	// Run pending migrations
 	for _, m := range migrations {
-		if applied[m.Version] {
-			log.Printf("Migration %s (%s) already applied, skipping", m.Version, m.Name)
+		if a, ok := applied[m.Version]; ok {
+			switch {
+			case a.Checksum == "":
+				// Applied before checksums were recorded
+				if _, err := r.db.Exec("UPDATE schema_migrations SET checksum = $1 WHERE version = $2", m.Checksum, m.Version); err != nil {
+					migrateLog.Error().Msgf("Failed to record the checksum of migration %s: %v", m.Version, err)
+				}
+			case a.Checksum != m.Checksum:
+				migrateLog.Warn().Msgf("Migration %s (%s) was changed after it was applied; changes need a new migration", m.Version, m.Name)
+			}
 			continue
 		}
 
-		log.Printf("Applying migration %s (%s)...", m.Version, m.Name)
+		migrateLog.Info().Msgf("Applying migration %s (%s)...", m.Version, m.Name)
 		if err := r.applyMigration(m); err != nil {
 			return fmt.Errorf("failed to apply migration %s: %w", m.Version, err)
 		}
-		log.Printf("Migration %s applied successfully", m.Version)
+		migrateLog.Info().Msgf("Migration %s applied successfully", m.Version)
+	}
+	for _, s := range Report(migrations, applied) {
+		if s.Unknown {
+			migrateLog.Warn().Msgf("Migration %s (%s) was applied by a newer gateway; this one may not know its schema", s.Version, s.Name)
+		}
 	}
 
 	return nil
@@ -75,73 +270,31 @@ func (r *Runner) ensureMigrationsTable() error {
 			name TEXT NOT NULL,
 			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT;
 	`
 	_, err := r.db.Exec(query)
 	return err
 }
 
-// loadMigrations reads all SQL files and returns them as migrations
-func (r *Runner) loadMigrations() ([]Migration, error) {
-	entries, err := sqlFiles.ReadDir(".")
-	if err != nil {
-		return nil, err
-	}
-
-	var migrations []Migration
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-
-		content, err := sqlFiles.ReadFile(entry.Name())
-		if err != nil {
-			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
-		}
+// getAppliedMigrations returns the applied migrations by version
+func (r *Runner) getAppliedMigrations() (map[string]Applied, error) {
+	applied := make(map[string]Applied)
 
-		// Extract version from filename (e.g., "001_init_schema.sql" -> "001")
-		name := strings.TrimSuffix(entry.Name(), ".sql")
-		parts := strings.SplitN(name, "_", 2)
-		version := parts[0]
-		migrationName := name
-		if len(parts) > 1 {
-			migrationName = parts[1]
-		}
-
-		migrations = append(migrations, Migration{
-			Version: version,
-			Name:    migrationName,
-			SQL:     string(content),
-		})
-	}
-
-	// Sort by version
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
-	})
-
-	return migrations, nil
-}
-
-// getAppliedMigrations returns a map of already applied migration versions
-func (r *Runner) getAppliedMigrations() (map[string]bool, error) {
-	applied := make(map[string]bool)
-
-	rows, err := r.db.Query("SELECT version FROM schema_migrations")
+	rows, err := r.db.Query("SELECT version, name, COALESCE(checksum, ''), COALESCE(applied_at, 'epoch') FROM schema_migrations")
 	if err != nil {
-		// Table might not exist yet
-		return applied, nil
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var version string
-		if err := rows.Scan(&version); err != nil {
-			continue
+		var a Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
 		}
-		applied[version] = true
+		applied[a.Version] = a
 	}
 
-	return applied, nil
+	return applied, rows.Err()
 }
 
 // applyMigration executes a single migration within a transaction
@@ -152,6 +305,11 @@ func (r *Runner) applyMigration(m Migration) error {
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// Migrations may rewrite large tables, beyond the gateway's statement_timeout
+	if _, err := tx.Exec("SET LOCAL statement_timeout = 0"); err != nil {
+		return err
+	}
+
 	// Execute the migration SQL
 	if _, err := tx.Exec(m.SQL); err != nil {
 		return fmt.Errorf("SQL execution failed: %w", err)
@@ -159,8 +317,8 @@ func (r *Runner) applyMigration(m Migration) error {
 
 	// Record the migration
 	if _, err := tx.Exec(
-		"INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING",
-		m.Version, m.Name,
+		"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3) ON CONFLICT (version) DO NOTHING",
+		m.Version, m.Name, m.Checksum,
 	); err != nil {
 		return fmt.Errorf("failed to record migration: %w", err)
 	}
@@ -178,24 +336,15 @@ func (r *Runner) GetCurrentVersion() (string, error) {
 	return version, err
 }
 
-// ListApplied returns all applied migrations
-func (r *Runner) ListApplied() ([]Migration, error) {
-	rows, err := r.db.Query("SELECT version, name FROM schema_migrations ORDER BY version")
+// Status reports every embedded migration and whether the database applied it
+func (r *Runner) Status() ([]Status, error) {
+	migrations, err := Postgres()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var migrations []Migration
-	for rows.Next() {
-		var m Migration
-		if err := rows.Scan(&m.Version, &m.Name); err != nil {
-			continue
-		}
-		migrations = append(migrations, m)
+	applied, err := r.getAppliedMigrations()
+	if err != nil {
+		return nil, err
 	}
-	return migrations, nil
+	return Report(migrations, applied), nil
 }
-
-// Suppress unused import warning
-var _ = filepath.Base
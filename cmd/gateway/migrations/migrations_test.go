@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for name, load := range map[string]func() ([]Migration, error){"postgres": Postgres, "clickhouse": ClickHouse} {
+		list, err := load()
+		if err != nil || len(list) == 0 {
+			t.Fatalf("%s: %d migrations, %v", name, len(list), err)
+		}
+		for i, m := range list {
+			if i > 0 && list[i-1].Version >= m.Version {
+				t.Errorf("%s: %s after %s", name, m.Version, list[i-1].Version)
+			}
+			if len(m.Checksum) != 64 {
+				t.Errorf("%s %s: checksum %q", name, m.Version, m.Checksum)
+			}
+		}
+	}
+
+	// ClickHouse runs one statement at a time, each idempotent since a failed migration runs again
+	list, _ := ClickHouse()
+	for _, m := range list {
+		for _, stmt := range Statements(m.SQL) {
+			if !strings.Contains(stmt, "IF NOT EXISTS") && !strings.Contains(stmt, "IF EXISTS") {
+				t.Errorf("%s: statement is not idempotent: %s", m.Version, stmt)
+			}
+		}
+	}
+}
+
+func TestLoadRejectsBadNames(t *testing.T) {
+	for name, files := range map[string]fstest.MapFS{
+		"duplicate":  {"m/001_a.sql": {}, "m/001_b.sql": {}},
+		"unnumbered": {"m/init.sql": {}},
+	} {
+		if _, err := load(files, "m"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	list, err := load(fstest.MapFS{"m/016_b.sql": {Data: []byte("B;")}, "m/016_a.sql": {Data: []byte("A;")}}, "m")
+	if err != nil || len(list) != 1 || list[0].Name != "a+b" || list[0].SQL != "A;\nB;" {
+		t.Errorf("shared version: %+v %v", list, err)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	sql := `-- Migration: 002_example.sql
+CREATE TABLE IF NOT EXISTS t (
+    a String -- comment; not the end
+) ENGINE = MergeTree()
+ORDER BY a;
+
+-- Next
+ALTER TABLE t ADD COLUMN IF NOT EXISTS b String DEFAULT ';';
+ALTER TABLE t DROP COLUMN IF EXISTS c`
+	got := Statements(sql)
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS t (\n    a String -- comment; not the end\n) ENGINE = MergeTree()\nORDER BY a",
+		"ALTER TABLE t ADD COLUMN IF NOT EXISTS b String DEFAULT ';'",
+		"ALTER TABLE t DROP COLUMN IF EXISTS c",
+	}
+	if strings.Join(got, "\n|\n") != strings.Join(want, "\n|\n") {
+		t.Errorf("Statements =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	list := []Migration{{Version: "001", Name: "a", Checksum: "x"}, {Version: "002", Name: "b", Checksum: "y"}, {Version: "003", Name: "c"}}
+	applied := map[string]Applied{
+		"001": {Version: "001", Name: "a", Checksum: "x", AppliedAt: at},
+		"002": {Version: "002", Name: "b", Checksum: "old", AppliedAt: at},
+		"004": {Version: "004", Name: "d", AppliedAt: at},
+	}
+	report := Report(list, applied)
+	if len(report) != 4 {
+		t.Fatalf("report %+v", report)
+	}
+	if r := report[0]; !r.Applied || r.Modified || r.AppliedAt == nil {
+		t.Errorf("001: %+v", r)
+	}
+	if r := report[1]; !r.Modified {
+		t.Errorf("002 changed after it was applied: %+v", r)
+	}
+	if r := report[2]; r.Applied {
+		t.Errorf("003 is pending: %+v", r)
+	}
+	if r := report[3]; !r.Unknown || r.Version != "004" {
+		t.Errorf("004 was applied by a newer gateway: %+v", r)
+	}
+}
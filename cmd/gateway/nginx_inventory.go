@@ -0,0 +1,152 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+)
+
+// InventoryCount is how many agents have a module or OpenSSL version
+type InventoryCount struct {
+	Value  string `json:"value"`
+	Agents int    `json:"agents"`
+}
+
+// NginxInventoryAgent is an agent's nginx modules and OpenSSL versions across its binaries
+type NginxInventoryAgent struct {
+	AgentID         string   `json:"agent_id"`
+	Hostname        string   `json:"hostname,omitempty"`
+	Status          string   `json:"status,omitempty"`
+	OpensslVersions []string `json:"openssl_versions"`
+	Modules         []string `json:"modules"`
+}
+
+// NginxInventory is the nginx modules and OpenSSL versions of the fleet. With a module filter,
+// the agents are split by whether one of their binaries has it compiled in or loaded.
+type NginxInventory struct {
+	Total           int                   `json:"total"` // agents that reported their nginx builds
+	Modules         []InventoryCount      `json:"modules"`
+	OpensslVersions []InventoryCount      `json:"openssl_versions"`
+	Module          string                `json:"module,omitempty"`
+	With            []NginxInventoryAgent `json:"with,omitempty"`
+	Without         []NginxInventoryAgent `json:"without,omitempty"`
+}
+
+// recordNginxBuilds keeps the nginx builds of a heartbeat on the session and, when they changed,
+// on the agent record. Heartbeats without builds (older agents) keep the last ones.
+func (s *server) recordNginxBuilds(session *AgentSession, instances []*pb.NginxInstance) {
+	var builds []*pb.NginxBuild
+	for _, inst := range instances {
+		if inst.Build != nil {
+			builds = append(builds, inst.Build)
+		}
+	}
+	if len(builds) == 0 {
+		return
+	}
+	session.mu.Lock()
+	changed := !nginxBuildsEqual(session.nginxBuilds, builds)
+	if changed {
+		session.nginxBuilds = builds
+	}
+	session.mu.Unlock()
+	if !changed || s.db == nil {
+		return
+	}
+	if err := s.db.SaveAgentNginxBuilds(session.id, builds); err != nil {
+		gatewayLog.Error().Msgf("Failed to save nginx builds of agent %s: %v", session.id, err)
+	}
+}
+
+func nginxBuildsEqual(a, b []*pb.NginxBuild) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// inventoryAgent merges the modules and OpenSSL versions of an agent's binaries
+func inventoryAgent(a agentNginxBuilds) NginxInventoryAgent {
+	modules := make(map[string]bool)
+	ssl := make(map[string]bool)
+	for _, b := range a.Builds {
+		for _, m := range b.Modules {
+			modules[m] = true
+		}
+		for _, m := range b.LoadedModules {
+			modules[m] = true
+		}
+		if b.OpensslVersion != "" {
+			ssl[b.OpensslVersion] = true
+		}
+	}
+	return NginxInventoryAgent{
+		AgentID:         a.AgentID,
+		Hostname:        a.Hostname,
+		Status:          a.Status,
+		OpensslVersions: slices.Sorted(maps.Keys(ssl)),
+		Modules:         slices.Sorted(maps.Keys(modules)),
+	}
+}
+
+// hasNginxModule reports whether one of the agent's modules contains name, case-insensitively,
+// so "brotli" matches both ngx_brotli and ngx_http_brotli_filter_module
+func hasNginxModule(a NginxInventoryAgent, name string) bool {
+	name = strings.ToLower(name)
+	for _, m := range a.Modules {
+		if strings.Contains(strings.ToLower(m), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildNginxInventory counts modules and OpenSSL versions over the agents, most common first
+func buildNginxInventory(agents []agentNginxBuilds, module string) NginxInventory {
+	inv := NginxInventory{Total: len(agents), Module: module}
+	modules := make(map[string]int)
+	ssl := make(map[string]int)
+	for _, a := range agents {
+		ia := inventoryAgent(a)
+		for _, m := range ia.Modules {
+			modules[m]++
+		}
+		for _, v := range ia.OpensslVersions {
+			ssl[v]++
+		}
+		if module == "" {
+			continue
+		}
+		if hasNginxModule(ia, module) {
+			inv.With = append(inv.With, ia)
+		} else {
+			inv.Without = append(inv.Without, ia)
+		}
+	}
+	inv.Modules = inventoryCounts(modules)
+	inv.OpensslVersions = inventoryCounts(ssl)
+	return inv
+}
+
+func inventoryCounts(counts map[string]int) []InventoryCount {
+	out := make([]InventoryCount, 0, len(counts))
+	for v, n := range counts {
+		out = append(out, InventoryCount{Value: v, Agents: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Agents != out[j].Agents {
+			return out[i].Agents > out[j].Agents
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
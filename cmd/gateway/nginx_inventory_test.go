@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestBuildNginxInventory(t *testing.T) {
+	agents := []agentNginxBuilds{
+		{AgentID: "a", Builds: []*pb.NginxBuild{{OpensslVersion: "OpenSSL 3.0.11", Modules: []string{"http_ssl_module", "ngx_brotli"}}}},
+		{AgentID: "b", Builds: []*pb.NginxBuild{{OpensslVersion: "OpenSSL 3.0.11", Modules: []string{"http_ssl_module"}, LoadedModules: []string{"ngx_http_brotli_filter_module"}}}},
+		{AgentID: "c", Builds: []*pb.NginxBuild{
+			{OpensslVersion: "OpenSSL 1.1.1w", Modules: []string{"http_ssl_module"}},
+			{OpensslVersion: "OpenSSL 3.0.11", Modules: []string{"http_v2_module"}},
+		}},
+	}
+	inv := buildNginxInventory(agents, "Brotli")
+	if inv.Total != 3 {
+		t.Errorf("total = %d", inv.Total)
+	}
+	if m := inv.Modules[0]; m.Value != "http_ssl_module" || m.Agents != 3 {
+		t.Errorf("most common module = %+v", m)
+	}
+	if v := inv.OpensslVersions[0]; v.Value != "OpenSSL 3.0.11" || v.Agents != 3 {
+		t.Errorf("most common openssl = %+v", v)
+	}
+	var with, without []string
+	for _, a := range inv.With {
+		with = append(with, a.AgentID)
+	}
+	for _, a := range inv.Without {
+		without = append(without, a.AgentID)
+	}
+	if strings.Join(with, ",") != "a,b" || strings.Join(without, ",") != "c" {
+		t.Errorf("with brotli = %v, without = %v", with, without)
+	}
+	if got := strings.Join(inv.Without[0].OpensslVersions, ","); got != "OpenSSL 1.1.1w,OpenSSL 3.0.11" {
+		t.Errorf("openssl versions of c = %s", got)
+	}
+}
+
+func TestRecordNginxBuilds(t *testing.T) {
+	s := &server{}
+	session := &AgentSession{id: "a"}
+	build := &pb.NginxBuild{BinaryPath: "/usr/sbin/nginx", Modules: []string{"http_ssl_module"}}
+	s.recordNginxBuilds(session, []*pb.NginxInstance{{Pid: "1", Build: build}, {Pid: "2"}})
+	if len(session.nginxBuilds) != 1 {
+		t.Fatalf("builds = %v", session.nginxBuilds)
+	}
+	// A heartbeat from an older agent without builds keeps the last ones
+	s.recordNginxBuilds(session, []*pb.NginxInstance{{Pid: "1"}})
+	if len(session.nginxBuilds) != 1 {
+		t.Error("builds dropped by a heartbeat without them")
+	}
+}
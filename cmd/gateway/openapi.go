@@ -0,0 +1,21 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes the REST API. It is generated from the routes registered in
+// createHTTPServer and the handlers behind them; TestOpenAPISpecUpToDate fails when it is stale.
+//
+//go:generate go run ./apispec/gen -o openapi.json
+//go:embed openapi.json
+var openAPISpec []byte
+
+// handleOpenAPI handles GET /api/openapi.json. The spec is public so clients can be generated
+// before they have credentials.
+func (s *server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/apispec"
+)
+
+func TestOpenAPISpecUpToDate(t *testing.T) {
+	spec, err := apispec.Generate(".", apispec.Title, apispec.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(spec, openAPISpec) {
+		t.Error("openapi.json is stale; run go generate ./cmd/gateway and commit the result")
+	}
+}
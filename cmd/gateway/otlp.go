@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// otlpInstanceID names the source of OTLP data in the instance_id columns: the service instance,
+// host or service of its resource
+func otlpInstanceID(resource map[string]string) string {
+	for _, key := range []string{"service.instance.id", "host.name", "service.name"} {
+		if v := resource[key]; v != "" {
+			return v
+		}
+	}
+	return "otlp"
+}
+
+// otlpAttributes flattens OTLP attributes to strings
+func otlpAttributes(attrs []*commonpb.KeyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = anyValueString(kv.Value)
+	}
+	return m
+}
+
+func anyValueString(v *commonpb.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_BytesValue:
+		return hex.EncodeToString(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		parts := make([]string, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			parts = append(parts, anyValueString(item))
+		}
+		return strings.Join(parts, ",")
+	case *commonpb.AnyValue_KvlistValue:
+		return protojson.Format(v.KvlistValue)
+	}
+	return ""
+}
+
+// firstAttr returns the first of keys set in attrs; the HTTP semantic conventions renamed most
+// attributes, so both spellings are accepted
+func firstAttr(attrs map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v := attrs[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// otlpLogEntry maps a log record describing an HTTP request (HTTP semantic conventions, or the
+// attributes the agent's own OTLP exporter sets) to an access log entry. Other records return nil.
+func otlpLogEntry(rec *logspb.LogRecord) *pb.LogEntry {
+	attrs := otlpAttributes(rec.Attributes)
+	method := firstAttr(attrs, "http.request.method", "http.method", "http.request_method")
+	if method == "" {
+		return nil
+	}
+	uri := firstAttr(attrs, "url.path", "http.target", "url.full", "http.url")
+	if q := attrs["url.query"]; q != "" && attrs["url.path"] != "" {
+		uri += "?" + q
+	}
+	status, _ := strconv.Atoi(firstAttr(attrs, "http.response.status_code", "http.status_code"))
+	bytesSent, _ := strconv.ParseInt(firstAttr(attrs, "http.response.body.size", "http.response_content_length"), 10, 64)
+	bytesReceived, _ := strconv.ParseInt(firstAttr(attrs, "http.request.body.size", "http.request_content_length"), 10, 64)
+	duration, _ := strconv.ParseFloat(firstAttr(attrs, "http.server.request.duration"), 64) // seconds
+
+	ts := rec.TimeUnixNano
+	if ts == 0 {
+		ts = rec.ObservedTimeUnixNano
+	}
+	timestamp := time.Now().Unix()
+	if ts > 0 {
+		timestamp = int64(ts / uint64(time.Second))
+	}
+
+	labels := map[string]string{}
+	for key, val := range attrs {
+		if strings.HasPrefix(key, "http.") || strings.HasPrefix(key, "url.") || strings.HasPrefix(key, "client.") ||
+			strings.HasPrefix(key, "user_agent.") || key == "log.type" {
+			continue
+		}
+		labels[key] = val
+	}
+
+	return &pb.LogEntry{
+		Timestamp:     timestamp,
+		LogType:       "access",
+		Content:       anyValueString(rec.Body),
+		RemoteAddr:    firstAttr(attrs, "client.address", "http.client_ip", "net.sock.peer.addr", "net.peer.ip"),
+		RequestMethod: method,
+		RequestUri:    uri,
+		Status:        int32(status),
+		BodyBytesSent: bytesSent,
+		RequestLength: bytesReceived,
+		RequestTime:   float32(duration),
+		RequestId:     firstAttr(attrs, "http.request.header.x-request-id"),
+		Referer:       firstAttr(attrs, "http.request.header.referer"),
+		UserAgent:     firstAttr(attrs, "user_agent.original", "http.user_agent"),
+		XForwardedFor: firstAttr(attrs, "http.request.header.x-forwarded-for"),
+		Labels:        labels,
+	}
+}
+
+// ingestOTLPLogs stores the HTTP request logs of an export and returns how many records were
+// rejected for not describing one
+func (s *server) ingestOTLPLogs(req *collogspb.ExportLogsServiceRequest) (rejected int64) {
+	for _, rl := range req.ResourceLogs {
+		instanceID := otlpInstanceID(otlpAttributes(rl.GetResource().GetAttributes()))
+		for _, sl := range rl.ScopeLogs {
+			for _, rec := range sl.LogRecords {
+				entry := otlpLogEntry(rec)
+				if entry == nil {
+					rejected++
+					continue
+				}
+				if err := s.clickhouse.InsertAccessLog(entry, instanceID); err != nil {
+					rejected++
+					continue
+				}
+				if s.logExport != nil {
+					s.logExport.Export(instanceID, entry)
+				}
+				if s.attackDetector != nil {
+					if events := s.attackDetector.Inspect(instanceID, entry); len(events) > 0 {
+						s.clickhouse.InsertSecurityEvents(events)
+					}
+				}
+			}
+		}
+	}
+	return rejected
+}
+
+// otlpNumber returns the value of a number data point
+func otlpNumber(dp *metricspb.NumberDataPoint) float64 {
+	if v, ok := dp.Value.(*metricspb.NumberDataPoint_AsInt); ok {
+		return float64(v.AsInt)
+	}
+	return dp.GetAsDouble()
+}
+
+// otlpGenericMetrics maps an OTLP metric to custom metric samples: gauges and sums one per data
+// point, histograms and summaries as their _count and _sum
+func otlpGenericMetrics(source string, m *metricspb.Metric, resourceLabels map[string]string) []*pb.GenericMetric {
+	var out []*pb.GenericMetric
+	add := func(name, typ string, value float64, attrs []*commonpb.KeyValue, tsNano uint64) {
+		labels := otlpAttributes(attrs)
+		for k, v := range resourceLabels {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+		out = append(out, &pb.GenericMetric{
+			Source:      source,
+			Name:        name,
+			Value:       value,
+			Labels:      labels,
+			TimestampMs: int64(tsNano / uint64(time.Millisecond)),
+			Type:        typ,
+		})
+	}
+	switch data := m.Data.(type) {
+	case *metricspb.Metric_Gauge:
+		for _, dp := range data.Gauge.DataPoints {
+			add(m.Name, "gauge", otlpNumber(dp), dp.Attributes, dp.TimeUnixNano)
+		}
+	case *metricspb.Metric_Sum:
+		typ := "gauge"
+		if data.Sum.IsMonotonic {
+			typ = "counter"
+		}
+		for _, dp := range data.Sum.DataPoints {
+			add(m.Name, typ, otlpNumber(dp), dp.Attributes, dp.TimeUnixNano)
+		}
+	case *metricspb.Metric_Histogram:
+		for _, dp := range data.Histogram.DataPoints {
+			add(m.Name+"_count", "histogram", float64(dp.Count), dp.Attributes, dp.TimeUnixNano)
+			add(m.Name+"_sum", "histogram", dp.GetSum(), dp.Attributes, dp.TimeUnixNano)
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		for _, dp := range data.ExponentialHistogram.DataPoints {
+			add(m.Name+"_count", "histogram", float64(dp.Count), dp.Attributes, dp.TimeUnixNano)
+			add(m.Name+"_sum", "histogram", dp.GetSum(), dp.Attributes, dp.TimeUnixNano)
+		}
+	case *metricspb.Metric_Summary:
+		for _, dp := range data.Summary.DataPoints {
+			add(m.Name+"_count", "summary", float64(dp.Count), dp.Attributes, dp.TimeUnixNano)
+			add(m.Name+"_sum", "summary", dp.Sum, dp.Attributes, dp.TimeUnixNano)
+		}
+	}
+	return out
+}
+
+// ingestOTLPMetrics stores the metrics of an export with the custom collector samples, the
+// instrumentation scope (or "otlp") as their source
+func (s *server) ingestOTLPMetrics(req *colmetricspb.ExportMetricsServiceRequest) {
+	now := time.Now()
+	for _, rm := range req.ResourceMetrics {
+		resource := otlpAttributes(rm.GetResource().GetAttributes())
+		instanceID := otlpInstanceID(resource)
+		resourceLabels := map[string]string{}
+		if name := resource["service.name"]; name != "" {
+			resourceLabels["service.name"] = name
+		}
+		for _, sm := range rm.ScopeMetrics {
+			source := sm.GetScope().GetName()
+			if source == "" {
+				source = "otlp"
+			}
+			var samples []*pb.GenericMetric
+			for _, m := range sm.Metrics {
+				samples = append(samples, otlpGenericMetrics(source, m, resourceLabels)...)
+			}
+			s.clickhouse.InsertGenericMetrics(samples, instanceID, now)
+		}
+	}
+}
+
+// ingestOTLPTraces stores the spans of an export; span attributes include the service name
+func (s *server) ingestOTLPTraces(req *coltracepb.ExportTraceServiceRequest) (rejected int64) {
+	for _, rs := range req.ResourceSpans {
+		resource := otlpAttributes(rs.GetResource().GetAttributes())
+		instanceID := otlpInstanceID(resource)
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				attrs := otlpAttributes(span.Attributes)
+				if name := resource["service.name"]; name != "" {
+					attrs["service.name"] = name
+				}
+				if msg := span.GetStatus().GetMessage(); msg != "" {
+					attrs["status.message"] = msg
+				}
+				if span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+					attrs["status.code"] = "error"
+				}
+				item := spanBatchItem{
+					traceID: hex.EncodeToString(span.TraceId),
+					spanID:  hex.EncodeToString(span.SpanId),
+					parent:  hex.EncodeToString(span.ParentSpanId),
+					name:    span.Name,
+					start:   time.Unix(0, int64(span.StartTimeUnixNano)),
+					end:     time.Unix(0, int64(span.EndTimeUnixNano)),
+					attrs:   attrs,
+					agentID: instanceID,
+				}
+				if !s.clickhouse.queueSpan(item) {
+					rejected++
+				}
+			}
+		}
+	}
+	return rejected
+}
+
+// otlpTokenValid checks the bearer token a collector sent against otlp.auth_token
+func (s *server) otlpTokenValid(authorization string) bool {
+	want := s.config.OTLP.AuthToken
+	if want == "" {
+		return true
+	}
+	got := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// otlpJSONIDs rewrites the hex trace and span IDs of OTLP/JSON to the base64 protojson expects
+func otlpJSONIDs(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	var walk func(v interface{}) error
+	walk = func(v interface{}) error {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, val := range v {
+				if id, ok := val.(string); ok && (key == "traceId" || key == "spanId" || key == "parentSpanId") {
+					raw, err := hex.DecodeString(id)
+					if err != nil {
+						return fmt.Errorf("invalid %s %q", key, id)
+					}
+					v[key] = base64.StdEncoding.EncodeToString(raw)
+				} else if err := walk(val); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// handleOTLP serves OTLP/HTTP (POST /v1/logs, /v1/metrics, /v1/traces) in its binary protobuf and
+// JSON encodings; the response uses the request's encoding
+func (s *server) handleOTLP(w http.ResponseWriter, r *http.Request) {
+	if !s.otlpTokenValid(r.Header.Get("Authorization")) {
+		http.Error(w, `{"error":"invalid OTLP token"}`, http.StatusUnauthorized)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, `{"error":"invalid gzip body"}`, http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read body"}`, http.StatusBadRequest)
+		return
+	}
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	unmarshal := proto.Unmarshal
+	if isJSON {
+		if data, err = otlpJSONIDs(data); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+			return
+		}
+		unmarshal = protojson.Unmarshal
+	}
+
+	var resp proto.Message
+	switch r.URL.Path {
+	case "/v1/logs":
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err = unmarshal(data, req); err == nil {
+			res := &collogspb.ExportLogsServiceResponse{}
+			if rejected := s.ingestOTLPLogs(req); rejected > 0 {
+				res.PartialSuccess = &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: rejected, ErrorMessage: "only HTTP request logs are stored"}
+			}
+			resp = res
+		}
+	case "/v1/metrics":
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		if err = unmarshal(data, req); err == nil {
+			s.ingestOTLPMetrics(req)
+			resp = &colmetricspb.ExportMetricsServiceResponse{}
+		}
+	case "/v1/traces":
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err = unmarshal(data, req); err == nil {
+			res := &coltracepb.ExportTraceServiceResponse{}
+			if rejected := s.ingestOTLPTraces(req); rejected > 0 {
+				res.PartialSuccess = &coltracepb.ExportTracePartialSuccess{RejectedSpans: rejected, ErrorMessage: "span queue full"}
+			}
+			resp = res
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+
+	var out []byte
+	if isJSON {
+		out, _ = protojson.Marshal(resp)
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		out, _ = proto.Marshal(resp)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}
+	w.Write(out)
+}
+
+type otlpLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	srv *server
+}
+
+func (o otlpLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	res := &collogspb.ExportLogsServiceResponse{}
+	if rejected := o.srv.ingestOTLPLogs(req); rejected > 0 {
+		res.PartialSuccess = &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: rejected, ErrorMessage: "only HTTP request logs are stored"}
+	}
+	return res, nil
+}
+
+type otlpMetricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	srv *server
+}
+
+func (o otlpMetricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	o.srv.ingestOTLPMetrics(req)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+type otlpTraceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	srv *server
+}
+
+func (o otlpTraceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	res := &coltracepb.ExportTraceServiceResponse{}
+	if rejected := o.srv.ingestOTLPTraces(req); rejected > 0 {
+		res.PartialSuccess = &coltracepb.ExportTracePartialSuccess{RejectedSpans: rejected, ErrorMessage: "span queue full"}
+	}
+	return res, nil
+}
+
+// otlpAuthInterceptor rejects OTLP/gRPC exports without the configured bearer token, and all of them
+// while ClickHouse is unavailable
+func (s *server) otlpAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var authorization string
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	if !s.otlpTokenValid(authorization) {
+		return nil, status.Error(codes.Unauthenticated, "invalid OTLP token")
+	}
+	if s.clickhouse == nil {
+		return nil, status.Error(codes.Unavailable, "ClickHouse connection not available")
+	}
+	return handler(ctx, req)
+}
+
+// startOTLPReceiver serves OTLP/gRPC on otlp.grpc_port; the caller stops the returned server
+func (s *server) startOTLPReceiver(creds credentials.TransportCredentials) (*grpc.Server, error) {
+	address := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.OTLP.GRPCPort)
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(16 * 1024 * 1024),
+		grpc.UnaryInterceptor(s.otlpAuthInterceptor),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	gs := grpc.NewServer(opts...)
+	collogspb.RegisterLogsServiceServer(gs, otlpLogsService{srv: s})
+	colmetricspb.RegisterMetricsServiceServer(gs, otlpMetricsService{srv: s})
+	coltracepb.RegisterTraceServiceServer(gs, otlpTraceService{srv: s})
+	go func() {
+		if err := gs.Serve(lis); err != nil {
+			ingestLog.Error().Msgf("OTLP gRPC server error: %v", err)
+		}
+	}()
+	if s.config.OTLP.AuthToken == "" {
+		ingestLog.Warn().Msgf("OTLP receiver on %s accepts data without a token; set otlp.auth_token", address)
+	}
+	ingestLog.Info().Msgf("OTLP receiver listening on %s (gRPC) and /v1/{logs,metrics,traces} (HTTP)", address)
+	return gs, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func strAttr(key, val string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: val}}}
+}
+
+func intAttr(key string, val int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: val}}}
+}
+
+func TestOTLPLogEntry(t *testing.T) {
+	entry := otlpLogEntry(&logspb.LogRecord{
+		TimeUnixNano: 1791000000123456789,
+		Body:         &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "GET /cart 200"}},
+		Attributes: []*commonpb.KeyValue{
+			strAttr("http.request.method", "GET"),
+			strAttr("url.path", "/cart"),
+			strAttr("url.query", "id=7"),
+			intAttr("http.response.status_code", 200),
+			strAttr("client.address", "198.51.100.7"),
+			strAttr("user_agent.original", "curl/8.4.0"),
+			strAttr("deployment.environment", "prod"),
+		},
+	})
+	if entry == nil {
+		t.Fatal("HTTP log record was rejected")
+	}
+	if entry.RequestMethod != "GET" || entry.RequestUri != "/cart?id=7" || entry.Status != 200 || entry.RemoteAddr != "198.51.100.7" || entry.UserAgent != "curl/8.4.0" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Timestamp != 1791000000 || entry.Content != "GET /cart 200" || len(entry.Labels) != 1 || entry.Labels["deployment.environment"] != "prod" {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	// The attributes the agent's own OTLP exporter sets
+	legacy := otlpLogEntry(&logspb.LogRecord{Attributes: []*commonpb.KeyValue{
+		strAttr("http.request_method", "POST"), strAttr("http.target", "/login"), intAttr("http.status_code", 401), strAttr("http.client_ip", "10.0.0.1"),
+	}})
+	if legacy == nil || legacy.RequestMethod != "POST" || legacy.RequestUri != "/login" || legacy.Status != 401 || legacy.RemoteAddr != "10.0.0.1" {
+		t.Errorf("legacy = %+v", legacy)
+	}
+
+	if otlpLogEntry(&logspb.LogRecord{Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "worker started"}}}) != nil {
+		t.Error("records that are not HTTP requests should be rejected")
+	}
+}
+
+func TestOTLPGenericMetrics(t *testing.T) {
+	resource := map[string]string{"service.name": "edge-proxy"}
+	sum := &metricspb.Metric{Name: "requests_total", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+		IsMonotonic: true,
+		DataPoints: []*metricspb.NumberDataPoint{
+			{Attributes: []*commonpb.KeyValue{strAttr("route", "/api")}, TimeUnixNano: 1791000000000000000, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 42}},
+		},
+	}}}
+	samples := otlpGenericMetrics("envoy", sum, resource)
+	if len(samples) != 1 || samples[0].Type != "counter" || samples[0].Value != 42 || samples[0].TimestampMs != 1791000000000 {
+		t.Fatalf("sum = %v", samples)
+	}
+	if samples[0].Labels["route"] != "/api" || samples[0].Labels["service.name"] != "edge-proxy" || samples[0].Source != "envoy" {
+		t.Errorf("labels = %v", samples[0].Labels)
+	}
+
+	sumValue := 1.5
+	hist := &metricspb.Metric{Name: "latency", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+		DataPoints: []*metricspb.HistogramDataPoint{{Count: 3, Sum: &sumValue}},
+	}}}
+	samples = otlpGenericMetrics("envoy", hist, nil)
+	if len(samples) != 2 || samples[0].Name != "latency_count" || samples[0].Value != 3 || samples[1].Name != "latency_sum" || samples[1].Value != 1.5 {
+		t.Errorf("histogram = %v", samples)
+	}
+}
+
+func TestHandleOTLPAuth(t *testing.T) {
+	s := &server{config: &config.Config{OTLP: config.OTLPConfig{Enabled: true, AuthToken: "s3cret"}}}
+	for _, tc := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusServiceUnavailable}, // authenticated, but no ClickHouse
+	} {
+		req := httptest.NewRequest("POST", "/v1/logs", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		s.handleOTLP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("Authorization %q: status = %d, want %d", tc.auth, rec.Code, tc.want)
+		}
+	}
+
+	s.config.OTLP.AuthToken = ""
+	if !s.otlpTokenValid("") {
+		t.Error("without a configured token any sender is accepted")
+	}
+}
+
+func TestHandleOTLPIngest(t *testing.T) {
+	s := &server{
+		config:     &config.Config{OTLP: config.OTLPConfig{Enabled: true}},
+		clickhouse: &ClickHouseDB{logChan: make(chan logBatchItem, 10), spanChan: make(chan spanBatchItem, 10), genChan: make(chan genericMetricItem, 10)},
+	}
+	body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"host.name","value":{"stringValue":"web-7"}}]},
+		"scopeLogs":[{"logRecords":[
+			{"attributes":[{"key":"http.request.method","value":{"stringValue":"GET"}},{"key":"url.path","value":{"stringValue":"/"}}]},
+			{"body":{"stringValue":"not a request"}}
+		]}]}]}`
+	req := httptest.NewRequest("POST", "/v1/logs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.handleOTLP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"rejectedLogRecords":"1"`) {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if item := <-s.clickhouse.logChan; item.agentID != "web-7" || item.entry.RequestUri != "/" {
+		t.Errorf("queued = %s %+v", item.agentID, item.entry)
+	}
+
+	traces := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"checkout"}}]},
+		"scopeSpans":[{"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"GET /pay",
+		"startTimeUnixNano":"1791000000000000000","endTimeUnixNano":"1791000000250000000"}]}]}]}`
+	req = httptest.NewRequest("POST", "/v1/traces", strings.NewReader(traces))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	s.handleOTLP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("traces: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	span := <-s.clickhouse.spanChan
+	if span.traceID != "5b8efff798038103d269b633813fc60c" || span.agentID != "checkout" || span.end.Sub(span.start).Milliseconds() != 250 || span.attrs["service.name"] != "checkout" {
+		t.Errorf("span = %+v", span)
+	}
+}
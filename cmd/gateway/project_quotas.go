@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+// Project quotas keep a noisy tenant from filling the shared ClickHouse cluster. Each gateway counts
+// the access log bytes of every project as it ingests them and flushes its counts to PostgreSQL,
+// which sums them over the gateways; enforcement reads the sum as of the last flush plus what the
+// gateway counted since. Live tails and the Kafka export are not limited.
+
+const (
+	quotaLevelSoft = "soft"
+	quotaLevelHard = "hard"
+
+	quotaActionSample = "sample"
+	quotaActionDrop   = "drop"
+
+	defaultQuotaSamplePercent = 10
+
+	projectUsageFlushInterval = 10 * time.Second
+	projectQuotaCacheTTL      = time.Minute
+	projectUsageRetention     = 400 * 24 * time.Hour
+)
+
+var avikaProjectIngestBytesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "avika_project_ingest_bytes_total",
+		Help: "Access log bytes ingested per project, by whether they were stored in ClickHouse or dropped by its quota",
+	},
+	[]string{"project_id", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(avikaProjectIngestBytesTotal)
+}
+
+// validate checks a quota and fills in the hard limit action and its sample rate
+func (q *ProjectQuota) validate() error {
+	if q.SoftBytesPerDay < 0 || q.HardBytesPerDay < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if q.SoftBytesPerDay > 0 && q.HardBytesPerDay > 0 && q.SoftBytesPerDay > q.HardBytesPerDay {
+		return fmt.Errorf("soft_bytes_per_day must not exceed hard_bytes_per_day")
+	}
+	switch q.HardAction {
+	case "":
+		q.HardAction = quotaActionSample
+	case quotaActionSample, quotaActionDrop:
+	default:
+		return fmt.Errorf("hard_action must be %s or %s", quotaActionSample, quotaActionDrop)
+	}
+	if q.HardAction == quotaActionSample && q.SamplePercent == 0 {
+		q.SamplePercent = defaultQuotaSamplePercent
+	}
+	if q.SamplePercent < 0 || q.SamplePercent > 100 {
+		return fmt.Errorf("sample_percent must be from 0 to 100")
+	}
+	return nil
+}
+
+// admit decides whether an entry of size bytes is stored for a project that stored used bytes today;
+// roll is uniform in [0, 1). level is the limit the entry takes the project over, "" for none. A nil
+// quota admits everything.
+func (q *ProjectQuota) admit(used, size int64, roll float64) (store bool, level string) {
+	if q == nil {
+		return true, ""
+	}
+	if q.HardBytesPerDay > 0 && used+size > q.HardBytesPerDay {
+		return q.HardAction == quotaActionSample && roll*100 < q.SamplePercent, quotaLevelHard
+	}
+	if q.SoftBytesPerDay > 0 && used+size > q.SoftBytesPerDay {
+		return true, quotaLevelSoft
+	}
+	return true, ""
+}
+
+// usageDay is the UTC day usage at t counts toward
+func usageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+type projectDay struct {
+	projectID string
+	day       time.Time
+}
+
+// quotaEnforcer counts and limits the access logs each project stores
+type quotaEnforcer struct {
+	tenants *tenantCache
+	quotas  func() (map[string]ProjectQuota, error)
+	flushed func(projectID string, day time.Time, d projectUsageDelta) (int64, error)
+	notify  func(projectID, level string, day time.Time, used int64, q ProjectQuota)
+	now     func() time.Time
+	roll    func() float64
+
+	mu            sync.Mutex
+	day           time.Time
+	quotaCache    map[string]ProjectQuota
+	quotasExpire  time.Time
+	used          map[string]int64 // bytes stored today, over every gateway as of the last flush
+	pending       map[projectDay]*projectUsageDelta
+	notifiedToday map[string]bool // project + level
+}
+
+func newQuotaEnforcer(tenants func() (map[string]agentTenant, error), quotas func() (map[string]ProjectQuota, error),
+	flushed func(string, time.Time, projectUsageDelta) (int64, error), notify func(string, string, time.Time, int64, ProjectQuota)) *quotaEnforcer {
+	return &quotaEnforcer{
+		tenants:       &tenantCache{load: tenants},
+		quotas:        quotas,
+		flushed:       flushed,
+		notify:        notify,
+		now:           time.Now,
+		roll:          rand.Float64,
+		used:          map[string]int64{},
+		pending:       map[projectDay]*projectUsageDelta{},
+		notifiedToday: map[string]bool{},
+	}
+}
+
+// quotaOf returns the quota of a project, nil when it has none; qe.mu is held
+func (qe *quotaEnforcer) quotaOf(projectID string, now time.Time) *ProjectQuota {
+	if now.After(qe.quotasExpire) {
+		if quotas, err := qe.quotas(); err != nil {
+			gatewayLog.Error().Msgf("Loading project quotas failed, keeping the previous ones: %v", err)
+		} else {
+			qe.quotaCache = quotas
+		}
+		qe.quotasExpire = now.Add(projectQuotaCacheTTL)
+	}
+	if q, ok := qe.quotaCache[projectID]; ok {
+		return &q
+	}
+	return nil
+}
+
+// Invalidate makes the next entry reload the quotas, after one changed
+func (qe *quotaEnforcer) Invalidate() {
+	qe.mu.Lock()
+	qe.quotasExpire = time.Time{}
+	qe.mu.Unlock()
+}
+
+// Admit counts an access log entry of an agent toward its project and reports whether it is stored.
+// Entries of agents outside any project are always stored.
+func (qe *quotaEnforcer) Admit(agentID string, entry *pb.LogEntry) bool {
+	projectID := qe.tenants.get(agentID).ProjectID
+	if projectID == "" {
+		return true
+	}
+	size := int64(proto.Size(entry))
+	now := qe.now()
+
+	qe.mu.Lock()
+	if day := usageDay(now); !day.Equal(qe.day) {
+		qe.day, qe.used, qe.notifiedToday = day, map[string]int64{}, map[string]bool{}
+	}
+	q := qe.quotaOf(projectID, now)
+	store, level := q.admit(qe.used[projectID], size, qe.roll())
+	key := projectDay{projectID, qe.day}
+	d := qe.pending[key]
+	if d == nil {
+		d = &projectUsageDelta{}
+		qe.pending[key] = d
+	}
+	if store {
+		qe.used[projectID] += size
+		d.storedBytes += size
+		d.storedEntries++
+	} else {
+		d.droppedBytes += size
+		d.droppedEntries++
+	}
+	notify := level != "" && !qe.notifiedToday[projectID+level]
+	if notify {
+		qe.notifiedToday[projectID+level] = true
+	}
+	used, day := qe.used[projectID], qe.day
+	qe.mu.Unlock()
+
+	if store {
+		avikaProjectIngestBytesTotal.WithLabelValues(projectID, "stored").Add(float64(size))
+	} else {
+		avikaProjectIngestBytesTotal.WithLabelValues(projectID, "dropped").Add(float64(size))
+	}
+	if notify && qe.notify != nil {
+		go qe.notify(projectID, level, day, used, *q)
+	}
+	return store
+}
+
+// Flush writes the usage counted since the last flush and takes each project's total over every
+// gateway as its usage today. Usage that fails to write is kept for the next flush.
+func (qe *quotaEnforcer) Flush() {
+	qe.mu.Lock()
+	pending := qe.pending
+	qe.pending = map[projectDay]*projectUsageDelta{}
+	qe.mu.Unlock()
+
+	for key, d := range pending {
+		total, err := qe.flushed(key.projectID, key.day, *d)
+		qe.mu.Lock()
+		if err != nil {
+			if cur := qe.pending[key]; cur != nil {
+				cur.storedBytes += d.storedBytes
+				cur.storedEntries += d.storedEntries
+				cur.droppedBytes += d.droppedBytes
+				cur.droppedEntries += d.droppedEntries
+			} else {
+				qe.pending[key] = d
+			}
+		} else if key.day.Equal(qe.day) {
+			// Entries admitted while the flush ran are not in the total yet
+			if cur := qe.pending[key]; cur != nil {
+				total += cur.storedBytes
+			}
+			qe.used[key.projectID] = total
+		}
+		qe.mu.Unlock()
+		if err != nil {
+			gatewayLog.Error().Msgf("Failed to record ingest usage of project %s: %v", key.projectID, err)
+		}
+	}
+}
+
+// notifyProjectQuota publishes the quota.soft_exceeded or quota.hard_exceeded event of a project's
+// day, once over every gateway
+func (s *server) notifyProjectQuota(projectID, level string, day time.Time, used int64, q ProjectQuota) {
+	first, err := s.db.MarkProjectQuotaNotified(projectID, day, level)
+	if err != nil {
+		gatewayLog.Error().Msgf("Failed to record %s quota notification of project %s: %v", level, projectID, err)
+		return
+	}
+	if !first {
+		return
+	}
+	name := projectID
+	if p, err := s.db.GetProject(projectID); err == nil && p != nil {
+		name = p.Name
+	}
+	ev := SystemEvent{
+		Type:     "quota.soft_exceeded",
+		Severity: "warning",
+		Message:  fmt.Sprintf("Project %s passed its soft ingest quota of %d bytes today", name, q.SoftBytesPerDay),
+		Data: map[string]interface{}{
+			"project_id":         projectID,
+			"day":                day.Format("2006-01-02"),
+			"stored_bytes":       used,
+			"soft_bytes_per_day": q.SoftBytesPerDay,
+			"hard_bytes_per_day": q.HardBytesPerDay,
+		},
+	}
+	if level == quotaLevelHard {
+		ev.Type, ev.Severity = "quota.hard_exceeded", "critical"
+		ev.Data["hard_action"] = q.HardAction
+		if q.HardAction == quotaActionSample {
+			ev.Data["sample_percent"] = q.SamplePercent
+			ev.Message = fmt.Sprintf("Project %s reached its hard ingest quota of %d bytes today; storing %g%% of its access logs until midnight UTC",
+				name, q.HardBytesPerDay, q.SamplePercent)
+		} else {
+			ev.Message = fmt.Sprintf("Project %s reached its hard ingest quota of %d bytes today; dropping its access logs until midnight UTC",
+				name, q.HardBytesPerDay)
+		}
+	}
+	s.publishEvent(ev)
+}
+
+// startProjectQuotas flushes ingest usage every projectUsageFlushInterval and, on the leader,
+// prunes usage older than projectUsageRetention
+func (s *server) startProjectQuotas() {
+	if s.ingestQuotas == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(projectUsageFlushInterval)
+		defer ticker.Stop()
+		var pruned time.Time
+		for now := range ticker.C {
+			s.ingestQuotas.Flush()
+			if now.Sub(pruned) < time.Hour || !s.isLeader() {
+				continue
+			}
+			pruned = now
+			if n, err := s.db.PruneProjectIngestUsage(usageDay(now.Add(-projectUsageRetention))); err != nil {
+				gatewayLog.Error().Msgf("Failed to prune project ingest usage: %v", err)
+			} else if n > 0 {
+				gatewayLog.Info().Msgf("Pruned %d days of project ingest usage", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProjectQuotaValidate(t *testing.T) {
+	q := ProjectQuota{SoftBytesPerDay: 100, HardBytesPerDay: 200}
+	if err := q.validate(); err != nil || q.HardAction != quotaActionSample || q.SamplePercent != defaultQuotaSamplePercent {
+		t.Errorf("defaults: %+v, %v", q, err)
+	}
+	drop := ProjectQuota{HardBytesPerDay: 200, HardAction: quotaActionDrop}
+	if err := drop.validate(); err != nil || drop.SamplePercent != 0 {
+		t.Errorf("drop: %+v, %v", drop, err)
+	}
+	for _, bad := range []ProjectQuota{
+		{SoftBytesPerDay: -1},
+		{SoftBytesPerDay: 300, HardBytesPerDay: 200},
+		{HardBytesPerDay: 200, HardAction: "throttle"},
+		{HardBytesPerDay: 200, SamplePercent: 150},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("%+v: expected an error", bad)
+		}
+	}
+}
+
+func TestProjectQuotaAdmit(t *testing.T) {
+	q := &ProjectQuota{SoftBytesPerDay: 100, HardBytesPerDay: 200, HardAction: quotaActionSample, SamplePercent: 10}
+	tests := []struct {
+		used  int64
+		roll  float64
+		store bool
+		level string
+	}{
+		{used: 0, store: true},
+		{used: 95, store: true, level: quotaLevelSoft},
+		{used: 195, roll: 0.05, store: true, level: quotaLevelHard},
+		{used: 195, roll: 0.5, store: false, level: quotaLevelHard},
+	}
+	for _, tt := range tests {
+		if store, level := q.admit(tt.used, 10, tt.roll); store != tt.store || level != tt.level {
+			t.Errorf("used %d roll %g: store %v level %q, want %v %q", tt.used, tt.roll, store, level, tt.store, tt.level)
+		}
+	}
+	q.HardAction = quotaActionDrop
+	if store, _ := q.admit(195, 10, 0); store {
+		t.Error("drop stores nothing past the hard limit")
+	}
+	var none *ProjectQuota
+	if store, level := none.admit(1<<40, 10, 0.9); !store || level != "" {
+		t.Error("no quota admits everything")
+	}
+}
+
+func TestQuotaEnforcer(t *testing.T) {
+	entry := &pb.LogEntry{RequestUri: "/checkout", Status: 200}
+	size := int64(proto.Size(entry))
+	otherGateways := int64(0)
+	var flushed []projectUsageDelta
+	flushErr := error(nil)
+	var mu sync.Mutex
+	notified := map[string]int{}
+
+	qe := newQuotaEnforcer(
+		func() (map[string]agentTenant, error) {
+			return map[string]agentTenant{"a1": {ProjectID: "p1", EnvironmentID: "e1"}}, nil
+		},
+		func() (map[string]ProjectQuota, error) {
+			return map[string]ProjectQuota{"p1": {ProjectID: "p1", SoftBytesPerDay: 2 * size, HardBytesPerDay: 4 * size, HardAction: quotaActionDrop}}, nil
+		},
+		func(projectID string, day time.Time, d projectUsageDelta) (int64, error) {
+			if flushErr != nil {
+				return 0, flushErr
+			}
+			flushed = append(flushed, d)
+			return d.storedBytes + otherGateways, nil
+		},
+		func(projectID, level string, day time.Time, used int64, q ProjectQuota) {
+			mu.Lock()
+			notified[projectID+":"+level]++
+			mu.Unlock()
+		},
+	)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	qe.now = func() time.Time { return now }
+
+	if !qe.Admit("unassigned", entry) {
+		t.Fatal("agents outside a project are not limited")
+	}
+	stored := 0
+	for i := 0; i < 6; i++ {
+		if qe.Admit("a1", entry) {
+			stored++
+		}
+	}
+	if stored != 4 {
+		t.Errorf("stored %d entries, want 4 under the hard limit", stored)
+	}
+
+	// A failed flush keeps the counts for the next one
+	flushErr = errors.New("database down")
+	qe.Flush()
+	flushErr = nil
+	qe.Flush()
+	if len(flushed) != 1 || flushed[0].storedEntries != 4 || flushed[0].droppedEntries != 2 {
+		t.Errorf("flushed %+v", flushed)
+	}
+
+	// The next day starts from nothing, however much other gateways stored the day before
+	otherGateways = 10 * size
+	now = now.Add(24 * time.Hour)
+	if !qe.Admit("a1", entry) {
+		t.Error("a new day starts under the limit")
+	}
+	// After a flush the project's usage includes what the other gateways stored
+	qe.Flush()
+	if qe.Admit("a1", entry) {
+		t.Error("usage over every gateway is past the hard limit")
+	}
+
+	// Notifications go out in the background
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		mu.Lock()
+		done := notified["p1:hard"] == 2
+		mu.Unlock()
+		if done {
+			break
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if notified["p1:soft"] != 1 || notified["p1:hard"] != 2 {
+		t.Errorf("notified %v, want soft and hard once on the first day and hard on the second", notified)
+	}
+}
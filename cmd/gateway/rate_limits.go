@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// rateLimitZonePrefix namespaces managed zones so they cannot clash with hand-written ones
+const rateLimitZonePrefix = "avika_"
+
+// rateLimitKeys maps a rule key type to the nginx variable used as the limit_req_zone key
+var rateLimitKeys = map[string]string{
+	"ip":     "$binary_remote_addr",
+	"ip_uri": "$binary_remote_addr$uri",
+	"server": "$server_name",
+}
+
+var (
+	rateLimitZonePattern = regexp.MustCompile(`^[a-z0-9_]{1,48}$`)
+	rateLimitPathPattern = regexp.MustCompile(`^/[^\s;{}"'\\]*$`)
+)
+
+// validateRateLimitRule fills defaults and rejects values that would render an invalid nginx config
+func validateRateLimitRule(r *RateLimitRule) error {
+	r.Zone = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(r.Zone)), rateLimitZonePrefix)
+	if r.Name == "" {
+		r.Name = r.Zone
+	}
+	if r.KeyType == "" {
+		r.KeyType = "ip"
+	}
+	if r.RateUnit == "" {
+		r.RateUnit = "r/s"
+	}
+	if r.ZoneSizeMB == 0 {
+		r.ZoneSizeMB = 10
+	}
+
+	if !rateLimitZonePattern.MatchString(r.Zone) {
+		return fmt.Errorf("zone must be 1-48 characters of a-z, 0-9 and _")
+	}
+	if _, ok := rateLimitKeys[r.KeyType]; !ok {
+		return fmt.Errorf("key_type must be one of ip, ip_uri, server")
+	}
+	if r.PathPrefix != "" && !rateLimitPathPattern.MatchString(r.PathPrefix) {
+		return fmt.Errorf("path_prefix must start with / and contain no whitespace, quotes, braces or semicolons")
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if r.RateUnit != "r/s" && r.RateUnit != "r/m" {
+		return fmt.Errorf("rate_unit must be r/s or r/m")
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if r.ZoneSizeMB < 1 || r.ZoneSizeMB > 1024 {
+		return fmt.Errorf("zone_size_mb must be between 1 and 1024")
+	}
+	return nil
+}
+
+// renderRateLimitConfig renders enabled rules as an http-context include. Path-scoped rules use a map
+// that yields an empty key outside the prefix, which nginx does not account. limit_req set here is
+// inherited by servers and locations that do not declare their own limit_req.
+func renderRateLimitConfig(rules []RateLimitRule) (string, []string) {
+	active := make([]RateLimitRule, 0, len(rules))
+	for _, r := range rules {
+		if r.Enabled {
+			active = append(active, r)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Zone < active[j].Zone })
+
+	var b strings.Builder
+	b.WriteString("# Managed by Avika: rate limit rules. Manual changes are overwritten.\n")
+	zones := make([]string, 0, len(active))
+	if len(active) == 0 {
+		return b.String(), zones
+	}
+	b.WriteString("limit_req_status 429;\n")
+	for _, r := range active {
+		zone := rateLimitZonePrefix + r.Zone
+		zones = append(zones, zone)
+		key := rateLimitKeys[r.KeyType]
+
+		fmt.Fprintf(&b, "\n# %s\n", strings.ReplaceAll(r.Name, "\n", " "))
+		if r.PathPrefix != "" {
+			variable := "$" + zone + "_key"
+			fmt.Fprintf(&b, "map $uri %s {\n    \"~^%s\" \"%s\";\n    default \"\";\n}\n", variable, regexp.QuoteMeta(r.PathPrefix), key)
+			key = variable
+		}
+		fmt.Fprintf(&b, "limit_req_zone %s zone=%s:%dm rate=%d%s;\n", key, zone, r.ZoneSizeMB, r.Rate, r.RateUnit)
+		fmt.Fprintf(&b, "limit_req zone=%s", zone)
+		if r.Burst > 0 {
+			fmt.Fprintf(&b, " burst=%d", r.Burst)
+		}
+		if r.NoDelay {
+			b.WriteString(" nodelay")
+		}
+		b.WriteString(";\n")
+	}
+	return b.String(), zones
+}
+
+// limitingRequestsPattern matches the error log line nginx writes when limit_req rejects a request:
+// limiting requests, excess: 20.500 by zone "avika_api", client: 1.2.3.4, server: x, request: "GET /a HTTP/1.1"
+var limitingRequestsPattern = regexp.MustCompile(`limiting requests, excess: ([0-9.]+) by zone "([^"]+)", client: ([^,]+)(?:, server: [^,]*)?(?:, request: "(\S+) (\S+))?`)
+
+// parseRateLimitLog turns a limit_req rejection in an error log entry into a rate_limited event
+func parseRateLimitLog(agentID string, entry *pb.LogEntry) (SecurityEvent, bool) {
+	if entry.LogType != "error" {
+		return SecurityEvent{}, false
+	}
+	m := limitingRequestsPattern.FindStringSubmatch(entry.Content)
+	if m == nil {
+		return SecurityEvent{}, false
+	}
+	ts := time.Unix(entry.Timestamp, 0)
+	if entry.Timestamp <= 0 {
+		ts = time.Now()
+	}
+	excess, _ := strconv.ParseFloat(m[1], 64)
+	return SecurityEvent{
+		Timestamp: ts,
+		AgentID:   agentID,
+		ClientIP:  strings.TrimSpace(m[3]),
+		EventType: eventRateLimited,
+		Severity:  "info",
+		RuleID:    m[2],
+		Method:    m[4],
+		URI:       m[5],
+		Status:    429,
+		Count:     1,
+		Details:   fmt.Sprintf("excess %.3f", excess),
+	}, true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestValidateRateLimitRule(t *testing.T) {
+	r := RateLimitRule{Zone: "Avika_API", Rate: 10}
+	if err := validateRateLimitRule(&r); err != nil {
+		t.Fatalf("valid rule rejected: %v", err)
+	}
+	if r.Zone != "api" || r.KeyType != "ip" || r.RateUnit != "r/s" || r.ZoneSizeMB != 10 || r.Name != "api" {
+		t.Errorf("defaults not applied: %+v", r)
+	}
+
+	invalid := []RateLimitRule{
+		{Zone: "bad zone", Rate: 1},
+		{Zone: "ok", Rate: 0},
+		{Zone: "ok", Rate: 1, RateUnit: "r/h"},
+		{Zone: "ok", Rate: 1, KeyType: "cookie"},
+		{Zone: "ok", Rate: 1, PathPrefix: "api"},
+		{Zone: "ok", Rate: 1, PathPrefix: "/api; return 200"},
+		{Zone: "ok", Rate: 1, Burst: -1},
+	}
+	for _, r := range invalid {
+		if err := validateRateLimitRule(&r); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+}
+
+func TestRenderRateLimitConfig(t *testing.T) {
+	rules := []RateLimitRule{
+		{Name: "API", Zone: "api", KeyType: "ip", PathPrefix: "/api/v1.0/", Rate: 10, RateUnit: "r/s", Burst: 20, NoDelay: true, ZoneSizeMB: 10, Enabled: true},
+		{Name: "Global", Zone: "global", KeyType: "server", Rate: 600, RateUnit: "r/m", ZoneSizeMB: 1, Enabled: true},
+		{Name: "Off", Zone: "off", KeyType: "ip", Rate: 1, RateUnit: "r/s", ZoneSizeMB: 1},
+	}
+	content, zones := renderRateLimitConfig(rules)
+
+	if len(zones) != 2 || zones[0] != "avika_api" || zones[1] != "avika_global" {
+		t.Errorf("zones = %v", zones)
+	}
+	for _, want := range []string{
+		"limit_req_status 429;",
+		"map $uri $avika_api_key {\n    \"~^/api/v1\\.0/\" \"$binary_remote_addr\";\n    default \"\";\n}",
+		"limit_req_zone $avika_api_key zone=avika_api:10m rate=10r/s;",
+		"limit_req zone=avika_api burst=20 nodelay;",
+		"limit_req_zone $server_name zone=avika_global:1m rate=600r/m;",
+		"limit_req zone=avika_global;",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("rendered config missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "avika_off") {
+		t.Error("disabled rule was rendered")
+	}
+
+	empty, zones := renderRateLimitConfig(nil)
+	if len(zones) != 0 || strings.Contains(empty, "limit_req") {
+		t.Errorf("empty rule set rendered directives:\n%s", empty)
+	}
+}
+
+func TestParseRateLimitLog(t *testing.T) {
+	entry := &pb.LogEntry{
+		Timestamp: 1700000000,
+		LogType:   "error",
+		Content: `2023/11/14 22:13:20 [error] 123#0: *5 limiting requests, excess: 20.500 by zone "avika_api", ` +
+			`client: 203.0.113.9, server: example.com, request: "POST /api/login HTTP/1.1", host: "example.com"`,
+	}
+	ev, ok := parseRateLimitLog("agent-1", entry)
+	if !ok {
+		t.Fatal("limit_req rejection not parsed")
+	}
+	if ev.EventType != eventRateLimited || ev.RuleID != "avika_api" || ev.ClientIP != "203.0.113.9" ||
+		ev.Method != "POST" || ev.URI != "/api/login" || !ev.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	if _, ok := parseRateLimitLog("agent-1", &pb.LogEntry{LogType: "error", Content: "upstream timed out"}); ok {
+		t.Error("unrelated error log parsed as rate limited")
+	}
+	if _, ok := parseRateLimitLog("agent-1", &pb.LogEntry{LogType: "access", Content: entry.Content}); ok {
+		t.Error("access log parsed as rate limited")
+	}
+}
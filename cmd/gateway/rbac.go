@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -155,6 +156,7 @@ func (db *DB) CreateProject(name, slug, description, createdBy string) (*Project
 	}
 	p.Description = desc.String
 	p.CreatedBy = creator.String
+	db.invalidateAccess()
 	return &p, nil
 }
 
@@ -208,6 +210,11 @@ func (db *DB) GetProjectBySlug(slug string) (*Project, error) {
 
 // ListProjects lists all projects (for superadmins) or accessible projects (for users)
 func (db *DB) ListProjects() ([]Project, error) {
+	projects, err := rbacCached(db.rbac, "projects", db.listProjects)
+	return slices.Clone(projects), err
+}
+
+func (db *DB) listProjects() ([]Project, error) {
 	query := `
 		SELECT id, name, slug, description, created_by, created_at, updated_at
 		FROM projects ORDER BY name
@@ -234,6 +241,13 @@ func (db *DB) ListProjects() ([]Project, error) {
 
 // ListProjectsForUser lists projects accessible by a user
 func (db *DB) ListProjectsForUser(username string) ([]Project, error) {
+	projects, err := rbacCached(db.rbac, "projects:"+username, func() ([]Project, error) {
+		return db.listProjectsForUser(username)
+	})
+	return slices.Clone(projects), err
+}
+
+func (db *DB) listProjectsForUser(username string) ([]Project, error) {
 	query := `
 		SELECT DISTINCT p.id, p.name, p.slug, p.description, p.created_by, p.created_at, p.updated_at
 		FROM projects p
@@ -266,12 +280,18 @@ func (db *DB) ListProjectsForUser(username string) ([]Project, error) {
 func (db *DB) UpdateProject(id, name, description string) error {
 	query := `UPDATE projects SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
 	_, err := db.conn.Exec(query, name, description, id)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
 // DeleteProject deletes a project
 func (db *DB) DeleteProject(id string) error {
 	_, err := db.conn.Exec("DELETE FROM projects WHERE id = $1", id)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -401,6 +421,9 @@ func (db *DB) UpdateEnvironment(id, name, description, color string, sortOrder i
 // DeleteEnvironment deletes an environment
 func (db *DB) DeleteEnvironment(id string) error {
 	_, err := db.conn.Exec("DELETE FROM environments WHERE id = $1", id)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -479,12 +502,16 @@ func (db *DB) AssignServer(agentID, environmentID, displayName, assignedBy strin
 	sa.DisplayName = dispName.String
 	sa.AssignedBy = assignBy.String
 	sa.Tags = tagsArray
+	db.invalidateAccess()
 	return &sa, nil
 }
 
 // UnassignServer removes a server from its environment
 func (db *DB) UnassignServer(agentID string) error {
 	_, err := db.conn.Exec("UPDATE server_assignments SET environment_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE agent_id = $1", agentID)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -722,6 +749,9 @@ func (db *DB) UpdateTeam(id, name, description string) error {
 // DeleteTeam deletes a team
 func (db *DB) DeleteTeam(id string) error {
 	_, err := db.conn.Exec("DELETE FROM teams WHERE id = $1", id)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -737,12 +767,18 @@ func (db *DB) AddTeamMember(teamID, username string, role TeamRole) error {
 		ON CONFLICT (team_id, username) DO UPDATE SET role = EXCLUDED.role
 	`
 	_, err := db.conn.Exec(query, teamID, username, role)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
 // RemoveTeamMember removes a user from a team
 func (db *DB) RemoveTeamMember(teamID, username string) error {
 	_, err := db.conn.Exec("DELETE FROM team_members WHERE team_id = $1 AND username = $2", teamID, username)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -792,12 +828,18 @@ func (db *DB) GrantProjectAccess(teamID, projectID string, permission Permission
 		ON CONFLICT (team_id, project_id) DO UPDATE SET permission = EXCLUDED.permission, granted_by = EXCLUDED.granted_by
 	`
 	_, err := db.conn.Exec(query, teamID, projectID, permission, grantedBy)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
 // RevokeProjectAccess revokes a team's access to a project
 func (db *DB) RevokeProjectAccess(teamID, projectID string) error {
 	_, err := db.conn.Exec("DELETE FROM team_project_access WHERE team_id = $1 AND project_id = $2", teamID, projectID)
+	if err == nil {
+		db.invalidateAccess()
+	}
 	return err
 }
 
@@ -829,6 +871,12 @@ func (db *DB) ListTeamProjectAccess(teamID string) ([]TeamProjectAccess, error)
 
 // IsSuperAdmin checks if a user is a superadmin
 func (db *DB) IsSuperAdmin(username string) (bool, error) {
+	return rbacCached(db.rbac, "superadmin:"+username, func() (bool, error) {
+		return db.isSuperAdmin(username)
+	})
+}
+
+func (db *DB) isSuperAdmin(username string) (bool, error) {
 	var isSuperAdmin bool
 	err := db.conn.QueryRow("SELECT COALESCE(is_superadmin, FALSE) FROM users WHERE username = $1", username).Scan(&isSuperAdmin)
 	if err == sql.ErrNoRows {
@@ -839,13 +887,23 @@ func (db *DB) IsSuperAdmin(username string) (bool, error) {
 
 // GetUserAccess gets the full access info for a user
 func (db *DB) GetUserAccess(username string) (*UserAccess, error) {
+	ua, err := rbacCached(db.rbac, "access:"+username, func() (*UserAccess, error) {
+		return db.getUserAccess(username)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneUserAccess(ua), nil
+}
+
+func (db *DB) getUserAccess(username string) (*UserAccess, error) {
 	ua := &UserAccess{
 		Username:      username,
 		ProjectAccess: make(map[string]Permission),
 	}
 
 	// Check superadmin status
-	isSuperAdmin, err := db.IsSuperAdmin(username)
+	isSuperAdmin, err := db.isSuperAdmin(username)
 	if err != nil {
 		return nil, err
 	}
@@ -898,43 +956,34 @@ func (db *DB) GetUserAccess(username string) (*UserAccess, error) {
 
 // HasProjectAccess checks if a user has at least the required permission on a project
 func (db *DB) HasProjectAccess(username, projectID string, requiredPermission Permission) (bool, error) {
-	// Superadmins have full access
-	isSuperAdmin, err := db.IsSuperAdmin(username)
+	ua, err := rbacCached(db.rbac, "access:"+username, func() (*UserAccess, error) {
+		return db.getUserAccess(username)
+	})
 	if err != nil {
 		return false, err
 	}
-	if isSuperAdmin {
+	// Superadmins have full access
+	if ua.IsSuperAdmin {
 		return true, nil
 	}
 
-	// Check team-based access
-	query := `
-		SELECT tpa.permission FROM team_project_access tpa
-		JOIN team_members tm ON tpa.team_id = tm.team_id
-		WHERE tm.username = $1 AND tpa.project_id = $2
-		ORDER BY 
-			CASE tpa.permission 
-				WHEN 'admin' THEN 4 
-				WHEN 'operate' THEN 3 
-				WHEN 'write' THEN 2 
-				WHEN 'read' THEN 1 
-			END DESC
-		LIMIT 1
-	`
-	var permission Permission
-	err = db.conn.QueryRow(query, username, projectID).Scan(&permission)
-	if err == sql.ErrNoRows {
+	// Check team-based access, the highest permission of the user's teams
+	permission, ok := ua.ProjectAccess[projectID]
+	if !ok {
 		return false, nil
 	}
-	if err != nil {
-		return false, err
-	}
-
 	return permissionLevel(permission) >= permissionLevel(requiredPermission), nil
 }
 
 // GetVisibleAgentIDs returns agent IDs visible to a user
 func (db *DB) GetVisibleAgentIDs(username string) ([]string, error) {
+	agents, err := rbacCached(db.rbac, "agents:"+username, func() ([]string, error) {
+		return db.getVisibleAgentIDs(username)
+	})
+	return slices.Clone(agents), err
+}
+
+func (db *DB) getVisibleAgentIDs(username string) ([]string, error) {
 	// Superadmins see all agents
 	isSuperAdmin, err := db.IsSuperAdmin(username)
 	if err != nil {
@@ -1033,6 +1082,30 @@ func (db *DB) GetAgentIDsForProject(projectID string) ([]string, error) {
 	return agents, nil
 }
 
+// ListAgentTenants returns the project and environment of every agent assigned to an environment
+func (db *DB) ListAgentTenants() (map[string]agentTenant, error) {
+	rows, err := db.conn.Query(`
+		SELECT sa.agent_id, e.project_id, e.id
+		FROM server_assignments sa
+		JOIN environments e ON sa.environment_id = e.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := map[string]agentTenant{}
+	for rows.Next() {
+		var id string
+		var t agentTenant
+		if err := rows.Scan(&id, &t.ProjectID, &t.EnvironmentID); err != nil {
+			return nil, err
+		}
+		tenants[id] = t
+	}
+	return tenants, rows.Err()
+}
+
 // permissionLevel returns a numeric level for permission comparison
 func permissionLevel(p Permission) int {
 	switch p {
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Every API request checks the caller's access (IsSuperAdmin, HasProjectAccess,
+// GetVisibleAgentIDs) and dashboards poll, so those answers are cached for rbacCacheTTL. Changes
+// to projects, environments, server assignments, teams, members and grants clear the cache, and
+// tell the other gateways to clear theirs through a PostgreSQL NOTIFY.
+
+const (
+	rbacCacheTTL = 15 * time.Second
+	// rbacInvalidateChannel is the NOTIFY channel gateways clear their access cache on
+	rbacInvalidateChannel = "avika_rbac_invalidate"
+	// rbacListenRetry is how long the listener waits to reconnect after losing its connection
+	rbacListenRetry = 5 * time.Second
+)
+
+type rbacCacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// rbacCache holds access answers by key until they expire or the cache is invalidated
+type rbacCache struct {
+	mu      sync.Mutex
+	entries map[string]rbacCacheEntry
+	// generation is bumped by invalidate, so an answer loaded across an invalidation is not kept
+	generation uint64
+}
+
+func newRBACCache() *rbacCache {
+	return &rbacCache{entries: map[string]rbacCacheEntry{}}
+}
+
+// invalidate drops every cached answer
+func (c *rbacCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.generation++
+}
+
+// rbacCached returns the answer cached under key, or loads and caches it. A nil cache always loads.
+func rbacCached[T any](c *rbacCache, key string, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value.(T), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.entries[key] = rbacCacheEntry{value: value, expires: time.Now().Add(rbacCacheTTL)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// cloneUserAccess copies ua so callers of the cache cannot change the cached one
+func cloneUserAccess(ua *UserAccess) *UserAccess {
+	out := *ua
+	out.Teams = slices.Clone(ua.Teams)
+	out.ProjectAccess = maps.Clone(ua.ProjectAccess)
+	return &out
+}
+
+// invalidateAccess clears the access cache of this gateway and of the others, after a change to
+// who can see what
+func (db *DB) invalidateAccess() {
+	if db.rbac == nil {
+		return
+	}
+	db.rbac.invalidate()
+	if _, err := db.ExecContext(context.Background(), "SELECT pg_notify($1, '')", rbacInvalidateChannel); err != nil {
+		dbLog.Error().Msgf("Failed to notify other gateways of an access change: %v", err)
+	}
+}
+
+// startAccessCacheListener clears the access cache when another gateway changes access
+func (s *server) startAccessCacheListener() {
+	if s.db == nil || s.db.pool == nil || s.db.rbac == nil {
+		return
+	}
+	go func() {
+		for {
+			err := s.db.listenAccessInvalidations(context.Background())
+			dbLog.Warn().Msgf("Access cache listener stopped, retrying in %s: %v", rbacListenRetry, err)
+			time.Sleep(rbacListenRetry)
+		}
+	}()
+}
+
+// listenAccessInvalidations invalidates the access cache on every notification of
+// rbacInvalidateChannel until its connection fails
+func (db *DB) listenAccessInvalidations(ctx context.Context) error {
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection stays in LISTEN mode, so it leaves the pool for good
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+rbacInvalidateChannel); err != nil {
+		return err
+	}
+	// Changes made while the listener was down were missed
+	db.rbac.invalidate()
+	for {
+		if _, err := pgConn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		db.rbac.invalidate()
+	}
+}
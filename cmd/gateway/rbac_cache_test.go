@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRBACCached(t *testing.T) {
+	c := newRBACCache()
+	loads := 0
+	load := func() (bool, error) {
+		loads++
+		return true, nil
+	}
+
+	for range 3 {
+		if ok, err := rbacCached(c, "superadmin:alice", load); !ok || err != nil {
+			t.Fatalf("rbacCached = %v, %v", ok, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, want once", loads)
+	}
+
+	c.invalidate()
+	rbacCached(c, "superadmin:alice", load)
+	if loads != 2 {
+		t.Errorf("loaded %d times after invalidate, want 2", loads)
+	}
+
+	// Errors are not cached
+	failing := func() (bool, error) {
+		loads++
+		return false, errors.New("down")
+	}
+	rbacCached(c, "superadmin:bob", failing)
+	rbacCached(c, "superadmin:bob", failing)
+	if loads != 4 {
+		t.Errorf("loaded %d times, want failed loads retried", loads)
+	}
+
+	// An answer loaded across an invalidation may predate the change, and is not kept
+	rbacCached(c, "superadmin:carol", func() (bool, error) {
+		c.invalidate()
+		return true, nil
+	})
+	if _, ok := c.entries["superadmin:carol"]; ok {
+		t.Error("kept an answer loaded across an invalidation")
+	}
+
+	// Without a cache every call loads
+	loads = 0
+	rbacCached(nil, "superadmin:alice", load)
+	rbacCached(nil, "superadmin:alice", load)
+	if loads != 2 {
+		t.Errorf("nil cache loaded %d times, want 2", loads)
+	}
+}
+
+func TestGetUserAccessReturnsCopies(t *testing.T) {
+	db := &DB{rbac: newRBACCache()}
+	cached := &UserAccess{Username: "alice", ProjectAccess: map[string]Permission{"p1": PermissionRead}}
+	db.rbac.entries["access:alice"] = rbacCacheEntry{value: cached, expires: time.Now().Add(time.Hour)}
+
+	ua, err := db.GetUserAccess("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ua.ProjectAccess["p1"] = PermissionAdmin
+	if cached.ProjectAccess["p1"] != PermissionRead {
+		t.Error("a caller changed the cached access")
+	}
+
+	if ok, _ := db.HasProjectAccess("alice", "p1", PermissionWrite); ok {
+		t.Error("read access granted write")
+	}
+	if ok, _ := db.HasProjectAccess("alice", "p1", PermissionRead); !ok {
+		t.Error("read access denied read")
+	}
+	if ok, _ := db.HasProjectAccess("alice", "p2", PermissionRead); ok {
+		t.Error("access granted to another project")
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 )
@@ -520,7 +519,7 @@ func (re *RecommendationEngine) GenerateRecommendations(ctx context.Context, ana
 	if re.llmClient != nil && re.llmClient.GetProviderName() != "mock" {
 		llmRecs, err := re.generateLLMRecommendations(ctx, analysisCtx)
 		if err != nil {
-			log.Printf("LLM recommendation generation failed (using rules only): %v", err)
+			aiLog.Error().Msgf("LLM recommendation generation failed (using rules only): %v", err)
 		} else {
 			// Merge LLM recommendations, avoiding duplicates
 			recommendations = mergeRecommendations(recommendations, llmRecs)
@@ -62,13 +62,11 @@ func (s *server) DownloadReport(ctx context.Context, req *pb.ReportRequest) (*pb
 		return nil, err
 	}
 
-	start := time.Unix(req.StartTime, 0)
-	end := time.Unix(req.EndTime, 0)
-	format := req.GetFormat()
-	if format == "" {
-		format = "pdf"
-	}
+	return renderReport(report, time.Unix(req.StartTime, 0), time.Unix(req.EndTime, 0), req.GetFormat())
+}
 
+// renderReport renders report data as a PDF, or as an Excel workbook for format excel or xlsx
+func renderReport(report *pb.ReportResponse, start, end time.Time, format string) (*pb.ReportDownloadResponse, error) {
 	switch format {
 	case "excel", "xlsx":
 		excelData, err := GenerateExcelReport(report, start, end)
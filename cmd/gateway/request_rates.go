@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// requestRateIdle is how long an nginx instance's last sample is kept without a new one
+const requestRateIdle = 30 * time.Minute
+
+type requestRateKey struct {
+	agentID    string
+	instanceID string
+}
+
+type requestSample struct {
+	total int64
+	at    time.Time
+	rate  float64 // since the sample before
+}
+
+// requestRates derives requests per second from the request counter of stub_status (or VTS),
+// which counts every request since nginx started, by the difference between an nginx instance's
+// consecutive samples. Agents compute the rate themselves from their sampling times; this covers
+// agents that do not report one.
+type requestRates struct {
+	mu        sync.Mutex
+	last      map[requestRateKey]requestSample
+	lastPrune time.Time
+}
+
+func newRequestRates() *requestRates {
+	return &requestRates{last: map[requestRateKey]requestSample{}}
+}
+
+// observe records a sample of an nginx instance's request counter taken at at, and returns the
+// rate since its previous sample. The first sample gives 0. Samples are timed to the second, so one
+// no newer than the previous is skipped and gives the previous rate.
+// A counter lower than before means nginx restarted, so the requests since the restart are
+// spread over the interval.
+func (r *requestRates) observe(agentID, instanceID string, total int64, at time.Time) float64 {
+	// A nil tracker, or metrics without a request counter (system only), give no rate
+	if r == nil || total == 0 {
+		return 0
+	}
+	key := requestRateKey{agentID: agentID, instanceID: instanceID}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if at.Sub(r.lastPrune) >= time.Minute {
+		for k, s := range r.last {
+			if at.Sub(s.at) > requestRateIdle {
+				delete(r.last, k)
+			}
+		}
+		r.lastPrune = at
+	}
+
+	prev, ok := r.last[key]
+	if ok && !at.After(prev.at) {
+		return prev.rate
+	}
+	sample := requestSample{total: total, at: at}
+	if ok {
+		requests := total - prev.total
+		if requests < 0 {
+			requests = total
+		}
+		sample.rate = float64(requests) / at.Sub(prev.at).Seconds()
+	}
+	r.last[key] = sample
+	return sample.rate
+}
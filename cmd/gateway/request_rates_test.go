@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestRates(t *testing.T) {
+	r := newRequestRates()
+	start := time.Now()
+
+	if got := r.observe("agent-1", "", 1000, start); got != 0 {
+		t.Errorf("first sample = %v, want 0", got)
+	}
+	if got := r.observe("agent-1", "", 1600, start.Add(10*time.Second)); got != 60 {
+		t.Errorf("rate = %v, want 60", got)
+	}
+	// Each agent and nginx instance has its own counter
+	if got := r.observe("agent-1", "second", 50, start.Add(10*time.Second)); got != 0 {
+		t.Errorf("first sample of another instance = %v, want 0", got)
+	}
+	// A sample no newer than the previous one is ignored and gives the previous rate
+	if got := r.observe("agent-1", "", 1700, start.Add(10*time.Second)); got != 60 {
+		t.Errorf("rate of a sample at the same time = %v, want 60", got)
+	}
+	// nginx restarted: 100 requests since
+	if got := r.observe("agent-1", "", 100, start.Add(20*time.Second)); got != 10 {
+		t.Errorf("rate after restart = %v, want 10", got)
+	}
+	// System-only metrics carry no counter
+	if got := r.observe("agent-1", "", 0, start.Add(25*time.Second)); got != 0 {
+		t.Errorf("rate without a counter = %v, want 0", got)
+	}
+	if got := r.observe("agent-1", "", 300, start.Add(30*time.Second)); got != 20 {
+		t.Errorf("rate = %v, want 20", got)
+	}
+
+	// Instances that stopped reporting are forgotten
+	r.observe("agent-2", "", 10, start.Add(time.Hour))
+	r.mu.Lock()
+	_, kept := r.last[requestRateKey{agentID: "agent-1"}]
+	r.mu.Unlock()
+	if kept {
+		t.Error("idle instance was not pruned")
+	}
+
+	var none *requestRates
+	if got := none.observe("agent-1", "", 10, start); got != 0 {
+		t.Errorf("nil tracker = %v, want 0", got)
+	}
+}
+
+func TestRequestRatesReplayedBacklog(t *testing.T) {
+	// A backlog replayed after a reconnect arrives at once, but keeps the times it was sampled at
+	r := newRequestRates()
+	start := time.Unix(1700000000, 0)
+	for i := 0; i <= 5; i++ {
+		got := r.observe("agent-1", "", int64(1000+50*i), start.Add(time.Duration(i)*time.Second))
+		if i > 0 && got != 50 {
+			t.Errorf("sample %d: rate = %v, want 50", i, got)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/google/uuid"
+)
+
+const (
+	scheduledTaskTick         = 30 * time.Second
+	scheduledTaskAgentTimeout = 2 * time.Minute
+	scheduledTaskRunRetention = 90 * 24 * time.Hour
+	defaultReportDays         = 7
+)
+
+// scheduledTaskActions are the operations a task can run. Reload, restart and config_backup run on
+// each target agent in turn; report runs once over all of them.
+var scheduledTaskActions = map[string]bool{
+	"reload":        true,
+	"restart":       true,
+	"config_backup": true,
+	"report":        true,
+}
+
+// validateScheduledTask checks the task and fills in its defaults and next run
+func validateScheduledTask(t *ScheduledTask, now time.Time) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !scheduledTaskActions[t.Action] {
+		return fmt.Errorf("unknown action %q (reload, restart, config_backup, report)", t.Action)
+	}
+	if t.Timezone == "" {
+		t.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return fmt.Errorf("unknown timezone %q", t.Timezone)
+	}
+	sched, err := parseCron(t.Cron)
+	if err != nil {
+		return err
+	}
+	next := sched.next(now.In(loc))
+	if next.IsZero() {
+		return fmt.Errorf("cron expression %q never matches", t.Cron)
+	}
+	if t.Action == "report" {
+		if len(t.Params.ReportRecipients) == 0 {
+			return fmt.Errorf("report tasks need report_recipients")
+		}
+		switch t.Params.ReportType {
+		case "":
+			t.Params.ReportType = "summary"
+		case "summary", "detailed", "security":
+		default:
+			return fmt.Errorf("unknown report_type %q", t.Params.ReportType)
+		}
+		if t.Params.ReportDays <= 0 {
+			t.Params.ReportDays = defaultReportDays
+		}
+	}
+	t.NextRunAt = nil
+	if t.Enabled {
+		t.NextRunAt = &next
+	}
+	return nil
+}
+
+// nextScheduledRun returns the next run of t after now, nil when it has none
+func nextScheduledRun(t *ScheduledTask, now time.Time) *time.Time {
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil
+	}
+	sched, err := parseCron(t.Cron)
+	if err != nil {
+		return nil
+	}
+	next := sched.next(now.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// startTaskScheduler runs the due scheduled tasks on the leader gateway and prunes old runs and
+// jobs
+func (s *server) startTaskScheduler() {
+	if s.db == nil {
+		return
+	}
+	if n, err := s.db.InterruptScheduledTaskRuns(s.instanceID); err != nil {
+		jobsLog.Error().Msgf("Failed to close interrupted scheduled task runs: %v", err)
+	} else if n > 0 {
+		jobsLog.Info().Msgf("Marked %d scheduled task run(s) interrupted by the gateway restart", n)
+	}
+	go func() {
+		jobsLog.Info().Msg("Starting task scheduler")
+		ticker := time.NewTicker(scheduledTaskTick)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			s.runDueTasks(now)
+			if now.Sub(lastPrune) >= 24*time.Hour {
+				if n, err := s.db.PruneScheduledTaskRuns(now.Add(-scheduledTaskRunRetention)); err != nil {
+					jobsLog.Error().Msgf("Failed to prune scheduled task runs: %v", err)
+				} else if n > 0 {
+					jobsLog.Info().Msgf("Pruned %d scheduled task runs", n)
+				}
+				if n, err := s.db.PruneJobs(now.Add(-jobRetention)); err != nil {
+					jobsLog.Error().Msgf("Failed to prune jobs: %v", err)
+				} else if n > 0 {
+					jobsLog.Info().Msgf("Pruned %d jobs", n)
+				}
+				lastPrune = now
+			}
+		}
+	}()
+}
+
+// runDueTasks starts the tasks whose next run has come. Runs missed while no gateway was leading
+// are caught up once, not once per missed slot.
+func (s *server) runDueTasks(now time.Time) {
+	tasks, err := s.db.ListDueScheduledTasks(now)
+	if err != nil {
+		jobsLog.Error().Msgf("Task scheduler: failed to list due tasks: %v", err)
+		return
+	}
+	for i := range tasks {
+		t := &tasks[i]
+		claimed, err := s.db.ClaimScheduledTask(t.ID, *t.NextRunAt, nextScheduledRun(t, now))
+		if err != nil {
+			jobsLog.Error().Msgf("Task scheduler: failed to claim task %s: %v", t.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if _, err := s.startScheduledTaskRun(t, "schedule", ""); err != nil {
+			jobsLog.Error().Msgf("Task scheduler: task %q not started: %v", t.Name, err)
+		}
+	}
+}
+
+// startScheduledTaskRun records a run of t and executes it in the background. A task runs once at a
+// time on a gateway.
+func (s *server) startScheduledTaskRun(t *ScheduledTask, trigger, username string) (*ScheduledTaskRun, error) {
+	run := &ScheduledTaskRun{
+		ID:          uuid.New().String(),
+		TaskID:      t.ID,
+		Trigger:     trigger,
+		TriggeredBy: username,
+		Status:      "running",
+		Results:     []ScheduledTaskResult{},
+		GatewayID:   s.instanceID,
+	}
+	if running, busy := s.scheduledTaskRuns.LoadOrStore(t.ID, run.ID); busy {
+		return nil, fmt.Errorf("run %s of the task is still in progress", running)
+	}
+	if err := s.db.CreateScheduledTaskRun(run); err != nil {
+		s.scheduledTaskRuns.Delete(t.ID)
+		return nil, err
+	}
+	go func() {
+		defer s.scheduledTaskRuns.Delete(t.ID)
+		s.executeScheduledTask(context.Background(), t, run)
+	}()
+	return run, nil
+}
+
+// executeScheduledTask runs the action of t on its targets, saves the outcome and alerts the
+// task's recipients when it failed
+func (s *server) executeScheduledTask(ctx context.Context, t *ScheduledTask, run *ScheduledTaskRun) {
+	targets, offline, err := s.scheduledTaskTargets(ctx, t)
+	if err != nil {
+		run.Error = fmt.Sprintf("failed to resolve targets: %v", err)
+	} else if t.Action == "report" {
+		run.Targets = len(targets)
+		if err := s.sendScheduledReport(ctx, t, targets); err != nil {
+			run.Error = err.Error()
+		}
+	} else {
+		run.Targets = len(targets)
+		for _, agentID := range targets {
+			res := ScheduledTaskResult{AgentID: agentID, OK: true}
+			if err := s.runTaskOnAgent(ctx, t, agentID); err != nil {
+				res.OK, res.Error = false, err.Error()
+				run.Failed++
+			}
+			run.Results = append(run.Results, res)
+		}
+		for _, agentID := range offline {
+			run.Results = append(run.Results, ScheduledTaskResult{AgentID: agentID, Error: "offline, skipped"})
+		}
+		if run.Failed > 0 {
+			run.Error = fmt.Sprintf("%s failed on %d of %d agents", t.Action, run.Failed, run.Targets)
+		}
+	}
+
+	run.Status = "succeeded"
+	if run.Error != "" {
+		run.Status = "failed"
+	}
+	completed := time.Now()
+	run.CompletedAt = &completed
+	if err := s.db.FinishScheduledTaskRun(run); err != nil {
+		jobsLog.Error().Msgf("Scheduled task %q: failed to save run %s: %v", t.Name, run.ID, err)
+	}
+	jobsLog.Info().Msgf("Scheduled task %q (%s) %s: %d target(s), %d failed", t.Name, t.Action, run.Status, run.Targets, run.Failed)
+	ev := SystemEvent{
+		Type:    "task." + run.Status,
+		Actor:   run.TriggeredBy,
+		Message: fmt.Sprintf("Scheduled task %s (%s) %s on %d target(s)", t.Name, t.Action, run.Status, run.Targets),
+		Data: map[string]interface{}{
+			"task_id": t.ID,
+			"run_id":  run.ID,
+			"trigger": run.Trigger,
+			"targets": run.Targets,
+			"failed":  run.Failed,
+			"error":   run.Error,
+		},
+	}
+	if run.Status == "failed" {
+		ev.Severity = "warning"
+	}
+	s.publishEvent(ev)
+
+	if run.Status == "failed" && t.NotifyRecipients != "" && s.alerts != nil {
+		subject := fmt.Sprintf("[WARNING] Scheduled task %s failed", t.Name)
+		body := fmt.Sprintf("Scheduled task '%s' failed.\n\nAction: %s\nSchedule: %s (%s)\nTrigger: %s\nError: %s\nStarted: %s",
+			t.Name, t.Action, t.Cron, t.Timezone, run.Trigger, run.Error, run.StartedAt.Format(time.RFC1123))
+		s.alerts.notifyRecipients(t.NotifyRecipients, "warning", subject, body)
+	}
+}
+
+// scheduledTaskTargets returns the online and offline agents of the task's environment, or of the
+// whole fleet when it has none
+func (s *server) scheduledTaskTargets(ctx context.Context, t *ScheduledTask) (online, offline []string, err error) {
+	agents, err := s.listAgentsByID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []string
+	if t.EnvironmentID == "" {
+		for id := range agents {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	} else if ids, err = s.resolveTargetAgents(ctx, nil, "", t.EnvironmentID); err != nil {
+		return nil, nil, err
+	}
+	for _, id := range ids {
+		if a := agents[id]; a != nil && a.Status == "online" {
+			online = append(online, id)
+		} else {
+			offline = append(offline, id)
+		}
+	}
+	return online, offline, nil
+}
+
+// runTaskOnAgent runs a reload, restart or config backup on one agent
+func (s *server) runTaskOnAgent(ctx context.Context, t *ScheduledTask, agentID string) error {
+	ctx, cancel := context.WithTimeout(ctx, scheduledTaskAgentTimeout)
+	defer cancel()
+
+	switch t.Action {
+	case "reload":
+		resp, err := s.ReloadNginx(ctx, &pb.ReloadRequest{InstanceId: agentID, NginxInstanceId: t.Params.NginxInstanceID})
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+	case "restart":
+		resp, err := s.RestartNginx(ctx, &pb.RestartRequest{InstanceId: agentID, NginxInstanceId: t.Params.NginxInstanceID})
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("%s", resp.Error)
+		}
+	case "config_backup":
+		resp, err := s.GetConfig(ctx, &pb.ConfigRequest{InstanceId: agentID, NginxInstanceId: t.Params.NginxInstanceID})
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("%s", resp.Error)
+		}
+		if resp.Config == nil || resp.Config.Content == "" {
+			return fmt.Errorf("agent returned an empty config")
+		}
+		certs := []byte("[]")
+		if certResp, err := s.ListCertificates(ctx, &pb.CertListRequest{InstanceId: agentID}); err == nil && certResp != nil {
+			certs, _ = json.Marshal(certResp.Certificates)
+		}
+		return s.db.CreateConfigBackup(ctx, agentID, "scheduled", resp.Config.Content, certs)
+	default:
+		return fmt.Errorf("unknown action %q", t.Action)
+	}
+	return nil
+}
+
+// sendScheduledReport emails the report for the last ReportDays over the task's agents
+func (s *server) sendScheduledReport(ctx context.Context, t *ScheduledTask, agentIDs []string) error {
+	if t.EnvironmentID == "" {
+		agentIDs = nil // the whole fleet
+	} else if len(agentIDs) == 0 {
+		return fmt.Errorf("no online agents in the environment")
+	}
+	end := time.Now()
+	start := end.AddDate(0, 0, -t.Params.ReportDays)
+	_, err := s.SendReport(ctx, &pb.SendReportRequest{
+		Request: &pb.ReportRequest{
+			StartTime:  start.Unix(),
+			EndTime:    end.Unix(),
+			AgentIds:   agentIDs,
+			ReportType: t.Params.ReportType,
+		},
+		Recipients: t.Params.ReportRecipients,
+		Subject:    "Avika report: " + t.Name,
+		Body:       fmt.Sprintf("Scheduled report '%s' for %s to %s.", t.Name, start.Format("2006-01-02"), end.Format("2006-01-02")),
+	})
+	return err
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateScheduledTask(t *testing.T) {
+	now := time.Date(2026, 3, 14, 10, 17, 0, 0, time.UTC)
+	task := ScheduledTask{Name: " Nightly backup ", Cron: "0 1 * * *", Timezone: "Europe/Berlin", Action: "config_backup", Enabled: true}
+	if err := validateScheduledTask(&task, now); err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "Nightly backup" || task.NextRunAt == nil || !task.NextRunAt.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("task = %+v, next run %v", task, task.NextRunAt)
+	}
+
+	report := ScheduledTask{Name: "Weekly", Cron: "@weekly", Action: "report", Params: ScheduledTaskParams{ReportRecipients: []string{"ops@example.com"}}}
+	if err := validateScheduledTask(&report, now); err != nil {
+		t.Fatal(err)
+	}
+	if report.Timezone != "UTC" || report.Params.ReportType != "summary" || report.Params.ReportDays != defaultReportDays || report.NextRunAt != nil {
+		t.Errorf("report defaults = %+v", report)
+	}
+
+	for _, bad := range []ScheduledTask{
+		{Name: "x", Cron: "0 1 * * *", Action: "reboot"},
+		{Name: "x", Cron: "0 1 * *", Action: "reload"},
+		{Name: "x", Cron: "0 1 * * *", Action: "reload", Timezone: "Mars/Olympus"},
+		{Name: "x", Cron: "0 0 30 feb *", Action: "reload"},
+		{Name: "x", Cron: "@daily", Action: "report"},
+		{Cron: "@daily", Action: "reload"},
+	} {
+		if err := validateScheduledTask(&bad, now); err == nil {
+			t.Errorf("validateScheduledTask(%+v) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Alert rule metric types on the gateway's own health. They are read from the gateway's state
+// rather than ClickHouse, so they still fire when ClickHouse or PostgreSQL is down, and every
+// gateway evaluates them for itself, leader or not. Migration 053 adds a default rule for each.
+const (
+	// gatewayInsertFailuresMetric is the number of ClickHouse batch inserts that failed in the window
+	gatewayInsertFailuresMetric = "gateway_clickhouse_insert_failures"
+	// gatewayKafkaStallMetric is how long, in seconds, the Kafka consumer has failed to read
+	gatewayKafkaStallMetric = "gateway_kafka_consumer_stall"
+	// gatewayQueueSaturationMetric is how full the fullest batch write queue is, in percent
+	gatewayQueueSaturationMetric = "gateway_queue_saturation"
+	// gatewayPostgresDownMetric is 1 while PostgreSQL does not answer, 0 otherwise
+	gatewayPostgresDownMetric = "gateway_postgres_down"
+)
+
+var avikaClickHouseInsertFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "avika_clickhouse_insert_failures_total",
+		Help: "ClickHouse batch inserts that failed, by table; their rows are lost",
+	},
+	[]string{"table"},
+)
+
+func init() {
+	prometheus.MustRegister(avikaClickHouseInsertFailures)
+}
+
+// postgresPingTimeout bounds the ping behind gateway_postgres_down
+const postgresPingTimeout = 5 * time.Second
+
+func isGatewayHealthMetric(metricType string) bool {
+	switch metricType {
+	case gatewayInsertFailuresMetric, gatewayKafkaStallMetric, gatewayQueueSaturationMetric, gatewayPostgresDownMetric:
+		return true
+	}
+	return false
+}
+
+// failureCount counts failures per minute over the last hour
+type failureCount struct {
+	mu      sync.Mutex
+	minutes [60]struct {
+		minute int64
+		n      int
+	}
+}
+
+// add counts a failure at now
+func (c *failureCount) add(now time.Time) {
+	minute := now.Unix() / 60
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bucket := &c.minutes[minute%int64(len(c.minutes))]
+	if bucket.minute != minute {
+		bucket.minute, bucket.n = minute, 0
+	}
+	bucket.n++
+}
+
+// since returns the failures counted in the window before now, at most the last hour
+func (c *failureCount) since(now time.Time, window time.Duration) int {
+	last := now.Unix() / 60
+	first := max(now.Add(-window).Unix()/60, last-int64(len(c.minutes)))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, bucket := range c.minutes {
+		if bucket.minute > first && bucket.minute <= last {
+			n += bucket.n
+		}
+	}
+	return n
+}
+
+// insertFailed counts a failed batch insert into table for gateway_clickhouse_insert_failures
+func (db *ClickHouseDB) insertFailed(table string) {
+	avikaClickHouseInsertFailures.WithLabelValues(table).Inc()
+	db.insertFailures.add(time.Now())
+}
+
+// consumerHealth tracks since when a Kafka consumer has failed to read
+type consumerHealth struct {
+	mu           sync.Mutex
+	failingSince time.Time
+}
+
+// failed records a read error; the stall counts from the first error in a row
+func (c *consumerHealth) failed(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failingSince.IsZero() {
+		c.failingSince = now
+	}
+}
+
+// ok records a successful read
+func (c *consumerHealth) ok() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failingSince = time.Time{}
+}
+
+// stalledFor returns how long reads have failed, 0 when the last read worked
+func (c *consumerHealth) stalledFor(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failingSince.IsZero() {
+		return 0
+	}
+	return now.Sub(c.failingSince)
+}
+
+// queueSaturation returns how full the fullest of queues is, in percent
+func queueSaturation(queues []BatchQueue) float64 {
+	var fullest float64
+	for _, q := range queues {
+		if q.Capacity > 0 {
+			fullest = max(fullest, float64(q.Depth)/float64(q.Capacity)*100)
+		}
+	}
+	return fullest
+}
+
+// gatewayHealthValue returns the value of a gateway health metric over the last windowSec seconds
+func (s *server) gatewayHealthValue(ctx context.Context, metricType string, windowSec int) (float64, error) {
+	now := time.Now()
+	switch metricType {
+	case gatewayInsertFailuresMetric:
+		if s.clickhouse == nil {
+			return 0, nil
+		}
+		window := time.Duration(windowSec) * time.Second
+		if window <= 0 {
+			window = 5 * time.Minute
+		}
+		return float64(s.clickhouse.insertFailures.since(now, window)), nil
+	case gatewayKafkaStallMetric:
+		return s.recConsumer.stalledFor(now).Seconds(), nil
+	case gatewayQueueSaturationMetric:
+		return queueSaturation(s.batchQueues()), nil
+	case gatewayPostgresDownMetric:
+		if s.db == nil {
+			return 0, nil
+		}
+		ctx, cancel := context.WithTimeout(ctx, postgresPingTimeout)
+		defer cancel()
+		if err := s.db.conn.PingContext(ctx); err != nil {
+			alertsLog.Warn().Msgf("Self-monitoring: PostgreSQL ping failed: %v", err)
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unknown gateway health metric %q", metricType)
+}
+
+// evaluateGatewayHealthRules evaluates the enabled gateway health rules. PostgreSQL holds the
+// rules, so while it is down the rules it last returned are used.
+func (e *AlertEngine) evaluateGatewayHealthRules() {
+	if e.gatewayHealth == nil {
+		return
+	}
+	rules, err := e.db.ListAlertRules()
+	e.healthRulesMu.Lock()
+	if err == nil {
+		e.healthRules = e.healthRules[:0]
+		for _, rule := range rules {
+			if isGatewayHealthMetric(rule.MetricType) {
+				e.healthRules = append(e.healthRules, rule)
+			}
+		}
+	}
+	rules = append([]*pb.AlertRule(nil), e.healthRules...)
+	e.healthRulesMu.Unlock()
+	if err != nil {
+		alertsLog.Warn().Msgf("AlertEngine: Failed to list rules, evaluating the %d gateway health rules last listed: %v", len(rules), err)
+	}
+
+	for _, rule := range rules {
+		if rule.Enabled {
+			go e.evaluateRule(rule)
+		}
+	}
+}
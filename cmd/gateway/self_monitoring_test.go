@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestFailureCount(t *testing.T) {
+	var c failureCount
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+	c.add(now.Add(-2 * time.Hour)) // overwritten: same bucket as now
+	c.add(now.Add(-61 * time.Minute))
+	c.add(now.Add(-10 * time.Minute))
+	c.add(now.Add(-2 * time.Minute))
+	c.add(now)
+	c.add(now)
+
+	if got := c.since(now, 5*time.Minute); got != 3 {
+		t.Errorf("last 5 min = %d, want 3", got)
+	}
+	if got := c.since(now, 15*time.Minute); got != 4 {
+		t.Errorf("last 15 min = %d, want 4", got)
+	}
+	if got := c.since(now, 2*time.Hour); got != 4 {
+		t.Errorf("last 2 h = %d, want 4 (an hour is kept)", got)
+	}
+}
+
+func TestConsumerHealth(t *testing.T) {
+	var c consumerHealth
+	start := time.Now()
+	if got := c.stalledFor(start); got != 0 {
+		t.Errorf("stalled %s before any error", got)
+	}
+	c.failed(start)
+	c.failed(start.Add(time.Minute))
+	if got := c.stalledFor(start.Add(2 * time.Minute)); got != 2*time.Minute {
+		t.Errorf("stalled %s, want 2m from the first error", got)
+	}
+	c.ok()
+	if got := c.stalledFor(start.Add(3 * time.Minute)); got != 0 {
+		t.Errorf("stalled %s after a successful read", got)
+	}
+}
+
+func TestQueueSaturation(t *testing.T) {
+	queues := []BatchQueue{
+		{Name: "access_logs", Depth: 50, Capacity: 100},
+		{Name: "spans", Depth: 900, Capacity: 1000},
+		{Name: "empty", Depth: 0, Capacity: 0},
+	}
+	if got := queueSaturation(queues); got != 90 {
+		t.Errorf("saturation = %v, want 90", got)
+	}
+	if got := queueSaturation(nil); got != 0 {
+		t.Errorf("saturation of no queues = %v, want 0", got)
+	}
+}
+
+func TestGatewayHealthValue(t *testing.T) {
+	ch := &ClickHouseDB{logChan: make(chan logBatchItem, 10)}
+	for range 7 {
+		ch.logChan <- logBatchItem{}
+	}
+	ch.insertFailed("access_logs")
+	ch.insertFailed("spans")
+	s := &server{clickhouse: ch}
+	s.recConsumer.failed(time.Now().Add(-10 * time.Minute))
+
+	for metric, want := range map[string]float64{
+		gatewayInsertFailuresMetric:  2,
+		gatewayQueueSaturationMetric: 70,
+		gatewayPostgresDownMetric:    0, // no PostgreSQL configured
+	} {
+		got, err := s.gatewayHealthValue(context.Background(), metric, 300)
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", metric, got, err, want)
+		}
+	}
+	stall, err := s.gatewayHealthValue(context.Background(), gatewayKafkaStallMetric, 300)
+	if err != nil || stall < 600 {
+		t.Errorf("kafka stall = %v, %v; want at least 600", stall, err)
+	}
+	if _, err := s.gatewayHealthValue(context.Background(), "cpu", 300); err == nil {
+		t.Error("expected an error for a metric that is not a gateway health metric")
+	}
+}
+
+func TestEvaluateRuleGatewayHealth(t *testing.T) {
+	engine := NewAlertEngine(nil, nil, &config.Config{})
+	engine.gatewayHealth = func(ctx context.Context, metricType string, windowSec int) (float64, error) {
+		return 95, nil
+	}
+	var events []SystemEvent
+	engine.emitEvent = func(ev SystemEvent) { events = append(events, ev) }
+
+	rule := &pb.AlertRule{Id: "q", Name: "Queue saturated", MetricType: gatewayQueueSaturationMetric,
+		Threshold: 80, Comparison: "gt", WindowSec: 300, Enabled: true}
+	engine.evaluateRule(rule)
+	if len(events) != 1 || events[0].Type != "alert.fired" {
+		t.Fatalf("events = %+v, want one alert.fired", events)
+	}
+
+	engine.gatewayHealth = func(ctx context.Context, metricType string, windowSec int) (float64, error) {
+		return 10, nil
+	}
+	engine.evaluateRule(rule)
+	if len(events) != 2 || events[1].Type != "alert.resolved" {
+		t.Fatalf("events = %+v, want alert.resolved after the queue drained", events)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+// serviceMapMaxEdges caps each source of edges (access logs, spans) to its busiest edges
+const serviceMapMaxEdges = 500
+
+// ServiceMap is the topology of the fleet over a time range: agents, the upstreams they proxy to
+// (from the upstream_addr of access logs) and the traced services that call or are called by them
+// (from parent/child spans across sources)
+type ServiceMap struct {
+	From  int64            `json:"from"`
+	To    int64            `json:"to"`
+	Nodes []ServiceMapNode `json:"nodes"`
+	Edges []ServiceMapEdge `json:"edges"`
+}
+
+// ServiceMapNode is an agent, upstream or service; IDs are prefixed with the kind ("agent:a1")
+type ServiceMapNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"` // agent, upstream or service
+	Label string `json:"label"`
+}
+
+// ServiceMapEdge is the traffic from one node to another
+type ServiceMapEdge struct {
+	Source       string  `json:"source"`
+	Target       string  `json:"target"`
+	Requests     uint64  `json:"requests"`
+	RPS          float64 `json:"rps"`
+	ErrorRate    float64 `json:"error_rate"` // percent of requests that failed (5xx or error status)
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	Via          string  `json:"via"` // access_logs or spans
+}
+
+// serviceMapUpstreamExpr is the upstream that answered a request: NGINX lists every upstream tried
+// ("a:80, b:80") or internal redirects ("a:80 : b:80"), the last one served the response
+const serviceMapUpstreamExpr = `trimBoth(arrayElement(splitByRegexp('\\s*[,:]\\s+', %s), -1))`
+
+// GetServiceMap aggregates the edges of the service map of [from, to]. An empty filter covers
+// every agent; otherwise only edges touching the agents it selects are returned.
+func (db *ClickHouseDB) GetServiceMap(ctx context.Context, from, to time.Time, filter tenantFilter) (*ServiceMap, error) {
+	upstream := fmt.Sprintf(serviceMapUpstreamExpr, "upstream_addr")
+	upstreamStatus := fmt.Sprintf(serviceMapUpstreamExpr, "upstream_status")
+	filterClause, filterArgs := filter.and()
+	where := "WHERE timestamp >= ? AND timestamp <= ? AND upstream_addr != ''" + filterClause
+	args := append([]interface{}{from, to}, filterArgs...)
+	edges, err := db.queryServiceMapEdges(ctx, fmt.Sprintf(`
+		SELECT
+			concat('agent:', instance_id) AS source,
+			concat('upstream:', %s) AS target,
+			count() AS requests,
+			countIf(toUInt16OrZero(%s) >= 500 OR (%s = '' AND status >= 500)) AS errors,
+			avg(upstream_response_time) * 1000 AS avg_ms,
+			quantile(0.95)(upstream_response_time) * 1000 AS p95_ms
+		FROM nginx_analytics.access_logs
+		%s
+		GROUP BY source, target
+		ORDER BY requests DESC
+		LIMIT ?
+	`, upstream, upstreamStatus, upstreamStatus, where), append(args, serviceMapMaxEdges), "access_logs")
+	if err != nil {
+		return nil, err
+	}
+
+	// A span whose parent comes from another source is a call between two nodes: NGINX to a
+	// traced service through its upstream span, or a traced service to NGINX
+	spanNode := `if(%[1]s.attributes['service.name'] != '', concat('service:', %[1]s.attributes['service.name']), concat('agent:', %[1]s.instance_id))`
+	spanWhere := "WHERE c.instance_id != p.instance_id"
+	spanArgs := []interface{}{from, to, from, to}
+	if cond, condArgs := filter.cond("c"); cond != "" {
+		parentCond, _ := filter.cond("p")
+		spanWhere += " AND ((" + cond + ") OR (" + parentCond + "))"
+		spanArgs = append(append(spanArgs, condArgs...), condArgs...)
+	}
+	spanEdges, err := db.queryServiceMapEdges(ctx, fmt.Sprintf(`
+		SELECT
+			%s AS source,
+			%s AS target,
+			count() AS requests,
+			countIf(c.attributes['status.code'] = 'error'
+				OR toUInt16OrZero(c.attributes['status']) >= 500
+				OR toUInt16OrZero(c.attributes['http.response.status_code']) >= 500) AS errors,
+			avg(c.duration_ms) AS avg_ms,
+			quantile(0.95)(c.duration_ms) AS p95_ms
+		FROM (
+			SELECT trace_id, parent_span_id, instance_id, project_id, environment_id, attributes,
+				(toUnixTimestamp64Nano(end_time) - toUnixTimestamp64Nano(start_time)) / 1e6 AS duration_ms
+			FROM nginx_analytics.spans
+			WHERE start_time >= ? AND start_time <= ? AND parent_span_id != ''
+		) AS c
+		INNER JOIN (
+			SELECT trace_id, span_id, instance_id, project_id, environment_id, attributes
+			FROM nginx_analytics.spans
+			WHERE start_time >= ? AND start_time <= ?
+		) AS p ON c.trace_id = p.trace_id AND c.parent_span_id = p.span_id
+		%s
+		GROUP BY source, target
+		HAVING source != target
+		ORDER BY requests DESC
+		LIMIT ?
+	`, fmt.Sprintf(spanNode, "p"), fmt.Sprintf(spanNode, "c"), spanWhere), append(spanArgs, serviceMapMaxEdges), "spans")
+	if err != nil {
+		return nil, err
+	}
+	return newServiceMap(from, to, append(edges, spanEdges...)), nil
+}
+
+// queryServiceMapEdges scans source, target, requests, errors, avg_ms and p95_ms rows; RPS and
+// error rates are left to newServiceMap
+func (db *ClickHouseDB) queryServiceMapEdges(ctx context.Context, query string, args []interface{}, via string) ([]ServiceMapEdge, error) {
+	rows, err := db.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []ServiceMapEdge
+	for rows.Next() {
+		var e ServiceMapEdge
+		var errors uint64
+		if err := rows.Scan(&e.Source, &e.Target, &e.Requests, &errors, &e.AvgLatencyMs, &e.P95LatencyMs); err != nil {
+			return nil, err
+		}
+		if e.Requests > 0 {
+			e.ErrorRate = float64(errors) / float64(e.Requests) * 100
+		}
+		e.Via = via
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// newServiceMap derives the nodes of the edges and their request rates over [from, to]
+func newServiceMap(from, to time.Time, edges []ServiceMapEdge) *ServiceMap {
+	m := &ServiceMap{From: from.Unix(), To: to.Unix(), Nodes: []ServiceMapNode{}, Edges: []ServiceMapEdge{}}
+	seconds := to.Sub(from).Seconds()
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		if seconds > 0 {
+			e.RPS = float64(e.Requests) / seconds
+		}
+		m.Edges = append(m.Edges, e)
+		for _, id := range []string{e.Source, e.Target} {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			kind, label, _ := strings.Cut(id, ":")
+			m.Nodes = append(m.Nodes, ServiceMapNode{ID: id, Kind: kind, Label: label})
+		}
+	}
+	sort.Slice(m.Nodes, func(i, j int) bool { return m.Nodes[i].ID < m.Nodes[j].ID })
+	return m
+}
+
+// handleServiceMap handles GET /api/analytics/service-map?window=1h (or from/to in unix seconds)
+// with optional agent_id (comma separated), project_id or environment_id.
+func (s *server) handleServiceMap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, _ := strconv.ParseInt(query.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(query.Get("to"), 10, 64)
+	m, err := buildMetricQuery(MetricQuery{Metric: "requests", Window: query.Get("window"), From: from, To: to, Interval: "auto"}, time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, escapeJSON(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if s.clickhouse == nil {
+		http.Error(w, `{"error":"ClickHouse connection not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, visible, ok := s.analyticsFilterOf(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !visible {
+		json.NewEncoder(w).Encode(newServiceMap(m.From, m.To, nil))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	serviceMap, err := s.clickhouse.GetServiceMap(ctx, m.From, m.To, filter)
+	if err != nil {
+		logging.Ctx(r.Context(), gatewayLog).Error().Msgf("Service map query failed: %v", err)
+		http.Error(w, `{"error":"query failed"}`, clickHouseErrorStatus(w, err))
+		return
+	}
+	json.NewEncoder(w).Encode(serviceMap)
+}
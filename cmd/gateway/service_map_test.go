@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewServiceMap(t *testing.T) {
+	from := time.Unix(1_800_000_000, 0)
+	m := newServiceMap(from, from.Add(100*time.Second), []ServiceMapEdge{
+		{Source: "agent:a1", Target: "upstream:10.0.0.1:8080", Requests: 500, Via: "access_logs"},
+		{Source: "agent:a2", Target: "upstream:10.0.0.1:8080", Requests: 100, Via: "access_logs"},
+		{Source: "agent:a1", Target: "service:checkout", Requests: 50, Via: "spans"},
+	})
+
+	if len(m.Edges) != 3 || m.Edges[0].RPS != 5 || m.Edges[2].RPS != 0.5 {
+		t.Errorf("edges = %+v", m.Edges)
+	}
+	want := []ServiceMapNode{
+		{ID: "agent:a1", Kind: "agent", Label: "a1"},
+		{ID: "agent:a2", Kind: "agent", Label: "a2"},
+		{ID: "service:checkout", Kind: "service", Label: "checkout"},
+		{ID: "upstream:10.0.0.1:8080", Kind: "upstream", Label: "10.0.0.1:8080"},
+	}
+	if len(m.Nodes) != len(want) {
+		t.Fatalf("nodes = %+v", m.Nodes)
+	}
+	for i := range want {
+		if m.Nodes[i] != want[i] {
+			t.Errorf("node %d = %+v, want %+v", i, m.Nodes[i], want[i])
+		}
+	}
+
+	if empty := newServiceMap(from, from, nil); empty.Nodes == nil || empty.Edges == nil {
+		t.Error("an empty map should encode empty lists, not null")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Component and page states, best first
+const (
+	statusOperational   = "operational"
+	statusUnknown       = "unknown" // No results yet
+	statusPartialOutage = "partial_outage"
+	statusMajorOutage   = "major_outage"
+)
+
+var statusRank = map[string]int{statusOperational: 0, statusUnknown: 1, statusPartialOutage: 2, statusMajorOutage: 3}
+
+// statusPageHistoryDays is how far back uptime bars and incidents reach
+const statusPageHistoryDays = 90
+
+// statusPageCacheTTL bounds how often a public page hits ClickHouse
+const statusPageCacheTTL = 30 * time.Second
+
+var statusPageSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,98}[a-z0-9]$`)
+
+// StatusPageView is what a status page shows. It names components, never the checks or targets behind them.
+type StatusPageView struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	Status      string                `json:"status"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Components  []StatusComponentView `json:"components"`
+	SLOs        []StatusSLOView       `json:"slos"`
+	Incidents   []StatusIncidentView  `json:"incidents"`
+}
+
+type StatusComponentView struct {
+	Name   string              `json:"name"`
+	Status string              `json:"status"`
+	Uptime map[string]*float64 `json:"uptime"` // "7d", "30d", "90d"; null without results
+	Days   []StatusDayView     `json:"days"`   // Oldest first
+}
+
+type StatusDayView struct {
+	Date      string   `json:"date"`
+	UptimePct *float64 `json:"uptime_pct"`
+}
+
+type StatusIncidentView struct {
+	Component   string     `json:"component"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	DurationSec int64      `json:"duration_sec"`
+}
+
+type StatusSLOView struct {
+	Type    string  `json:"type"`
+	Window  string  `json:"window"`
+	Target  float64 `json:"target"`
+	Current float64 `json:"current"`
+	Met     bool    `json:"met"`
+}
+
+type cachedStatusPage struct {
+	at   time.Time
+	view *StatusPageView
+}
+
+func validateStatusPage(p *StatusPage) error {
+	p.Slug = strings.ToLower(strings.TrimSpace(p.Slug))
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Components == nil {
+		p.Components = []StatusPageComponent{}
+	}
+	if p.SLOTargetIDs == nil {
+		p.SLOTargetIDs = []string{}
+	}
+	if p.ProjectID == "" {
+		return fmt.Errorf("project_id is required")
+	}
+	if !statusPageSlugPattern.MatchString(p.Slug) {
+		return fmt.Errorf("slug must be 3-100 lowercase letters, digits or dashes")
+	}
+	if p.Title == "" || len(p.Title) > 200 {
+		return fmt.Errorf("title must be 1-200 characters")
+	}
+	if len(p.Components) > 50 {
+		return fmt.Errorf("at most 50 components")
+	}
+	for i := range p.Components {
+		comp := &p.Components[i]
+		comp.Name = strings.TrimSpace(comp.Name)
+		if comp.Name == "" {
+			return fmt.Errorf("component %d needs a name", i+1)
+		}
+		if len(comp.CheckIDs) == 0 {
+			return fmt.Errorf("component %s needs at least one check", comp.Name)
+		}
+	}
+	return nil
+}
+
+// statusPageTokenValid compares a presented token with the stored hash in constant time
+func statusPageTokenValid(p *StatusPage, token string) bool {
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(sha256Hex(token)), []byte(p.tokenHash)) == 1
+}
+
+// componentStatus derives a component's state from how many of its checks are down
+func componentStatus(checks, down int, hasResults bool) string {
+	switch {
+	case down == 0 && !hasResults:
+		return statusUnknown
+	case down == 0:
+		return statusOperational
+	case down >= checks:
+		return statusMajorOutage
+	default:
+		return statusPartialOutage
+	}
+}
+
+func uptimePct(runs, failures uint64) *float64 {
+	if runs == 0 {
+		return nil
+	}
+	pct := float64(runs-failures) / float64(runs) * 100
+	return &pct
+}
+
+// assembleStatusPage builds the component, uptime and incident sections of a page from daily
+// result counts and the incidents of its checks
+func assembleStatusPage(p *StatusPage, days []SyntheticDay, incidents []SyntheticIncident, now time.Time) *StatusPageView {
+	view := &StatusPageView{
+		Title:       p.Title,
+		Description: p.Description,
+		GeneratedAt: now,
+		Components:  []StatusComponentView{},
+		SLOs:        []StatusSLOView{},
+		Incidents:   []StatusIncidentView{},
+	}
+
+	type counts struct{ runs, failures uint64 }
+	byCheck := map[string]map[string]counts{} // check -> day -> counts
+	for _, d := range days {
+		if byCheck[d.CheckID] == nil {
+			byCheck[d.CheckID] = map[string]counts{}
+		}
+		c := byCheck[d.CheckID][d.Day]
+		byCheck[d.CheckID][d.Day] = counts{c.runs + d.Runs, c.failures + d.Failures}
+	}
+	down := map[string]bool{}
+	for _, in := range incidents {
+		if in.ResolvedAt == nil {
+			down[in.CheckID] = true
+		}
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	componentOf := map[string]string{}
+	for _, comp := range p.Components {
+		cv := StatusComponentView{Name: comp.Name, Uptime: map[string]*float64{}}
+		nDown := 0
+		perDay := make([]counts, statusPageHistoryDays)
+		for _, id := range comp.CheckIDs {
+			if _, ok := componentOf[id]; !ok {
+				componentOf[id] = comp.Name
+			}
+			if down[id] {
+				nDown++
+			}
+			for i := range perDay {
+				c := byCheck[id][today.AddDate(0, 0, i-statusPageHistoryDays+1).Format("2006-01-02")]
+				perDay[i].runs += c.runs
+				perDay[i].failures += c.failures
+			}
+		}
+
+		var total counts
+		for i, c := range perDay {
+			cv.Days = append(cv.Days, StatusDayView{
+				Date:      today.AddDate(0, 0, i-statusPageHistoryDays+1).Format("2006-01-02"),
+				UptimePct: uptimePct(c.runs, c.failures),
+			})
+			total.runs += c.runs
+			total.failures += c.failures
+		}
+		for _, window := range []int{7, 30, 90} {
+			var wc counts
+			for _, c := range perDay[statusPageHistoryDays-window:] {
+				wc.runs += c.runs
+				wc.failures += c.failures
+			}
+			cv.Uptime[fmt.Sprintf("%dd", window)] = uptimePct(wc.runs, wc.failures)
+		}
+		cv.Status = componentStatus(len(comp.CheckIDs), nDown, total.runs > 0)
+		view.Components = append(view.Components, cv)
+	}
+
+	view.Status = statusUnknown
+	if len(view.Components) > 0 {
+		view.Status = statusOperational
+		known := false
+		for _, cv := range view.Components {
+			if cv.Status == statusUnknown {
+				continue
+			}
+			known = true
+			if statusRank[cv.Status] > statusRank[view.Status] {
+				view.Status = cv.Status
+			}
+		}
+		if !known {
+			view.Status = statusUnknown
+		}
+	}
+
+	for _, in := range incidents {
+		iv := StatusIncidentView{Component: componentOf[in.CheckID], StartedAt: in.StartedAt, ResolvedAt: in.ResolvedAt}
+		end := now
+		if in.ResolvedAt != nil {
+			end = *in.ResolvedAt
+		}
+		iv.DurationSec = int64(end.Sub(in.StartedAt).Seconds())
+		view.Incidents = append(view.Incidents, iv)
+	}
+	return view
+}
+
+// buildStatusPageView gathers results, incidents and SLO compliance for a page
+func (s *server) buildStatusPageView(ctx context.Context, p *StatusPage) (*StatusPageView, error) {
+	now := time.Now()
+	since := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-statusPageHistoryDays)
+
+	var checkIDs []string
+	for _, comp := range p.Components {
+		checkIDs = append(checkIDs, comp.CheckIDs...)
+	}
+	days := []SyntheticDay{}
+	if s.clickhouse != nil {
+		var err error
+		if days, err = s.clickhouse.GetSyntheticDailyCounts(ctx, checkIDs, since, now); err != nil {
+			return nil, err
+		}
+	}
+	incidents, err := s.db.ListSyntheticIncidents(checkIDs, since, 100)
+	if err != nil {
+		return nil, err
+	}
+	view := assembleStatusPage(p, days, incidents, now)
+
+	if len(p.SLOTargetIDs) > 0 && s.clickhouse != nil {
+		targets, err := s.db.ListSLOTargets()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range targets {
+			if !stringInSlice(p.SLOTargetIDs, t.ID) {
+				continue
+			}
+			sli, err := s.clickhouse.GetSLI(ctx, t.EntityType, t.EntityID, t.SLOType, t.TimeWindow)
+			if err != nil {
+				continue
+			}
+			met := sli >= t.TargetValue
+			if t.SLOType == "latency" {
+				met = sli <= t.TargetValue
+			}
+			view.SLOs = append(view.SLOs, StatusSLOView{Type: t.SLOType, Window: t.TimeWindow, Target: t.TargetValue, Current: sli, Met: met})
+		}
+	}
+	return view, nil
+}
+
+// cachedStatusPageView serves public requests from a short-lived cache
+func (s *server) cachedStatusPageView(ctx context.Context, p *StatusPage) (*StatusPageView, error) {
+	if v, ok := s.statusPageCache.Load(p.ID); ok {
+		if c := v.(*cachedStatusPage); time.Since(c.at) < statusPageCacheTTL {
+			return c.view, nil
+		}
+	}
+	view, err := s.buildStatusPageView(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	s.statusPageCache.Store(p.ID, &cachedStatusPage{at: time.Now(), view: view})
+	return view, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValidateStatusPage(t *testing.T) {
+	p := StatusPage{ProjectID: "p1", Slug: " Acme-Status ", Title: " Acme ", Components: []StatusPageComponent{{Name: "API", CheckIDs: []string{"c1"}}}}
+	if err := validateStatusPage(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Slug != "acme-status" || p.Title != "Acme" || p.SLOTargetIDs == nil {
+		t.Errorf("not normalized: %+v", p)
+	}
+
+	bad := []StatusPage{
+		{Slug: "acme", Title: "Acme"},
+		{ProjectID: "p1", Slug: "a", Title: "Acme"},
+		{ProjectID: "p1", Slug: "acme/status", Title: "Acme"},
+		{ProjectID: "p1", Slug: "acme", Title: ""},
+		{ProjectID: "p1", Slug: "acme", Title: "Acme", Components: []StatusPageComponent{{Name: "API"}}},
+		{ProjectID: "p1", Slug: "acme", Title: "Acme", Components: []StatusPageComponent{{CheckIDs: []string{"c1"}}}},
+	}
+	for i := range bad {
+		if err := validateStatusPage(&bad[i]); err == nil {
+			t.Errorf("page %d should be rejected: %+v", i, bad[i])
+		}
+	}
+}
+
+func TestStatusPageTokenValid(t *testing.T) {
+	p := &StatusPage{tokenHash: sha256Hex("secret")}
+	if !statusPageTokenValid(p, "secret") {
+		t.Error("matching token rejected")
+	}
+	if statusPageTokenValid(p, "guess") || statusPageTokenValid(p, "") {
+		t.Error("wrong or empty token accepted")
+	}
+}
+
+func TestComponentStatus(t *testing.T) {
+	tests := []struct {
+		checks, down int
+		results      bool
+		want         string
+	}{
+		{2, 0, true, statusOperational},
+		{2, 0, false, statusUnknown},
+		{2, 1, true, statusPartialOutage},
+		{2, 2, true, statusMajorOutage},
+		{1, 1, false, statusMajorOutage},
+	}
+	for _, tt := range tests {
+		if got := componentStatus(tt.checks, tt.down, tt.results); got != tt.want {
+			t.Errorf("componentStatus(%d, %d, %v) = %s, want %s", tt.checks, tt.down, tt.results, got, tt.want)
+		}
+	}
+}
+
+func TestAssembleStatusPage(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	p := &StatusPage{
+		Title: "Acme",
+		Components: []StatusPageComponent{
+			{Name: "API", CheckIDs: []string{"api-eu", "api-us"}},
+			{Name: "Website", CheckIDs: []string{"web"}},
+			{Name: "Docs", CheckIDs: []string{"docs"}},
+		},
+	}
+	days := []SyntheticDay{
+		{CheckID: "api-eu", Day: "2026-03-10", Runs: 100, Failures: 10},
+		{CheckID: "api-us", Day: "2026-03-10", Runs: 100, Failures: 0},
+		{CheckID: "api-eu", Day: "2026-02-01", Runs: 100, Failures: 100}, // Outside the 30 day window
+		{CheckID: "web", Day: "2026-03-09", Runs: 50, Failures: 0},
+	}
+	resolved := now.Add(-time.Hour)
+	incidents := []SyntheticIncident{
+		{CheckID: "api-eu", StartedAt: now.Add(-10 * time.Minute)},
+		{CheckID: "web", StartedAt: now.Add(-2 * time.Hour), ResolvedAt: &resolved},
+	}
+
+	view := assembleStatusPage(p, days, incidents, now)
+	if len(view.Components) != 3 {
+		t.Fatalf("components = %d", len(view.Components))
+	}
+	api, web, docs := view.Components[0], view.Components[1], view.Components[2]
+	if api.Status != statusPartialOutage || web.Status != statusOperational || docs.Status != statusUnknown {
+		t.Errorf("statuses = %s, %s, %s", api.Status, web.Status, docs.Status)
+	}
+	if view.Status != statusPartialOutage {
+		t.Errorf("page status = %s", view.Status)
+	}
+	if got := api.Uptime["30d"]; got == nil || *got != 95 {
+		t.Errorf("API 30d uptime = %v, want 95", got)
+	}
+	if got := api.Uptime["90d"]; got == nil || math.Abs(*got-190.0/3) > 1e-9 {
+		t.Errorf("API 90d uptime = %v, want 63.3", got)
+	}
+	if docs.Uptime["7d"] != nil {
+		t.Error("component without results should have no uptime")
+	}
+	if len(api.Days) != statusPageHistoryDays || api.Days[len(api.Days)-1].Date != "2026-03-10" {
+		t.Errorf("days end at %s, want today", api.Days[len(api.Days)-1].Date)
+	}
+
+	if len(view.Incidents) != 2 || view.Incidents[0].Component != "API" || view.Incidents[0].DurationSec != 600 {
+		t.Errorf("incidents = %+v", view.Incidents)
+	}
+	if view.Incidents[1].DurationSec != 3600 {
+		t.Errorf("resolved incident duration = %d", view.Incidents[1].DurationSec)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// agentKeepaliveMinTime is the shortest keepalive ping interval accepted from agents; agents ping
+// every 20s
+const agentKeepaliveMinTime = 10 * time.Second
+
+// errStreamStale ends a Commander stream that stopped delivering messages
+var errStreamStale = status.Error(codes.Unavailable, "no message from agent within heartbeat timeout")
+
+var avikaAgentStreamsStaleTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "avika_agent_streams_stale_total",
+	Help: "Agent streams dropped after no message within the heartbeat timeout",
+})
+
+func init() {
+	prometheus.MustRegister(avikaAgentStreamsStaleTotal)
+}
+
+// grpcKeepaliveOptions pings idle agent connections so a connection lost without a FIN (NAT
+// timeout, crashed host) fails within keepalive_time + keepalive_timeout
+func grpcKeepaliveOptions(cfg *config.Config) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             agentKeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if cfg.Agent.KeepaliveTime > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.Agent.KeepaliveTime,
+			Timeout: cfg.Agent.KeepaliveTimeout,
+		}))
+	}
+	return opts
+}
+
+// agentRecv is one result of Recv on a Commander stream
+type agentRecv struct {
+	msg *pb.AgentMessage
+	err error
+}
+
+// receiveAgentMessages reads a Commander stream in the background so the caller can time out
+// waiting for it. It stops after the first error or once the stream's handler returned.
+func receiveAgentMessages(stream pb.Commander_ConnectServer) <-chan agentRecv {
+	ch := make(chan agentRecv)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			select {
+			case ch <- agentRecv{msg: msg, err: err}:
+			case <-stream.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// heartbeatTimeout is how long a Commander stream may stay silent, 0 never
+func (s *server) heartbeatTimeout() time.Duration {
+	if s.config == nil {
+		return 0
+	}
+	return s.config.Agent.HeartbeatTimeout
+}
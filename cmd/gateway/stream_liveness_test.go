@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// silentStream is a half-open agent connection: Recv blocks until the handler returns
+type silentStream struct {
+	pb.Commander_ConnectServer
+	ctx context.Context
+}
+
+func (s *silentStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *silentStream) Recv() (*pb.AgentMessage, error) {
+	<-s.ctx.Done()
+	return nil, s.ctx.Err()
+}
+
+func TestConnectDropsStaleStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &server{config: &config.Config{Agent: config.AgentConfig{HeartbeatTimeout: 50 * time.Millisecond}}}
+	before := testutil.ToFloat64(avikaAgentStreamsStaleTotal)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Connect(&silentStream{ctx: ctx}) }()
+	select {
+	case err := <-done:
+		if err != errStreamStale {
+			t.Errorf("Connect = %v, want errStreamStale", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Connect did not drop the silent stream")
+	}
+	if got := testutil.ToFloat64(avikaAgentStreamsStaleTotal) - before; got != 1 {
+		t.Errorf("stale streams counted = %v, want 1", got)
+	}
+}
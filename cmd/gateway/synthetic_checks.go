@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// syntheticCheckMetric is the alert rule metric type whose recipients are told when a synthetic
+// check goes down or recovers. Like cert_expiry it is event driven, not evaluated on a schedule.
+const syntheticCheckMetric = "synthetic_check"
+
+const (
+	syntheticCheckHTTP = "http"
+	syntheticCheckDNS  = "dns"
+	syntheticCheckTCP  = "tcp"
+	syntheticCheckUDP  = "udp"
+	syntheticCheckTLS  = "tls"
+)
+
+// syntheticCheckTypes lists the supported check_type values
+var syntheticCheckTypes = []string{syntheticCheckHTTP, syntheticCheckDNS, syntheticCheckTCP, syntheticCheckUDP, syntheticCheckTLS}
+
+var syntheticHTTPMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// syntheticMaxBody bounds how much of a response body is read for assertions
+const syntheticMaxBody = 1 << 20
+
+// SyntheticCheckSettings are the type-specific request options and assertions of a check
+type SyntheticCheckSettings struct {
+	// HTTP; TCP and UDP send Body as their payload and match the reply against BodyContains/BodyRegex
+	Method          string            `json:"method,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	ExpectedStatus  []int             `json:"expected_status,omitempty"` // Empty accepts any 2xx/3xx
+	BodyContains    string            `json:"body_contains,omitempty"`
+	BodyRegex       string            `json:"body_regex,omitempty"`
+	FollowRedirects bool              `json:"follow_redirects,omitempty"`
+	SkipTLSVerify   bool              `json:"skip_tls_verify,omitempty"` // Also applies to TLS checks
+
+	// DNS
+	RecordType     string   `json:"record_type,omitempty"`     // A, AAAA, CNAME, MX, NS or TXT
+	Resolver       string   `json:"resolver,omitempty"`        // host:port; empty uses the system resolver
+	ExpectedValues []string `json:"expected_values,omitempty"` // Each must be among the answers
+
+	// TLS
+	ServerName   string `json:"server_name,omitempty"`    // SNI; defaults to the target host
+	MinValidDays int    `json:"min_valid_days,omitempty"` // Fail when the leaf certificate expires sooner
+}
+
+// SyntheticResult is the outcome of one check execution
+type SyntheticResult struct {
+	Timestamp  time.Time `json:"timestamp"`
+	CheckID    string    `json:"check_id"`
+	Region     string    `json:"region"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"status_code,omitempty"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"` // Transport error or the first failed assertion
+}
+
+// validateSyntheticCheck fills defaults and rejects checks the runner cannot execute
+func validateSyntheticCheck(c *SyntheticCheck) error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.CheckType = strings.ToLower(c.CheckType)
+	if c.CheckType == "" {
+		c.CheckType = syntheticCheckHTTP
+	}
+	if c.IntervalSec == 0 {
+		c.IntervalSec = 60
+	}
+	if c.TimeoutMs == 0 {
+		c.TimeoutMs = 10000
+	}
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = 2
+	}
+	if c.Regions == nil {
+		c.Regions = []string{}
+	}
+
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !stringInSlice(syntheticCheckTypes, c.CheckType) {
+		return fmt.Errorf("check_type must be one of %s", strings.Join(syntheticCheckTypes, ", "))
+	}
+	if c.IntervalSec < 10 || c.IntervalSec > 86400 {
+		return fmt.Errorf("interval_sec must be between 10 and 86400")
+	}
+	if c.TimeoutMs < 100 || c.TimeoutMs > 60000 {
+		return fmt.Errorf("timeout_ms must be between 100 and 60000")
+	}
+	if c.MaxLatencyMs < 0 {
+		return fmt.Errorf("max_latency_ms must not be negative")
+	}
+	if c.FailureThreshold < 1 {
+		return fmt.Errorf("failure_threshold must be at least 1")
+	}
+
+	s := &c.Settings
+	switch c.CheckType {
+	case syntheticCheckHTTP:
+		u, err := url.Parse(c.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("target must be an http(s) URL")
+		}
+		s.Method = strings.ToUpper(s.Method)
+		if s.Method == "" {
+			s.Method = "GET"
+		}
+		if !stringInSlice(syntheticHTTPMethods, s.Method) {
+			return fmt.Errorf("unsupported method %s", s.Method)
+		}
+		for _, code := range s.ExpectedStatus {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("expected_status %d is not an HTTP status", code)
+			}
+		}
+	case syntheticCheckDNS:
+		c.Target = strings.TrimSuffix(strings.TrimSpace(c.Target), ".")
+		if c.Target == "" || strings.ContainsAny(c.Target, "/: ") {
+			return fmt.Errorf("target must be a hostname")
+		}
+		s.RecordType = strings.ToUpper(s.RecordType)
+		if s.RecordType == "" {
+			s.RecordType = "A"
+		}
+		if !stringInSlice(syntheticDNSRecordTypes, s.RecordType) {
+			return fmt.Errorf("record_type must be one of %s", strings.Join(syntheticDNSRecordTypes, ", "))
+		}
+		if s.Resolver != "" {
+			if _, _, err := net.SplitHostPort(s.Resolver); err != nil {
+				return fmt.Errorf("resolver must be host:port")
+			}
+		}
+	case syntheticCheckTCP, syntheticCheckUDP, syntheticCheckTLS:
+		if c.CheckType == syntheticCheckTLS && !strings.Contains(c.Target, ":") {
+			c.Target = net.JoinHostPort(c.Target, "443")
+		}
+		if _, port, err := net.SplitHostPort(c.Target); err != nil || port == "" {
+			return fmt.Errorf("target must be host:port")
+		}
+		if c.CheckType == syntheticCheckUDP && s.Body == "" {
+			return fmt.Errorf("udp checks need a body to send")
+		}
+		if s.MinValidDays < 0 {
+			return fmt.Errorf("min_valid_days must not be negative")
+		}
+	}
+	if s.BodyRegex != "" {
+		if _, err := regexp.Compile(s.BodyRegex); err != nil {
+			return fmt.Errorf("invalid body_regex: %v", err)
+		}
+	}
+	return nil
+}
+
+// syntheticCGNAT is the shared address space some clouds serve metadata from (100.100.100.200)
+var syntheticCGNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// parseSyntheticNetworks parses the configured allowed_networks, skipping invalid entries
+func parseSyntheticNetworks(entries []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if p, err := netip.ParsePrefix(e); err == nil {
+			prefixes = append(prefixes, p.Masked())
+		} else if a, err := netip.ParseAddr(e); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+		} else if e != "" {
+			alertsLog.Warn().Msgf("Ignoring invalid synthetic allowed network %q", e)
+		}
+	}
+	return prefixes
+}
+
+// syntheticAddrAllowed reports whether a check may reach addr: any public address, and internal
+// ones (loopback, private, link-local, metadata, unspecified) only inside allowed
+func syntheticAddrAllowed(addr netip.Addr, allowed []netip.Prefix) bool {
+	addr = addr.Unmap()
+	internal := addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() || syntheticCGNAT.Contains(addr)
+	if !internal {
+		return true
+	}
+	for _, p := range allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// syntheticDialer dials like net.Dialer but refuses internal addresses outside allowed. The address
+// is checked after resolution, on every connection, so a name that resolves inward, including one
+// rebound after the check was saved, is refused too.
+func syntheticDialer(allowed []netip.Prefix) *net.Dialer {
+	return &net.Dialer{
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !syntheticAddrAllowed(ap.Addr(), allowed) {
+				return fmt.Errorf("%s is an internal address outside synthetic.allowed_networks", ap.Addr().Unmap())
+			}
+			return nil
+		},
+	}
+}
+
+// checkSyntheticTarget refuses a target, or DNS resolver, that is a literal internal address outside
+// allowed when the check is saved; names are only resolved, and checked, when the check runs
+func checkSyntheticTarget(c *SyntheticCheck, allowed []netip.Prefix) error {
+	host := c.Target
+	if c.CheckType == syntheticCheckDNS {
+		host, _, _ = net.SplitHostPort(c.Settings.Resolver) // DNS checks only connect to their resolver
+	} else if c.CheckType == syntheticCheckHTTP {
+		if u, err := url.Parse(c.Target); err == nil {
+			host = u.Hostname()
+		}
+	} else if h, _, err := net.SplitHostPort(c.Target); err == nil {
+		host = h
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !syntheticAddrAllowed(addr, allowed) {
+		return fmt.Errorf("%s is an internal address outside synthetic.allowed_networks", host)
+	}
+	return nil
+}
+
+// runSyntheticCheck executes a check once; it may only reach the internal addresses in allowed
+func runSyntheticCheck(ctx context.Context, c *SyntheticCheck, region string, allowed []netip.Prefix) SyntheticResult {
+	res := SyntheticResult{Timestamp: time.Now(), CheckID: c.ID, Region: region}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	var err error
+	switch c.CheckType {
+	case syntheticCheckHTTP:
+		err = runHTTPCheck(ctx, c, &res, allowed)
+	case syntheticCheckDNS:
+		err = runDNSCheck(ctx, c, &res, allowed)
+	case syntheticCheckTCP, syntheticCheckUDP:
+		err = runSocketCheck(ctx, c, &res, allowed)
+	case syntheticCheckTLS:
+		err = runTLSCheck(ctx, c, &res, allowed)
+	default:
+		err = fmt.Errorf("unsupported check type %s", c.CheckType)
+	}
+	if err == nil && c.MaxLatencyMs > 0 && res.LatencyMs > float64(c.MaxLatencyMs) {
+		err = fmt.Errorf("latency %.0fms exceeds %dms", res.LatencyMs, c.MaxLatencyMs)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.Success = err == nil
+	return res
+}
+
+func runHTTPCheck(ctx context.Context, c *SyntheticCheck, res *SyntheticResult, allowed []netip.Prefix) error {
+	s := c.Settings
+	var body io.Reader
+	if s.Body != "" {
+		body = strings.NewReader(s.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, s.Method, c.Target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Avika-Synthetic/1.0")
+	for k, v := range s.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v // Probe a virtual host through its IP
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	// No proxy: the dialer would check the proxy's address rather than the target's
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:       syntheticDialer(allowed).DialContext,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: s.SkipTLSVerify},
+			DisableKeepAlives: true,
+		},
+	}
+	if !s.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, syntheticMaxBody))
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	res.StatusCode = resp.StatusCode
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+
+	if len(s.ExpectedStatus) > 0 {
+		ok := false
+		for _, code := range s.ExpectedStatus {
+			if resp.StatusCode == code {
+				ok = true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("status %d not in %v", resp.StatusCode, s.ExpectedStatus)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return matchSyntheticBody(s, content)
+}
+
+// matchSyntheticBody applies the body_contains and body_regex assertions to a response
+func matchSyntheticBody(s SyntheticCheckSettings, content []byte) error {
+	if s.BodyContains != "" && !bytes.Contains(content, []byte(s.BodyContains)) {
+		return fmt.Errorf("body does not contain %q", s.BodyContains)
+	}
+	if s.BodyRegex != "" {
+		re, err := regexp.Compile(s.BodyRegex)
+		if err != nil {
+			return err
+		}
+		if !re.Match(content) {
+			return fmt.Errorf("body does not match %q", s.BodyRegex)
+		}
+	}
+	return nil
+}
+
+// syntheticState tracks scheduling and up/down state of one check on this gateway
+type syntheticState struct {
+	lastRun  time.Time
+	running  bool
+	failures int // Consecutive failures
+	down     bool
+	seen     bool // A result was recorded since the gateway started
+}
+
+// SyntheticRunner schedules the checks assigned to this gateway's region
+type SyntheticRunner struct {
+	region  string
+	allowed []netip.Prefix // Internal networks checks may reach
+	sem     chan struct{}
+
+	mu       sync.Mutex
+	checks   []SyntheticCheck
+	loadedAt time.Time
+	state    map[string]*syntheticState
+}
+
+func NewSyntheticRunner(region string, concurrency int, allowed []netip.Prefix) *SyntheticRunner {
+	if concurrency <= 0 {
+		concurrency = 20
+	}
+	return &SyntheticRunner{
+		region:  region,
+		allowed: allowed,
+		sem:     make(chan struct{}, concurrency),
+		state:   make(map[string]*syntheticState),
+	}
+}
+
+// runsIn reports whether a check with the given regions runs on a gateway in region
+func syntheticRunsIn(regions []string, region string) bool {
+	return len(regions) == 0 || stringInSlice(regions, region)
+}
+
+// startSyntheticChecks runs due synthetic checks every few seconds
+func (s *server) startSyntheticChecks() {
+	if s.synthetic == nil || s.db == nil {
+		return
+	}
+	alertsLog.Info().Str("region", s.synthetic.region).Msg("Starting synthetic check runner")
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			// With several gateways in a region, one of them runs its checks
+			if s.leases.holds(syntheticLease(s.synthetic.region)) {
+				s.runDueSyntheticChecks(time.Now())
+			}
+		}
+	}()
+}
+
+func (s *server) runDueSyntheticChecks(now time.Time) {
+	r := s.synthetic
+	r.mu.Lock()
+	if now.Sub(r.loadedAt) >= 30*time.Second {
+		checks, err := s.db.ListSyntheticChecks()
+		if err != nil {
+			alertsLog.Error().Msgf("Synthetic: failed to load checks: %v", err)
+		} else {
+			r.checks, r.loadedAt = checks, now
+		}
+	}
+	var due []SyntheticCheck
+	for _, c := range r.checks {
+		if !c.Enabled || !syntheticRunsIn(c.Regions, r.region) {
+			continue
+		}
+		st, ok := r.state[c.ID]
+		if !ok {
+			st = &syntheticState{}
+			r.state[c.ID] = st
+		}
+		if st.running || now.Sub(st.lastRun) < time.Duration(c.IntervalSec)*time.Second {
+			continue
+		}
+		st.lastRun, st.running = now, true
+		due = append(due, c)
+	}
+	r.mu.Unlock()
+
+	for i := range due {
+		go func(c SyntheticCheck) {
+			r.sem <- struct{}{}
+			res := runSyntheticCheck(context.Background(), &c, r.region, r.allowed)
+			<-r.sem
+			s.recordSyntheticResult(&c, res)
+		}(due[i])
+	}
+}
+
+// recordSyntheticResult stores a result, feeds the server uptime view and raises down/recovered events
+func (s *server) recordSyntheticResult(c *SyntheticCheck, res SyntheticResult) {
+	if s.clickhouse != nil {
+		s.clickhouse.InsertSyntheticResult(res)
+	}
+
+	if c.AgentID != "" {
+		status := "UP"
+		if !res.Success {
+			status = "DOWN"
+		}
+		report := &pb.UptimeReport{
+			Timestamp: res.Timestamp.Unix(),
+			Status:    status,
+			LatencyMs: float32(res.LatencyMs),
+			CheckType: strings.ToUpper(c.CheckType),
+			Target:    c.Target,
+			Error:     res.Error,
+		}
+		var reports []*pb.UptimeReport
+		if val, ok := s.uptimeReports.Load(c.AgentID); ok {
+			reports = val.([]*pb.UptimeReport)
+		}
+		reports = append([]*pb.UptimeReport{report}, reports...)
+		if len(reports) > 50 {
+			reports = reports[:50]
+		}
+		s.uptimeReports.Store(c.AgentID, reports)
+	}
+
+	r := s.synthetic
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	st := r.state[c.ID]
+	if st == nil {
+		st = &syntheticState{}
+		r.state[c.ID] = st
+	}
+	st.running = false
+	first := !st.seen
+	st.seen = true
+	var transition string
+	if res.Success {
+		st.failures = 0
+		if st.down {
+			st.down, transition = false, "recovered"
+		}
+	} else {
+		st.failures++
+		if !st.down && st.failures >= c.FailureThreshold {
+			st.down, transition = true, "down"
+		}
+	}
+	r.mu.Unlock()
+
+	if s.db != nil {
+		var err error
+		switch {
+		case transition == "down":
+			err = s.db.OpenSyntheticIncident(c.ID, res.Region, res.Error, res.Timestamp)
+		case transition == "recovered" || (first && res.Success):
+			// The first pass after a restart also closes an incident left open by the previous run
+			err = s.db.ResolveSyntheticIncident(c.ID, res.Region, res.Timestamp)
+		}
+		if err != nil {
+			alertsLog.Error().Msgf("Synthetic: failed to record incident for check %s: %v", c.ID, err)
+		}
+	}
+	if transition != "" {
+		s.notifySyntheticTransition(c, res, transition)
+	}
+}
+
+// notifySyntheticTransition tells the recipients of enabled synthetic_check alert rules that a check
+// went down or recovered
+func (s *server) notifySyntheticTransition(c *SyntheticCheck, res SyntheticResult, transition string) {
+	severity, subject := "critical", fmt.Sprintf("[CRITICAL] Synthetic check %s is down", c.Name)
+	body := fmt.Sprintf("Check '%s' (%s %s) failed %d consecutive times from region %s.\n\nLast error: %s\nLatency: %.0fms",
+		c.Name, strings.ToUpper(c.CheckType), c.Target, c.FailureThreshold, res.Region, res.Error, res.LatencyMs)
+	if transition == "recovered" {
+		severity, subject = "info", fmt.Sprintf("[RESOLVED] Synthetic check %s recovered", c.Name)
+		body = fmt.Sprintf("Check '%s' (%s %s) is passing again from region %s.\n\nLatency: %.0fms",
+			c.Name, strings.ToUpper(c.CheckType), c.Target, res.Region, res.LatencyMs)
+	}
+	alertsLog.Info().Msgf("SYNTHETIC CHECK %s: %s (%s)", strings.ToUpper(transition), c.Name, c.Target)
+
+	if s.alerts == nil || s.db == nil {
+		return
+	}
+	rules, err := s.db.ListAlertRules()
+	if err != nil {
+		alertsLog.Error().Msgf("Synthetic: failed to list alert rules: %v", err)
+		return
+	}
+	for _, rule := range rules {
+		if rule.Enabled && rule.MetricType == syntheticCheckMetric && rule.Recipients != "" {
+			s.alerts.notifyRecipients(rule.Recipients, severity, subject, body)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// syntheticTestNetworks lets checks reach the test servers on loopback
+var syntheticTestNetworks = parseSyntheticNetworks([]string{"127.0.0.0/8", "::1"})
+
+func TestValidateSyntheticCheck(t *testing.T) {
+	c := SyntheticCheck{Name: " home ", Target: "https://example.com/"}
+	if err := validateSyntheticCheck(&c); err != nil {
+		t.Fatalf("validateSyntheticCheck: %v", err)
+	}
+	if c.Name != "home" || c.CheckType != syntheticCheckHTTP || c.Settings.Method != "GET" {
+		t.Errorf("defaults not applied: %+v", c)
+	}
+	if c.IntervalSec != 60 || c.TimeoutMs != 10000 || c.FailureThreshold != 2 {
+		t.Errorf("interval/timeout/threshold = %d/%d/%d", c.IntervalSec, c.TimeoutMs, c.FailureThreshold)
+	}
+
+	bad := []SyntheticCheck{
+		{Target: "https://example.com"},
+		{Name: "x", Target: "ftp://example.com"},
+		{Name: "x", Target: "example.com"},
+		{Name: "x", Target: "https://example.com", CheckType: "icmp"},
+		{Name: "x", Target: "https://example.com", IntervalSec: 5},
+		{Name: "x", Target: "https://example.com", Settings: SyntheticCheckSettings{Method: "TRACE"}},
+		{Name: "x", Target: "https://example.com", Settings: SyntheticCheckSettings{ExpectedStatus: []int{42}}},
+		{Name: "x", Target: "https://example.com", Settings: SyntheticCheckSettings{BodyRegex: "("}},
+	}
+	for i := range bad {
+		if err := validateSyntheticCheck(&bad[i]); err == nil {
+			t.Errorf("check %d should be rejected: %+v", i, bad[i])
+		}
+	}
+}
+
+func TestRunHTTPCheckAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if r.Header.Get("X-Probe") != "1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"status":"ok","version":"1.4.2"}`)
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(w, "ok")
+		case "/moved":
+			http.Redirect(w, r, "/health", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		settings SyntheticCheckSettings
+		maxMs    int
+		wantOK   bool
+		wantErr  string
+	}{
+		{"passes", "/health", SyntheticCheckSettings{Headers: map[string]string{"X-Probe": "1"}, BodyContains: `"ok"`, BodyRegex: `version":"1\.\d+`}, 0, true, ""},
+		{"missing header", "/health", SyntheticCheckSettings{}, 0, false, "status 400"},
+		{"expected status", "/missing", SyntheticCheckSettings{ExpectedStatus: []int{404}}, 0, true, ""},
+		{"body contains", "/health", SyntheticCheckSettings{Headers: map[string]string{"X-Probe": "1"}, BodyContains: "degraded"}, 0, false, "does not contain"},
+		{"body regex", "/health", SyntheticCheckSettings{Headers: map[string]string{"X-Probe": "1"}, BodyRegex: `version":"2\.`}, 0, false, "does not match"},
+		{"latency", "/slow", SyntheticCheckSettings{}, 10, false, "exceeds 10ms"},
+		{"redirect not followed", "/moved", SyntheticCheckSettings{ExpectedStatus: []int{200}}, 0, false, "status 302"},
+		{"redirect followed", "/moved", SyntheticCheckSettings{ExpectedStatus: []int{400}, FollowRedirects: true}, 0, true, ""},
+	}
+	for _, tt := range tests {
+		c := SyntheticCheck{ID: "c1", Name: tt.name, Target: ts.URL + tt.path, Settings: tt.settings, MaxLatencyMs: tt.maxMs}
+		if err := validateSyntheticCheck(&c); err != nil {
+			t.Fatalf("%s: validate: %v", tt.name, err)
+		}
+		res := runSyntheticCheck(context.Background(), &c, "eu", syntheticTestNetworks)
+		if res.Success != tt.wantOK {
+			t.Errorf("%s: Success = %v (error %q), want %v", tt.name, res.Success, res.Error, tt.wantOK)
+		}
+		if tt.wantErr != "" && !strings.Contains(res.Error, tt.wantErr) {
+			t.Errorf("%s: Error = %q, want it to contain %q", tt.name, res.Error, tt.wantErr)
+		}
+		if res.CheckID != "c1" || res.Region != "eu" {
+			t.Errorf("%s: result not attributed: %+v", tt.name, res)
+		}
+	}
+}
+
+func TestRunHTTPCheckTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	c := SyntheticCheck{Name: "slow", Target: ts.URL, TimeoutMs: 100}
+	if err := validateSyntheticCheck(&c); err != nil {
+		t.Fatal(err)
+	}
+	if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); res.Success {
+		t.Error("check exceeding its timeout should fail")
+	}
+}
+
+func TestRecordSyntheticResultTransitions(t *testing.T) {
+	s := &server{synthetic: NewSyntheticRunner("default", 1, nil)}
+	c := &SyntheticCheck{ID: "c1", Name: "home", CheckType: syntheticCheckHTTP, Target: "https://example.com", FailureThreshold: 2, AgentID: "agent-1"}
+
+	record := func(ok bool) *syntheticState {
+		s.recordSyntheticResult(c, SyntheticResult{Timestamp: time.Now(), CheckID: c.ID, Success: ok})
+		return s.synthetic.state[c.ID]
+	}
+	if st := record(false); st.down || st.failures != 1 {
+		t.Errorf("after 1 failure: %+v", st)
+	}
+	if st := record(false); !st.down {
+		t.Errorf("check should be down after reaching the threshold: %+v", st)
+	}
+	if st := record(true); st.down || st.failures != 0 {
+		t.Errorf("check should recover on success: %+v", st)
+	}
+
+	val, ok := s.uptimeReports.Load("agent-1")
+	if !ok {
+		t.Fatal("agent-bound check did not feed uptime reports")
+	}
+	reports := val.([]*pb.UptimeReport)
+	if len(reports) != 3 || reports[0].Status != "UP" || reports[1].Status != "DOWN" {
+		t.Errorf("reports = %v", reports)
+	}
+}
+
+func TestSyntheticRunsIn(t *testing.T) {
+	if !syntheticRunsIn(nil, "eu") {
+		t.Error("checks without regions run everywhere")
+	}
+	if !syntheticRunsIn([]string{"us", "eu"}, "eu") || syntheticRunsIn([]string{"us"}, "eu") {
+		t.Error("region filter mismatch")
+	}
+}
+
+func TestSyntheticInternalTargets(t *testing.T) {
+	allowed := parseSyntheticNetworks([]string{"10.1.0.0/16", " 192.168.1.5 ", "not-a-network"})
+	for addr, want := range map[string]bool{
+		"203.0.113.10":     true,
+		"10.1.2.3":         true,
+		"192.168.1.5":      true,
+		"192.168.1.6":      false,
+		"10.2.0.1":         false,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"169.254.169.254":  false,
+		"100.100.100.200":  false,
+		"fd00:ec2::254":    false,
+		"0.0.0.0":          false,
+	} {
+		if got := syntheticAddrAllowed(netip.MustParseAddr(addr), allowed); got != want {
+			t.Errorf("syntheticAddrAllowed(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	for _, c := range []SyntheticCheck{
+		{Name: "metadata", Target: "http://169.254.169.254/latest/meta-data/"},
+		{Name: "loopback", CheckType: "tcp", Target: "127.0.0.1:6379"},
+		{Name: "private", CheckType: "tls", Target: "[fd00::1]:443"},
+		{Name: "resolver", CheckType: "dns", Target: "example.com", Settings: SyntheticCheckSettings{Resolver: "10.0.0.2:53"}},
+	} {
+		if err := validateSyntheticCheck(&c); err != nil {
+			t.Fatalf("%s: validate: %v", c.Name, err)
+		}
+		if err := checkSyntheticTarget(&c, allowed); err == nil {
+			t.Errorf("%s: target %s should be refused", c.Name, c.Target)
+		}
+	}
+	named := SyntheticCheck{Name: "named", Target: "https://example.com/health"}
+	if err := checkSyntheticTarget(&named, nil); err != nil {
+		t.Errorf("named target refused: %v", err)
+	}
+}
+
+func TestRunHTTPCheckRefusesInternalAddresses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	// A name resolving to loopback is refused when dialled, whatever it resolved to when saved
+	target := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	c := SyntheticCheck{Name: "rebound", Target: target}
+	if err := validateSyntheticCheck(&c); err != nil {
+		t.Fatal(err)
+	}
+	res := runSyntheticCheck(context.Background(), &c, "default", nil)
+	if res.Success || !strings.Contains(res.Error, "internal address") {
+		t.Errorf("loopback target reached: %+v", res)
+	}
+	if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); !res.Success {
+		t.Errorf("allowed loopback target refused: %s", res.Error)
+	}
+}
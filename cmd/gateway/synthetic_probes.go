@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+var syntheticDNSRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+// syntheticResolver returns the system resolver, or one that sends every query to addr, which may
+// only be an internal address inside allowed
+func syntheticResolver(addr string, allowed []netip.Prefix) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	d := syntheticDialer(allowed)
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// lookupDNSRecords resolves host and returns the answers in presentation form
+func lookupDNSRecords(ctx context.Context, r *net.Resolver, recordType, host string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupNetIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.Unmap().String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "NS":
+		nss, err := r.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = txts
+	default:
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+	return answers, nil
+}
+
+// missingDNSValues returns the expected values absent from the answers. Names compare case
+// insensitively and without the trailing dot.
+func missingDNSValues(answers, expected []string) []string {
+	normalize := func(v string) string { return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), ".")) }
+	have := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		have[normalize(a)] = true
+	}
+	var missing []string
+	for _, e := range expected {
+		if !have[normalize(e)] {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+func runDNSCheck(ctx context.Context, c *SyntheticCheck, res *SyntheticResult, allowed []netip.Prefix) error {
+	s := c.Settings
+	start := time.Now()
+	answers, err := lookupDNSRecords(ctx, syntheticResolver(s.Resolver, allowed), s.RecordType, c.Target)
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return err
+	}
+	if len(answers) == 0 {
+		return fmt.Errorf("no %s records", s.RecordType)
+	}
+	if missing := missingDNSValues(answers, s.ExpectedValues); len(missing) > 0 {
+		return fmt.Errorf("%s records %v missing %v", s.RecordType, answers, missing)
+	}
+	return nil
+}
+
+// runSocketCheck connects over TCP or UDP. When a body is configured it is sent and the reply is
+// matched against the body assertions; UDP always waits for a reply since a send alone proves nothing.
+func runSocketCheck(ctx context.Context, c *SyntheticCheck, res *SyntheticResult, allowed []netip.Prefix) error {
+	s := c.Settings
+	d := syntheticDialer(allowed)
+	start := time.Now()
+	conn, err := d.DialContext(ctx, c.CheckType, c.Target)
+	if err != nil {
+		res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if s.Body != "" {
+		if _, err := conn.Write([]byte(s.Body)); err != nil {
+			return fmt.Errorf("send: %w", err)
+		}
+		buf := make([]byte, 64<<10)
+		n, err := conn.Read(buf)
+		res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		if err != nil {
+			return fmt.Errorf("no reply: %w", err)
+		}
+		return matchSyntheticBody(s, buf[:n])
+	}
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	return nil
+}
+
+// runTLSCheck completes a handshake and fails when the leaf certificate expires within min_valid_days
+func runTLSCheck(ctx context.Context, c *SyntheticCheck, res *SyntheticResult, allowed []netip.Prefix) error {
+	s := c.Settings
+	host, _, _ := net.SplitHostPort(c.Target)
+	serverName := s.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	d := tls.Dialer{NetDialer: syntheticDialer(allowed), Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: s.SkipTLSVerify}}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", c.Target)
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate presented")
+	}
+	daysLeft := int(time.Until(certs[0].NotAfter).Hours() / 24)
+	if daysLeft < s.MinValidDays {
+		return fmt.Errorf("certificate for %s expires in %d days (%s)", serverName, daysLeft, certs[0].NotAfter.UTC().Format("2006-01-02"))
+	}
+	return nil
+}
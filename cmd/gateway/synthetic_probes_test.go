@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateSyntheticCheckTypes(t *testing.T) {
+	dns := SyntheticCheck{Name: "dns", CheckType: "DNS", Target: "example.com."}
+	if err := validateSyntheticCheck(&dns); err != nil {
+		t.Fatal(err)
+	}
+	if dns.Target != "example.com" || dns.Settings.RecordType != "A" {
+		t.Errorf("dns defaults not applied: %+v", dns)
+	}
+	tlsCheck := SyntheticCheck{Name: "tls", CheckType: "tls", Target: "example.com"}
+	if err := validateSyntheticCheck(&tlsCheck); err != nil || tlsCheck.Target != "example.com:443" {
+		t.Errorf("tls target = %q, %v", tlsCheck.Target, err)
+	}
+
+	bad := []SyntheticCheck{
+		{Name: "x", CheckType: "dns", Target: "https://example.com"},
+		{Name: "x", CheckType: "dns", Target: "example.com", Settings: SyntheticCheckSettings{RecordType: "SRV"}},
+		{Name: "x", CheckType: "dns", Target: "example.com", Settings: SyntheticCheckSettings{Resolver: "1.1.1.1"}},
+		{Name: "x", CheckType: "tcp", Target: "example.com"},
+		{Name: "x", CheckType: "udp", Target: "example.com:53"},
+	}
+	for i := range bad {
+		if err := validateSyntheticCheck(&bad[i]); err == nil {
+			t.Errorf("check %d should be rejected: %+v", i, bad[i])
+		}
+	}
+}
+
+func TestMissingDNSValues(t *testing.T) {
+	answers := []string{"Mail.Example.com.", "10.0.0.1"}
+	if missing := missingDNSValues(answers, []string{"mail.example.com", "10.0.0.1"}); len(missing) != 0 {
+		t.Errorf("missing = %v, want none", missing)
+	}
+	if missing := missingDNSValues(answers, []string{"10.0.0.2"}); len(missing) != 1 {
+		t.Errorf("missing = %v, want [10.0.0.2]", missing)
+	}
+}
+
+func TestRunSocketChecks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 64)
+			n, _ := conn.Read(buf)
+			conn.Write([]byte("+PONG " + string(buf[:n])))
+			conn.Close()
+		}
+	}()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	tests := []struct {
+		name     string
+		typ      string
+		target   string
+		settings SyntheticCheckSettings
+		wantOK   bool
+	}{
+		{"tcp connect", "tcp", ln.Addr().String(), SyntheticCheckSettings{}, true},
+		{"tcp reply", "tcp", ln.Addr().String(), SyntheticCheckSettings{Body: "PING", BodyContains: "+PONG"}, true},
+		{"tcp wrong reply", "tcp", ln.Addr().String(), SyntheticCheckSettings{Body: "PING", BodyRegex: "^-ERR"}, false},
+		{"udp echo", "udp", pc.LocalAddr().String(), SyntheticCheckSettings{Body: "hello", BodyContains: "hello"}, true},
+	}
+	for _, tt := range tests {
+		c := SyntheticCheck{Name: tt.name, CheckType: tt.typ, Target: tt.target, Settings: tt.settings, TimeoutMs: 2000}
+		if err := validateSyntheticCheck(&c); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); res.Success != tt.wantOK {
+			t.Errorf("%s: Success = %v (error %q), want %v", tt.name, res.Success, res.Error, tt.wantOK)
+		}
+	}
+
+	// Nothing listening on a closed port
+	addr := ln.Addr().String()
+	ln.Close()
+	c := SyntheticCheck{Name: "closed", CheckType: "tcp", Target: addr, TimeoutMs: 1000}
+	validateSyntheticCheck(&c)
+	if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); res.Success {
+		t.Error("connect to a closed port should fail")
+	}
+}
+
+func TestRunTLSCheckExpiry(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+	target := strings.TrimPrefix(ts.URL, "https://")
+
+	c := SyntheticCheck{Name: "tls", CheckType: "tls", Target: target, Settings: SyntheticCheckSettings{SkipTLSVerify: true, MinValidDays: 30}}
+	if err := validateSyntheticCheck(&c); err != nil {
+		t.Fatal(err)
+	}
+	if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); !res.Success {
+		t.Errorf("handshake failed: %s", res.Error)
+	}
+
+	// The test certificate is valid for far less than a million days
+	c.Settings.MinValidDays = 1000000
+	res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks)
+	if res.Success || !strings.Contains(res.Error, "expires in") {
+		t.Errorf("expiry assertion not applied: %+v", res)
+	}
+
+	// The self-signed test certificate fails verification
+	c.Settings = SyntheticCheckSettings{}
+	if res := runSyntheticCheck(context.Background(), &c, "default", syntheticTestNetworks); res.Success {
+		t.Error("untrusted certificate should fail without skip_tls_verify")
+	}
+}
+
+func TestProbesRefuseInternalAddresses(t *testing.T) {
+	for _, c := range []SyntheticCheck{
+		{Name: "tcp", CheckType: "tcp", Target: "localhost:6379"},
+		{Name: "udp", CheckType: "udp", Target: "127.0.0.1:53", Settings: SyntheticCheckSettings{Body: "x"}},
+		{Name: "tls", CheckType: "tls", Target: "[::1]:443"},
+		{Name: "resolver", CheckType: "dns", Target: "example.com", Settings: SyntheticCheckSettings{Resolver: "169.254.169.254:53"}},
+	} {
+		c.TimeoutMs = 1000
+		if err := validateSyntheticCheck(&c); err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		if res := runSyntheticCheck(context.Background(), &c, "default", nil); res.Success || !strings.Contains(res.Error, "internal address") {
+			t.Errorf("%s: internal address reached: %+v", c.Name, res)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxRetentionDays bounds retention set through the API or config
+const maxRetentionDays = 3650
+
+// retentionTable is a ClickHouse table whose TTL the gateway manages
+type retentionTable struct {
+	Name        string
+	TimeColumn  string // DateTime expression rows expire by
+	AgentColumn string // column holding the agent id; empty when rows don't belong to an agent
+	DefaultDays int
+}
+
+var retentionTables = []retentionTable{
+	{Name: "access_logs", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 7},
+	{Name: "spans", TimeColumn: "toDateTime(start_time)", AgentColumn: "instance_id", DefaultDays: 7},
+	{Name: "system_metrics", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "nginx_metrics", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "nginx_workers", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "disk_usage", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "log_files", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "gateway_metrics", TimeColumn: "toDateTime(timestamp)", DefaultDays: 30},
+	{Name: "generic_metrics", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "traffic_5min", TimeColumn: "ts", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "requests_1m", TimeColumn: "ts", AgentColumn: "instance_id", DefaultDays: 30},
+	{Name: "requests_5m", TimeColumn: "ts", AgentColumn: "instance_id", DefaultDays: 365},
+	{Name: "requests_1h", TimeColumn: "ts", AgentColumn: "instance_id", DefaultDays: 365},
+	{Name: "requests_uri_1m", TimeColumn: "ts", AgentColumn: "instance_id", DefaultDays: uriMinuteRollupDays},
+	{Name: "requests_uri_1h", TimeColumn: "hour", AgentColumn: "instance_id", DefaultDays: 90},
+	{Name: "geo_requests_hourly", TimeColumn: "hour", DefaultDays: 90},
+	{Name: "security_events", TimeColumn: "toDateTime(timestamp)", AgentColumn: "instance_id", DefaultDays: 90},
+	{Name: "synthetic_results", TimeColumn: "toDateTime(timestamp)", DefaultDays: 90},
+	{Name: "terminal_sessions", TimeColumn: "toDateTime(started_at)", AgentColumn: "agent_id", DefaultDays: 365},
+}
+
+func findRetentionTable(name string) (retentionTable, bool) {
+	for _, t := range retentionTables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return retentionTable{}, false
+}
+
+// tableRetention is the effective retention of a table
+type tableRetention struct {
+	DefaultDays  int            // built-in, or from the retention config section
+	Days         int            // fleet-wide
+	Environments map[string]int // environment id -> days
+}
+
+// effectiveRetention resolves the retention of every managed table: built-in default, then the
+// config section, then stored policies
+func effectiveRetention(configured map[string]int, policies []TableRetentionPolicy) map[string]tableRetention {
+	eff := make(map[string]tableRetention, len(retentionTables))
+	for _, t := range retentionTables {
+		days := t.DefaultDays
+		if d, ok := configured[t.Name]; ok && d > 0 && d <= maxRetentionDays {
+			days = d
+		}
+		eff[t.Name] = tableRetention{DefaultDays: days, Days: days, Environments: make(map[string]int)}
+	}
+	for _, p := range policies {
+		r, ok := eff[p.Table]
+		if !ok {
+			continue
+		}
+		if p.EnvironmentID == "" {
+			r.Days = p.Days
+		} else {
+			r.Environments[p.EnvironmentID] = p.Days
+		}
+		eff[p.Table] = r
+	}
+	return eff
+}
+
+// ttlOverride is the retention of the agents of one environment
+type ttlOverride struct {
+	days   int
+	agents []string
+}
+
+// quoteCHString quotes a ClickHouse string literal
+func quoteCHString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// buildTTLExpr renders a table TTL: one DELETE rule per environment override, limited to its
+// agents, and the fleet-wide rule for every other row
+func buildTTLExpr(t retentionTable, days int, overrides []ttlOverride) string {
+	var rules, all []string
+	for _, o := range overrides {
+		quoted := make([]string, len(o.agents))
+		for i, a := range o.agents {
+			quoted[i] = quoteCHString(a)
+		}
+		rules = append(rules, fmt.Sprintf("%s + INTERVAL %d DAY DELETE WHERE %s IN (%s)", t.TimeColumn, o.days, t.AgentColumn, strings.Join(quoted, ", ")))
+		all = append(all, quoted...)
+	}
+	rule := fmt.Sprintf("%s + INTERVAL %d DAY", t.TimeColumn, days)
+	if len(all) > 0 {
+		rule += fmt.Sprintf(" DELETE WHERE %s NOT IN (%s)", t.AgentColumn, strings.Join(all, ", "))
+	}
+	return strings.Join(append(rules, rule), ", ")
+}
+
+// desiredTTL returns the TTL expression of a table for its effective retention. Overrides of
+// environments without agents, or equal to the fleet-wide retention, are left out.
+func (s *server) desiredTTL(t retentionTable, r tableRetention) (string, error) {
+	var overrides []ttlOverride
+	if t.AgentColumn != "" && s.db != nil {
+		envIDs := make([]string, 0, len(r.Environments))
+		for id := range r.Environments {
+			envIDs = append(envIDs, id)
+		}
+		sort.Strings(envIDs)
+		for _, id := range envIDs {
+			days := r.Environments[id]
+			if days == r.Days {
+				continue
+			}
+			agents, err := s.db.GetAgentIDsForEnvironment(id)
+			if err != nil {
+				return "", err
+			}
+			if len(agents) > 0 {
+				sort.Strings(agents)
+				overrides = append(overrides, ttlOverride{days: days, agents: agents})
+			}
+		}
+	}
+	return buildTTLExpr(t, r.Days, overrides), nil
+}
+
+// loadRetention returns the effective retention of every managed table
+func (s *server) loadRetention() (map[string]tableRetention, error) {
+	var policies []TableRetentionPolicy
+	if s.db != nil {
+		var err error
+		if policies, err = s.db.ListTableRetentionPolicies(); err != nil {
+			return nil, err
+		}
+	}
+	return effectiveRetention(s.config.Retention.Tables, policies), nil
+}
+
+// applyTableRetention sets the TTL of the managed tables, or only of the named one. Unless force
+// is set, tables whose TTL is unchanged since the last apply are skipped: ALTER ... MODIFY TTL
+// rewrites every part.
+func (s *server) applyTableRetention(ctx context.Context, only string, force bool) error {
+	if s.clickhouse == nil {
+		return nil
+	}
+	eff, err := s.loadRetention()
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, t := range retentionTables {
+		if only != "" && t.Name != only {
+			continue
+		}
+		expr, err := s.desiredTTL(t, eff[t.Name])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t.Name, err))
+			continue
+		}
+		if applied, ok := s.retentionApplied.Load(t.Name); ok && !force && applied.(string) == expr {
+			continue
+		}
+		if err := s.clickhouse.SetTableTTL(ctx, t.Name, expr); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t.Name, err))
+			continue
+		}
+		s.retentionApplied.Store(t.Name, expr)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("setting TTL failed for %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// accessLogRetentionDays is the shortest time any agent's access logs are kept
+func (s *server) accessLogRetentionDays() int {
+	eff, err := s.loadRetention()
+	if err != nil {
+		return 7
+	}
+	r := eff["access_logs"]
+	days := r.Days
+	for _, d := range r.Environments {
+		days = min(days, d)
+	}
+	return days
+}
+
+// startRetentionManager applies table TTLs at startup, then hourly so environment overrides
+// follow agents being assigned to or moved between environments
+func (s *server) startRetentionManager() {
+	if s.clickhouse == nil {
+		return
+	}
+	go func() {
+		if s.isLeader() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if err := s.applyTableRetention(ctx, "", true); err != nil {
+				analyticsLog.Error().Err(err).Msg("Cannot apply ClickHouse table retention")
+			}
+			cancel()
+		}
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if err := s.applyTableRetention(ctx, "", false); err != nil {
+				analyticsLog.Error().Err(err).Msg("Cannot apply ClickHouse table retention")
+			}
+			cancel()
+		}
+	}()
+}
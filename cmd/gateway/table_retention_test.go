@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func TestEffectiveRetention(t *testing.T) {
+	eff := effectiveRetention(map[string]int{"access_logs": 14, "spans": 0, "unknown": 3}, []TableRetentionPolicy{
+		{Table: "access_logs", Days: 21},
+		{Table: "access_logs", EnvironmentID: "env-prod", Days: 60},
+		{Table: "dropped_table", Days: 5},
+	})
+	if r := eff["access_logs"]; r.DefaultDays != 14 || r.Days != 21 || r.Environments["env-prod"] != 60 {
+		t.Errorf("access_logs = %+v", r)
+	}
+	if r := eff["spans"]; r.Days != 7 {
+		t.Errorf("spans = %+v, an invalid configured value keeps the built-in default", r)
+	}
+	if _, ok := eff["unknown"]; ok || len(eff) != len(retentionTables) {
+		t.Errorf("only managed tables are returned: %v", eff)
+	}
+}
+
+func TestBuildTTLExpr(t *testing.T) {
+	logs, _ := findRetentionTable("access_logs")
+	if got := buildTTLExpr(logs, 7, nil); got != "toDateTime(timestamp) + INTERVAL 7 DAY" {
+		t.Errorf("fleet-wide = %s", got)
+	}
+	got := buildTTLExpr(logs, 7, []ttlOverride{
+		{days: 30, agents: []string{"web-1", "web-2"}},
+		{days: 3, agents: []string{"o'brien"}},
+	})
+	want := "toDateTime(timestamp) + INTERVAL 30 DAY DELETE WHERE instance_id IN ('web-1', 'web-2'), " +
+		"toDateTime(timestamp) + INTERVAL 3 DAY DELETE WHERE instance_id IN ('o\\'brien'), " +
+		"toDateTime(timestamp) + INTERVAL 7 DAY DELETE WHERE instance_id NOT IN ('web-1', 'web-2', 'o\\'brien')"
+	if got != want {
+		t.Errorf("overrides =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTableTTLFromEngine(t *testing.T) {
+	engine := "MergeTree PARTITION BY toYYYYMM(toDateTime(timestamp)) ORDER BY (instance_id, timestamp) TTL toDateTime(timestamp) + toIntervalDay(7) SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1"
+	if got := tableTTLFromEngine(engine); got != "toDateTime(timestamp) + toIntervalDay(7)" {
+		t.Errorf("ttl = %q", got)
+	}
+	if got := tableTTLFromEngine("MergeTree ORDER BY ts"); got != "" {
+		t.Errorf("no TTL = %q", got)
+	}
+}
+
+func TestValidateRetentionUpdate(t *testing.T) {
+	s := &server{config: &config.Config{Archive: config.ArchiveConfig{Enabled: true, ArchiveAfterDays: 3}}}
+	logs, _ := findRetentionTable("access_logs")
+	gw, _ := findRetentionTable("gateway_metrics")
+	for _, tc := range []struct {
+		table retentionTable
+		req   tableRetentionUpdate
+		err   string
+	}{
+		{logs, tableRetentionUpdate{Days: 14}, ""},
+		{logs, tableRetentionUpdate{Days: 5000}, "between 1 and"},
+		{logs, tableRetentionUpdate{Days: 3}, "archive_after_days"},
+		{gw, tableRetentionUpdate{Environments: map[string]int{"env-prod": 30}}, "per environment"},
+		{gw, tableRetentionUpdate{Days: 90}, ""},
+	} {
+		err := s.validateRetentionUpdate(tc.table, tc.req)
+		if (tc.err == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s %+v: err = %v, want %q", tc.table.Name, tc.req, err, tc.err)
+		}
+	}
+}
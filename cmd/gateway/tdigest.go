@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// tdigestCompression bounds the centroids of a digest to about 1.6×compression; percentiles are
+// then within a fraction of a percent of rank, and closer at the tails
+const tdigestCompression = 100
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tdigest estimates quantiles of a stream in bounded memory (a merging t-digest, Dunning 2019):
+// values are buffered, then merged into centroids whose weight the k1 scale function keeps small
+// near the tails, so p95 and p99 stay accurate. Not safe for concurrent use; Quantile does not
+// modify the digest, so readers may share one under a read lock.
+type tdigest struct {
+	centroids []centroid // sorted by mean
+	buffer    []centroid // added since the last merge
+	count     float64
+	min, max  float64
+}
+
+func newTDigest() *tdigest {
+	return &tdigest{min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add adds a value
+func (t *tdigest) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	t.buffer = append(t.buffer, centroid{mean: x, weight: 1})
+	t.count++
+	t.min = min(t.min, x)
+	t.max = max(t.max, x)
+	if len(t.buffer) >= 4*tdigestCompression {
+		t.centroids = t.merged()
+		t.buffer = t.buffer[:0]
+	}
+}
+
+// Count returns how many values were added
+func (t *tdigest) Count() int64 {
+	return int64(t.count)
+}
+
+// k is the k1 scale function: centroids may span at most 1 of k
+func (t *tdigest) k(q float64) float64 {
+	return tdigestCompression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// kInverse returns the quantile at k
+func (t *tdigest) kInverse(k float64) float64 {
+	if k >= tdigestCompression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/tdigestCompression) + 1) / 2
+}
+
+// merged returns the centroids with the buffer merged in, without changing t
+func (t *tdigest) merged() []centroid {
+	if len(t.buffer) == 0 {
+		return t.centroids
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(append(all, t.centroids...), t.buffer...)
+	slices.SortFunc(all, func(a, b centroid) int {
+		switch {
+		case a.mean < b.mean:
+			return -1
+		case a.mean > b.mean:
+			return 1
+		}
+		return 0
+	})
+
+	out := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	weightBefore := 0.0 // of the centroids already in out
+	limit := t.kInverse(t.k(0)+1) * t.count
+	for _, c := range all[1:] {
+		if weightBefore+cur.weight+c.weight <= limit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		weightBefore += cur.weight
+		out = append(out, cur)
+		limit = t.kInverse(t.k(weightBefore/t.count)+1) * t.count
+		cur = c
+	}
+	return append(out, cur)
+}
+
+// Quantile returns the estimated value at quantile q (0 to 1), 0 when empty
+func (t *tdigest) Quantile(q float64) float64 {
+	centroids := t.merged()
+	if len(centroids) == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	// Interpolate between the centers of neighbouring centroids, and between the outer centers and
+	// the smallest and largest values
+	target := q * t.count
+	prevMean, prevCenter := t.min, 0.0
+	cumulative := 0.0
+	for _, c := range centroids {
+		center := cumulative + c.weight/2
+		if target < center {
+			return prevMean + (c.mean-prevMean)*(target-prevCenter)/(center-prevCenter)
+		}
+		prevMean, prevCenter = c.mean, center
+		cumulative += c.weight
+	}
+	if t.count == prevCenter {
+		return t.max
+	}
+	return prevMean + (t.max-prevMean)*(target-prevCenter)/(t.count-prevCenter)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestTDigestQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for name, sample := range map[string]func() float64{
+		"uniform":     func() float64 { return rng.Float64() },
+		"exponential": func() float64 { return rng.ExpFloat64() * 0.05 },
+		"lognormal":   func() float64 { return math.Exp(rng.NormFloat64()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := newTDigest()
+			values := make([]float64, 100000)
+			for i := range values {
+				values[i] = sample()
+				d.Add(values[i])
+			}
+			slices.Sort(values)
+			if d.Count() != int64(len(values)) {
+				t.Fatalf("count = %d", d.Count())
+			}
+			if n := len(d.merged()); n > 2*tdigestCompression {
+				t.Errorf("%d centroids, want at most %d", n, 2*tdigestCompression)
+			}
+			for _, q := range []float64{0.5, 0.9, 0.95, 0.99, 0.999} {
+				got := d.Quantile(q)
+				// Compare ranks: the estimate must fall within 0.5% of q
+				rank := float64(sortSearch(values, got)) / float64(len(values))
+				if math.Abs(rank-q) > 0.005 {
+					t.Errorf("q%.3f = %v at rank %.4f", q, got, rank)
+				}
+			}
+			if d.Quantile(0) != values[0] || d.Quantile(1) != values[len(values)-1] {
+				t.Errorf("extremes = %v, %v; want %v, %v", d.Quantile(0), d.Quantile(1), values[0], values[len(values)-1])
+			}
+		})
+	}
+}
+
+func sortSearch(sorted []float64, v float64) int {
+	i, _ := slices.BinarySearch(sorted, v)
+	return i
+}
+
+func TestTDigestSmall(t *testing.T) {
+	d := newTDigest()
+	if got := d.Quantile(0.95); got != 0 {
+		t.Errorf("empty digest = %v, want 0", got)
+	}
+	d.Add(0.2)
+	if got := d.Quantile(0.95); got != 0.2 {
+		t.Errorf("one value = %v, want 0.2", got)
+	}
+	for _, v := range []float64{0.1, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0} {
+		d.Add(v)
+	}
+	if got := d.Quantile(0.5); math.Abs(got-0.55) > 0.051 {
+		t.Errorf("median of 0.1..1.0 = %v", got)
+	}
+	if got := d.Quantile(0.95); got < 0.9 || got > 1.0 {
+		t.Errorf("p95 of 0.1..1.0 = %v", got)
+	}
+	// Quantile leaves the buffer alone
+	if len(d.buffer) != 10 || len(d.centroids) != 0 {
+		t.Errorf("Quantile changed the digest: %d buffered, %d centroids", len(d.buffer), len(d.centroids))
+	}
+}
+
+func TestTDigestSingleCentroid(t *testing.T) {
+	// One heavy centroid: quantiles interpolate between the extremes and its mean on either side
+	d := &tdigest{centroids: []centroid{{mean: 5, weight: 100}}, count: 100, min: 1, max: 9}
+	for q, want := range map[float64]float64{0: 1, 0.25: 3, 0.5: 5, 0.75: 7, 1: 9} {
+		if got := d.Quantile(q); math.Abs(got-want) > 1e-9 {
+			t.Errorf("q%.2f = %v, want %v", q, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Declarative tenancy: projects, environments, teams, members and access grants addressed by slug,
+// so infrastructure-as-code tools can converge on a desired state. Slugs are the keys; IDs are
+// assigned on create and never change, so renaming a project keeps its assignments and grants.
+
+var (
+	tenancySlugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	tenancyColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+const defaultEnvironmentColor = "#6366f1"
+
+// TenancyState is a desired (or exported) set of projects and teams
+type TenancyState struct {
+	Projects []DeclaredProject `json:"projects"`
+	Teams    []DeclaredTeam    `json:"teams"`
+}
+
+// DeclaredProject is a project and its environments; ID is output only
+type DeclaredProject struct {
+	ID           string                `json:"id,omitempty"`
+	Slug         string                `json:"slug"`
+	Name         string                `json:"name"`
+	Description  string                `json:"description,omitempty"`
+	Environments []DeclaredEnvironment `json:"environments,omitempty"`
+}
+
+// DeclaredEnvironment is an environment of a declared project; ID is output only
+type DeclaredEnvironment struct {
+	ID           string `json:"id,omitempty"`
+	Slug         string `json:"slug"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Color        string `json:"color,omitempty"`
+	SortOrder    int    `json:"sort_order"`
+	IsProduction bool   `json:"is_production"`
+}
+
+// DeclaredTeam is a team with its members and the projects it can access; ID is output only
+type DeclaredTeam struct {
+	ID          string           `json:"id,omitempty"`
+	Slug        string           `json:"slug"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Members     []DeclaredMember `json:"members,omitempty"`
+	Access      []DeclaredAccess `json:"access,omitempty"`
+}
+
+// DeclaredMember is a team membership
+type DeclaredMember struct {
+	Username string   `json:"username"`
+	Role     TeamRole `json:"role,omitempty"`
+}
+
+// DeclaredAccess grants a team a permission on the project with the given slug
+type DeclaredAccess struct {
+	Project    string     `json:"project"`
+	Permission Permission `json:"permission"`
+}
+
+// TenancyChange is one change made (or, on a dry run, that would be made) by an apply
+type TenancyChange struct {
+	Action string `json:"action"` // create, update or delete
+	Kind   string `json:"kind"`   // project, environment, team, member or access
+	Key    string `json:"key"`    // slug, project/environment, team/username or team/project
+	ID     string `json:"id,omitempty"`
+}
+
+func validateSlug(kind, slug string, maxLen int) error {
+	if !tenancySlugPattern.MatchString(slug) || len(slug) > maxLen {
+		return fmt.Errorf("%s slug %q must be lowercase letters, digits and single dashes, at most %d characters", kind, slug, maxLen)
+	}
+	return nil
+}
+
+func validPermission(p Permission) bool {
+	return p == PermissionRead || p == PermissionWrite || p == PermissionOperate || p == PermissionAdmin
+}
+
+// normalize fills defaults in place and validates an environment
+func (e *DeclaredEnvironment) normalize() error {
+	if err := validateSlug("environment", e.Slug, 50); err != nil {
+		return err
+	}
+	e.Name = strings.TrimSpace(e.Name)
+	if e.Name == "" {
+		e.Name = strings.ToUpper(e.Slug[:1]) + e.Slug[1:]
+	}
+	if len(e.Name) > 50 {
+		return fmt.Errorf("environment %s: name must be at most 50 characters", e.Slug)
+	}
+	if e.Color == "" {
+		e.Color = defaultEnvironmentColor
+	}
+	if !tenancyColorPattern.MatchString(e.Color) {
+		return fmt.Errorf("environment %s: color must be #rrggbb", e.Slug)
+	}
+	return nil
+}
+
+// normalize fills defaults in place and validates a membership
+func (m *DeclaredMember) normalize() error {
+	m.Username = strings.TrimSpace(m.Username)
+	if m.Username == "" {
+		return fmt.Errorf("member username is required")
+	}
+	if m.Role == "" {
+		m.Role = TeamRoleMember
+	}
+	if m.Role != TeamRoleMember && m.Role != TeamRoleAdmin {
+		return fmt.Errorf("member %s: role must be member or admin", m.Username)
+	}
+	return nil
+}
+
+// normalize fills defaults in place and validates an access grant
+func (a *DeclaredAccess) normalize() error {
+	if a.Permission == "" {
+		a.Permission = PermissionRead
+	}
+	if !validPermission(a.Permission) {
+		return fmt.Errorf("access to %s: permission must be read, write, operate or admin", a.Project)
+	}
+	return validateSlug("project", a.Project, 100)
+}
+
+// normalize fills defaults in place and validates the whole state: slugs are unique within their
+// scope and names are set. Access to projects not in the state is checked against the database
+// when applying.
+func (st *TenancyState) normalize() error {
+	projects := map[string]bool{}
+	for i := range st.Projects {
+		p := &st.Projects[i]
+		if err := validateSlug("project", p.Slug, 100); err != nil {
+			return err
+		}
+		if projects[p.Slug] {
+			return fmt.Errorf("project %s is declared twice", p.Slug)
+		}
+		projects[p.Slug] = true
+		if p.Name = strings.TrimSpace(p.Name); p.Name == "" || len(p.Name) > 100 {
+			return fmt.Errorf("project %s: name is required, at most 100 characters", p.Slug)
+		}
+		envs := map[string]bool{}
+		for j := range p.Environments {
+			e := &p.Environments[j]
+			if err := e.normalize(); err != nil {
+				return fmt.Errorf("project %s: %w", p.Slug, err)
+			}
+			if envs[e.Slug] {
+				return fmt.Errorf("project %s: environment %s is declared twice", p.Slug, e.Slug)
+			}
+			envs[e.Slug] = true
+		}
+	}
+	teams := map[string]bool{}
+	for i := range st.Teams {
+		t := &st.Teams[i]
+		if err := validateSlug("team", t.Slug, 100); err != nil {
+			return err
+		}
+		if teams[t.Slug] {
+			return fmt.Errorf("team %s is declared twice", t.Slug)
+		}
+		teams[t.Slug] = true
+		if t.Name = strings.TrimSpace(t.Name); t.Name == "" || len(t.Name) > 100 {
+			return fmt.Errorf("team %s: name is required, at most 100 characters", t.Slug)
+		}
+		members := map[string]bool{}
+		for j := range t.Members {
+			m := &t.Members[j]
+			if err := m.normalize(); err != nil {
+				return fmt.Errorf("team %s: %w", t.Slug, err)
+			}
+			if members[m.Username] {
+				return fmt.Errorf("team %s: member %s is declared twice", t.Slug, m.Username)
+			}
+			members[m.Username] = true
+		}
+		access := map[string]bool{}
+		for j := range t.Access {
+			a := &t.Access[j]
+			if err := a.normalize(); err != nil {
+				return fmt.Errorf("team %s: %w", t.Slug, err)
+			}
+			if access[a.Project] {
+				return fmt.Errorf("team %s: access to %s is declared twice", t.Slug, a.Project)
+			}
+			access[a.Project] = true
+		}
+	}
+	return nil
+}
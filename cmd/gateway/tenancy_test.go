@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTenancyStateNormalize(t *testing.T) {
+	st := TenancyState{
+		Projects: []DeclaredProject{{
+			Slug: "shop",
+			Name: " Shop ",
+			Environments: []DeclaredEnvironment{
+				{Slug: "production", IsProduction: true},
+				{Slug: "staging", Name: "Stage", Color: "#eab308"},
+			},
+		}},
+		Teams: []DeclaredTeam{{
+			Slug:    "sre",
+			Name:    "SRE",
+			Members: []DeclaredMember{{Username: "alice"}, {Username: "bob", Role: TeamRoleAdmin}},
+			Access:  []DeclaredAccess{{Project: "shop"}},
+		}},
+	}
+	if err := st.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	p := st.Projects[0]
+	if p.Name != "Shop" || p.Environments[0].Name != "Production" || p.Environments[0].Color != defaultEnvironmentColor {
+		t.Errorf("project defaults: %+v", p)
+	}
+	if p.Environments[1].Name != "Stage" || p.Environments[1].Color != "#eab308" {
+		t.Errorf("declared environment fields overwritten: %+v", p.Environments[1])
+	}
+	team := st.Teams[0]
+	if team.Members[0].Role != TeamRoleMember || team.Members[1].Role != TeamRoleAdmin || team.Access[0].Permission != PermissionRead {
+		t.Errorf("team defaults: %+v", team)
+	}
+}
+
+func TestTenancyStateNormalizeErrors(t *testing.T) {
+	cases := map[string]TenancyState{
+		"must be lowercase": {Projects: []DeclaredProject{{Slug: "Shop", Name: "Shop"}}},
+		"name is required":  {Projects: []DeclaredProject{{Slug: "shop"}}},
+		"project shop is declared twice": {Projects: []DeclaredProject{
+			{Slug: "shop", Name: "Shop"}, {Slug: "shop", Name: "Shop 2"},
+		}},
+		"environment prod is declared twice": {Projects: []DeclaredProject{{Slug: "shop", Name: "Shop",
+			Environments: []DeclaredEnvironment{{Slug: "prod"}, {Slug: "prod"}}}}},
+		"color must be": {Projects: []DeclaredProject{{Slug: "shop", Name: "Shop",
+			Environments: []DeclaredEnvironment{{Slug: "prod", Color: "red"}}}}},
+		"role must be": {Teams: []DeclaredTeam{{Slug: "sre", Name: "SRE",
+			Members: []DeclaredMember{{Username: "alice", Role: "owner"}}}}},
+		"permission must be": {Teams: []DeclaredTeam{{Slug: "sre", Name: "SRE",
+			Access: []DeclaredAccess{{Project: "shop", Permission: "root"}}}}},
+		"access to shop is declared twice": {Teams: []DeclaredTeam{{Slug: "sre", Name: "SRE",
+			Access: []DeclaredAccess{{Project: "shop"}, {Project: "shop", Permission: PermissionAdmin}}}}},
+	}
+	for want, st := range cases {
+		err := st.normalize()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestTenancyCreated(t *testing.T) {
+	changes := []TenancyChange{
+		{Action: "update", Kind: "project", Key: "shop"},
+		{Action: "create", Kind: "environment", Key: "shop/prod"},
+	}
+	if tenancyCreated(changes, "project", "shop") || !tenancyCreated(changes, "environment", "shop/prod") {
+		t.Error("tenancyCreated should only match create changes of the same resource")
+	}
+}
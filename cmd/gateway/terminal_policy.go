@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+// privilegeEscalationCommands are blocked when sudo is prohibited.
+var privilegeEscalationCommands = map[string]bool{
+	"sudo":   true,
+	"su":     true,
+	"doas":   true,
+	"pkexec": true,
+}
+
+// terminalShells are the shells a terminal session may start, as the agent's allowedShells. The
+// shell is not a command the policy checks; the allow and deny lists apply to the lines typed into
+// it.
+var terminalShells = map[string]bool{
+	"/bin/sh":   true,
+	"/bin/bash": true,
+	"/bin/ash":  true,
+	"/bin/zsh":  true,
+}
+
+// checkTerminalShell validates the shell a session starts; empty starts the agent's default shell.
+func checkTerminalShell(shell string) (string, bool) {
+	if shell == "" || terminalShells[shell] {
+		return "", true
+	}
+	return fmt.Sprintf("shell %q is not in the allowed list", shell), false
+}
+
+// terminalPolicy is the effective policy for one terminal session.
+type terminalPolicy struct {
+	ReadOnly     bool
+	Allowed      []string
+	Denied       []string
+	ProhibitSudo bool
+	MaxDuration  time.Duration
+}
+
+// hasLineRules reports whether input must be inspected line by line.
+func (p terminalPolicy) hasLineRules() bool {
+	return len(p.Allowed) > 0 || len(p.Denied) > 0 || p.ProhibitSudo
+}
+
+// terminalPolicyForRole resolves the policy for a role from the gateway config.
+func terminalPolicyForRole(cfg *config.Config, role string) terminalPolicy {
+	if cfg == nil {
+		return terminalPolicy{}
+	}
+	p := terminalPolicy{
+		ProhibitSudo: cfg.Terminal.ProhibitSudo,
+		MaxDuration:  cfg.Terminal.MaxSessionDuration,
+	}
+	if rp, ok := cfg.Terminal.Roles[role]; ok {
+		p.ReadOnly = rp.ReadOnly
+		p.Allowed = rp.AllowedCommands
+		p.Denied = rp.DeniedCommands
+		if rp.MaxSessionDuration > 0 {
+			p.MaxDuration = rp.MaxSessionDuration
+		}
+	}
+	return p
+}
+
+// CheckCommand validates a full command line against the policy.
+// It returns a human readable reason when the command must be blocked.
+func (p terminalPolicy) CheckCommand(line string) (string, bool) {
+	for _, seg := range splitShellCommands(line) {
+		name := commandName(seg)
+		if name == "" {
+			continue
+		}
+		if p.ProhibitSudo && privilegeEscalationCommands[name] {
+			return fmt.Sprintf("%s is not permitted", name), false
+		}
+		for _, d := range p.Denied {
+			if matchesCommand(seg, name, d) {
+				return fmt.Sprintf("command %q is denied", d), false
+			}
+		}
+		if len(p.Allowed) > 0 {
+			allowed := false
+			for _, a := range p.Allowed {
+				if matchesCommand(seg, name, a) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return fmt.Sprintf("command %q is not in the allowlist", name), false
+			}
+		}
+	}
+	return "", true
+}
+
+// splitShellCommands splits a command line on shell control operators and command
+// substitutions so every command in a pipeline or chain is checked.
+func splitShellCommands(line string) []string {
+	r := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n", "&", "\n", "$(", "\n", "`", "\n", ")", "\n")
+	var out []string
+	for _, seg := range strings.Split(r.Replace(line), "\n") {
+		if seg = strings.TrimSpace(seg); seg != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+// commandName returns the base name of the executable of a single command,
+// skipping leading VAR=value assignments and the `env`/`exec`/`command` wrappers.
+func commandName(seg string) string {
+	for _, f := range strings.Fields(seg) {
+		if strings.Contains(f, "=") && !strings.HasPrefix(f, "=") {
+			continue
+		}
+		name := path.Base(strings.Trim(f, `'"\`))
+		switch name {
+		case "env", "exec", "command", "nohup", "time":
+			continue
+		}
+		return name
+	}
+	return ""
+}
+
+// matchesCommand matches a rule against a command. Single-word rules match the
+// executable name; multi-word rules (e.g. "rm -rf") match as a prefix.
+func matchesCommand(seg, name, rule string) bool {
+	rule = strings.TrimSpace(rule)
+	if rule == "" {
+		return false
+	}
+	if !strings.Contains(rule, " ") {
+		return name == rule
+	}
+	return strings.HasPrefix(strings.Join(strings.Fields(seg), " "), strings.Join(strings.Fields(rule), " "))
+}
+
+// terminalInputFilter tracks the line being typed in an interactive shell and
+// enforces the policy when the user presses Enter. Keystrokes are forwarded as
+// they are typed (so the remote PTY can echo them); a blocked line is replaced
+// with Ctrl-U (kill line) instead of the newline, so it never executes.
+type terminalInputFilter struct {
+	policy terminalPolicy
+	line   []byte
+	// edited is set when the line contains keys we cannot interpret locally
+	// (arrows, history, tab completion); such lines can't be verified.
+	edited bool
+}
+
+func newTerminalInputFilter(p terminalPolicy) *terminalInputFilter {
+	return &terminalInputFilter{policy: p}
+}
+
+// Filter processes a chunk of user input and returns the bytes that may be
+// forwarded to the agent plus the reasons for any blocked lines.
+func (f *terminalInputFilter) Filter(in []byte) ([]byte, []string) {
+	if f.policy.ReadOnly {
+		if len(in) == 0 {
+			return nil, nil
+		}
+		return nil, []string{"session is read-only"}
+	}
+	if !f.policy.hasLineRules() {
+		return in, nil
+	}
+
+	out := make([]byte, 0, len(in))
+	var denied []string
+	for _, b := range in {
+		switch {
+		case b == '\r' || b == '\n':
+			reason, ok := f.checkLine()
+			if ok {
+				out = append(out, b)
+			} else {
+				out = append(out, 0x15) // Ctrl-U: discard the typed line
+				denied = append(denied, reason)
+			}
+			f.reset()
+		case b == 0x7f || b == 0x08: // Backspace / DEL
+			if len(f.line) > 0 {
+				_, size := utf8.DecodeLastRune(f.line)
+				f.line = f.line[:len(f.line)-size]
+			}
+			out = append(out, b)
+		case b == 0x03 || b == 0x15: // Ctrl-C / Ctrl-U clear the line
+			f.reset()
+			out = append(out, b)
+		case b < 0x20:
+			f.edited = true
+			out = append(out, b)
+		default:
+			f.line = append(f.line, b)
+			out = append(out, b)
+		}
+	}
+	return out, denied
+}
+
+func (f *terminalInputFilter) checkLine() (string, bool) {
+	line := strings.TrimSpace(string(f.line))
+	if f.edited {
+		return "line was edited with control keys (history/completion) and cannot be verified", false
+	}
+	if line == "" {
+		return "", true
+	}
+	return f.policy.CheckCommand(line)
+}
+
+func (f *terminalInputFilter) reset() {
+	f.line = f.line[:0]
+	f.edited = false
+}
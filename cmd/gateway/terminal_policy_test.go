@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func TestTerminalPolicy_CheckCommand(t *testing.T) {
+	policy := terminalPolicy{
+		Allowed:      []string{"ls", "cat", "tail", "grep", "nginx -t"},
+		Denied:       []string{"cat /etc/shadow"},
+		ProhibitSudo: true,
+	}
+
+	tests := []struct {
+		line    string
+		allowed bool
+	}{
+		{"ls -la /etc/nginx", true},
+		{"tail -f /var/log/nginx/access.log | grep 500", true},
+		{"nginx -t", true},
+		{"nginx -s stop", false},
+		{"sudo ls", false},
+		{"/usr/bin/sudo ls", false},
+		{"ls; rm -rf /", false},
+		{"ls && su -", false},
+		{"cat $(rm -rf /tmp/x)", false},
+		{"cat /etc/shadow", false},
+		{"FOO=bar ls", true},
+		{"env sudo ls", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := policy.CheckCommand(tt.line); ok != tt.allowed {
+			t.Errorf("CheckCommand(%q) = %v, want %v", tt.line, ok, tt.allowed)
+		}
+	}
+}
+
+func TestCheckTerminalShell(t *testing.T) {
+	for _, shell := range []string{"", "/bin/sh", "/bin/bash"} {
+		if reason, ok := checkTerminalShell(shell); !ok {
+			t.Errorf("checkTerminalShell(%q) denied: %s", shell, reason)
+		}
+	}
+	for _, shell := range []string{"/usr/bin/python3", "bash", "/bin/bash -c id", "/bin/sh; rm -rf /"} {
+		if _, ok := checkTerminalShell(shell); ok {
+			t.Errorf("checkTerminalShell(%q) allowed", shell)
+		}
+	}
+
+	// An allowlist without the shell still lets the session start, and applies to what is typed
+	policy := terminalPolicy{Allowed: []string{"ls", "tail"}}
+	if _, ok := checkTerminalShell("/bin/bash"); !ok {
+		t.Error("an allowlist policy should not reject the shell")
+	}
+	f := newTerminalInputFilter(policy)
+	if out, denied := f.Filter([]byte("ls -la\r")); len(denied) != 0 || out[len(out)-1] != '\r' {
+		t.Errorf("allowed command blocked: %q %v", out, denied)
+	}
+	if _, denied := f.Filter([]byte("bash -c id\r")); len(denied) != 1 {
+		t.Errorf("command outside the allowlist typed into the shell should be blocked, got %v", denied)
+	}
+}
+
+func TestTerminalInputFilter(t *testing.T) {
+	f := newTerminalInputFilter(terminalPolicy{Denied: []string{"rm"}})
+
+	// Keystrokes are forwarded individually until Enter
+	out, denied := f.Filter([]byte("rm -rf /"))
+	if string(out) != "rm -rf /" || len(denied) != 0 {
+		t.Fatalf("unexpected passthrough: %q %v", out, denied)
+	}
+	out, denied = f.Filter([]byte("\r"))
+	if !bytes.Equal(out, []byte{0x15}) || len(denied) != 1 {
+		t.Errorf("expected Enter to be replaced with Ctrl-U, got %q %v", out, denied)
+	}
+
+	// Backspace edits are tracked
+	out, denied = f.Filter([]byte("rmx\x7f\x7f\x7fls\r"))
+	if len(denied) != 0 || out[len(out)-1] != '\r' {
+		t.Errorf("expected edited line to pass, got %q %v", out, denied)
+	}
+
+	// History recall can't be verified
+	_, denied = f.Filter([]byte("\x1b[A\r"))
+	if len(denied) != 1 {
+		t.Errorf("expected history recall to be blocked, got %v", denied)
+	}
+}
+
+func TestTerminalInputFilter_ReadOnly(t *testing.T) {
+	f := newTerminalInputFilter(terminalPolicy{ReadOnly: true})
+	out, denied := f.Filter([]byte("ls\r"))
+	if len(out) != 0 || len(denied) != 1 {
+		t.Errorf("read-only session forwarded input: %q %v", out, denied)
+	}
+}
+
+func TestTerminalPolicyForRole(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{
+		ProhibitSudo:       true,
+		MaxSessionDuration: time.Hour,
+		Roles: map[string]config.TerminalRolePolicy{
+			"viewer":   {ReadOnly: true},
+			"operator": {AllowedCommands: []string{"ls"}, MaxSessionDuration: 15 * time.Minute},
+		},
+	}}
+
+	if p := terminalPolicyForRole(cfg, "viewer"); !p.ReadOnly || p.MaxDuration != time.Hour {
+		t.Errorf("viewer policy = %+v", p)
+	}
+	if p := terminalPolicyForRole(cfg, "operator"); p.ReadOnly || p.MaxDuration != 15*time.Minute || !p.ProhibitSudo {
+		t.Errorf("operator policy = %+v", p)
+	}
+	if p := terminalPolicyForRole(cfg, "admin"); p.ReadOnly || len(p.Allowed) != 0 {
+		t.Errorf("admin policy = %+v", p)
+	}
+}
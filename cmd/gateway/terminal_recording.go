@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/cmd/gateway/middleware"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxTerminalRecordingBytes caps the in-memory asciicast body so a runaway
+	// session (e.g. `cat` on a large file) cannot exhaust gateway memory.
+	maxTerminalRecordingBytes = 16 * 1024 * 1024
+
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+)
+
+// TerminalSession is the metadata of a recorded terminal session.
+type TerminalSession struct {
+	SessionID  string    `json:"session_id"`
+	AgentID    string    `json:"agent_id"`
+	Username   string    `json:"username"`
+	Command    string    `json:"command"`
+	RemoteAddr string    `json:"remote_addr"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+	BytesIn    uint64    `json:"bytes_in"`
+	BytesOut   uint64    `json:"bytes_out"`
+	Truncated  bool      `json:"truncated"`
+}
+
+// asciicastHeader is the first line of an asciicast v2 file.
+// See https://docs.asciinema.org/manual/asciicast/v2/
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// terminalRecorder captures stdin/stdout of a terminal session as asciicast v2 events.
+// It is safe for concurrent use by the WS->gRPC and gRPC->WS goroutines.
+type terminalRecorder struct {
+	mu        sync.Mutex
+	meta      TerminalSession
+	width     int
+	height    int
+	events    bytes.Buffer
+	truncated bool
+}
+
+func newTerminalRecorder(agentID, username, command, remoteAddr string, width, height int) *terminalRecorder {
+	if width <= 0 {
+		width = defaultTerminalWidth
+	}
+	if height <= 0 {
+		height = defaultTerminalHeight
+	}
+	return &terminalRecorder{
+		meta: TerminalSession{
+			SessionID:  uuid.New().String(),
+			AgentID:    agentID,
+			Username:   username,
+			Command:    command,
+			RemoteAddr: remoteAddr,
+			StartedAt:  time.Now().UTC(),
+		},
+		width:  width,
+		height: height,
+	}
+}
+
+// RecordInput records data typed by the user.
+func (t *terminalRecorder) RecordInput(data []byte) {
+	t.record("i", data, time.Now())
+}
+
+// RecordOutput records data printed by the remote shell.
+func (t *terminalRecorder) RecordOutput(data []byte) {
+	t.record("o", data, time.Now())
+}
+
+func (t *terminalRecorder) record(kind string, data []byte, at time.Time) {
+	if len(data) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if kind == "i" {
+		t.meta.BytesIn += uint64(len(data))
+	} else {
+		t.meta.BytesOut += uint64(len(data))
+	}
+	if t.truncated {
+		return
+	}
+
+	elapsed := at.Sub(t.meta.StartedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	// json.Marshal replaces invalid UTF-8 with U+FFFD, which is what players expect.
+	line, err := json.Marshal([]interface{}{roundSeconds(elapsed), kind, string(data)})
+	if err != nil {
+		return
+	}
+	if t.events.Len()+len(line)+1 > maxTerminalRecordingBytes {
+		t.truncated = true
+		return
+	}
+	t.events.Write(line)
+	t.events.WriteByte('\n')
+}
+
+// Finish stamps the end time and returns the session metadata and the full asciicast document.
+func (t *terminalRecorder) Finish() (TerminalSession, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.meta.EndedAt = time.Now().UTC()
+	t.meta.DurationMs = t.meta.EndedAt.Sub(t.meta.StartedAt).Milliseconds()
+	t.meta.Truncated = t.truncated
+
+	header := asciicastHeader{
+		Version:   2,
+		Width:     t.width,
+		Height:    t.height,
+		Timestamp: t.meta.StartedAt.Unix(),
+		Title:     fmt.Sprintf("%s@%s", t.meta.Username, t.meta.AgentID),
+		Env:       map[string]string{"SHELL": t.meta.Command, "TERM": "xterm-256color"},
+	}
+	hdr, _ := json.Marshal(header)
+
+	var sb strings.Builder
+	sb.Grow(len(hdr) + 1 + t.events.Len())
+	sb.Write(hdr)
+	sb.WriteByte('\n')
+	sb.Write(t.events.Bytes())
+	return t.meta, sb.String()
+}
+
+func roundSeconds(s float64) float64 {
+	return float64(int64(s*1e6)) / 1e6
+}
+
+// saveTerminalRecording persists a finished session; failures are logged, never fatal to the session.
+func (srv *server) saveTerminalRecording(rec *terminalRecorder) {
+	if rec == nil || srv.clickhouse == nil {
+		return
+	}
+	meta, cast := rec.Finish()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.clickhouse.InsertTerminalSession(ctx, meta, cast); err != nil {
+		securityLog.Error().Msgf("Failed to store terminal recording %s for agent %s: %v", meta.SessionID, meta.AgentID, err)
+		return
+	}
+	securityLog.Info().Msgf("Stored terminal recording %s for agent %s (%d bytes in, %d bytes out)", meta.SessionID, meta.AgentID, meta.BytesIn, meta.BytesOut)
+}
+
+// GET /api/servers/{agentId}/terminal-sessions
+func (srv *server) handleListTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	agentID := r.PathValue("agentId")
+	if agentID == "" {
+		http.Error(w, "agent id required", http.StatusBadRequest)
+		return
+	}
+	if resolved, ok := srv.resolveAgentID(agentID); ok {
+		agentID = resolved
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	if user != nil && !srv.canUserAccessAgent(user.Username, agentID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	if srv.clickhouse == nil {
+		http.Error(w, "ClickHouse connection not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	sessions, err := srv.clickhouse.ListTerminalSessions(r.Context(), agentID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// GET /api/terminal-sessions/{id}/cast
+// Returns the raw asciicast v2 document, playable with `asciinema play`.
+func (srv *server) handleGetTerminalRecording(w http.ResponseWriter, r *http.Request) {
+	meta, cast, ok := srv.loadTerminalRecording(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", meta.SessionID+".cast"))
+	w.Write([]byte(cast))
+}
+
+// GET /api/terminal-sessions/{id}/playback?speed=2
+// Streams the recording as newline-delimited asciicast events, paced like the original session.
+func (srv *server) handleStreamTerminalPlayback(w http.ResponseWriter, r *http.Request) {
+	_, cast, ok := srv.loadTerminalRecording(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	speed, _ := strconv.ParseFloat(r.URL.Query().Get("speed"), 64)
+	if speed <= 0 {
+		speed = 1
+	}
+	// Cap idle gaps so a session left open over lunch doesn't stall playback.
+	maxIdle := 2 * time.Second
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("idle_limit"), 64); err == nil && v > 0 {
+		maxIdle = time.Duration(v * float64(time.Second))
+	}
+
+	// Playback can outlast the server's WriteTimeout; lift the deadline for this response only.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	scanner := bufio.NewScanner(strings.NewReader(cast))
+	scanner.Buffer(make([]byte, 64*1024), maxTerminalRecordingBytes)
+
+	// Header first, then events
+	if !scanner.Scan() {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", scanner.Bytes())
+	flusher.Flush()
+
+	var prev float64
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var ev []json.RawMessage
+		if err := json.Unmarshal(line, &ev); err != nil || len(ev) < 1 {
+			continue
+		}
+		var at float64
+		if err := json.Unmarshal(ev[0], &at); err != nil {
+			continue
+		}
+		delay := time.Duration((at - prev) / speed * float64(time.Second))
+		if delay > maxIdle {
+			delay = maxIdle
+		}
+		prev = at
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// loadTerminalRecording fetches a recording and enforces agent-level RBAC. It writes the error response itself.
+func (srv *server) loadTerminalRecording(w http.ResponseWriter, r *http.Request) (TerminalSession, string, bool) {
+	sessionID := r.PathValue("id")
+	if sessionID == "" {
+		http.Error(w, "session id required", http.StatusBadRequest)
+		return TerminalSession{}, "", false
+	}
+	if srv.clickhouse == nil {
+		http.Error(w, "ClickHouse connection not available", http.StatusServiceUnavailable)
+		return TerminalSession{}, "", false
+	}
+
+	meta, cast, err := srv.clickhouse.GetTerminalSession(r.Context(), sessionID)
+	if err != nil {
+		http.Error(w, "Recording not found", http.StatusNotFound)
+		return TerminalSession{}, "", false
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	if user != nil && !srv.canUserAccessAgent(user.Username, meta.AgentID) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return TerminalSession{}, "", false
+	}
+	return meta, cast, true
+}
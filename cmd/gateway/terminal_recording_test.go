@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTerminalRecorder_Asciicast(t *testing.T) {
+	rec := newTerminalRecorder("agent-1", "alice", "/bin/bash", "10.0.0.1:5555", 0, 0)
+	rec.RecordInput([]byte("ls\r"))
+	rec.RecordOutput([]byte("file.txt\r\n"))
+	rec.RecordOutput(nil)
+
+	meta, cast := rec.Finish()
+	if meta.BytesIn != 3 || meta.BytesOut != 10 {
+		t.Errorf("unexpected byte counts: in=%d out=%d", meta.BytesIn, meta.BytesOut)
+	}
+
+	lines := strings.Split(strings.TrimSpace(cast), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 events, got %d lines:\n%s", len(lines), cast)
+	}
+
+	var hdr asciicastHeader
+	if err := json.Unmarshal([]byte(lines[0]), &hdr); err != nil {
+		t.Fatalf("invalid header: %v", err)
+	}
+	if hdr.Version != 2 || hdr.Width != defaultTerminalWidth || hdr.Height != defaultTerminalHeight {
+		t.Errorf("unexpected header: %+v", hdr)
+	}
+
+	var ev []interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &ev); err != nil {
+		t.Fatalf("invalid event: %v", err)
+	}
+	if len(ev) != 3 || ev[1] != "i" || ev[2] != "ls\r" {
+		t.Errorf("unexpected input event: %v", ev)
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &ev); err != nil {
+		t.Fatalf("invalid event: %v", err)
+	}
+	if ev[1] != "o" || ev[2] != "file.txt\r\n" {
+		t.Errorf("unexpected output event: %v", ev)
+	}
+}
+
+func TestTerminalRecorder_Truncates(t *testing.T) {
+	rec := newTerminalRecorder("agent-1", "alice", "", "", 120, 40)
+	chunk := []byte(strings.Repeat("x", 1024*1024))
+	for i := 0; i < 20; i++ {
+		rec.RecordOutput(chunk)
+	}
+
+	meta, cast := rec.Finish()
+	if !meta.Truncated {
+		t.Error("expected recording to be marked truncated")
+	}
+	if meta.BytesOut != uint64(20*len(chunk)) {
+		t.Errorf("byte counter should include dropped output, got %d", meta.BytesOut)
+	}
+	if len(cast) > maxTerminalRecordingBytes+1024 {
+		t.Errorf("recording exceeded cap: %d bytes", len(cast))
+	}
+}
@@ -0,0 +1,263 @@
+package threatintel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+var feedLog = logging.NewLogger("threatintel")
+
+// Feed types
+const (
+	TypeSpamhausDROP = "spamhaus_drop"
+	TypeAbuseIPDB    = "abuseipdb"
+	TypeList         = "list"
+)
+
+const (
+	defaultSpamhausURL     = "https://www.spamhaus.org/drop/drop_v4.json"
+	defaultAbuseIPDBURL    = "https://api.abuseipdb.com/api/v2/blacklist"
+	defaultMinConfidence   = 90
+	defaultRefreshInterval = 6 * time.Hour
+	maxFeedSize            = 64 << 20
+)
+
+// Feed configures one source of malicious addresses
+type Feed struct {
+	Name          string // Unique name shown in the UI and stored with tagged requests
+	Type          string // spamhaus_drop, abuseipdb or list
+	URL           string // Download URL; optional for spamhaus_drop/abuseipdb, list feeds need URL or Path
+	Path          string // Local file for list feeds (works offline)
+	APIKey        string // AbuseIPDB API key
+	MinConfidence int    // AbuseIPDB confidence threshold (default 90)
+	Category      string // Tag stored with matching requests; defaults per type
+}
+
+// DefaultCategory returns the category used when a feed does not set one
+func (f Feed) DefaultCategory() string {
+	if f.Category != "" {
+		return f.Category
+	}
+	switch f.Type {
+	case TypeSpamhausDROP:
+		return "hijacked_network"
+	case TypeAbuseIPDB:
+		return "abusive_ip"
+	default:
+		return "blocklist"
+	}
+}
+
+// remote reports whether loading the feed requires network access
+func (f Feed) remote() bool {
+	return f.Type != TypeList || f.Path == ""
+}
+
+// Validate checks that the feed has enough configuration to load
+func (f Feed) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("feed name is required")
+	}
+	switch f.Type {
+	case TypeSpamhausDROP:
+	case TypeAbuseIPDB:
+		if f.APIKey == "" {
+			return fmt.Errorf("feed %s: api key is required for abuseipdb", f.Name)
+		}
+	case TypeList:
+		if f.URL == "" && f.Path == "" {
+			return fmt.Errorf("feed %s: url or path is required", f.Name)
+		}
+	default:
+		return fmt.Errorf("feed %s: unknown type %q", f.Name, f.Type)
+	}
+	return nil
+}
+
+// Refresher periodically reloads feeds into a Store
+type Refresher struct {
+	store    *Store
+	feeds    []Feed
+	interval time.Duration
+	offline  bool
+	client   *http.Client
+}
+
+// NewRefresher creates a refresher. With offline set only local list files are loaded.
+func NewRefresher(store *Store, feeds []Feed, interval time.Duration, offline bool) *Refresher {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	return &Refresher{
+		store:    store,
+		feeds:    feeds,
+		interval: interval,
+		offline:  offline,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Start loads all feeds now and then on every interval until ctx is done
+func (r *Refresher) Start(ctx context.Context) {
+	go func() {
+		r.Refresh(ctx)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh reloads every feed. A feed that fails keeps its previous entries.
+func (r *Refresher) Refresh(ctx context.Context) {
+	for _, f := range r.feeds {
+		category := f.DefaultCategory()
+		if err := f.Validate(); err != nil {
+			r.store.SetError(f.Name, f.Type, category, err)
+			continue
+		}
+		if r.offline && f.remote() {
+			r.store.SetError(f.Name, f.Type, category, fmt.Errorf("remote feeds are disabled in offline mode"))
+			continue
+		}
+		prefixes, err := r.load(ctx, f)
+		if err != nil {
+			feedLog.Error().Msgf("Threat intel: feed %s failed: %v", f.Name, err)
+			r.store.SetError(f.Name, f.Type, category, err)
+			continue
+		}
+		r.store.Replace(f.Name, f.Type, category, prefixes)
+		feedLog.Info().Msgf("Threat intel: loaded %d entries from %s", len(prefixes), f.Name)
+	}
+}
+
+func (r *Refresher) load(ctx context.Context, f Feed) ([]netip.Prefix, error) {
+	if f.Type == TypeList && f.Path != "" {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ParseList(file)
+	}
+
+	req, err := r.feedRequest(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download returned %s", resp.Status)
+	}
+	return ParseList(io.LimitReader(resp.Body, maxFeedSize))
+}
+
+func (r *Refresher) feedRequest(ctx context.Context, f Feed) (*http.Request, error) {
+	switch f.Type {
+	case TypeSpamhausDROP:
+		u := f.URL
+		if u == "" {
+			u = defaultSpamhausURL
+		}
+		return http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	case TypeAbuseIPDB:
+		base := f.URL
+		if base == "" {
+			base = defaultAbuseIPDBURL
+		}
+		minConfidence := f.MinConfidence
+		if minConfidence <= 0 {
+			minConfidence = defaultMinConfidence
+		}
+		q := url.Values{"confidenceMinimum": {strconv.Itoa(minConfidence)}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Key", f.APIKey)
+		req.Header.Set("Accept", "text/plain")
+		return req, nil
+	default:
+		return http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	}
+}
+
+// ParseList reads addresses and CIDRs, one per line. It accepts plain lists with # or ;
+// comments, Spamhaus DROP text ("1.2.3.0/24 ; SBL123") and Spamhaus JSON lines ({"cidr": ...}).
+// Unparseable lines are skipped; an input without a single entry is an error.
+func ParseList(r io.Reader) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '{' {
+			var rec struct {
+				CIDR string `json:"cidr"`
+				IP   string `json:"ip"`
+			}
+			if json.Unmarshal([]byte(line), &rec) != nil {
+				continue
+			}
+			line = rec.CIDR
+			if line == "" {
+				line = rec.IP
+			}
+		}
+		if i := strings.IndexAny(line, " \t;#,"); i >= 0 {
+			line = line[:i]
+		}
+		if p, ok := parsePrefix(line); ok {
+			prefixes = append(prefixes, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("feed contains no addresses")
+	}
+	return prefixes, nil
+}
+
+// parsePrefix accepts "a.b.c.d", "a.b.c.d/n" and their IPv6 forms
+func parsePrefix(s string) (netip.Prefix, bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		return p.Masked(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
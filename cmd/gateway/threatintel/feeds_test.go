@@ -0,0 +1,111 @@
+package threatintel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	input := `; Spamhaus DROP List
+1.10.16.0/20 ; SBL256894
+{"cidr":"2.56.192.0/22","sblid":"SBL459831","rir":"ripencc"}
+{"type":"metadata","timestamp":1700000000,"size":2}
+# custom entries
+203.0.113.7
+2001:db8::/32 bad network
+not-an-ip
+`
+	prefixes, err := ParseList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseList: %v", err)
+	}
+	want := []string{"1.10.16.0/20", "2.56.192.0/22", "203.0.113.7/32", "2001:db8::/32"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("got %v, want %v", prefixes, want)
+	}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Errorf("prefix %d = %s, want %s", i, p, want[i])
+		}
+	}
+
+	if _, err := ParseList(strings.NewReader("# empty\n")); err == nil {
+		t.Error("expected an error for a feed without entries")
+	}
+}
+
+func TestStoreLookup(t *testing.T) {
+	s := NewStore()
+	prefixes, _ := ParseList(strings.NewReader("10.0.0.0/8\n10.1.2.0/24\n198.51.100.9\n"))
+	s.Replace("drop", TypeSpamhausDROP, "hijacked_network", prefixes)
+
+	m, ok := s.Lookup("10.1.2.3")
+	if !ok || m.Feed != "drop" || m.Prefix != "10.1.2.0/24" {
+		t.Errorf("10.1.2.3: %+v, %v (want most specific prefix)", m, ok)
+	}
+	if m, ok := s.Lookup("::ffff:198.51.100.9"); !ok || m.Prefix != "198.51.100.9/32" {
+		t.Errorf("mapped address: %+v, %v", m, ok)
+	}
+	if _, ok := s.Lookup("192.0.2.1"); ok {
+		t.Error("192.0.2.1 should not match")
+	}
+
+	s.SetError("drop", TypeSpamhausDROP, "hijacked_network", os.ErrDeadlineExceeded)
+	if _, ok := s.Lookup("10.9.9.9"); !ok {
+		t.Error("a failed refresh must keep previous entries")
+	}
+	if st := s.Status(); len(st) != 1 || st[0].Entries != 3 || st[0].Error == "" {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestRefresher(t *testing.T) {
+	var gotKey, gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotQuery = r.Header.Get("Key"), r.URL.RawQuery
+		w.Write([]byte("192.0.2.10\n192.0.2.11\n"))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "custom.txt")
+	if err := os.WriteFile(path, []byte("198.51.100.0/24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	feeds := []Feed{
+		{Name: "abuseipdb", Type: TypeAbuseIPDB, URL: ts.URL, APIKey: "k"},
+		{Name: "local", Type: TypeList, Path: path},
+		{Name: "broken", Type: TypeAbuseIPDB},
+	}
+
+	s := NewStore()
+	NewRefresher(s, feeds, 0, false).Refresh(context.Background())
+	if gotKey != "k" || gotQuery != "confidenceMinimum=90" {
+		t.Errorf("abuseipdb request key=%q query=%q", gotKey, gotQuery)
+	}
+	if m, ok := s.Lookup("192.0.2.11"); !ok || m.Category != "abusive_ip" {
+		t.Errorf("abuseipdb entry: %+v, %v", m, ok)
+	}
+	if m, ok := s.Lookup("198.51.100.20"); !ok || m.Feed != "local" {
+		t.Errorf("local entry: %+v, %v", m, ok)
+	}
+
+	offline := NewStore()
+	NewRefresher(offline, feeds, 0, true).Refresh(context.Background())
+	if _, ok := offline.Lookup("192.0.2.10"); ok {
+		t.Error("remote feeds must not load in offline mode")
+	}
+	if _, ok := offline.Lookup("198.51.100.20"); !ok {
+		t.Error("local lists should load in offline mode")
+	}
+	for _, st := range offline.Status() {
+		if st.Name != "local" && st.Error == "" {
+			t.Errorf("feed %s should report an error: %+v", st.Name, st)
+		}
+	}
+}
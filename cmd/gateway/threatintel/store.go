@@ -0,0 +1,145 @@
+// Package threatintel matches client IPs against threat intelligence feeds
+// (Spamhaus DROP, AbuseIPDB, custom lists) so access logs can be tagged with known bad sources.
+package threatintel
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Match describes why an address is considered malicious
+type Match struct {
+	Feed     string `json:"feed"`
+	Category string `json:"category"`
+	Prefix   string `json:"prefix"`
+}
+
+// FeedStatus reports the state of one configured feed
+type FeedStatus struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Category   string    `json:"category"`
+	Entries    int       `json:"entries"`
+	LastUpdate time.Time `json:"last_update,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// feedIndex holds one feed's prefixes keyed by their masked form, grouped by prefix length,
+// so a lookup costs one map probe per distinct length instead of a scan of every entry.
+type feedIndex struct {
+	name     string
+	category string
+	bits     []int
+	prefixes map[netip.Prefix]struct{}
+}
+
+func newFeedIndex(name, category string, prefixes []netip.Prefix) *feedIndex {
+	idx := &feedIndex{name: name, category: category, prefixes: make(map[netip.Prefix]struct{}, len(prefixes))}
+	seenBits := make(map[int]bool)
+	for _, p := range prefixes {
+		p = p.Masked()
+		idx.prefixes[p] = struct{}{}
+		if !seenBits[p.Bits()] {
+			seenBits[p.Bits()] = true
+			idx.bits = append(idx.bits, p.Bits())
+		}
+	}
+	// Most specific first so the reported prefix is the narrowest listed one
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.bits)))
+	return idx
+}
+
+func (f *feedIndex) lookup(addr netip.Addr) (netip.Prefix, bool) {
+	for _, bits := range f.bits {
+		if bits > addr.BitLen() {
+			continue
+		}
+		p, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if _, ok := f.prefixes[p]; ok {
+			return p, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// Store is a concurrency-safe set of feeds. Feeds are replaced wholesale on refresh.
+type Store struct {
+	mu     sync.RWMutex
+	feeds  map[string]*feedIndex
+	order  []string
+	status map[string]*FeedStatus
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		feeds:  make(map[string]*feedIndex),
+		status: make(map[string]*FeedStatus),
+	}
+}
+
+// Replace swaps in the entries for a feed
+func (s *Store) Replace(name, feedType, category string, prefixes []netip.Prefix) {
+	idx := newFeedIndex(name, category, prefixes)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feeds[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.feeds[name] = idx
+	s.status[name] = &FeedStatus{Name: name, Type: feedType, Category: category, Entries: len(idx.prefixes), LastUpdate: time.Now()}
+}
+
+// SetError records a failed refresh without discarding the entries already loaded
+func (s *Store) SetError(name, feedType, category string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.status[name]
+	if !ok {
+		st = &FeedStatus{Name: name, Type: feedType, Category: category}
+		s.status[name] = st
+		s.order = append(s.order, name)
+	}
+	st.Error = err.Error()
+}
+
+// Lookup returns the first feed listing ipStr
+func (s *Store) Lookup(ipStr string) (Match, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ipStr))
+	if err != nil {
+		return Match{}, false
+	}
+	addr = addr.Unmap()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, name := range s.order {
+		f, ok := s.feeds[name]
+		if !ok {
+			continue
+		}
+		if p, ok := f.lookup(addr); ok {
+			return Match{Feed: f.name, Category: f.category, Prefix: p.String()}, true
+		}
+	}
+	return Match{}, false
+}
+
+// Status returns the state of every feed in configuration order
+func (s *Store) Status() []FeedStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]FeedStatus, 0, len(s.order))
+	for _, name := range s.order {
+		if st, ok := s.status[name]; ok {
+			out = append(out, *st)
+		}
+	}
+	return out
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// TLSScanTarget is one HTTPS virtual host served by an agent
+type TLSScanTarget struct {
+	AgentID    string `json:"agent_id"`
+	ServerName string `json:"server_name"`
+	Address    string `json:"address"` // agent IP:port dialled with ServerName as SNI
+}
+
+// TLSScanResult is the graded TLS and security header posture of one virtual host
+type TLSScanResult struct {
+	ID         string    `json:"id,omitempty"`
+	AgentID    string    `json:"agent_id"`
+	ServerName string    `json:"server_name"`
+	Address    string    `json:"address"`
+	ScannedAt  time.Time `json:"scanned_at"`
+	Grade      string    `json:"grade"`
+	Score      int       `json:"score"`
+	Error      string    `json:"error,omitempty"` // Set when no TLS handshake succeeded
+
+	Protocols     []string  `json:"protocols"`    // Accepted protocol versions
+	CipherSuite   string    `json:"cipher_suite"` // Negotiated with a default client
+	WeakCiphers   []string  `json:"weak_ciphers"` // Accepted suites without forward secrecy or with broken primitives
+	CertSubject   string    `json:"cert_subject"`
+	CertIssuer    string    `json:"cert_issuer"`
+	CertNotAfter  time.Time `json:"cert_not_after"`
+	CertDaysLeft  int       `json:"cert_days_left"`
+	ChainIssues   []string  `json:"chain_issues"`
+	HSTS          string    `json:"hsts,omitempty"` // Raw header values; empty when missing
+	CSP           string    `json:"csp,omitempty"`
+	XFrameOptions string    `json:"x_frame_options,omitempty"`
+	XContentType  string    `json:"x_content_type_options,omitempty"`
+	Findings      []string  `json:"findings"`
+}
+
+var tlsProbeVersions = []struct {
+	version uint16
+	name    string
+}{
+	{tls.VersionTLS10, "TLSv1.0"},
+	{tls.VersionTLS11, "TLSv1.1"},
+	{tls.VersionTLS12, "TLSv1.2"},
+	{tls.VersionTLS13, "TLSv1.3"},
+}
+
+// weakTLSCipherSuites are offered in a TLS 1.2 probe; acceptance of any is a finding.
+// They are Go's insecure suites plus the static-RSA suites that lack forward secrecy.
+func weakTLSCipherSuites() []uint16 {
+	var ids []uint16
+	for _, cs := range tls.InsecureCipherSuites() {
+		ids = append(ids, cs.ID)
+	}
+	for _, cs := range tls.CipherSuites() {
+		if strings.HasPrefix(cs.Name, "TLS_RSA_") {
+			ids = append(ids, cs.ID)
+		}
+	}
+	return ids
+}
+
+// tlsListenPort returns the port of a listen directive that serves TLS, or 0
+func tlsListenPort(listen string) int {
+	fields := strings.Fields(listen)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "unix:") {
+		return 0
+	}
+	ssl := false
+	for _, f := range fields[1:] {
+		if f == "ssl" || f == "quic" {
+			ssl = true
+		}
+	}
+	addr := fields[0]
+	portStr := addr
+	if i := strings.LastIndex(addr, ":"); i >= 0 && !strings.HasSuffix(addr, "]") {
+		portStr = addr[i+1:]
+	} else if strings.Contains(addr, ".") || strings.HasSuffix(addr, "]") {
+		portStr = "80" // address without port
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0
+	}
+	if !ssl && port != 443 {
+		return 0
+	}
+	return port
+}
+
+// tlsScanHostname reports whether a server_name value names one concrete host
+var tlsScanHostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)+$`)
+
+// tlsTargetsFromConfig lists the HTTPS virtual hosts of an agent. Wildcard, regex and catch-all
+// server names are skipped because there is no concrete name to send as SNI.
+func tlsTargetsFromConfig(agentID, agentIP string, servers []*pb.ServerBlock) []TLSScanTarget {
+	seen := make(map[string]bool)
+	var targets []TLSScanTarget
+	for _, s := range servers {
+		ports := map[int]bool{}
+		for _, l := range s.Listen {
+			if p := tlsListenPort(l); p > 0 {
+				ports[p] = true
+			}
+		}
+		for port := range ports {
+			for _, name := range s.ServerName {
+				name = strings.ToLower(strings.TrimSuffix(name, "."))
+				if !tlsScanHostname.MatchString(name) {
+					continue
+				}
+				addr := net.JoinHostPort(agentIP, strconv.Itoa(port))
+				if seen[name+"|"+addr] {
+					continue
+				}
+				seen[name+"|"+addr] = true
+				targets = append(targets, TLSScanTarget{AgentID: agentID, ServerName: name, Address: addr})
+			}
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].ServerName != targets[j].ServerName {
+			return targets[i].ServerName < targets[j].ServerName
+		}
+		return targets[i].Address < targets[j].Address
+	})
+	return targets
+}
+
+func tlsHandshake(ctx context.Context, t TLSScanTarget, cfg *tls.Config) (*tls.ConnectionState, error) {
+	cfg.ServerName = t.ServerName
+	cfg.InsecureSkipVerify = true // the chain is verified separately so handshakes succeed on broken chains
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 10 * time.Second}, Config: cfg}
+	conn, err := dialer.DialContext(ctx, "tcp", t.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	return &state, nil
+}
+
+// verifyCertChain describes why the presented chain would be rejected by a browser
+func verifyCertChain(serverName string, certs []*x509.Certificate, now time.Time) []string {
+	if len(certs) == 0 {
+		return []string{"no certificate presented"}
+	}
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	var issues []string
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: intermediates, CurrentTime: now})
+	var hostErr x509.HostnameError
+	var authErr x509.UnknownAuthorityError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case err == nil:
+	case errors.As(err, &hostErr):
+		issues = append(issues, "certificate does not cover "+serverName)
+	case errors.As(err, &authErr):
+		if leaf.Subject.String() == leaf.Issuer.String() {
+			issues = append(issues, "self-signed certificate")
+		} else {
+			issues = append(issues, "untrusted issuer or incomplete chain")
+		}
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		issues = append(issues, "certificate expired or not yet valid")
+	default:
+		issues = append(issues, "chain verification failed: "+err.Error())
+	}
+
+	switch leaf.SignatureAlgorithm {
+	case x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.MD5WithRSA:
+		issues = append(issues, "weak signature algorithm "+leaf.SignatureAlgorithm.String())
+	}
+	switch k := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			issues = append(issues, fmt.Sprintf("RSA key of %d bits", k.N.BitLen()))
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().BitSize < 256 {
+			issues = append(issues, fmt.Sprintf("EC key of %d bits", k.Curve.Params().BitSize))
+		}
+	}
+	return issues
+}
+
+// fetchSecurityHeaders requests / over HTTPS without following redirects
+func fetchSecurityHeaders(ctx context.Context, t TLSScanTarget) (http.Header, error) {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, network, t.Address)
+		},
+		TLSClientConfig:     &tls.Config{ServerName: t.ServerName, InsecureSkipVerify: true},
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableKeepAlives:   true,
+	}
+	client := &http.Client{
+		Transport:     transport,
+		Timeout:       15 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+t.ServerName+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Avika-TLS-Scanner/1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// scanTLSTarget probes protocol versions, weak cipher suites, the certificate chain and the
+// security headers of one virtual host, then grades the result
+func scanTLSTarget(ctx context.Context, t TLSScanTarget) TLSScanResult {
+	now := time.Now()
+	res := TLSScanResult{
+		AgentID:     t.AgentID,
+		ServerName:  t.ServerName,
+		Address:     t.Address,
+		ScannedAt:   now,
+		Protocols:   []string{},
+		WeakCiphers: []string{},
+		ChainIssues: []string{},
+	}
+
+	for _, v := range tlsProbeVersions {
+		if _, err := tlsHandshake(ctx, t, &tls.Config{MinVersion: v.version, MaxVersion: v.version}); err == nil {
+			res.Protocols = append(res.Protocols, v.name)
+		}
+	}
+
+	state, err := tlsHandshake(ctx, t, &tls.Config{})
+	if err != nil {
+		res.Error = err.Error()
+		gradeTLSResult(&res, now)
+		return res
+	}
+	res.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		res.CertSubject = leaf.Subject.CommonName
+		res.CertIssuer = leaf.Issuer.CommonName
+		res.CertNotAfter = leaf.NotAfter
+		res.CertDaysLeft = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	}
+	res.ChainIssues = verifyCertChain(t.ServerName, state.PeerCertificates, now)
+
+	// Enumerate accepted weak suites by removing each negotiated one and retrying
+	weak := weakTLSCipherSuites()
+	for len(weak) > 0 {
+		st, err := tlsHandshake(ctx, t, &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS12, CipherSuites: weak})
+		if err != nil {
+			break
+		}
+		res.WeakCiphers = append(res.WeakCiphers, tls.CipherSuiteName(st.CipherSuite))
+		remaining := weak[:0]
+		for _, id := range weak {
+			if id != st.CipherSuite {
+				remaining = append(remaining, id)
+			}
+		}
+		weak = remaining
+	}
+
+	if h, err := fetchSecurityHeaders(ctx, t); err == nil {
+		res.HSTS = h.Get("Strict-Transport-Security")
+		res.CSP = h.Get("Content-Security-Policy")
+		res.XFrameOptions = h.Get("X-Frame-Options")
+		res.XContentType = h.Get("X-Content-Type-Options")
+	} else {
+		res.Findings = append(res.Findings, "security headers not checked: "+err.Error())
+	}
+
+	gradeTLSResult(&res, now)
+	return res
+}
+
+var hstsMaxAgePattern = regexp.MustCompile(`(?i)max-age\s*=\s*"?(\d+)`)
+
+// gradeTLSResult scores the result out of 100 and maps it to A-F. Broken chains and failed
+// handshakes grade F; legacy protocols cap the grade at B and weak ciphers at C.
+func gradeTLSResult(res *TLSScanResult, now time.Time) {
+	score := 100
+	maxGrade := "A"
+	capAt := func(g string) {
+		if g > maxGrade {
+			maxGrade = g
+		}
+	}
+	finding := func(penalty int, format string, args ...interface{}) {
+		score -= penalty
+		res.Findings = append(res.Findings, fmt.Sprintf(format, args...))
+	}
+
+	if res.Error != "" {
+		res.Findings = append(res.Findings, "TLS handshake failed: "+res.Error)
+		res.Score, res.Grade = 0, "F"
+		return
+	}
+
+	if len(res.ChainIssues) > 0 {
+		for _, issue := range res.ChainIssues {
+			finding(0, "certificate: %s", issue)
+		}
+		score -= 40
+		capAt("F")
+	}
+	if res.CertDaysLeft < 14 && !res.CertNotAfter.IsZero() && res.CertNotAfter.After(now) {
+		finding(10, "certificate expires in %d days", res.CertDaysLeft)
+	}
+
+	for _, p := range res.Protocols {
+		if p == "TLSv1.0" || p == "TLSv1.1" {
+			finding(15, "legacy protocol %s enabled", p)
+			capAt("B")
+		}
+	}
+	if !stringInSlice(res.Protocols, "TLSv1.3") {
+		finding(5, "TLSv1.3 not supported")
+	}
+	if len(res.WeakCiphers) > 0 {
+		finding(20, "weak cipher suites accepted: %s", strings.Join(res.WeakCiphers, ", "))
+		capAt("C")
+	}
+
+	if res.HSTS == "" {
+		finding(10, "Strict-Transport-Security header missing")
+	} else if m := hstsMaxAgePattern.FindStringSubmatch(res.HSTS); m == nil {
+		finding(5, "Strict-Transport-Security has no max-age")
+	} else if age, _ := strconv.ParseInt(m[1], 10, 64); age < 180*24*3600 {
+		finding(5, "Strict-Transport-Security max-age below 6 months (%d s)", age)
+	}
+	if res.CSP == "" {
+		finding(5, "Content-Security-Policy header missing")
+	}
+	if res.XContentType == "" {
+		finding(3, "X-Content-Type-Options header missing")
+	}
+	if res.XFrameOptions == "" && !strings.Contains(strings.ToLower(res.CSP), "frame-ancestors") {
+		finding(2, "clickjacking protection missing (X-Frame-Options or CSP frame-ancestors)")
+	}
+
+	if score < 0 {
+		score = 0
+	}
+	grade := "F"
+	switch {
+	case score >= 90:
+		grade = "A"
+	case score >= 80:
+		grade = "B"
+	case score >= 65:
+		grade = "C"
+	case score >= 50:
+		grade = "D"
+	}
+	if grade < maxGrade {
+		grade = maxGrade
+	}
+	res.Score, res.Grade = score, grade
+}
+
+// startTLSScanner periodically scans the HTTPS virtual hosts of every online agent
+func (srv *server) startTLSScanner() {
+	interval := srv.config.Security.TLSScanInterval
+	if interval <= 0 || srv.db == nil {
+		return
+	}
+	go func() {
+		alertsLog.Info().Dur("interval", interval).Msg("Starting TLS posture scanner")
+		// Give agents time to reconnect after a gateway restart before the first sweep
+		time.Sleep(5 * time.Minute)
+		srv.scanFleetTLS()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			srv.scanFleetTLS()
+		}
+	}()
+}
+
+func (srv *server) scanFleetTLS() {
+	// Each gateway scans the agents connected to it
+	agentIDs := srv.connectedAgentIDs()
+
+	scanned := 0
+	for _, agentID := range agentIDs {
+		results, err := srv.scanAgentTLS(context.Background(), agentID)
+		if err != nil {
+			alertsLog.Error().Msgf("TLS scanner: agent %s: %v", agentID, err)
+			continue
+		}
+		scanned += len(results)
+	}
+	if n, err := srv.db.PruneTLSScanResults(tlsScanRetention); err == nil && n > 0 {
+		alertsLog.Info().Msgf("TLS scanner: pruned %d old results", n)
+	}
+	alertsLog.Info().Int("agents", len(agentIDs)).Int("vhosts", scanned).Msg("TLS posture scan complete")
+}
+
+// tlsScanRetention is how long scan history is kept
+const tlsScanRetention = 180 * 24 * time.Hour
+
+// scanAgentTLS reads the agent's server blocks, scans each HTTPS virtual host and stores the results
+func (srv *server) scanAgentTLS(ctx context.Context, agentID string) ([]TLSScanResult, error) {
+	val, ok := srv.sessions.Load(agentID)
+	if !ok {
+		return nil, fmt.Errorf("agent not connected")
+	}
+	session := val.(*AgentSession)
+	session.mu.Lock()
+	agentIP := session.ip
+	session.mu.Unlock()
+	if net.ParseIP(agentIP) == nil {
+		return nil, fmt.Errorf("agent address %q is not dialable", agentIP)
+	}
+
+	client, conn, err := srv.getAgentClient(agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	cfgCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	resp, err := client.GetConfig(cfgCtx, &pb.ConfigRequest{InstanceId: agentID})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" || resp.Config == nil {
+		return nil, fmt.Errorf("read config: %s", resp.Error)
+	}
+
+	targets := tlsTargetsFromConfig(agentID, agentIP, resp.Config.Servers)
+	results := make([]TLSScanResult, len(targets))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t TLSScanTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			scanCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			defer cancel()
+			results[i] = scanTLSTarget(scanCtx, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if err := srv.db.InsertTLSScanResult(&results[i]); err != nil {
+			alertsLog.Error().Msgf("TLS scanner: failed to store result for %s on %s: %v", results[i].ServerName, agentID, err)
+		}
+	}
+	return results, nil
+}
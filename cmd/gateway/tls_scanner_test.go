@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestTLSListenPort(t *testing.T) {
+	tests := map[string]int{
+		"443 ssl":                  443,
+		"443":                      443,
+		"[::]:443 ssl http2":       443,
+		"10.0.0.1:8443 ssl":        8443,
+		"*:8443 ssl":               8443,
+		"80":                       0,
+		"[::]:80":                  0,
+		"unix:/run/nginx.sock ssl": 0,
+		"10.0.0.1 ssl":             80,
+	}
+	for listen, want := range tests {
+		if got := tlsListenPort(listen); got != want {
+			t.Errorf("tlsListenPort(%q) = %d, want %d", listen, got, want)
+		}
+	}
+}
+
+func TestTLSTargetsFromConfig(t *testing.T) {
+	servers := []*pb.ServerBlock{
+		{Listen: []string{"443 ssl", "[::]:443 ssl"}, ServerName: []string{"example.com", "www.example.com", "*.example.com", "_"}},
+		{Listen: []string{"80"}, ServerName: []string{"plain.example.com"}},
+		{Listen: []string{"8443 ssl"}, ServerName: []string{"~^api\\d+\\.example\\.com$", "API.example.com."}},
+	}
+	targets := tlsTargetsFromConfig("agent-1", "192.0.2.10", servers)
+
+	var got []string
+	for _, tg := range targets {
+		got = append(got, tg.ServerName+"@"+tg.Address)
+	}
+	want := []string{"api.example.com@192.0.2.10:8443", "example.com@192.0.2.10:443", "www.example.com@192.0.2.10:443"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+}
+
+func TestGradeTLSResult(t *testing.T) {
+	now := time.Now()
+	good := TLSScanResult{
+		Protocols:    []string{"TLSv1.2", "TLSv1.3"},
+		CertNotAfter: now.Add(90 * 24 * time.Hour),
+		CertDaysLeft: 90,
+		HSTS:         "max-age=31536000; includeSubDomains",
+		CSP:          "default-src 'self'; frame-ancestors 'none'",
+		XContentType: "nosniff",
+	}
+	gradeTLSResult(&good, now)
+	if good.Grade != "A" || good.Score != 100 {
+		t.Errorf("good config graded %s/%d: %v", good.Grade, good.Score, good.Findings)
+	}
+
+	legacy := good
+	legacy.Findings = nil
+	legacy.Protocols = []string{"TLSv1.0", "TLSv1.2", "TLSv1.3"}
+	gradeTLSResult(&legacy, now)
+	if legacy.Grade != "B" {
+		t.Errorf("TLSv1.0 graded %s, want B", legacy.Grade)
+	}
+
+	weak := good
+	weak.Findings = nil
+	weak.WeakCiphers = []string{"TLS_RSA_WITH_3DES_EDE_CBC_SHA"}
+	gradeTLSResult(&weak, now)
+	if weak.Grade != "C" {
+		t.Errorf("weak ciphers graded %s, want C", weak.Grade)
+	}
+
+	broken := good
+	broken.Findings = nil
+	broken.ChainIssues = []string{"self-signed certificate"}
+	gradeTLSResult(&broken, now)
+	if broken.Grade != "F" {
+		t.Errorf("broken chain graded %s, want F", broken.Grade)
+	}
+
+	failed := TLSScanResult{Error: "connection refused"}
+	gradeTLSResult(&failed, now)
+	if failed.Grade != "F" || failed.Score != 0 {
+		t.Errorf("failed handshake graded %s/%d", failed.Grade, failed.Score)
+	}
+}
+
+func TestScanTLSTarget(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	target := TLSScanTarget{AgentID: "agent-1", ServerName: "example.com", Address: srv.Listener.Addr().String()}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res := scanTLSTarget(ctx, target)
+
+	if res.Error != "" {
+		t.Fatalf("scan failed: %s", res.Error)
+	}
+	if !stringInSlice(res.Protocols, "TLSv1.3") {
+		t.Errorf("protocols = %v, want TLSv1.3 accepted", res.Protocols)
+	}
+	if res.HSTS != "max-age=31536000" || res.XFrameOptions != "DENY" {
+		t.Errorf("headers not captured: hsts=%q xfo=%q", res.HSTS, res.XFrameOptions)
+	}
+	// The httptest certificate is signed by an untrusted CA
+	if len(res.ChainIssues) == 0 || res.Grade != "F" {
+		t.Errorf("untrusted chain not reported: issues=%v grade=%s", res.ChainIssues, res.Grade)
+	}
+
+	closed := scanTLSTarget(ctx, TLSScanTarget{ServerName: "example.com", Address: "127.0.0.1:1"})
+	if closed.Error == "" || closed.Grade != "F" {
+		t.Errorf("closed port graded %s with error %q", closed.Grade, closed.Error)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxTrafficMirrorLocations = 20
+
+var trafficMirrorHostRe = regexp.MustCompile(`^[A-Za-z0-9.-]+(:[0-9]{1,5})?$|^\[[0-9A-Fa-f:.]+\](:[0-9]{1,5})?$`)
+
+// setPaths derives the files of a mirror from its slug: the sampling lands in conf.d/, included
+// from http, and the mirror itself in avika/mirror/, included from the server blocks it shadows
+func (m *TrafficMirror) setPaths() {
+	m.HTTPPath = "conf.d/avika-mirror-" + m.Slug + ".conf"
+	m.IncludePath = "avika/mirror/" + m.Slug + ".conf"
+}
+
+// trafficMirrorVar names a variable of a mirror
+func trafficMirrorVar(slug, name string) string {
+	return "$avika_mirror_" + strings.ReplaceAll(slug, "-", "_") + "_" + name
+}
+
+// trafficMirrorRequest is the body of POST and PUT /api/traffic-mirrors. The slug is only read on
+// create, as configs include the mirror's file; unset fields keep their value on update.
+type trafficMirrorRequest struct {
+	Name          string        `json:"name"`
+	Slug          string        `json:"slug"`
+	Target        string        `json:"target"`
+	SamplePercent *float64      `json:"sample_percent"`
+	Locations     []string      `json:"locations"`
+	MirrorBody    *bool         `json:"mirror_body"`
+	Selector      *BulkSelector `json:"selector"`
+}
+
+// newTrafficMirror validates a create request; the slug defaults to one derived from the name and
+// request bodies are mirrored unless turned off
+func (req trafficMirrorRequest) newTrafficMirror() (*TrafficMirror, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.Selector == nil {
+		return nil, status.Error(codes.InvalidArgument, "selector is required")
+	}
+	if req.SamplePercent == nil {
+		return nil, status.Error(codes.InvalidArgument, "sample_percent is required")
+	}
+	m := &TrafficMirror{Name: req.Name, Slug: req.Slug, MirrorBody: true}
+	if m.Slug == "" {
+		m.Slug = strings.Trim(templateSlugRe.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	}
+	if !snippetSlugRe.MatchString(m.Slug) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid slug %q: use lowercase letters, digits and dashes", m.Slug)
+	}
+	m.setPaths()
+	if err := req.update(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// update applies the set fields of a request to m and validates the result
+func (req trafficMirrorRequest) update(m *TrafficMirror) error {
+	if req.Name != "" {
+		m.Name = req.Name
+	}
+	if req.Target != "" {
+		m.Target = req.Target
+	}
+	if req.SamplePercent != nil {
+		m.SamplePercent = *req.SamplePercent
+	}
+	if req.Locations != nil {
+		m.Locations = req.Locations
+	}
+	if req.MirrorBody != nil {
+		m.MirrorBody = *req.MirrorBody
+	}
+	if req.Selector != nil {
+		m.Selector = *req.Selector
+	}
+	if _, err := parseTrafficMirrorTarget(m.Target); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	hundredths := math.Round(m.SamplePercent * 100)
+	if m.SamplePercent <= 0 || m.SamplePercent > 100 || math.Abs(m.SamplePercent*100-hundredths) > 1e-6 {
+		return status.Error(codes.InvalidArgument, "sample_percent must be above 0 and at most 100, with at most two decimals")
+	}
+	if len(m.Locations) > maxTrafficMirrorLocations {
+		return status.Errorf(codes.InvalidArgument, "at most %d locations", maxTrafficMirrorLocations)
+	}
+	for _, loc := range m.Locations {
+		if !maintenanceLocationRe.MatchString(loc) {
+			return status.Errorf(codes.InvalidArgument, "invalid location %q: use a path prefix such as /api/", loc)
+		}
+	}
+	if m.Locations == nil {
+		m.Locations = []string{}
+	}
+	if len(m.Selector.AgentIDs) == 0 && m.Selector.GroupID == "" && m.Selector.EnvironmentID == "" && len(m.Selector.Tags) == 0 {
+		return status.Error(codes.InvalidArgument, "selector must name agents, a group, an environment or tags")
+	}
+	return nil
+}
+
+// parseTrafficMirrorTarget checks a target is an http(s) URL of a host, an optional port and an
+// optional path prefix, all of which go into the agents' config
+func parseTrafficMirrorTarget(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("target must be an http or https URL, e.g. http://staging.internal:8080")
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" || !trafficMirrorHostRe.MatchString(u.Host) {
+		return nil, fmt.Errorf("target must be scheme://host[:port][/path] without credentials or query")
+	}
+	if u.Path != "" && !maintenanceLocationRe.MatchString(u.Path) {
+		return nil, fmt.Errorf("invalid target path %q", u.Path)
+	}
+	return u, nil
+}
+
+// trafficMirrorThreshold is the split_clients bound for a sampling rate: a request is mirrored when
+// the MurmurHash2 of its $request_id is below it, as nginx computes it
+func trafficMirrorThreshold(percent float64) uint32 {
+	hundredths := uint64(math.Round(percent * 100))
+	return uint32(hundredths * math.MaxUint32 / 10000)
+}
+
+// renderTrafficMirror compiles a mirror into its files, in the order they must be written: the
+// sampling and upstream, then the mirror that uses them
+func renderTrafficMirror(m *TrafficMirror) []managedFile {
+	u, _ := parseTrafficMirrorTarget(m.Target)
+	upstream := "avika_mirror_" + strings.ReplaceAll(m.Slug, "-", "_")
+	sample, path, on := trafficMirrorVar(m.Slug, "sample"), trafficMirrorVar(m.Slug, "path"), trafficMirrorVar(m.Slug, "on")
+
+	var h strings.Builder
+	fmt.Fprintf(&h, "# Managed by Avika: traffic mirror %q to %s. Changes are overwritten.\n", m.Name, m.Target)
+	fmt.Fprintf(&h, "split_clients \"${request_id}\" %s {\n    %s%% 1;\n    * 0;\n}\n",
+		sample, strconv.FormatFloat(m.SamplePercent, 'f', -1, 64))
+	if len(m.Locations) == 0 {
+		fmt.Fprintf(&h, "map $request_uri %s {\n    default 1;\n}\n", path)
+	} else {
+		fmt.Fprintf(&h, "map $request_uri %s {\n    default 0;\n", path)
+		for _, loc := range m.Locations {
+			fmt.Fprintf(&h, "    \"~^%s\" 1;\n", regexp.QuoteMeta(loc))
+		}
+		h.WriteString("}\n")
+	}
+	fmt.Fprintf(&h, "upstream %s {\n    server %s;\n    keepalive 8;\n}\n", upstream, trafficMirrorServer(u))
+
+	body := "on"
+	if !m.MirrorBody {
+		body = "off"
+	}
+	uri := "/_avika_mirror/" + m.Slug
+	var sv strings.Builder
+	fmt.Fprintf(&sv, "# Managed by Avika: traffic mirror %q to %s. Changes are overwritten.\n", m.Name, m.Target)
+	fmt.Fprintf(&sv, "mirror %s;\nmirror_request_body %s;\n", uri, body)
+	fmt.Fprintf(&sv, "location = %s {\n    internal;\n", uri)
+	// The subrequest answers itself unless the request is sampled and on a mirrored location
+	fmt.Fprintf(&sv, "    set %s \"${%s}${%s}\";\n", on, strings.TrimPrefix(sample, "$"), strings.TrimPrefix(path, "$"))
+	fmt.Fprintf(&sv, "    if (%s != 11) {\n        return 204;\n    }\n", on)
+	if !m.MirrorBody {
+		sv.WriteString("    proxy_pass_request_body off;\n    proxy_set_header Content-Length \"\";\n")
+	}
+	sv.WriteString("    proxy_http_version 1.1;\n    proxy_set_header Connection \"\";\n")
+	sv.WriteString("    proxy_set_header Host $host;\n")
+	fmt.Fprintf(&sv, "    proxy_set_header X-Avika-Mirror %s;\n", m.Slug)
+	sv.WriteString("    proxy_connect_timeout 1s;\n    proxy_send_timeout 5s;\n    proxy_read_timeout 5s;\n")
+	if u.Scheme == "https" {
+		fmt.Fprintf(&sv, "    proxy_ssl_server_name on;\n    proxy_ssl_name %s;\n", u.Hostname())
+	}
+	fmt.Fprintf(&sv, "    proxy_pass %s://%s%s$request_uri;\n}\n", u.Scheme, upstream, strings.TrimSuffix(u.Path, "/"))
+
+	return []managedFile{
+		{Path: m.HTTPPath, Content: h.String()},
+		{Path: m.IncludePath, Content: sv.String()},
+	}
+}
+
+// trafficMirrorServer is the upstream server of a target, with the scheme's port when it has none
+func trafficMirrorServer(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return u.Host + ":443"
+	}
+	return u.Host + ":80"
+}
+
+// trafficMirrorOffFiles stop a mirror on an agent: the mirror first, then the sampling. The files
+// stay as comments so server blocks including them remain valid.
+func trafficMirrorOffFiles(m *TrafficMirror) []managedFile {
+	off := fmt.Sprintf("# Managed by Avika: traffic mirror %q, disabled. Changes are overwritten.\n", m.Name)
+	return []managedFile{
+		{Path: m.IncludePath, Content: off},
+		{Path: m.HTTPPath, Content: off},
+	}
+}
+
+// applyTrafficMirror writes a mirror, or its disabled files, to the agents batchPushConcurrency at
+// a time and records the outcome on each
+func (s *server) applyTrafficMirror(ctx context.Context, m *TrafficMirror, agentIDs []string, enable bool) []TrafficMirrorAgent {
+	files := trafficMirrorOffFiles(m)
+	if enable {
+		files = renderTrafficMirror(m)
+	}
+	results := make([]TrafficMirrorAgent, len(agentIDs))
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range agentIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *TrafficMirrorAgent, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.AgentID, r.Status, r.Enabled = id, "applied", enable
+			if err := s.writeManagedFiles(ctx, id, files); err != nil {
+				r.Status, r.Error, r.Enabled = "failed", err.Error(), false
+			}
+			r.AppliedAt = time.Now()
+			if err := s.db.SaveTrafficMirrorAgent(m.ID, r); err != nil {
+				gatewayLog.Error().Msgf("Failed to record traffic mirror %s on %s: %v", m.Slug, id, err)
+			}
+		}(&results[i], id)
+	}
+	wg.Wait()
+	return results
+}
+
+// TrafficMirrorStats is the traffic a mirror shadowed over a time range, from the access logs of its
+// agents
+type TrafficMirrorStats struct {
+	From     int64                    `json:"from"`
+	To       int64                    `json:"to"`
+	Step     int64                    `json:"step"`
+	Matched  uint64                   `json:"matched_requests"`  // requests on the mirrored locations
+	Mirrored uint64                   `json:"mirrored_requests"` // of which were sent to the target
+	Series   []TrafficMirrorStatPoint `json:"series"`
+}
+
+// TrafficMirrorStatPoint is one bucket of mirror traffic
+type TrafficMirrorStatPoint struct {
+	Time     int64  `json:"time"`
+	Matched  uint64 `json:"matched"`
+	Mirrored uint64 `json:"mirrored"`
+}
+
+// trafficMirrorBucket is a row of mirror traffic: requests on the mirrored locations, those sampled
+// by their request ID, and those logged without one
+type trafficMirrorBucket struct {
+	Time      int64
+	Matched   uint64
+	Sampled   uint64
+	NoRequest uint64
+}
+
+// GetTrafficMirrorTraffic buckets the requests of a mirror's agents on its locations. Requests
+// logged with their request ID are sampled with the hash nginx uses.
+func (db *ClickHouseDB) GetTrafficMirrorTraffic(ctx context.Context, from, to time.Time, step time.Duration, agents []string, m *TrafficMirror) ([]trafficMirrorBucket, error) {
+	locationCond := "1"
+	args := []interface{}{trafficMirrorThreshold(m.SamplePercent), from, to, agents}
+	if len(m.Locations) > 0 {
+		locationCond = "arrayExists(p -> startsWith(request_uri, p), ?)"
+		args = append(args, m.Locations)
+	}
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			toUnixTimestamp(toStartOfInterval(timestamp, INTERVAL %d SECOND)) AS t,
+			count(),
+			countIf(request_id != '' AND murmurHash2_32(request_id) < ?),
+			countIf(request_id = '')
+		FROM nginx_analytics.access_logs
+		WHERE timestamp >= ? AND timestamp <= ? AND instance_id IN (?) AND %s
+		GROUP BY t
+		ORDER BY t
+	`, int64(step.Seconds()), locationCond), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []trafficMirrorBucket
+	for rows.Next() {
+		var b trafficMirrorBucket
+		var t uint32
+		if err := rows.Scan(&t, &b.Matched, &b.Sampled, &b.NoRequest); err != nil {
+			return nil, err
+		}
+		b.Time = int64(t)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// newTrafficMirrorStats totals mirror traffic. Requests logged without a request ID are counted as
+// mirrored at the sampling rate.
+func newTrafficMirrorStats(m *TrafficMirror, from, to time.Time, step time.Duration, buckets []trafficMirrorBucket) *TrafficMirrorStats {
+	stats := &TrafficMirrorStats{From: from.Unix(), To: to.Unix(), Step: int64(step.Seconds()), Series: []TrafficMirrorStatPoint{}}
+	for _, b := range buckets {
+		mirrored := b.Sampled + uint64(math.Round(float64(b.NoRequest)*m.SamplePercent/100))
+		stats.Matched += b.Matched
+		stats.Mirrored += mirrored
+		stats.Series = append(stats.Series, TrafficMirrorStatPoint{Time: b.Time, Matched: b.Matched, Mirrored: mirrored})
+	}
+	return stats
+}
+
+// trafficMirrorRange narrows a stats range to the time the mirror ran
+func trafficMirrorRange(m *TrafficMirror, from, to time.Time) (time.Time, time.Time) {
+	if m.EnabledAt != nil && m.EnabledAt.After(from) {
+		from = *m.EnabledAt
+	}
+	if !m.Enabled && m.DisabledAt != nil && m.DisabledAt.Before(to) {
+		to = *m.DisabledAt
+	}
+	return from, to
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTrafficMirror(t *testing.T) {
+	pct := 12.5
+	req := trafficMirrorRequest{
+		Name:          "Checkout Shadow",
+		Target:        "http://staging.internal:8080",
+		SamplePercent: &pct,
+		Locations:     []string{"/checkout/"},
+		Selector:      &BulkSelector{Tags: []string{"edge"}},
+	}
+	m, err := req.newTrafficMirror()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Slug != "checkout-shadow" || !m.MirrorBody || m.HTTPPath != "conf.d/avika-mirror-checkout-shadow.conf" ||
+		m.IncludePath != "avika/mirror/checkout-shadow.conf" {
+		t.Errorf("mirror = %+v", m)
+	}
+
+	zero, tiny, over := 0.0, 0.001, 101.0
+	for name, bad := range map[string]trafficMirrorRequest{
+		"no sampling":   {Name: "x", Target: req.Target, Selector: req.Selector},
+		"zero sampling": {Name: "x", Target: req.Target, SamplePercent: &zero, Selector: req.Selector},
+		"fine sampling": {Name: "x", Target: req.Target, SamplePercent: &tiny, Selector: req.Selector},
+		"over 100":      {Name: "x", Target: req.Target, SamplePercent: &over, Selector: req.Selector},
+		"ftp target":    {Name: "x", Target: "ftp://staging", SamplePercent: &pct, Selector: req.Selector},
+		"query target":  {Name: "x", Target: "http://staging/?a=1", SamplePercent: &pct, Selector: req.Selector},
+		"user target":   {Name: "x", Target: "http://u:p@staging", SamplePercent: &pct, Selector: req.Selector},
+		"bad location":  {Name: "x", Target: req.Target, SamplePercent: &pct, Locations: []string{"api"}, Selector: req.Selector},
+		"no selector":   {Name: "x", Target: req.Target, SamplePercent: &pct, Selector: &BulkSelector{}},
+	} {
+		if _, err := bad.newTrafficMirror(); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+}
+
+func TestRenderTrafficMirror(t *testing.T) {
+	m := &TrafficMirror{Name: "api", Slug: "api-shadow", Target: "https://staging.example.com/shadow/", SamplePercent: 5,
+		Locations: []string{"/api/v1.0/"}, MirrorBody: false}
+	m.setPaths()
+
+	files := renderTrafficMirror(m)
+	if len(files) != 2 || files[0].Path != m.HTTPPath || files[1].Path != m.IncludePath {
+		t.Fatalf("files = %+v", files)
+	}
+	for _, want := range []string{
+		"split_clients \"${request_id}\" $avika_mirror_api_shadow_sample {\n    5% 1;\n    * 0;\n}\n",
+		"    default 0;\n    \"~^/api/v1\\.0/\" 1;\n",
+		"upstream avika_mirror_api_shadow {\n    server staging.example.com:443;\n",
+	} {
+		if !strings.Contains(files[0].Content, want) {
+			t.Errorf("http file lacks %q:\n%s", want, files[0].Content)
+		}
+	}
+	for _, want := range []string{
+		"mirror /_avika_mirror/api-shadow;\nmirror_request_body off;\n",
+		"    if ($avika_mirror_api_shadow_on != 11) {\n",
+		"    proxy_pass_request_body off;\n",
+		"    proxy_ssl_name staging.example.com;\n",
+		"    proxy_pass https://avika_mirror_api_shadow/shadow$request_uri;\n",
+	} {
+		if !strings.Contains(files[1].Content, want) {
+			t.Errorf("server include lacks %q:\n%s", want, files[1].Content)
+		}
+	}
+
+	if off := trafficMirrorOffFiles(m); off[0].Path != m.IncludePath || strings.Contains(off[0].Content, "mirror /") {
+		t.Errorf("off files = %+v, want the mirror stopped first", off)
+	}
+}
+
+func TestTrafficMirrorThreshold(t *testing.T) {
+	if got := trafficMirrorThreshold(100); got != math.MaxUint32 {
+		t.Errorf("100%% = %d", got)
+	}
+	// split_clients "${request_id}" with 0.01% stops at hundredths * 0xffffffff / 10000
+	if got := trafficMirrorThreshold(0.01); got != 429496 {
+		t.Errorf("0.01%% = %d", got)
+	}
+}
+
+func TestTrafficMirrorStats(t *testing.T) {
+	m := &TrafficMirror{SamplePercent: 10}
+	from := time.Unix(0, 0)
+	stats := newTrafficMirrorStats(m, from, from.Add(time.Hour), time.Minute, []trafficMirrorBucket{
+		{Time: 0, Matched: 1000, Sampled: 95, NoRequest: 0},
+		{Time: 60, Matched: 500, Sampled: 30, NoRequest: 200},
+	})
+	if stats.Matched != 1500 || stats.Mirrored != 145 || stats.Step != 60 || len(stats.Series) != 2 || stats.Series[1].Mirrored != 50 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	enabled, disabled := from.Add(10*time.Minute), from.Add(20*time.Minute)
+	m.EnabledAt, m.DisabledAt = &enabled, &disabled
+	if f, to := trafficMirrorRange(m, from, from.Add(time.Hour)); !f.Equal(enabled) || !to.Equal(disabled) {
+		t.Errorf("range = %v - %v", f, to)
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultTrafficSplitKey   = "${remote_addr}${http_user_agent}"
+	maxTrafficSplitVariants  = 10
+	trafficSplitAgentTimeout = 60 * time.Second
+)
+
+var (
+	trafficSplitVariantRe  = regexp.MustCompile(`^[A-Za-z0-9_-]{1,50}$`)
+	trafficSplitUpstreamRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+)
+
+// trafficSplitVariable is the variable holding the upstream chosen for a request
+func trafficSplitVariable(slug string) string {
+	return "$avika_split_" + strings.ReplaceAll(slug, "-", "_")
+}
+
+// trafficSplitIncludePath is where a split lands, relative to the NGINX conf dir; conf.d/ is
+// included from http, where split_clients belongs
+func trafficSplitIncludePath(slug string) string {
+	return "conf.d/avika-split-" + slug + ".conf"
+}
+
+// trafficSplitRequest is the body of POST and PUT /api/traffic-splits. The slug is only read on
+// create, as configs route on the split's variable; unset fields keep their value on update.
+type trafficSplitRequest struct {
+	Name     string                `json:"name"`
+	Slug     string                `json:"slug"`
+	Key      string                `json:"key"`
+	Variants []TrafficSplitVariant `json:"variants"`
+	Selector *BulkSelector         `json:"selector"`
+}
+
+// newTrafficSplit validates a create request; the slug defaults to one derived from the name and
+// the key to the client address and user agent
+func (req trafficSplitRequest) newTrafficSplit() (*TrafficSplit, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.Selector == nil {
+		return nil, status.Error(codes.InvalidArgument, "selector is required")
+	}
+	sp := &TrafficSplit{Name: req.Name, Slug: req.Slug}
+	if sp.Slug == "" {
+		sp.Slug = strings.Trim(templateSlugRe.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	}
+	if !snippetSlugRe.MatchString(sp.Slug) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid slug %q: use lowercase letters, digits and dashes", sp.Slug)
+	}
+	sp.Variable, sp.IncludePath = trafficSplitVariable(sp.Slug), trafficSplitIncludePath(sp.Slug)
+	if err := req.update(sp); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// update applies the set fields of a request to sp and validates the result
+func (req trafficSplitRequest) update(sp *TrafficSplit) error {
+	if req.Name != "" {
+		sp.Name = req.Name
+	}
+	if req.Key != "" {
+		sp.Key = req.Key
+	}
+	if sp.Key == "" {
+		sp.Key = defaultTrafficSplitKey
+	}
+	if req.Variants != nil {
+		sp.Variants = req.Variants
+	}
+	if req.Selector != nil {
+		sp.Selector = *req.Selector
+	}
+	if !strings.Contains(sp.Key, "$") || strings.ContainsAny(sp.Key, "\"\\;\r\n") {
+		return status.Errorf(codes.InvalidArgument, "invalid key %q: use NGINX variables, e.g. %s", sp.Key, defaultTrafficSplitKey)
+	}
+	if len(sp.Selector.AgentIDs) == 0 && sp.Selector.GroupID == "" && sp.Selector.EnvironmentID == "" && len(sp.Selector.Tags) == 0 {
+		return status.Error(codes.InvalidArgument, "selector must name agents, a group, an environment or tags")
+	}
+	if err := validateTrafficSplitVariants(sp.Variants); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// validateTrafficSplitVariants checks there are 2-10 distinct variants whose weights, in percent
+// with up to two decimals, add up to 100
+func validateTrafficSplitVariants(variants []TrafficSplitVariant) error {
+	if len(variants) < 2 || len(variants) > maxTrafficSplitVariants {
+		return fmt.Errorf("a split needs 2 to %d variants", maxTrafficSplitVariants)
+	}
+	seen := make(map[string]bool)
+	var total int64
+	for _, v := range variants {
+		if !trafficSplitVariantRe.MatchString(v.Name) {
+			return fmt.Errorf("invalid variant name %q", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant %q", v.Name)
+		}
+		seen[v.Name] = true
+		if !trafficSplitUpstreamRe.MatchString(v.Upstream) {
+			return fmt.Errorf("variant %s: invalid upstream %q", v.Name, v.Upstream)
+		}
+		hundredths := math.Round(v.Weight * 100)
+		if v.Weight < 0 || v.Weight > 100 || math.Abs(v.Weight*100-hundredths) > 1e-6 {
+			return fmt.Errorf("variant %s: weight must be between 0 and 100 with at most two decimals", v.Name)
+		}
+		total += int64(hundredths)
+	}
+	if total != 10000 {
+		return fmt.Errorf("weights add up to %s%%, not 100%%", strconv.FormatFloat(float64(total)/100, 'f', -1, 64))
+	}
+	return nil
+}
+
+// renderTrafficSplit is the split_clients block of a split. Variants weighted 0 get no clients and
+// are left out, so their upstream may be gone from the agent.
+func renderTrafficSplit(sp *TrafficSplit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Managed by Avika: traffic split %q. Changes made here are overwritten.\n", sp.Name)
+	fmt.Fprintf(&b, "split_clients \"%s\" %s {\n", sp.Key, sp.Variable)
+	for _, v := range sp.Variants {
+		if v.Weight > 0 {
+			fmt.Fprintf(&b, "    %s%% %s;\n", strconv.FormatFloat(v.Weight, 'f', -1, 64), v.Upstream)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// missingUpstreams returns the upstreams of the weighted variants not defined in pools
+func missingUpstreams(variants []TrafficSplitVariant, pools []*pb.UpstreamPool) []string {
+	defined := make(map[string]bool, len(pools))
+	for _, p := range pools {
+		defined[p.Name] = true
+	}
+	var missing []string
+	for _, v := range variants {
+		if v.Weight > 0 && !defined[v.Upstream] {
+			missing = append(missing, v.Upstream)
+		}
+	}
+	return missing
+}
+
+// applyTrafficSplit writes a split to the agents, batchPushConcurrency at a time, and records the
+// outcome on each
+func (s *server) applyTrafficSplit(ctx context.Context, sp *TrafficSplit, agentIDs []string) []TrafficSplitAgent {
+	content := renderTrafficSplit(sp)
+	results := make([]TrafficSplitAgent, len(agentIDs))
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range agentIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *TrafficSplitAgent, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.AgentID, r.Status = id, "applied"
+			if err := s.writeTrafficSplit(ctx, sp, id, content); err != nil {
+				r.Status, r.Error = "failed", err.Error()
+			} else {
+				r.Variants = sp.Variants
+			}
+			r.AppliedAt = time.Now()
+			if err := s.db.SaveTrafficSplitAgent(sp.ID, r); err != nil {
+				gatewayLog.Error().Msgf("Failed to record traffic split %s on %s: %v", sp.Slug, id, err)
+			}
+		}(&results[i], id)
+	}
+	wg.Wait()
+	return results
+}
+
+// writeTrafficSplit checks the agent defines the upstreams of the split, then writes its file; the
+// agent tests the config and reloads, restoring the previous file when the test fails
+func (s *server) writeTrafficSplit(ctx context.Context, sp *TrafficSplit, agentID, content string) error {
+	client, conn, err := s.getAgentClient(agentID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, trafficSplitAgentTimeout)
+	defer cancel()
+
+	pools, err := client.ListUpstreams(ctx, &pb.UpstreamListRequest{InstanceId: agentID})
+	if err != nil {
+		return err
+	}
+	if pools.Error != "" {
+		return errors.New(pools.Error)
+	}
+	if missing := missingUpstreams(sp.Variants, pools.Upstreams); len(missing) > 0 {
+		return fmt.Errorf("upstream %s not defined on the agent", strings.Join(missing, ", "))
+	}
+	res, err := client.UpdateConfig(ctx, &pb.ConfigUpdate{InstanceId: agentID, ConfigPath: sp.IncludePath, NewContent: content})
+	if err != nil {
+		return err
+	}
+	if !res.Success {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// TrafficSplitStats is the traffic each variant of a split served over a time range, from the
+// access logs of the split's agents
+type TrafficSplitStats struct {
+	From     int64                      `json:"from"`
+	To       int64                      `json:"to"`
+	Variants []TrafficSplitVariantStats `json:"variants"`
+	// Unattributed counts proxied requests answered by a server no variant's upstream lists
+	Unattributed uint64 `json:"unattributed_requests"`
+}
+
+// TrafficSplitVariantStats is the traffic of one variant
+type TrafficSplitVariantStats struct {
+	Name         string   `json:"name"`
+	Upstream     string   `json:"upstream"`
+	Weight       float64  `json:"weight"`
+	Servers      []string `json:"servers"` // upstream addresses attributed to the variant
+	Requests     uint64   `json:"requests"`
+	Share        float64  `json:"share"` // percent of the attributed requests
+	RPS          float64  `json:"rps"`
+	Errors       uint64   `json:"errors"`
+	ErrorRate    float64  `json:"error_rate"` // percent of requests that failed (5xx)
+	AvgLatencyMs float64  `json:"avg_latency_ms"`
+	P95LatencyMs float64  `json:"p95_latency_ms"`
+}
+
+// variantTraffic is a row of GetVariantTraffic
+type variantTraffic struct {
+	Variant  string
+	Requests uint64
+	Errors   uint64
+	AvgMs    float64
+	P95Ms    float64
+}
+
+// GetVariantTraffic aggregates the proxied requests of agents over [from, to] by variant, mapping
+// the upstream address that answered each request through addrs to variants; requests answered by
+// other addresses are grouped under an empty variant
+func (db *ClickHouseDB) GetVariantTraffic(ctx context.Context, from, to time.Time, agents []string, addrs map[string]string) ([]variantTraffic, error) {
+	upstream := fmt.Sprintf(serviceMapUpstreamExpr, "upstream_addr")
+	upstreamStatus := fmt.Sprintf(serviceMapUpstreamExpr, "upstream_status")
+	variant := "''"
+	var args []interface{}
+	if len(addrs) > 0 {
+		keys := make([]string, 0, len(addrs))
+		values := make([]string, 0, len(addrs))
+		for addr, name := range addrs {
+			keys, values = append(keys, addr), append(values, name)
+		}
+		variant = fmt.Sprintf("transform(%s, ?, ?, '')", upstream)
+		args = append(args, keys, values)
+	}
+	rows, err := db.conn.Query(ctx, fmt.Sprintf(`
+		SELECT
+			%s AS variant,
+			count() AS requests,
+			countIf(toUInt16OrZero(%s) >= 500 OR (%s = '' AND status >= 500)) AS errors,
+			avg(upstream_response_time) * 1000 AS avg_ms,
+			quantile(0.95)(upstream_response_time) * 1000 AS p95_ms
+		FROM nginx_analytics.access_logs
+		WHERE timestamp >= ? AND timestamp <= ? AND upstream_addr != '' AND instance_id IN (?)
+		GROUP BY variant
+	`, variant, upstreamStatus, upstreamStatus), append(args, from, to, agents)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []variantTraffic
+	for rows.Next() {
+		var v variantTraffic
+		if err := rows.Scan(&v.Variant, &v.Requests, &v.Errors, &v.AvgMs, &v.P95Ms); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// trafficSplitAddresses maps the server addresses of each variant's upstream, as reported by the
+// agents, to the variant. Host names are resolved so they match the addresses NGINX logs; an
+// address listed by two variants is attributed to neither.
+func trafficSplitAddresses(ctx context.Context, variants []TrafficSplitVariant, pools []*pb.UpstreamPool, lookup func(ctx context.Context, host string) ([]string, error)) map[string]string {
+	byUpstream := make(map[string]string, len(variants))
+	for _, v := range variants {
+		byUpstream[v.Upstream] = v.Name
+	}
+	addrs := make(map[string]string)
+	shared := make(map[string]bool)
+	add := func(addr, name string) {
+		if prev, ok := addrs[addr]; ok && prev != name {
+			shared[addr] = true
+		}
+		addrs[addr] = name
+	}
+	for _, p := range pools {
+		name, ok := byUpstream[p.Name]
+		if !ok {
+			continue
+		}
+		for _, srv := range p.Servers {
+			add(srv.Address, name)
+			host, port, err := net.SplitHostPort(srv.Address)
+			if err != nil || net.ParseIP(host) != nil || lookup == nil {
+				continue
+			}
+			ips, err := lookup(ctx, host)
+			if err != nil {
+				continue
+			}
+			for _, ip := range ips {
+				add(net.JoinHostPort(ip, port), name)
+			}
+		}
+	}
+	for addr := range shared {
+		delete(addrs, addr)
+	}
+	return addrs
+}
+
+// newTrafficSplitStats combines the per-variant rows with the split's variants
+func newTrafficSplitStats(sp *TrafficSplit, from, to time.Time, addrs map[string]string, rows []variantTraffic) *TrafficSplitStats {
+	stats := &TrafficSplitStats{From: from.Unix(), To: to.Unix(), Variants: []TrafficSplitVariantStats{}}
+	byName := make(map[string]variantTraffic, len(rows))
+	var attributed uint64
+	for _, r := range rows {
+		if r.Variant == "" {
+			stats.Unattributed += r.Requests
+			continue
+		}
+		byName[r.Variant] = r
+		attributed += r.Requests
+	}
+	seconds := to.Sub(from).Seconds()
+	for _, v := range sp.Variants {
+		vs := TrafficSplitVariantStats{Name: v.Name, Upstream: v.Upstream, Weight: v.Weight, Servers: []string{}}
+		for addr, name := range addrs {
+			if name == v.Name {
+				vs.Servers = append(vs.Servers, addr)
+			}
+		}
+		sort.Strings(vs.Servers)
+		if r, ok := byName[v.Name]; ok {
+			vs.Requests, vs.Errors, vs.AvgLatencyMs, vs.P95LatencyMs = r.Requests, r.Errors, r.AvgMs, r.P95Ms
+			if r.Requests > 0 {
+				vs.ErrorRate = float64(r.Errors) / float64(r.Requests) * 100
+			}
+			if attributed > 0 {
+				vs.Share = float64(r.Requests) / float64(attributed) * 100
+			}
+			if seconds > 0 {
+				vs.RPS = float64(r.Requests) / seconds
+			}
+		}
+		stats.Variants = append(stats.Variants, vs)
+	}
+	return stats
+}
+
+// trafficSplitPools collects the upstream pools of the agents that answer, so variants map to the
+// servers of their upstreams on every agent
+func (s *server) trafficSplitPools(ctx context.Context, agentIDs []string) []*pb.UpstreamPool {
+	var mu sync.Mutex
+	var pools []*pb.UpstreamPool
+	sem := make(chan struct{}, batchPushConcurrency)
+	var wg sync.WaitGroup
+	for _, id := range agentIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			client, conn, err := s.getAgentClient(id)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			callCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			res, err := client.ListUpstreams(callCtx, &pb.UpstreamListRequest{InstanceId: id})
+			if err != nil || res.Error != "" {
+				return
+			}
+			mu.Lock()
+			pools = append(pools, res.Upstreams...)
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return pools
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestValidateTrafficSplitVariants(t *testing.T) {
+	ok := []TrafficSplitVariant{{Name: "blue", Upstream: "checkout_blue", Weight: 99.5}, {Name: "green", Upstream: "checkout_green", Weight: 0.5}}
+	if err := validateTrafficSplitVariants(ok); err != nil {
+		t.Errorf("valid variants: %v", err)
+	}
+
+	bad := map[string][]TrafficSplitVariant{
+		"one variant":    {{Name: "blue", Upstream: "b", Weight: 100}},
+		"sum below 100":  {{Name: "blue", Upstream: "b", Weight: 50}, {Name: "green", Upstream: "g", Weight: 40}},
+		"three decimals": {{Name: "blue", Upstream: "b", Weight: 99.995}, {Name: "green", Upstream: "g", Weight: 0.005}},
+		"duplicate":      {{Name: "blue", Upstream: "b", Weight: 50}, {Name: "blue", Upstream: "g", Weight: 50}},
+		"variable":       {{Name: "blue", Upstream: "$host", Weight: 50}, {Name: "green", Upstream: "g", Weight: 50}},
+		"negative":       {{Name: "blue", Upstream: "b", Weight: 110}, {Name: "green", Upstream: "g", Weight: -10}},
+	}
+	for name, variants := range bad {
+		if err := validateTrafficSplitVariants(variants); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewTrafficSplit(t *testing.T) {
+	req := trafficSplitRequest{
+		Name:     "Checkout Canary",
+		Variants: []TrafficSplitVariant{{Name: "stable", Upstream: "checkout_v1", Weight: 90}, {Name: "canary", Upstream: "checkout_v2", Weight: 10}},
+		Selector: &BulkSelector{EnvironmentID: "prod"},
+	}
+	sp, err := req.newTrafficSplit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.Slug != "checkout-canary" || sp.Variable != "$avika_split_checkout_canary" ||
+		sp.IncludePath != "conf.d/avika-split-checkout-canary.conf" || sp.Key != defaultTrafficSplitKey {
+		t.Errorf("split = %+v", sp)
+	}
+
+	for _, bad := range []trafficSplitRequest{
+		{Name: "No selector", Variants: req.Variants},
+		{Name: "Empty selector", Variants: req.Variants, Selector: &BulkSelector{}},
+		{Name: "Bad key", Key: `$host"; return 200; #`, Variants: req.Variants, Selector: req.Selector},
+	} {
+		if _, err := bad.newTrafficSplit(); err == nil {
+			t.Errorf("%s should be rejected", bad.Name)
+		}
+	}
+}
+
+func TestRenderTrafficSplit(t *testing.T) {
+	sp := &TrafficSplit{
+		Name:     "checkout",
+		Key:      "${cookie_uid}",
+		Variable: trafficSplitVariable("checkout"),
+		Variants: []TrafficSplitVariant{
+			{Name: "blue", Upstream: "checkout_blue", Weight: 0},
+			{Name: "green", Upstream: "checkout_green", Weight: 87.5},
+			{Name: "exp", Upstream: "checkout_exp", Weight: 12.5},
+		},
+	}
+	got := renderTrafficSplit(sp)
+	want := `split_clients "${cookie_uid}" $avika_split_checkout {
+    87.5% checkout_green;
+    12.5% checkout_exp;
+}
+`
+	if !strings.HasSuffix(got, want) || !strings.HasPrefix(got, "# Managed by Avika") {
+		t.Errorf("rendered:\n%s", got)
+	}
+	if missing := missingUpstreams(sp.Variants, []*pb.UpstreamPool{{Name: "checkout_green"}}); !reflect.DeepEqual(missing, []string{"checkout_exp"}) {
+		t.Errorf("missing = %v, want only the weighted upstream not defined", missing)
+	}
+}
+
+func TestTrafficSplitStats(t *testing.T) {
+	sp := &TrafficSplit{Variants: []TrafficSplitVariant{
+		{Name: "blue", Upstream: "app_blue", Weight: 75},
+		{Name: "green", Upstream: "app_green", Weight: 25},
+	}}
+	pools := []*pb.UpstreamPool{
+		{Name: "app_blue", Servers: []*pb.UpstreamServer{{Address: "10.0.0.1:8080"}, {Address: "shared:80"}}},
+		{Name: "app_green", Servers: []*pb.UpstreamServer{{Address: "green.internal:8080"}, {Address: "shared:80"}}},
+		{Name: "other", Servers: []*pb.UpstreamServer{{Address: "10.9.9.9:80"}}},
+	}
+	lookup := func(_ context.Context, host string) ([]string, error) {
+		return map[string][]string{"green.internal": {"10.0.1.1"}, "shared": {"10.0.2.2"}}[host], nil
+	}
+	addrs := trafficSplitAddresses(context.Background(), sp.Variants, pools, lookup)
+	want := map[string]string{"10.0.0.1:8080": "blue", "green.internal:8080": "green", "10.0.1.1:8080": "green"}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("addresses = %v, want %v", addrs, want)
+	}
+
+	from := time.Unix(0, 0)
+	stats := newTrafficSplitStats(sp, from, from.Add(100*time.Second), addrs, []variantTraffic{
+		{Variant: "blue", Requests: 300, Errors: 3},
+		{Variant: "green", Requests: 100, Errors: 10},
+		{Variant: "", Requests: 50},
+	})
+	blue, green := stats.Variants[0], stats.Variants[1]
+	if blue.Share != 75 || blue.RPS != 3 || blue.ErrorRate != 1 || len(blue.Servers) != 1 {
+		t.Errorf("blue = %+v", blue)
+	}
+	if green.Share != 25 || green.ErrorRate != 10 || len(green.Servers) != 2 || stats.Unattributed != 50 {
+		t.Errorf("green = %+v, unattributed %d", green, stats.Unattributed)
+	}
+}
@@ -4,15 +4,19 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
-	"sync"
 
+	"github.com/avika-ai/avika/cmd/gateway/geo"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/ua-parser/uap-go/uaparser"
 )
 
+// uaCacheSize bounds the parsed user-agent cache; it sits on the log ingest path
+const uaCacheSize = 10000
+
 // UAParser handles user-agent string parsing with caching
 type UAParser struct {
 	parser *uaparser.Parser
-	cache  sync.Map // thread-safe cache for parsed results
+	cache  *lru.Cache[string, *ParsedUA] // thread-safe cache for parsed results
 }
 
 // ParsedUA contains the parsed user-agent information
@@ -23,16 +27,36 @@ type ParsedUA struct {
 	OSVersion      string
 	DeviceType     string // desktop, mobile, tablet, bot
 	IsBot          bool
+	BotCategory    string // empty for humans, otherwise one of the geo.BotCategory* values
 }
 
 // Common bot patterns for detection
 var botPatterns = regexp.MustCompile(`(?i)(bot|crawler|spider|scraper|curl|wget|python|java|go-http|libwww|apache|http|fetch|headless|phantom|selenium|puppeteer|playwright|ahrefsbot|googlebot|bingbot|yandexbot|baiduspider|facebookexternalhit|twitterbot|slackbot|linkedinbot|discordbot|telegrambot|whatsapp|applebot|duckduckbot|semrushbot|dotbot|petalbot|mj12bot|bytespider|claudebot|gptbot|chatgpt|anthropic)`)
 
+// botCategoryPatterns classifies automated clients, checked in order
+var botCategoryPatterns = []struct {
+	category string
+	pattern  *regexp.Regexp
+}{
+	{geo.BotCategoryAI, regexp.MustCompile(`(?i)(gptbot|chatgpt|oai-searchbot|claudebot|claude-web|anthropic|ccbot|perplexitybot|bytespider|google-extended|cohere-ai|diffbot|amazonbot)`)},
+	{geo.BotCategorySearchEngine, regexp.MustCompile(`(?i)(googlebot|bingbot|yandex(bot|images)|baiduspider|duckduckbot|applebot|slurp|sogou|seznambot|petalbot|naver|qwantify)`)},
+	{geo.BotCategorySEO, regexp.MustCompile(`(?i)(ahrefsbot|semrushbot|mj12bot|dotbot|rogerbot|blexbot|dataforseobot|screaming frog|serpstatbot)`)},
+	{geo.BotCategorySocial, regexp.MustCompile(`(?i)(facebookexternalhit|meta-externalagent|twitterbot|slackbot|linkedinbot|discordbot|telegrambot|whatsapp|pinterest|redditbot|skypeuripreview)`)},
+	{geo.BotCategoryMonitoring, regexp.MustCompile(`(?i)(uptimerobot|pingdom|statuscake|site24x7|datadog|newrelic|kube-probe|elb-healthchecker|googlehc|nagios|zabbix|prometheus|blackbox|checkly|better uptime)`)},
+	{geo.BotCategoryAutomation, regexp.MustCompile(`(?i)(headless|phantomjs|selenium|puppeteer|playwright|webdriver)`)},
+	{geo.BotCategoryTool, regexp.MustCompile(`(?i)(curl|wget|python|java/|go-http|libwww|okhttp|axios|node-fetch|httpclient|postman|insomnia|scrapy)`)},
+}
+
 // NewUAParser creates a new user-agent parser
 func NewUAParser() (*UAParser, error) {
 	parser := uaparser.NewFromSaved()
+	cache, err := lru.New[string, *ParsedUA](uaCacheSize)
+	if err != nil {
+		return nil, err
+	}
 	return &UAParser{
 		parser: parser,
+		cache:  cache,
 	}, nil
 }
 
@@ -48,8 +72,8 @@ func (p *UAParser) Parse(userAgent string) *ParsedUA {
 	}
 
 	// Check cache first
-	if cached, ok := p.cache.Load(userAgent); ok {
-		return cached.(*ParsedUA)
+	if cached, ok := p.cache.Get(userAgent); ok {
+		return cached
 	}
 
 	// Parse the user-agent
@@ -75,8 +99,16 @@ func (p *UAParser) Parse(userAgent string) *ParsedUA {
 		result.DeviceType = "unknown"
 	}
 
+	// Known automated clients are bots even when the generic heuristics miss them
+	if result.BotCategory = classifyBot(userAgent); result.BotCategory != "" {
+		result.IsBot = true
+		result.DeviceType = "bot"
+	} else if result.IsBot {
+		result.BotCategory = geo.BotCategoryOther
+	}
+
 	// Store in cache
-	p.cache.Store(userAgent, result)
+	p.cache.Add(userAgent, result)
 
 	return result
 }
@@ -152,6 +184,16 @@ func isBot(client *uaparser.Client, userAgent string) bool {
 	return false
 }
 
+// classifyBot returns the bot category a user-agent belongs to, or "" when it matches none
+func classifyBot(userAgent string) string {
+	for _, c := range botCategoryPatterns {
+		if c.pattern.MatchString(userAgent) {
+			return c.category
+		}
+	}
+	return ""
+}
+
 // ExtractReferrerDomain extracts the domain from a referrer URL
 func ExtractReferrerDomain(referer string) string {
 	if referer == "" || referer == "-" {
@@ -269,3 +269,34 @@ func BenchmarkExtractReferrerDomain(b *testing.B) {
 		ExtractReferrerDomain(referer)
 	}
 }
+
+func TestUAParser_BotCategory(t *testing.T) {
+	parser, err := NewUAParser()
+	if err != nil {
+		t.Fatalf("Failed to create UAParser: %v", err)
+	}
+
+	tests := []struct {
+		userAgent string
+		category  string
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "search_engine"},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)", "ai_crawler"},
+		{"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", "seo"},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", "social"},
+		{"kube-probe/1.29", "monitoring"},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", "automation"},
+		{"curl/7.88.1", "tool"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", ""},
+	}
+
+	for _, tt := range tests {
+		result := parser.Parse(tt.userAgent)
+		if result.BotCategory != tt.category {
+			t.Errorf("%q: category = %q, want %q", tt.userAgent, result.BotCategory, tt.category)
+		}
+		if result.IsBot != (tt.category != "") {
+			t.Errorf("%q: IsBot = %v", tt.userAgent, result.IsBot)
+		}
+	}
+}
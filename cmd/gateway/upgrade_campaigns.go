@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// upgradeVerifyTimeout is how long an agent has to come back on the target version
+	upgradeVerifyTimeout = 5 * time.Minute
+	// upgradePollInterval is how often a campaign checks the versions agents report
+	upgradePollInterval = 5 * time.Second
+)
+
+// AgentVersionGroup is the agents running one version
+type AgentVersionGroup struct {
+	Version  string   `json:"version"`
+	Total    int      `json:"total"`
+	Online   int      `json:"online"`
+	AgentIDs []string `json:"agent_ids"`
+}
+
+// FleetVersions is the fleet grouped by agent and by nginx version
+type FleetVersions struct {
+	ServedVersion string              `json:"served_version,omitempty"` // agent version the gateway serves for updates
+	Total         int                 `json:"total"`
+	AgentVersions []AgentVersionGroup `json:"agent_versions"`
+	NginxVersions []AgentVersionGroup `json:"nginx_versions"`
+}
+
+// groupAgentVersions groups agents by version, the most common version first
+func groupAgentVersions(agents []*pb.AgentInfo, version func(*pb.AgentInfo) string) []AgentVersionGroup {
+	index := make(map[string]int)
+	groups := []AgentVersionGroup{}
+	for _, a := range agents {
+		v := version(a)
+		if v == "" {
+			v = "unknown"
+		}
+		i, ok := index[v]
+		if !ok {
+			i = len(groups)
+			index[v] = i
+			groups = append(groups, AgentVersionGroup{Version: v})
+		}
+		g := &groups[i]
+		g.Total++
+		if a.Status == "online" {
+			g.Online++
+		}
+		g.AgentIDs = append(g.AgentIDs, a.AgentId)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Version < groups[j].Version
+	})
+	for _, g := range groups {
+		sort.Strings(g.AgentIDs)
+	}
+	return groups
+}
+
+func fleetVersions(agents []*pb.AgentInfo, served string) FleetVersions {
+	return FleetVersions{
+		ServedVersion: served,
+		Total:         len(agents),
+		AgentVersions: groupAgentVersions(agents, func(a *pb.AgentInfo) string { return a.AgentVersion }),
+		NginxVersions: groupAgentVersions(agents, func(a *pb.AgentInfo) string { return a.Version }),
+	}
+}
+
+// updatesDir is the directory the gateway serves agent updates from
+func (s *server) updatesDir() string {
+	if s.config != nil && s.config.Server.UpdatesDir != "" {
+		return s.config.Server.UpdatesDir
+	}
+	return "./updates"
+}
+
+// servedAgentVersion returns the version in the version.json agents update from
+func servedAgentVersion(dir string) (string, error) {
+	data, err := os.ReadFile(dir + "/version.json")
+	if err != nil {
+		return "", err
+	}
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("invalid %s/version.json: %w", dir, err)
+	}
+	return manifest.Version, nil
+}
+
+// upgradeCampaignRequest selects the agents of a campaign: the listed agents, group and
+// environment, or the whole fleet when none is set, narrowed to from_versions when given
+type upgradeCampaignRequest struct {
+	Name                string   `json:"name"`
+	TargetVersion       string   `json:"target_version"` // defaults to the served version; any served release
+	AgentIDs            []string `json:"agent_ids"`
+	GroupID             string   `json:"group_id"`
+	EnvironmentID       string   `json:"environment_id"`
+	FromVersions        []string `json:"from_versions"`
+	BatchSize           int      `json:"batch_size"`
+	SoakSeconds         int      `json:"soak_seconds"`
+	FailureThresholdPct int      `json:"failure_threshold_pct"`
+}
+
+// listAgentsByID returns every agent of the fleet, including those of other gateways
+func (s *server) listAgentsByID(ctx context.Context) (map[string]*pb.AgentInfo, error) {
+	resp, err := s.ListAgents(ctx, &pb.ListAgentsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	agents := make(map[string]*pb.AgentInfo, len(resp.Agents))
+	for _, a := range resp.Agents {
+		agents[a.AgentId] = a
+	}
+	return agents, nil
+}
+
+// planUpgradeCampaign builds the campaign for the selected agents. Agents already on the target
+// version or unknown are recorded as skipped; the others are split into batches of batch_size.
+func planUpgradeCampaign(req upgradeCampaignRequest, agentIDs []string, agents map[string]*pb.AgentInfo) (*UpgradeCampaign, [][]string) {
+	c := &UpgradeCampaign{
+		ID:                  uuid.New().String(),
+		Name:                req.Name,
+		TargetVersion:       req.TargetVersion,
+		BatchSize:           req.BatchSize,
+		SoakSeconds:         req.SoakSeconds,
+		FailureThresholdPct: req.FailureThresholdPct,
+		Status:              "pending",
+	}
+	var pending []string
+	for _, id := range agentIDs {
+		info := agents[id]
+		if info != nil && len(req.FromVersions) > 0 && !slices.Contains(req.FromVersions, info.AgentVersion) {
+			continue
+		}
+		r := UpgradeResult{AgentID: id, Status: "skipped"}
+		switch {
+		case info == nil:
+			r.Error = "unknown agent"
+		case info.AgentVersion == req.TargetVersion:
+			r.Error = "already on the target version"
+		default:
+			r.Status = "pending"
+			pending = append(pending, id)
+		}
+		if info != nil {
+			r.Hostname, r.FromVersion = info.Hostname, info.AgentVersion
+		}
+		c.Results = append(c.Results, r)
+	}
+
+	batches := planBatches(pending, "rolling", req.BatchSize, 0)
+	batchOf := make(map[string]int)
+	for i, batch := range batches {
+		for _, id := range batch {
+			batchOf[id] = i + 1
+		}
+	}
+	for i := range c.Results {
+		c.Results[i].Batch = batchOf[c.Results[i].AgentID]
+		c.Progress.add(c.Results[i].Status, 1)
+	}
+	c.TotalBatches = len(batches)
+	return c, batches
+}
+
+// CreateUpgradeCampaign validates and plans a campaign, then runs it in the background on this
+// gateway. Progress is persisted in agent_upgrade_campaigns and agent_upgrade_results.
+func (s *server) CreateUpgradeCampaign(ctx context.Context, req upgradeCampaignRequest, username string) (*UpgradeCampaign, error) {
+	if req.BatchSize <= 0 {
+		req.BatchSize = 1
+	}
+	if req.SoakSeconds < 0 || req.FailureThresholdPct < 0 || req.FailureThresholdPct > 100 {
+		return nil, status.Error(codes.InvalidArgument, "soak_seconds must not be negative and failure_threshold_pct must be within 0-100")
+	}
+	served, err := servedAgentVersion(s.updatesDir())
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no agent update is served: %v", err)
+	}
+	if req.TargetVersion == "" {
+		req.TargetVersion = served
+	}
+	if _, ok := releaseManifestPath(s.updatesDir(), req.TargetVersion); !ok {
+		// Agents update from a version.json the gateway serves and refuse any other version
+		return nil, releaseNotServedError(req.TargetVersion, listAgentReleases(s.updatesDir()))
+	}
+	if req.Name == "" {
+		req.Name = "Upgrade to " + req.TargetVersion
+	}
+
+	agents, err := s.listAgentsByID(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list agents: %v", err)
+	}
+	var agentIDs []string
+	if len(req.AgentIDs) == 0 && req.GroupID == "" && req.EnvironmentID == "" {
+		for id := range agents {
+			agentIDs = append(agentIDs, id)
+		}
+		sort.Strings(agentIDs)
+	} else if agentIDs, err = s.resolveTargetAgents(ctx, req.AgentIDs, req.GroupID, req.EnvironmentID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve targets: %v", err)
+	}
+
+	c, batches := planUpgradeCampaign(req, agentIDs, agents)
+	if len(c.Results) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no target agents")
+	}
+	c.GatewayID, c.CreatedBy = s.instanceID, username
+	if len(batches) == 0 {
+		now := time.Now()
+		c.Status, c.StartedAt, c.CompletedAt = "completed", &now, &now
+	}
+	if err := s.db.CreateUpgradeCampaign(c); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record campaign: %v", err)
+	}
+	if len(batches) == 0 {
+		if err := s.db.UpdateUpgradeCampaign(c); err != nil {
+			jobsLog.Error().Msgf("Upgrade campaign %s: failed to save status: %v", c.ID, err)
+		}
+		return c, nil
+	}
+
+	// The runner updates c in place; the caller gets the campaign as it starts
+	started := *c
+	started.Results = slices.Clone(c.Results)
+
+	_, err = s.startJob("upgrade_campaign", c.ID, username, c.Progress.Pending, func(ctx context.Context, run *jobRun) error {
+		runner := &upgradeRunner{
+			campaign: c,
+			batches:  batches,
+			update:   s.upgradeAgent,
+			agents:   s.listAgentsByID,
+			save: func(c *UpgradeCampaign, r *UpgradeResult) {
+				s.saveUpgradeProgress(c, r)
+				trackUpgradeProgress(run, c, r)
+			},
+			verifyTimeout: upgradeVerifyTimeout,
+			pollInterval:  upgradePollInterval,
+		}
+		runner.run(ctx)
+		return upgradeCampaignJobError(c)
+	})
+	if err != nil {
+		completed := time.Now()
+		c.Status, c.Error, c.CompletedAt = "aborted", err.Error(), &completed
+		s.saveUpgradeProgress(c, nil)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &started, nil
+}
+
+// CancelUpgradeCampaign stops a campaign after the batch being verified
+func (s *server) CancelUpgradeCampaign(id string) bool {
+	return s.cancelJob(id)
+}
+
+// trackUpgradeProgress mirrors a campaign save in its job: the batch as the step, and each agent
+// upgraded or failed as progress
+func trackUpgradeProgress(run *jobRun, c *UpgradeCampaign, r *UpgradeResult) {
+	switch {
+	case r == nil:
+		if c.Status == "running" && c.CurrentBatch > 0 {
+			run.setStep(fmt.Sprintf("Batch %d of %d: upgrading to %s", c.CurrentBatch, c.TotalBatches, c.TargetVersion))
+		}
+	case r.Status == "upgraded":
+		run.advance(1)
+	case r.Status == "failed":
+		run.advance(1)
+		run.logf("warn", "%s: %s", r.AgentID, r.Error)
+	}
+}
+
+// upgradeCampaignJobError is the outcome of a finished campaign for its job. Agents failing below
+// the failure threshold do not fail the job; they are in its log.
+func upgradeCampaignJobError(c *UpgradeCampaign) error {
+	switch c.Status {
+	case "completed":
+		return nil
+	case "cancelled":
+		return context.Canceled
+	}
+	return errors.New(c.Error)
+}
+
+// upgradeAgent sends the update command for version to an agent
+func (s *server) upgradeAgent(ctx context.Context, agentID, version string) error {
+	resp, err := s.UpdateAgent(ctx, &pb.UpdateAgentRequest{AgentId: agentID, Version: version})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Message)
+	}
+	return nil
+}
+
+// saveUpgradeProgress persists a result, or the campaign itself when r is nil
+func (s *server) saveUpgradeProgress(c *UpgradeCampaign, r *UpgradeResult) {
+	var err error
+	if r != nil {
+		err = s.db.SaveUpgradeResult(c.ID, r)
+	} else {
+		err = s.db.UpdateUpgradeCampaign(c)
+	}
+	if err != nil {
+		jobsLog.Error().Msgf("Upgrade campaign %s: failed to save progress: %v", c.ID, err)
+	}
+}
+
+// interruptUpgradeCampaigns closes the campaigns this gateway was running before it restarted
+func (s *server) interruptUpgradeCampaigns() {
+	if s.db == nil {
+		return
+	}
+	if n, err := s.db.InterruptUpgradeCampaigns(s.instanceID); err != nil {
+		jobsLog.Error().Msgf("Failed to close interrupted upgrade campaigns: %v", err)
+	} else if n > 0 {
+		jobsLog.Info().Msgf("Marked %d upgrade campaign(s) interrupted by the gateway restart", n)
+	}
+}
+
+// upgradeFailureExceeded reports whether failed of attempted agents is above thresholdPct
+func upgradeFailureExceeded(failed, attempted, thresholdPct int) bool {
+	return attempted > 0 && failed*100 > thresholdPct*attempted
+}
+
+// upgradeRunner drives a campaign batch by batch: it sends the update command to the agents of a
+// batch, waits for them to report the target version, soaks, then checks the failure threshold
+type upgradeRunner struct {
+	campaign *UpgradeCampaign
+	batches  [][]string
+	update   func(ctx context.Context, agentID, version string) error
+	agents   func(ctx context.Context) (map[string]*pb.AgentInfo, error)
+	save     func(c *UpgradeCampaign, r *UpgradeResult) // r nil saves the campaign
+
+	verifyTimeout time.Duration
+	pollInterval  time.Duration
+}
+
+func (u *upgradeRunner) finish(r *UpgradeResult, status, errMsg string) {
+	now := time.Now()
+	r.Status, r.Error, r.CompletedAt = status, errMsg, &now
+	u.save(u.campaign, r)
+}
+
+func (u *upgradeRunner) run(ctx context.Context) {
+	c := u.campaign
+	results := make(map[string]*UpgradeResult, len(c.Results))
+	for i := range c.Results {
+		results[c.Results[i].AgentID] = &c.Results[i]
+	}
+	now := time.Now()
+	c.Status, c.StartedAt = "running", &now
+	u.save(c, nil)
+
+	attempted, failed := 0, 0
+	for i, batch := range u.batches {
+		if ctx.Err() != nil {
+			break
+		}
+		c.CurrentBatch = i + 1
+		u.save(c, nil)
+
+		var sent []*UpgradeResult
+		for _, id := range batch {
+			r := results[id]
+			started := time.Now()
+			r.Status, r.StartedAt = "updating", &started
+			if err := u.update(ctx, id, c.TargetVersion); err != nil {
+				u.finish(r, "failed", err.Error())
+				continue
+			}
+			u.save(c, r)
+			sent = append(sent, r)
+		}
+		u.verify(ctx, sent)
+		if c.SoakSeconds > 0 && ctx.Err() == nil {
+			sleepCtx(ctx, time.Duration(c.SoakSeconds)*time.Second)
+			u.checkSoak(ctx, sent)
+		}
+
+		attempted += len(batch)
+		for _, id := range batch {
+			if results[id].Status == "failed" {
+				failed++
+			}
+		}
+		if upgradeFailureExceeded(failed, attempted, c.FailureThresholdPct) {
+			c.Status = "aborted"
+			c.Error = fmt.Sprintf("%d of %d upgraded agents failed, above the %d%% threshold", failed, attempted, c.FailureThresholdPct)
+			break
+		}
+	}
+
+	if c.Status != "aborted" {
+		c.Status = "completed"
+		if ctx.Err() != nil {
+			c.Status = "cancelled"
+		}
+	}
+	for i := range c.Results {
+		if r := &c.Results[i]; r.Status == "pending" || r.Status == "updating" {
+			u.finish(r, "skipped", "campaign "+c.Status)
+		}
+	}
+	c.Progress = UpgradeProgress{}
+	for _, r := range c.Results {
+		c.Progress.add(r.Status, 1)
+	}
+	completed := time.Now()
+	c.CompletedAt = &completed
+	u.save(c, nil)
+	jobsLog.Info().Msgf("Upgrade campaign %s finished: %s (%d upgraded, %d failed)", c.ID, c.Status, c.Progress.Upgraded, c.Progress.Failed)
+}
+
+// verify waits until the agents sent the update report the target version, failing those that
+// do not within the verify timeout
+func (u *upgradeRunner) verify(ctx context.Context, sent []*UpgradeResult) {
+	deadline := time.Now().Add(u.verifyTimeout)
+	for {
+		waiting := 0
+		if agents, err := u.agents(ctx); err != nil {
+			jobsLog.Error().Msgf("Upgrade campaign %s: failed to list agents: %v", u.campaign.ID, err)
+			waiting = len(sent)
+		} else {
+			for _, r := range sent {
+				if r.Status != "updating" {
+					continue
+				}
+				if a := agents[r.AgentID]; a != nil && a.Status == "online" && a.AgentVersion == u.campaign.TargetVersion {
+					u.finish(r, "upgraded", "")
+					continue
+				}
+				if reason, ok := updateRolledBack(agents[r.AgentID], u.campaign.TargetVersion, r.StartedAt); ok {
+					u.finish(r, "failed", reason)
+					continue
+				}
+				waiting++
+			}
+		}
+		if waiting == 0 || ctx.Err() != nil {
+			return
+		}
+		if !time.Now().Before(deadline) {
+			for _, r := range sent {
+				if r.Status == "updating" {
+					u.finish(r, "failed", fmt.Sprintf("did not come back on %s within %s", u.campaign.TargetVersion, u.verifyTimeout))
+				}
+			}
+			return
+		}
+		sleepCtx(ctx, u.pollInterval)
+	}
+}
+
+// updateRolledBack reports whether the agent gave up an update to version started after since,
+// either rolling back after its health gate or failing before the swap, and why
+func updateRolledBack(a *pb.AgentInfo, version string, since *time.Time) (string, bool) {
+	if a == nil || a.UpdateStatus == nil || since == nil {
+		return "", false
+	}
+	us := a.UpdateStatus
+	if us.ToVersion != version || us.StartedAt < since.Unix() {
+		return "", false
+	}
+	switch us.State {
+	case "rolled_back":
+		return "rolled back to " + us.FromVersion + ": " + us.Error, true
+	case "failed":
+		return "update failed: " + us.Error, true
+	}
+	return "", false
+}
+
+// checkSoak fails the upgraded agents that went offline or changed version during the soak
+func (u *upgradeRunner) checkSoak(ctx context.Context, sent []*UpgradeResult) {
+	if ctx.Err() != nil {
+		return
+	}
+	agents, err := u.agents(ctx)
+	if err != nil {
+		jobsLog.Error().Msgf("Upgrade campaign %s: failed to list agents after soak: %v", u.campaign.ID, err)
+		return
+	}
+	for _, r := range sent {
+		if r.Status != "upgraded" {
+			continue
+		}
+		switch a := agents[r.AgentID]; {
+		case a == nil || a.Status != "online":
+			u.finish(r, "failed", "went offline during the soak time")
+		case a.AgentVersion != u.campaign.TargetVersion:
+			u.finish(r, "failed", "reverted to "+a.AgentVersion+" during the soak time")
+		}
+	}
+}
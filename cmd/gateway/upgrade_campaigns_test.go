@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestFleetVersions(t *testing.T) {
+	agents := []*pb.AgentInfo{
+		{AgentId: "a", AgentVersion: "1.2.0", Version: "1.25.3", Status: "online"},
+		{AgentId: "b", AgentVersion: "1.1.0", Version: "1.25.3", Status: "offline"},
+		{AgentId: "c", AgentVersion: "1.2.0", Version: "", Status: "offline"},
+	}
+	v := fleetVersions(agents, "1.2.0")
+	if v.Total != 3 || len(v.AgentVersions) != 2 {
+		t.Fatalf("unexpected versions %+v", v)
+	}
+	if g := v.AgentVersions[0]; g.Version != "1.2.0" || g.Total != 2 || g.Online != 1 || strings.Join(g.AgentIDs, ",") != "a,c" {
+		t.Errorf("most common agent version = %+v", g)
+	}
+	if g := v.NginxVersions[1]; g.Version != "unknown" || g.Total != 1 {
+		t.Errorf("agents without nginx version = %+v", g)
+	}
+}
+
+func TestServedAgentVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/version.json", []byte(`{"version":"1.4.2","build_date":""}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := servedAgentVersion(dir); err != nil || v != "1.4.2" {
+		t.Errorf("servedAgentVersion = %q, %v", v, err)
+	}
+}
+
+func TestPlanUpgradeCampaign(t *testing.T) {
+	agents := map[string]*pb.AgentInfo{
+		"a": {AgentId: "a", AgentVersion: "1.0.0"},
+		"b": {AgentId: "b", AgentVersion: "1.1.0"},
+		"c": {AgentId: "c", AgentVersion: "2.0.0"},
+		"d": {AgentId: "d", AgentVersion: "1.0.0"},
+		"e": {AgentId: "e", AgentVersion: "0.9.0"},
+	}
+	req := upgradeCampaignRequest{TargetVersion: "2.0.0", BatchSize: 2, FromVersions: []string{"1.0.0", "1.1.0", "2.0.0"}}
+	c, batches := planUpgradeCampaign(req, []string{"a", "b", "c", "d", "e", "ghost"}, agents)
+
+	if fmt.Sprint(batches) != "[[a b] [d]]" || c.TotalBatches != 2 {
+		t.Errorf("batches = %v", batches)
+	}
+	got := map[string]string{}
+	for _, r := range c.Results {
+		got[r.AgentID] = fmt.Sprintf("%s/%d", r.Status, r.Batch)
+	}
+	want := map[string]string{"a": "pending/1", "b": "pending/1", "c": "skipped/0", "d": "pending/2", "ghost": "skipped/0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	if c.Progress.Total != 5 || c.Progress.Pending != 3 || c.Progress.Skipped != 2 {
+		t.Errorf("progress = %+v", c.Progress)
+	}
+}
+
+func TestUpgradeFailureExceeded(t *testing.T) {
+	tests := []struct {
+		failed, attempted, pct int
+		want                   bool
+	}{
+		{0, 0, 0, false},
+		{0, 5, 0, false},
+		{1, 5, 0, true},
+		{1, 5, 20, false},
+		{2, 5, 20, true},
+		{5, 5, 100, false},
+	}
+	for _, tt := range tests {
+		if got := upgradeFailureExceeded(tt.failed, tt.attempted, tt.pct); got != tt.want {
+			t.Errorf("upgradeFailureExceeded(%d, %d, %d) = %v", tt.failed, tt.attempted, tt.pct, got)
+		}
+	}
+}
+
+// fakeFleet upgrades agents when they receive the update command, except the broken ones
+type fakeFleet struct {
+	mu     sync.Mutex
+	agents map[string]*pb.AgentInfo
+	broken map[string]bool
+	sent   []string
+}
+
+func (f *fakeFleet) update(ctx context.Context, agentID, version string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, agentID)
+	if !f.broken[agentID] {
+		f.agents[agentID] = &pb.AgentInfo{AgentId: agentID, AgentVersion: version, Status: "online"}
+	}
+	return nil
+}
+
+func (f *fakeFleet) list(ctx context.Context) (map[string]*pb.AgentInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	agents := make(map[string]*pb.AgentInfo, len(f.agents))
+	for id, a := range f.agents {
+		agents[id] = a
+	}
+	return agents, nil
+}
+
+func TestUpdateRolledBack(t *testing.T) {
+	sent := time.Unix(1000, 0)
+	status := func(state, to string, started int64) *pb.AgentInfo {
+		return &pb.AgentInfo{AgentVersion: "1.0.0", UpdateStatus: &pb.UpdateStatus{State: state, FromVersion: "1.0.0", ToVersion: to, Error: "no gateway connection", StartedAt: started}}
+	}
+	if reason, ok := updateRolledBack(status("rolled_back", "2.0.0", 1001), "2.0.0", &sent); !ok || !strings.Contains(reason, "rolled back to 1.0.0") {
+		t.Errorf("rolled back update = %q, %v", reason, ok)
+	}
+	for name, a := range map[string]*pb.AgentInfo{
+		"pending":        status("pending", "2.0.0", 1001),
+		"other version":  status("rolled_back", "1.5.0", 1001),
+		"earlier update": status("failed", "2.0.0", 999),
+		"no status":      {AgentVersion: "1.0.0"},
+	} {
+		if _, ok := updateRolledBack(a, "2.0.0", &sent); ok {
+			t.Errorf("%s: reported as rolled back", name)
+		}
+	}
+}
+
+func TestUpgradeRunnerAbortsAboveThreshold(t *testing.T) {
+	fleet := &fakeFleet{agents: map[string]*pb.AgentInfo{}, broken: map[string]bool{"c": true}}
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+	for _, id := range ids {
+		fleet.agents[id] = &pb.AgentInfo{AgentId: id, AgentVersion: "1.0.0", Status: "online"}
+	}
+	agents, _ := fleet.list(context.Background())
+	c, batches := planUpgradeCampaign(upgradeCampaignRequest{TargetVersion: "2.0.0", BatchSize: 2, FailureThresholdPct: 20}, ids, agents)
+
+	runner := &upgradeRunner{
+		campaign:      c,
+		batches:       batches,
+		update:        fleet.update,
+		agents:        fleet.list,
+		save:          func(*UpgradeCampaign, *UpgradeResult) {},
+		verifyTimeout: 50 * time.Millisecond,
+		pollInterval:  10 * time.Millisecond,
+	}
+	runner.run(context.Background())
+
+	// Batch 2 leaves 1 failure out of 4 attempted agents, above 20%: batch 3 never starts
+	if c.Status != "aborted" || c.CurrentBatch != 2 {
+		t.Errorf("campaign %s at batch %d, want aborted at batch 2", c.Status, c.CurrentBatch)
+	}
+	if strings.Join(fleet.sent, ",") != "a,b,c,d" {
+		t.Errorf("update commands sent to %v", fleet.sent)
+	}
+	if p := c.Progress; p.Upgraded != 3 || p.Failed != 1 || p.Skipped != 2 {
+		t.Errorf("progress = %+v", p)
+	}
+	if c.CompletedAt == nil {
+		t.Error("campaign has no completion time")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+// endpointSQL is the endpoint of an access_logs row: its template, or for rows stored before
+// templating its path
+const endpointSQL = "if(endpoint != '', endpoint, cutQueryString(request_uri))"
+
+// idSegmentRe matches path segments that identify a resource rather than name an endpoint: numbers,
+// UUIDs and hex strings of 16 characters or more (hashes, object ids)
+var idSegmentRe = regexp.MustCompile(`^(?:[0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+type uriTemplate struct {
+	pattern  *regexp.Regexp
+	template string
+}
+
+// uriNormalizer groups request URIs into endpoints at ingest, so /users/123 and /users/456 count
+// as /users/{id} in top endpoints. The query string is dropped, the first of the configured
+// templates whose pattern matches the path names the endpoint, and otherwise numeric, UUID and
+// hex segments are collapsed to {id}. A nil normalizer only drops the query string.
+type uriNormalizer struct {
+	templates   []uriTemplate
+	collapseIDs bool
+}
+
+// newURINormalizer compiles the templates of analytics.uri_templates
+func newURINormalizer(cfg config.AnalyticsConfig) (*uriNormalizer, error) {
+	n := &uriNormalizer{collapseIDs: cfg.CollapseIDs}
+	for i, t := range cfg.URITemplates {
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("uri_templates[%d]: %w", i, err)
+		}
+		if t.Template == "" {
+			return nil, fmt.Errorf("uri_templates[%d]: template is empty", i)
+		}
+		n.templates = append(n.templates, uriTemplate{pattern: re, template: t.Template})
+	}
+	return n, nil
+}
+
+// Normalize returns the endpoint of a request URI
+func (n *uriNormalizer) Normalize(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	if n == nil {
+		return path
+	}
+	for _, t := range n.templates {
+		// $1 and ${name} in the template are expanded from the pattern's groups
+		if m := t.pattern.FindStringSubmatchIndex(path); m != nil {
+			return string(t.pattern.ExpandString(nil, t.template, path, m))
+		}
+	}
+	if !n.collapseIDs {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if idSegmentRe.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
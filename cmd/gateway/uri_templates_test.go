@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/config"
+)
+
+func TestURINormalizer(t *testing.T) {
+	n, err := newURINormalizer(config.AnalyticsConfig{
+		CollapseIDs: true,
+		URITemplates: []config.URITemplate{
+			{Pattern: `^/users/[^/]+/avatar$`, Template: "/users/{user}/avatar"},
+			{Pattern: `^/docs/(?P<section>[a-z]+)/.+$`, Template: "/docs/${section}/{page}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for uri, want := range map[string]string{
+		"/users/123":                                         "/users/{id}",
+		"/users/456?expand=all":                              "/users/{id}",
+		"/users/alice/avatar":                                "/users/{user}/avatar",
+		"/docs/api/getting-started/intro":                    "/docs/api/{page}",
+		"/orders/3f2b8c1e-9d4a-4f6b-8a7c-1e2d3c4b5a69/items": "/orders/{id}/items",
+		"/blobs/5f1d7a3c9e2b4d6f8a0c":                        "/blobs/{id}",
+		"/api/v2/health":                                     "/api/v2/health",
+		"/":                                                  "/",
+		"/search?q=42":                                       "/search",
+	} {
+		if got := n.Normalize(uri); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", uri, got, want)
+		}
+	}
+
+	n.collapseIDs = false
+	if got := n.Normalize("/users/123"); got != "/users/123" {
+		t.Errorf("without collapsing ids, /users/123 = %q", got)
+	}
+	var none *uriNormalizer
+	if got := none.Normalize("/users/123?x=1"); got != "/users/123" {
+		t.Errorf("nil normalizer = %q, want the path", got)
+	}
+}
+
+func TestRollupsMatchURL(t *testing.T) {
+	n, err := newURINormalizer(config.AnalyticsConfig{CollapseIDs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &ClickHouseDB{endpoints: n}
+	// A raw URI is stored under its template, so filtering by it over an hour reads access_logs
+	for filter, want := range map[string]bool{
+		"":                 true,
+		"/users/{id}":      true,
+		"/api/v2/health":   true,
+		"/users/123":       false,
+		"/search?q=shoes":  false,
+		"/users/123?x=all": false,
+	} {
+		if got := db.rollupsMatchURL(filter); got != want {
+			t.Errorf("rollupsMatchURL(%q) = %v, want %v", filter, got, want)
+		}
+	}
+}
+
+func TestURINormalizerInvalid(t *testing.T) {
+	for _, tmpl := range []config.URITemplate{
+		{Pattern: `^/users/(`, Template: "/users/{id}"},
+		{Pattern: `^/users/`, Template: ""},
+	} {
+		if _, err := newURINormalizer(config.AnalyticsConfig{URITemplates: []config.URITemplate{tmpl}}); err == nil {
+			t.Errorf("%+v: expected an error", tmpl)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/avika-ai/avika/cmd/gateway/vulns"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+	"google.golang.org/protobuf/proto"
+)
+
+// VulnerabilityFinding is an advisory affecting a version an agent runs
+type VulnerabilityFinding struct {
+	ID               string  `json:"id"`
+	Product          string  `json:"product"`
+	InstalledVersion string  `json:"installed_version"`
+	Severity         string  `json:"severity"`
+	Score            float64 `json:"score"`
+	Summary          string  `json:"summary"`
+	FixedIn          string  `json:"fixed_in,omitempty"`
+	URL              string  `json:"url,omitempty"`
+}
+
+// AgentVulnerabilities is the advisories affecting one agent, highest severity first
+type AgentVulnerabilities struct {
+	AgentID  string                 `json:"agent_id"`
+	Hostname string                 `json:"hostname,omitempty"`
+	Status   string                 `json:"status,omitempty"`
+	OS       string                 `json:"os,omitempty"`
+	Kernel   string                 `json:"kernel,omitempty"`
+	Severity string                 `json:"severity"` // highest severity among the findings
+	Findings []VulnerabilityFinding `json:"findings"`
+}
+
+// VulnerabilityReport is the CVE exposure of the fleet
+type VulnerabilityReport struct {
+	Feed       vulns.Status           `json:"feed"`
+	Total      int                    `json:"total"`       // agents checked
+	Vulnerable int                    `json:"vulnerable"`  // agents with at least one finding
+	BySeverity map[string]int         `json:"by_severity"` // vulnerable agents by their highest severity
+	Agents     []AgentVulnerabilities `json:"agents"`
+}
+
+// opensslPackages are the package names OpenSSL is installed under
+var opensslPackages = []string{"openssl", "openssl-libs", "libssl3", "libssl3t64", "libssl1.1"}
+
+// recordHostInventory keeps the host inventory of a heartbeat on the session and, when it
+// changed, on the agent record. Heartbeats without one (older agents) keep the last one.
+func (s *server) recordHostInventory(session *AgentSession, inv *pb.HostInventory) {
+	if inv == nil {
+		return
+	}
+	session.mu.Lock()
+	changed := !proto.Equal(session.hostInventory, inv)
+	if changed {
+		session.hostInventory = inv
+	}
+	session.mu.Unlock()
+	if !changed || s.db == nil {
+		return
+	}
+	if err := s.db.SaveAgentHostInventory(session.id, inv); err != nil {
+		securityLog.Error().Msgf("Failed to save host inventory of agent %s: %v", session.id, err)
+	}
+}
+
+// agentProductVersions returns the upstream versions of the products an agent runs. OpenSSL is
+// taken from the library nginx runs with, else from the installed packages.
+func agentProductVersions(a agentSoftware) map[string][]string {
+	versions := make(map[string][]string)
+	add := func(product, v string) {
+		if v = vulns.UpstreamVersion(v); v != "" && !slices.Contains(versions[product], v) {
+			versions[product] = append(versions[product], v)
+		}
+	}
+	add(vulns.ProductNginx, strings.TrimPrefix(a.NginxVersion, "nginx/"))
+	for _, b := range a.Builds {
+		add(vulns.ProductOpenSSL, b.OpensslVersion)
+	}
+	if a.Host != nil {
+		add(vulns.ProductLinux, a.Host.Kernel)
+		if len(versions[vulns.ProductOpenSSL]) == 0 {
+			for _, p := range a.Host.Packages {
+				if slices.Contains(opensslPackages, p.Name) {
+					add(vulns.ProductOpenSSL, p.Version)
+				}
+			}
+		}
+	}
+	return versions
+}
+
+// matchAgentVulnerabilities matches an agent's versions against the feed
+func matchAgentVulnerabilities(feed *vulns.Store, a agentSoftware) AgentVulnerabilities {
+	av := AgentVulnerabilities{AgentID: a.AgentID, Hostname: a.Hostname, Status: a.Status, Findings: []VulnerabilityFinding{}}
+	if a.Host != nil {
+		av.OS, av.Kernel = a.Host.OsName, a.Host.Kernel
+	}
+	for product, versions := range agentProductVersions(a) {
+		for _, v := range versions {
+			for _, adv := range feed.Match(product, v) {
+				av.Findings = append(av.Findings, VulnerabilityFinding{
+					ID:               adv.ID,
+					Product:          product,
+					InstalledVersion: v,
+					Severity:         adv.Severity,
+					Score:            adv.Score,
+					Summary:          adv.Summary,
+					FixedIn:          adv.FixedIn(v),
+					URL:              adv.URL,
+				})
+			}
+		}
+	}
+	sort.Slice(av.Findings, func(i, j int) bool {
+		fi, fj := av.Findings[i], av.Findings[j]
+		if ri, rj := vulns.SeverityRank(fi.Severity), vulns.SeverityRank(fj.Severity); ri != rj {
+			return ri > rj
+		}
+		if fi.Score != fj.Score {
+			return fi.Score > fj.Score
+		}
+		return fi.ID < fj.ID
+	})
+	if len(av.Findings) > 0 {
+		av.Severity = av.Findings[0].Severity
+	}
+	return av
+}
+
+// buildVulnerabilityReport matches the agents against the feed. Findings below minSeverity
+// (e.g. "high" keeps high and critical) are left out.
+func buildVulnerabilityReport(feed *vulns.Store, agents []agentSoftware, minSeverity string) VulnerabilityReport {
+	report := VulnerabilityReport{Feed: feed.Status(), Total: len(agents), BySeverity: map[string]int{}, Agents: []AgentVulnerabilities{}}
+	minRank := vulns.SeverityRank(minSeverity)
+	for _, a := range agents {
+		av := matchAgentVulnerabilities(feed, a)
+		av.Findings = slices.DeleteFunc(av.Findings, func(f VulnerabilityFinding) bool {
+			return vulns.SeverityRank(f.Severity) < minRank
+		})
+		if len(av.Findings) == 0 {
+			continue
+		}
+		report.Vulnerable++
+		report.BySeverity[av.Severity]++
+		report.Agents = append(report.Agents, av)
+	}
+	sort.SliceStable(report.Agents, func(i, j int) bool {
+		ai, aj := report.Agents[i], report.Agents[j]
+		if ri, rj := vulns.SeverityRank(ai.Severity), vulns.SeverityRank(aj.Severity); ri != rj {
+			return ri > rj
+		}
+		return len(ai.Findings) > len(aj.Findings)
+	})
+	return report
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/avika-ai/avika/cmd/gateway/vulns"
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestAgentProductVersions(t *testing.T) {
+	a := agentSoftware{
+		NginxVersion: "nginx/1.24.0",
+		Host: &pb.HostInventory{
+			Kernel:   "5.15.0-91-generic",
+			Packages: []*pb.PackageVersion{{Name: "libssl3", Version: "3.0.2-0ubuntu1.10", Manager: "dpkg"}},
+		},
+	}
+	got := agentProductVersions(a)
+	if got[vulns.ProductNginx][0] != "1.24.0" || got[vulns.ProductLinux][0] != "5.15.0" || got[vulns.ProductOpenSSL][0] != "3.0.2" {
+		t.Fatalf("versions = %v", got)
+	}
+
+	// The library nginx runs with wins over the packages
+	a.Builds = []*pb.NginxBuild{{OpensslVersion: "OpenSSL 3.0.11 19 Sep 2023"}}
+	if got := agentProductVersions(a)[vulns.ProductOpenSSL]; len(got) != 1 || got[0] != "3.0.11" {
+		t.Errorf("openssl = %v", got)
+	}
+}
+
+func TestBuildVulnerabilityReport(t *testing.T) {
+	feed := vulns.NewStore()
+	agents := []agentSoftware{
+		{AgentID: "old", NginxVersion: "1.20.0", Host: &pb.HostInventory{OsName: "Ubuntu 22.04", Packages: []*pb.PackageVersion{{Name: "openssl", Version: "3.0.5"}}}},
+		{AgentID: "patched", NginxVersion: "1.27.4"},
+		{AgentID: "mid", NginxVersion: "1.26.1"},
+	}
+
+	report := buildVulnerabilityReport(feed, agents, "")
+	if report.Total != 3 || report.Vulnerable != 2 {
+		t.Fatalf("total %d vulnerable %d", report.Total, report.Vulnerable)
+	}
+	if report.Agents[0].AgentID != "old" || report.Agents[0].Severity != "high" || report.Agents[0].OS != "Ubuntu 22.04" {
+		t.Errorf("first agent = %+v", report.Agents[0])
+	}
+	for _, f := range report.Agents[0].Findings {
+		if f.ID == "CVE-2022-3602" && (f.Product != vulns.ProductOpenSSL || f.FixedIn != "3.0.7") {
+			t.Errorf("openssl finding = %+v", f)
+		}
+	}
+
+	report = buildVulnerabilityReport(feed, agents, "critical")
+	if report.Vulnerable != 0 || len(report.Agents) != 0 {
+		t.Errorf("critical report = %+v", report.Agents)
+	}
+}
@@ -0,0 +1,320 @@
+// Package vulns matches the software versions agents report against a CVE feed.
+package vulns
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avika-ai/avika/internal/common/logging"
+)
+
+var vulnsLog = logging.NewLogger("vulns")
+
+// Products advisories apply to
+const (
+	ProductNginx   = "nginx"
+	ProductOpenSSL = "openssl"
+	ProductLinux   = "linux"
+)
+
+const (
+	defaultRefreshInterval = 24 * time.Hour
+	maxFeedSize            = 32 << 20
+)
+
+// builtinFeed is used until a configured feed loads, and when none is configured
+//
+//go:embed feed.json
+var builtinFeed []byte
+
+// severityRank orders severities, unknown ones lowest
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// SeverityRank returns the rank of a severity (low 1 to critical 4), 0 when unknown
+func SeverityRank(severity string) int {
+	return severityRank[strings.ToLower(severity)]
+}
+
+// Range is a span of affected versions: from Introduced (inclusive, empty for any) up to Fixed
+// (exclusive, empty when unfixed)
+type Range struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+// Advisory is one CVE for one product
+type Advisory struct {
+	ID       string  `json:"id"`
+	Product  string  `json:"product"`
+	Severity string  `json:"severity"` // low, medium, high, critical
+	Score    float64 `json:"score"`
+	Summary  string  `json:"summary"`
+	URL      string  `json:"url,omitempty"`
+	Affected []Range `json:"affected"`
+}
+
+// Affects reports whether version falls in one of the advisory's ranges
+func (a Advisory) Affects(version string) bool {
+	if version == "" {
+		return false
+	}
+	for _, r := range a.Affected {
+		if r.Introduced != "" && CompareVersions(version, r.Introduced) < 0 {
+			continue
+		}
+		if r.Fixed != "" && CompareVersions(version, r.Fixed) >= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// FixedIn returns the first fixed version at or after version, empty when unfixed
+func (a Advisory) FixedIn(version string) string {
+	for _, r := range a.Affected {
+		if r.Fixed != "" && (r.Introduced == "" || CompareVersions(version, r.Introduced) >= 0) && CompareVersions(version, r.Fixed) < 0 {
+			return r.Fixed
+		}
+	}
+	return ""
+}
+
+// Feed is a set of advisories
+type Feed struct {
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// Parse reads a JSON feed
+func Parse(r io.Reader) (*Feed, error) {
+	var f Feed
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid CVE feed: %w", err)
+	}
+	for i, a := range f.Advisories {
+		if a.ID == "" || a.Product == "" {
+			return nil, fmt.Errorf("invalid CVE feed: advisory %d has no id or product", i)
+		}
+		f.Advisories[i].Product = strings.ToLower(a.Product)
+		f.Advisories[i].Severity = strings.ToLower(a.Severity)
+	}
+	return &f, nil
+}
+
+// CompareVersions compares dotted versions numerically, segment by segment. A letter suffix
+// sorts after the bare number (OpenSSL 1.1.1 < 1.1.1a < 1.1.1w < 1.1.1za).
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xs := splitSegment(x)
+		yn, ys := splitSegment(y)
+		if xn != yn {
+			if xn < yn {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(xs, ys); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func splitSegment(s string) (int, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, _ := strconv.Atoi(s[:i])
+	return n, s[i:]
+}
+
+// UpstreamVersion reduces a reported version to the upstream release advisories use: the
+// package epoch and revision are dropped ("1:3.0.2-0ubuntu1.10" -> "3.0.2"), as are kernel
+// build suffixes ("5.15.0-91-generic" -> "5.15.0") and OpenSSL banners ("OpenSSL 3.0.11 19 Sep
+// 2023" -> "3.0.11")
+func UpstreamVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if rest, ok := strings.CutPrefix(v, "OpenSSL "); ok {
+		v = rest
+	}
+	if i := strings.IndexByte(v, ' '); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		v = v[i+1:]
+	}
+	if i := strings.IndexAny(v, "-+~"); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// Store holds the current feed
+type Store struct {
+	mu       sync.RWMutex
+	feed     *Feed
+	source   string
+	loadedAt time.Time
+	err      error
+}
+
+// NewStore returns a store loaded with the built-in feed
+func NewStore() *Store {
+	s := &Store{}
+	f, err := Parse(strings.NewReader(string(builtinFeed)))
+	if err != nil {
+		panic(err)
+	}
+	s.set(f, "builtin")
+	return s
+}
+
+func (s *Store) set(f *Feed, source string) {
+	sort.SliceStable(f.Advisories, func(i, j int) bool { return f.Advisories[i].ID < f.Advisories[j].ID })
+	s.mu.Lock()
+	s.feed, s.source, s.loadedAt, s.err = f, source, time.Now(), nil
+	s.mu.Unlock()
+}
+
+// Status describes the loaded feed
+type Status struct {
+	Source     string    `json:"source"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	Advisories int       `json:"advisories"`
+	Error      string    `json:"error,omitempty"` // last refresh error; the previous feed stays in use
+}
+
+// Status returns the source and age of the loaded feed
+func (s *Store) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st := Status{Source: s.source, LoadedAt: s.loadedAt}
+	if s.feed != nil {
+		st.UpdatedAt, st.Advisories = s.feed.UpdatedAt, len(s.feed.Advisories)
+	}
+	if s.err != nil {
+		st.Error = s.err.Error()
+	}
+	return st
+}
+
+// Match returns the advisories affecting a product version, highest score first
+func (s *Store) Match(product, version string) []Advisory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.feed == nil {
+		return nil
+	}
+	var out []Advisory
+	for _, a := range s.feed.Advisories {
+		if a.Product == product && a.Affects(version) {
+			out = append(out, a)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// Refresher reloads a configured feed from a URL or file
+type Refresher struct {
+	store    *Store
+	url      string
+	path     string
+	interval time.Duration
+	offline  bool
+	client   *http.Client
+}
+
+// NewRefresher creates a refresher for the feed at url or path. With offline set only the file
+// is loaded.
+func NewRefresher(store *Store, url, path string, interval time.Duration, offline bool) *Refresher {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	return &Refresher{store: store, url: url, path: path, interval: interval, offline: offline, client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// Start loads the feed now and then on every interval until ctx is done. Without a configured
+// feed the built-in one stays in use.
+func (r *Refresher) Start(ctx context.Context) {
+	if r.url == "" && r.path == "" {
+		return
+	}
+	go func() {
+		r.Refresh(ctx)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh reloads the feed. On failure the previous feed is kept.
+func (r *Refresher) Refresh(ctx context.Context) {
+	f, source, err := r.load(ctx)
+	if err != nil {
+		vulnsLog.Error().Msgf("CVE feed: refresh failed: %v", err)
+		r.store.mu.Lock()
+		r.store.err = err
+		r.store.mu.Unlock()
+		return
+	}
+	r.store.set(f, source)
+	vulnsLog.Info().Msgf("CVE feed: loaded %d advisories from %s", len(f.Advisories), source)
+}
+
+func (r *Refresher) load(ctx context.Context) (*Feed, string, error) {
+	if r.path != "" {
+		file, err := os.Open(r.path)
+		if err != nil {
+			return nil, r.path, err
+		}
+		defer file.Close()
+		f, err := Parse(file)
+		return f, r.path, err
+	}
+	if r.offline {
+		return nil, r.url, fmt.Errorf("remote CVE feed is disabled in offline mode")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, r.url, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, r.url, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, r.url, fmt.Errorf("download returned %s", resp.Status)
+	}
+	f, err := Parse(io.LimitReader(resp.Body, maxFeedSize))
+	return f, r.url, err
+}
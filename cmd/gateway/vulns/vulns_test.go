@@ -0,0 +1,108 @@
+package vulns
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.24.0", "1.25.3", -1},
+		{"1.25.10", "1.25.3", 1},
+		{"1.25", "1.25.0", 0},
+		{"1.1.1", "1.1.1a", -1},
+		{"1.1.1w", "1.1.1t", 1},
+		{"1.0.2z", "1.0.2za", -1},
+		{"3.0.11", "3.0.8", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUpstreamVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.24.0":                     "1.24.0",
+		"1:3.0.2-0ubuntu1.10":        "3.0.2",
+		"3.0.7-27.el9":               "3.0.7",
+		"5.15.0-91-generic":          "5.15.0",
+		"6.1.0+deb12":                "6.1.0",
+		"OpenSSL 3.0.11 19 Sep 2023": "3.0.11",
+		"1.1.1w-r1":                  "1.1.1w",
+	}
+	for in, want := range tests {
+		if got := UpstreamVersion(in); got != want {
+			t.Errorf("UpstreamVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStoreMatch(t *testing.T) {
+	s := NewStore()
+	ids := func(advs []Advisory) map[string]bool {
+		m := make(map[string]bool)
+		for _, a := range advs {
+			m[a.ID] = true
+		}
+		return m
+	}
+
+	got := ids(s.Match(ProductNginx, "1.24.0"))
+	if !got["CVE-2023-44487"] || !got["CVE-2024-7347"] || got["CVE-2022-41741"] {
+		t.Errorf("nginx 1.24.0 matched %v", got)
+	}
+	if got := s.Match(ProductNginx, "1.27.4"); len(got) != 0 {
+		t.Errorf("nginx 1.27.4 matched %v", got)
+	}
+	if got := ids(s.Match(ProductOpenSSL, "3.0.5")); !got["CVE-2022-3602"] {
+		t.Errorf("openssl 3.0.5 matched %v", got)
+	}
+	if got := s.Match(ProductOpenSSL, "1.1.1w"); len(got) != 0 {
+		t.Errorf("openssl 1.1.1w matched %v", got)
+	}
+
+	for _, a := range s.Match(ProductNginx, "1.22.0") {
+		if a.ID == "CVE-2022-41741" && a.FixedIn("1.22.0") != "1.22.1" {
+			t.Errorf("FixedIn = %q, want 1.22.1", a.FixedIn("1.22.0"))
+		}
+	}
+}
+
+func TestRefresherLoadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.json")
+	feed := `{"updated_at":"2025-01-01T00:00:00Z","advisories":[{"id":"CVE-2099-0001","product":"NGINX","severity":"Critical","score":9.8,"affected":[{"fixed":"1.30.0"}]}]}`
+	if err := os.WriteFile(path, []byte(feed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore()
+	NewRefresher(s, "", path, 0, true).Refresh(context.Background())
+	if st := s.Status(); st.Source != path || st.Advisories != 1 {
+		t.Fatalf("status = %+v", st)
+	}
+	got := s.Match(ProductNginx, "1.26.2")
+	if len(got) != 1 || got[0].Severity != "critical" {
+		t.Fatalf("matched %+v", got)
+	}
+
+	// A broken feed keeps the loaded one
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	NewRefresher(s, "", path, 0, true).Refresh(context.Background())
+	if st := s.Status(); st.Advisories != 1 || st.Error == "" {
+		t.Errorf("status after a failed refresh = %+v", st)
+	}
+
+	// Offline mode never downloads
+	NewRefresher(s, "http://127.0.0.1:1/feed.json", "", 0, true).Refresh(context.Background())
+	if st := s.Status(); st.Advisories != 1 || st.Error == "" {
+		t.Errorf("status after an offline refresh = %+v", st)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookPollInterval   = 5 * time.Second
+	webhookTimeout        = 10 * time.Second
+	webhookMaxAttempts    = 8
+	webhookBatchSize      = 50
+	webhookRetention      = 30 * 24 * time.Hour
+	webhookMaxBackoff     = time.Hour
+	webhookInitialBackoff = 30 * time.Second
+)
+
+// webhookEvents are the lifecycle events endpoints can subscribe to
+var webhookEvents = []string{
+	"agent.online",
+	"agent.offline",
+	"agent.updated",
+	"config.changed",
+	"alert.fired",
+	"alert.resolved",
+	"drift.detected",
+	"quota.soft_exceeded",
+	"quota.hard_exceeded",
+}
+
+// webhookWants reports whether an endpoint subscribed to events receives eventType. "ping" test
+// events always go through.
+func webhookWants(events []string, eventType string) bool {
+	return eventType == "ping" || slices.Contains(events, "*") || slices.Contains(events, eventType)
+}
+
+// webhookBackoff is the wait before retrying after the given number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookInitialBackoff
+	for i := 1; i < attempts && d < webhookMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, webhookMaxBackoff)
+}
+
+// signWebhook returns the X-Avika-Signature header value: the HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the endpoint secret. Receivers recompute it and reject stale timestamps to stop replays.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// queueWebhookEvent records a delivery of the event to each subscribed endpoint, or only to
+// endpointID when set, and returns how many were queued
+func (s *server) queueWebhookEvent(eventType string, data map[string]interface{}, occurred time.Time, endpointID string) (int, error) {
+	endpoints, err := s.db.ListWebhookEndpoints()
+	if err != nil {
+		return 0, err
+	}
+	eventID := uuid.New().String()
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          eventID,
+		"type":        eventType,
+		"occurred_at": occurred.Format(time.RFC3339),
+		"gateway_id":  s.instanceID,
+		"data":        data,
+	})
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, e := range endpoints {
+		if endpointID != "" && e.ID != endpointID {
+			continue
+		}
+		if !e.Enabled || !webhookWants(e.Events, eventType) {
+			continue
+		}
+		d := &WebhookDelivery{ID: uuid.New().String(), EndpointID: e.ID, EventID: eventID, EventType: eventType, Payload: payload}
+		if err := s.db.CreateWebhookDelivery(d); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// startWebhookDispatcher delivers the queued webhook events from the leader gateway, retrying
+// failures with exponential backoff, and prunes the delivery log
+func (s *server) startWebhookDispatcher() {
+	if s.db == nil {
+		return
+	}
+	go func() {
+		alertsLog.Info().Msg("Starting webhook dispatcher")
+		client := &http.Client{Timeout: webhookTimeout}
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+		for now := range ticker.C {
+			if !s.isLeader() {
+				continue
+			}
+			s.deliverDueWebhooks(client, now)
+			if now.Sub(lastPrune) >= 24*time.Hour {
+				if n, err := s.db.PruneWebhookDeliveries(now.Add(-webhookRetention)); err != nil {
+					alertsLog.Error().Msgf("Failed to prune webhook deliveries: %v", err)
+				} else if n > 0 {
+					alertsLog.Info().Msgf("Pruned %d webhook deliveries", n)
+				}
+				lastPrune = now
+			}
+		}
+	}()
+}
+
+func (s *server) deliverDueWebhooks(client *http.Client, now time.Time) {
+	deliveries, endpoints, err := s.db.ListDueWebhookDeliveries(now, webhookBatchSize)
+	if err != nil {
+		alertsLog.Error().Msgf("Webhooks: failed to list due deliveries: %v", err)
+		return
+	}
+	for i := range deliveries {
+		d := &deliveries[i]
+		e := endpoints[d.EndpointID]
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		code, err := postWebhook(ctx, client, e, d)
+		cancel()
+		recordWebhookAttempt(d, code, err, time.Now())
+		if d.Status == "failed" {
+			alertsLog.Warn().Msgf("Webhooks: giving up on %s delivery %s to %s after %d attempts: %s", d.EventType, d.ID, e.URL, d.Attempts, d.Error)
+		}
+		if err := s.db.SaveWebhookAttempt(d); err != nil {
+			alertsLog.Error().Msgf("Webhooks: failed to save delivery %s: %v", d.ID, err)
+		}
+	}
+}
+
+// postWebhook sends a delivery to its endpoint and returns the response status; non-2xx responses
+// are errors
+func postWebhook(ctx context.Context, client *http.Client, e *WebhookEndpoint, d *WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Avika-Webhooks/1.0")
+	req.Header.Set("X-Avika-Event", d.EventType)
+	req.Header.Set("X-Avika-Delivery", d.ID)
+	req.Header.Set("X-Avika-Attempt", strconv.Itoa(d.Attempts+1))
+	req.Header.Set("X-Avika-Signature", signWebhook(e.Secret, time.Now().Unix(), d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// recordWebhookAttempt updates a delivery after an attempt: delivered, scheduled for a retry, or
+// failed once webhookMaxAttempts are used up
+func recordWebhookAttempt(d *WebhookDelivery, code int, err error, now time.Time) {
+	d.Attempts++
+	d.ResponseCode = code
+	if err == nil {
+		d.Status, d.Error, d.NextAttemptAt, d.DeliveredAt = "delivered", "", nil, &now
+		return
+	}
+	d.Error = err.Error()
+	if d.Attempts >= webhookMaxAttempts {
+		d.Status, d.NextAttemptAt = "failed", nil
+		return
+	}
+	next := now.Add(webhookBackoff(d.Attempts))
+	d.NextAttemptAt = &next
+}
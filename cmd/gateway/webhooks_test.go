@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"type":"agent.online"}`)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+	if got := signWebhook("whsec_test", 1700000000, body); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookWants(t *testing.T) {
+	if !webhookWants([]string{"agent.offline"}, "agent.offline") || webhookWants([]string{"agent.offline"}, "agent.online") {
+		t.Error("explicit subscription")
+	}
+	if !webhookWants([]string{"*"}, "drift.detected") || !webhookWants([]string{"alert.fired"}, "ping") {
+		t.Error("wildcard and ping should match")
+	}
+}
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	d := &WebhookDelivery{Status: "pending"}
+	recordWebhookAttempt(d, 503, errors.New("HTTP 503"), now)
+	if d.Status != "pending" || d.Attempts != 1 || d.NextAttemptAt == nil || !d.NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("after first failure: %+v", d)
+	}
+	for d.Status == "pending" {
+		recordWebhookAttempt(d, 0, errors.New("connection refused"), now)
+	}
+	if d.Status != "failed" || d.Attempts != webhookMaxAttempts || d.NextAttemptAt != nil {
+		t.Errorf("after retries: %+v", d)
+	}
+	if got := webhookBackoff(20); got != webhookMaxBackoff {
+		t.Errorf("backoff = %v", got)
+	}
+
+	d = &WebhookDelivery{Status: "pending", Attempts: 2, Error: "timeout"}
+	recordWebhookAttempt(d, 204, nil, now)
+	if d.Status != "delivered" || d.Error != "" || d.DeliveredAt == nil {
+		t.Errorf("after success: %+v", d)
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var gotSig, gotEvent, gotBody string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotSig, gotEvent, gotBody = r.Header.Get("X-Avika-Signature"), r.Header.Get("X-Avika-Event"), string(b)
+		w.WriteHeader(status)
+		fmt.Fprint(w, "nope")
+	}))
+	defer srv.Close()
+
+	e := &WebhookEndpoint{URL: srv.URL, Secret: "whsec_test"}
+	d := &WebhookDelivery{ID: "d1", EventType: "agent.offline", Payload: []byte(`{"type":"agent.offline"}`)}
+	if code, err := postWebhook(context.Background(), srv.Client(), e, d); err != nil || code != 200 {
+		t.Fatalf("post = %d, %v", code, err)
+	}
+	ts, _, _ := strings.Cut(strings.TrimPrefix(gotSig, "t="), ",")
+	var unix int64
+	fmt.Sscan(ts, &unix)
+	if gotEvent != "agent.offline" || gotBody != string(d.Payload) || gotSig != signWebhook("whsec_test", unix, d.Payload) {
+		t.Errorf("event %q, body %q, signature %q", gotEvent, gotBody, gotSig)
+	}
+
+	status = http.StatusInternalServerError
+	if code, err := postWebhook(context.Background(), srv.Client(), e, d); err == nil || code != 500 || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("post = %d, %v", code, err)
+	}
+}
+
+func TestWebhookEndpointRequest(t *testing.T) {
+	name, url := "CMDB", "https://cmdb.example.com/hooks/avika"
+	e := &WebhookEndpoint{Enabled: true}
+	req := webhookEndpointRequest{Name: &name, URL: &url, Events: []string{"agent.online", "config.changed"}}
+	if err := req.apply(e); err != nil || e.URL != url || len(e.Events) != 2 {
+		t.Fatalf("apply = %v, %+v", err, e)
+	}
+	// Omitted fields keep their values
+	disabled := false
+	if err := (&webhookEndpointRequest{Enabled: &disabled}).apply(e); err != nil || e.Enabled || e.Name != "CMDB" {
+		t.Errorf("partial update = %v, %+v", err, e)
+	}
+	bad := "ftp://cmdb.example.com"
+	if err := (&webhookEndpointRequest{URL: &bad}).apply(e); err == nil {
+		t.Error("expected an error for a non-HTTP URL")
+	}
+	if err := (&webhookEndpointRequest{Events: []string{"agent.deleted"}}).apply(&WebhookEndpoint{Name: "x", URL: url}); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
@@ -10,7 +10,7 @@ import { Tabs, TabsContent, TabsList, TabsTrigger } from "@/components/ui/tabs";
 import { 
     BarChart3, TrendingUp, AlertCircle, Activity, Clock, Globe, Server, 
     Radio, Download, ChevronDown, RefreshCw, Zap, ArrowUpRight, ArrowDownRight,
-    Wifi, LayoutDashboard, Filter, Users
+    Wifi, LayoutDashboard, Filter, Users, ArrowDownUp
 } from "lucide-react";
 import { 
     Line, LineChart, Bar, BarChart, ResponsiveContainer, Tooltip, 
@@ -32,6 +32,7 @@ const GeoAnalyticsContent = dynamic(
 );
 import { LiveMetricsProvider, useLiveMetrics } from "@/components/analytics/LiveMetricsProvider";
 import { TrafficDashboard } from "@/components/analytics/dashboards/TrafficDashboard";
+import { BandwidthPanel } from "@/components/analytics/BandwidthPanel";
 import { NginxCoreDashboard } from "@/components/analytics/dashboards/NginxCoreDashboard";
 import { useTheme } from "@/lib/theme-provider";
 import { getChartColorsForTheme, getHttpStatusColor } from "@/lib/chart-colors";
@@ -67,7 +68,11 @@ const initialData = {
         total_status_503: 0
     },
     insights: [],
-    gateway_metrics: []
+    gateway_metrics: [],
+    bandwidth: [],
+    bandwidth_by_agent: [],
+    bandwidth_by_uri: [],
+    egress_cost: null
 };
 
 // KPI Card Component - HIGH VISIBILITY VERSION
@@ -239,7 +244,11 @@ function AnalyticsView() {
                         connections_history: data.connections_history || [],
                         http_status_metrics: data.http_status_metrics || initialData.http_status_metrics,
                         insights: data.insights || [],
-                        gateway_metrics: data.gateway_metrics || []
+                        gateway_metrics: data.gateway_metrics || [],
+                        bandwidth: data.bandwidth || [],
+                        bandwidth_by_agent: data.bandwidth_by_agent || [],
+                        bandwidth_by_uri: data.bandwidth_by_uri || [],
+                        egress_cost: data.egress_cost || null
                     });
                 }
             } catch (error) {
@@ -276,7 +285,11 @@ function AnalyticsView() {
                 connections_history: liveData.connections_history || [],
                 http_status_metrics: liveData.http_status_metrics || initialData.http_status_metrics,
                 insights: liveData.insights || [],
-                gateway_metrics: liveData.gateway_metrics || []
+                gateway_metrics: liveData.gateway_metrics || [],
+                bandwidth: liveData.bandwidth || [],
+                bandwidth_by_agent: liveData.bandwidth_by_agent || [],
+                bandwidth_by_uri: liveData.bandwidth_by_uri || [],
+                egress_cost: liveData.egress_cost || null
             });
         }
     }, [isLive, liveData]);
@@ -392,7 +405,7 @@ function AnalyticsView() {
         requests: parseInt(e.requests),
         errors: parseInt(e.errors),
         p95: e.p95,
-        avgLatency: Math.round(e.p95 / 1.5),
+        p50: Math.round(e.p50 || 0),
         traffic: e.traffic
     }));
 
@@ -597,6 +610,7 @@ function AnalyticsView() {
                             { value: 'overview', label: 'Overview', icon: LayoutDashboard },
                             { value: 'visitors', label: 'Visitor Analytics', icon: Users },
                             { value: 'geo', label: 'Geo', icon: Globe },
+                            { value: 'bandwidth', label: 'Bandwidth', icon: ArrowDownUp },
                         ].map(tab => (
                             <TabsTrigger
                                 key={tab.value}
@@ -671,6 +685,17 @@ function AnalyticsView() {
                         <TabsContent value="geo" className="space-y-6 mt-6">
                             <GeoAnalyticsContent />
                         </TabsContent>
+
+                        <TabsContent value="bandwidth" className="space-y-6 mt-6">
+                            <BandwidthPanel
+                                bandwidth={analyticsData.bandwidth}
+                                byAgent={analyticsData.bandwidth_by_agent}
+                                byUri={analyticsData.bandwidth_by_uri}
+                                egressCost={analyticsData.egress_cost}
+                                summary={analyticsData.summary}
+                                formatTime={formatTimeForDisplay}
+                            />
+                        </TabsContent>
                     </>
                 )}
             </Tabs>
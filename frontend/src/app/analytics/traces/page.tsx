@@ -26,6 +26,8 @@ export default function TracesPage() {
     const [statusFilter, setStatusFilter] = useState("");
     const [methodFilter, setMethodFilter] = useState("");
     const [uriSearch, setUriSearch] = useState("");
+    const [minDuration, setMinDuration] = useState("");
+    const [sortBy, setSortBy] = useState("time");
     const [copiedId, setCopiedId] = useState<string | null>(null);
 
     const copyToClipboard = async (text: string) => {
@@ -41,7 +43,7 @@ export default function TracesPage() {
 
     useEffect(() => {
         fetchTraces();
-    }, [window, statusFilter, methodFilter, selectedProject, selectedEnvironment]);
+    }, [window, statusFilter, methodFilter, minDuration, sortBy, selectedProject, selectedEnvironment]);
 
     const fetchTraces = async () => {
         setLoading(true);
@@ -50,6 +52,8 @@ export default function TracesPage() {
             if (statusFilter) url += `&status=${statusFilter}`;
             if (methodFilter) url += `&method=${methodFilter}`;
             if (uriSearch) url += `&uri=${encodeURIComponent(uriSearch)}`;
+            if (Number(minDuration) > 0) url += `&min_duration_ms=${minDuration}`;
+            if (sortBy !== "time") url += `&sort=${sortBy}`;
 
             // Project/environment filtering
             if (selectedEnvironment) {
@@ -183,13 +187,44 @@ export default function TracesPage() {
                                 </SelectContent>
                             </Select>
 
+                            {/* Duration Filter */}
+                            <Select value={minDuration} onValueChange={setMinDuration}>
+                                <SelectTrigger
+                                    className="h-10 w-[140px] rounded-lg border-2 px-3 py-1 text-sm font-medium transition-all duration-200 cursor-pointer bg-slate-800/50 border-slate-600/50 text-white hover:border-slate-500 hover:bg-slate-800/70 focus:outline-none focus:border-blue-500 focus:ring-2 focus:ring-blue-500/20"
+                                >
+                                    <SelectValue placeholder="Any Duration" />
+                                </SelectTrigger>
+                                <SelectContent>
+                                    <SelectItem value="0">Any Duration</SelectItem>
+                                    <SelectItem value="100">&ge; 100 ms</SelectItem>
+                                    <SelectItem value="500">&ge; 500 ms</SelectItem>
+                                    <SelectItem value="1000">&ge; 1 s</SelectItem>
+                                    <SelectItem value="5000">&ge; 5 s</SelectItem>
+                                </SelectContent>
+                            </Select>
+
+                            {/* Sort */}
+                            <Select value={sortBy} onValueChange={setSortBy}>
+                                <SelectTrigger
+                                    className="h-10 w-[140px] rounded-lg border-2 px-3 py-1 text-sm font-medium transition-all duration-200 cursor-pointer bg-slate-800/50 border-slate-600/50 text-white hover:border-slate-500 hover:bg-slate-800/70 focus:outline-none focus:border-blue-500 focus:ring-2 focus:ring-blue-500/20"
+                                >
+                                    <SelectValue placeholder="Newest First" />
+                                </SelectTrigger>
+                                <SelectContent>
+                                    <SelectItem value="time">Newest First</SelectItem>
+                                    <SelectItem value="duration">Slowest First</SelectItem>
+                                </SelectContent>
+                            </Select>
+
                             {/* Clear All Filters */}
-                            {(statusFilter || methodFilter || uriSearch) && (
+                            {(statusFilter || methodFilter || uriSearch || Number(minDuration) > 0 || sortBy !== "time") && (
                                 <button
                                     onClick={() => {
                                         setStatusFilter("");
                                         setMethodFilter("");
                                         setUriSearch("");
+                                        setMinDuration("");
+                                        setSortBy("time");
                                     }}
                                     className="flex items-center gap-1 px-3 py-2 text-xs font-semibold rounded-lg transition-all duration-200
                                         text-red-400 hover:bg-red-500/10 hover:text-red-300 border border-red-500/30 hover:border-red-500/50"
@@ -209,13 +244,14 @@ export default function TracesPage() {
                                 <TableHead>URI</TableHead>
                                 <TableHead>Status</TableHead>
                                 <TableHead>Duration</TableHead>
+                                <TableHead>Spans</TableHead>
                                 <TableHead className="text-right">Action</TableHead>
                             </TableRow>
                         </TableHeader>
                         <TableBody>
                             {loading ? (
                                 <TableRow>
-                                    <TableCell colSpan={7} className="text-center py-16">
+                                    <TableCell colSpan={8} className="text-center py-16">
                                         <div className="flex flex-col items-center gap-3">
                                             <div className="h-8 w-8 animate-spin rounded-full border-4 border-slate-600 border-t-blue-500"></div>
                                             <span className="text-sm text-slate-400">Loading traces...</span>
@@ -224,7 +260,7 @@ export default function TracesPage() {
                                 </TableRow>
                             ) : traces.length === 0 ? (
                                 <TableRow>
-                                    <TableCell colSpan={7} className="text-center py-16">
+                                    <TableCell colSpan={8} className="text-center py-16">
                                         <div className="flex flex-col items-center gap-3">
                                             <FileSearch className="h-12 w-12 text-slate-500" />
                                             <div className="text-sm font-medium text-slate-300">No traces found</div>
@@ -242,7 +278,8 @@ export default function TracesPage() {
                                 // gRPC returns nano strings in snake_case
                                 const startTimeMs = parseInt(rootSpan.start_time) / 1000000;
                                 const endTimeMs = parseInt(rootSpan.end_time) / 1000000;
-                                const durationMs = endTimeMs - startTimeMs;
+                                // Whole trace duration when the gateway reports it, else the root span's
+                                const durationMs = trace.duration_ms || endTimeMs - startTimeMs;
 
                                 const status = rootSpan.attributes?.status || "200";
                                 const isError = parseInt(status) >= 400;
@@ -292,6 +329,9 @@ export default function TracesPage() {
                                         <TableCell className={durationMs > 500 ? "text-amber-500 font-medium" : ""}>
                                             {durationMs.toFixed(2)} ms
                                         </TableCell>
+                                        <TableCell className="text-muted-foreground">
+                                            {trace.span_count || trace.spans.length}
+                                        </TableCell>
                                         <TableCell className="text-right">
                                             <Link href={`/analytics/traces/${trace.request_id}`}>
                                                 <Button variant="ghost" size="sm">
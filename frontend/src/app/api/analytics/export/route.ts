@@ -1,5 +1,5 @@
 import { NextResponse } from 'next/server';
-import { getAgentServiceClient } from '@/lib/grpc-client';
+import { getAgentServiceClient, sessionMetadata } from '@/lib/grpc-client';
 
 export const dynamic = 'force-dynamic';
 
@@ -12,6 +12,7 @@ export async function GET(request: Request) {
     const projectId = searchParams.get('project_id');
 
     const client = getAgentServiceClient();
+    const metadata = await sessionMetadata();
 
     const analyticsRequest: any = {
         time_window: timeWindow,
@@ -27,7 +28,7 @@ export async function GET(request: Request) {
     }
 
     return new Promise<NextResponse>((resolve) => {
-        client.GetAnalytics(analyticsRequest, (err: any, response: any) => {
+        client.GetAnalytics(analyticsRequest, metadata, (err: any, response: any) => {
             if (err) {
                 console.error('gRPC GetAnalytics Error for export:', err);
                 return resolve(NextResponse.json({ error: err.message }, { status: 500 }));
@@ -1,6 +1,6 @@
 
 import { NextResponse } from 'next/server';
-import { getAgentServiceClient } from '@/lib/grpc-client';
+import { getAgentServiceClient, sessionMetadata } from '@/lib/grpc-client';
 
 export const dynamic = 'force-dynamic';
 
@@ -12,6 +12,7 @@ export async function GET(request: Request) {
     const timezone = searchParams.get('timezone') || 'UTC';
 
     const client = getAgentServiceClient();
+    const metadata = await sessionMetadata();
 
     const agentId = searchParams.get('agent_id');
     const environmentId = searchParams.get('environment_id');
@@ -39,8 +40,26 @@ export async function GET(request: Request) {
         analyticsRequest.time_window = timeWindow;
     }
 
+    // Compare mode: a baseline shifted by an offset, an absolute baseline range, and/or other agents
+    const compare: any = {};
+    if (searchParams.get('compare_offset')) compare.offset = searchParams.get('compare_offset');
+    if (searchParams.get('compare_from') && searchParams.get('compare_to')) {
+        compare.from_timestamp = parseInt(searchParams.get('compare_from')!);
+        compare.to_timestamp = parseInt(searchParams.get('compare_to')!);
+    }
+    if (searchParams.get('compare_environment_id')) {
+        compare.environment_id = searchParams.get('compare_environment_id');
+    } else if (searchParams.get('compare_project_id')) {
+        compare.project_id = searchParams.get('compare_project_id');
+    } else if (searchParams.get('compare_agent_id')) {
+        compare.agent_id = searchParams.get('compare_agent_id');
+    }
+    if (Object.keys(compare).length > 0) {
+        analyticsRequest.compare = compare;
+    }
+
     return new Promise<NextResponse>((resolve) => {
-        client.GetAnalytics(analyticsRequest, (err: any, response: any) => {
+        client.GetAnalytics(analyticsRequest, metadata, (err: any, response: any) => {
             if (err) {
                 console.error('gRPC GetAnalytics Error:', err);
                 // Return empty/mock data on error so the page doesn't crash
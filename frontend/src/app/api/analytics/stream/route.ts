@@ -1,4 +1,4 @@
-import { getAgentServiceClient } from '@/lib/grpc-client';
+import { getAgentServiceClient, sessionMetadata } from '@/lib/grpc-client';
 
 export const dynamic = 'force-dynamic';
 
@@ -10,10 +10,12 @@ export async function GET(req: Request) {
     const projectId = searchParams.get('project_id');
 
     const client = getAgentServiceClient();
+    const metadata = await sessionMetadata();
 
     // Build request with project/environment filter
     const analyticsRequest: any = {
-        time_window: timeWindow
+        time_window: timeWindow,
+        deltas: true, // applied by LiveMetricsProvider (lib/analytics-delta.ts)
     };
     
     if (environmentId) {
@@ -26,7 +28,7 @@ export async function GET(req: Request) {
 
     const stream = new ReadableStream({
         start(controller) {
-            const grpcStream = client.StreamAnalytics(analyticsRequest);
+            const grpcStream = client.StreamAnalytics(analyticsRequest, metadata);
 
             grpcStream.on('data', (data: any) => {
                 // Formatting according to SSE spec: data: <json>\n\n
@@ -0,0 +1,61 @@
+import { NextRequest, NextResponse } from "next/server";
+import { cookies } from "next/headers";
+
+import { getGatewayUrl } from '@/lib/gateway-url';
+
+const GATEWAY_URL = getGatewayUrl();
+
+// Daily ingest quota of the project
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const cookieStore = await cookies();
+    const sessionCookie = cookieStore.get("avika_session");
+
+    const response = await fetch(`${GATEWAY_URL}/api/projects/${id}/quota`, {
+      headers: {
+        Cookie: sessionCookie ? `avika_session=${sessionCookie.value}` : "",
+      },
+    });
+    const data = await response.json();
+    return NextResponse.json(data, { status: response.status });
+  } catch (error) {
+    console.error("Error fetching project quota:", error);
+    return NextResponse.json(
+      { error: "Internal server error" },
+      { status: 500 }
+    );
+  }
+}
+
+export async function PUT(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const cookieStore = await cookies();
+    const sessionCookie = cookieStore.get("avika_session");
+    const body = await request.json();
+
+    const response = await fetch(`${GATEWAY_URL}/api/projects/${id}/quota`, {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+        Cookie: sessionCookie ? `avika_session=${sessionCookie.value}` : "",
+      },
+      body: JSON.stringify(body),
+    });
+    const data = await response.json();
+    return NextResponse.json(data, { status: response.status });
+  } catch (error) {
+    console.error("Error updating project quota:", error);
+    return NextResponse.json(
+      { error: "Internal server error" },
+      { status: 500 }
+    );
+  }
+}
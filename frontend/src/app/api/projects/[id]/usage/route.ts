@@ -0,0 +1,36 @@
+import { NextRequest, NextResponse } from "next/server";
+import { cookies } from "next/headers";
+
+import { getGatewayUrl } from '@/lib/gateway-url';
+
+const GATEWAY_URL = getGatewayUrl();
+
+// Access log bytes the project stored and had dropped per day, against its quota
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const cookieStore = await cookies();
+    const sessionCookie = cookieStore.get("avika_session");
+    const query = request.nextUrl.searchParams.toString();
+
+    const response = await fetch(
+      `${GATEWAY_URL}/api/projects/${id}/usage${query ? `?${query}` : ""}`,
+      {
+        headers: {
+          Cookie: sessionCookie ? `avika_session=${sessionCookie.value}` : "",
+        },
+      }
+    );
+    const data = await response.json();
+    return NextResponse.json(data, { status: response.status });
+  } catch (error) {
+    console.error("Error fetching project usage:", error);
+    return NextResponse.json(
+      { error: "Internal server error" },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,37 @@
+import { NextRequest, NextResponse } from "next/server";
+import { getGatewayUrl } from "@/lib/gateway-url";
+import { normalizeServerId } from "@/lib/api";
+
+const GATEWAY_URL = getGatewayUrl();
+
+// On-the-fly NGINX binary upgrade (USR2, WINCH, QUIT) with rollback; can take minutes
+export async function POST(
+    request: NextRequest,
+    { params }: { params: Promise<{ id: string }> }
+) {
+    const { id: rawId } = await params;
+    const id = normalizeServerId(rawId);
+    try {
+        const sessionCookie = request.cookies.get("avika_session")?.value;
+        const body = await request.json();
+
+        const gatewayResponse = await fetch(`${GATEWAY_URL}/api/servers/${encodeURIComponent(id)}/nginx/upgrade`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json",
+                ...(sessionCookie ? { Cookie: `avika_session=${sessionCookie}` } : {})
+            },
+            body: JSON.stringify({
+                binary_path: body.binary_path,
+                expected_version: body.expected_version,
+                nginx_instance: body.nginx_instance,
+                timeout_sec: body.timeout_sec,
+            }),
+        });
+        const data = await gatewayResponse.json();
+        return NextResponse.json(data, { status: gatewayResponse.status });
+    } catch (error) {
+        console.error("Failed to upgrade NGINX binary", error);
+        return NextResponse.json({ error: "Failed to upgrade NGINX binary" }, { status: 500 });
+    }
+}
@@ -28,6 +28,9 @@ export async function GET(
         );
     }
 
+    // Hosts running several NGINX instances: ?nginx_instance=<instance ID> picks the config shown
+    const nginxInstanceId = new URL(request.url).searchParams.get('nginx_instance') || '';
+
     console.log(`GET /api/servers/${id} - extracting details`);
 
     return new Promise<NextResponse>((resolve) => {
@@ -51,7 +54,7 @@ export async function GET(
             }
 
             // Fetch config and certificates
-            client.GetConfig({ instance_id: id }, (configErr: any, config: any) => {
+            client.GetConfig({ instance_id: id, nginx_instance_id: nginxInstanceId }, (configErr: any, config: any) => {
                 client.ListCertificates({ instance_id: id }, (certErr: any, certs: any) => {
                     const normalizedAgent = {
                         ...(agent || {}),
@@ -65,6 +68,7 @@ export async function GET(
                         build_date: agent?.buildDate || agent?.build_date,
                         git_commit: agent?.gitCommit || agent?.git_commit,
                         git_branch: agent?.gitBranch || agent?.git_branch,
+                        nginx_instances: agent?.nginxInstances || agent?.nginx_instances || [],
                     };
                     resolve(NextResponse.json({
                         ...normalizedAgent,
@@ -85,29 +89,32 @@ export async function POST(
 ) {
     const { id: rawId } = await params;
     const id = normalizeServerId(rawId);
-    const { action, content, backup } = await request.json();
+    const { action, content, backup, nginx_instance } = await request.json();
+    // Commands target the agent's primary NGINX instance unless one is given
+    const nginxInstanceId = nginx_instance || '';
     const client = getAgentServiceClient();
     console.log(`POST /api/servers/${id} - action: ${action}`);
 
     return new Promise<NextResponse>((resolve) => {
         if (action === 'reload') {
-            client.ReloadNginx({ instance_id: id }, (err: any, response: any) => {
+            client.ReloadNginx({ instance_id: id, nginx_instance_id: nginxInstanceId }, (err: any, response: any) => {
                 if (err) return resolve(NextResponse.json({ error: err.message }, { status: 500 }));
                 resolve(NextResponse.json(response));
             });
         } else if (action === 'restart') {
-            client.RestartNginx({ instance_id: id }, (err: any, response: any) => {
+            client.RestartNginx({ instance_id: id, nginx_instance_id: nginxInstanceId }, (err: any, response: any) => {
                 if (err) return resolve(NextResponse.json({ success: false, error: err.message }, { status: 500 }));
                 resolve(NextResponse.json({ success: response?.success ?? true, error: response?.error }));
             });
         } else if (action === 'stop') {
-            client.StopNginx({ instance_id: id }, (err: any, response: any) => {
+            client.StopNginx({ instance_id: id, nginx_instance_id: nginxInstanceId }, (err: any, response: any) => {
                 if (err) return resolve(NextResponse.json({ success: false, error: err.message }, { status: 500 }));
                 resolve(NextResponse.json({ success: response?.success ?? true, error: response?.error }));
             });
         } else if (action === 'update_config') {
             client.UpdateConfig({
                 instance_id: id,
+                nginx_instance_id: nginxInstanceId,
                 new_content: content,
                 backup: backup || true
             }, (err: any, response: any) => {
@@ -0,0 +1,30 @@
+import { NextRequest, NextResponse } from "next/server";
+import { getGatewayUrl } from "@/lib/gateway-url";
+import { normalizeServerId } from "@/lib/api";
+
+const GATEWAY_URL = getGatewayUrl();
+
+// Service manager state, restart history and journal of the server's NGINX unit
+export async function GET(
+    request: NextRequest,
+    { params }: { params: Promise<{ id: string }> }
+) {
+    const { id: rawId } = await params;
+    const id = normalizeServerId(rawId);
+    try {
+        const sessionCookie = request.cookies.get("avika_session")?.value;
+        const query = request.nextUrl.searchParams.toString();
+        const gatewayResponse = await fetch(
+            `${GATEWAY_URL}/api/servers/${encodeURIComponent(id)}/service${query ? `?${query}` : ""}`,
+            {
+                method: "GET",
+                headers: sessionCookie ? { Cookie: `avika_session=${sessionCookie}` } : {},
+            }
+        );
+        const data = await gatewayResponse.json();
+        return NextResponse.json(data, { status: gatewayResponse.status });
+    } catch (error) {
+        console.error("Failed to fetch service status", error);
+        return NextResponse.json({ error: "Failed to fetch service status" }, { status: 500 });
+    }
+}
@@ -32,3 +32,24 @@ export async function POST(
         });
     });
 }
+
+export async function GET(
+    request: Request,
+    { params }: { params: Promise<{ id: string }> }
+) {
+    const { id: rawId } = await params;
+    const agentId = normalizeServerId(rawId);
+    const client = getAgentServiceClient();
+
+    return new Promise<NextResponse>((resolve) => {
+        client.GetUpdateStatus({ agent_id: agentId }, (err: any, response: any) => {
+            if (err) {
+                console.error('gRPC Error:', err);
+                return resolve(
+                    NextResponse.json({ error: err.details || 'Failed to get update status' }, { status: 500 })
+                );
+            }
+            resolve(NextResponse.json(response));
+        });
+    });
+}
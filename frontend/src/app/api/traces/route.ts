@@ -19,7 +19,10 @@ export async function GET(request: Request) {
         limit: limit,
         status_filter: searchParams.get('status') || '',
         method_filter: searchParams.get('method') || '',
-        uri_filter: searchParams.get('uri') || ''
+        uri_filter: searchParams.get('uri') || '',
+        min_duration_ms: parseFloat(searchParams.get('min_duration_ms') || '0') || 0,
+        max_duration_ms: parseFloat(searchParams.get('max_duration_ms') || '0') || 0,
+        sort_by: searchParams.get('sort') || ''
     };
     
     // Project/environment filtering takes precedence
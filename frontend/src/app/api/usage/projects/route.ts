@@ -0,0 +1,32 @@
+import { NextRequest, NextResponse } from "next/server";
+import { cookies } from "next/headers";
+
+import { getGatewayUrl } from '@/lib/gateway-url';
+
+const GATEWAY_URL = getGatewayUrl();
+
+// Every project's ingest today against its quota, heaviest first
+export async function GET(request: NextRequest) {
+  try {
+    const cookieStore = await cookies();
+    const sessionCookie = cookieStore.get("avika_session");
+    const query = request.nextUrl.searchParams.toString();
+
+    const response = await fetch(
+      `${GATEWAY_URL}/api/usage/projects${query ? `?${query}` : ""}`,
+      {
+        headers: {
+          Cookie: sessionCookie ? `avika_session=${sessionCookie.value}` : "",
+        },
+      }
+    );
+    const data = await response.json();
+    return NextResponse.json(data, { status: response.status });
+  } catch (error) {
+    console.error("Error fetching project usage:", error);
+    return NextResponse.json(
+      { error: "Internal server error" },
+      { status: 500 }
+    );
+  }
+}
@@ -322,7 +322,7 @@ function MonitoringPageContent() {
         requests: parseInt(e.requests),
         errors: parseInt(e.errors),
         p95: e.p95,
-        avgLatency: Math.round(e.p95 / 1.5),
+        p50: Math.round(e.p50 || 0),
         traffic: e.traffic
     })), [data?.top_endpoints]);
 
@@ -493,7 +493,7 @@ function MonitoringPageContent() {
                             value={`${Math.round(summary.avg_latency || 0)}ms`}
                             icon={<Timer className="h-4 w-4" />}
                             colorClass="text-amber-400"
-                            subValue="p50 response time"
+                            subValue="mean response time"
                         />
                         <MetricCard
                             title="Bandwidth"
@@ -743,7 +743,7 @@ function MonitoringPageContent() {
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('uri')}>URL Path</TableHead>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('requests')}>Requests</TableHead>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('traffic')}>Bandwidth</TableHead>
-                                        <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('avgLatency')}>Avg Latency</TableHead>
+                                        <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('p50')}>P50</TableHead>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('errors')}>Errors</TableHead>
                                     </TableRow>
                                 </TableHeader>
@@ -754,8 +754,8 @@ function MonitoringPageContent() {
                                             <TableCell style={{ color: "rgb(var(--theme-text-muted))" }}>{stat.requests.toLocaleString()}</TableCell>
                                             <TableCell style={{ color: "rgb(var(--theme-text-muted))" }}>{typeof stat.traffic === 'number' ? formatBandwidth(stat.traffic) : (stat.traffic ?? '—')}</TableCell>
                                             <TableCell>
-                                                <Badge className={stat.avgLatency > 150 ? "bg-amber-500/15 text-amber-400 border-amber-500/30" : "bg-emerald-500/15 text-emerald-400 border-emerald-500/30"}>
-                                                    {stat.avgLatency}ms
+                                                <Badge className={stat.p50 > 150 ? "bg-amber-500/15 text-amber-400 border-amber-500/30" : "bg-emerald-500/15 text-emerald-400 border-emerald-500/30"}>
+                                                    {stat.p50}ms
                                                 </Badge>
                                             </TableCell>
                                             <TableCell>
@@ -1117,6 +1117,7 @@ function MonitoringPageContent() {
                                             <Legend />
                                             <Line type="monotone" dataKey="cpu_usage" stroke={chartColors.cpu} strokeWidth={2} name="CPU %" dot={false} />
                                             <Line type="monotone" dataKey="memory_mb" stroke={chartColors.memory} strokeWidth={2} name="Memory MB" dot={false} />
+                                            <Line type="monotone" dataKey="load1" stroke={chartColors.info} strokeWidth={2} name="Host Load (1m)" dot={false} />
                                         </LineChart>
                                     </ResponsiveContainer>
                                 </div>
@@ -1205,7 +1206,7 @@ function MonitoringPageContent() {
                                 <TableHeader>
                                     <TableRow style={{ borderColor: "rgb(var(--theme-border))" }}>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('uri')}>Endpoint</TableHead>
-                                        <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('avgLatency')}>Avg Latency</TableHead>
+                                        <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('p50')}>P50</TableHead>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('p95')}>P95</TableHead>
                                         <TableHead className="cursor-pointer hover:opacity-90 rounded py-2" style={{ color: "rgb(var(--theme-text-muted))" }} onClick={() => handleSort('requests')}>Requests</TableHead>
                                     </TableRow>
@@ -1215,8 +1216,8 @@ function MonitoringPageContent() {
                                         <TableRow key={idx} style={{ borderColor: "rgb(var(--theme-border))" }}>
                                             <TableCell className="font-mono text-sm" style={{ color: "rgb(var(--theme-text))" }}>{endpoint.uri}</TableCell>
                                             <TableCell>
-                                                <Badge className={endpoint.avgLatency > 150 ? "bg-red-500/15 text-red-400" : endpoint.avgLatency > 100 ? "bg-amber-500/15 text-amber-400" : "bg-emerald-500/15 text-emerald-400"}>
-                                                    {endpoint.avgLatency}ms
+                                                <Badge className={endpoint.p50 > 150 ? "bg-red-500/15 text-red-400" : endpoint.p50 > 100 ? "bg-amber-500/15 text-amber-400" : "bg-emerald-500/15 text-emerald-400"}>
+                                                    {endpoint.p50}ms
                                                 </Badge>
                                             </TableCell>
                                             <TableCell style={{ color: "rgb(var(--theme-text-muted))" }}>{endpoint.p95}ms</TableCell>
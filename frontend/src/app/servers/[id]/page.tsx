@@ -65,6 +65,13 @@ const nginxMonarch = {
     }
 };
 
+function formatDiskBytes(bytes: number): string {
+    if (!bytes || bytes < 1) return "0 B";
+    const units = ["B", "KB", "MB", "GB", "TB", "PB"];
+    const i = Math.min(Math.floor(Math.log(bytes) / Math.log(1024)), units.length - 1);
+    return `${(bytes / Math.pow(1024, i)).toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
 export default function ServerDetailPage({ params }: { params: Promise<{ id: string }> }) {
     const { id: rawId } = use(params);
     const id = normalizeServerId(rawId);
@@ -96,6 +103,7 @@ export default function ServerDetailPage({ params }: { params: Promise<{ id: str
     const [logSearchMatchIndex, setLogSearchMatchIndex] = useState(-1);
     const logEventSourceRef = useRef<EventSource | null>(null);
     const [uptimeReports, setUptimeReports] = useState<any[]>([]);
+    const [diskUsage, setDiskUsage] = useState<{ disks: any[]; log_files: any[] }>({ disks: [], log_files: [] });
     const [analytics, setAnalytics] = useState<any>(null);
     const [isConnected, setIsConnected] = useState(false);
     const [isLoading, setIsLoading] = useState(true);
@@ -695,6 +703,23 @@ export default function ServerDetailPage({ params }: { params: Promise<{ id: str
         return () => clearInterval(interval);
     }, [id]);
 
+    useEffect(() => {
+        const fetchDisks = async () => {
+            try {
+                const res = await apiFetch(`/api/servers/${encodeURIComponent(id)}/disks`);
+                if (res.ok) {
+                    const data = await res.json();
+                    setDiskUsage({ disks: data.disks || [], log_files: data.log_files || [] });
+                }
+            } catch (err) {
+                console.error("Failed to fetch disk usage", err);
+            }
+        };
+        fetchDisks();
+        const interval = setInterval(fetchDisks, 60000);
+        return () => clearInterval(interval);
+    }, [id]);
+
     // Poll real-time stats when Logs tab is active (sliding-window from gateway)
     useEffect(() => {
         if (activeTab !== "logs") return;
@@ -1977,6 +2002,50 @@ export default function ServerDetailPage({ params }: { params: Promise<{ id: str
 
                 <TabsContent value="uptime">
                     <div className="space-y-4">
+                        <Card className="bg-neutral-900 border-neutral-800">
+                            <CardHeader>
+                                <CardTitle className="text-white">Disks</CardTitle>
+                                <CardDescription>Usage of each filesystem; the one holding NGINX logs is watched by the &quot;Log partition nearly full&quot; alert</CardDescription>
+                            </CardHeader>
+                            <CardContent>
+                                <div className="space-y-2">
+                                    {diskUsage.disks.length === 0 && <div className="text-neutral-500 italic">No disk data reported in the last 15 minutes...</div>}
+                                    {diskUsage.disks.map((disk) => (
+                                        <div key={disk.mount} className="p-3 rounded-lg bg-neutral-950 border border-neutral-800">
+                                            <div className="flex items-center justify-between">
+                                                <div>
+                                                    <div className="text-sm font-medium text-white font-mono">
+                                                        {disk.mount}
+                                                        {disk.nginx_logs && <Badge className="ml-2 bg-blue-500/10 text-blue-400 border-blue-500/20">nginx logs</Badge>}
+                                                    </div>
+                                                    <div className="text-xs text-neutral-500">{disk.device} • {disk.fstype} • inodes {disk.inodes_used_percent.toFixed(0)}%</div>
+                                                </div>
+                                                <div className="text-right">
+                                                    <div className={`text-sm font-bold ${disk.used_percent >= 90 ? "text-red-400" : disk.used_percent >= 80 ? "text-amber-400" : "text-white"}`}>{disk.used_percent.toFixed(1)}%</div>
+                                                    <div className="text-xs text-neutral-500">{formatDiskBytes(disk.used_bytes)} of {formatDiskBytes(disk.used_bytes + disk.available_bytes)}</div>
+                                                </div>
+                                            </div>
+                                            <div className="mt-2 h-1.5 w-full rounded bg-neutral-800">
+                                                <div className={`h-1.5 rounded ${disk.used_percent >= 90 ? "bg-red-500" : disk.used_percent >= 80 ? "bg-amber-500" : "bg-blue-500"}`} style={{ width: `${Math.min(disk.used_percent, 100)}%` }} />
+                                            </div>
+                                            {disk.log_growth_bytes_per_sec > 0 && (
+                                                <div className="mt-2 text-xs text-neutral-400">
+                                                    Logs grow {formatDiskBytes(disk.log_growth_bytes_per_sec * 3600)}/h
+                                                    {disk.hours_until_full != null && <> • full in about {disk.hours_until_full < 48 ? `${disk.hours_until_full.toFixed(1)} h` : `${(disk.hours_until_full / 24).toFixed(0)} days`}</>}
+                                                </div>
+                                            )}
+                                        </div>
+                                    ))}
+                                    {diskUsage.log_files.map((file) => (
+                                        <div key={file.path} className="flex items-center justify-between px-3 py-2 text-xs">
+                                            <span className="font-mono text-neutral-400">{file.path}</span>
+                                            <span className="text-neutral-300">{formatDiskBytes(file.size_bytes)} • +{formatDiskBytes(file.growth_bytes_per_sec * 3600)}/h</span>
+                                        </div>
+                                    ))}
+                                </div>
+                            </CardContent>
+                        </Card>
+
                         <Card className="bg-neutral-900 border-neutral-800">
                             <CardHeader>
                                 <CardTitle className="text-white">Latency Trend (ms)</CardTitle>
@@ -28,11 +28,33 @@ import { toast } from "sonner";
 import { useProject, Project, Environment } from "@/lib/project-context";
 import { useRouter } from "next/navigation";
 
+interface ProjectUsage {
+  project_id: string;
+  quota: { soft_bytes_per_day: number; hard_bytes_per_day: number; hard_action: string; sample_percent: number } | null;
+  today: { stored_bytes: number; dropped_bytes: number };
+  status: "ok" | "soft_exceeded" | "hard_exceeded";
+  quota_percent?: number;
+}
+
+function formatBytes(b: number): string {
+  if (b >= 1073741824) return (b / 1073741824).toFixed(2) + " GB";
+  if (b >= 1048576) return (b / 1048576).toFixed(2) + " MB";
+  if (b >= 1024) return (b / 1024).toFixed(2) + " KB";
+  return b + " B";
+}
+
+const usageColors: Record<ProjectUsage["status"], string> = {
+  ok: "bg-emerald-500",
+  soft_exceeded: "bg-amber-500",
+  hard_exceeded: "bg-red-500",
+};
+
 export default function ProjectsPage() {
   const router = useRouter();
   const { isSuperAdmin, refreshProjects } = useProject();
   const [projects, setProjects] = useState<Project[]>([]);
   const [environments, setEnvironments] = useState<Record<string, Environment[]>>({});
+  const [usage, setUsage] = useState<Record<string, ProjectUsage>>({});
   const [isLoading, setIsLoading] = useState(true);
   const [searchQuery, setSearchQuery] = useState("");
   const [isCreateDialogOpen, setIsCreateDialogOpen] = useState(false);
@@ -63,6 +85,21 @@ export default function ProjectsPage() {
         })
       );
       setEnvironments(envsMap);
+
+      // Ingest today against each project's quota
+      try {
+        const usageResponse = await fetch("/api/usage/projects?days=1", { credentials: "include" });
+        if (usageResponse.ok) {
+          const usageData = await usageResponse.json();
+          const usageMap: Record<string, ProjectUsage> = {};
+          (usageData.projects || []).forEach((u: ProjectUsage) => {
+            usageMap[u.project_id] = u;
+          });
+          setUsage(usageMap);
+        }
+      } catch {
+        setUsage({});
+      }
     } catch (error) {
       console.error("Failed to fetch projects:", error);
       toast.error("Failed to load projects");
@@ -291,6 +328,31 @@ export default function ProjectsPage() {
                       </div>
                     ))}
                   </div>
+                  {usage[project.id] && (
+                    <div className="mb-3 space-y-1">
+                      <div className="flex items-center justify-between text-xs text-muted-foreground">
+                        <span>
+                          Ingested today: {formatBytes(usage[project.id].today.stored_bytes)}
+                          {usage[project.id].quota?.hard_bytes_per_day
+                            ? ` of ${formatBytes(usage[project.id].quota!.hard_bytes_per_day)}`
+                            : ""}
+                        </span>
+                        {usage[project.id].today.dropped_bytes > 0 && (
+                          <span className="text-red-500">
+                            {formatBytes(usage[project.id].today.dropped_bytes)} dropped
+                          </span>
+                        )}
+                      </div>
+                      {usage[project.id].quota && (
+                        <div className="h-1.5 w-full rounded-full bg-muted overflow-hidden">
+                          <div
+                            className={`h-full ${usageColors[usage[project.id].status]}`}
+                            style={{ width: `${Math.min(usage[project.id].quota_percent || 0, 100)}%` }}
+                          />
+                        </div>
+                      )}
+                    </div>
+                  )}
                   <div className="flex items-center justify-between">
                     <Badge variant="secondary">
                       <Layers className="mr-1 h-3 w-3" />
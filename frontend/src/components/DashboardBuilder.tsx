@@ -26,7 +26,7 @@ const ALL_WIDGETS: DashboardWidget[] = [
     { id: "total_requests", label: "Total Requests", icon: Globe, description: "Total HTTP request count for the time window.", pinned: true },
     { id: "request_rate", label: "Request Rate", icon: Activity, description: "Average requests per second.", pinned: true },
     { id: "error_rate", label: "Error Rate", icon: AlertTriangle, description: "Percentage of 5xx error responses.", pinned: true },
-    { id: "avg_latency", label: "Avg Latency", icon: Clock, description: "P50 average response time in ms.", pinned: true },
+    { id: "avg_latency", label: "Avg Latency", icon: Clock, description: "Mean response time in ms.", pinned: true },
     { id: "agent_count", label: "Active Agents", icon: Server, description: "Number of connected Avika agents.", pinned: false },
 ];
 
@@ -563,6 +563,14 @@ export function AgentFleetTable({
                                                 <span className={`w-1.5 h-1.5 rounded-full mr-2 ${statusInfo.dotColor}`} />
                                                 {statusInfo.label}
                                             </Badge>
+                                            {instance.resource_status?.degraded && (
+                                                <Badge
+                                                    className="ml-1 bg-amber-500/10 text-amber-500 border-amber-500/20 text-[10px]"
+                                                    title={`Over its resource limits: ${instance.resource_status.reason}. Log collection is paused and collection slowed down.`}
+                                                >
+                                                    Degraded
+                                                </Badge>
+                                            )}
                                         </TableCell>
                                         <TableCell className="text-xs" style={{ color: 'rgb(var(--theme-text-muted))' }}>
                                             {instance.last_seen ? formatLastSeen(instance.last_seen) : "N/A"}
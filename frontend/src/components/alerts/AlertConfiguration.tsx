@@ -193,6 +193,20 @@ export function AlertConfiguration() {
                                             <SelectItem value="latency">P95 Latency (ms)</SelectItem>
                                             <SelectItem value="connections">Active Connections</SelectItem>
                                             <SelectItem value="config_drift">Config Drift (agents)</SelectItem>
+                                            <SelectItem value="worker_restarts">NGINX Worker Restarts</SelectItem>
+                                            <SelectItem value="worker_crashes">NGINX Worker Crashes</SelectItem>
+                                            <SelectItem value="service_restarts">NGINX Service Restarts</SelectItem>
+                                            <SelectItem value="service_failures">NGINX Service Failures</SelectItem>
+                                            <SelectItem value="restart_loop">NGINX Restart Loop (agents)</SelectItem>
+                                            <SelectItem value="disk_usage">Disk Usage (%)</SelectItem>
+                                            <SelectItem value="log_disk_usage">Log Partition Usage (%)</SelectItem>
+                                            <SelectItem value="fd_usage">File Descriptor Usage (%)</SelectItem>
+                                            <SelectItem value="nginx_fd_usage">NGINX Open Files (% of limit)</SelectItem>
+                                            <SelectItem value="conntrack_usage">Conntrack Table Usage (%)</SelectItem>
+                                            <SelectItem value="gateway_clickhouse_insert_failures">Gateway: ClickHouse Insert Failures</SelectItem>
+                                            <SelectItem value="gateway_kafka_consumer_stall">Gateway: Kafka Consumer Stall (s)</SelectItem>
+                                            <SelectItem value="gateway_queue_saturation">Gateway: Write Queue Saturation (%)</SelectItem>
+                                            <SelectItem value="gateway_postgres_down">Gateway: PostgreSQL Down</SelectItem>
                                         </SelectContent>
                                     </Select>
                                 </div>
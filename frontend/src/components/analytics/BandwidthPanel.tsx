@@ -0,0 +1,144 @@
+"use client";
+
+import { Card, CardContent, CardHeader, CardTitle, CardDescription } from "@/components/ui/card";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { Area, AreaChart, CartesianGrid, Legend, ResponsiveContainer, Tooltip, XAxis, YAxis } from "recharts";
+import { useTheme } from "@/lib/theme-provider";
+import { getChartColorsForTheme } from "@/lib/chart-colors";
+
+interface BandwidthStat {
+    key: string;
+    requests: number | string;
+    bytes_in: number | string;
+    bytes_out: number | string;
+    egress_cost?: number;
+}
+
+interface EgressCost {
+    price_per_gb: number;
+    currency: string;
+    total: number;
+}
+
+interface BandwidthPanelProps {
+    bandwidth: { time: string; bytes_in: number | string; bytes_out: number | string }[];
+    byAgent: BandwidthStat[];
+    byUri: BandwidthStat[];
+    egressCost?: EgressCost | null;
+    summary: { total_bandwidth?: number | string; total_bytes_in?: number | string };
+    formatTime: (time: string) => string;
+}
+
+const formatBytes = (value: number | string) => {
+    const bytes = Number(value) || 0;
+    if (bytes === 0) return "0 B";
+    const k = 1024;
+    const sizes = ["B", "KB", "MB", "GB", "TB", "PB"];
+    const i = Math.min(Math.floor(Math.log(bytes) / Math.log(k)), sizes.length - 1);
+    return parseFloat((bytes / Math.pow(k, i)).toFixed(1)) + " " + sizes[i];
+};
+
+// Bandwidth received and sent over the window, and the agents and paths driving egress (and its
+// cost when the gateway has an egress price configured)
+export function BandwidthPanel({ bandwidth, byAgent, byUri, egressCost, summary, formatTime }: BandwidthPanelProps) {
+    const { theme } = useTheme();
+    const chartColors = getChartColorsForTheme(theme);
+
+    const formatCost = (cost?: number) =>
+        new Intl.NumberFormat(undefined, { style: "currency", currency: egressCost?.currency || "USD" }).format(cost || 0);
+
+    const chartData = bandwidth.map(p => ({
+        time: formatTime(p.time),
+        in: Number(p.bytes_in) || 0,
+        out: Number(p.bytes_out) || 0,
+    }));
+
+    const kpis = [
+        { label: "Bytes received", value: formatBytes(summary.total_bytes_in || 0) },
+        { label: "Bytes sent", value: formatBytes(summary.total_bandwidth || 0) },
+        egressCost
+            ? { label: `Egress cost (${formatCost(egressCost.price_per_gb)}/GB)`, value: formatCost(egressCost.total) }
+            : { label: "Egress cost", value: "No price configured" },
+    ];
+
+    const statTable = (title: string, keyLabel: string, stats: BandwidthStat[]) => (
+        <Card style={{ background: 'rgb(var(--theme-surface))', borderColor: 'rgb(var(--theme-border))' }}>
+            <CardHeader>
+                <CardTitle className="text-sm font-medium" style={{ color: 'rgb(var(--theme-text))' }}>{title}</CardTitle>
+            </CardHeader>
+            <CardContent>
+                <Table>
+                    <TableHeader>
+                        <TableRow>
+                            <TableHead>{keyLabel}</TableHead>
+                            <TableHead className="text-right">Requests</TableHead>
+                            <TableHead className="text-right">In</TableHead>
+                            <TableHead className="text-right">Out</TableHead>
+                            {egressCost && <TableHead className="text-right">Cost</TableHead>}
+                        </TableRow>
+                    </TableHeader>
+                    <TableBody>
+                        {stats.length === 0 ? (
+                            <TableRow>
+                                <TableCell colSpan={egressCost ? 5 : 4} className="text-center" style={{ color: 'rgb(var(--theme-text-muted))' }}>
+                                    No traffic in this window
+                                </TableCell>
+                            </TableRow>
+                        ) : stats.map(s => (
+                            <TableRow key={s.key}>
+                                <TableCell className="font-mono text-xs max-w-[280px] truncate" title={s.key}>{s.key || "(empty)"}</TableCell>
+                                <TableCell className="text-right">{Number(s.requests).toLocaleString()}</TableCell>
+                                <TableCell className="text-right">{formatBytes(s.bytes_in)}</TableCell>
+                                <TableCell className="text-right">{formatBytes(s.bytes_out)}</TableCell>
+                                {egressCost && <TableCell className="text-right">{formatCost(s.egress_cost)}</TableCell>}
+                            </TableRow>
+                        ))}
+                    </TableBody>
+                </Table>
+            </CardContent>
+        </Card>
+    );
+
+    return (
+        <div className="space-y-6">
+            <div className="grid gap-4 md:grid-cols-3">
+                {kpis.map(kpi => (
+                    <Card key={kpi.label} style={{ background: 'rgb(var(--theme-surface))', borderColor: 'rgb(var(--theme-border))' }}>
+                        <CardContent className="pt-6">
+                            <p className="text-sm" style={{ color: 'rgb(var(--theme-text-muted))' }}>{kpi.label}</p>
+                            <p className="text-2xl font-bold mt-1" style={{ color: 'rgb(var(--theme-text))' }}>{kpi.value}</p>
+                        </CardContent>
+                    </Card>
+                ))}
+            </div>
+
+            <Card style={{ background: 'rgb(var(--theme-surface))', borderColor: 'rgb(var(--theme-border))' }}>
+                <CardHeader>
+                    <CardTitle className="text-sm font-medium" style={{ color: 'rgb(var(--theme-text))' }}>Bandwidth</CardTitle>
+                    <CardDescription>Request bytes received and response bytes sent</CardDescription>
+                </CardHeader>
+                <CardContent className="h-[300px]">
+                    <ResponsiveContainer width="100%" height="100%" minWidth={0} minHeight={0}>
+                        <AreaChart data={chartData}>
+                            <CartesianGrid strokeDasharray="3 3" vertical={false} stroke={chartColors.grid} />
+                            <XAxis dataKey="time" stroke={chartColors.axis} fontSize={12} tickLine={false} axisLine={false} />
+                            <YAxis stroke={chartColors.axis} fontSize={12} tickLine={false} axisLine={false} tickFormatter={formatBytes} />
+                            <Tooltip
+                                formatter={(value: number) => formatBytes(value)}
+                                contentStyle={{ backgroundColor: chartColors.tooltipBg, borderColor: chartColors.tooltipBorder, color: chartColors.tooltipText, borderRadius: '8px' }}
+                            />
+                            <Legend />
+                            <Area type="monotone" dataKey="out" name="Sent" stroke="#3b82f6" fill="#3b82f6" fillOpacity={0.2} />
+                            <Area type="monotone" dataKey="in" name="Received" stroke="#10b981" fill="#10b981" fillOpacity={0.2} />
+                        </AreaChart>
+                    </ResponsiveContainer>
+                </CardContent>
+            </Card>
+
+            <div className="grid gap-4 lg:grid-cols-2">
+                {statTable("Egress by agent", "Agent", byAgent)}
+                {statTable("Egress by path", "Path", byUri)}
+            </div>
+        </div>
+    );
+}
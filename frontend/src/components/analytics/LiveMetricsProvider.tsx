@@ -1,6 +1,7 @@
 "use client";
 
 import React, { createContext, useContext, useEffect, useState, ReactNode } from 'react';
+import { applyAnalyticsMessage } from '@/lib/analytics-delta';
 
 interface LiveMetricsContextType {
     data: any;
@@ -44,7 +45,8 @@ export function LiveMetricsProvider({
         eventSource.onmessage = (event) => {
             try {
                 const parsedData = JSON.parse(event.data);
-                setData(parsedData);
+                // The stream sends changes between periodic full snapshots
+                setData((prev: any) => applyAnalyticsMessage(prev, parsedData));
             } catch (err) {
                 console.error('Failed to parse live data:', err);
             }
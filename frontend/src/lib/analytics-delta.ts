@@ -0,0 +1,39 @@
+/**
+ * Applies StreamAnalytics messages requested with `deltas: true` (see AnalyticsDelta in
+ * agent.proto). A resync replaces the state; a delta replaces the fields it names and patches
+ * the time series it lists. Messages without a delta are full snapshots.
+ */
+
+type Analytics = Record<string, any>;
+
+interface SeriesPatch {
+  field: string;
+  drop: number;
+  keep: number;
+}
+
+interface AnalyticsDelta {
+  resync?: boolean;
+  replaced?: string[];
+  series?: SeriesPatch[];
+}
+
+export function applyAnalyticsMessage(prev: Analytics | null, msg: Analytics): Analytics {
+  const delta: AnalyticsDelta | null | undefined = msg.delta;
+  if (!delta || delta.resync || !prev) {
+    return msg;
+  }
+  const next: Analytics = { ...prev };
+  for (const field of delta.replaced ?? []) {
+    next[field] = msg[field];
+  }
+  for (const patch of delta.series ?? []) {
+    const old: unknown[] = prev[patch.field] ?? [];
+    next[patch.field] = [
+      ...old.slice(patch.drop, patch.drop + patch.keep),
+      ...(msg[patch.field] ?? []),
+    ];
+  }
+  next.delta = delta;
+  return next;
+}
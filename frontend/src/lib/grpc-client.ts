@@ -66,3 +66,15 @@ export const getAgentServiceClient = () => {
     }
     return clientInstance;
 };
+
+// sessionMetadata forwards the user's session to the gateway, which then applies their RBAC
+// visibility (analytics are limited to the agents of their projects)
+export const sessionMetadata = async (): Promise<grpc.Metadata> => {
+    const { cookies } = await import('next/headers');
+    const metadata = new grpc.Metadata();
+    const session = (await cookies()).get('avika_session');
+    if (session?.value) {
+        metadata.set('authorization', `Bearer ${session.value}`);
+    }
+    return metadata;
+};
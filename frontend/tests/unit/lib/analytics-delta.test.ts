@@ -0,0 +1,36 @@
+import { describe, it, expect } from 'vitest';
+import { applyAnalyticsMessage } from '@/lib/analytics-delta';
+
+const point = (time: string, requests: number) => ({ time, requests, errors: 0 });
+
+describe('applyAnalyticsMessage', () => {
+    const snapshot = {
+        request_rate: [point('10:00', 100), point('10:01', 101), point('10:02', 102)],
+        summary: { total_requests: '303' },
+        top_endpoints: [{ uri: '/', requests: '300' }],
+        delta: { resync: true, replaced: [], series: [] },
+    };
+
+    it('uses snapshots and resyncs as they are', () => {
+        expect(applyAnalyticsMessage(null, snapshot)).toBe(snapshot);
+        const plain = { request_rate: [] };
+        expect(applyAnalyticsMessage(snapshot, plain)).toBe(plain);
+    });
+
+    it('patches series and replaces only the named fields', () => {
+        const msg = {
+            request_rate: [point('10:02', 150), point('10:03', 7)],
+            summary: { total_requests: '358' },
+            top_endpoints: [],
+            delta: {
+                resync: false,
+                replaced: ['summary'],
+                series: [{ field: 'request_rate', drop: 1, keep: 1 }],
+            },
+        };
+        const next = applyAnalyticsMessage(snapshot, msg);
+        expect(next.request_rate).toEqual([point('10:01', 101), point('10:02', 150), point('10:03', 7)]);
+        expect(next.summary.total_requests).toBe('358');
+        expect(next.top_endpoints).toBe(snapshot.top_endpoints);
+    });
+});
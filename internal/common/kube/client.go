@@ -0,0 +1,193 @@
+// Package kube is a minimal Kubernetes API client for reading pod metadata from inside a cluster,
+// used by the agent to describe its pod and by the gateway to confirm pod deletion.
+package kube
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceAccountDir holds the token, CA bundle and namespace mounted into every pod
+const ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ErrNotFound is returned when the requested object does not exist
+var ErrNotFound = errors.New("kubernetes object not found")
+
+// Client provides read access to the Kubernetes API
+type Client struct {
+	host   string
+	token  string
+	client *http.Client
+}
+
+// Config holds Kubernetes client configuration
+type Config struct {
+	Host    string // API server URL, e.g. https://10.96.0.1:443
+	Token   string
+	CAFile  string // PEM bundle to verify the API server; system roots when empty
+	Timeout time.Duration
+}
+
+// OwnerReference points to the controller of an object
+type OwnerReference struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller *bool  `json:"controller,omitempty"`
+}
+
+// ObjectMeta is the subset of object metadata the clients need
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	Labels          map[string]string `json:"labels"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences"`
+}
+
+// Pod is the subset of a pod the clients need
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+}
+
+// NewClient creates a new Kubernetes client
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("kubernetes API host is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		host:   strings.TrimRight(cfg.Host, "/"),
+		token:  strings.TrimSpace(cfg.Token),
+		client: &http.Client{Timeout: cfg.Timeout, Transport: transport},
+	}, nil
+}
+
+// NewInClusterClient creates a client from the pod's service account and the
+// KUBERNETES_SERVICE_HOST/PORT environment, failing outside a cluster
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+	token, err := os.ReadFile(ServiceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	return NewClient(Config{
+		Host:   "https://" + net.JoinHostPort(host, port),
+		Token:  string(token),
+		CAFile: ServiceAccountDir + "/ca.crt",
+	})
+}
+
+// GetPod fetches a pod, returning ErrNotFound when it does not exist
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (*Pod, error) {
+	var pod Pod
+	if err := c.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", url.PathEscape(namespace), url.PathEscape(name)), &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// GetReplicaSet fetches the metadata of a ReplicaSet, returning ErrNotFound when it does not exist
+func (c *Client) GetReplicaSet(ctx context.Context, namespace, name string) (*ObjectMeta, error) {
+	var rs struct {
+		Metadata ObjectMeta `json:"metadata"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/replicasets/%s", url.PathEscape(namespace), url.PathEscape(name)), &rs); err != nil {
+		return nil, err
+	}
+	return &rs.Metadata, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.host+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("kubernetes API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// ControllerOf returns the controlling owner of an object, if any
+func ControllerOf(meta ObjectMeta) *OwnerReference {
+	for i, ref := range meta.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return &meta.OwnerReferences[i]
+		}
+	}
+	return nil
+}
+
+// ParseDownwardLabels parses a Downward API labels file: one key="value" per line, values quoted as Go strings
+func ParseDownwardLabels(r io.Reader) (map[string]string, error) {
+	labels := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label line %q", line)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for label %s: %w", key, err)
+		}
+		labels[key] = value
+	}
+	return labels, scanner.Err()
+}
@@ -0,0 +1,61 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetPod(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fixture" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/web/pods/nginx-7d9f-abcde":
+			w.Write([]byte(`{"metadata":{"name":"nginx-7d9f-abcde","namespace":"web","uid":"u1",
+				"labels":{"app":"nginx"},
+				"ownerReferences":[{"kind":"ReplicaSet","name":"nginx-7d9f","uid":"rs1","controller":true}]},
+				"spec":{"nodeName":"node-1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(Config{Host: ts.URL, Token: "fixture\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, err := client.GetPod(context.Background(), "web", "nginx-7d9f-abcde")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Metadata.UID != "u1" || pod.Spec.NodeName != "node-1" || pod.Metadata.Labels["app"] != "nginx" {
+		t.Errorf("unexpected pod: %+v", pod)
+	}
+	if owner := ControllerOf(pod.Metadata); owner == nil || owner.Kind != "ReplicaSet" || owner.Name != "nginx-7d9f" {
+		t.Errorf("controller = %+v", owner)
+	}
+
+	if _, err := client.GetPod(context.Background(), "web", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing pod error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseDownwardLabels(t *testing.T) {
+	labels, err := ParseDownwardLabels(strings.NewReader("app=\"nginx\"\npod-template-hash=\"7d9f\"\n\nnote=\"a \\\"quoted\\\" value\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 3 || labels["app"] != "nginx" || labels["note"] != `a "quoted" value` {
+		t.Errorf("labels = %v", labels)
+	}
+	if _, err := ParseDownwardLabels(strings.NewReader("app=nginx")); err == nil {
+		t.Error("unquoted value should be rejected")
+	}
+}
@@ -0,0 +1,240 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Components log through loggers of NewLogger. Each has a level of its own, set at runtime with
+// SetLevel, or follows the default level of Setup.
+
+// output is where every logger writes; Setup points it at the configured writer
+var output = &switchWriter{}
+
+// switchWriter forwards writes to a writer that can be replaced while loggers use it
+type switchWriter struct {
+	mu sync.RWMutex
+	w  io.Writer
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.w == nil {
+		return os.Stdout.Write(p)
+	}
+	return s.w.Write(p)
+}
+
+// fields are added to every event
+type fields struct {
+	service, version string
+}
+
+var serviceFields atomic.Pointer[fields]
+
+// defaultLevel is the level of components without one of their own
+var defaultLevel atomic.Int32
+
+type component struct {
+	level      atomic.Int32
+	overridden atomic.Bool
+}
+
+var (
+	componentsMu sync.Mutex
+	components   = map[string]*component{}
+)
+
+func init() {
+	defaultLevel.Store(int32(zerolog.InfoLevel))
+}
+
+func register(name string) *component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	c, ok := components[name]
+	if !ok {
+		c = &component{}
+		components[name] = c
+	}
+	return c
+}
+
+func (c *component) enabled(level zerolog.Level) bool {
+	min := zerolog.Level(defaultLevel.Load())
+	if c != nil && c.overridden.Load() {
+		min = zerolog.Level(c.level.Load())
+	}
+	return level >= min || level == zerolog.NoLevel
+}
+
+// componentHook drops the events below the level of their component and adds the service fields
+type componentHook struct {
+	c *component
+}
+
+func (h componentHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if !h.c.enabled(level) {
+		e.Discard()
+		return
+	}
+	if f := serviceFields.Load(); f != nil {
+		if f.service != "" {
+			e.Str("service", f.service)
+		}
+		if f.version != "" {
+			e.Str("version", f.version)
+		}
+	}
+}
+
+// ParseLevel parses debug, info, warn (or warning), error and trace
+func ParseLevel(s string) (zerolog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return zerolog.TraceLevel, nil
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "info":
+		return zerolog.InfoLevel, nil
+	case "warn", "warning":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	}
+	return zerolog.NoLevel, fmt.Errorf("unknown log level %q", s)
+}
+
+// SetLevel sets the level of a component. An empty component sets the default level; an empty
+// level makes the component follow the default again.
+func SetLevel(name, level string) error {
+	if name == "" {
+		l, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		defaultLevel.Store(int32(l))
+		return nil
+	}
+
+	componentsMu.Lock()
+	c, ok := components[name]
+	componentsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown log component %q", name)
+	}
+	if level == "" {
+		c.overridden.Store(false)
+		return nil
+	}
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	c.level.Store(int32(l))
+	c.overridden.Store(true)
+	return nil
+}
+
+// ComponentLevel is the level a component logs at
+type ComponentLevel struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+	// Overridden is set when the component has a level of its own instead of the default
+	Overridden bool `json:"overridden"`
+}
+
+// Levels returns the default level and the level of every component, by name
+func Levels() (string, []ComponentLevel) {
+	def := zerolog.Level(defaultLevel.Load())
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	levels := make([]ComponentLevel, 0, len(components))
+	for name, c := range components {
+		cl := ComponentLevel{Component: name, Level: def.String()}
+		if c.overridden.Load() {
+			cl.Level, cl.Overridden = zerolog.Level(c.level.Load()).String(), true
+		}
+		levels = append(levels, cl)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Component < levels[j].Component })
+	return def.String(), levels
+}
+
+// Sampled returns a logger that writes burst events per period and drops the rest, for messages
+// logged on every request or message
+func Sampled(logger zerolog.Logger, burst uint32, period time.Duration) zerolog.Logger {
+	return logger.Sample(&zerolog.BurstSampler{Burst: burst, Period: period})
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying the ID of the request it serves
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "" when it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Ctx returns logger with the request ID of ctx, so every line logged for a request carries it
+func Ctx(ctx context.Context, logger zerolog.Logger) *zerolog.Logger {
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With().Str("request_id", id).Logger()
+	}
+	return &logger
+}
+
+// RedirectStdLog sends what is still written through the standard library's log package, e.g. by
+// dependencies, to logger: one event per line, at info unless the line starts with a level
+func RedirectStdLog(logger zerolog.Logger) {
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{logger: logger})
+}
+
+type stdLogWriter struct {
+	logger zerolog.Logger
+}
+
+func (w stdLogWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\n"))
+	level := zerolog.InfoLevel
+	upper := strings.ToUpper(msg)
+	for _, prefix := range []struct {
+		text  string
+		level zerolog.Level
+	}{
+		{"[DEBUG]", zerolog.DebugLevel}, {"DEBUG:", zerolog.DebugLevel},
+		{"[WARN]", zerolog.WarnLevel}, {"WARN:", zerolog.WarnLevel}, {"WARNING:", zerolog.WarnLevel},
+		{"[ERROR]", zerolog.ErrorLevel}, {"ERROR:", zerolog.ErrorLevel},
+	} {
+		if strings.HasPrefix(upper, prefix.text) {
+			level = prefix.level
+			msg = strings.TrimSpace(msg[len(prefix.text):])
+			break
+		}
+	}
+	w.logger.WithLevel(level).Msg(msg)
+	return len(p), nil
+}
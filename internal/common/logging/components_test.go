@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupBuffer points every logger at a buffer, at the given default level
+func setupBuffer(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	Setup(&Config{Level: level, Format: "json", Output: &buf, TimeFormat: time.RFC3339, Service: "test"})
+	return &buf
+}
+
+func lines(buf *bytes.Buffer) []map[string]any {
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestComponentLevels(t *testing.T) {
+	// Created before Setup, like package-level loggers
+	db := NewLogger("db-test")
+	api := NewLogger("api-test")
+	buf := setupBuffer(t, "info")
+
+	db.Debug().Msg("hidden")
+	db.Info().Msg("shown")
+	if got := lines(buf); len(got) != 1 || got[0]["message"] != "shown" || got[0]["component"] != "db-test" || got[0]["service"] != "test" {
+		t.Fatalf("default level: %v", got)
+	}
+
+	buf.Reset()
+	if err := SetLevel("db-test", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	db.Debug().Msg("db debug")
+	api.Debug().Msg("api debug")
+	if got := lines(buf); len(got) != 1 || got[0]["message"] != "db debug" {
+		t.Fatalf("component level: %v", got)
+	}
+
+	_, levels := Levels()
+	found := false
+	for _, l := range levels {
+		if l.Component == "db-test" {
+			found = l.Level == "debug" && l.Overridden
+		}
+	}
+	if !found {
+		t.Errorf("levels = %v", levels)
+	}
+
+	buf.Reset()
+	if err := SetLevel("db-test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevel("", "error"); err != nil {
+		t.Fatal(err)
+	}
+	db.Debug().Msg("hidden")
+	api.Warn().Msg("hidden")
+	api.Error().Msg("api error")
+	if got := lines(buf); len(got) != 1 || got[0]["message"] != "api error" {
+		t.Fatalf("default level change: %v", got)
+	}
+
+	if err := SetLevel("nope", "debug"); err == nil {
+		t.Error("unknown component should be rejected")
+	}
+	if err := SetLevel("db-test", "loud"); err == nil {
+		t.Error("unknown level should be rejected")
+	}
+}
+
+func TestSampled(t *testing.T) {
+	buf := setupBuffer(t, "info")
+	l := Sampled(NewLogger("sampled-test"), 3, time.Hour)
+	for i := 0; i < 10; i++ {
+		l.Info().Int("i", i).Msg("write")
+	}
+	if got := len(lines(buf)); got != 3 {
+		t.Errorf("sampled %d events, want 3", got)
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	buf := setupBuffer(t, "info")
+	base := NewLogger("ctx-test")
+	ctx := ContextWithRequestID(context.Background(), "abc")
+	Ctx(ctx, base).Info().Msg("with id")
+	Ctx(context.Background(), base).Info().Msg("without id")
+	got := lines(buf)
+	if len(got) != 2 || got[0]["request_id"] != "abc" || got[0]["component"] != "ctx-test" || got[1]["request_id"] != nil {
+		t.Errorf("context logger: %v", got)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	buf := setupBuffer(t, "info")
+	defer log.SetOutput(log.Writer())
+	RedirectStdLog(NewLogger("stdlog-test"))
+	log.Printf("plain %d", 1)
+	log.Printf("WARNING: careful")
+	got := lines(buf)
+	if len(got) != 2 || got[0]["message"] != "plain 1" || got[0]["level"] != "info" ||
+		got[1]["message"] != "careful" || got[1]["level"] != "warn" {
+		t.Errorf("std log: %v", got)
+	}
+}
@@ -18,6 +18,7 @@ type Config struct {
 	TimeFormat string
 	Service    string // service name for log context
 	Version    string // version for log context
+	NoColor    bool   // console format without colors, e.g. for log files
 }
 
 // DefaultConfig returns default logging configuration.
@@ -31,63 +32,61 @@ func DefaultConfig() *Config {
 	}
 }
 
-// Setup initializes the global logger with the given configuration.
+// Setup initializes the global logger with the given configuration. Loggers of NewLogger, created
+// before or after, write to the output it sets up.
 func Setup(cfg *Config) zerolog.Logger {
 	if cfg == nil {
 		cfg = DefaultConfig()
 	}
 
-	// Set log level
-	level := zerolog.InfoLevel
-	switch cfg.Level {
-	case "debug":
-		level = zerolog.DebugLevel
-	case "info":
+	// Set log level: the default of components without a level of their own. Events are filtered
+	// per component, so the global level lets everything through.
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
 		level = zerolog.InfoLevel
-	case "warn":
-		level = zerolog.WarnLevel
-	case "error":
-		level = zerolog.ErrorLevel
 	}
-	zerolog.SetGlobalLevel(level)
+	defaultLevel.Store(int32(level))
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
 
 	// Set time format
 	zerolog.TimeFieldFormat = cfg.TimeFormat
 
 	// Set output
-	output := cfg.Output
-	if output == nil {
-		output = os.Stdout
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
 	}
 
 	// Use console writer for human-readable output
 	if cfg.Format == "console" {
-		output = zerolog.ConsoleWriter{
-			Out:        output,
-			TimeFormat: time.Kitchen,
+		timeFormat := time.Kitchen
+		if cfg.TimeFormat != "" {
+			timeFormat = cfg.TimeFormat
+		}
+		out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: timeFormat,
+			NoColor:    cfg.NoColor,
 		}
 	}
-
-	// Create logger with context
-	logger := zerolog.New(output).
-		With().
-		Timestamp().
-		Str("service", cfg.Service).
-		Logger()
-
-	if cfg.Version != "" {
-		logger = logger.With().Str("version", cfg.Version).Logger()
-	}
+	output.set(out)
+	serviceFields.Store(&fields{service: cfg.Service, version: cfg.Version})
 
 	// Set global logger
+	logger := zerolog.New(output).With().Timestamp().Logger().Hook(componentHook{})
 	log.Logger = logger
 
 	return logger
 }
 
-// NewLogger creates a new logger with the given context fields.
-func NewLogger(service string) zerolog.Logger {
-	return log.With().Str("component", service).Logger()
+// NewLogger creates a logger for a component, whose level can be changed at runtime with
+// SetLevel.
+func NewLogger(component string) zerolog.Logger {
+	return zerolog.New(output).With().
+		Timestamp().
+		Str("component", component).
+		Logger().
+		Hook(componentHook{c: register(component)})
 }
 
 // WithRequest adds request context to a logger.
@@ -32,6 +32,8 @@ type AgentMessage struct {
 	//	*AgentMessage_State
 	//	*AgentMessage_LogEntry
 	//	*AgentMessage_Metrics
+	//	*AgentMessage_GenericMetrics
+	//	*AgentMessage_UpdateRejected
 	Payload       isAgentMessage_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -133,6 +135,24 @@ func (x *AgentMessage) GetMetrics() *NginxMetrics {
 	return nil
 }
 
+func (x *AgentMessage) GetGenericMetrics() *GenericMetrics {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_GenericMetrics); ok {
+			return x.GenericMetrics
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetUpdateRejected() *UpdateRejected {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_UpdateRejected); ok {
+			return x.UpdateRejected
+		}
+	}
+	return nil
+}
+
 type isAgentMessage_Payload interface {
 	isAgentMessage_Payload()
 }
@@ -157,6 +177,14 @@ type AgentMessage_Metrics struct {
 	Metrics *NginxMetrics `protobuf:"bytes,7,opt,name=metrics,proto3,oneof"`
 }
 
+type AgentMessage_GenericMetrics struct {
+	GenericMetrics *GenericMetrics `protobuf:"bytes,8,opt,name=generic_metrics,json=genericMetrics,proto3,oneof"`
+}
+
+type AgentMessage_UpdateRejected struct {
+	UpdateRejected *UpdateRejected `protobuf:"bytes,9,opt,name=update_rejected,json=updateRejected,proto3,oneof"`
+}
+
 func (*AgentMessage_Heartbeat) isAgentMessage_Payload() {}
 
 func (*AgentMessage_CommandResult) isAgentMessage_Payload() {}
@@ -167,6 +195,209 @@ func (*AgentMessage_LogEntry) isAgentMessage_Payload() {}
 
 func (*AgentMessage_Metrics) isAgentMessage_Payload() {}
 
+func (*AgentMessage_GenericMetrics) isAgentMessage_Payload() {}
+
+func (*AgentMessage_UpdateRejected) isAgentMessage_Payload() {}
+
+// UpdateRejected reports an update the agent refused to install because the package failed
+// verification (checksum, signature or transport policy)
+type UpdateRejected struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`   // version the update server offered
+	Platform      string                 `protobuf:"bytes,2,opt,name=platform,proto3" json:"platform,omitempty"` // os-arch of the binary, e.g. linux-amd64
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`           // where the binary was (or would have been) downloaded from
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRejected) Reset() {
+	*x = UpdateRejected{}
+	mi := &file_api_proto_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRejected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRejected) ProtoMessage() {}
+
+func (x *UpdateRejected) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRejected.ProtoReflect.Descriptor instead.
+func (*UpdateRejected) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UpdateRejected) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *UpdateRejected) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *UpdateRejected) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *UpdateRejected) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// GenericMetrics carries samples from custom collectors (exec scripts, Prometheus endpoints)
+type GenericMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metrics       []*GenericMetric       `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenericMetrics) Reset() {
+	*x = GenericMetrics{}
+	mi := &file_api_proto_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenericMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenericMetrics) ProtoMessage() {}
+
+func (x *GenericMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenericMetrics.ProtoReflect.Descriptor instead.
+func (*GenericMetrics) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GenericMetrics) GetMetrics() []*GenericMetric {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+type GenericMetric struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"` // collector name, e.g. "php-fpm"
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`     // metric name, e.g. "active_processes"
+	Value         float64                `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	TimestampMs   int64                  `protobuf:"varint,5,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"` // sample time; the message timestamp when zero
+	Type          string                 `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`                                   // gauge, counter or untyped
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenericMetric) Reset() {
+	*x = GenericMetric{}
+	mi := &file_api_proto_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenericMetric) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenericMetric) ProtoMessage() {}
+
+func (x *GenericMetric) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenericMetric.ProtoReflect.Descriptor instead.
+func (*GenericMetric) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GenericMetric) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *GenericMetric) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GenericMetric) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *GenericMetric) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *GenericMetric) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *GenericMetric) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
 type SystemMetrics struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	CpuUsagePercent    float32                `protobuf:"fixed32,1,opt,name=cpu_usage_percent,json=cpuUsagePercent,proto3" json:"cpu_usage_percent,omitempty"`
@@ -181,13 +412,24 @@ type SystemMetrics struct {
 	CpuSystemPercent   float32                `protobuf:"fixed32,10,opt,name=cpu_system_percent,json=cpuSystemPercent,proto3" json:"cpu_system_percent,omitempty"`
 	CpuIowaitPercent   float32                `protobuf:"fixed32,11,opt,name=cpu_iowait_percent,json=cpuIowaitPercent,proto3" json:"cpu_iowait_percent,omitempty"`
 	Labels             map[string]string      `protobuf:"bytes,12,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+	Disks              []*DiskUsage           `protobuf:"bytes,13,rep,name=disks,proto3" json:"disks,omitempty"`
+	LogFiles           []*LogFileUsage        `protobuf:"bytes,14,rep,name=log_files,json=logFiles,proto3" json:"log_files,omitempty"`
+	// System-wide file handles from /proc/sys/fs/file-nr
+	FdAllocated   uint64            `protobuf:"varint,15,opt,name=fd_allocated,json=fdAllocated,proto3" json:"fd_allocated,omitempty"`
+	FdMax         uint64            `protobuf:"varint,16,opt,name=fd_max,json=fdMax,proto3" json:"fd_max,omitempty"`
+	FdUsedPercent float32           `protobuf:"fixed32,17,opt,name=fd_used_percent,json=fdUsedPercent,proto3" json:"fd_used_percent,omitempty"`
+	NginxFds      []*ProcessFdUsage `protobuf:"bytes,18,rep,name=nginx_fds,json=nginxFds,proto3" json:"nginx_fds,omitempty"` // nginx master and workers
+	// Connection tracking table; zero when nf_conntrack is not loaded
+	ConntrackCount       uint64  `protobuf:"varint,19,opt,name=conntrack_count,json=conntrackCount,proto3" json:"conntrack_count,omitempty"`
+	ConntrackMax         uint64  `protobuf:"varint,20,opt,name=conntrack_max,json=conntrackMax,proto3" json:"conntrack_max,omitempty"`
+	ConntrackUsedPercent float32 `protobuf:"fixed32,21,opt,name=conntrack_used_percent,json=conntrackUsedPercent,proto3" json:"conntrack_used_percent,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *SystemMetrics) Reset() {
 	*x = SystemMetrics{}
-	mi := &file_api_proto_agent_proto_msgTypes[1]
+	mi := &file_api_proto_agent_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -199,7 +441,7 @@ func (x *SystemMetrics) String() string {
 func (*SystemMetrics) ProtoMessage() {}
 
 func (x *SystemMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[1]
+	mi := &file_api_proto_agent_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -212,7 +454,7 @@ func (x *SystemMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemMetrics.ProtoReflect.Descriptor instead.
 func (*SystemMetrics) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{1}
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SystemMetrics) GetCpuUsagePercent() float32 {
@@ -299,135 +541,96 @@ func (x *SystemMetrics) GetLabels() map[string]string {
 	return nil
 }
 
-type HttpStatusMetrics struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Status_2XxCount int64                  `protobuf:"varint,1,opt,name=status_2xx_count,json=status2xxCount,proto3" json:"status_2xx_count,omitempty"`
-	Status_200Count int64                  `protobuf:"varint,2,opt,name=status_200_count,json=status200Count,proto3" json:"status_200_count,omitempty"`
-	Status_3XxCount int64                  `protobuf:"varint,3,opt,name=status_3xx_count,json=status3xxCount,proto3" json:"status_3xx_count,omitempty"`
-	Status_4XxCount int64                  `protobuf:"varint,4,opt,name=status_4xx_count,json=status4xxCount,proto3" json:"status_4xx_count,omitempty"`
-	Status_404Count int64                  `protobuf:"varint,5,opt,name=status_404_count,json=status404Count,proto3" json:"status_404_count,omitempty"`
-	Status_5XxCount int64                  `protobuf:"varint,6,opt,name=status_5xx_count,json=status5xxCount,proto3" json:"status_5xx_count,omitempty"`
-	Status_503Count int64                  `protobuf:"varint,7,opt,name=status_503_count,json=status503Count,proto3" json:"status_503_count,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
-}
-
-func (x *HttpStatusMetrics) Reset() {
-	*x = HttpStatusMetrics{}
-	mi := &file_api_proto_agent_proto_msgTypes[2]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *HttpStatusMetrics) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*HttpStatusMetrics) ProtoMessage() {}
-
-func (x *HttpStatusMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[2]
+func (x *SystemMetrics) GetDisks() []*DiskUsage {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Disks
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use HttpStatusMetrics.ProtoReflect.Descriptor instead.
-func (*HttpStatusMetrics) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{2}
+func (x *SystemMetrics) GetLogFiles() []*LogFileUsage {
+	if x != nil {
+		return x.LogFiles
+	}
+	return nil
 }
 
-func (x *HttpStatusMetrics) GetStatus_2XxCount() int64 {
+func (x *SystemMetrics) GetFdAllocated() uint64 {
 	if x != nil {
-		return x.Status_2XxCount
+		return x.FdAllocated
 	}
 	return 0
 }
 
-func (x *HttpStatusMetrics) GetStatus_200Count() int64 {
+func (x *SystemMetrics) GetFdMax() uint64 {
 	if x != nil {
-		return x.Status_200Count
+		return x.FdMax
 	}
 	return 0
 }
 
-func (x *HttpStatusMetrics) GetStatus_3XxCount() int64 {
+func (x *SystemMetrics) GetFdUsedPercent() float32 {
 	if x != nil {
-		return x.Status_3XxCount
+		return x.FdUsedPercent
 	}
 	return 0
 }
 
-func (x *HttpStatusMetrics) GetStatus_4XxCount() int64 {
+func (x *SystemMetrics) GetNginxFds() []*ProcessFdUsage {
 	if x != nil {
-		return x.Status_4XxCount
+		return x.NginxFds
 	}
-	return 0
+	return nil
 }
 
-func (x *HttpStatusMetrics) GetStatus_404Count() int64 {
+func (x *SystemMetrics) GetConntrackCount() uint64 {
 	if x != nil {
-		return x.Status_404Count
+		return x.ConntrackCount
 	}
 	return 0
 }
 
-func (x *HttpStatusMetrics) GetStatus_5XxCount() int64 {
+func (x *SystemMetrics) GetConntrackMax() uint64 {
 	if x != nil {
-		return x.Status_5XxCount
+		return x.ConntrackMax
 	}
 	return 0
 }
 
-func (x *HttpStatusMetrics) GetStatus_503Count() int64 {
+func (x *SystemMetrics) GetConntrackUsedPercent() float32 {
 	if x != nil {
-		return x.Status_503Count
+		return x.ConntrackUsedPercent
 	}
 	return 0
 }
 
-type NginxMetrics struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	ActiveConnections   int64                  `protobuf:"varint,1,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
-	AcceptedConnections int64                  `protobuf:"varint,2,opt,name=accepted_connections,json=acceptedConnections,proto3" json:"accepted_connections,omitempty"`
-	HandledConnections  int64                  `protobuf:"varint,3,opt,name=handled_connections,json=handledConnections,proto3" json:"handled_connections,omitempty"`
-	TotalRequests       int64                  `protobuf:"varint,4,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
-	Reading             int64                  `protobuf:"varint,5,opt,name=reading,proto3" json:"reading,omitempty"`
-	Writing             int64                  `protobuf:"varint,6,opt,name=writing,proto3" json:"writing,omitempty"`
-	Waiting             int64                  `protobuf:"varint,7,opt,name=waiting,proto3" json:"waiting,omitempty"`
-	RequestsPerSecond   float64                `protobuf:"fixed64,8,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
-	System              *SystemMetrics         `protobuf:"bytes,9,opt,name=system,proto3" json:"system,omitempty"`
-	HttpStatus          *HttpStatusMetrics     `protobuf:"bytes,10,opt,name=http_status,json=httpStatus,proto3" json:"http_status,omitempty"`
-	// High-performance observability extensions
-	Labels              map[string]string  `protobuf:"bytes,11,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // prometheus-style labels: server, upstream, route, etc.
-	LatencyDistribution []*HistogramBucket `protobuf:"bytes,12,rep,name=latency_distribution,json=latencyDistribution,proto3" json:"latency_distribution,omitempty"`
-	// VTS (nginx-module-vts): total bytes in/out aggregated from ServerZones
-	BytesInTotal  int64 `protobuf:"varint,13,opt,name=bytes_in_total,json=bytesInTotal,proto3" json:"bytes_in_total,omitempty"`
-	BytesOutTotal int64 `protobuf:"varint,14,opt,name=bytes_out_total,json=bytesOutTotal,proto3" json:"bytes_out_total,omitempty"`
+// ProcessFdUsage is the open file descriptors of one process against its soft RLIMIT_NOFILE
+type ProcessFdUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"` // master or worker
+	OpenFds       uint64                 `protobuf:"varint,3,opt,name=open_fds,json=openFds,proto3" json:"open_fds,omitempty"`
+	Limit         uint64                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"` // 0 when unlimited
+	UsedPercent   float32                `protobuf:"fixed32,5,opt,name=used_percent,json=usedPercent,proto3" json:"used_percent,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NginxMetrics) Reset() {
-	*x = NginxMetrics{}
-	mi := &file_api_proto_agent_proto_msgTypes[3]
+func (x *ProcessFdUsage) Reset() {
+	*x = ProcessFdUsage{}
+	mi := &file_api_proto_agent_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NginxMetrics) String() string {
+func (x *ProcessFdUsage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NginxMetrics) ProtoMessage() {}
+func (*ProcessFdUsage) ProtoMessage() {}
 
-func (x *NginxMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[3]
+func (x *ProcessFdUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -438,132 +641,181 @@ func (x *NginxMetrics) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NginxMetrics.ProtoReflect.Descriptor instead.
-func (*NginxMetrics) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ProcessFdUsage.ProtoReflect.Descriptor instead.
+func (*ProcessFdUsage) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *NginxMetrics) GetActiveConnections() int64 {
+func (x *ProcessFdUsage) GetPid() int32 {
 	if x != nil {
-		return x.ActiveConnections
+		return x.Pid
 	}
 	return 0
 }
 
-func (x *NginxMetrics) GetAcceptedConnections() int64 {
+func (x *ProcessFdUsage) GetRole() string {
 	if x != nil {
-		return x.AcceptedConnections
+		return x.Role
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetrics) GetHandledConnections() int64 {
+func (x *ProcessFdUsage) GetOpenFds() uint64 {
 	if x != nil {
-		return x.HandledConnections
+		return x.OpenFds
 	}
 	return 0
 }
 
-func (x *NginxMetrics) GetTotalRequests() int64 {
+func (x *ProcessFdUsage) GetLimit() uint64 {
 	if x != nil {
-		return x.TotalRequests
+		return x.Limit
 	}
 	return 0
 }
 
-func (x *NginxMetrics) GetReading() int64 {
+func (x *ProcessFdUsage) GetUsedPercent() float32 {
 	if x != nil {
-		return x.Reading
+		return x.UsedPercent
 	}
 	return 0
 }
 
-func (x *NginxMetrics) GetWriting() int64 {
+// DiskUsage is one mounted filesystem
+type DiskUsage struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Mount             string                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"`
+	Device            string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	Fstype            string                 `protobuf:"bytes,3,opt,name=fstype,proto3" json:"fstype,omitempty"`
+	TotalBytes        uint64                 `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	UsedBytes         uint64                 `protobuf:"varint,5,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	AvailableBytes    uint64                 `protobuf:"varint,6,opt,name=available_bytes,json=availableBytes,proto3" json:"available_bytes,omitempty"` // free to unprivileged users
+	UsedPercent       float32                `protobuf:"fixed32,7,opt,name=used_percent,json=usedPercent,proto3" json:"used_percent,omitempty"`         // used / (used + available), as df reports it
+	InodesUsedPercent float32                `protobuf:"fixed32,8,opt,name=inodes_used_percent,json=inodesUsedPercent,proto3" json:"inodes_used_percent,omitempty"`
+	NginxLogs         bool                   `protobuf:"varint,9,opt,name=nginx_logs,json=nginxLogs,proto3" json:"nginx_logs,omitempty"` // holds an nginx log file
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *DiskUsage) Reset() {
+	*x = DiskUsage{}
+	mi := &file_api_proto_agent_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskUsage) ProtoMessage() {}
+
+func (x *DiskUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[6]
 	if x != nil {
-		return x.Writing
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *NginxMetrics) GetWaiting() int64 {
+// Deprecated: Use DiskUsage.ProtoReflect.Descriptor instead.
+func (*DiskUsage) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DiskUsage) GetMount() string {
 	if x != nil {
-		return x.Waiting
+		return x.Mount
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetrics) GetRequestsPerSecond() float64 {
+func (x *DiskUsage) GetDevice() string {
 	if x != nil {
-		return x.RequestsPerSecond
+		return x.Device
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetrics) GetSystem() *SystemMetrics {
+func (x *DiskUsage) GetFstype() string {
 	if x != nil {
-		return x.System
+		return x.Fstype
 	}
-	return nil
+	return ""
 }
 
-func (x *NginxMetrics) GetHttpStatus() *HttpStatusMetrics {
+func (x *DiskUsage) GetTotalBytes() uint64 {
 	if x != nil {
-		return x.HttpStatus
+		return x.TotalBytes
 	}
-	return nil
+	return 0
 }
 
-func (x *NginxMetrics) GetLabels() map[string]string {
+func (x *DiskUsage) GetUsedBytes() uint64 {
 	if x != nil {
-		return x.Labels
+		return x.UsedBytes
 	}
-	return nil
+	return 0
 }
 
-func (x *NginxMetrics) GetLatencyDistribution() []*HistogramBucket {
+func (x *DiskUsage) GetAvailableBytes() uint64 {
 	if x != nil {
-		return x.LatencyDistribution
+		return x.AvailableBytes
 	}
-	return nil
+	return 0
 }
 
-func (x *NginxMetrics) GetBytesInTotal() int64 {
+func (x *DiskUsage) GetUsedPercent() float32 {
 	if x != nil {
-		return x.BytesInTotal
+		return x.UsedPercent
 	}
 	return 0
 }
 
-func (x *NginxMetrics) GetBytesOutTotal() int64 {
+func (x *DiskUsage) GetInodesUsedPercent() float32 {
 	if x != nil {
-		return x.BytesOutTotal
+		return x.InodesUsedPercent
 	}
 	return 0
 }
 
-type HistogramBucket struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Le            float32                `protobuf:"fixed32,1,opt,name=le,proto3" json:"le,omitempty"` // less than or equal to (ms)
-	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *DiskUsage) GetNginxLogs() bool {
+	if x != nil {
+		return x.NginxLogs
+	}
+	return false
 }
 
-func (x *HistogramBucket) Reset() {
-	*x = HistogramBucket{}
-	mi := &file_api_proto_agent_proto_msgTypes[4]
+// LogFileUsage is the size and growth of an nginx log file
+type LogFileUsage struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Path              string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Mount             string                 `protobuf:"bytes,2,opt,name=mount,proto3" json:"mount,omitempty"`
+	SizeBytes         uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	GrowthBytesPerSec float32                `protobuf:"fixed32,4,opt,name=growth_bytes_per_sec,json=growthBytesPerSec,proto3" json:"growth_bytes_per_sec,omitempty"` // since the previous sample; 0 after a rotation
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *LogFileUsage) Reset() {
+	*x = LogFileUsage{}
+	mi := &file_api_proto_agent_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistogramBucket) String() string {
+func (x *LogFileUsage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistogramBucket) ProtoMessage() {}
+func (*LogFileUsage) ProtoMessage() {}
 
-func (x *HistogramBucket) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[4]
+func (x *LogFileUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -574,54 +826,67 @@ func (x *HistogramBucket) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistogramBucket.ProtoReflect.Descriptor instead.
-func (*HistogramBucket) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use LogFileUsage.ProtoReflect.Descriptor instead.
+func (*LogFileUsage) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *HistogramBucket) GetLe() float32 {
+func (x *LogFileUsage) GetPath() string {
 	if x != nil {
-		return x.Le
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LogFileUsage) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+func (x *LogFileUsage) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
 	}
 	return 0
 }
 
-func (x *HistogramBucket) GetCount() int64 {
+func (x *LogFileUsage) GetGrowthBytesPerSec() float32 {
 	if x != nil {
-		return x.Count
+		return x.GrowthBytesPerSec
 	}
 	return 0
 }
 
-type ServerCommand struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	CommandId string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
-	// Types that are valid to be assigned to Payload:
-	//
-	//	*ServerCommand_ConfigPush
-	//	*ServerCommand_Action
-	//	*ServerCommand_LogRequest
-	//	*ServerCommand_Update
-	Payload       isServerCommand_Payload `protobuf_oneof:"payload"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type HttpStatusMetrics struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Status_2XxCount int64                  `protobuf:"varint,1,opt,name=status_2xx_count,json=status2xxCount,proto3" json:"status_2xx_count,omitempty"`
+	Status_200Count int64                  `protobuf:"varint,2,opt,name=status_200_count,json=status200Count,proto3" json:"status_200_count,omitempty"`
+	Status_3XxCount int64                  `protobuf:"varint,3,opt,name=status_3xx_count,json=status3xxCount,proto3" json:"status_3xx_count,omitempty"`
+	Status_4XxCount int64                  `protobuf:"varint,4,opt,name=status_4xx_count,json=status4xxCount,proto3" json:"status_4xx_count,omitempty"`
+	Status_404Count int64                  `protobuf:"varint,5,opt,name=status_404_count,json=status404Count,proto3" json:"status_404_count,omitempty"`
+	Status_5XxCount int64                  `protobuf:"varint,6,opt,name=status_5xx_count,json=status5xxCount,proto3" json:"status_5xx_count,omitempty"`
+	Status_503Count int64                  `protobuf:"varint,7,opt,name=status_503_count,json=status503Count,proto3" json:"status_503_count,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *ServerCommand) Reset() {
-	*x = ServerCommand{}
-	mi := &file_api_proto_agent_proto_msgTypes[5]
+func (x *HttpStatusMetrics) Reset() {
+	*x = HttpStatusMetrics{}
+	mi := &file_api_proto_agent_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ServerCommand) String() string {
+func (x *HttpStatusMetrics) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerCommand) ProtoMessage() {}
+func (*HttpStatusMetrics) ProtoMessage() {}
 
-func (x *ServerCommand) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[5]
+func (x *HttpStatusMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -632,306 +897,260 @@ func (x *ServerCommand) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ServerCommand.ProtoReflect.Descriptor instead.
-func (*ServerCommand) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use HttpStatusMetrics.ProtoReflect.Descriptor instead.
+func (*HttpStatusMetrics) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ServerCommand) GetCommandId() string {
+func (x *HttpStatusMetrics) GetStatus_2XxCount() int64 {
 	if x != nil {
-		return x.CommandId
+		return x.Status_2XxCount
 	}
-	return ""
+	return 0
 }
 
-func (x *ServerCommand) GetPayload() isServerCommand_Payload {
+func (x *HttpStatusMetrics) GetStatus_200Count() int64 {
 	if x != nil {
-		return x.Payload
+		return x.Status_200Count
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerCommand) GetConfigPush() *ConfigPush {
+func (x *HttpStatusMetrics) GetStatus_3XxCount() int64 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerCommand_ConfigPush); ok {
-			return x.ConfigPush
-		}
+		return x.Status_3XxCount
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerCommand) GetAction() *Action {
+func (x *HttpStatusMetrics) GetStatus_4XxCount() int64 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerCommand_Action); ok {
-			return x.Action
-		}
+		return x.Status_4XxCount
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerCommand) GetLogRequest() *LogRequest {
+func (x *HttpStatusMetrics) GetStatus_404Count() int64 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerCommand_LogRequest); ok {
-			return x.LogRequest
-		}
+		return x.Status_404Count
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerCommand) GetUpdate() *Update {
+func (x *HttpStatusMetrics) GetStatus_5XxCount() int64 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerCommand_Update); ok {
-			return x.Update
-		}
+		return x.Status_5XxCount
 	}
-	return nil
+	return 0
 }
 
-type isServerCommand_Payload interface {
-	isServerCommand_Payload()
+func (x *HttpStatusMetrics) GetStatus_503Count() int64 {
+	if x != nil {
+		return x.Status_503Count
+	}
+	return 0
 }
 
-type ServerCommand_ConfigPush struct {
-	ConfigPush *ConfigPush `protobuf:"bytes,2,opt,name=config_push,json=configPush,proto3,oneof"`
+type NginxMetrics struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ActiveConnections   int64                  `protobuf:"varint,1,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	AcceptedConnections int64                  `protobuf:"varint,2,opt,name=accepted_connections,json=acceptedConnections,proto3" json:"accepted_connections,omitempty"`
+	HandledConnections  int64                  `protobuf:"varint,3,opt,name=handled_connections,json=handledConnections,proto3" json:"handled_connections,omitempty"`
+	TotalRequests       int64                  `protobuf:"varint,4,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	Reading             int64                  `protobuf:"varint,5,opt,name=reading,proto3" json:"reading,omitempty"`
+	Writing             int64                  `protobuf:"varint,6,opt,name=writing,proto3" json:"writing,omitempty"`
+	Waiting             int64                  `protobuf:"varint,7,opt,name=waiting,proto3" json:"waiting,omitempty"`
+	RequestsPerSecond   float64                `protobuf:"fixed64,8,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
+	System              *SystemMetrics         `protobuf:"bytes,9,opt,name=system,proto3" json:"system,omitempty"`
+	HttpStatus          *HttpStatusMetrics     `protobuf:"bytes,10,opt,name=http_status,json=httpStatus,proto3" json:"http_status,omitempty"`
+	// High-performance observability extensions
+	Labels              map[string]string  `protobuf:"bytes,11,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // prometheus-style labels: server, upstream, route, etc.
+	LatencyDistribution []*HistogramBucket `protobuf:"bytes,12,rep,name=latency_distribution,json=latencyDistribution,proto3" json:"latency_distribution,omitempty"`
+	// VTS (nginx-module-vts): total bytes in/out aggregated from ServerZones
+	BytesInTotal  int64          `protobuf:"varint,13,opt,name=bytes_in_total,json=bytesInTotal,proto3" json:"bytes_in_total,omitempty"`
+	BytesOutTotal int64          `protobuf:"varint,14,opt,name=bytes_out_total,json=bytesOutTotal,proto3" json:"bytes_out_total,omitempty"`
+	Workers       *NginxWorkers  `protobuf:"bytes,15,opt,name=workers,proto3" json:"workers,omitempty"`
+	InstanceId    string         `protobuf:"bytes,16,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"` // NginxInstance.instance_id these metrics were scraped from
+	Service       *ServiceStatus `protobuf:"bytes,17,opt,name=service,proto3" json:"service,omitempty"`                         // unit state and churn since the previous report, without journal
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type ServerCommand_Action struct {
-	Action *Action `protobuf:"bytes,3,opt,name=action,proto3,oneof"`
+func (x *NginxMetrics) Reset() {
+	*x = NginxMetrics{}
+	mi := &file_api_proto_agent_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type ServerCommand_LogRequest struct {
-	LogRequest *LogRequest `protobuf:"bytes,4,opt,name=log_request,json=logRequest,proto3,oneof"`
+func (x *NginxMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type ServerCommand_Update struct {
-	Update *Update `protobuf:"bytes,5,opt,name=update,proto3,oneof"`
-}
+func (*NginxMetrics) ProtoMessage() {}
 
-func (*ServerCommand_ConfigPush) isServerCommand_Payload() {}
+func (x *NginxMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-func (*ServerCommand_Action) isServerCommand_Payload() {}
-
-func (*ServerCommand_LogRequest) isServerCommand_Payload() {}
-
-func (*ServerCommand_Update) isServerCommand_Payload() {}
-
-type Update struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`                      // version to update to, or "latest"
-	UpdateUrl     string                 `protobuf:"bytes,2,opt,name=update_url,json=updateUrl,proto3" json:"update_url,omitempty"` // optional: override the agent's configured update server URL
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *Update) Reset() {
-	*x = Update{}
-	mi := &file_api_proto_agent_proto_msgTypes[6]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *Update) String() string {
-	return protoimpl.X.MessageStringOf(x)
+// Deprecated: Use NginxMetrics.ProtoReflect.Descriptor instead.
+func (*NginxMetrics) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{9}
 }
 
-func (*Update) ProtoMessage() {}
-
-func (x *Update) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[6]
+func (x *NginxMetrics) GetActiveConnections() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ActiveConnections
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Update.ProtoReflect.Descriptor instead.
-func (*Update) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{6}
+	return 0
 }
 
-func (x *Update) GetVersion() string {
+func (x *NginxMetrics) GetAcceptedConnections() int64 {
 	if x != nil {
-		return x.Version
+		return x.AcceptedConnections
 	}
-	return ""
+	return 0
 }
 
-func (x *Update) GetUpdateUrl() string {
+func (x *NginxMetrics) GetHandledConnections() int64 {
 	if x != nil {
-		return x.UpdateUrl
+		return x.HandledConnections
 	}
-	return ""
-}
-
-type Heartbeat struct {
-	state                 protoimpl.MessageState `protogen:"open.v1"`
-	Hostname              string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Version               string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // NGINX version
-	Uptime                float64                `protobuf:"fixed64,3,opt,name=uptime,proto3" json:"uptime,omitempty"`
-	Instances             []*NginxInstance       `protobuf:"bytes,4,rep,name=instances,proto3" json:"instances,omitempty"`
-	IsPod                 bool                   `protobuf:"varint,5,opt,name=is_pod,json=isPod,proto3" json:"is_pod,omitempty"`
-	PodIp                 string                 `protobuf:"bytes,6,opt,name=pod_ip,json=podIp,proto3" json:"pod_ip,omitempty"`
-	AgentVersion          string                 `protobuf:"bytes,7,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`                                            // Agent version
-	BuildDate             string                 `protobuf:"bytes,8,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`                                                     // Build timestamp
-	GitCommit             string                 `protobuf:"bytes,9,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`                                                     // Git commit hash
-	GitBranch             string                 `protobuf:"bytes,10,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`                                                    // Git branch name
-	Labels                map[string]string      `protobuf:"bytes,11,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Agent labels for auto-assignment (project, environment, etc.)
-	MgmtAddress           string                 `protobuf:"bytes,12,opt,name=mgmt_address,json=mgmtAddress,proto3" json:"mgmt_address,omitempty"`                                              // Optional host:port the gateway should use to dial this agent (e.g. 10.0.2.15:5025)
-	MgmtAddressCandidates []string               `protobuf:"bytes,13,rep,name=mgmt_address_candidates,json=mgmtAddressCandidates,proto3" json:"mgmt_address_candidates,omitempty"`              // All non-loopback host:port the gateway can probe to find a reachable address (K8s CNI, multi-NIC, Vagrant, etc.)
-	unknownFields         protoimpl.UnknownFields
-	sizeCache             protoimpl.SizeCache
-}
-
-func (x *Heartbeat) Reset() {
-	*x = Heartbeat{}
-	mi := &file_api_proto_agent_proto_msgTypes[7]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *Heartbeat) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*Heartbeat) ProtoMessage() {}
-
-func (x *Heartbeat) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[7]
+func (x *NginxMetrics) GetTotalRequests() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.TotalRequests
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
-func (*Heartbeat) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{7}
+	return 0
 }
 
-func (x *Heartbeat) GetHostname() string {
+func (x *NginxMetrics) GetReading() int64 {
 	if x != nil {
-		return x.Hostname
+		return x.Reading
 	}
-	return ""
+	return 0
 }
 
-func (x *Heartbeat) GetVersion() string {
+func (x *NginxMetrics) GetWriting() int64 {
 	if x != nil {
-		return x.Version
+		return x.Writing
 	}
-	return ""
+	return 0
 }
 
-func (x *Heartbeat) GetUptime() float64 {
+func (x *NginxMetrics) GetWaiting() int64 {
 	if x != nil {
-		return x.Uptime
+		return x.Waiting
 	}
 	return 0
 }
 
-func (x *Heartbeat) GetInstances() []*NginxInstance {
+func (x *NginxMetrics) GetRequestsPerSecond() float64 {
 	if x != nil {
-		return x.Instances
+		return x.RequestsPerSecond
 	}
-	return nil
+	return 0
 }
 
-func (x *Heartbeat) GetIsPod() bool {
+func (x *NginxMetrics) GetSystem() *SystemMetrics {
 	if x != nil {
-		return x.IsPod
+		return x.System
 	}
-	return false
+	return nil
 }
 
-func (x *Heartbeat) GetPodIp() string {
+func (x *NginxMetrics) GetHttpStatus() *HttpStatusMetrics {
 	if x != nil {
-		return x.PodIp
+		return x.HttpStatus
 	}
-	return ""
+	return nil
 }
 
-func (x *Heartbeat) GetAgentVersion() string {
+func (x *NginxMetrics) GetLabels() map[string]string {
 	if x != nil {
-		return x.AgentVersion
+		return x.Labels
 	}
-	return ""
+	return nil
 }
 
-func (x *Heartbeat) GetBuildDate() string {
+func (x *NginxMetrics) GetLatencyDistribution() []*HistogramBucket {
 	if x != nil {
-		return x.BuildDate
+		return x.LatencyDistribution
 	}
-	return ""
+	return nil
 }
 
-func (x *Heartbeat) GetGitCommit() string {
+func (x *NginxMetrics) GetBytesInTotal() int64 {
 	if x != nil {
-		return x.GitCommit
+		return x.BytesInTotal
 	}
-	return ""
+	return 0
 }
 
-func (x *Heartbeat) GetGitBranch() string {
+func (x *NginxMetrics) GetBytesOutTotal() int64 {
 	if x != nil {
-		return x.GitBranch
+		return x.BytesOutTotal
 	}
-	return ""
+	return 0
 }
 
-func (x *Heartbeat) GetLabels() map[string]string {
+func (x *NginxMetrics) GetWorkers() *NginxWorkers {
 	if x != nil {
-		return x.Labels
+		return x.Workers
 	}
 	return nil
 }
 
-func (x *Heartbeat) GetMgmtAddress() string {
+func (x *NginxMetrics) GetInstanceId() string {
 	if x != nil {
-		return x.MgmtAddress
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *Heartbeat) GetMgmtAddressCandidates() []string {
+func (x *NginxMetrics) GetService() *ServiceStatus {
 	if x != nil {
-		return x.MgmtAddressCandidates
+		return x.Service
 	}
 	return nil
 }
 
-type NginxInstance struct {
+// NginxWorkers is the worker processes of the nginx masters on the host and their churn since
+// the previous sample
+type NginxWorkers struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Pid           string                 `protobuf:"bytes,1,opt,name=pid,proto3" json:"pid,omitempty"`
-	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
-	ConfPath      string                 `protobuf:"bytes,3,opt,name=conf_path,json=confPath,proto3" json:"conf_path,omitempty"`
-	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"` // "RUNNING", "STOPPED"
+	Processes     []*NginxWorkerProcess  `protobuf:"bytes,1,rep,name=processes,proto3" json:"processes,omitempty"`
+	Restarts      int32                  `protobuf:"varint,2,opt,name=restarts,proto3" json:"restarts,omitempty"` // workers that replaced earlier ones (crashes, reloads and restarts)
+	Crashes       int32                  `protobuf:"varint,3,opt,name=crashes,proto3" json:"crashes,omitempty"`   // "worker process N exited on signal" and fatal exits in the error log
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NginxInstance) Reset() {
-	*x = NginxInstance{}
-	mi := &file_api_proto_agent_proto_msgTypes[8]
+func (x *NginxWorkers) Reset() {
+	*x = NginxWorkers{}
+	mi := &file_api_proto_agent_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NginxInstance) String() string {
+func (x *NginxWorkers) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NginxInstance) ProtoMessage() {}
+func (*NginxWorkers) ProtoMessage() {}
 
-func (x *NginxInstance) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[8]
+func (x *NginxWorkers) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -942,63 +1161,58 @@ func (x *NginxInstance) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NginxInstance.ProtoReflect.Descriptor instead.
-func (*NginxInstance) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *NginxInstance) GetPid() string {
-	if x != nil {
-		return x.Pid
-	}
-	return ""
+// Deprecated: Use NginxWorkers.ProtoReflect.Descriptor instead.
+func (*NginxWorkers) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *NginxInstance) GetVersion() string {
+func (x *NginxWorkers) GetProcesses() []*NginxWorkerProcess {
 	if x != nil {
-		return x.Version
+		return x.Processes
 	}
-	return ""
+	return nil
 }
 
-func (x *NginxInstance) GetConfPath() string {
+func (x *NginxWorkers) GetRestarts() int32 {
 	if x != nil {
-		return x.ConfPath
+		return x.Restarts
 	}
-	return ""
+	return 0
 }
 
-func (x *NginxInstance) GetStatus() string {
+func (x *NginxWorkers) GetCrashes() int32 {
 	if x != nil {
-		return x.Status
+		return x.Crashes
 	}
-	return ""
+	return 0
 }
 
-type CommandResult struct {
+type NginxWorkerProcess struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	CommandId     string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	MasterPid     int32                  `protobuf:"varint,2,opt,name=master_pid,json=masterPid,proto3" json:"master_pid,omitempty"`
+	CpuPercent    float32                `protobuf:"fixed32,3,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"` // of one core, since the previous sample
+	RssBytes      uint64                 `protobuf:"varint,4,opt,name=rss_bytes,json=rssBytes,proto3" json:"rss_bytes,omitempty"`
+	StartedAt     int64                  `protobuf:"varint,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"` // unix seconds
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CommandResult) Reset() {
-	*x = CommandResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[9]
+func (x *NginxWorkerProcess) Reset() {
+	*x = NginxWorkerProcess{}
+	mi := &file_api_proto_agent_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CommandResult) String() string {
+func (x *NginxWorkerProcess) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommandResult) ProtoMessage() {}
+func (*NginxWorkerProcess) ProtoMessage() {}
 
-func (x *CommandResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[9]
+func (x *NginxWorkerProcess) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1009,55 +1223,69 @@ func (x *CommandResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommandResult.ProtoReflect.Descriptor instead.
-func (*CommandResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use NginxWorkerProcess.ProtoReflect.Descriptor instead.
+func (*NginxWorkerProcess) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *CommandResult) GetCommandId() string {
+func (x *NginxWorkerProcess) GetPid() int32 {
 	if x != nil {
-		return x.CommandId
+		return x.Pid
 	}
-	return ""
+	return 0
 }
 
-func (x *CommandResult) GetSuccess() bool {
+func (x *NginxWorkerProcess) GetMasterPid() int32 {
 	if x != nil {
-		return x.Success
+		return x.MasterPid
 	}
-	return false
+	return 0
 }
 
-func (x *CommandResult) GetErrorMessage() string {
+func (x *NginxWorkerProcess) GetCpuPercent() float32 {
 	if x != nil {
-		return x.ErrorMessage
+		return x.CpuPercent
 	}
-	return ""
+	return 0
 }
 
-type StateSnapshot struct {
+func (x *NginxWorkerProcess) GetRssBytes() uint64 {
+	if x != nil {
+		return x.RssBytes
+	}
+	return 0
+}
+
+func (x *NginxWorkerProcess) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+type HistogramBucket struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConfigHash    string                 `protobuf:"bytes,1,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`
-	ConfigHashes  *ConfigHashes          `protobuf:"bytes,2,opt,name=config_hashes,json=configHashes,proto3" json:"config_hashes,omitempty"` // Detailed config hashes for drift detection
+	Le            float32                `protobuf:"fixed32,1,opt,name=le,proto3" json:"le,omitempty"` // less than or equal to (ms)
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StateSnapshot) Reset() {
-	*x = StateSnapshot{}
-	mi := &file_api_proto_agent_proto_msgTypes[10]
+func (x *HistogramBucket) Reset() {
+	*x = HistogramBucket{}
+	mi := &file_api_proto_agent_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StateSnapshot) String() string {
+func (x *HistogramBucket) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StateSnapshot) ProtoMessage() {}
+func (*HistogramBucket) ProtoMessage() {}
 
-func (x *StateSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[10]
+func (x *HistogramBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1068,53 +1296,56 @@ func (x *StateSnapshot) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StateSnapshot.ProtoReflect.Descriptor instead.
-func (*StateSnapshot) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use HistogramBucket.ProtoReflect.Descriptor instead.
+func (*HistogramBucket) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *StateSnapshot) GetConfigHash() string {
+func (x *HistogramBucket) GetLe() float32 {
 	if x != nil {
-		return x.ConfigHash
+		return x.Le
 	}
-	return ""
+	return 0
 }
 
-func (x *StateSnapshot) GetConfigHashes() *ConfigHashes {
+func (x *HistogramBucket) GetCount() int64 {
 	if x != nil {
-		return x.ConfigHashes
+		return x.Count
 	}
-	return nil
+	return 0
 }
 
-type ConfigHashes struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	MainConfHash        string                 `protobuf:"bytes,1,opt,name=main_conf_hash,json=mainConfHash,proto3" json:"main_conf_hash,omitempty"`
-	MainConfModified    int64                  `protobuf:"varint,2,opt,name=main_conf_modified,json=mainConfModified,proto3" json:"main_conf_modified,omitempty"`
-	SiteConfigs         []*FileHash            `protobuf:"bytes,3,rep,name=site_configs,json=siteConfigs,proto3" json:"site_configs,omitempty"`
-	IncludeFiles        []*FileHash            `protobuf:"bytes,4,rep,name=include_files,json=includeFiles,proto3" json:"include_files,omitempty"`
-	Certificates        []*CertHashInfo        `protobuf:"bytes,5,rep,name=certificates,proto3" json:"certificates,omitempty"`
-	MaintenancePageHash string                 `protobuf:"bytes,6,opt,name=maintenance_page_hash,json=maintenancePageHash,proto3" json:"maintenance_page_hash,omitempty"`
-	ComputedAt          int64                  `protobuf:"varint,7,opt,name=computed_at,json=computedAt,proto3" json:"computed_at,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type ServerCommand struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	CommandId string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ServerCommand_ConfigPush
+	//	*ServerCommand_Action
+	//	*ServerCommand_LogRequest
+	//	*ServerCommand_Update
+	//	*ServerCommand_ConfigureAgent
+	//	*ServerCommand_Drain
+	Payload       isServerCommand_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigHashes) Reset() {
-	*x = ConfigHashes{}
-	mi := &file_api_proto_agent_proto_msgTypes[11]
+func (x *ServerCommand) Reset() {
+	*x = ServerCommand{}
+	mi := &file_api_proto_agent_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigHashes) String() string {
+func (x *ServerCommand) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigHashes) ProtoMessage() {}
+func (*ServerCommand) ProtoMessage() {}
 
-func (x *ConfigHashes) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[11]
+func (x *ServerCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1125,154 +1356,145 @@ func (x *ConfigHashes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigHashes.ProtoReflect.Descriptor instead.
-func (*ConfigHashes) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use ServerCommand.ProtoReflect.Descriptor instead.
+func (*ServerCommand) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *ConfigHashes) GetMainConfHash() string {
+func (x *ServerCommand) GetCommandId() string {
 	if x != nil {
-		return x.MainConfHash
+		return x.CommandId
 	}
 	return ""
 }
 
-func (x *ConfigHashes) GetMainConfModified() int64 {
+func (x *ServerCommand) GetPayload() isServerCommand_Payload {
 	if x != nil {
-		return x.MainConfModified
+		return x.Payload
 	}
-	return 0
+	return nil
 }
 
-func (x *ConfigHashes) GetSiteConfigs() []*FileHash {
+func (x *ServerCommand) GetConfigPush() *ConfigPush {
 	if x != nil {
-		return x.SiteConfigs
+		if x, ok := x.Payload.(*ServerCommand_ConfigPush); ok {
+			return x.ConfigPush
+		}
 	}
 	return nil
 }
 
-func (x *ConfigHashes) GetIncludeFiles() []*FileHash {
+func (x *ServerCommand) GetAction() *Action {
 	if x != nil {
-		return x.IncludeFiles
+		if x, ok := x.Payload.(*ServerCommand_Action); ok {
+			return x.Action
+		}
 	}
 	return nil
 }
 
-func (x *ConfigHashes) GetCertificates() []*CertHashInfo {
+func (x *ServerCommand) GetLogRequest() *LogRequest {
 	if x != nil {
-		return x.Certificates
+		if x, ok := x.Payload.(*ServerCommand_LogRequest); ok {
+			return x.LogRequest
+		}
 	}
 	return nil
 }
 
-func (x *ConfigHashes) GetMaintenancePageHash() string {
+func (x *ServerCommand) GetUpdate() *Update {
 	if x != nil {
-		return x.MaintenancePageHash
+		if x, ok := x.Payload.(*ServerCommand_Update); ok {
+			return x.Update
+		}
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigHashes) GetComputedAt() int64 {
+func (x *ServerCommand) GetConfigureAgent() *ConfigureAgent {
 	if x != nil {
-		return x.ComputedAt
+		if x, ok := x.Payload.(*ServerCommand_ConfigureAgent); ok {
+			return x.ConfigureAgent
+		}
 	}
-	return 0
+	return nil
 }
 
-type FileHash struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
-	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
-	ModifiedAt    int64                  `protobuf:"varint,4,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ServerCommand) GetDrain() *Drain {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerCommand_Drain); ok {
+			return x.Drain
+		}
+	}
+	return nil
 }
 
-func (x *FileHash) Reset() {
-	*x = FileHash{}
-	mi := &file_api_proto_agent_proto_msgTypes[12]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+type isServerCommand_Payload interface {
+	isServerCommand_Payload()
 }
 
-func (x *FileHash) String() string {
-	return protoimpl.X.MessageStringOf(x)
+type ServerCommand_ConfigPush struct {
+	ConfigPush *ConfigPush `protobuf:"bytes,2,opt,name=config_push,json=configPush,proto3,oneof"`
 }
 
-func (*FileHash) ProtoMessage() {}
-
-func (x *FileHash) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[12]
-	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
+type ServerCommand_Action struct {
+	Action *Action `protobuf:"bytes,3,opt,name=action,proto3,oneof"`
 }
 
-// Deprecated: Use FileHash.ProtoReflect.Descriptor instead.
-func (*FileHash) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{12}
+type ServerCommand_LogRequest struct {
+	LogRequest *LogRequest `protobuf:"bytes,4,opt,name=log_request,json=logRequest,proto3,oneof"`
 }
 
-func (x *FileHash) GetPath() string {
-	if x != nil {
-		return x.Path
-	}
-	return ""
+type ServerCommand_Update struct {
+	Update *Update `protobuf:"bytes,5,opt,name=update,proto3,oneof"`
 }
 
-func (x *FileHash) GetHash() string {
-	if x != nil {
-		return x.Hash
-	}
-	return ""
+type ServerCommand_ConfigureAgent struct {
+	ConfigureAgent *ConfigureAgent `protobuf:"bytes,6,opt,name=configure_agent,json=configureAgent,proto3,oneof"`
 }
 
-func (x *FileHash) GetSize() int64 {
-	if x != nil {
-		return x.Size
-	}
-	return 0
+type ServerCommand_Drain struct {
+	Drain *Drain `protobuf:"bytes,7,opt,name=drain,proto3,oneof"`
 }
 
-func (x *FileHash) GetModifiedAt() int64 {
-	if x != nil {
-		return x.ModifiedAt
-	}
-	return 0
-}
+func (*ServerCommand_ConfigPush) isServerCommand_Payload() {}
 
-type CertHashInfo struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Domain          string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
-	CertPath        string                 `protobuf:"bytes,2,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
-	CertHash        string                 `protobuf:"bytes,3,opt,name=cert_hash,json=certHash,proto3" json:"cert_hash,omitempty"`
-	ExpiryTimestamp int64                  `protobuf:"varint,4,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
-	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (*ServerCommand_Action) isServerCommand_Payload() {}
+
+func (*ServerCommand_LogRequest) isServerCommand_Payload() {}
+
+func (*ServerCommand_Update) isServerCommand_Payload() {}
+
+func (*ServerCommand_ConfigureAgent) isServerCommand_Payload() {}
+
+func (*ServerCommand_Drain) isServerCommand_Payload() {}
+
+// Drain tells an agent its gateway is shutting down. The agent closes the stream so the gateway
+// can stop without waiting for it, and sends its backlog through its other gateways; with a
+// single gateway address it reconnects right away to land on another replica behind it.
+type Drain struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Reason            string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	RetryAfterSeconds int32                  `protobuf:"varint,2,opt,name=retry_after_seconds,json=retryAfterSeconds,proto3" json:"retry_after_seconds,omitempty"` // how long to stay away from this gateway when others are configured
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *CertHashInfo) Reset() {
-	*x = CertHashInfo{}
-	mi := &file_api_proto_agent_proto_msgTypes[13]
+func (x *Drain) Reset() {
+	*x = Drain{}
+	mi := &file_api_proto_agent_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CertHashInfo) String() string {
+func (x *Drain) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CertHashInfo) ProtoMessage() {}
+func (*Drain) ProtoMessage() {}
 
-func (x *CertHashInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[13]
+func (x *Drain) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1283,69 +1505,49 @@ func (x *CertHashInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CertHashInfo.ProtoReflect.Descriptor instead.
-func (*CertHashInfo) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *CertHashInfo) GetDomain() string {
-	if x != nil {
-		return x.Domain
-	}
-	return ""
-}
-
-func (x *CertHashInfo) GetCertPath() string {
-	if x != nil {
-		return x.CertPath
-	}
-	return ""
+// Deprecated: Use Drain.ProtoReflect.Descriptor instead.
+func (*Drain) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *CertHashInfo) GetCertHash() string {
+func (x *Drain) GetReason() string {
 	if x != nil {
-		return x.CertHash
+		return x.Reason
 	}
 	return ""
 }
 
-func (x *CertHashInfo) GetExpiryTimestamp() int64 {
+func (x *Drain) GetRetryAfterSeconds() int32 {
 	if x != nil {
-		return x.ExpiryTimestamp
+		return x.RetryAfterSeconds
 	}
 	return 0
 }
 
-func (x *CertHashInfo) GetIssuer() string {
-	if x != nil {
-		return x.Issuer
-	}
-	return ""
-}
-
-type ConfigPush struct {
+// ConfigureAgent changes agent runtime settings without a restart. Settings use the
+// avika-agent.conf keys; the agent persists them and acks with the values in effect.
+type ConfigureAgent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	VersionId     string                 `protobuf:"bytes,1,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
-	Files         map[string][]byte      `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // filename -> content
+	Settings      map[string]string      `protobuf:"bytes,1,rep,name=settings,proto3" json:"settings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigPush) Reset() {
-	*x = ConfigPush{}
-	mi := &file_api_proto_agent_proto_msgTypes[14]
+func (x *ConfigureAgent) Reset() {
+	*x = ConfigureAgent{}
+	mi := &file_api_proto_agent_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigPush) String() string {
+func (x *ConfigureAgent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigPush) ProtoMessage() {}
+func (*ConfigureAgent) ProtoMessage() {}
 
-func (x *ConfigPush) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[14]
+func (x *ConfigureAgent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1356,47 +1558,41 @@ func (x *ConfigPush) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigPush.ProtoReflect.Descriptor instead.
-func (*ConfigPush) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use ConfigureAgent.ProtoReflect.Descriptor instead.
+func (*ConfigureAgent) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ConfigPush) GetVersionId() string {
+func (x *ConfigureAgent) GetSettings() map[string]string {
 	if x != nil {
-		return x.VersionId
+		return x.Settings
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigPush) GetFiles() map[string][]byte {
-	if x != nil {
-		return x.Files
-	}
-	return nil
-}
-
-type Action struct {
+type Update struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "RELOAD", "RESTART"
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`                      // version to update to, or "latest"
+	UpdateUrl     string                 `protobuf:"bytes,2,opt,name=update_url,json=updateUrl,proto3" json:"update_url,omitempty"` // optional: override the agent's configured update server URL
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Action) Reset() {
-	*x = Action{}
-	mi := &file_api_proto_agent_proto_msgTypes[15]
+func (x *Update) Reset() {
+	*x = Update{}
+	mi := &file_api_proto_agent_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Action) String() string {
+func (x *Update) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Action) ProtoMessage() {}
+func (*Update) ProtoMessage() {}
 
-func (x *Action) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[15]
+func (x *Update) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1407,43 +1603,63 @@ func (x *Action) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Action.ProtoReflect.Descriptor instead.
-func (*Action) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use Update.ProtoReflect.Descriptor instead.
+func (*Update) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *Action) GetType() string {
+func (x *Update) GetVersion() string {
 	if x != nil {
-		return x.Type
+		return x.Version
 	}
 	return ""
 }
 
-type ConfigAugment struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AugmentId     string                 `protobuf:"bytes,1,opt,name=augment_id,json=augmentId,proto3" json:"augment_id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Snippet       string                 `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"` // NGINX config snippet
-	Context       string                 `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"` // "http", "server", "location"
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Update) GetUpdateUrl() string {
+	if x != nil {
+		return x.UpdateUrl
+	}
+	return ""
 }
 
-func (x *ConfigAugment) Reset() {
-	*x = ConfigAugment{}
-	mi := &file_api_proto_agent_proto_msgTypes[16]
+type Heartbeat struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Hostname              string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Version               string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // NGINX version
+	Uptime                float64                `protobuf:"fixed64,3,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Instances             []*NginxInstance       `protobuf:"bytes,4,rep,name=instances,proto3" json:"instances,omitempty"`
+	IsPod                 bool                   `protobuf:"varint,5,opt,name=is_pod,json=isPod,proto3" json:"is_pod,omitempty"`
+	PodIp                 string                 `protobuf:"bytes,6,opt,name=pod_ip,json=podIp,proto3" json:"pod_ip,omitempty"`
+	AgentVersion          string                 `protobuf:"bytes,7,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`                                            // Agent version
+	BuildDate             string                 `protobuf:"bytes,8,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`                                                     // Build timestamp
+	GitCommit             string                 `protobuf:"bytes,9,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`                                                     // Git commit hash
+	GitBranch             string                 `protobuf:"bytes,10,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`                                                    // Git branch name
+	Labels                map[string]string      `protobuf:"bytes,11,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Agent labels for auto-assignment (project, environment, etc.)
+	MgmtAddress           string                 `protobuf:"bytes,12,opt,name=mgmt_address,json=mgmtAddress,proto3" json:"mgmt_address,omitempty"`                                              // Optional host:port the gateway should use to dial this agent (e.g. 10.0.2.15:5025)
+	MgmtAddressCandidates []string               `protobuf:"bytes,13,rep,name=mgmt_address_candidates,json=mgmtAddressCandidates,proto3" json:"mgmt_address_candidates,omitempty"`              // All non-loopback host:port the gateway can probe to find a reachable address (K8s CNI, multi-NIC, Vagrant, etc.)
+	Kubernetes            *KubernetesMetadata    `protobuf:"bytes,14,opt,name=kubernetes,proto3" json:"kubernetes,omitempty"`                                                                   // Set when the agent runs in a pod
+	UpdateStatus          *UpdateStatus          `protobuf:"bytes,15,opt,name=update_status,json=updateStatus,proto3" json:"update_status,omitempty"`                                           // Outcome of the agent's last self-update, if any
+	Host                  *HostInventory         `protobuf:"bytes,16,opt,name=host,proto3" json:"host,omitempty"`                                                                               // OS, kernel and package versions, refreshed every few minutes
+	ResourceStatus        *AgentResourceStatus   `protobuf:"bytes,17,opt,name=resource_status,json=resourceStatus,proto3" json:"resource_status,omitempty"`                                     // Agent CPU and memory against its self-limits
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_api_proto_agent_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigAugment) String() string {
+func (x *Heartbeat) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigAugment) ProtoMessage() {}
+func (*Heartbeat) ProtoMessage() {}
 
-func (x *ConfigAugment) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[16]
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1454,96 +1670,159 @@ func (x *ConfigAugment) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigAugment.ProtoReflect.Descriptor instead.
-func (*ConfigAugment) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *ConfigAugment) GetAugmentId() string {
+func (x *Heartbeat) GetHostname() string {
 	if x != nil {
-		return x.AugmentId
+		return x.Hostname
 	}
 	return ""
 }
 
-func (x *ConfigAugment) GetName() string {
+func (x *Heartbeat) GetVersion() string {
 	if x != nil {
-		return x.Name
+		return x.Version
 	}
 	return ""
 }
 
-func (x *ConfigAugment) GetSnippet() string {
+func (x *Heartbeat) GetUptime() float64 {
 	if x != nil {
-		return x.Snippet
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *Heartbeat) GetInstances() []*NginxInstance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+func (x *Heartbeat) GetIsPod() bool {
+	if x != nil {
+		return x.IsPod
+	}
+	return false
+}
+
+func (x *Heartbeat) GetPodIp() string {
+	if x != nil {
+		return x.PodIp
 	}
 	return ""
 }
 
-func (x *ConfigAugment) GetContext() string {
+func (x *Heartbeat) GetAgentVersion() string {
 	if x != nil {
-		return x.Context
+		return x.AgentVersion
 	}
 	return ""
 }
 
-type ListAlertRulesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Heartbeat) GetBuildDate() string {
+	if x != nil {
+		return x.BuildDate
+	}
+	return ""
 }
 
-func (x *ListAlertRulesRequest) Reset() {
-	*x = ListAlertRulesRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[17]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Heartbeat) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
 }
 
-func (x *ListAlertRulesRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Heartbeat) GetGitBranch() string {
+	if x != nil {
+		return x.GitBranch
+	}
+	return ""
 }
 
-func (*ListAlertRulesRequest) ProtoMessage() {}
+func (x *Heartbeat) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
 
-func (x *ListAlertRulesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[17]
+func (x *Heartbeat) GetMgmtAddress() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.MgmtAddress
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use ListAlertRulesRequest.ProtoReflect.Descriptor instead.
-func (*ListAlertRulesRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{17}
+func (x *Heartbeat) GetMgmtAddressCandidates() []string {
+	if x != nil {
+		return x.MgmtAddressCandidates
+	}
+	return nil
 }
 
-type AlertRuleList struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Rules         []*AlertRule           `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Heartbeat) GetKubernetes() *KubernetesMetadata {
+	if x != nil {
+		return x.Kubernetes
+	}
+	return nil
 }
 
-func (x *AlertRuleList) Reset() {
-	*x = AlertRuleList{}
+func (x *Heartbeat) GetUpdateStatus() *UpdateStatus {
+	if x != nil {
+		return x.UpdateStatus
+	}
+	return nil
+}
+
+func (x *Heartbeat) GetHost() *HostInventory {
+	if x != nil {
+		return x.Host
+	}
+	return nil
+}
+
+func (x *Heartbeat) GetResourceStatus() *AgentResourceStatus {
+	if x != nil {
+		return x.ResourceStatus
+	}
+	return nil
+}
+
+// AgentResourceStatus is the agent's own resource use. While it stays over a configured limit the
+// agent degrades: log collection pauses and heartbeats and metrics slow down.
+type AgentResourceStatus struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Degraded         bool                   `protobuf:"varint,1,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	Reason           string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`                                                // which limit was exceeded, e.g. "cpu 41.0% over limit 25%"
+	DegradedSince    int64                  `protobuf:"varint,3,opt,name=degraded_since,json=degradedSince,proto3" json:"degraded_since,omitempty"`            // unix seconds, 0 when not degraded
+	CpuPercent       float32                `protobuf:"fixed32,4,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`                    // of one core, averaged since the previous sample
+	MemoryBytes      uint64                 `protobuf:"varint,5,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`                  // resident set size
+	CpuLimitPercent  float32                `protobuf:"fixed32,6,opt,name=cpu_limit_percent,json=cpuLimitPercent,proto3" json:"cpu_limit_percent,omitempty"`   // 0 when unlimited
+	MemoryLimitBytes uint64                 `protobuf:"varint,7,opt,name=memory_limit_bytes,json=memoryLimitBytes,proto3" json:"memory_limit_bytes,omitempty"` // 0 when unlimited
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AgentResourceStatus) Reset() {
+	*x = AgentResourceStatus{}
 	mi := &file_api_proto_agent_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AlertRuleList) String() string {
+func (x *AgentResourceStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AlertRuleList) ProtoMessage() {}
+func (*AgentResourceStatus) ProtoMessage() {}
 
-func (x *AlertRuleList) ProtoReflect() protoreflect.Message {
+func (x *AgentResourceStatus) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1555,39 +1834,87 @@ func (x *AlertRuleList) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AlertRuleList.ProtoReflect.Descriptor instead.
-func (*AlertRuleList) Descriptor() ([]byte, []int) {
+// Deprecated: Use AgentResourceStatus.ProtoReflect.Descriptor instead.
+func (*AgentResourceStatus) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *AlertRuleList) GetRules() []*AlertRule {
+func (x *AgentResourceStatus) GetDegraded() bool {
 	if x != nil {
-		return x.Rules
+		return x.Degraded
 	}
-	return nil
+	return false
 }
 
-type DeleteAlertRuleRequest struct {
+func (x *AgentResourceStatus) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AgentResourceStatus) GetDegradedSince() int64 {
+	if x != nil {
+		return x.DegradedSince
+	}
+	return 0
+}
+
+func (x *AgentResourceStatus) GetCpuPercent() float32 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *AgentResourceStatus) GetMemoryBytes() uint64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
+func (x *AgentResourceStatus) GetCpuLimitPercent() float32 {
+	if x != nil {
+		return x.CpuLimitPercent
+	}
+	return 0
+}
+
+func (x *AgentResourceStatus) GetMemoryLimitBytes() uint64 {
+	if x != nil {
+		return x.MemoryLimitBytes
+	}
+	return 0
+}
+
+// HostInventory is the agent host's OS and the versions of the packages CVEs are matched against
+type HostInventory struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OsId          string                 `protobuf:"bytes,1,opt,name=os_id,json=osId,proto3" json:"os_id,omitempty"`                // os-release ID, e.g. "ubuntu"
+	OsVersion     string                 `protobuf:"bytes,2,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"` // os-release VERSION_ID, e.g. "22.04"
+	OsName        string                 `protobuf:"bytes,3,opt,name=os_name,json=osName,proto3" json:"os_name,omitempty"`          // os-release PRETTY_NAME
+	Kernel        string                 `protobuf:"bytes,4,opt,name=kernel,proto3" json:"kernel,omitempty"`                        // running kernel release, e.g. "5.15.0-91-generic"
+	Arch          string                 `protobuf:"bytes,5,opt,name=arch,proto3" json:"arch,omitempty"`
+	Packages      []*PackageVersion      `protobuf:"bytes,6,rep,name=packages,proto3" json:"packages,omitempty"` // installed nginx and openssl packages
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteAlertRuleRequest) Reset() {
-	*x = DeleteAlertRuleRequest{}
+func (x *HostInventory) Reset() {
+	*x = HostInventory{}
 	mi := &file_api_proto_agent_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteAlertRuleRequest) String() string {
+func (x *HostInventory) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteAlertRuleRequest) ProtoMessage() {}
+func (*HostInventory) ProtoMessage() {}
 
-func (x *DeleteAlertRuleRequest) ProtoReflect() protoreflect.Message {
+func (x *HostInventory) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1599,39 +1926,76 @@ func (x *DeleteAlertRuleRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteAlertRuleRequest.ProtoReflect.Descriptor instead.
-func (*DeleteAlertRuleRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use HostInventory.ProtoReflect.Descriptor instead.
+func (*HostInventory) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *DeleteAlertRuleRequest) GetId() string {
+func (x *HostInventory) GetOsId() string {
 	if x != nil {
-		return x.Id
+		return x.OsId
 	}
 	return ""
 }
 
-type DeleteAlertRuleResponse struct {
+func (x *HostInventory) GetOsVersion() string {
+	if x != nil {
+		return x.OsVersion
+	}
+	return ""
+}
+
+func (x *HostInventory) GetOsName() string {
+	if x != nil {
+		return x.OsName
+	}
+	return ""
+}
+
+func (x *HostInventory) GetKernel() string {
+	if x != nil {
+		return x.Kernel
+	}
+	return ""
+}
+
+func (x *HostInventory) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *HostInventory) GetPackages() []*PackageVersion {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+type PackageVersion struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // as the package manager reports it, e.g. "1.18.0-6ubuntu14.4"
+	Manager       string                 `protobuf:"bytes,3,opt,name=manager,proto3" json:"manager,omitempty"` // dpkg, rpm or apk
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteAlertRuleResponse) Reset() {
-	*x = DeleteAlertRuleResponse{}
+func (x *PackageVersion) Reset() {
+	*x = PackageVersion{}
 	mi := &file_api_proto_agent_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteAlertRuleResponse) String() string {
+func (x *PackageVersion) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteAlertRuleResponse) ProtoMessage() {}
+func (*PackageVersion) ProtoMessage() {}
 
-func (x *DeleteAlertRuleResponse) ProtoReflect() protoreflect.Message {
+func (x *PackageVersion) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1643,49 +2007,61 @@ func (x *DeleteAlertRuleResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteAlertRuleResponse.ProtoReflect.Descriptor instead.
-func (*DeleteAlertRuleResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use PackageVersion.ProtoReflect.Descriptor instead.
+func (*PackageVersion) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *DeleteAlertRuleResponse) GetSuccess() bool {
+func (x *PackageVersion) GetName() string {
 	if x != nil {
-		return x.Success
+		return x.Name
 	}
-	return false
+	return ""
 }
 
-type AlertRule struct {
+func (x *PackageVersion) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PackageVersion) GetManager() string {
+	if x != nil {
+		return x.Manager
+	}
+	return ""
+}
+
+// UpdateStatus is the outcome of an agent self-update. After swapping binaries the new version
+// must start and reach a gateway within the health timeout, otherwise the agent restores the
+// previous binary.
+type UpdateStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	MetricType    string                 `protobuf:"bytes,3,opt,name=metric_type,json=metricType,proto3" json:"metric_type,omitempty"` // "cpu", "memory", "rps", "error_rate"
-	Threshold     float32                `protobuf:"fixed32,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
-	Comparison    string                 `protobuf:"bytes,5,opt,name=comparison,proto3" json:"comparison,omitempty"` // "gt", "lt", "eq", "gte", "lte", "rate_increase", "rate_decrease"
-	WindowSec     int32                  `protobuf:"varint,6,opt,name=window_sec,json=windowSec,proto3" json:"window_sec,omitempty"`
-	Enabled       bool                   `protobuf:"varint,7,opt,name=enabled,proto3" json:"enabled,omitempty"`
-	Recipients    string                 `protobuf:"bytes,8,opt,name=recipients,proto3" json:"recipients,omitempty"` // comma-separated emails or webhooks
-	CooldownSec   int32                  `protobuf:"varint,9,opt,name=cooldown_sec,json=cooldownSec,proto3" json:"cooldown_sec,omitempty"`
-	Severity      string                 `protobuf:"bytes,10,opt,name=severity,proto3" json:"severity,omitempty"`     // "info", "warning", "critical"
-	Conditions    string                 `protobuf:"bytes,11,opt,name=conditions,proto3" json:"conditions,omitempty"` // JSON-encoded multi-condition rule
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"` // "pending", "succeeded", "rolled_back" or "failed"
+	FromVersion   string                 `protobuf:"bytes,2,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	ToVersion     string                 `protobuf:"bytes,3,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`                              // why the update failed or was rolled back
+	StartedAt     int64                  `protobuf:"varint,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`    // unix seconds
+	FinishedAt    int64                  `protobuf:"varint,6,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"` // unix seconds; 0 while pending
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AlertRule) Reset() {
-	*x = AlertRule{}
+func (x *UpdateStatus) Reset() {
+	*x = UpdateStatus{}
 	mi := &file_api_proto_agent_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AlertRule) String() string {
+func (x *UpdateStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AlertRule) ProtoMessage() {}
+func (*UpdateStatus) ProtoMessage() {}
 
-func (x *AlertRule) ProtoReflect() protoreflect.Message {
+func (x *UpdateStatus) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1697,112 +2073,180 @@ func (x *AlertRule) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AlertRule.ProtoReflect.Descriptor instead.
-func (*AlertRule) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateStatus.ProtoReflect.Descriptor instead.
+func (*UpdateStatus) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *AlertRule) GetId() string {
+func (x *UpdateStatus) GetState() string {
 	if x != nil {
-		return x.Id
+		return x.State
 	}
 	return ""
 }
 
-func (x *AlertRule) GetName() string {
+func (x *UpdateStatus) GetFromVersion() string {
 	if x != nil {
-		return x.Name
+		return x.FromVersion
 	}
 	return ""
 }
 
-func (x *AlertRule) GetMetricType() string {
+func (x *UpdateStatus) GetToVersion() string {
 	if x != nil {
-		return x.MetricType
+		return x.ToVersion
 	}
 	return ""
 }
 
-func (x *AlertRule) GetThreshold() float32 {
+func (x *UpdateStatus) GetError() string {
 	if x != nil {
-		return x.Threshold
+		return x.Error
 	}
-	return 0
+	return ""
 }
 
-func (x *AlertRule) GetComparison() string {
+func (x *UpdateStatus) GetStartedAt() int64 {
 	if x != nil {
-		return x.Comparison
+		return x.StartedAt
 	}
-	return ""
+	return 0
 }
 
-func (x *AlertRule) GetWindowSec() int32 {
+func (x *UpdateStatus) GetFinishedAt() int64 {
 	if x != nil {
-		return x.WindowSec
+		return x.FinishedAt
 	}
 	return 0
 }
 
-func (x *AlertRule) GetEnabled() bool {
+// KubernetesMetadata identifies the pod an agent runs in (Downward API, falling back to the kube API)
+type KubernetesMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	PodName       string                 `protobuf:"bytes,2,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	PodUid        string                 `protobuf:"bytes,3,opt,name=pod_uid,json=podUid,proto3" json:"pod_uid,omitempty"`
+	NodeName      string                 `protobuf:"bytes,4,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	OwnerKind     string                 `protobuf:"bytes,5,opt,name=owner_kind,json=ownerKind,proto3" json:"owner_kind,omitempty"` // Deployment, DaemonSet, StatefulSet, Job...; ReplicaSets resolve to their Deployment
+	OwnerName     string                 `protobuf:"bytes,6,opt,name=owner_name,json=ownerName,proto3" json:"owner_name,omitempty"`
+	PodLabels     map[string]string      `protobuf:"bytes,7,rep,name=pod_labels,json=podLabels,proto3" json:"pod_labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KubernetesMetadata) Reset() {
+	*x = KubernetesMetadata{}
+	mi := &file_api_proto_agent_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KubernetesMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KubernetesMetadata) ProtoMessage() {}
+
+func (x *KubernetesMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[22]
 	if x != nil {
-		return x.Enabled
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *AlertRule) GetRecipients() string {
+// Deprecated: Use KubernetesMetadata.ProtoReflect.Descriptor instead.
+func (*KubernetesMetadata) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *KubernetesMetadata) GetNamespace() string {
 	if x != nil {
-		return x.Recipients
+		return x.Namespace
 	}
 	return ""
 }
 
-func (x *AlertRule) GetCooldownSec() int32 {
+func (x *KubernetesMetadata) GetPodName() string {
 	if x != nil {
-		return x.CooldownSec
+		return x.PodName
 	}
-	return 0
+	return ""
 }
 
-func (x *AlertRule) GetSeverity() string {
+func (x *KubernetesMetadata) GetPodUid() string {
 	if x != nil {
-		return x.Severity
+		return x.PodUid
 	}
 	return ""
 }
 
-func (x *AlertRule) GetConditions() string {
+func (x *KubernetesMetadata) GetNodeName() string {
 	if x != nil {
-		return x.Conditions
+		return x.NodeName
 	}
 	return ""
 }
 
-type ExecRequest struct {
+func (x *KubernetesMetadata) GetOwnerKind() string {
+	if x != nil {
+		return x.OwnerKind
+	}
+	return ""
+}
+
+func (x *KubernetesMetadata) GetOwnerName() string {
+	if x != nil {
+		return x.OwnerName
+	}
+	return ""
+}
+
+func (x *KubernetesMetadata) GetPodLabels() map[string]string {
+	if x != nil {
+		return x.PodLabels
+	}
+	return nil
+}
+
+type NginxInstance struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	Input         []byte                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`     // stdin
-	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"` // optional: command to run, e.g. /bin/bash
+	Pid           string                 `protobuf:"bytes,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	ConfPath      string                 `protobuf:"bytes,3,opt,name=conf_path,json=confPath,proto3" json:"conf_path,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                           // "RUNNING", "STOPPED"
+	Build         *NginxBuild            `protobuf:"bytes,5,opt,name=build,proto3" json:"build,omitempty"`                             // set on one instance per nginx binary
+	InstanceId    string                 `protobuf:"bytes,6,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"` // stable across restarts: the conf path, "<conf path>@<pid>" when masters share one
+	Prefix        string                 `protobuf:"bytes,7,opt,name=prefix,proto3" json:"prefix,omitempty"`                           // -p prefix, empty for the compiled-in default
+	WorkerCount   int32                  `protobuf:"varint,8,opt,name=worker_count,json=workerCount,proto3" json:"worker_count,omitempty"`
+	BinaryPath    string                 `protobuf:"bytes,9,opt,name=binary_path,json=binaryPath,proto3" json:"binary_path,omitempty"`
+	SystemdUnit   string                 `protobuf:"bytes,10,opt,name=systemd_unit,json=systemdUnit,proto3" json:"systemd_unit,omitempty"` // unit the master runs under, e.g. "nginx-api.service"
+	AccessLog     string                 `protobuf:"bytes,11,opt,name=access_log,json=accessLog,proto3" json:"access_log,omitempty"`       // first access_log of the configuration
+	ErrorLog      string                 `protobuf:"bytes,12,opt,name=error_log,json=errorLog,proto3" json:"error_log,omitempty"`          // first error_log of the configuration
+	Primary       bool                   `protobuf:"varint,13,opt,name=primary,proto3" json:"primary,omitempty"`                           // the instance commands without an nginx_instance_id target
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExecRequest) Reset() {
-	*x = ExecRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[22]
+func (x *NginxInstance) Reset() {
+	*x = NginxInstance{}
+	mi := &file_api_proto_agent_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExecRequest) String() string {
+func (x *NginxInstance) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExecRequest) ProtoMessage() {}
+func (*NginxInstance) ProtoMessage() {}
 
-func (x *ExecRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[22]
+func (x *NginxInstance) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1813,113 +2257,129 @@ func (x *ExecRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExecRequest.ProtoReflect.Descriptor instead.
-func (*ExecRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use NginxInstance.ProtoReflect.Descriptor instead.
+func (*NginxInstance) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *ExecRequest) GetInstanceId() string {
+func (x *NginxInstance) GetPid() string {
 	if x != nil {
-		return x.InstanceId
+		return x.Pid
 	}
 	return ""
 }
 
-func (x *ExecRequest) GetInput() []byte {
+func (x *NginxInstance) GetVersion() string {
 	if x != nil {
-		return x.Input
+		return x.Version
 	}
-	return nil
+	return ""
 }
 
-func (x *ExecRequest) GetCommand() string {
+func (x *NginxInstance) GetConfPath() string {
 	if x != nil {
-		return x.Command
+		return x.ConfPath
 	}
 	return ""
 }
 
-type ExecResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Output        []byte                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"` // stdout/stderr
-	ExitCode      int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *NginxInstance) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
 }
 
-func (x *ExecResponse) Reset() {
-	*x = ExecResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[23]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *NginxInstance) GetBuild() *NginxBuild {
+	if x != nil {
+		return x.Build
+	}
+	return nil
 }
 
-func (x *ExecResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *NginxInstance) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
 }
 
-func (*ExecResponse) ProtoMessage() {}
+func (x *NginxInstance) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
 
-func (x *ExecResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[23]
+func (x *NginxInstance) GetWorkerCount() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.WorkerCount
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use ExecResponse.ProtoReflect.Descriptor instead.
-func (*ExecResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{23}
+func (x *NginxInstance) GetBinaryPath() string {
+	if x != nil {
+		return x.BinaryPath
+	}
+	return ""
 }
 
-func (x *ExecResponse) GetOutput() []byte {
+func (x *NginxInstance) GetSystemdUnit() string {
 	if x != nil {
-		return x.Output
+		return x.SystemdUnit
 	}
-	return nil
+	return ""
 }
 
-func (x *ExecResponse) GetExitCode() int32 {
+func (x *NginxInstance) GetAccessLog() string {
 	if x != nil {
-		return x.ExitCode
+		return x.AccessLog
 	}
-	return 0
+	return ""
 }
 
-func (x *ExecResponse) GetError() string {
+func (x *NginxInstance) GetErrorLog() string {
 	if x != nil {
-		return x.Error
+		return x.ErrorLog
 	}
 	return ""
 }
 
-type UpdateAgentRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *NginxInstance) GetPrimary() bool {
+	if x != nil {
+		return x.Primary
+	}
+	return false
 }
 
-func (x *UpdateAgentRequest) Reset() {
-	*x = UpdateAgentRequest{}
+// NginxBuild is how an nginx binary was built (nginx -V) and the dynamic modules its config loads
+type NginxBuild struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BinaryPath     string                 `protobuf:"bytes,1,opt,name=binary_path,json=binaryPath,proto3" json:"binary_path,omitempty"`
+	Compiler       string                 `protobuf:"bytes,2,opt,name=compiler,proto3" json:"compiler,omitempty"`                                   // e.g. "gcc 12.2.0 (Debian 12.2.0-14)"
+	OpensslVersion string                 `protobuf:"bytes,3,opt,name=openssl_version,json=opensslVersion,proto3" json:"openssl_version,omitempty"` // OpenSSL it runs with, e.g. "OpenSSL 3.0.11 19 Sep 2023"
+	ConfigureArgs  []string               `protobuf:"bytes,4,rep,name=configure_args,json=configureArgs,proto3" json:"configure_args,omitempty"`
+	Modules        []string               `protobuf:"bytes,5,rep,name=modules,proto3" json:"modules,omitempty"`                                  // compiled in: --with-*_module, --add-module and --add-dynamic-module
+	LoadedModules  []string               `protobuf:"bytes,6,rep,name=loaded_modules,json=loadedModules,proto3" json:"loaded_modules,omitempty"` // load_module directives, e.g. "ngx_http_brotli_filter_module"
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *NginxBuild) Reset() {
+	*x = NginxBuild{}
 	mi := &file_api_proto_agent_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateAgentRequest) String() string {
+func (x *NginxBuild) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateAgentRequest) ProtoMessage() {}
+func (*NginxBuild) ProtoMessage() {}
 
-func (x *UpdateAgentRequest) ProtoReflect() protoreflect.Message {
+func (x *NginxBuild) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1931,40 +2391,77 @@ func (x *UpdateAgentRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateAgentRequest.ProtoReflect.Descriptor instead.
-func (*UpdateAgentRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use NginxBuild.ProtoReflect.Descriptor instead.
+func (*NginxBuild) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *UpdateAgentRequest) GetAgentId() string {
+func (x *NginxBuild) GetBinaryPath() string {
 	if x != nil {
-		return x.AgentId
+		return x.BinaryPath
 	}
 	return ""
 }
 
-type UpdateAgentResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *NginxBuild) GetCompiler() string {
+	if x != nil {
+		return x.Compiler
+	}
+	return ""
 }
 
-func (x *UpdateAgentResponse) Reset() {
-	*x = UpdateAgentResponse{}
+func (x *NginxBuild) GetOpensslVersion() string {
+	if x != nil {
+		return x.OpensslVersion
+	}
+	return ""
+}
+
+func (x *NginxBuild) GetConfigureArgs() []string {
+	if x != nil {
+		return x.ConfigureArgs
+	}
+	return nil
+}
+
+func (x *NginxBuild) GetModules() []string {
+	if x != nil {
+		return x.Modules
+	}
+	return nil
+}
+
+func (x *NginxBuild) GetLoadedModules() []string {
+	if x != nil {
+		return x.LoadedModules
+	}
+	return nil
+}
+
+type CommandResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CommandId       string                 `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	Success         bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ErrorMessage    string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	AppliedSettings map[string]string      `protobuf:"bytes,4,rep,name=applied_settings,json=appliedSettings,proto3" json:"applied_settings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // ConfigureAgent: the settings in effect after the command
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CommandResult) Reset() {
+	*x = CommandResult{}
 	mi := &file_api_proto_agent_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateAgentResponse) String() string {
+func (x *CommandResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateAgentResponse) ProtoMessage() {}
+func (*CommandResult) ProtoMessage() {}
 
-func (x *UpdateAgentResponse) ProtoReflect() protoreflect.Message {
+func (x *CommandResult) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1976,47 +2473,61 @@ func (x *UpdateAgentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateAgentResponse.ProtoReflect.Descriptor instead.
-func (*UpdateAgentResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CommandResult.ProtoReflect.Descriptor instead.
+func (*CommandResult) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *UpdateAgentResponse) GetSuccess() bool {
+func (x *CommandResult) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *CommandResult) GetSuccess() bool {
 	if x != nil {
 		return x.Success
 	}
 	return false
 }
 
-func (x *UpdateAgentResponse) GetMessage() string {
+func (x *CommandResult) GetErrorMessage() string {
 	if x != nil {
-		return x.Message
+		return x.ErrorMessage
 	}
 	return ""
 }
 
-type ConfigRequest struct {
+func (x *CommandResult) GetAppliedSettings() map[string]string {
+	if x != nil {
+		return x.AppliedSettings
+	}
+	return nil
+}
+
+type StateSnapshot struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	ConfigPath    string                 `protobuf:"bytes,2,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
+	ConfigHash    string                 `protobuf:"bytes,1,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`
+	ConfigHashes  *ConfigHashes          `protobuf:"bytes,2,opt,name=config_hashes,json=configHashes,proto3" json:"config_hashes,omitempty"` // Detailed config hashes for drift detection
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigRequest) Reset() {
-	*x = ConfigRequest{}
+func (x *StateSnapshot) Reset() {
+	*x = StateSnapshot{}
 	mi := &file_api_proto_agent_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigRequest) String() string {
+func (x *StateSnapshot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigRequest) ProtoMessage() {}
+func (*StateSnapshot) ProtoMessage() {}
 
-func (x *ConfigRequest) ProtoReflect() protoreflect.Message {
+func (x *StateSnapshot) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2028,48 +2539,52 @@ func (x *ConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigRequest.ProtoReflect.Descriptor instead.
-func (*ConfigRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use StateSnapshot.ProtoReflect.Descriptor instead.
+func (*StateSnapshot) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *ConfigRequest) GetInstanceId() string {
+func (x *StateSnapshot) GetConfigHash() string {
 	if x != nil {
-		return x.InstanceId
+		return x.ConfigHash
 	}
 	return ""
 }
 
-func (x *ConfigRequest) GetConfigPath() string {
+func (x *StateSnapshot) GetConfigHashes() *ConfigHashes {
 	if x != nil {
-		return x.ConfigPath
+		return x.ConfigHashes
 	}
-	return ""
+	return nil
 }
 
-type ConfigResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	Config        *NginxConfig           `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ConfigHashes struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	MainConfHash        string                 `protobuf:"bytes,1,opt,name=main_conf_hash,json=mainConfHash,proto3" json:"main_conf_hash,omitempty"`
+	MainConfModified    int64                  `protobuf:"varint,2,opt,name=main_conf_modified,json=mainConfModified,proto3" json:"main_conf_modified,omitempty"`
+	SiteConfigs         []*FileHash            `protobuf:"bytes,3,rep,name=site_configs,json=siteConfigs,proto3" json:"site_configs,omitempty"`
+	IncludeFiles        []*FileHash            `protobuf:"bytes,4,rep,name=include_files,json=includeFiles,proto3" json:"include_files,omitempty"`
+	Certificates        []*CertHashInfo        `protobuf:"bytes,5,rep,name=certificates,proto3" json:"certificates,omitempty"`
+	MaintenancePageHash string                 `protobuf:"bytes,6,opt,name=maintenance_page_hash,json=maintenancePageHash,proto3" json:"maintenance_page_hash,omitempty"`
+	ComputedAt          int64                  `protobuf:"varint,7,opt,name=computed_at,json=computedAt,proto3" json:"computed_at,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
-func (x *ConfigResponse) Reset() {
-	*x = ConfigResponse{}
+func (x *ConfigHashes) Reset() {
+	*x = ConfigHashes{}
 	mi := &file_api_proto_agent_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigResponse) String() string {
+func (x *ConfigHashes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigResponse) ProtoMessage() {}
+func (*ConfigHashes) ProtoMessage() {}
 
-func (x *ConfigResponse) ProtoReflect() protoreflect.Message {
+func (x *ConfigHashes) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2081,57 +2596,84 @@ func (x *ConfigResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigResponse.ProtoReflect.Descriptor instead.
-func (*ConfigResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigHashes.ProtoReflect.Descriptor instead.
+func (*ConfigHashes) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *ConfigResponse) GetInstanceId() string {
+func (x *ConfigHashes) GetMainConfHash() string {
 	if x != nil {
-		return x.InstanceId
+		return x.MainConfHash
 	}
 	return ""
 }
 
-func (x *ConfigResponse) GetConfig() *NginxConfig {
+func (x *ConfigHashes) GetMainConfModified() int64 {
 	if x != nil {
-		return x.Config
+		return x.MainConfModified
+	}
+	return 0
+}
+
+func (x *ConfigHashes) GetSiteConfigs() []*FileHash {
+	if x != nil {
+		return x.SiteConfigs
 	}
 	return nil
 }
 
-func (x *ConfigResponse) GetError() string {
+func (x *ConfigHashes) GetIncludeFiles() []*FileHash {
 	if x != nil {
-		return x.Error
+		return x.IncludeFiles
+	}
+	return nil
+}
+
+func (x *ConfigHashes) GetCertificates() []*CertHashInfo {
+	if x != nil {
+		return x.Certificates
+	}
+	return nil
+}
+
+func (x *ConfigHashes) GetMaintenancePageHash() string {
+	if x != nil {
+		return x.MaintenancePageHash
 	}
 	return ""
 }
 
-type NginxConfig struct {
+func (x *ConfigHashes) GetComputedAt() int64 {
+	if x != nil {
+		return x.ComputedAt
+	}
+	return 0
+}
+
+type FileHash struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConfigPath    string                 `protobuf:"bytes,1,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
-	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
-	Servers       []*ServerBlock         `protobuf:"bytes,3,rep,name=servers,proto3" json:"servers,omitempty"`
-	Upstreams     []*UpstreamBlock       `protobuf:"bytes,4,rep,name=upstreams,proto3" json:"upstreams,omitempty"`
-	LastModified  int64                  `protobuf:"varint,5,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedAt    int64                  `protobuf:"varint,4,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NginxConfig) Reset() {
-	*x = NginxConfig{}
+func (x *FileHash) Reset() {
+	*x = FileHash{}
 	mi := &file_api_proto_agent_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NginxConfig) String() string {
+func (x *FileHash) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NginxConfig) ProtoMessage() {}
+func (*FileHash) ProtoMessage() {}
 
-func (x *NginxConfig) ProtoReflect() protoreflect.Message {
+func (x *FileHash) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2143,72 +2685,64 @@ func (x *NginxConfig) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NginxConfig.ProtoReflect.Descriptor instead.
-func (*NginxConfig) Descriptor() ([]byte, []int) {
+// Deprecated: Use FileHash.ProtoReflect.Descriptor instead.
+func (*FileHash) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *NginxConfig) GetConfigPath() string {
+func (x *FileHash) GetPath() string {
 	if x != nil {
-		return x.ConfigPath
+		return x.Path
 	}
 	return ""
 }
 
-func (x *NginxConfig) GetContent() string {
+func (x *FileHash) GetHash() string {
 	if x != nil {
-		return x.Content
+		return x.Hash
 	}
 	return ""
 }
 
-func (x *NginxConfig) GetServers() []*ServerBlock {
-	if x != nil {
-		return x.Servers
-	}
-	return nil
-}
-
-func (x *NginxConfig) GetUpstreams() []*UpstreamBlock {
+func (x *FileHash) GetSize() int64 {
 	if x != nil {
-		return x.Upstreams
+		return x.Size
 	}
-	return nil
+	return 0
 }
 
-func (x *NginxConfig) GetLastModified() int64 {
+func (x *FileHash) GetModifiedAt() int64 {
 	if x != nil {
-		return x.LastModified
+		return x.ModifiedAt
 	}
 	return 0
 }
 
-type ServerBlock struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Listen        []string               `protobuf:"bytes,1,rep,name=listen,proto3" json:"listen,omitempty"`
-	ServerName    []string               `protobuf:"bytes,2,rep,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
-	Root          string                 `protobuf:"bytes,3,opt,name=root,proto3" json:"root,omitempty"`
-	Locations     []*LocationBlock       `protobuf:"bytes,4,rep,name=locations,proto3" json:"locations,omitempty"`
-	SslConfig     map[string]string      `protobuf:"bytes,5,rep,name=ssl_config,json=sslConfig,proto3" json:"ssl_config,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Directives    map[string]string      `protobuf:"bytes,6,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type CertHashInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Domain          string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	CertPath        string                 `protobuf:"bytes,2,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
+	CertHash        string                 `protobuf:"bytes,3,opt,name=cert_hash,json=certHash,proto3" json:"cert_hash,omitempty"`
+	ExpiryTimestamp int64                  `protobuf:"varint,4,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
+	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *ServerBlock) Reset() {
-	*x = ServerBlock{}
+func (x *CertHashInfo) Reset() {
+	*x = CertHashInfo{}
 	mi := &file_api_proto_agent_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ServerBlock) String() string {
+func (x *CertHashInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerBlock) ProtoMessage() {}
+func (*CertHashInfo) ProtoMessage() {}
 
-func (x *ServerBlock) ProtoReflect() protoreflect.Message {
+func (x *CertHashInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2220,77 +2754,68 @@ func (x *ServerBlock) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ServerBlock.ProtoReflect.Descriptor instead.
-func (*ServerBlock) Descriptor() ([]byte, []int) {
+// Deprecated: Use CertHashInfo.ProtoReflect.Descriptor instead.
+func (*CertHashInfo) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *ServerBlock) GetListen() []string {
+func (x *CertHashInfo) GetDomain() string {
 	if x != nil {
-		return x.Listen
+		return x.Domain
 	}
-	return nil
+	return ""
 }
 
-func (x *ServerBlock) GetServerName() []string {
+func (x *CertHashInfo) GetCertPath() string {
 	if x != nil {
-		return x.ServerName
-	}
-	return nil
-}
-
-func (x *ServerBlock) GetRoot() string {
-	if x != nil {
-		return x.Root
+		return x.CertPath
 	}
 	return ""
 }
 
-func (x *ServerBlock) GetLocations() []*LocationBlock {
+func (x *CertHashInfo) GetCertHash() string {
 	if x != nil {
-		return x.Locations
+		return x.CertHash
 	}
-	return nil
+	return ""
 }
 
-func (x *ServerBlock) GetSslConfig() map[string]string {
+func (x *CertHashInfo) GetExpiryTimestamp() int64 {
 	if x != nil {
-		return x.SslConfig
+		return x.ExpiryTimestamp
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerBlock) GetDirectives() map[string]string {
+func (x *CertHashInfo) GetIssuer() string {
 	if x != nil {
-		return x.Directives
+		return x.Issuer
 	}
-	return nil
+	return ""
 }
 
-type LocationBlock struct {
+type ConfigPush struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	MatchType     string                 `protobuf:"bytes,2,opt,name=match_type,json=matchType,proto3" json:"match_type,omitempty"`
-	Directives    map[string]string      `protobuf:"bytes,3,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ProxyPass     string                 `protobuf:"bytes,4,opt,name=proxy_pass,json=proxyPass,proto3" json:"proxy_pass,omitempty"`
+	VersionId     string                 `protobuf:"bytes,1,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	Files         map[string][]byte      `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // filename -> content
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LocationBlock) Reset() {
-	*x = LocationBlock{}
+func (x *ConfigPush) Reset() {
+	*x = ConfigPush{}
 	mi := &file_api_proto_agent_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LocationBlock) String() string {
+func (x *ConfigPush) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LocationBlock) ProtoMessage() {}
+func (*ConfigPush) ProtoMessage() {}
 
-func (x *LocationBlock) ProtoReflect() protoreflect.Message {
+func (x *ConfigPush) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2302,62 +2827,46 @@ func (x *LocationBlock) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LocationBlock.ProtoReflect.Descriptor instead.
-func (*LocationBlock) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigPush.ProtoReflect.Descriptor instead.
+func (*ConfigPush) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *LocationBlock) GetPath() string {
-	if x != nil {
-		return x.Path
-	}
-	return ""
-}
-
-func (x *LocationBlock) GetMatchType() string {
+func (x *ConfigPush) GetVersionId() string {
 	if x != nil {
-		return x.MatchType
+		return x.VersionId
 	}
 	return ""
 }
 
-func (x *LocationBlock) GetDirectives() map[string]string {
+func (x *ConfigPush) GetFiles() map[string][]byte {
 	if x != nil {
-		return x.Directives
+		return x.Files
 	}
 	return nil
 }
 
-func (x *LocationBlock) GetProxyPass() string {
-	if x != nil {
-		return x.ProxyPass
-	}
-	return ""
-}
-
-type UpstreamBlock struct {
+type Action struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Servers       []string               `protobuf:"bytes,2,rep,name=servers,proto3" json:"servers,omitempty"`
-	Directives    map[string]string      `protobuf:"bytes,3,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "RELOAD", "RESTART"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpstreamBlock) Reset() {
-	*x = UpstreamBlock{}
+func (x *Action) Reset() {
+	*x = Action{}
 	mi := &file_api_proto_agent_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpstreamBlock) String() string {
+func (x *Action) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpstreamBlock) ProtoMessage() {}
+func (*Action) ProtoMessage() {}
 
-func (x *UpstreamBlock) ProtoReflect() protoreflect.Message {
+func (x *Action) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2369,56 +2878,42 @@ func (x *UpstreamBlock) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpstreamBlock.ProtoReflect.Descriptor instead.
-func (*UpstreamBlock) Descriptor() ([]byte, []int) {
+// Deprecated: Use Action.ProtoReflect.Descriptor instead.
+func (*Action) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *UpstreamBlock) GetName() string {
+func (x *Action) GetType() string {
 	if x != nil {
-		return x.Name
+		return x.Type
 	}
 	return ""
 }
 
-func (x *UpstreamBlock) GetServers() []string {
-	if x != nil {
-		return x.Servers
-	}
-	return nil
-}
-
-func (x *UpstreamBlock) GetDirectives() map[string]string {
-	if x != nil {
-		return x.Directives
-	}
-	return nil
-}
-
-type ConfigUpdate struct {
+type ConfigAugment struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	ConfigPath    string                 `protobuf:"bytes,2,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
-	NewContent    string                 `protobuf:"bytes,3,opt,name=new_content,json=newContent,proto3" json:"new_content,omitempty"`
-	Backup        bool                   `protobuf:"varint,4,opt,name=backup,proto3" json:"backup,omitempty"`
+	AugmentId     string                 `protobuf:"bytes,1,opt,name=augment_id,json=augmentId,proto3" json:"augment_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Snippet       string                 `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"` // NGINX config snippet
+	Context       string                 `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"` // "http", "server", "location"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigUpdate) Reset() {
-	*x = ConfigUpdate{}
+func (x *ConfigAugment) Reset() {
+	*x = ConfigAugment{}
 	mi := &file_api_proto_agent_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigUpdate) String() string {
+func (x *ConfigAugment) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigUpdate) ProtoMessage() {}
+func (*ConfigAugment) ProtoMessage() {}
 
-func (x *ConfigUpdate) ProtoReflect() protoreflect.Message {
+func (x *ConfigAugment) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2430,62 +2925,59 @@ func (x *ConfigUpdate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigUpdate.ProtoReflect.Descriptor instead.
-func (*ConfigUpdate) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigAugment.ProtoReflect.Descriptor instead.
+func (*ConfigAugment) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *ConfigUpdate) GetInstanceId() string {
+func (x *ConfigAugment) GetAugmentId() string {
 	if x != nil {
-		return x.InstanceId
+		return x.AugmentId
 	}
 	return ""
 }
 
-func (x *ConfigUpdate) GetConfigPath() string {
+func (x *ConfigAugment) GetName() string {
 	if x != nil {
-		return x.ConfigPath
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ConfigUpdate) GetNewContent() string {
+func (x *ConfigAugment) GetSnippet() string {
 	if x != nil {
-		return x.NewContent
+		return x.Snippet
 	}
 	return ""
 }
 
-func (x *ConfigUpdate) GetBackup() bool {
+func (x *ConfigAugment) GetContext() string {
 	if x != nil {
-		return x.Backup
+		return x.Context
 	}
-	return false
+	return ""
 }
 
-type ConfigUpdateResponse struct {
+type ListAlertRulesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-	BackupPath    string                 `protobuf:"bytes,3,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigUpdateResponse) Reset() {
-	*x = ConfigUpdateResponse{}
+func (x *ListAlertRulesRequest) Reset() {
+	*x = ListAlertRulesRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigUpdateResponse) String() string {
+func (x *ListAlertRulesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigUpdateResponse) ProtoMessage() {}
+func (*ListAlertRulesRequest) ProtoMessage() {}
 
-func (x *ConfigUpdateResponse) ProtoReflect() protoreflect.Message {
+func (x *ListAlertRulesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2497,54 +2989,32 @@ func (x *ConfigUpdateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigUpdateResponse.ProtoReflect.Descriptor instead.
-func (*ConfigUpdateResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListAlertRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListAlertRulesRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *ConfigUpdateResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *ConfigUpdateResponse) GetError() string {
-	if x != nil {
-		return x.Error
-	}
-	return ""
-}
-
-func (x *ConfigUpdateResponse) GetBackupPath() string {
-	if x != nil {
-		return x.BackupPath
-	}
-	return ""
-}
-
-type ConfigValidation struct {
+type AlertRuleList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	ConfigContent string                 `protobuf:"bytes,2,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
+	Rules         []*AlertRule           `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigValidation) Reset() {
-	*x = ConfigValidation{}
+func (x *AlertRuleList) Reset() {
+	*x = AlertRuleList{}
 	mi := &file_api_proto_agent_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigValidation) String() string {
+func (x *AlertRuleList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigValidation) ProtoMessage() {}
+func (*AlertRuleList) ProtoMessage() {}
 
-func (x *ConfigValidation) ProtoReflect() protoreflect.Message {
+func (x *AlertRuleList) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2556,48 +3026,39 @@ func (x *ConfigValidation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigValidation.ProtoReflect.Descriptor instead.
-func (*ConfigValidation) Descriptor() ([]byte, []int) {
+// Deprecated: Use AlertRuleList.ProtoReflect.Descriptor instead.
+func (*AlertRuleList) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *ConfigValidation) GetInstanceId() string {
-	if x != nil {
-		return x.InstanceId
-	}
-	return ""
-}
-
-func (x *ConfigValidation) GetConfigContent() string {
+func (x *AlertRuleList) GetRules() []*AlertRule {
 	if x != nil {
-		return x.ConfigContent
+		return x.Rules
 	}
-	return ""
+	return nil
 }
 
-type ValidationResult struct {
+type DeleteAlertRuleRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
-	Errors        []string               `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
-	Warnings      []string               `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ValidationResult) Reset() {
-	*x = ValidationResult{}
+func (x *DeleteAlertRuleRequest) Reset() {
+	*x = DeleteAlertRuleRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ValidationResult) String() string {
+func (x *DeleteAlertRuleRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ValidationResult) ProtoMessage() {}
+func (*DeleteAlertRuleRequest) ProtoMessage() {}
 
-func (x *ValidationResult) ProtoReflect() protoreflect.Message {
+func (x *DeleteAlertRuleRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2609,53 +3070,39 @@ func (x *ValidationResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ValidationResult.ProtoReflect.Descriptor instead.
-func (*ValidationResult) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteAlertRuleRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAlertRuleRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *ValidationResult) GetValid() bool {
+func (x *DeleteAlertRuleRequest) GetId() string {
 	if x != nil {
-		return x.Valid
+		return x.Id
 	}
-	return false
+	return ""
 }
 
-func (x *ValidationResult) GetErrors() []string {
-	if x != nil {
-		return x.Errors
-	}
-	return nil
+type DeleteAlertRuleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ValidationResult) GetWarnings() []string {
-	if x != nil {
-		return x.Warnings
-	}
-	return nil
-}
-
-type ReloadRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ReloadRequest) Reset() {
-	*x = ReloadRequest{}
+func (x *DeleteAlertRuleResponse) Reset() {
+	*x = DeleteAlertRuleResponse{}
 	mi := &file_api_proto_agent_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReloadRequest) String() string {
+func (x *DeleteAlertRuleResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReloadRequest) ProtoMessage() {}
+func (*DeleteAlertRuleResponse) ProtoMessage() {}
 
-func (x *ReloadRequest) ProtoReflect() protoreflect.Message {
+func (x *DeleteAlertRuleResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2667,40 +3114,53 @@ func (x *ReloadRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReloadRequest.ProtoReflect.Descriptor instead.
-func (*ReloadRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteAlertRuleResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAlertRuleResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *ReloadRequest) GetInstanceId() string {
+func (x *DeleteAlertRuleResponse) GetSuccess() bool {
 	if x != nil {
-		return x.InstanceId
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-type ReloadResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type AlertRule struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	MetricType     string                 `protobuf:"bytes,3,opt,name=metric_type,json=metricType,proto3" json:"metric_type,omitempty"` // "cpu", "memory", "rps", "error_rate"
+	Threshold      float32                `protobuf:"fixed32,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Comparison     string                 `protobuf:"bytes,5,opt,name=comparison,proto3" json:"comparison,omitempty"` // "gt", "lt", "eq", "gte", "lte", "rate_increase", "rate_decrease"
+	WindowSec      int32                  `protobuf:"varint,6,opt,name=window_sec,json=windowSec,proto3" json:"window_sec,omitempty"`
+	Enabled        bool                   `protobuf:"varint,7,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Recipients     string                 `protobuf:"bytes,8,opt,name=recipients,proto3" json:"recipients,omitempty"` // comma-separated emails or webhooks
+	CooldownSec    int32                  `protobuf:"varint,9,opt,name=cooldown_sec,json=cooldownSec,proto3" json:"cooldown_sec,omitempty"`
+	Severity       string                 `protobuf:"bytes,10,opt,name=severity,proto3" json:"severity,omitempty"`                                      // "info", "warning", "critical"
+	Conditions     string                 `protobuf:"bytes,11,opt,name=conditions,proto3" json:"conditions,omitempty"`                                  // JSON-encoded multi-condition rule
+	Action         string                 `protobuf:"bytes,12,opt,name=action,proto3" json:"action,omitempty"`                                          // "" notifies only; "ban" also pushes temporary deny rules for the offending clients
+	BanDurationSec int32                  `protobuf:"varint,13,opt,name=ban_duration_sec,json=banDurationSec,proto3" json:"ban_duration_sec,omitempty"` // How long a "ban" action blocks each client
+	ProjectId      string                 `protobuf:"bytes,14,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`                   // Only the project's agents are evaluated; "" for the whole fleet
+	Pack           string                 `protobuf:"bytes,15,opt,name=pack,proto3" json:"pack,omitempty"`                                              // "<pack>/<template>" for rules created from a built-in rule pack, "" otherwise
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *ReloadResponse) Reset() {
-	*x = ReloadResponse{}
+func (x *AlertRule) Reset() {
+	*x = AlertRule{}
 	mi := &file_api_proto_agent_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReloadResponse) String() string {
+func (x *AlertRule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReloadResponse) ProtoMessage() {}
+func (*AlertRule) ProtoMessage() {}
 
-func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
+func (x *AlertRule) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2712,46 +3172,139 @@ func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReloadResponse.ProtoReflect.Descriptor instead.
-func (*ReloadResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use AlertRule.ProtoReflect.Descriptor instead.
+func (*AlertRule) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *ReloadResponse) GetSuccess() bool {
+func (x *AlertRule) GetId() string {
 	if x != nil {
-		return x.Success
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AlertRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AlertRule) GetMetricType() string {
+	if x != nil {
+		return x.MetricType
+	}
+	return ""
+}
+
+func (x *AlertRule) GetThreshold() float32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *AlertRule) GetComparison() string {
+	if x != nil {
+		return x.Comparison
+	}
+	return ""
+}
+
+func (x *AlertRule) GetWindowSec() int32 {
+	if x != nil {
+		return x.WindowSec
+	}
+	return 0
+}
+
+func (x *AlertRule) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
 	}
 	return false
 }
 
-func (x *ReloadResponse) GetError() string {
+func (x *AlertRule) GetRecipients() string {
 	if x != nil {
-		return x.Error
+		return x.Recipients
 	}
 	return ""
 }
 
-type RestartRequest struct {
+func (x *AlertRule) GetCooldownSec() int32 {
+	if x != nil {
+		return x.CooldownSec
+	}
+	return 0
+}
+
+func (x *AlertRule) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *AlertRule) GetConditions() string {
+	if x != nil {
+		return x.Conditions
+	}
+	return ""
+}
+
+func (x *AlertRule) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AlertRule) GetBanDurationSec() int32 {
+	if x != nil {
+		return x.BanDurationSec
+	}
+	return 0
+}
+
+func (x *AlertRule) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *AlertRule) GetPack() string {
+	if x != nil {
+		return x.Pack
+	}
+	return ""
+}
+
+type ExecRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Input         []byte                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`     // stdin
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"` // optional: command to run, e.g. /bin/bash
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RestartRequest) Reset() {
-	*x = RestartRequest{}
+func (x *ExecRequest) Reset() {
+	*x = ExecRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RestartRequest) String() string {
+func (x *ExecRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RestartRequest) ProtoMessage() {}
+func (*ExecRequest) ProtoMessage() {}
 
-func (x *RestartRequest) ProtoReflect() protoreflect.Message {
+func (x *ExecRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2763,40 +3316,55 @@ func (x *RestartRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RestartRequest.ProtoReflect.Descriptor instead.
-func (*RestartRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExecRequest.ProtoReflect.Descriptor instead.
+func (*ExecRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *RestartRequest) GetInstanceId() string {
+func (x *ExecRequest) GetInstanceId() string {
 	if x != nil {
 		return x.InstanceId
 	}
 	return ""
 }
 
-type RestartResponse struct {
+func (x *ExecRequest) GetInput() []byte {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *ExecRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type ExecResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Output        []byte                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"` // stdout/stderr
+	ExitCode      int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RestartResponse) Reset() {
-	*x = RestartResponse{}
+func (x *ExecResponse) Reset() {
+	*x = ExecResponse{}
 	mi := &file_api_proto_agent_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RestartResponse) String() string {
+func (x *ExecResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RestartResponse) ProtoMessage() {}
+func (*ExecResponse) ProtoMessage() {}
 
-func (x *RestartResponse) ProtoReflect() protoreflect.Message {
+func (x *ExecResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2808,46 +3376,54 @@ func (x *RestartResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RestartResponse.ProtoReflect.Descriptor instead.
-func (*RestartResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExecResponse.ProtoReflect.Descriptor instead.
+func (*ExecResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *RestartResponse) GetSuccess() bool {
+func (x *ExecResponse) GetOutput() []byte {
 	if x != nil {
-		return x.Success
+		return x.Output
 	}
-	return false
+	return nil
 }
 
-func (x *RestartResponse) GetError() string {
+func (x *ExecResponse) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExecResponse) GetError() string {
 	if x != nil {
 		return x.Error
 	}
 	return ""
 }
 
-type StopRequest struct {
+type UpdateAgentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // version to update to; empty for the release the gateway picks for the agent
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StopRequest) Reset() {
-	*x = StopRequest{}
+func (x *UpdateAgentRequest) Reset() {
+	*x = UpdateAgentRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StopRequest) String() string {
+func (x *UpdateAgentRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopRequest) ProtoMessage() {}
+func (*UpdateAgentRequest) ProtoMessage() {}
 
-func (x *StopRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateAgentRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2859,40 +3435,46 @@ func (x *StopRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
-func (*StopRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateAgentRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAgentRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{40}
 }
 
-func (x *StopRequest) GetInstanceId() string {
+func (x *UpdateAgentRequest) GetAgentId() string {
 	if x != nil {
-		return x.InstanceId
+		return x.AgentId
 	}
 	return ""
 }
 
-type StopResponse struct {
+func (x *UpdateAgentRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type GetUpdateStatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StopResponse) Reset() {
-	*x = StopResponse{}
+func (x *GetUpdateStatusRequest) Reset() {
+	*x = GetUpdateStatusRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StopResponse) String() string {
+func (x *GetUpdateStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopResponse) ProtoMessage() {}
+func (*GetUpdateStatusRequest) ProtoMessage() {}
 
-func (x *StopResponse) ProtoReflect() protoreflect.Message {
+func (x *GetUpdateStatusRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2904,46 +3486,40 @@ func (x *StopResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
-func (*StopResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetUpdateStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetUpdateStatusRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{41}
 }
 
-func (x *StopResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *StopResponse) GetError() string {
+func (x *GetUpdateStatusRequest) GetAgentId() string {
 	if x != nil {
-		return x.Error
+		return x.AgentId
 	}
 	return ""
 }
 
-type CertListRequest struct {
+type UpdateAgentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CertListRequest) Reset() {
-	*x = CertListRequest{}
+func (x *UpdateAgentResponse) Reset() {
+	*x = UpdateAgentResponse{}
 	mi := &file_api_proto_agent_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CertListRequest) String() string {
+func (x *UpdateAgentResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CertListRequest) ProtoMessage() {}
+func (*UpdateAgentResponse) ProtoMessage() {}
 
-func (x *CertListRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateAgentResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2955,39 +3531,49 @@ func (x *CertListRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CertListRequest.ProtoReflect.Descriptor instead.
-func (*CertListRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateAgentResponse.ProtoReflect.Descriptor instead.
+func (*UpdateAgentResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *CertListRequest) GetInstanceId() string {
+func (x *UpdateAgentResponse) GetSuccess() bool {
 	if x != nil {
-		return x.InstanceId
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateAgentResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-type CertListResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Certificates  []*Certificate         `protobuf:"bytes,1,rep,name=certificates,proto3" json:"certificates,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ConfigRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	ConfigPath      string                 `protobuf:"bytes,2,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
+	Structured      bool                   `protobuf:"varint,3,opt,name=structured,proto3" json:"structured,omitempty"`                                   // Also return the parsed directive tree (main file + includes)
+	NginxInstanceId string                 `protobuf:"bytes,4,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"` // NginxInstance.instance_id; empty for the primary instance
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *CertListResponse) Reset() {
-	*x = CertListResponse{}
+func (x *ConfigRequest) Reset() {
+	*x = ConfigRequest{}
 	mi := &file_api_proto_agent_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CertListResponse) String() string {
+func (x *ConfigRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CertListResponse) ProtoMessage() {}
+func (*ConfigRequest) ProtoMessage() {}
 
-func (x *CertListResponse) ProtoReflect() protoreflect.Message {
+func (x *ConfigRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2999,45 +3585,62 @@ func (x *CertListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CertListResponse.ProtoReflect.Descriptor instead.
-func (*CertListResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigRequest.ProtoReflect.Descriptor instead.
+func (*ConfigRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *CertListResponse) GetCertificates() []*Certificate {
+func (x *ConfigRequest) GetInstanceId() string {
 	if x != nil {
-		return x.Certificates
+		return x.InstanceId
 	}
-	return nil
+	return ""
 }
 
-type Certificate struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Domain          string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
-	CertPath        string                 `protobuf:"bytes,2,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
-	KeyPath         string                 `protobuf:"bytes,3,opt,name=key_path,json=keyPath,proto3" json:"key_path,omitempty"`
-	ExpiryTimestamp int64                  `protobuf:"varint,4,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
-	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
-	SanDomains      []string               `protobuf:"bytes,6,rep,name=san_domains,json=sanDomains,proto3" json:"san_domains,omitempty"`
-	DaysUntilExpiry int32                  `protobuf:"varint,7,opt,name=days_until_expiry,json=daysUntilExpiry,proto3" json:"days_until_expiry,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *ConfigRequest) GetConfigPath() string {
+	if x != nil {
+		return x.ConfigPath
+	}
+	return ""
 }
 
-func (x *Certificate) Reset() {
-	*x = Certificate{}
+func (x *ConfigRequest) GetStructured() bool {
+	if x != nil {
+		return x.Structured
+	}
+	return false
+}
+
+func (x *ConfigRequest) GetNginxInstanceId() string {
+	if x != nil {
+		return x.NginxInstanceId
+	}
+	return ""
+}
+
+type ConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Config        *NginxConfig           `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigResponse) Reset() {
+	*x = ConfigResponse{}
 	mi := &file_api_proto_agent_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Certificate) String() string {
+func (x *ConfigResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Certificate) ProtoMessage() {}
+func (*ConfigResponse) ProtoMessage() {}
 
-func (x *Certificate) ProtoReflect() protoreflect.Message {
+func (x *ConfigResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3049,81 +3652,58 @@ func (x *Certificate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
-func (*Certificate) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigResponse.ProtoReflect.Descriptor instead.
+func (*ConfigResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{44}
 }
 
-func (x *Certificate) GetDomain() string {
-	if x != nil {
-		return x.Domain
-	}
-	return ""
-}
-
-func (x *Certificate) GetCertPath() string {
-	if x != nil {
-		return x.CertPath
-	}
-	return ""
-}
-
-func (x *Certificate) GetKeyPath() string {
-	if x != nil {
-		return x.KeyPath
-	}
-	return ""
-}
-
-func (x *Certificate) GetExpiryTimestamp() int64 {
-	if x != nil {
-		return x.ExpiryTimestamp
-	}
-	return 0
-}
-
-func (x *Certificate) GetIssuer() string {
+func (x *ConfigResponse) GetInstanceId() string {
 	if x != nil {
-		return x.Issuer
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *Certificate) GetSanDomains() []string {
+func (x *ConfigResponse) GetConfig() *NginxConfig {
 	if x != nil {
-		return x.SanDomains
+		return x.Config
 	}
 	return nil
 }
 
-func (x *Certificate) GetDaysUntilExpiry() int32 {
+func (x *ConfigResponse) GetError() string {
 	if x != nil {
-		return x.DaysUntilExpiry
+		return x.Error
 	}
-	return 0
+	return ""
 }
 
-// ============ Log Messages ============
-type ListAgentsRequest struct {
+type NginxConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConfigPath    string                 `protobuf:"bytes,1,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Servers       []*ServerBlock         `protobuf:"bytes,3,rep,name=servers,proto3" json:"servers,omitempty"`
+	Upstreams     []*UpstreamBlock       `protobuf:"bytes,4,rep,name=upstreams,proto3" json:"upstreams,omitempty"`
+	LastModified  int64                  `protobuf:"varint,5,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	Files         []*ConfigFile          `protobuf:"bytes,6,rep,name=files,proto3" json:"files,omitempty"` // Structured parse, populated when ConfigRequest.structured is set
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListAgentsRequest) Reset() {
-	*x = ListAgentsRequest{}
+func (x *NginxConfig) Reset() {
+	*x = NginxConfig{}
 	mi := &file_api_proto_agent_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListAgentsRequest) String() string {
+func (x *NginxConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListAgentsRequest) ProtoMessage() {}
+func (*NginxConfig) ProtoMessage() {}
 
-func (x *ListAgentsRequest) ProtoReflect() protoreflect.Message {
+func (x *NginxConfig) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3135,33 +3715,77 @@ func (x *ListAgentsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListAgentsRequest.ProtoReflect.Descriptor instead.
-func (*ListAgentsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use NginxConfig.ProtoReflect.Descriptor instead.
+func (*NginxConfig) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{45}
 }
 
-type ListAgentsResponse struct {
+func (x *NginxConfig) GetConfigPath() string {
+	if x != nil {
+		return x.ConfigPath
+	}
+	return ""
+}
+
+func (x *NginxConfig) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *NginxConfig) GetServers() []*ServerBlock {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+func (x *NginxConfig) GetUpstreams() []*UpstreamBlock {
+	if x != nil {
+		return x.Upstreams
+	}
+	return nil
+}
+
+func (x *NginxConfig) GetLastModified() int64 {
+	if x != nil {
+		return x.LastModified
+	}
+	return 0
+}
+
+func (x *NginxConfig) GetFiles() []*ConfigFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+// ConfigFile is one parsed file of an NGINX configuration (crossplane-style).
+type ConfigFile struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Agents        []*AgentInfo           `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
-	SystemVersion string                 `protobuf:"bytes,2,opt,name=system_version,json=systemVersion,proto3" json:"system_version,omitempty"` // Current system version (from VERSION file)
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Parsed        []*ConfigDirective     `protobuf:"bytes,2,rep,name=parsed,proto3" json:"parsed,omitempty"`
+	Errors        []string               `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListAgentsResponse) Reset() {
-	*x = ListAgentsResponse{}
+func (x *ConfigFile) Reset() {
+	*x = ConfigFile{}
 	mi := &file_api_proto_agent_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListAgentsResponse) String() string {
+func (x *ConfigFile) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListAgentsResponse) ProtoMessage() {}
+func (*ConfigFile) ProtoMessage() {}
 
-func (x *ListAgentsResponse) ProtoReflect() protoreflect.Message {
+func (x *ConfigFile) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3173,46 +3797,61 @@ func (x *ListAgentsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListAgentsResponse.ProtoReflect.Descriptor instead.
-func (*ListAgentsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigFile.ProtoReflect.Descriptor instead.
+func (*ConfigFile) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *ListAgentsResponse) GetAgents() []*AgentInfo {
+func (x *ConfigFile) GetFile() string {
 	if x != nil {
-		return x.Agents
+		return x.File
+	}
+	return ""
+}
+
+func (x *ConfigFile) GetParsed() []*ConfigDirective {
+	if x != nil {
+		return x.Parsed
 	}
 	return nil
 }
 
-func (x *ListAgentsResponse) GetSystemVersion() string {
+func (x *ConfigFile) GetErrors() []string {
 	if x != nil {
-		return x.SystemVersion
+		return x.Errors
 	}
-	return ""
+	return nil
 }
 
-type RemoveAgentRequest struct {
+// ConfigDirective is a single directive; block directives (http, server, location...) carry children.
+// Comments are represented as directive "#" with the text in comment.
+type ConfigDirective struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Directive     string                 `protobuf:"bytes,1,opt,name=directive,proto3" json:"directive,omitempty"`
+	Args          []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	Block         []*ConfigDirective     `protobuf:"bytes,4,rep,name=block,proto3" json:"block,omitempty"`
+	IsBlock       bool                   `protobuf:"varint,5,opt,name=is_block,json=isBlock,proto3" json:"is_block,omitempty"`
+	Comment       string                 `protobuf:"bytes,6,opt,name=comment,proto3" json:"comment,omitempty"`
+	Includes      []int32                `protobuf:"varint,7,rep,packed,name=includes,proto3" json:"includes,omitempty"` // Indexes into NginxConfig.files for include directives
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RemoveAgentRequest) Reset() {
-	*x = RemoveAgentRequest{}
+func (x *ConfigDirective) Reset() {
+	*x = ConfigDirective{}
 	mi := &file_api_proto_agent_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RemoveAgentRequest) String() string {
+func (x *ConfigDirective) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RemoveAgentRequest) ProtoMessage() {}
+func (*ConfigDirective) ProtoMessage() {}
 
-func (x *RemoveAgentRequest) ProtoReflect() protoreflect.Message {
+func (x *ConfigDirective) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3224,39 +3863,86 @@ func (x *RemoveAgentRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RemoveAgentRequest.ProtoReflect.Descriptor instead.
-func (*RemoveAgentRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigDirective.ProtoReflect.Descriptor instead.
+func (*ConfigDirective) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *RemoveAgentRequest) GetAgentId() string {
+func (x *ConfigDirective) GetDirective() string {
 	if x != nil {
-		return x.AgentId
+		return x.Directive
 	}
 	return ""
 }
 
-type RemoveAgentResponse struct {
+func (x *ConfigDirective) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *ConfigDirective) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ConfigDirective) GetBlock() []*ConfigDirective {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *ConfigDirective) GetIsBlock() bool {
+	if x != nil {
+		return x.IsBlock
+	}
+	return false
+}
+
+func (x *ConfigDirective) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *ConfigDirective) GetIncludes() []int32 {
+	if x != nil {
+		return x.Includes
+	}
+	return nil
+}
+
+type ServerBlock struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Listen        []string               `protobuf:"bytes,1,rep,name=listen,proto3" json:"listen,omitempty"`
+	ServerName    []string               `protobuf:"bytes,2,rep,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	Root          string                 `protobuf:"bytes,3,opt,name=root,proto3" json:"root,omitempty"`
+	Locations     []*LocationBlock       `protobuf:"bytes,4,rep,name=locations,proto3" json:"locations,omitempty"`
+	SslConfig     map[string]string      `protobuf:"bytes,5,rep,name=ssl_config,json=sslConfig,proto3" json:"ssl_config,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Directives    map[string]string      `protobuf:"bytes,6,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RemoveAgentResponse) Reset() {
-	*x = RemoveAgentResponse{}
+func (x *ServerBlock) Reset() {
+	*x = ServerBlock{}
 	mi := &file_api_proto_agent_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RemoveAgentResponse) String() string {
+func (x *ServerBlock) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RemoveAgentResponse) ProtoMessage() {}
+func (*ServerBlock) ProtoMessage() {}
 
-func (x *RemoveAgentResponse) ProtoReflect() protoreflect.Message {
+func (x *ServerBlock) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3268,39 +3954,77 @@ func (x *RemoveAgentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RemoveAgentResponse.ProtoReflect.Descriptor instead.
-func (*RemoveAgentResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ServerBlock.ProtoReflect.Descriptor instead.
+func (*ServerBlock) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *RemoveAgentResponse) GetSuccess() bool {
+func (x *ServerBlock) GetListen() []string {
 	if x != nil {
-		return x.Success
+		return x.Listen
 	}
-	return false
+	return nil
 }
 
-type GetAgentRequest struct {
+func (x *ServerBlock) GetServerName() []string {
+	if x != nil {
+		return x.ServerName
+	}
+	return nil
+}
+
+func (x *ServerBlock) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *ServerBlock) GetLocations() []*LocationBlock {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *ServerBlock) GetSslConfig() map[string]string {
+	if x != nil {
+		return x.SslConfig
+	}
+	return nil
+}
+
+func (x *ServerBlock) GetDirectives() map[string]string {
+	if x != nil {
+		return x.Directives
+	}
+	return nil
+}
+
+type LocationBlock struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	MatchType     string                 `protobuf:"bytes,2,opt,name=match_type,json=matchType,proto3" json:"match_type,omitempty"`
+	Directives    map[string]string      `protobuf:"bytes,3,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ProxyPass     string                 `protobuf:"bytes,4,opt,name=proxy_pass,json=proxyPass,proto3" json:"proxy_pass,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAgentRequest) Reset() {
-	*x = GetAgentRequest{}
+func (x *LocationBlock) Reset() {
+	*x = LocationBlock{}
 	mi := &file_api_proto_agent_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAgentRequest) String() string {
+func (x *LocationBlock) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAgentRequest) ProtoMessage() {}
+func (*LocationBlock) ProtoMessage() {}
 
-func (x *GetAgentRequest) ProtoReflect() protoreflect.Message {
+func (x *LocationBlock) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3312,54 +4036,62 @@ func (x *GetAgentRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAgentRequest.ProtoReflect.Descriptor instead.
-func (*GetAgentRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use LocationBlock.ProtoReflect.Descriptor instead.
+func (*LocationBlock) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *GetAgentRequest) GetAgentId() string {
+func (x *LocationBlock) GetPath() string {
 	if x != nil {
-		return x.AgentId
+		return x.Path
 	}
 	return ""
 }
 
-type AgentInfo struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	AgentId          string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Hostname         string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Version          string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"` // Nginx version of primary instance
-	Status           string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
-	InstancesCount   int32                  `protobuf:"varint,5,opt,name=instances_count,json=instancesCount,proto3" json:"instances_count,omitempty"`
-	Uptime           string                 `protobuf:"bytes,6,opt,name=uptime,proto3" json:"uptime,omitempty"`
-	Ip               string                 `protobuf:"bytes,7,opt,name=ip,proto3" json:"ip,omitempty"`
-	LastSeen         int64                  `protobuf:"varint,8,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
-	AgentVersion     string                 `protobuf:"bytes,9,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
-	IsPod            bool                   `protobuf:"varint,10,opt,name=is_pod,json=isPod,proto3" json:"is_pod,omitempty"`
-	PodIp            string                 `protobuf:"bytes,11,opt,name=pod_ip,json=podIp,proto3" json:"pod_ip,omitempty"`
-	BuildDate        string                 `protobuf:"bytes,12,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`                                                    // Build timestamp
-	GitCommit        string                 `protobuf:"bytes,13,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`                                                    // Git commit hash
-	GitBranch        string                 `protobuf:"bytes,14,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`                                                    // Git branch name
-	PskAuthenticated bool                   `protobuf:"varint,15,opt,name=psk_authenticated,json=pskAuthenticated,proto3" json:"psk_authenticated,omitempty"`                              // true if agent connected with valid PSK
-	Labels           map[string]string      `protobuf:"bytes,16,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Agent labels for project/environment assignment
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *LocationBlock) GetMatchType() string {
+	if x != nil {
+		return x.MatchType
+	}
+	return ""
 }
 
-func (x *AgentInfo) Reset() {
-	*x = AgentInfo{}
+func (x *LocationBlock) GetDirectives() map[string]string {
+	if x != nil {
+		return x.Directives
+	}
+	return nil
+}
+
+func (x *LocationBlock) GetProxyPass() string {
+	if x != nil {
+		return x.ProxyPass
+	}
+	return ""
+}
+
+type UpstreamBlock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Servers       []string               `protobuf:"bytes,2,rep,name=servers,proto3" json:"servers,omitempty"`
+	Directives    map[string]string      `protobuf:"bytes,3,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpstreamBlock) Reset() {
+	*x = UpstreamBlock{}
 	mi := &file_api_proto_agent_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentInfo) String() string {
+func (x *UpstreamBlock) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentInfo) ProtoMessage() {}
+func (*UpstreamBlock) ProtoMessage() {}
 
-func (x *AgentInfo) ProtoReflect() protoreflect.Message {
+func (x *UpstreamBlock) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_agent_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -3371,148 +4103,200 @@ func (x *AgentInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentInfo.ProtoReflect.Descriptor instead.
-func (*AgentInfo) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpstreamBlock.ProtoReflect.Descriptor instead.
+func (*UpstreamBlock) Descriptor() ([]byte, []int) {
 	return file_api_proto_agent_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *AgentInfo) GetAgentId() string {
+func (x *UpstreamBlock) GetName() string {
 	if x != nil {
-		return x.AgentId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *AgentInfo) GetHostname() string {
+func (x *UpstreamBlock) GetServers() []string {
 	if x != nil {
-		return x.Hostname
+		return x.Servers
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentInfo) GetVersion() string {
+func (x *UpstreamBlock) GetDirectives() map[string]string {
 	if x != nil {
-		return x.Version
+		return x.Directives
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentInfo) GetStatus() string {
+type ConfigUpdate struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	ConfigPath      string                 `protobuf:"bytes,2,opt,name=config_path,json=configPath,proto3" json:"config_path,omitempty"` // Relative paths (e.g. "conf.d/app.conf") write that file under the NGINX conf dir
+	NewContent      string                 `protobuf:"bytes,3,opt,name=new_content,json=newContent,proto3" json:"new_content,omitempty"`
+	Backup          bool                   `protobuf:"varint,4,opt,name=backup,proto3" json:"backup,omitempty"`
+	Files           []*ConfigFile          `protobuf:"bytes,5,rep,name=files,proto3" json:"files,omitempty"` // Structured update; when set, new_content is ignored
+	NginxInstanceId string                 `protobuf:"bytes,6,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ConfigUpdate) Reset() {
+	*x = ConfigUpdate{}
+	mi := &file_api_proto_agent_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigUpdate) ProtoMessage() {}
+
+func (x *ConfigUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[51]
 	if x != nil {
-		return x.Status
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *AgentInfo) GetInstancesCount() int32 {
+// Deprecated: Use ConfigUpdate.ProtoReflect.Descriptor instead.
+func (*ConfigUpdate) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ConfigUpdate) GetInstanceId() string {
 	if x != nil {
-		return x.InstancesCount
+		return x.InstanceId
 	}
-	return 0
+	return ""
 }
 
-func (x *AgentInfo) GetUptime() string {
+func (x *ConfigUpdate) GetConfigPath() string {
 	if x != nil {
-		return x.Uptime
+		return x.ConfigPath
 	}
 	return ""
 }
 
-func (x *AgentInfo) GetIp() string {
+func (x *ConfigUpdate) GetNewContent() string {
 	if x != nil {
-		return x.Ip
+		return x.NewContent
 	}
 	return ""
 }
 
-func (x *AgentInfo) GetLastSeen() int64 {
+func (x *ConfigUpdate) GetBackup() bool {
 	if x != nil {
-		return x.LastSeen
+		return x.Backup
 	}
-	return 0
+	return false
 }
 
-func (x *AgentInfo) GetAgentVersion() string {
+func (x *ConfigUpdate) GetFiles() []*ConfigFile {
 	if x != nil {
-		return x.AgentVersion
+		return x.Files
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentInfo) GetIsPod() bool {
+func (x *ConfigUpdate) GetNginxInstanceId() string {
 	if x != nil {
-		return x.IsPod
+		return x.NginxInstanceId
 	}
-	return false
+	return ""
 }
 
-func (x *AgentInfo) GetPodIp() string {
-	if x != nil {
-		return x.PodIp
-	}
-	return ""
+type ConfigUpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	BackupPath    string                 `protobuf:"bytes,3,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentInfo) GetBuildDate() string {
-	if x != nil {
-		return x.BuildDate
-	}
-	return ""
+func (x *ConfigUpdateResponse) Reset() {
+	*x = ConfigUpdateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentInfo) GetGitCommit() string {
+func (x *ConfigUpdateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigUpdateResponse) ProtoMessage() {}
+
+func (x *ConfigUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[52]
 	if x != nil {
-		return x.GitCommit
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *AgentInfo) GetGitBranch() string {
+// Deprecated: Use ConfigUpdateResponse.ProtoReflect.Descriptor instead.
+func (*ConfigUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ConfigUpdateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.GitBranch
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *AgentInfo) GetPskAuthenticated() bool {
+func (x *ConfigUpdateResponse) GetError() string {
 	if x != nil {
-		return x.PskAuthenticated
+		return x.Error
 	}
-	return false
+	return ""
 }
 
-func (x *AgentInfo) GetLabels() map[string]string {
+func (x *ConfigUpdateResponse) GetBackupPath() string {
 	if x != nil {
-		return x.Labels
+		return x.BackupPath
 	}
-	return nil
+	return ""
 }
 
-type LogRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	LogType       string                 `protobuf:"bytes,2,opt,name=log_type,json=logType,proto3" json:"log_type,omitempty"`
-	TailLines     int32                  `protobuf:"varint,3,opt,name=tail_lines,json=tailLines,proto3" json:"tail_lines,omitempty"`
-	Follow        bool                   `protobuf:"varint,4,opt,name=follow,proto3" json:"follow,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ConfigValidation struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	ConfigContent   string                 `protobuf:"bytes,2,opt,name=config_content,json=configContent,proto3" json:"config_content,omitempty"`
+	NginxInstanceId string                 `protobuf:"bytes,3,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *LogRequest) Reset() {
-	*x = LogRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[51]
+func (x *ConfigValidation) Reset() {
+	*x = ConfigValidation{}
+	mi := &file_api_proto_agent_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogRequest) String() string {
+func (x *ConfigValidation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogRequest) ProtoMessage() {}
+func (*ConfigValidation) ProtoMessage() {}
 
-func (x *LogRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[51]
+func (x *ConfigValidation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3523,78 +4307,56 @@ func (x *LogRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogRequest.ProtoReflect.Descriptor instead.
-func (*LogRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{51}
+// Deprecated: Use ConfigValidation.ProtoReflect.Descriptor instead.
+func (*ConfigValidation) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *LogRequest) GetInstanceId() string {
+func (x *ConfigValidation) GetInstanceId() string {
 	if x != nil {
 		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *LogRequest) GetLogType() string {
+func (x *ConfigValidation) GetConfigContent() string {
 	if x != nil {
-		return x.LogType
+		return x.ConfigContent
 	}
 	return ""
 }
 
-func (x *LogRequest) GetTailLines() int32 {
-	if x != nil {
-		return x.TailLines
-	}
-	return 0
-}
-
-func (x *LogRequest) GetFollow() bool {
+func (x *ConfigValidation) GetNginxInstanceId() string {
 	if x != nil {
-		return x.Follow
+		return x.NginxInstanceId
 	}
-	return false
+	return ""
 }
 
-type LogEntry struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp            int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	LogType              string                 `protobuf:"bytes,2,opt,name=log_type,json=logType,proto3" json:"log_type,omitempty"`
-	Content              string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	RemoteAddr           string                 `protobuf:"bytes,4,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
-	RequestMethod        string                 `protobuf:"bytes,5,opt,name=request_method,json=requestMethod,proto3" json:"request_method,omitempty"`
-	RequestUri           string                 `protobuf:"bytes,6,opt,name=request_uri,json=requestUri,proto3" json:"request_uri,omitempty"`
-	Status               int32                  `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
-	BodyBytesSent        int64                  `protobuf:"varint,8,opt,name=body_bytes_sent,json=bodyBytesSent,proto3" json:"body_bytes_sent,omitempty"`
-	RequestTime          float32                `protobuf:"fixed32,9,opt,name=request_time,json=requestTime,proto3" json:"request_time,omitempty"`
-	RequestId            string                 `protobuf:"bytes,10,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
-	UpstreamAddr         string                 `protobuf:"bytes,11,opt,name=upstream_addr,json=upstreamAddr,proto3" json:"upstream_addr,omitempty"`
-	UpstreamStatus       string                 `protobuf:"bytes,12,opt,name=upstream_status,json=upstreamStatus,proto3" json:"upstream_status,omitempty"`
-	UpstreamConnectTime  float32                `protobuf:"fixed32,13,opt,name=upstream_connect_time,json=upstreamConnectTime,proto3" json:"upstream_connect_time,omitempty"`
-	UpstreamHeaderTime   float32                `protobuf:"fixed32,14,opt,name=upstream_header_time,json=upstreamHeaderTime,proto3" json:"upstream_header_time,omitempty"`
-	UpstreamResponseTime float32                `protobuf:"fixed32,15,opt,name=upstream_response_time,json=upstreamResponseTime,proto3" json:"upstream_response_time,omitempty"`
-	Referer              string                 `protobuf:"bytes,16,opt,name=referer,proto3" json:"referer,omitempty"`
-	UserAgent            string                 `protobuf:"bytes,17,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
-	XForwardedFor        string                 `protobuf:"bytes,18,opt,name=x_forwarded_for,json=xForwardedFor,proto3" json:"x_forwarded_for,omitempty"` // Client IP from X-Forwarded-For header for geo lookup
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+type ValidationResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Errors        []string               `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	Warnings      []string               `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogEntry) Reset() {
-	*x = LogEntry{}
-	mi := &file_api_proto_agent_proto_msgTypes[52]
+func (x *ValidationResult) Reset() {
+	*x = ValidationResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogEntry) String() string {
+func (x *ValidationResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogEntry) ProtoMessage() {}
+func (*ValidationResult) ProtoMessage() {}
 
-func (x *LogEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[52]
+func (x *ValidationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3605,160 +4367,159 @@ func (x *LogEntry) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
-func (*LogEntry) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{52}
+// Deprecated: Use ValidationResult.ProtoReflect.Descriptor instead.
+func (*ValidationResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *LogEntry) GetTimestamp() int64 {
+func (x *ValidationResult) GetValid() bool {
 	if x != nil {
-		return x.Timestamp
+		return x.Valid
 	}
-	return 0
+	return false
 }
 
-func (x *LogEntry) GetLogType() string {
+func (x *ValidationResult) GetErrors() []string {
 	if x != nil {
-		return x.LogType
+		return x.Errors
 	}
-	return ""
+	return nil
 }
 
-func (x *LogEntry) GetContent() string {
+func (x *ValidationResult) GetWarnings() []string {
 	if x != nil {
-		return x.Content
+		return x.Warnings
 	}
-	return ""
+	return nil
 }
 
-func (x *LogEntry) GetRemoteAddr() string {
-	if x != nil {
-		return x.RemoteAddr
-	}
-	return ""
+type ReloadRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	NginxInstanceId string                 `protobuf:"bytes,2,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *LogEntry) GetRequestMethod() string {
-	if x != nil {
-		return x.RequestMethod
-	}
-	return ""
+func (x *ReloadRequest) Reset() {
+	*x = ReloadRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogEntry) GetRequestUri() string {
-	if x != nil {
-		return x.RequestUri
-	}
-	return ""
+func (x *ReloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *LogEntry) GetStatus() int32 {
-	if x != nil {
-		return x.Status
-	}
-	return 0
-}
+func (*ReloadRequest) ProtoMessage() {}
 
-func (x *LogEntry) GetBodyBytesSent() int64 {
+func (x *ReloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[55]
 	if x != nil {
-		return x.BodyBytesSent
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *LogEntry) GetRequestTime() float32 {
-	if x != nil {
-		return x.RequestTime
-	}
-	return 0
+// Deprecated: Use ReloadRequest.ProtoReflect.Descriptor instead.
+func (*ReloadRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *LogEntry) GetRequestId() string {
+func (x *ReloadRequest) GetInstanceId() string {
 	if x != nil {
-		return x.RequestId
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *LogEntry) GetUpstreamAddr() string {
+func (x *ReloadRequest) GetNginxInstanceId() string {
 	if x != nil {
-		return x.UpstreamAddr
+		return x.NginxInstanceId
 	}
 	return ""
 }
 
-func (x *LogEntry) GetUpstreamStatus() string {
-	if x != nil {
-		return x.UpstreamStatus
-	}
-	return ""
+type ReloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogEntry) GetUpstreamConnectTime() float32 {
-	if x != nil {
-		return x.UpstreamConnectTime
-	}
-	return 0
+func (x *ReloadResponse) Reset() {
+	*x = ReloadResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogEntry) GetUpstreamHeaderTime() float32 {
-	if x != nil {
-		return x.UpstreamHeaderTime
-	}
-	return 0
+func (x *ReloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *LogEntry) GetUpstreamResponseTime() float32 {
+func (*ReloadResponse) ProtoMessage() {}
+
+func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[56]
 	if x != nil {
-		return x.UpstreamResponseTime
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *LogEntry) GetReferer() string {
-	if x != nil {
-		return x.Referer
-	}
-	return ""
+// Deprecated: Use ReloadResponse.ProtoReflect.Descriptor instead.
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *LogEntry) GetUserAgent() string {
+func (x *ReloadResponse) GetSuccess() bool {
 	if x != nil {
-		return x.UserAgent
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *LogEntry) GetXForwardedFor() string {
+func (x *ReloadResponse) GetError() string {
 	if x != nil {
-		return x.XForwardedFor
+		return x.Error
 	}
 	return ""
 }
 
-type UptimeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type RestartRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	NginxInstanceId string                 `protobuf:"bytes,2,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *UptimeRequest) Reset() {
-	*x = UptimeRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[53]
+func (x *RestartRequest) Reset() {
+	*x = RestartRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UptimeRequest) String() string {
+func (x *RestartRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UptimeRequest) ProtoMessage() {}
+func (*RestartRequest) ProtoMessage() {}
 
-func (x *UptimeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[53]
+func (x *RestartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3769,47 +4530,48 @@ func (x *UptimeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UptimeRequest.ProtoReflect.Descriptor instead.
-func (*UptimeRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{53}
+// Deprecated: Use RestartRequest.ProtoReflect.Descriptor instead.
+func (*RestartRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *UptimeRequest) GetAgentId() string {
+func (x *RestartRequest) GetInstanceId() string {
 	if x != nil {
-		return x.AgentId
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *UptimeRequest) GetLimit() int32 {
+func (x *RestartRequest) GetNginxInstanceId() string {
 	if x != nil {
-		return x.Limit
+		return x.NginxInstanceId
 	}
-	return 0
+	return ""
 }
 
-type UptimeResponse struct {
+type RestartResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Reports       []*UptimeReport        `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UptimeResponse) Reset() {
-	*x = UptimeResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[54]
+func (x *RestartResponse) Reset() {
+	*x = RestartResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UptimeResponse) String() string {
+func (x *RestartResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UptimeResponse) ProtoMessage() {}
+func (*RestartResponse) ProtoMessage() {}
 
-func (x *UptimeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[54]
+func (x *RestartResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3820,45 +4582,48 @@ func (x *UptimeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UptimeResponse.ProtoReflect.Descriptor instead.
-func (*UptimeResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{54}
+// Deprecated: Use RestartResponse.ProtoReflect.Descriptor instead.
+func (*RestartResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{58}
 }
 
-func (x *UptimeResponse) GetReports() []*UptimeReport {
+func (x *RestartResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Reports
+		return x.Success
 	}
-	return nil
+	return false
 }
 
-type UptimeReport struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "UP", "DOWN", "DEGRADED"
-	LatencyMs     float32                `protobuf:"fixed32,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
-	CheckType     string                 `protobuf:"bytes,4,opt,name=check_type,json=checkType,proto3" json:"check_type,omitempty"` // "HTTP", "TCP"
-	Target        string                 `protobuf:"bytes,5,opt,name=target,proto3" json:"target,omitempty"`
-	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *RestartResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
-func (x *UptimeReport) Reset() {
-	*x = UptimeReport{}
-	mi := &file_api_proto_agent_proto_msgTypes[55]
+type StopRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	NginxInstanceId string                 `protobuf:"bytes,2,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UptimeReport) String() string {
+func (x *StopRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UptimeReport) ProtoMessage() {}
+func (*StopRequest) ProtoMessage() {}
 
-func (x *UptimeReport) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[55]
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3869,83 +4634,99 @@ func (x *UptimeReport) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UptimeReport.ProtoReflect.Descriptor instead.
-func (*UptimeReport) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{55}
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *UptimeReport) GetTimestamp() int64 {
+func (x *StopRequest) GetInstanceId() string {
 	if x != nil {
-		return x.Timestamp
+		return x.InstanceId
 	}
-	return 0
+	return ""
 }
 
-func (x *UptimeReport) GetStatus() string {
+func (x *StopRequest) GetNginxInstanceId() string {
 	if x != nil {
-		return x.Status
+		return x.NginxInstanceId
 	}
 	return ""
 }
 
-func (x *UptimeReport) GetLatencyMs() float32 {
-	if x != nil {
-		return x.LatencyMs
-	}
-	return 0
+type StopResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UptimeReport) GetCheckType() string {
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[60]
 	if x != nil {
-		return x.CheckType
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *UptimeReport) GetTarget() string {
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *StopResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Target
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *UptimeReport) GetError() string {
+func (x *StopResponse) GetError() string {
 	if x != nil {
 		return x.Error
 	}
 	return ""
 }
 
-type AnalyticsRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	AgentId          string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                              // Optional, defaults to all
-	TimeWindow       string                 `protobuf:"bytes,2,opt,name=time_window,json=timeWindow,proto3" json:"time_window,omitempty"`                     // "1h", "24h", "7d"
-	EnvironmentId    string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`            // Optional: filter by environment
-	ProjectId        string                 `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`                        // Optional: filter by project (all environments)
-	FromTimestamp    int64                  `protobuf:"varint,5,opt,name=from_timestamp,json=fromTimestamp,proto3" json:"from_timestamp,omitempty"`           // Optional: absolute start time (milliseconds)
-	ToTimestamp      int64                  `protobuf:"varint,6,opt,name=to_timestamp,json=toTimestamp,proto3" json:"to_timestamp,omitempty"`                 // Optional: absolute end time (milliseconds)
-	Timezone         string                 `protobuf:"bytes,7,opt,name=timezone,proto3" json:"timezone,omitempty"`                                           // Optional: client timezone for formatting
-	UrlFilter        string                 `protobuf:"bytes,8,opt,name=url_filter,json=urlFilter,proto3" json:"url_filter,omitempty"`                        // Optional: filter by specific URL
-	StatusCodeFilter string                 `protobuf:"bytes,9,opt,name=status_code_filter,json=statusCodeFilter,proto3" json:"status_code_filter,omitempty"` // Optional: filter by specific status code or class (e.g., "4xx")
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+type CertListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AnalyticsRequest) Reset() {
-	*x = AnalyticsRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[56]
+func (x *CertListRequest) Reset() {
+	*x = CertListRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AnalyticsRequest) String() string {
+func (x *CertListRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AnalyticsRequest) ProtoMessage() {}
+func (*CertListRequest) ProtoMessage() {}
 
-func (x *AnalyticsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[56]
+func (x *CertListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3956,114 +4737,40 @@ func (x *AnalyticsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AnalyticsRequest.ProtoReflect.Descriptor instead.
-func (*AnalyticsRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{56}
+// Deprecated: Use CertListRequest.ProtoReflect.Descriptor instead.
+func (*CertListRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *AnalyticsRequest) GetAgentId() string {
+func (x *CertListRequest) GetInstanceId() string {
 	if x != nil {
-		return x.AgentId
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *AnalyticsRequest) GetTimeWindow() string {
-	if x != nil {
-		return x.TimeWindow
-	}
-	return ""
-}
-
-func (x *AnalyticsRequest) GetEnvironmentId() string {
-	if x != nil {
-		return x.EnvironmentId
-	}
-	return ""
-}
-
-func (x *AnalyticsRequest) GetProjectId() string {
-	if x != nil {
-		return x.ProjectId
-	}
-	return ""
-}
-
-func (x *AnalyticsRequest) GetFromTimestamp() int64 {
-	if x != nil {
-		return x.FromTimestamp
-	}
-	return 0
-}
-
-func (x *AnalyticsRequest) GetToTimestamp() int64 {
-	if x != nil {
-		return x.ToTimestamp
-	}
-	return 0
-}
-
-func (x *AnalyticsRequest) GetTimezone() string {
-	if x != nil {
-		return x.Timezone
-	}
-	return ""
-}
-
-func (x *AnalyticsRequest) GetUrlFilter() string {
-	if x != nil {
-		return x.UrlFilter
-	}
-	return ""
-}
-
-func (x *AnalyticsRequest) GetStatusCodeFilter() string {
-	if x != nil {
-		return x.StatusCodeFilter
-	}
-	return ""
-}
-
-type AnalyticsResponse struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	RequestRate        []*TimeSeriesPoint     `protobuf:"bytes,1,rep,name=request_rate,json=requestRate,proto3" json:"request_rate,omitempty"`
-	StatusDistribution []*StatusCount         `protobuf:"bytes,2,rep,name=status_distribution,json=statusDistribution,proto3" json:"status_distribution,omitempty"`
-	LatencyTrend       []*LatencyPercentiles  `protobuf:"bytes,3,rep,name=latency_trend,json=latencyTrend,proto3" json:"latency_trend,omitempty"`
-	TopEndpoints       []*EndpointStat        `protobuf:"bytes,4,rep,name=top_endpoints,json=topEndpoints,proto3" json:"top_endpoints,omitempty"`
-	ConnectionsHistory []*NginxMetricPoint    `protobuf:"bytes,5,rep,name=connections_history,json=connectionsHistory,proto3" json:"connections_history,omitempty"`
-	// New fields for KPI cards and distributions
-	Summary             *AnalyticsSummary `protobuf:"bytes,6,opt,name=summary,proto3" json:"summary,omitempty"`
-	LatencyDistribution []*LatencyBucket  `protobuf:"bytes,7,rep,name=latency_distribution,json=latencyDistribution,proto3" json:"latency_distribution,omitempty"`
-	ServerDistribution  []*ServerStat     `protobuf:"bytes,8,rep,name=server_distribution,json=serverDistribution,proto3" json:"server_distribution,omitempty"`
-	// System metrics time series
-	SystemMetrics []*SystemMetricPoint `protobuf:"bytes,9,rep,name=system_metrics,json=systemMetrics,proto3" json:"system_metrics,omitempty"`
-	// HTTP status metrics
-	HttpStatusMetrics *HttpStatusMetricsResponse `protobuf:"bytes,10,opt,name=http_status_metrics,json=httpStatusMetrics,proto3" json:"http_status_metrics,omitempty"`
-	// Actionable Insights for Decision Hub
-	Insights []*Insight `protobuf:"bytes,11,rep,name=insights,proto3" json:"insights,omitempty"`
-	// Recent Requests (for detailed view)
-	RecentRequests []*LogEntry `protobuf:"bytes,12,rep,name=recent_requests,json=recentRequests,proto3" json:"recent_requests,omitempty"`
-	// Gateway specific metrics
-	GatewayMetrics []*GatewayMetricPoint `protobuf:"bytes,13,rep,name=gateway_metrics,json=gatewayMetrics,proto3" json:"gateway_metrics,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type CertListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Certificates  []*Certificate         `protobuf:"bytes,1,rep,name=certificates,proto3" json:"certificates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AnalyticsResponse) Reset() {
-	*x = AnalyticsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[57]
+func (x *CertListResponse) Reset() {
+	*x = CertListResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AnalyticsResponse) String() string {
+func (x *CertListResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AnalyticsResponse) ProtoMessage() {}
+func (*CertListResponse) ProtoMessage() {}
 
-func (x *AnalyticsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[57]
+func (x *CertListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4074,131 +4781,142 @@ func (x *AnalyticsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AnalyticsResponse.ProtoReflect.Descriptor instead.
-func (*AnalyticsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{57}
+// Deprecated: Use CertListResponse.ProtoReflect.Descriptor instead.
+func (*CertListResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *AnalyticsResponse) GetRequestRate() []*TimeSeriesPoint {
+func (x *CertListResponse) GetCertificates() []*Certificate {
 	if x != nil {
-		return x.RequestRate
+		return x.Certificates
 	}
 	return nil
 }
 
-func (x *AnalyticsResponse) GetStatusDistribution() []*StatusCount {
-	if x != nil {
-		return x.StatusDistribution
-	}
-	return nil
+type Certificate struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Domain          string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	CertPath        string                 `protobuf:"bytes,2,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
+	KeyPath         string                 `protobuf:"bytes,3,opt,name=key_path,json=keyPath,proto3" json:"key_path,omitempty"`
+	ExpiryTimestamp int64                  `protobuf:"varint,4,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
+	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	SanDomains      []string               `protobuf:"bytes,6,rep,name=san_domains,json=sanDomains,proto3" json:"san_domains,omitempty"`
+	DaysUntilExpiry int32                  `protobuf:"varint,7,opt,name=days_until_expiry,json=daysUntilExpiry,proto3" json:"days_until_expiry,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *AnalyticsResponse) GetLatencyTrend() []*LatencyPercentiles {
-	if x != nil {
-		return x.LatencyTrend
-	}
-	return nil
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	mi := &file_api_proto_agent_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *AnalyticsResponse) GetTopEndpoints() []*EndpointStat {
-	if x != nil {
-		return x.TopEndpoints
-	}
-	return nil
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *AnalyticsResponse) GetConnectionsHistory() []*NginxMetricPoint {
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[63]
 	if x != nil {
-		return x.ConnectionsHistory
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *AnalyticsResponse) GetSummary() *AnalyticsSummary {
-	if x != nil {
-		return x.Summary
-	}
-	return nil
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{63}
 }
 
-func (x *AnalyticsResponse) GetLatencyDistribution() []*LatencyBucket {
+func (x *Certificate) GetDomain() string {
 	if x != nil {
-		return x.LatencyDistribution
+		return x.Domain
 	}
-	return nil
+	return ""
 }
 
-func (x *AnalyticsResponse) GetServerDistribution() []*ServerStat {
+func (x *Certificate) GetCertPath() string {
 	if x != nil {
-		return x.ServerDistribution
+		return x.CertPath
 	}
-	return nil
+	return ""
 }
 
-func (x *AnalyticsResponse) GetSystemMetrics() []*SystemMetricPoint {
+func (x *Certificate) GetKeyPath() string {
 	if x != nil {
-		return x.SystemMetrics
+		return x.KeyPath
 	}
-	return nil
+	return ""
 }
 
-func (x *AnalyticsResponse) GetHttpStatusMetrics() *HttpStatusMetricsResponse {
+func (x *Certificate) GetExpiryTimestamp() int64 {
 	if x != nil {
-		return x.HttpStatusMetrics
+		return x.ExpiryTimestamp
 	}
-	return nil
+	return 0
 }
 
-func (x *AnalyticsResponse) GetInsights() []*Insight {
+func (x *Certificate) GetIssuer() string {
 	if x != nil {
-		return x.Insights
+		return x.Issuer
 	}
-	return nil
+	return ""
 }
 
-func (x *AnalyticsResponse) GetRecentRequests() []*LogEntry {
+func (x *Certificate) GetSanDomains() []string {
 	if x != nil {
-		return x.RecentRequests
+		return x.SanDomains
 	}
 	return nil
 }
 
-func (x *AnalyticsResponse) GetGatewayMetrics() []*GatewayMetricPoint {
+func (x *Certificate) GetDaysUntilExpiry() int32 {
 	if x != nil {
-		return x.GatewayMetrics
+		return x.DaysUntilExpiry
 	}
-	return nil
+	return 0
 }
 
-type GatewayMetricPoint struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	Time              string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	Eps               float32                `protobuf:"fixed32,2,opt,name=eps,proto3" json:"eps,omitempty"`
-	ActiveConnections int32                  `protobuf:"varint,3,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
-	CpuUsage          float32                `protobuf:"fixed32,4,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
-	MemoryMb          float32                `protobuf:"fixed32,5,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
-	Goroutines        int32                  `protobuf:"varint,6,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
-	DbLatency         float32                `protobuf:"fixed32,7,opt,name=db_latency,json=dbLatency,proto3" json:"db_latency,omitempty"`
-	Labels            map[string]string      `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+// ============ Log Messages ============
+// Filters, sorting and pagination of the agent list; an empty request lists every agent
+type ListAgentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // 0 = no pagination
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                     // agents to skip
+	Search        string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`                      // case-insensitive substring of the hostname, IP, agent ID, nginx or agent version
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                      // "online" or "offline"
+	EnvironmentId string                 `protobuf:"bytes,5,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,6,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Tags          []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`                   // server tags the agent must all carry
+	SortBy        string                 `protobuf:"bytes,8,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"` // "hostname" (default), "last_seen", "status", "ip", "version" or "agent_version"
+	Descending    bool                   `protobuf:"varint,9,opt,name=descending,proto3" json:"descending,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GatewayMetricPoint) Reset() {
-	*x = GatewayMetricPoint{}
-	mi := &file_api_proto_agent_proto_msgTypes[58]
+func (x *ListAgentsRequest) Reset() {
+	*x = ListAgentsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GatewayMetricPoint) String() string {
+func (x *ListAgentsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GatewayMetricPoint) ProtoMessage() {}
+func (*ListAgentsRequest) ProtoMessage() {}
 
-func (x *GatewayMetricPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[58]
+func (x *ListAgentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4209,95 +4927,98 @@ func (x *GatewayMetricPoint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GatewayMetricPoint.ProtoReflect.Descriptor instead.
-func (*GatewayMetricPoint) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{58}
+// Deprecated: Use ListAgentsRequest.ProtoReflect.Descriptor instead.
+func (*ListAgentsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *GatewayMetricPoint) GetTime() string {
+func (x *ListAgentsRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.Time
+		return x.PageSize
 	}
-	return ""
+	return 0
 }
 
-func (x *GatewayMetricPoint) GetEps() float32 {
+func (x *ListAgentsRequest) GetOffset() int32 {
 	if x != nil {
-		return x.Eps
+		return x.Offset
 	}
 	return 0
 }
 
-func (x *GatewayMetricPoint) GetActiveConnections() int32 {
+func (x *ListAgentsRequest) GetSearch() string {
 	if x != nil {
-		return x.ActiveConnections
+		return x.Search
 	}
-	return 0
+	return ""
 }
 
-func (x *GatewayMetricPoint) GetCpuUsage() float32 {
+func (x *ListAgentsRequest) GetStatus() string {
 	if x != nil {
-		return x.CpuUsage
+		return x.Status
 	}
-	return 0
+	return ""
 }
 
-func (x *GatewayMetricPoint) GetMemoryMb() float32 {
+func (x *ListAgentsRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.MemoryMb
+		return x.EnvironmentId
 	}
-	return 0
+	return ""
 }
 
-func (x *GatewayMetricPoint) GetGoroutines() int32 {
+func (x *ListAgentsRequest) GetProjectId() string {
 	if x != nil {
-		return x.Goroutines
+		return x.ProjectId
 	}
-	return 0
+	return ""
 }
 
-func (x *GatewayMetricPoint) GetDbLatency() float32 {
+func (x *ListAgentsRequest) GetTags() []string {
 	if x != nil {
-		return x.DbLatency
+		return x.Tags
 	}
-	return 0
+	return nil
 }
 
-func (x *GatewayMetricPoint) GetLabels() map[string]string {
+func (x *ListAgentsRequest) GetSortBy() string {
 	if x != nil {
-		return x.Labels
+		return x.SortBy
 	}
-	return nil
+	return ""
 }
 
-type Span struct {
+func (x *ListAgentsRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+type ListAgentsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TraceId       string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
-	SpanId        string                 `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
-	ParentSpanId  string                 `protobuf:"bytes,3,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
-	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`                             // e.g., "rewrite", "access", "content"
-	StartTime     int64                  `protobuf:"varint,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix nano
-	EndTime       int64                  `protobuf:"varint,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // Unix nano
-	Attributes    map[string]string      `protobuf:"bytes,7,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Agents        []*AgentInfo           `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+	SystemVersion string                 `protobuf:"bytes,2,opt,name=system_version,json=systemVersion,proto3" json:"system_version,omitempty"` // Current system version (from VERSION file)
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`                                     // agents matching the filters, before pagination
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Span) Reset() {
-	*x = Span{}
-	mi := &file_api_proto_agent_proto_msgTypes[59]
+func (x *ListAgentsResponse) Reset() {
+	*x = ListAgentsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Span) String() string {
+func (x *ListAgentsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Span) ProtoMessage() {}
+func (*ListAgentsResponse) ProtoMessage() {}
 
-func (x *Span) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[59]
+func (x *ListAgentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4308,84 +5029,98 @@ func (x *Span) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Span.ProtoReflect.Descriptor instead.
-func (*Span) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{59}
+// Deprecated: Use ListAgentsResponse.ProtoReflect.Descriptor instead.
+func (*ListAgentsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{65}
 }
 
-func (x *Span) GetTraceId() string {
+func (x *ListAgentsResponse) GetAgents() []*AgentInfo {
 	if x != nil {
-		return x.TraceId
+		return x.Agents
 	}
-	return ""
+	return nil
 }
 
-func (x *Span) GetSpanId() string {
+func (x *ListAgentsResponse) GetSystemVersion() string {
 	if x != nil {
-		return x.SpanId
+		return x.SystemVersion
 	}
 	return ""
 }
 
-func (x *Span) GetParentSpanId() string {
+func (x *ListAgentsResponse) GetTotal() int32 {
 	if x != nil {
-		return x.ParentSpanId
+		return x.Total
 	}
-	return ""
+	return 0
 }
 
-func (x *Span) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
+type RemoveAgentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Span) GetStartTime() int64 {
-	if x != nil {
-		return x.StartTime
-	}
-	return 0
+func (x *RemoveAgentRequest) Reset() {
+	*x = RemoveAgentRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Span) GetEndTime() int64 {
+func (x *RemoveAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveAgentRequest) ProtoMessage() {}
+
+func (x *RemoveAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[66]
 	if x != nil {
-		return x.EndTime
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *Span) GetAttributes() map[string]string {
+// Deprecated: Use RemoveAgentRequest.ProtoReflect.Descriptor instead.
+func (*RemoveAgentRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *RemoveAgentRequest) GetAgentId() string {
 	if x != nil {
-		return x.Attributes
+		return x.AgentId
 	}
-	return nil
+	return ""
 }
 
-type Trace struct {
+type RemoveAgentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
-	Spans         []*Span                `protobuf:"bytes,2,rep,name=spans,proto3" json:"spans,omitempty"`
-	RootEntry     *LogEntry              `protobuf:"bytes,3,opt,name=root_entry,json=rootEntry,proto3" json:"root_entry,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Trace) Reset() {
-	*x = Trace{}
-	mi := &file_api_proto_agent_proto_msgTypes[60]
+func (x *RemoveAgentResponse) Reset() {
+	*x = RemoveAgentResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Trace) String() string {
+func (x *RemoveAgentResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Trace) ProtoMessage() {}
+func (*RemoveAgentResponse) ProtoMessage() {}
 
-func (x *Trace) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[60]
+func (x *RemoveAgentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4396,64 +5131,105 @@ func (x *Trace) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Trace.ProtoReflect.Descriptor instead.
-func (*Trace) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{60}
+// Deprecated: Use RemoveAgentResponse.ProtoReflect.Descriptor instead.
+func (*RemoveAgentResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{67}
 }
 
-func (x *Trace) GetRequestId() string {
+func (x *RemoveAgentResponse) GetSuccess() bool {
 	if x != nil {
-		return x.RequestId
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *Trace) GetSpans() []*Span {
+type GetAgentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAgentRequest) Reset() {
+	*x = GetAgentRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAgentRequest) ProtoMessage() {}
+
+func (x *GetAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[68]
 	if x != nil {
-		return x.Spans
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *Trace) GetRootEntry() *LogEntry {
+// Deprecated: Use GetAgentRequest.ProtoReflect.Descriptor instead.
+func (*GetAgentRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *GetAgentRequest) GetAgentId() string {
 	if x != nil {
-		return x.RootEntry
+		return x.AgentId
 	}
-	return nil
+	return ""
 }
 
-type TraceRequest struct {
-	state      protoimpl.MessageState `protogen:"open.v1"`
-	AgentId    string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	TraceId    string                 `protobuf:"bytes,2,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
-	TimeWindow string                 `protobuf:"bytes,3,opt,name=time_window,json=timeWindow,proto3" json:"time_window,omitempty"`
-	Limit      int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
-	// Search filters
-	StatusFilter string `protobuf:"bytes,5,opt,name=status_filter,json=statusFilter,proto3" json:"status_filter,omitempty"` // e.g. "404", "5xx"
-	MethodFilter string `protobuf:"bytes,6,opt,name=method_filter,json=methodFilter,proto3" json:"method_filter,omitempty"` // e.g. "GET", "POST"
-	UriFilter    string `protobuf:"bytes,7,opt,name=uri_filter,json=uriFilter,proto3" json:"uri_filter,omitempty"`          // e.g. "/api/v1"
-	// Project/environment filters
-	EnvironmentId string `protobuf:"bytes,8,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"` // Optional: filter by environment
-	ProjectId     string `protobuf:"bytes,9,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`             // Optional: filter by project
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type AgentInfo struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AgentId          string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Hostname         string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Version          string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"` // Nginx version of primary instance
+	Status           string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	InstancesCount   int32                  `protobuf:"varint,5,opt,name=instances_count,json=instancesCount,proto3" json:"instances_count,omitempty"`
+	Uptime           string                 `protobuf:"bytes,6,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Ip               string                 `protobuf:"bytes,7,opt,name=ip,proto3" json:"ip,omitempty"`
+	LastSeen         int64                  `protobuf:"varint,8,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	AgentVersion     string                 `protobuf:"bytes,9,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	IsPod            bool                   `protobuf:"varint,10,opt,name=is_pod,json=isPod,proto3" json:"is_pod,omitempty"`
+	PodIp            string                 `protobuf:"bytes,11,opt,name=pod_ip,json=podIp,proto3" json:"pod_ip,omitempty"`
+	BuildDate        string                 `protobuf:"bytes,12,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`                                                    // Build timestamp
+	GitCommit        string                 `protobuf:"bytes,13,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`                                                    // Git commit hash
+	GitBranch        string                 `protobuf:"bytes,14,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`                                                    // Git branch name
+	PskAuthenticated bool                   `protobuf:"varint,15,opt,name=psk_authenticated,json=pskAuthenticated,proto3" json:"psk_authenticated,omitempty"`                              // true if agent connected with valid PSK
+	Labels           map[string]string      `protobuf:"bytes,16,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Agent labels for project/environment assignment
+	Kubernetes       *KubernetesMetadata    `protobuf:"bytes,17,opt,name=kubernetes,proto3" json:"kubernetes,omitempty"`                                                                   // Pod metadata of agents running in Kubernetes
+	GatewayId        string                 `protobuf:"bytes,18,opt,name=gateway_id,json=gatewayId,proto3" json:"gateway_id,omitempty"`                                                    // Gateway instance holding the agent's stream
+	UpdateStatus     *UpdateStatus          `protobuf:"bytes,19,opt,name=update_status,json=updateStatus,proto3" json:"update_status,omitempty"`                                           // Outcome of the agent's last self-update
+	NginxBuilds      []*NginxBuild          `protobuf:"bytes,20,rep,name=nginx_builds,json=nginxBuilds,proto3" json:"nginx_builds,omitempty"`                                              // Build and modules of each nginx binary (GetAgent only)
+	ResourceStatus   *AgentResourceStatus   `protobuf:"bytes,21,opt,name=resource_status,json=resourceStatus,proto3" json:"resource_status,omitempty"`                                     // Agent CPU and memory against its self-limits
+	NginxInstances   []*NginxInstance       `protobuf:"bytes,22,rep,name=nginx_instances,json=nginxInstances,proto3" json:"nginx_instances,omitempty"`                                     // NGINX instances on the host (GetAgent only)
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *TraceRequest) Reset() {
-	*x = TraceRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[61]
+func (x *AgentInfo) Reset() {
+	*x = AgentInfo{}
+	mi := &file_api_proto_agent_proto_msgTypes[69]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TraceRequest) String() string {
+func (x *AgentInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TraceRequest) ProtoMessage() {}
+func (*AgentInfo) ProtoMessage() {}
 
-func (x *TraceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[61]
+func (x *AgentInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[69]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4464,209 +5240,191 @@ func (x *TraceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TraceRequest.ProtoReflect.Descriptor instead.
-func (*TraceRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{61}
+// Deprecated: Use AgentInfo.ProtoReflect.Descriptor instead.
+func (*AgentInfo) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{69}
 }
 
-func (x *TraceRequest) GetAgentId() string {
+func (x *AgentInfo) GetAgentId() string {
 	if x != nil {
 		return x.AgentId
 	}
 	return ""
 }
 
-func (x *TraceRequest) GetTraceId() string {
+func (x *AgentInfo) GetHostname() string {
 	if x != nil {
-		return x.TraceId
+		return x.Hostname
 	}
 	return ""
 }
 
-func (x *TraceRequest) GetTimeWindow() string {
+func (x *AgentInfo) GetVersion() string {
 	if x != nil {
-		return x.TimeWindow
+		return x.Version
 	}
 	return ""
 }
 
-func (x *TraceRequest) GetLimit() int32 {
+func (x *AgentInfo) GetStatus() string {
 	if x != nil {
-		return x.Limit
+		return x.Status
 	}
-	return 0
+	return ""
 }
 
-func (x *TraceRequest) GetStatusFilter() string {
+func (x *AgentInfo) GetInstancesCount() int32 {
 	if x != nil {
-		return x.StatusFilter
+		return x.InstancesCount
 	}
-	return ""
+	return 0
 }
 
-func (x *TraceRequest) GetMethodFilter() string {
+func (x *AgentInfo) GetUptime() string {
 	if x != nil {
-		return x.MethodFilter
+		return x.Uptime
 	}
 	return ""
 }
 
-func (x *TraceRequest) GetUriFilter() string {
+func (x *AgentInfo) GetIp() string {
 	if x != nil {
-		return x.UriFilter
+		return x.Ip
 	}
 	return ""
 }
 
-func (x *TraceRequest) GetEnvironmentId() string {
+func (x *AgentInfo) GetLastSeen() int64 {
 	if x != nil {
-		return x.EnvironmentId
+		return x.LastSeen
 	}
-	return ""
+	return 0
 }
 
-func (x *TraceRequest) GetProjectId() string {
+func (x *AgentInfo) GetAgentVersion() string {
 	if x != nil {
-		return x.ProjectId
+		return x.AgentVersion
 	}
 	return ""
 }
 
-type TraceList struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Traces        []*Trace               `protobuf:"bytes,1,rep,name=traces,proto3" json:"traces,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *TraceList) Reset() {
-	*x = TraceList{}
-	mi := &file_api_proto_agent_proto_msgTypes[62]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *TraceList) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AgentInfo) GetIsPod() bool {
+	if x != nil {
+		return x.IsPod
+	}
+	return false
 }
 
-func (*TraceList) ProtoMessage() {}
-
-func (x *TraceList) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[62]
+func (x *AgentInfo) GetPodIp() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.PodIp
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use TraceList.ProtoReflect.Descriptor instead.
-func (*TraceList) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{62}
+func (x *AgentInfo) GetBuildDate() string {
+	if x != nil {
+		return x.BuildDate
+	}
+	return ""
 }
 
-func (x *TraceList) GetTraces() []*Trace {
+func (x *AgentInfo) GetGitCommit() string {
 	if x != nil {
-		return x.Traces
+		return x.GitCommit
 	}
-	return nil
+	return ""
 }
 
-type Insight struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "info", "warning", "critical"
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	Metadata      string                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *AgentInfo) GetGitBranch() string {
+	if x != nil {
+		return x.GitBranch
+	}
+	return ""
 }
 
-func (x *Insight) Reset() {
-	*x = Insight{}
-	mi := &file_api_proto_agent_proto_msgTypes[63]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AgentInfo) GetPskAuthenticated() bool {
+	if x != nil {
+		return x.PskAuthenticated
+	}
+	return false
 }
 
-func (x *Insight) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AgentInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
 }
 
-func (*Insight) ProtoMessage() {}
-
-func (x *Insight) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[63]
+func (x *AgentInfo) GetKubernetes() *KubernetesMetadata {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Kubernetes
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use Insight.ProtoReflect.Descriptor instead.
-func (*Insight) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{63}
+func (x *AgentInfo) GetGatewayId() string {
+	if x != nil {
+		return x.GatewayId
+	}
+	return ""
 }
 
-func (x *Insight) GetType() string {
+func (x *AgentInfo) GetUpdateStatus() *UpdateStatus {
 	if x != nil {
-		return x.Type
+		return x.UpdateStatus
 	}
-	return ""
+	return nil
 }
 
-func (x *Insight) GetTitle() string {
+func (x *AgentInfo) GetNginxBuilds() []*NginxBuild {
 	if x != nil {
-		return x.Title
+		return x.NginxBuilds
 	}
-	return ""
+	return nil
 }
 
-func (x *Insight) GetMessage() string {
+func (x *AgentInfo) GetResourceStatus() *AgentResourceStatus {
 	if x != nil {
-		return x.Message
+		return x.ResourceStatus
 	}
-	return ""
+	return nil
 }
 
-func (x *Insight) GetMetadata() string {
+func (x *AgentInfo) GetNginxInstances() []*NginxInstance {
 	if x != nil {
-		return x.Metadata
+		return x.NginxInstances
 	}
-	return ""
+	return nil
 }
 
-type ApplyAugmentRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
-	Augment       *ConfigAugment         `protobuf:"bytes,2,opt,name=augment,proto3" json:"augment,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type LogRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	LogType         string                 `protobuf:"bytes,2,opt,name=log_type,json=logType,proto3" json:"log_type,omitempty"`
+	TailLines       int32                  `protobuf:"varint,3,opt,name=tail_lines,json=tailLines,proto3" json:"tail_lines,omitempty"`
+	Follow          bool                   `protobuf:"varint,4,opt,name=follow,proto3" json:"follow,omitempty"`
+	NginxInstanceId string                 `protobuf:"bytes,5,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *ApplyAugmentRequest) Reset() {
-	*x = ApplyAugmentRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[64]
+func (x *LogRequest) Reset() {
+	*x = LogRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ApplyAugmentRequest) String() string {
+func (x *LogRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ApplyAugmentRequest) ProtoMessage() {}
+func (*LogRequest) ProtoMessage() {}
 
-func (x *ApplyAugmentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[64]
+func (x *LogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4677,49 +5435,90 @@ func (x *ApplyAugmentRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ApplyAugmentRequest.ProtoReflect.Descriptor instead.
-func (*ApplyAugmentRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{64}
+// Deprecated: Use LogRequest.ProtoReflect.Descriptor instead.
+func (*LogRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{70}
 }
 
-func (x *ApplyAugmentRequest) GetInstanceId() string {
+func (x *LogRequest) GetInstanceId() string {
 	if x != nil {
 		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *ApplyAugmentRequest) GetAugment() *ConfigAugment {
+func (x *LogRequest) GetLogType() string {
 	if x != nil {
-		return x.Augment
+		return x.LogType
 	}
-	return nil
+	return ""
 }
 
-type ApplyAugmentResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-	Preview       string                 `protobuf:"bytes,3,opt,name=preview,proto3" json:"preview,omitempty"` // Generated config preview
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *LogRequest) GetTailLines() int32 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
 }
 
-func (x *ApplyAugmentResponse) Reset() {
-	*x = ApplyAugmentResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[65]
+func (x *LogRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+func (x *LogRequest) GetNginxInstanceId() string {
+	if x != nil {
+		return x.NginxInstanceId
+	}
+	return ""
+}
+
+type LogEntry struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp            int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	LogType              string                 `protobuf:"bytes,2,opt,name=log_type,json=logType,proto3" json:"log_type,omitempty"`
+	Content              string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	RemoteAddr           string                 `protobuf:"bytes,4,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	RequestMethod        string                 `protobuf:"bytes,5,opt,name=request_method,json=requestMethod,proto3" json:"request_method,omitempty"`
+	RequestUri           string                 `protobuf:"bytes,6,opt,name=request_uri,json=requestUri,proto3" json:"request_uri,omitempty"`
+	Status               int32                  `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
+	BodyBytesSent        int64                  `protobuf:"varint,8,opt,name=body_bytes_sent,json=bodyBytesSent,proto3" json:"body_bytes_sent,omitempty"`
+	RequestTime          float32                `protobuf:"fixed32,9,opt,name=request_time,json=requestTime,proto3" json:"request_time,omitempty"`
+	RequestId            string                 `protobuf:"bytes,10,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	UpstreamAddr         string                 `protobuf:"bytes,11,opt,name=upstream_addr,json=upstreamAddr,proto3" json:"upstream_addr,omitempty"`
+	UpstreamStatus       string                 `protobuf:"bytes,12,opt,name=upstream_status,json=upstreamStatus,proto3" json:"upstream_status,omitempty"`
+	UpstreamConnectTime  float32                `protobuf:"fixed32,13,opt,name=upstream_connect_time,json=upstreamConnectTime,proto3" json:"upstream_connect_time,omitempty"`
+	UpstreamHeaderTime   float32                `protobuf:"fixed32,14,opt,name=upstream_header_time,json=upstreamHeaderTime,proto3" json:"upstream_header_time,omitempty"`
+	UpstreamResponseTime float32                `protobuf:"fixed32,15,opt,name=upstream_response_time,json=upstreamResponseTime,proto3" json:"upstream_response_time,omitempty"`
+	Referer              string                 `protobuf:"bytes,16,opt,name=referer,proto3" json:"referer,omitempty"`
+	UserAgent            string                 `protobuf:"bytes,17,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	XForwardedFor        string                 `protobuf:"bytes,18,opt,name=x_forwarded_for,json=xForwardedFor,proto3" json:"x_forwarded_for,omitempty"`                                      // Client IP from X-Forwarded-For header for geo lookup
+	Labels               map[string]string      `protobuf:"bytes,19,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // log_format variables without a field of their own
+	RequestLength        int64                  `protobuf:"varint,20,opt,name=request_length,json=requestLength,proto3" json:"request_length,omitempty"`                                       // Request size in bytes: line, headers and body ($request_length)
+	UpstreamCacheStatus  string                 `protobuf:"bytes,21,opt,name=upstream_cache_status,json=upstreamCacheStatus,proto3" json:"upstream_cache_status,omitempty"`                    // $upstream_cache_status: HIT, MISS, EXPIRED, STALE, ...; empty when not cached
+	Traceparent          string                 `protobuf:"bytes,22,opt,name=traceparent,proto3" json:"traceparent,omitempty"`                                                                 // $http_traceparent: W3C trace context sent by the caller
+	XRequestId           string                 `protobuf:"bytes,23,opt,name=x_request_id,json=xRequestId,proto3" json:"x_request_id,omitempty"`                                               // $http_x_request_id: request ID sent by the caller (request_id is NGINX's own)
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_api_proto_agent_proto_msgTypes[71]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ApplyAugmentResponse) String() string {
+func (x *LogEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ApplyAugmentResponse) ProtoMessage() {}
+func (*LogEntry) ProtoMessage() {}
 
-func (x *ApplyAugmentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[65]
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[71]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4730,180 +5529,195 @@ func (x *ApplyAugmentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ApplyAugmentResponse.ProtoReflect.Descriptor instead.
-func (*ApplyAugmentResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{65}
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{71}
 }
 
-func (x *ApplyAugmentResponse) GetSuccess() bool {
+func (x *LogEntry) GetTimestamp() int64 {
 	if x != nil {
-		return x.Success
+		return x.Timestamp
 	}
-	return false
+	return 0
 }
 
-func (x *ApplyAugmentResponse) GetError() string {
+func (x *LogEntry) GetLogType() string {
 	if x != nil {
-		return x.Error
+		return x.LogType
 	}
 	return ""
 }
 
-func (x *ApplyAugmentResponse) GetPreview() string {
+func (x *LogEntry) GetContent() string {
 	if x != nil {
-		return x.Preview
+		return x.Content
 	}
 	return ""
 }
 
-type AnalyticsSummary struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	TotalRequests  int64                  `protobuf:"varint,1,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
-	ErrorRate      float32                `protobuf:"fixed32,2,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
-	AvgLatency     float32                `protobuf:"fixed32,3,opt,name=avg_latency,json=avgLatency,proto3" json:"avg_latency,omitempty"`
-	TotalBandwidth uint64                 `protobuf:"varint,4,opt,name=total_bandwidth,json=totalBandwidth,proto3" json:"total_bandwidth,omitempty"`
-	// Comparison vs previous period (deltas)
-	RequestsDelta  float32 `protobuf:"fixed32,5,opt,name=requests_delta,json=requestsDelta,proto3" json:"requests_delta,omitempty"`
-	LatencyDelta   float32 `protobuf:"fixed32,6,opt,name=latency_delta,json=latencyDelta,proto3" json:"latency_delta,omitempty"`
-	ErrorRateDelta float32 `protobuf:"fixed32,7,opt,name=error_rate_delta,json=errorRateDelta,proto3" json:"error_rate_delta,omitempty"`
-	Requests_2Xx   int64   `protobuf:"varint,8,opt,name=requests_2xx,json=requests2xx,proto3" json:"requests_2xx,omitempty"`
-	Requests_3Xx   int64   `protobuf:"varint,9,opt,name=requests_3xx,json=requests3xx,proto3" json:"requests_3xx,omitempty"`
-	Requests_4Xx   int64   `protobuf:"varint,10,opt,name=requests_4xx,json=requests4xx,proto3" json:"requests_4xx,omitempty"`
-	Requests_5Xx   int64   `protobuf:"varint,11,opt,name=requests_5xx,json=requests5xx,proto3" json:"requests_5xx,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *LogEntry) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
 }
 
-func (x *AnalyticsSummary) Reset() {
-	*x = AnalyticsSummary{}
-	mi := &file_api_proto_agent_proto_msgTypes[66]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *LogEntry) GetRequestMethod() string {
+	if x != nil {
+		return x.RequestMethod
+	}
+	return ""
 }
 
-func (x *AnalyticsSummary) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *LogEntry) GetRequestUri() string {
+	if x != nil {
+		return x.RequestUri
+	}
+	return ""
 }
 
-func (*AnalyticsSummary) ProtoMessage() {}
-
-func (x *AnalyticsSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[66]
+func (x *LogEntry) GetStatus() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Status
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use AnalyticsSummary.ProtoReflect.Descriptor instead.
-func (*AnalyticsSummary) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{66}
+func (x *LogEntry) GetBodyBytesSent() int64 {
+	if x != nil {
+		return x.BodyBytesSent
+	}
+	return 0
 }
 
-func (x *AnalyticsSummary) GetTotalRequests() int64 {
+func (x *LogEntry) GetRequestTime() float32 {
 	if x != nil {
-		return x.TotalRequests
+		return x.RequestTime
 	}
 	return 0
 }
 
-func (x *AnalyticsSummary) GetErrorRate() float32 {
+func (x *LogEntry) GetRequestId() string {
 	if x != nil {
-		return x.ErrorRate
+		return x.RequestId
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetAvgLatency() float32 {
+func (x *LogEntry) GetUpstreamAddr() string {
 	if x != nil {
-		return x.AvgLatency
+		return x.UpstreamAddr
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetTotalBandwidth() uint64 {
+func (x *LogEntry) GetUpstreamStatus() string {
 	if x != nil {
-		return x.TotalBandwidth
+		return x.UpstreamStatus
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetRequestsDelta() float32 {
+func (x *LogEntry) GetUpstreamConnectTime() float32 {
 	if x != nil {
-		return x.RequestsDelta
+		return x.UpstreamConnectTime
 	}
 	return 0
 }
 
-func (x *AnalyticsSummary) GetLatencyDelta() float32 {
+func (x *LogEntry) GetUpstreamHeaderTime() float32 {
 	if x != nil {
-		return x.LatencyDelta
+		return x.UpstreamHeaderTime
 	}
 	return 0
 }
 
-func (x *AnalyticsSummary) GetErrorRateDelta() float32 {
+func (x *LogEntry) GetUpstreamResponseTime() float32 {
 	if x != nil {
-		return x.ErrorRateDelta
+		return x.UpstreamResponseTime
 	}
 	return 0
 }
 
-func (x *AnalyticsSummary) GetRequests_2Xx() int64 {
+func (x *LogEntry) GetReferer() string {
 	if x != nil {
-		return x.Requests_2Xx
+		return x.Referer
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetRequests_3Xx() int64 {
+func (x *LogEntry) GetUserAgent() string {
 	if x != nil {
-		return x.Requests_3Xx
+		return x.UserAgent
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetRequests_4Xx() int64 {
+func (x *LogEntry) GetXForwardedFor() string {
 	if x != nil {
-		return x.Requests_4Xx
+		return x.XForwardedFor
 	}
-	return 0
+	return ""
 }
 
-func (x *AnalyticsSummary) GetRequests_5Xx() int64 {
+func (x *LogEntry) GetLabels() map[string]string {
 	if x != nil {
-		return x.Requests_5Xx
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *LogEntry) GetRequestLength() int64 {
+	if x != nil {
+		return x.RequestLength
 	}
 	return 0
 }
 
-type LatencyBucket struct {
+func (x *LogEntry) GetUpstreamCacheStatus() string {
+	if x != nil {
+		return x.UpstreamCacheStatus
+	}
+	return ""
+}
+
+func (x *LogEntry) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
+func (x *LogEntry) GetXRequestId() string {
+	if x != nil {
+		return x.XRequestId
+	}
+	return ""
+}
+
+type UptimeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Bucket        string                 `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"` // e.g. "0-50ms"
-	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LatencyBucket) Reset() {
-	*x = LatencyBucket{}
-	mi := &file_api_proto_agent_proto_msgTypes[67]
+func (x *UptimeRequest) Reset() {
+	*x = UptimeRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[72]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LatencyBucket) String() string {
+func (x *UptimeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LatencyBucket) ProtoMessage() {}
+func (*UptimeRequest) ProtoMessage() {}
 
-func (x *LatencyBucket) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[67]
+func (x *UptimeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[72]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4914,50 +5728,47 @@ func (x *LatencyBucket) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LatencyBucket.ProtoReflect.Descriptor instead.
-func (*LatencyBucket) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{67}
+// Deprecated: Use UptimeRequest.ProtoReflect.Descriptor instead.
+func (*UptimeRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{72}
 }
 
-func (x *LatencyBucket) GetBucket() string {
+func (x *UptimeRequest) GetAgentId() string {
 	if x != nil {
-		return x.Bucket
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *LatencyBucket) GetCount() int64 {
+func (x *UptimeRequest) GetLimit() int32 {
 	if x != nil {
-		return x.Count
+		return x.Limit
 	}
 	return 0
 }
 
-type ServerStat struct {
+type UptimeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
-	ErrorRate     float32                `protobuf:"fixed32,3,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
-	Traffic       uint64                 `protobuf:"varint,4,opt,name=traffic,proto3" json:"traffic,omitempty"`
+	Reports       []*UptimeReport        `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ServerStat) Reset() {
-	*x = ServerStat{}
-	mi := &file_api_proto_agent_proto_msgTypes[68]
+func (x *UptimeResponse) Reset() {
+	*x = UptimeResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[73]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ServerStat) String() string {
+func (x *UptimeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerStat) ProtoMessage() {}
+func (*UptimeResponse) ProtoMessage() {}
 
-func (x *ServerStat) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[68]
+func (x *UptimeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4968,70 +5779,45 @@ func (x *ServerStat) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ServerStat.ProtoReflect.Descriptor instead.
-func (*ServerStat) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{68}
-}
-
-func (x *ServerStat) GetHostname() string {
-	if x != nil {
-		return x.Hostname
-	}
-	return ""
-}
-
-func (x *ServerStat) GetRequests() int64 {
-	if x != nil {
-		return x.Requests
-	}
-	return 0
-}
-
-func (x *ServerStat) GetErrorRate() float32 {
-	if x != nil {
-		return x.ErrorRate
-	}
-	return 0
+// Deprecated: Use UptimeResponse.ProtoReflect.Descriptor instead.
+func (*UptimeResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{73}
 }
 
-func (x *ServerStat) GetTraffic() uint64 {
+func (x *UptimeResponse) GetReports() []*UptimeReport {
 	if x != nil {
-		return x.Traffic
+		return x.Reports
 	}
-	return 0
+	return nil
 }
 
-type NginxMetricPoint struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp         int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Active            int64                  `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
-	Reading           int64                  `protobuf:"varint,3,opt,name=reading,proto3" json:"reading,omitempty"`
-	Writing           int64                  `protobuf:"varint,4,opt,name=writing,proto3" json:"writing,omitempty"`
-	Waiting           int64                  `protobuf:"varint,5,opt,name=waiting,proto3" json:"waiting,omitempty"`
-	Accepted          int64                  `protobuf:"varint,6,opt,name=accepted,proto3" json:"accepted,omitempty"`
-	Handled           int64                  `protobuf:"varint,7,opt,name=handled,proto3" json:"handled,omitempty"`
-	Requests          int64                  `protobuf:"varint,8,opt,name=requests,proto3" json:"requests,omitempty"`
-	RequestsPerSecond float64                `protobuf:"fixed64,9,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
-	Time              string                 `protobuf:"bytes,10,opt,name=time,proto3" json:"time,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+type UptimeReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "UP", "DOWN", "DEGRADED"
+	LatencyMs     float32                `protobuf:"fixed32,3,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	CheckType     string                 `protobuf:"bytes,4,opt,name=check_type,json=checkType,proto3" json:"check_type,omitempty"` // "HTTP", "TCP"
+	Target        string                 `protobuf:"bytes,5,opt,name=target,proto3" json:"target,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NginxMetricPoint) Reset() {
-	*x = NginxMetricPoint{}
-	mi := &file_api_proto_agent_proto_msgTypes[69]
+func (x *UptimeReport) Reset() {
+	*x = UptimeReport{}
+	mi := &file_api_proto_agent_proto_msgTypes[74]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NginxMetricPoint) String() string {
+func (x *UptimeReport) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NginxMetricPoint) ProtoMessage() {}
+func (*UptimeReport) ProtoMessage() {}
 
-func (x *NginxMetricPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[69]
+func (x *UptimeReport) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[74]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5042,105 +5828,87 @@ func (x *NginxMetricPoint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NginxMetricPoint.ProtoReflect.Descriptor instead.
-func (*NginxMetricPoint) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{69}
+// Deprecated: Use UptimeReport.ProtoReflect.Descriptor instead.
+func (*UptimeReport) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{74}
 }
 
-func (x *NginxMetricPoint) GetTimestamp() int64 {
+func (x *UptimeReport) GetTimestamp() int64 {
 	if x != nil {
 		return x.Timestamp
 	}
 	return 0
 }
 
-func (x *NginxMetricPoint) GetActive() int64 {
-	if x != nil {
-		return x.Active
-	}
-	return 0
-}
-
-func (x *NginxMetricPoint) GetReading() int64 {
-	if x != nil {
-		return x.Reading
-	}
-	return 0
-}
-
-func (x *NginxMetricPoint) GetWriting() int64 {
-	if x != nil {
-		return x.Writing
-	}
-	return 0
-}
-
-func (x *NginxMetricPoint) GetWaiting() int64 {
-	if x != nil {
-		return x.Waiting
-	}
-	return 0
-}
-
-func (x *NginxMetricPoint) GetAccepted() int64 {
+func (x *UptimeReport) GetStatus() string {
 	if x != nil {
-		return x.Accepted
+		return x.Status
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetricPoint) GetHandled() int64 {
+func (x *UptimeReport) GetLatencyMs() float32 {
 	if x != nil {
-		return x.Handled
+		return x.LatencyMs
 	}
 	return 0
 }
 
-func (x *NginxMetricPoint) GetRequests() int64 {
+func (x *UptimeReport) GetCheckType() string {
 	if x != nil {
-		return x.Requests
+		return x.CheckType
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetricPoint) GetRequestsPerSecond() float64 {
+func (x *UptimeReport) GetTarget() string {
 	if x != nil {
-		return x.RequestsPerSecond
+		return x.Target
 	}
-	return 0
+	return ""
 }
 
-func (x *NginxMetricPoint) GetTime() string {
+func (x *UptimeReport) GetError() string {
 	if x != nil {
-		return x.Time
+		return x.Error
 	}
 	return ""
 }
 
-type TimeSeriesPoint struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
-	Errors        int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// The agents of an AnalyticsRequest are narrowed to those the caller may see: gRPC clients acting
+// for a user forward the user's session token as "authorization: Bearer <token>" metadata.
+type AnalyticsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AgentId          string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                              // Optional, defaults to all
+	TimeWindow       string                 `protobuf:"bytes,2,opt,name=time_window,json=timeWindow,proto3" json:"time_window,omitempty"`                     // "1h", "24h", "7d"
+	EnvironmentId    string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`            // Optional: filter by environment
+	ProjectId        string                 `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`                        // Optional: filter by project (all environments)
+	FromTimestamp    int64                  `protobuf:"varint,5,opt,name=from_timestamp,json=fromTimestamp,proto3" json:"from_timestamp,omitempty"`           // Optional: absolute start time (milliseconds)
+	ToTimestamp      int64                  `protobuf:"varint,6,opt,name=to_timestamp,json=toTimestamp,proto3" json:"to_timestamp,omitempty"`                 // Optional: absolute end time (milliseconds)
+	Timezone         string                 `protobuf:"bytes,7,opt,name=timezone,proto3" json:"timezone,omitempty"`                                           // Optional: IANA timezone (e.g. "Europe/Berlin") of time buckets and labels; UTC by default
+	UrlFilter        string                 `protobuf:"bytes,8,opt,name=url_filter,json=urlFilter,proto3" json:"url_filter,omitempty"`                        // Optional: filter by specific URL
+	StatusCodeFilter string                 `protobuf:"bytes,9,opt,name=status_code_filter,json=statusCodeFilter,proto3" json:"status_code_filter,omitempty"` // Optional: filter by specific status code or class (e.g., "4xx")
+	Deltas           bool                   `protobuf:"varint,10,opt,name=deltas,proto3" json:"deltas,omitempty"`                                             // StreamAnalytics: send only changes after the first response (see AnalyticsDelta)
+	Compare          *AnalyticsComparison   `protobuf:"bytes,11,opt,name=compare,proto3" json:"compare,omitempty"`                                            // Optional: also return a baseline to compare against
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *TimeSeriesPoint) Reset() {
-	*x = TimeSeriesPoint{}
-	mi := &file_api_proto_agent_proto_msgTypes[70]
+func (x *AnalyticsRequest) Reset() {
+	*x = AnalyticsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[75]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TimeSeriesPoint) String() string {
+func (x *AnalyticsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TimeSeriesPoint) ProtoMessage() {}
+func (*AnalyticsRequest) ProtoMessage() {}
 
-func (x *TimeSeriesPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[70]
+func (x *AnalyticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[75]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5151,181 +5919,118 @@ func (x *TimeSeriesPoint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TimeSeriesPoint.ProtoReflect.Descriptor instead.
-func (*TimeSeriesPoint) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{70}
+// Deprecated: Use AnalyticsRequest.ProtoReflect.Descriptor instead.
+func (*AnalyticsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{75}
 }
 
-func (x *TimeSeriesPoint) GetTime() string {
+func (x *AnalyticsRequest) GetAgentId() string {
 	if x != nil {
-		return x.Time
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *TimeSeriesPoint) GetRequests() int64 {
+func (x *AnalyticsRequest) GetTimeWindow() string {
 	if x != nil {
-		return x.Requests
+		return x.TimeWindow
 	}
-	return 0
+	return ""
 }
 
-func (x *TimeSeriesPoint) GetErrors() int64 {
+func (x *AnalyticsRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.Errors
+		return x.EnvironmentId
 	}
-	return 0
-}
-
-type StatusCount struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
-	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *StatusCount) Reset() {
-	*x = StatusCount{}
-	mi := &file_api_proto_agent_proto_msgTypes[71]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *StatusCount) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*StatusCount) ProtoMessage() {}
-
-func (x *StatusCount) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[71]
+func (x *AnalyticsRequest) GetProjectId() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ProjectId
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use StatusCount.ProtoReflect.Descriptor instead.
-func (*StatusCount) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{71}
+	return ""
 }
 
-func (x *StatusCount) GetCode() string {
+func (x *AnalyticsRequest) GetFromTimestamp() int64 {
 	if x != nil {
-		return x.Code
+		return x.FromTimestamp
 	}
-	return ""
+	return 0
 }
 
-func (x *StatusCount) GetCount() int64 {
+func (x *AnalyticsRequest) GetToTimestamp() int64 {
 	if x != nil {
-		return x.Count
+		return x.ToTimestamp
 	}
 	return 0
 }
 
-type LatencyPercentiles struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	P50           float32                `protobuf:"fixed32,2,opt,name=p50,proto3" json:"p50,omitempty"`
-	P95           float32                `protobuf:"fixed32,3,opt,name=p95,proto3" json:"p95,omitempty"`
-	P99           float32                `protobuf:"fixed32,4,opt,name=p99,proto3" json:"p99,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *LatencyPercentiles) Reset() {
-	*x = LatencyPercentiles{}
-	mi := &file_api_proto_agent_proto_msgTypes[72]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *LatencyPercentiles) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*LatencyPercentiles) ProtoMessage() {}
-
-func (x *LatencyPercentiles) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[72]
+func (x *AnalyticsRequest) GetTimezone() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Timezone
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use LatencyPercentiles.ProtoReflect.Descriptor instead.
-func (*LatencyPercentiles) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{72}
+	return ""
 }
 
-func (x *LatencyPercentiles) GetTime() string {
+func (x *AnalyticsRequest) GetUrlFilter() string {
 	if x != nil {
-		return x.Time
+		return x.UrlFilter
 	}
 	return ""
 }
 
-func (x *LatencyPercentiles) GetP50() float32 {
+func (x *AnalyticsRequest) GetStatusCodeFilter() string {
 	if x != nil {
-		return x.P50
+		return x.StatusCodeFilter
 	}
-	return 0
+	return ""
 }
 
-func (x *LatencyPercentiles) GetP95() float32 {
+func (x *AnalyticsRequest) GetDeltas() bool {
 	if x != nil {
-		return x.P95
+		return x.Deltas
 	}
-	return 0
+	return false
 }
 
-func (x *LatencyPercentiles) GetP99() float32 {
+func (x *AnalyticsRequest) GetCompare() *AnalyticsComparison {
 	if x != nil {
-		return x.P99
+		return x.Compare
 	}
-	return 0
+	return nil
 }
 
-type SystemMetricPoint struct {
+// AnalyticsComparison picks the baseline of a compare-mode request: another time range and/or
+// other agents. Unset fields keep those of the request, so {offset: "7d"} is this week vs last
+// week and {environment_id: "stable"} on a canary environment request is canary vs stable.
+type AnalyticsComparison struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	CpuUsage      float32                `protobuf:"fixed32,2,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
-	MemoryUsage   float32                `protobuf:"fixed32,3,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
-	NetworkRxRate float32                `protobuf:"fixed32,4,opt,name=network_rx_rate,json=networkRxRate,proto3" json:"network_rx_rate,omitempty"`
-	NetworkTxRate float32                `protobuf:"fixed32,5,opt,name=network_tx_rate,json=networkTxRate,proto3" json:"network_tx_rate,omitempty"`
-	CpuUser       float32                `protobuf:"fixed32,6,opt,name=cpu_user,json=cpuUser,proto3" json:"cpu_user,omitempty"`
-	CpuSystem     float32                `protobuf:"fixed32,7,opt,name=cpu_system,json=cpuSystem,proto3" json:"cpu_system,omitempty"`
-	CpuIowait     float32                `protobuf:"fixed32,8,opt,name=cpu_iowait,json=cpuIowait,proto3" json:"cpu_iowait,omitempty"`
+	Offset        string                 `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"`                                     // shift of the requested range into the past: "1h", "1d", "7d" or "30d"
+	FromTimestamp int64                  `protobuf:"varint,2,opt,name=from_timestamp,json=fromTimestamp,proto3" json:"from_timestamp,omitempty"` // or an absolute baseline range (milliseconds)
+	ToTimestamp   int64                  `protobuf:"varint,3,opt,name=to_timestamp,json=toTimestamp,proto3" json:"to_timestamp,omitempty"`
+	AgentId       string                 `protobuf:"bytes,4,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,5,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,6,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SystemMetricPoint) Reset() {
-	*x = SystemMetricPoint{}
-	mi := &file_api_proto_agent_proto_msgTypes[73]
+func (x *AnalyticsComparison) Reset() {
+	*x = AnalyticsComparison{}
+	mi := &file_api_proto_agent_proto_msgTypes[76]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SystemMetricPoint) String() string {
+func (x *AnalyticsComparison) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SystemMetricPoint) ProtoMessage() {}
+func (*AnalyticsComparison) ProtoMessage() {}
 
-func (x *SystemMetricPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[73]
+func (x *AnalyticsComparison) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[76]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5336,95 +6041,112 @@ func (x *SystemMetricPoint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SystemMetricPoint.ProtoReflect.Descriptor instead.
-func (*SystemMetricPoint) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{73}
+// Deprecated: Use AnalyticsComparison.ProtoReflect.Descriptor instead.
+func (*AnalyticsComparison) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{76}
 }
 
-func (x *SystemMetricPoint) GetTime() string {
+func (x *AnalyticsComparison) GetOffset() string {
 	if x != nil {
-		return x.Time
+		return x.Offset
 	}
 	return ""
 }
 
-func (x *SystemMetricPoint) GetCpuUsage() float32 {
+func (x *AnalyticsComparison) GetFromTimestamp() int64 {
 	if x != nil {
-		return x.CpuUsage
+		return x.FromTimestamp
 	}
 	return 0
 }
 
-func (x *SystemMetricPoint) GetMemoryUsage() float32 {
+func (x *AnalyticsComparison) GetToTimestamp() int64 {
 	if x != nil {
-		return x.MemoryUsage
+		return x.ToTimestamp
 	}
 	return 0
 }
 
-func (x *SystemMetricPoint) GetNetworkRxRate() float32 {
+func (x *AnalyticsComparison) GetAgentId() string {
 	if x != nil {
-		return x.NetworkRxRate
+		return x.AgentId
 	}
-	return 0
+	return ""
 }
 
-func (x *SystemMetricPoint) GetNetworkTxRate() float32 {
+func (x *AnalyticsComparison) GetEnvironmentId() string {
 	if x != nil {
-		return x.NetworkTxRate
+		return x.EnvironmentId
 	}
-	return 0
+	return ""
 }
 
-func (x *SystemMetricPoint) GetCpuUser() float32 {
+func (x *AnalyticsComparison) GetProjectId() string {
 	if x != nil {
-		return x.CpuUser
-	}
-	return 0
-}
-
-func (x *SystemMetricPoint) GetCpuSystem() float32 {
-	if x != nil {
-		return x.CpuSystem
-	}
-	return 0
-}
-
-func (x *SystemMetricPoint) GetCpuIowait() float32 {
-	if x != nil {
-		return x.CpuIowait
+		return x.ProjectId
 	}
-	return 0
+	return ""
 }
 
-type HttpStatusMetricsResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	Status_2Xx_5Min     []*TimeSeriesPoint     `protobuf:"bytes,1,rep,name=status_2xx_5min,json=status2xx5min,proto3" json:"status_2xx_5min,omitempty"`
-	TotalStatus_200_24H int64                  `protobuf:"varint,2,opt,name=total_status_200_24h,json=totalStatus20024h,proto3" json:"total_status_200_24h,omitempty"`
-	Status_4Xx_5Min     []*TimeSeriesPoint     `protobuf:"bytes,3,rep,name=status_4xx_5min,json=status4xx5min,proto3" json:"status_4xx_5min,omitempty"`
-	TotalStatus_404_24H int64                  `protobuf:"varint,4,opt,name=total_status_404_24h,json=totalStatus40424h,proto3" json:"total_status_404_24h,omitempty"`
-	Status_3Xx          []*TimeSeriesPoint     `protobuf:"bytes,5,rep,name=status_3xx,json=status3xx,proto3" json:"status_3xx,omitempty"`
-	Status_5Xx          []*TimeSeriesPoint     `protobuf:"bytes,6,rep,name=status_5xx,json=status5xx,proto3" json:"status_5xx,omitempty"`
-	TotalStatus_503     int64                  `protobuf:"varint,7,opt,name=total_status_503,json=totalStatus503,proto3" json:"total_status_503,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type AnalyticsResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	RequestRate        []*TimeSeriesPoint     `protobuf:"bytes,1,rep,name=request_rate,json=requestRate,proto3" json:"request_rate,omitempty"`
+	StatusDistribution []*StatusCount         `protobuf:"bytes,2,rep,name=status_distribution,json=statusDistribution,proto3" json:"status_distribution,omitempty"`
+	LatencyTrend       []*LatencyPercentiles  `protobuf:"bytes,3,rep,name=latency_trend,json=latencyTrend,proto3" json:"latency_trend,omitempty"`
+	TopEndpoints       []*EndpointStat        `protobuf:"bytes,4,rep,name=top_endpoints,json=topEndpoints,proto3" json:"top_endpoints,omitempty"`
+	ConnectionsHistory []*NginxMetricPoint    `protobuf:"bytes,5,rep,name=connections_history,json=connectionsHistory,proto3" json:"connections_history,omitempty"`
+	// New fields for KPI cards and distributions
+	Summary             *AnalyticsSummary `protobuf:"bytes,6,opt,name=summary,proto3" json:"summary,omitempty"`
+	LatencyDistribution []*LatencyBucket  `protobuf:"bytes,7,rep,name=latency_distribution,json=latencyDistribution,proto3" json:"latency_distribution,omitempty"`
+	ServerDistribution  []*ServerStat     `protobuf:"bytes,8,rep,name=server_distribution,json=serverDistribution,proto3" json:"server_distribution,omitempty"`
+	// System metrics time series
+	SystemMetrics []*SystemMetricPoint `protobuf:"bytes,9,rep,name=system_metrics,json=systemMetrics,proto3" json:"system_metrics,omitempty"`
+	// HTTP status metrics
+	HttpStatusMetrics *HttpStatusMetricsResponse `protobuf:"bytes,10,opt,name=http_status_metrics,json=httpStatusMetrics,proto3" json:"http_status_metrics,omitempty"`
+	// Actionable Insights for Decision Hub
+	Insights []*Insight `protobuf:"bytes,11,rep,name=insights,proto3" json:"insights,omitempty"`
+	// Recent Requests (for detailed view)
+	RecentRequests []*LogEntry `protobuf:"bytes,12,rep,name=recent_requests,json=recentRequests,proto3" json:"recent_requests,omitempty"`
+	// Gateway specific metrics
+	GatewayMetrics []*GatewayMetricPoint `protobuf:"bytes,13,rep,name=gateway_metrics,json=gatewayMetrics,proto3" json:"gateway_metrics,omitempty"`
+	// Network origin and automated traffic
+	TopAsns    []*ASNStat  `protobuf:"bytes,14,rep,name=top_asns,json=topAsns,proto3" json:"top_asns,omitempty"`
+	BotTraffic *BotTraffic `protobuf:"bytes,15,opt,name=bot_traffic,json=botTraffic,proto3" json:"bot_traffic,omitempty"`
+	// Sections left out of the response because their query failed or timed out
+	Warnings []string `protobuf:"bytes,16,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// Set on StreamAnalytics responses when the request asked for deltas
+	Delta *AnalyticsDelta `protobuf:"bytes,17,opt,name=delta,proto3" json:"delta,omitempty"`
+	// Bandwidth received (request_length) and sent (body_bytes_sent), with the biggest agents and
+	// URI paths by bytes sent
+	Bandwidth        []*BandwidthPoint `protobuf:"bytes,18,rep,name=bandwidth,proto3" json:"bandwidth,omitempty"`
+	BandwidthByAgent []*BandwidthStat  `protobuf:"bytes,19,rep,name=bandwidth_by_agent,json=bandwidthByAgent,proto3" json:"bandwidth_by_agent,omitempty"`
+	BandwidthByUri   []*BandwidthStat  `protobuf:"bytes,20,rep,name=bandwidth_by_uri,json=bandwidthByUri,proto3" json:"bandwidth_by_uri,omitempty"`
+	EgressCost       *EgressCost       `protobuf:"bytes,21,opt,name=egress_cost,json=egressCost,proto3" json:"egress_cost,omitempty"` // unset without an egress cost model
+	// Proxy cache effectiveness; unset when no request in the window went through a cache
+	Cache *CacheStats `protobuf:"bytes,22,opt,name=cache,proto3" json:"cache,omitempty"`
+	// Compare mode: the analytics of the baseline, and how the key metrics moved against it. A
+	// baseline range has the length of the requested one, so their series line up point by point.
+	Baseline      *AnalyticsResponse          `protobuf:"bytes,23,opt,name=baseline,proto3" json:"baseline,omitempty"`
+	Comparison    *AnalyticsComparisonSummary `protobuf:"bytes,24,opt,name=comparison,proto3" json:"comparison,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HttpStatusMetricsResponse) Reset() {
-	*x = HttpStatusMetricsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[74]
+func (x *AnalyticsResponse) Reset() {
+	*x = AnalyticsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[77]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HttpStatusMetricsResponse) String() string {
+func (x *AnalyticsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HttpStatusMetricsResponse) ProtoMessage() {}
+func (*AnalyticsResponse) ProtoMessage() {}
 
-func (x *HttpStatusMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[74]
+func (x *AnalyticsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[77]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5435,202 +6157,203 @@ func (x *HttpStatusMetricsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HttpStatusMetricsResponse.ProtoReflect.Descriptor instead.
-func (*HttpStatusMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{74}
+// Deprecated: Use AnalyticsResponse.ProtoReflect.Descriptor instead.
+func (*AnalyticsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{77}
 }
 
-func (x *HttpStatusMetricsResponse) GetStatus_2Xx_5Min() []*TimeSeriesPoint {
+func (x *AnalyticsResponse) GetRequestRate() []*TimeSeriesPoint {
 	if x != nil {
-		return x.Status_2Xx_5Min
+		return x.RequestRate
 	}
 	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetTotalStatus_200_24H() int64 {
+func (x *AnalyticsResponse) GetStatusDistribution() []*StatusCount {
 	if x != nil {
-		return x.TotalStatus_200_24H
+		return x.StatusDistribution
 	}
-	return 0
+	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetStatus_4Xx_5Min() []*TimeSeriesPoint {
+func (x *AnalyticsResponse) GetLatencyTrend() []*LatencyPercentiles {
 	if x != nil {
-		return x.Status_4Xx_5Min
+		return x.LatencyTrend
 	}
 	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetTotalStatus_404_24H() int64 {
+func (x *AnalyticsResponse) GetTopEndpoints() []*EndpointStat {
 	if x != nil {
-		return x.TotalStatus_404_24H
+		return x.TopEndpoints
 	}
-	return 0
+	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetStatus_3Xx() []*TimeSeriesPoint {
+func (x *AnalyticsResponse) GetConnectionsHistory() []*NginxMetricPoint {
 	if x != nil {
-		return x.Status_3Xx
+		return x.ConnectionsHistory
 	}
 	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetStatus_5Xx() []*TimeSeriesPoint {
+func (x *AnalyticsResponse) GetSummary() *AnalyticsSummary {
 	if x != nil {
-		return x.Status_5Xx
+		return x.Summary
 	}
 	return nil
 }
 
-func (x *HttpStatusMetricsResponse) GetTotalStatus_503() int64 {
+func (x *AnalyticsResponse) GetLatencyDistribution() []*LatencyBucket {
 	if x != nil {
-		return x.TotalStatus_503
+		return x.LatencyDistribution
 	}
-	return 0
+	return nil
 }
 
-type EndpointStat struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Uri           string                 `protobuf:"bytes,1,opt,name=uri,proto3" json:"uri,omitempty"`
-	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
-	P95           float32                `protobuf:"fixed32,3,opt,name=p95,proto3" json:"p95,omitempty"`
-	Errors        int64                  `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
-	Traffic       string                 `protobuf:"bytes,5,opt,name=traffic,proto3" json:"traffic,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *AnalyticsResponse) GetServerDistribution() []*ServerStat {
+	if x != nil {
+		return x.ServerDistribution
+	}
+	return nil
 }
 
-func (x *EndpointStat) Reset() {
-	*x = EndpointStat{}
-	mi := &file_api_proto_agent_proto_msgTypes[75]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AnalyticsResponse) GetSystemMetrics() []*SystemMetricPoint {
+	if x != nil {
+		return x.SystemMetrics
+	}
+	return nil
 }
 
-func (x *EndpointStat) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AnalyticsResponse) GetHttpStatusMetrics() *HttpStatusMetricsResponse {
+	if x != nil {
+		return x.HttpStatusMetrics
+	}
+	return nil
 }
 
-func (*EndpointStat) ProtoMessage() {}
-
-func (x *EndpointStat) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[75]
+func (x *AnalyticsResponse) GetInsights() []*Insight {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Insights
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use EndpointStat.ProtoReflect.Descriptor instead.
-func (*EndpointStat) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{75}
+func (x *AnalyticsResponse) GetRecentRequests() []*LogEntry {
+	if x != nil {
+		return x.RecentRequests
+	}
+	return nil
 }
 
-func (x *EndpointStat) GetUri() string {
+func (x *AnalyticsResponse) GetGatewayMetrics() []*GatewayMetricPoint {
 	if x != nil {
-		return x.Uri
+		return x.GatewayMetrics
 	}
-	return ""
+	return nil
 }
 
-func (x *EndpointStat) GetRequests() int64 {
+func (x *AnalyticsResponse) GetTopAsns() []*ASNStat {
 	if x != nil {
-		return x.Requests
+		return x.TopAsns
 	}
-	return 0
+	return nil
 }
 
-func (x *EndpointStat) GetP95() float32 {
+func (x *AnalyticsResponse) GetBotTraffic() *BotTraffic {
 	if x != nil {
-		return x.P95
+		return x.BotTraffic
 	}
-	return 0
+	return nil
 }
 
-func (x *EndpointStat) GetErrors() int64 {
+func (x *AnalyticsResponse) GetWarnings() []string {
 	if x != nil {
-		return x.Errors
+		return x.Warnings
 	}
-	return 0
+	return nil
 }
 
-func (x *EndpointStat) GetTraffic() string {
+func (x *AnalyticsResponse) GetDelta() *AnalyticsDelta {
 	if x != nil {
-		return x.Traffic
+		return x.Delta
 	}
-	return ""
+	return nil
 }
 
-type RecommendationRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Optional
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *AnalyticsResponse) GetBandwidth() []*BandwidthPoint {
+	if x != nil {
+		return x.Bandwidth
+	}
+	return nil
 }
 
-func (x *RecommendationRequest) Reset() {
-	*x = RecommendationRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[76]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AnalyticsResponse) GetBandwidthByAgent() []*BandwidthStat {
+	if x != nil {
+		return x.BandwidthByAgent
+	}
+	return nil
 }
 
-func (x *RecommendationRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AnalyticsResponse) GetBandwidthByUri() []*BandwidthStat {
+	if x != nil {
+		return x.BandwidthByUri
+	}
+	return nil
 }
 
-func (*RecommendationRequest) ProtoMessage() {}
+func (x *AnalyticsResponse) GetEgressCost() *EgressCost {
+	if x != nil {
+		return x.EgressCost
+	}
+	return nil
+}
 
-func (x *RecommendationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[76]
+func (x *AnalyticsResponse) GetCache() *CacheStats {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Cache
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use RecommendationRequest.ProtoReflect.Descriptor instead.
-func (*RecommendationRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{76}
+func (x *AnalyticsResponse) GetBaseline() *AnalyticsResponse {
+	if x != nil {
+		return x.Baseline
+	}
+	return nil
 }
 
-func (x *RecommendationRequest) GetAgentId() string {
+func (x *AnalyticsResponse) GetComparison() *AnalyticsComparisonSummary {
 	if x != nil {
-		return x.AgentId
+		return x.Comparison
 	}
-	return ""
+	return nil
 }
 
-type RecommendationResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Recommendations []*Recommendation      `protobuf:"bytes,1,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type AnalyticsComparisonSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromTimestamp int64                  `protobuf:"varint,1,opt,name=from_timestamp,json=fromTimestamp,proto3" json:"from_timestamp,omitempty"` // baseline range (milliseconds)
+	ToTimestamp   int64                  `protobuf:"varint,2,opt,name=to_timestamp,json=toTimestamp,proto3" json:"to_timestamp,omitempty"`
+	Changes       []*MetricChange        `protobuf:"bytes,3,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RecommendationResponse) Reset() {
-	*x = RecommendationResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[77]
+func (x *AnalyticsComparisonSummary) Reset() {
+	*x = AnalyticsComparisonSummary{}
+	mi := &file_api_proto_agent_proto_msgTypes[78]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RecommendationResponse) String() string {
+func (x *AnalyticsComparisonSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RecommendationResponse) ProtoMessage() {}
+func (*AnalyticsComparisonSummary) ProtoMessage() {}
 
-func (x *RecommendationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[77]
+func (x *AnalyticsComparisonSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[78]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5641,51 +6364,59 @@ func (x *RecommendationResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RecommendationResponse.ProtoReflect.Descriptor instead.
-func (*RecommendationResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{77}
+// Deprecated: Use AnalyticsComparisonSummary.ProtoReflect.Descriptor instead.
+func (*AnalyticsComparisonSummary) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{78}
 }
 
-func (x *RecommendationResponse) GetRecommendations() []*Recommendation {
+func (x *AnalyticsComparisonSummary) GetFromTimestamp() int64 {
 	if x != nil {
-		return x.Recommendations
+		return x.FromTimestamp
 	}
-	return nil
+	return 0
 }
 
-type Recommendation struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	Id                   int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title                string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Description          string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Details              string                 `protobuf:"bytes,4,opt,name=details,proto3" json:"details,omitempty"`
-	Impact               string                 `protobuf:"bytes,5,opt,name=impact,proto3" json:"impact,omitempty"`     // "high", "medium", "low"
-	Category             string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"` // "Performance", "Optimization", "Security"
-	Confidence           float32                `protobuf:"fixed32,7,opt,name=confidence,proto3" json:"confidence,omitempty"`
-	EstimatedImprovement string                 `protobuf:"bytes,8,opt,name=estimated_improvement,json=estimatedImprovement,proto3" json:"estimated_improvement,omitempty"`
-	CurrentConfig        string                 `protobuf:"bytes,9,opt,name=current_config,json=currentConfig,proto3" json:"current_config,omitempty"`
-	SuggestedConfig      string                 `protobuf:"bytes,10,opt,name=suggested_config,json=suggestedConfig,proto3" json:"suggested_config,omitempty"`
-	Server               string                 `protobuf:"bytes,11,opt,name=server,proto3" json:"server,omitempty"`
-	Timestamp            int64                  `protobuf:"varint,12,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+func (x *AnalyticsComparisonSummary) GetToTimestamp() int64 {
+	if x != nil {
+		return x.ToTimestamp
+	}
+	return 0
 }
 
-func (x *Recommendation) Reset() {
-	*x = Recommendation{}
-	mi := &file_api_proto_agent_proto_msgTypes[78]
+func (x *AnalyticsComparisonSummary) GetChanges() []*MetricChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// MetricChange is a key metric in the requested range (current) and the baseline
+type MetricChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metric        string                 `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"` // total_requests, error_rate, avg_latency, requests_5xx, total_bandwidth
+	Current       float64                `protobuf:"fixed64,2,opt,name=current,proto3" json:"current,omitempty"`
+	Baseline      float64                `protobuf:"fixed64,3,opt,name=baseline,proto3" json:"baseline,omitempty"`
+	Change        float64                `protobuf:"fixed64,4,opt,name=change,proto3" json:"change,omitempty"`                                    // current - baseline
+	ChangePercent float64                `protobuf:"fixed64,5,opt,name=change_percent,json=changePercent,proto3" json:"change_percent,omitempty"` // relative to the baseline; 0 when the baseline is 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricChange) Reset() {
+	*x = MetricChange{}
+	mi := &file_api_proto_agent_proto_msgTypes[79]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Recommendation) String() string {
+func (x *MetricChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Recommendation) ProtoMessage() {}
+func (*MetricChange) ProtoMessage() {}
 
-func (x *Recommendation) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[78]
+func (x *MetricChange) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[79]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5696,121 +6427,164 @@ func (x *Recommendation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Recommendation.ProtoReflect.Descriptor instead.
-func (*Recommendation) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{78}
+// Deprecated: Use MetricChange.ProtoReflect.Descriptor instead.
+func (*MetricChange) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{79}
 }
 
-func (x *Recommendation) GetId() int32 {
+func (x *MetricChange) GetMetric() string {
 	if x != nil {
-		return x.Id
+		return x.Metric
+	}
+	return ""
+}
+
+func (x *MetricChange) GetCurrent() float64 {
+	if x != nil {
+		return x.Current
 	}
 	return 0
 }
 
-func (x *Recommendation) GetTitle() string {
+func (x *MetricChange) GetBaseline() float64 {
 	if x != nil {
-		return x.Title
+		return x.Baseline
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetDescription() string {
+func (x *MetricChange) GetChange() float64 {
 	if x != nil {
-		return x.Description
+		return x.Change
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetDetails() string {
+func (x *MetricChange) GetChangePercent() float64 {
 	if x != nil {
-		return x.Details
+		return x.ChangePercent
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetImpact() string {
+// CacheStats is the effectiveness of the NGINX proxy cache ($upstream_cache_status) over the window.
+// Ratios are shares of the requests that went through a cache (a non-empty status): hits are HIT
+// and REVALIDATED, stale are STALE and UPDATING, misses are MISS and EXPIRED; BYPASS makes up the rest.
+type CacheStats struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CachedRequests int64                  `protobuf:"varint,1,opt,name=cached_requests,json=cachedRequests,proto3" json:"cached_requests,omitempty"` // requests with a cache status
+	HitRatio       float32                `protobuf:"fixed32,2,opt,name=hit_ratio,json=hitRatio,proto3" json:"hit_ratio,omitempty"`
+	MissRatio      float32                `protobuf:"fixed32,3,opt,name=miss_ratio,json=missRatio,proto3" json:"miss_ratio,omitempty"`
+	StaleRatio     float32                `protobuf:"fixed32,4,opt,name=stale_ratio,json=staleRatio,proto3" json:"stale_ratio,omitempty"`
+	BypassRatio    float32                `protobuf:"fixed32,5,opt,name=bypass_ratio,json=bypassRatio,proto3" json:"bypass_ratio,omitempty"`
+	Statuses       []*CacheStatusCount    `protobuf:"bytes,6,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	TopUris        []*CacheUriStat        `protobuf:"bytes,7,rep,name=top_uris,json=topUris,proto3" json:"top_uris,omitempty"` // paths with the most cached requests
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CacheStats) Reset() {
+	*x = CacheStats{}
+	mi := &file_api_proto_agent_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheStats) ProtoMessage() {}
+
+func (x *CacheStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[80]
 	if x != nil {
-		return x.Impact
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Recommendation) GetCategory() string {
+// Deprecated: Use CacheStats.ProtoReflect.Descriptor instead.
+func (*CacheStats) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *CacheStats) GetCachedRequests() int64 {
 	if x != nil {
-		return x.Category
+		return x.CachedRequests
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetConfidence() float32 {
+func (x *CacheStats) GetHitRatio() float32 {
 	if x != nil {
-		return x.Confidence
+		return x.HitRatio
 	}
 	return 0
 }
 
-func (x *Recommendation) GetEstimatedImprovement() string {
+func (x *CacheStats) GetMissRatio() float32 {
 	if x != nil {
-		return x.EstimatedImprovement
+		return x.MissRatio
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetCurrentConfig() string {
+func (x *CacheStats) GetStaleRatio() float32 {
 	if x != nil {
-		return x.CurrentConfig
+		return x.StaleRatio
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetSuggestedConfig() string {
+func (x *CacheStats) GetBypassRatio() float32 {
 	if x != nil {
-		return x.SuggestedConfig
+		return x.BypassRatio
 	}
-	return ""
+	return 0
 }
 
-func (x *Recommendation) GetServer() string {
+func (x *CacheStats) GetStatuses() []*CacheStatusCount {
 	if x != nil {
-		return x.Server
+		return x.Statuses
 	}
-	return ""
+	return nil
 }
 
-func (x *Recommendation) GetTimestamp() int64 {
+func (x *CacheStats) GetTopUris() []*CacheUriStat {
 	if x != nil {
-		return x.Timestamp
+		return x.TopUris
 	}
-	return 0
+	return nil
 }
 
-type ReportRequest struct {
+type CacheStatusCount struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`   // Unix timestamp
-	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`         // Unix timestamp
-	AgentIds      []string               `protobuf:"bytes,3,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`       // Optional filter
-	ReportType    string                 `protobuf:"bytes,4,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"` // "summary", "detailed", "security"
-	Format        string                 `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`                           // Download format: "pdf", "excel" (optional, default pdf)
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // HIT, MISS, EXPIRED, STALE, UPDATING, REVALIDATED, BYPASS
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReportRequest) Reset() {
-	*x = ReportRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[79]
+func (x *CacheStatusCount) Reset() {
+	*x = CacheStatusCount{}
+	mi := &file_api_proto_agent_proto_msgTypes[81]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReportRequest) String() string {
+func (x *CacheStatusCount) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReportRequest) ProtoMessage() {}
+func (*CacheStatusCount) ProtoMessage() {}
 
-func (x *ReportRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[79]
+func (x *CacheStatusCount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[81]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5821,79 +6595,50 @@ func (x *ReportRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReportRequest.ProtoReflect.Descriptor instead.
-func (*ReportRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{79}
-}
-
-func (x *ReportRequest) GetStartTime() int64 {
-	if x != nil {
-		return x.StartTime
-	}
-	return 0
-}
-
-func (x *ReportRequest) GetEndTime() int64 {
-	if x != nil {
-		return x.EndTime
-	}
-	return 0
-}
-
-func (x *ReportRequest) GetAgentIds() []string {
-	if x != nil {
-		return x.AgentIds
-	}
-	return nil
+// Deprecated: Use CacheStatusCount.ProtoReflect.Descriptor instead.
+func (*CacheStatusCount) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{81}
 }
 
-func (x *ReportRequest) GetReportType() string {
+func (x *CacheStatusCount) GetStatus() string {
 	if x != nil {
-		return x.ReportType
+		return x.Status
 	}
 	return ""
 }
 
-func (x *ReportRequest) GetFormat() string {
+func (x *CacheStatusCount) GetRequests() int64 {
 	if x != nil {
-		return x.Format
+		return x.Requests
 	}
-	return ""
+	return 0
 }
 
-type ReportResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	GeneratedAt         int64                  `protobuf:"varint,1,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
-	Summary             *ReportSummary         `protobuf:"bytes,2,opt,name=summary,proto3" json:"summary,omitempty"`
-	TrafficTrend        []*TimeSeriesPoint     `protobuf:"bytes,3,rep,name=traffic_trend,json=trafficTrend,proto3" json:"traffic_trend,omitempty"` // Reusing TimeSeriesPoint
-	TopUris             []*EndpointStat        `protobuf:"bytes,4,rep,name=top_uris,json=topUris,proto3" json:"top_uris,omitempty"`                // Reusing EndpointStat
-	TopServers          []*ServerStat          `protobuf:"bytes,5,rep,name=top_servers,json=topServers,proto3" json:"top_servers,omitempty"`       // Reusing ServerStat
-	SecurityEvents      []*SecurityEvent       `protobuf:"bytes,6,rep,name=security_events,json=securityEvents,proto3" json:"security_events,omitempty"`
-	ExecutiveSummary    string                 `protobuf:"bytes,7,opt,name=executive_summary,json=executiveSummary,proto3" json:"executive_summary,omitempty"`           // One-paragraph health + trend + risk
-	TopIssues           []string               `protobuf:"bytes,8,rep,name=top_issues,json=topIssues,proto3" json:"top_issues,omitempty"`                                // 3–5 bullets
-	Recommendations     []string               `protobuf:"bytes,9,rep,name=recommendations,proto3" json:"recommendations,omitempty"`                                     // 1–3 short actions
-	PeriodOverPeriod    string                 `protobuf:"bytes,10,opt,name=period_over_period,json=periodOverPeriod,proto3" json:"period_over_period,omitempty"`        // e.g. "Requests +12%, Errors −8% vs previous period"
-	AvailabilitySummary string                 `protobuf:"bytes,11,opt,name=availability_summary,json=availabilitySummary,proto3" json:"availability_summary,omitempty"` // e.g. "All agents healthy"
-	AlertsSummary       string                 `protobuf:"bytes,12,opt,name=alerts_summary,json=alertsSummary,proto3" json:"alerts_summary,omitempty"`                   // e.g. "3 alert rules active"
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type CacheUriStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uri           string                 `protobuf:"bytes,1,opt,name=uri,proto3" json:"uri,omitempty"` // path, without the query string
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	Hits          int64                  `protobuf:"varint,3,opt,name=hits,proto3" json:"hits,omitempty"`
+	HitRatio      float32                `protobuf:"fixed32,4,opt,name=hit_ratio,json=hitRatio,proto3" json:"hit_ratio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReportResponse) Reset() {
-	*x = ReportResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[80]
+func (x *CacheUriStat) Reset() {
+	*x = CacheUriStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[82]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReportResponse) String() string {
+func (x *CacheUriStat) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReportResponse) ProtoMessage() {}
+func (*CacheUriStat) ProtoMessage() {}
 
-func (x *ReportResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[80]
+func (x *CacheUriStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[82]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5904,124 +6649,136 @@ func (x *ReportResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReportResponse.ProtoReflect.Descriptor instead.
-func (*ReportResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{80}
+// Deprecated: Use CacheUriStat.ProtoReflect.Descriptor instead.
+func (*CacheUriStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{82}
 }
 
-func (x *ReportResponse) GetGeneratedAt() int64 {
+func (x *CacheUriStat) GetUri() string {
 	if x != nil {
-		return x.GeneratedAt
+		return x.Uri
 	}
-	return 0
+	return ""
 }
 
-func (x *ReportResponse) GetSummary() *ReportSummary {
+func (x *CacheUriStat) GetRequests() int64 {
 	if x != nil {
-		return x.Summary
+		return x.Requests
 	}
-	return nil
+	return 0
 }
 
-func (x *ReportResponse) GetTrafficTrend() []*TimeSeriesPoint {
+func (x *CacheUriStat) GetHits() int64 {
 	if x != nil {
-		return x.TrafficTrend
+		return x.Hits
 	}
-	return nil
+	return 0
 }
 
-func (x *ReportResponse) GetTopUris() []*EndpointStat {
+func (x *CacheUriStat) GetHitRatio() float32 {
 	if x != nil {
-		return x.TopUris
+		return x.HitRatio
 	}
-	return nil
+	return 0
 }
 
-func (x *ReportResponse) GetTopServers() []*ServerStat {
-	if x != nil {
-		return x.TopServers
-	}
-	return nil
+// BandwidthPoint is the traffic of a time bucket: bytes_in is request_length, bytes_out is
+// body_bytes_sent
+type BandwidthPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	BytesIn       uint64                 `protobuf:"varint,2,opt,name=bytes_in,json=bytesIn,proto3" json:"bytes_in,omitempty"`
+	BytesOut      uint64                 `protobuf:"varint,3,opt,name=bytes_out,json=bytesOut,proto3" json:"bytes_out,omitempty"`
+	TimeIso       string                 `protobuf:"bytes,4,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // see TimeSeriesPoint
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReportResponse) GetSecurityEvents() []*SecurityEvent {
+func (x *BandwidthPoint) Reset() {
+	*x = BandwidthPoint{}
+	mi := &file_api_proto_agent_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BandwidthPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BandwidthPoint) ProtoMessage() {}
+
+func (x *BandwidthPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[83]
 	if x != nil {
-		return x.SecurityEvents
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *ReportResponse) GetExecutiveSummary() string {
+// Deprecated: Use BandwidthPoint.ProtoReflect.Descriptor instead.
+func (*BandwidthPoint) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *BandwidthPoint) GetTime() string {
 	if x != nil {
-		return x.ExecutiveSummary
+		return x.Time
 	}
 	return ""
 }
 
-func (x *ReportResponse) GetTopIssues() []string {
+func (x *BandwidthPoint) GetBytesIn() uint64 {
 	if x != nil {
-		return x.TopIssues
+		return x.BytesIn
 	}
-	return nil
+	return 0
 }
 
-func (x *ReportResponse) GetRecommendations() []string {
+func (x *BandwidthPoint) GetBytesOut() uint64 {
 	if x != nil {
-		return x.Recommendations
+		return x.BytesOut
 	}
-	return nil
+	return 0
 }
 
-func (x *ReportResponse) GetPeriodOverPeriod() string {
+func (x *BandwidthPoint) GetTimeIso() string {
 	if x != nil {
-		return x.PeriodOverPeriod
-	}
-	return ""
-}
-
-func (x *ReportResponse) GetAvailabilitySummary() string {
-	if x != nil {
-		return x.AvailabilitySummary
+		return x.TimeIso
 	}
 	return ""
 }
 
-func (x *ReportResponse) GetAlertsSummary() string {
-	if x != nil {
-		return x.AlertsSummary
-	}
-	return ""
-}
-
-type ReportSummary struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	TotalRequests       int64                  `protobuf:"varint,1,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
-	ErrorRate           float32                `protobuf:"fixed32,2,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
-	TotalBandwidth      uint64                 `protobuf:"varint,3,opt,name=total_bandwidth,json=totalBandwidth,proto3" json:"total_bandwidth,omitempty"`
-	AvgLatency          float32                `protobuf:"fixed32,4,opt,name=avg_latency,json=avgLatency,proto3" json:"avg_latency,omitempty"`
-	UniqueVisitors      int64                  `protobuf:"varint,5,opt,name=unique_visitors,json=uniqueVisitors,proto3" json:"unique_visitors,omitempty"`
-	PeakRps             float32                `protobuf:"fixed32,6,opt,name=peak_rps,json=peakRps,proto3" json:"peak_rps,omitempty"`                                   // Max RPS in period
-	PrevPeriodRequests  int64                  `protobuf:"varint,7,opt,name=prev_period_requests,json=prevPeriodRequests,proto3" json:"prev_period_requests,omitempty"` // Same duration before start
-	PrevPeriodErrorRate float32                `protobuf:"fixed32,8,opt,name=prev_period_error_rate,json=prevPeriodErrorRate,proto3" json:"prev_period_error_rate,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+// BandwidthStat is the traffic of one agent or URI path over the window
+type BandwidthStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"` // agent ID or URI path
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	BytesIn       uint64                 `protobuf:"varint,3,opt,name=bytes_in,json=bytesIn,proto3" json:"bytes_in,omitempty"`
+	BytesOut      uint64                 `protobuf:"varint,4,opt,name=bytes_out,json=bytesOut,proto3" json:"bytes_out,omitempty"`
+	EgressCost    float64                `protobuf:"fixed64,5,opt,name=egress_cost,json=egressCost,proto3" json:"egress_cost,omitempty"` // bytes_out priced by the egress cost model; 0 without one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReportSummary) Reset() {
-	*x = ReportSummary{}
-	mi := &file_api_proto_agent_proto_msgTypes[81]
+func (x *BandwidthStat) Reset() {
+	*x = BandwidthStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[84]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReportSummary) String() string {
+func (x *BandwidthStat) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReportSummary) ProtoMessage() {}
+func (*BandwidthStat) ProtoMessage() {}
 
-func (x *ReportSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[81]
+func (x *BandwidthStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[84]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6032,91 +6789,72 @@ func (x *ReportSummary) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReportSummary.ProtoReflect.Descriptor instead.
-func (*ReportSummary) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{81}
-}
-
-func (x *ReportSummary) GetTotalRequests() int64 {
-	if x != nil {
-		return x.TotalRequests
-	}
-	return 0
-}
-
-func (x *ReportSummary) GetErrorRate() float32 {
-	if x != nil {
-		return x.ErrorRate
-	}
-	return 0
-}
-
-func (x *ReportSummary) GetTotalBandwidth() uint64 {
-	if x != nil {
-		return x.TotalBandwidth
-	}
-	return 0
+// Deprecated: Use BandwidthStat.ProtoReflect.Descriptor instead.
+func (*BandwidthStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{84}
 }
 
-func (x *ReportSummary) GetAvgLatency() float32 {
+func (x *BandwidthStat) GetKey() string {
 	if x != nil {
-		return x.AvgLatency
+		return x.Key
 	}
-	return 0
+	return ""
 }
 
-func (x *ReportSummary) GetUniqueVisitors() int64 {
+func (x *BandwidthStat) GetRequests() int64 {
 	if x != nil {
-		return x.UniqueVisitors
+		return x.Requests
 	}
 	return 0
 }
 
-func (x *ReportSummary) GetPeakRps() float32 {
+func (x *BandwidthStat) GetBytesIn() uint64 {
 	if x != nil {
-		return x.PeakRps
+		return x.BytesIn
 	}
 	return 0
 }
 
-func (x *ReportSummary) GetPrevPeriodRequests() int64 {
+func (x *BandwidthStat) GetBytesOut() uint64 {
 	if x != nil {
-		return x.PrevPeriodRequests
+		return x.BytesOut
 	}
 	return 0
 }
 
-func (x *ReportSummary) GetPrevPeriodErrorRate() float32 {
+func (x *BandwidthStat) GetEgressCost() float64 {
 	if x != nil {
-		return x.PrevPeriodErrorRate
+		return x.EgressCost
 	}
 	return 0
 }
 
-type SecurityEvent struct {
+// EgressCost is the egress cost model of the gateway (bandwidth.egress_cost_per_gb) applied to
+// the window; a GB is 2^30 bytes
+type EgressCost struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "WAF", "RateLimit", "AuthFailure"
-	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
-	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	PricePerGb    float64                `protobuf:"fixed64,1,opt,name=price_per_gb,json=pricePerGb,proto3" json:"price_per_gb,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Total         float64                `protobuf:"fixed64,3,opt,name=total,proto3" json:"total,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SecurityEvent) Reset() {
-	*x = SecurityEvent{}
-	mi := &file_api_proto_agent_proto_msgTypes[82]
+func (x *EgressCost) Reset() {
+	*x = EgressCost{}
+	mi := &file_api_proto_agent_proto_msgTypes[85]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SecurityEvent) String() string {
+func (x *EgressCost) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SecurityEvent) ProtoMessage() {}
+func (*EgressCost) ProtoMessage() {}
 
-func (x *SecurityEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[82]
+func (x *EgressCost) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[85]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6127,57 +6865,59 @@ func (x *SecurityEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SecurityEvent.ProtoReflect.Descriptor instead.
-func (*SecurityEvent) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{82}
+// Deprecated: Use EgressCost.ProtoReflect.Descriptor instead.
+func (*EgressCost) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{85}
 }
 
-func (x *SecurityEvent) GetType() string {
+func (x *EgressCost) GetPricePerGb() float64 {
 	if x != nil {
-		return x.Type
+		return x.PricePerGb
 	}
-	return ""
+	return 0
 }
 
-func (x *SecurityEvent) GetCount() int64 {
+func (x *EgressCost) GetCurrency() string {
 	if x != nil {
-		return x.Count
+		return x.Currency
 	}
-	return 0
+	return ""
 }
 
-func (x *SecurityEvent) GetSeverity() string {
+func (x *EgressCost) GetTotal() float64 {
 	if x != nil {
-		return x.Severity
+		return x.Total
 	}
-	return ""
+	return 0
 }
 
-type SendReportRequest struct {
+// AnalyticsDelta tells how a streamed response applies to the previous one. On a resync the
+// response is a full snapshot. Otherwise only the fields named in replaced and the series patched
+// in series carry data; every other field is unchanged since the previous response.
+type AnalyticsDelta struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Request       *ReportRequest         `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
-	Recipients    []string               `protobuf:"bytes,2,rep,name=recipients,proto3" json:"recipients,omitempty"`
-	Subject       string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
-	Body          string                 `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	Resync        bool                   `protobuf:"varint,1,opt,name=resync,proto3" json:"resync,omitempty"`
+	Replaced      []string               `protobuf:"bytes,2,rep,name=replaced,proto3" json:"replaced,omitempty"` // fields whose value replaces the previous one (may be empty)
+	Series        []*SeriesPatch         `protobuf:"bytes,3,rep,name=series,proto3" json:"series,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendReportRequest) Reset() {
-	*x = SendReportRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[83]
+func (x *AnalyticsDelta) Reset() {
+	*x = AnalyticsDelta{}
+	mi := &file_api_proto_agent_proto_msgTypes[86]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendReportRequest) String() string {
+func (x *AnalyticsDelta) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendReportRequest) ProtoMessage() {}
+func (*AnalyticsDelta) ProtoMessage() {}
 
-func (x *SendReportRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[83]
+func (x *AnalyticsDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[86]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6188,62 +6928,58 @@ func (x *SendReportRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendReportRequest.ProtoReflect.Descriptor instead.
-func (*SendReportRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{83}
+// Deprecated: Use AnalyticsDelta.ProtoReflect.Descriptor instead.
+func (*AnalyticsDelta) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{86}
 }
 
-func (x *SendReportRequest) GetRequest() *ReportRequest {
+func (x *AnalyticsDelta) GetResync() bool {
 	if x != nil {
-		return x.Request
+		return x.Resync
 	}
-	return nil
+	return false
 }
 
-func (x *SendReportRequest) GetRecipients() []string {
+func (x *AnalyticsDelta) GetReplaced() []string {
 	if x != nil {
-		return x.Recipients
+		return x.Replaced
 	}
 	return nil
 }
 
-func (x *SendReportRequest) GetSubject() string {
-	if x != nil {
-		return x.Subject
-	}
-	return ""
-}
-
-func (x *SendReportRequest) GetBody() string {
+func (x *AnalyticsDelta) GetSeries() []*SeriesPatch {
 	if x != nil {
-		return x.Body
+		return x.Series
 	}
-	return ""
+	return nil
 }
 
-type SendReportResponse struct {
+// SeriesPatch updates a time series field of the previous response: drop points from the front,
+// keep the next keep points, and append the points now carried in the field.
+type SeriesPatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Drop          int32                  `protobuf:"varint,2,opt,name=drop,proto3" json:"drop,omitempty"`
+	Keep          int32                  `protobuf:"varint,3,opt,name=keep,proto3" json:"keep,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendReportResponse) Reset() {
-	*x = SendReportResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[84]
+func (x *SeriesPatch) Reset() {
+	*x = SeriesPatch{}
+	mi := &file_api_proto_agent_proto_msgTypes[87]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendReportResponse) String() string {
+func (x *SeriesPatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendReportResponse) ProtoMessage() {}
+func (*SeriesPatch) ProtoMessage() {}
 
-func (x *SendReportResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[84]
+func (x *SeriesPatch) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[87]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6254,49 +6990,66 @@ func (x *SendReportResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendReportResponse.ProtoReflect.Descriptor instead.
-func (*SendReportResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{84}
+// Deprecated: Use SeriesPatch.ProtoReflect.Descriptor instead.
+func (*SeriesPatch) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{87}
 }
 
-func (x *SendReportResponse) GetSuccess() bool {
+func (x *SeriesPatch) GetField() string {
 	if x != nil {
-		return x.Success
+		return x.Field
 	}
-	return false
+	return ""
 }
 
-func (x *SendReportResponse) GetMessage() string {
+func (x *SeriesPatch) GetDrop() int32 {
 	if x != nil {
-		return x.Message
+		return x.Drop
 	}
-	return ""
+	return 0
 }
 
-type ReportDownloadResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
-	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
-	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+func (x *SeriesPatch) GetKeep() int32 {
+	if x != nil {
+		return x.Keep
+	}
+	return 0
+}
+
+type GatewayMetricPoint struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Time              string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Eps               float32                `protobuf:"fixed32,2,opt,name=eps,proto3" json:"eps,omitempty"`
+	ActiveConnections int32                  `protobuf:"varint,3,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	CpuUsage          float32                `protobuf:"fixed32,4,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"` // gateway process CPU, percent of one core
+	MemoryMb          float32                `protobuf:"fixed32,5,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	Goroutines        int32                  `protobuf:"varint,6,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	DbLatency         float32                `protobuf:"fixed32,7,opt,name=db_latency,json=dbLatency,proto3" json:"db_latency,omitempty"`
+	Labels            map[string]string      `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	TimeIso           string                 `protobuf:"bytes,9,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // see TimeSeriesPoint
+	// Load averages of the gateway's host over 1, 5 and 15 minutes
+	Load1         float32 `protobuf:"fixed32,10,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5         float32 `protobuf:"fixed32,11,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15        float32 `protobuf:"fixed32,12,opt,name=load15,proto3" json:"load15,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReportDownloadResponse) Reset() {
-	*x = ReportDownloadResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[85]
+func (x *GatewayMetricPoint) Reset() {
+	*x = GatewayMetricPoint{}
+	mi := &file_api_proto_agent_proto_msgTypes[88]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReportDownloadResponse) String() string {
+func (x *GatewayMetricPoint) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReportDownloadResponse) ProtoMessage() {}
+func (*GatewayMetricPoint) ProtoMessage() {}
 
-func (x *ReportDownloadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[85]
+func (x *GatewayMetricPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[88]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6307,125 +7060,123 @@ func (x *ReportDownloadResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReportDownloadResponse.ProtoReflect.Descriptor instead.
-func (*ReportDownloadResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{85}
+// Deprecated: Use GatewayMetricPoint.ProtoReflect.Descriptor instead.
+func (*GatewayMetricPoint) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{88}
 }
 
-func (x *ReportDownloadResponse) GetContent() []byte {
+func (x *GatewayMetricPoint) GetTime() string {
 	if x != nil {
-		return x.Content
+		return x.Time
 	}
-	return nil
+	return ""
 }
 
-func (x *ReportDownloadResponse) GetFileName() string {
+func (x *GatewayMetricPoint) GetEps() float32 {
 	if x != nil {
-		return x.FileName
+		return x.Eps
 	}
-	return ""
+	return 0
 }
 
-func (x *ReportDownloadResponse) GetContentType() string {
+func (x *GatewayMetricPoint) GetActiveConnections() int32 {
 	if x != nil {
-		return x.ContentType
+		return x.ActiveConnections
 	}
-	return ""
+	return 0
 }
 
-type GetAgentConfigRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *GatewayMetricPoint) GetCpuUsage() float32 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
 }
 
-func (x *GetAgentConfigRequest) Reset() {
-	*x = GetAgentConfigRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[86]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *GatewayMetricPoint) GetMemoryMb() float32 {
+	if x != nil {
+		return x.MemoryMb
+	}
+	return 0
 }
 
-func (x *GetAgentConfigRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *GatewayMetricPoint) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
 }
 
-func (*GetAgentConfigRequest) ProtoMessage() {}
-
-func (x *GetAgentConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[86]
+func (x *GatewayMetricPoint) GetDbLatency() float32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.DbLatency
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use GetAgentConfigRequest.ProtoReflect.Descriptor instead.
-func (*GetAgentConfigRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{86}
+func (x *GatewayMetricPoint) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
 }
 
-func (x *GetAgentConfigRequest) GetAgentId() string {
+func (x *GatewayMetricPoint) GetTimeIso() string {
 	if x != nil {
-		return x.AgentId
+		return x.TimeIso
 	}
 	return ""
 }
 
-type AgentConfig struct {
-	state   protoimpl.MessageState `protogen:"open.v1"`
-	AgentId string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	// Gateway Configuration
-	GatewayAddresses []string `protobuf:"bytes,2,rep,name=gateway_addresses,json=gatewayAddresses,proto3" json:"gateway_addresses,omitempty"`    // Multiple gateway addresses for redundancy
-	MultiGatewayMode bool     `protobuf:"varint,3,opt,name=multi_gateway_mode,json=multiGatewayMode,proto3" json:"multi_gateway_mode,omitempty"` // Enable sending to multiple gateways
-	// NGINX Configuration
-	NginxStatusUrl  string `protobuf:"bytes,4,opt,name=nginx_status_url,json=nginxStatusUrl,proto3" json:"nginx_status_url,omitempty"`
-	AccessLogPath   string `protobuf:"bytes,5,opt,name=access_log_path,json=accessLogPath,proto3" json:"access_log_path,omitempty"`
-	ErrorLogPath    string `protobuf:"bytes,6,opt,name=error_log_path,json=errorLogPath,proto3" json:"error_log_path,omitempty"`
-	NginxConfigPath string `protobuf:"bytes,7,opt,name=nginx_config_path,json=nginxConfigPath,proto3" json:"nginx_config_path,omitempty"`
-	LogFormat       string `protobuf:"bytes,8,opt,name=log_format,json=logFormat,proto3" json:"log_format,omitempty"`
-	// Agent Settings
-	HealthPort int32  `protobuf:"varint,9,opt,name=health_port,json=healthPort,proto3" json:"health_port,omitempty"`
-	MgmtPort   int32  `protobuf:"varint,10,opt,name=mgmt_port,json=mgmtPort,proto3" json:"mgmt_port,omitempty"`
-	LogLevel   string `protobuf:"bytes,11,opt,name=log_level,json=logLevel,proto3" json:"log_level,omitempty"`
-	BufferDir  string `protobuf:"bytes,12,opt,name=buffer_dir,json=bufferDir,proto3" json:"buffer_dir,omitempty"`
-	// Update Settings
-	UpdateServer          string `protobuf:"bytes,13,opt,name=update_server,json=updateServer,proto3" json:"update_server,omitempty"`
-	UpdateIntervalSeconds int64  `protobuf:"varint,14,opt,name=update_interval_seconds,json=updateIntervalSeconds,proto3" json:"update_interval_seconds,omitempty"`
-	// Telemetry Settings
-	MetricsIntervalSeconds   int32 `protobuf:"varint,15,opt,name=metrics_interval_seconds,json=metricsIntervalSeconds,proto3" json:"metrics_interval_seconds,omitempty"`
-	HeartbeatIntervalSeconds int32 `protobuf:"varint,16,opt,name=heartbeat_interval_seconds,json=heartbeatIntervalSeconds,proto3" json:"heartbeat_interval_seconds,omitempty"`
-	// Feature Flags
-	EnableVtsMetrics   bool `protobuf:"varint,17,opt,name=enable_vts_metrics,json=enableVtsMetrics,proto3" json:"enable_vts_metrics,omitempty"`
-	EnableLogStreaming bool `protobuf:"varint,18,opt,name=enable_log_streaming,json=enableLogStreaming,proto3" json:"enable_log_streaming,omitempty"`
-	AutoApplyConfig    bool `protobuf:"varint,19,opt,name=auto_apply_config,json=autoApplyConfig,proto3" json:"auto_apply_config,omitempty"`
-	// Log Management Extensions
-	LogRotation   *LogRotateConfig `protobuf:"bytes,20,opt,name=log_rotation,json=logRotation,proto3" json:"log_rotation,omitempty"`
-	Syslog        *SyslogConfig    `protobuf:"bytes,21,opt,name=syslog,proto3" json:"syslog,omitempty"`
+func (x *GatewayMetricPoint) GetLoad1() float32 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *GatewayMetricPoint) GetLoad5() float32 {
+	if x != nil {
+		return x.Load5
+	}
+	return 0
+}
+
+func (x *GatewayMetricPoint) GetLoad15() float32 {
+	if x != nil {
+		return x.Load15
+	}
+	return 0
+}
+
+type Span struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TraceId       string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId        string                 `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	ParentSpanId  string                 `protobuf:"bytes,3,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`                             // e.g., "rewrite", "access", "content"
+	StartTime     int64                  `protobuf:"varint,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix nano
+	EndTime       int64                  `protobuf:"varint,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // Unix nano
+	Attributes    map[string]string      `protobuf:"bytes,7,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentConfig) Reset() {
-	*x = AgentConfig{}
-	mi := &file_api_proto_agent_proto_msgTypes[87]
+func (x *Span) Reset() {
+	*x = Span{}
+	mi := &file_api_proto_agent_proto_msgTypes[89]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentConfig) String() string {
+func (x *Span) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentConfig) ProtoMessage() {}
+func (*Span) ProtoMessage() {}
 
-func (x *AgentConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[87]
+func (x *Span) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[89]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6436,159 +7187,2581 @@ func (x *AgentConfig) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentConfig.ProtoReflect.Descriptor instead.
-func (*AgentConfig) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{87}
+// Deprecated: Use Span.ProtoReflect.Descriptor instead.
+func (*Span) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{89}
 }
 
-func (x *AgentConfig) GetAgentId() string {
+func (x *Span) GetTraceId() string {
 	if x != nil {
-		return x.AgentId
+		return x.TraceId
 	}
 	return ""
 }
 
-func (x *AgentConfig) GetGatewayAddresses() []string {
+func (x *Span) GetSpanId() string {
 	if x != nil {
-		return x.GatewayAddresses
+		return x.SpanId
 	}
-	return nil
+	return ""
 }
 
-func (x *AgentConfig) GetMultiGatewayMode() bool {
+func (x *Span) GetParentSpanId() string {
 	if x != nil {
-		return x.MultiGatewayMode
+		return x.ParentSpanId
 	}
-	return false
+	return ""
 }
 
-func (x *AgentConfig) GetNginxStatusUrl() string {
+func (x *Span) GetName() string {
 	if x != nil {
-		return x.NginxStatusUrl
+		return x.Name
 	}
 	return ""
 }
 
-func (x *AgentConfig) GetAccessLogPath() string {
+func (x *Span) GetStartTime() int64 {
 	if x != nil {
-		return x.AccessLogPath
+		return x.StartTime
 	}
-	return ""
+	return 0
 }
 
-func (x *AgentConfig) GetErrorLogPath() string {
+func (x *Span) GetEndTime() int64 {
 	if x != nil {
-		return x.ErrorLogPath
+		return x.EndTime
 	}
-	return ""
+	return 0
 }
 
-func (x *AgentConfig) GetNginxConfigPath() string {
+func (x *Span) GetAttributes() map[string]string {
 	if x != nil {
-		return x.NginxConfigPath
+		return x.Attributes
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentConfig) GetLogFormat() string {
-	if x != nil {
-		return x.LogFormat
-	}
-	return ""
+type Trace struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Spans         []*Span                `protobuf:"bytes,2,rep,name=spans,proto3" json:"spans,omitempty"`
+	RootEntry     *LogEntry              `protobuf:"bytes,3,opt,name=root_entry,json=rootEntry,proto3" json:"root_entry,omitempty"`
+	SpanCount     int32                  `protobuf:"varint,4,opt,name=span_count,json=spanCount,proto3" json:"span_count,omitempty"`     // spans of the trace, from every source
+	DurationMs    float64                `protobuf:"fixed64,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"` // first span start to last span end
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentConfig) GetHealthPort() int32 {
-	if x != nil {
-		return x.HealthPort
-	}
-	return 0
+func (x *Trace) Reset() {
+	*x = Trace{}
+	mi := &file_api_proto_agent_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentConfig) GetMgmtPort() int32 {
-	if x != nil {
-		return x.MgmtPort
-	}
-	return 0
+func (x *Trace) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *AgentConfig) GetLogLevel() string {
+func (*Trace) ProtoMessage() {}
+
+func (x *Trace) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[90]
 	if x != nil {
-		return x.LogLevel
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *AgentConfig) GetBufferDir() string {
+// Deprecated: Use Trace.ProtoReflect.Descriptor instead.
+func (*Trace) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *Trace) GetRequestId() string {
 	if x != nil {
-		return x.BufferDir
+		return x.RequestId
 	}
 	return ""
 }
 
-func (x *AgentConfig) GetUpdateServer() string {
+func (x *Trace) GetSpans() []*Span {
 	if x != nil {
-		return x.UpdateServer
+		return x.Spans
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentConfig) GetUpdateIntervalSeconds() int64 {
+func (x *Trace) GetRootEntry() *LogEntry {
 	if x != nil {
-		return x.UpdateIntervalSeconds
+		return x.RootEntry
 	}
-	return 0
+	return nil
 }
 
-func (x *AgentConfig) GetMetricsIntervalSeconds() int32 {
+func (x *Trace) GetSpanCount() int32 {
 	if x != nil {
-		return x.MetricsIntervalSeconds
+		return x.SpanCount
 	}
 	return 0
 }
 
-func (x *AgentConfig) GetHeartbeatIntervalSeconds() int32 {
+func (x *Trace) GetDurationMs() float64 {
 	if x != nil {
-		return x.HeartbeatIntervalSeconds
+		return x.DurationMs
 	}
 	return 0
 }
 
-func (x *AgentConfig) GetEnableVtsMetrics() bool {
-	if x != nil {
-		return x.EnableVtsMetrics
-	}
-	return false
+type TraceRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AgentId    string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	TraceId    string                 `protobuf:"bytes,2,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	TimeWindow string                 `protobuf:"bytes,3,opt,name=time_window,json=timeWindow,proto3" json:"time_window,omitempty"`
+	Limit      int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Search filters
+	StatusFilter string `protobuf:"bytes,5,opt,name=status_filter,json=statusFilter,proto3" json:"status_filter,omitempty"` // e.g. "404", "5xx"
+	MethodFilter string `protobuf:"bytes,6,opt,name=method_filter,json=methodFilter,proto3" json:"method_filter,omitempty"` // e.g. "GET", "POST"
+	UriFilter    string `protobuf:"bytes,7,opt,name=uri_filter,json=uriFilter,proto3" json:"uri_filter,omitempty"`          // e.g. "/api/v1"
+	// Project/environment filters
+	EnvironmentId string `protobuf:"bytes,8,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"` // Optional: filter by environment
+	ProjectId     string `protobuf:"bytes,9,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`             // Optional: filter by project
+	// Duration filters (trace duration in ms, 0 = unbounded) and ordering
+	MinDurationMs float64 `protobuf:"fixed64,10,opt,name=min_duration_ms,json=minDurationMs,proto3" json:"min_duration_ms,omitempty"`
+	MaxDurationMs float64 `protobuf:"fixed64,11,opt,name=max_duration_ms,json=maxDurationMs,proto3" json:"max_duration_ms,omitempty"`
+	SortBy        string  `protobuf:"bytes,12,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"` // "time" (default, newest first) or "duration" (slowest first)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentConfig) GetEnableLogStreaming() bool {
-	if x != nil {
-		return x.EnableLogStreaming
-	}
-	return false
+func (x *TraceRequest) Reset() {
+	*x = TraceRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentConfig) GetAutoApplyConfig() bool {
-	if x != nil {
-		return x.AutoApplyConfig
-	}
-	return false
+func (x *TraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *AgentConfig) GetLogRotation() *LogRotateConfig {
+func (*TraceRequest) ProtoMessage() {}
+
+func (x *TraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[91]
 	if x != nil {
-		return x.LogRotation
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *AgentConfig) GetSyslog() *SyslogConfig {
-	if x != nil {
-		return x.Syslog
-	}
-	return nil
+// Deprecated: Use TraceRequest.ProtoReflect.Descriptor instead.
+func (*TraceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{91}
 }
 
-type AgentConfigUpdateResult struct {
+func (x *TraceRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetTimeWindow() string {
+	if x != nil {
+		return x.TimeWindow
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *TraceRequest) GetStatusFilter() string {
+	if x != nil {
+		return x.StatusFilter
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetMethodFilter() string {
+	if x != nil {
+		return x.MethodFilter
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetUriFilter() string {
+	if x != nil {
+		return x.UriFilter
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *TraceRequest) GetMinDurationMs() float64 {
+	if x != nil {
+		return x.MinDurationMs
+	}
+	return 0
+}
+
+func (x *TraceRequest) GetMaxDurationMs() float64 {
+	if x != nil {
+		return x.MaxDurationMs
+	}
+	return 0
+}
+
+func (x *TraceRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+type TraceList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Traces        []*Trace               `protobuf:"bytes,1,rep,name=traces,proto3" json:"traces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceList) Reset() {
+	*x = TraceList{}
+	mi := &file_api_proto_agent_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceList) ProtoMessage() {}
+
+func (x *TraceList) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceList.ProtoReflect.Descriptor instead.
+func (*TraceList) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *TraceList) GetTraces() []*Trace {
+	if x != nil {
+		return x.Traces
+	}
+	return nil
+}
+
+type Insight struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "info", "warning", "critical"
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Metadata      string                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Insight) Reset() {
+	*x = Insight{}
+	mi := &file_api_proto_agent_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Insight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Insight) ProtoMessage() {}
+
+func (x *Insight) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Insight.ProtoReflect.Descriptor instead.
+func (*Insight) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *Insight) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Insight) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Insight) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Insight) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
+type ApplyAugmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Augment       *ConfigAugment         `protobuf:"bytes,2,opt,name=augment,proto3" json:"augment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyAugmentRequest) Reset() {
+	*x = ApplyAugmentRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyAugmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyAugmentRequest) ProtoMessage() {}
+
+func (x *ApplyAugmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyAugmentRequest.ProtoReflect.Descriptor instead.
+func (*ApplyAugmentRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *ApplyAugmentRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *ApplyAugmentRequest) GetAugment() *ConfigAugment {
+	if x != nil {
+		return x.Augment
+	}
+	return nil
+}
+
+type ApplyAugmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Preview       string                 `protobuf:"bytes,3,opt,name=preview,proto3" json:"preview,omitempty"` // Generated config preview
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyAugmentResponse) Reset() {
+	*x = ApplyAugmentResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyAugmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyAugmentResponse) ProtoMessage() {}
+
+func (x *ApplyAugmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyAugmentResponse.ProtoReflect.Descriptor instead.
+func (*ApplyAugmentResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *ApplyAugmentResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ApplyAugmentResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ApplyAugmentResponse) GetPreview() string {
+	if x != nil {
+		return x.Preview
+	}
+	return ""
+}
+
+type AnalyticsSummary struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TotalRequests  int64                  `protobuf:"varint,1,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	ErrorRate      float32                `protobuf:"fixed32,2,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
+	AvgLatency     float32                `protobuf:"fixed32,3,opt,name=avg_latency,json=avgLatency,proto3" json:"avg_latency,omitempty"` // mean request time in ms (not a percentile)
+	TotalBandwidth uint64                 `protobuf:"varint,4,opt,name=total_bandwidth,json=totalBandwidth,proto3" json:"total_bandwidth,omitempty"`
+	// Comparison vs previous period (deltas)
+	RequestsDelta  float32 `protobuf:"fixed32,5,opt,name=requests_delta,json=requestsDelta,proto3" json:"requests_delta,omitempty"`
+	LatencyDelta   float32 `protobuf:"fixed32,6,opt,name=latency_delta,json=latencyDelta,proto3" json:"latency_delta,omitempty"`
+	ErrorRateDelta float32 `protobuf:"fixed32,7,opt,name=error_rate_delta,json=errorRateDelta,proto3" json:"error_rate_delta,omitempty"`
+	Requests_2Xx   int64   `protobuf:"varint,8,opt,name=requests_2xx,json=requests2xx,proto3" json:"requests_2xx,omitempty"`
+	Requests_3Xx   int64   `protobuf:"varint,9,opt,name=requests_3xx,json=requests3xx,proto3" json:"requests_3xx,omitempty"`
+	Requests_4Xx   int64   `protobuf:"varint,10,opt,name=requests_4xx,json=requests4xx,proto3" json:"requests_4xx,omitempty"`
+	Requests_5Xx   int64   `protobuf:"varint,11,opt,name=requests_5xx,json=requests5xx,proto3" json:"requests_5xx,omitempty"`
+	TotalBytesIn   uint64  `protobuf:"varint,12,opt,name=total_bytes_in,json=totalBytesIn,proto3" json:"total_bytes_in,omitempty"` // request_length; total_bandwidth is bytes sent
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AnalyticsSummary) Reset() {
+	*x = AnalyticsSummary{}
+	mi := &file_api_proto_agent_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyticsSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsSummary) ProtoMessage() {}
+
+func (x *AnalyticsSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsSummary.ProtoReflect.Descriptor instead.
+func (*AnalyticsSummary) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *AnalyticsSummary) GetTotalRequests() int64 {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetErrorRate() float32 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetAvgLatency() float32 {
+	if x != nil {
+		return x.AvgLatency
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetTotalBandwidth() uint64 {
+	if x != nil {
+		return x.TotalBandwidth
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetRequestsDelta() float32 {
+	if x != nil {
+		return x.RequestsDelta
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetLatencyDelta() float32 {
+	if x != nil {
+		return x.LatencyDelta
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetErrorRateDelta() float32 {
+	if x != nil {
+		return x.ErrorRateDelta
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetRequests_2Xx() int64 {
+	if x != nil {
+		return x.Requests_2Xx
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetRequests_3Xx() int64 {
+	if x != nil {
+		return x.Requests_3Xx
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetRequests_4Xx() int64 {
+	if x != nil {
+		return x.Requests_4Xx
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetRequests_5Xx() int64 {
+	if x != nil {
+		return x.Requests_5Xx
+	}
+	return 0
+}
+
+func (x *AnalyticsSummary) GetTotalBytesIn() uint64 {
+	if x != nil {
+		return x.TotalBytesIn
+	}
+	return 0
+}
+
+type LatencyBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bucket        string                 `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"` // e.g. "0-50ms"
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencyBucket) Reset() {
+	*x = LatencyBucket{}
+	mi := &file_api_proto_agent_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencyBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyBucket) ProtoMessage() {}
+
+func (x *LatencyBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyBucket.ProtoReflect.Descriptor instead.
+func (*LatencyBucket) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *LatencyBucket) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *LatencyBucket) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ServerStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	ErrorRate     float32                `protobuf:"fixed32,3,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
+	Traffic       uint64                 `protobuf:"varint,4,opt,name=traffic,proto3" json:"traffic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerStat) Reset() {
+	*x = ServerStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerStat) ProtoMessage() {}
+
+func (x *ServerStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerStat.ProtoReflect.Descriptor instead.
+func (*ServerStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *ServerStat) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *ServerStat) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *ServerStat) GetErrorRate() float32 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *ServerStat) GetTraffic() uint64 {
+	if x != nil {
+		return x.Traffic
+	}
+	return 0
+}
+
+type ASNStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Asn           uint32                 `protobuf:"varint,1,opt,name=asn,proto3" json:"asn,omitempty"`
+	Organization  string                 `protobuf:"bytes,2,opt,name=organization,proto3" json:"organization,omitempty"`
+	Requests      int64                  `protobuf:"varint,3,opt,name=requests,proto3" json:"requests,omitempty"`
+	Errors        int64                  `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	BotRequests   int64                  `protobuf:"varint,5,opt,name=bot_requests,json=botRequests,proto3" json:"bot_requests,omitempty"`
+	Traffic       uint64                 `protobuf:"varint,6,opt,name=traffic,proto3" json:"traffic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ASNStat) Reset() {
+	*x = ASNStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ASNStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ASNStat) ProtoMessage() {}
+
+func (x *ASNStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ASNStat.ProtoReflect.Descriptor instead.
+func (*ASNStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *ASNStat) GetAsn() uint32 {
+	if x != nil {
+		return x.Asn
+	}
+	return 0
+}
+
+func (x *ASNStat) GetOrganization() string {
+	if x != nil {
+		return x.Organization
+	}
+	return ""
+}
+
+func (x *ASNStat) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *ASNStat) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *ASNStat) GetBotRequests() int64 {
+	if x != nil {
+		return x.BotRequests
+	}
+	return 0
+}
+
+func (x *ASNStat) GetTraffic() uint64 {
+	if x != nil {
+		return x.Traffic
+	}
+	return 0
+}
+
+type BotCategoryStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BotCategoryStat) Reset() {
+	*x = BotCategoryStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BotCategoryStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BotCategoryStat) ProtoMessage() {}
+
+func (x *BotCategoryStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BotCategoryStat.ProtoReflect.Descriptor instead.
+func (*BotCategoryStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *BotCategoryStat) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *BotCategoryStat) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+type BotTraffic struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalRequests int64                  `protobuf:"varint,1,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	BotRequests   int64                  `protobuf:"varint,2,opt,name=bot_requests,json=botRequests,proto3" json:"bot_requests,omitempty"`
+	BotShare      float32                `protobuf:"fixed32,3,opt,name=bot_share,json=botShare,proto3" json:"bot_share,omitempty"` // percentage of requests classified as automated
+	Categories    []*BotCategoryStat     `protobuf:"bytes,4,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BotTraffic) Reset() {
+	*x = BotTraffic{}
+	mi := &file_api_proto_agent_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BotTraffic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BotTraffic) ProtoMessage() {}
+
+func (x *BotTraffic) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BotTraffic.ProtoReflect.Descriptor instead.
+func (*BotTraffic) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *BotTraffic) GetTotalRequests() int64 {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return 0
+}
+
+func (x *BotTraffic) GetBotRequests() int64 {
+	if x != nil {
+		return x.BotRequests
+	}
+	return 0
+}
+
+func (x *BotTraffic) GetBotShare() float32 {
+	if x != nil {
+		return x.BotShare
+	}
+	return 0
+}
+
+func (x *BotTraffic) GetCategories() []*BotCategoryStat {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type NginxMetricPoint struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp         int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Active            int64                  `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	Reading           int64                  `protobuf:"varint,3,opt,name=reading,proto3" json:"reading,omitempty"`
+	Writing           int64                  `protobuf:"varint,4,opt,name=writing,proto3" json:"writing,omitempty"`
+	Waiting           int64                  `protobuf:"varint,5,opt,name=waiting,proto3" json:"waiting,omitempty"`
+	Accepted          int64                  `protobuf:"varint,6,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Handled           int64                  `protobuf:"varint,7,opt,name=handled,proto3" json:"handled,omitempty"`
+	Requests          int64                  `protobuf:"varint,8,opt,name=requests,proto3" json:"requests,omitempty"`
+	RequestsPerSecond float64                `protobuf:"fixed64,9,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
+	Time              string                 `protobuf:"bytes,10,opt,name=time,proto3" json:"time,omitempty"`
+	TimeIso           string                 `protobuf:"bytes,11,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // see TimeSeriesPoint
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *NginxMetricPoint) Reset() {
+	*x = NginxMetricPoint{}
+	mi := &file_api_proto_agent_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NginxMetricPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NginxMetricPoint) ProtoMessage() {}
+
+func (x *NginxMetricPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NginxMetricPoint.ProtoReflect.Descriptor instead.
+func (*NginxMetricPoint) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *NginxMetricPoint) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetActive() int64 {
+	if x != nil {
+		return x.Active
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetReading() int64 {
+	if x != nil {
+		return x.Reading
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetWriting() int64 {
+	if x != nil {
+		return x.Writing
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetWaiting() int64 {
+	if x != nil {
+		return x.Waiting
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetHandled() int64 {
+	if x != nil {
+		return x.Handled
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetRequestsPerSecond() float64 {
+	if x != nil {
+		return x.RequestsPerSecond
+	}
+	return 0
+}
+
+func (x *NginxMetricPoint) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *NginxMetricPoint) GetTimeIso() string {
+	if x != nil {
+		return x.TimeIso
+	}
+	return ""
+}
+
+type TimeSeriesPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	Errors        int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	TimeIso       string                 `protobuf:"bytes,4,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // bucket start, ISO 8601 with the UTC offset of the request timezone
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeSeriesPoint) Reset() {
+	*x = TimeSeriesPoint{}
+	mi := &file_api_proto_agent_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeSeriesPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeSeriesPoint) ProtoMessage() {}
+
+func (x *TimeSeriesPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeSeriesPoint.ProtoReflect.Descriptor instead.
+func (*TimeSeriesPoint) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *TimeSeriesPoint) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *TimeSeriesPoint) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *TimeSeriesPoint) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *TimeSeriesPoint) GetTimeIso() string {
+	if x != nil {
+		return x.TimeIso
+	}
+	return ""
+}
+
+type StatusCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusCount) Reset() {
+	*x = StatusCount{}
+	mi := &file_api_proto_agent_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusCount) ProtoMessage() {}
+
+func (x *StatusCount) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusCount.ProtoReflect.Descriptor instead.
+func (*StatusCount) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *StatusCount) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *StatusCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type LatencyPercentiles struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// Request time percentiles in ms
+	P50           float32 `protobuf:"fixed32,2,opt,name=p50,proto3" json:"p50,omitempty"`
+	P95           float32 `protobuf:"fixed32,3,opt,name=p95,proto3" json:"p95,omitempty"`
+	P99           float32 `protobuf:"fixed32,4,opt,name=p99,proto3" json:"p99,omitempty"`
+	TimeIso       string  `protobuf:"bytes,5,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // see TimeSeriesPoint
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencyPercentiles) Reset() {
+	*x = LatencyPercentiles{}
+	mi := &file_api_proto_agent_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencyPercentiles) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyPercentiles) ProtoMessage() {}
+
+func (x *LatencyPercentiles) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyPercentiles.ProtoReflect.Descriptor instead.
+func (*LatencyPercentiles) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *LatencyPercentiles) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *LatencyPercentiles) GetP50() float32 {
+	if x != nil {
+		return x.P50
+	}
+	return 0
+}
+
+func (x *LatencyPercentiles) GetP95() float32 {
+	if x != nil {
+		return x.P95
+	}
+	return 0
+}
+
+func (x *LatencyPercentiles) GetP99() float32 {
+	if x != nil {
+		return x.P99
+	}
+	return 0
+}
+
+func (x *LatencyPercentiles) GetTimeIso() string {
+	if x != nil {
+		return x.TimeIso
+	}
+	return ""
+}
+
+type SystemMetricPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	CpuUsage      float32                `protobuf:"fixed32,2,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
+	MemoryUsage   float32                `protobuf:"fixed32,3,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
+	NetworkRxRate float32                `protobuf:"fixed32,4,opt,name=network_rx_rate,json=networkRxRate,proto3" json:"network_rx_rate,omitempty"`
+	NetworkTxRate float32                `protobuf:"fixed32,5,opt,name=network_tx_rate,json=networkTxRate,proto3" json:"network_tx_rate,omitempty"`
+	CpuUser       float32                `protobuf:"fixed32,6,opt,name=cpu_user,json=cpuUser,proto3" json:"cpu_user,omitempty"`
+	CpuSystem     float32                `protobuf:"fixed32,7,opt,name=cpu_system,json=cpuSystem,proto3" json:"cpu_system,omitempty"`
+	CpuIowait     float32                `protobuf:"fixed32,8,opt,name=cpu_iowait,json=cpuIowait,proto3" json:"cpu_iowait,omitempty"`
+	TimeIso       string                 `protobuf:"bytes,9,opt,name=time_iso,json=timeIso,proto3" json:"time_iso,omitempty"` // see TimeSeriesPoint
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SystemMetricPoint) Reset() {
+	*x = SystemMetricPoint{}
+	mi := &file_api_proto_agent_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SystemMetricPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemMetricPoint) ProtoMessage() {}
+
+func (x *SystemMetricPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemMetricPoint.ProtoReflect.Descriptor instead.
+func (*SystemMetricPoint) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *SystemMetricPoint) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *SystemMetricPoint) GetCpuUsage() float32 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetMemoryUsage() float32 {
+	if x != nil {
+		return x.MemoryUsage
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetNetworkRxRate() float32 {
+	if x != nil {
+		return x.NetworkRxRate
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetNetworkTxRate() float32 {
+	if x != nil {
+		return x.NetworkTxRate
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetCpuUser() float32 {
+	if x != nil {
+		return x.CpuUser
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetCpuSystem() float32 {
+	if x != nil {
+		return x.CpuSystem
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetCpuIowait() float32 {
+	if x != nil {
+		return x.CpuIowait
+	}
+	return 0
+}
+
+func (x *SystemMetricPoint) GetTimeIso() string {
+	if x != nil {
+		return x.TimeIso
+	}
+	return ""
+}
+
+type HttpStatusMetricsResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Status_2Xx_5Min     []*TimeSeriesPoint     `protobuf:"bytes,1,rep,name=status_2xx_5min,json=status2xx5min,proto3" json:"status_2xx_5min,omitempty"`
+	TotalStatus_200_24H int64                  `protobuf:"varint,2,opt,name=total_status_200_24h,json=totalStatus20024h,proto3" json:"total_status_200_24h,omitempty"`
+	Status_4Xx_5Min     []*TimeSeriesPoint     `protobuf:"bytes,3,rep,name=status_4xx_5min,json=status4xx5min,proto3" json:"status_4xx_5min,omitempty"`
+	TotalStatus_404_24H int64                  `protobuf:"varint,4,opt,name=total_status_404_24h,json=totalStatus40424h,proto3" json:"total_status_404_24h,omitempty"`
+	Status_3Xx          []*TimeSeriesPoint     `protobuf:"bytes,5,rep,name=status_3xx,json=status3xx,proto3" json:"status_3xx,omitempty"`
+	Status_5Xx          []*TimeSeriesPoint     `protobuf:"bytes,6,rep,name=status_5xx,json=status5xx,proto3" json:"status_5xx,omitempty"`
+	TotalStatus_503     int64                  `protobuf:"varint,7,opt,name=total_status_503,json=totalStatus503,proto3" json:"total_status_503,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *HttpStatusMetricsResponse) Reset() {
+	*x = HttpStatusMetricsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[107]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpStatusMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpStatusMetricsResponse) ProtoMessage() {}
+
+func (x *HttpStatusMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[107]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpStatusMetricsResponse.ProtoReflect.Descriptor instead.
+func (*HttpStatusMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *HttpStatusMetricsResponse) GetStatus_2Xx_5Min() []*TimeSeriesPoint {
+	if x != nil {
+		return x.Status_2Xx_5Min
+	}
+	return nil
+}
+
+func (x *HttpStatusMetricsResponse) GetTotalStatus_200_24H() int64 {
+	if x != nil {
+		return x.TotalStatus_200_24H
+	}
+	return 0
+}
+
+func (x *HttpStatusMetricsResponse) GetStatus_4Xx_5Min() []*TimeSeriesPoint {
+	if x != nil {
+		return x.Status_4Xx_5Min
+	}
+	return nil
+}
+
+func (x *HttpStatusMetricsResponse) GetTotalStatus_404_24H() int64 {
+	if x != nil {
+		return x.TotalStatus_404_24H
+	}
+	return 0
+}
+
+func (x *HttpStatusMetricsResponse) GetStatus_3Xx() []*TimeSeriesPoint {
+	if x != nil {
+		return x.Status_3Xx
+	}
+	return nil
+}
+
+func (x *HttpStatusMetricsResponse) GetStatus_5Xx() []*TimeSeriesPoint {
+	if x != nil {
+		return x.Status_5Xx
+	}
+	return nil
+}
+
+func (x *HttpStatusMetricsResponse) GetTotalStatus_503() int64 {
+	if x != nil {
+		return x.TotalStatus_503
+	}
+	return 0
+}
+
+type EndpointStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uri           string                 `protobuf:"bytes,1,opt,name=uri,proto3" json:"uri,omitempty"`
+	Requests      int64                  `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	P95           float32                `protobuf:"fixed32,3,opt,name=p95,proto3" json:"p95,omitempty"` // 95th percentile request time in ms
+	Errors        int64                  `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	Traffic       string                 `protobuf:"bytes,5,opt,name=traffic,proto3" json:"traffic,omitempty"`
+	P50           float32                `protobuf:"fixed32,6,opt,name=p50,proto3" json:"p50,omitempty"` // median request time in ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndpointStat) Reset() {
+	*x = EndpointStat{}
+	mi := &file_api_proto_agent_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndpointStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndpointStat) ProtoMessage() {}
+
+func (x *EndpointStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[108]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndpointStat.ProtoReflect.Descriptor instead.
+func (*EndpointStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *EndpointStat) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+func (x *EndpointStat) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *EndpointStat) GetP95() float32 {
+	if x != nil {
+		return x.P95
+	}
+	return 0
+}
+
+func (x *EndpointStat) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *EndpointStat) GetTraffic() string {
+	if x != nil {
+		return x.Traffic
+	}
+	return ""
+}
+
+func (x *EndpointStat) GetP50() float32 {
+	if x != nil {
+		return x.P50
+	}
+	return 0
+}
+
+type RecommendationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Optional
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecommendationRequest) Reset() {
+	*x = RecommendationRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[109]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecommendationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecommendationRequest) ProtoMessage() {}
+
+func (x *RecommendationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[109]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecommendationRequest.ProtoReflect.Descriptor instead.
+func (*RecommendationRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *RecommendationRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+type RecommendationResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Recommendations []*Recommendation      `protobuf:"bytes,1,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RecommendationResponse) Reset() {
+	*x = RecommendationResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[110]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecommendationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecommendationResponse) ProtoMessage() {}
+
+func (x *RecommendationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[110]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecommendationResponse.ProtoReflect.Descriptor instead.
+func (*RecommendationResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *RecommendationResponse) GetRecommendations() []*Recommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+type Recommendation struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Id                   int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title                string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description          string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Details              string                 `protobuf:"bytes,4,opt,name=details,proto3" json:"details,omitempty"`
+	Impact               string                 `protobuf:"bytes,5,opt,name=impact,proto3" json:"impact,omitempty"`     // "high", "medium", "low"
+	Category             string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"` // "Performance", "Optimization", "Security"
+	Confidence           float32                `protobuf:"fixed32,7,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	EstimatedImprovement string                 `protobuf:"bytes,8,opt,name=estimated_improvement,json=estimatedImprovement,proto3" json:"estimated_improvement,omitempty"`
+	CurrentConfig        string                 `protobuf:"bytes,9,opt,name=current_config,json=currentConfig,proto3" json:"current_config,omitempty"`
+	SuggestedConfig      string                 `protobuf:"bytes,10,opt,name=suggested_config,json=suggestedConfig,proto3" json:"suggested_config,omitempty"`
+	Server               string                 `protobuf:"bytes,11,opt,name=server,proto3" json:"server,omitempty"`
+	Timestamp            int64                  `protobuf:"varint,12,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *Recommendation) Reset() {
+	*x = Recommendation{}
+	mi := &file_api_proto_agent_proto_msgTypes[111]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recommendation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recommendation) ProtoMessage() {}
+
+func (x *Recommendation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[111]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recommendation.ProtoReflect.Descriptor instead.
+func (*Recommendation) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *Recommendation) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Recommendation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Recommendation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Recommendation) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+func (x *Recommendation) GetImpact() string {
+	if x != nil {
+		return x.Impact
+	}
+	return ""
+}
+
+func (x *Recommendation) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Recommendation) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Recommendation) GetEstimatedImprovement() string {
+	if x != nil {
+		return x.EstimatedImprovement
+	}
+	return ""
+}
+
+func (x *Recommendation) GetCurrentConfig() string {
+	if x != nil {
+		return x.CurrentConfig
+	}
+	return ""
+}
+
+func (x *Recommendation) GetSuggestedConfig() string {
+	if x != nil {
+		return x.SuggestedConfig
+	}
+	return ""
+}
+
+func (x *Recommendation) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *Recommendation) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`   // Unix timestamp
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`         // Unix timestamp
+	AgentIds      []string               `protobuf:"bytes,3,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`       // Optional filter
+	ReportType    string                 `protobuf:"bytes,4,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"` // "summary", "detailed", "security"
+	Format        string                 `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`                           // Download format: "pdf", "excel" (optional, default pdf)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportRequest) Reset() {
+	*x = ReportRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[112]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportRequest) ProtoMessage() {}
+
+func (x *ReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[112]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportRequest.ProtoReflect.Descriptor instead.
+func (*ReportRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{112}
+}
+
+func (x *ReportRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *ReportRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *ReportRequest) GetAgentIds() []string {
+	if x != nil {
+		return x.AgentIds
+	}
+	return nil
+}
+
+func (x *ReportRequest) GetReportType() string {
+	if x != nil {
+		return x.ReportType
+	}
+	return ""
+}
+
+func (x *ReportRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type ReportResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	GeneratedAt         int64                  `protobuf:"varint,1,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	Summary             *ReportSummary         `protobuf:"bytes,2,opt,name=summary,proto3" json:"summary,omitempty"`
+	TrafficTrend        []*TimeSeriesPoint     `protobuf:"bytes,3,rep,name=traffic_trend,json=trafficTrend,proto3" json:"traffic_trend,omitempty"` // Reusing TimeSeriesPoint
+	TopUris             []*EndpointStat        `protobuf:"bytes,4,rep,name=top_uris,json=topUris,proto3" json:"top_uris,omitempty"`                // Reusing EndpointStat
+	TopServers          []*ServerStat          `protobuf:"bytes,5,rep,name=top_servers,json=topServers,proto3" json:"top_servers,omitempty"`       // Reusing ServerStat
+	SecurityEvents      []*SecurityEvent       `protobuf:"bytes,6,rep,name=security_events,json=securityEvents,proto3" json:"security_events,omitempty"`
+	ExecutiveSummary    string                 `protobuf:"bytes,7,opt,name=executive_summary,json=executiveSummary,proto3" json:"executive_summary,omitempty"`           // One-paragraph health + trend + risk
+	TopIssues           []string               `protobuf:"bytes,8,rep,name=top_issues,json=topIssues,proto3" json:"top_issues,omitempty"`                                // 3–5 bullets
+	Recommendations     []string               `protobuf:"bytes,9,rep,name=recommendations,proto3" json:"recommendations,omitempty"`                                     // 1–3 short actions
+	PeriodOverPeriod    string                 `protobuf:"bytes,10,opt,name=period_over_period,json=periodOverPeriod,proto3" json:"period_over_period,omitempty"`        // e.g. "Requests +12%, Errors −8% vs previous period"
+	AvailabilitySummary string                 `protobuf:"bytes,11,opt,name=availability_summary,json=availabilitySummary,proto3" json:"availability_summary,omitempty"` // e.g. "All agents healthy"
+	AlertsSummary       string                 `protobuf:"bytes,12,opt,name=alerts_summary,json=alertsSummary,proto3" json:"alerts_summary,omitempty"`                   // e.g. "3 alert rules active"
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ReportResponse) Reset() {
+	*x = ReportResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[113]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportResponse) ProtoMessage() {}
+
+func (x *ReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[113]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportResponse.ProtoReflect.Descriptor instead.
+func (*ReportResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *ReportResponse) GetGeneratedAt() int64 {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return 0
+}
+
+func (x *ReportResponse) GetSummary() *ReportSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetTrafficTrend() []*TimeSeriesPoint {
+	if x != nil {
+		return x.TrafficTrend
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetTopUris() []*EndpointStat {
+	if x != nil {
+		return x.TopUris
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetTopServers() []*ServerStat {
+	if x != nil {
+		return x.TopServers
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetSecurityEvents() []*SecurityEvent {
+	if x != nil {
+		return x.SecurityEvents
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetExecutiveSummary() string {
+	if x != nil {
+		return x.ExecutiveSummary
+	}
+	return ""
+}
+
+func (x *ReportResponse) GetTopIssues() []string {
+	if x != nil {
+		return x.TopIssues
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetRecommendations() []string {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+func (x *ReportResponse) GetPeriodOverPeriod() string {
+	if x != nil {
+		return x.PeriodOverPeriod
+	}
+	return ""
+}
+
+func (x *ReportResponse) GetAvailabilitySummary() string {
+	if x != nil {
+		return x.AvailabilitySummary
+	}
+	return ""
+}
+
+func (x *ReportResponse) GetAlertsSummary() string {
+	if x != nil {
+		return x.AlertsSummary
+	}
+	return ""
+}
+
+type ReportSummary struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	TotalRequests       int64                  `protobuf:"varint,1,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	ErrorRate           float32                `protobuf:"fixed32,2,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"`
+	TotalBandwidth      uint64                 `protobuf:"varint,3,opt,name=total_bandwidth,json=totalBandwidth,proto3" json:"total_bandwidth,omitempty"`
+	AvgLatency          float32                `protobuf:"fixed32,4,opt,name=avg_latency,json=avgLatency,proto3" json:"avg_latency,omitempty"` // mean request time in ms (not a percentile)
+	UniqueVisitors      int64                  `protobuf:"varint,5,opt,name=unique_visitors,json=uniqueVisitors,proto3" json:"unique_visitors,omitempty"`
+	PeakRps             float32                `protobuf:"fixed32,6,opt,name=peak_rps,json=peakRps,proto3" json:"peak_rps,omitempty"`                                   // Max RPS in period
+	PrevPeriodRequests  int64                  `protobuf:"varint,7,opt,name=prev_period_requests,json=prevPeriodRequests,proto3" json:"prev_period_requests,omitempty"` // Same duration before start
+	PrevPeriodErrorRate float32                `protobuf:"fixed32,8,opt,name=prev_period_error_rate,json=prevPeriodErrorRate,proto3" json:"prev_period_error_rate,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ReportSummary) Reset() {
+	*x = ReportSummary{}
+	mi := &file_api_proto_agent_proto_msgTypes[114]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportSummary) ProtoMessage() {}
+
+func (x *ReportSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[114]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportSummary.ProtoReflect.Descriptor instead.
+func (*ReportSummary) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *ReportSummary) GetTotalRequests() int64 {
+	if x != nil {
+		return x.TotalRequests
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetErrorRate() float32 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetTotalBandwidth() uint64 {
+	if x != nil {
+		return x.TotalBandwidth
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetAvgLatency() float32 {
+	if x != nil {
+		return x.AvgLatency
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetUniqueVisitors() int64 {
+	if x != nil {
+		return x.UniqueVisitors
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetPeakRps() float32 {
+	if x != nil {
+		return x.PeakRps
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetPrevPeriodRequests() int64 {
+	if x != nil {
+		return x.PrevPeriodRequests
+	}
+	return 0
+}
+
+func (x *ReportSummary) GetPrevPeriodErrorRate() float32 {
+	if x != nil {
+		return x.PrevPeriodErrorRate
+	}
+	return 0
+}
+
+type SecurityEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "WAF", "RateLimit", "AuthFailure"
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SecurityEvent) Reset() {
+	*x = SecurityEvent{}
+	mi := &file_api_proto_agent_proto_msgTypes[115]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecurityEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecurityEvent) ProtoMessage() {}
+
+func (x *SecurityEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[115]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecurityEvent.ProtoReflect.Descriptor instead.
+func (*SecurityEvent) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *SecurityEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SecurityEvent) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SecurityEvent) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+type SendReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Request       *ReportRequest         `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Recipients    []string               `protobuf:"bytes,2,rep,name=recipients,proto3" json:"recipients,omitempty"`
+	Subject       string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body          string                 `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendReportRequest) Reset() {
+	*x = SendReportRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[116]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendReportRequest) ProtoMessage() {}
+
+func (x *SendReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[116]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendReportRequest.ProtoReflect.Descriptor instead.
+func (*SendReportRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{116}
+}
+
+func (x *SendReportRequest) GetRequest() *ReportRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *SendReportRequest) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+func (x *SendReportRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *SendReportRequest) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+type SendReportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendReportResponse) Reset() {
+	*x = SendReportResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[117]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendReportResponse) ProtoMessage() {}
+
+func (x *SendReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[117]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendReportResponse.ProtoReflect.Descriptor instead.
+func (*SendReportResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{117}
+}
+
+func (x *SendReportResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SendReportResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ReportDownloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportDownloadResponse) Reset() {
+	*x = ReportDownloadResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[118]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportDownloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportDownloadResponse) ProtoMessage() {}
+
+func (x *ReportDownloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[118]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportDownloadResponse.ProtoReflect.Descriptor instead.
+func (*ReportDownloadResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{118}
+}
+
+func (x *ReportDownloadResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ReportDownloadResponse) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ReportDownloadResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type GetAgentConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAgentConfigRequest) Reset() {
+	*x = GetAgentConfigRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[119]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAgentConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAgentConfigRequest) ProtoMessage() {}
+
+func (x *GetAgentConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[119]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAgentConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetAgentConfigRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{119}
+}
+
+func (x *GetAgentConfigRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+type AgentConfig struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	AgentId string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	// Gateway Configuration
+	GatewayAddresses []string `protobuf:"bytes,2,rep,name=gateway_addresses,json=gatewayAddresses,proto3" json:"gateway_addresses,omitempty"`    // Multiple gateway addresses for redundancy
+	MultiGatewayMode bool     `protobuf:"varint,3,opt,name=multi_gateway_mode,json=multiGatewayMode,proto3" json:"multi_gateway_mode,omitempty"` // Enable sending to multiple gateways
+	// NGINX Configuration
+	NginxStatusUrl  string `protobuf:"bytes,4,opt,name=nginx_status_url,json=nginxStatusUrl,proto3" json:"nginx_status_url,omitempty"`
+	AccessLogPath   string `protobuf:"bytes,5,opt,name=access_log_path,json=accessLogPath,proto3" json:"access_log_path,omitempty"`
+	ErrorLogPath    string `protobuf:"bytes,6,opt,name=error_log_path,json=errorLogPath,proto3" json:"error_log_path,omitempty"`
+	NginxConfigPath string `protobuf:"bytes,7,opt,name=nginx_config_path,json=nginxConfigPath,proto3" json:"nginx_config_path,omitempty"`
+	LogFormat       string `protobuf:"bytes,8,opt,name=log_format,json=logFormat,proto3" json:"log_format,omitempty"`
+	// Agent Settings
+	HealthPort int32  `protobuf:"varint,9,opt,name=health_port,json=healthPort,proto3" json:"health_port,omitempty"`
+	MgmtPort   int32  `protobuf:"varint,10,opt,name=mgmt_port,json=mgmtPort,proto3" json:"mgmt_port,omitempty"`
+	LogLevel   string `protobuf:"bytes,11,opt,name=log_level,json=logLevel,proto3" json:"log_level,omitempty"`
+	BufferDir  string `protobuf:"bytes,12,opt,name=buffer_dir,json=bufferDir,proto3" json:"buffer_dir,omitempty"`
+	// Update Settings
+	UpdateServer          string `protobuf:"bytes,13,opt,name=update_server,json=updateServer,proto3" json:"update_server,omitempty"`
+	UpdateIntervalSeconds int64  `protobuf:"varint,14,opt,name=update_interval_seconds,json=updateIntervalSeconds,proto3" json:"update_interval_seconds,omitempty"`
+	// Telemetry Settings
+	MetricsIntervalSeconds   int32 `protobuf:"varint,15,opt,name=metrics_interval_seconds,json=metricsIntervalSeconds,proto3" json:"metrics_interval_seconds,omitempty"`
+	HeartbeatIntervalSeconds int32 `protobuf:"varint,16,opt,name=heartbeat_interval_seconds,json=heartbeatIntervalSeconds,proto3" json:"heartbeat_interval_seconds,omitempty"`
+	// Feature Flags
+	EnableVtsMetrics   bool `protobuf:"varint,17,opt,name=enable_vts_metrics,json=enableVtsMetrics,proto3" json:"enable_vts_metrics,omitempty"`
+	EnableLogStreaming bool `protobuf:"varint,18,opt,name=enable_log_streaming,json=enableLogStreaming,proto3" json:"enable_log_streaming,omitempty"`
+	AutoApplyConfig    bool `protobuf:"varint,19,opt,name=auto_apply_config,json=autoApplyConfig,proto3" json:"auto_apply_config,omitempty"`
+	// Log Management Extensions
+	LogRotation   *LogRotateConfig `protobuf:"bytes,20,opt,name=log_rotation,json=logRotation,proto3" json:"log_rotation,omitempty"`
+	Syslog        *SyslogConfig    `protobuf:"bytes,21,opt,name=syslog,proto3" json:"syslog,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentConfig) Reset() {
+	*x = AgentConfig{}
+	mi := &file_api_proto_agent_proto_msgTypes[120]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentConfig) ProtoMessage() {}
+
+func (x *AgentConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[120]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentConfig.ProtoReflect.Descriptor instead.
+func (*AgentConfig) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{120}
+}
+
+func (x *AgentConfig) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetGatewayAddresses() []string {
+	if x != nil {
+		return x.GatewayAddresses
+	}
+	return nil
+}
+
+func (x *AgentConfig) GetMultiGatewayMode() bool {
+	if x != nil {
+		return x.MultiGatewayMode
+	}
+	return false
+}
+
+func (x *AgentConfig) GetNginxStatusUrl() string {
+	if x != nil {
+		return x.NginxStatusUrl
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetAccessLogPath() string {
+	if x != nil {
+		return x.AccessLogPath
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetErrorLogPath() string {
+	if x != nil {
+		return x.ErrorLogPath
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetNginxConfigPath() string {
+	if x != nil {
+		return x.NginxConfigPath
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetLogFormat() string {
+	if x != nil {
+		return x.LogFormat
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetHealthPort() int32 {
+	if x != nil {
+		return x.HealthPort
+	}
+	return 0
+}
+
+func (x *AgentConfig) GetMgmtPort() int32 {
+	if x != nil {
+		return x.MgmtPort
+	}
+	return 0
+}
+
+func (x *AgentConfig) GetLogLevel() string {
+	if x != nil {
+		return x.LogLevel
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetBufferDir() string {
+	if x != nil {
+		return x.BufferDir
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetUpdateServer() string {
+	if x != nil {
+		return x.UpdateServer
+	}
+	return ""
+}
+
+func (x *AgentConfig) GetUpdateIntervalSeconds() int64 {
+	if x != nil {
+		return x.UpdateIntervalSeconds
+	}
+	return 0
+}
+
+func (x *AgentConfig) GetMetricsIntervalSeconds() int32 {
+	if x != nil {
+		return x.MetricsIntervalSeconds
+	}
+	return 0
+}
+
+func (x *AgentConfig) GetHeartbeatIntervalSeconds() int32 {
+	if x != nil {
+		return x.HeartbeatIntervalSeconds
+	}
+	return 0
+}
+
+func (x *AgentConfig) GetEnableVtsMetrics() bool {
+	if x != nil {
+		return x.EnableVtsMetrics
+	}
+	return false
+}
+
+func (x *AgentConfig) GetEnableLogStreaming() bool {
+	if x != nil {
+		return x.EnableLogStreaming
+	}
+	return false
+}
+
+func (x *AgentConfig) GetAutoApplyConfig() bool {
+	if x != nil {
+		return x.AutoApplyConfig
+	}
+	return false
+}
+
+func (x *AgentConfig) GetLogRotation() *LogRotateConfig {
+	if x != nil {
+		return x.LogRotation
+	}
+	return nil
+}
+
+func (x *AgentConfig) GetSyslog() *SyslogConfig {
+	if x != nil {
+		return x.Syslog
+	}
+	return nil
+}
+
+type AgentConfigUpdateResult struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Error           string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
@@ -6598,21 +9771,1102 @@ type AgentConfigUpdateResult struct {
 	sizeCache       protoimpl.SizeCache
 }
 
-func (x *AgentConfigUpdateResult) Reset() {
-	*x = AgentConfigUpdateResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[88]
+func (x *AgentConfigUpdateResult) Reset() {
+	*x = AgentConfigUpdateResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[121]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentConfigUpdateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentConfigUpdateResult) ProtoMessage() {}
+
+func (x *AgentConfigUpdateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[121]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentConfigUpdateResult.ProtoReflect.Descriptor instead.
+func (*AgentConfigUpdateResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{121}
+}
+
+func (x *AgentConfigUpdateResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AgentConfigUpdateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AgentConfigUpdateResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AgentConfigUpdateResult) GetRequiresRestart() bool {
+	if x != nil {
+		return x.RequiresRestart
+	}
+	return false
+}
+
+type AgentGroup struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Id                        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	EnvironmentId             string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	Name                      string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Slug                      string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	Description               string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	GoldenAgentId             string                 `protobuf:"bytes,6,opt,name=golden_agent_id,json=goldenAgentId,proto3" json:"golden_agent_id,omitempty"`
+	ExpectedConfigHash        string                 `protobuf:"bytes,7,opt,name=expected_config_hash,json=expectedConfigHash,proto3" json:"expected_config_hash,omitempty"`
+	DriftCheckEnabled         bool                   `protobuf:"varint,8,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
+	DriftCheckIntervalSeconds int32                  `protobuf:"varint,9,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
+	Metadata                  map[string]string      `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedBy                 string                 `protobuf:"bytes,11,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt                 int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt                 int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	AgentCount                int32                  `protobuf:"varint,14,opt,name=agent_count,json=agentCount,proto3" json:"agent_count,omitempty"` // Number of agents in this group
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *AgentGroup) Reset() {
+	*x = AgentGroup{}
+	mi := &file_api_proto_agent_proto_msgTypes[122]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentGroup) ProtoMessage() {}
+
+func (x *AgentGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[122]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentGroup.ProtoReflect.Descriptor instead.
+func (*AgentGroup) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{122}
+}
+
+func (x *AgentGroup) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetGoldenAgentId() string {
+	if x != nil {
+		return x.GoldenAgentId
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetExpectedConfigHash() string {
+	if x != nil {
+		return x.ExpectedConfigHash
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetDriftCheckEnabled() bool {
+	if x != nil {
+		return x.DriftCheckEnabled
+	}
+	return false
+}
+
+func (x *AgentGroup) GetDriftCheckIntervalSeconds() int32 {
+	if x != nil {
+		return x.DriftCheckIntervalSeconds
+	}
+	return 0
+}
+
+func (x *AgentGroup) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *AgentGroup) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *AgentGroup) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *AgentGroup) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *AgentGroup) GetAgentCount() int32 {
+	if x != nil {
+		return x.AgentCount
+	}
+	return 0
+}
+
+type ListGroupsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EnvironmentId string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"` // Required: filter by environment
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGroupsRequest) Reset() {
+	*x = ListGroupsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[123]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGroupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGroupsRequest) ProtoMessage() {}
+
+func (x *ListGroupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[123]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGroupsRequest.ProtoReflect.Descriptor instead.
+func (*ListGroupsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{123}
+}
+
+func (x *ListGroupsRequest) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+type ListGroupsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*AgentGroup          `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGroupsResponse) Reset() {
+	*x = ListGroupsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[124]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGroupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGroupsResponse) ProtoMessage() {}
+
+func (x *ListGroupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[124]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGroupsResponse.ProtoReflect.Descriptor instead.
+func (*ListGroupsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{124}
+}
+
+func (x *ListGroupsResponse) GetGroups() []*AgentGroup {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type GetGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupRequest) Reset() {
+	*x = GetGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[125]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupRequest) ProtoMessage() {}
+
+func (x *GetGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[125]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{125}
+}
+
+func (x *GetGroupRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+type CreateGroupRequest struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	EnvironmentId             string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	Name                      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Slug                      string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
+	Description               string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	DriftCheckEnabled         bool                   `protobuf:"varint,5,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
+	DriftCheckIntervalSeconds int32                  `protobuf:"varint,6,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
+	Metadata                  map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *CreateGroupRequest) Reset() {
+	*x = CreateGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[126]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGroupRequest) ProtoMessage() {}
+
+func (x *CreateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[126]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGroupRequest.ProtoReflect.Descriptor instead.
+func (*CreateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{126}
+}
+
+func (x *CreateGroupRequest) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+func (x *CreateGroupRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateGroupRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *CreateGroupRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateGroupRequest) GetDriftCheckEnabled() bool {
+	if x != nil {
+		return x.DriftCheckEnabled
+	}
+	return false
+}
+
+func (x *CreateGroupRequest) GetDriftCheckIntervalSeconds() int32 {
+	if x != nil {
+		return x.DriftCheckIntervalSeconds
+	}
+	return 0
+}
+
+func (x *CreateGroupRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type UpdateGroupRequest struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	GroupId                   string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Name                      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description               string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	DriftCheckEnabled         bool                   `protobuf:"varint,4,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
+	DriftCheckIntervalSeconds int32                  `protobuf:"varint,5,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
+	Metadata                  map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *UpdateGroupRequest) Reset() {
+	*x = UpdateGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[127]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGroupRequest) ProtoMessage() {}
+
+func (x *UpdateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[127]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGroupRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{127}
+}
+
+func (x *UpdateGroupRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *UpdateGroupRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateGroupRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *UpdateGroupRequest) GetDriftCheckEnabled() bool {
+	if x != nil {
+		return x.DriftCheckEnabled
+	}
+	return false
+}
+
+func (x *UpdateGroupRequest) GetDriftCheckIntervalSeconds() int32 {
+	if x != nil {
+		return x.DriftCheckIntervalSeconds
+	}
+	return 0
+}
+
+func (x *UpdateGroupRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type DeleteGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGroupRequest) Reset() {
+	*x = DeleteGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[128]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGroupRequest) ProtoMessage() {}
+
+func (x *DeleteGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[128]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGroupRequest.ProtoReflect.Descriptor instead.
+func (*DeleteGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{128}
+}
+
+func (x *DeleteGroupRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+type DeleteGroupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGroupResponse) Reset() {
+	*x = DeleteGroupResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[129]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGroupResponse) ProtoMessage() {}
+
+func (x *DeleteGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[129]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGroupResponse.ProtoReflect.Descriptor instead.
+func (*DeleteGroupResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{129}
+}
+
+func (x *DeleteGroupResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeleteGroupResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type AddAgentsToGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	AgentIds      []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddAgentsToGroupRequest) Reset() {
+	*x = AddAgentsToGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[130]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddAgentsToGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddAgentsToGroupRequest) ProtoMessage() {}
+
+func (x *AddAgentsToGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[130]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddAgentsToGroupRequest.ProtoReflect.Descriptor instead.
+func (*AddAgentsToGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{130}
+}
+
+func (x *AddAgentsToGroupRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *AddAgentsToGroupRequest) GetAgentIds() []string {
+	if x != nil {
+		return x.AgentIds
+	}
+	return nil
+}
+
+type AddAgentsToGroupResponse struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Success       bool                          `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Results       []*AgentGroupAssignmentResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddAgentsToGroupResponse) Reset() {
+	*x = AddAgentsToGroupResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[131]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddAgentsToGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddAgentsToGroupResponse) ProtoMessage() {}
+
+func (x *AddAgentsToGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[131]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddAgentsToGroupResponse.ProtoReflect.Descriptor instead.
+func (*AddAgentsToGroupResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{131}
+}
+
+func (x *AddAgentsToGroupResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AddAgentsToGroupResponse) GetResults() []*AgentGroupAssignmentResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type AgentGroupAssignmentResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentGroupAssignmentResult) Reset() {
+	*x = AgentGroupAssignmentResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[132]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentGroupAssignmentResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentGroupAssignmentResult) ProtoMessage() {}
+
+func (x *AgentGroupAssignmentResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[132]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentGroupAssignmentResult.ProtoReflect.Descriptor instead.
+func (*AgentGroupAssignmentResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{132}
+}
+
+func (x *AgentGroupAssignmentResult) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *AgentGroupAssignmentResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AgentGroupAssignmentResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type RemoveAgentFromGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	AgentId       string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveAgentFromGroupRequest) Reset() {
+	*x = RemoveAgentFromGroupRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[133]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveAgentFromGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveAgentFromGroupRequest) ProtoMessage() {}
+
+func (x *RemoveAgentFromGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[133]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveAgentFromGroupRequest.ProtoReflect.Descriptor instead.
+func (*RemoveAgentFromGroupRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{133}
+}
+
+func (x *RemoveAgentFromGroupRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *RemoveAgentFromGroupRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+type RemoveAgentFromGroupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveAgentFromGroupResponse) Reset() {
+	*x = RemoveAgentFromGroupResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[134]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveAgentFromGroupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveAgentFromGroupResponse) ProtoMessage() {}
+
+func (x *RemoveAgentFromGroupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[134]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveAgentFromGroupResponse.ProtoReflect.Descriptor instead.
+func (*RemoveAgentFromGroupResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{134}
+}
+
+func (x *RemoveAgentFromGroupResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RemoveAgentFromGroupResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SetGoldenAgentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	AgentId       string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Empty to clear golden agent
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetGoldenAgentRequest) Reset() {
+	*x = SetGoldenAgentRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[135]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetGoldenAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetGoldenAgentRequest) ProtoMessage() {}
+
+func (x *SetGoldenAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[135]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetGoldenAgentRequest.ProtoReflect.Descriptor instead.
+func (*SetGoldenAgentRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{135}
+}
+
+func (x *SetGoldenAgentRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *SetGoldenAgentRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+type SetGoldenAgentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetGoldenAgentResponse) Reset() {
+	*x = SetGoldenAgentResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[136]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetGoldenAgentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetGoldenAgentResponse) ProtoMessage() {}
+
+func (x *SetGoldenAgentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[136]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetGoldenAgentResponse.ProtoReflect.Descriptor instead.
+func (*SetGoldenAgentResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{136}
+}
+
+func (x *SetGoldenAgentResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SetGoldenAgentResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetGroupAgentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupAgentsRequest) Reset() {
+	*x = GetGroupAgentsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[137]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupAgentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupAgentsRequest) ProtoMessage() {}
+
+func (x *GetGroupAgentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[137]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupAgentsRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupAgentsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{137}
+}
+
+func (x *GetGroupAgentsRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+type GetGroupAgentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Agents        []*AgentInfo           `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupAgentsResponse) Reset() {
+	*x = GetGroupAgentsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[138]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupAgentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupAgentsResponse) ProtoMessage() {}
+
+func (x *GetGroupAgentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[138]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupAgentsResponse.ProtoReflect.Descriptor instead.
+func (*GetGroupAgentsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{138}
+}
+
+func (x *GetGroupAgentsResponse) GetAgents() []*AgentInfo {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+type DriftCheckRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Scope              string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`                                              // "group", "environment"
+	ScopeId            string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`                           // group_id or environment_id
+	CheckTypes         []string               `protobuf:"bytes,3,rep,name=check_types,json=checkTypes,proto3" json:"check_types,omitempty"`                  // ["nginx_main_conf", "ssl_certs", "maintenance_page"]
+	BaselineAgentId    string                 `protobuf:"bytes,4,opt,name=baseline_agent_id,json=baselineAgentId,proto3" json:"baseline_agent_id,omitempty"` // Optional: use specific agent as baseline
+	IncludeDiffContent bool                   `protobuf:"varint,5,opt,name=include_diff_content,json=includeDiffContent,proto3" json:"include_diff_content,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *DriftCheckRequest) Reset() {
+	*x = DriftCheckRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[139]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentConfigUpdateResult) String() string {
+func (x *DriftCheckRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentConfigUpdateResult) ProtoMessage() {}
+func (*DriftCheckRequest) ProtoMessage() {}
 
-func (x *AgentConfigUpdateResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[88]
+func (x *DriftCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[139]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6623,74 +10877,80 @@ func (x *AgentConfigUpdateResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentConfigUpdateResult.ProtoReflect.Descriptor instead.
-func (*AgentConfigUpdateResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{88}
+// Deprecated: Use DriftCheckRequest.ProtoReflect.Descriptor instead.
+func (*DriftCheckRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{139}
 }
 
-func (x *AgentConfigUpdateResult) GetSuccess() bool {
+func (x *DriftCheckRequest) GetScope() string {
 	if x != nil {
-		return x.Success
+		return x.Scope
 	}
-	return false
+	return ""
 }
 
-func (x *AgentConfigUpdateResult) GetError() string {
+func (x *DriftCheckRequest) GetScopeId() string {
 	if x != nil {
-		return x.Error
+		return x.ScopeId
 	}
 	return ""
 }
 
-func (x *AgentConfigUpdateResult) GetMessage() string {
+func (x *DriftCheckRequest) GetCheckTypes() []string {
 	if x != nil {
-		return x.Message
+		return x.CheckTypes
+	}
+	return nil
+}
+
+func (x *DriftCheckRequest) GetBaselineAgentId() string {
+	if x != nil {
+		return x.BaselineAgentId
 	}
 	return ""
 }
 
-func (x *AgentConfigUpdateResult) GetRequiresRestart() bool {
+func (x *DriftCheckRequest) GetIncludeDiffContent() bool {
 	if x != nil {
-		return x.RequiresRestart
+		return x.IncludeDiffContent
 	}
 	return false
 }
 
-type AgentGroup struct {
-	state                     protoimpl.MessageState `protogen:"open.v1"`
-	Id                        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	EnvironmentId             string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	Name                      string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Slug                      string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
-	Description               string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
-	GoldenAgentId             string                 `protobuf:"bytes,6,opt,name=golden_agent_id,json=goldenAgentId,proto3" json:"golden_agent_id,omitempty"`
-	ExpectedConfigHash        string                 `protobuf:"bytes,7,opt,name=expected_config_hash,json=expectedConfigHash,proto3" json:"expected_config_hash,omitempty"`
-	DriftCheckEnabled         bool                   `protobuf:"varint,8,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
-	DriftCheckIntervalSeconds int32                  `protobuf:"varint,9,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
-	Metadata                  map[string]string      `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	CreatedBy                 string                 `protobuf:"bytes,11,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
-	CreatedAt                 int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt                 int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	AgentCount                int32                  `protobuf:"varint,14,opt,name=agent_count,json=agentCount,proto3" json:"agent_count,omitempty"` // Number of agents in this group
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+type DriftCheckResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ReportId        string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
+	Scope           string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	ScopeId         string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	CheckType       string                 `protobuf:"bytes,4,opt,name=check_type,json=checkType,proto3" json:"check_type,omitempty"`
+	BaselineType    string                 `protobuf:"bytes,5,opt,name=baseline_type,json=baselineType,proto3" json:"baseline_type,omitempty"` // "golden_agent", "majority", "template"
+	BaselineAgentId string                 `protobuf:"bytes,6,opt,name=baseline_agent_id,json=baselineAgentId,proto3" json:"baseline_agent_id,omitempty"`
+	BaselineHash    string                 `protobuf:"bytes,7,opt,name=baseline_hash,json=baselineHash,proto3" json:"baseline_hash,omitempty"`
+	TotalAgents     int32                  `protobuf:"varint,8,opt,name=total_agents,json=totalAgents,proto3" json:"total_agents,omitempty"`
+	InSyncCount     int32                  `protobuf:"varint,9,opt,name=in_sync_count,json=inSyncCount,proto3" json:"in_sync_count,omitempty"`
+	DriftedCount    int32                  `protobuf:"varint,10,opt,name=drifted_count,json=driftedCount,proto3" json:"drifted_count,omitempty"`
+	ErrorCount      int32                  `protobuf:"varint,11,opt,name=error_count,json=errorCount,proto3" json:"error_count,omitempty"`
+	Items           []*DriftItem           `protobuf:"bytes,12,rep,name=items,proto3" json:"items,omitempty"`
+	CreatedAt       int64                  `protobuf:"varint,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *AgentGroup) Reset() {
-	*x = AgentGroup{}
-	mi := &file_api_proto_agent_proto_msgTypes[89]
+func (x *DriftCheckResponse) Reset() {
+	*x = DriftCheckResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[140]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentGroup) String() string {
+func (x *DriftCheckResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentGroup) ProtoMessage() {}
+func (*DriftCheckResponse) ProtoMessage() {}
 
-func (x *AgentGroup) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[89]
+func (x *DriftCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[140]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6701,131 +10961,224 @@ func (x *AgentGroup) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentGroup.ProtoReflect.Descriptor instead.
-func (*AgentGroup) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{89}
+// Deprecated: Use DriftCheckResponse.ProtoReflect.Descriptor instead.
+func (*DriftCheckResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{140}
 }
 
-func (x *AgentGroup) GetId() string {
+func (x *DriftCheckResponse) GetReportId() string {
 	if x != nil {
-		return x.Id
+		return x.ReportId
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetEnvironmentId() string {
+func (x *DriftCheckResponse) GetScope() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.Scope
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetName() string {
+func (x *DriftCheckResponse) GetScopeId() string {
 	if x != nil {
-		return x.Name
+		return x.ScopeId
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetSlug() string {
+func (x *DriftCheckResponse) GetCheckType() string {
 	if x != nil {
-		return x.Slug
+		return x.CheckType
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetDescription() string {
+func (x *DriftCheckResponse) GetBaselineType() string {
 	if x != nil {
-		return x.Description
+		return x.BaselineType
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetGoldenAgentId() string {
+func (x *DriftCheckResponse) GetBaselineAgentId() string {
 	if x != nil {
-		return x.GoldenAgentId
+		return x.BaselineAgentId
+	}
+	return ""
+}
+
+func (x *DriftCheckResponse) GetBaselineHash() string {
+	if x != nil {
+		return x.BaselineHash
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetExpectedConfigHash() string {
+func (x *DriftCheckResponse) GetTotalAgents() int32 {
+	if x != nil {
+		return x.TotalAgents
+	}
+	return 0
+}
+
+func (x *DriftCheckResponse) GetInSyncCount() int32 {
+	if x != nil {
+		return x.InSyncCount
+	}
+	return 0
+}
+
+func (x *DriftCheckResponse) GetDriftedCount() int32 {
+	if x != nil {
+		return x.DriftedCount
+	}
+	return 0
+}
+
+func (x *DriftCheckResponse) GetErrorCount() int32 {
+	if x != nil {
+		return x.ErrorCount
+	}
+	return 0
+}
+
+func (x *DriftCheckResponse) GetItems() []*DriftItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *DriftCheckResponse) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type DriftItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "in_sync", "drifted", "missing", "error"
+	CurrentHash   string                 `protobuf:"bytes,4,opt,name=current_hash,json=currentHash,proto3" json:"current_hash,omitempty"`
+	Severity      string                 `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`                          // "critical", "warning", "info"
+	DiffSummary   string                 `protobuf:"bytes,6,opt,name=diff_summary,json=diffSummary,proto3" json:"diff_summary,omitempty"` // "+3 lines, -1 line"
+	DiffContent   string                 `protobuf:"bytes,7,opt,name=diff_content,json=diffContent,proto3" json:"diff_content,omitempty"` // Unified diff
+	ErrorMessage  string                 `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DriftItem) Reset() {
+	*x = DriftItem{}
+	mi := &file_api_proto_agent_proto_msgTypes[141]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriftItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriftItem) ProtoMessage() {}
+
+func (x *DriftItem) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[141]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriftItem.ProtoReflect.Descriptor instead.
+func (*DriftItem) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{141}
+}
+
+func (x *DriftItem) GetAgentId() string {
 	if x != nil {
-		return x.ExpectedConfigHash
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetDriftCheckEnabled() bool {
+func (x *DriftItem) GetHostname() string {
 	if x != nil {
-		return x.DriftCheckEnabled
+		return x.Hostname
 	}
-	return false
+	return ""
 }
 
-func (x *AgentGroup) GetDriftCheckIntervalSeconds() int32 {
+func (x *DriftItem) GetStatus() string {
 	if x != nil {
-		return x.DriftCheckIntervalSeconds
+		return x.Status
 	}
-	return 0
+	return ""
 }
 
-func (x *AgentGroup) GetMetadata() map[string]string {
+func (x *DriftItem) GetCurrentHash() string {
 	if x != nil {
-		return x.Metadata
+		return x.CurrentHash
 	}
-	return nil
+	return ""
 }
 
-func (x *AgentGroup) GetCreatedBy() string {
+func (x *DriftItem) GetSeverity() string {
 	if x != nil {
-		return x.CreatedBy
+		return x.Severity
 	}
 	return ""
 }
 
-func (x *AgentGroup) GetCreatedAt() int64 {
+func (x *DriftItem) GetDiffSummary() string {
 	if x != nil {
-		return x.CreatedAt
+		return x.DiffSummary
 	}
-	return 0
+	return ""
 }
 
-func (x *AgentGroup) GetUpdatedAt() int64 {
+func (x *DriftItem) GetDiffContent() string {
 	if x != nil {
-		return x.UpdatedAt
+		return x.DiffContent
 	}
-	return 0
+	return ""
 }
 
-func (x *AgentGroup) GetAgentCount() int32 {
+func (x *DriftItem) GetErrorMessage() string {
 	if x != nil {
-		return x.AgentCount
+		return x.ErrorMessage
 	}
-	return 0
+	return ""
 }
 
-type ListGroupsRequest struct {
+type GetDriftReportRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	EnvironmentId string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"` // Required: filter by environment
+	ReportId      string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListGroupsRequest) Reset() {
-	*x = ListGroupsRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[90]
+func (x *GetDriftReportRequest) Reset() {
+	*x = GetDriftReportRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[142]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListGroupsRequest) String() string {
+func (x *GetDriftReportRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListGroupsRequest) ProtoMessage() {}
+func (*GetDriftReportRequest) ProtoMessage() {}
 
-func (x *ListGroupsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[90]
+func (x *GetDriftReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[142]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6836,40 +11189,42 @@ func (x *ListGroupsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListGroupsRequest.ProtoReflect.Descriptor instead.
-func (*ListGroupsRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{90}
+// Deprecated: Use GetDriftReportRequest.ProtoReflect.Descriptor instead.
+func (*GetDriftReportRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{142}
 }
 
-func (x *ListGroupsRequest) GetEnvironmentId() string {
+func (x *GetDriftReportRequest) GetReportId() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.ReportId
 	}
 	return ""
 }
 
-type ListGroupsResponse struct {
+type ListDriftReportsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Groups        []*AgentGroup          `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	Scope         string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"` // "group", "environment"
+	ScopeId       string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListGroupsResponse) Reset() {
-	*x = ListGroupsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[91]
+func (x *ListDriftReportsRequest) Reset() {
+	*x = ListDriftReportsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[143]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListGroupsResponse) String() string {
+func (x *ListDriftReportsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListGroupsResponse) ProtoMessage() {}
+func (*ListDriftReportsRequest) ProtoMessage() {}
 
-func (x *ListGroupsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[91]
+func (x *ListDriftReportsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[143]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6880,40 +11235,54 @@ func (x *ListGroupsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListGroupsResponse.ProtoReflect.Descriptor instead.
-func (*ListGroupsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{91}
+// Deprecated: Use ListDriftReportsRequest.ProtoReflect.Descriptor instead.
+func (*ListDriftReportsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{143}
 }
 
-func (x *ListGroupsResponse) GetGroups() []*AgentGroup {
+func (x *ListDriftReportsRequest) GetScope() string {
 	if x != nil {
-		return x.Groups
+		return x.Scope
 	}
-	return nil
+	return ""
 }
 
-type GetGroupRequest struct {
+func (x *ListDriftReportsRequest) GetScopeId() string {
+	if x != nil {
+		return x.ScopeId
+	}
+	return ""
+}
+
+func (x *ListDriftReportsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListDriftReportsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Reports       []*DriftCheckResponse  `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetGroupRequest) Reset() {
-	*x = GetGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[92]
+func (x *ListDriftReportsResponse) Reset() {
+	*x = ListDriftReportsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[144]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetGroupRequest) String() string {
+func (x *ListDriftReportsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetGroupRequest) ProtoMessage() {}
+func (*ListDriftReportsResponse) ProtoMessage() {}
 
-func (x *GetGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[92]
+func (x *ListDriftReportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[144]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6924,46 +11293,43 @@ func (x *GetGroupRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetGroupRequest.ProtoReflect.Descriptor instead.
-func (*GetGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{92}
+// Deprecated: Use ListDriftReportsResponse.ProtoReflect.Descriptor instead.
+func (*ListDriftReportsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{144}
 }
 
-func (x *GetGroupRequest) GetGroupId() string {
+func (x *ListDriftReportsResponse) GetReports() []*DriftCheckResponse {
 	if x != nil {
-		return x.GroupId
+		return x.Reports
 	}
-	return ""
+	return nil
 }
 
-type CreateGroupRequest struct {
-	state                     protoimpl.MessageState `protogen:"open.v1"`
-	EnvironmentId             string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	Name                      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Slug                      string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
-	Description               string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	DriftCheckEnabled         bool                   `protobuf:"varint,5,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
-	DriftCheckIntervalSeconds int32                  `protobuf:"varint,6,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
-	Metadata                  map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+type ResolveDriftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReportId      string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
+	AgentIds      []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`                  // Specific agents to resolve, empty = all drifted
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`                                      // "sync_to_baseline", "sync_to_agent", "acknowledge"
+	SourceAgentId string                 `protobuf:"bytes,4,opt,name=source_agent_id,json=sourceAgentId,proto3" json:"source_agent_id,omitempty"` // For "sync_to_agent" action
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateGroupRequest) Reset() {
-	*x = CreateGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[93]
+func (x *ResolveDriftRequest) Reset() {
+	*x = ResolveDriftRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[145]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateGroupRequest) String() string {
+func (x *ResolveDriftRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateGroupRequest) ProtoMessage() {}
+func (*ResolveDriftRequest) ProtoMessage() {}
 
-func (x *CreateGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[93]
+func (x *ResolveDriftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[145]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6974,87 +11340,85 @@ func (x *CreateGroupRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateGroupRequest.ProtoReflect.Descriptor instead.
-func (*CreateGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{93}
+// Deprecated: Use ResolveDriftRequest.ProtoReflect.Descriptor instead.
+func (*ResolveDriftRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{145}
 }
 
-func (x *CreateGroupRequest) GetEnvironmentId() string {
+func (x *ResolveDriftRequest) GetReportId() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.ReportId
 	}
 	return ""
 }
 
-func (x *CreateGroupRequest) GetName() string {
+func (x *ResolveDriftRequest) GetAgentIds() []string {
 	if x != nil {
-		return x.Name
+		return x.AgentIds
 	}
-	return ""
+	return nil
 }
 
-func (x *CreateGroupRequest) GetSlug() string {
+func (x *ResolveDriftRequest) GetAction() string {
 	if x != nil {
-		return x.Slug
+		return x.Action
 	}
 	return ""
 }
 
-func (x *CreateGroupRequest) GetDescription() string {
+func (x *ResolveDriftRequest) GetSourceAgentId() string {
 	if x != nil {
-		return x.Description
+		return x.SourceAgentId
 	}
 	return ""
 }
 
-func (x *CreateGroupRequest) GetDriftCheckEnabled() bool {
-	if x != nil {
-		return x.DriftCheckEnabled
-	}
-	return false
-}
-
-func (x *CreateGroupRequest) GetDriftCheckIntervalSeconds() int32 {
-	if x != nil {
-		return x.DriftCheckIntervalSeconds
-	}
-	return 0
-}
-
-func (x *CreateGroupRequest) GetMetadata() map[string]string {
-	if x != nil {
-		return x.Metadata
-	}
-	return nil
-}
-
-type UpdateGroupRequest struct {
-	state                     protoimpl.MessageState `protogen:"open.v1"`
-	GroupId                   string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	Name                      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description               string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	DriftCheckEnabled         bool                   `protobuf:"varint,4,opt,name=drift_check_enabled,json=driftCheckEnabled,proto3" json:"drift_check_enabled,omitempty"`
-	DriftCheckIntervalSeconds int32                  `protobuf:"varint,5,opt,name=drift_check_interval_seconds,json=driftCheckIntervalSeconds,proto3" json:"drift_check_interval_seconds,omitempty"`
-	Metadata                  map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+type BatchConfigUpdateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Target selection (one required)
+	AgentIds      []string `protobuf:"bytes,1,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
+	GroupId       string   `protobuf:"bytes,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	EnvironmentId string   `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	// Configuration source (one required)
+	TemplateId    string `protobuf:"bytes,4,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	RawContent    string `protobuf:"bytes,5,opt,name=raw_content,json=rawContent,proto3" json:"raw_content,omitempty"`
+	SourceAgentId string `protobuf:"bytes,6,opt,name=source_agent_id,json=sourceAgentId,proto3" json:"source_agent_id,omitempty"` // Copy from specific agent
+	SnippetId     string `protobuf:"bytes,18,opt,name=snippet_id,json=snippetId,proto3" json:"snippet_id,omitempty"`              // Shared config snippet, written to its managed include file
+	// Variables for template
+	Variables map[string]string `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Strategy
+	Strategy                   string `protobuf:"bytes,8,opt,name=strategy,proto3" json:"strategy,omitempty"` // "parallel", "rolling", "canary"
+	BatchSize                  int32  `protobuf:"varint,9,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	PauseBetweenBatchesSeconds int32  `protobuf:"varint,10,opt,name=pause_between_batches_seconds,json=pauseBetweenBatchesSeconds,proto3" json:"pause_between_batches_seconds,omitempty"`
+	// Canary options
+	CanaryPercentage      int32 `protobuf:"varint,11,opt,name=canary_percentage,json=canaryPercentage,proto3" json:"canary_percentage,omitempty"`
+	CanaryDurationSeconds int32 `protobuf:"varint,12,opt,name=canary_duration_seconds,json=canaryDurationSeconds,proto3" json:"canary_duration_seconds,omitempty"`
+	// Safety options
+	DryRun         bool `protobuf:"varint,13,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	ValidateOnly   bool `protobuf:"varint,14,opt,name=validate_only,json=validateOnly,proto3" json:"validate_only,omitempty"`
+	BackupFirst    bool `protobuf:"varint,15,opt,name=backup_first,json=backupFirst,proto3" json:"backup_first,omitempty"`
+	RollbackOnFail bool `protobuf:"varint,16,opt,name=rollback_on_fail,json=rollbackOnFail,proto3" json:"rollback_on_fail,omitempty"`
+	// Metadata
+	Description   string `protobuf:"bytes,17,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateGroupRequest) Reset() {
-	*x = UpdateGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[94]
+func (x *BatchConfigUpdateRequest) Reset() {
+	*x = BatchConfigUpdateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[146]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateGroupRequest) String() string {
+func (x *BatchConfigUpdateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateGroupRequest) ProtoMessage() {}
+func (*BatchConfigUpdateRequest) ProtoMessage() {}
 
-func (x *UpdateGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[94]
+func (x *BatchConfigUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[146]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7065,172 +11429,171 @@ func (x *UpdateGroupRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateGroupRequest.ProtoReflect.Descriptor instead.
-func (*UpdateGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{94}
+// Deprecated: Use BatchConfigUpdateRequest.ProtoReflect.Descriptor instead.
+func (*BatchConfigUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{146}
 }
 
-func (x *UpdateGroupRequest) GetGroupId() string {
+func (x *BatchConfigUpdateRequest) GetAgentIds() []string {
 	if x != nil {
-		return x.GroupId
+		return x.AgentIds
 	}
-	return ""
+	return nil
 }
 
-func (x *UpdateGroupRequest) GetName() string {
+func (x *BatchConfigUpdateRequest) GetGroupId() string {
 	if x != nil {
-		return x.Name
+		return x.GroupId
 	}
 	return ""
 }
 
-func (x *UpdateGroupRequest) GetDescription() string {
+func (x *BatchConfigUpdateRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.Description
+		return x.EnvironmentId
 	}
 	return ""
 }
 
-func (x *UpdateGroupRequest) GetDriftCheckEnabled() bool {
+func (x *BatchConfigUpdateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.DriftCheckEnabled
+		return x.TemplateId
 	}
-	return false
+	return ""
 }
 
-func (x *UpdateGroupRequest) GetDriftCheckIntervalSeconds() int32 {
+func (x *BatchConfigUpdateRequest) GetRawContent() string {
 	if x != nil {
-		return x.DriftCheckIntervalSeconds
+		return x.RawContent
 	}
-	return 0
+	return ""
 }
 
-func (x *UpdateGroupRequest) GetMetadata() map[string]string {
+func (x *BatchConfigUpdateRequest) GetSourceAgentId() string {
 	if x != nil {
-		return x.Metadata
+		return x.SourceAgentId
 	}
-	return nil
-}
-
-type DeleteGroupRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	return ""
 }
 
-func (x *DeleteGroupRequest) Reset() {
-	*x = DeleteGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[95]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *BatchConfigUpdateRequest) GetSnippetId() string {
+	if x != nil {
+		return x.SnippetId
+	}
+	return ""
 }
 
-func (x *DeleteGroupRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *BatchConfigUpdateRequest) GetVariables() map[string]string {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
 }
 
-func (*DeleteGroupRequest) ProtoMessage() {}
-
-func (x *DeleteGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[95]
+func (x *BatchConfigUpdateRequest) GetStrategy() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Strategy
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use DeleteGroupRequest.ProtoReflect.Descriptor instead.
-func (*DeleteGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{95}
+func (x *BatchConfigUpdateRequest) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
 }
 
-func (x *DeleteGroupRequest) GetGroupId() string {
+func (x *BatchConfigUpdateRequest) GetPauseBetweenBatchesSeconds() int32 {
 	if x != nil {
-		return x.GroupId
+		return x.PauseBetweenBatchesSeconds
 	}
-	return ""
+	return 0
 }
 
-type DeleteGroupResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *BatchConfigUpdateRequest) GetCanaryPercentage() int32 {
+	if x != nil {
+		return x.CanaryPercentage
+	}
+	return 0
 }
 
-func (x *DeleteGroupResponse) Reset() {
-	*x = DeleteGroupResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[96]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *BatchConfigUpdateRequest) GetCanaryDurationSeconds() int32 {
+	if x != nil {
+		return x.CanaryDurationSeconds
+	}
+	return 0
 }
 
-func (x *DeleteGroupResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *BatchConfigUpdateRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
 }
 
-func (*DeleteGroupResponse) ProtoMessage() {}
-
-func (x *DeleteGroupResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[96]
+func (x *BatchConfigUpdateRequest) GetValidateOnly() bool {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ValidateOnly
 	}
-	return mi.MessageOf(x)
+	return false
 }
 
-// Deprecated: Use DeleteGroupResponse.ProtoReflect.Descriptor instead.
-func (*DeleteGroupResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{96}
+func (x *BatchConfigUpdateRequest) GetBackupFirst() bool {
+	if x != nil {
+		return x.BackupFirst
+	}
+	return false
 }
 
-func (x *DeleteGroupResponse) GetSuccess() bool {
+func (x *BatchConfigUpdateRequest) GetRollbackOnFail() bool {
 	if x != nil {
-		return x.Success
+		return x.RollbackOnFail
 	}
 	return false
 }
 
-func (x *DeleteGroupResponse) GetMessage() string {
+func (x *BatchConfigUpdateRequest) GetDescription() string {
 	if x != nil {
-		return x.Message
+		return x.Description
 	}
 	return ""
 }
 
-type AddAgentsToGroupRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	AgentIds      []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type BatchConfigUpdateResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BatchId        string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Status         string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "in_progress", "completed", "partial_failure", "failed", "cancelled", "rolled_back"
+	Strategy       string                 `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	TotalAgents    int32                  `protobuf:"varint,4,opt,name=total_agents,json=totalAgents,proto3" json:"total_agents,omitempty"`
+	CurrentBatch   int32                  `protobuf:"varint,5,opt,name=current_batch,json=currentBatch,proto3" json:"current_batch,omitempty"`
+	TotalBatches   int32                  `protobuf:"varint,6,opt,name=total_batches,json=totalBatches,proto3" json:"total_batches,omitempty"`
+	CompletedCount int32                  `protobuf:"varint,7,opt,name=completed_count,json=completedCount,proto3" json:"completed_count,omitempty"`
+	FailedCount    int32                  `protobuf:"varint,8,opt,name=failed_count,json=failedCount,proto3" json:"failed_count,omitempty"`
+	PendingCount   int32                  `protobuf:"varint,9,opt,name=pending_count,json=pendingCount,proto3" json:"pending_count,omitempty"`
+	Results        []*AgentUpdateResult   `protobuf:"bytes,10,rep,name=results,proto3" json:"results,omitempty"`
+	StartedAt      int64                  `protobuf:"varint,11,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt    int64                  `protobuf:"varint,12,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	Error          string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *AddAgentsToGroupRequest) Reset() {
-	*x = AddAgentsToGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[97]
+func (x *BatchConfigUpdateResponse) Reset() {
+	*x = BatchConfigUpdateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[147]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddAgentsToGroupRequest) String() string {
+func (x *BatchConfigUpdateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddAgentsToGroupRequest) ProtoMessage() {}
+func (*BatchConfigUpdateResponse) ProtoMessage() {}
 
-func (x *AddAgentsToGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[97]
+func (x *BatchConfigUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[147]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7241,160 +11604,131 @@ func (x *AddAgentsToGroupRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddAgentsToGroupRequest.ProtoReflect.Descriptor instead.
-func (*AddAgentsToGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{97}
+// Deprecated: Use BatchConfigUpdateResponse.ProtoReflect.Descriptor instead.
+func (*BatchConfigUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{147}
 }
 
-func (x *AddAgentsToGroupRequest) GetGroupId() string {
+func (x *BatchConfigUpdateResponse) GetBatchId() string {
 	if x != nil {
-		return x.GroupId
+		return x.BatchId
 	}
 	return ""
 }
 
-func (x *AddAgentsToGroupRequest) GetAgentIds() []string {
-	if x != nil {
-		return x.AgentIds
-	}
-	return nil
-}
-
-type AddAgentsToGroupResponse struct {
-	state         protoimpl.MessageState        `protogen:"open.v1"`
-	Success       bool                          `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Results       []*AgentGroupAssignmentResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *AddAgentsToGroupResponse) Reset() {
-	*x = AddAgentsToGroupResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[98]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *AddAgentsToGroupResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*AddAgentsToGroupResponse) ProtoMessage() {}
-
-func (x *AddAgentsToGroupResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[98]
+func (x *BatchConfigUpdateResponse) GetStatus() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Status
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use AddAgentsToGroupResponse.ProtoReflect.Descriptor instead.
-func (*AddAgentsToGroupResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{98}
+func (x *BatchConfigUpdateResponse) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
 }
 
-func (x *AddAgentsToGroupResponse) GetSuccess() bool {
+func (x *BatchConfigUpdateResponse) GetTotalAgents() int32 {
 	if x != nil {
-		return x.Success
+		return x.TotalAgents
 	}
-	return false
+	return 0
 }
 
-func (x *AddAgentsToGroupResponse) GetResults() []*AgentGroupAssignmentResult {
+func (x *BatchConfigUpdateResponse) GetCurrentBatch() int32 {
 	if x != nil {
-		return x.Results
+		return x.CurrentBatch
 	}
-	return nil
+	return 0
 }
 
-type AgentGroupAssignmentResult struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *BatchConfigUpdateResponse) GetTotalBatches() int32 {
+	if x != nil {
+		return x.TotalBatches
+	}
+	return 0
 }
 
-func (x *AgentGroupAssignmentResult) Reset() {
-	*x = AgentGroupAssignmentResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[99]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *BatchConfigUpdateResponse) GetCompletedCount() int32 {
+	if x != nil {
+		return x.CompletedCount
+	}
+	return 0
 }
 
-func (x *AgentGroupAssignmentResult) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *BatchConfigUpdateResponse) GetFailedCount() int32 {
+	if x != nil {
+		return x.FailedCount
+	}
+	return 0
 }
 
-func (*AgentGroupAssignmentResult) ProtoMessage() {}
-
-func (x *AgentGroupAssignmentResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[99]
+func (x *BatchConfigUpdateResponse) GetPendingCount() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.PendingCount
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use AgentGroupAssignmentResult.ProtoReflect.Descriptor instead.
-func (*AgentGroupAssignmentResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{99}
+func (x *BatchConfigUpdateResponse) GetResults() []*AgentUpdateResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
 }
 
-func (x *AgentGroupAssignmentResult) GetAgentId() string {
+func (x *BatchConfigUpdateResponse) GetStartedAt() int64 {
 	if x != nil {
-		return x.AgentId
+		return x.StartedAt
 	}
-	return ""
+	return 0
 }
 
-func (x *AgentGroupAssignmentResult) GetSuccess() bool {
+func (x *BatchConfigUpdateResponse) GetCompletedAt() int64 {
 	if x != nil {
-		return x.Success
+		return x.CompletedAt
 	}
-	return false
+	return 0
 }
 
-func (x *AgentGroupAssignmentResult) GetError() string {
+func (x *BatchConfigUpdateResponse) GetError() string {
 	if x != nil {
 		return x.Error
 	}
 	return ""
 }
 
-type RemoveAgentFromGroupRequest struct {
+type AgentUpdateResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	AgentId       string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "success", "failed", "skipped", "rolled_back", "pending"
+	BackupPath    string                 `protobuf:"bytes,4,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
+	ConfigHash    string                 `protobuf:"bytes,5,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	DurationMs    int32                  `protobuf:"varint,7,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	CompletedAt   int64                  `protobuf:"varint,8,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RemoveAgentFromGroupRequest) Reset() {
-	*x = RemoveAgentFromGroupRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[100]
+func (x *AgentUpdateResult) Reset() {
+	*x = AgentUpdateResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[148]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RemoveAgentFromGroupRequest) String() string {
+func (x *AgentUpdateResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RemoveAgentFromGroupRequest) ProtoMessage() {}
+func (*AgentUpdateResult) ProtoMessage() {}
 
-func (x *RemoveAgentFromGroupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[100]
+func (x *AgentUpdateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[148]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7405,100 +11739,89 @@ func (x *RemoveAgentFromGroupRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RemoveAgentFromGroupRequest.ProtoReflect.Descriptor instead.
-func (*RemoveAgentFromGroupRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{100}
+// Deprecated: Use AgentUpdateResult.ProtoReflect.Descriptor instead.
+func (*AgentUpdateResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{148}
 }
 
-func (x *RemoveAgentFromGroupRequest) GetGroupId() string {
+func (x *AgentUpdateResult) GetAgentId() string {
 	if x != nil {
-		return x.GroupId
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *RemoveAgentFromGroupRequest) GetAgentId() string {
+func (x *AgentUpdateResult) GetHostname() string {
 	if x != nil {
-		return x.AgentId
+		return x.Hostname
 	}
 	return ""
 }
 
-type RemoveAgentFromGroupResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *RemoveAgentFromGroupResponse) Reset() {
-	*x = RemoveAgentFromGroupResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[101]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AgentUpdateResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
 }
 
-func (x *RemoveAgentFromGroupResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AgentUpdateResult) GetBackupPath() string {
+	if x != nil {
+		return x.BackupPath
+	}
+	return ""
 }
 
-func (*RemoveAgentFromGroupResponse) ProtoMessage() {}
-
-func (x *RemoveAgentFromGroupResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[101]
+func (x *AgentUpdateResult) GetConfigHash() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ConfigHash
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use RemoveAgentFromGroupResponse.ProtoReflect.Descriptor instead.
-func (*RemoveAgentFromGroupResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{101}
+func (x *AgentUpdateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
-func (x *RemoveAgentFromGroupResponse) GetSuccess() bool {
+func (x *AgentUpdateResult) GetDurationMs() int32 {
 	if x != nil {
-		return x.Success
+		return x.DurationMs
 	}
-	return false
+	return 0
 }
 
-func (x *RemoveAgentFromGroupResponse) GetMessage() string {
+func (x *AgentUpdateResult) GetCompletedAt() int64 {
 	if x != nil {
-		return x.Message
+		return x.CompletedAt
 	}
-	return ""
+	return 0
 }
 
-type SetGoldenAgentRequest struct {
+type GetBatchStatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	AgentId       string                 `protobuf:"bytes,2,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Empty to clear golden agent
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetGoldenAgentRequest) Reset() {
-	*x = SetGoldenAgentRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[102]
+func (x *GetBatchStatusRequest) Reset() {
+	*x = GetBatchStatusRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[149]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetGoldenAgentRequest) String() string {
+func (x *GetBatchStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetGoldenAgentRequest) ProtoMessage() {}
+func (*GetBatchStatusRequest) ProtoMessage() {}
 
-func (x *SetGoldenAgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[102]
+func (x *GetBatchStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[149]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7509,48 +11832,40 @@ func (x *SetGoldenAgentRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetGoldenAgentRequest.ProtoReflect.Descriptor instead.
-func (*SetGoldenAgentRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{102}
-}
-
-func (x *SetGoldenAgentRequest) GetGroupId() string {
-	if x != nil {
-		return x.GroupId
-	}
-	return ""
+// Deprecated: Use GetBatchStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetBatchStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{149}
 }
 
-func (x *SetGoldenAgentRequest) GetAgentId() string {
+func (x *GetBatchStatusRequest) GetBatchId() string {
 	if x != nil {
-		return x.AgentId
+		return x.BatchId
 	}
 	return ""
 }
 
-type SetGoldenAgentResponse struct {
+type CancelBatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetGoldenAgentResponse) Reset() {
-	*x = SetGoldenAgentResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[103]
+func (x *CancelBatchRequest) Reset() {
+	*x = CancelBatchRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[150]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetGoldenAgentResponse) String() string {
+func (x *CancelBatchRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetGoldenAgentResponse) ProtoMessage() {}
+func (*CancelBatchRequest) ProtoMessage() {}
 
-func (x *SetGoldenAgentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[103]
+func (x *CancelBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[150]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7561,47 +11876,41 @@ func (x *SetGoldenAgentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetGoldenAgentResponse.ProtoReflect.Descriptor instead.
-func (*SetGoldenAgentResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{103}
-}
-
-func (x *SetGoldenAgentResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
+// Deprecated: Use CancelBatchRequest.ProtoReflect.Descriptor instead.
+func (*CancelBatchRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{150}
 }
 
-func (x *SetGoldenAgentResponse) GetMessage() string {
+func (x *CancelBatchRequest) GetBatchId() string {
 	if x != nil {
-		return x.Message
+		return x.BatchId
 	}
 	return ""
 }
 
-type GetGroupAgentsRequest struct {
+type CancelBatchResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GroupId       string                 `protobuf:"bytes,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetGroupAgentsRequest) Reset() {
-	*x = GetGroupAgentsRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[104]
+func (x *CancelBatchResponse) Reset() {
+	*x = CancelBatchResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[151]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetGroupAgentsRequest) String() string {
+func (x *CancelBatchResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetGroupAgentsRequest) ProtoMessage() {}
+func (*CancelBatchResponse) ProtoMessage() {}
 
-func (x *GetGroupAgentsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[104]
+func (x *CancelBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[151]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7612,40 +11921,47 @@ func (x *GetGroupAgentsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetGroupAgentsRequest.ProtoReflect.Descriptor instead.
-func (*GetGroupAgentsRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{104}
+// Deprecated: Use CancelBatchResponse.ProtoReflect.Descriptor instead.
+func (*CancelBatchResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{151}
 }
 
-func (x *GetGroupAgentsRequest) GetGroupId() string {
+func (x *CancelBatchResponse) GetSuccess() bool {
 	if x != nil {
-		return x.GroupId
+		return x.Success
+	}
+	return false
+}
+
+func (x *CancelBatchResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-type GetGroupAgentsResponse struct {
+type RollbackBatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Agents        []*AgentInfo           `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetGroupAgentsResponse) Reset() {
-	*x = GetGroupAgentsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[105]
+func (x *RollbackBatchRequest) Reset() {
+	*x = RollbackBatchRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[152]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetGroupAgentsResponse) String() string {
+func (x *RollbackBatchRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetGroupAgentsResponse) ProtoMessage() {}
+func (*RollbackBatchRequest) ProtoMessage() {}
 
-func (x *GetGroupAgentsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[105]
+func (x *RollbackBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[152]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7656,44 +11972,42 @@ func (x *GetGroupAgentsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetGroupAgentsResponse.ProtoReflect.Descriptor instead.
-func (*GetGroupAgentsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{105}
+// Deprecated: Use RollbackBatchRequest.ProtoReflect.Descriptor instead.
+func (*RollbackBatchRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{152}
 }
 
-func (x *GetGroupAgentsResponse) GetAgents() []*AgentInfo {
+func (x *RollbackBatchRequest) GetBatchId() string {
 	if x != nil {
-		return x.Agents
+		return x.BatchId
 	}
-	return nil
+	return ""
 }
 
-type DriftCheckRequest struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	Scope              string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`                                              // "group", "environment"
-	ScopeId            string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`                           // group_id or environment_id
-	CheckTypes         []string               `protobuf:"bytes,3,rep,name=check_types,json=checkTypes,proto3" json:"check_types,omitempty"`                  // ["nginx_main_conf", "ssl_certs", "maintenance_page"]
-	BaselineAgentId    string                 `protobuf:"bytes,4,opt,name=baseline_agent_id,json=baselineAgentId,proto3" json:"baseline_agent_id,omitempty"` // Optional: use specific agent as baseline
-	IncludeDiffContent bool                   `protobuf:"varint,5,opt,name=include_diff_content,json=includeDiffContent,proto3" json:"include_diff_content,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type RollbackBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Results       []*AgentUpdateResult   `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DriftCheckRequest) Reset() {
-	*x = DriftCheckRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[106]
+func (x *RollbackBatchResponse) Reset() {
+	*x = RollbackBatchResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[153]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DriftCheckRequest) String() string {
+func (x *RollbackBatchResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DriftCheckRequest) ProtoMessage() {}
+func (*RollbackBatchResponse) ProtoMessage() {}
 
-func (x *DriftCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[106]
+func (x *RollbackBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[153]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7704,80 +12018,68 @@ func (x *DriftCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DriftCheckRequest.ProtoReflect.Descriptor instead.
-func (*DriftCheckRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{106}
+// Deprecated: Use RollbackBatchResponse.ProtoReflect.Descriptor instead.
+func (*RollbackBatchResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{153}
 }
 
-func (x *DriftCheckRequest) GetScope() string {
+func (x *RollbackBatchResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Scope
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *DriftCheckRequest) GetScopeId() string {
+func (x *RollbackBatchResponse) GetMessage() string {
 	if x != nil {
-		return x.ScopeId
+		return x.Message
 	}
 	return ""
 }
 
-func (x *DriftCheckRequest) GetCheckTypes() []string {
+func (x *RollbackBatchResponse) GetResults() []*AgentUpdateResult {
 	if x != nil {
-		return x.CheckTypes
+		return x.Results
 	}
 	return nil
 }
 
-func (x *DriftCheckRequest) GetBaselineAgentId() string {
-	if x != nil {
-		return x.BaselineAgentId
-	}
-	return ""
-}
-
-func (x *DriftCheckRequest) GetIncludeDiffContent() bool {
-	if x != nil {
-		return x.IncludeDiffContent
-	}
-	return false
-}
-
-type DriftCheckResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	ReportId        string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
-	Scope           string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
-	ScopeId         string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
-	CheckType       string                 `protobuf:"bytes,4,opt,name=check_type,json=checkType,proto3" json:"check_type,omitempty"`
-	BaselineType    string                 `protobuf:"bytes,5,opt,name=baseline_type,json=baselineType,proto3" json:"baseline_type,omitempty"` // "golden_agent", "majority", "template"
-	BaselineAgentId string                 `protobuf:"bytes,6,opt,name=baseline_agent_id,json=baselineAgentId,proto3" json:"baseline_agent_id,omitempty"`
-	BaselineHash    string                 `protobuf:"bytes,7,opt,name=baseline_hash,json=baselineHash,proto3" json:"baseline_hash,omitempty"`
-	TotalAgents     int32                  `protobuf:"varint,8,opt,name=total_agents,json=totalAgents,proto3" json:"total_agents,omitempty"`
-	InSyncCount     int32                  `protobuf:"varint,9,opt,name=in_sync_count,json=inSyncCount,proto3" json:"in_sync_count,omitempty"`
-	DriftedCount    int32                  `protobuf:"varint,10,opt,name=drifted_count,json=driftedCount,proto3" json:"drifted_count,omitempty"`
-	ErrorCount      int32                  `protobuf:"varint,11,opt,name=error_count,json=errorCount,proto3" json:"error_count,omitempty"`
-	Items           []*DriftItem           `protobuf:"bytes,12,rep,name=items,proto3" json:"items,omitempty"`
-	CreatedAt       int64                  `protobuf:"varint,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type ConfigTemplate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	GroupId       string                 `protobuf:"bytes,4,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	TemplateType  string                 `protobuf:"bytes,7,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"` // 'nginx_main_conf', 'server_block', 'location_block'
+	Content       string                 `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
+	Variables     []*TemplateVariable    `protobuf:"bytes,9,rep,name=variables,proto3" json:"variables,omitempty"`
+	Defaults      map[string]string      `protobuf:"bytes,10,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Version       int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	IsActive      bool                   `protobuf:"varint,12,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,13,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,15,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DriftCheckResponse) Reset() {
-	*x = DriftCheckResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[107]
+func (x *ConfigTemplate) Reset() {
+	*x = ConfigTemplate{}
+	mi := &file_api_proto_agent_proto_msgTypes[154]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DriftCheckResponse) String() string {
+func (x *ConfigTemplate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DriftCheckResponse) ProtoMessage() {}
+func (*ConfigTemplate) ProtoMessage() {}
 
-func (x *DriftCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[107]
+func (x *ConfigTemplate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[154]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7788,131 +12090,143 @@ func (x *DriftCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DriftCheckResponse.ProtoReflect.Descriptor instead.
-func (*DriftCheckResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{107}
+// Deprecated: Use ConfigTemplate.ProtoReflect.Descriptor instead.
+func (*ConfigTemplate) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{154}
 }
 
-func (x *DriftCheckResponse) GetReportId() string {
+func (x *ConfigTemplate) GetId() string {
 	if x != nil {
-		return x.ReportId
+		return x.Id
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetScope() string {
+func (x *ConfigTemplate) GetProjectId() string {
 	if x != nil {
-		return x.Scope
+		return x.ProjectId
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetScopeId() string {
+func (x *ConfigTemplate) GetEnvironmentId() string {
 	if x != nil {
-		return x.ScopeId
+		return x.EnvironmentId
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetCheckType() string {
+func (x *ConfigTemplate) GetGroupId() string {
 	if x != nil {
-		return x.CheckType
+		return x.GroupId
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetBaselineType() string {
+func (x *ConfigTemplate) GetName() string {
 	if x != nil {
-		return x.BaselineType
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetBaselineAgentId() string {
+func (x *ConfigTemplate) GetDescription() string {
 	if x != nil {
-		return x.BaselineAgentId
+		return x.Description
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetBaselineHash() string {
+func (x *ConfigTemplate) GetTemplateType() string {
 	if x != nil {
-		return x.BaselineHash
+		return x.TemplateType
 	}
 	return ""
 }
 
-func (x *DriftCheckResponse) GetTotalAgents() int32 {
+func (x *ConfigTemplate) GetContent() string {
 	if x != nil {
-		return x.TotalAgents
+		return x.Content
 	}
-	return 0
+	return ""
 }
 
-func (x *DriftCheckResponse) GetInSyncCount() int32 {
+func (x *ConfigTemplate) GetVariables() []*TemplateVariable {
 	if x != nil {
-		return x.InSyncCount
+		return x.Variables
 	}
-	return 0
+	return nil
 }
 
-func (x *DriftCheckResponse) GetDriftedCount() int32 {
+func (x *ConfigTemplate) GetDefaults() map[string]string {
 	if x != nil {
-		return x.DriftedCount
+		return x.Defaults
 	}
-	return 0
+	return nil
 }
 
-func (x *DriftCheckResponse) GetErrorCount() int32 {
+func (x *ConfigTemplate) GetVersion() int32 {
 	if x != nil {
-		return x.ErrorCount
+		return x.Version
 	}
 	return 0
 }
 
-func (x *DriftCheckResponse) GetItems() []*DriftItem {
+func (x *ConfigTemplate) GetIsActive() bool {
 	if x != nil {
-		return x.Items
+		return x.IsActive
 	}
-	return nil
+	return false
 }
 
-func (x *DriftCheckResponse) GetCreatedAt() int64 {
+func (x *ConfigTemplate) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *ConfigTemplate) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *ConfigTemplate) GetUpdatedAt() int64 {
 	if x != nil {
-		return x.CreatedAt
+		return x.UpdatedAt
 	}
 	return 0
 }
 
-type DriftItem struct {
+type TemplateVariable struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "in_sync", "drifted", "missing", "error"
-	CurrentHash   string                 `protobuf:"bytes,4,opt,name=current_hash,json=currentHash,proto3" json:"current_hash,omitempty"`
-	Severity      string                 `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`                          // "critical", "warning", "info"
-	DiffSummary   string                 `protobuf:"bytes,6,opt,name=diff_summary,json=diffSummary,proto3" json:"diff_summary,omitempty"` // "+3 lines, -1 line"
-	DiffContent   string                 `protobuf:"bytes,7,opt,name=diff_content,json=diffContent,proto3" json:"diff_content,omitempty"` // Unified diff
-	ErrorMessage  string                 `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	DefaultValue  string                 `protobuf:"bytes,4,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
+	Validation    string                 `protobuf:"bytes,5,opt,name=validation,proto3" json:"validation,omitempty"` // Regex pattern
+	Options       []string               `protobuf:"bytes,6,rep,name=options,proto3" json:"options,omitempty"`       // For enum types
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DriftItem) Reset() {
-	*x = DriftItem{}
-	mi := &file_api_proto_agent_proto_msgTypes[108]
+func (x *TemplateVariable) Reset() {
+	*x = TemplateVariable{}
+	mi := &file_api_proto_agent_proto_msgTypes[155]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DriftItem) String() string {
+func (x *TemplateVariable) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DriftItem) ProtoMessage() {}
+func (*TemplateVariable) ProtoMessage() {}
 
-func (x *DriftItem) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[108]
+func (x *TemplateVariable) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[155]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7923,89 +12237,78 @@ func (x *DriftItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DriftItem.ProtoReflect.Descriptor instead.
-func (*DriftItem) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{108}
-}
-
-func (x *DriftItem) GetAgentId() string {
-	if x != nil {
-		return x.AgentId
-	}
-	return ""
-}
-
-func (x *DriftItem) GetHostname() string {
-	if x != nil {
-		return x.Hostname
-	}
-	return ""
+// Deprecated: Use TemplateVariable.ProtoReflect.Descriptor instead.
+func (*TemplateVariable) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{155}
 }
 
-func (x *DriftItem) GetStatus() string {
+func (x *TemplateVariable) GetName() string {
 	if x != nil {
-		return x.Status
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DriftItem) GetCurrentHash() string {
+func (x *TemplateVariable) GetDescription() string {
 	if x != nil {
-		return x.CurrentHash
+		return x.Description
 	}
 	return ""
 }
 
-func (x *DriftItem) GetSeverity() string {
+func (x *TemplateVariable) GetRequired() bool {
 	if x != nil {
-		return x.Severity
+		return x.Required
 	}
-	return ""
+	return false
 }
 
-func (x *DriftItem) GetDiffSummary() string {
+func (x *TemplateVariable) GetDefaultValue() string {
 	if x != nil {
-		return x.DiffSummary
+		return x.DefaultValue
 	}
 	return ""
 }
 
-func (x *DriftItem) GetDiffContent() string {
+func (x *TemplateVariable) GetValidation() string {
 	if x != nil {
-		return x.DiffContent
+		return x.Validation
 	}
 	return ""
 }
 
-func (x *DriftItem) GetErrorMessage() string {
+func (x *TemplateVariable) GetOptions() []string {
 	if x != nil {
-		return x.ErrorMessage
+		return x.Options
 	}
-	return ""
+	return nil
 }
 
-type GetDriftReportRequest struct {
+type ListConfigTemplatesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ReportId      string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	TemplateType  string                 `protobuf:"bytes,4,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetDriftReportRequest) Reset() {
-	*x = GetDriftReportRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[109]
+func (x *ListConfigTemplatesRequest) Reset() {
+	*x = ListConfigTemplatesRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[156]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetDriftReportRequest) String() string {
+func (x *ListConfigTemplatesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetDriftReportRequest) ProtoMessage() {}
+func (*ListConfigTemplatesRequest) ProtoMessage() {}
 
-func (x *GetDriftReportRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[109]
+func (x *ListConfigTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[156]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8016,42 +12319,61 @@ func (x *GetDriftReportRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetDriftReportRequest.ProtoReflect.Descriptor instead.
-func (*GetDriftReportRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{109}
+// Deprecated: Use ListConfigTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListConfigTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{156}
 }
 
-func (x *GetDriftReportRequest) GetReportId() string {
+func (x *ListConfigTemplatesRequest) GetProjectId() string {
 	if x != nil {
-		return x.ReportId
+		return x.ProjectId
 	}
 	return ""
 }
 
-type ListDriftReportsRequest struct {
+func (x *ListConfigTemplatesRequest) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+func (x *ListConfigTemplatesRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *ListConfigTemplatesRequest) GetTemplateType() string {
+	if x != nil {
+		return x.TemplateType
+	}
+	return ""
+}
+
+type ListConfigTemplatesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Scope         string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"` // "group", "environment"
-	ScopeId       string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
-	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Templates     []*ConfigTemplate      `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDriftReportsRequest) Reset() {
-	*x = ListDriftReportsRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[110]
+func (x *ListConfigTemplatesResponse) Reset() {
+	*x = ListConfigTemplatesResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[157]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDriftReportsRequest) String() string {
+func (x *ListConfigTemplatesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDriftReportsRequest) ProtoMessage() {}
+func (*ListConfigTemplatesResponse) ProtoMessage() {}
 
-func (x *ListDriftReportsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[110]
+func (x *ListConfigTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[157]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8062,54 +12384,40 @@ func (x *ListDriftReportsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDriftReportsRequest.ProtoReflect.Descriptor instead.
-func (*ListDriftReportsRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{110}
-}
-
-func (x *ListDriftReportsRequest) GetScope() string {
-	if x != nil {
-		return x.Scope
-	}
-	return ""
-}
-
-func (x *ListDriftReportsRequest) GetScopeId() string {
-	if x != nil {
-		return x.ScopeId
-	}
-	return ""
+// Deprecated: Use ListConfigTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListConfigTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{157}
 }
 
-func (x *ListDriftReportsRequest) GetLimit() int32 {
+func (x *ListConfigTemplatesResponse) GetTemplates() []*ConfigTemplate {
 	if x != nil {
-		return x.Limit
+		return x.Templates
 	}
-	return 0
+	return nil
 }
 
-type ListDriftReportsResponse struct {
+type GetConfigTemplateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Reports       []*DriftCheckResponse  `protobuf:"bytes,1,rep,name=reports,proto3" json:"reports,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDriftReportsResponse) Reset() {
-	*x = ListDriftReportsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[111]
+func (x *GetConfigTemplateRequest) Reset() {
+	*x = GetConfigTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[158]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDriftReportsResponse) String() string {
+func (x *GetConfigTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDriftReportsResponse) ProtoMessage() {}
+func (*GetConfigTemplateRequest) ProtoMessage() {}
 
-func (x *ListDriftReportsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[111]
+func (x *GetConfigTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[158]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8120,43 +12428,48 @@ func (x *ListDriftReportsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDriftReportsResponse.ProtoReflect.Descriptor instead.
-func (*ListDriftReportsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{111}
+// Deprecated: Use GetConfigTemplateRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{158}
 }
 
-func (x *ListDriftReportsResponse) GetReports() []*DriftCheckResponse {
+func (x *GetConfigTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.Reports
+		return x.TemplateId
 	}
-	return nil
+	return ""
 }
 
-type ResolveDriftRequest struct {
+type CreateConfigTemplateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ReportId      string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
-	AgentIds      []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`                  // Specific agents to resolve, empty = all drifted
-	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`                                      // "sync_to_baseline", "sync_to_agent", "acknowledge"
-	SourceAgentId string                 `protobuf:"bytes,4,opt,name=source_agent_id,json=sourceAgentId,proto3" json:"source_agent_id,omitempty"` // For "sync_to_agent" action
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	TemplateType  string                 `protobuf:"bytes,6,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"`
+	Content       string                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`
+	Variables     []*TemplateVariable    `protobuf:"bytes,8,rep,name=variables,proto3" json:"variables,omitempty"`
+	Defaults      map[string]string      `protobuf:"bytes,9,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResolveDriftRequest) Reset() {
-	*x = ResolveDriftRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[112]
+func (x *CreateConfigTemplateRequest) Reset() {
+	*x = CreateConfigTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[159]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResolveDriftRequest) String() string {
+func (x *CreateConfigTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResolveDriftRequest) ProtoMessage() {}
+func (*CreateConfigTemplateRequest) ProtoMessage() {}
 
-func (x *ResolveDriftRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[112]
+func (x *CreateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[159]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8164,87 +12477,106 @@ func (x *ResolveDriftRequest) ProtoReflect() protoreflect.Message {
 		}
 		return ms
 	}
-	return mi.MessageOf(x)
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateConfigTemplateRequest.ProtoReflect.Descriptor instead.
+func (*CreateConfigTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{159}
+}
+
+func (x *CreateConfigTemplateRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *CreateConfigTemplateRequest) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
 }
 
-// Deprecated: Use ResolveDriftRequest.ProtoReflect.Descriptor instead.
-func (*ResolveDriftRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{112}
+func (x *CreateConfigTemplateRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
 }
 
-func (x *ResolveDriftRequest) GetReportId() string {
+func (x *CreateConfigTemplateRequest) GetName() string {
 	if x != nil {
-		return x.ReportId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ResolveDriftRequest) GetAgentIds() []string {
+func (x *CreateConfigTemplateRequest) GetDescription() string {
 	if x != nil {
-		return x.AgentIds
+		return x.Description
 	}
-	return nil
+	return ""
 }
 
-func (x *ResolveDriftRequest) GetAction() string {
+func (x *CreateConfigTemplateRequest) GetTemplateType() string {
 	if x != nil {
-		return x.Action
+		return x.TemplateType
 	}
 	return ""
 }
 
-func (x *ResolveDriftRequest) GetSourceAgentId() string {
+func (x *CreateConfigTemplateRequest) GetContent() string {
 	if x != nil {
-		return x.SourceAgentId
+		return x.Content
 	}
 	return ""
 }
 
-type BatchConfigUpdateRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Target selection (one required)
-	AgentIds      []string `protobuf:"bytes,1,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
-	GroupId       string   `protobuf:"bytes,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	EnvironmentId string   `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	// Configuration source (one required)
-	TemplateId    string `protobuf:"bytes,4,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	RawContent    string `protobuf:"bytes,5,opt,name=raw_content,json=rawContent,proto3" json:"raw_content,omitempty"`
-	SourceAgentId string `protobuf:"bytes,6,opt,name=source_agent_id,json=sourceAgentId,proto3" json:"source_agent_id,omitempty"` // Copy from specific agent
-	// Variables for template
-	Variables map[string]string `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	// Strategy
-	Strategy                   string `protobuf:"bytes,8,opt,name=strategy,proto3" json:"strategy,omitempty"` // "parallel", "rolling", "canary"
-	BatchSize                  int32  `protobuf:"varint,9,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
-	PauseBetweenBatchesSeconds int32  `protobuf:"varint,10,opt,name=pause_between_batches_seconds,json=pauseBetweenBatchesSeconds,proto3" json:"pause_between_batches_seconds,omitempty"`
-	// Canary options
-	CanaryPercentage      int32 `protobuf:"varint,11,opt,name=canary_percentage,json=canaryPercentage,proto3" json:"canary_percentage,omitempty"`
-	CanaryDurationSeconds int32 `protobuf:"varint,12,opt,name=canary_duration_seconds,json=canaryDurationSeconds,proto3" json:"canary_duration_seconds,omitempty"`
-	// Safety options
-	DryRun         bool `protobuf:"varint,13,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
-	ValidateOnly   bool `protobuf:"varint,14,opt,name=validate_only,json=validateOnly,proto3" json:"validate_only,omitempty"`
-	BackupFirst    bool `protobuf:"varint,15,opt,name=backup_first,json=backupFirst,proto3" json:"backup_first,omitempty"`
-	RollbackOnFail bool `protobuf:"varint,16,opt,name=rollback_on_fail,json=rollbackOnFail,proto3" json:"rollback_on_fail,omitempty"`
-	// Metadata
-	Description   string `protobuf:"bytes,17,opt,name=description,proto3" json:"description,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *CreateConfigTemplateRequest) GetVariables() []*TemplateVariable {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
 }
 
-func (x *BatchConfigUpdateRequest) Reset() {
-	*x = BatchConfigUpdateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[113]
+func (x *CreateConfigTemplateRequest) GetDefaults() map[string]string {
+	if x != nil {
+		return x.Defaults
+	}
+	return nil
+}
+
+type UpdateConfigTemplateRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId     string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description    string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Content        string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Variables      []*TemplateVariable    `protobuf:"bytes,5,rep,name=variables,proto3" json:"variables,omitempty"`
+	Defaults       map[string]string      `protobuf:"bytes,6,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IsActive       bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	RestoreVersion int32                  `protobuf:"varint,8,opt,name=restore_version,json=restoreVersion,proto3" json:"restore_version,omitempty"` // Copy content/variables/defaults from an earlier version (as a new version)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateConfigTemplateRequest) Reset() {
+	*x = UpdateConfigTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[160]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchConfigUpdateRequest) String() string {
+func (x *UpdateConfigTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchConfigUpdateRequest) ProtoMessage() {}
+func (*UpdateConfigTemplateRequest) ProtoMessage() {}
 
-func (x *BatchConfigUpdateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[113]
+func (x *UpdateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[160]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8255,164 +12587,189 @@ func (x *BatchConfigUpdateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchConfigUpdateRequest.ProtoReflect.Descriptor instead.
-func (*BatchConfigUpdateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{113}
-}
-
-func (x *BatchConfigUpdateRequest) GetAgentIds() []string {
-	if x != nil {
-		return x.AgentIds
-	}
-	return nil
+// Deprecated: Use UpdateConfigTemplateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateConfigTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{160}
 }
 
-func (x *BatchConfigUpdateRequest) GetGroupId() string {
+func (x *UpdateConfigTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.GroupId
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateRequest) GetEnvironmentId() string {
+func (x *UpdateConfigTemplateRequest) GetName() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateRequest) GetTemplateId() string {
+func (x *UpdateConfigTemplateRequest) GetDescription() string {
 	if x != nil {
-		return x.TemplateId
+		return x.Description
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateRequest) GetRawContent() string {
+func (x *UpdateConfigTemplateRequest) GetContent() string {
 	if x != nil {
-		return x.RawContent
+		return x.Content
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateRequest) GetSourceAgentId() string {
+func (x *UpdateConfigTemplateRequest) GetVariables() []*TemplateVariable {
 	if x != nil {
-		return x.SourceAgentId
+		return x.Variables
 	}
-	return ""
+	return nil
 }
 
-func (x *BatchConfigUpdateRequest) GetVariables() map[string]string {
+func (x *UpdateConfigTemplateRequest) GetDefaults() map[string]string {
 	if x != nil {
-		return x.Variables
+		return x.Defaults
 	}
 	return nil
 }
 
-func (x *BatchConfigUpdateRequest) GetStrategy() string {
+func (x *UpdateConfigTemplateRequest) GetIsActive() bool {
 	if x != nil {
-		return x.Strategy
+		return x.IsActive
 	}
-	return ""
+	return false
 }
 
-func (x *BatchConfigUpdateRequest) GetBatchSize() int32 {
+func (x *UpdateConfigTemplateRequest) GetRestoreVersion() int32 {
 	if x != nil {
-		return x.BatchSize
+		return x.RestoreVersion
 	}
 	return 0
 }
 
-func (x *BatchConfigUpdateRequest) GetPauseBetweenBatchesSeconds() int32 {
-	if x != nil {
-		return x.PauseBetweenBatchesSeconds
-	}
-	return 0
+type DeleteConfigTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchConfigUpdateRequest) GetCanaryPercentage() int32 {
-	if x != nil {
-		return x.CanaryPercentage
-	}
-	return 0
+func (x *DeleteConfigTemplateRequest) Reset() {
+	*x = DeleteConfigTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[161]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchConfigUpdateRequest) GetCanaryDurationSeconds() int32 {
-	if x != nil {
-		return x.CanaryDurationSeconds
-	}
-	return 0
+func (x *DeleteConfigTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *BatchConfigUpdateRequest) GetDryRun() bool {
+func (*DeleteConfigTemplateRequest) ProtoMessage() {}
+
+func (x *DeleteConfigTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[161]
 	if x != nil {
-		return x.DryRun
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *BatchConfigUpdateRequest) GetValidateOnly() bool {
+// Deprecated: Use DeleteConfigTemplateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteConfigTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{161}
+}
+
+func (x *DeleteConfigTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.ValidateOnly
+		return x.TemplateId
 	}
-	return false
+	return ""
 }
 
-func (x *BatchConfigUpdateRequest) GetBackupFirst() bool {
+type DeleteConfigTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteConfigTemplateResponse) Reset() {
+	*x = DeleteConfigTemplateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[162]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteConfigTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteConfigTemplateResponse) ProtoMessage() {}
+
+func (x *DeleteConfigTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[162]
 	if x != nil {
-		return x.BackupFirst
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *BatchConfigUpdateRequest) GetRollbackOnFail() bool {
+// Deprecated: Use DeleteConfigTemplateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteConfigTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{162}
+}
+
+func (x *DeleteConfigTemplateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.RollbackOnFail
+		return x.Success
 	}
 	return false
 }
 
-func (x *BatchConfigUpdateRequest) GetDescription() string {
+func (x *DeleteConfigTemplateResponse) GetMessage() string {
 	if x != nil {
-		return x.Description
+		return x.Message
 	}
 	return ""
 }
 
-type BatchConfigUpdateResponse struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	BatchId        string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
-	Status         string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending", "in_progress", "completed", "partial_failure", "failed", "cancelled", "rolled_back"
-	Strategy       string                 `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`
-	TotalAgents    int32                  `protobuf:"varint,4,opt,name=total_agents,json=totalAgents,proto3" json:"total_agents,omitempty"`
-	CurrentBatch   int32                  `protobuf:"varint,5,opt,name=current_batch,json=currentBatch,proto3" json:"current_batch,omitempty"`
-	TotalBatches   int32                  `protobuf:"varint,6,opt,name=total_batches,json=totalBatches,proto3" json:"total_batches,omitempty"`
-	CompletedCount int32                  `protobuf:"varint,7,opt,name=completed_count,json=completedCount,proto3" json:"completed_count,omitempty"`
-	FailedCount    int32                  `protobuf:"varint,8,opt,name=failed_count,json=failedCount,proto3" json:"failed_count,omitempty"`
-	PendingCount   int32                  `protobuf:"varint,9,opt,name=pending_count,json=pendingCount,proto3" json:"pending_count,omitempty"`
-	Results        []*AgentUpdateResult   `protobuf:"bytes,10,rep,name=results,proto3" json:"results,omitempty"`
-	StartedAt      int64                  `protobuf:"varint,11,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
-	CompletedAt    int64                  `protobuf:"varint,12,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
-	Error          string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type RenderConfigTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Variables     map[string]string      `protobuf:"bytes,2,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Overrides, applied last
+	AgentId       string                 `protobuf:"bytes,3,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                                                                // Resolve built-in and per-agent values for this agent
+	EnvironmentId string                 `protobuf:"bytes,4,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`                                              // Resolve per-environment values (defaults to the agent's environment)
+	Version       int32                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`                                                                              // Render an earlier version (0 = current)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchConfigUpdateResponse) Reset() {
-	*x = BatchConfigUpdateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[114]
+func (x *RenderConfigTemplateRequest) Reset() {
+	*x = RenderConfigTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[163]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchConfigUpdateResponse) String() string {
+func (x *RenderConfigTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchConfigUpdateResponse) ProtoMessage() {}
+func (*RenderConfigTemplateRequest) ProtoMessage() {}
 
-func (x *BatchConfigUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[114]
+func (x *RenderConfigTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[163]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8423,131 +12780,150 @@ func (x *BatchConfigUpdateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchConfigUpdateResponse.ProtoReflect.Descriptor instead.
-func (*BatchConfigUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{114}
+// Deprecated: Use RenderConfigTemplateRequest.ProtoReflect.Descriptor instead.
+func (*RenderConfigTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{163}
 }
 
-func (x *BatchConfigUpdateResponse) GetBatchId() string {
+func (x *RenderConfigTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.BatchId
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateResponse) GetStatus() string {
+func (x *RenderConfigTemplateRequest) GetVariables() map[string]string {
 	if x != nil {
-		return x.Status
+		return x.Variables
 	}
-	return ""
+	return nil
 }
 
-func (x *BatchConfigUpdateResponse) GetStrategy() string {
+func (x *RenderConfigTemplateRequest) GetAgentId() string {
 	if x != nil {
-		return x.Strategy
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *BatchConfigUpdateResponse) GetTotalAgents() int32 {
+func (x *RenderConfigTemplateRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.TotalAgents
+		return x.EnvironmentId
 	}
-	return 0
+	return ""
 }
 
-func (x *BatchConfigUpdateResponse) GetCurrentBatch() int32 {
+func (x *RenderConfigTemplateRequest) GetVersion() int32 {
 	if x != nil {
-		return x.CurrentBatch
+		return x.Version
 	}
 	return 0
 }
 
-func (x *BatchConfigUpdateResponse) GetTotalBatches() int32 {
-	if x != nil {
-		return x.TotalBatches
-	}
-	return 0
+type RenderConfigTemplateResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	RenderedContent   string                 `protobuf:"bytes,1,opt,name=rendered_content,json=renderedContent,proto3" json:"rendered_content,omitempty"`
+	Valid             bool                   `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	ValidationErrors  []string               `protobuf:"bytes,3,rep,name=validation_errors,json=validationErrors,proto3" json:"validation_errors,omitempty"`
+	ResolvedVariables map[string]string      `protobuf:"bytes,4,rep,name=resolved_variables,json=resolvedVariables,proto3" json:"resolved_variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	TargetPath        string                 `protobuf:"bytes,5,opt,name=target_path,json=targetPath,proto3" json:"target_path,omitempty"` // Where the rendered file is written on the agent (relative to the NGINX conf dir)
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *BatchConfigUpdateResponse) GetCompletedCount() int32 {
-	if x != nil {
-		return x.CompletedCount
-	}
-	return 0
+func (x *RenderConfigTemplateResponse) Reset() {
+	*x = RenderConfigTemplateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[164]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchConfigUpdateResponse) GetFailedCount() int32 {
+func (x *RenderConfigTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderConfigTemplateResponse) ProtoMessage() {}
+
+func (x *RenderConfigTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[164]
 	if x != nil {
-		return x.FailedCount
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *BatchConfigUpdateResponse) GetPendingCount() int32 {
+// Deprecated: Use RenderConfigTemplateResponse.ProtoReflect.Descriptor instead.
+func (*RenderConfigTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{164}
+}
+
+func (x *RenderConfigTemplateResponse) GetRenderedContent() string {
 	if x != nil {
-		return x.PendingCount
+		return x.RenderedContent
 	}
-	return 0
+	return ""
 }
 
-func (x *BatchConfigUpdateResponse) GetResults() []*AgentUpdateResult {
+func (x *RenderConfigTemplateResponse) GetValid() bool {
 	if x != nil {
-		return x.Results
+		return x.Valid
 	}
-	return nil
+	return false
 }
 
-func (x *BatchConfigUpdateResponse) GetStartedAt() int64 {
+func (x *RenderConfigTemplateResponse) GetValidationErrors() []string {
 	if x != nil {
-		return x.StartedAt
+		return x.ValidationErrors
 	}
-	return 0
+	return nil
 }
 
-func (x *BatchConfigUpdateResponse) GetCompletedAt() int64 {
+func (x *RenderConfigTemplateResponse) GetResolvedVariables() map[string]string {
 	if x != nil {
-		return x.CompletedAt
+		return x.ResolvedVariables
 	}
-	return 0
+	return nil
 }
 
-func (x *BatchConfigUpdateResponse) GetError() string {
+func (x *RenderConfigTemplateResponse) GetTargetPath() string {
 	if x != nil {
-		return x.Error
+		return x.TargetPath
 	}
 	return ""
 }
 
-type AgentUpdateResult struct {
+type ConfigTemplateVersion struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "success", "failed", "skipped", "rolled_back", "pending"
-	BackupPath    string                 `protobuf:"bytes,4,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
-	ConfigHash    string                 `protobuf:"bytes,5,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`
-	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
-	DurationMs    int32                  `protobuf:"varint,7,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
-	CompletedAt   int64                  `protobuf:"varint,8,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Variables     []*TemplateVariable    `protobuf:"bytes,4,rep,name=variables,proto3" json:"variables,omitempty"`
+	Defaults      map[string]string      `protobuf:"bytes,5,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedBy     string                 `protobuf:"bytes,6,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentUpdateResult) Reset() {
-	*x = AgentUpdateResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[115]
+func (x *ConfigTemplateVersion) Reset() {
+	*x = ConfigTemplateVersion{}
+	mi := &file_api_proto_agent_proto_msgTypes[165]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentUpdateResult) String() string {
+func (x *ConfigTemplateVersion) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentUpdateResult) ProtoMessage() {}
+func (*ConfigTemplateVersion) ProtoMessage() {}
 
-func (x *AgentUpdateResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[115]
+func (x *ConfigTemplateVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[165]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8558,89 +12934,126 @@ func (x *AgentUpdateResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentUpdateResult.ProtoReflect.Descriptor instead.
-func (*AgentUpdateResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{115}
+// Deprecated: Use ConfigTemplateVersion.ProtoReflect.Descriptor instead.
+func (*ConfigTemplateVersion) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{165}
 }
 
-func (x *AgentUpdateResult) GetAgentId() string {
+func (x *ConfigTemplateVersion) GetTemplateId() string {
 	if x != nil {
-		return x.AgentId
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *AgentUpdateResult) GetHostname() string {
+func (x *ConfigTemplateVersion) GetVersion() int32 {
 	if x != nil {
-		return x.Hostname
+		return x.Version
 	}
-	return ""
+	return 0
 }
 
-func (x *AgentUpdateResult) GetStatus() string {
+func (x *ConfigTemplateVersion) GetContent() string {
 	if x != nil {
-		return x.Status
+		return x.Content
 	}
 	return ""
 }
 
-func (x *AgentUpdateResult) GetBackupPath() string {
+func (x *ConfigTemplateVersion) GetVariables() []*TemplateVariable {
 	if x != nil {
-		return x.BackupPath
+		return x.Variables
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentUpdateResult) GetConfigHash() string {
+func (x *ConfigTemplateVersion) GetDefaults() map[string]string {
 	if x != nil {
-		return x.ConfigHash
+		return x.Defaults
 	}
-	return ""
+	return nil
 }
 
-func (x *AgentUpdateResult) GetError() string {
+func (x *ConfigTemplateVersion) GetCreatedBy() string {
 	if x != nil {
-		return x.Error
+		return x.CreatedBy
 	}
 	return ""
 }
 
-func (x *AgentUpdateResult) GetDurationMs() int32 {
+func (x *ConfigTemplateVersion) GetCreatedAt() int64 {
 	if x != nil {
-		return x.DurationMs
+		return x.CreatedAt
 	}
 	return 0
 }
 
-func (x *AgentUpdateResult) GetCompletedAt() int64 {
+type ListConfigTemplateVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListConfigTemplateVersionsRequest) Reset() {
+	*x = ListConfigTemplateVersionsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[166]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListConfigTemplateVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListConfigTemplateVersionsRequest) ProtoMessage() {}
+
+func (x *ListConfigTemplateVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[166]
 	if x != nil {
-		return x.CompletedAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-type GetBatchStatusRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+// Deprecated: Use ListConfigTemplateVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListConfigTemplateVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{166}
+}
+
+func (x *ListConfigTemplateVersionsRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+type ListConfigTemplateVersionsResponse struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Versions      []*ConfigTemplateVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetBatchStatusRequest) Reset() {
-	*x = GetBatchStatusRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[116]
+func (x *ListConfigTemplateVersionsResponse) Reset() {
+	*x = ListConfigTemplateVersionsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[167]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetBatchStatusRequest) String() string {
+func (x *ListConfigTemplateVersionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetBatchStatusRequest) ProtoMessage() {}
+func (*ListConfigTemplateVersionsResponse) ProtoMessage() {}
 
-func (x *GetBatchStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[116]
+func (x *ListConfigTemplateVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[167]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8651,40 +13064,45 @@ func (x *GetBatchStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetBatchStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetBatchStatusRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{116}
+// Deprecated: Use ListConfigTemplateVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListConfigTemplateVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{167}
 }
 
-func (x *GetBatchStatusRequest) GetBatchId() string {
+func (x *ListConfigTemplateVersionsResponse) GetVersions() []*ConfigTemplateVersion {
 	if x != nil {
-		return x.BatchId
+		return x.Versions
 	}
-	return ""
+	return nil
 }
 
-type CancelBatchRequest struct {
+// Per-environment or per-agent variable values for a template
+type ConfigTemplateVariables struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Scope         string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"` // "environment" or "agent"
+	ScopeId       string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	Variables     map[string]string      `protobuf:"bytes,4,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UpdatedAt     int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CancelBatchRequest) Reset() {
-	*x = CancelBatchRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[117]
+func (x *ConfigTemplateVariables) Reset() {
+	*x = ConfigTemplateVariables{}
+	mi := &file_api_proto_agent_proto_msgTypes[168]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CancelBatchRequest) String() string {
+func (x *ConfigTemplateVariables) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CancelBatchRequest) ProtoMessage() {}
+func (*ConfigTemplateVariables) ProtoMessage() {}
 
-func (x *CancelBatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[117]
+func (x *ConfigTemplateVariables) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[168]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8695,41 +13113,71 @@ func (x *CancelBatchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CancelBatchRequest.ProtoReflect.Descriptor instead.
-func (*CancelBatchRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{117}
+// Deprecated: Use ConfigTemplateVariables.ProtoReflect.Descriptor instead.
+func (*ConfigTemplateVariables) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{168}
 }
 
-func (x *CancelBatchRequest) GetBatchId() string {
+func (x *ConfigTemplateVariables) GetTemplateId() string {
 	if x != nil {
-		return x.BatchId
+		return x.TemplateId
 	}
 	return ""
 }
 
-type CancelBatchResponse struct {
+func (x *ConfigTemplateVariables) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *ConfigTemplateVariables) GetScopeId() string {
+	if x != nil {
+		return x.ScopeId
+	}
+	return ""
+}
+
+func (x *ConfigTemplateVariables) GetVariables() map[string]string {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+func (x *ConfigTemplateVariables) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type SetConfigTemplateVariablesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Scope         string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	ScopeId       string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	Variables     map[string]string      `protobuf:"bytes,4,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Replaces the stored values; empty clears them
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
-}
-
-func (x *CancelBatchResponse) Reset() {
-	*x = CancelBatchResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[118]
+}
+
+func (x *SetConfigTemplateVariablesRequest) Reset() {
+	*x = SetConfigTemplateVariablesRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[169]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CancelBatchResponse) String() string {
+func (x *SetConfigTemplateVariablesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CancelBatchResponse) ProtoMessage() {}
+func (*SetConfigTemplateVariablesRequest) ProtoMessage() {}
 
-func (x *CancelBatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[118]
+func (x *SetConfigTemplateVariablesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[169]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8740,47 +13188,73 @@ func (x *CancelBatchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CancelBatchResponse.ProtoReflect.Descriptor instead.
-func (*CancelBatchResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{118}
+// Deprecated: Use SetConfigTemplateVariablesRequest.ProtoReflect.Descriptor instead.
+func (*SetConfigTemplateVariablesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{169}
 }
 
-func (x *CancelBatchResponse) GetSuccess() bool {
+func (x *SetConfigTemplateVariablesRequest) GetTemplateId() string {
 	if x != nil {
-		return x.Success
+		return x.TemplateId
 	}
-	return false
+	return ""
 }
 
-func (x *CancelBatchResponse) GetMessage() string {
+func (x *SetConfigTemplateVariablesRequest) GetScope() string {
 	if x != nil {
-		return x.Message
+		return x.Scope
 	}
 	return ""
 }
 
-type RollbackBatchRequest struct {
+func (x *SetConfigTemplateVariablesRequest) GetScopeId() string {
+	if x != nil {
+		return x.ScopeId
+	}
+	return ""
+}
+
+func (x *SetConfigTemplateVariablesRequest) GetVariables() map[string]string {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+type MaintenanceTemplate struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	BatchId       string                 `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	HtmlContent   string                 `protobuf:"bytes,5,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
+	CssContent    string                 `protobuf:"bytes,6,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
+	Assets        map[string]string      `protobuf:"bytes,7,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // filename -> base64 content
+	Variables     []*TemplateVariable    `protobuf:"bytes,8,rep,name=variables,proto3" json:"variables,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,9,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	IsBuiltIn     bool                   `protobuf:"varint,10,opt,name=is_built_in,json=isBuiltIn,proto3" json:"is_built_in,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,11,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RollbackBatchRequest) Reset() {
-	*x = RollbackBatchRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[119]
+func (x *MaintenanceTemplate) Reset() {
+	*x = MaintenanceTemplate{}
+	mi := &file_api_proto_agent_proto_msgTypes[170]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RollbackBatchRequest) String() string {
+func (x *MaintenanceTemplate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RollbackBatchRequest) ProtoMessage() {}
+func (*MaintenanceTemplate) ProtoMessage() {}
 
-func (x *RollbackBatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[119]
+func (x *MaintenanceTemplate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[170]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8791,114 +13265,141 @@ func (x *RollbackBatchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RollbackBatchRequest.ProtoReflect.Descriptor instead.
-func (*RollbackBatchRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{119}
+// Deprecated: Use MaintenanceTemplate.ProtoReflect.Descriptor instead.
+func (*MaintenanceTemplate) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{170}
 }
 
-func (x *RollbackBatchRequest) GetBatchId() string {
+func (x *MaintenanceTemplate) GetId() string {
 	if x != nil {
-		return x.BatchId
+		return x.Id
 	}
 	return ""
 }
 
-type RollbackBatchResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Results       []*AgentUpdateResult   `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *MaintenanceTemplate) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
 }
 
-func (x *RollbackBatchResponse) Reset() {
-	*x = RollbackBatchResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[120]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *MaintenanceTemplate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-func (x *RollbackBatchResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *MaintenanceTemplate) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
 }
 
-func (*RollbackBatchResponse) ProtoMessage() {}
+func (x *MaintenanceTemplate) GetHtmlContent() string {
+	if x != nil {
+		return x.HtmlContent
+	}
+	return ""
+}
 
-func (x *RollbackBatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[120]
+func (x *MaintenanceTemplate) GetCssContent() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.CssContent
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use RollbackBatchResponse.ProtoReflect.Descriptor instead.
-func (*RollbackBatchResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{120}
+func (x *MaintenanceTemplate) GetAssets() map[string]string {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
 }
 
-func (x *RollbackBatchResponse) GetSuccess() bool {
+func (x *MaintenanceTemplate) GetVariables() []*TemplateVariable {
 	if x != nil {
-		return x.Success
+		return x.Variables
+	}
+	return nil
+}
+
+func (x *MaintenanceTemplate) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
 	}
 	return false
 }
 
-func (x *RollbackBatchResponse) GetMessage() string {
+func (x *MaintenanceTemplate) GetIsBuiltIn() bool {
 	if x != nil {
-		return x.Message
+		return x.IsBuiltIn
+	}
+	return false
+}
+
+func (x *MaintenanceTemplate) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
 	}
 	return ""
 }
 
-func (x *RollbackBatchResponse) GetResults() []*AgentUpdateResult {
+func (x *MaintenanceTemplate) GetCreatedAt() int64 {
 	if x != nil {
-		return x.Results
+		return x.CreatedAt
 	}
-	return nil
+	return 0
 }
 
-type ConfigTemplate struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	EnvironmentId string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	GroupId       string                 `protobuf:"bytes,4,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
-	TemplateType  string                 `protobuf:"bytes,7,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"` // 'nginx_main_conf', 'server_block', 'location_block'
-	Content       string                 `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
-	Variables     []*TemplateVariable    `protobuf:"bytes,9,rep,name=variables,proto3" json:"variables,omitempty"`
-	Defaults      map[string]string      `protobuf:"bytes,10,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Version       int32                  `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
-	IsActive      bool                   `protobuf:"varint,12,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
-	CreatedBy     string                 `protobuf:"bytes,13,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
-	CreatedAt     int64                  `protobuf:"varint,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     int64                  `protobuf:"varint,15,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *MaintenanceTemplate) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
 }
 
-func (x *ConfigTemplate) Reset() {
-	*x = ConfigTemplate{}
-	mi := &file_api_proto_agent_proto_msgTypes[121]
+type MaintenanceState struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Scope          string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"` // "agent", "group", "environment", "project", "site", "location"
+	ScopeId        string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	SiteFilter     string                 `protobuf:"bytes,4,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
+	LocationFilter string                 `protobuf:"bytes,5,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
+	TemplateId     string                 `protobuf:"bytes,6,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	TemplateVars   map[string]string      `protobuf:"bytes,7,rep,name=template_vars,json=templateVars,proto3" json:"template_vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IsEnabled      bool                   `protobuf:"varint,8,opt,name=is_enabled,json=isEnabled,proto3" json:"is_enabled,omitempty"`
+	EnabledAt      int64                  `protobuf:"varint,9,opt,name=enabled_at,json=enabledAt,proto3" json:"enabled_at,omitempty"`
+	EnabledBy      string                 `protobuf:"bytes,10,opt,name=enabled_by,json=enabledBy,proto3" json:"enabled_by,omitempty"`
+	ScheduleType   string                 `protobuf:"bytes,11,opt,name=schedule_type,json=scheduleType,proto3" json:"schedule_type,omitempty"` // "immediate", "scheduled", "recurring"
+	ScheduledStart int64                  `protobuf:"varint,12,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"`
+	ScheduledEnd   int64                  `protobuf:"varint,13,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`
+	RecurrenceRule string                 `protobuf:"bytes,14,opt,name=recurrence_rule,json=recurrenceRule,proto3" json:"recurrence_rule,omitempty"`
+	Timezone       string                 `protobuf:"bytes,15,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	BypassIps      []string               `protobuf:"bytes,16,rep,name=bypass_ips,json=bypassIps,proto3" json:"bypass_ips,omitempty"`
+	BypassHeaders  map[string]string      `protobuf:"bytes,17,rep,name=bypass_headers,json=bypassHeaders,proto3" json:"bypass_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Reason         string                 `protobuf:"bytes,18,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MaintenanceState) Reset() {
+	*x = MaintenanceState{}
+	mi := &file_api_proto_agent_proto_msgTypes[171]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigTemplate) String() string {
+func (x *MaintenanceState) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigTemplate) ProtoMessage() {}
+func (*MaintenanceState) ProtoMessage() {}
 
-func (x *ConfigTemplate) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[121]
+func (x *MaintenanceState) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[171]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8909,143 +13410,173 @@ func (x *ConfigTemplate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigTemplate.ProtoReflect.Descriptor instead.
-func (*ConfigTemplate) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{121}
+// Deprecated: Use MaintenanceState.ProtoReflect.Descriptor instead.
+func (*MaintenanceState) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{171}
 }
 
-func (x *ConfigTemplate) GetId() string {
+func (x *MaintenanceState) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetProjectId() string {
+func (x *MaintenanceState) GetScope() string {
 	if x != nil {
-		return x.ProjectId
+		return x.Scope
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetEnvironmentId() string {
+func (x *MaintenanceState) GetScopeId() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.ScopeId
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetGroupId() string {
+func (x *MaintenanceState) GetSiteFilter() string {
 	if x != nil {
-		return x.GroupId
+		return x.SiteFilter
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetName() string {
+func (x *MaintenanceState) GetLocationFilter() string {
 	if x != nil {
-		return x.Name
+		return x.LocationFilter
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetDescription() string {
+func (x *MaintenanceState) GetTemplateId() string {
 	if x != nil {
-		return x.Description
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *MaintenanceState) GetTemplateVars() map[string]string {
+	if x != nil {
+		return x.TemplateVars
+	}
+	return nil
+}
+
+func (x *MaintenanceState) GetIsEnabled() bool {
+	if x != nil {
+		return x.IsEnabled
+	}
+	return false
+}
+
+func (x *MaintenanceState) GetEnabledAt() int64 {
+	if x != nil {
+		return x.EnabledAt
 	}
-	return ""
+	return 0
 }
 
-func (x *ConfigTemplate) GetTemplateType() string {
+func (x *MaintenanceState) GetEnabledBy() string {
 	if x != nil {
-		return x.TemplateType
+		return x.EnabledBy
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetContent() string {
+func (x *MaintenanceState) GetScheduleType() string {
 	if x != nil {
-		return x.Content
+		return x.ScheduleType
 	}
 	return ""
 }
 
-func (x *ConfigTemplate) GetVariables() []*TemplateVariable {
+func (x *MaintenanceState) GetScheduledStart() int64 {
 	if x != nil {
-		return x.Variables
+		return x.ScheduledStart
 	}
-	return nil
+	return 0
 }
 
-func (x *ConfigTemplate) GetDefaults() map[string]string {
+func (x *MaintenanceState) GetScheduledEnd() int64 {
 	if x != nil {
-		return x.Defaults
+		return x.ScheduledEnd
 	}
-	return nil
+	return 0
 }
 
-func (x *ConfigTemplate) GetVersion() int32 {
+func (x *MaintenanceState) GetRecurrenceRule() string {
 	if x != nil {
-		return x.Version
+		return x.RecurrenceRule
 	}
-	return 0
+	return ""
 }
 
-func (x *ConfigTemplate) GetIsActive() bool {
+func (x *MaintenanceState) GetTimezone() string {
 	if x != nil {
-		return x.IsActive
+		return x.Timezone
 	}
-	return false
+	return ""
 }
 
-func (x *ConfigTemplate) GetCreatedBy() string {
+func (x *MaintenanceState) GetBypassIps() []string {
 	if x != nil {
-		return x.CreatedBy
+		return x.BypassIps
 	}
-	return ""
+	return nil
 }
 
-func (x *ConfigTemplate) GetCreatedAt() int64 {
+func (x *MaintenanceState) GetBypassHeaders() map[string]string {
 	if x != nil {
-		return x.CreatedAt
+		return x.BypassHeaders
 	}
-	return 0
+	return nil
 }
 
-func (x *ConfigTemplate) GetUpdatedAt() int64 {
+func (x *MaintenanceState) GetReason() string {
 	if x != nil {
-		return x.UpdatedAt
+		return x.Reason
 	}
-	return 0
+	return ""
 }
 
-type TemplateVariable struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
-	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
-	DefaultValue  string                 `protobuf:"bytes,4,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
-	Validation    string                 `protobuf:"bytes,5,opt,name=validation,proto3" json:"validation,omitempty"` // Regex pattern
-	Options       []string               `protobuf:"bytes,6,rep,name=options,proto3" json:"options,omitempty"`       // For enum types
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SetMaintenanceRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Action         string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"` // "enable", "disable", "schedule", "cancel_schedule"
+	Scope          string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	ScopeId        string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	SiteFilter     string                 `protobuf:"bytes,4,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
+	LocationFilter string                 `protobuf:"bytes,5,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
+	TemplateId     string                 `protobuf:"bytes,6,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	TemplateVars   map[string]string      `protobuf:"bytes,7,rep,name=template_vars,json=templateVars,proto3" json:"template_vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ScheduleType   string                 `protobuf:"bytes,8,opt,name=schedule_type,json=scheduleType,proto3" json:"schedule_type,omitempty"`
+	ScheduledStart int64                  `protobuf:"varint,9,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"`
+	ScheduledEnd   int64                  `protobuf:"varint,10,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`
+	RecurrenceRule string                 `protobuf:"bytes,11,opt,name=recurrence_rule,json=recurrenceRule,proto3" json:"recurrence_rule,omitempty"`
+	Timezone       string                 `protobuf:"bytes,12,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	BypassIps      []string               `protobuf:"bytes,13,rep,name=bypass_ips,json=bypassIps,proto3" json:"bypass_ips,omitempty"`
+	BypassHeaders  map[string]string      `protobuf:"bytes,14,rep,name=bypass_headers,json=bypassHeaders,proto3" json:"bypass_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Reason         string                 `protobuf:"bytes,15,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *TemplateVariable) Reset() {
-	*x = TemplateVariable{}
-	mi := &file_api_proto_agent_proto_msgTypes[122]
+func (x *SetMaintenanceRequest) Reset() {
+	*x = SetMaintenanceRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[172]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TemplateVariable) String() string {
+func (x *SetMaintenanceRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TemplateVariable) ProtoMessage() {}
+func (*SetMaintenanceRequest) ProtoMessage() {}
 
-func (x *TemplateVariable) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[122]
+func (x *SetMaintenanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[172]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9056,187 +13587,141 @@ func (x *TemplateVariable) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TemplateVariable.ProtoReflect.Descriptor instead.
-func (*TemplateVariable) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{122}
+// Deprecated: Use SetMaintenanceRequest.ProtoReflect.Descriptor instead.
+func (*SetMaintenanceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{172}
 }
 
-func (x *TemplateVariable) GetName() string {
+func (x *SetMaintenanceRequest) GetAction() string {
 	if x != nil {
-		return x.Name
+		return x.Action
 	}
 	return ""
 }
 
-func (x *TemplateVariable) GetDescription() string {
+func (x *SetMaintenanceRequest) GetScope() string {
 	if x != nil {
-		return x.Description
+		return x.Scope
 	}
 	return ""
 }
 
-func (x *TemplateVariable) GetRequired() bool {
+func (x *SetMaintenanceRequest) GetScopeId() string {
 	if x != nil {
-		return x.Required
+		return x.ScopeId
 	}
-	return false
+	return ""
 }
 
-func (x *TemplateVariable) GetDefaultValue() string {
+func (x *SetMaintenanceRequest) GetSiteFilter() string {
 	if x != nil {
-		return x.DefaultValue
+		return x.SiteFilter
 	}
 	return ""
 }
 
-func (x *TemplateVariable) GetValidation() string {
+func (x *SetMaintenanceRequest) GetLocationFilter() string {
 	if x != nil {
-		return x.Validation
+		return x.LocationFilter
 	}
 	return ""
 }
 
-func (x *TemplateVariable) GetOptions() []string {
+func (x *SetMaintenanceRequest) GetTemplateId() string {
 	if x != nil {
-		return x.Options
+		return x.TemplateId
 	}
-	return nil
-}
-
-type ListConfigTemplatesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	TemplateType  string                 `protobuf:"bytes,4,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListConfigTemplatesRequest) Reset() {
-	*x = ListConfigTemplatesRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[123]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListConfigTemplatesRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*ListConfigTemplatesRequest) ProtoMessage() {}
-
-func (x *ListConfigTemplatesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[123]
+func (x *SetMaintenanceRequest) GetTemplateVars() map[string]string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.TemplateVars
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use ListConfigTemplatesRequest.ProtoReflect.Descriptor instead.
-func (*ListConfigTemplatesRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{123}
+	return nil
 }
 
-func (x *ListConfigTemplatesRequest) GetProjectId() string {
+func (x *SetMaintenanceRequest) GetScheduleType() string {
 	if x != nil {
-		return x.ProjectId
+		return x.ScheduleType
 	}
 	return ""
 }
 
-func (x *ListConfigTemplatesRequest) GetEnvironmentId() string {
+func (x *SetMaintenanceRequest) GetScheduledStart() int64 {
 	if x != nil {
-		return x.EnvironmentId
+		return x.ScheduledStart
 	}
-	return ""
+	return 0
 }
 
-func (x *ListConfigTemplatesRequest) GetGroupId() string {
+func (x *SetMaintenanceRequest) GetScheduledEnd() int64 {
 	if x != nil {
-		return x.GroupId
+		return x.ScheduledEnd
 	}
-	return ""
+	return 0
 }
 
-func (x *ListConfigTemplatesRequest) GetTemplateType() string {
+func (x *SetMaintenanceRequest) GetRecurrenceRule() string {
 	if x != nil {
-		return x.TemplateType
+		return x.RecurrenceRule
 	}
 	return ""
 }
 
-type ListConfigTemplatesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Templates     []*ConfigTemplate      `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListConfigTemplatesResponse) Reset() {
-	*x = ListConfigTemplatesResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[124]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListConfigTemplatesResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SetMaintenanceRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
 }
 
-func (*ListConfigTemplatesResponse) ProtoMessage() {}
-
-func (x *ListConfigTemplatesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[124]
+func (x *SetMaintenanceRequest) GetBypassIps() []string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.BypassIps
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ListConfigTemplatesResponse.ProtoReflect.Descriptor instead.
-func (*ListConfigTemplatesResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{124}
+func (x *SetMaintenanceRequest) GetBypassHeaders() map[string]string {
+	if x != nil {
+		return x.BypassHeaders
+	}
+	return nil
 }
 
-func (x *ListConfigTemplatesResponse) GetTemplates() []*ConfigTemplate {
+func (x *SetMaintenanceRequest) GetReason() string {
 	if x != nil {
-		return x.Templates
+		return x.Reason
 	}
-	return nil
+	return ""
 }
 
-type GetConfigTemplateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SetMaintenanceResponse struct {
+	state              protoimpl.MessageState    `protogen:"open.v1"`
+	Success            bool                      `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	MaintenanceStateId string                    `protobuf:"bytes,2,opt,name=maintenance_state_id,json=maintenanceStateId,proto3" json:"maintenance_state_id,omitempty"`
+	Results            []*AgentMaintenanceResult `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	Error              string                    `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
-func (x *GetConfigTemplateRequest) Reset() {
-	*x = GetConfigTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[125]
+func (x *SetMaintenanceResponse) Reset() {
+	*x = SetMaintenanceResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[173]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetConfigTemplateRequest) String() string {
+func (x *SetMaintenanceResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetConfigTemplateRequest) ProtoMessage() {}
+func (*SetMaintenanceResponse) ProtoMessage() {}
 
-func (x *GetConfigTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[125]
+func (x *SetMaintenanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[173]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9247,48 +13732,64 @@ func (x *GetConfigTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetConfigTemplateRequest.ProtoReflect.Descriptor instead.
-func (*GetConfigTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{125}
+// Deprecated: Use SetMaintenanceResponse.ProtoReflect.Descriptor instead.
+func (*SetMaintenanceResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{173}
 }
 
-func (x *GetConfigTemplateRequest) GetTemplateId() string {
+func (x *SetMaintenanceResponse) GetSuccess() bool {
 	if x != nil {
-		return x.TemplateId
+		return x.Success
+	}
+	return false
+}
+
+func (x *SetMaintenanceResponse) GetMaintenanceStateId() string {
+	if x != nil {
+		return x.MaintenanceStateId
 	}
 	return ""
 }
 
-type CreateConfigTemplateRequest struct {
+func (x *SetMaintenanceResponse) GetResults() []*AgentMaintenanceResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SetMaintenanceResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type AgentMaintenanceResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	GroupId       string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
-	TemplateType  string                 `protobuf:"bytes,6,opt,name=template_type,json=templateType,proto3" json:"template_type,omitempty"`
-	Content       string                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`
-	Variables     []*TemplateVariable    `protobuf:"bytes,8,rep,name=variables,proto3" json:"variables,omitempty"`
-	Defaults      map[string]string      `protobuf:"bytes,9,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateConfigTemplateRequest) Reset() {
-	*x = CreateConfigTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[126]
+func (x *AgentMaintenanceResult) Reset() {
+	*x = AgentMaintenanceResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[174]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateConfigTemplateRequest) String() string {
+func (x *AgentMaintenanceResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateConfigTemplateRequest) ProtoMessage() {}
+func (*AgentMaintenanceResult) ProtoMessage() {}
 
-func (x *CreateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[126]
+func (x *AgentMaintenanceResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[174]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9299,102 +13800,131 @@ func (x *CreateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateConfigTemplateRequest.ProtoReflect.Descriptor instead.
-func (*CreateConfigTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{126}
+// Deprecated: Use AgentMaintenanceResult.ProtoReflect.Descriptor instead.
+func (*AgentMaintenanceResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{174}
 }
 
-func (x *CreateConfigTemplateRequest) GetProjectId() string {
+func (x *AgentMaintenanceResult) GetAgentId() string {
 	if x != nil {
-		return x.ProjectId
+		return x.AgentId
 	}
 	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetEnvironmentId() string {
+func (x *AgentMaintenanceResult) GetHostname() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.Hostname
 	}
 	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetGroupId() string {
+func (x *AgentMaintenanceResult) GetSuccess() bool {
 	if x != nil {
-		return x.GroupId
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *CreateConfigTemplateRequest) GetName() string {
+func (x *AgentMaintenanceResult) GetError() string {
 	if x != nil {
-		return x.Name
+		return x.Error
 	}
 	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetDescription() string {
+type GetMaintenanceStatusRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Scope          string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	ScopeId        string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
+	SiteFilter     string                 `protobuf:"bytes,3,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
+	LocationFilter string                 `protobuf:"bytes,4,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetMaintenanceStatusRequest) Reset() {
+	*x = GetMaintenanceStatusRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[175]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMaintenanceStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMaintenanceStatusRequest) ProtoMessage() {}
+
+func (x *GetMaintenanceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[175]
 	if x != nil {
-		return x.Description
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *CreateConfigTemplateRequest) GetTemplateType() string {
+// Deprecated: Use GetMaintenanceStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetMaintenanceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{175}
+}
+
+func (x *GetMaintenanceStatusRequest) GetScope() string {
 	if x != nil {
-		return x.TemplateType
+		return x.Scope
 	}
 	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetContent() string {
+func (x *GetMaintenanceStatusRequest) GetScopeId() string {
 	if x != nil {
-		return x.Content
+		return x.ScopeId
 	}
 	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetVariables() []*TemplateVariable {
+func (x *GetMaintenanceStatusRequest) GetSiteFilter() string {
 	if x != nil {
-		return x.Variables
+		return x.SiteFilter
 	}
-	return nil
+	return ""
 }
 
-func (x *CreateConfigTemplateRequest) GetDefaults() map[string]string {
+func (x *GetMaintenanceStatusRequest) GetLocationFilter() string {
 	if x != nil {
-		return x.Defaults
+		return x.LocationFilter
 	}
-	return nil
+	return ""
 }
 
-type UpdateConfigTemplateRequest struct {
+type ListMaintenanceStatesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Content       string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
-	Variables     []*TemplateVariable    `protobuf:"bytes,5,rep,name=variables,proto3" json:"variables,omitempty"`
-	Defaults      map[string]string      `protobuf:"bytes,6,rep,name=defaults,proto3" json:"defaults,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	IsActive      bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	EnabledOnly   bool                   `protobuf:"varint,3,opt,name=enabled_only,json=enabledOnly,proto3" json:"enabled_only,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateConfigTemplateRequest) Reset() {
-	*x = UpdateConfigTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[127]
+func (x *ListMaintenanceStatesRequest) Reset() {
+	*x = ListMaintenanceStatesRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[176]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateConfigTemplateRequest) String() string {
+func (x *ListMaintenanceStatesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateConfigTemplateRequest) ProtoMessage() {}
+func (*ListMaintenanceStatesRequest) ProtoMessage() {}
 
-func (x *UpdateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[127]
+func (x *ListMaintenanceStatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[176]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9405,82 +13935,98 @@ func (x *UpdateConfigTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateConfigTemplateRequest.ProtoReflect.Descriptor instead.
-func (*UpdateConfigTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{127}
+// Deprecated: Use ListMaintenanceStatesRequest.ProtoReflect.Descriptor instead.
+func (*ListMaintenanceStatesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{176}
 }
 
-func (x *UpdateConfigTemplateRequest) GetTemplateId() string {
+func (x *ListMaintenanceStatesRequest) GetProjectId() string {
 	if x != nil {
-		return x.TemplateId
+		return x.ProjectId
 	}
 	return ""
 }
 
-func (x *UpdateConfigTemplateRequest) GetName() string {
+func (x *ListMaintenanceStatesRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.Name
+		return x.EnvironmentId
 	}
 	return ""
 }
 
-func (x *UpdateConfigTemplateRequest) GetDescription() string {
+func (x *ListMaintenanceStatesRequest) GetEnabledOnly() bool {
 	if x != nil {
-		return x.Description
+		return x.EnabledOnly
 	}
-	return ""
+	return false
 }
 
-func (x *UpdateConfigTemplateRequest) GetContent() string {
-	if x != nil {
-		return x.Content
-	}
-	return ""
+type ListMaintenanceStatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	States        []*MaintenanceState    `protobuf:"bytes,1,rep,name=states,proto3" json:"states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateConfigTemplateRequest) GetVariables() []*TemplateVariable {
-	if x != nil {
-		return x.Variables
-	}
-	return nil
+func (x *ListMaintenanceStatesResponse) Reset() {
+	*x = ListMaintenanceStatesResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[177]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateConfigTemplateRequest) GetDefaults() map[string]string {
+func (x *ListMaintenanceStatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMaintenanceStatesResponse) ProtoMessage() {}
+
+func (x *ListMaintenanceStatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[177]
 	if x != nil {
-		return x.Defaults
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *UpdateConfigTemplateRequest) GetIsActive() bool {
+// Deprecated: Use ListMaintenanceStatesResponse.ProtoReflect.Descriptor instead.
+func (*ListMaintenanceStatesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{177}
+}
+
+func (x *ListMaintenanceStatesResponse) GetStates() []*MaintenanceState {
 	if x != nil {
-		return x.IsActive
+		return x.States
 	}
-	return false
+	return nil
 }
 
-type DeleteConfigTemplateRequest struct {
+type ListMaintenanceTemplatesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteConfigTemplateRequest) Reset() {
-	*x = DeleteConfigTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[128]
+func (x *ListMaintenanceTemplatesRequest) Reset() {
+	*x = ListMaintenanceTemplatesRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[178]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteConfigTemplateRequest) String() string {
+func (x *ListMaintenanceTemplatesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteConfigTemplateRequest) ProtoMessage() {}
+func (*ListMaintenanceTemplatesRequest) ProtoMessage() {}
 
-func (x *DeleteConfigTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[128]
+func (x *ListMaintenanceTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[178]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9491,41 +14037,40 @@ func (x *DeleteConfigTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteConfigTemplateRequest.ProtoReflect.Descriptor instead.
-func (*DeleteConfigTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{128}
+// Deprecated: Use ListMaintenanceTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListMaintenanceTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{178}
 }
 
-func (x *DeleteConfigTemplateRequest) GetTemplateId() string {
+func (x *ListMaintenanceTemplatesRequest) GetProjectId() string {
 	if x != nil {
-		return x.TemplateId
+		return x.ProjectId
 	}
 	return ""
 }
 
-type DeleteConfigTemplateResponse struct {
+type ListMaintenanceTemplatesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Templates     []*MaintenanceTemplate `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteConfigTemplateResponse) Reset() {
-	*x = DeleteConfigTemplateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[129]
+func (x *ListMaintenanceTemplatesResponse) Reset() {
+	*x = ListMaintenanceTemplatesResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[179]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteConfigTemplateResponse) String() string {
+func (x *ListMaintenanceTemplatesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteConfigTemplateResponse) ProtoMessage() {}
+func (*ListMaintenanceTemplatesResponse) ProtoMessage() {}
 
-func (x *DeleteConfigTemplateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[129]
+func (x *ListMaintenanceTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[179]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9536,48 +14081,47 @@ func (x *DeleteConfigTemplateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteConfigTemplateResponse.ProtoReflect.Descriptor instead.
-func (*DeleteConfigTemplateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{129}
-}
-
-func (x *DeleteConfigTemplateResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
+// Deprecated: Use ListMaintenanceTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListMaintenanceTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{179}
 }
 
-func (x *DeleteConfigTemplateResponse) GetMessage() string {
+func (x *ListMaintenanceTemplatesResponse) GetTemplates() []*MaintenanceTemplate {
 	if x != nil {
-		return x.Message
+		return x.Templates
 	}
-	return ""
+	return nil
 }
 
-type RenderConfigTemplateRequest struct {
+type CreateMaintenanceTemplateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	Variables     map[string]string      `protobuf:"bytes,2,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	HtmlContent   string                 `protobuf:"bytes,4,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
+	CssContent    string                 `protobuf:"bytes,5,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
+	Assets        map[string]string      `protobuf:"bytes,6,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Variables     []*TemplateVariable    `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,8,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RenderConfigTemplateRequest) Reset() {
-	*x = RenderConfigTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[130]
+func (x *CreateMaintenanceTemplateRequest) Reset() {
+	*x = CreateMaintenanceTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[180]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RenderConfigTemplateRequest) String() string {
+func (x *CreateMaintenanceTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RenderConfigTemplateRequest) ProtoMessage() {}
+func (*CreateMaintenanceTemplateRequest) ProtoMessage() {}
 
-func (x *RenderConfigTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[130]
+func (x *CreateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[180]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9588,119 +14132,96 @@ func (x *RenderConfigTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RenderConfigTemplateRequest.ProtoReflect.Descriptor instead.
-func (*RenderConfigTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{130}
+// Deprecated: Use CreateMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
+func (*CreateMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{180}
 }
 
-func (x *RenderConfigTemplateRequest) GetTemplateId() string {
+func (x *CreateMaintenanceTemplateRequest) GetProjectId() string {
 	if x != nil {
-		return x.TemplateId
+		return x.ProjectId
 	}
 	return ""
 }
 
-func (x *RenderConfigTemplateRequest) GetVariables() map[string]string {
+func (x *CreateMaintenanceTemplateRequest) GetName() string {
 	if x != nil {
-		return x.Variables
+		return x.Name
 	}
-	return nil
-}
-
-type RenderConfigTemplateResponse struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	RenderedContent  string                 `protobuf:"bytes,1,opt,name=rendered_content,json=renderedContent,proto3" json:"rendered_content,omitempty"`
-	Valid            bool                   `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
-	ValidationErrors []string               `protobuf:"bytes,3,rep,name=validation_errors,json=validationErrors,proto3" json:"validation_errors,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
-}
-
-func (x *RenderConfigTemplateResponse) Reset() {
-	*x = RenderConfigTemplateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[131]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *RenderConfigTemplateResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*RenderConfigTemplateResponse) ProtoMessage() {}
-
-func (x *RenderConfigTemplateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[131]
+func (x *CreateMaintenanceTemplateRequest) GetDescription() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Description
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use RenderConfigTemplateResponse.ProtoReflect.Descriptor instead.
-func (*RenderConfigTemplateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{131}
+func (x *CreateMaintenanceTemplateRequest) GetHtmlContent() string {
+	if x != nil {
+		return x.HtmlContent
+	}
+	return ""
 }
 
-func (x *RenderConfigTemplateResponse) GetRenderedContent() string {
+func (x *CreateMaintenanceTemplateRequest) GetCssContent() string {
 	if x != nil {
-		return x.RenderedContent
+		return x.CssContent
 	}
 	return ""
 }
 
-func (x *RenderConfigTemplateResponse) GetValid() bool {
+func (x *CreateMaintenanceTemplateRequest) GetAssets() map[string]string {
 	if x != nil {
-		return x.Valid
+		return x.Assets
 	}
-	return false
+	return nil
 }
 
-func (x *RenderConfigTemplateResponse) GetValidationErrors() []string {
+func (x *CreateMaintenanceTemplateRequest) GetVariables() []*TemplateVariable {
 	if x != nil {
-		return x.ValidationErrors
+		return x.Variables
 	}
 	return nil
 }
 
-type MaintenanceTemplate struct {
+func (x *CreateMaintenanceTemplateRequest) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+type UpdateMaintenanceTemplateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	HtmlContent   string                 `protobuf:"bytes,5,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
-	CssContent    string                 `protobuf:"bytes,6,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
-	Assets        map[string]string      `protobuf:"bytes,7,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // filename -> base64 content
-	Variables     []*TemplateVariable    `protobuf:"bytes,8,rep,name=variables,proto3" json:"variables,omitempty"`
-	IsDefault     bool                   `protobuf:"varint,9,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
-	IsBuiltIn     bool                   `protobuf:"varint,10,opt,name=is_built_in,json=isBuiltIn,proto3" json:"is_built_in,omitempty"`
-	CreatedBy     string                 `protobuf:"bytes,11,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
-	CreatedAt     int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	HtmlContent   string                 `protobuf:"bytes,4,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
+	CssContent    string                 `protobuf:"bytes,5,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
+	Assets        map[string]string      `protobuf:"bytes,6,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Variables     []*TemplateVariable    `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,8,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MaintenanceTemplate) Reset() {
-	*x = MaintenanceTemplate{}
-	mi := &file_api_proto_agent_proto_msgTypes[132]
+func (x *UpdateMaintenanceTemplateRequest) Reset() {
+	*x = UpdateMaintenanceTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[181]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MaintenanceTemplate) String() string {
+func (x *UpdateMaintenanceTemplateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MaintenanceTemplate) ProtoMessage() {}
+func (*UpdateMaintenanceTemplateRequest) ProtoMessage() {}
 
-func (x *MaintenanceTemplate) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[132]
+func (x *UpdateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[181]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9711,141 +14232,134 @@ func (x *MaintenanceTemplate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MaintenanceTemplate.ProtoReflect.Descriptor instead.
-func (*MaintenanceTemplate) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{132}
-}
-
-func (x *MaintenanceTemplate) GetId() string {
-	if x != nil {
-		return x.Id
-	}
-	return ""
+// Deprecated: Use UpdateMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{181}
 }
 
-func (x *MaintenanceTemplate) GetProjectId() string {
+func (x *UpdateMaintenanceTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.ProjectId
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *MaintenanceTemplate) GetName() string {
+func (x *UpdateMaintenanceTemplateRequest) GetName() string {
 	if x != nil {
 		return x.Name
 	}
 	return ""
 }
 
-func (x *MaintenanceTemplate) GetDescription() string {
+func (x *UpdateMaintenanceTemplateRequest) GetDescription() string {
 	if x != nil {
 		return x.Description
 	}
 	return ""
 }
 
-func (x *MaintenanceTemplate) GetHtmlContent() string {
+func (x *UpdateMaintenanceTemplateRequest) GetHtmlContent() string {
 	if x != nil {
 		return x.HtmlContent
 	}
 	return ""
 }
 
-func (x *MaintenanceTemplate) GetCssContent() string {
+func (x *UpdateMaintenanceTemplateRequest) GetCssContent() string {
 	if x != nil {
 		return x.CssContent
 	}
 	return ""
 }
 
-func (x *MaintenanceTemplate) GetAssets() map[string]string {
+func (x *UpdateMaintenanceTemplateRequest) GetAssets() map[string]string {
 	if x != nil {
 		return x.Assets
 	}
 	return nil
 }
 
-func (x *MaintenanceTemplate) GetVariables() []*TemplateVariable {
+func (x *UpdateMaintenanceTemplateRequest) GetVariables() []*TemplateVariable {
 	if x != nil {
 		return x.Variables
 	}
 	return nil
 }
 
-func (x *MaintenanceTemplate) GetIsDefault() bool {
+func (x *UpdateMaintenanceTemplateRequest) GetIsDefault() bool {
 	if x != nil {
 		return x.IsDefault
 	}
 	return false
 }
 
-func (x *MaintenanceTemplate) GetIsBuiltIn() bool {
-	if x != nil {
-		return x.IsBuiltIn
-	}
-	return false
+type DeleteMaintenanceTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MaintenanceTemplate) GetCreatedBy() string {
-	if x != nil {
-		return x.CreatedBy
-	}
-	return ""
+func (x *DeleteMaintenanceTemplateRequest) Reset() {
+	*x = DeleteMaintenanceTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[182]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *MaintenanceTemplate) GetCreatedAt() int64 {
-	if x != nil {
-		return x.CreatedAt
-	}
-	return 0
+func (x *DeleteMaintenanceTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *MaintenanceTemplate) GetUpdatedAt() int64 {
+func (*DeleteMaintenanceTemplateRequest) ProtoMessage() {}
+
+func (x *DeleteMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[182]
 	if x != nil {
-		return x.UpdatedAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-type MaintenanceState struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Scope          string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"` // "agent", "group", "environment", "project", "site", "location"
-	ScopeId        string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
-	SiteFilter     string                 `protobuf:"bytes,4,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
-	LocationFilter string                 `protobuf:"bytes,5,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
-	TemplateId     string                 `protobuf:"bytes,6,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	TemplateVars   map[string]string      `protobuf:"bytes,7,rep,name=template_vars,json=templateVars,proto3" json:"template_vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	IsEnabled      bool                   `protobuf:"varint,8,opt,name=is_enabled,json=isEnabled,proto3" json:"is_enabled,omitempty"`
-	EnabledAt      int64                  `protobuf:"varint,9,opt,name=enabled_at,json=enabledAt,proto3" json:"enabled_at,omitempty"`
-	EnabledBy      string                 `protobuf:"bytes,10,opt,name=enabled_by,json=enabledBy,proto3" json:"enabled_by,omitempty"`
-	ScheduleType   string                 `protobuf:"bytes,11,opt,name=schedule_type,json=scheduleType,proto3" json:"schedule_type,omitempty"` // "immediate", "scheduled", "recurring"
-	ScheduledStart int64                  `protobuf:"varint,12,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"`
-	ScheduledEnd   int64                  `protobuf:"varint,13,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`
-	RecurrenceRule string                 `protobuf:"bytes,14,opt,name=recurrence_rule,json=recurrenceRule,proto3" json:"recurrence_rule,omitempty"`
-	Timezone       string                 `protobuf:"bytes,15,opt,name=timezone,proto3" json:"timezone,omitempty"`
-	BypassIps      []string               `protobuf:"bytes,16,rep,name=bypass_ips,json=bypassIps,proto3" json:"bypass_ips,omitempty"`
-	BypassHeaders  map[string]string      `protobuf:"bytes,17,rep,name=bypass_headers,json=bypassHeaders,proto3" json:"bypass_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Reason         string                 `protobuf:"bytes,18,opt,name=reason,proto3" json:"reason,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// Deprecated: Use DeleteMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{182}
+}
+
+func (x *DeleteMaintenanceTemplateRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
 }
 
-func (x *MaintenanceState) Reset() {
-	*x = MaintenanceState{}
-	mi := &file_api_proto_agent_proto_msgTypes[133]
+type DeleteMaintenanceTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMaintenanceTemplateResponse) Reset() {
+	*x = DeleteMaintenanceTemplateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[183]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MaintenanceState) String() string {
+func (x *DeleteMaintenanceTemplateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MaintenanceState) ProtoMessage() {}
+func (*DeleteMaintenanceTemplateResponse) ProtoMessage() {}
 
-func (x *MaintenanceState) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[133]
+func (x *DeleteMaintenanceTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[183]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -9856,173 +14370,155 @@ func (x *MaintenanceState) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MaintenanceState.ProtoReflect.Descriptor instead.
-func (*MaintenanceState) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{133}
-}
-
-func (x *MaintenanceState) GetId() string {
-	if x != nil {
-		return x.Id
-	}
-	return ""
+// Deprecated: Use DeleteMaintenanceTemplateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMaintenanceTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{183}
 }
 
-func (x *MaintenanceState) GetScope() string {
+func (x *DeleteMaintenanceTemplateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Scope
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *MaintenanceState) GetScopeId() string {
+func (x *DeleteMaintenanceTemplateResponse) GetMessage() string {
 	if x != nil {
-		return x.ScopeId
+		return x.Message
 	}
 	return ""
 }
 
-func (x *MaintenanceState) GetSiteFilter() string {
-	if x != nil {
-		return x.SiteFilter
-	}
-	return ""
+type PreviewMaintenanceTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Variables     map[string]string      `protobuf:"bytes,2,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MaintenanceState) GetLocationFilter() string {
-	if x != nil {
-		return x.LocationFilter
-	}
-	return ""
+func (x *PreviewMaintenanceTemplateRequest) Reset() {
+	*x = PreviewMaintenanceTemplateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[184]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *MaintenanceState) GetTemplateId() string {
-	if x != nil {
-		return x.TemplateId
-	}
-	return ""
+func (x *PreviewMaintenanceTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *MaintenanceState) GetTemplateVars() map[string]string {
-	if x != nil {
-		return x.TemplateVars
-	}
-	return nil
-}
+func (*PreviewMaintenanceTemplateRequest) ProtoMessage() {}
 
-func (x *MaintenanceState) GetIsEnabled() bool {
+func (x *PreviewMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[184]
 	if x != nil {
-		return x.IsEnabled
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *MaintenanceState) GetEnabledAt() int64 {
-	if x != nil {
-		return x.EnabledAt
-	}
-	return 0
+// Deprecated: Use PreviewMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
+func (*PreviewMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{184}
 }
 
-func (x *MaintenanceState) GetEnabledBy() string {
+func (x *PreviewMaintenanceTemplateRequest) GetTemplateId() string {
 	if x != nil {
-		return x.EnabledBy
+		return x.TemplateId
 	}
 	return ""
 }
 
-func (x *MaintenanceState) GetScheduleType() string {
+func (x *PreviewMaintenanceTemplateRequest) GetVariables() map[string]string {
 	if x != nil {
-		return x.ScheduleType
+		return x.Variables
 	}
-	return ""
+	return nil
 }
 
-func (x *MaintenanceState) GetScheduledStart() int64 {
-	if x != nil {
-		return x.ScheduledStart
-	}
-	return 0
+type PreviewMaintenanceTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RenderedHtml  string                 `protobuf:"bytes,1,opt,name=rendered_html,json=renderedHtml,proto3" json:"rendered_html,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MaintenanceState) GetScheduledEnd() int64 {
-	if x != nil {
-		return x.ScheduledEnd
-	}
-	return 0
+func (x *PreviewMaintenanceTemplateResponse) Reset() {
+	*x = PreviewMaintenanceTemplateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[185]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *MaintenanceState) GetRecurrenceRule() string {
-	if x != nil {
-		return x.RecurrenceRule
-	}
-	return ""
+func (x *PreviewMaintenanceTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *MaintenanceState) GetTimezone() string {
-	if x != nil {
-		return x.Timezone
-	}
-	return ""
-}
+func (*PreviewMaintenanceTemplateResponse) ProtoMessage() {}
 
-func (x *MaintenanceState) GetBypassIps() []string {
+func (x *PreviewMaintenanceTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[185]
 	if x != nil {
-		return x.BypassIps
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *MaintenanceState) GetBypassHeaders() map[string]string {
-	if x != nil {
-		return x.BypassHeaders
-	}
-	return nil
+// Deprecated: Use PreviewMaintenanceTemplateResponse.ProtoReflect.Descriptor instead.
+func (*PreviewMaintenanceTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{185}
 }
 
-func (x *MaintenanceState) GetReason() string {
+func (x *PreviewMaintenanceTemplateResponse) GetRenderedHtml() string {
 	if x != nil {
-		return x.Reason
+		return x.RenderedHtml
 	}
 	return ""
 }
 
-type SetMaintenanceRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Action         string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"` // "enable", "disable", "schedule", "cancel_schedule"
-	Scope          string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
-	ScopeId        string                 `protobuf:"bytes,3,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
-	SiteFilter     string                 `protobuf:"bytes,4,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
-	LocationFilter string                 `protobuf:"bytes,5,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
-	TemplateId     string                 `protobuf:"bytes,6,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	TemplateVars   map[string]string      `protobuf:"bytes,7,rep,name=template_vars,json=templateVars,proto3" json:"template_vars,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ScheduleType   string                 `protobuf:"bytes,8,opt,name=schedule_type,json=scheduleType,proto3" json:"schedule_type,omitempty"`
-	ScheduledStart int64                  `protobuf:"varint,9,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"`
-	ScheduledEnd   int64                  `protobuf:"varint,10,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`
-	RecurrenceRule string                 `protobuf:"bytes,11,opt,name=recurrence_rule,json=recurrenceRule,proto3" json:"recurrence_rule,omitempty"`
-	Timezone       string                 `protobuf:"bytes,12,opt,name=timezone,proto3" json:"timezone,omitempty"`
-	BypassIps      []string               `protobuf:"bytes,13,rep,name=bypass_ips,json=bypassIps,proto3" json:"bypass_ips,omitempty"`
-	BypassHeaders  map[string]string      `protobuf:"bytes,14,rep,name=bypass_headers,json=bypassHeaders,proto3" json:"bypass_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Reason         string                 `protobuf:"bytes,15,opt,name=reason,proto3" json:"reason,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type CertificateInventoryItem struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Domain          string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	EnvironmentId   string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	CertType        string                 `protobuf:"bytes,4,opt,name=cert_type,json=certType,proto3" json:"cert_type,omitempty"` // "letsencrypt", "commercial", "self_signed"
+	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	ExpiryTimestamp int64                  `protobuf:"varint,6,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
+	DaysUntilExpiry int32                  `protobuf:"varint,7,opt,name=days_until_expiry,json=daysUntilExpiry,proto3" json:"days_until_expiry,omitempty"`
+	SanDomains      []string               `protobuf:"bytes,8,rep,name=san_domains,json=sanDomains,proto3" json:"san_domains,omitempty"`
+	AutoRenew       bool                   `protobuf:"varint,9,opt,name=auto_renew,json=autoRenew,proto3" json:"auto_renew,omitempty"`
+	DeployedToCount int32                  `protobuf:"varint,10,opt,name=deployed_to_count,json=deployedToCount,proto3" json:"deployed_to_count,omitempty"`
+	CertContentHash string                 `protobuf:"bytes,11,opt,name=cert_content_hash,json=certContentHash,proto3" json:"cert_content_hash,omitempty"`
+	CreatedAt       int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *SetMaintenanceRequest) Reset() {
-	*x = SetMaintenanceRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[134]
+func (x *CertificateInventoryItem) Reset() {
+	*x = CertificateInventoryItem{}
+	mi := &file_api_proto_agent_proto_msgTypes[186]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetMaintenanceRequest) String() string {
+func (x *CertificateInventoryItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetMaintenanceRequest) ProtoMessage() {}
+func (*CertificateInventoryItem) ProtoMessage() {}
 
-func (x *SetMaintenanceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[134]
+func (x *CertificateInventoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[186]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10033,141 +14529,137 @@ func (x *SetMaintenanceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetMaintenanceRequest.ProtoReflect.Descriptor instead.
-func (*SetMaintenanceRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{134}
-}
-
-func (x *SetMaintenanceRequest) GetAction() string {
-	if x != nil {
-		return x.Action
-	}
-	return ""
+// Deprecated: Use CertificateInventoryItem.ProtoReflect.Descriptor instead.
+func (*CertificateInventoryItem) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{186}
 }
 
-func (x *SetMaintenanceRequest) GetScope() string {
+func (x *CertificateInventoryItem) GetId() string {
 	if x != nil {
-		return x.Scope
+		return x.Id
 	}
 	return ""
 }
 
-func (x *SetMaintenanceRequest) GetScopeId() string {
+func (x *CertificateInventoryItem) GetDomain() string {
 	if x != nil {
-		return x.ScopeId
+		return x.Domain
 	}
 	return ""
 }
 
-func (x *SetMaintenanceRequest) GetSiteFilter() string {
+func (x *CertificateInventoryItem) GetEnvironmentId() string {
 	if x != nil {
-		return x.SiteFilter
+		return x.EnvironmentId
 	}
 	return ""
 }
 
-func (x *SetMaintenanceRequest) GetLocationFilter() string {
+func (x *CertificateInventoryItem) GetCertType() string {
 	if x != nil {
-		return x.LocationFilter
+		return x.CertType
 	}
 	return ""
 }
 
-func (x *SetMaintenanceRequest) GetTemplateId() string {
+func (x *CertificateInventoryItem) GetIssuer() string {
 	if x != nil {
-		return x.TemplateId
+		return x.Issuer
 	}
 	return ""
 }
 
-func (x *SetMaintenanceRequest) GetTemplateVars() map[string]string {
-	if x != nil {
-		return x.TemplateVars
-	}
-	return nil
-}
-
-func (x *SetMaintenanceRequest) GetScheduleType() string {
+func (x *CertificateInventoryItem) GetExpiryTimestamp() int64 {
 	if x != nil {
-		return x.ScheduleType
+		return x.ExpiryTimestamp
 	}
-	return ""
+	return 0
 }
 
-func (x *SetMaintenanceRequest) GetScheduledStart() int64 {
+func (x *CertificateInventoryItem) GetDaysUntilExpiry() int32 {
 	if x != nil {
-		return x.ScheduledStart
+		return x.DaysUntilExpiry
 	}
 	return 0
 }
 
-func (x *SetMaintenanceRequest) GetScheduledEnd() int64 {
+func (x *CertificateInventoryItem) GetSanDomains() []string {
 	if x != nil {
-		return x.ScheduledEnd
+		return x.SanDomains
 	}
-	return 0
+	return nil
 }
 
-func (x *SetMaintenanceRequest) GetRecurrenceRule() string {
+func (x *CertificateInventoryItem) GetAutoRenew() bool {
 	if x != nil {
-		return x.RecurrenceRule
+		return x.AutoRenew
 	}
-	return ""
+	return false
 }
 
-func (x *SetMaintenanceRequest) GetTimezone() string {
+func (x *CertificateInventoryItem) GetDeployedToCount() int32 {
 	if x != nil {
-		return x.Timezone
+		return x.DeployedToCount
 	}
-	return ""
+	return 0
 }
 
-func (x *SetMaintenanceRequest) GetBypassIps() []string {
+func (x *CertificateInventoryItem) GetCertContentHash() string {
 	if x != nil {
-		return x.BypassIps
+		return x.CertContentHash
 	}
-	return nil
+	return ""
 }
 
-func (x *SetMaintenanceRequest) GetBypassHeaders() map[string]string {
+func (x *CertificateInventoryItem) GetCreatedAt() int64 {
 	if x != nil {
-		return x.BypassHeaders
+		return x.CreatedAt
 	}
-	return nil
+	return 0
 }
 
-func (x *SetMaintenanceRequest) GetReason() string {
+func (x *CertificateInventoryItem) GetUpdatedAt() int64 {
 	if x != nil {
-		return x.Reason
+		return x.UpdatedAt
 	}
-	return ""
+	return 0
 }
 
-type SetMaintenanceResponse struct {
-	state              protoimpl.MessageState    `protogen:"open.v1"`
-	Success            bool                      `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	MaintenanceStateId string                    `protobuf:"bytes,2,opt,name=maintenance_state_id,json=maintenanceStateId,proto3" json:"maintenance_state_id,omitempty"`
-	Results            []*AgentMaintenanceResult `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
-	Error              string                    `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type UploadCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	CertContent   []byte                 `protobuf:"bytes,2,opt,name=cert_content,json=certContent,proto3" json:"cert_content,omitempty"` // PEM format
+	KeyContent    []byte                 `protobuf:"bytes,3,opt,name=key_content,json=keyContent,proto3" json:"key_content,omitempty"`
+	ChainContent  []byte                 `protobuf:"bytes,4,opt,name=chain_content,json=chainContent,proto3" json:"chain_content,omitempty"` // Intermediate certs
+	CertType      string                 `protobuf:"bytes,5,opt,name=cert_type,json=certType,proto3" json:"cert_type,omitempty"`
+	EnvironmentId string                 `protobuf:"bytes,6,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	// Target selection for immediate deployment
+	AgentIds       []string `protobuf:"bytes,7,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
+	GroupId        string   `protobuf:"bytes,8,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	BackupExisting bool     `protobuf:"varint,9,opt,name=backup_existing,json=backupExisting,proto3" json:"backup_existing,omitempty"`
+	ReloadNginx    bool     `protobuf:"varint,10,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
+	// Optional: load cert/key/chain from Vault KV v2 instead of the content fields
+	// (keys: "certificate", "private_key", "chain")
+	VaultPath     string `protobuf:"bytes,11,opt,name=vault_path,json=vaultPath,proto3" json:"vault_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetMaintenanceResponse) Reset() {
-	*x = SetMaintenanceResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[135]
+func (x *UploadCertificateRequest) Reset() {
+	*x = UploadCertificateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[187]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetMaintenanceResponse) String() string {
+func (x *UploadCertificateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetMaintenanceResponse) ProtoMessage() {}
+func (*UploadCertificateRequest) ProtoMessage() {}
 
-func (x *SetMaintenanceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[135]
+func (x *UploadCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[187]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10178,132 +14670,113 @@ func (x *SetMaintenanceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetMaintenanceResponse.ProtoReflect.Descriptor instead.
-func (*SetMaintenanceResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{135}
+// Deprecated: Use UploadCertificateRequest.ProtoReflect.Descriptor instead.
+func (*UploadCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{187}
 }
 
-func (x *SetMaintenanceResponse) GetSuccess() bool {
+func (x *UploadCertificateRequest) GetDomain() string {
 	if x != nil {
-		return x.Success
+		return x.Domain
 	}
-	return false
+	return ""
 }
 
-func (x *SetMaintenanceResponse) GetMaintenanceStateId() string {
+func (x *UploadCertificateRequest) GetCertContent() []byte {
 	if x != nil {
-		return x.MaintenanceStateId
+		return x.CertContent
 	}
-	return ""
+	return nil
 }
 
-func (x *SetMaintenanceResponse) GetResults() []*AgentMaintenanceResult {
+func (x *UploadCertificateRequest) GetKeyContent() []byte {
 	if x != nil {
-		return x.Results
+		return x.KeyContent
 	}
 	return nil
 }
 
-func (x *SetMaintenanceResponse) GetError() string {
+func (x *UploadCertificateRequest) GetChainContent() []byte {
 	if x != nil {
-		return x.Error
+		return x.ChainContent
 	}
-	return ""
-}
-
-type AgentMaintenanceResult struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *AgentMaintenanceResult) Reset() {
-	*x = AgentMaintenanceResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[136]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+	return nil
 }
 
-func (x *AgentMaintenanceResult) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *UploadCertificateRequest) GetCertType() string {
+	if x != nil {
+		return x.CertType
+	}
+	return ""
 }
 
-func (*AgentMaintenanceResult) ProtoMessage() {}
-
-func (x *AgentMaintenanceResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[136]
+func (x *UploadCertificateRequest) GetEnvironmentId() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.EnvironmentId
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use AgentMaintenanceResult.ProtoReflect.Descriptor instead.
-func (*AgentMaintenanceResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{136}
+func (x *UploadCertificateRequest) GetAgentIds() []string {
+	if x != nil {
+		return x.AgentIds
+	}
+	return nil
 }
 
-func (x *AgentMaintenanceResult) GetAgentId() string {
+func (x *UploadCertificateRequest) GetGroupId() string {
 	if x != nil {
-		return x.AgentId
+		return x.GroupId
 	}
 	return ""
 }
 
-func (x *AgentMaintenanceResult) GetHostname() string {
+func (x *UploadCertificateRequest) GetBackupExisting() bool {
 	if x != nil {
-		return x.Hostname
+		return x.BackupExisting
 	}
-	return ""
+	return false
 }
 
-func (x *AgentMaintenanceResult) GetSuccess() bool {
+func (x *UploadCertificateRequest) GetReloadNginx() bool {
 	if x != nil {
-		return x.Success
+		return x.ReloadNginx
 	}
 	return false
 }
 
-func (x *AgentMaintenanceResult) GetError() string {
+func (x *UploadCertificateRequest) GetVaultPath() string {
 	if x != nil {
-		return x.Error
+		return x.VaultPath
 	}
 	return ""
 }
 
-type GetMaintenanceStatusRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Scope          string                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
-	ScopeId        string                 `protobuf:"bytes,2,opt,name=scope_id,json=scopeId,proto3" json:"scope_id,omitempty"`
-	SiteFilter     string                 `protobuf:"bytes,3,opt,name=site_filter,json=siteFilter,proto3" json:"site_filter,omitempty"`
-	LocationFilter string                 `protobuf:"bytes,4,opt,name=location_filter,json=locationFilter,proto3" json:"location_filter,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type UploadCertificateResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Success       bool                    `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	CertificateId string                  `protobuf:"bytes,2,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
+	Deployments   []*CertDeploymentResult `protobuf:"bytes,3,rep,name=deployments,proto3" json:"deployments,omitempty"`
+	Error         string                  `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMaintenanceStatusRequest) Reset() {
-	*x = GetMaintenanceStatusRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[137]
+func (x *UploadCertificateResponse) Reset() {
+	*x = UploadCertificateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[188]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMaintenanceStatusRequest) String() string {
+func (x *UploadCertificateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMaintenanceStatusRequest) ProtoMessage() {}
+func (*UploadCertificateResponse) ProtoMessage() {}
 
-func (x *GetMaintenanceStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[137]
+func (x *UploadCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[188]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10314,63 +14787,66 @@ func (x *GetMaintenanceStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMaintenanceStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetMaintenanceStatusRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{137}
+// Deprecated: Use UploadCertificateResponse.ProtoReflect.Descriptor instead.
+func (*UploadCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{188}
 }
 
-func (x *GetMaintenanceStatusRequest) GetScope() string {
+func (x *UploadCertificateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Scope
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *GetMaintenanceStatusRequest) GetScopeId() string {
+func (x *UploadCertificateResponse) GetCertificateId() string {
 	if x != nil {
-		return x.ScopeId
+		return x.CertificateId
 	}
 	return ""
 }
 
-func (x *GetMaintenanceStatusRequest) GetSiteFilter() string {
+func (x *UploadCertificateResponse) GetDeployments() []*CertDeploymentResult {
 	if x != nil {
-		return x.SiteFilter
+		return x.Deployments
 	}
-	return ""
+	return nil
 }
 
-func (x *GetMaintenanceStatusRequest) GetLocationFilter() string {
+func (x *UploadCertificateResponse) GetError() string {
 	if x != nil {
-		return x.LocationFilter
+		return x.Error
 	}
 	return ""
 }
 
-type ListMaintenanceStatesRequest struct {
+type CertDeploymentResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	EnvironmentId string                 `protobuf:"bytes,2,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	EnabledOnly   bool                   `protobuf:"varint,3,opt,name=enabled_only,json=enabledOnly,proto3" json:"enabled_only,omitempty"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	CertPath      string                 `protobuf:"bytes,4,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
+	KeyPath       string                 `protobuf:"bytes,5,opt,name=key_path,json=keyPath,proto3" json:"key_path,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListMaintenanceStatesRequest) Reset() {
-	*x = ListMaintenanceStatesRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[138]
+func (x *CertDeploymentResult) Reset() {
+	*x = CertDeploymentResult{}
+	mi := &file_api_proto_agent_proto_msgTypes[189]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMaintenanceStatesRequest) String() string {
+func (x *CertDeploymentResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMaintenanceStatesRequest) ProtoMessage() {}
+func (*CertDeploymentResult) ProtoMessage() {}
 
-func (x *ListMaintenanceStatesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[138]
+func (x *CertDeploymentResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[189]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10381,54 +14857,77 @@ func (x *ListMaintenanceStatesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMaintenanceStatesRequest.ProtoReflect.Descriptor instead.
-func (*ListMaintenanceStatesRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{138}
+// Deprecated: Use CertDeploymentResult.ProtoReflect.Descriptor instead.
+func (*CertDeploymentResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{189}
+}
+
+func (x *CertDeploymentResult) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *CertDeploymentResult) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *CertDeploymentResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
 }
 
-func (x *ListMaintenanceStatesRequest) GetProjectId() string {
+func (x *CertDeploymentResult) GetCertPath() string {
 	if x != nil {
-		return x.ProjectId
+		return x.CertPath
 	}
 	return ""
 }
 
-func (x *ListMaintenanceStatesRequest) GetEnvironmentId() string {
+func (x *CertDeploymentResult) GetKeyPath() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.KeyPath
 	}
 	return ""
 }
 
-func (x *ListMaintenanceStatesRequest) GetEnabledOnly() bool {
+func (x *CertDeploymentResult) GetError() string {
 	if x != nil {
-		return x.EnabledOnly
+		return x.Error
 	}
-	return false
+	return ""
 }
 
-type ListMaintenanceStatesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	States        []*MaintenanceState    `protobuf:"bytes,1,rep,name=states,proto3" json:"states,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type GetCertificateInventoryRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	EnvironmentId      string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	ExpiringOnly       bool                   `protobuf:"varint,2,opt,name=expiring_only,json=expiringOnly,proto3" json:"expiring_only,omitempty"`
+	ExpiringWithinDays int32                  `protobuf:"varint,3,opt,name=expiring_within_days,json=expiringWithinDays,proto3" json:"expiring_within_days,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
-func (x *ListMaintenanceStatesResponse) Reset() {
-	*x = ListMaintenanceStatesResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[139]
+func (x *GetCertificateInventoryRequest) Reset() {
+	*x = GetCertificateInventoryRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[190]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMaintenanceStatesResponse) String() string {
+func (x *GetCertificateInventoryRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMaintenanceStatesResponse) ProtoMessage() {}
+func (*GetCertificateInventoryRequest) ProtoMessage() {}
 
-func (x *ListMaintenanceStatesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[139]
+func (x *GetCertificateInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[190]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10439,84 +14938,54 @@ func (x *ListMaintenanceStatesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMaintenanceStatesResponse.ProtoReflect.Descriptor instead.
-func (*ListMaintenanceStatesResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{139}
+// Deprecated: Use GetCertificateInventoryRequest.ProtoReflect.Descriptor instead.
+func (*GetCertificateInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{190}
 }
 
-func (x *ListMaintenanceStatesResponse) GetStates() []*MaintenanceState {
+func (x *GetCertificateInventoryRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.States
+		return x.EnvironmentId
 	}
-	return nil
-}
-
-type ListMaintenanceTemplatesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListMaintenanceTemplatesRequest) Reset() {
-	*x = ListMaintenanceTemplatesRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[140]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListMaintenanceTemplatesRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*ListMaintenanceTemplatesRequest) ProtoMessage() {}
-
-func (x *ListMaintenanceTemplatesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[140]
+func (x *GetCertificateInventoryRequest) GetExpiringOnly() bool {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ExpiringOnly
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use ListMaintenanceTemplatesRequest.ProtoReflect.Descriptor instead.
-func (*ListMaintenanceTemplatesRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{140}
+	return false
 }
 
-func (x *ListMaintenanceTemplatesRequest) GetProjectId() string {
+func (x *GetCertificateInventoryRequest) GetExpiringWithinDays() int32 {
 	if x != nil {
-		return x.ProjectId
+		return x.ExpiringWithinDays
 	}
-	return ""
+	return 0
 }
 
-type ListMaintenanceTemplatesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Templates     []*MaintenanceTemplate `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
+type GetCertificateInventoryResponse struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Certificates  []*CertificateInventoryItem `protobuf:"bytes,1,rep,name=certificates,proto3" json:"certificates,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListMaintenanceTemplatesResponse) Reset() {
-	*x = ListMaintenanceTemplatesResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[141]
+func (x *GetCertificateInventoryResponse) Reset() {
+	*x = GetCertificateInventoryResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[191]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMaintenanceTemplatesResponse) String() string {
+func (x *GetCertificateInventoryResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMaintenanceTemplatesResponse) ProtoMessage() {}
+func (*GetCertificateInventoryResponse) ProtoMessage() {}
 
-func (x *ListMaintenanceTemplatesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[141]
+func (x *GetCertificateInventoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[191]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10527,47 +14996,45 @@ func (x *ListMaintenanceTemplatesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMaintenanceTemplatesResponse.ProtoReflect.Descriptor instead.
-func (*ListMaintenanceTemplatesResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{141}
+// Deprecated: Use GetCertificateInventoryResponse.ProtoReflect.Descriptor instead.
+func (*GetCertificateInventoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{191}
 }
 
-func (x *ListMaintenanceTemplatesResponse) GetTemplates() []*MaintenanceTemplate {
+func (x *GetCertificateInventoryResponse) GetCertificates() []*CertificateInventoryItem {
 	if x != nil {
-		return x.Templates
+		return x.Certificates
 	}
 	return nil
 }
 
-type CreateMaintenanceTemplateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	HtmlContent   string                 `protobuf:"bytes,4,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
-	CssContent    string                 `protobuf:"bytes,5,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
-	Assets        map[string]string      `protobuf:"bytes,6,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Variables     []*TemplateVariable    `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty"`
-	IsDefault     bool                   `protobuf:"varint,8,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type DeployCertificateRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CertificateId  string                 `protobuf:"bytes,1,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
+	AgentIds       []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
+	GroupId        string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	EnvironmentId  string                 `protobuf:"bytes,4,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	BackupExisting bool                   `protobuf:"varint,5,opt,name=backup_existing,json=backupExisting,proto3" json:"backup_existing,omitempty"`
+	ReloadNginx    bool                   `protobuf:"varint,6,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *CreateMaintenanceTemplateRequest) Reset() {
-	*x = CreateMaintenanceTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[142]
+func (x *DeployCertificateRequest) Reset() {
+	*x = DeployCertificateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[192]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateMaintenanceTemplateRequest) String() string {
+func (x *DeployCertificateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateMaintenanceTemplateRequest) ProtoMessage() {}
+func (*DeployCertificateRequest) ProtoMessage() {}
 
-func (x *CreateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[142]
+func (x *DeployCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[192]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10578,96 +15045,120 @@ func (x *CreateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
-func (*CreateMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{142}
+// Deprecated: Use DeployCertificateRequest.ProtoReflect.Descriptor instead.
+func (*DeployCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{192}
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetProjectId() string {
+func (x *DeployCertificateRequest) GetCertificateId() string {
 	if x != nil {
-		return x.ProjectId
+		return x.CertificateId
 	}
 	return ""
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetName() string {
+func (x *DeployCertificateRequest) GetAgentIds() []string {
 	if x != nil {
-		return x.Name
+		return x.AgentIds
 	}
-	return ""
+	return nil
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetDescription() string {
+func (x *DeployCertificateRequest) GetGroupId() string {
 	if x != nil {
-		return x.Description
+		return x.GroupId
 	}
 	return ""
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetHtmlContent() string {
+func (x *DeployCertificateRequest) GetEnvironmentId() string {
 	if x != nil {
-		return x.HtmlContent
+		return x.EnvironmentId
 	}
 	return ""
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetCssContent() string {
+func (x *DeployCertificateRequest) GetBackupExisting() bool {
 	if x != nil {
-		return x.CssContent
+		return x.BackupExisting
 	}
-	return ""
+	return false
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetAssets() map[string]string {
+func (x *DeployCertificateRequest) GetReloadNginx() bool {
 	if x != nil {
-		return x.Assets
+		return x.ReloadNginx
 	}
-	return nil
+	return false
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetVariables() []*TemplateVariable {
+type DeleteCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CertificateId string                 `protobuf:"bytes,1,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCertificateRequest) Reset() {
+	*x = DeleteCertificateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[193]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCertificateRequest) ProtoMessage() {}
+
+func (x *DeleteCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[193]
 	if x != nil {
-		return x.Variables
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *CreateMaintenanceTemplateRequest) GetIsDefault() bool {
+// Deprecated: Use DeleteCertificateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{193}
+}
+
+func (x *DeleteCertificateRequest) GetCertificateId() string {
 	if x != nil {
-		return x.IsDefault
+		return x.CertificateId
 	}
-	return false
+	return ""
 }
 
-type UpdateMaintenanceTemplateRequest struct {
+type DeleteCertificateResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	HtmlContent   string                 `protobuf:"bytes,4,opt,name=html_content,json=htmlContent,proto3" json:"html_content,omitempty"`
-	CssContent    string                 `protobuf:"bytes,5,opt,name=css_content,json=cssContent,proto3" json:"css_content,omitempty"`
-	Assets        map[string]string      `protobuf:"bytes,6,rep,name=assets,proto3" json:"assets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Variables     []*TemplateVariable    `protobuf:"bytes,7,rep,name=variables,proto3" json:"variables,omitempty"`
-	IsDefault     bool                   `protobuf:"varint,8,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateMaintenanceTemplateRequest) Reset() {
-	*x = UpdateMaintenanceTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[143]
+func (x *DeleteCertificateResponse) Reset() {
+	*x = DeleteCertificateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[194]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMaintenanceTemplateRequest) String() string {
+func (x *DeleteCertificateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMaintenanceTemplateRequest) ProtoMessage() {}
+func (*DeleteCertificateResponse) ProtoMessage() {}
 
-func (x *UpdateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[143]
+func (x *DeleteCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[194]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10678,89 +15169,128 @@ func (x *UpdateMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
-func (*UpdateMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{143}
+// Deprecated: Use DeleteCertificateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{194}
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetTemplateId() string {
+func (x *DeleteCertificateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.TemplateId
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetName() string {
+func (x *DeleteCertificateResponse) GetMessage() string {
 	if x != nil {
-		return x.Name
+		return x.Message
 	}
 	return ""
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetDescription() string {
+type CompareEnvironmentsRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	SourceEnvironmentId string                 `protobuf:"bytes,1,opt,name=source_environment_id,json=sourceEnvironmentId,proto3" json:"source_environment_id,omitempty"`
+	TargetEnvironmentId string                 `protobuf:"bytes,2,opt,name=target_environment_id,json=targetEnvironmentId,proto3" json:"target_environment_id,omitempty"`
+	CompareTypes        []string               `protobuf:"bytes,3,rep,name=compare_types,json=compareTypes,proto3" json:"compare_types,omitempty"` // ["nginx_main_conf", "ssl_certs", "maintenance"]
+	IncludeDiffContent  bool                   `protobuf:"varint,4,opt,name=include_diff_content,json=includeDiffContent,proto3" json:"include_diff_content,omitempty"`
+	GroupMapping        map[string]string      `protobuf:"bytes,5,rep,name=group_mapping,json=groupMapping,proto3" json:"group_mapping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // source_group_id -> target_group_id
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *CompareEnvironmentsRequest) Reset() {
+	*x = CompareEnvironmentsRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[195]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompareEnvironmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareEnvironmentsRequest) ProtoMessage() {}
+
+func (x *CompareEnvironmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[195]
 	if x != nil {
-		return x.Description
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetHtmlContent() string {
+// Deprecated: Use CompareEnvironmentsRequest.ProtoReflect.Descriptor instead.
+func (*CompareEnvironmentsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{195}
+}
+
+func (x *CompareEnvironmentsRequest) GetSourceEnvironmentId() string {
 	if x != nil {
-		return x.HtmlContent
+		return x.SourceEnvironmentId
 	}
 	return ""
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetCssContent() string {
+func (x *CompareEnvironmentsRequest) GetTargetEnvironmentId() string {
 	if x != nil {
-		return x.CssContent
+		return x.TargetEnvironmentId
 	}
 	return ""
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetAssets() map[string]string {
+func (x *CompareEnvironmentsRequest) GetCompareTypes() []string {
 	if x != nil {
-		return x.Assets
+		return x.CompareTypes
 	}
 	return nil
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetVariables() []*TemplateVariable {
+func (x *CompareEnvironmentsRequest) GetIncludeDiffContent() bool {
 	if x != nil {
-		return x.Variables
+		return x.IncludeDiffContent
 	}
-	return nil
+	return false
 }
 
-func (x *UpdateMaintenanceTemplateRequest) GetIsDefault() bool {
+func (x *CompareEnvironmentsRequest) GetGroupMapping() map[string]string {
 	if x != nil {
-		return x.IsDefault
+		return x.GroupMapping
 	}
-	return false
+	return nil
 }
 
-type DeleteMaintenanceTemplateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type CompareEnvironmentsResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ComparisonId      string                 `protobuf:"bytes,1,opt,name=comparison_id,json=comparisonId,proto3" json:"comparison_id,omitempty"`
+	SourceEnvironment string                 `protobuf:"bytes,2,opt,name=source_environment,json=sourceEnvironment,proto3" json:"source_environment,omitempty"`
+	TargetEnvironment string                 `protobuf:"bytes,3,opt,name=target_environment,json=targetEnvironment,proto3" json:"target_environment,omitempty"`
+	ComparedAt        int64                  `protobuf:"varint,4,opt,name=compared_at,json=comparedAt,proto3" json:"compared_at,omitempty"`
+	Categories        []*ComparisonCategory  `protobuf:"bytes,5,rep,name=categories,proto3" json:"categories,omitempty"`
+	Summary           *ComparisonSummary     `protobuf:"bytes,6,opt,name=summary,proto3" json:"summary,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *DeleteMaintenanceTemplateRequest) Reset() {
-	*x = DeleteMaintenanceTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[144]
+func (x *CompareEnvironmentsResponse) Reset() {
+	*x = CompareEnvironmentsResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[196]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteMaintenanceTemplateRequest) String() string {
+func (x *CompareEnvironmentsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteMaintenanceTemplateRequest) ProtoMessage() {}
+func (*CompareEnvironmentsResponse) ProtoMessage() {}
 
-func (x *DeleteMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[144]
+func (x *CompareEnvironmentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[196]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10771,93 +15301,82 @@ func (x *DeleteMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
-func (*DeleteMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{144}
+// Deprecated: Use CompareEnvironmentsResponse.ProtoReflect.Descriptor instead.
+func (*CompareEnvironmentsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{196}
 }
 
-func (x *DeleteMaintenanceTemplateRequest) GetTemplateId() string {
+func (x *CompareEnvironmentsResponse) GetComparisonId() string {
 	if x != nil {
-		return x.TemplateId
+		return x.ComparisonId
 	}
 	return ""
 }
 
-type DeleteMaintenanceTemplateResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *DeleteMaintenanceTemplateResponse) Reset() {
-	*x = DeleteMaintenanceTemplateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[145]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *DeleteMaintenanceTemplateResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *CompareEnvironmentsResponse) GetSourceEnvironment() string {
+	if x != nil {
+		return x.SourceEnvironment
+	}
+	return ""
 }
 
-func (*DeleteMaintenanceTemplateResponse) ProtoMessage() {}
-
-func (x *DeleteMaintenanceTemplateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[145]
+func (x *CompareEnvironmentsResponse) GetTargetEnvironment() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.TargetEnvironment
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use DeleteMaintenanceTemplateResponse.ProtoReflect.Descriptor instead.
-func (*DeleteMaintenanceTemplateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{145}
+func (x *CompareEnvironmentsResponse) GetComparedAt() int64 {
+	if x != nil {
+		return x.ComparedAt
+	}
+	return 0
 }
 
-func (x *DeleteMaintenanceTemplateResponse) GetSuccess() bool {
+func (x *CompareEnvironmentsResponse) GetCategories() []*ComparisonCategory {
 	if x != nil {
-		return x.Success
+		return x.Categories
 	}
-	return false
+	return nil
 }
 
-func (x *DeleteMaintenanceTemplateResponse) GetMessage() string {
+func (x *CompareEnvironmentsResponse) GetSummary() *ComparisonSummary {
 	if x != nil {
-		return x.Message
+		return x.Summary
 	}
-	return ""
+	return nil
 }
 
-type PreviewMaintenanceTemplateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
-	Variables     map[string]string      `protobuf:"bytes,2,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ComparisonCategory struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Type            string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	SourceCount     int32                  `protobuf:"varint,2,opt,name=source_count,json=sourceCount,proto3" json:"source_count,omitempty"`
+	TargetCount     int32                  `protobuf:"varint,3,opt,name=target_count,json=targetCount,proto3" json:"target_count,omitempty"`
+	SameCount       int32                  `protobuf:"varint,4,opt,name=same_count,json=sameCount,proto3" json:"same_count,omitempty"`
+	DifferentCount  int32                  `protobuf:"varint,5,opt,name=different_count,json=differentCount,proto3" json:"different_count,omitempty"`
+	SourceOnlyCount int32                  `protobuf:"varint,6,opt,name=source_only_count,json=sourceOnlyCount,proto3" json:"source_only_count,omitempty"`
+	TargetOnlyCount int32                  `protobuf:"varint,7,opt,name=target_only_count,json=targetOnlyCount,proto3" json:"target_only_count,omitempty"`
+	Differences     []*ConfigDifference    `protobuf:"bytes,8,rep,name=differences,proto3" json:"differences,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *PreviewMaintenanceTemplateRequest) Reset() {
-	*x = PreviewMaintenanceTemplateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[146]
+func (x *ComparisonCategory) Reset() {
+	*x = ComparisonCategory{}
+	mi := &file_api_proto_agent_proto_msgTypes[197]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PreviewMaintenanceTemplateRequest) String() string {
+func (x *ComparisonCategory) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PreviewMaintenanceTemplateRequest) ProtoMessage() {}
+func (*ComparisonCategory) ProtoMessage() {}
 
-func (x *PreviewMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[146]
+func (x *ComparisonCategory) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[197]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10868,103 +15387,94 @@ func (x *PreviewMaintenanceTemplateRequest) ProtoReflect() protoreflect.Message
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PreviewMaintenanceTemplateRequest.ProtoReflect.Descriptor instead.
-func (*PreviewMaintenanceTemplateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{146}
+// Deprecated: Use ComparisonCategory.ProtoReflect.Descriptor instead.
+func (*ComparisonCategory) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{197}
 }
 
-func (x *PreviewMaintenanceTemplateRequest) GetTemplateId() string {
+func (x *ComparisonCategory) GetType() string {
 	if x != nil {
-		return x.TemplateId
+		return x.Type
 	}
 	return ""
 }
 
-func (x *PreviewMaintenanceTemplateRequest) GetVariables() map[string]string {
+func (x *ComparisonCategory) GetSourceCount() int32 {
 	if x != nil {
-		return x.Variables
+		return x.SourceCount
 	}
-	return nil
+	return 0
 }
 
-type PreviewMaintenanceTemplateResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RenderedHtml  string                 `protobuf:"bytes,1,opt,name=rendered_html,json=renderedHtml,proto3" json:"rendered_html,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ComparisonCategory) GetTargetCount() int32 {
+	if x != nil {
+		return x.TargetCount
+	}
+	return 0
 }
 
-func (x *PreviewMaintenanceTemplateResponse) Reset() {
-	*x = PreviewMaintenanceTemplateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[147]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *ComparisonCategory) GetSameCount() int32 {
+	if x != nil {
+		return x.SameCount
+	}
+	return 0
 }
 
-func (x *PreviewMaintenanceTemplateResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *ComparisonCategory) GetDifferentCount() int32 {
+	if x != nil {
+		return x.DifferentCount
+	}
+	return 0
 }
 
-func (*PreviewMaintenanceTemplateResponse) ProtoMessage() {}
-
-func (x *PreviewMaintenanceTemplateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[147]
+func (x *ComparisonCategory) GetSourceOnlyCount() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.SourceOnlyCount
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use PreviewMaintenanceTemplateResponse.ProtoReflect.Descriptor instead.
-func (*PreviewMaintenanceTemplateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{147}
+func (x *ComparisonCategory) GetTargetOnlyCount() int32 {
+	if x != nil {
+		return x.TargetOnlyCount
+	}
+	return 0
 }
 
-func (x *PreviewMaintenanceTemplateResponse) GetRenderedHtml() string {
+func (x *ComparisonCategory) GetDifferences() []*ConfigDifference {
 	if x != nil {
-		return x.RenderedHtml
+		return x.Differences
 	}
-	return ""
+	return nil
 }
 
-type CertificateInventoryItem struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Domain          string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
-	EnvironmentId   string                 `protobuf:"bytes,3,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	CertType        string                 `protobuf:"bytes,4,opt,name=cert_type,json=certType,proto3" json:"cert_type,omitempty"` // "letsencrypt", "commercial", "self_signed"
-	Issuer          string                 `protobuf:"bytes,5,opt,name=issuer,proto3" json:"issuer,omitempty"`
-	ExpiryTimestamp int64                  `protobuf:"varint,6,opt,name=expiry_timestamp,json=expiryTimestamp,proto3" json:"expiry_timestamp,omitempty"`
-	DaysUntilExpiry int32                  `protobuf:"varint,7,opt,name=days_until_expiry,json=daysUntilExpiry,proto3" json:"days_until_expiry,omitempty"`
-	SanDomains      []string               `protobuf:"bytes,8,rep,name=san_domains,json=sanDomains,proto3" json:"san_domains,omitempty"`
-	AutoRenew       bool                   `protobuf:"varint,9,opt,name=auto_renew,json=autoRenew,proto3" json:"auto_renew,omitempty"`
-	DeployedToCount int32                  `protobuf:"varint,10,opt,name=deployed_to_count,json=deployedToCount,proto3" json:"deployed_to_count,omitempty"`
-	CertContentHash string                 `protobuf:"bytes,11,opt,name=cert_content_hash,json=certContentHash,proto3" json:"cert_content_hash,omitempty"`
-	CreatedAt       int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt       int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type ConfigDifference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"` // filename, domain, etc.
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`         // "same", "different", "source_only", "target_only"
+	SourceValue   string                 `protobuf:"bytes,3,opt,name=source_value,json=sourceValue,proto3" json:"source_value,omitempty"`
+	TargetValue   string                 `protobuf:"bytes,4,opt,name=target_value,json=targetValue,proto3" json:"target_value,omitempty"`
+	Diff          string                 `protobuf:"bytes,5,opt,name=diff,proto3" json:"diff,omitempty"` // Unified diff
+	Severity      string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CertificateInventoryItem) Reset() {
-	*x = CertificateInventoryItem{}
-	mi := &file_api_proto_agent_proto_msgTypes[148]
+func (x *ConfigDifference) Reset() {
+	*x = ConfigDifference{}
+	mi := &file_api_proto_agent_proto_msgTypes[198]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CertificateInventoryItem) String() string {
+func (x *ConfigDifference) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CertificateInventoryItem) ProtoMessage() {}
+func (*ConfigDifference) ProtoMessage() {}
 
-func (x *CertificateInventoryItem) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[148]
+func (x *ConfigDifference) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[198]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -10975,134 +15485,143 @@ func (x *CertificateInventoryItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CertificateInventoryItem.ProtoReflect.Descriptor instead.
-func (*CertificateInventoryItem) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{148}
+// Deprecated: Use ConfigDifference.ProtoReflect.Descriptor instead.
+func (*ConfigDifference) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{198}
 }
 
-func (x *CertificateInventoryItem) GetId() string {
+func (x *ConfigDifference) GetIdentifier() string {
 	if x != nil {
-		return x.Id
+		return x.Identifier
 	}
 	return ""
 }
 
-func (x *CertificateInventoryItem) GetDomain() string {
+func (x *ConfigDifference) GetStatus() string {
 	if x != nil {
-		return x.Domain
+		return x.Status
 	}
 	return ""
 }
 
-func (x *CertificateInventoryItem) GetEnvironmentId() string {
+func (x *ConfigDifference) GetSourceValue() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.SourceValue
 	}
 	return ""
 }
 
-func (x *CertificateInventoryItem) GetCertType() string {
+func (x *ConfigDifference) GetTargetValue() string {
 	if x != nil {
-		return x.CertType
+		return x.TargetValue
 	}
 	return ""
 }
 
-func (x *CertificateInventoryItem) GetIssuer() string {
+func (x *ConfigDifference) GetDiff() string {
 	if x != nil {
-		return x.Issuer
+		return x.Diff
 	}
 	return ""
 }
 
-func (x *CertificateInventoryItem) GetExpiryTimestamp() int64 {
+func (x *ConfigDifference) GetSeverity() string {
 	if x != nil {
-		return x.ExpiryTimestamp
+		return x.Severity
 	}
-	return 0
+	return ""
 }
 
-func (x *CertificateInventoryItem) GetDaysUntilExpiry() int32 {
-	if x != nil {
-		return x.DaysUntilExpiry
-	}
-	return 0
+type ComparisonSummary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TotalChecks      int32                  `protobuf:"varint,1,opt,name=total_checks,json=totalChecks,proto3" json:"total_checks,omitempty"`
+	IdenticalCount   int32                  `protobuf:"varint,2,opt,name=identical_count,json=identicalCount,proto3" json:"identical_count,omitempty"`
+	DifferentCount   int32                  `protobuf:"varint,3,opt,name=different_count,json=differentCount,proto3" json:"different_count,omitempty"`
+	HasCriticalDiffs bool                   `protobuf:"varint,4,opt,name=has_critical_diffs,json=hasCriticalDiffs,proto3" json:"has_critical_diffs,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *CertificateInventoryItem) GetSanDomains() []string {
-	if x != nil {
-		return x.SanDomains
-	}
-	return nil
+func (x *ComparisonSummary) Reset() {
+	*x = ComparisonSummary{}
+	mi := &file_api_proto_agent_proto_msgTypes[199]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *CertificateInventoryItem) GetAutoRenew() bool {
+func (x *ComparisonSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComparisonSummary) ProtoMessage() {}
+
+func (x *ComparisonSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[199]
 	if x != nil {
-		return x.AutoRenew
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *CertificateInventoryItem) GetDeployedToCount() int32 {
+// Deprecated: Use ComparisonSummary.ProtoReflect.Descriptor instead.
+func (*ComparisonSummary) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{199}
+}
+
+func (x *ComparisonSummary) GetTotalChecks() int32 {
 	if x != nil {
-		return x.DeployedToCount
+		return x.TotalChecks
 	}
 	return 0
 }
 
-func (x *CertificateInventoryItem) GetCertContentHash() string {
+func (x *ComparisonSummary) GetIdenticalCount() int32 {
 	if x != nil {
-		return x.CertContentHash
+		return x.IdenticalCount
 	}
-	return ""
+	return 0
 }
 
-func (x *CertificateInventoryItem) GetCreatedAt() int64 {
+func (x *ComparisonSummary) GetDifferentCount() int32 {
 	if x != nil {
-		return x.CreatedAt
+		return x.DifferentCount
 	}
 	return 0
 }
 
-func (x *CertificateInventoryItem) GetUpdatedAt() int64 {
+func (x *ComparisonSummary) GetHasCriticalDiffs() bool {
 	if x != nil {
-		return x.UpdatedAt
+		return x.HasCriticalDiffs
 	}
-	return 0
+	return false
 }
 
-type UploadCertificateRequest struct {
+type GetComparisonRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
-	CertContent   []byte                 `protobuf:"bytes,2,opt,name=cert_content,json=certContent,proto3" json:"cert_content,omitempty"` // PEM format
-	KeyContent    []byte                 `protobuf:"bytes,3,opt,name=key_content,json=keyContent,proto3" json:"key_content,omitempty"`
-	ChainContent  []byte                 `protobuf:"bytes,4,opt,name=chain_content,json=chainContent,proto3" json:"chain_content,omitempty"` // Intermediate certs
-	CertType      string                 `protobuf:"bytes,5,opt,name=cert_type,json=certType,proto3" json:"cert_type,omitempty"`
-	EnvironmentId string                 `protobuf:"bytes,6,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	// Target selection for immediate deployment
-	AgentIds       []string `protobuf:"bytes,7,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
-	GroupId        string   `protobuf:"bytes,8,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	BackupExisting bool     `protobuf:"varint,9,opt,name=backup_existing,json=backupExisting,proto3" json:"backup_existing,omitempty"`
-	ReloadNginx    bool     `protobuf:"varint,10,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	ComparisonId  string                 `protobuf:"bytes,1,opt,name=comparison_id,json=comparisonId,proto3" json:"comparison_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UploadCertificateRequest) Reset() {
-	*x = UploadCertificateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[149]
+func (x *GetComparisonRequest) Reset() {
+	*x = GetComparisonRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[200]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadCertificateRequest) String() string {
+func (x *GetComparisonRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadCertificateRequest) ProtoMessage() {}
+func (*GetComparisonRequest) ProtoMessage() {}
 
-func (x *UploadCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[149]
+func (x *GetComparisonRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[200]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11113,106 +15632,157 @@ func (x *UploadCertificateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UploadCertificateRequest.ProtoReflect.Descriptor instead.
-func (*UploadCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{149}
+// Deprecated: Use GetComparisonRequest.ProtoReflect.Descriptor instead.
+func (*GetComparisonRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{200}
 }
 
-func (x *UploadCertificateRequest) GetDomain() string {
+func (x *GetComparisonRequest) GetComparisonId() string {
 	if x != nil {
-		return x.Domain
+		return x.ComparisonId
 	}
 	return ""
 }
 
-func (x *UploadCertificateRequest) GetCertContent() []byte {
+type SiteLocationUpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"` // "agent", "group", "environment"
+	TargetId      string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	ServerName    string                 `protobuf:"bytes,3,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	LocationPath  string                 `protobuf:"bytes,4,opt,name=location_path,json=locationPath,proto3" json:"location_path,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"` // "create", "update", "delete"
+	Config        *LocationConfigData    `protobuf:"bytes,6,opt,name=config,proto3" json:"config,omitempty"`
+	DryRun        bool                   `protobuf:"varint,7,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	BackupFirst   bool                   `protobuf:"varint,8,opt,name=backup_first,json=backupFirst,proto3" json:"backup_first,omitempty"`
+	ReloadNginx   bool                   `protobuf:"varint,9,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SiteLocationUpdateRequest) Reset() {
+	*x = SiteLocationUpdateRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[201]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SiteLocationUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SiteLocationUpdateRequest) ProtoMessage() {}
+
+func (x *SiteLocationUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[201]
 	if x != nil {
-		return x.CertContent
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *UploadCertificateRequest) GetKeyContent() []byte {
+// Deprecated: Use SiteLocationUpdateRequest.ProtoReflect.Descriptor instead.
+func (*SiteLocationUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{201}
+}
+
+func (x *SiteLocationUpdateRequest) GetTarget() string {
 	if x != nil {
-		return x.KeyContent
+		return x.Target
 	}
-	return nil
+	return ""
 }
 
-func (x *UploadCertificateRequest) GetChainContent() []byte {
+func (x *SiteLocationUpdateRequest) GetTargetId() string {
 	if x != nil {
-		return x.ChainContent
+		return x.TargetId
 	}
-	return nil
+	return ""
 }
 
-func (x *UploadCertificateRequest) GetCertType() string {
+func (x *SiteLocationUpdateRequest) GetServerName() string {
 	if x != nil {
-		return x.CertType
+		return x.ServerName
 	}
 	return ""
 }
 
-func (x *UploadCertificateRequest) GetEnvironmentId() string {
+func (x *SiteLocationUpdateRequest) GetLocationPath() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.LocationPath
 	}
 	return ""
 }
 
-func (x *UploadCertificateRequest) GetAgentIds() []string {
+func (x *SiteLocationUpdateRequest) GetAction() string {
 	if x != nil {
-		return x.AgentIds
+		return x.Action
+	}
+	return ""
+}
+
+func (x *SiteLocationUpdateRequest) GetConfig() *LocationConfigData {
+	if x != nil {
+		return x.Config
 	}
 	return nil
 }
 
-func (x *UploadCertificateRequest) GetGroupId() string {
+func (x *SiteLocationUpdateRequest) GetDryRun() bool {
 	if x != nil {
-		return x.GroupId
+		return x.DryRun
 	}
-	return ""
+	return false
 }
 
-func (x *UploadCertificateRequest) GetBackupExisting() bool {
+func (x *SiteLocationUpdateRequest) GetBackupFirst() bool {
 	if x != nil {
-		return x.BackupExisting
+		return x.BackupFirst
 	}
 	return false
 }
 
-func (x *UploadCertificateRequest) GetReloadNginx() bool {
+func (x *SiteLocationUpdateRequest) GetReloadNginx() bool {
 	if x != nil {
 		return x.ReloadNginx
 	}
 	return false
 }
 
-type UploadCertificateResponse struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	Success       bool                    `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	CertificateId string                  `protobuf:"bytes,2,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
-	Deployments   []*CertDeploymentResult `protobuf:"bytes,3,rep,name=deployments,proto3" json:"deployments,omitempty"`
-	Error         string                  `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type LocationConfigData struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ProxyPass           string                 `protobuf:"bytes,1,opt,name=proxy_pass,json=proxyPass,proto3" json:"proxy_pass,omitempty"`
+	ProxyHeaders        map[string]string      `protobuf:"bytes,2,rep,name=proxy_headers,json=proxyHeaders,proto3" json:"proxy_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ProxyConnectTimeout int32                  `protobuf:"varint,3,opt,name=proxy_connect_timeout,json=proxyConnectTimeout,proto3" json:"proxy_connect_timeout,omitempty"`
+	ProxyReadTimeout    int32                  `protobuf:"varint,4,opt,name=proxy_read_timeout,json=proxyReadTimeout,proto3" json:"proxy_read_timeout,omitempty"`
+	RateLimit           *RateLimitConfigData   `protobuf:"bytes,5,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	Cache               *CacheConfigData       `protobuf:"bytes,6,opt,name=cache,proto3" json:"cache,omitempty"`
+	AllowedMethods      []string               `protobuf:"bytes,7,rep,name=allowed_methods,json=allowedMethods,proto3" json:"allowed_methods,omitempty"`
+	DenyIps             []string               `protobuf:"bytes,8,rep,name=deny_ips,json=denyIps,proto3" json:"deny_ips,omitempty"`
+	AllowIps            []string               `protobuf:"bytes,9,rep,name=allow_ips,json=allowIps,proto3" json:"allow_ips,omitempty"`
+	CustomDirectives    string                 `protobuf:"bytes,10,opt,name=custom_directives,json=customDirectives,proto3" json:"custom_directives,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
-func (x *UploadCertificateResponse) Reset() {
-	*x = UploadCertificateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[150]
+func (x *LocationConfigData) Reset() {
+	*x = LocationConfigData{}
+	mi := &file_api_proto_agent_proto_msgTypes[202]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadCertificateResponse) String() string {
+func (x *LocationConfigData) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadCertificateResponse) ProtoMessage() {}
+func (*LocationConfigData) ProtoMessage() {}
 
-func (x *UploadCertificateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[150]
+func (x *LocationConfigData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[202]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11223,147 +15793,106 @@ func (x *UploadCertificateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UploadCertificateResponse.ProtoReflect.Descriptor instead.
-func (*UploadCertificateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{150}
-}
-
-func (x *UploadCertificateResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
+// Deprecated: Use LocationConfigData.ProtoReflect.Descriptor instead.
+func (*LocationConfigData) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{202}
 }
 
-func (x *UploadCertificateResponse) GetCertificateId() string {
+func (x *LocationConfigData) GetProxyPass() string {
 	if x != nil {
-		return x.CertificateId
+		return x.ProxyPass
 	}
 	return ""
 }
 
-func (x *UploadCertificateResponse) GetDeployments() []*CertDeploymentResult {
+func (x *LocationConfigData) GetProxyHeaders() map[string]string {
 	if x != nil {
-		return x.Deployments
+		return x.ProxyHeaders
 	}
 	return nil
 }
 
-func (x *UploadCertificateResponse) GetError() string {
+func (x *LocationConfigData) GetProxyConnectTimeout() int32 {
 	if x != nil {
-		return x.Error
+		return x.ProxyConnectTimeout
 	}
-	return ""
-}
-
-type CertDeploymentResult struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
-	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	CertPath      string                 `protobuf:"bytes,4,opt,name=cert_path,json=certPath,proto3" json:"cert_path,omitempty"`
-	KeyPath       string                 `protobuf:"bytes,5,opt,name=key_path,json=keyPath,proto3" json:"key_path,omitempty"`
-	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *CertDeploymentResult) Reset() {
-	*x = CertDeploymentResult{}
-	mi := &file_api_proto_agent_proto_msgTypes[151]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *CertDeploymentResult) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*CertDeploymentResult) ProtoMessage() {}
-
-func (x *CertDeploymentResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[151]
+func (x *LocationConfigData) GetProxyReadTimeout() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ProxyReadTimeout
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use CertDeploymentResult.ProtoReflect.Descriptor instead.
-func (*CertDeploymentResult) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{151}
+	return 0
 }
 
-func (x *CertDeploymentResult) GetAgentId() string {
+func (x *LocationConfigData) GetRateLimit() *RateLimitConfigData {
 	if x != nil {
-		return x.AgentId
+		return x.RateLimit
 	}
-	return ""
+	return nil
 }
 
-func (x *CertDeploymentResult) GetHostname() string {
+func (x *LocationConfigData) GetCache() *CacheConfigData {
 	if x != nil {
-		return x.Hostname
+		return x.Cache
 	}
-	return ""
+	return nil
 }
 
-func (x *CertDeploymentResult) GetSuccess() bool {
+func (x *LocationConfigData) GetAllowedMethods() []string {
 	if x != nil {
-		return x.Success
+		return x.AllowedMethods
 	}
-	return false
+	return nil
 }
 
-func (x *CertDeploymentResult) GetCertPath() string {
+func (x *LocationConfigData) GetDenyIps() []string {
 	if x != nil {
-		return x.CertPath
+		return x.DenyIps
 	}
-	return ""
+	return nil
 }
 
-func (x *CertDeploymentResult) GetKeyPath() string {
+func (x *LocationConfigData) GetAllowIps() []string {
 	if x != nil {
-		return x.KeyPath
+		return x.AllowIps
 	}
-	return ""
+	return nil
 }
 
-func (x *CertDeploymentResult) GetError() string {
+func (x *LocationConfigData) GetCustomDirectives() string {
 	if x != nil {
-		return x.Error
+		return x.CustomDirectives
 	}
 	return ""
 }
 
-type GetCertificateInventoryRequest struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	EnvironmentId      string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	ExpiringOnly       bool                   `protobuf:"varint,2,opt,name=expiring_only,json=expiringOnly,proto3" json:"expiring_only,omitempty"`
-	ExpiringWithinDays int32                  `protobuf:"varint,3,opt,name=expiring_within_days,json=expiringWithinDays,proto3" json:"expiring_within_days,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type RateLimitConfigData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Zone          string                 `protobuf:"bytes,1,opt,name=zone,proto3" json:"zone,omitempty"`
+	Rate          string                 `protobuf:"bytes,2,opt,name=rate,proto3" json:"rate,omitempty"` // e.g., "10r/s"
+	Burst         int32                  `protobuf:"varint,3,opt,name=burst,proto3" json:"burst,omitempty"`
+	NoDelay       bool                   `protobuf:"varint,4,opt,name=no_delay,json=noDelay,proto3" json:"no_delay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetCertificateInventoryRequest) Reset() {
-	*x = GetCertificateInventoryRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[152]
+func (x *RateLimitConfigData) Reset() {
+	*x = RateLimitConfigData{}
+	mi := &file_api_proto_agent_proto_msgTypes[203]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetCertificateInventoryRequest) String() string {
+func (x *RateLimitConfigData) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetCertificateInventoryRequest) ProtoMessage() {}
+func (*RateLimitConfigData) ProtoMessage() {}
 
-func (x *GetCertificateInventoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[152]
+func (x *RateLimitConfigData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[203]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11374,103 +15903,65 @@ func (x *GetCertificateInventoryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetCertificateInventoryRequest.ProtoReflect.Descriptor instead.
-func (*GetCertificateInventoryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{152}
+// Deprecated: Use RateLimitConfigData.ProtoReflect.Descriptor instead.
+func (*RateLimitConfigData) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{203}
 }
 
-func (x *GetCertificateInventoryRequest) GetEnvironmentId() string {
+func (x *RateLimitConfigData) GetZone() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.Zone
 	}
 	return ""
 }
 
-func (x *GetCertificateInventoryRequest) GetExpiringOnly() bool {
+func (x *RateLimitConfigData) GetRate() string {
 	if x != nil {
-		return x.ExpiringOnly
+		return x.Rate
 	}
-	return false
+	return ""
 }
 
-func (x *GetCertificateInventoryRequest) GetExpiringWithinDays() int32 {
+func (x *RateLimitConfigData) GetBurst() int32 {
 	if x != nil {
-		return x.ExpiringWithinDays
+		return x.Burst
 	}
 	return 0
 }
 
-type GetCertificateInventoryResponse struct {
-	state         protoimpl.MessageState      `protogen:"open.v1"`
-	Certificates  []*CertificateInventoryItem `protobuf:"bytes,1,rep,name=certificates,proto3" json:"certificates,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GetCertificateInventoryResponse) Reset() {
-	*x = GetCertificateInventoryResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[153]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *GetCertificateInventoryResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*GetCertificateInventoryResponse) ProtoMessage() {}
-
-func (x *GetCertificateInventoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[153]
-	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use GetCertificateInventoryResponse.ProtoReflect.Descriptor instead.
-func (*GetCertificateInventoryResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{153}
-}
-
-func (x *GetCertificateInventoryResponse) GetCertificates() []*CertificateInventoryItem {
+func (x *RateLimitConfigData) GetNoDelay() bool {
 	if x != nil {
-		return x.Certificates
+		return x.NoDelay
 	}
-	return nil
+	return false
 }
 
-type DeployCertificateRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	CertificateId  string                 `protobuf:"bytes,1,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
-	AgentIds       []string               `protobuf:"bytes,2,rep,name=agent_ids,json=agentIds,proto3" json:"agent_ids,omitempty"`
-	GroupId        string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
-	EnvironmentId  string                 `protobuf:"bytes,4,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
-	BackupExisting bool                   `protobuf:"varint,5,opt,name=backup_existing,json=backupExisting,proto3" json:"backup_existing,omitempty"`
-	ReloadNginx    bool                   `protobuf:"varint,6,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type CacheConfigData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Zone          string                 `protobuf:"bytes,1,opt,name=zone,proto3" json:"zone,omitempty"`
+	Valid         []string               `protobuf:"bytes,2,rep,name=valid,proto3" json:"valid,omitempty"` // ["200 302 10m", "404 1m"]
+	Methods       []string               `protobuf:"bytes,3,rep,name=methods,proto3" json:"methods,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	BypassVar     string                 `protobuf:"bytes,5,opt,name=bypass_var,json=bypassVar,proto3" json:"bypass_var,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeployCertificateRequest) Reset() {
-	*x = DeployCertificateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[154]
+func (x *CacheConfigData) Reset() {
+	*x = CacheConfigData{}
+	mi := &file_api_proto_agent_proto_msgTypes[204]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeployCertificateRequest) String() string {
+func (x *CacheConfigData) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeployCertificateRequest) ProtoMessage() {}
+func (*CacheConfigData) ProtoMessage() {}
 
-func (x *DeployCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[154]
+func (x *CacheConfigData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[204]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11481,75 +15972,70 @@ func (x *DeployCertificateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeployCertificateRequest.ProtoReflect.Descriptor instead.
-func (*DeployCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{154}
+// Deprecated: Use CacheConfigData.ProtoReflect.Descriptor instead.
+func (*CacheConfigData) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{204}
 }
 
-func (x *DeployCertificateRequest) GetCertificateId() string {
+func (x *CacheConfigData) GetZone() string {
 	if x != nil {
-		return x.CertificateId
+		return x.Zone
 	}
 	return ""
 }
 
-func (x *DeployCertificateRequest) GetAgentIds() []string {
+func (x *CacheConfigData) GetValid() []string {
 	if x != nil {
-		return x.AgentIds
+		return x.Valid
 	}
 	return nil
 }
 
-func (x *DeployCertificateRequest) GetGroupId() string {
+func (x *CacheConfigData) GetMethods() []string {
 	if x != nil {
-		return x.GroupId
+		return x.Methods
 	}
-	return ""
+	return nil
 }
 
-func (x *DeployCertificateRequest) GetEnvironmentId() string {
+func (x *CacheConfigData) GetKey() string {
 	if x != nil {
-		return x.EnvironmentId
+		return x.Key
 	}
 	return ""
 }
 
-func (x *DeployCertificateRequest) GetBackupExisting() bool {
-	if x != nil {
-		return x.BackupExisting
-	}
-	return false
-}
-
-func (x *DeployCertificateRequest) GetReloadNginx() bool {
+func (x *CacheConfigData) GetBypassVar() string {
 	if x != nil {
-		return x.ReloadNginx
+		return x.BypassVar
 	}
-	return false
+	return ""
 }
 
-type DeleteCertificateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	CertificateId string                 `protobuf:"bytes,1,opt,name=certificate_id,json=certificateId,proto3" json:"certificate_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SiteLocationUpdateResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Results         []*AgentUpdateResult   `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	ValidationError string                 `protobuf:"bytes,3,opt,name=validation_error,json=validationError,proto3" json:"validation_error,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *DeleteCertificateRequest) Reset() {
-	*x = DeleteCertificateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[155]
+func (x *SiteLocationUpdateResponse) Reset() {
+	*x = SiteLocationUpdateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[205]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteCertificateRequest) String() string {
+func (x *SiteLocationUpdateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteCertificateRequest) ProtoMessage() {}
+func (*SiteLocationUpdateResponse) ProtoMessage() {}
 
-func (x *DeleteCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[155]
+func (x *SiteLocationUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[205]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11560,41 +16046,54 @@ func (x *DeleteCertificateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteCertificateRequest.ProtoReflect.Descriptor instead.
-func (*DeleteCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{155}
+// Deprecated: Use SiteLocationUpdateResponse.ProtoReflect.Descriptor instead.
+func (*SiteLocationUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{205}
 }
 
-func (x *DeleteCertificateRequest) GetCertificateId() string {
+func (x *SiteLocationUpdateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.CertificateId
+		return x.Success
+	}
+	return false
+}
+
+func (x *SiteLocationUpdateResponse) GetResults() []*AgentUpdateResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SiteLocationUpdateResponse) GetValidationError() string {
+	if x != nil {
+		return x.ValidationError
 	}
 	return ""
 }
 
-type DeleteCertificateResponse struct {
+type UpstreamListRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteCertificateResponse) Reset() {
-	*x = DeleteCertificateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[156]
+func (x *UpstreamListRequest) Reset() {
+	*x = UpstreamListRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[206]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteCertificateResponse) String() string {
+func (x *UpstreamListRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteCertificateResponse) ProtoMessage() {}
+func (*UpstreamListRequest) ProtoMessage() {}
 
-func (x *DeleteCertificateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[156]
+func (x *UpstreamListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[206]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11605,51 +16104,47 @@ func (x *DeleteCertificateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteCertificateResponse.ProtoReflect.Descriptor instead.
-func (*DeleteCertificateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{156}
-}
-
-func (x *DeleteCertificateResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
+// Deprecated: Use UpstreamListRequest.ProtoReflect.Descriptor instead.
+func (*UpstreamListRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{206}
 }
 
-func (x *DeleteCertificateResponse) GetMessage() string {
+func (x *UpstreamListRequest) GetInstanceId() string {
 	if x != nil {
-		return x.Message
+		return x.InstanceId
 	}
 	return ""
 }
 
-type CompareEnvironmentsRequest struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	SourceEnvironmentId string                 `protobuf:"bytes,1,opt,name=source_environment_id,json=sourceEnvironmentId,proto3" json:"source_environment_id,omitempty"`
-	TargetEnvironmentId string                 `protobuf:"bytes,2,opt,name=target_environment_id,json=targetEnvironmentId,proto3" json:"target_environment_id,omitempty"`
-	CompareTypes        []string               `protobuf:"bytes,3,rep,name=compare_types,json=compareTypes,proto3" json:"compare_types,omitempty"` // ["nginx_main_conf", "ssl_certs", "maintenance"]
-	IncludeDiffContent  bool                   `protobuf:"varint,4,opt,name=include_diff_content,json=includeDiffContent,proto3" json:"include_diff_content,omitempty"`
-	GroupMapping        map[string]string      `protobuf:"bytes,5,rep,name=group_mapping,json=groupMapping,proto3" json:"group_mapping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // source_group_id -> target_group_id
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type UpstreamServer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Params        []string               `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"` // Remaining server parameters, e.g. "weight=2", "max_fails=3"
+	Weight        int32                  `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	Backup        bool                   `protobuf:"varint,4,opt,name=backup,proto3" json:"backup,omitempty"`
+	Down          bool                   `protobuf:"varint,5,opt,name=down,proto3" json:"down,omitempty"`
+	Drain         bool                   `protobuf:"varint,6,opt,name=drain,proto3" json:"drain,omitempty"`
+	State         string                 `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`                  // Runtime state from the Plus API: up, draining, down, unavail, checking, unhealthy
+	PeerId        int64                  `protobuf:"varint,8,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"` // Plus API server id (0 when the Plus API is not used)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CompareEnvironmentsRequest) Reset() {
-	*x = CompareEnvironmentsRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[157]
+func (x *UpstreamServer) Reset() {
+	*x = UpstreamServer{}
+	mi := &file_api_proto_agent_proto_msgTypes[207]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CompareEnvironmentsRequest) String() string {
+func (x *UpstreamServer) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CompareEnvironmentsRequest) ProtoMessage() {}
+func (*UpstreamServer) ProtoMessage() {}
 
-func (x *CompareEnvironmentsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[157]
+func (x *UpstreamServer) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[207]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11660,159 +16155,93 @@ func (x *CompareEnvironmentsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CompareEnvironmentsRequest.ProtoReflect.Descriptor instead.
-func (*CompareEnvironmentsRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{157}
-}
-
-func (x *CompareEnvironmentsRequest) GetSourceEnvironmentId() string {
-	if x != nil {
-		return x.SourceEnvironmentId
-	}
-	return ""
+// Deprecated: Use UpstreamServer.ProtoReflect.Descriptor instead.
+func (*UpstreamServer) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{207}
 }
 
-func (x *CompareEnvironmentsRequest) GetTargetEnvironmentId() string {
+func (x *UpstreamServer) GetAddress() string {
 	if x != nil {
-		return x.TargetEnvironmentId
+		return x.Address
 	}
 	return ""
 }
 
-func (x *CompareEnvironmentsRequest) GetCompareTypes() []string {
+func (x *UpstreamServer) GetParams() []string {
 	if x != nil {
-		return x.CompareTypes
+		return x.Params
 	}
 	return nil
 }
 
-func (x *CompareEnvironmentsRequest) GetIncludeDiffContent() bool {
-	if x != nil {
-		return x.IncludeDiffContent
-	}
-	return false
-}
-
-func (x *CompareEnvironmentsRequest) GetGroupMapping() map[string]string {
+func (x *UpstreamServer) GetWeight() int32 {
 	if x != nil {
-		return x.GroupMapping
+		return x.Weight
 	}
-	return nil
-}
-
-type CompareEnvironmentsResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	ComparisonId      string                 `protobuf:"bytes,1,opt,name=comparison_id,json=comparisonId,proto3" json:"comparison_id,omitempty"`
-	SourceEnvironment string                 `protobuf:"bytes,2,opt,name=source_environment,json=sourceEnvironment,proto3" json:"source_environment,omitempty"`
-	TargetEnvironment string                 `protobuf:"bytes,3,opt,name=target_environment,json=targetEnvironment,proto3" json:"target_environment,omitempty"`
-	ComparedAt        int64                  `protobuf:"varint,4,opt,name=compared_at,json=comparedAt,proto3" json:"compared_at,omitempty"`
-	Categories        []*ComparisonCategory  `protobuf:"bytes,5,rep,name=categories,proto3" json:"categories,omitempty"`
-	Summary           *ComparisonSummary     `protobuf:"bytes,6,opt,name=summary,proto3" json:"summary,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
-}
-
-func (x *CompareEnvironmentsResponse) Reset() {
-	*x = CompareEnvironmentsResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[158]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *CompareEnvironmentsResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*CompareEnvironmentsResponse) ProtoMessage() {}
-
-func (x *CompareEnvironmentsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[158]
+func (x *UpstreamServer) GetBackup() bool {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Backup
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use CompareEnvironmentsResponse.ProtoReflect.Descriptor instead.
-func (*CompareEnvironmentsResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{158}
+	return false
 }
 
-func (x *CompareEnvironmentsResponse) GetComparisonId() string {
+func (x *UpstreamServer) GetDown() bool {
 	if x != nil {
-		return x.ComparisonId
+		return x.Down
 	}
-	return ""
+	return false
 }
 
-func (x *CompareEnvironmentsResponse) GetSourceEnvironment() string {
+func (x *UpstreamServer) GetDrain() bool {
 	if x != nil {
-		return x.SourceEnvironment
+		return x.Drain
 	}
-	return ""
+	return false
 }
 
-func (x *CompareEnvironmentsResponse) GetTargetEnvironment() string {
+func (x *UpstreamServer) GetState() string {
 	if x != nil {
-		return x.TargetEnvironment
+		return x.State
 	}
 	return ""
 }
 
-func (x *CompareEnvironmentsResponse) GetComparedAt() int64 {
+func (x *UpstreamServer) GetPeerId() int64 {
 	if x != nil {
-		return x.ComparedAt
+		return x.PeerId
 	}
 	return 0
 }
 
-func (x *CompareEnvironmentsResponse) GetCategories() []*ComparisonCategory {
-	if x != nil {
-		return x.Categories
-	}
-	return nil
-}
-
-func (x *CompareEnvironmentsResponse) GetSummary() *ComparisonSummary {
-	if x != nil {
-		return x.Summary
-	}
-	return nil
-}
-
-type ComparisonCategory struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Type            string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
-	SourceCount     int32                  `protobuf:"varint,2,opt,name=source_count,json=sourceCount,proto3" json:"source_count,omitempty"`
-	TargetCount     int32                  `protobuf:"varint,3,opt,name=target_count,json=targetCount,proto3" json:"target_count,omitempty"`
-	SameCount       int32                  `protobuf:"varint,4,opt,name=same_count,json=sameCount,proto3" json:"same_count,omitempty"`
-	DifferentCount  int32                  `protobuf:"varint,5,opt,name=different_count,json=differentCount,proto3" json:"different_count,omitempty"`
-	SourceOnlyCount int32                  `protobuf:"varint,6,opt,name=source_only_count,json=sourceOnlyCount,proto3" json:"source_only_count,omitempty"`
-	TargetOnlyCount int32                  `protobuf:"varint,7,opt,name=target_only_count,json=targetOnlyCount,proto3" json:"target_only_count,omitempty"`
-	Differences     []*ConfigDifference    `protobuf:"bytes,8,rep,name=differences,proto3" json:"differences,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type UpstreamPool struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"` // Config file that defines the upstream
+	Servers       []*UpstreamServer      `protobuf:"bytes,3,rep,name=servers,proto3" json:"servers,omitempty"`
+	Directives    map[string]string      `protobuf:"bytes,4,rep,name=directives,proto3" json:"directives,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Dynamic       bool                   `protobuf:"varint,5,opt,name=dynamic,proto3" json:"dynamic,omitempty"` // Managed through the Plus API (upstream has a shared memory zone)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ComparisonCategory) Reset() {
-	*x = ComparisonCategory{}
-	mi := &file_api_proto_agent_proto_msgTypes[159]
+func (x *UpstreamPool) Reset() {
+	*x = UpstreamPool{}
+	mi := &file_api_proto_agent_proto_msgTypes[208]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ComparisonCategory) String() string {
+func (x *UpstreamPool) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ComparisonCategory) ProtoMessage() {}
+func (*UpstreamPool) ProtoMessage() {}
 
-func (x *ComparisonCategory) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[159]
+func (x *UpstreamPool) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[208]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11823,94 +16252,132 @@ func (x *ComparisonCategory) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ComparisonCategory.ProtoReflect.Descriptor instead.
-func (*ComparisonCategory) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{159}
+// Deprecated: Use UpstreamPool.ProtoReflect.Descriptor instead.
+func (*UpstreamPool) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{208}
+}
+
+func (x *UpstreamPool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-func (x *ComparisonCategory) GetType() string {
+func (x *UpstreamPool) GetFile() string {
 	if x != nil {
-		return x.Type
+		return x.File
 	}
 	return ""
 }
 
-func (x *ComparisonCategory) GetSourceCount() int32 {
+func (x *UpstreamPool) GetServers() []*UpstreamServer {
 	if x != nil {
-		return x.SourceCount
+		return x.Servers
 	}
-	return 0
+	return nil
 }
 
-func (x *ComparisonCategory) GetTargetCount() int32 {
+func (x *UpstreamPool) GetDirectives() map[string]string {
 	if x != nil {
-		return x.TargetCount
+		return x.Directives
 	}
-	return 0
+	return nil
 }
 
-func (x *ComparisonCategory) GetSameCount() int32 {
+func (x *UpstreamPool) GetDynamic() bool {
 	if x != nil {
-		return x.SameCount
+		return x.Dynamic
 	}
-	return 0
+	return false
 }
 
-func (x *ComparisonCategory) GetDifferentCount() int32 {
+type UpstreamListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Upstreams     []*UpstreamPool        `protobuf:"bytes,1,rep,name=upstreams,proto3" json:"upstreams,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	PlusApi       bool                   `protobuf:"varint,3,opt,name=plus_api,json=plusApi,proto3" json:"plus_api,omitempty"` // The NGINX Plus API is configured and reachable
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpstreamListResponse) Reset() {
+	*x = UpstreamListResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[209]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpstreamListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpstreamListResponse) ProtoMessage() {}
+
+func (x *UpstreamListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[209]
 	if x != nil {
-		return x.DifferentCount
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *ComparisonCategory) GetSourceOnlyCount() int32 {
+// Deprecated: Use UpstreamListResponse.ProtoReflect.Descriptor instead.
+func (*UpstreamListResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{209}
+}
+
+func (x *UpstreamListResponse) GetUpstreams() []*UpstreamPool {
 	if x != nil {
-		return x.SourceOnlyCount
+		return x.Upstreams
 	}
-	return 0
+	return nil
 }
 
-func (x *ComparisonCategory) GetTargetOnlyCount() int32 {
+func (x *UpstreamListResponse) GetError() string {
 	if x != nil {
-		return x.TargetOnlyCount
+		return x.Error
 	}
-	return 0
+	return ""
 }
 
-func (x *ComparisonCategory) GetDifferences() []*ConfigDifference {
+func (x *UpstreamListResponse) GetPlusApi() bool {
 	if x != nil {
-		return x.Differences
+		return x.PlusApi
 	}
-	return nil
+	return false
 }
 
-type ConfigDifference struct {
+type UpstreamServerUpdate struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"` // filename, domain, etc.
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`         // "same", "different", "source_only", "target_only"
-	SourceValue   string                 `protobuf:"bytes,3,opt,name=source_value,json=sourceValue,proto3" json:"source_value,omitempty"`
-	TargetValue   string                 `protobuf:"bytes,4,opt,name=target_value,json=targetValue,proto3" json:"target_value,omitempty"`
-	Diff          string                 `protobuf:"bytes,5,opt,name=diff,proto3" json:"diff,omitempty"` // Unified diff
-	Severity      string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	Upstream      string                 `protobuf:"bytes,2,opt,name=upstream,proto3" json:"upstream,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Action        string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"` // add, remove, drain, disable, enable
+	Params        []string               `protobuf:"bytes,5,rep,name=params,proto3" json:"params,omitempty"` // Server parameters for "add", e.g. "weight=2"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigDifference) Reset() {
-	*x = ConfigDifference{}
-	mi := &file_api_proto_agent_proto_msgTypes[160]
+func (x *UpstreamServerUpdate) Reset() {
+	*x = UpstreamServerUpdate{}
+	mi := &file_api_proto_agent_proto_msgTypes[210]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigDifference) String() string {
+func (x *UpstreamServerUpdate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigDifference) ProtoMessage() {}
+func (*UpstreamServerUpdate) ProtoMessage() {}
 
-func (x *ConfigDifference) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[160]
+func (x *UpstreamServerUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[210]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -11921,78 +16388,70 @@ func (x *ConfigDifference) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigDifference.ProtoReflect.Descriptor instead.
-func (*ConfigDifference) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{160}
-}
-
-func (x *ConfigDifference) GetIdentifier() string {
-	if x != nil {
-		return x.Identifier
-	}
-	return ""
+// Deprecated: Use UpstreamServerUpdate.ProtoReflect.Descriptor instead.
+func (*UpstreamServerUpdate) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{210}
 }
 
-func (x *ConfigDifference) GetStatus() string {
+func (x *UpstreamServerUpdate) GetInstanceId() string {
 	if x != nil {
-		return x.Status
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *ConfigDifference) GetSourceValue() string {
+func (x *UpstreamServerUpdate) GetUpstream() string {
 	if x != nil {
-		return x.SourceValue
+		return x.Upstream
 	}
 	return ""
 }
 
-func (x *ConfigDifference) GetTargetValue() string {
+func (x *UpstreamServerUpdate) GetAddress() string {
 	if x != nil {
-		return x.TargetValue
+		return x.Address
 	}
 	return ""
 }
 
-func (x *ConfigDifference) GetDiff() string {
+func (x *UpstreamServerUpdate) GetAction() string {
 	if x != nil {
-		return x.Diff
+		return x.Action
 	}
 	return ""
 }
 
-func (x *ConfigDifference) GetSeverity() string {
+func (x *UpstreamServerUpdate) GetParams() []string {
 	if x != nil {
-		return x.Severity
+		return x.Params
 	}
-	return ""
+	return nil
 }
 
-type ComparisonSummary struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	TotalChecks      int32                  `protobuf:"varint,1,opt,name=total_checks,json=totalChecks,proto3" json:"total_checks,omitempty"`
-	IdenticalCount   int32                  `protobuf:"varint,2,opt,name=identical_count,json=identicalCount,proto3" json:"identical_count,omitempty"`
-	DifferentCount   int32                  `protobuf:"varint,3,opt,name=different_count,json=differentCount,proto3" json:"different_count,omitempty"`
-	HasCriticalDiffs bool                   `protobuf:"varint,4,opt,name=has_critical_diffs,json=hasCriticalDiffs,proto3" json:"has_critical_diffs,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+type UpstreamServerUpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Method        string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"` // "plus_api" (runtime change) or "config" (config rewritten and reloaded)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ComparisonSummary) Reset() {
-	*x = ComparisonSummary{}
-	mi := &file_api_proto_agent_proto_msgTypes[161]
+func (x *UpstreamServerUpdateResponse) Reset() {
+	*x = UpstreamServerUpdateResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[211]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ComparisonSummary) String() string {
+func (x *UpstreamServerUpdateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ComparisonSummary) ProtoMessage() {}
+func (*UpstreamServerUpdateResponse) ProtoMessage() {}
 
-func (x *ComparisonSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[161]
+func (x *UpstreamServerUpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[211]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12003,61 +16462,56 @@ func (x *ComparisonSummary) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ComparisonSummary.ProtoReflect.Descriptor instead.
-func (*ComparisonSummary) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{161}
-}
-
-func (x *ComparisonSummary) GetTotalChecks() int32 {
-	if x != nil {
-		return x.TotalChecks
-	}
-	return 0
+// Deprecated: Use UpstreamServerUpdateResponse.ProtoReflect.Descriptor instead.
+func (*UpstreamServerUpdateResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{211}
 }
 
-func (x *ComparisonSummary) GetIdenticalCount() int32 {
+func (x *UpstreamServerUpdateResponse) GetSuccess() bool {
 	if x != nil {
-		return x.IdenticalCount
+		return x.Success
 	}
-	return 0
+	return false
 }
 
-func (x *ComparisonSummary) GetDifferentCount() int32 {
+func (x *UpstreamServerUpdateResponse) GetError() string {
 	if x != nil {
-		return x.DifferentCount
+		return x.Error
 	}
-	return 0
+	return ""
 }
 
-func (x *ComparisonSummary) GetHasCriticalDiffs() bool {
+func (x *UpstreamServerUpdateResponse) GetMethod() string {
 	if x != nil {
-		return x.HasCriticalDiffs
+		return x.Method
 	}
-	return false
+	return ""
 }
 
-type GetComparisonRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ComparisonId  string                 `protobuf:"bytes,1,opt,name=comparison_id,json=comparisonId,proto3" json:"comparison_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ServiceStatusRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`                  // agent
+	NginxInstanceId string                 `protobuf:"bytes,2,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"` // NginxInstance.instance_id; empty for the primary instance
+	JournalLines    int32                  `protobuf:"varint,3,opt,name=journal_lines,json=journalLines,proto3" json:"journal_lines,omitempty"`           // journal lines around the last failure; 0 for the default of 50
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *GetComparisonRequest) Reset() {
-	*x = GetComparisonRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[162]
+func (x *ServiceStatusRequest) Reset() {
+	*x = ServiceStatusRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[212]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetComparisonRequest) String() string {
+func (x *ServiceStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetComparisonRequest) ProtoMessage() {}
+func (*ServiceStatusRequest) ProtoMessage() {}
 
-func (x *GetComparisonRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[162]
+func (x *ServiceStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[212]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12068,48 +16522,70 @@ func (x *GetComparisonRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetComparisonRequest.ProtoReflect.Descriptor instead.
-func (*GetComparisonRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{162}
+// Deprecated: Use ServiceStatusRequest.ProtoReflect.Descriptor instead.
+func (*ServiceStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{212}
 }
 
-func (x *GetComparisonRequest) GetComparisonId() string {
+func (x *ServiceStatusRequest) GetInstanceId() string {
 	if x != nil {
-		return x.ComparisonId
+		return x.InstanceId
 	}
 	return ""
 }
 
-type SiteLocationUpdateRequest struct {
+func (x *ServiceStatusRequest) GetNginxInstanceId() string {
+	if x != nil {
+		return x.NginxInstanceId
+	}
+	return ""
+}
+
+func (x *ServiceStatusRequest) GetJournalLines() int32 {
+	if x != nil {
+		return x.JournalLines
+	}
+	return 0
+}
+
+// ServiceStatus is the state of the service manager unit running nginx
+type ServiceStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"` // "agent", "group", "environment"
-	TargetId      string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
-	ServerName    string                 `protobuf:"bytes,3,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
-	LocationPath  string                 `protobuf:"bytes,4,opt,name=location_path,json=locationPath,proto3" json:"location_path,omitempty"`
-	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"` // "create", "update", "delete"
-	Config        *LocationConfigData    `protobuf:"bytes,6,opt,name=config,proto3" json:"config,omitempty"`
-	DryRun        bool                   `protobuf:"varint,7,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
-	BackupFirst   bool                   `protobuf:"varint,8,opt,name=backup_first,json=backupFirst,proto3" json:"backup_first,omitempty"`
-	ReloadNginx   bool                   `protobuf:"varint,9,opt,name=reload_nginx,json=reloadNginx,proto3" json:"reload_nginx,omitempty"`
+	Manager       string                 `protobuf:"bytes,1,opt,name=manager,proto3" json:"manager,omitempty"`                   // "systemd" or "openrc"
+	Unit          string                 `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`                         // e.g. "nginx.service"
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`                       // systemd ActiveState (active, failed, ...) or openrc status (started, crashed, ...)
+	SubState      string                 `protobuf:"bytes,4,opt,name=sub_state,json=subState,proto3" json:"sub_state,omitempty"` // systemd SubState, e.g. "running", "auto-restart"
+	Result        string                 `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`                     // outcome of the last run: "success", "exit-code", "signal", "start-limit-hit", ...
+	MainPid       int32                  `protobuf:"varint,6,opt,name=main_pid,json=mainPid,proto3" json:"main_pid,omitempty"`
+	StartedAt     int64                  `protobuf:"varint,7,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`               // unix seconds the running main process started
+	LastFailureAt int64                  `protobuf:"varint,8,opt,name=last_failure_at,json=lastFailureAt,proto3" json:"last_failure_at,omitempty"` // unix seconds, 0 when none was seen
+	RestartCount  int32                  `protobuf:"varint,9,opt,name=restart_count,json=restartCount,proto3" json:"restart_count,omitempty"`      // automatic restarts by systemd since the unit was last started by hand (NRestarts)
+	FailureCount  int32                  `protobuf:"varint,10,opt,name=failure_count,json=failureCount,proto3" json:"failure_count,omitempty"`     // failures seen since the agent started
+	RestartLoop   bool                   `protobuf:"varint,11,opt,name=restart_loop,json=restartLoop,proto3" json:"restart_loop,omitempty"`        // restarted 3 times within 10 minutes, or systemd gave up on it
+	History       []*ServiceEvent        `protobuf:"bytes,12,rep,name=history,proto3" json:"history,omitempty"`                                    // recent restarts and failures, oldest first
+	Journal       []string               `protobuf:"bytes,13,rep,name=journal,proto3" json:"journal,omitempty"`                                    // journal lines around the last failure (GetServiceStatus only)
+	NewRestarts   int32                  `protobuf:"varint,14,opt,name=new_restarts,json=newRestarts,proto3" json:"new_restarts,omitempty"`        // restarts since the previous metrics report
+	NewFailures   int32                  `protobuf:"varint,15,opt,name=new_failures,json=newFailures,proto3" json:"new_failures,omitempty"`        // failures since the previous metrics report
+	Error         string                 `protobuf:"bytes,16,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SiteLocationUpdateRequest) Reset() {
-	*x = SiteLocationUpdateRequest{}
-	mi := &file_api_proto_agent_proto_msgTypes[163]
+func (x *ServiceStatus) Reset() {
+	*x = ServiceStatus{}
+	mi := &file_api_proto_agent_proto_msgTypes[213]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SiteLocationUpdateRequest) String() string {
+func (x *ServiceStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SiteLocationUpdateRequest) ProtoMessage() {}
+func (*ServiceStatus) ProtoMessage() {}
 
-func (x *SiteLocationUpdateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[163]
+func (x *ServiceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[213]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12120,215 +16596,211 @@ func (x *SiteLocationUpdateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SiteLocationUpdateRequest.ProtoReflect.Descriptor instead.
-func (*SiteLocationUpdateRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{163}
+// Deprecated: Use ServiceStatus.ProtoReflect.Descriptor instead.
+func (*ServiceStatus) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{213}
 }
 
-func (x *SiteLocationUpdateRequest) GetTarget() string {
+func (x *ServiceStatus) GetManager() string {
 	if x != nil {
-		return x.Target
+		return x.Manager
 	}
 	return ""
 }
 
-func (x *SiteLocationUpdateRequest) GetTargetId() string {
+func (x *ServiceStatus) GetUnit() string {
 	if x != nil {
-		return x.TargetId
+		return x.Unit
 	}
 	return ""
 }
 
-func (x *SiteLocationUpdateRequest) GetServerName() string {
+func (x *ServiceStatus) GetState() string {
 	if x != nil {
-		return x.ServerName
+		return x.State
 	}
 	return ""
 }
 
-func (x *SiteLocationUpdateRequest) GetLocationPath() string {
+func (x *ServiceStatus) GetSubState() string {
 	if x != nil {
-		return x.LocationPath
+		return x.SubState
 	}
 	return ""
 }
 
-func (x *SiteLocationUpdateRequest) GetAction() string {
+func (x *ServiceStatus) GetResult() string {
 	if x != nil {
-		return x.Action
+		return x.Result
 	}
 	return ""
 }
 
-func (x *SiteLocationUpdateRequest) GetConfig() *LocationConfigData {
+func (x *ServiceStatus) GetMainPid() int32 {
 	if x != nil {
-		return x.Config
+		return x.MainPid
 	}
-	return nil
+	return 0
 }
 
-func (x *SiteLocationUpdateRequest) GetDryRun() bool {
+func (x *ServiceStatus) GetStartedAt() int64 {
 	if x != nil {
-		return x.DryRun
+		return x.StartedAt
 	}
-	return false
+	return 0
 }
 
-func (x *SiteLocationUpdateRequest) GetBackupFirst() bool {
+func (x *ServiceStatus) GetLastFailureAt() int64 {
 	if x != nil {
-		return x.BackupFirst
+		return x.LastFailureAt
 	}
-	return false
+	return 0
 }
 
-func (x *SiteLocationUpdateRequest) GetReloadNginx() bool {
+func (x *ServiceStatus) GetRestartCount() int32 {
 	if x != nil {
-		return x.ReloadNginx
+		return x.RestartCount
 	}
-	return false
-}
-
-type LocationConfigData struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	ProxyPass           string                 `protobuf:"bytes,1,opt,name=proxy_pass,json=proxyPass,proto3" json:"proxy_pass,omitempty"`
-	ProxyHeaders        map[string]string      `protobuf:"bytes,2,rep,name=proxy_headers,json=proxyHeaders,proto3" json:"proxy_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ProxyConnectTimeout int32                  `protobuf:"varint,3,opt,name=proxy_connect_timeout,json=proxyConnectTimeout,proto3" json:"proxy_connect_timeout,omitempty"`
-	ProxyReadTimeout    int32                  `protobuf:"varint,4,opt,name=proxy_read_timeout,json=proxyReadTimeout,proto3" json:"proxy_read_timeout,omitempty"`
-	RateLimit           *RateLimitConfigData   `protobuf:"bytes,5,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
-	Cache               *CacheConfigData       `protobuf:"bytes,6,opt,name=cache,proto3" json:"cache,omitempty"`
-	AllowedMethods      []string               `protobuf:"bytes,7,rep,name=allowed_methods,json=allowedMethods,proto3" json:"allowed_methods,omitempty"`
-	DenyIps             []string               `protobuf:"bytes,8,rep,name=deny_ips,json=denyIps,proto3" json:"deny_ips,omitempty"`
-	AllowIps            []string               `protobuf:"bytes,9,rep,name=allow_ips,json=allowIps,proto3" json:"allow_ips,omitempty"`
-	CustomDirectives    string                 `protobuf:"bytes,10,opt,name=custom_directives,json=customDirectives,proto3" json:"custom_directives,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
-}
-
-func (x *LocationConfigData) Reset() {
-	*x = LocationConfigData{}
-	mi := &file_api_proto_agent_proto_msgTypes[164]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *LocationConfigData) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*LocationConfigData) ProtoMessage() {}
-
-func (x *LocationConfigData) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[164]
+func (x *ServiceStatus) GetFailureCount() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.FailureCount
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use LocationConfigData.ProtoReflect.Descriptor instead.
-func (*LocationConfigData) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{164}
+func (x *ServiceStatus) GetRestartLoop() bool {
+	if x != nil {
+		return x.RestartLoop
+	}
+	return false
 }
 
-func (x *LocationConfigData) GetProxyPass() string {
+func (x *ServiceStatus) GetHistory() []*ServiceEvent {
 	if x != nil {
-		return x.ProxyPass
+		return x.History
 	}
-	return ""
+	return nil
 }
 
-func (x *LocationConfigData) GetProxyHeaders() map[string]string {
+func (x *ServiceStatus) GetJournal() []string {
 	if x != nil {
-		return x.ProxyHeaders
+		return x.Journal
 	}
 	return nil
 }
 
-func (x *LocationConfigData) GetProxyConnectTimeout() int32 {
+func (x *ServiceStatus) GetNewRestarts() int32 {
 	if x != nil {
-		return x.ProxyConnectTimeout
+		return x.NewRestarts
 	}
 	return 0
 }
 
-func (x *LocationConfigData) GetProxyReadTimeout() int32 {
+func (x *ServiceStatus) GetNewFailures() int32 {
 	if x != nil {
-		return x.ProxyReadTimeout
+		return x.NewFailures
 	}
 	return 0
 }
 
-func (x *LocationConfigData) GetRateLimit() *RateLimitConfigData {
+func (x *ServiceStatus) GetError() string {
 	if x != nil {
-		return x.RateLimit
+		return x.Error
 	}
-	return nil
+	return ""
 }
 
-func (x *LocationConfigData) GetCache() *CacheConfigData {
-	if x != nil {
-		return x.Cache
-	}
-	return nil
+type ServiceEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // unix seconds
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`            // "restart" or "failure"
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`        // e.g. "result=exit-code status=1"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LocationConfigData) GetAllowedMethods() []string {
+func (x *ServiceEvent) Reset() {
+	*x = ServiceEvent{}
+	mi := &file_api_proto_agent_proto_msgTypes[214]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceEvent) ProtoMessage() {}
+
+func (x *ServiceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[214]
 	if x != nil {
-		return x.AllowedMethods
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *LocationConfigData) GetDenyIps() []string {
+// Deprecated: Use ServiceEvent.ProtoReflect.Descriptor instead.
+func (*ServiceEvent) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{214}
+}
+
+func (x *ServiceEvent) GetTimestamp() int64 {
 	if x != nil {
-		return x.DenyIps
+		return x.Timestamp
 	}
-	return nil
+	return 0
 }
 
-func (x *LocationConfigData) GetAllowIps() []string {
+func (x *ServiceEvent) GetType() string {
 	if x != nil {
-		return x.AllowIps
+		return x.Type
 	}
-	return nil
+	return ""
 }
 
-func (x *LocationConfigData) GetCustomDirectives() string {
+func (x *ServiceEvent) GetDetail() string {
 	if x != nil {
-		return x.CustomDirectives
+		return x.Detail
 	}
 	return ""
 }
 
-type RateLimitConfigData struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Zone          string                 `protobuf:"bytes,1,opt,name=zone,proto3" json:"zone,omitempty"`
-	Rate          string                 `protobuf:"bytes,2,opt,name=rate,proto3" json:"rate,omitempty"` // e.g., "10r/s"
-	Burst         int32                  `protobuf:"varint,3,opt,name=burst,proto3" json:"burst,omitempty"`
-	NoDelay       bool                   `protobuf:"varint,4,opt,name=no_delay,json=noDelay,proto3" json:"no_delay,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// BinaryUpgradeRequest replaces the nginx binary of an instance on the fly: USR2 to start a new
+// master, WINCH to stop the old workers, QUIT to stop the old master
+type BinaryUpgradeRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId      string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`                  // agent
+	NginxInstanceId string                 `protobuf:"bytes,2,opt,name=nginx_instance_id,json=nginxInstanceId,proto3" json:"nginx_instance_id,omitempty"` // NginxInstance.instance_id; empty for the primary instance
+	BinaryPath      string                 `protobuf:"bytes,3,opt,name=binary_path,json=binaryPath,proto3" json:"binary_path,omitempty"`                  // new binary installed over the running one; empty when it was replaced in place already
+	ExpectedVersion string                 `protobuf:"bytes,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`   // e.g. "1.27.1"; rolled back when the new master runs another version
+	TimeoutSec      int32                  `protobuf:"varint,5,opt,name=timeout_sec,json=timeoutSec,proto3" json:"timeout_sec,omitempty"`                 // per step; 0 for 30
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *RateLimitConfigData) Reset() {
-	*x = RateLimitConfigData{}
-	mi := &file_api_proto_agent_proto_msgTypes[165]
+func (x *BinaryUpgradeRequest) Reset() {
+	*x = BinaryUpgradeRequest{}
+	mi := &file_api_proto_agent_proto_msgTypes[215]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RateLimitConfigData) String() string {
+func (x *BinaryUpgradeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RateLimitConfigData) ProtoMessage() {}
+func (*BinaryUpgradeRequest) ProtoMessage() {}
 
-func (x *RateLimitConfigData) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[165]
+func (x *BinaryUpgradeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[215]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12339,65 +16811,75 @@ func (x *RateLimitConfigData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RateLimitConfigData.ProtoReflect.Descriptor instead.
-func (*RateLimitConfigData) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{165}
+// Deprecated: Use BinaryUpgradeRequest.ProtoReflect.Descriptor instead.
+func (*BinaryUpgradeRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{215}
 }
 
-func (x *RateLimitConfigData) GetZone() string {
+func (x *BinaryUpgradeRequest) GetInstanceId() string {
 	if x != nil {
-		return x.Zone
+		return x.InstanceId
 	}
 	return ""
 }
 
-func (x *RateLimitConfigData) GetRate() string {
+func (x *BinaryUpgradeRequest) GetNginxInstanceId() string {
 	if x != nil {
-		return x.Rate
+		return x.NginxInstanceId
 	}
 	return ""
 }
 
-func (x *RateLimitConfigData) GetBurst() int32 {
+func (x *BinaryUpgradeRequest) GetBinaryPath() string {
 	if x != nil {
-		return x.Burst
+		return x.BinaryPath
 	}
-	return 0
+	return ""
 }
 
-func (x *RateLimitConfigData) GetNoDelay() bool {
+func (x *BinaryUpgradeRequest) GetExpectedVersion() string {
 	if x != nil {
-		return x.NoDelay
+		return x.ExpectedVersion
 	}
-	return false
+	return ""
 }
 
-type CacheConfigData struct {
+func (x *BinaryUpgradeRequest) GetTimeoutSec() int32 {
+	if x != nil {
+		return x.TimeoutSec
+	}
+	return 0
+}
+
+type BinaryUpgradeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Zone          string                 `protobuf:"bytes,1,opt,name=zone,proto3" json:"zone,omitempty"`
-	Valid         []string               `protobuf:"bytes,2,rep,name=valid,proto3" json:"valid,omitempty"` // ["200 302 10m", "404 1m"]
-	Methods       []string               `protobuf:"bytes,3,rep,name=methods,proto3" json:"methods,omitempty"`
-	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
-	BypassVar     string                 `protobuf:"bytes,5,opt,name=bypass_var,json=bypassVar,proto3" json:"bypass_var,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	RolledBack    bool                   `protobuf:"varint,3,opt,name=rolled_back,json=rolledBack,proto3" json:"rolled_back,omitempty"` // a step failed and the old master serves again with the old binary
+	OldPid        int32                  `protobuf:"varint,4,opt,name=old_pid,json=oldPid,proto3" json:"old_pid,omitempty"`
+	NewPid        int32                  `protobuf:"varint,5,opt,name=new_pid,json=newPid,proto3" json:"new_pid,omitempty"`
+	OldVersion    string                 `protobuf:"bytes,6,opt,name=old_version,json=oldVersion,proto3" json:"old_version,omitempty"`
+	NewVersion    string                 `protobuf:"bytes,7,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	Steps         []*BinaryUpgradeStep   `protobuf:"bytes,8,rep,name=steps,proto3" json:"steps,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CacheConfigData) Reset() {
-	*x = CacheConfigData{}
-	mi := &file_api_proto_agent_proto_msgTypes[166]
+func (x *BinaryUpgradeResponse) Reset() {
+	*x = BinaryUpgradeResponse{}
+	mi := &file_api_proto_agent_proto_msgTypes[216]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CacheConfigData) String() string {
+func (x *BinaryUpgradeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CacheConfigData) ProtoMessage() {}
+func (*BinaryUpgradeResponse) ProtoMessage() {}
 
-func (x *CacheConfigData) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[166]
+func (x *BinaryUpgradeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[216]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12408,70 +16890,92 @@ func (x *CacheConfigData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CacheConfigData.ProtoReflect.Descriptor instead.
-func (*CacheConfigData) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{166}
+// Deprecated: Use BinaryUpgradeResponse.ProtoReflect.Descriptor instead.
+func (*BinaryUpgradeResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{216}
 }
 
-func (x *CacheConfigData) GetZone() string {
+func (x *BinaryUpgradeResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Zone
+		return x.Success
+	}
+	return false
+}
+
+func (x *BinaryUpgradeResponse) GetError() string {
+	if x != nil {
+		return x.Error
 	}
 	return ""
 }
 
-func (x *CacheConfigData) GetValid() []string {
+func (x *BinaryUpgradeResponse) GetRolledBack() bool {
 	if x != nil {
-		return x.Valid
+		return x.RolledBack
 	}
-	return nil
+	return false
 }
 
-func (x *CacheConfigData) GetMethods() []string {
+func (x *BinaryUpgradeResponse) GetOldPid() int32 {
 	if x != nil {
-		return x.Methods
+		return x.OldPid
 	}
-	return nil
+	return 0
 }
 
-func (x *CacheConfigData) GetKey() string {
+func (x *BinaryUpgradeResponse) GetNewPid() int32 {
 	if x != nil {
-		return x.Key
+		return x.NewPid
+	}
+	return 0
+}
+
+func (x *BinaryUpgradeResponse) GetOldVersion() string {
+	if x != nil {
+		return x.OldVersion
 	}
 	return ""
 }
 
-func (x *CacheConfigData) GetBypassVar() string {
+func (x *BinaryUpgradeResponse) GetNewVersion() string {
 	if x != nil {
-		return x.BypassVar
+		return x.NewVersion
 	}
 	return ""
 }
 
-type SiteLocationUpdateResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Results         []*AgentUpdateResult   `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
-	ValidationError string                 `protobuf:"bytes,3,opt,name=validation_error,json=validationError,proto3" json:"validation_error,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *BinaryUpgradeResponse) GetSteps() []*BinaryUpgradeStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
 }
 
-func (x *SiteLocationUpdateResponse) Reset() {
-	*x = SiteLocationUpdateResponse{}
-	mi := &file_api_proto_agent_proto_msgTypes[167]
+type BinaryUpgradeStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // "preflight", "install", "usr2", "verify", "winch", "quit" or "rollback"
+	Ok            bool                   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BinaryUpgradeStep) Reset() {
+	*x = BinaryUpgradeStep{}
+	mi := &file_api_proto_agent_proto_msgTypes[217]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SiteLocationUpdateResponse) String() string {
+func (x *BinaryUpgradeStep) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SiteLocationUpdateResponse) ProtoMessage() {}
+func (*BinaryUpgradeStep) ProtoMessage() {}
 
-func (x *SiteLocationUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_agent_proto_msgTypes[167]
+func (x *BinaryUpgradeStep) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_agent_proto_msgTypes[217]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -12482,37 +16986,44 @@ func (x *SiteLocationUpdateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SiteLocationUpdateResponse.ProtoReflect.Descriptor instead.
-func (*SiteLocationUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_agent_proto_rawDescGZIP(), []int{167}
+// Deprecated: Use BinaryUpgradeStep.ProtoReflect.Descriptor instead.
+func (*BinaryUpgradeStep) Descriptor() ([]byte, []int) {
+	return file_api_proto_agent_proto_rawDescGZIP(), []int{217}
 }
 
-func (x *SiteLocationUpdateResponse) GetSuccess() bool {
+func (x *BinaryUpgradeStep) GetName() string {
 	if x != nil {
-		return x.Success
+		return x.Name
 	}
-	return false
+	return ""
 }
 
-func (x *SiteLocationUpdateResponse) GetResults() []*AgentUpdateResult {
+func (x *BinaryUpgradeStep) GetOk() bool {
 	if x != nil {
-		return x.Results
+		return x.Ok
 	}
-	return nil
+	return false
 }
 
-func (x *SiteLocationUpdateResponse) GetValidationError() string {
+func (x *BinaryUpgradeStep) GetDetail() string {
 	if x != nil {
-		return x.ValidationError
+		return x.Detail
 	}
 	return ""
 }
 
+func (x *BinaryUpgradeStep) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
 var File_api_proto_agent_proto protoreflect.FileDescriptor
 
 const file_api_proto_agent_proto_rawDesc = "" +
 	"\n" +
-	"\x15api/proto/agent.proto\x12\x0enginx.agent.v1\x1a\x1capi/proto/agent_config.proto\"\xff\x02\n" +
+	"\x15api/proto/agent.proto\x12\x0enginx.agent.v1\x1a\x1capi/proto/agent_config.proto\"\x95\x04\n" +
 	"\fAgentMessage\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x129\n" +
@@ -12520,8 +17031,27 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x0ecommand_result\x18\x04 \x01(\v2\x1d.nginx.agent.v1.CommandResultH\x00R\rcommandResult\x125\n" +
 	"\x05state\x18\x05 \x01(\v2\x1d.nginx.agent.v1.StateSnapshotH\x00R\x05state\x127\n" +
 	"\tlog_entry\x18\x06 \x01(\v2\x18.nginx.agent.v1.LogEntryH\x00R\blogEntry\x128\n" +
-	"\ametrics\x18\a \x01(\v2\x1c.nginx.agent.v1.NginxMetricsH\x00R\ametricsB\t\n" +
-	"\apayload\"\xef\x04\n" +
+	"\ametrics\x18\a \x01(\v2\x1c.nginx.agent.v1.NginxMetricsH\x00R\ametrics\x12I\n" +
+	"\x0fgeneric_metrics\x18\b \x01(\v2\x1e.nginx.agent.v1.GenericMetricsH\x00R\x0egenericMetrics\x12I\n" +
+	"\x0fupdate_rejected\x18\t \x01(\v2\x1e.nginx.agent.v1.UpdateRejectedH\x00R\x0eupdateRejectedB\t\n" +
+	"\apayload\"p\n" +
+	"\x0eUpdateRejected\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1a\n" +
+	"\bplatform\x18\x02 \x01(\tR\bplatform\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"I\n" +
+	"\x0eGenericMetrics\x127\n" +
+	"\ametrics\x18\x01 \x03(\v2\x1d.nginx.agent.v1.GenericMetricR\ametrics\"\x86\x02\n" +
+	"\rGenericMetric\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x01R\x05value\x12A\n" +
+	"\x06labels\x18\x04 \x03(\v2).nginx.agent.v1.GenericMetric.LabelsEntryR\x06labels\x12!\n" +
+	"\ftimestamp_ms\x18\x05 \x01(\x03R\vtimestampMs\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\tR\x04type\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xfe\a\n" +
 	"\rSystemMetrics\x12*\n" +
 	"\x11cpu_usage_percent\x18\x01 \x01(\x02R\x0fcpuUsagePercent\x120\n" +
 	"\x14memory_usage_percent\x18\x02 \x01(\x02R\x12memoryUsagePercent\x12,\n" +
@@ -12535,10 +17065,44 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x12cpu_system_percent\x18\n" +
 	" \x01(\x02R\x10cpuSystemPercent\x12,\n" +
 	"\x12cpu_iowait_percent\x18\v \x01(\x02R\x10cpuIowaitPercent\x12A\n" +
-	"\x06labels\x18\f \x03(\v2).nginx.agent.v1.SystemMetrics.LabelsEntryR\x06labels\x1a9\n" +
+	"\x06labels\x18\f \x03(\v2).nginx.agent.v1.SystemMetrics.LabelsEntryR\x06labels\x12/\n" +
+	"\x05disks\x18\r \x03(\v2\x19.nginx.agent.v1.DiskUsageR\x05disks\x129\n" +
+	"\tlog_files\x18\x0e \x03(\v2\x1c.nginx.agent.v1.LogFileUsageR\blogFiles\x12!\n" +
+	"\ffd_allocated\x18\x0f \x01(\x04R\vfdAllocated\x12\x15\n" +
+	"\x06fd_max\x18\x10 \x01(\x04R\x05fdMax\x12&\n" +
+	"\x0ffd_used_percent\x18\x11 \x01(\x02R\rfdUsedPercent\x12;\n" +
+	"\tnginx_fds\x18\x12 \x03(\v2\x1e.nginx.agent.v1.ProcessFdUsageR\bnginxFds\x12'\n" +
+	"\x0fconntrack_count\x18\x13 \x01(\x04R\x0econntrackCount\x12#\n" +
+	"\rconntrack_max\x18\x14 \x01(\x04R\fconntrackMax\x124\n" +
+	"\x16conntrack_used_percent\x18\x15 \x01(\x02R\x14conntrackUsedPercent\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb9\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8a\x01\n" +
+	"\x0eProcessFdUsage\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x19\n" +
+	"\bopen_fds\x18\x03 \x01(\x04R\aopenFds\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x04R\x05limit\x12!\n" +
+	"\fused_percent\x18\x05 \x01(\x02R\vusedPercent\"\xac\x02\n" +
+	"\tDiskUsage\x12\x14\n" +
+	"\x05mount\x18\x01 \x01(\tR\x05mount\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x16\n" +
+	"\x06fstype\x18\x03 \x01(\tR\x06fstype\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x04R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x05 \x01(\x04R\tusedBytes\x12'\n" +
+	"\x0favailable_bytes\x18\x06 \x01(\x04R\x0eavailableBytes\x12!\n" +
+	"\fused_percent\x18\a \x01(\x02R\vusedPercent\x12.\n" +
+	"\x13inodes_used_percent\x18\b \x01(\x02R\x11inodesUsedPercent\x12\x1d\n" +
+	"\n" +
+	"nginx_logs\x18\t \x01(\bR\tnginxLogs\"\x88\x01\n" +
+	"\fLogFileUsage\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x14\n" +
+	"\x05mount\x18\x02 \x01(\tR\x05mount\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x04R\tsizeBytes\x12/\n" +
+	"\x14growth_bytes_per_sec\x18\x04 \x01(\x02R\x11growthBytesPerSec\"\xb9\x02\n" +
 	"\x11HttpStatusMetrics\x12(\n" +
 	"\x10status_2xx_count\x18\x01 \x01(\x03R\x0estatus2xxCount\x12(\n" +
 	"\x10status_200_count\x18\x02 \x01(\x03R\x0estatus200Count\x12(\n" +
@@ -12546,7 +17110,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x10status_4xx_count\x18\x04 \x01(\x03R\x0estatus4xxCount\x12(\n" +
 	"\x10status_404_count\x18\x05 \x01(\x03R\x0estatus404Count\x12(\n" +
 	"\x10status_5xx_count\x18\x06 \x01(\x03R\x0estatus5xxCount\x12(\n" +
-	"\x10status_503_count\x18\a \x01(\x03R\x0estatus503Count\"\xe0\x05\n" +
+	"\x10status_503_count\x18\a \x01(\x03R\x0estatus503Count\"\xf2\x06\n" +
 	"\fNginxMetrics\x12-\n" +
 	"\x12active_connections\x18\x01 \x01(\x03R\x11activeConnections\x121\n" +
 	"\x14accepted_connections\x18\x02 \x01(\x03R\x13acceptedConnections\x12/\n" +
@@ -12563,13 +17127,30 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x06labels\x18\v \x03(\v2(.nginx.agent.v1.NginxMetrics.LabelsEntryR\x06labels\x12R\n" +
 	"\x14latency_distribution\x18\f \x03(\v2\x1f.nginx.agent.v1.HistogramBucketR\x13latencyDistribution\x12$\n" +
 	"\x0ebytes_in_total\x18\r \x01(\x03R\fbytesInTotal\x12&\n" +
-	"\x0fbytes_out_total\x18\x0e \x01(\x03R\rbytesOutTotal\x1a9\n" +
+	"\x0fbytes_out_total\x18\x0e \x01(\x03R\rbytesOutTotal\x126\n" +
+	"\aworkers\x18\x0f \x01(\v2\x1c.nginx.agent.v1.NginxWorkersR\aworkers\x12\x1f\n" +
+	"\vinstance_id\x18\x10 \x01(\tR\n" +
+	"instanceId\x127\n" +
+	"\aservice\x18\x11 \x01(\v2\x1d.nginx.agent.v1.ServiceStatusR\aservice\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"7\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x86\x01\n" +
+	"\fNginxWorkers\x12@\n" +
+	"\tprocesses\x18\x01 \x03(\v2\".nginx.agent.v1.NginxWorkerProcessR\tprocesses\x12\x1a\n" +
+	"\brestarts\x18\x02 \x01(\x05R\brestarts\x12\x18\n" +
+	"\acrashes\x18\x03 \x01(\x05R\acrashes\"\xa2\x01\n" +
+	"\x12NginxWorkerProcess\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x1d\n" +
+	"\n" +
+	"master_pid\x18\x02 \x01(\x05R\tmasterPid\x12\x1f\n" +
+	"\vcpu_percent\x18\x03 \x01(\x02R\n" +
+	"cpuPercent\x12\x1b\n" +
+	"\trss_bytes\x18\x04 \x01(\x04R\brssBytes\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\x03R\tstartedAt\"7\n" +
 	"\x0fHistogramBucket\x12\x0e\n" +
 	"\x02le\x18\x01 \x01(\x02R\x02le\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x03R\x05count\"\x9b\x02\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\x95\x03\n" +
 	"\rServerCommand\x12\x1d\n" +
 	"\n" +
 	"command_id\x18\x01 \x01(\tR\tcommandId\x12=\n" +
@@ -12578,12 +17159,22 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x06action\x18\x03 \x01(\v2\x16.nginx.agent.v1.ActionH\x00R\x06action\x12=\n" +
 	"\vlog_request\x18\x04 \x01(\v2\x1a.nginx.agent.v1.LogRequestH\x00R\n" +
 	"logRequest\x120\n" +
-	"\x06update\x18\x05 \x01(\v2\x16.nginx.agent.v1.UpdateH\x00R\x06updateB\t\n" +
-	"\apayload\"A\n" +
+	"\x06update\x18\x05 \x01(\v2\x16.nginx.agent.v1.UpdateH\x00R\x06update\x12I\n" +
+	"\x0fconfigure_agent\x18\x06 \x01(\v2\x1e.nginx.agent.v1.ConfigureAgentH\x00R\x0econfigureAgent\x12-\n" +
+	"\x05drain\x18\a \x01(\v2\x15.nginx.agent.v1.DrainH\x00R\x05drainB\t\n" +
+	"\apayload\"O\n" +
+	"\x05Drain\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12.\n" +
+	"\x13retry_after_seconds\x18\x02 \x01(\x05R\x11retryAfterSeconds\"\x97\x01\n" +
+	"\x0eConfigureAgent\x12H\n" +
+	"\bsettings\x18\x01 \x03(\v2,.nginx.agent.v1.ConfigureAgent.SettingsEntryR\bsettings\x1a;\n" +
+	"\rSettingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
 	"\x06Update\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1d\n" +
 	"\n" +
-	"update_url\x18\x02 \x01(\tR\tupdateUrl\"\x9b\x04\n" +
+	"update_url\x18\x02 \x01(\tR\tupdateUrl\"\xa3\x06\n" +
 	"\tHeartbeat\x12\x1a\n" +
 	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12\x16\n" +
@@ -12601,20 +17192,97 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	" \x01(\tR\tgitBranch\x12=\n" +
 	"\x06labels\x18\v \x03(\v2%.nginx.agent.v1.Heartbeat.LabelsEntryR\x06labels\x12!\n" +
 	"\fmgmt_address\x18\f \x01(\tR\vmgmtAddress\x126\n" +
-	"\x17mgmt_address_candidates\x18\r \x03(\tR\x15mgmtAddressCandidates\x1a9\n" +
+	"\x17mgmt_address_candidates\x18\r \x03(\tR\x15mgmtAddressCandidates\x12B\n" +
+	"\n" +
+	"kubernetes\x18\x0e \x01(\v2\".nginx.agent.v1.KubernetesMetadataR\n" +
+	"kubernetes\x12A\n" +
+	"\rupdate_status\x18\x0f \x01(\v2\x1c.nginx.agent.v1.UpdateStatusR\fupdateStatus\x121\n" +
+	"\x04host\x18\x10 \x01(\v2\x1d.nginx.agent.v1.HostInventoryR\x04host\x12L\n" +
+	"\x0fresource_status\x18\x11 \x01(\v2#.nginx.agent.v1.AgentResourceStatusR\x0eresourceStatus\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"p\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8e\x02\n" +
+	"\x13AgentResourceStatus\x12\x1a\n" +
+	"\bdegraded\x18\x01 \x01(\bR\bdegraded\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12%\n" +
+	"\x0edegraded_since\x18\x03 \x01(\x03R\rdegradedSince\x12\x1f\n" +
+	"\vcpu_percent\x18\x04 \x01(\x02R\n" +
+	"cpuPercent\x12!\n" +
+	"\fmemory_bytes\x18\x05 \x01(\x04R\vmemoryBytes\x12*\n" +
+	"\x11cpu_limit_percent\x18\x06 \x01(\x02R\x0fcpuLimitPercent\x12,\n" +
+	"\x12memory_limit_bytes\x18\a \x01(\x04R\x10memoryLimitBytes\"\xc4\x01\n" +
+	"\rHostInventory\x12\x13\n" +
+	"\x05os_id\x18\x01 \x01(\tR\x04osId\x12\x1d\n" +
+	"\n" +
+	"os_version\x18\x02 \x01(\tR\tosVersion\x12\x17\n" +
+	"\aos_name\x18\x03 \x01(\tR\x06osName\x12\x16\n" +
+	"\x06kernel\x18\x04 \x01(\tR\x06kernel\x12\x12\n" +
+	"\x04arch\x18\x05 \x01(\tR\x04arch\x12:\n" +
+	"\bpackages\x18\x06 \x03(\v2\x1e.nginx.agent.v1.PackageVersionR\bpackages\"X\n" +
+	"\x0ePackageVersion\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x18\n" +
+	"\amanager\x18\x03 \x01(\tR\amanager\"\xbc\x01\n" +
+	"\fUpdateStatus\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\x12!\n" +
+	"\ffrom_version\x18\x02 \x01(\tR\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x03 \x01(\tR\ttoVersion\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\x03R\tstartedAt\x12\x1f\n" +
+	"\vfinished_at\x18\x06 \x01(\x03R\n" +
+	"finishedAt\"\xd1\x02\n" +
+	"\x12KubernetesMetadata\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x19\n" +
+	"\bpod_name\x18\x02 \x01(\tR\apodName\x12\x17\n" +
+	"\apod_uid\x18\x03 \x01(\tR\x06podUid\x12\x1b\n" +
+	"\tnode_name\x18\x04 \x01(\tR\bnodeName\x12\x1d\n" +
+	"\n" +
+	"owner_kind\x18\x05 \x01(\tR\townerKind\x12\x1d\n" +
+	"\n" +
+	"owner_name\x18\x06 \x01(\tR\townerName\x12P\n" +
+	"\n" +
+	"pod_labels\x18\a \x03(\v21.nginx.agent.v1.KubernetesMetadata.PodLabelsEntryR\tpodLabels\x1a<\n" +
+	"\x0ePodLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x98\x03\n" +
 	"\rNginxInstance\x12\x10\n" +
 	"\x03pid\x18\x01 \x01(\tR\x03pid\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1b\n" +
 	"\tconf_path\x18\x03 \x01(\tR\bconfPath\x12\x16\n" +
-	"\x06status\x18\x04 \x01(\tR\x06status\"m\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x120\n" +
+	"\x05build\x18\x05 \x01(\v2\x1a.nginx.agent.v1.NginxBuildR\x05build\x12\x1f\n" +
+	"\vinstance_id\x18\x06 \x01(\tR\n" +
+	"instanceId\x12\x16\n" +
+	"\x06prefix\x18\a \x01(\tR\x06prefix\x12!\n" +
+	"\fworker_count\x18\b \x01(\x05R\vworkerCount\x12\x1f\n" +
+	"\vbinary_path\x18\t \x01(\tR\n" +
+	"binaryPath\x12!\n" +
+	"\fsystemd_unit\x18\n" +
+	" \x01(\tR\vsystemdUnit\x12\x1d\n" +
+	"\n" +
+	"access_log\x18\v \x01(\tR\taccessLog\x12\x1b\n" +
+	"\terror_log\x18\f \x01(\tR\berrorLog\x12\x18\n" +
+	"\aprimary\x18\r \x01(\bR\aprimary\"\xda\x01\n" +
+	"\n" +
+	"NginxBuild\x12\x1f\n" +
+	"\vbinary_path\x18\x01 \x01(\tR\n" +
+	"binaryPath\x12\x1a\n" +
+	"\bcompiler\x18\x02 \x01(\tR\bcompiler\x12'\n" +
+	"\x0fopenssl_version\x18\x03 \x01(\tR\x0eopensslVersion\x12%\n" +
+	"\x0econfigure_args\x18\x04 \x03(\tR\rconfigureArgs\x12\x18\n" +
+	"\amodules\x18\x05 \x03(\tR\amodules\x12%\n" +
+	"\x0eloaded_modules\x18\x06 \x03(\tR\rloadedModules\"\x90\x02\n" +
 	"\rCommandResult\x12\x1d\n" +
 	"\n" +
 	"command_id\x18\x01 \x01(\tR\tcommandId\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12#\n" +
-	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"s\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12]\n" +
+	"\x10applied_settings\x18\x04 \x03(\v22.nginx.agent.v1.CommandResult.AppliedSettingsEntryR\x0fappliedSettings\x1aB\n" +
+	"\x14AppliedSettingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"s\n" +
 	"\rStateSnapshot\x12\x1f\n" +
 	"\vconfig_hash\x18\x01 \x01(\tR\n" +
 	"configHash\x12A\n" +
@@ -12663,7 +17331,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x16DeleteAlertRuleRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"3\n" +
 	"\x17DeleteAlertRuleResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xc6\x02\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xbb\x03\n" +
 	"\tAlertRule\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1f\n" +
@@ -12684,7 +17352,12 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	" \x01(\tR\bseverity\x12\x1e\n" +
 	"\n" +
 	"conditions\x18\v \x01(\tR\n" +
-	"conditions\"^\n" +
+	"conditions\x12\x16\n" +
+	"\x06action\x18\f \x01(\tR\x06action\x12(\n" +
+	"\x10ban_duration_sec\x18\r \x01(\x05R\x0ebanDurationSec\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x0e \x01(\tR\tprojectId\x12\x12\n" +
+	"\x04pack\x18\x0f \x01(\tR\x04pack\"^\n" +
 	"\vExecRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x12\x14\n" +
@@ -12693,29 +17366,50 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\fExecResponse\x12\x16\n" +
 	"\x06output\x18\x01 \x01(\fR\x06output\x12\x1b\n" +
 	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"/\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"I\n" +
 	"\x12UpdateAgentRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\"3\n" +
+	"\x16GetUpdateStatusRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\"I\n" +
 	"\x13UpdateAgentResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"Q\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x9d\x01\n" +
 	"\rConfigRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x12\x1f\n" +
 	"\vconfig_path\x18\x02 \x01(\tR\n" +
-	"configPath\"|\n" +
+	"configPath\x12\x1e\n" +
+	"\n" +
+	"structured\x18\x03 \x01(\bR\n" +
+	"structured\x12*\n" +
+	"\x11nginx_instance_id\x18\x04 \x01(\tR\x0fnginxInstanceId\"|\n" +
 	"\x0eConfigResponse\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x123\n" +
 	"\x06config\x18\x02 \x01(\v2\x1b.nginx.agent.v1.NginxConfigR\x06config\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"\xe1\x01\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x93\x02\n" +
 	"\vNginxConfig\x12\x1f\n" +
 	"\vconfig_path\x18\x01 \x01(\tR\n" +
 	"configPath\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\tR\acontent\x125\n" +
 	"\aservers\x18\x03 \x03(\v2\x1b.nginx.agent.v1.ServerBlockR\aservers\x12;\n" +
 	"\tupstreams\x18\x04 \x03(\v2\x1d.nginx.agent.v1.UpstreamBlockR\tupstreams\x12#\n" +
-	"\rlast_modified\x18\x05 \x01(\x03R\flastModified\"\xac\x03\n" +
+	"\rlast_modified\x18\x05 \x01(\x03R\flastModified\x120\n" +
+	"\x05files\x18\x06 \x03(\v2\x1a.nginx.agent.v1.ConfigFileR\x05files\"q\n" +
+	"\n" +
+	"ConfigFile\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x127\n" +
+	"\x06parsed\x18\x02 \x03(\v2\x1f.nginx.agent.v1.ConfigDirectiveR\x06parsed\x12\x16\n" +
+	"\x06errors\x18\x03 \x03(\tR\x06errors\"\xdf\x01\n" +
+	"\x0fConfigDirective\x12\x1c\n" +
+	"\tdirective\x18\x01 \x01(\tR\tdirective\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x125\n" +
+	"\x05block\x18\x04 \x03(\v2\x1f.nginx.agent.v1.ConfigDirectiveR\x05block\x12\x19\n" +
+	"\bis_block\x18\x05 \x01(\bR\aisBlock\x12\x18\n" +
+	"\acomment\x18\x06 \x01(\tR\acomment\x12\x1a\n" +
+	"\bincludes\x18\a \x03(\x05R\bincludes\"\xac\x03\n" +
 	"\vServerBlock\x12\x16\n" +
 	"\x06listen\x18\x01 \x03(\tR\x06listen\x12\x1f\n" +
 	"\vserver_name\x18\x02 \x03(\tR\n" +
@@ -12753,7 +17447,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"directives\x1a=\n" +
 	"\x0fDirectivesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x89\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe7\x01\n" +
 	"\fConfigUpdate\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x12\x1f\n" +
@@ -12761,35 +17455,41 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"configPath\x12\x1f\n" +
 	"\vnew_content\x18\x03 \x01(\tR\n" +
 	"newContent\x12\x16\n" +
-	"\x06backup\x18\x04 \x01(\bR\x06backup\"g\n" +
+	"\x06backup\x18\x04 \x01(\bR\x06backup\x120\n" +
+	"\x05files\x18\x05 \x03(\v2\x1a.nginx.agent.v1.ConfigFileR\x05files\x12*\n" +
+	"\x11nginx_instance_id\x18\x06 \x01(\tR\x0fnginxInstanceId\"g\n" +
 	"\x14ConfigUpdateResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
 	"\vbackup_path\x18\x03 \x01(\tR\n" +
-	"backupPath\"Z\n" +
+	"backupPath\"\x86\x01\n" +
 	"\x10ConfigValidation\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x12%\n" +
-	"\x0econfig_content\x18\x02 \x01(\tR\rconfigContent\"\\\n" +
+	"\x0econfig_content\x18\x02 \x01(\tR\rconfigContent\x12*\n" +
+	"\x11nginx_instance_id\x18\x03 \x01(\tR\x0fnginxInstanceId\"\\\n" +
 	"\x10ValidationResult\x12\x14\n" +
 	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
 	"\x06errors\x18\x02 \x03(\tR\x06errors\x12\x1a\n" +
-	"\bwarnings\x18\x03 \x03(\tR\bwarnings\"0\n" +
+	"\bwarnings\x18\x03 \x03(\tR\bwarnings\"\\\n" +
 	"\rReloadRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
-	"instanceId\"@\n" +
+	"instanceId\x12*\n" +
+	"\x11nginx_instance_id\x18\x02 \x01(\tR\x0fnginxInstanceId\"@\n" +
 	"\x0eReloadResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"1\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"]\n" +
 	"\x0eRestartRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
-	"instanceId\"A\n" +
+	"instanceId\x12*\n" +
+	"\x11nginx_instance_id\x18\x02 \x01(\tR\x0fnginxInstanceId\"A\n" +
 	"\x0fRestartResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\".\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"Z\n" +
 	"\vStopRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
-	"instanceId\">\n" +
+	"instanceId\x12*\n" +
+	"\x11nginx_instance_id\x18\x02 \x01(\tR\x0fnginxInstanceId\">\n" +
 	"\fStopResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\"2\n" +
@@ -12806,17 +17506,30 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x06issuer\x18\x05 \x01(\tR\x06issuer\x12\x1f\n" +
 	"\vsan_domains\x18\x06 \x03(\tR\n" +
 	"sanDomains\x12*\n" +
-	"\x11days_until_expiry\x18\a \x01(\x05R\x0fdaysUntilExpiry\"\x13\n" +
-	"\x11ListAgentsRequest\"n\n" +
+	"\x11days_until_expiry\x18\a \x01(\x05R\x0fdaysUntilExpiry\"\x8b\x02\n" +
+	"\x11ListAgentsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06search\x18\x03 \x01(\tR\x06search\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12%\n" +
+	"\x0eenvironment_id\x18\x05 \x01(\tR\renvironmentId\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x06 \x01(\tR\tprojectId\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x17\n" +
+	"\asort_by\x18\b \x01(\tR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\t \x01(\bR\n" +
+	"descending\"\x84\x01\n" +
 	"\x12ListAgentsResponse\x121\n" +
 	"\x06agents\x18\x01 \x03(\v2\x19.nginx.agent.v1.AgentInfoR\x06agents\x12%\n" +
-	"\x0esystem_version\x18\x02 \x01(\tR\rsystemVersion\"/\n" +
+	"\x0esystem_version\x18\x02 \x01(\tR\rsystemVersion\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"/\n" +
 	"\x12RemoveAgentRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\"/\n" +
 	"\x13RemoveAgentResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\",\n" +
 	"\x0fGetAgentRequest\x12\x19\n" +
-	"\bagent_id\x18\x01 \x01(\tR\aagentId\"\xb9\x04\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\"\xb4\a\n" +
 	"\tAgentInfo\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1a\n" +
 	"\bhostname\x18\x02 \x01(\tR\bhostname\x12\x18\n" +
@@ -12837,10 +17550,19 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\n" +
 	"git_branch\x18\x0e \x01(\tR\tgitBranch\x12+\n" +
 	"\x11psk_authenticated\x18\x0f \x01(\bR\x10pskAuthenticated\x12=\n" +
-	"\x06labels\x18\x10 \x03(\v2%.nginx.agent.v1.AgentInfo.LabelsEntryR\x06labels\x1a9\n" +
+	"\x06labels\x18\x10 \x03(\v2%.nginx.agent.v1.AgentInfo.LabelsEntryR\x06labels\x12B\n" +
+	"\n" +
+	"kubernetes\x18\x11 \x01(\v2\".nginx.agent.v1.KubernetesMetadataR\n" +
+	"kubernetes\x12\x1d\n" +
+	"\n" +
+	"gateway_id\x18\x12 \x01(\tR\tgatewayId\x12A\n" +
+	"\rupdate_status\x18\x13 \x01(\v2\x1c.nginx.agent.v1.UpdateStatusR\fupdateStatus\x12=\n" +
+	"\fnginx_builds\x18\x14 \x03(\v2\x1a.nginx.agent.v1.NginxBuildR\vnginxBuilds\x12L\n" +
+	"\x0fresource_status\x18\x15 \x01(\v2#.nginx.agent.v1.AgentResourceStatusR\x0eresourceStatus\x12F\n" +
+	"\x0fnginx_instances\x18\x16 \x03(\v2\x1d.nginx.agent.v1.NginxInstanceR\x0enginxInstances\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x7f\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xab\x01\n" +
 	"\n" +
 	"LogRequest\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
@@ -12848,7 +17570,8 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\blog_type\x18\x02 \x01(\tR\alogType\x12\x1d\n" +
 	"\n" +
 	"tail_lines\x18\x03 \x01(\x05R\ttailLines\x12\x16\n" +
-	"\x06follow\x18\x04 \x01(\bR\x06follow\"\x93\x05\n" +
+	"\x06follow\x18\x04 \x01(\bR\x06follow\x12*\n" +
+	"\x11nginx_instance_id\x18\x05 \x01(\tR\x0fnginxInstanceId\"\xab\a\n" +
 	"\bLogEntry\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x19\n" +
 	"\blog_type\x18\x02 \x01(\tR\alogType\x12\x18\n" +
@@ -12872,7 +17595,16 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\areferer\x18\x10 \x01(\tR\areferer\x12\x1d\n" +
 	"\n" +
 	"user_agent\x18\x11 \x01(\tR\tuserAgent\x12&\n" +
-	"\x0fx_forwarded_for\x18\x12 \x01(\tR\rxForwardedFor\"@\n" +
+	"\x0fx_forwarded_for\x18\x12 \x01(\tR\rxForwardedFor\x12<\n" +
+	"\x06labels\x18\x13 \x03(\v2$.nginx.agent.v1.LogEntry.LabelsEntryR\x06labels\x12%\n" +
+	"\x0erequest_length\x18\x14 \x01(\x03R\rrequestLength\x122\n" +
+	"\x15upstream_cache_status\x18\x15 \x01(\tR\x13upstreamCacheStatus\x12 \n" +
+	"\vtraceparent\x18\x16 \x01(\tR\vtraceparent\x12 \n" +
+	"\fx_request_id\x18\x17 \x01(\tR\n" +
+	"xRequestId\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"@\n" +
 	"\rUptimeRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\x05R\x05limit\"H\n" +
@@ -12886,7 +17618,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\n" +
 	"check_type\x18\x04 \x01(\tR\tcheckType\x12\x16\n" +
 	"\x06target\x18\x05 \x01(\tR\x06target\x12\x14\n" +
-	"\x05error\x18\x06 \x01(\tR\x05error\"\xc7\x02\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"\x9e\x03\n" +
 	"\x10AnalyticsRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1f\n" +
 	"\vtime_window\x18\x02 \x01(\tR\n" +
@@ -12899,7 +17631,18 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\btimezone\x18\a \x01(\tR\btimezone\x12\x1d\n" +
 	"\n" +
 	"url_filter\x18\b \x01(\tR\turlFilter\x12,\n" +
-	"\x12status_code_filter\x18\t \x01(\tR\x10statusCodeFilter\"\xc9\a\n" +
+	"\x12status_code_filter\x18\t \x01(\tR\x10statusCodeFilter\x12\x16\n" +
+	"\x06deltas\x18\n" +
+	" \x01(\bR\x06deltas\x12=\n" +
+	"\acompare\x18\v \x01(\v2#.nginx.agent.v1.AnalyticsComparisonR\acompare\"\xd8\x01\n" +
+	"\x13AnalyticsComparison\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\tR\x06offset\x12%\n" +
+	"\x0efrom_timestamp\x18\x02 \x01(\x03R\rfromTimestamp\x12!\n" +
+	"\fto_timestamp\x18\x03 \x01(\x03R\vtoTimestamp\x12\x19\n" +
+	"\bagent_id\x18\x04 \x01(\tR\aagentId\x12%\n" +
+	"\x0eenvironment_id\x18\x05 \x01(\tR\renvironmentId\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x06 \x01(\tR\tprojectId\"\xda\f\n" +
 	"\x11AnalyticsResponse\x12B\n" +
 	"\frequest_rate\x18\x01 \x03(\v2\x1f.nginx.agent.v1.TimeSeriesPointR\vrequestRate\x12L\n" +
 	"\x13status_distribution\x18\x02 \x03(\v2\x1b.nginx.agent.v1.StatusCountR\x12statusDistribution\x12G\n" +
@@ -12914,7 +17657,77 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	" \x01(\v2).nginx.agent.v1.HttpStatusMetricsResponseR\x11httpStatusMetrics\x123\n" +
 	"\binsights\x18\v \x03(\v2\x17.nginx.agent.v1.InsightR\binsights\x12A\n" +
 	"\x0frecent_requests\x18\f \x03(\v2\x18.nginx.agent.v1.LogEntryR\x0erecentRequests\x12K\n" +
-	"\x0fgateway_metrics\x18\r \x03(\v2\".nginx.agent.v1.GatewayMetricPointR\x0egatewayMetrics\"\xe5\x02\n" +
+	"\x0fgateway_metrics\x18\r \x03(\v2\".nginx.agent.v1.GatewayMetricPointR\x0egatewayMetrics\x122\n" +
+	"\btop_asns\x18\x0e \x03(\v2\x17.nginx.agent.v1.ASNStatR\atopAsns\x12;\n" +
+	"\vbot_traffic\x18\x0f \x01(\v2\x1a.nginx.agent.v1.BotTrafficR\n" +
+	"botTraffic\x12\x1a\n" +
+	"\bwarnings\x18\x10 \x03(\tR\bwarnings\x124\n" +
+	"\x05delta\x18\x11 \x01(\v2\x1e.nginx.agent.v1.AnalyticsDeltaR\x05delta\x12<\n" +
+	"\tbandwidth\x18\x12 \x03(\v2\x1e.nginx.agent.v1.BandwidthPointR\tbandwidth\x12K\n" +
+	"\x12bandwidth_by_agent\x18\x13 \x03(\v2\x1d.nginx.agent.v1.BandwidthStatR\x10bandwidthByAgent\x12G\n" +
+	"\x10bandwidth_by_uri\x18\x14 \x03(\v2\x1d.nginx.agent.v1.BandwidthStatR\x0ebandwidthByUri\x12;\n" +
+	"\vegress_cost\x18\x15 \x01(\v2\x1a.nginx.agent.v1.EgressCostR\n" +
+	"egressCost\x120\n" +
+	"\x05cache\x18\x16 \x01(\v2\x1a.nginx.agent.v1.CacheStatsR\x05cache\x12=\n" +
+	"\bbaseline\x18\x17 \x01(\v2!.nginx.agent.v1.AnalyticsResponseR\bbaseline\x12J\n" +
+	"\n" +
+	"comparison\x18\x18 \x01(\v2*.nginx.agent.v1.AnalyticsComparisonSummaryR\n" +
+	"comparison\"\x9e\x01\n" +
+	"\x1aAnalyticsComparisonSummary\x12%\n" +
+	"\x0efrom_timestamp\x18\x01 \x01(\x03R\rfromTimestamp\x12!\n" +
+	"\fto_timestamp\x18\x02 \x01(\x03R\vtoTimestamp\x126\n" +
+	"\achanges\x18\x03 \x03(\v2\x1c.nginx.agent.v1.MetricChangeR\achanges\"\x9b\x01\n" +
+	"\fMetricChange\x12\x16\n" +
+	"\x06metric\x18\x01 \x01(\tR\x06metric\x12\x18\n" +
+	"\acurrent\x18\x02 \x01(\x01R\acurrent\x12\x1a\n" +
+	"\bbaseline\x18\x03 \x01(\x01R\bbaseline\x12\x16\n" +
+	"\x06change\x18\x04 \x01(\x01R\x06change\x12%\n" +
+	"\x0echange_percent\x18\x05 \x01(\x01R\rchangePercent\"\xac\x02\n" +
+	"\n" +
+	"CacheStats\x12'\n" +
+	"\x0fcached_requests\x18\x01 \x01(\x03R\x0ecachedRequests\x12\x1b\n" +
+	"\thit_ratio\x18\x02 \x01(\x02R\bhitRatio\x12\x1d\n" +
+	"\n" +
+	"miss_ratio\x18\x03 \x01(\x02R\tmissRatio\x12\x1f\n" +
+	"\vstale_ratio\x18\x04 \x01(\x02R\n" +
+	"staleRatio\x12!\n" +
+	"\fbypass_ratio\x18\x05 \x01(\x02R\vbypassRatio\x12<\n" +
+	"\bstatuses\x18\x06 \x03(\v2 .nginx.agent.v1.CacheStatusCountR\bstatuses\x127\n" +
+	"\btop_uris\x18\a \x03(\v2\x1c.nginx.agent.v1.CacheUriStatR\atopUris\"F\n" +
+	"\x10CacheStatusCount\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\"m\n" +
+	"\fCacheUriStat\x12\x10\n" +
+	"\x03uri\x18\x01 \x01(\tR\x03uri\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x12\n" +
+	"\x04hits\x18\x03 \x01(\x03R\x04hits\x12\x1b\n" +
+	"\thit_ratio\x18\x04 \x01(\x02R\bhitRatio\"w\n" +
+	"\x0eBandwidthPoint\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\tR\x04time\x12\x19\n" +
+	"\bbytes_in\x18\x02 \x01(\x04R\abytesIn\x12\x1b\n" +
+	"\tbytes_out\x18\x03 \x01(\x04R\bbytesOut\x12\x19\n" +
+	"\btime_iso\x18\x04 \x01(\tR\atimeIso\"\x96\x01\n" +
+	"\rBandwidthStat\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x19\n" +
+	"\bbytes_in\x18\x03 \x01(\x04R\abytesIn\x12\x1b\n" +
+	"\tbytes_out\x18\x04 \x01(\x04R\bbytesOut\x12\x1f\n" +
+	"\vegress_cost\x18\x05 \x01(\x01R\n" +
+	"egressCost\"`\n" +
+	"\n" +
+	"EgressCost\x12 \n" +
+	"\fprice_per_gb\x18\x01 \x01(\x01R\n" +
+	"pricePerGb\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x01R\x05total\"y\n" +
+	"\x0eAnalyticsDelta\x12\x16\n" +
+	"\x06resync\x18\x01 \x01(\bR\x06resync\x12\x1a\n" +
+	"\breplaced\x18\x02 \x03(\tR\breplaced\x123\n" +
+	"\x06series\x18\x03 \x03(\v2\x1b.nginx.agent.v1.SeriesPatchR\x06series\"K\n" +
+	"\vSeriesPatch\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04drop\x18\x02 \x01(\x05R\x04drop\x12\x12\n" +
+	"\x04keep\x18\x03 \x01(\x05R\x04keep\"\xc4\x03\n" +
 	"\x12GatewayMetricPoint\x12\x12\n" +
 	"\x04time\x18\x01 \x01(\tR\x04time\x12\x10\n" +
 	"\x03eps\x18\x02 \x01(\x02R\x03eps\x12-\n" +
@@ -12926,7 +17739,12 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"goroutines\x12\x1d\n" +
 	"\n" +
 	"db_latency\x18\a \x01(\x02R\tdbLatency\x12F\n" +
-	"\x06labels\x18\b \x03(\v2..nginx.agent.v1.GatewayMetricPoint.LabelsEntryR\x06labels\x1a9\n" +
+	"\x06labels\x18\b \x03(\v2..nginx.agent.v1.GatewayMetricPoint.LabelsEntryR\x06labels\x12\x19\n" +
+	"\btime_iso\x18\t \x01(\tR\atimeIso\x12\x14\n" +
+	"\x05load1\x18\n" +
+	" \x01(\x02R\x05load1\x12\x14\n" +
+	"\x05load5\x18\v \x01(\x02R\x05load5\x12\x16\n" +
+	"\x06load15\x18\f \x01(\x02R\x06load15\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb3\x02\n" +
@@ -12943,13 +17761,17 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"attributes\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8b\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xcb\x01\n" +
 	"\x05Trace\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12*\n" +
 	"\x05spans\x18\x02 \x03(\v2\x14.nginx.agent.v1.SpanR\x05spans\x127\n" +
 	"\n" +
-	"root_entry\x18\x03 \x01(\v2\x18.nginx.agent.v1.LogEntryR\trootEntry\"\xaa\x02\n" +
+	"root_entry\x18\x03 \x01(\v2\x18.nginx.agent.v1.LogEntryR\trootEntry\x12\x1d\n" +
+	"\n" +
+	"span_count\x18\x04 \x01(\x05R\tspanCount\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x01R\n" +
+	"durationMs\"\x93\x03\n" +
 	"\fTraceRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x19\n" +
 	"\btrace_id\x18\x02 \x01(\tR\atraceId\x12\x1f\n" +
@@ -12962,7 +17784,11 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"uri_filter\x18\a \x01(\tR\turiFilter\x12%\n" +
 	"\x0eenvironment_id\x18\b \x01(\tR\renvironmentId\x12\x1d\n" +
 	"\n" +
-	"project_id\x18\t \x01(\tR\tprojectId\":\n" +
+	"project_id\x18\t \x01(\tR\tprojectId\x12&\n" +
+	"\x0fmin_duration_ms\x18\n" +
+	" \x01(\x01R\rminDurationMs\x12&\n" +
+	"\x0fmax_duration_ms\x18\v \x01(\x01R\rmaxDurationMs\x12\x17\n" +
+	"\asort_by\x18\f \x01(\tR\x06sortBy\":\n" +
 	"\tTraceList\x12-\n" +
 	"\x06traces\x18\x01 \x03(\v2\x15.nginx.agent.v1.TraceR\x06traces\"i\n" +
 	"\aInsight\x12\x12\n" +
@@ -12977,7 +17803,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x14ApplyAugmentResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12\x18\n" +
-	"\apreview\x18\x03 \x01(\tR\apreview\"\xa4\x03\n" +
+	"\apreview\x18\x03 \x01(\tR\apreview\"\xca\x03\n" +
 	"\x10AnalyticsSummary\x12%\n" +
 	"\x0etotal_requests\x18\x01 \x01(\x03R\rtotalRequests\x12\x1d\n" +
 	"\n" +
@@ -12992,7 +17818,8 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\frequests_3xx\x18\t \x01(\x03R\vrequests3xx\x12!\n" +
 	"\frequests_4xx\x18\n" +
 	" \x01(\x03R\vrequests4xx\x12!\n" +
-	"\frequests_5xx\x18\v \x01(\x03R\vrequests5xx\"=\n" +
+	"\frequests_5xx\x18\v \x01(\x03R\vrequests5xx\x12$\n" +
+	"\x0etotal_bytes_in\x18\f \x01(\x04R\ftotalBytesIn\"=\n" +
 	"\rLatencyBucket\x12\x16\n" +
 	"\x06bucket\x18\x01 \x01(\tR\x06bucket\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x03R\x05count\"}\n" +
@@ -13002,7 +17829,25 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x1d\n" +
 	"\n" +
 	"error_rate\x18\x03 \x01(\x02R\terrorRate\x12\x18\n" +
-	"\atraffic\x18\x04 \x01(\x04R\atraffic\"\xac\x02\n" +
+	"\atraffic\x18\x04 \x01(\x04R\atraffic\"\xb0\x01\n" +
+	"\aASNStat\x12\x10\n" +
+	"\x03asn\x18\x01 \x01(\rR\x03asn\x12\"\n" +
+	"\forganization\x18\x02 \x01(\tR\forganization\x12\x1a\n" +
+	"\brequests\x18\x03 \x01(\x03R\brequests\x12\x16\n" +
+	"\x06errors\x18\x04 \x01(\x03R\x06errors\x12!\n" +
+	"\fbot_requests\x18\x05 \x01(\x03R\vbotRequests\x12\x18\n" +
+	"\atraffic\x18\x06 \x01(\x04R\atraffic\"I\n" +
+	"\x0fBotCategoryStat\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x03R\brequests\"\xb4\x01\n" +
+	"\n" +
+	"BotTraffic\x12%\n" +
+	"\x0etotal_requests\x18\x01 \x01(\x03R\rtotalRequests\x12!\n" +
+	"\fbot_requests\x18\x02 \x01(\x03R\vbotRequests\x12\x1b\n" +
+	"\tbot_share\x18\x03 \x01(\x02R\bbotShare\x12?\n" +
+	"\n" +
+	"categories\x18\x04 \x03(\v2\x1f.nginx.agent.v1.BotCategoryStatR\n" +
+	"categories\"\xc7\x02\n" +
 	"\x10NginxMetricPoint\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x16\n" +
 	"\x06active\x18\x02 \x01(\x03R\x06active\x12\x18\n" +
@@ -13014,19 +17859,22 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\brequests\x18\b \x01(\x03R\brequests\x12.\n" +
 	"\x13requests_per_second\x18\t \x01(\x01R\x11requestsPerSecond\x12\x12\n" +
 	"\x04time\x18\n" +
-	" \x01(\tR\x04time\"Y\n" +
+	" \x01(\tR\x04time\x12\x19\n" +
+	"\btime_iso\x18\v \x01(\tR\atimeIso\"t\n" +
 	"\x0fTimeSeriesPoint\x12\x12\n" +
 	"\x04time\x18\x01 \x01(\tR\x04time\x12\x1a\n" +
 	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x16\n" +
-	"\x06errors\x18\x03 \x01(\x03R\x06errors\"7\n" +
+	"\x06errors\x18\x03 \x01(\x03R\x06errors\x12\x19\n" +
+	"\btime_iso\x18\x04 \x01(\tR\atimeIso\"7\n" +
 	"\vStatusCount\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\tR\x04code\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x03R\x05count\"^\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"y\n" +
 	"\x12LatencyPercentiles\x12\x12\n" +
 	"\x04time\x18\x01 \x01(\tR\x04time\x12\x10\n" +
 	"\x03p50\x18\x02 \x01(\x02R\x03p50\x12\x10\n" +
 	"\x03p95\x18\x03 \x01(\x02R\x03p95\x12\x10\n" +
-	"\x03p99\x18\x04 \x01(\x02R\x03p99\"\x90\x02\n" +
+	"\x03p99\x18\x04 \x01(\x02R\x03p99\x12\x19\n" +
+	"\btime_iso\x18\x05 \x01(\tR\atimeIso\"\xab\x02\n" +
 	"\x11SystemMetricPoint\x12\x12\n" +
 	"\x04time\x18\x01 \x01(\tR\x04time\x12\x1b\n" +
 	"\tcpu_usage\x18\x02 \x01(\x02R\bcpuUsage\x12!\n" +
@@ -13037,7 +17885,8 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\n" +
 	"cpu_system\x18\a \x01(\x02R\tcpuSystem\x12\x1d\n" +
 	"\n" +
-	"cpu_iowait\x18\b \x01(\x02R\tcpuIowait\"\xb9\x03\n" +
+	"cpu_iowait\x18\b \x01(\x02R\tcpuIowait\x12\x19\n" +
+	"\btime_iso\x18\t \x01(\tR\atimeIso\"\xb9\x03\n" +
 	"\x19HttpStatusMetricsResponse\x12G\n" +
 	"\x0fstatus_2xx_5min\x18\x01 \x03(\v2\x1f.nginx.agent.v1.TimeSeriesPointR\rstatus2xx5min\x12/\n" +
 	"\x14total_status_200_24h\x18\x02 \x01(\x03R\x11totalStatus20024h\x12G\n" +
@@ -13047,13 +17896,14 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"status_3xx\x18\x05 \x03(\v2\x1f.nginx.agent.v1.TimeSeriesPointR\tstatus3xx\x12>\n" +
 	"\n" +
 	"status_5xx\x18\x06 \x03(\v2\x1f.nginx.agent.v1.TimeSeriesPointR\tstatus5xx\x12(\n" +
-	"\x10total_status_503\x18\a \x01(\x03R\x0etotalStatus503\"\x80\x01\n" +
+	"\x10total_status_503\x18\a \x01(\x03R\x0etotalStatus503\"\x92\x01\n" +
 	"\fEndpointStat\x12\x10\n" +
 	"\x03uri\x18\x01 \x01(\tR\x03uri\x12\x1a\n" +
 	"\brequests\x18\x02 \x01(\x03R\brequests\x12\x10\n" +
 	"\x03p95\x18\x03 \x01(\x02R\x03p95\x12\x16\n" +
 	"\x06errors\x18\x04 \x01(\x03R\x06errors\x12\x18\n" +
-	"\atraffic\x18\x05 \x01(\tR\atraffic\"2\n" +
+	"\atraffic\x18\x05 \x01(\tR\atraffic\x12\x10\n" +
+	"\x03p50\x18\x06 \x01(\x02R\x03p50\"2\n" +
 	"\x15RecommendationRequest\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\"b\n" +
 	"\x16RecommendationResponse\x12H\n" +
@@ -13288,7 +18138,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\treport_id\x18\x01 \x01(\tR\breportId\x12\x1b\n" +
 	"\tagent_ids\x18\x02 \x03(\tR\bagentIds\x12\x16\n" +
 	"\x06action\x18\x03 \x01(\tR\x06action\x12&\n" +
-	"\x0fsource_agent_id\x18\x04 \x01(\tR\rsourceAgentId\"\x88\x06\n" +
+	"\x0fsource_agent_id\x18\x04 \x01(\tR\rsourceAgentId\"\xa7\x06\n" +
 	"\x18BatchConfigUpdateRequest\x12\x1b\n" +
 	"\tagent_ids\x18\x01 \x03(\tR\bagentIds\x12\x19\n" +
 	"\bgroup_id\x18\x02 \x01(\tR\agroupId\x12%\n" +
@@ -13297,7 +18147,9 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"templateId\x12\x1f\n" +
 	"\vraw_content\x18\x05 \x01(\tR\n" +
 	"rawContent\x12&\n" +
-	"\x0fsource_agent_id\x18\x06 \x01(\tR\rsourceAgentId\x12U\n" +
+	"\x0fsource_agent_id\x18\x06 \x01(\tR\rsourceAgentId\x12\x1d\n" +
+	"\n" +
+	"snippet_id\x18\x12 \x01(\tR\tsnippetId\x12U\n" +
 	"\tvariables\x18\a \x03(\v27.nginx.agent.v1.BatchConfigUpdateRequest.VariablesEntryR\tvariables\x12\x1a\n" +
 	"\bstrategy\x18\b \x01(\tR\bstrategy\x12\x1d\n" +
 	"\n" +
@@ -13412,7 +18264,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\bdefaults\x18\t \x03(\v29.nginx.agent.v1.CreateConfigTemplateRequest.DefaultsEntryR\bdefaults\x1a;\n" +
 	"\rDefaultsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xff\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa8\x03\n" +
 	"\x1bUpdateConfigTemplateRequest\x12\x1f\n" +
 	"\vtemplate_id\x18\x01 \x01(\tR\n" +
 	"templateId\x12\x12\n" +
@@ -13421,7 +18273,8 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\acontent\x18\x04 \x01(\tR\acontent\x12>\n" +
 	"\tvariables\x18\x05 \x03(\v2 .nginx.agent.v1.TemplateVariableR\tvariables\x12U\n" +
 	"\bdefaults\x18\x06 \x03(\v29.nginx.agent.v1.UpdateConfigTemplateRequest.DefaultsEntryR\bdefaults\x12\x1b\n" +
-	"\tis_active\x18\a \x01(\bR\bisActive\x1a;\n" +
+	"\tis_active\x18\a \x01(\bR\bisActive\x12'\n" +
+	"\x0frestore_version\x18\b \x01(\x05R\x0erestoreVersion\x1a;\n" +
 	"\rDefaultsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\">\n" +
@@ -13430,18 +18283,66 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"templateId\"R\n" +
 	"\x1cDeleteConfigTemplateResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\xd6\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xb2\x02\n" +
 	"\x1bRenderConfigTemplateRequest\x12\x1f\n" +
 	"\vtemplate_id\x18\x01 \x01(\tR\n" +
 	"templateId\x12X\n" +
-	"\tvariables\x18\x02 \x03(\v2:.nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntryR\tvariables\x1a<\n" +
+	"\tvariables\x18\x02 \x03(\v2:.nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntryR\tvariables\x12\x19\n" +
+	"\bagent_id\x18\x03 \x01(\tR\aagentId\x12%\n" +
+	"\x0eenvironment_id\x18\x04 \x01(\tR\renvironmentId\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x05R\aversion\x1a<\n" +
 	"\x0eVariablesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe7\x02\n" +
 	"\x1cRenderConfigTemplateResponse\x12)\n" +
 	"\x10rendered_content\x18\x01 \x01(\tR\x0frenderedContent\x12\x14\n" +
 	"\x05valid\x18\x02 \x01(\bR\x05valid\x12+\n" +
-	"\x11validation_errors\x18\x03 \x03(\tR\x10validationErrors\"\x9e\x04\n" +
+	"\x11validation_errors\x18\x03 \x03(\tR\x10validationErrors\x12r\n" +
+	"\x12resolved_variables\x18\x04 \x03(\v2C.nginx.agent.v1.RenderConfigTemplateResponse.ResolvedVariablesEntryR\x11resolvedVariables\x12\x1f\n" +
+	"\vtarget_path\x18\x05 \x01(\tR\n" +
+	"targetPath\x1aD\n" +
+	"\x16ResolvedVariablesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf8\x02\n" +
+	"\x15ConfigTemplateVersion\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12>\n" +
+	"\tvariables\x18\x04 \x03(\v2 .nginx.agent.v1.TemplateVariableR\tvariables\x12O\n" +
+	"\bdefaults\x18\x05 \x03(\v23.nginx.agent.v1.ConfigTemplateVersion.DefaultsEntryR\bdefaults\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x06 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x1a;\n" +
+	"\rDefaultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"D\n" +
+	"!ListConfigTemplateVersionsRequest\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\"g\n" +
+	"\"ListConfigTemplateVersionsResponse\x12A\n" +
+	"\bversions\x18\x01 \x03(\v2%.nginx.agent.v1.ConfigTemplateVersionR\bversions\"\x9e\x02\n" +
+	"\x17ConfigTemplateVariables\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\x12\x19\n" +
+	"\bscope_id\x18\x03 \x01(\tR\ascopeId\x12T\n" +
+	"\tvariables\x18\x04 \x03(\v26.nginx.agent.v1.ConfigTemplateVariables.VariablesEntryR\tvariables\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x1a<\n" +
+	"\x0eVariablesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x93\x02\n" +
+	"!SetConfigTemplateVariablesRequest\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\x12\x19\n" +
+	"\bscope_id\x18\x03 \x01(\tR\ascopeId\x12^\n" +
+	"\tvariables\x18\x04 \x03(\v2@.nginx.agent.v1.SetConfigTemplateVariablesRequest.VariablesEntryR\tvariables\x1a<\n" +
+	"\x0eVariablesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9e\x04\n" +
 	"\x13MaintenanceTemplate\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
 	"\n" +
@@ -13615,7 +18516,7 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\f \x01(\x03R\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\r \x01(\x03R\tupdatedAt\"\xe3\x02\n" +
+	"updated_at\x18\r \x01(\x03R\tupdatedAt\"\x82\x03\n" +
 	"\x18UploadCertificateRequest\x12\x16\n" +
 	"\x06domain\x18\x01 \x01(\tR\x06domain\x12!\n" +
 	"\fcert_content\x18\x02 \x01(\fR\vcertContent\x12\x1f\n" +
@@ -13628,7 +18529,9 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\bgroup_id\x18\b \x01(\tR\agroupId\x12'\n" +
 	"\x0fbackup_existing\x18\t \x01(\bR\x0ebackupExisting\x12!\n" +
 	"\freload_nginx\x18\n" +
-	" \x01(\bR\vreloadNginx\"\xba\x01\n" +
+	" \x01(\bR\vreloadNginx\x12\x1d\n" +
+	"\n" +
+	"vault_path\x18\v \x01(\tR\tvaultPath\"\xba\x01\n" +
 	"\x19UploadCertificateResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12%\n" +
 	"\x0ecertificate_id\x18\x02 \x01(\tR\rcertificateId\x12F\n" +
@@ -13747,9 +18650,102 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x1aSiteLocationUpdateResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12;\n" +
 	"\aresults\x18\x02 \x03(\v2!.nginx.agent.v1.AgentUpdateResultR\aresults\x12)\n" +
-	"\x10validation_error\x18\x03 \x01(\tR\x0fvalidationError2W\n" +
+	"\x10validation_error\x18\x03 \x01(\tR\x0fvalidationError\"6\n" +
+	"\x13UpstreamListRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\"\xcb\x01\n" +
+	"\x0eUpstreamServer\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06params\x18\x02 \x03(\tR\x06params\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x05R\x06weight\x12\x16\n" +
+	"\x06backup\x18\x04 \x01(\bR\x06backup\x12\x12\n" +
+	"\x04down\x18\x05 \x01(\bR\x04down\x12\x14\n" +
+	"\x05drain\x18\x06 \x01(\bR\x05drain\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12\x17\n" +
+	"\apeer_id\x18\b \x01(\x03R\x06peerId\"\x97\x02\n" +
+	"\fUpstreamPool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x128\n" +
+	"\aservers\x18\x03 \x03(\v2\x1e.nginx.agent.v1.UpstreamServerR\aservers\x12L\n" +
+	"\n" +
+	"directives\x18\x04 \x03(\v2,.nginx.agent.v1.UpstreamPool.DirectivesEntryR\n" +
+	"directives\x12\x18\n" +
+	"\adynamic\x18\x05 \x01(\bR\adynamic\x1a=\n" +
+	"\x0fDirectivesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x83\x01\n" +
+	"\x14UpstreamListResponse\x12:\n" +
+	"\tupstreams\x18\x01 \x03(\v2\x1c.nginx.agent.v1.UpstreamPoolR\tupstreams\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x19\n" +
+	"\bplus_api\x18\x03 \x01(\bR\aplusApi\"\x9d\x01\n" +
+	"\x14UpstreamServerUpdate\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12\x1a\n" +
+	"\bupstream\x18\x02 \x01(\tR\bupstream\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x16\n" +
+	"\x06params\x18\x05 \x03(\tR\x06params\"f\n" +
+	"\x1cUpstreamServerUpdateResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\"\x88\x01\n" +
+	"\x14ServiceStatusRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12*\n" +
+	"\x11nginx_instance_id\x18\x02 \x01(\tR\x0fnginxInstanceId\x12#\n" +
+	"\rjournal_lines\x18\x03 \x01(\x05R\fjournalLines\"\x85\x04\n" +
+	"\rServiceStatus\x12\x18\n" +
+	"\amanager\x18\x01 \x01(\tR\amanager\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1b\n" +
+	"\tsub_state\x18\x04 \x01(\tR\bsubState\x12\x16\n" +
+	"\x06result\x18\x05 \x01(\tR\x06result\x12\x19\n" +
+	"\bmain_pid\x18\x06 \x01(\x05R\amainPid\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\a \x01(\x03R\tstartedAt\x12&\n" +
+	"\x0flast_failure_at\x18\b \x01(\x03R\rlastFailureAt\x12#\n" +
+	"\rrestart_count\x18\t \x01(\x05R\frestartCount\x12#\n" +
+	"\rfailure_count\x18\n" +
+	" \x01(\x05R\ffailureCount\x12!\n" +
+	"\frestart_loop\x18\v \x01(\bR\vrestartLoop\x126\n" +
+	"\ahistory\x18\f \x03(\v2\x1c.nginx.agent.v1.ServiceEventR\ahistory\x12\x18\n" +
+	"\ajournal\x18\r \x03(\tR\ajournal\x12!\n" +
+	"\fnew_restarts\x18\x0e \x01(\x05R\vnewRestarts\x12!\n" +
+	"\fnew_failures\x18\x0f \x01(\x05R\vnewFailures\x12\x14\n" +
+	"\x05error\x18\x10 \x01(\tR\x05error\"X\n" +
+	"\fServiceEvent\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xd0\x01\n" +
+	"\x14BinaryUpgradeRequest\x12\x1f\n" +
+	"\vinstance_id\x18\x01 \x01(\tR\n" +
+	"instanceId\x12*\n" +
+	"\x11nginx_instance_id\x18\x02 \x01(\tR\x0fnginxInstanceId\x12\x1f\n" +
+	"\vbinary_path\x18\x03 \x01(\tR\n" +
+	"binaryPath\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\tR\x0fexpectedVersion\x12\x1f\n" +
+	"\vtimeout_sec\x18\x05 \x01(\x05R\n" +
+	"timeoutSec\"\x95\x02\n" +
+	"\x15BinaryUpgradeResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
+	"\vrolled_back\x18\x03 \x01(\bR\n" +
+	"rolledBack\x12\x17\n" +
+	"\aold_pid\x18\x04 \x01(\x05R\x06oldPid\x12\x17\n" +
+	"\anew_pid\x18\x05 \x01(\x05R\x06newPid\x12\x1f\n" +
+	"\vold_version\x18\x06 \x01(\tR\n" +
+	"oldVersion\x12\x1f\n" +
+	"\vnew_version\x18\a \x01(\tR\n" +
+	"newVersion\x127\n" +
+	"\x05steps\x18\b \x03(\v2!.nginx.agent.v1.BinaryUpgradeStepR\x05steps\"p\n" +
+	"\x11BinaryUpgradeStep\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs2W\n" +
 	"\tCommander\x12J\n" +
-	"\aConnect\x12\x1c.nginx.agent.v1.AgentMessage\x1a\x1d.nginx.agent.v1.ServerCommand(\x010\x012\xf60\n" +
+	"\aConnect\x12\x1c.nginx.agent.v1.AgentMessage\x1a\x1d.nginx.agent.v1.ServerCommand(\x010\x012\xd36\n" +
 	"\fAgentService\x12J\n" +
 	"\tGetConfig\x12\x1d.nginx.agent.v1.ConfigRequest\x1a\x1e.nginx.agent.v1.ConfigResponse\x12R\n" +
 	"\fUpdateConfig\x12\x1c.nginx.agent.v1.ConfigUpdate\x1a$.nginx.agent.v1.ConfigUpdateResponse\x12T\n" +
@@ -13770,7 +18766,8 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x0fGetTraceDetails\x12\x1c.nginx.agent.v1.TraceRequest\x1a\x15.nginx.agent.v1.Trace\x12c\n" +
 	"\x12GetRecommendations\x12%.nginx.agent.v1.RecommendationRequest\x1a&.nginx.agent.v1.RecommendationResponse\x12Y\n" +
 	"\fApplyAugment\x12#.nginx.agent.v1.ApplyAugmentRequest\x1a$.nginx.agent.v1.ApplyAugmentResponse\x12V\n" +
-	"\vUpdateAgent\x12\".nginx.agent.v1.UpdateAgentRequest\x1a#.nginx.agent.v1.UpdateAgentResponse\x12H\n" +
+	"\vUpdateAgent\x12\".nginx.agent.v1.UpdateAgentRequest\x1a#.nginx.agent.v1.UpdateAgentResponse\x12W\n" +
+	"\x0fGetUpdateStatus\x12&.nginx.agent.v1.GetUpdateStatusRequest\x1a\x1c.nginx.agent.v1.UpdateStatus\x12H\n" +
 	"\aExecute\x12\x1b.nginx.agent.v1.ExecRequest\x1a\x1c.nginx.agent.v1.ExecResponse(\x010\x01\x12T\n" +
 	"\x0eGetAgentConfig\x12%.nginx.agent.v1.GetAgentConfigRequest\x1a\x1b.nginx.agent.v1.AgentConfig\x12Y\n" +
 	"\x11UpdateAgentConfig\x12\x1b.nginx.agent.v1.AgentConfig\x1a'.nginx.agent.v1.AgentConfigUpdateResult\x12O\n" +
@@ -13805,7 +18802,9 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x14CreateConfigTemplate\x12+.nginx.agent.v1.CreateConfigTemplateRequest\x1a\x1e.nginx.agent.v1.ConfigTemplate\x12c\n" +
 	"\x14UpdateConfigTemplate\x12+.nginx.agent.v1.UpdateConfigTemplateRequest\x1a\x1e.nginx.agent.v1.ConfigTemplate\x12q\n" +
 	"\x14DeleteConfigTemplate\x12+.nginx.agent.v1.DeleteConfigTemplateRequest\x1a,.nginx.agent.v1.DeleteConfigTemplateResponse\x12q\n" +
-	"\x14RenderConfigTemplate\x12+.nginx.agent.v1.RenderConfigTemplateRequest\x1a,.nginx.agent.v1.RenderConfigTemplateResponse\x12_\n" +
+	"\x14RenderConfigTemplate\x12+.nginx.agent.v1.RenderConfigTemplateRequest\x1a,.nginx.agent.v1.RenderConfigTemplateResponse\x12\x83\x01\n" +
+	"\x1aListConfigTemplateVersions\x121.nginx.agent.v1.ListConfigTemplateVersionsRequest\x1a2.nginx.agent.v1.ListConfigTemplateVersionsResponse\x12x\n" +
+	"\x1aSetConfigTemplateVariables\x121.nginx.agent.v1.SetConfigTemplateVariablesRequest\x1a'.nginx.agent.v1.ConfigTemplateVariables\x12_\n" +
 	"\x0eSetMaintenance\x12%.nginx.agent.v1.SetMaintenanceRequest\x1a&.nginx.agent.v1.SetMaintenanceResponse\x12e\n" +
 	"\x14GetMaintenanceStatus\x12+.nginx.agent.v1.GetMaintenanceStatusRequest\x1a .nginx.agent.v1.MaintenanceState\x12t\n" +
 	"\x15ListMaintenanceStates\x12,.nginx.agent.v1.ListMaintenanceStatesRequest\x1a-.nginx.agent.v1.ListMaintenanceStatesResponse\x12}\n" +
@@ -13820,7 +18819,11 @@ const file_api_proto_agent_proto_rawDesc = "" +
 	"\x11DeleteCertificate\x12(.nginx.agent.v1.DeleteCertificateRequest\x1a).nginx.agent.v1.DeleteCertificateResponse\x12n\n" +
 	"\x13CompareEnvironments\x12*.nginx.agent.v1.CompareEnvironmentsRequest\x1a+.nginx.agent.v1.CompareEnvironmentsResponse\x12b\n" +
 	"\rGetComparison\x12$.nginx.agent.v1.GetComparisonRequest\x1a+.nginx.agent.v1.CompareEnvironmentsResponse\x12k\n" +
-	"\x12UpdateSiteLocation\x12).nginx.agent.v1.SiteLocationUpdateRequest\x1a*.nginx.agent.v1.SiteLocationUpdateResponseB7Z5github.com/avika-ai/avika/internal/common/proto/agentb\x06proto3"
+	"\x12UpdateSiteLocation\x12).nginx.agent.v1.SiteLocationUpdateRequest\x1a*.nginx.agent.v1.SiteLocationUpdateResponse\x12Z\n" +
+	"\rListUpstreams\x12#.nginx.agent.v1.UpstreamListRequest\x1a$.nginx.agent.v1.UpstreamListResponse\x12j\n" +
+	"\x14UpdateUpstreamServer\x12$.nginx.agent.v1.UpstreamServerUpdate\x1a,.nginx.agent.v1.UpstreamServerUpdateResponse\x12W\n" +
+	"\x10GetServiceStatus\x12$.nginx.agent.v1.ServiceStatusRequest\x1a\x1d.nginx.agent.v1.ServiceStatus\x12a\n" +
+	"\x12UpgradeNginxBinary\x12$.nginx.agent.v1.BinaryUpgradeRequest\x1a%.nginx.agent.v1.BinaryUpgradeResponseB7Z5github.com/avika-ai/avika/internal/common/proto/agentb\x06proto3"
 
 var (
 	file_api_proto_agent_proto_rawDescOnce sync.Once
@@ -13834,458 +18837,590 @@ func file_api_proto_agent_proto_rawDescGZIP() []byte {
 	return file_api_proto_agent_proto_rawDescData
 }
 
-var file_api_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 197)
+var file_api_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 257)
 var file_api_proto_agent_proto_goTypes = []any{
 	(*AgentMessage)(nil),                       // 0: nginx.agent.v1.AgentMessage
-	(*SystemMetrics)(nil),                      // 1: nginx.agent.v1.SystemMetrics
-	(*HttpStatusMetrics)(nil),                  // 2: nginx.agent.v1.HttpStatusMetrics
-	(*NginxMetrics)(nil),                       // 3: nginx.agent.v1.NginxMetrics
-	(*HistogramBucket)(nil),                    // 4: nginx.agent.v1.HistogramBucket
-	(*ServerCommand)(nil),                      // 5: nginx.agent.v1.ServerCommand
-	(*Update)(nil),                             // 6: nginx.agent.v1.Update
-	(*Heartbeat)(nil),                          // 7: nginx.agent.v1.Heartbeat
-	(*NginxInstance)(nil),                      // 8: nginx.agent.v1.NginxInstance
-	(*CommandResult)(nil),                      // 9: nginx.agent.v1.CommandResult
-	(*StateSnapshot)(nil),                      // 10: nginx.agent.v1.StateSnapshot
-	(*ConfigHashes)(nil),                       // 11: nginx.agent.v1.ConfigHashes
-	(*FileHash)(nil),                           // 12: nginx.agent.v1.FileHash
-	(*CertHashInfo)(nil),                       // 13: nginx.agent.v1.CertHashInfo
-	(*ConfigPush)(nil),                         // 14: nginx.agent.v1.ConfigPush
-	(*Action)(nil),                             // 15: nginx.agent.v1.Action
-	(*ConfigAugment)(nil),                      // 16: nginx.agent.v1.ConfigAugment
-	(*ListAlertRulesRequest)(nil),              // 17: nginx.agent.v1.ListAlertRulesRequest
-	(*AlertRuleList)(nil),                      // 18: nginx.agent.v1.AlertRuleList
-	(*DeleteAlertRuleRequest)(nil),             // 19: nginx.agent.v1.DeleteAlertRuleRequest
-	(*DeleteAlertRuleResponse)(nil),            // 20: nginx.agent.v1.DeleteAlertRuleResponse
-	(*AlertRule)(nil),                          // 21: nginx.agent.v1.AlertRule
-	(*ExecRequest)(nil),                        // 22: nginx.agent.v1.ExecRequest
-	(*ExecResponse)(nil),                       // 23: nginx.agent.v1.ExecResponse
-	(*UpdateAgentRequest)(nil),                 // 24: nginx.agent.v1.UpdateAgentRequest
-	(*UpdateAgentResponse)(nil),                // 25: nginx.agent.v1.UpdateAgentResponse
-	(*ConfigRequest)(nil),                      // 26: nginx.agent.v1.ConfigRequest
-	(*ConfigResponse)(nil),                     // 27: nginx.agent.v1.ConfigResponse
-	(*NginxConfig)(nil),                        // 28: nginx.agent.v1.NginxConfig
-	(*ServerBlock)(nil),                        // 29: nginx.agent.v1.ServerBlock
-	(*LocationBlock)(nil),                      // 30: nginx.agent.v1.LocationBlock
-	(*UpstreamBlock)(nil),                      // 31: nginx.agent.v1.UpstreamBlock
-	(*ConfigUpdate)(nil),                       // 32: nginx.agent.v1.ConfigUpdate
-	(*ConfigUpdateResponse)(nil),               // 33: nginx.agent.v1.ConfigUpdateResponse
-	(*ConfigValidation)(nil),                   // 34: nginx.agent.v1.ConfigValidation
-	(*ValidationResult)(nil),                   // 35: nginx.agent.v1.ValidationResult
-	(*ReloadRequest)(nil),                      // 36: nginx.agent.v1.ReloadRequest
-	(*ReloadResponse)(nil),                     // 37: nginx.agent.v1.ReloadResponse
-	(*RestartRequest)(nil),                     // 38: nginx.agent.v1.RestartRequest
-	(*RestartResponse)(nil),                    // 39: nginx.agent.v1.RestartResponse
-	(*StopRequest)(nil),                        // 40: nginx.agent.v1.StopRequest
-	(*StopResponse)(nil),                       // 41: nginx.agent.v1.StopResponse
-	(*CertListRequest)(nil),                    // 42: nginx.agent.v1.CertListRequest
-	(*CertListResponse)(nil),                   // 43: nginx.agent.v1.CertListResponse
-	(*Certificate)(nil),                        // 44: nginx.agent.v1.Certificate
-	(*ListAgentsRequest)(nil),                  // 45: nginx.agent.v1.ListAgentsRequest
-	(*ListAgentsResponse)(nil),                 // 46: nginx.agent.v1.ListAgentsResponse
-	(*RemoveAgentRequest)(nil),                 // 47: nginx.agent.v1.RemoveAgentRequest
-	(*RemoveAgentResponse)(nil),                // 48: nginx.agent.v1.RemoveAgentResponse
-	(*GetAgentRequest)(nil),                    // 49: nginx.agent.v1.GetAgentRequest
-	(*AgentInfo)(nil),                          // 50: nginx.agent.v1.AgentInfo
-	(*LogRequest)(nil),                         // 51: nginx.agent.v1.LogRequest
-	(*LogEntry)(nil),                           // 52: nginx.agent.v1.LogEntry
-	(*UptimeRequest)(nil),                      // 53: nginx.agent.v1.UptimeRequest
-	(*UptimeResponse)(nil),                     // 54: nginx.agent.v1.UptimeResponse
-	(*UptimeReport)(nil),                       // 55: nginx.agent.v1.UptimeReport
-	(*AnalyticsRequest)(nil),                   // 56: nginx.agent.v1.AnalyticsRequest
-	(*AnalyticsResponse)(nil),                  // 57: nginx.agent.v1.AnalyticsResponse
-	(*GatewayMetricPoint)(nil),                 // 58: nginx.agent.v1.GatewayMetricPoint
-	(*Span)(nil),                               // 59: nginx.agent.v1.Span
-	(*Trace)(nil),                              // 60: nginx.agent.v1.Trace
-	(*TraceRequest)(nil),                       // 61: nginx.agent.v1.TraceRequest
-	(*TraceList)(nil),                          // 62: nginx.agent.v1.TraceList
-	(*Insight)(nil),                            // 63: nginx.agent.v1.Insight
-	(*ApplyAugmentRequest)(nil),                // 64: nginx.agent.v1.ApplyAugmentRequest
-	(*ApplyAugmentResponse)(nil),               // 65: nginx.agent.v1.ApplyAugmentResponse
-	(*AnalyticsSummary)(nil),                   // 66: nginx.agent.v1.AnalyticsSummary
-	(*LatencyBucket)(nil),                      // 67: nginx.agent.v1.LatencyBucket
-	(*ServerStat)(nil),                         // 68: nginx.agent.v1.ServerStat
-	(*NginxMetricPoint)(nil),                   // 69: nginx.agent.v1.NginxMetricPoint
-	(*TimeSeriesPoint)(nil),                    // 70: nginx.agent.v1.TimeSeriesPoint
-	(*StatusCount)(nil),                        // 71: nginx.agent.v1.StatusCount
-	(*LatencyPercentiles)(nil),                 // 72: nginx.agent.v1.LatencyPercentiles
-	(*SystemMetricPoint)(nil),                  // 73: nginx.agent.v1.SystemMetricPoint
-	(*HttpStatusMetricsResponse)(nil),          // 74: nginx.agent.v1.HttpStatusMetricsResponse
-	(*EndpointStat)(nil),                       // 75: nginx.agent.v1.EndpointStat
-	(*RecommendationRequest)(nil),              // 76: nginx.agent.v1.RecommendationRequest
-	(*RecommendationResponse)(nil),             // 77: nginx.agent.v1.RecommendationResponse
-	(*Recommendation)(nil),                     // 78: nginx.agent.v1.Recommendation
-	(*ReportRequest)(nil),                      // 79: nginx.agent.v1.ReportRequest
-	(*ReportResponse)(nil),                     // 80: nginx.agent.v1.ReportResponse
-	(*ReportSummary)(nil),                      // 81: nginx.agent.v1.ReportSummary
-	(*SecurityEvent)(nil),                      // 82: nginx.agent.v1.SecurityEvent
-	(*SendReportRequest)(nil),                  // 83: nginx.agent.v1.SendReportRequest
-	(*SendReportResponse)(nil),                 // 84: nginx.agent.v1.SendReportResponse
-	(*ReportDownloadResponse)(nil),             // 85: nginx.agent.v1.ReportDownloadResponse
-	(*GetAgentConfigRequest)(nil),              // 86: nginx.agent.v1.GetAgentConfigRequest
-	(*AgentConfig)(nil),                        // 87: nginx.agent.v1.AgentConfig
-	(*AgentConfigUpdateResult)(nil),            // 88: nginx.agent.v1.AgentConfigUpdateResult
-	(*AgentGroup)(nil),                         // 89: nginx.agent.v1.AgentGroup
-	(*ListGroupsRequest)(nil),                  // 90: nginx.agent.v1.ListGroupsRequest
-	(*ListGroupsResponse)(nil),                 // 91: nginx.agent.v1.ListGroupsResponse
-	(*GetGroupRequest)(nil),                    // 92: nginx.agent.v1.GetGroupRequest
-	(*CreateGroupRequest)(nil),                 // 93: nginx.agent.v1.CreateGroupRequest
-	(*UpdateGroupRequest)(nil),                 // 94: nginx.agent.v1.UpdateGroupRequest
-	(*DeleteGroupRequest)(nil),                 // 95: nginx.agent.v1.DeleteGroupRequest
-	(*DeleteGroupResponse)(nil),                // 96: nginx.agent.v1.DeleteGroupResponse
-	(*AddAgentsToGroupRequest)(nil),            // 97: nginx.agent.v1.AddAgentsToGroupRequest
-	(*AddAgentsToGroupResponse)(nil),           // 98: nginx.agent.v1.AddAgentsToGroupResponse
-	(*AgentGroupAssignmentResult)(nil),         // 99: nginx.agent.v1.AgentGroupAssignmentResult
-	(*RemoveAgentFromGroupRequest)(nil),        // 100: nginx.agent.v1.RemoveAgentFromGroupRequest
-	(*RemoveAgentFromGroupResponse)(nil),       // 101: nginx.agent.v1.RemoveAgentFromGroupResponse
-	(*SetGoldenAgentRequest)(nil),              // 102: nginx.agent.v1.SetGoldenAgentRequest
-	(*SetGoldenAgentResponse)(nil),             // 103: nginx.agent.v1.SetGoldenAgentResponse
-	(*GetGroupAgentsRequest)(nil),              // 104: nginx.agent.v1.GetGroupAgentsRequest
-	(*GetGroupAgentsResponse)(nil),             // 105: nginx.agent.v1.GetGroupAgentsResponse
-	(*DriftCheckRequest)(nil),                  // 106: nginx.agent.v1.DriftCheckRequest
-	(*DriftCheckResponse)(nil),                 // 107: nginx.agent.v1.DriftCheckResponse
-	(*DriftItem)(nil),                          // 108: nginx.agent.v1.DriftItem
-	(*GetDriftReportRequest)(nil),              // 109: nginx.agent.v1.GetDriftReportRequest
-	(*ListDriftReportsRequest)(nil),            // 110: nginx.agent.v1.ListDriftReportsRequest
-	(*ListDriftReportsResponse)(nil),           // 111: nginx.agent.v1.ListDriftReportsResponse
-	(*ResolveDriftRequest)(nil),                // 112: nginx.agent.v1.ResolveDriftRequest
-	(*BatchConfigUpdateRequest)(nil),           // 113: nginx.agent.v1.BatchConfigUpdateRequest
-	(*BatchConfigUpdateResponse)(nil),          // 114: nginx.agent.v1.BatchConfigUpdateResponse
-	(*AgentUpdateResult)(nil),                  // 115: nginx.agent.v1.AgentUpdateResult
-	(*GetBatchStatusRequest)(nil),              // 116: nginx.agent.v1.GetBatchStatusRequest
-	(*CancelBatchRequest)(nil),                 // 117: nginx.agent.v1.CancelBatchRequest
-	(*CancelBatchResponse)(nil),                // 118: nginx.agent.v1.CancelBatchResponse
-	(*RollbackBatchRequest)(nil),               // 119: nginx.agent.v1.RollbackBatchRequest
-	(*RollbackBatchResponse)(nil),              // 120: nginx.agent.v1.RollbackBatchResponse
-	(*ConfigTemplate)(nil),                     // 121: nginx.agent.v1.ConfigTemplate
-	(*TemplateVariable)(nil),                   // 122: nginx.agent.v1.TemplateVariable
-	(*ListConfigTemplatesRequest)(nil),         // 123: nginx.agent.v1.ListConfigTemplatesRequest
-	(*ListConfigTemplatesResponse)(nil),        // 124: nginx.agent.v1.ListConfigTemplatesResponse
-	(*GetConfigTemplateRequest)(nil),           // 125: nginx.agent.v1.GetConfigTemplateRequest
-	(*CreateConfigTemplateRequest)(nil),        // 126: nginx.agent.v1.CreateConfigTemplateRequest
-	(*UpdateConfigTemplateRequest)(nil),        // 127: nginx.agent.v1.UpdateConfigTemplateRequest
-	(*DeleteConfigTemplateRequest)(nil),        // 128: nginx.agent.v1.DeleteConfigTemplateRequest
-	(*DeleteConfigTemplateResponse)(nil),       // 129: nginx.agent.v1.DeleteConfigTemplateResponse
-	(*RenderConfigTemplateRequest)(nil),        // 130: nginx.agent.v1.RenderConfigTemplateRequest
-	(*RenderConfigTemplateResponse)(nil),       // 131: nginx.agent.v1.RenderConfigTemplateResponse
-	(*MaintenanceTemplate)(nil),                // 132: nginx.agent.v1.MaintenanceTemplate
-	(*MaintenanceState)(nil),                   // 133: nginx.agent.v1.MaintenanceState
-	(*SetMaintenanceRequest)(nil),              // 134: nginx.agent.v1.SetMaintenanceRequest
-	(*SetMaintenanceResponse)(nil),             // 135: nginx.agent.v1.SetMaintenanceResponse
-	(*AgentMaintenanceResult)(nil),             // 136: nginx.agent.v1.AgentMaintenanceResult
-	(*GetMaintenanceStatusRequest)(nil),        // 137: nginx.agent.v1.GetMaintenanceStatusRequest
-	(*ListMaintenanceStatesRequest)(nil),       // 138: nginx.agent.v1.ListMaintenanceStatesRequest
-	(*ListMaintenanceStatesResponse)(nil),      // 139: nginx.agent.v1.ListMaintenanceStatesResponse
-	(*ListMaintenanceTemplatesRequest)(nil),    // 140: nginx.agent.v1.ListMaintenanceTemplatesRequest
-	(*ListMaintenanceTemplatesResponse)(nil),   // 141: nginx.agent.v1.ListMaintenanceTemplatesResponse
-	(*CreateMaintenanceTemplateRequest)(nil),   // 142: nginx.agent.v1.CreateMaintenanceTemplateRequest
-	(*UpdateMaintenanceTemplateRequest)(nil),   // 143: nginx.agent.v1.UpdateMaintenanceTemplateRequest
-	(*DeleteMaintenanceTemplateRequest)(nil),   // 144: nginx.agent.v1.DeleteMaintenanceTemplateRequest
-	(*DeleteMaintenanceTemplateResponse)(nil),  // 145: nginx.agent.v1.DeleteMaintenanceTemplateResponse
-	(*PreviewMaintenanceTemplateRequest)(nil),  // 146: nginx.agent.v1.PreviewMaintenanceTemplateRequest
-	(*PreviewMaintenanceTemplateResponse)(nil), // 147: nginx.agent.v1.PreviewMaintenanceTemplateResponse
-	(*CertificateInventoryItem)(nil),           // 148: nginx.agent.v1.CertificateInventoryItem
-	(*UploadCertificateRequest)(nil),           // 149: nginx.agent.v1.UploadCertificateRequest
-	(*UploadCertificateResponse)(nil),          // 150: nginx.agent.v1.UploadCertificateResponse
-	(*CertDeploymentResult)(nil),               // 151: nginx.agent.v1.CertDeploymentResult
-	(*GetCertificateInventoryRequest)(nil),     // 152: nginx.agent.v1.GetCertificateInventoryRequest
-	(*GetCertificateInventoryResponse)(nil),    // 153: nginx.agent.v1.GetCertificateInventoryResponse
-	(*DeployCertificateRequest)(nil),           // 154: nginx.agent.v1.DeployCertificateRequest
-	(*DeleteCertificateRequest)(nil),           // 155: nginx.agent.v1.DeleteCertificateRequest
-	(*DeleteCertificateResponse)(nil),          // 156: nginx.agent.v1.DeleteCertificateResponse
-	(*CompareEnvironmentsRequest)(nil),         // 157: nginx.agent.v1.CompareEnvironmentsRequest
-	(*CompareEnvironmentsResponse)(nil),        // 158: nginx.agent.v1.CompareEnvironmentsResponse
-	(*ComparisonCategory)(nil),                 // 159: nginx.agent.v1.ComparisonCategory
-	(*ConfigDifference)(nil),                   // 160: nginx.agent.v1.ConfigDifference
-	(*ComparisonSummary)(nil),                  // 161: nginx.agent.v1.ComparisonSummary
-	(*GetComparisonRequest)(nil),               // 162: nginx.agent.v1.GetComparisonRequest
-	(*SiteLocationUpdateRequest)(nil),          // 163: nginx.agent.v1.SiteLocationUpdateRequest
-	(*LocationConfigData)(nil),                 // 164: nginx.agent.v1.LocationConfigData
-	(*RateLimitConfigData)(nil),                // 165: nginx.agent.v1.RateLimitConfigData
-	(*CacheConfigData)(nil),                    // 166: nginx.agent.v1.CacheConfigData
-	(*SiteLocationUpdateResponse)(nil),         // 167: nginx.agent.v1.SiteLocationUpdateResponse
-	nil,                                        // 168: nginx.agent.v1.SystemMetrics.LabelsEntry
-	nil,                                        // 169: nginx.agent.v1.NginxMetrics.LabelsEntry
-	nil,                                        // 170: nginx.agent.v1.Heartbeat.LabelsEntry
-	nil,                                        // 171: nginx.agent.v1.ConfigPush.FilesEntry
-	nil,                                        // 172: nginx.agent.v1.ServerBlock.SslConfigEntry
-	nil,                                        // 173: nginx.agent.v1.ServerBlock.DirectivesEntry
-	nil,                                        // 174: nginx.agent.v1.LocationBlock.DirectivesEntry
-	nil,                                        // 175: nginx.agent.v1.UpstreamBlock.DirectivesEntry
-	nil,                                        // 176: nginx.agent.v1.AgentInfo.LabelsEntry
-	nil,                                        // 177: nginx.agent.v1.GatewayMetricPoint.LabelsEntry
-	nil,                                        // 178: nginx.agent.v1.Span.AttributesEntry
-	nil,                                        // 179: nginx.agent.v1.AgentGroup.MetadataEntry
-	nil,                                        // 180: nginx.agent.v1.CreateGroupRequest.MetadataEntry
-	nil,                                        // 181: nginx.agent.v1.UpdateGroupRequest.MetadataEntry
-	nil,                                        // 182: nginx.agent.v1.BatchConfigUpdateRequest.VariablesEntry
-	nil,                                        // 183: nginx.agent.v1.ConfigTemplate.DefaultsEntry
-	nil,                                        // 184: nginx.agent.v1.CreateConfigTemplateRequest.DefaultsEntry
-	nil,                                        // 185: nginx.agent.v1.UpdateConfigTemplateRequest.DefaultsEntry
-	nil,                                        // 186: nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntry
-	nil,                                        // 187: nginx.agent.v1.MaintenanceTemplate.AssetsEntry
-	nil,                                        // 188: nginx.agent.v1.MaintenanceState.TemplateVarsEntry
-	nil,                                        // 189: nginx.agent.v1.MaintenanceState.BypassHeadersEntry
-	nil,                                        // 190: nginx.agent.v1.SetMaintenanceRequest.TemplateVarsEntry
-	nil,                                        // 191: nginx.agent.v1.SetMaintenanceRequest.BypassHeadersEntry
-	nil,                                        // 192: nginx.agent.v1.CreateMaintenanceTemplateRequest.AssetsEntry
-	nil,                                        // 193: nginx.agent.v1.UpdateMaintenanceTemplateRequest.AssetsEntry
-	nil,                                        // 194: nginx.agent.v1.PreviewMaintenanceTemplateRequest.VariablesEntry
-	nil,                                        // 195: nginx.agent.v1.CompareEnvironmentsRequest.GroupMappingEntry
-	nil,                                        // 196: nginx.agent.v1.LocationConfigData.ProxyHeadersEntry
-	(*LogRotateConfig)(nil),                    // 197: nginx.agent.v1.LogRotateConfig
-	(*SyslogConfig)(nil),                       // 198: nginx.agent.v1.SyslogConfig
+	(*UpdateRejected)(nil),                     // 1: nginx.agent.v1.UpdateRejected
+	(*GenericMetrics)(nil),                     // 2: nginx.agent.v1.GenericMetrics
+	(*GenericMetric)(nil),                      // 3: nginx.agent.v1.GenericMetric
+	(*SystemMetrics)(nil),                      // 4: nginx.agent.v1.SystemMetrics
+	(*ProcessFdUsage)(nil),                     // 5: nginx.agent.v1.ProcessFdUsage
+	(*DiskUsage)(nil),                          // 6: nginx.agent.v1.DiskUsage
+	(*LogFileUsage)(nil),                       // 7: nginx.agent.v1.LogFileUsage
+	(*HttpStatusMetrics)(nil),                  // 8: nginx.agent.v1.HttpStatusMetrics
+	(*NginxMetrics)(nil),                       // 9: nginx.agent.v1.NginxMetrics
+	(*NginxWorkers)(nil),                       // 10: nginx.agent.v1.NginxWorkers
+	(*NginxWorkerProcess)(nil),                 // 11: nginx.agent.v1.NginxWorkerProcess
+	(*HistogramBucket)(nil),                    // 12: nginx.agent.v1.HistogramBucket
+	(*ServerCommand)(nil),                      // 13: nginx.agent.v1.ServerCommand
+	(*Drain)(nil),                              // 14: nginx.agent.v1.Drain
+	(*ConfigureAgent)(nil),                     // 15: nginx.agent.v1.ConfigureAgent
+	(*Update)(nil),                             // 16: nginx.agent.v1.Update
+	(*Heartbeat)(nil),                          // 17: nginx.agent.v1.Heartbeat
+	(*AgentResourceStatus)(nil),                // 18: nginx.agent.v1.AgentResourceStatus
+	(*HostInventory)(nil),                      // 19: nginx.agent.v1.HostInventory
+	(*PackageVersion)(nil),                     // 20: nginx.agent.v1.PackageVersion
+	(*UpdateStatus)(nil),                       // 21: nginx.agent.v1.UpdateStatus
+	(*KubernetesMetadata)(nil),                 // 22: nginx.agent.v1.KubernetesMetadata
+	(*NginxInstance)(nil),                      // 23: nginx.agent.v1.NginxInstance
+	(*NginxBuild)(nil),                         // 24: nginx.agent.v1.NginxBuild
+	(*CommandResult)(nil),                      // 25: nginx.agent.v1.CommandResult
+	(*StateSnapshot)(nil),                      // 26: nginx.agent.v1.StateSnapshot
+	(*ConfigHashes)(nil),                       // 27: nginx.agent.v1.ConfigHashes
+	(*FileHash)(nil),                           // 28: nginx.agent.v1.FileHash
+	(*CertHashInfo)(nil),                       // 29: nginx.agent.v1.CertHashInfo
+	(*ConfigPush)(nil),                         // 30: nginx.agent.v1.ConfigPush
+	(*Action)(nil),                             // 31: nginx.agent.v1.Action
+	(*ConfigAugment)(nil),                      // 32: nginx.agent.v1.ConfigAugment
+	(*ListAlertRulesRequest)(nil),              // 33: nginx.agent.v1.ListAlertRulesRequest
+	(*AlertRuleList)(nil),                      // 34: nginx.agent.v1.AlertRuleList
+	(*DeleteAlertRuleRequest)(nil),             // 35: nginx.agent.v1.DeleteAlertRuleRequest
+	(*DeleteAlertRuleResponse)(nil),            // 36: nginx.agent.v1.DeleteAlertRuleResponse
+	(*AlertRule)(nil),                          // 37: nginx.agent.v1.AlertRule
+	(*ExecRequest)(nil),                        // 38: nginx.agent.v1.ExecRequest
+	(*ExecResponse)(nil),                       // 39: nginx.agent.v1.ExecResponse
+	(*UpdateAgentRequest)(nil),                 // 40: nginx.agent.v1.UpdateAgentRequest
+	(*GetUpdateStatusRequest)(nil),             // 41: nginx.agent.v1.GetUpdateStatusRequest
+	(*UpdateAgentResponse)(nil),                // 42: nginx.agent.v1.UpdateAgentResponse
+	(*ConfigRequest)(nil),                      // 43: nginx.agent.v1.ConfigRequest
+	(*ConfigResponse)(nil),                     // 44: nginx.agent.v1.ConfigResponse
+	(*NginxConfig)(nil),                        // 45: nginx.agent.v1.NginxConfig
+	(*ConfigFile)(nil),                         // 46: nginx.agent.v1.ConfigFile
+	(*ConfigDirective)(nil),                    // 47: nginx.agent.v1.ConfigDirective
+	(*ServerBlock)(nil),                        // 48: nginx.agent.v1.ServerBlock
+	(*LocationBlock)(nil),                      // 49: nginx.agent.v1.LocationBlock
+	(*UpstreamBlock)(nil),                      // 50: nginx.agent.v1.UpstreamBlock
+	(*ConfigUpdate)(nil),                       // 51: nginx.agent.v1.ConfigUpdate
+	(*ConfigUpdateResponse)(nil),               // 52: nginx.agent.v1.ConfigUpdateResponse
+	(*ConfigValidation)(nil),                   // 53: nginx.agent.v1.ConfigValidation
+	(*ValidationResult)(nil),                   // 54: nginx.agent.v1.ValidationResult
+	(*ReloadRequest)(nil),                      // 55: nginx.agent.v1.ReloadRequest
+	(*ReloadResponse)(nil),                     // 56: nginx.agent.v1.ReloadResponse
+	(*RestartRequest)(nil),                     // 57: nginx.agent.v1.RestartRequest
+	(*RestartResponse)(nil),                    // 58: nginx.agent.v1.RestartResponse
+	(*StopRequest)(nil),                        // 59: nginx.agent.v1.StopRequest
+	(*StopResponse)(nil),                       // 60: nginx.agent.v1.StopResponse
+	(*CertListRequest)(nil),                    // 61: nginx.agent.v1.CertListRequest
+	(*CertListResponse)(nil),                   // 62: nginx.agent.v1.CertListResponse
+	(*Certificate)(nil),                        // 63: nginx.agent.v1.Certificate
+	(*ListAgentsRequest)(nil),                  // 64: nginx.agent.v1.ListAgentsRequest
+	(*ListAgentsResponse)(nil),                 // 65: nginx.agent.v1.ListAgentsResponse
+	(*RemoveAgentRequest)(nil),                 // 66: nginx.agent.v1.RemoveAgentRequest
+	(*RemoveAgentResponse)(nil),                // 67: nginx.agent.v1.RemoveAgentResponse
+	(*GetAgentRequest)(nil),                    // 68: nginx.agent.v1.GetAgentRequest
+	(*AgentInfo)(nil),                          // 69: nginx.agent.v1.AgentInfo
+	(*LogRequest)(nil),                         // 70: nginx.agent.v1.LogRequest
+	(*LogEntry)(nil),                           // 71: nginx.agent.v1.LogEntry
+	(*UptimeRequest)(nil),                      // 72: nginx.agent.v1.UptimeRequest
+	(*UptimeResponse)(nil),                     // 73: nginx.agent.v1.UptimeResponse
+	(*UptimeReport)(nil),                       // 74: nginx.agent.v1.UptimeReport
+	(*AnalyticsRequest)(nil),                   // 75: nginx.agent.v1.AnalyticsRequest
+	(*AnalyticsComparison)(nil),                // 76: nginx.agent.v1.AnalyticsComparison
+	(*AnalyticsResponse)(nil),                  // 77: nginx.agent.v1.AnalyticsResponse
+	(*AnalyticsComparisonSummary)(nil),         // 78: nginx.agent.v1.AnalyticsComparisonSummary
+	(*MetricChange)(nil),                       // 79: nginx.agent.v1.MetricChange
+	(*CacheStats)(nil),                         // 80: nginx.agent.v1.CacheStats
+	(*CacheStatusCount)(nil),                   // 81: nginx.agent.v1.CacheStatusCount
+	(*CacheUriStat)(nil),                       // 82: nginx.agent.v1.CacheUriStat
+	(*BandwidthPoint)(nil),                     // 83: nginx.agent.v1.BandwidthPoint
+	(*BandwidthStat)(nil),                      // 84: nginx.agent.v1.BandwidthStat
+	(*EgressCost)(nil),                         // 85: nginx.agent.v1.EgressCost
+	(*AnalyticsDelta)(nil),                     // 86: nginx.agent.v1.AnalyticsDelta
+	(*SeriesPatch)(nil),                        // 87: nginx.agent.v1.SeriesPatch
+	(*GatewayMetricPoint)(nil),                 // 88: nginx.agent.v1.GatewayMetricPoint
+	(*Span)(nil),                               // 89: nginx.agent.v1.Span
+	(*Trace)(nil),                              // 90: nginx.agent.v1.Trace
+	(*TraceRequest)(nil),                       // 91: nginx.agent.v1.TraceRequest
+	(*TraceList)(nil),                          // 92: nginx.agent.v1.TraceList
+	(*Insight)(nil),                            // 93: nginx.agent.v1.Insight
+	(*ApplyAugmentRequest)(nil),                // 94: nginx.agent.v1.ApplyAugmentRequest
+	(*ApplyAugmentResponse)(nil),               // 95: nginx.agent.v1.ApplyAugmentResponse
+	(*AnalyticsSummary)(nil),                   // 96: nginx.agent.v1.AnalyticsSummary
+	(*LatencyBucket)(nil),                      // 97: nginx.agent.v1.LatencyBucket
+	(*ServerStat)(nil),                         // 98: nginx.agent.v1.ServerStat
+	(*ASNStat)(nil),                            // 99: nginx.agent.v1.ASNStat
+	(*BotCategoryStat)(nil),                    // 100: nginx.agent.v1.BotCategoryStat
+	(*BotTraffic)(nil),                         // 101: nginx.agent.v1.BotTraffic
+	(*NginxMetricPoint)(nil),                   // 102: nginx.agent.v1.NginxMetricPoint
+	(*TimeSeriesPoint)(nil),                    // 103: nginx.agent.v1.TimeSeriesPoint
+	(*StatusCount)(nil),                        // 104: nginx.agent.v1.StatusCount
+	(*LatencyPercentiles)(nil),                 // 105: nginx.agent.v1.LatencyPercentiles
+	(*SystemMetricPoint)(nil),                  // 106: nginx.agent.v1.SystemMetricPoint
+	(*HttpStatusMetricsResponse)(nil),          // 107: nginx.agent.v1.HttpStatusMetricsResponse
+	(*EndpointStat)(nil),                       // 108: nginx.agent.v1.EndpointStat
+	(*RecommendationRequest)(nil),              // 109: nginx.agent.v1.RecommendationRequest
+	(*RecommendationResponse)(nil),             // 110: nginx.agent.v1.RecommendationResponse
+	(*Recommendation)(nil),                     // 111: nginx.agent.v1.Recommendation
+	(*ReportRequest)(nil),                      // 112: nginx.agent.v1.ReportRequest
+	(*ReportResponse)(nil),                     // 113: nginx.agent.v1.ReportResponse
+	(*ReportSummary)(nil),                      // 114: nginx.agent.v1.ReportSummary
+	(*SecurityEvent)(nil),                      // 115: nginx.agent.v1.SecurityEvent
+	(*SendReportRequest)(nil),                  // 116: nginx.agent.v1.SendReportRequest
+	(*SendReportResponse)(nil),                 // 117: nginx.agent.v1.SendReportResponse
+	(*ReportDownloadResponse)(nil),             // 118: nginx.agent.v1.ReportDownloadResponse
+	(*GetAgentConfigRequest)(nil),              // 119: nginx.agent.v1.GetAgentConfigRequest
+	(*AgentConfig)(nil),                        // 120: nginx.agent.v1.AgentConfig
+	(*AgentConfigUpdateResult)(nil),            // 121: nginx.agent.v1.AgentConfigUpdateResult
+	(*AgentGroup)(nil),                         // 122: nginx.agent.v1.AgentGroup
+	(*ListGroupsRequest)(nil),                  // 123: nginx.agent.v1.ListGroupsRequest
+	(*ListGroupsResponse)(nil),                 // 124: nginx.agent.v1.ListGroupsResponse
+	(*GetGroupRequest)(nil),                    // 125: nginx.agent.v1.GetGroupRequest
+	(*CreateGroupRequest)(nil),                 // 126: nginx.agent.v1.CreateGroupRequest
+	(*UpdateGroupRequest)(nil),                 // 127: nginx.agent.v1.UpdateGroupRequest
+	(*DeleteGroupRequest)(nil),                 // 128: nginx.agent.v1.DeleteGroupRequest
+	(*DeleteGroupResponse)(nil),                // 129: nginx.agent.v1.DeleteGroupResponse
+	(*AddAgentsToGroupRequest)(nil),            // 130: nginx.agent.v1.AddAgentsToGroupRequest
+	(*AddAgentsToGroupResponse)(nil),           // 131: nginx.agent.v1.AddAgentsToGroupResponse
+	(*AgentGroupAssignmentResult)(nil),         // 132: nginx.agent.v1.AgentGroupAssignmentResult
+	(*RemoveAgentFromGroupRequest)(nil),        // 133: nginx.agent.v1.RemoveAgentFromGroupRequest
+	(*RemoveAgentFromGroupResponse)(nil),       // 134: nginx.agent.v1.RemoveAgentFromGroupResponse
+	(*SetGoldenAgentRequest)(nil),              // 135: nginx.agent.v1.SetGoldenAgentRequest
+	(*SetGoldenAgentResponse)(nil),             // 136: nginx.agent.v1.SetGoldenAgentResponse
+	(*GetGroupAgentsRequest)(nil),              // 137: nginx.agent.v1.GetGroupAgentsRequest
+	(*GetGroupAgentsResponse)(nil),             // 138: nginx.agent.v1.GetGroupAgentsResponse
+	(*DriftCheckRequest)(nil),                  // 139: nginx.agent.v1.DriftCheckRequest
+	(*DriftCheckResponse)(nil),                 // 140: nginx.agent.v1.DriftCheckResponse
+	(*DriftItem)(nil),                          // 141: nginx.agent.v1.DriftItem
+	(*GetDriftReportRequest)(nil),              // 142: nginx.agent.v1.GetDriftReportRequest
+	(*ListDriftReportsRequest)(nil),            // 143: nginx.agent.v1.ListDriftReportsRequest
+	(*ListDriftReportsResponse)(nil),           // 144: nginx.agent.v1.ListDriftReportsResponse
+	(*ResolveDriftRequest)(nil),                // 145: nginx.agent.v1.ResolveDriftRequest
+	(*BatchConfigUpdateRequest)(nil),           // 146: nginx.agent.v1.BatchConfigUpdateRequest
+	(*BatchConfigUpdateResponse)(nil),          // 147: nginx.agent.v1.BatchConfigUpdateResponse
+	(*AgentUpdateResult)(nil),                  // 148: nginx.agent.v1.AgentUpdateResult
+	(*GetBatchStatusRequest)(nil),              // 149: nginx.agent.v1.GetBatchStatusRequest
+	(*CancelBatchRequest)(nil),                 // 150: nginx.agent.v1.CancelBatchRequest
+	(*CancelBatchResponse)(nil),                // 151: nginx.agent.v1.CancelBatchResponse
+	(*RollbackBatchRequest)(nil),               // 152: nginx.agent.v1.RollbackBatchRequest
+	(*RollbackBatchResponse)(nil),              // 153: nginx.agent.v1.RollbackBatchResponse
+	(*ConfigTemplate)(nil),                     // 154: nginx.agent.v1.ConfigTemplate
+	(*TemplateVariable)(nil),                   // 155: nginx.agent.v1.TemplateVariable
+	(*ListConfigTemplatesRequest)(nil),         // 156: nginx.agent.v1.ListConfigTemplatesRequest
+	(*ListConfigTemplatesResponse)(nil),        // 157: nginx.agent.v1.ListConfigTemplatesResponse
+	(*GetConfigTemplateRequest)(nil),           // 158: nginx.agent.v1.GetConfigTemplateRequest
+	(*CreateConfigTemplateRequest)(nil),        // 159: nginx.agent.v1.CreateConfigTemplateRequest
+	(*UpdateConfigTemplateRequest)(nil),        // 160: nginx.agent.v1.UpdateConfigTemplateRequest
+	(*DeleteConfigTemplateRequest)(nil),        // 161: nginx.agent.v1.DeleteConfigTemplateRequest
+	(*DeleteConfigTemplateResponse)(nil),       // 162: nginx.agent.v1.DeleteConfigTemplateResponse
+	(*RenderConfigTemplateRequest)(nil),        // 163: nginx.agent.v1.RenderConfigTemplateRequest
+	(*RenderConfigTemplateResponse)(nil),       // 164: nginx.agent.v1.RenderConfigTemplateResponse
+	(*ConfigTemplateVersion)(nil),              // 165: nginx.agent.v1.ConfigTemplateVersion
+	(*ListConfigTemplateVersionsRequest)(nil),  // 166: nginx.agent.v1.ListConfigTemplateVersionsRequest
+	(*ListConfigTemplateVersionsResponse)(nil), // 167: nginx.agent.v1.ListConfigTemplateVersionsResponse
+	(*ConfigTemplateVariables)(nil),            // 168: nginx.agent.v1.ConfigTemplateVariables
+	(*SetConfigTemplateVariablesRequest)(nil),  // 169: nginx.agent.v1.SetConfigTemplateVariablesRequest
+	(*MaintenanceTemplate)(nil),                // 170: nginx.agent.v1.MaintenanceTemplate
+	(*MaintenanceState)(nil),                   // 171: nginx.agent.v1.MaintenanceState
+	(*SetMaintenanceRequest)(nil),              // 172: nginx.agent.v1.SetMaintenanceRequest
+	(*SetMaintenanceResponse)(nil),             // 173: nginx.agent.v1.SetMaintenanceResponse
+	(*AgentMaintenanceResult)(nil),             // 174: nginx.agent.v1.AgentMaintenanceResult
+	(*GetMaintenanceStatusRequest)(nil),        // 175: nginx.agent.v1.GetMaintenanceStatusRequest
+	(*ListMaintenanceStatesRequest)(nil),       // 176: nginx.agent.v1.ListMaintenanceStatesRequest
+	(*ListMaintenanceStatesResponse)(nil),      // 177: nginx.agent.v1.ListMaintenanceStatesResponse
+	(*ListMaintenanceTemplatesRequest)(nil),    // 178: nginx.agent.v1.ListMaintenanceTemplatesRequest
+	(*ListMaintenanceTemplatesResponse)(nil),   // 179: nginx.agent.v1.ListMaintenanceTemplatesResponse
+	(*CreateMaintenanceTemplateRequest)(nil),   // 180: nginx.agent.v1.CreateMaintenanceTemplateRequest
+	(*UpdateMaintenanceTemplateRequest)(nil),   // 181: nginx.agent.v1.UpdateMaintenanceTemplateRequest
+	(*DeleteMaintenanceTemplateRequest)(nil),   // 182: nginx.agent.v1.DeleteMaintenanceTemplateRequest
+	(*DeleteMaintenanceTemplateResponse)(nil),  // 183: nginx.agent.v1.DeleteMaintenanceTemplateResponse
+	(*PreviewMaintenanceTemplateRequest)(nil),  // 184: nginx.agent.v1.PreviewMaintenanceTemplateRequest
+	(*PreviewMaintenanceTemplateResponse)(nil), // 185: nginx.agent.v1.PreviewMaintenanceTemplateResponse
+	(*CertificateInventoryItem)(nil),           // 186: nginx.agent.v1.CertificateInventoryItem
+	(*UploadCertificateRequest)(nil),           // 187: nginx.agent.v1.UploadCertificateRequest
+	(*UploadCertificateResponse)(nil),          // 188: nginx.agent.v1.UploadCertificateResponse
+	(*CertDeploymentResult)(nil),               // 189: nginx.agent.v1.CertDeploymentResult
+	(*GetCertificateInventoryRequest)(nil),     // 190: nginx.agent.v1.GetCertificateInventoryRequest
+	(*GetCertificateInventoryResponse)(nil),    // 191: nginx.agent.v1.GetCertificateInventoryResponse
+	(*DeployCertificateRequest)(nil),           // 192: nginx.agent.v1.DeployCertificateRequest
+	(*DeleteCertificateRequest)(nil),           // 193: nginx.agent.v1.DeleteCertificateRequest
+	(*DeleteCertificateResponse)(nil),          // 194: nginx.agent.v1.DeleteCertificateResponse
+	(*CompareEnvironmentsRequest)(nil),         // 195: nginx.agent.v1.CompareEnvironmentsRequest
+	(*CompareEnvironmentsResponse)(nil),        // 196: nginx.agent.v1.CompareEnvironmentsResponse
+	(*ComparisonCategory)(nil),                 // 197: nginx.agent.v1.ComparisonCategory
+	(*ConfigDifference)(nil),                   // 198: nginx.agent.v1.ConfigDifference
+	(*ComparisonSummary)(nil),                  // 199: nginx.agent.v1.ComparisonSummary
+	(*GetComparisonRequest)(nil),               // 200: nginx.agent.v1.GetComparisonRequest
+	(*SiteLocationUpdateRequest)(nil),          // 201: nginx.agent.v1.SiteLocationUpdateRequest
+	(*LocationConfigData)(nil),                 // 202: nginx.agent.v1.LocationConfigData
+	(*RateLimitConfigData)(nil),                // 203: nginx.agent.v1.RateLimitConfigData
+	(*CacheConfigData)(nil),                    // 204: nginx.agent.v1.CacheConfigData
+	(*SiteLocationUpdateResponse)(nil),         // 205: nginx.agent.v1.SiteLocationUpdateResponse
+	(*UpstreamListRequest)(nil),                // 206: nginx.agent.v1.UpstreamListRequest
+	(*UpstreamServer)(nil),                     // 207: nginx.agent.v1.UpstreamServer
+	(*UpstreamPool)(nil),                       // 208: nginx.agent.v1.UpstreamPool
+	(*UpstreamListResponse)(nil),               // 209: nginx.agent.v1.UpstreamListResponse
+	(*UpstreamServerUpdate)(nil),               // 210: nginx.agent.v1.UpstreamServerUpdate
+	(*UpstreamServerUpdateResponse)(nil),       // 211: nginx.agent.v1.UpstreamServerUpdateResponse
+	(*ServiceStatusRequest)(nil),               // 212: nginx.agent.v1.ServiceStatusRequest
+	(*ServiceStatus)(nil),                      // 213: nginx.agent.v1.ServiceStatus
+	(*ServiceEvent)(nil),                       // 214: nginx.agent.v1.ServiceEvent
+	(*BinaryUpgradeRequest)(nil),               // 215: nginx.agent.v1.BinaryUpgradeRequest
+	(*BinaryUpgradeResponse)(nil),              // 216: nginx.agent.v1.BinaryUpgradeResponse
+	(*BinaryUpgradeStep)(nil),                  // 217: nginx.agent.v1.BinaryUpgradeStep
+	nil,                                        // 218: nginx.agent.v1.GenericMetric.LabelsEntry
+	nil,                                        // 219: nginx.agent.v1.SystemMetrics.LabelsEntry
+	nil,                                        // 220: nginx.agent.v1.NginxMetrics.LabelsEntry
+	nil,                                        // 221: nginx.agent.v1.ConfigureAgent.SettingsEntry
+	nil,                                        // 222: nginx.agent.v1.Heartbeat.LabelsEntry
+	nil,                                        // 223: nginx.agent.v1.KubernetesMetadata.PodLabelsEntry
+	nil,                                        // 224: nginx.agent.v1.CommandResult.AppliedSettingsEntry
+	nil,                                        // 225: nginx.agent.v1.ConfigPush.FilesEntry
+	nil,                                        // 226: nginx.agent.v1.ServerBlock.SslConfigEntry
+	nil,                                        // 227: nginx.agent.v1.ServerBlock.DirectivesEntry
+	nil,                                        // 228: nginx.agent.v1.LocationBlock.DirectivesEntry
+	nil,                                        // 229: nginx.agent.v1.UpstreamBlock.DirectivesEntry
+	nil,                                        // 230: nginx.agent.v1.AgentInfo.LabelsEntry
+	nil,                                        // 231: nginx.agent.v1.LogEntry.LabelsEntry
+	nil,                                        // 232: nginx.agent.v1.GatewayMetricPoint.LabelsEntry
+	nil,                                        // 233: nginx.agent.v1.Span.AttributesEntry
+	nil,                                        // 234: nginx.agent.v1.AgentGroup.MetadataEntry
+	nil,                                        // 235: nginx.agent.v1.CreateGroupRequest.MetadataEntry
+	nil,                                        // 236: nginx.agent.v1.UpdateGroupRequest.MetadataEntry
+	nil,                                        // 237: nginx.agent.v1.BatchConfigUpdateRequest.VariablesEntry
+	nil,                                        // 238: nginx.agent.v1.ConfigTemplate.DefaultsEntry
+	nil,                                        // 239: nginx.agent.v1.CreateConfigTemplateRequest.DefaultsEntry
+	nil,                                        // 240: nginx.agent.v1.UpdateConfigTemplateRequest.DefaultsEntry
+	nil,                                        // 241: nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntry
+	nil,                                        // 242: nginx.agent.v1.RenderConfigTemplateResponse.ResolvedVariablesEntry
+	nil,                                        // 243: nginx.agent.v1.ConfigTemplateVersion.DefaultsEntry
+	nil,                                        // 244: nginx.agent.v1.ConfigTemplateVariables.VariablesEntry
+	nil,                                        // 245: nginx.agent.v1.SetConfigTemplateVariablesRequest.VariablesEntry
+	nil,                                        // 246: nginx.agent.v1.MaintenanceTemplate.AssetsEntry
+	nil,                                        // 247: nginx.agent.v1.MaintenanceState.TemplateVarsEntry
+	nil,                                        // 248: nginx.agent.v1.MaintenanceState.BypassHeadersEntry
+	nil,                                        // 249: nginx.agent.v1.SetMaintenanceRequest.TemplateVarsEntry
+	nil,                                        // 250: nginx.agent.v1.SetMaintenanceRequest.BypassHeadersEntry
+	nil,                                        // 251: nginx.agent.v1.CreateMaintenanceTemplateRequest.AssetsEntry
+	nil,                                        // 252: nginx.agent.v1.UpdateMaintenanceTemplateRequest.AssetsEntry
+	nil,                                        // 253: nginx.agent.v1.PreviewMaintenanceTemplateRequest.VariablesEntry
+	nil,                                        // 254: nginx.agent.v1.CompareEnvironmentsRequest.GroupMappingEntry
+	nil,                                        // 255: nginx.agent.v1.LocationConfigData.ProxyHeadersEntry
+	nil,                                        // 256: nginx.agent.v1.UpstreamPool.DirectivesEntry
+	(*LogRotateConfig)(nil),                    // 257: nginx.agent.v1.LogRotateConfig
+	(*SyslogConfig)(nil),                       // 258: nginx.agent.v1.SyslogConfig
 }
 var file_api_proto_agent_proto_depIdxs = []int32{
-	7,   // 0: nginx.agent.v1.AgentMessage.heartbeat:type_name -> nginx.agent.v1.Heartbeat
-	9,   // 1: nginx.agent.v1.AgentMessage.command_result:type_name -> nginx.agent.v1.CommandResult
-	10,  // 2: nginx.agent.v1.AgentMessage.state:type_name -> nginx.agent.v1.StateSnapshot
-	52,  // 3: nginx.agent.v1.AgentMessage.log_entry:type_name -> nginx.agent.v1.LogEntry
-	3,   // 4: nginx.agent.v1.AgentMessage.metrics:type_name -> nginx.agent.v1.NginxMetrics
-	168, // 5: nginx.agent.v1.SystemMetrics.labels:type_name -> nginx.agent.v1.SystemMetrics.LabelsEntry
-	1,   // 6: nginx.agent.v1.NginxMetrics.system:type_name -> nginx.agent.v1.SystemMetrics
-	2,   // 7: nginx.agent.v1.NginxMetrics.http_status:type_name -> nginx.agent.v1.HttpStatusMetrics
-	169, // 8: nginx.agent.v1.NginxMetrics.labels:type_name -> nginx.agent.v1.NginxMetrics.LabelsEntry
-	4,   // 9: nginx.agent.v1.NginxMetrics.latency_distribution:type_name -> nginx.agent.v1.HistogramBucket
-	14,  // 10: nginx.agent.v1.ServerCommand.config_push:type_name -> nginx.agent.v1.ConfigPush
-	15,  // 11: nginx.agent.v1.ServerCommand.action:type_name -> nginx.agent.v1.Action
-	51,  // 12: nginx.agent.v1.ServerCommand.log_request:type_name -> nginx.agent.v1.LogRequest
-	6,   // 13: nginx.agent.v1.ServerCommand.update:type_name -> nginx.agent.v1.Update
-	8,   // 14: nginx.agent.v1.Heartbeat.instances:type_name -> nginx.agent.v1.NginxInstance
-	170, // 15: nginx.agent.v1.Heartbeat.labels:type_name -> nginx.agent.v1.Heartbeat.LabelsEntry
-	11,  // 16: nginx.agent.v1.StateSnapshot.config_hashes:type_name -> nginx.agent.v1.ConfigHashes
-	12,  // 17: nginx.agent.v1.ConfigHashes.site_configs:type_name -> nginx.agent.v1.FileHash
-	12,  // 18: nginx.agent.v1.ConfigHashes.include_files:type_name -> nginx.agent.v1.FileHash
-	13,  // 19: nginx.agent.v1.ConfigHashes.certificates:type_name -> nginx.agent.v1.CertHashInfo
-	171, // 20: nginx.agent.v1.ConfigPush.files:type_name -> nginx.agent.v1.ConfigPush.FilesEntry
-	21,  // 21: nginx.agent.v1.AlertRuleList.rules:type_name -> nginx.agent.v1.AlertRule
-	28,  // 22: nginx.agent.v1.ConfigResponse.config:type_name -> nginx.agent.v1.NginxConfig
-	29,  // 23: nginx.agent.v1.NginxConfig.servers:type_name -> nginx.agent.v1.ServerBlock
-	31,  // 24: nginx.agent.v1.NginxConfig.upstreams:type_name -> nginx.agent.v1.UpstreamBlock
-	30,  // 25: nginx.agent.v1.ServerBlock.locations:type_name -> nginx.agent.v1.LocationBlock
-	172, // 26: nginx.agent.v1.ServerBlock.ssl_config:type_name -> nginx.agent.v1.ServerBlock.SslConfigEntry
-	173, // 27: nginx.agent.v1.ServerBlock.directives:type_name -> nginx.agent.v1.ServerBlock.DirectivesEntry
-	174, // 28: nginx.agent.v1.LocationBlock.directives:type_name -> nginx.agent.v1.LocationBlock.DirectivesEntry
-	175, // 29: nginx.agent.v1.UpstreamBlock.directives:type_name -> nginx.agent.v1.UpstreamBlock.DirectivesEntry
-	44,  // 30: nginx.agent.v1.CertListResponse.certificates:type_name -> nginx.agent.v1.Certificate
-	50,  // 31: nginx.agent.v1.ListAgentsResponse.agents:type_name -> nginx.agent.v1.AgentInfo
-	176, // 32: nginx.agent.v1.AgentInfo.labels:type_name -> nginx.agent.v1.AgentInfo.LabelsEntry
-	55,  // 33: nginx.agent.v1.UptimeResponse.reports:type_name -> nginx.agent.v1.UptimeReport
-	70,  // 34: nginx.agent.v1.AnalyticsResponse.request_rate:type_name -> nginx.agent.v1.TimeSeriesPoint
-	71,  // 35: nginx.agent.v1.AnalyticsResponse.status_distribution:type_name -> nginx.agent.v1.StatusCount
-	72,  // 36: nginx.agent.v1.AnalyticsResponse.latency_trend:type_name -> nginx.agent.v1.LatencyPercentiles
-	75,  // 37: nginx.agent.v1.AnalyticsResponse.top_endpoints:type_name -> nginx.agent.v1.EndpointStat
-	69,  // 38: nginx.agent.v1.AnalyticsResponse.connections_history:type_name -> nginx.agent.v1.NginxMetricPoint
-	66,  // 39: nginx.agent.v1.AnalyticsResponse.summary:type_name -> nginx.agent.v1.AnalyticsSummary
-	67,  // 40: nginx.agent.v1.AnalyticsResponse.latency_distribution:type_name -> nginx.agent.v1.LatencyBucket
-	68,  // 41: nginx.agent.v1.AnalyticsResponse.server_distribution:type_name -> nginx.agent.v1.ServerStat
-	73,  // 42: nginx.agent.v1.AnalyticsResponse.system_metrics:type_name -> nginx.agent.v1.SystemMetricPoint
-	74,  // 43: nginx.agent.v1.AnalyticsResponse.http_status_metrics:type_name -> nginx.agent.v1.HttpStatusMetricsResponse
-	63,  // 44: nginx.agent.v1.AnalyticsResponse.insights:type_name -> nginx.agent.v1.Insight
-	52,  // 45: nginx.agent.v1.AnalyticsResponse.recent_requests:type_name -> nginx.agent.v1.LogEntry
-	58,  // 46: nginx.agent.v1.AnalyticsResponse.gateway_metrics:type_name -> nginx.agent.v1.GatewayMetricPoint
-	177, // 47: nginx.agent.v1.GatewayMetricPoint.labels:type_name -> nginx.agent.v1.GatewayMetricPoint.LabelsEntry
-	178, // 48: nginx.agent.v1.Span.attributes:type_name -> nginx.agent.v1.Span.AttributesEntry
-	59,  // 49: nginx.agent.v1.Trace.spans:type_name -> nginx.agent.v1.Span
-	52,  // 50: nginx.agent.v1.Trace.root_entry:type_name -> nginx.agent.v1.LogEntry
-	60,  // 51: nginx.agent.v1.TraceList.traces:type_name -> nginx.agent.v1.Trace
-	16,  // 52: nginx.agent.v1.ApplyAugmentRequest.augment:type_name -> nginx.agent.v1.ConfigAugment
-	70,  // 53: nginx.agent.v1.HttpStatusMetricsResponse.status_2xx_5min:type_name -> nginx.agent.v1.TimeSeriesPoint
-	70,  // 54: nginx.agent.v1.HttpStatusMetricsResponse.status_4xx_5min:type_name -> nginx.agent.v1.TimeSeriesPoint
-	70,  // 55: nginx.agent.v1.HttpStatusMetricsResponse.status_3xx:type_name -> nginx.agent.v1.TimeSeriesPoint
-	70,  // 56: nginx.agent.v1.HttpStatusMetricsResponse.status_5xx:type_name -> nginx.agent.v1.TimeSeriesPoint
-	78,  // 57: nginx.agent.v1.RecommendationResponse.recommendations:type_name -> nginx.agent.v1.Recommendation
-	81,  // 58: nginx.agent.v1.ReportResponse.summary:type_name -> nginx.agent.v1.ReportSummary
-	70,  // 59: nginx.agent.v1.ReportResponse.traffic_trend:type_name -> nginx.agent.v1.TimeSeriesPoint
-	75,  // 60: nginx.agent.v1.ReportResponse.top_uris:type_name -> nginx.agent.v1.EndpointStat
-	68,  // 61: nginx.agent.v1.ReportResponse.top_servers:type_name -> nginx.agent.v1.ServerStat
-	82,  // 62: nginx.agent.v1.ReportResponse.security_events:type_name -> nginx.agent.v1.SecurityEvent
-	79,  // 63: nginx.agent.v1.SendReportRequest.request:type_name -> nginx.agent.v1.ReportRequest
-	197, // 64: nginx.agent.v1.AgentConfig.log_rotation:type_name -> nginx.agent.v1.LogRotateConfig
-	198, // 65: nginx.agent.v1.AgentConfig.syslog:type_name -> nginx.agent.v1.SyslogConfig
-	179, // 66: nginx.agent.v1.AgentGroup.metadata:type_name -> nginx.agent.v1.AgentGroup.MetadataEntry
-	89,  // 67: nginx.agent.v1.ListGroupsResponse.groups:type_name -> nginx.agent.v1.AgentGroup
-	180, // 68: nginx.agent.v1.CreateGroupRequest.metadata:type_name -> nginx.agent.v1.CreateGroupRequest.MetadataEntry
-	181, // 69: nginx.agent.v1.UpdateGroupRequest.metadata:type_name -> nginx.agent.v1.UpdateGroupRequest.MetadataEntry
-	99,  // 70: nginx.agent.v1.AddAgentsToGroupResponse.results:type_name -> nginx.agent.v1.AgentGroupAssignmentResult
-	50,  // 71: nginx.agent.v1.GetGroupAgentsResponse.agents:type_name -> nginx.agent.v1.AgentInfo
-	108, // 72: nginx.agent.v1.DriftCheckResponse.items:type_name -> nginx.agent.v1.DriftItem
-	107, // 73: nginx.agent.v1.ListDriftReportsResponse.reports:type_name -> nginx.agent.v1.DriftCheckResponse
-	182, // 74: nginx.agent.v1.BatchConfigUpdateRequest.variables:type_name -> nginx.agent.v1.BatchConfigUpdateRequest.VariablesEntry
-	115, // 75: nginx.agent.v1.BatchConfigUpdateResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
-	115, // 76: nginx.agent.v1.RollbackBatchResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
-	122, // 77: nginx.agent.v1.ConfigTemplate.variables:type_name -> nginx.agent.v1.TemplateVariable
-	183, // 78: nginx.agent.v1.ConfigTemplate.defaults:type_name -> nginx.agent.v1.ConfigTemplate.DefaultsEntry
-	121, // 79: nginx.agent.v1.ListConfigTemplatesResponse.templates:type_name -> nginx.agent.v1.ConfigTemplate
-	122, // 80: nginx.agent.v1.CreateConfigTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
-	184, // 81: nginx.agent.v1.CreateConfigTemplateRequest.defaults:type_name -> nginx.agent.v1.CreateConfigTemplateRequest.DefaultsEntry
-	122, // 82: nginx.agent.v1.UpdateConfigTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
-	185, // 83: nginx.agent.v1.UpdateConfigTemplateRequest.defaults:type_name -> nginx.agent.v1.UpdateConfigTemplateRequest.DefaultsEntry
-	186, // 84: nginx.agent.v1.RenderConfigTemplateRequest.variables:type_name -> nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntry
-	187, // 85: nginx.agent.v1.MaintenanceTemplate.assets:type_name -> nginx.agent.v1.MaintenanceTemplate.AssetsEntry
-	122, // 86: nginx.agent.v1.MaintenanceTemplate.variables:type_name -> nginx.agent.v1.TemplateVariable
-	188, // 87: nginx.agent.v1.MaintenanceState.template_vars:type_name -> nginx.agent.v1.MaintenanceState.TemplateVarsEntry
-	189, // 88: nginx.agent.v1.MaintenanceState.bypass_headers:type_name -> nginx.agent.v1.MaintenanceState.BypassHeadersEntry
-	190, // 89: nginx.agent.v1.SetMaintenanceRequest.template_vars:type_name -> nginx.agent.v1.SetMaintenanceRequest.TemplateVarsEntry
-	191, // 90: nginx.agent.v1.SetMaintenanceRequest.bypass_headers:type_name -> nginx.agent.v1.SetMaintenanceRequest.BypassHeadersEntry
-	136, // 91: nginx.agent.v1.SetMaintenanceResponse.results:type_name -> nginx.agent.v1.AgentMaintenanceResult
-	133, // 92: nginx.agent.v1.ListMaintenanceStatesResponse.states:type_name -> nginx.agent.v1.MaintenanceState
-	132, // 93: nginx.agent.v1.ListMaintenanceTemplatesResponse.templates:type_name -> nginx.agent.v1.MaintenanceTemplate
-	192, // 94: nginx.agent.v1.CreateMaintenanceTemplateRequest.assets:type_name -> nginx.agent.v1.CreateMaintenanceTemplateRequest.AssetsEntry
-	122, // 95: nginx.agent.v1.CreateMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
-	193, // 96: nginx.agent.v1.UpdateMaintenanceTemplateRequest.assets:type_name -> nginx.agent.v1.UpdateMaintenanceTemplateRequest.AssetsEntry
-	122, // 97: nginx.agent.v1.UpdateMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
-	194, // 98: nginx.agent.v1.PreviewMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.PreviewMaintenanceTemplateRequest.VariablesEntry
-	151, // 99: nginx.agent.v1.UploadCertificateResponse.deployments:type_name -> nginx.agent.v1.CertDeploymentResult
-	148, // 100: nginx.agent.v1.GetCertificateInventoryResponse.certificates:type_name -> nginx.agent.v1.CertificateInventoryItem
-	195, // 101: nginx.agent.v1.CompareEnvironmentsRequest.group_mapping:type_name -> nginx.agent.v1.CompareEnvironmentsRequest.GroupMappingEntry
-	159, // 102: nginx.agent.v1.CompareEnvironmentsResponse.categories:type_name -> nginx.agent.v1.ComparisonCategory
-	161, // 103: nginx.agent.v1.CompareEnvironmentsResponse.summary:type_name -> nginx.agent.v1.ComparisonSummary
-	160, // 104: nginx.agent.v1.ComparisonCategory.differences:type_name -> nginx.agent.v1.ConfigDifference
-	164, // 105: nginx.agent.v1.SiteLocationUpdateRequest.config:type_name -> nginx.agent.v1.LocationConfigData
-	196, // 106: nginx.agent.v1.LocationConfigData.proxy_headers:type_name -> nginx.agent.v1.LocationConfigData.ProxyHeadersEntry
-	165, // 107: nginx.agent.v1.LocationConfigData.rate_limit:type_name -> nginx.agent.v1.RateLimitConfigData
-	166, // 108: nginx.agent.v1.LocationConfigData.cache:type_name -> nginx.agent.v1.CacheConfigData
-	115, // 109: nginx.agent.v1.SiteLocationUpdateResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
-	0,   // 110: nginx.agent.v1.Commander.Connect:input_type -> nginx.agent.v1.AgentMessage
-	26,  // 111: nginx.agent.v1.AgentService.GetConfig:input_type -> nginx.agent.v1.ConfigRequest
-	32,  // 112: nginx.agent.v1.AgentService.UpdateConfig:input_type -> nginx.agent.v1.ConfigUpdate
-	34,  // 113: nginx.agent.v1.AgentService.ValidateConfig:input_type -> nginx.agent.v1.ConfigValidation
-	36,  // 114: nginx.agent.v1.AgentService.ReloadNginx:input_type -> nginx.agent.v1.ReloadRequest
-	38,  // 115: nginx.agent.v1.AgentService.RestartNginx:input_type -> nginx.agent.v1.RestartRequest
-	40,  // 116: nginx.agent.v1.AgentService.StopNginx:input_type -> nginx.agent.v1.StopRequest
-	42,  // 117: nginx.agent.v1.AgentService.ListCertificates:input_type -> nginx.agent.v1.CertListRequest
-	51,  // 118: nginx.agent.v1.AgentService.GetLogs:input_type -> nginx.agent.v1.LogRequest
-	45,  // 119: nginx.agent.v1.AgentService.ListAgents:input_type -> nginx.agent.v1.ListAgentsRequest
-	49,  // 120: nginx.agent.v1.AgentService.GetAgent:input_type -> nginx.agent.v1.GetAgentRequest
-	47,  // 121: nginx.agent.v1.AgentService.RemoveAgent:input_type -> nginx.agent.v1.RemoveAgentRequest
-	53,  // 122: nginx.agent.v1.AgentService.GetUptimeReports:input_type -> nginx.agent.v1.UptimeRequest
-	56,  // 123: nginx.agent.v1.AgentService.GetAnalytics:input_type -> nginx.agent.v1.AnalyticsRequest
-	56,  // 124: nginx.agent.v1.AgentService.StreamAnalytics:input_type -> nginx.agent.v1.AnalyticsRequest
-	61,  // 125: nginx.agent.v1.AgentService.GetTraces:input_type -> nginx.agent.v1.TraceRequest
-	61,  // 126: nginx.agent.v1.AgentService.GetTraceDetails:input_type -> nginx.agent.v1.TraceRequest
-	76,  // 127: nginx.agent.v1.AgentService.GetRecommendations:input_type -> nginx.agent.v1.RecommendationRequest
-	64,  // 128: nginx.agent.v1.AgentService.ApplyAugment:input_type -> nginx.agent.v1.ApplyAugmentRequest
-	24,  // 129: nginx.agent.v1.AgentService.UpdateAgent:input_type -> nginx.agent.v1.UpdateAgentRequest
-	22,  // 130: nginx.agent.v1.AgentService.Execute:input_type -> nginx.agent.v1.ExecRequest
-	86,  // 131: nginx.agent.v1.AgentService.GetAgentConfig:input_type -> nginx.agent.v1.GetAgentConfigRequest
-	87,  // 132: nginx.agent.v1.AgentService.UpdateAgentConfig:input_type -> nginx.agent.v1.AgentConfig
-	79,  // 133: nginx.agent.v1.AgentService.GenerateReport:input_type -> nginx.agent.v1.ReportRequest
-	83,  // 134: nginx.agent.v1.AgentService.SendReport:input_type -> nginx.agent.v1.SendReportRequest
-	79,  // 135: nginx.agent.v1.AgentService.DownloadReport:input_type -> nginx.agent.v1.ReportRequest
-	17,  // 136: nginx.agent.v1.AgentService.ListAlertRules:input_type -> nginx.agent.v1.ListAlertRulesRequest
-	21,  // 137: nginx.agent.v1.AgentService.CreateAlertRule:input_type -> nginx.agent.v1.AlertRule
-	19,  // 138: nginx.agent.v1.AgentService.DeleteAlertRule:input_type -> nginx.agent.v1.DeleteAlertRuleRequest
-	90,  // 139: nginx.agent.v1.AgentService.ListGroups:input_type -> nginx.agent.v1.ListGroupsRequest
-	92,  // 140: nginx.agent.v1.AgentService.GetGroup:input_type -> nginx.agent.v1.GetGroupRequest
-	93,  // 141: nginx.agent.v1.AgentService.CreateGroup:input_type -> nginx.agent.v1.CreateGroupRequest
-	94,  // 142: nginx.agent.v1.AgentService.UpdateGroup:input_type -> nginx.agent.v1.UpdateGroupRequest
-	95,  // 143: nginx.agent.v1.AgentService.DeleteGroup:input_type -> nginx.agent.v1.DeleteGroupRequest
-	97,  // 144: nginx.agent.v1.AgentService.AddAgentsToGroup:input_type -> nginx.agent.v1.AddAgentsToGroupRequest
-	100, // 145: nginx.agent.v1.AgentService.RemoveAgentFromGroup:input_type -> nginx.agent.v1.RemoveAgentFromGroupRequest
-	102, // 146: nginx.agent.v1.AgentService.SetGoldenAgent:input_type -> nginx.agent.v1.SetGoldenAgentRequest
-	104, // 147: nginx.agent.v1.AgentService.GetGroupAgents:input_type -> nginx.agent.v1.GetGroupAgentsRequest
-	106, // 148: nginx.agent.v1.AgentService.CheckDrift:input_type -> nginx.agent.v1.DriftCheckRequest
-	109, // 149: nginx.agent.v1.AgentService.GetDriftReport:input_type -> nginx.agent.v1.GetDriftReportRequest
-	110, // 150: nginx.agent.v1.AgentService.ListDriftReports:input_type -> nginx.agent.v1.ListDriftReportsRequest
-	112, // 151: nginx.agent.v1.AgentService.ResolveDrift:input_type -> nginx.agent.v1.ResolveDriftRequest
-	113, // 152: nginx.agent.v1.AgentService.BatchUpdateConfig:input_type -> nginx.agent.v1.BatchConfigUpdateRequest
-	116, // 153: nginx.agent.v1.AgentService.GetBatchStatus:input_type -> nginx.agent.v1.GetBatchStatusRequest
-	117, // 154: nginx.agent.v1.AgentService.CancelBatch:input_type -> nginx.agent.v1.CancelBatchRequest
-	119, // 155: nginx.agent.v1.AgentService.RollbackBatch:input_type -> nginx.agent.v1.RollbackBatchRequest
-	123, // 156: nginx.agent.v1.AgentService.ListConfigTemplates:input_type -> nginx.agent.v1.ListConfigTemplatesRequest
-	125, // 157: nginx.agent.v1.AgentService.GetConfigTemplate:input_type -> nginx.agent.v1.GetConfigTemplateRequest
-	126, // 158: nginx.agent.v1.AgentService.CreateConfigTemplate:input_type -> nginx.agent.v1.CreateConfigTemplateRequest
-	127, // 159: nginx.agent.v1.AgentService.UpdateConfigTemplate:input_type -> nginx.agent.v1.UpdateConfigTemplateRequest
-	128, // 160: nginx.agent.v1.AgentService.DeleteConfigTemplate:input_type -> nginx.agent.v1.DeleteConfigTemplateRequest
-	130, // 161: nginx.agent.v1.AgentService.RenderConfigTemplate:input_type -> nginx.agent.v1.RenderConfigTemplateRequest
-	134, // 162: nginx.agent.v1.AgentService.SetMaintenance:input_type -> nginx.agent.v1.SetMaintenanceRequest
-	137, // 163: nginx.agent.v1.AgentService.GetMaintenanceStatus:input_type -> nginx.agent.v1.GetMaintenanceStatusRequest
-	138, // 164: nginx.agent.v1.AgentService.ListMaintenanceStates:input_type -> nginx.agent.v1.ListMaintenanceStatesRequest
-	140, // 165: nginx.agent.v1.AgentService.ListMaintenanceTemplates:input_type -> nginx.agent.v1.ListMaintenanceTemplatesRequest
-	142, // 166: nginx.agent.v1.AgentService.CreateMaintenanceTemplate:input_type -> nginx.agent.v1.CreateMaintenanceTemplateRequest
-	143, // 167: nginx.agent.v1.AgentService.UpdateMaintenanceTemplate:input_type -> nginx.agent.v1.UpdateMaintenanceTemplateRequest
-	144, // 168: nginx.agent.v1.AgentService.DeleteMaintenanceTemplate:input_type -> nginx.agent.v1.DeleteMaintenanceTemplateRequest
-	146, // 169: nginx.agent.v1.AgentService.PreviewMaintenanceTemplate:input_type -> nginx.agent.v1.PreviewMaintenanceTemplateRequest
-	149, // 170: nginx.agent.v1.AgentService.UploadCertificate:input_type -> nginx.agent.v1.UploadCertificateRequest
-	152, // 171: nginx.agent.v1.AgentService.GetCertificateInventory:input_type -> nginx.agent.v1.GetCertificateInventoryRequest
-	154, // 172: nginx.agent.v1.AgentService.DeployCertificate:input_type -> nginx.agent.v1.DeployCertificateRequest
-	155, // 173: nginx.agent.v1.AgentService.DeleteCertificate:input_type -> nginx.agent.v1.DeleteCertificateRequest
-	157, // 174: nginx.agent.v1.AgentService.CompareEnvironments:input_type -> nginx.agent.v1.CompareEnvironmentsRequest
-	162, // 175: nginx.agent.v1.AgentService.GetComparison:input_type -> nginx.agent.v1.GetComparisonRequest
-	163, // 176: nginx.agent.v1.AgentService.UpdateSiteLocation:input_type -> nginx.agent.v1.SiteLocationUpdateRequest
-	5,   // 177: nginx.agent.v1.Commander.Connect:output_type -> nginx.agent.v1.ServerCommand
-	27,  // 178: nginx.agent.v1.AgentService.GetConfig:output_type -> nginx.agent.v1.ConfigResponse
-	33,  // 179: nginx.agent.v1.AgentService.UpdateConfig:output_type -> nginx.agent.v1.ConfigUpdateResponse
-	35,  // 180: nginx.agent.v1.AgentService.ValidateConfig:output_type -> nginx.agent.v1.ValidationResult
-	37,  // 181: nginx.agent.v1.AgentService.ReloadNginx:output_type -> nginx.agent.v1.ReloadResponse
-	39,  // 182: nginx.agent.v1.AgentService.RestartNginx:output_type -> nginx.agent.v1.RestartResponse
-	41,  // 183: nginx.agent.v1.AgentService.StopNginx:output_type -> nginx.agent.v1.StopResponse
-	43,  // 184: nginx.agent.v1.AgentService.ListCertificates:output_type -> nginx.agent.v1.CertListResponse
-	52,  // 185: nginx.agent.v1.AgentService.GetLogs:output_type -> nginx.agent.v1.LogEntry
-	46,  // 186: nginx.agent.v1.AgentService.ListAgents:output_type -> nginx.agent.v1.ListAgentsResponse
-	50,  // 187: nginx.agent.v1.AgentService.GetAgent:output_type -> nginx.agent.v1.AgentInfo
-	48,  // 188: nginx.agent.v1.AgentService.RemoveAgent:output_type -> nginx.agent.v1.RemoveAgentResponse
-	54,  // 189: nginx.agent.v1.AgentService.GetUptimeReports:output_type -> nginx.agent.v1.UptimeResponse
-	57,  // 190: nginx.agent.v1.AgentService.GetAnalytics:output_type -> nginx.agent.v1.AnalyticsResponse
-	57,  // 191: nginx.agent.v1.AgentService.StreamAnalytics:output_type -> nginx.agent.v1.AnalyticsResponse
-	62,  // 192: nginx.agent.v1.AgentService.GetTraces:output_type -> nginx.agent.v1.TraceList
-	60,  // 193: nginx.agent.v1.AgentService.GetTraceDetails:output_type -> nginx.agent.v1.Trace
-	77,  // 194: nginx.agent.v1.AgentService.GetRecommendations:output_type -> nginx.agent.v1.RecommendationResponse
-	65,  // 195: nginx.agent.v1.AgentService.ApplyAugment:output_type -> nginx.agent.v1.ApplyAugmentResponse
-	25,  // 196: nginx.agent.v1.AgentService.UpdateAgent:output_type -> nginx.agent.v1.UpdateAgentResponse
-	23,  // 197: nginx.agent.v1.AgentService.Execute:output_type -> nginx.agent.v1.ExecResponse
-	87,  // 198: nginx.agent.v1.AgentService.GetAgentConfig:output_type -> nginx.agent.v1.AgentConfig
-	88,  // 199: nginx.agent.v1.AgentService.UpdateAgentConfig:output_type -> nginx.agent.v1.AgentConfigUpdateResult
-	80,  // 200: nginx.agent.v1.AgentService.GenerateReport:output_type -> nginx.agent.v1.ReportResponse
-	84,  // 201: nginx.agent.v1.AgentService.SendReport:output_type -> nginx.agent.v1.SendReportResponse
-	85,  // 202: nginx.agent.v1.AgentService.DownloadReport:output_type -> nginx.agent.v1.ReportDownloadResponse
-	18,  // 203: nginx.agent.v1.AgentService.ListAlertRules:output_type -> nginx.agent.v1.AlertRuleList
-	21,  // 204: nginx.agent.v1.AgentService.CreateAlertRule:output_type -> nginx.agent.v1.AlertRule
-	20,  // 205: nginx.agent.v1.AgentService.DeleteAlertRule:output_type -> nginx.agent.v1.DeleteAlertRuleResponse
-	91,  // 206: nginx.agent.v1.AgentService.ListGroups:output_type -> nginx.agent.v1.ListGroupsResponse
-	89,  // 207: nginx.agent.v1.AgentService.GetGroup:output_type -> nginx.agent.v1.AgentGroup
-	89,  // 208: nginx.agent.v1.AgentService.CreateGroup:output_type -> nginx.agent.v1.AgentGroup
-	89,  // 209: nginx.agent.v1.AgentService.UpdateGroup:output_type -> nginx.agent.v1.AgentGroup
-	96,  // 210: nginx.agent.v1.AgentService.DeleteGroup:output_type -> nginx.agent.v1.DeleteGroupResponse
-	98,  // 211: nginx.agent.v1.AgentService.AddAgentsToGroup:output_type -> nginx.agent.v1.AddAgentsToGroupResponse
-	101, // 212: nginx.agent.v1.AgentService.RemoveAgentFromGroup:output_type -> nginx.agent.v1.RemoveAgentFromGroupResponse
-	103, // 213: nginx.agent.v1.AgentService.SetGoldenAgent:output_type -> nginx.agent.v1.SetGoldenAgentResponse
-	105, // 214: nginx.agent.v1.AgentService.GetGroupAgents:output_type -> nginx.agent.v1.GetGroupAgentsResponse
-	107, // 215: nginx.agent.v1.AgentService.CheckDrift:output_type -> nginx.agent.v1.DriftCheckResponse
-	107, // 216: nginx.agent.v1.AgentService.GetDriftReport:output_type -> nginx.agent.v1.DriftCheckResponse
-	111, // 217: nginx.agent.v1.AgentService.ListDriftReports:output_type -> nginx.agent.v1.ListDriftReportsResponse
-	114, // 218: nginx.agent.v1.AgentService.ResolveDrift:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
-	114, // 219: nginx.agent.v1.AgentService.BatchUpdateConfig:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
-	114, // 220: nginx.agent.v1.AgentService.GetBatchStatus:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
-	118, // 221: nginx.agent.v1.AgentService.CancelBatch:output_type -> nginx.agent.v1.CancelBatchResponse
-	120, // 222: nginx.agent.v1.AgentService.RollbackBatch:output_type -> nginx.agent.v1.RollbackBatchResponse
-	124, // 223: nginx.agent.v1.AgentService.ListConfigTemplates:output_type -> nginx.agent.v1.ListConfigTemplatesResponse
-	121, // 224: nginx.agent.v1.AgentService.GetConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
-	121, // 225: nginx.agent.v1.AgentService.CreateConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
-	121, // 226: nginx.agent.v1.AgentService.UpdateConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
-	129, // 227: nginx.agent.v1.AgentService.DeleteConfigTemplate:output_type -> nginx.agent.v1.DeleteConfigTemplateResponse
-	131, // 228: nginx.agent.v1.AgentService.RenderConfigTemplate:output_type -> nginx.agent.v1.RenderConfigTemplateResponse
-	135, // 229: nginx.agent.v1.AgentService.SetMaintenance:output_type -> nginx.agent.v1.SetMaintenanceResponse
-	133, // 230: nginx.agent.v1.AgentService.GetMaintenanceStatus:output_type -> nginx.agent.v1.MaintenanceState
-	139, // 231: nginx.agent.v1.AgentService.ListMaintenanceStates:output_type -> nginx.agent.v1.ListMaintenanceStatesResponse
-	141, // 232: nginx.agent.v1.AgentService.ListMaintenanceTemplates:output_type -> nginx.agent.v1.ListMaintenanceTemplatesResponse
-	132, // 233: nginx.agent.v1.AgentService.CreateMaintenanceTemplate:output_type -> nginx.agent.v1.MaintenanceTemplate
-	132, // 234: nginx.agent.v1.AgentService.UpdateMaintenanceTemplate:output_type -> nginx.agent.v1.MaintenanceTemplate
-	145, // 235: nginx.agent.v1.AgentService.DeleteMaintenanceTemplate:output_type -> nginx.agent.v1.DeleteMaintenanceTemplateResponse
-	147, // 236: nginx.agent.v1.AgentService.PreviewMaintenanceTemplate:output_type -> nginx.agent.v1.PreviewMaintenanceTemplateResponse
-	150, // 237: nginx.agent.v1.AgentService.UploadCertificate:output_type -> nginx.agent.v1.UploadCertificateResponse
-	153, // 238: nginx.agent.v1.AgentService.GetCertificateInventory:output_type -> nginx.agent.v1.GetCertificateInventoryResponse
-	150, // 239: nginx.agent.v1.AgentService.DeployCertificate:output_type -> nginx.agent.v1.UploadCertificateResponse
-	156, // 240: nginx.agent.v1.AgentService.DeleteCertificate:output_type -> nginx.agent.v1.DeleteCertificateResponse
-	158, // 241: nginx.agent.v1.AgentService.CompareEnvironments:output_type -> nginx.agent.v1.CompareEnvironmentsResponse
-	158, // 242: nginx.agent.v1.AgentService.GetComparison:output_type -> nginx.agent.v1.CompareEnvironmentsResponse
-	167, // 243: nginx.agent.v1.AgentService.UpdateSiteLocation:output_type -> nginx.agent.v1.SiteLocationUpdateResponse
-	177, // [177:244] is the sub-list for method output_type
-	110, // [110:177] is the sub-list for method input_type
-	110, // [110:110] is the sub-list for extension type_name
-	110, // [110:110] is the sub-list for extension extendee
-	0,   // [0:110] is the sub-list for field type_name
+	17,  // 0: nginx.agent.v1.AgentMessage.heartbeat:type_name -> nginx.agent.v1.Heartbeat
+	25,  // 1: nginx.agent.v1.AgentMessage.command_result:type_name -> nginx.agent.v1.CommandResult
+	26,  // 2: nginx.agent.v1.AgentMessage.state:type_name -> nginx.agent.v1.StateSnapshot
+	71,  // 3: nginx.agent.v1.AgentMessage.log_entry:type_name -> nginx.agent.v1.LogEntry
+	9,   // 4: nginx.agent.v1.AgentMessage.metrics:type_name -> nginx.agent.v1.NginxMetrics
+	2,   // 5: nginx.agent.v1.AgentMessage.generic_metrics:type_name -> nginx.agent.v1.GenericMetrics
+	1,   // 6: nginx.agent.v1.AgentMessage.update_rejected:type_name -> nginx.agent.v1.UpdateRejected
+	3,   // 7: nginx.agent.v1.GenericMetrics.metrics:type_name -> nginx.agent.v1.GenericMetric
+	218, // 8: nginx.agent.v1.GenericMetric.labels:type_name -> nginx.agent.v1.GenericMetric.LabelsEntry
+	219, // 9: nginx.agent.v1.SystemMetrics.labels:type_name -> nginx.agent.v1.SystemMetrics.LabelsEntry
+	6,   // 10: nginx.agent.v1.SystemMetrics.disks:type_name -> nginx.agent.v1.DiskUsage
+	7,   // 11: nginx.agent.v1.SystemMetrics.log_files:type_name -> nginx.agent.v1.LogFileUsage
+	5,   // 12: nginx.agent.v1.SystemMetrics.nginx_fds:type_name -> nginx.agent.v1.ProcessFdUsage
+	4,   // 13: nginx.agent.v1.NginxMetrics.system:type_name -> nginx.agent.v1.SystemMetrics
+	8,   // 14: nginx.agent.v1.NginxMetrics.http_status:type_name -> nginx.agent.v1.HttpStatusMetrics
+	220, // 15: nginx.agent.v1.NginxMetrics.labels:type_name -> nginx.agent.v1.NginxMetrics.LabelsEntry
+	12,  // 16: nginx.agent.v1.NginxMetrics.latency_distribution:type_name -> nginx.agent.v1.HistogramBucket
+	10,  // 17: nginx.agent.v1.NginxMetrics.workers:type_name -> nginx.agent.v1.NginxWorkers
+	213, // 18: nginx.agent.v1.NginxMetrics.service:type_name -> nginx.agent.v1.ServiceStatus
+	11,  // 19: nginx.agent.v1.NginxWorkers.processes:type_name -> nginx.agent.v1.NginxWorkerProcess
+	30,  // 20: nginx.agent.v1.ServerCommand.config_push:type_name -> nginx.agent.v1.ConfigPush
+	31,  // 21: nginx.agent.v1.ServerCommand.action:type_name -> nginx.agent.v1.Action
+	70,  // 22: nginx.agent.v1.ServerCommand.log_request:type_name -> nginx.agent.v1.LogRequest
+	16,  // 23: nginx.agent.v1.ServerCommand.update:type_name -> nginx.agent.v1.Update
+	15,  // 24: nginx.agent.v1.ServerCommand.configure_agent:type_name -> nginx.agent.v1.ConfigureAgent
+	14,  // 25: nginx.agent.v1.ServerCommand.drain:type_name -> nginx.agent.v1.Drain
+	221, // 26: nginx.agent.v1.ConfigureAgent.settings:type_name -> nginx.agent.v1.ConfigureAgent.SettingsEntry
+	23,  // 27: nginx.agent.v1.Heartbeat.instances:type_name -> nginx.agent.v1.NginxInstance
+	222, // 28: nginx.agent.v1.Heartbeat.labels:type_name -> nginx.agent.v1.Heartbeat.LabelsEntry
+	22,  // 29: nginx.agent.v1.Heartbeat.kubernetes:type_name -> nginx.agent.v1.KubernetesMetadata
+	21,  // 30: nginx.agent.v1.Heartbeat.update_status:type_name -> nginx.agent.v1.UpdateStatus
+	19,  // 31: nginx.agent.v1.Heartbeat.host:type_name -> nginx.agent.v1.HostInventory
+	18,  // 32: nginx.agent.v1.Heartbeat.resource_status:type_name -> nginx.agent.v1.AgentResourceStatus
+	20,  // 33: nginx.agent.v1.HostInventory.packages:type_name -> nginx.agent.v1.PackageVersion
+	223, // 34: nginx.agent.v1.KubernetesMetadata.pod_labels:type_name -> nginx.agent.v1.KubernetesMetadata.PodLabelsEntry
+	24,  // 35: nginx.agent.v1.NginxInstance.build:type_name -> nginx.agent.v1.NginxBuild
+	224, // 36: nginx.agent.v1.CommandResult.applied_settings:type_name -> nginx.agent.v1.CommandResult.AppliedSettingsEntry
+	27,  // 37: nginx.agent.v1.StateSnapshot.config_hashes:type_name -> nginx.agent.v1.ConfigHashes
+	28,  // 38: nginx.agent.v1.ConfigHashes.site_configs:type_name -> nginx.agent.v1.FileHash
+	28,  // 39: nginx.agent.v1.ConfigHashes.include_files:type_name -> nginx.agent.v1.FileHash
+	29,  // 40: nginx.agent.v1.ConfigHashes.certificates:type_name -> nginx.agent.v1.CertHashInfo
+	225, // 41: nginx.agent.v1.ConfigPush.files:type_name -> nginx.agent.v1.ConfigPush.FilesEntry
+	37,  // 42: nginx.agent.v1.AlertRuleList.rules:type_name -> nginx.agent.v1.AlertRule
+	45,  // 43: nginx.agent.v1.ConfigResponse.config:type_name -> nginx.agent.v1.NginxConfig
+	48,  // 44: nginx.agent.v1.NginxConfig.servers:type_name -> nginx.agent.v1.ServerBlock
+	50,  // 45: nginx.agent.v1.NginxConfig.upstreams:type_name -> nginx.agent.v1.UpstreamBlock
+	46,  // 46: nginx.agent.v1.NginxConfig.files:type_name -> nginx.agent.v1.ConfigFile
+	47,  // 47: nginx.agent.v1.ConfigFile.parsed:type_name -> nginx.agent.v1.ConfigDirective
+	47,  // 48: nginx.agent.v1.ConfigDirective.block:type_name -> nginx.agent.v1.ConfigDirective
+	49,  // 49: nginx.agent.v1.ServerBlock.locations:type_name -> nginx.agent.v1.LocationBlock
+	226, // 50: nginx.agent.v1.ServerBlock.ssl_config:type_name -> nginx.agent.v1.ServerBlock.SslConfigEntry
+	227, // 51: nginx.agent.v1.ServerBlock.directives:type_name -> nginx.agent.v1.ServerBlock.DirectivesEntry
+	228, // 52: nginx.agent.v1.LocationBlock.directives:type_name -> nginx.agent.v1.LocationBlock.DirectivesEntry
+	229, // 53: nginx.agent.v1.UpstreamBlock.directives:type_name -> nginx.agent.v1.UpstreamBlock.DirectivesEntry
+	46,  // 54: nginx.agent.v1.ConfigUpdate.files:type_name -> nginx.agent.v1.ConfigFile
+	63,  // 55: nginx.agent.v1.CertListResponse.certificates:type_name -> nginx.agent.v1.Certificate
+	69,  // 56: nginx.agent.v1.ListAgentsResponse.agents:type_name -> nginx.agent.v1.AgentInfo
+	230, // 57: nginx.agent.v1.AgentInfo.labels:type_name -> nginx.agent.v1.AgentInfo.LabelsEntry
+	22,  // 58: nginx.agent.v1.AgentInfo.kubernetes:type_name -> nginx.agent.v1.KubernetesMetadata
+	21,  // 59: nginx.agent.v1.AgentInfo.update_status:type_name -> nginx.agent.v1.UpdateStatus
+	24,  // 60: nginx.agent.v1.AgentInfo.nginx_builds:type_name -> nginx.agent.v1.NginxBuild
+	18,  // 61: nginx.agent.v1.AgentInfo.resource_status:type_name -> nginx.agent.v1.AgentResourceStatus
+	23,  // 62: nginx.agent.v1.AgentInfo.nginx_instances:type_name -> nginx.agent.v1.NginxInstance
+	231, // 63: nginx.agent.v1.LogEntry.labels:type_name -> nginx.agent.v1.LogEntry.LabelsEntry
+	74,  // 64: nginx.agent.v1.UptimeResponse.reports:type_name -> nginx.agent.v1.UptimeReport
+	76,  // 65: nginx.agent.v1.AnalyticsRequest.compare:type_name -> nginx.agent.v1.AnalyticsComparison
+	103, // 66: nginx.agent.v1.AnalyticsResponse.request_rate:type_name -> nginx.agent.v1.TimeSeriesPoint
+	104, // 67: nginx.agent.v1.AnalyticsResponse.status_distribution:type_name -> nginx.agent.v1.StatusCount
+	105, // 68: nginx.agent.v1.AnalyticsResponse.latency_trend:type_name -> nginx.agent.v1.LatencyPercentiles
+	108, // 69: nginx.agent.v1.AnalyticsResponse.top_endpoints:type_name -> nginx.agent.v1.EndpointStat
+	102, // 70: nginx.agent.v1.AnalyticsResponse.connections_history:type_name -> nginx.agent.v1.NginxMetricPoint
+	96,  // 71: nginx.agent.v1.AnalyticsResponse.summary:type_name -> nginx.agent.v1.AnalyticsSummary
+	97,  // 72: nginx.agent.v1.AnalyticsResponse.latency_distribution:type_name -> nginx.agent.v1.LatencyBucket
+	98,  // 73: nginx.agent.v1.AnalyticsResponse.server_distribution:type_name -> nginx.agent.v1.ServerStat
+	106, // 74: nginx.agent.v1.AnalyticsResponse.system_metrics:type_name -> nginx.agent.v1.SystemMetricPoint
+	107, // 75: nginx.agent.v1.AnalyticsResponse.http_status_metrics:type_name -> nginx.agent.v1.HttpStatusMetricsResponse
+	93,  // 76: nginx.agent.v1.AnalyticsResponse.insights:type_name -> nginx.agent.v1.Insight
+	71,  // 77: nginx.agent.v1.AnalyticsResponse.recent_requests:type_name -> nginx.agent.v1.LogEntry
+	88,  // 78: nginx.agent.v1.AnalyticsResponse.gateway_metrics:type_name -> nginx.agent.v1.GatewayMetricPoint
+	99,  // 79: nginx.agent.v1.AnalyticsResponse.top_asns:type_name -> nginx.agent.v1.ASNStat
+	101, // 80: nginx.agent.v1.AnalyticsResponse.bot_traffic:type_name -> nginx.agent.v1.BotTraffic
+	86,  // 81: nginx.agent.v1.AnalyticsResponse.delta:type_name -> nginx.agent.v1.AnalyticsDelta
+	83,  // 82: nginx.agent.v1.AnalyticsResponse.bandwidth:type_name -> nginx.agent.v1.BandwidthPoint
+	84,  // 83: nginx.agent.v1.AnalyticsResponse.bandwidth_by_agent:type_name -> nginx.agent.v1.BandwidthStat
+	84,  // 84: nginx.agent.v1.AnalyticsResponse.bandwidth_by_uri:type_name -> nginx.agent.v1.BandwidthStat
+	85,  // 85: nginx.agent.v1.AnalyticsResponse.egress_cost:type_name -> nginx.agent.v1.EgressCost
+	80,  // 86: nginx.agent.v1.AnalyticsResponse.cache:type_name -> nginx.agent.v1.CacheStats
+	77,  // 87: nginx.agent.v1.AnalyticsResponse.baseline:type_name -> nginx.agent.v1.AnalyticsResponse
+	78,  // 88: nginx.agent.v1.AnalyticsResponse.comparison:type_name -> nginx.agent.v1.AnalyticsComparisonSummary
+	79,  // 89: nginx.agent.v1.AnalyticsComparisonSummary.changes:type_name -> nginx.agent.v1.MetricChange
+	81,  // 90: nginx.agent.v1.CacheStats.statuses:type_name -> nginx.agent.v1.CacheStatusCount
+	82,  // 91: nginx.agent.v1.CacheStats.top_uris:type_name -> nginx.agent.v1.CacheUriStat
+	87,  // 92: nginx.agent.v1.AnalyticsDelta.series:type_name -> nginx.agent.v1.SeriesPatch
+	232, // 93: nginx.agent.v1.GatewayMetricPoint.labels:type_name -> nginx.agent.v1.GatewayMetricPoint.LabelsEntry
+	233, // 94: nginx.agent.v1.Span.attributes:type_name -> nginx.agent.v1.Span.AttributesEntry
+	89,  // 95: nginx.agent.v1.Trace.spans:type_name -> nginx.agent.v1.Span
+	71,  // 96: nginx.agent.v1.Trace.root_entry:type_name -> nginx.agent.v1.LogEntry
+	90,  // 97: nginx.agent.v1.TraceList.traces:type_name -> nginx.agent.v1.Trace
+	32,  // 98: nginx.agent.v1.ApplyAugmentRequest.augment:type_name -> nginx.agent.v1.ConfigAugment
+	100, // 99: nginx.agent.v1.BotTraffic.categories:type_name -> nginx.agent.v1.BotCategoryStat
+	103, // 100: nginx.agent.v1.HttpStatusMetricsResponse.status_2xx_5min:type_name -> nginx.agent.v1.TimeSeriesPoint
+	103, // 101: nginx.agent.v1.HttpStatusMetricsResponse.status_4xx_5min:type_name -> nginx.agent.v1.TimeSeriesPoint
+	103, // 102: nginx.agent.v1.HttpStatusMetricsResponse.status_3xx:type_name -> nginx.agent.v1.TimeSeriesPoint
+	103, // 103: nginx.agent.v1.HttpStatusMetricsResponse.status_5xx:type_name -> nginx.agent.v1.TimeSeriesPoint
+	111, // 104: nginx.agent.v1.RecommendationResponse.recommendations:type_name -> nginx.agent.v1.Recommendation
+	114, // 105: nginx.agent.v1.ReportResponse.summary:type_name -> nginx.agent.v1.ReportSummary
+	103, // 106: nginx.agent.v1.ReportResponse.traffic_trend:type_name -> nginx.agent.v1.TimeSeriesPoint
+	108, // 107: nginx.agent.v1.ReportResponse.top_uris:type_name -> nginx.agent.v1.EndpointStat
+	98,  // 108: nginx.agent.v1.ReportResponse.top_servers:type_name -> nginx.agent.v1.ServerStat
+	115, // 109: nginx.agent.v1.ReportResponse.security_events:type_name -> nginx.agent.v1.SecurityEvent
+	112, // 110: nginx.agent.v1.SendReportRequest.request:type_name -> nginx.agent.v1.ReportRequest
+	257, // 111: nginx.agent.v1.AgentConfig.log_rotation:type_name -> nginx.agent.v1.LogRotateConfig
+	258, // 112: nginx.agent.v1.AgentConfig.syslog:type_name -> nginx.agent.v1.SyslogConfig
+	234, // 113: nginx.agent.v1.AgentGroup.metadata:type_name -> nginx.agent.v1.AgentGroup.MetadataEntry
+	122, // 114: nginx.agent.v1.ListGroupsResponse.groups:type_name -> nginx.agent.v1.AgentGroup
+	235, // 115: nginx.agent.v1.CreateGroupRequest.metadata:type_name -> nginx.agent.v1.CreateGroupRequest.MetadataEntry
+	236, // 116: nginx.agent.v1.UpdateGroupRequest.metadata:type_name -> nginx.agent.v1.UpdateGroupRequest.MetadataEntry
+	132, // 117: nginx.agent.v1.AddAgentsToGroupResponse.results:type_name -> nginx.agent.v1.AgentGroupAssignmentResult
+	69,  // 118: nginx.agent.v1.GetGroupAgentsResponse.agents:type_name -> nginx.agent.v1.AgentInfo
+	141, // 119: nginx.agent.v1.DriftCheckResponse.items:type_name -> nginx.agent.v1.DriftItem
+	140, // 120: nginx.agent.v1.ListDriftReportsResponse.reports:type_name -> nginx.agent.v1.DriftCheckResponse
+	237, // 121: nginx.agent.v1.BatchConfigUpdateRequest.variables:type_name -> nginx.agent.v1.BatchConfigUpdateRequest.VariablesEntry
+	148, // 122: nginx.agent.v1.BatchConfigUpdateResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
+	148, // 123: nginx.agent.v1.RollbackBatchResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
+	155, // 124: nginx.agent.v1.ConfigTemplate.variables:type_name -> nginx.agent.v1.TemplateVariable
+	238, // 125: nginx.agent.v1.ConfigTemplate.defaults:type_name -> nginx.agent.v1.ConfigTemplate.DefaultsEntry
+	154, // 126: nginx.agent.v1.ListConfigTemplatesResponse.templates:type_name -> nginx.agent.v1.ConfigTemplate
+	155, // 127: nginx.agent.v1.CreateConfigTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
+	239, // 128: nginx.agent.v1.CreateConfigTemplateRequest.defaults:type_name -> nginx.agent.v1.CreateConfigTemplateRequest.DefaultsEntry
+	155, // 129: nginx.agent.v1.UpdateConfigTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
+	240, // 130: nginx.agent.v1.UpdateConfigTemplateRequest.defaults:type_name -> nginx.agent.v1.UpdateConfigTemplateRequest.DefaultsEntry
+	241, // 131: nginx.agent.v1.RenderConfigTemplateRequest.variables:type_name -> nginx.agent.v1.RenderConfigTemplateRequest.VariablesEntry
+	242, // 132: nginx.agent.v1.RenderConfigTemplateResponse.resolved_variables:type_name -> nginx.agent.v1.RenderConfigTemplateResponse.ResolvedVariablesEntry
+	155, // 133: nginx.agent.v1.ConfigTemplateVersion.variables:type_name -> nginx.agent.v1.TemplateVariable
+	243, // 134: nginx.agent.v1.ConfigTemplateVersion.defaults:type_name -> nginx.agent.v1.ConfigTemplateVersion.DefaultsEntry
+	165, // 135: nginx.agent.v1.ListConfigTemplateVersionsResponse.versions:type_name -> nginx.agent.v1.ConfigTemplateVersion
+	244, // 136: nginx.agent.v1.ConfigTemplateVariables.variables:type_name -> nginx.agent.v1.ConfigTemplateVariables.VariablesEntry
+	245, // 137: nginx.agent.v1.SetConfigTemplateVariablesRequest.variables:type_name -> nginx.agent.v1.SetConfigTemplateVariablesRequest.VariablesEntry
+	246, // 138: nginx.agent.v1.MaintenanceTemplate.assets:type_name -> nginx.agent.v1.MaintenanceTemplate.AssetsEntry
+	155, // 139: nginx.agent.v1.MaintenanceTemplate.variables:type_name -> nginx.agent.v1.TemplateVariable
+	247, // 140: nginx.agent.v1.MaintenanceState.template_vars:type_name -> nginx.agent.v1.MaintenanceState.TemplateVarsEntry
+	248, // 141: nginx.agent.v1.MaintenanceState.bypass_headers:type_name -> nginx.agent.v1.MaintenanceState.BypassHeadersEntry
+	249, // 142: nginx.agent.v1.SetMaintenanceRequest.template_vars:type_name -> nginx.agent.v1.SetMaintenanceRequest.TemplateVarsEntry
+	250, // 143: nginx.agent.v1.SetMaintenanceRequest.bypass_headers:type_name -> nginx.agent.v1.SetMaintenanceRequest.BypassHeadersEntry
+	174, // 144: nginx.agent.v1.SetMaintenanceResponse.results:type_name -> nginx.agent.v1.AgentMaintenanceResult
+	171, // 145: nginx.agent.v1.ListMaintenanceStatesResponse.states:type_name -> nginx.agent.v1.MaintenanceState
+	170, // 146: nginx.agent.v1.ListMaintenanceTemplatesResponse.templates:type_name -> nginx.agent.v1.MaintenanceTemplate
+	251, // 147: nginx.agent.v1.CreateMaintenanceTemplateRequest.assets:type_name -> nginx.agent.v1.CreateMaintenanceTemplateRequest.AssetsEntry
+	155, // 148: nginx.agent.v1.CreateMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
+	252, // 149: nginx.agent.v1.UpdateMaintenanceTemplateRequest.assets:type_name -> nginx.agent.v1.UpdateMaintenanceTemplateRequest.AssetsEntry
+	155, // 150: nginx.agent.v1.UpdateMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.TemplateVariable
+	253, // 151: nginx.agent.v1.PreviewMaintenanceTemplateRequest.variables:type_name -> nginx.agent.v1.PreviewMaintenanceTemplateRequest.VariablesEntry
+	189, // 152: nginx.agent.v1.UploadCertificateResponse.deployments:type_name -> nginx.agent.v1.CertDeploymentResult
+	186, // 153: nginx.agent.v1.GetCertificateInventoryResponse.certificates:type_name -> nginx.agent.v1.CertificateInventoryItem
+	254, // 154: nginx.agent.v1.CompareEnvironmentsRequest.group_mapping:type_name -> nginx.agent.v1.CompareEnvironmentsRequest.GroupMappingEntry
+	197, // 155: nginx.agent.v1.CompareEnvironmentsResponse.categories:type_name -> nginx.agent.v1.ComparisonCategory
+	199, // 156: nginx.agent.v1.CompareEnvironmentsResponse.summary:type_name -> nginx.agent.v1.ComparisonSummary
+	198, // 157: nginx.agent.v1.ComparisonCategory.differences:type_name -> nginx.agent.v1.ConfigDifference
+	202, // 158: nginx.agent.v1.SiteLocationUpdateRequest.config:type_name -> nginx.agent.v1.LocationConfigData
+	255, // 159: nginx.agent.v1.LocationConfigData.proxy_headers:type_name -> nginx.agent.v1.LocationConfigData.ProxyHeadersEntry
+	203, // 160: nginx.agent.v1.LocationConfigData.rate_limit:type_name -> nginx.agent.v1.RateLimitConfigData
+	204, // 161: nginx.agent.v1.LocationConfigData.cache:type_name -> nginx.agent.v1.CacheConfigData
+	148, // 162: nginx.agent.v1.SiteLocationUpdateResponse.results:type_name -> nginx.agent.v1.AgentUpdateResult
+	207, // 163: nginx.agent.v1.UpstreamPool.servers:type_name -> nginx.agent.v1.UpstreamServer
+	256, // 164: nginx.agent.v1.UpstreamPool.directives:type_name -> nginx.agent.v1.UpstreamPool.DirectivesEntry
+	208, // 165: nginx.agent.v1.UpstreamListResponse.upstreams:type_name -> nginx.agent.v1.UpstreamPool
+	214, // 166: nginx.agent.v1.ServiceStatus.history:type_name -> nginx.agent.v1.ServiceEvent
+	217, // 167: nginx.agent.v1.BinaryUpgradeResponse.steps:type_name -> nginx.agent.v1.BinaryUpgradeStep
+	0,   // 168: nginx.agent.v1.Commander.Connect:input_type -> nginx.agent.v1.AgentMessage
+	43,  // 169: nginx.agent.v1.AgentService.GetConfig:input_type -> nginx.agent.v1.ConfigRequest
+	51,  // 170: nginx.agent.v1.AgentService.UpdateConfig:input_type -> nginx.agent.v1.ConfigUpdate
+	53,  // 171: nginx.agent.v1.AgentService.ValidateConfig:input_type -> nginx.agent.v1.ConfigValidation
+	55,  // 172: nginx.agent.v1.AgentService.ReloadNginx:input_type -> nginx.agent.v1.ReloadRequest
+	57,  // 173: nginx.agent.v1.AgentService.RestartNginx:input_type -> nginx.agent.v1.RestartRequest
+	59,  // 174: nginx.agent.v1.AgentService.StopNginx:input_type -> nginx.agent.v1.StopRequest
+	61,  // 175: nginx.agent.v1.AgentService.ListCertificates:input_type -> nginx.agent.v1.CertListRequest
+	70,  // 176: nginx.agent.v1.AgentService.GetLogs:input_type -> nginx.agent.v1.LogRequest
+	64,  // 177: nginx.agent.v1.AgentService.ListAgents:input_type -> nginx.agent.v1.ListAgentsRequest
+	68,  // 178: nginx.agent.v1.AgentService.GetAgent:input_type -> nginx.agent.v1.GetAgentRequest
+	66,  // 179: nginx.agent.v1.AgentService.RemoveAgent:input_type -> nginx.agent.v1.RemoveAgentRequest
+	72,  // 180: nginx.agent.v1.AgentService.GetUptimeReports:input_type -> nginx.agent.v1.UptimeRequest
+	75,  // 181: nginx.agent.v1.AgentService.GetAnalytics:input_type -> nginx.agent.v1.AnalyticsRequest
+	75,  // 182: nginx.agent.v1.AgentService.StreamAnalytics:input_type -> nginx.agent.v1.AnalyticsRequest
+	91,  // 183: nginx.agent.v1.AgentService.GetTraces:input_type -> nginx.agent.v1.TraceRequest
+	91,  // 184: nginx.agent.v1.AgentService.GetTraceDetails:input_type -> nginx.agent.v1.TraceRequest
+	109, // 185: nginx.agent.v1.AgentService.GetRecommendations:input_type -> nginx.agent.v1.RecommendationRequest
+	94,  // 186: nginx.agent.v1.AgentService.ApplyAugment:input_type -> nginx.agent.v1.ApplyAugmentRequest
+	40,  // 187: nginx.agent.v1.AgentService.UpdateAgent:input_type -> nginx.agent.v1.UpdateAgentRequest
+	41,  // 188: nginx.agent.v1.AgentService.GetUpdateStatus:input_type -> nginx.agent.v1.GetUpdateStatusRequest
+	38,  // 189: nginx.agent.v1.AgentService.Execute:input_type -> nginx.agent.v1.ExecRequest
+	119, // 190: nginx.agent.v1.AgentService.GetAgentConfig:input_type -> nginx.agent.v1.GetAgentConfigRequest
+	120, // 191: nginx.agent.v1.AgentService.UpdateAgentConfig:input_type -> nginx.agent.v1.AgentConfig
+	112, // 192: nginx.agent.v1.AgentService.GenerateReport:input_type -> nginx.agent.v1.ReportRequest
+	116, // 193: nginx.agent.v1.AgentService.SendReport:input_type -> nginx.agent.v1.SendReportRequest
+	112, // 194: nginx.agent.v1.AgentService.DownloadReport:input_type -> nginx.agent.v1.ReportRequest
+	33,  // 195: nginx.agent.v1.AgentService.ListAlertRules:input_type -> nginx.agent.v1.ListAlertRulesRequest
+	37,  // 196: nginx.agent.v1.AgentService.CreateAlertRule:input_type -> nginx.agent.v1.AlertRule
+	35,  // 197: nginx.agent.v1.AgentService.DeleteAlertRule:input_type -> nginx.agent.v1.DeleteAlertRuleRequest
+	123, // 198: nginx.agent.v1.AgentService.ListGroups:input_type -> nginx.agent.v1.ListGroupsRequest
+	125, // 199: nginx.agent.v1.AgentService.GetGroup:input_type -> nginx.agent.v1.GetGroupRequest
+	126, // 200: nginx.agent.v1.AgentService.CreateGroup:input_type -> nginx.agent.v1.CreateGroupRequest
+	127, // 201: nginx.agent.v1.AgentService.UpdateGroup:input_type -> nginx.agent.v1.UpdateGroupRequest
+	128, // 202: nginx.agent.v1.AgentService.DeleteGroup:input_type -> nginx.agent.v1.DeleteGroupRequest
+	130, // 203: nginx.agent.v1.AgentService.AddAgentsToGroup:input_type -> nginx.agent.v1.AddAgentsToGroupRequest
+	133, // 204: nginx.agent.v1.AgentService.RemoveAgentFromGroup:input_type -> nginx.agent.v1.RemoveAgentFromGroupRequest
+	135, // 205: nginx.agent.v1.AgentService.SetGoldenAgent:input_type -> nginx.agent.v1.SetGoldenAgentRequest
+	137, // 206: nginx.agent.v1.AgentService.GetGroupAgents:input_type -> nginx.agent.v1.GetGroupAgentsRequest
+	139, // 207: nginx.agent.v1.AgentService.CheckDrift:input_type -> nginx.agent.v1.DriftCheckRequest
+	142, // 208: nginx.agent.v1.AgentService.GetDriftReport:input_type -> nginx.agent.v1.GetDriftReportRequest
+	143, // 209: nginx.agent.v1.AgentService.ListDriftReports:input_type -> nginx.agent.v1.ListDriftReportsRequest
+	145, // 210: nginx.agent.v1.AgentService.ResolveDrift:input_type -> nginx.agent.v1.ResolveDriftRequest
+	146, // 211: nginx.agent.v1.AgentService.BatchUpdateConfig:input_type -> nginx.agent.v1.BatchConfigUpdateRequest
+	149, // 212: nginx.agent.v1.AgentService.GetBatchStatus:input_type -> nginx.agent.v1.GetBatchStatusRequest
+	150, // 213: nginx.agent.v1.AgentService.CancelBatch:input_type -> nginx.agent.v1.CancelBatchRequest
+	152, // 214: nginx.agent.v1.AgentService.RollbackBatch:input_type -> nginx.agent.v1.RollbackBatchRequest
+	156, // 215: nginx.agent.v1.AgentService.ListConfigTemplates:input_type -> nginx.agent.v1.ListConfigTemplatesRequest
+	158, // 216: nginx.agent.v1.AgentService.GetConfigTemplate:input_type -> nginx.agent.v1.GetConfigTemplateRequest
+	159, // 217: nginx.agent.v1.AgentService.CreateConfigTemplate:input_type -> nginx.agent.v1.CreateConfigTemplateRequest
+	160, // 218: nginx.agent.v1.AgentService.UpdateConfigTemplate:input_type -> nginx.agent.v1.UpdateConfigTemplateRequest
+	161, // 219: nginx.agent.v1.AgentService.DeleteConfigTemplate:input_type -> nginx.agent.v1.DeleteConfigTemplateRequest
+	163, // 220: nginx.agent.v1.AgentService.RenderConfigTemplate:input_type -> nginx.agent.v1.RenderConfigTemplateRequest
+	166, // 221: nginx.agent.v1.AgentService.ListConfigTemplateVersions:input_type -> nginx.agent.v1.ListConfigTemplateVersionsRequest
+	169, // 222: nginx.agent.v1.AgentService.SetConfigTemplateVariables:input_type -> nginx.agent.v1.SetConfigTemplateVariablesRequest
+	172, // 223: nginx.agent.v1.AgentService.SetMaintenance:input_type -> nginx.agent.v1.SetMaintenanceRequest
+	175, // 224: nginx.agent.v1.AgentService.GetMaintenanceStatus:input_type -> nginx.agent.v1.GetMaintenanceStatusRequest
+	176, // 225: nginx.agent.v1.AgentService.ListMaintenanceStates:input_type -> nginx.agent.v1.ListMaintenanceStatesRequest
+	178, // 226: nginx.agent.v1.AgentService.ListMaintenanceTemplates:input_type -> nginx.agent.v1.ListMaintenanceTemplatesRequest
+	180, // 227: nginx.agent.v1.AgentService.CreateMaintenanceTemplate:input_type -> nginx.agent.v1.CreateMaintenanceTemplateRequest
+	181, // 228: nginx.agent.v1.AgentService.UpdateMaintenanceTemplate:input_type -> nginx.agent.v1.UpdateMaintenanceTemplateRequest
+	182, // 229: nginx.agent.v1.AgentService.DeleteMaintenanceTemplate:input_type -> nginx.agent.v1.DeleteMaintenanceTemplateRequest
+	184, // 230: nginx.agent.v1.AgentService.PreviewMaintenanceTemplate:input_type -> nginx.agent.v1.PreviewMaintenanceTemplateRequest
+	187, // 231: nginx.agent.v1.AgentService.UploadCertificate:input_type -> nginx.agent.v1.UploadCertificateRequest
+	190, // 232: nginx.agent.v1.AgentService.GetCertificateInventory:input_type -> nginx.agent.v1.GetCertificateInventoryRequest
+	192, // 233: nginx.agent.v1.AgentService.DeployCertificate:input_type -> nginx.agent.v1.DeployCertificateRequest
+	193, // 234: nginx.agent.v1.AgentService.DeleteCertificate:input_type -> nginx.agent.v1.DeleteCertificateRequest
+	195, // 235: nginx.agent.v1.AgentService.CompareEnvironments:input_type -> nginx.agent.v1.CompareEnvironmentsRequest
+	200, // 236: nginx.agent.v1.AgentService.GetComparison:input_type -> nginx.agent.v1.GetComparisonRequest
+	201, // 237: nginx.agent.v1.AgentService.UpdateSiteLocation:input_type -> nginx.agent.v1.SiteLocationUpdateRequest
+	206, // 238: nginx.agent.v1.AgentService.ListUpstreams:input_type -> nginx.agent.v1.UpstreamListRequest
+	210, // 239: nginx.agent.v1.AgentService.UpdateUpstreamServer:input_type -> nginx.agent.v1.UpstreamServerUpdate
+	212, // 240: nginx.agent.v1.AgentService.GetServiceStatus:input_type -> nginx.agent.v1.ServiceStatusRequest
+	215, // 241: nginx.agent.v1.AgentService.UpgradeNginxBinary:input_type -> nginx.agent.v1.BinaryUpgradeRequest
+	13,  // 242: nginx.agent.v1.Commander.Connect:output_type -> nginx.agent.v1.ServerCommand
+	44,  // 243: nginx.agent.v1.AgentService.GetConfig:output_type -> nginx.agent.v1.ConfigResponse
+	52,  // 244: nginx.agent.v1.AgentService.UpdateConfig:output_type -> nginx.agent.v1.ConfigUpdateResponse
+	54,  // 245: nginx.agent.v1.AgentService.ValidateConfig:output_type -> nginx.agent.v1.ValidationResult
+	56,  // 246: nginx.agent.v1.AgentService.ReloadNginx:output_type -> nginx.agent.v1.ReloadResponse
+	58,  // 247: nginx.agent.v1.AgentService.RestartNginx:output_type -> nginx.agent.v1.RestartResponse
+	60,  // 248: nginx.agent.v1.AgentService.StopNginx:output_type -> nginx.agent.v1.StopResponse
+	62,  // 249: nginx.agent.v1.AgentService.ListCertificates:output_type -> nginx.agent.v1.CertListResponse
+	71,  // 250: nginx.agent.v1.AgentService.GetLogs:output_type -> nginx.agent.v1.LogEntry
+	65,  // 251: nginx.agent.v1.AgentService.ListAgents:output_type -> nginx.agent.v1.ListAgentsResponse
+	69,  // 252: nginx.agent.v1.AgentService.GetAgent:output_type -> nginx.agent.v1.AgentInfo
+	67,  // 253: nginx.agent.v1.AgentService.RemoveAgent:output_type -> nginx.agent.v1.RemoveAgentResponse
+	73,  // 254: nginx.agent.v1.AgentService.GetUptimeReports:output_type -> nginx.agent.v1.UptimeResponse
+	77,  // 255: nginx.agent.v1.AgentService.GetAnalytics:output_type -> nginx.agent.v1.AnalyticsResponse
+	77,  // 256: nginx.agent.v1.AgentService.StreamAnalytics:output_type -> nginx.agent.v1.AnalyticsResponse
+	92,  // 257: nginx.agent.v1.AgentService.GetTraces:output_type -> nginx.agent.v1.TraceList
+	90,  // 258: nginx.agent.v1.AgentService.GetTraceDetails:output_type -> nginx.agent.v1.Trace
+	110, // 259: nginx.agent.v1.AgentService.GetRecommendations:output_type -> nginx.agent.v1.RecommendationResponse
+	95,  // 260: nginx.agent.v1.AgentService.ApplyAugment:output_type -> nginx.agent.v1.ApplyAugmentResponse
+	42,  // 261: nginx.agent.v1.AgentService.UpdateAgent:output_type -> nginx.agent.v1.UpdateAgentResponse
+	21,  // 262: nginx.agent.v1.AgentService.GetUpdateStatus:output_type -> nginx.agent.v1.UpdateStatus
+	39,  // 263: nginx.agent.v1.AgentService.Execute:output_type -> nginx.agent.v1.ExecResponse
+	120, // 264: nginx.agent.v1.AgentService.GetAgentConfig:output_type -> nginx.agent.v1.AgentConfig
+	121, // 265: nginx.agent.v1.AgentService.UpdateAgentConfig:output_type -> nginx.agent.v1.AgentConfigUpdateResult
+	113, // 266: nginx.agent.v1.AgentService.GenerateReport:output_type -> nginx.agent.v1.ReportResponse
+	117, // 267: nginx.agent.v1.AgentService.SendReport:output_type -> nginx.agent.v1.SendReportResponse
+	118, // 268: nginx.agent.v1.AgentService.DownloadReport:output_type -> nginx.agent.v1.ReportDownloadResponse
+	34,  // 269: nginx.agent.v1.AgentService.ListAlertRules:output_type -> nginx.agent.v1.AlertRuleList
+	37,  // 270: nginx.agent.v1.AgentService.CreateAlertRule:output_type -> nginx.agent.v1.AlertRule
+	36,  // 271: nginx.agent.v1.AgentService.DeleteAlertRule:output_type -> nginx.agent.v1.DeleteAlertRuleResponse
+	124, // 272: nginx.agent.v1.AgentService.ListGroups:output_type -> nginx.agent.v1.ListGroupsResponse
+	122, // 273: nginx.agent.v1.AgentService.GetGroup:output_type -> nginx.agent.v1.AgentGroup
+	122, // 274: nginx.agent.v1.AgentService.CreateGroup:output_type -> nginx.agent.v1.AgentGroup
+	122, // 275: nginx.agent.v1.AgentService.UpdateGroup:output_type -> nginx.agent.v1.AgentGroup
+	129, // 276: nginx.agent.v1.AgentService.DeleteGroup:output_type -> nginx.agent.v1.DeleteGroupResponse
+	131, // 277: nginx.agent.v1.AgentService.AddAgentsToGroup:output_type -> nginx.agent.v1.AddAgentsToGroupResponse
+	134, // 278: nginx.agent.v1.AgentService.RemoveAgentFromGroup:output_type -> nginx.agent.v1.RemoveAgentFromGroupResponse
+	136, // 279: nginx.agent.v1.AgentService.SetGoldenAgent:output_type -> nginx.agent.v1.SetGoldenAgentResponse
+	138, // 280: nginx.agent.v1.AgentService.GetGroupAgents:output_type -> nginx.agent.v1.GetGroupAgentsResponse
+	140, // 281: nginx.agent.v1.AgentService.CheckDrift:output_type -> nginx.agent.v1.DriftCheckResponse
+	140, // 282: nginx.agent.v1.AgentService.GetDriftReport:output_type -> nginx.agent.v1.DriftCheckResponse
+	144, // 283: nginx.agent.v1.AgentService.ListDriftReports:output_type -> nginx.agent.v1.ListDriftReportsResponse
+	147, // 284: nginx.agent.v1.AgentService.ResolveDrift:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
+	147, // 285: nginx.agent.v1.AgentService.BatchUpdateConfig:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
+	147, // 286: nginx.agent.v1.AgentService.GetBatchStatus:output_type -> nginx.agent.v1.BatchConfigUpdateResponse
+	151, // 287: nginx.agent.v1.AgentService.CancelBatch:output_type -> nginx.agent.v1.CancelBatchResponse
+	153, // 288: nginx.agent.v1.AgentService.RollbackBatch:output_type -> nginx.agent.v1.RollbackBatchResponse
+	157, // 289: nginx.agent.v1.AgentService.ListConfigTemplates:output_type -> nginx.agent.v1.ListConfigTemplatesResponse
+	154, // 290: nginx.agent.v1.AgentService.GetConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
+	154, // 291: nginx.agent.v1.AgentService.CreateConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
+	154, // 292: nginx.agent.v1.AgentService.UpdateConfigTemplate:output_type -> nginx.agent.v1.ConfigTemplate
+	162, // 293: nginx.agent.v1.AgentService.DeleteConfigTemplate:output_type -> nginx.agent.v1.DeleteConfigTemplateResponse
+	164, // 294: nginx.agent.v1.AgentService.RenderConfigTemplate:output_type -> nginx.agent.v1.RenderConfigTemplateResponse
+	167, // 295: nginx.agent.v1.AgentService.ListConfigTemplateVersions:output_type -> nginx.agent.v1.ListConfigTemplateVersionsResponse
+	168, // 296: nginx.agent.v1.AgentService.SetConfigTemplateVariables:output_type -> nginx.agent.v1.ConfigTemplateVariables
+	173, // 297: nginx.agent.v1.AgentService.SetMaintenance:output_type -> nginx.agent.v1.SetMaintenanceResponse
+	171, // 298: nginx.agent.v1.AgentService.GetMaintenanceStatus:output_type -> nginx.agent.v1.MaintenanceState
+	177, // 299: nginx.agent.v1.AgentService.ListMaintenanceStates:output_type -> nginx.agent.v1.ListMaintenanceStatesResponse
+	179, // 300: nginx.agent.v1.AgentService.ListMaintenanceTemplates:output_type -> nginx.agent.v1.ListMaintenanceTemplatesResponse
+	170, // 301: nginx.agent.v1.AgentService.CreateMaintenanceTemplate:output_type -> nginx.agent.v1.MaintenanceTemplate
+	170, // 302: nginx.agent.v1.AgentService.UpdateMaintenanceTemplate:output_type -> nginx.agent.v1.MaintenanceTemplate
+	183, // 303: nginx.agent.v1.AgentService.DeleteMaintenanceTemplate:output_type -> nginx.agent.v1.DeleteMaintenanceTemplateResponse
+	185, // 304: nginx.agent.v1.AgentService.PreviewMaintenanceTemplate:output_type -> nginx.agent.v1.PreviewMaintenanceTemplateResponse
+	188, // 305: nginx.agent.v1.AgentService.UploadCertificate:output_type -> nginx.agent.v1.UploadCertificateResponse
+	191, // 306: nginx.agent.v1.AgentService.GetCertificateInventory:output_type -> nginx.agent.v1.GetCertificateInventoryResponse
+	188, // 307: nginx.agent.v1.AgentService.DeployCertificate:output_type -> nginx.agent.v1.UploadCertificateResponse
+	194, // 308: nginx.agent.v1.AgentService.DeleteCertificate:output_type -> nginx.agent.v1.DeleteCertificateResponse
+	196, // 309: nginx.agent.v1.AgentService.CompareEnvironments:output_type -> nginx.agent.v1.CompareEnvironmentsResponse
+	196, // 310: nginx.agent.v1.AgentService.GetComparison:output_type -> nginx.agent.v1.CompareEnvironmentsResponse
+	205, // 311: nginx.agent.v1.AgentService.UpdateSiteLocation:output_type -> nginx.agent.v1.SiteLocationUpdateResponse
+	209, // 312: nginx.agent.v1.AgentService.ListUpstreams:output_type -> nginx.agent.v1.UpstreamListResponse
+	211, // 313: nginx.agent.v1.AgentService.UpdateUpstreamServer:output_type -> nginx.agent.v1.UpstreamServerUpdateResponse
+	213, // 314: nginx.agent.v1.AgentService.GetServiceStatus:output_type -> nginx.agent.v1.ServiceStatus
+	216, // 315: nginx.agent.v1.AgentService.UpgradeNginxBinary:output_type -> nginx.agent.v1.BinaryUpgradeResponse
+	242, // [242:316] is the sub-list for method output_type
+	168, // [168:242] is the sub-list for method input_type
+	168, // [168:168] is the sub-list for extension type_name
+	168, // [168:168] is the sub-list for extension extendee
+	0,   // [0:168] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_agent_proto_init() }
@@ -14300,12 +19435,16 @@ func file_api_proto_agent_proto_init() {
 		(*AgentMessage_State)(nil),
 		(*AgentMessage_LogEntry)(nil),
 		(*AgentMessage_Metrics)(nil),
+		(*AgentMessage_GenericMetrics)(nil),
+		(*AgentMessage_UpdateRejected)(nil),
 	}
-	file_api_proto_agent_proto_msgTypes[5].OneofWrappers = []any{
+	file_api_proto_agent_proto_msgTypes[13].OneofWrappers = []any{
 		(*ServerCommand_ConfigPush)(nil),
 		(*ServerCommand_Action)(nil),
 		(*ServerCommand_LogRequest)(nil),
 		(*ServerCommand_Update)(nil),
+		(*ServerCommand_ConfigureAgent)(nil),
+		(*ServerCommand_Drain)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -14313,7 +19452,7 @@ func file_api_proto_agent_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_agent_proto_rawDesc), len(file_api_proto_agent_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   197,
+			NumMessages:   257,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
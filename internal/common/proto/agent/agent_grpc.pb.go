@@ -140,6 +140,7 @@ const (
 	AgentService_GetRecommendations_FullMethodName         = "/nginx.agent.v1.AgentService/GetRecommendations"
 	AgentService_ApplyAugment_FullMethodName               = "/nginx.agent.v1.AgentService/ApplyAugment"
 	AgentService_UpdateAgent_FullMethodName                = "/nginx.agent.v1.AgentService/UpdateAgent"
+	AgentService_GetUpdateStatus_FullMethodName            = "/nginx.agent.v1.AgentService/GetUpdateStatus"
 	AgentService_Execute_FullMethodName                    = "/nginx.agent.v1.AgentService/Execute"
 	AgentService_GetAgentConfig_FullMethodName             = "/nginx.agent.v1.AgentService/GetAgentConfig"
 	AgentService_UpdateAgentConfig_FullMethodName          = "/nginx.agent.v1.AgentService/UpdateAgentConfig"
@@ -172,6 +173,8 @@ const (
 	AgentService_UpdateConfigTemplate_FullMethodName       = "/nginx.agent.v1.AgentService/UpdateConfigTemplate"
 	AgentService_DeleteConfigTemplate_FullMethodName       = "/nginx.agent.v1.AgentService/DeleteConfigTemplate"
 	AgentService_RenderConfigTemplate_FullMethodName       = "/nginx.agent.v1.AgentService/RenderConfigTemplate"
+	AgentService_ListConfigTemplateVersions_FullMethodName = "/nginx.agent.v1.AgentService/ListConfigTemplateVersions"
+	AgentService_SetConfigTemplateVariables_FullMethodName = "/nginx.agent.v1.AgentService/SetConfigTemplateVariables"
 	AgentService_SetMaintenance_FullMethodName             = "/nginx.agent.v1.AgentService/SetMaintenance"
 	AgentService_GetMaintenanceStatus_FullMethodName       = "/nginx.agent.v1.AgentService/GetMaintenanceStatus"
 	AgentService_ListMaintenanceStates_FullMethodName      = "/nginx.agent.v1.AgentService/ListMaintenanceStates"
@@ -187,6 +190,10 @@ const (
 	AgentService_CompareEnvironments_FullMethodName        = "/nginx.agent.v1.AgentService/CompareEnvironments"
 	AgentService_GetComparison_FullMethodName              = "/nginx.agent.v1.AgentService/GetComparison"
 	AgentService_UpdateSiteLocation_FullMethodName         = "/nginx.agent.v1.AgentService/UpdateSiteLocation"
+	AgentService_ListUpstreams_FullMethodName              = "/nginx.agent.v1.AgentService/ListUpstreams"
+	AgentService_UpdateUpstreamServer_FullMethodName       = "/nginx.agent.v1.AgentService/UpdateUpstreamServer"
+	AgentService_GetServiceStatus_FullMethodName           = "/nginx.agent.v1.AgentService/GetServiceStatus"
+	AgentService_UpgradeNginxBinary_FullMethodName         = "/nginx.agent.v1.AgentService/UpgradeNginxBinary"
 )
 
 // AgentServiceClient is the client API for AgentService service.
@@ -225,6 +232,7 @@ type AgentServiceClient interface {
 	ApplyAugment(ctx context.Context, in *ApplyAugmentRequest, opts ...grpc.CallOption) (*ApplyAugmentResponse, error)
 	// Agent Management
 	UpdateAgent(ctx context.Context, in *UpdateAgentRequest, opts ...grpc.CallOption) (*UpdateAgentResponse, error)
+	GetUpdateStatus(ctx context.Context, in *GetUpdateStatusRequest, opts ...grpc.CallOption) (*UpdateStatus, error)
 	// Command Execution (Shell)
 	Execute(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExecRequest, ExecResponse], error)
 	// Agent Configuration
@@ -265,6 +273,8 @@ type AgentServiceClient interface {
 	UpdateConfigTemplate(ctx context.Context, in *UpdateConfigTemplateRequest, opts ...grpc.CallOption) (*ConfigTemplate, error)
 	DeleteConfigTemplate(ctx context.Context, in *DeleteConfigTemplateRequest, opts ...grpc.CallOption) (*DeleteConfigTemplateResponse, error)
 	RenderConfigTemplate(ctx context.Context, in *RenderConfigTemplateRequest, opts ...grpc.CallOption) (*RenderConfigTemplateResponse, error)
+	ListConfigTemplateVersions(ctx context.Context, in *ListConfigTemplateVersionsRequest, opts ...grpc.CallOption) (*ListConfigTemplateVersionsResponse, error)
+	SetConfigTemplateVariables(ctx context.Context, in *SetConfigTemplateVariablesRequest, opts ...grpc.CallOption) (*ConfigTemplateVariables, error)
 	// ============ Maintenance Mode ============
 	SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*SetMaintenanceResponse, error)
 	GetMaintenanceStatus(ctx context.Context, in *GetMaintenanceStatusRequest, opts ...grpc.CallOption) (*MaintenanceState, error)
@@ -284,6 +294,12 @@ type AgentServiceClient interface {
 	GetComparison(ctx context.Context, in *GetComparisonRequest, opts ...grpc.CallOption) (*CompareEnvironmentsResponse, error)
 	// ============ Site/Location Updates ============
 	UpdateSiteLocation(ctx context.Context, in *SiteLocationUpdateRequest, opts ...grpc.CallOption) (*SiteLocationUpdateResponse, error)
+	// ============ Upstream Pools ============
+	ListUpstreams(ctx context.Context, in *UpstreamListRequest, opts ...grpc.CallOption) (*UpstreamListResponse, error)
+	UpdateUpstreamServer(ctx context.Context, in *UpstreamServerUpdate, opts ...grpc.CallOption) (*UpstreamServerUpdateResponse, error)
+	// ============ Service Manager ============
+	GetServiceStatus(ctx context.Context, in *ServiceStatusRequest, opts ...grpc.CallOption) (*ServiceStatus, error)
+	UpgradeNginxBinary(ctx context.Context, in *BinaryUpgradeRequest, opts ...grpc.CallOption) (*BinaryUpgradeResponse, error)
 }
 
 type agentServiceClient struct {
@@ -502,6 +518,16 @@ func (c *agentServiceClient) UpdateAgent(ctx context.Context, in *UpdateAgentReq
 	return out, nil
 }
 
+func (c *agentServiceClient) GetUpdateStatus(ctx context.Context, in *GetUpdateStatusRequest, opts ...grpc.CallOption) (*UpdateStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateStatus)
+	err := c.cc.Invoke(ctx, AgentService_GetUpdateStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentServiceClient) Execute(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExecRequest, ExecResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[2], AgentService_Execute_FullMethodName, cOpts...)
@@ -825,6 +851,26 @@ func (c *agentServiceClient) RenderConfigTemplate(ctx context.Context, in *Rende
 	return out, nil
 }
 
+func (c *agentServiceClient) ListConfigTemplateVersions(ctx context.Context, in *ListConfigTemplateVersionsRequest, opts ...grpc.CallOption) (*ListConfigTemplateVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListConfigTemplateVersionsResponse)
+	err := c.cc.Invoke(ctx, AgentService_ListConfigTemplateVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) SetConfigTemplateVariables(ctx context.Context, in *SetConfigTemplateVariablesRequest, opts ...grpc.CallOption) (*ConfigTemplateVariables, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfigTemplateVariables)
+	err := c.cc.Invoke(ctx, AgentService_SetConfigTemplateVariables_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *agentServiceClient) SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*SetMaintenanceResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SetMaintenanceResponse)
@@ -975,6 +1021,46 @@ func (c *agentServiceClient) UpdateSiteLocation(ctx context.Context, in *SiteLoc
 	return out, nil
 }
 
+func (c *agentServiceClient) ListUpstreams(ctx context.Context, in *UpstreamListRequest, opts ...grpc.CallOption) (*UpstreamListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpstreamListResponse)
+	err := c.cc.Invoke(ctx, AgentService_ListUpstreams_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) UpdateUpstreamServer(ctx context.Context, in *UpstreamServerUpdate, opts ...grpc.CallOption) (*UpstreamServerUpdateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpstreamServerUpdateResponse)
+	err := c.cc.Invoke(ctx, AgentService_UpdateUpstreamServer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) GetServiceStatus(ctx context.Context, in *ServiceStatusRequest, opts ...grpc.CallOption) (*ServiceStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServiceStatus)
+	err := c.cc.Invoke(ctx, AgentService_GetServiceStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) UpgradeNginxBinary(ctx context.Context, in *BinaryUpgradeRequest, opts ...grpc.CallOption) (*BinaryUpgradeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BinaryUpgradeResponse)
+	err := c.cc.Invoke(ctx, AgentService_UpgradeNginxBinary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServiceServer is the server API for AgentService service.
 // All implementations must embed UnimplementedAgentServiceServer
 // for forward compatibility.
@@ -1011,6 +1097,7 @@ type AgentServiceServer interface {
 	ApplyAugment(context.Context, *ApplyAugmentRequest) (*ApplyAugmentResponse, error)
 	// Agent Management
 	UpdateAgent(context.Context, *UpdateAgentRequest) (*UpdateAgentResponse, error)
+	GetUpdateStatus(context.Context, *GetUpdateStatusRequest) (*UpdateStatus, error)
 	// Command Execution (Shell)
 	Execute(grpc.BidiStreamingServer[ExecRequest, ExecResponse]) error
 	// Agent Configuration
@@ -1051,6 +1138,8 @@ type AgentServiceServer interface {
 	UpdateConfigTemplate(context.Context, *UpdateConfigTemplateRequest) (*ConfigTemplate, error)
 	DeleteConfigTemplate(context.Context, *DeleteConfigTemplateRequest) (*DeleteConfigTemplateResponse, error)
 	RenderConfigTemplate(context.Context, *RenderConfigTemplateRequest) (*RenderConfigTemplateResponse, error)
+	ListConfigTemplateVersions(context.Context, *ListConfigTemplateVersionsRequest) (*ListConfigTemplateVersionsResponse, error)
+	SetConfigTemplateVariables(context.Context, *SetConfigTemplateVariablesRequest) (*ConfigTemplateVariables, error)
 	// ============ Maintenance Mode ============
 	SetMaintenance(context.Context, *SetMaintenanceRequest) (*SetMaintenanceResponse, error)
 	GetMaintenanceStatus(context.Context, *GetMaintenanceStatusRequest) (*MaintenanceState, error)
@@ -1070,6 +1159,12 @@ type AgentServiceServer interface {
 	GetComparison(context.Context, *GetComparisonRequest) (*CompareEnvironmentsResponse, error)
 	// ============ Site/Location Updates ============
 	UpdateSiteLocation(context.Context, *SiteLocationUpdateRequest) (*SiteLocationUpdateResponse, error)
+	// ============ Upstream Pools ============
+	ListUpstreams(context.Context, *UpstreamListRequest) (*UpstreamListResponse, error)
+	UpdateUpstreamServer(context.Context, *UpstreamServerUpdate) (*UpstreamServerUpdateResponse, error)
+	// ============ Service Manager ============
+	GetServiceStatus(context.Context, *ServiceStatusRequest) (*ServiceStatus, error)
+	UpgradeNginxBinary(context.Context, *BinaryUpgradeRequest) (*BinaryUpgradeResponse, error)
 	mustEmbedUnimplementedAgentServiceServer()
 }
 
@@ -1137,6 +1232,9 @@ func (UnimplementedAgentServiceServer) ApplyAugment(context.Context, *ApplyAugme
 func (UnimplementedAgentServiceServer) UpdateAgent(context.Context, *UpdateAgentRequest) (*UpdateAgentResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method UpdateAgent not implemented")
 }
+func (UnimplementedAgentServiceServer) GetUpdateStatus(context.Context, *GetUpdateStatusRequest) (*UpdateStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetUpdateStatus not implemented")
+}
 func (UnimplementedAgentServiceServer) Execute(grpc.BidiStreamingServer[ExecRequest, ExecResponse]) error {
 	return status.Error(codes.Unimplemented, "method Execute not implemented")
 }
@@ -1233,6 +1331,12 @@ func (UnimplementedAgentServiceServer) DeleteConfigTemplate(context.Context, *De
 func (UnimplementedAgentServiceServer) RenderConfigTemplate(context.Context, *RenderConfigTemplateRequest) (*RenderConfigTemplateResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method RenderConfigTemplate not implemented")
 }
+func (UnimplementedAgentServiceServer) ListConfigTemplateVersions(context.Context, *ListConfigTemplateVersionsRequest) (*ListConfigTemplateVersionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListConfigTemplateVersions not implemented")
+}
+func (UnimplementedAgentServiceServer) SetConfigTemplateVariables(context.Context, *SetConfigTemplateVariablesRequest) (*ConfigTemplateVariables, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetConfigTemplateVariables not implemented")
+}
 func (UnimplementedAgentServiceServer) SetMaintenance(context.Context, *SetMaintenanceRequest) (*SetMaintenanceResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SetMaintenance not implemented")
 }
@@ -1278,6 +1382,18 @@ func (UnimplementedAgentServiceServer) GetComparison(context.Context, *GetCompar
 func (UnimplementedAgentServiceServer) UpdateSiteLocation(context.Context, *SiteLocationUpdateRequest) (*SiteLocationUpdateResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method UpdateSiteLocation not implemented")
 }
+func (UnimplementedAgentServiceServer) ListUpstreams(context.Context, *UpstreamListRequest) (*UpstreamListResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListUpstreams not implemented")
+}
+func (UnimplementedAgentServiceServer) UpdateUpstreamServer(context.Context, *UpstreamServerUpdate) (*UpstreamServerUpdateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateUpstreamServer not implemented")
+}
+func (UnimplementedAgentServiceServer) GetServiceStatus(context.Context, *ServiceStatusRequest) (*ServiceStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetServiceStatus not implemented")
+}
+func (UnimplementedAgentServiceServer) UpgradeNginxBinary(context.Context, *BinaryUpgradeRequest) (*BinaryUpgradeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpgradeNginxBinary not implemented")
+}
 func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
 func (UnimplementedAgentServiceServer) testEmbeddedByValue()                      {}
 
@@ -1627,6 +1743,24 @@ func _AgentService_UpdateAgent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_GetUpdateStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUpdateStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).GetUpdateStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_GetUpdateStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).GetUpdateStatus(ctx, req.(*GetUpdateStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AgentService_Execute_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(AgentServiceServer).Execute(&grpc.GenericServerStream[ExecRequest, ExecResponse]{ServerStream: stream})
 }
@@ -2192,6 +2326,42 @@ func _AgentService_RenderConfigTemplate_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_ListConfigTemplateVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConfigTemplateVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ListConfigTemplateVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ListConfigTemplateVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ListConfigTemplateVersions(ctx, req.(*ListConfigTemplateVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_SetConfigTemplateVariables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConfigTemplateVariablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).SetConfigTemplateVariables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_SetConfigTemplateVariables_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).SetConfigTemplateVariables(ctx, req.(*SetConfigTemplateVariablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AgentService_SetMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SetMaintenanceRequest)
 	if err := dec(in); err != nil {
@@ -2462,6 +2632,78 @@ func _AgentService_UpdateSiteLocation_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_ListUpstreams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpstreamListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).ListUpstreams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_ListUpstreams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).ListUpstreams(ctx, req.(*UpstreamListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_UpdateUpstreamServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpstreamServerUpdate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).UpdateUpstreamServer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_UpdateUpstreamServer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).UpdateUpstreamServer(ctx, req.(*UpstreamServerUpdate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_GetServiceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServiceStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).GetServiceStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_GetServiceStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).GetServiceStatus(ctx, req.(*ServiceStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_UpgradeNginxBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinaryUpgradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).UpgradeNginxBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_UpgradeNginxBinary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).UpgradeNginxBinary(ctx, req.(*BinaryUpgradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -2537,6 +2779,10 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateAgent",
 			Handler:    _AgentService_UpdateAgent_Handler,
 		},
+		{
+			MethodName: "GetUpdateStatus",
+			Handler:    _AgentService_GetUpdateStatus_Handler,
+		},
 		{
 			MethodName: "GetAgentConfig",
 			Handler:    _AgentService_GetAgentConfig_Handler,
@@ -2661,6 +2907,14 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RenderConfigTemplate",
 			Handler:    _AgentService_RenderConfigTemplate_Handler,
 		},
+		{
+			MethodName: "ListConfigTemplateVersions",
+			Handler:    _AgentService_ListConfigTemplateVersions_Handler,
+		},
+		{
+			MethodName: "SetConfigTemplateVariables",
+			Handler:    _AgentService_SetConfigTemplateVariables_Handler,
+		},
 		{
 			MethodName: "SetMaintenance",
 			Handler:    _AgentService_SetMaintenance_Handler,
@@ -2721,6 +2975,22 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateSiteLocation",
 			Handler:    _AgentService_UpdateSiteLocation_Handler,
 		},
+		{
+			MethodName: "ListUpstreams",
+			Handler:    _AgentService_ListUpstreams_Handler,
+		},
+		{
+			MethodName: "UpdateUpstreamServer",
+			Handler:    _AgentService_UpdateUpstreamServer_Handler,
+		},
+		{
+			MethodName: "GetServiceStatus",
+			Handler:    _AgentService_GetServiceStatus_Handler,
+		},
+		{
+			MethodName: "UpgradeNginxBinary",
+			Handler:    _AgentService_UpgradeNginxBinary_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
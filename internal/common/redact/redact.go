@@ -0,0 +1,259 @@
+// Package redact scrubs personal data and secrets from access log entries before they are stored.
+// The agent applies it as it collects logs; the gateway can apply the same rules on ingest.
+package redact
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+// Rules a Redactor applies
+const (
+	RuleQuery  = "query"  // values of secret query parameters
+	RuleTokens = "tokens" // bearer tokens, JWTs and well-known API keys
+	RuleEmails = "emails" // email addresses
+	RuleIPs    = "ips"    // client addresses, truncated to a network
+)
+
+// AllRules are the rule names in the order they apply
+var AllRules = []string{RuleQuery, RuleTokens, RuleEmails, RuleIPs}
+
+// Mask replaces redacted values
+const Mask = "REDACTED"
+
+// DefaultParams are the query parameters whose values the query rule masks, matched without case
+var DefaultParams = []string{
+	"access_token", "api_key", "apikey", "auth", "client_secret", "code", "id_token", "key", "passwd",
+	"password", "pwd", "refresh_token", "secret", "session", "sessionid", "sig", "signature", "token",
+	"x-amz-credential", "x-amz-security-token", "x-amz-signature",
+}
+
+// Default networks client addresses are truncated to
+const (
+	DefaultIPv4Prefix = 24
+	DefaultIPv6Prefix = 48
+)
+
+var (
+	tokenRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`),
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`), // JWT
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                             // AWS access key ID
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}\b`),                            // GitHub
+		regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),                         // Slack
+		regexp.MustCompile(`\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`),           // Stripe
+		regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),                              // GitLab
+	}
+	emailRe   = regexp.MustCompile(`[A-Za-z0-9._%+-]+(?:@|%40)[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	paramRe   = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	ipTokenRe = regexp.MustCompile(`[0-9A-Fa-f:.]{3,}`)
+)
+
+// Config selects the rules of a Redactor
+type Config struct {
+	Rules      []string // some of AllRules
+	Params     []string // query parameters masked besides DefaultParams
+	IPv4Prefix int      // bits of IPv4 client addresses kept; DefaultIPv4Prefix when 0
+	IPv6Prefix int      // bits of IPv6 client addresses kept; DefaultIPv6Prefix when 0
+}
+
+// Redactor scrubs log entries. A nil Redactor leaves them as they are.
+type Redactor struct {
+	cfg     Config
+	rules   map[string]bool
+	queryRe *regexp.Regexp
+}
+
+// ParseRules normalizes a comma-separated list of rules: sorted in the order they apply, without
+// duplicates. "all" selects every rule; "", "none" and "off" none.
+func ParseRules(val string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, name := range strings.Split(val, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "", "none", "off":
+		case "all":
+			for _, r := range AllRules {
+				selected[r] = true
+			}
+		case RuleQuery, RuleTokens, RuleEmails, RuleIPs:
+			selected[name] = true
+		default:
+			return nil, fmt.Errorf("unknown redaction rule %q, expected some of %s or all", name, strings.Join(AllRules, ", "))
+		}
+	}
+	rules := []string{}
+	for _, r := range AllRules {
+		if selected[r] {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// ParseParams normalizes a comma-separated list of query parameter names to lower case, sorted and
+// without duplicates
+func ParseParams(val string) ([]string, error) {
+	seen := map[string]bool{}
+	params := []string{}
+	for _, name := range strings.Split(val, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "" || seen[name] {
+			continue
+		}
+		if !paramRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid query parameter name %q", name)
+		}
+		seen[name] = true
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	return params, nil
+}
+
+// ParsePrefix parses the bits of a client address to keep, from 1 to max; "" is the default
+func ParsePrefix(val string, max, def int) (int, error) {
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("expected a prefix length from 1 to %d", max)
+	}
+	return n, nil
+}
+
+// New returns a Redactor applying cfg, nil when it has no rules
+func New(cfg Config) *Redactor {
+	if len(cfg.Rules) == 0 {
+		return nil
+	}
+	if cfg.IPv4Prefix == 0 {
+		cfg.IPv4Prefix = DefaultIPv4Prefix
+	}
+	if cfg.IPv6Prefix == 0 {
+		cfg.IPv6Prefix = DefaultIPv6Prefix
+	}
+	r := &Redactor{cfg: cfg, rules: map[string]bool{}}
+	for _, rule := range cfg.Rules {
+		r.rules[rule] = true
+	}
+	if r.rules[RuleQuery] {
+		names := make([]string, 0, len(DefaultParams)+len(cfg.Params))
+		for _, p := range append(append([]string{}, DefaultParams...), cfg.Params...) {
+			names = append(names, regexp.QuoteMeta(p))
+		}
+		// The parameter name is kept; its value runs to the next separator
+		r.queryRe = regexp.MustCompile(`(?i)([?&;](?:` + strings.Join(names, "|") + `)=)[^&;#\s"]*`)
+	}
+	return r
+}
+
+// Rules returns the rules r applies, nil for a nil Redactor
+func (r *Redactor) Rules() []string {
+	if r == nil {
+		return nil
+	}
+	return append([]string(nil), r.cfg.Rules...)
+}
+
+// Apply scrubs an entry in place. It reports whether anything was masked.
+func (r *Redactor) Apply(e *pb.LogEntry) bool {
+	if r == nil || e == nil {
+		return false
+	}
+	changed := false
+	set := func(field *string, val string) {
+		if *field != val {
+			*field, changed = val, true
+		}
+	}
+	set(&e.RequestUri, r.Text(e.RequestUri))
+	set(&e.Referer, r.Text(e.Referer))
+	set(&e.UserAgent, r.Text(e.UserAgent))
+
+	content := r.Text(e.Content)
+	if r.rules[RuleIPs] {
+		// The raw line carries the addresses too; mask those the entry was parsed with
+		for _, addr := range append([]string{e.RemoteAddr}, strings.Split(e.XForwardedFor, ",")...) {
+			addr = strings.TrimSpace(addr)
+			if masked := r.IP(addr); masked != addr {
+				content = replaceToken(content, addr, masked)
+			}
+		}
+		set(&e.RemoteAddr, r.IP(e.RemoteAddr))
+		set(&e.XForwardedFor, r.IPList(e.XForwardedFor))
+	}
+	set(&e.Content, content)
+	return changed
+}
+
+// Text masks query secrets, tokens and email addresses in s, as its rules select
+func (r *Redactor) Text(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	if r.queryRe != nil {
+		s = r.queryRe.ReplaceAllString(s, "${1}"+Mask)
+	}
+	if r.rules[RuleTokens] {
+		for _, re := range tokenRes {
+			s = re.ReplaceAllString(s, Mask)
+		}
+	}
+	if r.rules[RuleEmails] {
+		s = emailRe.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// IP truncates a client address to its network, e.g. 203.0.113.77 to 203.0.113.0 with a /24
+// prefix, when the ips rule applies. Values that are not addresses are returned as they are.
+func (r *Redactor) IP(s string) string {
+	if r == nil || !r.rules[RuleIPs] {
+		return s
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return s
+	}
+	bits := r.cfg.IPv6Prefix
+	if addr.Is4() || addr.Is4In6() {
+		addr, bits = addr.Unmap(), r.cfg.IPv4Prefix
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return s
+	}
+	return prefix.Addr().String()
+}
+
+// IPList truncates each address of a comma-separated list such as X-Forwarded-For
+func (r *Redactor) IPList(s string) string {
+	if r == nil || !r.rules[RuleIPs] || s == "" {
+		return s
+	}
+	parts := strings.Split(s, ",")
+	for i, p := range parts {
+		trimmed := strings.TrimSpace(p)
+		parts[i] = strings.Replace(p, trimmed, r.IP(trimmed), 1)
+	}
+	return strings.Join(parts, ",")
+}
+
+// replaceToken replaces old in s where it stands alone, not inside a longer address
+func replaceToken(s, old, new string) string {
+	if old == "" {
+		return s
+	}
+	return ipTokenRe.ReplaceAllStringFunc(s, func(tok string) string {
+		if tok == old {
+			return new
+		}
+		return tok
+	})
+}
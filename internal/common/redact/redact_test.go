@@ -0,0 +1,82 @@
+package redact
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/avika-ai/avika/internal/common/proto/agent"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" IPs,query, ips ")
+	if err != nil || !reflect.DeepEqual(rules, []string{RuleQuery, RuleIPs}) {
+		t.Errorf("rules = %v, %v", rules, err)
+	}
+	if rules, _ := ParseRules("all"); !reflect.DeepEqual(rules, AllRules) {
+		t.Errorf("all = %v", rules)
+	}
+	if rules, _ := ParseRules("off"); len(rules) != 0 {
+		t.Errorf("off = %v", rules)
+	}
+	if _, err := ParseRules("query,phones"); err == nil {
+		t.Error("unknown rule should be rejected")
+	}
+	if _, err := ParseParams("otp, bad name"); err == nil {
+		t.Error("invalid parameter should be rejected")
+	}
+}
+
+func TestApply(t *testing.T) {
+	r := New(Config{Rules: AllRules, Params: []string{"otp"}})
+	e := &pb.LogEntry{
+		RemoteAddr:    "203.0.113.77",
+		XForwardedFor: "198.51.100.9, 2001:db8:abcd:12::1",
+		RequestUri:    "/login?user=jane%40example.com&token=abc123&OTP=999&page=2",
+		Referer:       "https://example.com/?access_token=eyJhbGciOiJIUzI1.eyJzdWIiOiIxMjM0.sig",
+		UserAgent:     "curl/8.0",
+		Content:       `203.0.113.77 - - [17/Oct/2026:10:00:00 +0000] "GET /login?user=jane%40example.com&token=abc123 HTTP/1.1" 200 5 "-" "curl/8.0" "198.51.100.9"`,
+	}
+	if !r.Apply(e) {
+		t.Fatal("Apply reported no change")
+	}
+	want := &pb.LogEntry{
+		RemoteAddr:    "203.0.113.0",
+		XForwardedFor: "198.51.100.0, 2001:db8:abcd::",
+		RequestUri:    "/login?user=REDACTED&token=REDACTED&OTP=REDACTED&page=2",
+		Referer:       "https://example.com/?access_token=REDACTED",
+		UserAgent:     "curl/8.0",
+		Content:       `203.0.113.0 - - [17/Oct/2026:10:00:00 +0000] "GET /login?user=REDACTED&token=REDACTED HTTP/1.1" 200 5 "-" "curl/8.0" "198.51.100.0"`,
+	}
+	for _, f := range []struct{ name, got, want string }{
+		{"remote_addr", e.RemoteAddr, want.RemoteAddr},
+		{"x_forwarded_for", e.XForwardedFor, want.XForwardedFor},
+		{"request_uri", e.RequestUri, want.RequestUri},
+		{"referer", e.Referer, want.Referer},
+		{"user_agent", e.UserAgent, want.UserAgent},
+		{"content", e.Content, want.Content},
+	} {
+		if f.got != f.want {
+			t.Errorf("%s = %q, want %q", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestRulesApplySeparately(t *testing.T) {
+	r := New(Config{Rules: []string{RuleTokens}, IPv4Prefix: 16})
+	if got := r.Text("Authorization: Bearer abc.def-123 from ops@example.com"); got != "Authorization: REDACTED from ops@example.com" {
+		t.Errorf("tokens only = %q", got)
+	}
+	if got := r.IP("203.0.113.77"); got != "203.0.113.77" {
+		t.Errorf("ips off = %q", got)
+	}
+	if got := New(Config{Rules: []string{RuleIPs}, IPv4Prefix: 16}).IP("203.0.113.77"); got != "203.0.0.0" {
+		t.Errorf("/16 = %q", got)
+	}
+	if New(Config{}) != nil {
+		t.Error("no rules should give a nil Redactor")
+	}
+	var none *Redactor
+	if none.Apply(&pb.LogEntry{RequestUri: "/?token=x"}) {
+		t.Error("nil Redactor changed an entry")
+	}
+}
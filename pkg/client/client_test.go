@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedMethod(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.EscapedPath() != "/api/servers/web%2F1/events" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if r.URL.Query().Get("category") != "config" {
+			t.Errorf("query not sent: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("token not sent: %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"events":[{"type":"config.applied"}]}`))
+	}))
+	defer srv.Close()
+
+	var out struct {
+		Events []struct {
+			Type string `json:"type"`
+		} `json:"events"`
+	}
+	err := New(srv.URL+"/", "tok").ListServerEvents(context.Background(), "web/1", url.Values{"category": {"config"}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Events) != 1 || out.Events[0].Type != "config.applied" {
+		t.Errorf("unexpected response %+v", out)
+	}
+}
+
+func TestDoSendsBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/json" || strings.TrimSpace(string(data)) != `{"name":"Shop"}` {
+			t.Errorf("unexpected body %q", data)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var out map[string]interface{}
+	if err := New(srv.URL, "").Do(context.Background(), http.MethodPut, "/api/tenancy/projects/shop", nil, map[string]string{"name": "Shop"}, &out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"superadmin required"}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, "tok").ListWebhooks(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "superadmin required" {
+		t.Errorf("expected a 403 APIError, got %v", err)
+	}
+}
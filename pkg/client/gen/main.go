@@ -0,0 +1,136 @@
+// Command gen writes the client's methods from the gateway's OpenAPI document.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+type spec struct {
+	Paths map[string]map[string]operation `json:"paths"`
+}
+
+type operation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Parameters  []struct {
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+	RequestBody json.RawMessage `json:"requestBody"`
+}
+
+// method is one generated client method
+type method struct {
+	name, summary, httpMethod, path string
+	query, body                     bool
+}
+
+func main() {
+	specPath := flag.String("spec", "../../cmd/gateway/openapi.json", "OpenAPI document")
+	out := flag.String("o", "operations_gen.go", "output file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Fatalf("parse %s: %v", *specPath, err)
+	}
+
+	var methods []method
+	for path, ops := range s.Paths {
+		for httpMethod, op := range ops {
+			m := method{
+				name:       op.OperationID,
+				summary:    op.Summary,
+				httpMethod: strings.ToUpper(httpMethod),
+				path:       path,
+				body:       len(op.RequestBody) > 0,
+			}
+			for _, p := range op.Parameters {
+				if p.In == "query" {
+					m.query = true
+				}
+			}
+			methods = append(methods, m)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go generate; DO NOT EDIT.\n\npackage client\n\n")
+	b.WriteString("import (\n\"context\"\n\"net/http\"\n\"net/url\"\n)\n\n")
+	for _, m := range methods {
+		writeMethod(&b, m)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("format generated code: %v", err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeMethod(b *bytes.Buffer, m method) {
+	// the path is built from its literal segments and the escaped parameters
+	var params, pathExpr []string
+	last := 0
+	for _, loc := range pathParamPattern.FindAllStringSubmatchIndex(m.path, -1) {
+		if loc[0] > last {
+			pathExpr = append(pathExpr, strconv.Quote(m.path[last:loc[0]]))
+		}
+		name := paramName(m.path[loc[2]:loc[3]])
+		params = append(params, name+" string")
+		pathExpr = append(pathExpr, "url.PathEscape("+name+")")
+		last = loc[1]
+	}
+	if rest := m.path[last:]; rest != "" || len(pathExpr) == 0 {
+		pathExpr = append(pathExpr, strconv.Quote(rest))
+	}
+
+	query, body := "nil", "nil"
+	if m.query {
+		params = append(params, "query url.Values")
+		query = "query"
+	}
+	if m.body {
+		params = append(params, "body interface{}")
+		body = "body"
+	}
+	params = append(params, "out interface{}")
+
+	fmt.Fprintf(b, "\n// %s calls %s %s", m.name, m.httpMethod, m.path)
+	if m.summary != "" && !strings.EqualFold(strings.ReplaceAll(m.summary, " ", ""), m.name) {
+		fmt.Fprintf(b, ": %s", m.summary)
+	}
+	fmt.Fprintf(b, "\nfunc (c *Client) %s(ctx context.Context, %s) error {\n", m.name, strings.Join(params, ", "))
+	fmt.Fprintf(b, "return c.Do(ctx, %s, %s, %s, %s, out)\n}\n", httpMethodConst(m.httpMethod), strings.Join(pathExpr, " + "), query, body)
+}
+
+// paramName keeps path parameter names usable as Go identifiers
+func paramName(name string) string {
+	if token.IsKeyword(name) || name == "ctx" || name == "query" || name == "body" || name == "out" || name == "c" {
+		return name + "Param"
+	}
+	return name
+}
+
+func httpMethodConst(m string) string {
+	return "http.Method" + m[:1] + strings.ToLower(m[1:])
+}
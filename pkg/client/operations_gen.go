@@ -0,0 +1,1519 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// AddBlocklist calls POST /api/security/blocklist
+func (c *Client) AddBlocklist(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/security/blocklist", nil, body, out)
+}
+
+// AddTeamMember calls POST /api/teams/{id}/members
+func (c *Client) AddTeamMember(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/teams/"+url.PathEscape(id)+"/members", nil, body, out)
+}
+
+// AlertHistory calls GET /api/alerts/history: The state changes of alert rules over a window (window=7d by default, or from/to in unix seconds) and per rule the time spent firing
+func (c *Client) AlertHistory(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/alerts/history", query, nil, out)
+}
+
+// Analytics calls GET /api/analytics
+func (c *Client) Analytics(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/analytics", query, nil, out)
+}
+
+// AnalyticsQuery calls POST /api/analytics/query: Runs an AnalyticsQuery within the limits of the caller's role
+func (c *Client) AnalyticsQuery(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/analytics/query", nil, body, out)
+}
+
+// AnalyticsQueryCatalog calls GET /api/analytics/query/catalog: The tables, columns, functions and operators of /api/analytics/query, and the caller's limits
+func (c *Client) AnalyticsQueryCatalog(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/analytics/query/catalog", nil, nil, out)
+}
+
+// ApplyConfigTemplate calls POST /api/config-templates/{id}/apply: Body is a BatchConfigUpdateRequest without a config source; the template is rendered per agent
+func (c *Client) ApplyConfigTemplate(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config-templates/"+url.PathEscape(id)+"/apply", nil, body, out)
+}
+
+// ApplyGeoPolicy calls POST /api/geo-policies/{id}/apply: Compiles the policy again, e.g
+func (c *Client) ApplyGeoPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/geo-policies/"+url.PathEscape(id)+"/apply", nil, nil, out)
+}
+
+// ApplyLogRedaction calls POST /api/environments/{id}/log-redaction/apply: Pushes the environment's scrubbing to its online agents, which apply it without a restart and save it to avika-agent.conf, and returns what each then runs with
+func (c *Client) ApplyLogRedaction(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/environments/"+url.PathEscape(id)+"/log-redaction/apply", nil, nil, out)
+}
+
+// ApplyTenancy calls POST /api/tenancy/apply: Converges on the desired projects and teams in one transaction
+func (c *Client) ApplyTenancy(ctx context.Context, query url.Values, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/tenancy/apply", query, body, out)
+}
+
+// ApplyTrafficSplit calls POST /api/traffic-splits/{id}/apply: Writes the split again, e.g
+func (c *Client) ApplyTrafficSplit(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/traffic-splits/"+url.PathEscape(id)+"/apply", nil, nil, out)
+}
+
+// AssignServer calls POST /api/servers/{agentId}/assign
+func (c *Client) AssignServer(ctx context.Context, agentId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(agentId)+"/assign", nil, body, out)
+}
+
+// BatchUpdateConfig calls POST /api/config/batch
+func (c *Client) BatchUpdateConfig(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config/batch", nil, body, out)
+}
+
+// Callback calls GET /api/auth/oidc/callback: OIDC callback
+func (c *Client) Callback(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/oidc/callback", nil, nil, out)
+}
+
+// CancelBatch calls POST /api/config/batch/{id}/cancel
+func (c *Client) CancelBatch(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config/batch/"+url.PathEscape(id)+"/cancel", nil, nil, out)
+}
+
+// CancelBulkAction calls POST /api/bulk-actions/{id}/cancel
+func (c *Client) CancelBulkAction(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/bulk-actions/"+url.PathEscape(id)+"/cancel", nil, nil, out)
+}
+
+// CancelJob calls POST /api/jobs/{id}/cancel
+func (c *Client) CancelJob(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/jobs/"+url.PathEscape(id)+"/cancel", nil, nil, out)
+}
+
+// CancelUpgradeCampaign calls POST /api/fleet/upgrade-campaigns/{id}/cancel
+func (c *Client) CancelUpgradeCampaign(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/fleet/upgrade-campaigns/"+url.PathEscape(id)+"/cancel", nil, nil, out)
+}
+
+// ChangePassword calls POST /api/auth/change-password: Password change requests
+func (c *Client) ChangePassword(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/auth/change-password", nil, body, out)
+}
+
+// CompareDrift calls GET /api/projects/{id}/drift/compare
+func (c *Client) CompareDrift(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/drift/compare", query, nil, out)
+}
+
+// CreateBulkAction calls POST /api/bulk-actions: Starts a reload, restart or agent update over the selected agents and returns 202 with the job to poll
+func (c *Client) CreateBulkAction(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/bulk-actions", nil, body, out)
+}
+
+// CreateConfigSnippet calls POST /api/config-snippets: (superadmin)
+func (c *Client) CreateConfigSnippet(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config-snippets", nil, body, out)
+}
+
+// CreateConfigTemplate calls POST /api/config-templates
+func (c *Client) CreateConfigTemplate(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config-templates", nil, body, out)
+}
+
+// CreateDashboard calls POST /api/dashboards
+func (c *Client) CreateDashboard(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/dashboards", nil, body, out)
+}
+
+// CreateEnrollmentToken calls POST /api/environments/{id}/enrollment-tokens
+func (c *Client) CreateEnrollmentToken(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/environments/"+url.PathEscape(id)+"/enrollment-tokens", nil, body, out)
+}
+
+// CreateEnvironment calls POST /api/projects/{id}/environments
+func (c *Client) CreateEnvironment(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/projects/"+url.PathEscape(id)+"/environments", nil, body, out)
+}
+
+// CreateGeoPolicy calls POST /api/geo-policies: Compiles the policy, records it and writes it to the selected agents, returning the outcome on each
+func (c *Client) CreateGeoPolicy(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/geo-policies", nil, body, out)
+}
+
+// CreateMaintenanceTemplate calls POST /api/maintenance/templates: Creates a new maintenance template
+func (c *Client) CreateMaintenanceTemplate(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/maintenance/templates", nil, body, out)
+}
+
+// CreateProject calls POST /api/projects
+func (c *Client) CreateProject(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/projects", nil, body, out)
+}
+
+// CreateRateLimitRule calls POST /api/security/rate-limits
+func (c *Client) CreateRateLimitRule(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/security/rate-limits", nil, body, out)
+}
+
+// CreateReportJob calls POST /api/reports/jobs: Generates the report in the background and returns 202 with the job; the report is then downloaded from /api/jobs/{id}/artifact
+func (c *Client) CreateReportJob(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/reports/jobs", query, nil, out)
+}
+
+// CreateScheduledTask calls POST /api/scheduled-tasks
+func (c *Client) CreateScheduledTask(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/scheduled-tasks", nil, body, out)
+}
+
+// CreateStatusPage calls POST /api/status-pages
+func (c *Client) CreateStatusPage(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/status-pages", nil, body, out)
+}
+
+// CreateSyntheticCheck calls POST /api/synthetic/checks
+func (c *Client) CreateSyntheticCheck(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/synthetic/checks", nil, body, out)
+}
+
+// CreateTeam calls POST /api/teams
+func (c *Client) CreateTeam(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/teams", nil, body, out)
+}
+
+// CreateTrafficMirror calls POST /api/traffic-mirrors: Records the mirror and enables it on the selected agents, returning the outcome on each
+func (c *Client) CreateTrafficMirror(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/traffic-mirrors", nil, body, out)
+}
+
+// CreateTrafficSplit calls POST /api/traffic-splits: Records the split and writes it to the selected agents, returning the outcome on each
+func (c *Client) CreateTrafficSplit(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/traffic-splits", nil, body, out)
+}
+
+// CreateUpgradeCampaign calls POST /api/fleet/upgrade-campaigns
+func (c *Client) CreateUpgradeCampaign(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/fleet/upgrade-campaigns", nil, body, out)
+}
+
+// CreateWAFPolicy calls POST /api/waf/policies
+func (c *Client) CreateWAFPolicy(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/waf/policies", nil, body, out)
+}
+
+// CreateWebhook calls POST /api/webhooks
+func (c *Client) CreateWebhook(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/webhooks", nil, body, out)
+}
+
+// DebugConfig calls GET /debug/config: The configuration the gateway runs with, after environment overrides and secrets from the secrets provider, with passwords, keys and tokens replaced by ***
+func (c *Client) DebugConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/debug/config", nil, nil, out)
+}
+
+// DebugGoroutines calls GET /debug/goroutines: The stack of every goroutine, as a panic prints them
+func (c *Client) DebugGoroutines(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/debug/goroutines", nil, nil, out)
+}
+
+// DebugQueues calls GET /debug/queues: How many rows wait in each batch write queue of ClickHouse, or of the PostgreSQL analytics store, and how many fit
+func (c *Client) DebugQueues(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/debug/queues", nil, nil, out)
+}
+
+// DebugVars calls GET /debug/vars: The expvar variables, memstats and cmdline among them
+func (c *Client) DebugVars(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/debug/vars", nil, nil, out)
+}
+
+// DeleteAgentRetentionPolicy calls DELETE /api/environments/{id}/retention-policy: Returning the environment to the gateway defaults
+func (c *Client) DeleteAgentRetentionPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/environments/"+url.PathEscape(id)+"/retention-policy", nil, nil, out)
+}
+
+// DeleteBlocklist calls DELETE /api/security/blocklist/{id}
+func (c *Client) DeleteBlocklist(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/security/blocklist/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteCertificate calls DELETE /api/servers/{agentId}/certificates: Proxies DeleteCertificate to the agent
+func (c *Client) DeleteCertificate(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/servers/"+url.PathEscape(agentId)+"/certificates", query, nil, out)
+}
+
+// DeleteConfigSnippet calls DELETE /api/config-snippets/{id}: (superadmin)
+func (c *Client) DeleteConfigSnippet(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/config-snippets/"+url.PathEscape(id), query, nil, out)
+}
+
+// DeleteConfigTemplate calls DELETE /api/config-templates/{id}
+func (c *Client) DeleteConfigTemplate(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/config-templates/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteDashboard calls DELETE /api/dashboards/{id}
+func (c *Client) DeleteDashboard(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/dashboards/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteDashboardPanel calls DELETE /api/dashboards/{id}/panels/{panelId}
+func (c *Client) DeleteDashboardPanel(ctx context.Context, id string, panelId string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/dashboards/"+url.PathEscape(id)+"/panels/"+url.PathEscape(panelId), nil, nil, out)
+}
+
+// DeleteEnrollmentToken calls DELETE /api/enrollment-tokens/{id}
+func (c *Client) DeleteEnrollmentToken(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/enrollment-tokens/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteEnvironment calls DELETE /api/environments/{id}
+func (c *Client) DeleteEnvironment(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/environments/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteEnvironmentAgentConfig calls DELETE /api/environments/{id}/agent-config
+func (c *Client) DeleteEnvironmentAgentConfig(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/environments/"+url.PathEscape(id)+"/agent-config", nil, nil, out)
+}
+
+// DeleteEnvironmentRelease calls DELETE /api/fleet/releases/environments/{id}: The environment's agents go back to the stable channel
+func (c *Client) DeleteEnvironmentRelease(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/fleet/releases/environments/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteGeoPolicy calls DELETE /api/geo-policies/{id}: Lifts the policy on the agents it was written to, leaving its files as comments so the includes stay valid, then deletes it
+func (c *Client) DeleteGeoPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/geo-policies/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteMaintenanceTemplate calls DELETE /api/maintenance/templates: Deletes a maintenance template
+func (c *Client) DeleteMaintenanceTemplate(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/maintenance/templates", query, nil, out)
+}
+
+// DeleteProject calls DELETE /api/projects/{id}
+func (c *Client) DeleteProject(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/projects/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteProjectAlertPack calls DELETE /api/projects/{id}/alerts/packs/{pack}: Deletes the rules the project has from the pack
+func (c *Client) DeleteProjectAlertPack(ctx context.Context, id string, pack string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/projects/"+url.PathEscape(id)+"/alerts/packs/"+url.PathEscape(pack), nil, nil, out)
+}
+
+// DeleteRateLimitRule calls DELETE /api/security/rate-limits/{id}
+func (c *Client) DeleteRateLimitRule(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/security/rate-limits/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteSLOTarget calls DELETE /api/slo-targets
+func (c *Client) DeleteSLOTarget(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/slo-targets", query, nil, out)
+}
+
+// DeleteScheduledTask calls DELETE /api/scheduled-tasks/{id}
+func (c *Client) DeleteScheduledTask(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/scheduled-tasks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteStatusPage calls DELETE /api/status-pages/{id}
+func (c *Client) DeleteStatusPage(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/status-pages/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteSyntheticCheck calls DELETE /api/synthetic/checks/{id}
+func (c *Client) DeleteSyntheticCheck(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/synthetic/checks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteTeam calls DELETE /api/teams/{id}
+func (c *Client) DeleteTeam(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/teams/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteTenancyProjectsBySlug calls DELETE /api/tenancy/projects/{slug}: DELETE on the project, environment, team, member and access paths
+func (c *Client) DeleteTenancyProjectsBySlug(ctx context.Context, slug string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/tenancy/projects/"+url.PathEscape(slug), nil, nil, out)
+}
+
+// DeleteTenancyProjectsBySlugEnvironmentsByEnv calls DELETE /api/tenancy/projects/{slug}/environments/{env}: DELETE on the project, environment, team, member and access paths
+func (c *Client) DeleteTenancyProjectsBySlugEnvironmentsByEnv(ctx context.Context, slug string, env string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/tenancy/projects/"+url.PathEscape(slug)+"/environments/"+url.PathEscape(env), nil, nil, out)
+}
+
+// DeleteTenancyTeamsBySlug calls DELETE /api/tenancy/teams/{slug}: DELETE on the project, environment, team, member and access paths
+func (c *Client) DeleteTenancyTeamsBySlug(ctx context.Context, slug string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/tenancy/teams/"+url.PathEscape(slug), nil, nil, out)
+}
+
+// DeleteTenancyTeamsBySlugAccessByProject calls DELETE /api/tenancy/teams/{slug}/access/{project}: DELETE on the project, environment, team, member and access paths
+func (c *Client) DeleteTenancyTeamsBySlugAccessByProject(ctx context.Context, slug string, project string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/tenancy/teams/"+url.PathEscape(slug)+"/access/"+url.PathEscape(project), nil, nil, out)
+}
+
+// DeleteTenancyTeamsBySlugMembersByUsername calls DELETE /api/tenancy/teams/{slug}/members/{username}: DELETE on the project, environment, team, member and access paths
+func (c *Client) DeleteTenancyTeamsBySlugMembersByUsername(ctx context.Context, slug string, username string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/tenancy/teams/"+url.PathEscape(slug)+"/members/"+url.PathEscape(username), nil, nil, out)
+}
+
+// DeleteTrafficMirror calls DELETE /api/traffic-mirrors/{id}: Stops the mirror on the agents it was written to, leaving its files as comments so the includes stay valid, then deletes it
+func (c *Client) DeleteTrafficMirror(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/traffic-mirrors/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteTrafficSplit calls DELETE /api/traffic-splits/{id}
+func (c *Client) DeleteTrafficSplit(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/traffic-splits/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeleteWebhook calls DELETE /api/webhooks/{id}
+func (c *Client) DeleteWebhook(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/webhooks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// DeployFleetCertificate calls POST /api/certificates/{id}/deploy: Deploys an inventory certificate to agents, a group or an environment
+func (c *Client) DeployFleetCertificate(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/certificates/"+url.PathEscape(id)+"/deploy", nil, body, out)
+}
+
+// DisableTrafficMirror calls POST /api/traffic-mirrors/{id}/disable: Stops shadowing on the agents of the mirror's selection and on those it was enabled on that have since left it
+func (c *Client) DisableTrafficMirror(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/traffic-mirrors/"+url.PathEscape(id)+"/disable", nil, nil, out)
+}
+
+// DiscardStagedConfig calls DELETE /api/staging/config
+func (c *Client) DiscardStagedConfig(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/staging/config", query, nil, out)
+}
+
+// EnableTrafficMirror calls POST /api/traffic-mirrors/{id}/enable: Starts shadowing on the agents of the mirror's selection
+func (c *Client) EnableTrafficMirror(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/traffic-mirrors/"+url.PathEscape(id)+"/enable", nil, nil, out)
+}
+
+// EventStream calls GET /api/events/stream: A WebSocket receiving each new event matching the feed filters as a JSON message
+func (c *Client) EventStream(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/events/stream", query, nil, out)
+}
+
+// Export calls GET /api/export/{dataset}: Dataset is one of exportDatasets, or "all" for a multi-sheet workbook (xlsx only)
+func (c *Client) Export(ctx context.Context, dataset string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/export/"+url.PathEscape(dataset), query, nil, out)
+}
+
+// ExportReport calls GET /export-report: PDF report export requests
+func (c *Client) ExportReport(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/export-report", nil, nil, out)
+}
+
+// ExportTenancy calls GET /api/tenancy: Every project and team in the shape apply takes
+func (c *Client) ExportTenancy(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/tenancy", nil, nil, out)
+}
+
+// FleetVersions calls GET /api/fleet/versions: The agents the caller can see grouped by agent and nginx version
+func (c *Client) FleetVersions(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/versions", nil, nil, out)
+}
+
+// GeoData calls GET /api/geo: The /api/geo endpoint for geo analytics
+func (c *Client) GeoData(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/geo", query, nil, out)
+}
+
+// GeoPolicyStats calls GET /api/geo-policies/{id}/stats: (or from/to in unix seconds): the requests the policy blocked by country and by agent
+func (c *Client) GeoPolicyStats(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/geo-policies/"+url.PathEscape(id)+"/stats", query, nil, out)
+}
+
+// GetAgentConfig calls GET /api/agent-config
+func (c *Client) GetAgentConfig(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agent-config", query, nil, out)
+}
+
+// GetAgentReleases calls GET /api/fleet/releases
+func (c *Client) GetAgentReleases(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/releases", nil, nil, out)
+}
+
+// GetAgentRetentionPolicy calls GET /api/environments/{id}/retention-policy
+func (c *Client) GetAgentRetentionPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/environments/"+url.PathEscape(id)+"/retention-policy", nil, nil, out)
+}
+
+// GetAgentRuntimeConfig calls GET /api/agents/{id}/config
+func (c *Client) GetAgentRuntimeConfig(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agents/"+url.PathEscape(id)+"/config", nil, nil, out)
+}
+
+// GetAgentRuntimeSettings calls GET /api/servers/{agentId}/runtime-settings: The settings the running agent reports
+func (c *Client) GetAgentRuntimeSettings(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/runtime-settings", nil, nil, out)
+}
+
+// GetAuthSsoConfig calls GET /api/auth/sso-config
+func (c *Client) GetAuthSsoConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/sso-config", nil, nil, out)
+}
+
+// GetBatchStatus calls GET /api/config/batch/{id}
+func (c *Client) GetBatchStatus(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config/batch/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetBulkAction calls GET /api/bulk-actions/{id}: The status and progress of the job with the result of each agent
+func (c *Client) GetBulkAction(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/bulk-actions/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetCertificateInventory calls GET /api/certificates/inventory: Lists certificates stored in the gateway inventory
+func (c *Client) GetCertificateInventory(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/certificates/inventory", query, nil, out)
+}
+
+// GetConfigSnippet calls GET /api/config-snippets/{id}: The snippet with the version each agent has
+func (c *Client) GetConfigSnippet(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-snippets/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetConfigTemplate calls GET /api/config-templates/{id}
+func (c *Client) GetConfigTemplate(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-templates/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetDashboard calls GET /api/dashboards/{id}
+func (c *Client) GetDashboard(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/dashboards/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetDashboardPanelData calls GET /api/dashboards/{id}/panels/{panelId}/data: Runs the saved panel query
+func (c *Client) GetDashboardPanelData(ctx context.Context, id string, panelId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/dashboards/"+url.PathEscape(id)+"/panels/"+url.PathEscape(panelId)+"/data", query, nil, out)
+}
+
+// GetEnvironmentAgentConfig calls GET /api/environments/{id}/agent-config: The environment's own bundle and the configuration its agents receive, PSK masked
+func (c *Client) GetEnvironmentAgentConfig(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/environments/"+url.PathEscape(id)+"/agent-config", nil, nil, out)
+}
+
+// GetErrorAnalysis calls GET /api/v1/errors/analysis
+func (c *Client) GetErrorAnalysis(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/v1/errors/analysis", query, nil, out)
+}
+
+// GetErrorPatterns calls GET /api/v1/errors/patterns
+func (c *Client) GetErrorPatterns(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/v1/errors/patterns", query, nil, out)
+}
+
+// GetErrorTrend calls GET /api/v1/errors/trends
+func (c *Client) GetErrorTrend(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/v1/errors/trends", query, nil, out)
+}
+
+// GetGenericMetricSeries calls GET /api/generic-metrics/series: Handles
+func (c *Client) GetGenericMetricSeries(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/generic-metrics/series", query, nil, out)
+}
+
+// GetGeoPolicy calls GET /api/geo-policies/{id}: The policy with the outcome on each agent
+func (c *Client) GetGeoPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/geo-policies/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetGlobalAgentConfig calls GET /api/agent-config/global
+func (c *Client) GetGlobalAgentConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agent-config/global", nil, nil, out)
+}
+
+// GetHealth calls GET /health
+func (c *Client) GetHealth(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/health", nil, nil, out)
+}
+
+// GetIntegration calls GET /api/integrations/{type}
+func (c *Client) GetIntegration(ctx context.Context, typeParam string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/integrations/"+url.PathEscape(typeParam), nil, nil, out)
+}
+
+// GetJob calls GET /api/jobs/{id}: The status, step and progress of a job with the last 500 lines of its log
+func (c *Client) GetJob(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/jobs/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetJobArtifact calls GET /api/jobs/{id}/artifact: Downloads the file a job produced
+func (c *Client) GetJobArtifact(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/jobs/"+url.PathEscape(id)+"/artifact", nil, nil, out)
+}
+
+// GetLLMConfig calls GET /api/llm/config
+func (c *Client) GetLLMConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/llm/config", nil, nil, out)
+}
+
+// GetLogLevels calls GET /api/ops/log-levels: The default log level and the level of every component
+func (c *Client) GetLogLevels(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/ops/log-levels", nil, nil, out)
+}
+
+// GetLogRedaction calls GET /api/environments/{id}/log-redaction: The access log scrubbing the environment's agent config bundles select, whether the gateway also enforces it on ingest, and the settings each agent reports it runs with
+func (c *Client) GetLogRedaction(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/environments/"+url.PathEscape(id)+"/log-redaction", nil, nil, out)
+}
+
+// GetMaintenanceStatus calls GET /api/maintenance/status: Returns the current maintenance status for a scope
+func (c *Client) GetMaintenanceStatus(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/maintenance/status", query, nil, out)
+}
+
+// GetMetricCatalog calls GET /api/metrics/catalog: Lists the metrics, aggregations, dimensions, windows and intervals accepted by /api/metrics/query
+func (c *Client) GetMetricCatalog(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/metrics/catalog", nil, nil, out)
+}
+
+// GetMigrations calls GET /api/ops/migrations: The schema migrations of PostgreSQL and ClickHouse, applied and pending, to check an upgrade went through on every database
+func (c *Client) GetMigrations(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/ops/migrations", nil, nil, out)
+}
+
+// GetNginxCVEs calls GET /api/cve/nginx/{version}
+func (c *Client) GetNginxCVEs(ctx context.Context, version string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/cve/nginx/"+url.PathEscape(version), nil, nil, out)
+}
+
+// GetProject calls GET /api/projects/{id}
+func (c *Client) GetProject(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetProjectQuota calls GET /api/projects/{id}/quota: The daily ingest quota of a project, with zero limits when it has none
+func (c *Client) GetProjectQuota(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/quota", nil, nil, out)
+}
+
+// GetProjectUsage calls GET /api/projects/{id}/usage: The access log bytes a project stored and had dropped on each of the last days (default 30), and today's usage against its quota
+func (c *Client) GetProjectUsage(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/usage", nil, nil, out)
+}
+
+// GetRateLimitStatus calls GET /api/security/rate-limits/status
+func (c *Client) GetRateLimitStatus(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/rate-limits/status", nil, nil, out)
+}
+
+// GetReady calls GET /ready
+func (c *Client) GetReady(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/ready", nil, nil, out)
+}
+
+// GetRecommendations calls GET /api/v1/recommendations
+func (c *Client) GetRecommendations(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/v1/recommendations", query, nil, out)
+}
+
+// GetRpcByMethod calls GET /api/rpc/{method}: Calls an AgentService RPC with the request message as the JSON body (POST) or as query parameters (GET, read-only RPCs only), and writes the response message as JSON with the proto field names
+func (c *Client) GetRpcByMethod(ctx context.Context, method string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/rpc/"+url.PathEscape(method), nil, nil, out)
+}
+
+// GetSLOCompliance calls GET /api/slo-compliance
+func (c *Client) GetSLOCompliance(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/slo-compliance", nil, nil, out)
+}
+
+// GetSLOTargets calls GET /api/slo-targets
+func (c *Client) GetSLOTargets(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/slo-targets", nil, nil, out)
+}
+
+// GetScheduledTask calls GET /api/scheduled-tasks/{id}
+func (c *Client) GetScheduledTask(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/scheduled-tasks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetSecurityEvents calls GET /api/security/events
+func (c *Client) GetSecurityEvents(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/events", query, nil, out)
+}
+
+// GetServerAvailability calls GET /api/servers/{agentId}/availability: Uptime and the transitions within the window
+func (c *Client) GetServerAvailability(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/availability", nil, nil, out)
+}
+
+// GetServerDrift calls GET /api/servers/{agentId}/drift
+func (c *Client) GetServerDrift(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/drift", nil, nil, out)
+}
+
+// GetServerTLSPosture calls GET /api/servers/{agentId}/tls-posture
+func (c *Client) GetServerTLSPosture(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/tls-posture", query, nil, out)
+}
+
+// GetServiceStatus calls GET /api/servers/{agentId}/service: Reports the systemd/OpenRC state of the NGINX unit, its restart and failure history and the journal around its last failure
+func (c *Client) GetServiceStatus(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/service", query, nil, out)
+}
+
+// GetSettings calls GET /api/settings
+func (c *Client) GetSettings(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/settings", nil, nil, out)
+}
+
+// GetStagedConfig calls GET /api/staging/config
+func (c *Client) GetStagedConfig(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/staging/config", query, nil, out)
+}
+
+// GetSyntheticCheck calls GET /api/synthetic/checks/{id}
+func (c *Client) GetSyntheticCheck(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/synthetic/checks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetSyntheticResults calls GET /api/synthetic/checks/{id}/results
+func (c *Client) GetSyntheticResults(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/synthetic/checks/"+url.PathEscape(id)+"/results", query, nil, out)
+}
+
+// GetSyntheticStats calls GET /api/synthetic/checks/{id}/stats: Uptime and latency over the window plus a bucketed series for charts
+func (c *Client) GetSyntheticStats(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/synthetic/checks/"+url.PathEscape(id)+"/stats", nil, nil, out)
+}
+
+// GetTeam calls GET /api/teams/{id}
+func (c *Client) GetTeam(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/teams/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetTerminalRecording calls GET /api/terminal-sessions/{id}/cast: Returns the raw asciicast v2 document, playable with `asciinema play`
+func (c *Client) GetTerminalRecording(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/terminal-sessions/"+url.PathEscape(id)+"/cast", nil, nil, out)
+}
+
+// GetThreats calls GET /api/security/threats
+func (c *Client) GetThreats(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/threats", nil, nil, out)
+}
+
+// GetTrafficMirror calls GET /api/traffic-mirrors/{id}: The mirror with the outcome on each agent
+func (c *Client) GetTrafficMirror(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-mirrors/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetTrafficSplit calls GET /api/traffic-splits/{id}: The split with the weights each agent runs with
+func (c *Client) GetTrafficSplit(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-splits/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetUpgradeCampaign calls GET /api/fleet/upgrade-campaigns/{id}: The campaign with the result of each agent
+func (c *Client) GetUpgradeCampaign(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/upgrade-campaigns/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetV1AdminLlmConfig calls GET /api/v1/admin/llm/config
+func (c *Client) GetV1AdminLlmConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/v1/admin/llm/config", nil, nil, out)
+}
+
+// GetWAFPolicy calls GET /api/waf/policies/{id}
+func (c *Client) GetWAFPolicy(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/waf/policies/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GetWebhook calls GET /api/webhooks/{id}
+func (c *Client) GetWebhook(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/webhooks/"+url.PathEscape(id), nil, nil, out)
+}
+
+// GrantProjectAccess calls POST /api/teams/{id}/projects
+func (c *Client) GrantProjectAccess(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/teams/"+url.PathEscape(id)+"/projects", nil, body, out)
+}
+
+// GroupLogsStream calls GET /api/groups/{id}/logs/stream: Streams merged logs from all agents in a group as SSE (Radar-style)
+func (c *Client) GroupLogsStream(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/groups/"+url.PathEscape(id)+"/logs/stream", nil, nil, out)
+}
+
+// GroupRealtimeStats calls GET /api/groups/{id}/realtime-stats: Returns sliding-window real-time stats for a group (merged from all agents)
+func (c *Client) GroupRealtimeStats(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/groups/"+url.PathEscape(id)+"/realtime-stats", query, nil, out)
+}
+
+// Init calls GET /api/auth/oidc/login: Initiating OIDC login
+func (c *Client) Init(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/oidc/login", query, nil, out)
+}
+
+// LatencyHeatmap calls GET /api/analytics/latency-heatmap: (or from/to in unix seconds) with optional agent_id (comma separated), project_id or environment_id, and interval ("1m", "5m", "15m", "1h", "1d" or "auto")
+func (c *Client) LatencyHeatmap(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/analytics/latency-heatmap", query, nil, out)
+}
+
+// LiftBan calls DELETE /api/security/bans/{id}: The ban ends now and its deny rule is removed
+func (c *Client) LiftBan(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/security/bans/"+url.PathEscape(id), nil, nil, out)
+}
+
+// ListAgentConfigBackups calls GET /api/agents/{id}/config/backups: List agent config backups (last 5)
+func (c *Client) ListAgentConfigBackups(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agents/"+url.PathEscape(id)+"/config/backups", nil, nil, out)
+}
+
+// ListAgents calls GET /api/servers: Query parameters mirror ListAgentsRequest
+func (c *Client) ListAgents(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers", query, nil, out)
+}
+
+// ListAlertPacks calls GET /api/alerts/packs: The built-in rule packs and their templates
+func (c *Client) ListAlertPacks(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/alerts/packs", nil, nil, out)
+}
+
+// ListAuditLogs calls GET /api/audit
+func (c *Client) ListAuditLogs(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/audit", query, nil, out)
+}
+
+// ListAvailability calls GET /api/availability: Connectivity uptime of every visible agent
+func (c *Client) ListAvailability(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/availability", nil, nil, out)
+}
+
+// ListBans calls GET /api/security/bans
+func (c *Client) ListBans(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/bans", query, nil, out)
+}
+
+// ListBlocklist calls GET /api/security/blocklist
+func (c *Client) ListBlocklist(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/blocklist", query, nil, out)
+}
+
+// ListBulkActions calls GET /api/bulk-actions: The latest bulk actions with their progress; superadmins see everyone's, other users their own
+func (c *Client) ListBulkActions(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/bulk-actions", nil, nil, out)
+}
+
+// ListCertificates calls GET /api/servers/{agentId}/certificates: Proxies ListCertificates to the agent
+func (c *Client) ListCertificates(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/certificates", nil, nil, out)
+}
+
+// ListConfigSnippetVersions calls GET /api/config-snippets/{id}/versions: Newest first
+func (c *Client) ListConfigSnippetVersions(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-snippets/"+url.PathEscape(id)+"/versions", nil, nil, out)
+}
+
+// ListConfigSnippets calls GET /api/config-snippets: The snippets with how many agents have them and how many of those are behind
+func (c *Client) ListConfigSnippets(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-snippets", query, nil, out)
+}
+
+// ListConfigTemplateVersions calls GET /api/config-templates/{id}/versions
+func (c *Client) ListConfigTemplateVersions(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-templates/"+url.PathEscape(id)+"/versions", nil, nil, out)
+}
+
+// ListConfigTemplates calls GET /api/config-templates
+func (c *Client) ListConfigTemplates(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/config-templates", query, nil, out)
+}
+
+// ListDashboards calls GET /api/dashboards
+func (c *Client) ListDashboards(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/dashboards", nil, nil, out)
+}
+
+// ListEnrollmentTokens calls GET /api/environments/{id}/enrollment-tokens
+func (c *Client) ListEnrollmentTokens(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/environments/"+url.PathEscape(id)+"/enrollment-tokens", nil, nil, out)
+}
+
+// ListEnvironments calls GET /api/projects/{id}/environments
+func (c *Client) ListEnvironments(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/environments", nil, nil, out)
+}
+
+// ListEvents calls GET /api/events: The activity feed, newest first
+func (c *Client) ListEvents(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/events", query, nil, out)
+}
+
+// ListFleetCertificates calls GET /api/certificates: Fleet-wide certificate inventory sorted by expiry (soonest first)
+func (c *Client) ListFleetCertificates(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/certificates", query, nil, out)
+}
+
+// ListGenericMetrics calls GET /api/generic-metrics: The metrics agents' custom collectors reported within the window
+func (c *Client) ListGenericMetrics(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/generic-metrics", nil, nil, out)
+}
+
+// ListGeoPolicies calls GET /api/geo-policies
+func (c *Client) ListGeoPolicies(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/geo-policies", nil, nil, out)
+}
+
+// ListIntegrations calls GET /api/integrations
+func (c *Client) ListIntegrations(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/integrations", nil, nil, out)
+}
+
+// ListJobs calls GET /api/jobs: The latest jobs with their progress
+func (c *Client) ListJobs(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/jobs", query, nil, out)
+}
+
+// ListLogArchives calls GET /api/archive/logs
+func (c *Client) ListLogArchives(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/archive/logs", nil, nil, out)
+}
+
+// ListMaintenanceStates calls GET /api/maintenance/states: Returns all active maintenance states
+func (c *Client) ListMaintenanceStates(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/maintenance/states", nil, nil, out)
+}
+
+// ListMaintenanceTemplates calls GET /api/maintenance/templates: Returns all maintenance templates for a project
+func (c *Client) ListMaintenanceTemplates(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/maintenance/templates", query, nil, out)
+}
+
+// ListNginxConfigBackups calls GET /api/agents/{id}/nginx/backups
+func (c *Client) ListNginxConfigBackups(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agents/"+url.PathEscape(id)+"/nginx/backups", query, nil, out)
+}
+
+// ListProjectAlertPacks calls GET /api/projects/{id}/alerts/packs: Every rule pack with the rules the project has from it
+func (c *Client) ListProjectAlertPacks(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/alerts/packs", nil, nil, out)
+}
+
+// ListProjectEvents calls GET /api/projects/{id}/events: The timeline of a project's agents
+func (c *Client) ListProjectEvents(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/events", query, nil, out)
+}
+
+// ListProjectGroups calls GET /api/projects/{id}/groups: (all groups in project, for drift compare etc.)
+func (c *Client) ListProjectGroups(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id)+"/groups", nil, nil, out)
+}
+
+// ListProjectUsage calls GET /api/usage/projects: Every project's usage today against its quota and over the last days (default 30), heaviest today first, to find noisy tenants
+func (c *Client) ListProjectUsage(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/usage/projects", nil, nil, out)
+}
+
+// ListProjects calls GET /api/projects
+func (c *Client) ListProjects(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/projects", nil, nil, out)
+}
+
+// ListRPCs calls GET /api/rpc: The AgentService RPCs that can be called over HTTP
+func (c *Client) ListRPCs(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/rpc", nil, nil, out)
+}
+
+// ListRateLimitRules calls GET /api/security/rate-limits
+func (c *Client) ListRateLimitRules(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/rate-limits", nil, nil, out)
+}
+
+// ListScheduledTaskRuns calls GET /api/scheduled-tasks/{id}/runs
+func (c *Client) ListScheduledTaskRuns(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/scheduled-tasks/"+url.PathEscape(id)+"/runs", query, nil, out)
+}
+
+// ListScheduledTasks calls GET /api/scheduled-tasks
+func (c *Client) ListScheduledTasks(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/scheduled-tasks", query, nil, out)
+}
+
+// ListServerAssignments calls GET /api/server-assignments
+func (c *Client) ListServerAssignments(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/server-assignments", nil, nil, out)
+}
+
+// ListServerEvents calls GET /api/servers/{agentId}/events: The timeline of one agent
+func (c *Client) ListServerEvents(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/events", query, nil, out)
+}
+
+// ListStatusPages calls GET /api/status-pages
+func (c *Client) ListStatusPages(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/status-pages", nil, nil, out)
+}
+
+// ListSyntheticChecks calls GET /api/synthetic/checks
+func (c *Client) ListSyntheticChecks(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/synthetic/checks", nil, nil, out)
+}
+
+// ListTLSPosture calls GET /api/tls-posture: The latest grade of every scanned virtual host
+func (c *Client) ListTLSPosture(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/tls-posture", nil, nil, out)
+}
+
+// ListTableRetention calls GET /api/retention/tables: Retention and disk usage per table
+func (c *Client) ListTableRetention(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/retention/tables", nil, nil, out)
+}
+
+// ListTeamMembers calls GET /api/teams/{id}/members
+func (c *Client) ListTeamMembers(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/teams/"+url.PathEscape(id)+"/members", nil, nil, out)
+}
+
+// ListTeamProjects calls GET /api/teams/{id}/projects
+func (c *Client) ListTeamProjects(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/teams/"+url.PathEscape(id)+"/projects", nil, nil, out)
+}
+
+// ListTeams calls GET /api/teams
+func (c *Client) ListTeams(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/teams", nil, nil, out)
+}
+
+// ListTerminalSessions calls GET /api/servers/{agentId}/terminal-sessions
+func (c *Client) ListTerminalSessions(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/terminal-sessions", query, nil, out)
+}
+
+// ListTrafficMirrors calls GET /api/traffic-mirrors
+func (c *Client) ListTrafficMirrors(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-mirrors", nil, nil, out)
+}
+
+// ListTrafficSplits calls GET /api/traffic-splits
+func (c *Client) ListTrafficSplits(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-splits", nil, nil, out)
+}
+
+// ListUnassignedServers calls GET /api/servers/unassigned
+func (c *Client) ListUnassignedServers(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/unassigned", nil, nil, out)
+}
+
+// ListUpgradeCampaigns calls GET /api/fleet/upgrade-campaigns
+func (c *Client) ListUpgradeCampaigns(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/upgrade-campaigns", nil, nil, out)
+}
+
+// ListUpstreams calls GET /api/servers/{agentId}/upstreams: Lists upstream pools with their servers (and runtime state when the Plus API is available)
+func (c *Client) ListUpstreams(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/upstreams", nil, nil, out)
+}
+
+// ListWAFPolicies calls GET /api/waf/policies
+func (c *Client) ListWAFPolicies(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/waf/policies", nil, nil, out)
+}
+
+// ListWebhookDeliveries calls GET /api/webhooks/{id}/deliveries
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/webhooks/"+url.PathEscape(id)+"/deliveries", query, nil, out)
+}
+
+// ListWebhooks calls GET /api/webhooks: The endpoints without their secrets and the events they can subscribe to
+func (c *Client) ListWebhooks(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/webhooks", nil, nil, out)
+}
+
+// Logout calls GET /api/auth/logout: Logout requests
+func (c *Client) Logout(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/logout", nil, nil, out)
+}
+
+// Me calls GET /api/auth/me: Returns current user info
+func (c *Client) Me(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/me", nil, nil, out)
+}
+
+// MetricQuery calls POST /api/metrics/query
+func (c *Client) MetricQuery(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/metrics/query", nil, body, out)
+}
+
+// Metrics calls GET /metrics: Exposes Prometheus-format metrics
+func (c *Client) Metrics(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/metrics", nil, nil, out)
+}
+
+// NginxInventory calls GET /api/fleet/nginx-modules: The nginx modules and OpenSSL versions of the agents the caller can see
+func (c *Client) NginxInventory(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/nginx-modules", query, nil, out)
+}
+
+// OpenAPI calls GET /api/openapi.json
+func (c *Client) OpenAPI(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, out)
+}
+
+// PostAuthLdapLogin calls POST /api/auth/ldap/login: Login requests
+func (c *Client) PostAuthLdapLogin(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/auth/ldap/login", nil, body, out)
+}
+
+// PostAuthLogin calls POST /api/auth/login: Login requests
+func (c *Client) PostAuthLogin(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/auth/login", nil, body, out)
+}
+
+// PostDashboardsByIdPanels calls POST /api/dashboards/{id}/panels: And PUT /api/dashboards/{id}/panels/{panelId}
+func (c *Client) PostDashboardsByIdPanels(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/dashboards/"+url.PathEscape(id)+"/panels", nil, body, out)
+}
+
+// PostRpcByMethod calls POST /api/rpc/{method}: Calls an AgentService RPC with the request message as the JSON body (POST) or as query parameters (GET, read-only RPCs only), and writes the response message as JSON with the proto field names
+func (c *Client) PostRpcByMethod(ctx context.Context, method string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/rpc/"+url.PathEscape(method), nil, body, out)
+}
+
+// PostSettings calls POST /api/settings
+func (c *Client) PostSettings(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/settings", nil, body, out)
+}
+
+// PostV1Logs calls POST /v1/logs: Serves OTLP/HTTP (POST /v1/logs, /v1/metrics, /v1/traces) in its binary protobuf and JSON encodings; the response uses the request's encoding
+func (c *Client) PostV1Logs(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/v1/logs", nil, body, out)
+}
+
+// PostV1Metrics calls POST /v1/metrics: Serves OTLP/HTTP (POST /v1/logs, /v1/metrics, /v1/traces) in its binary protobuf and JSON encodings; the response uses the request's encoding
+func (c *Client) PostV1Metrics(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/v1/metrics", nil, body, out)
+}
+
+// PostV1Traces calls POST /v1/traces: Serves OTLP/HTTP (POST /v1/logs, /v1/metrics, /v1/traces) in its binary protobuf and JSON encodings; the response uses the request's encoding
+func (c *Client) PostV1Traces(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/v1/traces", nil, body, out)
+}
+
+// PreviewStatusPage calls GET /api/status-pages/{id}/view: For signed-in project members
+func (c *Client) PreviewStatusPage(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/status-pages/"+url.PathEscape(id)+"/view", nil, nil, out)
+}
+
+// PrunePreview calls GET /api/agents/prune-preview: The visible agents the next pruning run deregisters, plus those due within the look-ahead
+func (c *Client) PrunePreview(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/agents/prune-preview", query, nil, out)
+}
+
+// PublicStatusPage calls GET /api/public/status/{slug}
+func (c *Client) PublicStatusPage(ctx context.Context, slug string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/public/status/"+url.PathEscape(slug), query, nil, out)
+}
+
+// PurgeAnalyticsCache calls POST /api/analytics/cache/purge: The next request of every window queries ClickHouse again
+func (c *Client) PurgeAnalyticsCache(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/analytics/cache/purge", nil, nil, out)
+}
+
+// PushBlocklist calls POST /api/security/blocklist/push
+func (c *Client) PushBlocklist(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/security/blocklist/push", nil, body, out)
+}
+
+// PushRateLimits calls POST /api/security/rate-limits/push
+func (c *Client) PushRateLimits(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/security/rate-limits/push", nil, body, out)
+}
+
+// PutDashboardsByIdPanelsByPanelId calls PUT /api/dashboards/{id}/panels/{panelId}: And PUT /api/dashboards/{id}/panels/{panelId}
+func (c *Client) PutDashboardsByIdPanelsByPanelId(ctx context.Context, id string, panelId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/dashboards/"+url.PathEscape(id)+"/panels/"+url.PathEscape(panelId), nil, body, out)
+}
+
+// PutEnvironmentAgentConfig calls PUT /api/environments/{id}/agent-config
+func (c *Client) PutEnvironmentAgentConfig(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/environments/"+url.PathEscape(id)+"/agent-config", nil, nil, out)
+}
+
+// PutGlobalAgentConfig calls PUT /api/agent-config/global
+func (c *Client) PutGlobalAgentConfig(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/agent-config/global", nil, nil, out)
+}
+
+// PutIntegration calls PUT /api/integrations/{type}
+func (c *Client) PutIntegration(ctx context.Context, typeParam string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/integrations/"+url.PathEscape(typeParam), nil, body, out)
+}
+
+// PutLLMConfig calls PUT /api/llm/config
+func (c *Client) PutLLMConfig(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/llm/config", nil, body, out)
+}
+
+// PutLogLevels calls PUT /api/ops/log-levels: Changes the log level of a component, or the default level, on this gateway until it restarts
+func (c *Client) PutLogLevels(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/ops/log-levels", nil, body, out)
+}
+
+// PutProjectAlertPack calls PUT /api/projects/{id}/alerts/packs/{pack}: Creates the rules of the pack for the project, or updates them with new variables and recipients
+func (c *Client) PutProjectAlertPack(ctx context.Context, id string, pack string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/projects/"+url.PathEscape(id)+"/alerts/packs/"+url.PathEscape(pack), nil, body, out)
+}
+
+// PutProjectQuota calls PUT /api/projects/{id}/quota: Sets the bytes of access logs the project may store per UTC day
+func (c *Client) PutProjectQuota(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/projects/"+url.PathEscape(id)+"/quota", nil, body, out)
+}
+
+// PutTenancyAccess calls PUT /api/tenancy/teams/{slug}/access/{project}
+func (c *Client) PutTenancyAccess(ctx context.Context, slug string, project string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/tenancy/teams/"+url.PathEscape(slug)+"/access/"+url.PathEscape(project), nil, body, out)
+}
+
+// PutTenancyEnvironment calls PUT /api/tenancy/projects/{slug}/environments/{env}
+func (c *Client) PutTenancyEnvironment(ctx context.Context, slug string, env string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/tenancy/projects/"+url.PathEscape(slug)+"/environments/"+url.PathEscape(env), nil, body, out)
+}
+
+// PutTenancyMember calls PUT /api/tenancy/teams/{slug}/members/{username}
+func (c *Client) PutTenancyMember(ctx context.Context, slug string, username string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/tenancy/teams/"+url.PathEscape(slug)+"/members/"+url.PathEscape(username), nil, body, out)
+}
+
+// PutTenancyProject calls PUT /api/tenancy/projects/{slug}
+func (c *Client) PutTenancyProject(ctx context.Context, slug string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/tenancy/projects/"+url.PathEscape(slug), nil, body, out)
+}
+
+// PutTenancyTeam calls PUT /api/tenancy/teams/{slug}
+func (c *Client) PutTenancyTeam(ctx context.Context, slug string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/tenancy/teams/"+url.PathEscape(slug), nil, body, out)
+}
+
+// QueryLogArchive calls GET /api/archive/logs/{day}: Reading the day's logs straight from object storage
+func (c *Client) QueryLogArchive(ctx context.Context, day string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/archive/logs/"+url.PathEscape(day), query, nil, out)
+}
+
+// RedeliverWebhook calls POST /api/webhooks/{id}/deliveries/{deliveryId}/redeliver
+func (c *Client) RedeliverWebhook(ctx context.Context, id string, deliveryId string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/webhooks/"+url.PathEscape(id)+"/deliveries/"+url.PathEscape(deliveryId)+"/redeliver", nil, nil, out)
+}
+
+// ReleaseLogArchive calls DELETE /api/archive/logs/{day}/restore: Removes a restored day from access_logs_restored; the archive itself is kept
+func (c *Client) ReleaseLogArchive(ctx context.Context, day string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/archive/logs/"+url.PathEscape(day)+"/restore", nil, nil, out)
+}
+
+// RemoveTeamMember calls DELETE /api/teams/{id}/members/{username}
+func (c *Client) RemoveTeamMember(ctx context.Context, id string, username string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/teams/"+url.PathEscape(id)+"/members/"+url.PathEscape(username), nil, nil, out)
+}
+
+// RenderConfigTemplate calls POST /api/config-templates/{id}/render
+func (c *Client) RenderConfigTemplate(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config-templates/"+url.PathEscape(id)+"/render", nil, body, out)
+}
+
+// ResolveAgentRelease calls GET /api/fleet/releases/resolve: The version the gateway serves the agent and why
+func (c *Client) ResolveAgentRelease(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/fleet/releases/resolve", query, nil, out)
+}
+
+// RestoreAgentConfigBackup calls POST /api/agents/{id}/config/restore: Restore agent config from a backup
+func (c *Client) RestoreAgentConfigBackup(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/agents/"+url.PathEscape(id)+"/config/restore", nil, body, out)
+}
+
+// RestoreLogArchive calls POST /api/archive/logs/{day}/restore: Loads the day into access_logs_restored
+func (c *Client) RestoreLogArchive(ctx context.Context, day string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/archive/logs/"+url.PathEscape(day)+"/restore", nil, nil, out)
+}
+
+// RestoreNginxConfigBackup calls POST /api/agents/{id}/nginx/restore
+func (c *Client) RestoreNginxConfigBackup(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/agents/"+url.PathEscape(id)+"/nginx/restore", nil, body, out)
+}
+
+// RevokeProjectAccess calls DELETE /api/teams/{id}/projects/{projectId}
+func (c *Client) RevokeProjectAccess(ctx context.Context, id string, projectId string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/teams/"+url.PathEscape(id)+"/projects/"+url.PathEscape(projectId), nil, nil, out)
+}
+
+// RolloutConfigSnippet calls POST /api/config-snippets/{id}/rollout: (superadmin)
+func (c *Client) RolloutConfigSnippet(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config-snippets/"+url.PathEscape(id)+"/rollout", nil, body, out)
+}
+
+// RotateStatusPageToken calls POST /api/status-pages/{id}/token: Issues a new access token, invalidating links that carry the old one
+func (c *Client) RotateStatusPageToken(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/status-pages/"+url.PathEscape(id)+"/token", nil, nil, out)
+}
+
+// RunScheduledTask calls POST /api/scheduled-tasks/{id}/run: Starts a run now, outside the schedule, and returns it while it executes
+func (c *Client) RunScheduledTask(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/scheduled-tasks/"+url.PathEscape(id)+"/run", nil, nil, out)
+}
+
+// RunSyntheticCheck calls POST /api/synthetic/checks/{id}/run: Executes the check once from this gateway and records the result
+func (c *Client) RunSyntheticCheck(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/synthetic/checks/"+url.PathEscape(id)+"/run", nil, nil, out)
+}
+
+// SaveEnvironmentRelease calls PUT /api/fleet/releases/environments/{id}: The channel or pinned version of an environment's agents
+func (c *Client) SaveEnvironmentRelease(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/fleet/releases/environments/"+url.PathEscape(id), nil, body, out)
+}
+
+// SaveReleaseChannel calls PUT /api/fleet/releases/channels/{name}: The channel's version and staged rollout
+func (c *Client) SaveReleaseChannel(ctx context.Context, name string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/fleet/releases/channels/"+url.PathEscape(name), nil, body, out)
+}
+
+// ScanServerTLS calls POST /api/servers/{agentId}/tls-posture/scan
+func (c *Client) ScanServerTLS(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(agentId)+"/tls-posture/scan", nil, nil, out)
+}
+
+// ScoreConfig calls POST /api/config/score: API Handler
+func (c *Client) ScoreConfig(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/config/score", nil, body, out)
+}
+
+// ServerDisks calls GET /api/servers/{agentId}/disks: The disk usage and nginx log growth the agent reported in the last 15 minutes
+func (c *Client) ServerDisks(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/disks", nil, nil, out)
+}
+
+// ServerLogsStream calls GET /api/servers/{agentId}/logs/stream: The logs of one agent as SSE, with the same parameters as the group stream
+func (c *Client) ServerLogsStream(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/logs/stream", nil, nil, out)
+}
+
+// ServerRealtimeStats calls GET /api/servers/{agentId}/realtime-stats: Returns sliding-window real-time stats for one server (agent)
+func (c *Client) ServerRealtimeStats(ctx context.Context, agentId string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(agentId)+"/realtime-stats", query, nil, out)
+}
+
+// ServiceMap calls GET /api/analytics/service-map: (or from/to in unix seconds) with optional agent_id (comma separated), project_id or environment_id
+func (c *Client) ServiceMap(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/analytics/service-map", query, nil, out)
+}
+
+// SetConfigTemplateVariables calls PUT /api/config-templates/{id}/variables
+func (c *Client) SetConfigTemplateVariables(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/config-templates/"+url.PathEscape(id)+"/variables", nil, body, out)
+}
+
+// SetMaintenance calls POST /api/maintenance/set: Enables, disables or schedules maintenance for a scope
+func (c *Client) SetMaintenance(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/maintenance/set", nil, body, out)
+}
+
+// StageConfig calls POST /api/staging/config
+func (c *Client) StageConfig(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/staging/config", nil, body, out)
+}
+
+// Status calls GET /api/auth/oidc/status: StatusHandler returns OIDC configuration status for the frontend
+func (c *Client) Status(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/auth/oidc/status", nil, nil, out)
+}
+
+// StreamTerminalPlayback calls GET /api/terminal-sessions/{id}/playback: Streams the recording as newline-delimited asciicast events, paced like the original session
+func (c *Client) StreamTerminalPlayback(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/terminal-sessions/"+url.PathEscape(id)+"/playback", query, nil, out)
+}
+
+// TenantBackfill calls POST /api/retention/tenant-backfill: Stamps the rows of every tenant-aware table ingested without a project and environment, such as those from before the columns existed, with the current assignment of their agent
+func (c *Client) TenantBackfill(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/retention/tenant-backfill", nil, nil, out)
+}
+
+// Terminal calls GET /terminal: WebSocket terminal connections
+func (c *Client) Terminal(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/terminal", query, nil, out)
+}
+
+// TestAgentConfigConnection calls POST /api/agents/{id}/config/test
+func (c *Client) TestAgentConfigConnection(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/agents/"+url.PathEscape(id)+"/config/test", nil, body, out)
+}
+
+// TestIntegration calls POST /api/integrations/{type}/test
+func (c *Client) TestIntegration(ctx context.Context, typeParam string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/integrations/"+url.PathEscape(typeParam)+"/test", nil, nil, out)
+}
+
+// TestLLM calls POST /api/llm/test
+func (c *Client) TestLLM(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/llm/test", nil, nil, out)
+}
+
+// TestLLMConnection calls POST /api/v1/admin/llm/test
+func (c *Client) TestLLMConnection(ctx context.Context, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/admin/llm/test", nil, nil, out)
+}
+
+// TestWebhook calls POST /api/webhooks/{id}/test: Queues a ping event for the endpoint
+func (c *Client) TestWebhook(ctx context.Context, id string, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/webhooks/"+url.PathEscape(id)+"/test", nil, nil, out)
+}
+
+// TrafficMirrorStats calls GET /api/traffic-mirrors/{id}/stats: (or from/to in unix seconds): the requests on the mirrored locations and how many were shadowed, over time
+func (c *Client) TrafficMirrorStats(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-mirrors/"+url.PathEscape(id)+"/stats", query, nil, out)
+}
+
+// TrafficSplitStats calls GET /api/traffic-splits/{id}/stats: (or from/to in unix seconds): requests, error rate and latency of each variant on the agents running the split
+func (c *Client) TrafficSplitStats(ctx context.Context, id string, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/traffic-splits/"+url.PathEscape(id)+"/stats", query, nil, out)
+}
+
+// UnassignServer calls DELETE /api/servers/{agentId}/assign
+func (c *Client) UnassignServer(ctx context.Context, agentId string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, "/api/servers/"+url.PathEscape(agentId)+"/assign", nil, nil, out)
+}
+
+// UpdateAgentRetentionPolicy calls PUT /api/environments/{id}/retention-policy
+func (c *Client) UpdateAgentRetentionPolicy(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/environments/"+url.PathEscape(id)+"/retention-policy", nil, body, out)
+}
+
+// UpdateAgentRuntimeConfig calls PATCH /api/agents/{id}/config
+func (c *Client) UpdateAgentRuntimeConfig(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPatch, "/api/agents/"+url.PathEscape(id)+"/config", nil, body, out)
+}
+
+// UpdateAgentRuntimeSettings calls PUT /api/servers/{agentId}/runtime-settings: With {"settings": {"LOG_LEVEL": "debug", ...}}
+func (c *Client) UpdateAgentRuntimeSettings(ctx context.Context, agentId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/servers/"+url.PathEscape(agentId)+"/runtime-settings", nil, body, out)
+}
+
+// UpdateConfigSnippet calls PUT /api/config-snippets/{id}: (superadmin)
+func (c *Client) UpdateConfigSnippet(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/config-snippets/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateConfigTemplate calls PUT /api/config-templates/{id}
+func (c *Client) UpdateConfigTemplate(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/config-templates/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateDashboard calls PUT /api/dashboards/{id}: Updates name, description, team, sharing and default window
+func (c *Client) UpdateDashboard(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/dashboards/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateEnvironment calls PUT /api/environments/{id}
+func (c *Client) UpdateEnvironment(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/environments/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateGeoPolicy calls PUT /api/geo-policies/{id}: Changes the mode, countries, status code or selector and writes the policy to its agents at once
+func (c *Client) UpdateGeoPolicy(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/geo-policies/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateMaintenanceTemplate calls PUT /api/maintenance/templates: Updates an existing maintenance template
+func (c *Client) UpdateMaintenanceTemplate(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/maintenance/templates", nil, body, out)
+}
+
+// UpdateProject calls PUT /api/projects/{id}
+func (c *Client) UpdateProject(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/projects/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateRateLimitRule calls PUT /api/security/rate-limits/{id}
+func (c *Client) UpdateRateLimitRule(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/security/rate-limits/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateScheduledTask calls PUT /api/scheduled-tasks/{id}
+func (c *Client) UpdateScheduledTask(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/scheduled-tasks/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateServerTags calls PUT /api/servers/{agentId}/tags
+func (c *Client) UpdateServerTags(ctx context.Context, agentId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/servers/"+url.PathEscape(agentId)+"/tags", nil, body, out)
+}
+
+// UpdateStatusPage calls PUT /api/status-pages/{id}
+func (c *Client) UpdateStatusPage(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/status-pages/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateSyntheticCheck calls PUT /api/synthetic/checks/{id}
+func (c *Client) UpdateSyntheticCheck(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/synthetic/checks/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateTableRetention calls PUT /api/retention/tables/{table}
+func (c *Client) UpdateTableRetention(ctx context.Context, table string, query url.Values, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/retention/tables/"+url.PathEscape(table), query, body, out)
+}
+
+// UpdateTeam calls PUT /api/teams/{id}
+func (c *Client) UpdateTeam(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/teams/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateTrafficMirror calls PUT /api/traffic-mirrors/{id}: Changes the target, sampling, locations or selector and writes an enabled mirror to its agents at once
+func (c *Client) UpdateTrafficMirror(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/traffic-mirrors/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateTrafficSplit calls PUT /api/traffic-splits/{id}: Changes the weights, variants, key or selector and writes the split to its agents at once
+func (c *Client) UpdateTrafficSplit(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/traffic-splits/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateUpstreamServer calls POST /api/servers/{agentId}/upstreams/{name}/servers
+func (c *Client) UpdateUpstreamServer(ctx context.Context, agentId string, name string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(agentId)+"/upstreams/"+url.PathEscape(name)+"/servers", nil, body, out)
+}
+
+// UpdateWAFPolicy calls PUT /api/waf/policies/{id}
+func (c *Client) UpdateWAFPolicy(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/waf/policies/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpdateWebhook calls PUT /api/webhooks/{id}: The new secret is returned when rotated
+func (c *Client) UpdateWebhook(ctx context.Context, id string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, "/api/webhooks/"+url.PathEscape(id), nil, body, out)
+}
+
+// UpgradeNginxBinary calls POST /api/servers/{agentId}/nginx/upgrade: Replaces the running NGINX binary without dropping connections (USR2, WINCH, QUIT), rolling back when a step fails
+func (c *Client) UpgradeNginxBinary(ctx context.Context, agentId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(agentId)+"/nginx/upgrade", nil, body, out)
+}
+
+// UploadCertificate calls POST /api/servers/{agentId}/certificates: Certificate upload and proxies to the agent
+func (c *Client) UploadCertificate(ctx context.Context, agentId string, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(agentId)+"/certificates", nil, body, out)
+}
+
+// UploadFleetCertificate calls POST /api/certificates: Stores a certificate in the inventory and deploys it to the selected agents
+func (c *Client) UploadFleetCertificate(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/certificates", nil, body, out)
+}
+
+// UpsertSLOTarget calls POST /api/slo-targets
+func (c *Client) UpsertSLOTarget(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/slo-targets", nil, body, out)
+}
+
+// ValidateEnrollmentToken calls POST /api/enrollment-tokens/validate: This is used by agents to validate a token during registration
+func (c *Client) ValidateEnrollmentToken(ctx context.Context, body interface{}, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, "/api/enrollment-tokens/validate", nil, body, out)
+}
+
+// VisitorAnalytics calls GET /api/visitor-analytics
+func (c *Client) VisitorAnalytics(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/visitor-analytics", query, nil, out)
+}
+
+// Vulnerabilities calls GET /api/security/vulnerabilities: The agents the caller can see whose nginx, OpenSSL or kernel version is affected by an advisory of the CVE feed
+func (c *Client) Vulnerabilities(ctx context.Context, query url.Values, out interface{}) error {
+	return c.Do(ctx, http.MethodGet, "/api/security/vulnerabilities", query, nil, out)
+}
@@ -46,22 +46,55 @@ mkdir -p "$DIST_DIR"
 # Create version.json (binaries under .../bin/; SERVER_URL = gateway updates base e.g. http://gateway:5021/updates)
 echo -e "${BLUE}📝 Generating manifest...${NC}"
 
-cat <<EOF > "$DIST_DIR/version.json"
+# Optional: sign each binary with an ed25519 release key (PEM private key) so agents configured
+# with UPDATE_PUBLIC_KEY can verify the package. Generate a key pair with:
+#   openssl genpkey -algorithm ed25519 -out release.pem
+#   openssl pkey -in release.pem -pubout -outform DER | base64   # UPDATE_PUBLIC_KEY for agents
+SIGNING_KEY="${UPDATE_SIGNING_KEY:-}"
+sign_binary() {
+    local platform="$1"
+    local sha
+    if [ -z "$SIGNING_KEY" ]; then
+        return
+    fi
+    sha=$(awk '{print $1}' "$BIN_DIR/agent-${platform}.sha256")
+    printf 'avika-agent %s %s %s' "$VERSION" "$platform" "$sha" > "$DIST_DIR/.sign-msg"
+    openssl pkeyutl -sign -inkey "$SIGNING_KEY" -rawin -in "$DIST_DIR/.sign-msg" | base64 -w0
+    rm -f "$DIST_DIR/.sign-msg"
+}
+if [ -n "$SIGNING_KEY" ]; then
+    echo -e "${BLUE}🔏 Signing binaries with ${SIGNING_KEY}...${NC}"
+fi
+
+# write_manifest <file> <binaries base URL>
+write_manifest() {
+    cat <<EOF > "$1"
 {
   "version": "${VERSION}",
   "release_date": "$(date -u +%Y-%m-%dT%H:%M:%SZ)",
   "binaries": {
     "linux-amd64": {
-      "url": "${SERVER_URL}/bin/agent-linux-amd64",
-      "sha256": "$(cat $BIN_DIR/agent-linux-amd64.sha256)"
+      "url": "$2/agent-linux-amd64",
+      "sha256": "$(awk '{print $1}' $BIN_DIR/agent-linux-amd64.sha256)",
+      "signature": "$(sign_binary linux-amd64)"
     },
     "linux-arm64": {
-      "url": "${SERVER_URL}/bin/agent-linux-arm64",
-      "sha256": "$(cat $BIN_DIR/agent-linux-arm64.sha256)"
+      "url": "$2/agent-linux-arm64",
+      "sha256": "$(awk '{print $1}' $BIN_DIR/agent-linux-arm64.sha256)",
+      "signature": "$(sign_binary linux-arm64)"
     }
   }
 }
 EOF
+}
+write_manifest "$DIST_DIR/version.json" "${SERVER_URL}/bin"
+
+# Keep a copy under releases/<version>/ so the gateway can still serve this version to release
+# channels and pinned environments once a newer one is served at the root
+RELEASE_DIR="$DIST_DIR/releases/${VERSION}"
+mkdir -p "$RELEASE_DIR/bin"
+cp "$BIN_DIR/agent-linux-amd64" "$BIN_DIR/agent-linux-arm64" "$RELEASE_DIR/bin/"
+write_manifest "$RELEASE_DIR/version.json" "${SERVER_URL}/releases/${VERSION}/bin"
 
 # Copy systemd service file
 echo "📦 Copying systemd service..."
@@ -85,6 +118,7 @@ chmod +x "$DIST_DIR/deploy-agent.sh"
 echo ""
 echo -e "${GREEN}✅ Local release prepared in ./${DIST_DIR}${NC}"
 echo -e "  - Manifest: ./${DIST_DIR}/version.json"
+echo -e "  - Release: ./${DIST_DIR}/releases/${VERSION}/ (copy into the gateway's updates/releases/)"
 echo -e "  - Binaries: ./${BIN_DIR}/"
 echo -e "  - Service: ./${DIST_DIR}/avika-agent.service"
 echo -e "  - Deployment: ./${DIST_DIR}/deploy-agent.sh"
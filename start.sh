@@ -13,10 +13,6 @@ cd ../..
 echo "⏳ Waiting for services to be ready..."
 sleep 5
 
-# Initialize ClickHouse schema
-echo "🗄️  Initializing ClickHouse schema..."
-docker exec clickhouse clickhouse-client --database=nginx_analytics --multiquery < deploy/docker/clickhouse-schema.sql 2>/dev/null || echo "Schema already exists"
-
 # Start Frontend
 echo "🌐 Starting Frontend..."
 cd frontend